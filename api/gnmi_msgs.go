@@ -21,6 +21,7 @@ import (
 	"github.com/openconfig/gnmi/proto/gnmi_ext"
 	gvalue "github.com/openconfig/gnmi/value"
 	"github.com/openconfig/gnmic/utils"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -361,6 +362,80 @@ func Extension_HistoryRange(start, end string) func(msg proto.Message) error {
 	}
 }
 
+// depthExtensionNumber is the field number of the Depth extension in the gnmi_ext.Extension oneof.
+const depthExtensionNumber protowire.Number = 5
+
+// Extension_Depth creates a GNMIOption that adds a gNMI extension of
+// type Depth with the supplied level to a GetRequest or a SubscribeRequest.
+// The Depth extension is not defined in the gnmi_ext version this package depends on,
+// it is encoded as an unknown field of the gnmi_ext.Extension message,
+// which is wire compatible with the gnmi_ext.Depth message.
+func Extension_Depth(level uint32) func(msg proto.Message) error {
+	return func(msg proto.Message) error {
+		if msg == nil {
+			return ErrInvalidMsgType
+		}
+		switch msg := msg.ProtoReflect().Interface().(type) {
+		case *gnmi.GetRequest, *gnmi.SubscribeRequest:
+			// Depth message: uint32 level = 1;
+			depth := protowire.AppendTag(nil, 1, protowire.VarintType)
+			depth = protowire.AppendVarint(depth, uint64(level))
+
+			b := protowire.AppendTag(nil, depthExtensionNumber, protowire.BytesType)
+			b = protowire.AppendBytes(b, depth)
+			ext := new(gnmi_ext.Extension)
+			ext.ProtoReflect().SetUnknown(b)
+			return Extension(ext)(msg)
+		default:
+			return fmt.Errorf("option Extension_Depth: %w: %T", ErrInvalidMsgType, msg)
+		}
+	}
+}
+
+// ExtensionDepthLevel returns the level of a Depth extension
+// and false if ext is not a Depth extension.
+func ExtensionDepthLevel(ext *gnmi_ext.Extension) (uint32, bool) {
+	b := ext.ProtoReflect().GetUnknown()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return 0, false
+		}
+		b = b[n:]
+		if num != depthExtensionNumber || typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, false
+			}
+			b = b[n:]
+			continue
+		}
+		depth, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return 0, false
+		}
+		var level uint64
+		for len(depth) > 0 {
+			dnum, dtyp, dn := protowire.ConsumeTag(depth)
+			if dn < 0 {
+				return 0, false
+			}
+			depth = depth[dn:]
+			if dnum == 1 && dtyp == protowire.VarintType {
+				level, dn = protowire.ConsumeVarint(depth)
+			} else {
+				dn = protowire.ConsumeFieldValue(dnum, dtyp, depth)
+			}
+			if dn < 0 {
+				return 0, false
+			}
+			depth = depth[dn:]
+		}
+		return uint32(level), true
+	}
+	return 0, false
+}
+
 // Prefix creates a GNMIOption that creates a *gnmi.Path and adds it to the supplied
 // proto.Message (as a Path Prefix).
 // The proto.Message can be a *gnmi.GetRequest, *gnmi.SetRequest or a *gnmi.SubscribeRequest with RequestType Subscribe.
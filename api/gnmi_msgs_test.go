@@ -9,6 +9,7 @@
 package api
 
 import (
+	"bytes"
 	"errors"
 	"reflect"
 	"strings"
@@ -18,6 +19,7 @@ import (
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmi/proto/gnmi_ext"
 	"github.com/openconfig/gnmic/testutils"
+	"google.golang.org/protobuf/proto"
 )
 
 //  Capabilities Request / Response tests
@@ -1757,3 +1759,42 @@ func TestSupportedModel(t *testing.T) {
 		}
 	})
 }
+
+func TestExtensionDepth(t *testing.T) {
+	req, err := NewGetRequest(Path("/interfaces"), Extension_Depth(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req.GetExtension()) != 1 {
+		t.Fatalf("expected 1 extension, got %d", len(req.GetExtension()))
+	}
+	// gnmi_ext.Extension{depth: Depth{level: 2}} wire encoding
+	b, err := proto.Marshal(req.GetExtension()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x2a, 0x02, 0x08, 0x02}; !bytes.Equal(b, want) {
+		t.Errorf("unexpected depth extension encoding: got %x, want %x", b, want)
+	}
+	// survives a marshal/unmarshal round trip
+	b, err = proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nreq := new(gnmi.GetRequest)
+	err = proto.Unmarshal(b, nreq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	level, ok := ExtensionDepthLevel(nreq.GetExtension()[0])
+	if !ok || level != 2 {
+		t.Errorf("expected depth level 2, got %d, %v", level, ok)
+	}
+	if _, ok = ExtensionDepthLevel(&gnmi_ext.Extension{Ext: &gnmi_ext.Extension_History{}}); ok {
+		t.Errorf("history extension reported as a depth extension")
+	}
+	_, err = NewSetRequest(Extension_Depth(1))
+	if !errors.Is(err, ErrInvalidMsgType) {
+		t.Errorf("expected ErrInvalidMsgType, got %v", err)
+	}
+}
@@ -0,0 +1,440 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/proto/admin"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// adminServer implements the admin gRPC service,
+// its RPCs mirror the REST API endpoints.
+type adminServer struct {
+	admin.UnimplementedAdminServer
+	a *App
+}
+
+func (a *App) startAdminServer() {
+	if a.Config.APIServer == nil || a.Config.APIServer.GRPCAddress == "" {
+		return
+	}
+	opts := make([]grpc.ServerOption, 0, 3)
+	tlscfg, err := a.serverTLSConfig(
+		a.Config.APIServer.CaFile,
+		a.Config.APIServer.CertFile,
+		a.Config.APIServer.KeyFile,
+		a.Config.APIServer.SkipVerify,
+		a.Config.APIServer.ACME,
+		a.Config.APIServer.SpiffeIDs,
+		a.Config.APIServer.TLSPolicy)
+	if err != nil {
+		a.Logger.Printf("failed to create the admin gRPC server TLS config: %v", err)
+		return
+	}
+	if tlscfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlscfg)))
+	}
+	unary := make([]grpc.UnaryServerInterceptor, 0, 2)
+	if a.apiAuth != nil {
+		unary = append(unary, a.authUnaryInterceptor)
+		opts = append(opts, grpc.StreamInterceptor(a.authStreamInterceptor))
+	}
+	// the calls rejected by the authentication are not recorded,
+	// as for the REST API.
+	unary = append(unary, a.auditUnaryInterceptor)
+	opts = append(opts, grpc.ChainUnaryInterceptor(unary...))
+	l, err := utils.Listen(a.Config.APIServer.GRPCAddress, a.Config.APIServer.SocketPermissions)
+	if err != nil {
+		a.Logger.Printf("failed to start admin gRPC server listener: %v", err)
+		return
+	}
+	s := grpc.NewServer(opts...)
+	admin.RegisterAdminServer(s, &adminServer{a: a})
+	go func() {
+		err := s.Serve(l)
+		if err != nil {
+			a.Logger.Printf("admin gRPC server err: %v", err)
+		}
+	}()
+	go func() {
+		<-a.ctx.Done()
+		s.Stop()
+	}()
+}
+
+func (s *adminServer) ListTargets(ctx context.Context, req *admin.ListTargetsRequest) (*admin.ListTargetsResponse, error) {
+	s.a.configLock.RLock()
+	tcs := make([]*types.TargetConfig, 0, len(s.a.Config.Targets))
+	for n, tc := range s.a.Config.Targets {
+		if req.GetName() == "" || n == req.GetName() {
+			tcs = append(tcs, tc)
+		}
+	}
+	s.a.configLock.RUnlock()
+	if req.GetName() != "" && len(tcs) == 0 {
+		return nil, status.Errorf(codes.NotFound, "target %q not found", req.GetName())
+	}
+	sort.Slice(tcs, func(i, j int) bool {
+		return tcs[i].Name < tcs[j].Name
+	})
+	rsp := &admin.ListTargetsResponse{Targets: make([]*admin.Target, 0, len(tcs))}
+	s.a.operLock.RLock()
+	defer s.a.operLock.RUnlock()
+	for _, tc := range tcs {
+		at := &admin.Target{Config: targetConfigToProto(tc)}
+		if t, ok := s.a.Targets[tc.Name]; ok {
+			for sn := range t.Subscriptions {
+				at.Subscriptions = append(at.Subscriptions, sn)
+			}
+			sort.Strings(at.Subscriptions)
+			at.State = t.Health().State
+			at.LastError = t.LastError()
+		}
+		rsp.Targets = append(rsp.Targets, at)
+	}
+	return rsp, nil
+}
+
+func (s *adminServer) AddTarget(ctx context.Context, req *admin.AddTargetRequest) (*admin.AddTargetResponse, error) {
+	if req.GetTarget().GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing target name")
+	}
+	if s.a.targetConfigExists(req.GetTarget().GetName()) {
+		return nil, status.Errorf(codes.AlreadyExists, "target %q already exists", req.GetTarget().GetName())
+	}
+	tc := targetConfigFromProto(req.GetTarget())
+	err := s.a.Config.SetTargetConfigDefaults(tc)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	s.a.AddTargetConfig(tc)
+	if req.GetStart() {
+		go s.a.TargetSubscribeStream(s.a.ctx, tc)
+	}
+	return &admin.AddTargetResponse{}, nil
+}
+
+func (s *adminServer) DeleteTarget(ctx context.Context, req *admin.DeleteTargetRequest) (*admin.DeleteTargetResponse, error) {
+	if !s.a.targetConfigExists(req.GetName()) {
+		return nil, status.Errorf(codes.NotFound, "target %q not found", req.GetName())
+	}
+	err := s.a.DeleteTarget(ctx, req.GetName())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &admin.DeleteTargetResponse{}, nil
+}
+
+func (s *adminServer) StartTarget(ctx context.Context, req *admin.StartTargetRequest) (*admin.StartTargetResponse, error) {
+	s.a.configLock.RLock()
+	tc, ok := s.a.Config.Targets[req.GetName()]
+	s.a.configLock.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "target %q not found", req.GetName())
+	}
+	go s.a.TargetSubscribeStream(s.a.ctx, tc)
+	return &admin.StartTargetResponse{}, nil
+}
+
+func (s *adminServer) ListSubscriptions(ctx context.Context, req *admin.ListSubscriptionsRequest) (*admin.ListSubscriptionsResponse, error) {
+	s.a.configLock.RLock()
+	subs := make([]*types.SubscriptionConfig, 0, len(s.a.Config.Subscriptions))
+	for n, sc := range s.a.Config.Subscriptions {
+		if req.GetName() == "" || n == req.GetName() {
+			subs = append(subs, sc)
+		}
+	}
+	s.a.configLock.RUnlock()
+	if req.GetName() != "" && len(subs) == 0 {
+		return nil, status.Errorf(codes.NotFound, "subscription %q not found", req.GetName())
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].Name < subs[j].Name
+	})
+	rsp := &admin.ListSubscriptionsResponse{Subscriptions: make([]*admin.Subscription, 0, len(subs))}
+	for _, sc := range subs {
+		sr := s.a.subscriptionResponse(sc, nil)
+		rsp.Subscriptions = append(rsp.Subscriptions, &admin.Subscription{
+			Config:  subscriptionConfigToProto(sc),
+			Targets: sr.Targets,
+		})
+	}
+	return rsp, nil
+}
+
+func (s *adminServer) AddSubscription(ctx context.Context, req *admin.AddSubscriptionRequest) (*admin.AddSubscriptionResponse, error) {
+	sc := subscriptionConfigFromProto(req.GetSubscription())
+	if s.a.subscriptionConfigExists(sc.Name) {
+		return nil, status.Errorf(codes.AlreadyExists, "subscription %q already exists", sc.Name)
+	}
+	err := s.a.AddSubscriptionConfig(sc)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &admin.AddSubscriptionResponse{}, nil
+}
+
+func (s *adminServer) UpdateSubscription(ctx context.Context, req *admin.UpdateSubscriptionRequest) (*admin.UpdateSubscriptionResponse, error) {
+	sc := subscriptionConfigFromProto(req.GetSubscription())
+	if !s.a.subscriptionConfigExists(sc.Name) {
+		return nil, status.Errorf(codes.NotFound, "subscription %q not found", sc.Name)
+	}
+	err := s.a.UpdateSubscriptionConfig(sc)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &admin.UpdateSubscriptionResponse{}, nil
+}
+
+func (s *adminServer) DeleteSubscription(ctx context.Context, req *admin.DeleteSubscriptionRequest) (*admin.DeleteSubscriptionResponse, error) {
+	err := s.a.DeleteSubscriptionConfig(req.GetName())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &admin.DeleteSubscriptionResponse{}, nil
+}
+
+func (s *adminServer) GetCluster(ctx context.Context, req *admin.GetClusterRequest) (*admin.GetClusterResponse, error) {
+	if s.a.Config.Clustering == nil {
+		return nil, status.Error(codes.FailedPrecondition, "clustering is not enabled")
+	}
+	cs, err := s.a.clusteringState(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	rsp := &admin.GetClusterResponse{
+		Name:                  cs.ClusterName,
+		Leader:                cs.Leader,
+		NumberOfLockedTargets: uint64(cs.NumberOfLockedTargets),
+		Members:               make([]*admin.ClusterMember, 0, len(cs.Members)),
+	}
+	for _, m := range cs.Members {
+		rsp.Members = append(rsp.Members, &admin.ClusterMember{
+			Name:          m.Name,
+			ApiEndpoint:   m.APIEndpoint,
+			IsLeader:      m.IsLeader,
+			LockedTargets: m.LockedTargets,
+		})
+	}
+	return rsp, nil
+}
+
+func (s *adminServer) StreamEvents(req *admin.StreamEventsRequest, stream admin.Admin_StreamEventsServer) error {
+	c := &eventStreamClient{
+		target:       req.GetTarget(),
+		subscription: req.GetSubscription(),
+		ch:           make(chan *formatters.EventMsg, eventStreamBufferSize),
+	}
+	if req.GetExpression() != "" {
+		var err error
+		c.prg, err = formatters.CompileExpression(req.GetExpression())
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	s.a.streams.add(c)
+	defer s.a.streams.remove(c)
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.a.ctx.Done():
+			return nil
+		case ev := <-c.ch:
+			aev, err := eventToProto(ev)
+			if err != nil {
+				s.a.Logger.Printf("admin stream: failed to convert event: %v", err)
+				continue
+			}
+			err = stream.Send(aev)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func targetConfigFromProto(t *admin.TargetConfig) *types.TargetConfig {
+	tc := &types.TargetConfig{
+		Name:          t.GetName(),
+		Address:       t.GetAddress(),
+		Subscriptions: t.GetSubscriptions(),
+		Outputs:       t.GetOutputs(),
+		Profiles:      t.GetProfiles(),
+		EventTags:     t.GetEventTags(),
+		Vars:          t.GetVars(),
+		Namespace:     t.GetNamespace(),
+	}
+	if t.GetUsername() != "" {
+		tc.Username = stringPtr(t.GetUsername())
+	}
+	if t.GetPassword() != "" {
+		tc.Password = stringPtr(t.GetPassword())
+	}
+	if t.GetTimeout() != nil {
+		tc.Timeout = t.GetTimeout().AsDuration()
+	}
+	if t.GetInsecure() {
+		tc.Insecure = boolPtr(true)
+	}
+	if t.GetSkipVerify() {
+		tc.SkipVerify = boolPtr(true)
+	}
+	if t.GetTlsCa() != "" {
+		tc.TLSCA = stringPtr(t.GetTlsCa())
+	}
+	if t.GetTlsCert() != "" {
+		tc.TLSCert = stringPtr(t.GetTlsCert())
+	}
+	if t.GetTlsKey() != "" {
+		tc.TLSKey = stringPtr(t.GetTlsKey())
+	}
+	return tc
+}
+
+func targetConfigToProto(tc *types.TargetConfig) *admin.TargetConfig {
+	t := &admin.TargetConfig{
+		Name:          tc.Name,
+		Address:       tc.Address,
+		Timeout:       durationpb.New(tc.Timeout),
+		Subscriptions: tc.Subscriptions,
+		Outputs:       tc.Outputs,
+		Profiles:      tc.Profiles,
+		EventTags:     tc.EventTags,
+		Vars:          tc.Vars,
+		Namespace:     tc.Namespace,
+	}
+	if tc.Username != nil {
+		t.Username = *tc.Username
+	}
+	if tc.Password != nil {
+		t.Password = *tc.Password
+	}
+	if tc.Insecure != nil {
+		t.Insecure = *tc.Insecure
+	}
+	if tc.SkipVerify != nil {
+		t.SkipVerify = *tc.SkipVerify
+	}
+	if tc.TLSCA != nil {
+		t.TlsCa = *tc.TLSCA
+	}
+	if tc.TLSCert != nil {
+		t.TlsCert = *tc.TLSCert
+	}
+	if tc.TLSKey != nil {
+		t.TlsKey = *tc.TLSKey
+	}
+	return t
+}
+
+func subscriptionConfigFromProto(s *admin.SubscriptionConfig) *types.SubscriptionConfig {
+	sc := &types.SubscriptionConfig{
+		Name:              s.GetName(),
+		Models:            s.GetModels(),
+		Prefix:            s.GetPrefix(),
+		Target:            s.GetTarget(),
+		SetTarget:         s.GetSetTarget(),
+		Paths:             s.GetPaths(),
+		Mode:              s.GetMode(),
+		StreamMode:        s.GetStreamMode(),
+		Encoding:          s.GetEncoding(),
+		SuppressRedundant: s.GetSuppressRedundant(),
+		UpdatesOnly:       s.GetUpdatesOnly(),
+		Depth:             s.GetDepth(),
+	}
+	if s.GetSampleInterval() != nil {
+		d := s.GetSampleInterval().AsDuration()
+		sc.SampleInterval = &d
+	}
+	if s.GetHeartbeatInterval() != nil {
+		d := s.GetHeartbeatInterval().AsDuration()
+		sc.HeartbeatInterval = &d
+	}
+	return sc
+}
+
+func subscriptionConfigToProto(sc *types.SubscriptionConfig) *admin.SubscriptionConfig {
+	s := &admin.SubscriptionConfig{
+		Name:              sc.Name,
+		Models:            sc.Models,
+		Prefix:            sc.Prefix,
+		Target:            sc.Target,
+		SetTarget:         sc.SetTarget,
+		Paths:             sc.Paths,
+		Mode:              sc.Mode,
+		StreamMode:        sc.StreamMode,
+		Encoding:          sc.Encoding,
+		SuppressRedundant: sc.SuppressRedundant,
+		UpdatesOnly:       sc.UpdatesOnly,
+		Depth:             sc.Depth,
+	}
+	if sc.SampleInterval != nil {
+		s.SampleInterval = durationpb.New(*sc.SampleInterval)
+	}
+	if sc.HeartbeatInterval != nil {
+		s.HeartbeatInterval = durationpb.New(*sc.HeartbeatInterval)
+	}
+	return s
+}
+
+func eventToProto(ev *formatters.EventMsg) (*admin.Event, error) {
+	aev := &admin.Event{
+		Name:      ev.Name,
+		Timestamp: ev.Timestamp,
+		Tags:      ev.Tags,
+		Values:    make(map[string]*structpb.Value, len(ev.Values)),
+		Deletes:   ev.Deletes,
+	}
+	for k, v := range ev.Values {
+		pv, err := structpb.NewValue(protoValue(v))
+		if err != nil {
+			return nil, err
+		}
+		aev.Values[k] = pv
+	}
+	return aev, nil
+}
+
+// protoValue converts the event values types not handled by structpb.NewValue.
+func protoValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case uint8:
+		return uint32(v)
+	case uint16:
+		return uint32(v)
+	case int8:
+		return int32(v)
+	case int16:
+		return int32(v)
+	case float32:
+		return float64(v)
+	case time.Duration:
+		return v.String()
+	case []byte:
+		return string(v)
+	}
+	return v
+}
+
+func stringPtr(s string) *string { return &s }
+
+func boolPtr(b bool) *bool { return &b }
@@ -0,0 +1,148 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/proto/admin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func newAdminTestClient(t *testing.T, a *App, opts ...grpc.ServerOption) admin.AdminClient {
+	l := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer(opts...)
+	admin.RegisterAdminServer(s, &adminServer{a: a})
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return admin.NewAdminClient(conn)
+}
+
+func TestAdminServer(t *testing.T) {
+	a := New()
+	c := newAdminTestClient(t, a)
+	ctx := context.Background()
+
+	_, err := c.AddSubscription(ctx, &admin.AddSubscriptionRequest{
+		Subscription: &admin.SubscriptionConfig{
+			Name:           "sub1",
+			Paths:          []string{"/interface"},
+			StreamMode:     "sample",
+			SampleInterval: durationpb.New(10 * time.Second),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.AddSubscription(ctx, &admin.AddSubscriptionRequest{
+		Subscription: &admin.SubscriptionConfig{Name: "sub1", Paths: []string{"/interface"}},
+	})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("expected AlreadyExists, got %v", err)
+	}
+	subs, err := c.ListSubscriptions(ctx, &admin.ListSubscriptionsRequest{Name: "sub1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs.GetSubscriptions()) != 1 ||
+		subs.GetSubscriptions()[0].GetConfig().GetSampleInterval().AsDuration() != 10*time.Second {
+		t.Errorf("unexpected subscriptions: %v", subs)
+	}
+	_, err = c.UpdateSubscription(ctx, &admin.UpdateSubscriptionRequest{
+		Subscription: &admin.SubscriptionConfig{Name: "sub2", Paths: []string{"/interface"}},
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+
+	_, err = c.AddTarget(ctx, &admin.AddTargetRequest{
+		Target: &admin.TargetConfig{
+			Name:     "router1",
+			Address:  "10.0.0.1:57400",
+			Username: "admin",
+			Insecure: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets, err := c.ListTargets(ctx, &admin.ListTargetsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets.GetTargets()) != 1 || targets.GetTargets()[0].GetConfig().GetUsername() != "admin" ||
+		!targets.GetTargets()[0].GetConfig().GetInsecure() {
+		t.Errorf("unexpected targets: %v", targets)
+	}
+	_, err = c.DeleteTarget(ctx, &admin.DeleteTargetRequest{Name: "router2"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+	_, err = c.DeleteSubscription(ctx, &admin.DeleteSubscriptionRequest{Name: "sub1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetCluster(ctx, &admin.GetClusterRequest{})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition, got %v", err)
+	}
+}
+
+func TestAdminServerStreamEvents(t *testing.T) {
+	a := New()
+	c := newAdminTestClient(t, a)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := c.StreamEvents(ctx, &admin.StreamEventsRequest{Target: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// wait for the client registration
+	for i := 0; ; i++ {
+		a.streams.m.RLock()
+		n := len(a.streams.clients)
+		a.streams.m.RUnlock()
+		if n > 0 {
+			break
+		}
+		if i == 100 {
+			t.Fatal("stream client not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	a.streams.publish(streamTestResponse(1), outputs.Meta{"source": "t2", "subscription-name": "sub1"})
+	a.streams.publish(streamTestResponse(2), outputs.Meta{"source": "t1", "subscription-name": "sub1"})
+	ev, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.GetTags()["source"] != "t1" || ev.GetValues()["/counter"].GetNumberValue() != 2 {
+		t.Errorf("unexpected event: %v", ev)
+	}
+}
@@ -14,24 +14,31 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/target"
 	"github.com/openconfig/gnmic/types"
-	"github.com/openconfig/gnmic/utils"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/protobuf/proto"
 )
 
 func (a *App) newAPIServer() (*http.Server, error) {
 	a.routes()
-	tlscfg, err := utils.NewTLSConfig(
+	tlscfg, err := a.serverTLSConfig(
 		a.Config.APIServer.CaFile,
 		a.Config.APIServer.CertFile,
 		a.Config.APIServer.KeyFile,
 		a.Config.APIServer.SkipVerify,
-		true)
+		a.Config.APIServer.ACME,
+		a.Config.APIServer.SpiffeIDs,
+		a.Config.APIServer.TLSPolicy)
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +47,20 @@ func (a *App) newAPIServer() (*http.Server, error) {
 		a.reg.MustRegister(collectors.NewGoCollector())
 		a.reg.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 		a.reg.MustRegister(subscribeResponseReceivedCounter)
+		for _, c := range formatters.Metrics() {
+			if err := a.reg.Register(c); err != nil {
+				a.Logger.Printf("failed to register metric: %v", err)
+			}
+		}
 		go a.startClusterMetrics()
+		go a.startTargetsMetrics()
+		go a.startRuntimeMetrics()
+	}
+	if a.Config.APIServer.EnableUI {
+		a.uiRoutes()
+	}
+	if a.Config.APIServer.EnableProfiling {
+		a.debugRoutes()
 	}
 	s := &http.Server{
 		Addr:         a.Config.APIServer.Address,
@@ -63,23 +83,24 @@ type APIErrors struct {
 func (a *App) handleConfigTargetsGet(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
-	var err error
+	scope := apiScopeFromContext(r.Context())
 	a.configLock.RLock()
 	defer a.configLock.RUnlock()
 	if id == "" {
-		err = json.NewEncoder(w).Encode(a.Config.Targets)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		tcs := a.Config.Targets
+		if scope != nil {
+			tcs = make(map[string]*types.TargetConfig)
+			for n, tc := range a.Config.Targets {
+				if scope.allows(tc.Namespace) {
+					tcs[n] = tc
+				}
+			}
 		}
+		a.writeRedacted(w, tcs)
 		return
 	}
-	if t, ok := a.Config.Targets[id]; ok {
-		err = json.NewEncoder(w).Encode(t)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
-		}
+	if t, ok := a.Config.Targets[id]; ok && scope.allows(t.Namespace) {
+		a.writeRedacted(w, t)
 		return
 	}
 	w.WriteHeader(http.StatusNotFound)
@@ -101,6 +122,11 @@ func (a *App) handleConfigTargetsPost(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
 		return
 	}
+	if !a.targetConfigInScope(r, tc.Name) || !apiScopeFromContext(r.Context()).allows(tc.Namespace) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("namespace %q not allowed", tc.Namespace)}})
+		return
+	}
 	// if _, ok := a.Config.Targets[tc.Name]; ok {
 	// 	w.WriteHeader(http.StatusBadRequest)
 	// 	json.NewEncoder(w).Encode(APIErrors{Errors: []string{"target config already exists"}})
@@ -113,6 +139,11 @@ func (a *App) handleConfigTargetsPost(w http.ResponseWriter, r *http.Request) {
 func (a *App) handleConfigTargetsDelete(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
+	if !a.targetConfigInScope(r, id) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q not found", id)}})
+		return
+	}
 	err := a.DeleteTarget(r.Context(), id)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
@@ -121,8 +152,37 @@ func (a *App) handleConfigTargetsDelete(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+func (a *App) handleConfigReload(w http.ResponseWriter, r *http.Request) {
+	rr, err := a.ReloadConfig(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	b, err := json.Marshal(rr)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	w.Write(b)
+}
+
 func (a *App) handleConfigSubscriptions(w http.ResponseWriter, r *http.Request) {
-	a.handlerCommonGet(w, r, a.Config.Subscriptions)
+	scope := apiScopeFromContext(r.Context())
+	if scope == nil {
+		a.handlerCommonGet(w, r, a.Config.Subscriptions)
+		return
+	}
+	subs := make(map[string]*types.SubscriptionConfig)
+	a.configLock.RLock()
+	for n, sc := range a.Config.Subscriptions {
+		if subscriptionInScope(scope, sc) {
+			subs[n] = sc
+		}
+	}
+	a.configLock.RUnlock()
+	a.handlerCommonGet(w, r, subs)
 }
 
 func (a *App) handleConfigOutputs(w http.ResponseWriter, r *http.Request) {
@@ -149,6 +209,32 @@ func (a *App) handleConfigProcessors(w http.ResponseWriter, r *http.Request) {
 	a.handlerCommonGet(w, r, a.Config.Processors)
 }
 
+// targetConfigInScope reports whether the client of request r
+// has access to the namespace of target id.
+// It returns true if the target does not exist.
+func (a *App) targetConfigInScope(r *http.Request, id string) bool {
+	scope := apiScopeFromContext(r.Context())
+	if scope == nil {
+		return true
+	}
+	a.configLock.RLock()
+	defer a.configLock.RUnlock()
+	tc, ok := a.Config.Targets[id]
+	return !ok || scope.allows(tc.Namespace)
+}
+
+// runningTarget returns the running target id
+// if the client of request r has access to its namespace.
+func (a *App) runningTarget(r *http.Request, id string) (*target.Target, bool) {
+	a.operLock.RLock()
+	t, ok := a.Targets[id]
+	a.operLock.RUnlock()
+	if !ok || !apiScopeFromContext(r.Context()).allows(t.Config.Namespace) {
+		return nil, false
+	}
+	return t, true
+}
+
 func (a *App) handleConfig(w http.ResponseWriter, r *http.Request) {
 	a.handlerCommonGet(w, r, a.Config)
 }
@@ -157,10 +243,23 @@ func (a *App) handleTargetsGet(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 	if id == "" {
-		a.handlerCommonGet(w, r, a.Targets)
+		scope := apiScopeFromContext(r.Context())
+		if scope == nil {
+			a.handlerCommonGet(w, r, a.Targets)
+			return
+		}
+		ts := make(map[string]*target.Target)
+		a.operLock.RLock()
+		for n, t := range a.Targets {
+			if scope.allows(t.Config.Namespace) {
+				ts[n] = t
+			}
+		}
+		a.operLock.RUnlock()
+		a.handlerCommonGet(w, r, ts)
 		return
 	}
-	if t, ok := a.Targets[id]; ok {
+	if t, ok := a.runningTarget(r, id); ok {
 		a.handlerCommonGet(w, r, t)
 		return
 	}
@@ -168,6 +267,94 @@ func (a *App) handleTargetsGet(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(APIErrors{Errors: []string{"no targets found"}})
 }
 
+func (a *App) handleTargetsCapabilitiesGet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	t, ok := a.runningTarget(r, id)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q not found", id)}})
+		return
+	}
+	p := t.LastCapabilitiesProbe()
+	if p == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q capabilities not probed", id)}})
+		return
+	}
+	a.handlerCommonGet(w, r, p)
+}
+
+func (a *App) handleTargetsHealthGet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	t, ok := a.runningTarget(r, id)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q not found", id)}})
+		return
+	}
+	a.handlerCommonGet(w, r, t.Health())
+}
+
+func (a *App) handleTargetsSubscriptionsGet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	t, ok := a.runningTarget(r, id)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q not found", id)}})
+		return
+	}
+	now := time.Now()
+	a.operLock.RLock()
+	rsp := make([]*targetSubscriptionState, 0, len(t.Subscriptions))
+	for _, sc := range t.Subscriptions {
+		rsp = append(rsp, targetSubscriptionStatus(t, sc, now))
+	}
+	a.operLock.RUnlock()
+	sort.Slice(rsp, func(i, j int) bool {
+		return rsp[i].Name < rsp[j].Name
+	})
+	a.handlerCommonGet(w, r, rsp)
+}
+
+func (a *App) handleTargetsSubscriptionPause(w http.ResponseWriter, r *http.Request) {
+	a.handleTargetsSubscriptionPauseResume(w, r, true)
+}
+
+func (a *App) handleTargetsSubscriptionResume(w http.ResponseWriter, r *http.Request) {
+	a.handleTargetsSubscriptionPauseResume(w, r, false)
+}
+
+// handleTargetsSubscriptionPauseResume pauses or resumes the subscription {name} of target {id}.
+// A resumed subscription stays paused if it is outside of its schedule.
+func (a *App) handleTargetsSubscriptionPauseResume(w http.ResponseWriter, r *http.Request, pause bool) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	name := vars["name"]
+	t, ok := a.runningTarget(r, id)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q not found", id)}})
+		return
+	}
+	a.operLock.RLock()
+	defer a.operLock.RUnlock()
+	sc, ok := t.Subscriptions[name]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q has no subscription %q", id, name)}})
+		return
+	}
+	if pause {
+		a.pauseTargetSubscription(t, name, target.PausedByAPI)
+	} else {
+		a.resumeTargetSubscription(t, name, target.PausedByAPI)
+	}
+	a.handlerCommonGet(w, r, targetSubscriptionStatus(t, sc, time.Now()))
+}
+
 func (a *App) handleTargetsPost(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
@@ -176,11 +363,17 @@ func (a *App) handleTargetsPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	tc, ok := a.Config.Targets[id]
-	if !ok {
+	if !ok || !apiScopeFromContext(r.Context()).allows(tc.Namespace) {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q not found", id)}})
 		return
 	}
+	err := a.admitTarget(id)
+	if err != nil {
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
 	go a.TargetSubscribeStream(a.ctx, tc)
 }
 
@@ -191,7 +384,7 @@ func (a *App) handleTargetsDelete(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	if _, ok := a.Targets[id]; !ok {
+	if _, ok := a.runningTarget(r, id); !ok {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q not found", id)}})
 		return
@@ -204,11 +397,277 @@ func (a *App) handleTargetsDelete(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleTargetsGetRequest sends the gNMI GetRequest described in the request body
+// to target {id}, using the target existing gNMI client if any.
+func (a *App) handleTargetsGetRequest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	tc, mo, ok := a.proxyRequestParams(w, r, id)
+	if !ok {
+		return
+	}
+	g := new(config.ApplyGet)
+	err := readRequestBody(r, g)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	req, err := a.Config.CreateApplyGetRequest(g)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	rsp, err := a.ClientGet(a.ctx, tc, req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	a.writeProtoMsg(w, mo, rsp, id)
+}
+
+// handleTargetsSetRequest sends the gNMI SetRequest described in the request body
+// to target {id}, using the target existing gNMI client if any.
+func (a *App) handleTargetsSetRequest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	tc, mo, ok := a.proxyRequestParams(w, r, id)
+	if !ok {
+		return
+	}
+	s := new(config.SetRequestFile)
+	err := readRequestBody(r, s)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	req, err := a.Config.CreateApplySetRequest(s)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	rsp, err := a.ClientSet(context.WithValue(a.ctx, auditActorKey{}, apiActor(r)), tc, req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	a.writeProtoMsg(w, mo, rsp, id)
+}
+
+// proxyRequestParams returns the config of target id and the marshaling options
+// selected with the query parameter "format".
+// It writes the error response and returns false if one of them is not valid.
+func (a *App) proxyRequestParams(w http.ResponseWriter, r *http.Request, id string) (*types.TargetConfig, *formatters.MarshalOptions, bool) {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		format = "json"
+	case "json", "protojson", "event":
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("unsupported format %q, must be one of json, protojson or event", format)}})
+		return nil, nil, false
+	}
+	a.configLock.RLock()
+	tc, ok := a.Config.Targets[id]
+	a.configLock.RUnlock()
+	if !ok || !apiScopeFromContext(r.Context()).allows(tc.Namespace) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q not found", id)}})
+		return nil, nil, false
+	}
+	return tc, &formatters.MarshalOptions{Format: format}, true
+}
+
+func (a *App) writeProtoMsg(w http.ResponseWriter, mo *formatters.MarshalOptions, msg proto.Message, source string) {
+	b, err := mo.Marshal(msg, map[string]string{"source": source})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	w.Write(b)
+}
+
+func readRequestBody(r *http.Request, v interface{}) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	return json.Unmarshal(body, v)
+}
+
+type subscriptionResponse struct {
+	Config  *types.SubscriptionConfig `json:"config,omitempty"`
+	Targets []string                  `json:"targets,omitempty"`
+}
+
+func (a *App) subscriptionResponse(sc *types.SubscriptionConfig, scope apiScope) *subscriptionResponse {
+	rsp := &subscriptionResponse{Config: sc, Targets: make([]string, 0)}
+	a.operLock.RLock()
+	defer a.operLock.RUnlock()
+	for n, t := range a.Targets {
+		if !scope.allows(t.Config.Namespace) {
+			continue
+		}
+		if _, ok := t.Subscriptions[sc.Name]; ok {
+			rsp.Targets = append(rsp.Targets, n)
+		}
+	}
+	sort.Strings(rsp.Targets)
+	return rsp
+}
+
+func (a *App) handleSubscriptionsGet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	scope := apiScopeFromContext(r.Context())
+	a.configLock.RLock()
+	subs := make([]*types.SubscriptionConfig, 0, len(a.Config.Subscriptions))
+	for n, sc := range a.Config.Subscriptions {
+		if (id == "" || n == id) && subscriptionInScope(scope, sc) {
+			subs = append(subs, sc)
+		}
+	}
+	a.configLock.RUnlock()
+	if id != "" {
+		if len(subs) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("subscription %q not found", id)}})
+			return
+		}
+		a.handlerCommonGet(w, r, a.subscriptionResponse(subs[0], scope))
+		return
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].Name < subs[j].Name
+	})
+	rsp := make([]*subscriptionResponse, 0, len(subs))
+	for _, sc := range subs {
+		rsp = append(rsp, a.subscriptionResponse(sc, scope))
+	}
+	a.handlerCommonGet(w, r, rsp)
+}
+
+func (a *App) handleSubscriptionsPost(w http.ResponseWriter, r *http.Request) {
+	sc, err := readSubscriptionConfig(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	if !apiScopeFromContext(r.Context()).allows(sc.Namespace) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("namespace %q not allowed", sc.Namespace)}})
+		return
+	}
+	if a.subscriptionConfigExists(sc.Name) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("subscription %q already exists", sc.Name)}})
+		return
+	}
+	err = a.AddSubscriptionConfig(sc)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (a *App) handleSubscriptionsPut(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	sc, err := readSubscriptionConfig(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	if sc.Name != "" && sc.Name != id {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("subscription name %q does not match %q", sc.Name, id)}})
+		return
+	}
+	sc.Name = id
+	if !apiScopeFromContext(r.Context()).allows(sc.Namespace) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("namespace %q not allowed", sc.Namespace)}})
+		return
+	}
+	if !a.subscriptionAccessible(w, r, id) {
+		return
+	}
+	err = a.UpdateSubscriptionConfig(sc)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+}
+
+func (a *App) handleSubscriptionsDelete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if !a.subscriptionAccessible(w, r, id) {
+		return
+	}
+	err := a.DeleteSubscriptionConfig(id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+}
+
+// subscriptionAccessible reports whether subscription id exists
+// and the client of request r is allowed to modify it.
+// It writes the error response if not.
+func (a *App) subscriptionAccessible(w http.ResponseWriter, r *http.Request, id string) bool {
+	scope := apiScopeFromContext(r.Context())
+	a.configLock.RLock()
+	sc, ok := a.Config.Subscriptions[id]
+	a.configLock.RUnlock()
+	if !ok || !subscriptionInScope(scope, sc) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("subscription %q not found", id)}})
+		return false
+	}
+	if !scope.allows(sc.Namespace) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("namespace %q not allowed", sc.Namespace)}})
+		return false
+	}
+	return true
+}
+
+// subscriptionInScope reports whether the subscription sc is visible with the given scope.
+// The subscriptions without namespace are visible to all clients.
+func subscriptionInScope(scope apiScope, sc *types.SubscriptionConfig) bool {
+	return sc.Namespace == "" || scope.allows(sc.Namespace)
+}
+
+func readSubscriptionConfig(r *http.Request) (*types.SubscriptionConfig, error) {
+	sc := new(types.SubscriptionConfig)
+	err := readRequestBody(r, sc)
+	if err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
 type clusteringResponse struct {
 	ClusterName           string          `json:"name,omitempty"`
 	NumberOfLockedTargets int             `json:"number-of-locked-targets"`
 	Leader                string          `json:"leader,omitempty"`
 	Members               []clusterMember `json:"members,omitempty"`
+	Warnings              []string        `json:"warnings,omitempty"`
 }
 
 type clusterMember struct {
@@ -217,14 +676,31 @@ type clusterMember struct {
 	IsLeader              bool     `json:"is-leader,omitempty"`
 	NumberOfLockedTargets int      `json:"number-of-locked-nodes"`
 	LockedTargets         []string `json:"locked-targets,omitempty"`
+	MaxTargets            int      `json:"max-targets,omitempty"`
 }
 
 func (a *App) handleClusteringGet(w http.ResponseWriter, r *http.Request) {
 	if a.Config.Clustering == nil {
 		return
 	}
+	resp, err := a.clusteringState(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	w.Write(b)
+}
 
-	ctx, cancel := context.WithCancel(r.Context())
+// clusteringState returns the cluster leader and members along with their locked targets.
+func (a *App) clusteringState(ctx context.Context) (*clusteringResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	resp := new(clusteringResponse)
@@ -233,25 +709,19 @@ func (a *App) handleClusteringGet(w http.ResponseWriter, r *http.Request) {
 	leaderKey := fmt.Sprintf("gnmic/%s/leader", a.Config.ClusterName)
 	leader, err := a.locker.List(ctx, leaderKey)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
-		return
+		return nil, err
 	}
 	resp.Leader = leader[leaderKey]
 	lockedNodesPrefix := fmt.Sprintf("gnmic/%s/targets", a.Config.ClusterName)
 
 	lockedNodes, err := a.locker.List(a.ctx, lockedNodesPrefix)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
-		return
+		return nil, err
 	}
 	resp.NumberOfLockedTargets = len(lockedNodes)
 	services, err := a.locker.GetServices(ctx, fmt.Sprintf("%s-gnmic-api", a.Config.ClusterName), nil)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
-		return
+		return nil, err
 	}
 
 	instanceNodes := make(map[string][]string)
@@ -269,14 +739,10 @@ func (a *App) handleClusteringGet(w http.ResponseWriter, r *http.Request) {
 		resp.Members[i].IsLeader = resp.Leader == resp.Members[i].Name
 		resp.Members[i].NumberOfLockedTargets = len(instanceNodes[resp.Members[i].Name])
 		resp.Members[i].LockedTargets = instanceNodes[resp.Members[i].Name]
+		resp.Members[i].MaxTargets = serviceMaxTargets(s)
 	}
-	b, err := json.Marshal(resp)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
-		return
-	}
-	w.Write(b)
+	resp.Warnings = capacityWarnings(resp.Members)
+	return resp, nil
 }
 
 func (a *App) handleClusteringMembersGet(w http.ResponseWriter, r *http.Request) {
@@ -425,11 +891,16 @@ func (a *App) loggingMiddleware(next http.Handler) http.Handler {
 func (a *App) handlerCommonGet(w http.ResponseWriter, r *http.Request, i interface{}) {
 	a.configLock.RLock()
 	defer a.configLock.RUnlock()
+	a.writeRedacted(w, i)
+}
+
+// writeRedacted writes i as JSON to w, with its secrets redacted.
+func (a *App) writeRedacted(w http.ResponseWriter, i interface{}) {
 	b, err := json.Marshal(i)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
 		return
 	}
-	w.Write(b)
+	w.Write(a.Config.Redactor().RedactBytes(b))
 }
@@ -0,0 +1,407 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openconfig/gnmic/config"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	oidcDiscoveryPath      = "/.well-known/openid-configuration"
+	oidcJWKSMinRefresh     = 30 * time.Second
+	oidcClockSkew          = time.Minute
+	defaultOIDCHTTPTimeout = 10 * time.Second
+)
+
+var (
+	errMissingCredentials = errors.New("missing credentials")
+	errInvalidCredentials = errors.New("invalid credentials")
+)
+
+// apiAuthenticator validates the credentials sent with API requests,
+// either as a static token, a basic auth user or an OIDC issued JWT.
+type apiAuthenticator struct {
+	tokens       [][]byte
+	scopedTokens []*scopedToken
+	users        map[string]*config.APIUser
+	oidc         *oidcVerifier
+}
+
+type scopedToken struct {
+	token []byte
+	scope apiScope
+}
+
+// apiScope is the set of namespaces an API client has access to,
+// a nil scope grants access to all namespaces.
+type apiScope map[string]struct{}
+
+func newAPIScope(namespaces []string) apiScope {
+	if len(namespaces) == 0 {
+		return nil
+	}
+	s := make(apiScope, len(namespaces))
+	for _, ns := range namespaces {
+		s[ns] = struct{}{}
+	}
+	return s
+}
+
+// allows reports whether namespace ns is part of the scope.
+func (s apiScope) allows(ns string) bool {
+	if s == nil {
+		return true
+	}
+	_, ok := s[ns]
+	return ok
+}
+
+type apiScopeKey struct{}
+
+// apiScopeFromContext returns the scope of the authenticated
+// API client, nil if it is not restricted.
+func apiScopeFromContext(ctx context.Context) apiScope {
+	s, _ := ctx.Value(apiScopeKey{}).(apiScope)
+	return s
+}
+
+func newAPIAuthenticator(cfg *config.APIAuth) *apiAuthenticator {
+	if cfg == nil {
+		return nil
+	}
+	aa := &apiAuthenticator{
+		tokens:       make([][]byte, 0, len(cfg.Tokens)),
+		scopedTokens: make([]*scopedToken, 0, len(cfg.ScopedTokens)),
+		users:        make(map[string]*config.APIUser, len(cfg.Users)),
+	}
+	for _, t := range cfg.Tokens {
+		aa.tokens = append(aa.tokens, []byte(t))
+	}
+	for _, t := range cfg.ScopedTokens {
+		aa.scopedTokens = append(aa.scopedTokens, &scopedToken{
+			token: []byte(t.Token),
+			scope: newAPIScope(t.Namespaces),
+		})
+	}
+	for _, u := range cfg.Users {
+		aa.users[u.Username] = u
+	}
+	if cfg.OIDC != nil {
+		aa.oidc = newOIDCVerifier(cfg.OIDC)
+	}
+	return aa
+}
+
+// authenticate checks the value of an Authorization header
+// and returns the scope of the authenticated client.
+func (aa *apiAuthenticator) authenticate(ctx context.Context, header string) (apiScope, error) {
+	if header == "" {
+		return nil, errMissingCredentials
+	}
+	scheme, creds, ok := strings.Cut(header, " ")
+	if !ok {
+		return nil, errInvalidCredentials
+	}
+	creds = strings.TrimSpace(creds)
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		for _, t := range aa.tokens {
+			if subtle.ConstantTimeCompare(t, []byte(creds)) == 1 {
+				return nil, nil
+			}
+		}
+		for _, t := range aa.scopedTokens {
+			if subtle.ConstantTimeCompare(t.token, []byte(creds)) == 1 {
+				return t.scope, nil
+			}
+		}
+		if aa.oidc != nil {
+			return nil, aa.oidc.verify(ctx, creds)
+		}
+	case "basic":
+		username, password, ok := parseBasicAuth(creds)
+		if !ok {
+			return nil, errInvalidCredentials
+		}
+		if u, ok := aa.checkUser(username, password); ok {
+			return newAPIScope(u.Namespaces), nil
+		}
+	}
+	return nil, errInvalidCredentials
+}
+
+func (aa *apiAuthenticator) checkUser(username, password string) (*config.APIUser, bool) {
+	u, ok := aa.users[username]
+	if !ok {
+		return nil, false
+	}
+	if strings.HasPrefix(u.Password, "$2") {
+		return u, bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) == nil
+	}
+	return u, subtle.ConstantTimeCompare([]byte(u.Password), []byte(password)) == 1
+}
+
+func (aa *apiAuthenticator) challenge() string {
+	if len(aa.users) > 0 {
+		return `Basic realm="gnmic"`
+	}
+	return `Bearer realm="gnmic"`
+}
+
+func parseBasicAuth(creds string) (string, string, bool) {
+	b, err := base64.StdEncoding.DecodeString(creds)
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(b), ":")
+}
+
+func (a *App) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope, err := a.apiAuth.authenticate(r.Context(), r.Header.Get("Authorization"))
+		if err != nil {
+			if a.Config.APIServer != nil && a.Config.APIServer.Debug {
+				a.Logger.Printf("API request %s %s from %s rejected: %v", r.Method, r.URL.Path, r.RemoteAddr, err)
+			}
+			w.Header().Set("WWW-Authenticate", a.apiAuth.challenge())
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+			return
+		}
+		if scope != nil {
+			r = r.WithContext(context.WithValue(r.Context(), apiScopeKey{}, scope))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// unscopedOnly rejects the requests of the clients restricted to a set of namespaces,
+// it protects the routes exposing resources that do not belong to a namespace.
+func unscopedOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if apiScopeFromContext(r.Context()) != nil {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(APIErrors{Errors: []string{"access restricted to namespaced resources"}})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// setAPIRequestAuth adds the credentials gNMIc uses to reach
+// other cluster members API to an outgoing request.
+func (a *App) setAPIRequestAuth(req *http.Request) {
+	if a.Config.APIServer == nil || a.Config.APIServer.Auth == nil {
+		return
+	}
+	if len(a.Config.APIServer.Auth.Tokens) > 0 {
+		req.Header.Set("Authorization", "Bearer "+a.Config.APIServer.Auth.Tokens[0])
+	}
+}
+
+func (a *App) authUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	err := a.grpcAuthenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *App) authStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := a.grpcAuthenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (a *App) grpcAuthenticate(ctx context.Context) error {
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			header = v[0]
+		}
+	}
+	scope, err := a.apiAuth.authenticate(ctx, header)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	// the admin gRPC API is not namespace aware
+	if scope != nil {
+		return status.Error(codes.PermissionDenied, "access restricted to namespaced resources")
+	}
+	return nil
+}
+
+// oidcVerifier validates JWTs signed by an OIDC issuer.
+// The issuer keys are fetched on first use and refreshed
+// when a token signed with an unknown key is received.
+type oidcVerifier struct {
+	cfg    *config.APIOIDC
+	client *http.Client
+
+	m         sync.Mutex
+	jwksURL   string
+	keys      *jose.JSONWebKeySet
+	lastFetch time.Time
+}
+
+func newOIDCVerifier(cfg *config.APIOIDC) *oidcVerifier {
+	v := &oidcVerifier{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: defaultOIDCHTTPTimeout},
+		jwksURL: cfg.JWKSURL,
+	}
+	if cfg.SkipVerify {
+		v.client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		}
+	}
+	return v
+}
+
+func (v *oidcVerifier) verify(ctx context.Context, raw string) error {
+	tok, err := jwt.ParseSigned(raw)
+	if err != nil {
+		return errInvalidCredentials
+	}
+	if len(tok.Headers) == 0 {
+		return errInvalidCredentials
+	}
+	key, err := v.key(ctx, tok.Headers[0].KeyID)
+	if err != nil {
+		return err
+	}
+	// only asymmetric keys are accepted,
+	// a symmetric key published in the key set would allow anyone to sign tokens.
+	if !key.IsPublic() {
+		return errInvalidCredentials
+	}
+	claims := new(jwt.Claims)
+	err = tok.Claims(key, claims)
+	if err != nil {
+		return errInvalidCredentials
+	}
+	exp := jwt.Expected{
+		Issuer: v.cfg.Issuer,
+		Time:   time.Now(),
+	}
+	if v.cfg.Audience != "" {
+		exp.Audience = jwt.Audience{v.cfg.Audience}
+	}
+	err = claims.ValidateWithLeeway(exp, oidcClockSkew)
+	if err != nil {
+		return fmt.Errorf("invalid token: %v", err)
+	}
+	return nil
+}
+
+// key returns the issuer key with the given ID,
+// the key set is refetched if the key is not known.
+func (v *oidcVerifier) key(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if v.keys != nil {
+		if k := findJWK(v.keys, kid); k != nil {
+			return k, nil
+		}
+		if time.Since(v.lastFetch) < oidcJWKSMinRefresh {
+			return nil, errInvalidCredentials
+		}
+	}
+	err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OIDC issuer keys: %v", err)
+	}
+	if k := findJWK(v.keys, kid); k != nil {
+		return k, nil
+	}
+	return nil, errInvalidCredentials
+}
+
+func findJWK(ks *jose.JSONWebKeySet, kid string) *jose.JSONWebKey {
+	if kid == "" {
+		// a token without key ID can only be matched
+		// if the issuer has a single key.
+		if len(ks.Keys) == 1 {
+			return &ks.Keys[0]
+		}
+		return nil
+	}
+	keys := ks.Key(kid)
+	if len(keys) == 0 {
+		return nil
+	}
+	return &keys[0]
+}
+
+func (v *oidcVerifier) fetchKeys(ctx context.Context) error {
+	v.lastFetch = time.Now()
+	if v.jwksURL == "" {
+		disc := struct {
+			Issuer  string `json:"issuer,omitempty"`
+			JWKSURI string `json:"jwks_uri,omitempty"`
+		}{}
+		err := v.getJSON(ctx, strings.TrimSuffix(v.cfg.Issuer, "/")+oidcDiscoveryPath, &disc)
+		if err != nil {
+			return err
+		}
+		if disc.Issuer != v.cfg.Issuer {
+			return fmt.Errorf("discovered issuer %q does not match the configured issuer %q", disc.Issuer, v.cfg.Issuer)
+		}
+		if disc.JWKSURI == "" {
+			return errors.New("issuer discovery document has no jwks_uri")
+		}
+		v.jwksURL = disc.JWKSURI
+	}
+	ks := new(jose.JSONWebKeySet)
+	err := v.getJSON(ctx, v.jwksURL, ks)
+	if err != nil {
+		return err
+	}
+	v.keys = ks
+	return nil
+}
+
+func (v *oidcVerifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	rsp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status code=%d", url, rsp.StatusCode)
+	}
+	return json.NewDecoder(rsp.Body).Decode(out)
+}
@@ -0,0 +1,254 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/types"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestAPIAuthMiddleware(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New()
+	a.Config.APIServer = &config.APIServer{
+		Auth: &config.APIAuth{
+			Tokens: []string{"token1"},
+			Users: []*config.APIUser{
+				{Username: "admin", Password: "secret1"},
+				{Username: "viewer", Password: string(hash)},
+			},
+		},
+	}
+	a.routes()
+
+	basic := func(u, p string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(u+":"+p))
+	}
+	tests := map[string]struct {
+		path   string
+		header string
+		code   int
+	}{
+		"no_credentials":    {path: "/api/v1/targets", code: http.StatusUnauthorized},
+		"valid_token":       {path: "/api/v1/targets", header: "Bearer token1", code: http.StatusOK},
+		"invalid_token":     {path: "/api/v1/targets", header: "Bearer token2", code: http.StatusUnauthorized},
+		"basic_plain":       {path: "/api/v1/targets", header: basic("admin", "secret1"), code: http.StatusOK},
+		"basic_bcrypt":      {path: "/api/v1/targets", header: basic("viewer", "secret2"), code: http.StatusOK},
+		"basic_wrong":       {path: "/api/v1/targets", header: basic("viewer", "secret1"), code: http.StatusUnauthorized},
+		"basic_unknown":     {path: "/api/v1/targets", header: basic("other", "secret1"), code: http.StatusUnauthorized},
+		"v2_no_credentials": {path: "/api/v2/targets", code: http.StatusUnauthorized},
+		"v2_valid_token":    {path: "/api/v2/targets", header: "Bearer token1", code: http.StatusOK},
+		"openapi":           {path: "/api/v2/openapi.json", code: http.StatusOK},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			a.router.ServeHTTP(rec, req)
+			if rec.Code != tc.code {
+				t.Fatalf("expected status %d, got %d: %s", tc.code, rec.Code, rec.Body.String())
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("missing WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestAPINamespaceScope(t *testing.T) {
+	a := New()
+	a.Config.APIServer = &config.APIServer{
+		Auth: &config.APIAuth{
+			Tokens: []string{"admin"},
+			ScopedTokens: []*config.APIToken{
+				{Token: "team-a", Namespaces: []string{"team-a"}},
+			},
+			Users: []*config.APIUser{
+				{Username: "bob", Password: "secret", Namespaces: []string{"team-b"}},
+			},
+		},
+	}
+	a.Config.Targets = map[string]*types.TargetConfig{
+		"t1": {Name: "t1", Namespace: "team-a"},
+		"t2": {Name: "t2", Namespace: "team-b"},
+	}
+	a.Config.Subscriptions = map[string]*types.SubscriptionConfig{
+		"sub1": {Name: "sub1", Namespace: "team-a", Paths: []string{"/interface"}},
+		"sub2": {Name: "sub2", Namespace: "team-b", Paths: []string{"/interface"}},
+		"sub3": {Name: "sub3", Paths: []string{"/system"}},
+	}
+	a.routes()
+
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("bob:secret"))
+	tests := map[string]struct {
+		method string
+		path   string
+		body   string
+		header string
+		code   int
+		// keys expected in the returned map
+		keys []string
+	}{
+		"unscoped_config":        {path: "/api/v1/config", header: "Bearer admin", code: http.StatusOK},
+		"scoped_config":          {path: "/api/v1/config", header: "Bearer team-a", code: http.StatusForbidden},
+		"scoped_cluster":         {path: "/api/v1/cluster", header: basic, code: http.StatusForbidden},
+		"unscoped_targets":       {path: "/api/v1/config/targets", header: "Bearer admin", code: http.StatusOK, keys: []string{"t1", "t2"}},
+		"scoped_targets":         {path: "/api/v1/config/targets", header: "Bearer team-a", code: http.StatusOK, keys: []string{"t1"}},
+		"scoped_user_targets":    {path: "/api/v1/config/targets", header: basic, code: http.StatusOK, keys: []string{"t2"}},
+		"scoped_target":          {path: "/api/v1/config/targets/t1", header: "Bearer team-a", code: http.StatusOK},
+		"scoped_other_target":    {path: "/api/v1/config/targets/t2", header: "Bearer team-a", code: http.StatusNotFound},
+		"scoped_subscriptions":   {path: "/api/v1/config/subscriptions", header: "Bearer team-a", code: http.StatusOK, keys: []string{"sub1", "sub3"}},
+		"scoped_other_sub":       {path: "/api/v1/subscriptions/sub2", header: "Bearer team-a", code: http.StatusNotFound},
+		"scoped_delete_global":   {method: http.MethodDelete, path: "/api/v1/subscriptions/sub3", header: "Bearer team-a", code: http.StatusForbidden},
+		"scoped_create_other_ns": {method: http.MethodPost, path: "/api/v1/subscriptions", body: `{"name":"sub4","paths":["/interface"],"namespace":"team-b"}`, header: "Bearer team-a", code: http.StatusForbidden},
+		"scoped_create_global":   {method: http.MethodPost, path: "/api/v1/subscriptions", body: `{"name":"sub4","paths":["/interface"]}`, header: "Bearer team-a", code: http.StatusForbidden},
+		"scoped_add_other_ns":    {method: http.MethodPost, path: "/api/v1/config/targets", body: `{"name":"t3","namespace":"team-b"}`, header: "Bearer team-a", code: http.StatusForbidden},
+		"scoped_replace_other":   {method: http.MethodPost, path: "/api/v1/config/targets", body: `{"name":"t2","namespace":"team-a"}`, header: "Bearer team-a", code: http.StatusForbidden},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Authorization", tc.header)
+			rec := httptest.NewRecorder()
+			a.router.ServeHTTP(rec, req)
+			if rec.Code != tc.code {
+				t.Fatalf("expected status %d, got %d: %s", tc.code, rec.Code, rec.Body.String())
+			}
+			if tc.keys == nil {
+				return
+			}
+			m := make(map[string]interface{})
+			err := json.Unmarshal(rec.Body.Bytes(), &m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tc.keys) {
+				t.Errorf("expected %v, got %v", tc.keys, keys)
+			}
+		})
+	}
+}
+
+func TestOIDCVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk := jose.JSONWebKey{Key: &key.PublicKey, KeyID: "k1", Algorithm: string(jose.RS256), Use: "sig"}
+
+	mux := http.NewServeMux()
+	var issuer string
+	mux.HandleFunc(oidcDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   issuer,
+			"jwks_uri": issuer + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{jwk}})
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+	issuer = s.URL
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "k1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(c jwt.Claims) string {
+		raw, err := jwt.Signed(signer).Claims(c).CompactSerialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	now := time.Now()
+	v := newOIDCVerifier(&config.APIOIDC{Issuer: issuer, Audience: "gnmic"})
+	tests := map[string]struct {
+		claims jwt.Claims
+		valid  bool
+	}{
+		"valid": {
+			claims: jwt.Claims{Issuer: issuer, Audience: jwt.Audience{"gnmic"}, Expiry: jwt.NewNumericDate(now.Add(time.Hour))},
+			valid:  true,
+		},
+		"expired": {
+			claims: jwt.Claims{Issuer: issuer, Audience: jwt.Audience{"gnmic"}, Expiry: jwt.NewNumericDate(now.Add(-time.Hour))},
+		},
+		"wrong_audience": {
+			claims: jwt.Claims{Issuer: issuer, Audience: jwt.Audience{"other"}, Expiry: jwt.NewNumericDate(now.Add(time.Hour))},
+		},
+		"wrong_issuer": {
+			claims: jwt.Claims{Issuer: "https://example.com", Audience: jwt.Audience{"gnmic"}, Expiry: jwt.NewNumericDate(now.Add(time.Hour))},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := v.verify(context.Background(), sign(tc.claims))
+			if tc.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+	t.Run("other_key", func(t *testing.T) {
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		otherSigner, err := jose.NewSigner(
+			jose.SigningKey{Algorithm: jose.RS256, Key: other},
+			(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "k1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := jwt.Signed(otherSigner).Claims(jwt.Claims{Issuer: issuer, Audience: jwt.Audience{"gnmic"}}).CompactSerialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := v.verify(context.Background(), raw); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
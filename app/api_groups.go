@@ -0,0 +1,153 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/openconfig/gnmic/types"
+)
+
+type targetGroupResponse struct {
+	Group *types.TargetGroup `json:"group,omitempty"`
+	// names of the configured targets belonging to the group
+	Targets []string `json:"targets"`
+	// names of the group targets currently running
+	Running []string `json:"running"`
+}
+
+func (a *App) targetGroupRoutes() []*apiRoute {
+	return []*apiRoute{
+		{method: http.MethodGet, path: "/target-groups", handler: a.handleTargetGroupsGet,
+			tag: "target-groups", summary: "List the target groups", response: []*targetGroupResponse{},
+			namespaced: true},
+		{method: http.MethodGet, path: "/target-groups/{name}", handler: a.handleTargetGroupsGet,
+			tag: "target-groups", summary: "Get a target group", response: targetGroupResponse{},
+			namespaced: true},
+		{method: http.MethodPost, path: "/target-groups/{name}/enable", handler: a.handleTargetGroupEnable,
+			tag: "target-groups", summary: "Start the subscriptions of a target group targets", response: targetGroupResponse{},
+			namespaced: true},
+		{method: http.MethodPost, path: "/target-groups/{name}/disable", handler: a.handleTargetGroupDisable,
+			tag: "target-groups", summary: "Stop the subscriptions of a target group targets", response: targetGroupResponse{},
+			namespaced: true},
+	}
+}
+
+// targetGroupMembers returns the configurations of the targets of group g
+// the client of request r is allowed to access.
+func (a *App) targetGroupMembers(r *http.Request, g *types.TargetGroup) []*types.TargetConfig {
+	scope := apiScopeFromContext(r.Context())
+	a.configLock.RLock()
+	defer a.configLock.RUnlock()
+	tcs := make([]*types.TargetConfig, 0)
+	for _, n := range g.Members(a.Config.Targets) {
+		tc := a.Config.Targets[n]
+		if scope.allows(tc.Namespace) {
+			tcs = append(tcs, tc)
+		}
+	}
+	return tcs
+}
+
+func (a *App) targetGroupResponse(r *http.Request, g *types.TargetGroup) *targetGroupResponse {
+	rsp := &targetGroupResponse{Group: g, Targets: make([]string, 0), Running: make([]string, 0)}
+	for _, tc := range a.targetGroupMembers(r, g) {
+		rsp.Targets = append(rsp.Targets, tc.Name)
+		if _, ok := a.runningTarget(r, tc.Name); ok {
+			rsp.Running = append(rsp.Running, tc.Name)
+		}
+	}
+	return rsp
+}
+
+// targetGroup returns the target group {name} of request r,
+// it writes the error response if it does not exist.
+func (a *App) targetGroup(w http.ResponseWriter, r *http.Request) (*types.TargetGroup, bool) {
+	name := mux.Vars(r)["name"]
+	a.configLock.RLock()
+	g, ok := a.Config.TargetGroups[name]
+	a.configLock.RUnlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target group %q not found", name)}})
+		return nil, false
+	}
+	return g, true
+}
+
+func (a *App) handleTargetGroupsGet(w http.ResponseWriter, r *http.Request) {
+	if _, ok := mux.Vars(r)["name"]; ok {
+		g, ok := a.targetGroup(w, r)
+		if !ok {
+			return
+		}
+		a.handlerCommonGet(w, r, a.targetGroupResponse(r, g))
+		return
+	}
+	a.configLock.RLock()
+	groups := make([]*types.TargetGroup, 0, len(a.Config.TargetGroups))
+	for _, g := range a.Config.TargetGroups {
+		groups = append(groups, g)
+	}
+	a.configLock.RUnlock()
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	rsp := make([]*targetGroupResponse, 0, len(groups))
+	for _, g := range groups {
+		rsp = append(rsp, a.targetGroupResponse(r, g))
+	}
+	a.handlerCommonGet(w, r, rsp)
+}
+
+// handleTargetGroupEnable starts the subscriptions of the group targets that are not running.
+func (a *App) handleTargetGroupEnable(w http.ResponseWriter, r *http.Request) {
+	g, ok := a.targetGroup(w, r)
+	if !ok {
+		return
+	}
+	for _, tc := range a.targetGroupMembers(r, g) {
+		if _, ok := a.runningTarget(r, tc.Name); ok {
+			continue
+		}
+		a.Logger.Printf("target group %q: starting target %q", g.Name, tc.Name)
+		go a.TargetSubscribeStream(a.ctx, tc)
+	}
+	a.handlerCommonGet(w, r, a.targetGroupResponse(r, g))
+}
+
+// handleTargetGroupDisable stops the running targets of the group,
+// their configuration is kept.
+func (a *App) handleTargetGroupDisable(w http.ResponseWriter, r *http.Request) {
+	g, ok := a.targetGroup(w, r)
+	if !ok {
+		return
+	}
+	errs := make([]string, 0)
+	for _, tc := range a.targetGroupMembers(r, g) {
+		if _, ok := a.runningTarget(r, tc.Name); !ok {
+			continue
+		}
+		a.Logger.Printf("target group %q: stopping target %q", g.Name, tc.Name)
+		err := a.StopTarget(a.ctx, tc.Name)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("target %q: %v", tc.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIErrors{Errors: errs})
+		return
+	}
+	a.handlerCommonGet(w, r, a.targetGroupResponse(r, g))
+}
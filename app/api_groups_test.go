@@ -0,0 +1,76 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/openconfig/gnmic/target"
+	"github.com/openconfig/gnmic/types"
+)
+
+func TestTargetGroupsAPI(t *testing.T) {
+	a := New()
+	a.routes()
+	for _, n := range []string{"spine1", "spine2", "leaf1"} {
+		role := "spine"
+		if n == "leaf1" {
+			role = "leaf"
+		}
+		tc := &types.TargetConfig{Name: n, Vars: map[string]string{"role": role}}
+		a.Config.Targets[n] = tc
+		a.Targets[n] = target.NewTarget(tc)
+	}
+	a.Config.TargetGroups["spine"] = &types.TargetGroup{Name: "spine", Selector: map[string]string{"role": "spine"}}
+
+	do := func(method, url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		rec := httptest.NewRecorder()
+		a.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/api/v1/target-groups/spine")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get: unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	rsp := new(targetGroupResponse)
+	err := json.Unmarshal(rec.Body.Bytes(), rsp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{"spine1", "spine2"}
+	if !reflect.DeepEqual(rsp.Targets, exp) || !reflect.DeepEqual(rsp.Running, exp) {
+		t.Errorf("get: unexpected response: %s", rec.Body.String())
+	}
+	rec = do(http.MethodGet, "/api/v1/target-groups/leaf")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("get unknown: unexpected status %d", rec.Code)
+	}
+	// disable stops the group targets and keeps their config
+	rec = do(http.MethodPost, "/api/v1/target-groups/spine/disable")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("disable: unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, n := range exp {
+		if _, ok := a.Targets[n]; ok {
+			t.Errorf("disable: target %q still running", n)
+		}
+		if _, ok := a.Config.Targets[n]; !ok {
+			t.Errorf("disable: target %q config deleted", n)
+		}
+	}
+	if _, ok := a.Targets["leaf1"]; !ok {
+		t.Errorf("disable: target leaf1 outside the group stopped")
+	}
+}
@@ -0,0 +1,333 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const openAPIVersion = "3.0.3"
+
+type openAPIDoc struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Servers    []openAPIServer                         `json:"servers,omitempty"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+	Security   []map[string][]string                   `json:"security,omitempty"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*openAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type openAPISecurityScheme struct {
+	Type             string `json:"type"`
+	Scheme           string `json:"scheme,omitempty"`
+	BearerFormat     string `json:"bearerFormat,omitempty"`
+	OpenIDConnectURL string `json:"openIdConnectUrl,omitempty"`
+}
+
+type openAPIOperation struct {
+	Tags        []string                    `json:"tags,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema,omitempty"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+func (a *App) handleOpenAPI(v *apiVersion) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.handlerCommonGet(w, r, a.openAPIDocument(v))
+	}
+}
+
+// openAPIDocument builds the OpenAPI document of an API version
+// from its routes metadata.
+func (a *App) openAPIDocument(v *apiVersion) *openAPIDoc {
+	doc := &openAPIDoc{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title:   "gNMIc API " + v.name,
+			Version: version,
+		},
+		Servers: []openAPIServer{{URL: "/api/" + v.name}},
+		Paths:   make(map[string]map[string]*openAPIOperation),
+	}
+	sb := newOpenAPISchemaBuilder()
+	errSchema := sb.schema(reflect.TypeOf(APIErrors{}))
+	for _, rt := range v.routes {
+		op := &openAPIOperation{
+			Summary:   rt.summary,
+			Responses: make(map[string]*openAPIResponse),
+		}
+		if rt.tag != "" {
+			op.Tags = []string{rt.tag}
+		}
+		for _, elem := range strings.Split(rt.path, "/") {
+			if strings.HasPrefix(elem, "{") && strings.HasSuffix(elem, "}") {
+				op.Parameters = append(op.Parameters, &openAPIParameter{
+					Name:     strings.Trim(elem, "{}"),
+					In:       "path",
+					Required: true,
+					Schema:   &openAPISchema{Type: "string"},
+				})
+			}
+		}
+		queryParams := make([]string, 0, len(rt.query))
+		for name := range rt.query {
+			queryParams = append(queryParams, name)
+		}
+		sort.Strings(queryParams)
+		for _, name := range queryParams {
+			op.Parameters = append(op.Parameters, &openAPIParameter{
+				Name:        name,
+				In:          "query",
+				Description: rt.query[name],
+				Schema:      &openAPISchema{Type: "string"},
+			})
+		}
+		if rt.request != nil {
+			op.RequestBody = &openAPIRequestBody{
+				Required: true,
+				Content: map[string]*openAPIMediaType{
+					"application/json": {Schema: sb.schema(reflect.TypeOf(rt.request))},
+				},
+			}
+		}
+		status := rt.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		rsp := &openAPIResponse{Description: http.StatusText(status)}
+		switch {
+		case rt.produces != "":
+			rsp.Content = map[string]*openAPIMediaType{rt.produces: {}}
+		case rt.response != nil:
+			rsp.Content = map[string]*openAPIMediaType{
+				"application/json": {Schema: sb.schema(reflect.TypeOf(rt.response))},
+			}
+		case rt.method == http.MethodGet:
+			rsp.Content = map[string]*openAPIMediaType{
+				"application/json": {Schema: &openAPISchema{Type: "object"}},
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = rsp
+		op.Responses["default"] = &openAPIResponse{
+			Description: "Error",
+			Content: map[string]*openAPIMediaType{
+				"application/json": {Schema: errSchema},
+			},
+		}
+		if _, ok := doc.Paths[rt.path]; !ok {
+			doc.Paths[rt.path] = make(map[string]*openAPIOperation)
+		}
+		doc.Paths[rt.path][strings.ToLower(rt.method)] = op
+	}
+	doc.Components.Schemas = sb.schemas
+	a.openAPISecurity(doc)
+	return doc
+}
+
+// openAPISecurity adds the configured authentication methods
+// to the document as security schemes.
+func (a *App) openAPISecurity(doc *openAPIDoc) {
+	if a.Config.APIServer == nil || a.Config.APIServer.Auth == nil {
+		return
+	}
+	auth := a.Config.APIServer.Auth
+	doc.Components.SecuritySchemes = make(map[string]*openAPISecurityScheme)
+	if len(auth.Tokens) > 0 {
+		doc.Components.SecuritySchemes["token"] = &openAPISecurityScheme{Type: "http", Scheme: "bearer"}
+	}
+	if len(auth.Users) > 0 {
+		doc.Components.SecuritySchemes["basic"] = &openAPISecurityScheme{Type: "http", Scheme: "basic"}
+	}
+	if auth.OIDC != nil {
+		if auth.OIDC.Issuer != "" {
+			doc.Components.SecuritySchemes["oidc"] = &openAPISecurityScheme{
+				Type:             "openIdConnect",
+				OpenIDConnectURL: strings.TrimSuffix(auth.OIDC.Issuer, "/") + oidcDiscoveryPath,
+			}
+		} else {
+			doc.Components.SecuritySchemes["oidc"] = &openAPISecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+		}
+	}
+	names := make([]string, 0, len(doc.Components.SecuritySchemes))
+	for name := range doc.Components.SecuritySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// any of the schemes is accepted
+	for _, name := range names {
+		doc.Security = append(doc.Security, map[string][]string{name: {}})
+	}
+}
+
+var (
+	durationType      = reflect.TypeOf(time.Duration(0))
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// openAPISchemaBuilder derives JSON schemas from Go types,
+// following the encoding/json rules.
+// Named structs are added to the document components and referenced.
+type openAPISchemaBuilder struct {
+	schemas map[string]*openAPISchema
+	types   map[reflect.Type]string
+}
+
+func newOpenAPISchemaBuilder() *openAPISchemaBuilder {
+	return &openAPISchemaBuilder{
+		schemas: make(map[string]*openAPISchema),
+		types:   make(map[reflect.Type]string),
+	}
+}
+
+func (sb *openAPISchemaBuilder) schema(t reflect.Type) *openAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case durationType:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return &openAPISchema{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openAPISchema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: sb.schema(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: sb.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return sb.structSchema(t)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + sb.register(t)}
+	}
+	// interfaces and anything encoding/json does not handle
+	return &openAPISchema{}
+}
+
+// register adds a named struct to the components schemas and returns its name.
+// The package name is prepended if the type name is already taken.
+func (sb *openAPISchemaBuilder) register(t reflect.Type) string {
+	if name, ok := sb.types[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, ok := sb.schemas[name]; ok {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	s := new(openAPISchema)
+	// registered before being built to handle recursive types
+	sb.types[t] = name
+	sb.schemas[name] = s
+	*s = *sb.structSchema(t)
+	return name
+}
+
+func (sb *openAPISchemaBuilder) structSchema(t reflect.Type) *openAPISchema {
+	s := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			// embedded struct fields are promoted
+			for n, p := range sb.structSchema(ft).Properties {
+				if _, ok := s.Properties[n]; !ok {
+					s.Properties[n] = p
+				}
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = sb.schema(f.Type)
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	a := New()
+	a.routes()
+	for _, v := range a.apiVersions() {
+		t.Run(v.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/"+v.name+"/openapi.json", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+			}
+			doc := new(openAPIDoc)
+			err := json.Unmarshal(rec.Body.Bytes(), doc)
+			if err != nil {
+				t.Fatalf("failed to decode document: %v", err)
+			}
+			if doc.Servers[0].URL != "/api/"+v.name {
+				t.Errorf("unexpected server URL %q", doc.Servers[0].URL)
+			}
+			for _, rt := range v.routes {
+				op, ok := doc.Paths[rt.path]["get"]
+				if rt.method != http.MethodGet {
+					continue
+				}
+				if !ok {
+					t.Errorf("missing operation GET %s", rt.path)
+				}
+				if _, ok := op.Responses["200"]; !ok {
+					t.Errorf("missing GET %s response", rt.path)
+				}
+			}
+			op := doc.Paths["/subscriptions"]["post"]
+			if op == nil || op.RequestBody == nil {
+				t.Fatalf("missing POST /subscriptions request body")
+			}
+			if ref := op.RequestBody.Content["application/json"].Schema.Ref; ref != "#/components/schemas/SubscriptionConfig" {
+				t.Errorf("unexpected request body schema %q", ref)
+			}
+			if _, ok := op.Responses["201"]; !ok {
+				t.Errorf("missing POST /subscriptions 201 response")
+			}
+			sc, ok := doc.Components.Schemas["SubscriptionConfig"]
+			if !ok {
+				t.Fatalf("missing SubscriptionConfig schema")
+			}
+			if p, ok := sc.Properties["sample-interval"]; !ok || p.Type != "integer" {
+				t.Errorf("unexpected sample-interval property: %+v", p)
+			}
+			params := doc.Paths["/backup/targets/{id}/snapshots/{snapshot}"]["get"].Parameters
+			if len(params) != 2 || params[0].Name != "id" || params[1].Name != "snapshot" {
+				t.Errorf("unexpected path parameters: %+v", params)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/cache"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/utils"
+)
+
+// handleStateGet returns the latest values of a target, read from the gNMI server cache,
+// as events: with their timestamp, tags and values.
+func (a *App) handleStateGet(w http.ResponseWriter, r *http.Request) {
+	if a.c == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{"state not available: the gnmi-server cache is not enabled"}})
+		return
+	}
+	q := r.URL.Query()
+	name := q.Get("target")
+	if name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{"missing target"}})
+		return
+	}
+	if !a.targetConfigInScope(r, name) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q not found", name)}})
+		return
+	}
+	// the cache is keyed by the target name without port
+	cacheTarget := utils.GetHost(name)
+	paths := make([]*gnmi.Path, 0, len(q["path"]))
+	for _, p := range q["path"] {
+		gp, err := utils.ParsePath(strings.TrimSpace(p))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("invalid path %q: %v", p, err)}})
+			return
+		}
+		gp.Target = cacheTarget
+		paths = append(paths, gp)
+	}
+	if len(paths) == 0 {
+		paths = append(paths, &gnmi.Path{Target: cacheTarget})
+	}
+	ro := &cache.ReadOpts{
+		Subscription: q.Get("subscription"),
+		Target:       cacheTarget,
+		Paths:        paths,
+		Mode:         cache.ReadMode_Once,
+	}
+	evs := make([]*formatters.EventMsg, 0)
+	for n := range a.c.Subscribe(r.Context(), ro) {
+		if n.Err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(APIErrors{Errors: []string{n.Err.Error()}})
+			return
+		}
+		rsp := &gnmi.SubscribeResponse{
+			Response: &gnmi.SubscribeResponse_Update{Update: n.Notification},
+		}
+		nevs, err := formatters.ResponseToEventMsgs(n.Name, rsp,
+			map[string]string{"source": name, "subscription-name": n.Name})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+			return
+		}
+		evs = append(evs, nevs...)
+	}
+	sortStateEvents(evs)
+	a.handlerCommonGet(w, r, evs)
+}
+
+// sortStateEvents orders the events by subscription name and value names,
+// the cache returns them in no particular order.
+func sortStateEvents(evs []*formatters.EventMsg) {
+	keys := make(map[*formatters.EventMsg]string, len(evs))
+	for _, e := range evs {
+		names := make([]string, 0, len(e.Values)+len(e.Deletes))
+		for k := range e.Values {
+			names = append(names, k)
+		}
+		names = append(names, e.Deletes...)
+		sort.Strings(names)
+		keys[e] = e.Name + "\x00" + strings.Join(names, "\x00")
+	}
+	sort.SliceStable(evs, func(i, j int) bool {
+		return keys[evs[i]] < keys[evs[j]]
+	})
+}
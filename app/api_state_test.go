@@ -0,0 +1,99 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/cache"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+)
+
+func stateTestUpdate(ifName, state string, ts int64) *gnmi.SubscribeResponse {
+	return &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Timestamp: ts,
+				Update: []*gnmi.Update{{
+					Path: &gnmi.Path{Elem: []*gnmi.PathElem{
+						{Name: "interface", Key: map[string]string{"name": ifName}},
+						{Name: "oper-state"},
+					}},
+					Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: state}},
+				}},
+			},
+		},
+	}
+}
+
+func TestStateGet(t *testing.T) {
+	a := New()
+	a.routes()
+	do := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		rec := httptest.NewRecorder()
+		a.router.ServeHTTP(rec, req)
+		return rec
+	}
+	// no cache
+	if rec := do("/api/v1/state?target=t1"); rec.Code != http.StatusNotFound {
+		t.Errorf("no cache: unexpected status %d", rec.Code)
+	}
+
+	var err error
+	a.c, err = cache.New(&cache.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.AddTargetConfig(&types.TargetConfig{Name: "t1:57400"})
+	ctx := context.Background()
+	m := outputs.Meta{"source": "t1:57400", "subscription-name": "sub1"}
+	a.updateCache(ctx, stateTestUpdate("e1", "down", 1), m)
+	a.updateCache(ctx, stateTestUpdate("e1", "up", 2), m)
+	a.updateCache(ctx, stateTestUpdate("e2", "up", 3), m)
+
+	get := func(url string) []*formatters.EventMsg {
+		t.Helper()
+		rec := do(url)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+		}
+		evs := make([]*formatters.EventMsg, 0)
+		if err := json.Unmarshal(rec.Body.Bytes(), &evs); err != nil {
+			t.Fatal(err)
+		}
+		return evs
+	}
+	evs := get("/api/v1/state?target=t1:57400")
+	if len(evs) != 2 {
+		t.Fatalf("expected 2 events, got %d: %v", len(evs), evs)
+	}
+	evs = get("/api/v1/state?target=t1:57400&path=/interface[name=e1]/oper-state")
+	if len(evs) != 1 {
+		t.Fatalf("expected 1 event, got %d: %v", len(evs), evs)
+	}
+	e := evs[0]
+	if e.Timestamp != 2 || e.Tags["interface_name"] != "e1" || e.Tags["source"] != "t1:57400" ||
+		e.Values["/interface/oper-state"] != "up" {
+		t.Errorf("unexpected event: %v", e)
+	}
+	if evs := get("/api/v1/state?target=t1:57400&subscription=sub2"); len(evs) != 0 {
+		t.Errorf("expected no events, got %v", evs)
+	}
+	if rec := do("/api/v1/state"); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing target: unexpected status %d", rec.Code)
+	}
+}
@@ -0,0 +1,241 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/expr"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+)
+
+const (
+	eventStreamBufferSize        = 100
+	eventStreamKeepaliveInterval = 15 * time.Second
+)
+
+// eventStreamHub fans out the received subscribe responses, as events,
+// to the clients of the API /stream endpoint.
+type eventStreamHub struct {
+	m       *sync.RWMutex
+	clients map[*eventStreamClient]struct{}
+}
+
+// eventStreamClient is a client of the /stream endpoint
+// and the filter applied to the events it receives.
+type eventStreamClient struct {
+	target       string
+	subscription string
+	prg          *expr.Program
+	// namespaces the client has access to
+	scope apiScope
+
+	ch      chan *formatters.EventMsg
+	dropped uint64
+}
+
+func newEventStreamHub() *eventStreamHub {
+	return &eventStreamHub{
+		m:       new(sync.RWMutex),
+		clients: make(map[*eventStreamClient]struct{}),
+	}
+}
+
+func (h *eventStreamHub) add(c *eventStreamClient) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	h.clients[c] = struct{}{}
+}
+
+func (h *eventStreamHub) remove(c *eventStreamClient) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	delete(h.clients, c)
+}
+
+// publish converts rsp to events and sends them to the matching clients.
+// Events are dropped for the clients not keeping up.
+func (h *eventStreamHub) publish(rsp *gnmi.SubscribeResponse, m outputs.Meta) {
+	if h == nil || rsp.GetUpdate() == nil {
+		return
+	}
+	h.m.RLock()
+	defer h.m.RUnlock()
+	if len(h.clients) == 0 {
+		return
+	}
+	subName := m["subscription-name"]
+	if subName == "" {
+		subName = "default"
+	}
+	evs, err := formatters.ResponseToEventMsgs(subName, rsp, m)
+	if err != nil {
+		return
+	}
+	for c := range h.clients {
+		for _, ev := range evs {
+			if !c.match(ev) {
+				continue
+			}
+			select {
+			case c.ch <- ev:
+			default:
+				atomic.AddUint64(&c.dropped, 1)
+			}
+		}
+	}
+}
+
+func (c *eventStreamClient) match(ev *formatters.EventMsg) bool {
+	if !c.scope.allows(ev.Tags["namespace"]) {
+		return false
+	}
+	if c.target != "" && ev.Tags["source"] != c.target {
+		return false
+	}
+	if c.subscription != "" && ev.Tags["subscription-name"] != c.subscription {
+		return false
+	}
+	if c.prg == nil {
+		return true
+	}
+	ok, err := formatters.CheckExpression(c.prg, ev)
+	return err == nil && ok
+}
+
+// handleStream streams the received events to the client as Server-Sent Events.
+// The events can be filtered using the query parameters target, subscription and
+// expression (a CEL expression evaluated against each event).
+func (a *App) handleStream(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	c := &eventStreamClient{
+		target:       q.Get("target"),
+		subscription: q.Get("subscription"),
+		scope:        apiScopeFromContext(r.Context()),
+		ch:           make(chan *formatters.EventMsg, eventStreamBufferSize),
+	}
+	if e := q.Get("expression"); e != "" {
+		var err error
+		c.prg, err = formatters.CompileExpression(e)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+			return
+		}
+	}
+	st, err := newSSEStream(w)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	defer st.close()
+
+	a.streams.add(c)
+	defer a.streams.remove(c)
+	a.Logger.Printf("stream client %s connected: target=%q, subscription=%q, expression=%q",
+		r.RemoteAddr, c.target, c.subscription, q.Get("expression"))
+	defer func() {
+		a.Logger.Printf("stream client %s disconnected, %d events dropped", r.RemoteAddr, atomic.LoadUint64(&c.dropped))
+	}()
+
+	ticker := time.NewTicker(eventStreamKeepaliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-st.done:
+			return
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			_, err = io.WriteString(st.w, ": keepalive\n\n")
+		case ev := <-c.ch:
+			var b []byte
+			b, err = json.Marshal(ev)
+			if err != nil {
+				a.Logger.Printf("stream client %s: failed to marshal event: %v", r.RemoteAddr, err)
+				continue
+			}
+			_, err = fmt.Fprintf(st.w, "data: %s\n\n", b)
+		}
+		if err == nil {
+			err = st.flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// sseStream is the response stream of a Server-Sent Events client.
+type sseStream struct {
+	w     io.Writer
+	flush func() error
+	close func()
+	// closed when the client closes the connection
+	done <-chan struct{}
+}
+
+// newSSEStream writes the Server-Sent Events response headers.
+// HTTP/1.x connections are hijacked so that the stream is not bound by the API server write timeout,
+// other connections are flushed after each event.
+func newSSEStream(w http.ResponseWriter) (*sseStream, error) {
+	if hj, ok := w.(http.Hijacker); ok {
+		conn, rw, err := hj.Hijack()
+		if err == nil {
+			conn.SetDeadline(time.Time{})
+			done := make(chan struct{})
+			go func() {
+				io.Copy(io.Discard, rw)
+				close(done)
+			}()
+			_, err = io.WriteString(rw, "HTTP/1.1 200 OK\r\n"+
+				"Content-Type: text/event-stream\r\n"+
+				"Cache-Control: no-cache\r\n"+
+				"Connection: close\r\n\r\n")
+			if err == nil {
+				err = rw.Flush()
+			}
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return &sseStream{
+				w:     rw,
+				flush: rw.Flush,
+				close: func() { conn.Close() },
+				done:  done,
+			}, nil
+		}
+	}
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming not supported")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+	return &sseStream{
+		w:     w,
+		flush: func() error { f.Flush(); return nil },
+		close: func() {},
+		done:  make(chan struct{}),
+	}, nil
+}
@@ -0,0 +1,128 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+)
+
+func streamTestResponse(value int64) *gnmi.SubscribeResponse {
+	return &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Timestamp: 42,
+				Update: []*gnmi.Update{
+					{
+						Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "counter"}}},
+						Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_IntVal{IntVal: value}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestStreamEndpoint(t *testing.T) {
+	a := New()
+	a.routes()
+	srv := httptest.NewServer(a.router)
+	defer srv.Close()
+
+	rsp, err := http.Get(srv.URL + "/api/v1/stream?expression=" + url.QueryEscape(`values["/counter"] == 2`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", rsp.StatusCode)
+	}
+	if ct := rsp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	// wait for the client registration
+	for i := 0; ; i++ {
+		a.streams.m.RLock()
+		n := len(a.streams.clients)
+		a.streams.m.RUnlock()
+		if n > 0 {
+			break
+		}
+		if i == 100 {
+			t.Fatal("stream client not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	m := outputs.Meta{"source": "t1", "subscription-name": "sub1"}
+	a.streams.publish(streamTestResponse(1), m)
+	a.streams.publish(streamTestResponse(2), m)
+
+	r := bufio.NewReader(rsp.Body)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(line, "data: ") {
+		t.Fatalf("unexpected line %q", line)
+	}
+	ev := new(formatters.EventMsg)
+	err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Tags["source"] != "t1" || ev.Values["/counter"] != float64(2) {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+}
+
+func TestStreamEndpointBadExpression(t *testing.T) {
+	a := New()
+	a.routes()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stream?expression="+url.QueryEscape("values.counter =="), nil)
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unexpected status %d", rec.Code)
+	}
+}
+
+func TestEventStreamClientMatch(t *testing.T) {
+	ev := &formatters.EventMsg{
+		Name:   "sub1",
+		Tags:   map[string]string{"source": "t1", "subscription-name": "sub1"},
+		Values: map[string]interface{}{"counter": 1},
+	}
+	tests := map[string]struct {
+		c     *eventStreamClient
+		match bool
+	}{
+		"no_filter":          {c: &eventStreamClient{}, match: true},
+		"target":             {c: &eventStreamClient{target: "t1"}, match: true},
+		"other_target":       {c: &eventStreamClient{target: "t2"}, match: false},
+		"subscription":       {c: &eventStreamClient{subscription: "sub1"}, match: true},
+		"other_subscription": {c: &eventStreamClient{subscription: "sub2"}, match: false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if m := tc.c.match(ev); m != tc.match {
+				t.Errorf("expected %v, got %v", tc.match, m)
+			}
+		})
+	}
+}
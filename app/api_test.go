@@ -0,0 +1,113 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/types"
+	"google.golang.org/grpc"
+)
+
+type apiTestGNMIServer struct {
+	gnmi.UnimplementedGNMIServer
+	setReq *gnmi.SetRequest
+}
+
+func (s *apiTestGNMIServer) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+	return &gnmi.GetResponse{
+		Notification: []*gnmi.Notification{
+			{
+				Timestamp: 42,
+				Update: []*gnmi.Update{
+					{
+						Path: req.GetPath()[0],
+						Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "up"}},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (s *apiTestGNMIServer) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+	s.setReq = req
+	return &gnmi.SetResponse{Timestamp: 42}, nil
+}
+
+func TestTargetsGetSetRequests(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := &apiTestGNMIServer{}
+	srv := grpc.NewServer()
+	gnmi.RegisterGNMIServer(srv, gs)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	a := New()
+	a.routes()
+	a.Config.Encoding = "json"
+	insecure := true
+	a.AddTargetConfig(&types.TargetConfig{
+		Name:     "t1",
+		Address:  l.Addr().String(),
+		Insecure: &insecure,
+		Timeout:  5 * time.Second,
+	})
+
+	do := func(url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		a.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("/api/v1/targets/t1/get?format=event", `{"paths":["/interface/oper-state"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get: unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	evs := make([]map[string]interface{}, 0)
+	err = json.Unmarshal(rec.Body.Bytes(), &evs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 1 {
+		t.Fatalf("get: unexpected response: %s", rec.Body.String())
+	}
+	if v := evs[0]["values"].(map[string]interface{})["/interface/oper-state"]; v != "up" {
+		t.Errorf("get: unexpected value: %v", v)
+	}
+
+	rec = do("/api/v1/targets/t1/set", `{"deletes":["/interface[name=ethernet-1/1]"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("set: unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	if gs.setReq == nil || len(gs.setReq.GetDelete()) != 1 {
+		t.Errorf("set: unexpected request received by the target: %v", gs.setReq)
+	}
+
+	rec = do("/api/v1/targets/t2/get", `{"paths":["/"]}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown target: unexpected status %d", rec.Code)
+	}
+	rec = do("/api/v1/targets/t1/get?format=flat", `{"paths":["/"]}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unsupported format: unexpected status %d", rec.Code)
+	}
+}
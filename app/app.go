@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"sort"
 	"strings"
@@ -26,20 +27,28 @@ import (
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/audit"
+	"github.com/openconfig/gnmic/backup"
 	"github.com/openconfig/gnmic/cache"
 	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/credentials"
 	"github.com/openconfig/gnmic/formatters"
 	"github.com/openconfig/gnmic/inputs"
 	"github.com/openconfig/gnmic/lockers"
+	"github.com/openconfig/gnmic/logging"
+	"github.com/openconfig/gnmic/membudget"
+	"github.com/openconfig/gnmic/netconf"
 	"github.com/openconfig/gnmic/outputs"
 	"github.com/openconfig/gnmic/target"
 	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/grpctunnel/tunnel"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/grpclog"
@@ -76,21 +85,37 @@ type App struct {
 	// api
 	apiServices map[string]*lockers.Service
 	isLeader    bool
+	// instance each target was last assigned to, used by the leader dispatch loop
+	targetInstances map[string]string
+	// IDs of the services registered in the locker by this instance
+	sm                 *sync.Mutex
+	registeredServices map[string]struct{}
+	// rate of the received subscribe responses, used for the clustering admission control
+	msgRate *rateMeter
 	// prometheus registry
 	reg *prometheus.Registry
 	//
 	Logger *log.Logger
-	out    io.Writer
+	// leveled logger writing to Logger
+	slog *slog.Logger
+	out  io.Writer
 	// prompt mode
 	PromptMode    bool
 	PromptHistory []string
 	SchemaTree    *yang.Entry
+	// name of the target the SchemaTree was loaded from
+	SchemaTarget string
 	// yang
 	modules *yang.Modules
 	//
 	wg        *sync.WaitGroup
 	printLock *sync.Mutex
 	errCh     chan error
+	// results of the current multi target get, set or capabilities run
+	summary *runSummary
+	// limits the rate of the unary RPCs sent to all the targets
+	rpcLimiterOnce sync.Once
+	rpcLimiter     *rate.Limiter
 	// gnmi server
 	gnmi.UnimplementedGNMIServer
 	// gRPC server where the gNMI service will be registered
@@ -99,6 +124,10 @@ type App struct {
 	c               cache.Cache
 	subscribeRPCsem *semaphore.Weighted
 	unaryRPCsem     *semaphore.Weighted
+	// YANG schema of the targets data, used to filter Get responses
+	gnmiServerSchema *yang.Entry
+	// pooled target connections used in proxy mode
+	gnmiProxy *gnmiProxy
 	// tunnel server
 	// gRPC server where the tunnel service will be registered
 	grpcTunnelSrv *grpc.Server
@@ -106,6 +135,32 @@ type App struct {
 	ttm           *sync.RWMutex
 	tunTargets    map[tunnel.Target]struct{}
 	tunTargetCfn  map[tunnel.Target]context.CancelFunc
+	// credentials providers
+	cm                   *sync.Mutex
+	credentialsProviders map[string]credentials.Provider
+	targetsCredentials   map[string]*credentials.Credentials
+	// client side subscribe response filter
+	updateFilter *updateFilter
+	// subscribe duration and max-messages limits
+	capture *capture
+	// subscribe terminal UI
+	tui *tui
+	// backup mode snapshots storage
+	backupStore backup.Store
+	// NETCONF pollers by name
+	netconfPollers map[string]*netconf.Poller
+	// API server events streams clients
+	streams *eventStreamHub
+	// API server requests authentication, nil if disabled
+	apiAuth *apiAuthenticator
+	// command used to reload the configuration
+	reloadCmd *cobra.Command
+	// names of the items loaded from the configuration file
+	configNames *configNames
+	// memory budget, nil if unlimited
+	budget *membudget.Budget
+	// audit log of the Set requests and mutating API calls
+	audit *audit.Log
 }
 
 func New() *App {
@@ -127,6 +182,11 @@ func New() *App {
 		activeTargets: make(map[string]struct{}),
 		targetsLockFn: make(map[string]context.CancelFunc),
 		//
+		sm:                 new(sync.Mutex),
+		registeredServices: make(map[string]struct{}),
+		targetInstances:    make(map[string]string),
+		msgRate:            newRateMeter(),
+		//
 		router:        mux.NewRouter(),
 		apiServices:   make(map[string]*lockers.Service),
 		Logger:        log.New(io.Discard, "[gnmic] ", log.LstdFlags|log.Lmsgprefix),
@@ -142,12 +202,28 @@ func New() *App {
 		ttm:          new(sync.RWMutex),
 		tunTargets:   make(map[tunnel.Target]struct{}),
 		tunTargetCfn: make(map[tunnel.Target]context.CancelFunc),
+		// credentials providers
+		cm:                   new(sync.Mutex),
+		credentialsProviders: make(map[string]credentials.Provider),
+		targetsCredentials:   make(map[string]*credentials.Credentials),
+		streams:              newEventStreamHub(),
 	}
 	a.router.StrictSlash(true)
 	a.router.Use(headersMiddleware, a.loggingMiddleware)
+	a.initLeveledLogger()
 	return a
 }
 
+// initLeveledLogger sets the leveled logger writing to a.Logger,
+// the debug messages are logged if the debug flag is set.
+func (a *App) initLeveledLogger() {
+	level := logging.LevelInfo
+	if a.Config.Debug {
+		level = logging.LevelDebug
+	}
+	a.slog = logging.NewLogger(a.Logger, level)
+}
+
 func (a *App) Context() context.Context {
 	if a.ctx == nil {
 		return context.Background()
@@ -173,9 +249,12 @@ func (a *App) InitGlobalFlags() {
 	a.RootCmd.PersistentFlags().BoolVarP(&a.Config.GlobalFlags.SkipVerify, "skip-verify", "", false, "skip verify tls connection")
 	a.RootCmd.PersistentFlags().BoolVarP(&a.Config.GlobalFlags.NoPrefix, "no-prefix", "", false, "do not add [ip:port] prefix to print output in case of multiple targets")
 	a.RootCmd.PersistentFlags().BoolVarP(&a.Config.GlobalFlags.ProxyFromEnv, "proxy-from-env", "", false, "use proxy from environment")
+	a.RootCmd.PersistentFlags().StringVarP(&a.Config.GlobalFlags.Proxy, "proxy", "", "", "proxy used to reach the targets, socks5://, http:// or https://[user:password@]address:port")
 	a.RootCmd.PersistentFlags().StringVarP(&a.Config.GlobalFlags.Format, "format", "", "", fmt.Sprintf("output format, one of: %q", formatNames))
 	a.RootCmd.PersistentFlags().StringVarP(&a.Config.GlobalFlags.LogFile, "log-file", "", "", "log file path")
 	a.RootCmd.PersistentFlags().BoolVarP(&a.Config.GlobalFlags.Log, "log", "", false, "write log messages to stderr")
+	a.RootCmd.PersistentFlags().StringVarP(&a.Config.GlobalFlags.LogFormat, "log-format", "", "", "log messages format, one of \"text\" or \"json\". If set, the log messages are leveled and structured")
+	a.RootCmd.PersistentFlags().StringVarP(&a.Config.GlobalFlags.LogLevel, "log-level", "", "", "minimum level of the logged messages, one of \"debug\", \"info\", \"warn\" or \"error\"")
 	a.RootCmd.PersistentFlags().IntVarP(&a.Config.GlobalFlags.MaxMsgSize, "max-msg-size", "", msgSize, "max grpc msg size")
 	a.RootCmd.PersistentFlags().BoolVarP(&a.Config.GlobalFlags.PrintRequest, "print-request", "", false, "print request as well as the response(s)")
 	a.RootCmd.PersistentFlags().DurationVarP(&a.Config.GlobalFlags.Retry, "retry", "", defaultRetryTimer, "retry timer for RPCs")
@@ -191,12 +270,23 @@ func (a *App) InitGlobalFlags() {
 	a.RootCmd.PersistentFlags().StringVarP(&a.Config.GlobalFlags.TargetsFile, "targets-file", "", "", "path to file with targets configuration")
 	a.RootCmd.PersistentFlags().BoolVarP(&a.Config.GlobalFlags.Gzip, "gzip", "", false, "enable gzip compression on gRPC connections")
 	a.RootCmd.PersistentFlags().StringVarP(&a.Config.GlobalFlags.Token, "token", "", "", "token value, used for gRPC token based authentication")
+	a.RootCmd.PersistentFlags().StringSliceVarP(&a.Config.GlobalFlags.Group, "group", "", nil, "target group(s) name(s), only the targets of these groups are used")
+	a.RootCmd.PersistentFlags().IntVarP(&a.Config.GlobalFlags.RPCRetries, "rpc-retries", "", 0, "number of retries of the failed Capabilities, Get and Set RPCs, Set RPCs are retried only if they were not sent")
+	a.RootCmd.PersistentFlags().DurationVarP(&a.Config.GlobalFlags.RPCRetryBackoff, "rpc-retry-backoff", "", 0, "delay before the first RPC retry, doubled after each retry, defaults to 500ms")
+	a.RootCmd.PersistentFlags().Float64VarP(&a.Config.GlobalFlags.RPCRate, "rpc-rate", "", 0, "maximum number of Capabilities, Get and Set RPCs per second sent to all the targets, 0 means no limit")
+	a.RootCmd.PersistentFlags().IntVarP(&a.Config.GlobalFlags.RPCBurst, "rpc-burst", "", 0, "number of RPCs sent at once before --rpc-rate applies, defaults to --rpc-rate rounded up")
+	a.RootCmd.PersistentFlags().StringVarP(&a.Config.GlobalFlags.Summary, "summary", "", "", "write a JSON summary of the get, set and capabilities requests results to stderr or to a file")
+	a.RootCmd.PersistentFlags().BoolVarP(&a.Config.GlobalFlags.FailFast, "fail-fast", "", false, "cancel the get, set and capabilities requests to the other targets on the first failure")
+	a.RootCmd.PersistentFlags().BoolVarP(&a.Config.GlobalFlags.AllowPartial, "allow-partial", "", false, "exit with a zero code if the get, set and capabilities requests failed for some of the targets only")
+	a.RootCmd.PersistentFlags().StringArrayVarP(&a.Config.GlobalFlags.RedactPatterns, "redact-patterns", "", nil, "additional regular expressions matching the secrets redacted from the logs, the printed requests and the API responses")
+	a.RootCmd.PersistentFlags().StringVarP(&a.Config.GlobalFlags.Vendor, "vendor", "", "", fmt.Sprintf("targets vendor, sets the requests origin and encoding defaults, one of %q", types.VendorNames()))
 
 	a.RootCmd.PersistentFlags().StringArrayVarP(&a.Config.GlobalFlags.File, "file", "", nil, "YANG file(s)")
 	a.RootCmd.PersistentFlags().StringArrayVarP(&a.Config.GlobalFlags.Dir, "dir", "", nil, "YANG dir(s)")
 	a.RootCmd.PersistentFlags().StringArrayVarP(&a.Config.GlobalFlags.Exclude, "exclude", "", nil, "YANG module names to be excluded")
 
 	a.RootCmd.PersistentFlags().BoolVarP(&a.Config.GlobalFlags.UseTunnelServer, "use-tunnel-server", "", false, "use tunnel server to dial targets")
+	a.RootCmd.PersistentFlags().BoolVarP(&a.Config.GlobalFlags.StrictConfig, "strict-config", "", false, "validate the config file against the configuration schema and fail on unknown keys or invalid values")
 
 	a.RootCmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
 		a.Config.FileConfig.BindPFlag(flag.Name, flag)
@@ -212,6 +302,7 @@ func (a *App) PreRunE(cmd *cobra.Command, args []string) error {
 	}
 	a.Logger.SetOutput(logOutput)
 	a.Logger.SetFlags(flags)
+	a.initLeveledLogger()
 	a.Config.Address = config.SanitizeArrayFlagValue(a.Config.Address)
 	a.Logger.Printf("version=%s, commit=%s, date=%s, gitURL=%s, docs=https://gnmic.openconfig.net", version, commit, date, gitURL)
 
@@ -220,6 +311,22 @@ func (a *App) PreRunE(cmd *cobra.Command, args []string) error {
 	}
 	a.Logger.Printf("using config file %q", a.Config.FileConfig.ConfigFileUsed())
 	a.logConfigKVs()
+	if a.Config.StrictConfig && cmd.Name() != "validate" {
+		err = a.checkConfigFile()
+		if err != nil {
+			return err
+		}
+	}
+	if cmd.Name() != "validate" {
+		err = a.initSPIFFE()
+		if err != nil {
+			return err
+		}
+		err = a.initAudit()
+		if err != nil {
+			return err
+		}
+	}
 	return a.validateGlobals(cmd)
 }
 
@@ -304,6 +411,11 @@ func (a *App) PrintMsg(address string, msgName string, msg proto.Message) error
 		}
 		return err
 	}
+	switch msg.ProtoReflect().Interface().(type) {
+	case *gnmi.CapabilityRequest, *gnmi.GetRequest, *gnmi.SetRequest, *gnmi.SubscribeRequest:
+		// the requests values may hold secrets, e.g. a user password
+		b = a.Config.Redactor().RedactBytes(b)
+	}
 	sb := strings.Builder{}
 	sb.Write(b)
 	fmt.Fprintf(a.out, "%s\n", indent(printPrefix, sb.String()))
@@ -427,16 +539,22 @@ func (a *App) startAPIServer() {
 		a.Logger.Printf("failed to create a new API server: %v", err)
 		return
 	}
+	a.startAdminServer()
+	l, err := utils.Listen(a.Config.APIServer.Address, a.Config.APIServer.SocketPermissions)
+	if err != nil {
+		a.Logger.Printf("failed to start API server listener: %v", err)
+		return
+	}
 	go func() {
 		var err error
 		if s.TLSConfig != nil {
-			err = s.ListenAndServeTLS("", "")
+			err = s.ServeTLS(l, "", "")
 			if err != nil {
 				a.Logger.Printf("API server err: %v", err)
 				return
 			}
 		} else {
-			err = s.ListenAndServe()
+			err = s.Serve(l)
 			if err != nil {
 				a.Logger.Printf("API server err: %v", err)
 				return
@@ -508,12 +626,15 @@ func (a *App) CreateGNMIClient(ctx context.Context, t *target.Target) error {
 		)
 		t.Config.Address = t.Config.Name
 	}
+	if err := a.loadTargetCredentials(ctx, t); err != nil {
+		return err
+	}
 	a.Logger.Printf("creating gRPC client for target %q", t.Config.Name)
 	if err := t.CreateGNMIClient(ctx, targetDialOpts...); err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return fmt.Errorf("failed to create a gRPC client for target %q, timeout (%s) reached", t.Config.Name, t.Config.Timeout)
+			return connectionError{fmt.Errorf("failed to create a gRPC client for target %q, timeout (%s) reached", t.Config.Name, t.Config.Timeout)}
 		}
-		return fmt.Errorf("failed to create a gRPC client for target %q : %w", t.Config.Name, err)
+		return connectionError{fmt.Errorf("failed to create a gRPC client for target %q : %w", t.Config.Name, err)}
 	}
 	return nil
 }
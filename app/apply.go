@@ -0,0 +1,163 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/grpctunnel/tunnel"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func (a *App) ApplyPreRunE(cmd *cobra.Command, args []string) error {
+	a.Config.SetLocalFlagsFromFile(cmd)
+	err := a.Config.ReadApplyFiles()
+	if err != nil {
+		return fmt.Errorf("failed reading apply files: %v", err)
+	}
+
+	a.createCollectorDialOpts()
+	return a.initTunnelServer(tunnel.ServerConfig{
+		AddTargetHandler:    a.tunServerAddTargetHandler,
+		DeleteTargetHandler: a.tunServerDeleteTargetHandler,
+		RegisterHandler:     a.tunServerRegisterHandler,
+		Handler:             a.tunServerHandler,
+	})
+}
+
+func (a *App) ApplyRunE(cmd *cobra.Command, args []string) error {
+	defer a.InitApplyFlags(cmd)
+
+	if a.Config.Format == formatEvent {
+		return fmt.Errorf("format event not supported for apply")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	targetsConfig, err := a.GetTargets()
+	if err != nil {
+		return fmt.Errorf("failed getting targets config: %v", err)
+	}
+	if !a.PromptMode {
+		for _, tc := range targetsConfig {
+			a.AddTargetConfig(tc)
+		}
+	}
+	numTargets := len(a.Config.Targets)
+	a.errCh = make(chan error, numTargets*2)
+	a.wg.Add(numTargets)
+	for _, tc := range a.Config.Targets {
+		go a.ApplyRequests(ctx, tc)
+	}
+	a.wg.Wait()
+	return a.checkErrors()
+}
+
+// ApplyRequests renders the apply files for target tc and
+// runs the resulting requests in order, stopping at the first failure.
+func (a *App) ApplyRequests(ctx context.Context, tc *types.TargetConfig) {
+	defer a.wg.Done()
+	reqs, err := a.Config.CreateApplyRequests(tc)
+	if err != nil {
+		a.logError(fmt.Errorf("target %q: failed to create apply requests: %v", tc.Name, err))
+		return
+	}
+	for _, req := range reqs {
+		err = a.applyRequest(ctx, tc, req)
+		if err != nil {
+			a.logError(fmt.Errorf("target %q: request %q failed: %v", tc.Name, req.Name, err))
+			return
+		}
+	}
+}
+
+func (a *App) applyRequest(ctx context.Context, tc *types.TargetConfig, req *config.ApplyRequest) error {
+	printReq := a.Config.PrintRequest || a.Config.ApplyDryRun
+	switch {
+	case req.Get != nil:
+		getReq, err := a.Config.CreateApplyGetRequest(req.Get)
+		if err != nil {
+			return err
+		}
+		if printReq {
+			err = a.PrintMsg(tc.Name, fmt.Sprintf("%s Get Request:", req.Name), getReq)
+			if err != nil {
+				return err
+			}
+		}
+		if a.Config.ApplyDryRun {
+			return nil
+		}
+		rsp, err := a.ClientGet(ctx, tc, getReq)
+		if err != nil {
+			return err
+		}
+		return a.PrintMsg(tc.Name, fmt.Sprintf("%s Get Response:", req.Name), rsp)
+	case req.Set != nil:
+		setReq, err := a.Config.CreateApplySetRequest(req.Set)
+		if err != nil {
+			return err
+		}
+		if printReq {
+			err = a.PrintMsg(tc.Name, fmt.Sprintf("%s Set Request:", req.Name), setReq)
+			if err != nil {
+				return err
+			}
+		}
+		if a.Config.ApplyDryRun {
+			return nil
+		}
+		rsp, err := a.ClientSet(ctx, tc, setReq)
+		if err != nil {
+			return err
+		}
+		return a.PrintMsg(tc.Name, fmt.Sprintf("%s Set Response:", req.Name), rsp)
+	case req.Subscribe != nil:
+		subReq, err := a.Config.CreateApplySubscribeRequest(req.Subscribe, tc.Name)
+		if err != nil {
+			return err
+		}
+		if printReq {
+			err = a.PrintMsg(tc.Name, fmt.Sprintf("%s Subscribe Request:", req.Name), subReq)
+			if err != nil {
+				return err
+			}
+		}
+		if a.Config.ApplyDryRun {
+			return nil
+		}
+		rsps, err := a.ClientSubscribeOnce(ctx, tc, subReq)
+		if err != nil {
+			return err
+		}
+		for _, rsp := range rsps {
+			err = a.PrintMsg(tc.Name, fmt.Sprintf("%s Subscribe Response:", req.Name), rsp)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// InitApplyFlags used to init or reset applyCmd flags for gnmic-prompt mode
+func (a *App) InitApplyFlags(cmd *cobra.Command) {
+	cmd.ResetFlags()
+
+	cmd.Flags().StringArrayVarP(&a.Config.LocalFlags.ApplyFile, "file", "", []string{}, "apply request template file(s)")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.ApplyVars, "vars", "", "", "apply request variables file")
+	cmd.Flags().BoolVarP(&a.Config.LocalFlags.ApplyDryRun, "dry-run", "", false, "prints the rendered requests without initiating a gRPC connection")
+
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
+	})
+}
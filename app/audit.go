@@ -0,0 +1,231 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"os/user"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/openconfig/gnmic/audit"
+)
+
+// auditActorKey is the context key of the user
+// that triggered a Set request, and its address.
+type auditActorKey struct{}
+
+type auditActor struct {
+	user   string
+	client string
+}
+
+func (a *App) initAudit() error {
+	err := a.Config.GetAudit()
+	if err != nil {
+		return err
+	}
+	if a.Config.Audit == nil {
+		return nil
+	}
+	var sinks []audit.Sink
+	if len(a.Config.Audit.Outputs) > 0 {
+		sinks = append(sinks, a.auditToOutputs)
+	}
+	a.audit, err = audit.New(a.Config.Audit, sinks...)
+	if err != nil {
+		return err
+	}
+	a.Logger.Printf("audit log enabled: file=%q, outputs=%v, hmac=%v",
+		a.Config.Audit.File, a.Config.Audit.Outputs, a.Config.Audit.HMACKey != "")
+	return nil
+}
+
+// auditToOutputs writes the audit record r as an event to the audit outputs.
+func (a *App) auditToOutputs(r *audit.Record) {
+	a.operLock.RLock()
+	defer a.operLock.RUnlock()
+	for _, name := range a.Config.Audit.Outputs {
+		if o, ok := a.Outputs[name]; ok {
+			o.WriteEvent(a.ctx, r.EventMsg())
+		}
+	}
+}
+
+func (a *App) writeAudit(r *audit.Record) {
+	err := a.audit.Write(r)
+	if err != nil {
+		a.Logger.Printf("failed to write audit record: %v", err)
+	}
+}
+
+// auditSet records the Set request req sent to target.
+func (a *App) auditSet(ctx context.Context, target string, req *gnmi.SetRequest, err error) {
+	if a.audit == nil {
+		return
+	}
+	r := audit.SetRecord(target, req, err)
+	act := actorFromContext(ctx)
+	r.User, r.Client = act.user, act.client
+	a.writeAudit(r)
+}
+
+// actorFromContext returns the user that triggered a Set request.
+// It is either set by the API server, taken from the gNMI server
+// request metadata, or the local user running gNMIc.
+func actorFromContext(ctx context.Context) auditActor {
+	if act, ok := ctx.Value(auditActorKey{}).(auditActor); ok {
+		return act
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		act := auditActor{client: p.Addr.String()}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if u := md.Get("username"); len(u) > 0 {
+				act.user = u[0]
+			}
+		}
+		return act
+	}
+	if u, err := user.Current(); err == nil {
+		return auditActor{user: u.Username}
+	}
+	return auditActor{}
+}
+
+// apiActor returns the user of the API request r and its address.
+// The user is the basic authentication username, or the authentication scheme
+// if the client is identified by a token.
+func apiActor(r *http.Request) auditActor {
+	act := auditActor{client: r.RemoteAddr}
+	if username, _, ok := r.BasicAuth(); ok {
+		act.user = username
+		return act
+	}
+	if scheme, _, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok {
+		act.user = strings.ToLower(scheme)
+	}
+	return act
+}
+
+// auditMiddleware records the mutating API calls and their response status code.
+func (a *App) auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.audit == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		act := apiActor(r)
+		r = r.WithContext(context.WithValue(r.Context(), auditActorKey{}, act))
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		rec := &audit.Record{
+			Kind:   audit.KindAPI,
+			User:   act.user,
+			Client: act.client,
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Status: sw.status,
+			Result: audit.ResultSuccess,
+		}
+		if sw.status >= http.StatusBadRequest {
+			rec.Result = audit.ResultFailure
+		}
+		a.writeAudit(rec)
+	})
+}
+
+// auditedAdminMethods are the mutating methods of the admin gRPC service.
+var auditedAdminMethods = map[string]struct{}{
+	"/gnmic.admin.Admin/AddTarget":          {},
+	"/gnmic.admin.Admin/DeleteTarget":       {},
+	"/gnmic.admin.Admin/StartTarget":        {},
+	"/gnmic.admin.Admin/AddSubscription":    {},
+	"/gnmic.admin.Admin/UpdateSubscription": {},
+	"/gnmic.admin.Admin/DeleteSubscription": {},
+}
+
+// grpcActor returns the user of the admin gRPC call and its address.
+// Like for the REST API, the user is the basic authentication username,
+// or the authentication scheme if the client is identified by a token.
+func grpcActor(ctx context.Context) auditActor {
+	var act auditActor
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		act.client = p.Addr.String()
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return act
+	}
+	v := md.Get("authorization")
+	if len(v) == 0 {
+		return act
+	}
+	scheme, creds, ok := strings.Cut(v[0], " ")
+	if !ok {
+		return act
+	}
+	if strings.EqualFold(scheme, "basic") {
+		if username, _, ok := parseBasicAuth(creds); ok {
+			act.user = username
+			return act
+		}
+	}
+	act.user = strings.ToLower(scheme)
+	return act
+}
+
+// auditUnaryInterceptor records the mutating admin gRPC calls and their status code.
+func (a *App) auditUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if a.audit == nil {
+		return handler(ctx, req)
+	}
+	if _, ok := auditedAdminMethods[info.FullMethod]; !ok {
+		return handler(ctx, req)
+	}
+	act := grpcActor(ctx)
+	rsp, err := handler(context.WithValue(ctx, auditActorKey{}, act), req)
+	st := status.Convert(err)
+	rec := &audit.Record{
+		Kind:   audit.KindAPI,
+		User:   act.user,
+		Client: act.client,
+		Method: info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:],
+		Path:   info.FullMethod,
+		Status: int(st.Code()),
+		Result: audit.ResultSuccess,
+	}
+	if err != nil {
+		rec.Result = audit.ResultFailure
+		rec.Error = st.Message()
+	}
+	a.writeAudit(rec)
+	return rsp, err
+}
+
+// statusWriter keeps the status code written to a http.ResponseWriter.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,115 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openconfig/gnmic/audit"
+	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/proto/admin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	a := New()
+	a.Config.APIServer = &config.APIServer{
+		Auth: &config.APIAuth{
+			Users: []*config.APIUser{{Username: "admin", Password: "secret1"}},
+		},
+	}
+	var records []*audit.Record
+	var err error
+	a.audit, err = audit.New(&audit.Config{}, func(r *audit.Record) { records = append(records, r) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.routes()
+
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:secret1"))
+	for _, rq := range []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: http.MethodGet, path: "/api/v1/config/targets"},
+		{method: http.MethodPost, path: "/api/v1/config/targets", body: `{"name":"router1","address":"10.0.0.1:57400"}`},
+		{method: http.MethodDelete, path: "/api/v1/config/targets/router1"},
+	} {
+		req := httptest.NewRequest(rq.method, rq.path, strings.NewReader(rq.body))
+		req.Header.Set("Authorization", auth)
+		a.router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 audit records, got %d: %+v", len(records), records)
+	}
+	for i, method := range []string{http.MethodPost, http.MethodDelete} {
+		r := records[i]
+		if r.Kind != audit.KindAPI || r.User != "admin" || r.Method != method || r.Status == 0 {
+			t.Errorf("unexpected record %d: %+v", i, r)
+		}
+	}
+}
+
+func TestAuditUnaryInterceptor(t *testing.T) {
+	a := New()
+	var records []*audit.Record
+	var err error
+	a.audit, err = audit.New(&audit.Config{}, func(r *audit.Record) { records = append(records, r) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newAdminTestClient(t, a, grpc.UnaryInterceptor(a.auditUnaryInterceptor))
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:secret1"))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", auth)
+
+	_, err = c.AddTarget(ctx, &admin.AddTargetRequest{
+		Target: &admin.TargetConfig{Name: "router1", Address: "10.0.0.1:57400"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// read-only calls are not recorded
+	_, err = c.ListTargets(ctx, &admin.ListTargetsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.DeleteTarget(ctx, &admin.DeleteTargetRequest{Name: "router2"})
+	if err == nil {
+		t.Fatal("expected an error deleting an unknown target")
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 audit records, got %d: %+v", len(records), records)
+	}
+	for i, want := range []struct {
+		method string
+		code   codes.Code
+		result string
+	}{
+		{method: "AddTarget", code: codes.OK, result: audit.ResultSuccess},
+		{method: "DeleteTarget", code: codes.NotFound, result: audit.ResultFailure},
+	} {
+		r := records[i]
+		if r.Kind != audit.KindAPI || r.User != "admin" || r.Client == "" ||
+			r.Method != want.method || r.Path != "/gnmic.admin.Admin/"+want.method ||
+			r.Status != int(want.code) || r.Result != want.result {
+			t.Errorf("unexpected record %d: %+v", i, r)
+		}
+	}
+	if records[1].Error == "" {
+		t.Errorf("expected the failed call error to be recorded: %+v", records[1])
+	}
+}
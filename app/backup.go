@@ -0,0 +1,290 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/backup"
+	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/grpctunnel/tunnel"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func (a *App) BackupPreRunE(cmd *cobra.Command, args []string) error {
+	a.Config.SetLocalFlagsFromFile(cmd)
+	a.Config.LocalFlags.BackupPath = config.SanitizeArrayFlagValue(a.Config.LocalFlags.BackupPath)
+
+	a.createCollectorDialOpts()
+	return a.initTunnelServer(tunnel.ServerConfig{
+		AddTargetHandler:    a.tunServerAddTargetHandler,
+		DeleteTargetHandler: a.tunServerDeleteTargetHandler,
+		RegisterHandler:     a.tunServerRegisterHandler,
+		Handler:             a.tunServerHandler,
+	})
+}
+
+func (a *App) BackupRunE(cmd *cobra.Command, args []string) error {
+	defer a.InitBackupFlags(cmd)
+
+	_, err := a.GetTargets()
+	if err != nil {
+		return fmt.Errorf("failed getting targets config: %v", err)
+	}
+	err = a.Config.GetBackup()
+	if err != nil {
+		return fmt.Errorf("failed reading backup config: %v", err)
+	}
+	req, err := a.Config.CreateBackupGetRequest()
+	if err != nil {
+		return err
+	}
+	a.backupStore, err = backup.NewStore(a.ctx, a.Config.Backup.Storage, a.Logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize backup storage: %v", err)
+	}
+	if !a.Config.LocalFlags.BackupOnce {
+		err = a.Config.GetAPIServer()
+		if err != nil {
+			return err
+		}
+		a.startAPIServer()
+	}
+	// a single backup run is not coordinated
+	if a.Config.Backup.LeaderOnly && !a.Config.LocalFlags.BackupOnce {
+		err = a.Config.GetClustering()
+		if err != nil {
+			return err
+		}
+		if a.Config.Clustering == nil {
+			return errors.New("backup leader-only requires a clustering configuration")
+		}
+		err = a.InitLocker()
+		if err != nil {
+			return err
+		}
+		a.runSingleton(a.ctx, "backup", func(ctx context.Context) {
+			a.backupLoop(ctx, req)
+		})
+		return nil
+	}
+	a.backupLoop(a.ctx, req)
+	return nil
+}
+
+// backupLoop backs up all the targets every backup interval
+// until ctx is done, or once if the --once flag is set.
+func (a *App) backupLoop(ctx context.Context, req *gnmi.GetRequest) {
+	ticker := time.NewTicker(a.Config.Backup.Interval)
+	defer ticker.Stop()
+	for {
+		a.backupTargets(ctx, req)
+		if a.Config.LocalFlags.BackupOnce {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *App) InitBackupFlags(cmd *cobra.Command) {
+	cmd.ResetFlags()
+
+	cmd.Flags().DurationVarP(&a.Config.LocalFlags.BackupInterval, "interval", "", 0, "interval between backups, overrides the backup config interval")
+	cmd.Flags().StringArrayVarP(&a.Config.LocalFlags.BackupPath, "path", "", []string{}, "backup request paths, overrides the backup config paths")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.BackupDir, "dir", "", "", "store the snapshots as files in this directory, overrides the backup config storage")
+	cmd.Flags().BoolVarP(&a.Config.LocalFlags.BackupOnce, "once", "", false, "run a single backup of all targets and exit")
+
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
+	})
+}
+
+// backupTargets takes a snapshot of all the configured targets.
+func (a *App) backupTargets(ctx context.Context, req *gnmi.GetRequest) {
+	a.configLock.RLock()
+	targets := make([]*types.TargetConfig, 0, len(a.Config.Targets))
+	for _, tc := range a.Config.Targets {
+		targets = append(targets, tc)
+	}
+	a.configLock.RUnlock()
+
+	a.wg.Add(len(targets))
+	for _, tc := range targets {
+		go func(tc *types.TargetConfig) {
+			defer a.wg.Done()
+			err := a.backupTarget(ctx, tc, req)
+			if err != nil {
+				a.Logger.Printf("target %q backup failed: %v", tc.Name, err)
+			}
+		}(tc)
+	}
+	a.wg.Wait()
+}
+
+func (a *App) backupTarget(ctx context.Context, tc *types.TargetConfig, req *gnmi.GetRequest) error {
+	ts := time.Now()
+	rsp, err := a.ClientGet(ctx, tc, req)
+	if err != nil {
+		return err
+	}
+	b, err := backupSnapshot(rsp)
+	if err != nil {
+		return err
+	}
+	snap, err := a.backupStore.Save(ctx, tc.Name, ts, b)
+	if err != nil {
+		return err
+	}
+	a.Logger.Printf("target %q backup saved: %s", tc.Name, snap.ID)
+	n, err := backup.Prune(ctx, a.backupStore, tc.Name, a.Config.Backup.Retention)
+	if err != nil {
+		return fmt.Errorf("failed to apply retention: %v", err)
+	}
+	if n > 0 && a.Config.Backup.Debug {
+		a.Logger.Printf("target %q: deleted %d old snapshot(s)", tc.Name, n)
+	}
+	return nil
+}
+
+// backupSnapshot flattens a GetResponse into an indented JSON object
+// with a key per leaf path, the keys are sorted to keep the snapshots diff friendly.
+func backupSnapshot(rsp *gnmi.GetResponse) ([]byte, error) {
+	flat, err := formatters.ResponsesFlat(rsp)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(flat, "", "  ")
+}
+
+// backupRequestTarget returns the name of the target from the request
+// after checking the backup mode is enabled and the target is known.
+func (a *App) backupRequestTarget(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if a.backupStore == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{"backup is not enabled"}})
+		return "", false
+	}
+	id := mux.Vars(r)["id"]
+	a.configLock.RLock()
+	_, ok := a.Config.Targets[id]
+	a.configLock.RUnlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q not found", id)}})
+		return "", false
+	}
+	return id, true
+}
+
+func (a *App) handleBackupSnapshotsGet(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.backupRequestTarget(w, r)
+	if !ok {
+		return
+	}
+	snaps, err := a.backupStore.List(r.Context(), id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	a.handlerCommonGet(w, r, snaps)
+}
+
+func (a *App) handleBackupSnapshotGet(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.backupRequestTarget(w, r)
+	if !ok {
+		return
+	}
+	b, err := a.backupStore.Read(r.Context(), id, mux.Vars(r)["snapshot"])
+	if err != nil {
+		a.handleBackupReadError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+type backupDiff struct {
+	From    string           `json:"from,omitempty"`
+	To      string           `json:"to,omitempty"`
+	Changes []*backup.Change `json:"changes"`
+}
+
+// handleBackupDiffGet returns the changes between the snapshots set
+// with the query parameters from and to.
+// They default to the two most recent snapshots of the target.
+func (a *App) handleBackupDiffGet(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.backupRequestTarget(w, r)
+	if !ok {
+		return
+	}
+	df := &backupDiff{
+		From: r.URL.Query().Get("from"),
+		To:   r.URL.Query().Get("to"),
+	}
+	if df.From == "" || df.To == "" {
+		snaps, err := a.backupStore.List(r.Context(), id)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+			return
+		}
+		if len(snaps) < 2 {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("target %q has less than 2 snapshots", id)}})
+			return
+		}
+		if df.To == "" {
+			df.To = snaps[len(snaps)-1].ID
+		}
+		if df.From == "" {
+			df.From = snaps[len(snaps)-2].ID
+		}
+	}
+	from, err := a.backupStore.Read(r.Context(), id, df.From)
+	if err != nil {
+		a.handleBackupReadError(w, err)
+		return
+	}
+	to, err := a.backupStore.Read(r.Context(), id, df.To)
+	if err != nil {
+		a.handleBackupReadError(w, err)
+		return
+	}
+	df.Changes, err = backup.Diff(from, to)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	a.handlerCommonGet(w, r, df)
+}
+
+func (a *App) handleBackupReadError(w http.ResponseWriter, err error) {
+	if errors.Is(err, backup.ErrSnapshotNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+}
@@ -11,6 +11,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmi/proto/gnmi_ext"
@@ -50,6 +51,7 @@ func (a *App) CapRunE(cmd *cobra.Command, args []string) error {
 			a.AddTargetConfig(tc)
 		}
 	}
+	a.startRun(cmd.Name(), cancel)
 	numTargets := len(a.Config.Targets)
 	a.errCh = make(chan error, numTargets*2)
 	a.wg.Add(numTargets)
@@ -57,7 +59,7 @@ func (a *App) CapRunE(cmd *cobra.Command, args []string) error {
 		go a.ReqCapabilities(ctx, tc)
 	}
 	a.wg.Wait()
-	return a.checkErrors()
+	return a.finishRun(a.checkErrors())
 }
 
 func (a *App) ReqCapabilities(ctx context.Context, tc *types.TargetConfig) {
@@ -73,7 +75,9 @@ func (a *App) ReqCapabilities(ctx context.Context, tc *types.TargetConfig) {
 	}
 
 	a.Logger.Printf("sending gNMI CapabilityRequest: gnmi_ext.Extension='%v' to %s", ext, tc.Name)
+	start := time.Now()
 	response, err := a.ClientCapabilities(ctx, tc, ext...)
+	a.recordResult(tc.Name, time.Since(start), err)
 	if err != nil {
 		a.logError(fmt.Errorf("target %q, capabilities request failed: %v", tc.Name, err))
 		return
@@ -66,6 +66,11 @@ func (a *App) leaderKey() string {
 	return fmt.Sprintf("gnmic/%s/leader", a.Config.Clustering.ClusterName)
 }
 
+// leader reports whether the instance holds the cluster leader lock.
+func (a *App) leader() bool {
+	return a.isLeader
+}
+
 func (a *App) inCluster() bool {
 	if a.Config == nil {
 		return false
@@ -87,28 +92,69 @@ func (a *App) apiServiceRegistration() {
 		tags = append(tags, "protocol=http")
 	}
 	tags = append(tags, a.Config.Clustering.Tags...)
+	if a.Config.Clustering.Zone != "" {
+		tags = append(tags, fmt.Sprintf("zone=%s", a.Config.Clustering.Zone))
+	}
+
+	serviceReg := a.newServiceRegistration(
+		a.Config.Clustering.InstanceName+"-api",
+		fmt.Sprintf("%s-%s", a.Config.Clustering.ClusterName, apiServiceName),
+		addr, p, tags)
+	a.registerService(serviceReg)
+}
 
+// newServiceRegistration builds the registration of an instance service,
+// its address defaults to addr if the clustering service-address is not set.
+// The service meta and TTL check are set from the clustering config.
+func (a *App) newServiceRegistration(id, name, addr string, port int, tags []string) *lockers.ServiceRegistration {
 	serviceReg := &lockers.ServiceRegistration{
-		ID:      a.Config.Clustering.InstanceName + "-api",
-		Name:    fmt.Sprintf("%s-%s", a.Config.Clustering.ClusterName, apiServiceName),
-		Address: a.Config.Clustering.ServiceAddress,
-		Port:    p,
-		Tags:    tags,
-		TTL:     5 * time.Second,
+		ID:              id,
+		Name:            name,
+		Address:         a.Config.Clustering.ServiceAddress,
+		Port:            port,
+		Tags:            tags,
+		Meta:            a.serviceMeta(),
+		TTL:             a.Config.Clustering.CheckInterval,
+		DeregisterAfter: a.Config.Clustering.CheckInterval * time.Duration(a.Config.Clustering.MaxFail),
 	}
 	if serviceReg.Address == "" {
 		serviceReg.Address = addr
 	}
-	var err error
+	return serviceReg
+}
+
+// serviceMeta returns the meta attached to the instance services,
+// including its capacity limits. The clustering meta overrides the built-in keys.
+func (a *App) serviceMeta() map[string]string {
+	meta := map[string]string{
+		"version":       version,
+		"cluster-name":  a.Config.Clustering.ClusterName,
+		"instance-name": a.Config.Clustering.InstanceName,
+	}
+	for k, v := range a.capacityMeta() {
+		meta[k] = v
+	}
+	for k, v := range a.Config.Clustering.Meta {
+		meta[k] = v
+	}
+	return meta
+}
+
+// registerService registers the service in the locker,
+// retrying until it succeeds or the app context is done.
+func (a *App) registerService(serviceReg *lockers.ServiceRegistration) {
 	a.Logger.Printf("registering service %+v", serviceReg)
+	a.sm.Lock()
+	a.registeredServices[serviceReg.ID] = struct{}{}
+	a.sm.Unlock()
 	for {
 		select {
 		case <-a.ctx.Done():
 			return
 		default:
-			err = a.locker.Register(a.ctx, serviceReg)
+			err := a.locker.Register(a.ctx, serviceReg)
 			if err != nil {
-				a.Logger.Printf("api service registration failed: %v", err)
+				a.Logger.Printf("service %q registration failed: %v", serviceReg.ID, err)
 				time.Sleep(retryTimer)
 				continue
 			}
@@ -117,6 +163,28 @@ func (a *App) apiServiceRegistration() {
 	}
 }
 
+// deregisterServices removes the services registered by this instance from the locker.
+func (a *App) deregisterServices() {
+	if a.locker == nil {
+		return
+	}
+	a.sm.Lock()
+	defer a.sm.Unlock()
+	for id := range a.registeredServices {
+		err := a.locker.Deregister(id)
+		if err != nil {
+			a.Logger.Printf("failed to deregister service %q: %v", id, err)
+		}
+		delete(a.registeredServices, id)
+	}
+}
+
+// Shutdown deregisters the instance services then cancels the app context.
+func (a *App) Shutdown() {
+	a.deregisterServices()
+	a.Cfn()
+}
+
 func (a *App) startCluster() {
 	if a.locker == nil || a.Config.Clustering == nil {
 		return
@@ -124,6 +192,11 @@ func (a *App) startCluster() {
 
 	// register api service
 	go a.apiServiceRegistration()
+	if a.Config.Clustering.MaxMsgRate > 0 {
+		go a.measureMsgRate()
+	}
+	// register the prometheus outputs scrape endpoints
+	a.prometheusServicesRegistration()
 
 	leaderKey := a.leaderKey()
 	var err error
@@ -262,6 +335,10 @@ func (a *App) dispatchTargets(ctx context.Context) {
 				continue
 			}
 			var err error
+			unassigned := 0
+			if a.Config.Clustering.StickyTargets {
+				a.recordTargetInstances()
+			}
 			//a.m.RLock()
 			dctx, cancel := context.WithTimeout(ctx, a.Config.Clustering.TargetsWatchTimer)
 			for _, tc := range a.Config.Targets {
@@ -275,14 +352,19 @@ func (a *App) dispatchTargets(ctx context.Context) {
 					// break from the targets loop
 					break
 				}
-				if err == errNoMoreSuitableServices {
+				if err == errNoMoreSuitableServices || err == errClusterAtCapacity {
 					// target has no suitable matching services,
 					// continue to next target without wait
+					unassigned++
 					continue
 				}
 			}
 			//a.m.RUnlock()
 			cancel()
+			clusterUnassignedTargets.Set(float64(unassigned))
+			if unassigned > 0 {
+				a.Logger.Printf("[cluster-leader] %d target(s) left unassigned", unassigned)
+			}
 			select {
 			case <-ctx.Done():
 				return
@@ -311,7 +393,7 @@ func (a *App) dispatchTarget(ctx context.Context, tc *types.TargetConfig) error
 	a.Logger.Printf("dispatching target %q", tc.Name)
 	denied := make([]string, 0)
 SELECTSERVICE:
-	service, err := a.selectService(tc.Tags, denied...)
+	service, err := a.selectService(tc, denied...)
 	if err != nil {
 		return err
 	}
@@ -360,6 +442,7 @@ WAIT:
 	if instance, ok := values[key]; ok {
 		if instance == instanceName {
 			a.Logger.Printf("[cluster-leader] lock %q acquired by %q", key, instanceName)
+			a.targetInstances[tc.Name] = instanceName
 			return nil
 		}
 	}
@@ -376,29 +459,48 @@ WAIT:
 	goto WAIT
 }
 
-func (a *App) selectService(tags []string, denied ...string) (*lockers.Service, error) {
-	numServices := len(a.apiServices)
+func (a *App) selectService(tc *types.TargetConfig, denied ...string) (*lockers.Service, error) {
+	if len(a.apiServices) == 0 {
+		return nil, errNotFound
+	}
+	services, err := a.servicesWithCapacity(a.eligibleServices(tc))
+	if err != nil {
+		a.Logger.Printf("cannot assign target %q: %v", tc.Name, err)
+		return nil, err
+	}
+	numServices := len(services)
 	switch numServices {
 	case 0:
-		return nil, errNotFound
+		a.Logger.Printf("no instance matches target %q affinity rules", tc.Name)
+		return nil, errNoMoreSuitableServices
 	case 1:
-		for _, s := range a.apiServices {
+		for _, s := range services {
 			return s, nil
 		}
 	default:
+		// select the instance the target was last assigned to
+		if s := a.stickyService(tc.Name, services, denied); s != nil {
+			a.Logger.Printf("selected service name: %s", s.ID)
+			return s, nil
+		}
 		// select instance by tags
 		matchingInstances := make([]string, 0)
-		tagCount := a.getInstancesTagsMatches(tags)
+		tagCount := a.getInstancesTagsMatches(tc.Tags)
+		for n := range tagCount {
+			if _, ok := services[n+"-api"]; !ok {
+				delete(tagCount, n)
+			}
+		}
 		if len(tagCount) > 0 {
 			matchingInstances = a.getHighestTagsMatches(tagCount)
-			a.Logger.Printf("current instances with tags=%v: %+v", tags, matchingInstances)
+			a.Logger.Printf("current instances with tags=%v: %+v", tc.Tags, matchingInstances)
 		} else {
-			for n := range a.apiServices {
+			for n := range services {
 				matchingInstances = append(matchingInstances, strings.TrimSuffix(n, "-api"))
 			}
 		}
 		if len(matchingInstances) == 1 {
-			return a.apiServices[fmt.Sprintf("%s-api", matchingInstances[0])], nil
+			return services[fmt.Sprintf("%s-api", matchingInstances[0])], nil
 		}
 		// select instance by load
 		load, err := a.getInstancesLoad(matchingInstances...)
@@ -410,7 +512,7 @@ func (a *App) selectService(tags []string, denied ...string) (*lockers.Service,
 		if len(load) == 0 {
 			for _, n := range matchingInstances {
 				a.Logger.Printf("selected service name: %s", n)
-				return a.apiServices[fmt.Sprintf("%s-api", n)], nil
+				return services[fmt.Sprintf("%s-api", n)], nil
 			}
 		}
 		for _, d := range denied {
@@ -423,10 +525,10 @@ func (a *App) selectService(tags []string, denied ...string) (*lockers.Service,
 		}
 		ss := a.getLowLoadInstance(load)
 		a.Logger.Printf("selected service name: %s", ss)
-		if srv, ok := a.apiServices[fmt.Sprintf("%s-api", ss)]; ok {
+		if srv, ok := services[fmt.Sprintf("%s-api", ss)]; ok {
 			return srv, nil
 		}
-		return a.apiServices[ss], nil
+		return services[ss], nil
 	}
 	return nil, errNotFound
 }
@@ -568,6 +670,7 @@ func (a *App) deleteTarget(ctx context.Context, name string) error {
 			continue
 		}
 
+		a.setAPIRequestAuth(req)
 		rsp, err := client.Do(req)
 		if err != nil {
 			rsp.Body.Close()
@@ -613,6 +716,7 @@ func (a *App) assignTarget(ctx context.Context, tc *types.TargetConfig, service
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	a.setAPIRequestAuth(req)
 	resp, err := client.Do(req)
 	if err != nil {
 		return err
@@ -627,6 +731,7 @@ func (a *App) assignTarget(ctx context.Context, tc *types.TargetConfig, service
 	if err != nil {
 		return err
 	}
+	a.setAPIRequestAuth(req)
 	resp, err = client.Do(req)
 	if err != nil {
 		return err
@@ -669,6 +774,7 @@ func (a *App) unassignTarget(ctx context.Context, name string, serviceID string)
 			a.Logger.Printf("failed to create HTTP request: %v", err)
 			continue
 		}
+		a.setAPIRequestAuth(req)
 		rsp, err := client.Do(req)
 		if err != nil {
 			rsp.Body.Close()
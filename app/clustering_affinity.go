@@ -0,0 +1,87 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"strings"
+
+	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/lockers"
+	"github.com/openconfig/gnmic/types"
+)
+
+// eligibleServices returns the API services of the instances
+// the target can be assigned to according to the clustering affinity rules.
+func (a *App) eligibleServices(tc *types.TargetConfig) map[string]*lockers.Service {
+	rules := make([]*config.AffinityRule, 0)
+	for _, r := range a.Config.Clustering.Affinity {
+		if r.Applies(tc, a.Config.TargetGroups) {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) == 0 {
+		return a.apiServices
+	}
+	services := make(map[string]*lockers.Service)
+OUTER:
+	for id, s := range a.apiServices {
+		instance := strings.TrimSuffix(id, "-api")
+		zone := serviceTag(s, "zone")
+		for _, r := range rules {
+			if !r.Allows(instance, zone) {
+				continue OUTER
+			}
+		}
+		services[id] = s
+	}
+	return services
+}
+
+// stickyService returns the service of the instance the target was last assigned to,
+// if sticky targets are enabled and that instance is still eligible and not denied.
+func (a *App) stickyService(name string, services map[string]*lockers.Service, denied []string) *lockers.Service {
+	if !a.Config.Clustering.StickyTargets {
+		return nil
+	}
+	instance, ok := a.targetInstances[name]
+	if !ok {
+		return nil
+	}
+	id := instance + "-api"
+	for _, d := range denied {
+		if d == id {
+			return nil
+		}
+	}
+	return services[id]
+}
+
+// recordTargetInstances records the instance currently holding each target lock,
+// so that a target is assigned to the same instance after its lock is released.
+func (a *App) recordTargetInstances() {
+	mapping, err := a.getTargetToInstanceMapping()
+	if err != nil {
+		a.Logger.Printf("failed to read the targets locks: %v", err)
+		return
+	}
+	for t, instance := range mapping {
+		a.targetInstances[t] = instance
+	}
+}
+
+// serviceTag returns the value of the service tag "key=value".
+func serviceTag(s *lockers.Service, key string) string {
+	for _, t := range s.Tags {
+		k, v, ok := strings.Cut(t, "=")
+		if ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
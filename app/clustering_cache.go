@@ -0,0 +1,94 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openconfig/gnmic/cache"
+	"github.com/openconfig/gnmic/lockers"
+)
+
+const cacheServiceName = "gnmic-cache"
+
+func (a *App) cacheServiceName() string {
+	return fmt.Sprintf("%s-%s", a.Config.Clustering.ClusterName, cacheServiceName)
+}
+
+// startCacheMesh registers the mesh cache address in the locker
+// and replicates the cache writes to the other cluster instances.
+func (a *App) startCacheMesh(ctx context.Context, mc cache.Mesh) {
+	if !a.inCluster() || a.locker == nil {
+		return
+	}
+	addr, port, err := net.SplitHostPort(mc.ListenAddress())
+	if err != nil {
+		a.Logger.Printf("invalid cache mesh address %q: %v", mc.ListenAddress(), err)
+		return
+	}
+	p, _ := strconv.Atoi(port)
+	serviceReg := a.newServiceRegistration(
+		a.Config.Clustering.InstanceName+"-cache",
+		a.cacheServiceName(),
+		addr, p,
+		[]string{
+			fmt.Sprintf("cluster-name=%s", a.Config.Clustering.ClusterName),
+			fmt.Sprintf("instance-name=%s", a.Config.Clustering.InstanceName),
+		})
+	go a.registerService(serviceReg)
+	go a.watchCachePeers(ctx, mc)
+}
+
+func (a *App) watchCachePeers(ctx context.Context, mc cache.Mesh) {
+START:
+	select {
+	case <-ctx.Done():
+		return
+	default:
+		peersChan := make(chan []*lockers.Service)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case srvs, ok := <-peersChan:
+					if !ok {
+						return
+					}
+					mc.SetPeers(a.cachePeers(srvs))
+				}
+			}
+		}()
+		err := a.locker.WatchServices(ctx, a.cacheServiceName(), []string{"cluster-name=" + a.Config.Clustering.ClusterName}, peersChan, a.Config.Clustering.ServicesWatchTimer)
+		if err != nil {
+			a.Logger.Printf("failed getting cache mesh services: %v", err)
+			time.Sleep(retryTimer)
+			goto START
+		}
+	}
+}
+
+// cachePeers returns the cache mesh addresses of the other instances by instance name.
+func (a *App) cachePeers(srvs []*lockers.Service) map[string]string {
+	peers := make(map[string]string, len(srvs))
+	for _, s := range srvs {
+		for _, t := range s.Tags {
+			k, name, _ := strings.Cut(t, "=")
+			if k == "instance-name" && name != a.Config.Clustering.InstanceName {
+				peers[name] = s.Address
+			}
+		}
+	}
+	return peers
+}
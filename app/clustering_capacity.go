@@ -0,0 +1,166 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/lockers"
+)
+
+const msgRateUpdatePeriod = 10 * time.Second
+
+var errClusterAtCapacity = errors.New("all suitable instances are at capacity")
+
+// rateMeter measures the rate of received messages,
+// the rate is computed over the period between two updates.
+type rateMeter struct {
+	count uint64
+
+	m        sync.Mutex
+	last     uint64
+	lastTime time.Time
+	rate     float64
+}
+
+func newRateMeter() *rateMeter {
+	return &rateMeter{lastTime: time.Now()}
+}
+
+func (r *rateMeter) mark() {
+	atomic.AddUint64(&r.count, 1)
+}
+
+func (r *rateMeter) update(now time.Time) float64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+	count := atomic.LoadUint64(&r.count)
+	if d := now.Sub(r.lastTime).Seconds(); d > 0 {
+		r.rate = float64(count-r.last) / d
+	}
+	r.last = count
+	r.lastTime = now
+	return r.rate
+}
+
+// Rate returns the messages rate measured by the last update, in messages per second.
+func (r *rateMeter) Rate() float64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.rate
+}
+
+// measureMsgRate periodically updates the instance received messages rate.
+func (a *App) measureMsgRate() {
+	ticker := time.NewTicker(msgRateUpdatePeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case now := <-ticker.C:
+			clusterMsgRate.Set(a.msgRate.update(now))
+		}
+	}
+}
+
+// capacityMeta returns the instance capacity limits advertised in its services meta.
+func (a *App) capacityMeta() map[string]string {
+	meta := make(map[string]string)
+	if a.Config.Clustering.MaxTargets > 0 {
+		meta["max-targets"] = strconv.Itoa(a.Config.Clustering.MaxTargets)
+	}
+	if a.Config.Clustering.MaxMsgRate > 0 {
+		meta["max-msg-rate"] = strconv.FormatFloat(a.Config.Clustering.MaxMsgRate, 'f', -1, 64)
+	}
+	return meta
+}
+
+// serviceMaxTargets returns the max-targets advertised by the service, 0 if it has none.
+func serviceMaxTargets(s *lockers.Service) int {
+	max, _ := strconv.Atoi(s.Meta["max-targets"])
+	return max
+}
+
+// servicesWithCapacity filters out the services of the instances
+// holding as many targets as their max-targets.
+// If all services are at capacity, the capacity fallback decides
+// whether the target is left unassigned or assigned to the least loaded instance.
+func (a *App) servicesWithCapacity(services map[string]*lockers.Service) (map[string]*lockers.Service, error) {
+	limited := false
+	for _, s := range services {
+		if serviceMaxTargets(s) > 0 {
+			limited = true
+			break
+		}
+	}
+	if !limited {
+		return services, nil
+	}
+	load, err := a.getInstancesLoad()
+	if err != nil {
+		return nil, err
+	}
+	available := make(map[string]*lockers.Service)
+	servicesLoad := make(map[string]int)
+	for id, s := range services {
+		l := load[strings.TrimSuffix(id, "-api")]
+		servicesLoad[id] = l
+		max := serviceMaxTargets(s)
+		if max > 0 && l >= max {
+			continue
+		}
+		available[id] = s
+	}
+	if len(available) > 0 {
+		return available, nil
+	}
+	if a.Config.Clustering.CapacityFallback == config.CapacityFallbackLeastLoaded {
+		id := a.getLowLoadInstance(servicesLoad)
+		a.Logger.Printf("all suitable instances are at capacity, falling back to the least loaded service %q", id)
+		return map[string]*lockers.Service{id: services[id]}, nil
+	}
+	return nil, errClusterAtCapacity
+}
+
+// admitTarget returns an error if the instance is at capacity
+// and cannot start a new target.
+func (a *App) admitTarget(name string) error {
+	if a.Config.Clustering == nil || a.Config.Clustering.MaxMsgRate <= 0 {
+		return nil
+	}
+	if rate := a.msgRate.Rate(); rate >= a.Config.Clustering.MaxMsgRate {
+		clusterRejectedTargets.Inc()
+		return fmt.Errorf("instance %q is at capacity, receiving %.1f msg/s out of %.1f: target %q rejected",
+			a.Config.Clustering.InstanceName, rate, a.Config.Clustering.MaxMsgRate, name)
+	}
+	return nil
+}
+
+// capacityWarnings returns a warning for each cluster member holding
+// as many targets as its max-targets, and one if all members are at capacity.
+func capacityWarnings(members []clusterMember) []string {
+	warnings := make([]string, 0)
+	for _, m := range members {
+		if m.MaxTargets > 0 && m.NumberOfLockedTargets >= m.MaxTargets {
+			warnings = append(warnings, fmt.Sprintf("instance %q is at capacity: %d/%d targets", m.Name, m.NumberOfLockedTargets, m.MaxTargets))
+		}
+	}
+	if len(members) > 0 && len(warnings) == len(members) {
+		warnings = append(warnings, "all the cluster instances are at capacity")
+	}
+	return warnings
+}
@@ -0,0 +1,204 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/gnmic/outputs/prometheus_output/prometheus_output"
+	"github.com/openconfig/gnmic/utils"
+)
+
+const (
+	prometheusServiceName = "gnmic-prometheus"
+	prometheusOutputType  = "prometheus"
+	defaultMetricsPath    = "/metrics"
+)
+
+// prometheusEndpoint is the scrape endpoint of a prometheus output.
+type prometheusEndpoint struct {
+	Output  string `json:"output,omitempty"`
+	Address string `json:"address"`
+	Path    string `json:"path"`
+	Scheme  string `json:"scheme,omitempty"`
+}
+
+// instanceAssignment lists the targets a cluster instance serves
+// and the prometheus endpoints exposing their metrics.
+type instanceAssignment struct {
+	Instance   string                `json:"instance"`
+	Targets    []string              `json:"targets"`
+	Prometheus []*prometheusEndpoint `json:"prometheus,omitempty"`
+}
+
+// promSDTargetGroup is a target group of the Prometheus HTTP service discovery.
+type promSDTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+func (a *App) prometheusServiceName() string {
+	return fmt.Sprintf("%s-%s", a.Config.Clustering.ClusterName, prometheusServiceName)
+}
+
+// prometheusServicesRegistration registers the scrape endpoint of each prometheus output
+// in the locker, so that the cluster members can tell which instance exposes which targets metrics.
+func (a *App) prometheusServicesRegistration() {
+	for name, cfg := range a.Config.Outputs {
+		if outType, _ := cfg["type"].(string); outType != prometheusOutputType {
+			continue
+		}
+		listen, path, scheme, err := prometheus_output.ScrapeEndpoint(cfg)
+		if err != nil {
+			a.Logger.Printf("output %q: failed to read the scrape endpoint: %v", name, err)
+			continue
+		}
+		if utils.IsUnixSocketAddress(listen) {
+			continue
+		}
+		addr, port, err := net.SplitHostPort(listen)
+		if err != nil {
+			a.Logger.Printf("output %q: invalid listen address %q: %v", name, listen, err)
+			continue
+		}
+		p, _ := strconv.Atoi(port)
+		serviceReg := a.newServiceRegistration(
+			fmt.Sprintf("%s-prometheus-%s", a.Config.Clustering.InstanceName, name),
+			a.prometheusServiceName(),
+			addr, p,
+			[]string{
+				fmt.Sprintf("cluster-name=%s", a.Config.Clustering.ClusterName),
+				fmt.Sprintf("instance-name=%s", a.Config.Clustering.InstanceName),
+				fmt.Sprintf("output-name=%s", name),
+				fmt.Sprintf("metrics-path=%s", path),
+				fmt.Sprintf("scheme=%s", scheme),
+			})
+		go a.registerService(serviceReg)
+	}
+}
+
+// clusterAssignment returns the targets served by each cluster instance
+// along with the scrape endpoints of its prometheus outputs, sorted by instance name.
+func (a *App) clusterAssignment(ctx context.Context) ([]*instanceAssignment, error) {
+	mapping, err := a.getTargetToInstanceMapping()
+	if err != nil {
+		return nil, err
+	}
+	services, err := a.locker.GetServices(ctx, a.prometheusServiceName(),
+		[]string{"cluster-name=" + a.Config.Clustering.ClusterName})
+	if err != nil {
+		return nil, err
+	}
+	instances := make(map[string]*instanceAssignment)
+	instance := func(name string) *instanceAssignment {
+		if _, ok := instances[name]; !ok {
+			instances[name] = &instanceAssignment{Instance: name, Targets: make([]string, 0)}
+		}
+		return instances[name]
+	}
+	for t, name := range mapping {
+		ia := instance(name)
+		ia.Targets = append(ia.Targets, t)
+	}
+	for _, s := range services {
+		pe := &prometheusEndpoint{Address: s.Address, Path: defaultMetricsPath}
+		var name string
+		for _, t := range s.Tags {
+			k, v, _ := strings.Cut(t, "=")
+			switch k {
+			case "instance-name":
+				name = v
+			case "output-name":
+				pe.Output = v
+			case "metrics-path":
+				pe.Path = v
+			case "scheme":
+				pe.Scheme = v
+			}
+		}
+		if name == "" {
+			continue
+		}
+		ia := instance(name)
+		ia.Prometheus = append(ia.Prometheus, pe)
+	}
+	result := make([]*instanceAssignment, 0, len(instances))
+	for _, ia := range instances {
+		sort.Strings(ia.Targets)
+		sort.Slice(ia.Prometheus, func(i, j int) bool {
+			return ia.Prometheus[i].Output < ia.Prometheus[j].Output
+		})
+		result = append(result, ia)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Instance < result[j].Instance
+	})
+	return result, nil
+}
+
+// handleClusteringAssignmentGet returns the targets served by each cluster instance.
+func (a *App) handleClusteringAssignmentGet(w http.ResponseWriter, r *http.Request) {
+	if a.Config.Clustering == nil {
+		return
+	}
+	result, err := a.clusterAssignment(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	a.handlerCommonGet(w, r, result)
+}
+
+// handleClusteringPrometheusSDGet returns the prometheus outputs scrape endpoints
+// in the Prometheus HTTP service discovery format.
+// Only the instances serving at least one target are returned,
+// each target metrics are then scraped from a single instance.
+func (a *App) handleClusteringPrometheusSDGet(w http.ResponseWriter, r *http.Request) {
+	if a.Config.Clustering == nil {
+		return
+	}
+	result, err := a.clusterAssignment(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	groups := make([]*promSDTargetGroup, 0, len(result))
+	for _, ia := range result {
+		if len(ia.Targets) == 0 {
+			continue
+		}
+		for _, pe := range ia.Prometheus {
+			g := &promSDTargetGroup{
+				Targets: []string{pe.Address},
+				Labels: map[string]string{
+					"__metrics_path__": pe.Path,
+					"gnmic_cluster":    a.Config.Clustering.ClusterName,
+					"gnmic_instance":   ia.Instance,
+				},
+			}
+			if pe.Output != "" {
+				g.Labels["gnmic_output"] = pe.Output
+			}
+			if pe.Scheme != "" {
+				g.Labels["__scheme__"] = pe.Scheme
+			}
+			groups = append(groups, g)
+		}
+	}
+	a.handlerCommonGet(w, r, groups)
+}
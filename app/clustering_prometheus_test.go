@@ -0,0 +1,195 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/lockers"
+)
+
+// fakeLocker serves fixed locks and services,
+// the methods it does not override panic.
+type fakeLocker struct {
+	lockers.Locker
+	locks    map[string]string
+	services []*lockers.Service
+
+	m            sync.Mutex
+	registered   []*lockers.ServiceRegistration
+	deregistered []string
+	// number of Lock calls and the channel closed to lose the last acquired lock
+	locked   int
+	unlocked []string
+	lost     chan struct{}
+}
+
+func (l *fakeLocker) List(_ context.Context, prefix string) (map[string]string, error) {
+	res := make(map[string]string)
+	for k, v := range l.locks {
+		if strings.HasPrefix(k, prefix) {
+			res[k] = v
+		}
+	}
+	return res, nil
+}
+
+func (l *fakeLocker) GetServices(context.Context, string, []string) ([]*lockers.Service, error) {
+	return l.services, nil
+}
+
+func (l *fakeLocker) Register(_ context.Context, s *lockers.ServiceRegistration) error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.registered = append(l.registered, s)
+	return nil
+}
+
+func (l *fakeLocker) Lock(context.Context, string, []byte) (bool, error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.locked++
+	l.lost = make(chan struct{})
+	return true, nil
+}
+
+func (l *fakeLocker) KeepLock(context.Context, string) (chan struct{}, chan error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.lost, make(chan error)
+}
+
+func (l *fakeLocker) Unlock(_ context.Context, key string) error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.unlocked = append(l.unlocked, key)
+	return nil
+}
+
+func (l *fakeLocker) Deregister(id string) error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.deregistered = append(l.deregistered, id)
+	return nil
+}
+
+func newClusterTestApp(t *testing.T, l lockers.Locker) *App {
+	a := New()
+	t.Cleanup(a.Cfn)
+	a.Config.FileConfig.Set("clustering", map[string]interface{}{
+		"cluster-name":  "c1",
+		"instance-name": "gnmic1",
+		"locker":        map[string]interface{}{"type": "consul"},
+	})
+	err := a.Config.GetClustering()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.locker = l
+	return a
+}
+
+func TestClusterPrometheusSD(t *testing.T) {
+	l := &fakeLocker{
+		locks: map[string]string{
+			"gnmic/c1/targets/router1": "gnmic1",
+			"gnmic/c1/targets/router2": "gnmic2",
+			"gnmic/c1/targets/router3": "gnmic1",
+		},
+		services: []*lockers.Service{
+			{ID: "gnmic1-prometheus-prom", Address: "10.0.0.1:9804", Tags: []string{"instance-name=gnmic1", "output-name=prom", "metrics-path=/metrics"}},
+			{ID: "gnmic2-prometheus-prom", Address: "10.0.0.2:9804", Tags: []string{"instance-name=gnmic2", "output-name=prom", "metrics-path=/metrics", "scheme=https"}},
+			// an instance without targets is not scraped
+			{ID: "gnmic3-prometheus-prom", Address: "10.0.0.3:9804", Tags: []string{"instance-name=gnmic3", "output-name=prom", "metrics-path=/metrics"}},
+		},
+	}
+	a := newClusterTestApp(t, l)
+	a.routes()
+
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/cluster/assignment", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("assignment: unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	var assignment []*instanceAssignment
+	err := json.Unmarshal(rec.Body.Bytes(), &assignment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assignment) != 3 ||
+		!reflect.DeepEqual(assignment[0].Targets, []string{"router1", "router3"}) ||
+		!reflect.DeepEqual(assignment[1].Targets, []string{"router2"}) ||
+		len(assignment[2].Targets) != 0 {
+		t.Fatalf("unexpected assignment: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/cluster/prometheus/sd", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("sd: unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	var groups []*promSDTargetGroup
+	err = json.Unmarshal(rec.Body.Bytes(), &groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []*promSDTargetGroup{
+		{
+			Targets: []string{"10.0.0.1:9804"},
+			Labels:  map[string]string{"__metrics_path__": "/metrics", "gnmic_cluster": "c1", "gnmic_instance": "gnmic1", "gnmic_output": "prom"},
+		},
+		{
+			Targets: []string{"10.0.0.2:9804"},
+			Labels:  map[string]string{"__metrics_path__": "/metrics", "__scheme__": "https", "gnmic_cluster": "c1", "gnmic_instance": "gnmic2", "gnmic_output": "prom"},
+		},
+	}
+	if !reflect.DeepEqual(groups, exp) {
+		t.Errorf("unexpected service discovery groups: %s", rec.Body.String())
+	}
+}
+
+func TestPrometheusServicesRegistration(t *testing.T) {
+	l := new(fakeLocker)
+	a := newClusterTestApp(t, l)
+	a.Config.Outputs = map[string]map[string]interface{}{
+		"prom": {"type": "prometheus", "listen": "10.0.0.1:9273", "path": "/gnmic"},
+		"file": {"type": "file"},
+	}
+	a.prometheusServicesRegistration()
+	deadline := time.Now().Add(time.Second)
+	for {
+		l.m.Lock()
+		n := len(l.registered)
+		l.m.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+	if len(l.registered) != 1 {
+		t.Fatalf("expected a single registered service, got %d", len(l.registered))
+	}
+	s := l.registered[0]
+	if s.Name != "c1-gnmic-prometheus" || s.Address != "10.0.0.1" || s.Port != 9273 {
+		t.Errorf("unexpected registration: %+v", s)
+	}
+	if !reflect.DeepEqual(s.Tags, []string{"cluster-name=c1", "instance-name=gnmic1", "output-name=prom", "metrics-path=/gnmic", "scheme=http"}) {
+		t.Errorf("unexpected registration tags: %v", s.Tags)
+	}
+}
@@ -0,0 +1,64 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (a *App) taskKey(task string) string {
+	return fmt.Sprintf("gnmic/%s/tasks/%s", a.Config.Clustering.ClusterName, task)
+}
+
+// runSingleton runs fn while this instance holds the task lock,
+// so that a single cluster member runs the task at a time.
+// If the lock is lost, fn's context is canceled and the lock is acquired again
+// before fn is restarted. runSingleton returns when fn returns or ctx is done.
+func (a *App) runSingleton(ctx context.Context, task string, fn func(ctx context.Context)) {
+	key := a.taskKey(task)
+	for {
+		ok, err := a.locker.Lock(ctx, key, []byte(a.Config.Clustering.InstanceName))
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil || !ok {
+			if err != nil {
+				a.Logger.Printf("failed to acquire task %q lock: %v", task, err)
+			}
+			time.Sleep(retryTimer)
+			continue
+		}
+		a.Logger.Printf("%q acquired task %q lock", a.Config.Clustering.InstanceName, task)
+		tctx, cancel := context.WithCancel(ctx)
+		doneCh, errCh := a.locker.KeepLock(tctx, key)
+		go func() {
+			select {
+			case <-doneCh:
+				a.Logger.Printf("%q lost task %q lock", a.Config.Clustering.InstanceName, task)
+			case err := <-errCh:
+				a.Logger.Printf("%q failed to maintain task %q lock: %v", a.Config.Clustering.InstanceName, task, err)
+			case <-tctx.Done():
+			}
+			cancel()
+		}()
+		fn(tctx)
+		lost := tctx.Err() != nil && ctx.Err() == nil
+		cancel()
+		if lost {
+			continue
+		}
+		err = a.locker.Unlock(context.Background(), key)
+		if err != nil {
+			a.Logger.Printf("failed to release task %q lock: %v", task, err)
+		}
+		return
+	}
+}
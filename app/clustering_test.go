@@ -9,11 +9,16 @@
 package app
 
 import (
+	"context"
+	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmic/config"
 	"github.com/openconfig/gnmic/lockers"
+	"github.com/openconfig/gnmic/types"
 )
 
 var testSetGetInstancesTagsMatches = map[string]struct {
@@ -254,3 +259,187 @@ func TestGetHighestTagsMatches(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceRegistrationShutdown(t *testing.T) {
+	l := new(fakeLocker)
+	a := newClusterTestApp(t, l)
+	a.Config.Clustering.Meta = map[string]string{"datacenter": "dc1", "version": "custom"}
+
+	a.registerService(a.newServiceRegistration("gnmic1-api", "c1-gnmic-api", "10.0.0.1", 7890, nil))
+	if len(l.registered) != 1 {
+		t.Fatalf("expected a single registered service, got %d", len(l.registered))
+	}
+	s := l.registered[0]
+	expMeta := map[string]string{
+		"version":       "custom",
+		"datacenter":    "dc1",
+		"cluster-name":  "c1",
+		"instance-name": "gnmic1",
+	}
+	if !reflect.DeepEqual(s.Meta, expMeta) {
+		t.Errorf("unexpected service meta: %v", s.Meta)
+	}
+	// default check interval and max fail
+	if s.TTL != 5*time.Second || s.DeregisterAfter != 15*time.Second {
+		t.Errorf("unexpected TTL check: ttl=%s, deregister-after=%s", s.TTL, s.DeregisterAfter)
+	}
+
+	a.Shutdown()
+	if !reflect.DeepEqual(l.deregistered, []string{"gnmic1-api"}) {
+		t.Errorf("expected the service to be deregistered on shutdown, got %v", l.deregistered)
+	}
+	if a.ctx.Err() == nil {
+		t.Errorf("expected the app context to be canceled on shutdown")
+	}
+}
+
+func TestRunSingleton(t *testing.T) {
+	l := new(fakeLocker)
+	a := newClusterTestApp(t, l)
+	runs := 0
+	a.runSingleton(a.ctx, "backup", func(ctx context.Context) {
+		runs++
+		if runs == 1 {
+			// the lock is lost during the first run
+			l.m.Lock()
+			close(l.lost)
+			l.m.Unlock()
+			<-ctx.Done()
+		}
+	})
+	if runs != 2 || l.locked != 2 {
+		t.Errorf("expected the task to run twice after acquiring the lock again, got %d runs and %d locks", runs, l.locked)
+	}
+	if !reflect.DeepEqual(l.unlocked, []string{"gnmic/c1/tasks/backup"}) {
+		t.Errorf("expected the task lock to be released once the task is done, got %v", l.unlocked)
+	}
+}
+
+func TestCachePeers(t *testing.T) {
+	a := newClusterTestApp(t, new(fakeLocker))
+	peers := a.cachePeers([]*lockers.Service{
+		{ID: "gnmic1-cache", Address: "10.0.0.1:57500", Tags: []string{"cluster-name=c1", "instance-name=gnmic1"}},
+		{ID: "gnmic2-cache", Address: "10.0.0.2:57500", Tags: []string{"cluster-name=c1", "instance-name=gnmic2"}},
+		{ID: "gnmic3-cache", Address: "10.0.0.3:57500", Tags: []string{"cluster-name=c1", "instance-name=gnmic3"}},
+	})
+	exp := map[string]string{"gnmic2": "10.0.0.2:57500", "gnmic3": "10.0.0.3:57500"}
+	if !reflect.DeepEqual(peers, exp) {
+		t.Errorf("expected the other instances as peers, got %v", peers)
+	}
+}
+
+func TestSelectServiceAffinity(t *testing.T) {
+	l := &fakeLocker{
+		locks: map[string]string{
+			"gnmic/c1/targets/router10": "gnmic2",
+		},
+	}
+	a := newClusterTestApp(t, l)
+	a.apiServices = map[string]*lockers.Service{
+		"gnmic1-api": {ID: "gnmic1-api", Tags: []string{"cluster-name=c1", "instance-name=gnmic1", "zone=paris"}},
+		"gnmic2-api": {ID: "gnmic2-api", Tags: []string{"cluster-name=c1", "instance-name=gnmic2", "zone=lyon"}},
+		"gnmic3-api": {ID: "gnmic3-api", Tags: []string{"cluster-name=c1", "instance-name=gnmic3", "zone=lyon"}},
+	}
+	a.Config.Clustering.Affinity = []*config.AffinityRule{
+		{TargetGroup: types.TargetGroup{Targets: []string{"router1"}}, Zones: []string{"lyon"}},
+		{TargetGroup: types.TargetGroup{Targets: []string{"router1"}}, Instances: []string{"gnmic2"}, AntiAffinity: true},
+		{TargetGroup: types.TargetGroup{Targets: []string{"router3"}}, Instances: []string{"gnmic4"}},
+	}
+	a.Config.Clustering.StickyTargets = true
+	a.targetInstances["router2"] = "gnmic2"
+
+	tests := map[string]struct {
+		target string
+		denied []string
+		exp    string
+		err    error
+	}{
+		"affinity_and_anti_affinity": {target: "router1", exp: "gnmic3-api"},
+		"sticky":                     {target: "router2", exp: "gnmic2-api"},
+		// gnmic2 holds a lock, gnmic1 and gnmic3 are the least loaded
+		"sticky_denied":  {target: "router2", denied: []string{"gnmic2-api", "gnmic1-api"}, exp: "gnmic3-api"},
+		"no_eligible":    {target: "router3", err: errNoMoreSuitableServices},
+		"no_restriction": {target: "router4", denied: []string{"gnmic1-api", "gnmic3-api"}, exp: "gnmic2-api"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := a.selectService(&types.TargetConfig{Name: tc.target}, tc.denied...)
+			if tc.err != nil {
+				if err != tc.err {
+					t.Fatalf("expected error %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.ID != tc.exp {
+				t.Errorf("expected service %q, got %q", tc.exp, s.ID)
+			}
+		})
+	}
+}
+
+func TestSelectServiceCapacity(t *testing.T) {
+	l := &fakeLocker{
+		locks: map[string]string{
+			"gnmic/c1/targets/router1": "gnmic1",
+			"gnmic/c1/targets/router2": "gnmic1",
+			"gnmic/c1/targets/router3": "gnmic2",
+		},
+	}
+	a := newClusterTestApp(t, l)
+	a.apiServices = map[string]*lockers.Service{
+		"gnmic1-api": {ID: "gnmic1-api", Meta: map[string]string{"max-targets": "2"}},
+		"gnmic2-api": {ID: "gnmic2-api", Meta: map[string]string{"max-targets": "3"}},
+	}
+	s, err := a.selectService(&types.TargetConfig{Name: "router4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "gnmic2-api" {
+		t.Errorf("expected the instance below its max-targets, got %q", s.ID)
+	}
+	// both instances are at capacity
+	l.locks["gnmic/c1/targets/router4"] = "gnmic2"
+	l.locks["gnmic/c1/targets/router5"] = "gnmic2"
+	_, err = a.selectService(&types.TargetConfig{Name: "router6"})
+	if err != errClusterAtCapacity {
+		t.Fatalf("expected error %v, got %v", errClusterAtCapacity, err)
+	}
+	a.Config.Clustering.CapacityFallback = config.CapacityFallbackLeastLoaded
+	s, err = a.selectService(&types.TargetConfig{Name: "router6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "gnmic1-api" {
+		t.Errorf("expected the least loaded instance, got %q", s.ID)
+	}
+}
+
+func TestAdmitTarget(t *testing.T) {
+	a := newClusterTestApp(t, &fakeLocker{})
+	a.Config.Clustering.MaxMsgRate = 10
+	start := a.msgRate.lastTime
+	for i := 0; i < 50; i++ {
+		a.msgRate.mark()
+	}
+	a.msgRate.update(start.Add(10 * time.Second))
+	if err := a.admitTarget("router1"); err != nil {
+		t.Fatalf("target rejected at 5 msg/s: %v", err)
+	}
+	for i := 0; i < 200; i++ {
+		a.msgRate.mark()
+	}
+	a.msgRate.update(start.Add(20 * time.Second))
+	if err := a.admitTarget("router1"); err == nil {
+		t.Fatal("target admitted above the max-msg-rate")
+	}
+	w := capacityWarnings([]clusterMember{
+		{Name: "gnmic1", NumberOfLockedTargets: 2, MaxTargets: 2},
+		{Name: "gnmic2", NumberOfLockedTargets: 1},
+	})
+	if len(w) != 1 {
+		t.Errorf("expected 1 warning, got %v", w)
+	}
+}
@@ -14,13 +14,15 @@ import (
 	"fmt"
 	"io"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmic/outputs"
 	"github.com/openconfig/gnmic/target"
+	"github.com/openconfig/gnmic/tracing"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -37,19 +39,15 @@ func (a *App) StartCollector(ctx context.Context) {
 	}()
 
 	for t := range a.targetsChan {
-		if a.Config.Debug {
-			a.Logger.Printf("starting target %+v", t)
-		}
 		if t == nil {
 			continue
 		}
+		a.slog.Debug("starting target", "target", t.Config.Name, "config", t.Config)
 		a.operLock.RLock()
 		_, ok := a.activeTargets[t.Config.Name]
 		a.operLock.RUnlock()
 		if ok {
-			if a.Config.Debug {
-				a.Logger.Printf("target %q listener already active", t.Config.Name)
-			}
+			a.slog.Debug("target listener already active", "target", t.Config.Name)
 			continue
 		}
 		a.operLock.Lock()
@@ -65,13 +63,20 @@ func (a *App) StartCollector(ctx context.Context) {
 			for {
 				select {
 				case rsp := <-rspChan:
+					arrival := time.Now()
 					subscribeResponseReceivedCounter.WithLabelValues(t.Config.Name, rsp.SubscriptionConfig.Name).Add(1)
-					if a.Config.Debug {
-						a.Logger.Printf("target %q: gNMI Subscribe Response: %+v", t.Config.Name, rsp)
-					}
+					t.ResponseReceived(rsp.SubscriptionConfig.Name)
+					a.msgRate.mark()
+					a.slog.Debug("gNMI Subscribe Response", "target", t.Config.Name,
+						"subscription", rsp.SubscriptionName, "response", rsp.Response)
+					sctx, span := tracing.StartSpan(ctx, "subscribe.receive",
+						attribute.String("target", t.Config.Name),
+						attribute.String("subscription", rsp.SubscriptionName))
 					err := t.DecodeProtoBytes(rsp.Response)
 					if err != nil {
 						a.Logger.Printf("target %q: failed to decode proto bytes: %v", t.Config.Name, err)
+						tracing.SetError(span, err)
+						span.End()
 						continue
 					}
 					m := outputs.Meta{
@@ -82,14 +87,18 @@ func (a *App) StartCollector(ctx context.Context) {
 					if rsp.SubscriptionConfig.Target != "" {
 						m["subscription-target"] = rsp.SubscriptionConfig.Target
 					}
-					for k, v := range t.Config.EventTags {
-						m[k] = v
-					}
-					if a.subscriptionMode(rsp.SubscriptionName) == subscriptionModeONCE {
-						a.Export(ctx, rsp.Response, m, t.Config.Outputs...)
-					} else {
-						go a.Export(ctx, rsp.Response, m, t.Config.Outputs...)
+					m.AddTargetConfig(t.Config)
+					adjustTimestamp(rsp.SubscriptionConfig.Timestamp, rsp.Response, arrival, m)
+					if a.admitResponse(sctx, rsp.Response, m, t.Config.Outputs) {
+						// ONCE responses are written before the next one is read,
+						// so they are all written, in order, when the collector returns.
+						if a.subscriptionMode(rsp.SubscriptionName) == subscriptionModeONCE {
+							a.exportResponse(withSyncWrite(sctx), rsp.Response, m, t.Config.Outputs...)
+						} else {
+							a.exportResponse(sctx, rsp.Response, m, t.Config.Outputs...)
+						}
 					}
+					span.End()
 					if remainingOnceSubscriptions > 0 {
 						if a.subscriptionMode(rsp.SubscriptionName) == subscriptionModeONCE {
 							switch rsp.Response.Response.(type) {
@@ -109,6 +118,7 @@ func (a *App) StartCollector(ctx context.Context) {
 						a.Logger.Printf("target %q: subscription %s closed stream(EOF)", t.Config.Name, tErr.SubscriptionName)
 					} else {
 						a.Logger.Printf("target %q: subscription %s rcv error: %v", t.Config.Name, tErr.SubscriptionName, tErr.Err)
+						t.SetLastError(tErr.Err)
 					}
 					if remainingOnceSubscriptions > 0 {
 						if a.subscriptionMode(tErr.SubscriptionName) == subscriptionModeONCE {
@@ -142,38 +152,48 @@ func (a *App) StartCollector(ctx context.Context) {
 }
 
 func (a *App) Export(ctx context.Context, rsp *gnmi.SubscribeResponse, m outputs.Meta, outs ...string) {
+	rsp = a.updateFilter.apply(rsp)
 	if rsp == nil {
 		return
 	}
+	last, ok := a.capture.countMessage(rsp)
+	if !ok {
+		return
+	}
+	if last {
+		defer a.stopCapture("received %d messages", a.capture.maxMessages)
+	}
+	if a.tui != nil {
+		a.tui.update(m["source"], rsp)
+	}
+	a.streams.publish(rsp, m)
+	ctx, span := tracing.StartSpan(ctx, "export")
+	defer span.End()
 	go a.updateCache(ctx, rsp, m)
-	wg := new(sync.WaitGroup)
+	// outputs created by InitOutput queue the message for their workers,
+	// so writing to them only blocks if their queue is full,
+	// unless ctx was returned by withSyncWrite.
+	a.operLock.RLock()
+	defer a.operLock.RUnlock()
 	// target has no outputs explicitly defined
 	if len(outs) == 0 {
-		wg.Add(len(a.Outputs))
 		for _, o := range a.Outputs {
-			go func(o outputs.Output) {
-				defer wg.Done()
-				defer a.operLock.RUnlock()
-				a.operLock.RLock()
-				o.Write(ctx, rsp, m)
-			}(o)
+			o.Write(ctx, rsp, m)
 		}
-		wg.Wait()
 		return
 	}
 	// write to the outputs defined under the target
 	for _, name := range outs {
-		a.operLock.RLock()
 		if o, ok := a.Outputs[name]; ok {
-			wg.Add(1)
-			go func(o outputs.Output) {
-				defer wg.Done()
-				o.Write(ctx, rsp, m)
-			}(o)
+			o.Write(ctx, rsp, m)
 		}
-		a.operLock.RUnlock()
 	}
-	wg.Wait()
+}
+
+func writeOutput(ctx context.Context, name string, o outputs.Output, msg proto.Message, m outputs.Meta) {
+	ctx, span := tracing.StartSpan(ctx, "output.write", attribute.String("output", name))
+	defer span.End()
+	o.Write(ctx, msg, m)
 }
 
 func (a *App) updateCache(ctx context.Context, rsp *gnmi.SubscribeResponse, m outputs.Meta) {
@@ -194,11 +214,14 @@ func (a *App) updateCache(ctx context.Context, rsp *gnmi.SubscribeResponse, m ou
 			a.Logger.Printf("response missing target")
 			return
 		}
-		if a.Config.Debug {
-			a.Logger.Printf("updating target %q cache", target)
-		}
+		a.slog.Debug("updating target cache", "target", target)
 		sub := m["subscription-name"]
-		a.c.Write(ctx, sub, &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: r.Update}})
+		ctx, span := tracing.StartSpan(ctx, "cache.write",
+			attribute.String("target", target),
+			attribute.String("subscription", sub))
+		defer span.End()
+		cr := &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: r.Update}}
+		a.c.Write(ctx, sub, cr)
 	}
 }
 
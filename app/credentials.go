@@ -0,0 +1,178 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openconfig/gnmic/credentials"
+	"github.com/openconfig/gnmic/target"
+)
+
+// credentialsProvider returns the initialized credentials provider named name,
+// initializing it on first use.
+func (a *App) credentialsProvider(name string) (credentials.Provider, error) {
+	a.cm.Lock()
+	defer a.cm.Unlock()
+	if p, ok := a.credentialsProviders[name]; ok {
+		return p, nil
+	}
+	cfg, ok := a.Config.CredentialsProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown credentials provider %q", name)
+	}
+	providerType, _ := cfg["type"].(string)
+	initializer, ok := credentials.Providers[providerType]
+	if !ok {
+		return nil, fmt.Errorf("credentials provider %q: unknown type %q", name, providerType)
+	}
+	a.Logger.Printf("initializing credentials provider %q type=%s", name, providerType)
+	p := initializer()
+	err := p.Init(a.ctx, cfg, credentials.WithLogger(a.Logger))
+	if err != nil {
+		return nil, fmt.Errorf("credentials provider %q: %v", name, err)
+	}
+	a.credentialsProviders[name] = p
+	return p, nil
+}
+
+// loadTargetCredentials reads the credentials of target t from its credentials provider
+// and sets them as the target credentials.
+func (a *App) loadTargetCredentials(ctx context.Context, t *target.Target) error {
+	if t.Config.Credentials == nil {
+		return nil
+	}
+	_, err := a.readTargetCredentials(ctx, t)
+	return err
+}
+
+// readTargetCredentials reads the credentials of target t,
+// sets them if they changed and returns true in that case.
+// The target config is not modified, the credentials are used by the next RPCs and dials.
+func (a *App) readTargetCredentials(ctx context.Context, t *target.Target) (bool, error) {
+	name := t.Config.Name
+	p, err := a.credentialsProvider(t.Config.Credentials.Provider)
+	if err != nil {
+		return false, err
+	}
+	c, err := p.Get(ctx, t.Config.Credentials.Secret)
+	if err != nil {
+		return false, fmt.Errorf("target %q: failed to read credentials: %v", name, err)
+	}
+	a.cm.Lock()
+	defer a.cm.Unlock()
+	if c.Equal(a.targetsCredentials[name]) {
+		return false, nil
+	}
+	tcreds, err := targetCredentials(name, c)
+	if err != nil {
+		return false, fmt.Errorf("target %q: failed to apply credentials: %v", name, err)
+	}
+	t.SetCredentials(tcreds)
+	a.targetsCredentials[name] = c
+	return true, nil
+}
+
+// watchTargetCredentials re-reads the credentials of target t at the provider refresh interval.
+// Rotated credentials are used by the next RPCs and redials.
+func (a *App) watchTargetCredentials(ctx context.Context, t *target.Target) {
+	if t.Config.Credentials == nil {
+		return
+	}
+	p, err := a.credentialsProvider(t.Config.Credentials.Provider)
+	if err != nil {
+		return
+	}
+	ticker := time.NewTicker(p.RefreshInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := a.readTargetCredentials(ctx, t)
+			if err != nil {
+				a.Logger.Printf("%v", err)
+				continue
+			}
+			if changed {
+				a.Logger.Printf("target %q credentials rotated", t.Config.Name)
+			}
+		}
+	}
+}
+
+// targetCredentials returns the non empty credentials c of target name.
+// The TLS material is written to files under the OS temp directory,
+// they are replaced atomically so that a concurrent dial never reads a partial file.
+func targetCredentials(name string, c *credentials.Credentials) (*target.Credentials, error) {
+	tc := new(target.Credentials)
+	if c.Username != "" {
+		username := c.Username
+		tc.Username = &username
+	}
+	if c.Password != "" {
+		password := c.Password
+		tc.Password = &password
+	}
+	if c.Token != "" {
+		token := c.Token
+		tc.Token = &token
+	}
+	if len(c.TLSCA)+len(c.TLSCert)+len(c.TLSKey) == 0 {
+		return tc, nil
+	}
+	dir := filepath.Join(os.TempDir(), "gnmic", "credentials", strings.NewReplacer("/", "_", ":", "_").Replace(name))
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range []struct {
+		name string
+		data []byte
+		dst  **string
+	}{
+		{name: "ca.pem", data: c.TLSCA, dst: &tc.TLSCA},
+		{name: "cert.pem", data: c.TLSCert, dst: &tc.TLSCert},
+		{name: "key.pem", data: c.TLSKey, dst: &tc.TLSKey},
+	} {
+		if len(f.data) == 0 {
+			continue
+		}
+		p := filepath.Join(dir, f.name)
+		err = writeFileAtomic(p, f.data)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = &p
+	}
+	return tc, nil
+}
+
+// writeFileAtomic writes data to a temporary file renamed to path.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,169 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	rpprof "runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openconfig/gnmic/membudget"
+	"github.com/openconfig/gnmic/outputs"
+)
+
+const debugDumpTimeFormat = "20060102T150405"
+
+var expvarOnce sync.Once
+
+type debugDumpResponse struct {
+	File string `json:"file,omitempty"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// runtimeStats is a snapshot of the gNMIc process resources usage.
+type runtimeStats struct {
+	Goroutines int `json:"goroutines"`
+	// last GC pause duration
+	GCPause    time.Duration `json:"gc-pause"`
+	NumGC      uint32        `json:"num-gc"`
+	HeapAlloc  uint64        `json:"heap-alloc"`
+	HeapInuse  uint64        `json:"heap-inuse"`
+	HeapObject uint64        `json:"heap-objects"`
+	// buffers usage per output name
+	OutputBuffers map[string]*bufferStats `json:"output-buffers,omitempty"`
+	// write queues usage per output name
+	OutputQueues map[string]*bufferStats `json:"output-queues,omitempty"`
+	// memory budget usage, if configured
+	MemoryBudget *membudget.Stats `json:"memory-budget,omitempty"`
+}
+
+type bufferStats struct {
+	Length   int `json:"length"`
+	Capacity int `json:"capacity"`
+	Workers  int `json:"workers,omitempty"`
+}
+
+// debugRoutes serves the pprof profiles, the expvar variables and
+// the goroutine/heap dump triggers under /debug/.
+func (a *App) debugRoutes() {
+	expvarOnce.Do(func() {
+		expvar.Publish("gnmic", expvar.Func(func() interface{} { return a.runtimeStats() }))
+	})
+	sr := a.router.PathPrefix("/debug").Subrouter()
+	if a.apiAuth != nil {
+		sr.Use(a.authMiddleware)
+	}
+	// the profiles and dumps expose all namespaces
+	sr.Use(func(next http.Handler) http.Handler {
+		return unscopedOnly(next.ServeHTTP)
+	})
+	sr.HandleFunc("/pprof/cmdline", pprof.Cmdline)
+	sr.HandleFunc("/pprof/profile", pprof.Profile)
+	sr.HandleFunc("/pprof/symbol", pprof.Symbol)
+	sr.HandleFunc("/pprof/trace", pprof.Trace)
+	// index and named profiles: heap, goroutine, allocs, block, mutex, threadcreate
+	sr.PathPrefix("/pprof/").HandlerFunc(pprof.Index)
+	sr.Handle("/vars", expvar.Handler())
+	sr.HandleFunc("/dump/{type}", a.handleDebugDump).Methods(http.MethodPost)
+}
+
+// handleDebugDump writes a goroutine or heap dump to the configured dump directory.
+func (a *App) handleDebugDump(w http.ResponseWriter, r *http.Request) {
+	typ := mux.Vars(r)["type"]
+	var debug int
+	switch typ {
+	case "goroutine":
+		// full stack traces of all goroutines
+		debug = 2
+	case "heap":
+		// up to date statistics
+		runtime.GC()
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{fmt.Sprintf("unknown dump type %q, must be one of goroutine or heap", typ)}})
+		return
+	}
+	rsp, err := a.writeDump(typ, debug)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+		return
+	}
+	a.Logger.Printf("%s dump written to %s", typ, rsp.File)
+	json.NewEncoder(w).Encode(rsp)
+}
+
+func (a *App) writeDump(typ string, debug int) (*debugDumpResponse, error) {
+	p := rpprof.Lookup(typ)
+	if p == nil {
+		return nil, fmt.Errorf("unknown profile %q", typ)
+	}
+	name := fmt.Sprintf("gnmic-%s-%s-%d", typ, time.Now().Format(debugDumpTimeFormat), os.Getpid())
+	if debug > 0 {
+		name += ".txt"
+	} else {
+		name += ".pprof"
+	}
+	fname := filepath.Join(a.Config.APIServer.DumpDirectory, name)
+	f, err := os.Create(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	err = p.WriteTo(f, debug)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &debugDumpResponse{File: fname, Size: fi.Size()}, nil
+}
+
+func (a *App) runtimeStats() *runtimeStats {
+	ms := new(runtime.MemStats)
+	runtime.ReadMemStats(ms)
+	rs := &runtimeStats{
+		Goroutines:    runtime.NumGoroutine(),
+		NumGC:         ms.NumGC,
+		HeapAlloc:     ms.HeapAlloc,
+		HeapInuse:     ms.HeapInuse,
+		HeapObject:    ms.HeapObjects,
+		OutputBuffers: make(map[string]*bufferStats),
+		OutputQueues:  make(map[string]*bufferStats),
+		MemoryBudget:  a.budget.Stats(),
+	}
+	if ms.NumGC > 0 {
+		rs.GCPause = time.Duration(ms.PauseNs[(ms.NumGC+255)%256])
+	}
+	a.operLock.RLock()
+	defer a.operLock.RUnlock()
+	for name, o := range a.Outputs {
+		if w, ok := o.(*outputWorkers); ok {
+			l, c := w.QueueSize()
+			rs.OutputQueues[name] = &bufferStats{Length: l, Capacity: c, Workers: w.workers}
+			o = w.Output
+		}
+		if b, ok := o.(outputs.Buffered); ok {
+			l, c := b.BufferSize()
+			rs.OutputBuffers[name] = &bufferStats{Length: l, Capacity: c}
+		}
+	}
+	return rs
+}
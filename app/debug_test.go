@@ -0,0 +1,127 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openconfig/gnmic/config"
+)
+
+func TestDebugRoutes(t *testing.T) {
+	a := New()
+	a.Config.APIServer = &config.APIServer{
+		EnableProfiling: true,
+		DumpDirectory:   t.TempDir(),
+	}
+	a.routes()
+	a.debugRoutes()
+
+	do := func(method, url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		rec := httptest.NewRecorder()
+		a.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/debug/pprof/")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("pprof index: unexpected status %d", rec.Code)
+	}
+	rec = do(http.MethodGet, "/debug/pprof/goroutine?debug=1")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "goroutine profile") {
+		t.Fatalf("pprof goroutine: unexpected response %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, "/debug/vars")
+	vars := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(rec.Body.Bytes(), &vars); err != nil {
+		t.Fatalf("failed to decode expvar response: %v", err)
+	}
+	if _, ok := vars["gnmic"]; !ok {
+		t.Errorf("expvar response is missing the gnmic variable")
+	}
+
+	for _, typ := range []string{"goroutine", "heap"} {
+		rec = do(http.MethodPost, "/debug/dump/"+typ)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s dump: unexpected status %d: %s", typ, rec.Code, rec.Body.String())
+		}
+		rsp := new(debugDumpResponse)
+		if err := json.Unmarshal(rec.Body.Bytes(), rsp); err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Dir(rsp.File) != a.Config.APIServer.DumpDirectory {
+			t.Errorf("%s dump written to unexpected location %q", typ, rsp.File)
+		}
+		fi, err := os.Stat(rsp.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() == 0 || fi.Size() != rsp.Size {
+			t.Errorf("%s dump: unexpected file size %d, response size %d", typ, fi.Size(), rsp.Size)
+		}
+	}
+
+	rec = do(http.MethodPost, "/debug/dump/mutex")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown dump type: expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestDebugRoutesNamespaceScope(t *testing.T) {
+	a := New()
+	a.Config.APIServer = &config.APIServer{
+		EnableProfiling: true,
+		DumpDirectory:   t.TempDir(),
+		Auth: &config.APIAuth{
+			Tokens: []string{"admin"},
+			ScopedTokens: []*config.APIToken{
+				{Token: "team-a", Namespaces: []string{"team-a"}},
+			},
+			Users: []*config.APIUser{
+				{Username: "bob", Password: "secret", Namespaces: []string{"team-b"}},
+			},
+		},
+	}
+	a.routes()
+	a.debugRoutes()
+
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("bob:secret"))
+	for _, tt := range []struct {
+		method string
+		path   string
+		header string
+		code   int
+	}{
+		{http.MethodGet, "/debug/pprof/", "Bearer admin", http.StatusOK},
+		{http.MethodGet, "/debug/pprof/", "Bearer team-a", http.StatusForbidden},
+		{http.MethodGet, "/debug/pprof/goroutine", basic, http.StatusForbidden},
+		{http.MethodGet, "/debug/vars", "Bearer team-a", http.StatusForbidden},
+		{http.MethodPost, "/debug/dump/heap", basic, http.StatusForbidden},
+		{http.MethodGet, "/debug/vars", "", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		a.router.ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("%s %s with %q: expected status %d, got %d", tt.method, tt.path, tt.header, tt.code, rec.Code)
+		}
+	}
+}
@@ -10,11 +10,15 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"os"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/openconfig/gnmi/proto/gnmi"
@@ -39,7 +43,7 @@ func (a *App) InitDiffFlags(cmd *cobra.Command) {
 
 	cmd.Flags().StringArrayVarP(&a.Config.LocalFlags.DiffPath, "path", "", []string{}, "diff request paths")
 	cmd.Flags().StringVarP(&a.Config.LocalFlags.DiffRef, "ref", "", "", "reference gNMI target to compare the other targets to")
-	cmd.MarkFlagRequired("ref")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.DiffRefFile, "ref-file", "", "", "JSON or YAML snapshot file used as reference instead of a gNMI target")
 	cmd.Flags().StringArrayVarP(&a.Config.LocalFlags.DiffCompare, "compare", "", []string{}, "gNMI targets to compare to the reference")
 	cmd.MarkFlagRequired("compare")
 	cmd.Flags().StringVarP(&a.Config.LocalFlags.DiffPrefix, "prefix", "", "", "diff request prefix")
@@ -48,6 +52,7 @@ func (a *App) InitDiffFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&a.Config.LocalFlags.DiffTarget, "target", "", "", "get request target")
 	cmd.Flags().BoolVarP(&a.Config.LocalFlags.DiffSub, "sub", "", false, "use subscribe ONCE mode instead of a get request")
 	cmd.Flags().Uint32VarP(&a.Config.LocalFlags.DiffQos, "qos", "", 0, "QoS marking in case subscribe RPC is used")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.DiffSetRequestFile, "set-request-file", "", "", "write a set request file that converges the compared targets to the reference")
 
 	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
 		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
@@ -62,6 +67,9 @@ func (a *App) DiffPreRunE(cmd *cobra.Command, args []string) error {
 	a.Config.LocalFlags.DiffPath = config.SanitizeArrayFlagValue(a.Config.LocalFlags.DiffPath)
 	a.Config.LocalFlags.DiffModel = config.SanitizeArrayFlagValue(a.Config.LocalFlags.DiffModel)
 	a.Config.LocalFlags.DiffCompare = config.SanitizeArrayFlagValue(a.Config.LocalFlags.DiffCompare)
+	if (a.Config.LocalFlags.DiffRef == "") == (a.Config.LocalFlags.DiffRefFile == "") {
+		return errors.New("exactly one of --ref or --ref-file must be set")
+	}
 
 	a.createCollectorDialOpts()
 	return a.initTunnelServer(tunnel.ServerConfig{
@@ -82,7 +90,7 @@ func (a *App) DiffRunE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed getting diff targets config: %v", err)
 	}
-	if refTarget == nil {
+	if refTarget == nil && a.Config.LocalFlags.DiffRefFile == "" {
 		return fmt.Errorf("failed getting diff reference target config")
 	}
 	if len(targetsConfig) == 0 {
@@ -107,13 +115,18 @@ func (a *App) DiffRunE(cmd *cobra.Command, args []string) error {
 		// )
 	} else {
 		// prompt mode
-		a.AddTargetConfig(refTarget)
+		if refTarget != nil {
+			a.AddTargetConfig(refTarget)
+		}
 		for _, tc := range targetsConfig {
 			a.AddTargetConfig(tc)
 		}
 	}
 
-	numTargets := len(targetsConfig) + 1
+	numTargets := len(targetsConfig)
+	if refTarget != nil {
+		numTargets++
+	}
 	a.errCh = make(chan error, numTargets*2)
 	a.wg.Add(numTargets)
 
@@ -149,40 +162,50 @@ func (a *App) subscribeBasedDiff(ctx context.Context, cmd *cobra.Command, ref *t
 	numCompares := len(compare)
 	refResponse := make([]proto.Message, 0)
 	rspChan := make(chan *targetDiffResponse, numCompares)
-	a.operLock.Lock()
-	refTarget, err := a.initTarget(ref)
-	a.operLock.Unlock()
-	if err != nil {
-		return err
-	}
-
-	go func() {
-		defer a.wg.Done()
-		err = refTarget.CreateGNMIClient(ctx, a.dialOpts...)
+	refName := a.Config.LocalFlags.DiffRefFile
+	if ref == nil {
+		snapshot, err := a.readDiffSnapshot()
 		if err != nil {
-			a.logError(err)
-			return
+			return err
+		}
+		refResponse = append(refResponse, snapshot)
+	} else {
+		refName = ref.Name
+		a.operLock.Lock()
+		refTarget, err := a.initTarget(ref)
+		a.operLock.Unlock()
+		if err != nil {
+			return err
 		}
-		a.Logger.Printf("sending gNMI SubscribeRequest: subscribe='%+v', mode='%+v', encoding='%+v', to %s",
-			subReq.Request, subReq.GetSubscribe().GetMode(), subReq.GetSubscribe().GetEncoding(), ref)
-		rspChan, errChan := refTarget.SubscribeOnceChan(ctx, subReq)
-		for {
-			select {
-			case r := <-rspChan:
-				switch r.Response.(type) {
-				case *gnmi.SubscribeResponse_Update:
-					refResponse = append(refResponse, r)
-				case *gnmi.SubscribeResponse_SyncResponse:
+
+		go func() {
+			defer a.wg.Done()
+			err := refTarget.CreateGNMIClient(ctx, a.dialOpts...)
+			if err != nil {
+				a.logError(err)
+				return
+			}
+			a.Logger.Printf("sending gNMI SubscribeRequest: subscribe='%+v', mode='%+v', encoding='%+v', to %s",
+				subReq.Request, subReq.GetSubscribe().GetMode(), subReq.GetSubscribe().GetEncoding(), ref)
+			rspChan, errChan := refTarget.SubscribeOnceChan(ctx, subReq)
+			for {
+				select {
+				case r := <-rspChan:
+					switch r.Response.(type) {
+					case *gnmi.SubscribeResponse_Update:
+						refResponse = append(refResponse, r)
+					case *gnmi.SubscribeResponse_SyncResponse:
+						return
+					}
+				case err := <-errChan:
+					if err != io.EOF {
+						a.logError(err)
+					}
 					return
 				}
-			case err := <-errChan:
-				if err != io.EOF {
-					a.logError(err)
-				}
-				return
 			}
-		}
-	}()
+		}()
+	}
 
 	for _, tc := range compare {
 		a.operLock.Lock()
@@ -243,13 +266,13 @@ func (a *App) subscribeBasedDiff(ctx context.Context, cmd *cobra.Command, ref *t
 	}
 
 	for _, cr := range rsps {
-		fmt.Fprintf(os.Stderr, "%q vs %q\n", ref.Name, cr.t)
+		fmt.Fprintf(os.Stderr, "%q vs %q\n", refName, cr.t)
 		err = a.responsesDiff(refResponse, cr.rs)
 		if err != nil {
 			a.logError(err)
 		}
 	}
-	return nil
+	return a.writeDiffSetRequests(refResponse, rsps)
 }
 
 func (a *App) getBasedDiff(ctx context.Context, ref *types.TargetConfig, compare []*types.TargetConfig) error {
@@ -262,17 +285,26 @@ func (a *App) getBasedDiff(ctx context.Context, ref *types.TargetConfig, compare
 
 	var refResponse proto.Message
 	numCompares := len(compare)
-
-	go func() {
-		defer a.wg.Done()
-		a.Logger.Printf("sending gNMI GetRequest: prefix='%v', path='%v', type='%v', encoding='%v', models='%+v', extension='%+v' to %s",
-			getReq.Prefix, getReq.Path, getReq.Type, getReq.Encoding, getReq.UseModels, getReq.Extension, ref)
-		refResponse, err = a.ClientGet(ctx, ref, getReq)
+	refName := a.Config.LocalFlags.DiffRefFile
+	if ref == nil {
+		refResponse, err = a.readDiffSnapshot()
 		if err != nil {
-			a.logError(fmt.Errorf("target %q get request failed: %v", ref, err))
-			return
+			return err
 		}
-	}()
+	} else {
+		refName = ref.Name
+		go func() {
+			defer a.wg.Done()
+			a.Logger.Printf("sending gNMI GetRequest: prefix='%v', path='%v', type='%v', encoding='%v', models='%+v', extension='%+v' to %s",
+				getReq.Prefix, getReq.Path, getReq.Type, getReq.Encoding, getReq.UseModels, getReq.Extension, ref)
+			var err error
+			refResponse, err = a.ClientGet(ctx, ref, getReq)
+			if err != nil {
+				a.logError(fmt.Errorf("target %q get request failed: %v", ref, err))
+				return
+			}
+		}()
+	}
 	rspChan := make(chan *targetDiffResponse, numCompares)
 	for _, tc := range compare {
 		go func(tc *types.TargetConfig) {
@@ -303,14 +335,18 @@ func (a *App) getBasedDiff(ctx context.Context, ref *types.TargetConfig, compare
 	sort.Slice(rsps, func(i, j int) bool {
 		return rsps[i].t < rsps[j].t
 	})
+	if refResponse == nil {
+		return fmt.Errorf("no response received from reference %q", refName)
+	}
 	for _, cr := range rsps {
-		fmt.Fprintf(os.Stderr, "%q vs %q\n", ref.Name, cr.t)
+		fmt.Fprintf(os.Stderr, "%q vs %q\n", refName, cr.t)
 		err = a.responsesDiff([]proto.Message{refResponse}, []proto.Message{cr.r})
 		if err != nil {
 			a.logError(err)
 		}
+		cr.rs = []proto.Message{cr.r}
 	}
-	return nil
+	return a.writeDiffSetRequests([]proto.Message{refResponse}, rsps)
 }
 
 func (a *App) responsesDiff(r1, r2 []proto.Message) error {
@@ -325,7 +361,7 @@ func (a *App) responsesDiff(r1, r2 []proto.Message) error {
 	var df diffs
 	for p, v := range rs1 {
 		if v2, ok := rs2[p]; ok {
-			if !reflect.DeepEqual(v, v2) {
+			if !diffValueEqual(v, v2) {
 				df = append(df, diff{add: false, path: p, value: fmt.Sprintf("%v", v)})
 				df = append(df, diff{add: true, path: p, value: fmt.Sprintf("%v", v2)})
 			}
@@ -345,6 +381,60 @@ func (a *App) responsesDiff(r1, r2 []proto.Message) error {
 	return nil
 }
 
+// diffValueEqual compares two flattened values,
+// values that only differ by their numeric type (e.g: a float64 from a JSON snapshot
+// and a uint64 from a gNMI TypedValue) are considered equal.
+func diffValueEqual(v1, v2 interface{}) bool {
+	if reflect.DeepEqual(v1, v2) {
+		return true
+	}
+	// leaf-lists
+	r1, r2 := reflect.ValueOf(v1), reflect.ValueOf(v2)
+	if r1.Kind() == reflect.Slice && r2.Kind() == reflect.Slice {
+		if r1.Len() != r2.Len() {
+			return false
+		}
+		for i := 0; i < r1.Len(); i++ {
+			if !diffValueEqual(r1.Index(i).Interface(), r2.Index(i).Interface()) {
+				return false
+			}
+		}
+		return true
+	}
+	n1, ok := diffNumber(v1)
+	if !ok {
+		return false
+	}
+	n2, ok := diffNumber(v2)
+	if !ok {
+		return false
+	}
+	return n1.Cmp(n2) == 0
+}
+
+// diffNumber returns the value of the integer or float v, exactly.
+// A float32 is taken as the shortest decimal representing it, e.g: 0.1 and not 0.100000001.
+// It returns false if v is not a number, or is NaN or infinite.
+func diffNumber(v interface{}) (*big.Float, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Float).SetInt64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Float).SetUint64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, false
+		}
+		if rv.Kind() == reflect.Float32 {
+			f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'g', -1, 32), 64)
+		}
+		return new(big.Float).SetFloat64(f), true
+	}
+	return nil, false
+}
+
 type diff struct {
 	add   bool
 	path  string
@@ -0,0 +1,271 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/utils"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+// readDiffSnapshot reads the JSON or YAML file set with --ref-file
+// and builds a GetResponse out of it.
+func (a *App) readDiffSnapshot() (*gnmi.GetResponse, error) {
+	b, err := utils.ReadFile(context.TODO(), a.Config.LocalFlags.DiffRefFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading reference file: %v", err)
+	}
+	return parseDiffSnapshot(b, a.Config.LocalFlags.DiffPrefix, a.Config.LocalFlags.DiffPath)
+}
+
+// parseDiffSnapshot parses a JSON or YAML snapshot into a GetResponse.
+// If all the top level keys are paths (start with "/"), each value is set under its path.
+// Otherwise the whole document is the value of the single request path.
+func parseDiffSnapshot(b []byte, prefix string, paths []string) (*gnmi.GetResponse, error) {
+	var v interface{}
+	err := yaml.Unmarshal(b, &v)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing reference file: %v", err)
+	}
+	v = utils.Convert(v)
+	values := make(map[string]interface{})
+	if m, ok := v.(map[string]interface{}); ok && len(m) > 0 && allPathKeys(m) {
+		values = m
+	} else {
+		if len(paths) != 1 {
+			return nil, errors.New("a reference file without top level paths requires exactly one --path")
+		}
+		values[paths[0]] = v
+	}
+	n := &gnmi.Notification{
+		Update: make([]*gnmi.Update, 0, len(values)),
+	}
+	if prefix != "" {
+		n.Prefix, err = utils.ParsePath(prefix)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ps := make([]string, 0, len(values))
+	for p := range values {
+		ps = append(ps, p)
+	}
+	sort.Strings(ps)
+	for _, p := range ps {
+		gp, err := utils.ParsePath(p)
+		if err != nil {
+			return nil, err
+		}
+		jv, err := json.Marshal(values[p])
+		if err != nil {
+			return nil, err
+		}
+		n.Update = append(n.Update, &gnmi.Update{
+			Path: gp,
+			Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: jv}},
+		})
+	}
+	return &gnmi.GetResponse{Notification: []*gnmi.Notification{n}}, nil
+}
+
+func allPathKeys(m map[string]interface{}) bool {
+	for k := range m {
+		if !strings.HasPrefix(k, "/") {
+			return false
+		}
+	}
+	return true
+}
+
+// writeDiffSetRequests writes a set request file per compared target
+// if --set-request-file is set.
+func (a *App) writeDiffSetRequests(ref []proto.Message, rsps []*targetDiffResponse) error {
+	fname := a.Config.LocalFlags.DiffSetRequestFile
+	if fname == "" {
+		return nil
+	}
+	for _, cr := range rsps {
+		req, err := diffSetRequest(ref, cr.rs)
+		if err != nil {
+			return err
+		}
+		var b []byte
+		switch filepath.Ext(fname) {
+		case ".json":
+			b, err = json.MarshalIndent(req, "", "  ")
+		default:
+			b, err = yaml.Marshal(req)
+		}
+		if err != nil {
+			return err
+		}
+		f := fname
+		if len(rsps) > 1 {
+			ext := filepath.Ext(fname)
+			f = fmt.Sprintf("%s_%s%s", strings.TrimSuffix(fname, ext), cr.t, ext)
+		}
+		err = os.WriteFile(f, b, 0644)
+		if err != nil {
+			return err
+		}
+		a.Logger.Printf("set request converging target %q written to %q", cr.t, f)
+	}
+	return nil
+}
+
+// diffSetRequest builds a set request that converges the target
+// that returned rs to the reference ref.
+// The updates are compared by path: replaces are created for the paths
+// missing or different in rs and deletes for the paths only present in rs
+// that do not overlap with a reference path.
+func diffSetRequest(ref, rs []proto.Message) (*config.SetRequestFile, error) {
+	refUpdates, err := indexDiffUpdates(ref)
+	if err != nil {
+		return nil, err
+	}
+	updates, err := indexDiffUpdates(rs)
+	if err != nil {
+		return nil, err
+	}
+	req := &config.SetRequestFile{}
+	for _, p := range sortedUpdatePaths(refUpdates) {
+		ru := refUpdates[p]
+		if u, ok := updates[p]; ok && diffJSONEqual(ru.Value, u.Value) {
+			continue
+		}
+		req.Replaces = append(req.Replaces, ru)
+	}
+	for _, p := range sortedUpdatePaths(updates) {
+		if !overlapsDiffPath(p, refUpdates) {
+			req.Deletes = append(req.Deletes, p)
+		}
+	}
+	return req, nil
+}
+
+// overlapsDiffPath returns true if p is equal to, a parent of or a child of
+// one of the reference paths. Those paths are covered by the reference replaces.
+func overlapsDiffPath(p string, refUpdates map[string]*config.UpdateItem) bool {
+	for rp := range refUpdates {
+		if p == rp || rp == "/" || p == "/" ||
+			strings.HasPrefix(rp, p+"/") || strings.HasPrefix(p, rp+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func indexDiffUpdates(msgs []proto.Message) (map[string]*config.UpdateItem, error) {
+	items := make(map[string]*config.UpdateItem)
+	for _, msg := range msgs {
+		var ns []*gnmi.Notification
+		switch msg := msg.(type) {
+		case *gnmi.GetResponse:
+			ns = msg.GetNotification()
+		case *gnmi.SubscribeResponse:
+			if msg.GetUpdate() != nil {
+				ns = []*gnmi.Notification{msg.GetUpdate()}
+			}
+		}
+		for _, n := range ns {
+			for _, u := range n.GetUpdate() {
+				elems := append(append([]*gnmi.PathElem{}, n.GetPrefix().GetElem()...), u.GetPath().GetElem()...)
+				origin := n.GetPrefix().GetOrigin()
+				if origin == "" {
+					origin = u.GetPath().GetOrigin()
+				}
+				p := sortedXPath(origin, elems)
+				if p == "" {
+					p = "/"
+				}
+				v, enc, err := diffTypedValue(u.GetVal())
+				if err != nil {
+					return nil, err
+				}
+				items[p] = &config.UpdateItem{Path: p, Value: v, Encoding: enc}
+			}
+		}
+	}
+	return items, nil
+}
+
+// diffTypedValue converts a TypedValue into a value and encoding
+// usable in a set request file.
+func diffTypedValue(tv *gnmi.TypedValue) (interface{}, string, error) {
+	switch tv.GetValue().(type) {
+	case *gnmi.TypedValue_JsonIetfVal:
+		var v interface{}
+		err := json.Unmarshal(tv.GetJsonIetfVal(), &v)
+		return v, "json_ietf", err
+	case *gnmi.TypedValue_JsonVal:
+		var v interface{}
+		err := json.Unmarshal(tv.GetJsonVal(), &v)
+		return v, "json", err
+	case *gnmi.TypedValue_LeaflistVal:
+		vs := make([]interface{}, 0, len(tv.GetLeaflistVal().GetElement()))
+		for _, e := range tv.GetLeaflistVal().GetElement() {
+			v, _, err := diffTypedValue(e)
+			if err != nil {
+				return nil, "", err
+			}
+			vs = append(vs, v)
+		}
+		return vs, "json_ietf", nil
+	case *gnmi.TypedValue_StringVal:
+		return tv.GetStringVal(), "json_ietf", nil
+	case *gnmi.TypedValue_AsciiVal:
+		return tv.GetAsciiVal(), "json_ietf", nil
+	case *gnmi.TypedValue_BoolVal:
+		return tv.GetBoolVal(), "json_ietf", nil
+	case *gnmi.TypedValue_IntVal:
+		return tv.GetIntVal(), "json_ietf", nil
+	case *gnmi.TypedValue_UintVal:
+		return tv.GetUintVal(), "json_ietf", nil
+	case *gnmi.TypedValue_DoubleVal:
+		return tv.GetDoubleVal(), "json_ietf", nil
+	case *gnmi.TypedValue_FloatVal:
+		//lint:ignore SA1019 still need GetFloatVal for backward compatibility
+		return tv.GetFloatVal(), "json_ietf", nil
+	}
+	return nil, "", fmt.Errorf("unsupported value type %T", tv.GetValue())
+}
+
+// diffJSONEqual compares two values by their JSON encoding,
+// so that numbers of different types with the same value are equal.
+func diffJSONEqual(v1, v2 interface{}) bool {
+	b1, err := json.Marshal(v1)
+	if err != nil {
+		return false
+	}
+	b2, err := json.Marshal(v2)
+	if err != nil {
+		return false
+	}
+	return string(b1) == string(b2)
+}
+
+func sortedUpdatePaths(m map[string]*config.UpdateItem) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
@@ -0,0 +1,125 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/config"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseDiffSnapshot(t *testing.T) {
+	rsp, err := parseDiffSnapshot([]byte(`
+/interface[name=ethernet-1/1]/admin-state: enable
+/system/name/host-name: srl1
+`), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upds := rsp.GetNotification()[0].GetUpdate()
+	if len(upds) != 2 {
+		t.Fatalf("expected 2 updates, got %d", len(upds))
+	}
+	if got := string(upds[1].GetVal().GetJsonIetfVal()); got != `"srl1"` {
+		t.Errorf("unexpected value %s", got)
+	}
+
+	rsp, err = parseDiffSnapshot([]byte(`{"host-name": "srl1"}`), "/system", []string{"/name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := rsp.GetNotification()[0]
+	if n.GetPrefix().GetElem()[0].GetName() != "system" {
+		t.Errorf("unexpected prefix %v", n.GetPrefix())
+	}
+	if got := string(n.GetUpdate()[0].GetVal().GetJsonIetfVal()); got != `{"host-name":"srl1"}` {
+		t.Errorf("unexpected value %s", got)
+	}
+
+	_, err = parseDiffSnapshot([]byte(`{"host-name": "srl1"}`), "", []string{"/a", "/b"})
+	if err == nil {
+		t.Errorf("expected an error with multiple paths")
+	}
+}
+
+func TestDiffSetRequest(t *testing.T) {
+	ref, err := parseDiffSnapshot([]byte(`
+/interface[name=ethernet-1/1]/mtu: 9000
+/interface[name=ethernet-1/2]/mtu: 1500
+/system/name/host-name: srl1
+`), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := &gnmi.GetResponse{
+		Notification: []*gnmi.Notification{{
+			Update: []*gnmi.Update{
+				{
+					Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}, {Name: "mtu"}}},
+					Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 9000}},
+				},
+				{
+					Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/2"}}, {Name: "mtu"}}},
+					Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 9000}},
+				},
+				{
+					Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/3"}}, {Name: "mtu"}}},
+					Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 1500}},
+				},
+				{
+					Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "system"}, {Name: "name"}}},
+					Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"host-name":"srl2"}`)}},
+				},
+			},
+		}},
+	}
+	req, err := diffSetRequest([]proto.Message{ref}, []proto.Message{target})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &config.SetRequestFile{
+		Replaces: []*config.UpdateItem{
+			{Path: "/interface[name=ethernet-1/2]/mtu", Value: float64(1500), Encoding: "json_ietf"},
+			{Path: "/system/name/host-name", Value: "srl1", Encoding: "json_ietf"},
+		},
+		Deletes: []string{"/interface[name=ethernet-1/3]/mtu"},
+	}
+	if !reflect.DeepEqual(req, want) {
+		t.Errorf("unexpected set request:\ngot:  %+v\nwant: %+v", req, want)
+	}
+}
+
+func TestDiffValueEqual(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		v1, v2 interface{}
+		want   bool
+	}{
+		{name: "same", v1: "up", v2: "up", want: true},
+		{name: "float and uint", v1: float64(1000000), v2: uint64(1000000), want: true},
+		{name: "float and int", v1: float64(-3), v2: int32(-3), want: true},
+		{name: "float32 and float64", v1: float32(0.1), v2: 0.1, want: true},
+		{name: "different numbers", v1: float64(1), v2: uint64(2)},
+		{name: "large uints", v1: uint64(1<<63 + 1), v2: uint64(1<<63 + 2)},
+		{name: "float precision", v1: float64(1 << 53), v2: uint64(1<<53 + 1)},
+		{name: "number and string", v1: "1", v2: int64(1)},
+		{name: "bool and string", v1: true, v2: "true"},
+		{name: "leaf-list", v1: []interface{}{float64(1), "a"}, v2: []interface{}{uint64(1), "a"}, want: true},
+		{name: "leaf-list length", v1: []interface{}{float64(1)}, v2: []interface{}{uint64(1), uint64(2)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := diffValueEqual(tc.v1, tc.v2); got != tc.want {
+				t.Errorf("diffValueEqual(%#v, %#v) = %v, want %v", tc.v1, tc.v2, got, tc.want)
+			}
+		})
+	}
+}
@@ -16,9 +16,11 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/huandu/xstrings"
+	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmic/config"
 	"github.com/openconfig/gnmic/utils"
 	"github.com/openconfig/goyang/pkg/yang"
@@ -33,6 +35,17 @@ type keyOpts struct {
 	snakeCase bool
 }
 
+// options for generating yaml/json payloads from the YANG schema
+type generateOpts struct {
+	keys *keyOpts
+	// skip the config false nodes
+	configOnly bool
+	// skip the nodes with a when condition
+	skipWhen bool
+	// set the list keys to placeholders
+	keyPlaceholders bool
+}
+
 func (ko *keyOpts) format(s string) string {
 	if ko.camelCase {
 		return xstrings.ToCamelCase(s)
@@ -63,9 +76,15 @@ func (a *App) GenerateRunE(cmd *cobra.Command, args []string) error {
 		camelCase: a.Config.LocalFlags.GenerateCamelCase,
 		snakeCase: a.Config.LocalFlags.GenerateSnakeCase,
 	}
+	gOpts := &generateOpts{
+		keys:            kOpts,
+		configOnly:      a.Config.GenerateConfigOnly,
+		skipWhen:        a.Config.GenerateSkipWhen,
+		keyPlaceholders: a.Config.GenerateKeyPlaceholders,
+	}
 	for _, e := range a.SchemaTree.Dir {
 		e.FixChoice()
-		nm := toMap(e, a.Config.GenerateConfigOnly, kOpts)
+		nm := toMap(e, gOpts)
 		if nm == nil {
 			continue
 		}
@@ -75,7 +94,7 @@ func (a *App) GenerateRunE(cmd *cobra.Command, args []string) error {
 			for k, v := range nm {
 				m[kOpts.format(k)] = v
 			}
-		case []interface{}, string:
+		default:
 			m[kOpts.format(e.Name)] = nm
 		}
 	}
@@ -163,9 +182,15 @@ func (a *App) GenerateSetRequestRunE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	m := make(map[string]interface{})
+	gOpts := &generateOpts{
+		keys:            new(keyOpts),
+		configOnly:      true,
+		skipWhen:        a.Config.GenerateSkipWhen,
+		keyPlaceholders: a.Config.GenerateKeyPlaceholders,
+	}
 	for _, e := range a.SchemaTree.Dir {
 		e.FixChoice()
-		nm := toMap(e, true, new(keyOpts))
+		nm := toMap(e, gOpts)
 		if nm == nil {
 			continue
 		}
@@ -204,6 +229,8 @@ func (a *App) InitGenerateFlags(cmd *cobra.Command) {
 	// persistent flags
 	cmd.PersistentFlags().StringVarP(&a.Config.LocalFlags.GenerateOutput, "output", "o", "", "output file, defaults to stdout")
 	cmd.PersistentFlags().BoolVarP(&a.Config.LocalFlags.GenerateJSON, "json", "j", false, "generate output as JSON format instead of YAML")
+	cmd.PersistentFlags().BoolVarP(&a.Config.LocalFlags.GenerateSkipWhen, "skip-when", "", false, "skip the YANG nodes with a when condition")
+	cmd.PersistentFlags().BoolVarP(&a.Config.LocalFlags.GenerateKeyPlaceholders, "key-placeholders", "", false, "set the lists keys to placeholders and generate the set request values per list entry")
 	// local flags
 	cmd.Flags().BoolVarP(&a.Config.LocalFlags.GenerateConfigOnly, "config-only", "", false, "generate output from YANG config nodes only")
 	cmd.Flags().StringVarP(&a.Config.LocalFlags.GeneratePath, "path", "", "", "generate marshaled YANG body under specified path")
@@ -235,18 +262,35 @@ func (a *App) generateYangSchema(dirs, files, excludes []string) error {
 			return err
 		}
 	}
-	if errors := a.modules.Process(); len(errors) > 0 {
+	return a.buildSchemaTree(excludes)
+}
+
+// buildSchemaTree processes the YANG modules read into a.modules
+// and sets them as a.SchemaTree, skipping the ones matching excludes.
+func (a *App) buildSchemaTree(excludes []string) error {
+	root, err := a.schemaTree(a.modules, excludes)
+	if err != nil {
+		return err
+	}
+	a.SchemaTree = root
+	return nil
+}
+
+// schemaTree processes the YANG modules read into ms and returns
+// a root entry holding them, skipping the ones matching excludes.
+func (a *App) schemaTree(ms *yang.Modules, excludes []string) (*yang.Entry, error) {
+	if errors := ms.Process(); len(errors) > 0 {
 		for _, e := range errors {
 			fmt.Fprintf(os.Stderr, "yang processing error: %v\n", e)
 		}
-		return fmt.Errorf("yang processing failed with %d errors", len(errors))
+		return nil, fmt.Errorf("yang processing failed with %d errors", len(errors))
 	}
 	// Keep track of the top level modules we read in.
 	// Those are the only modules we want to print below.
 	mods := map[string]*yang.Module{}
 	var names []string
 
-	for _, m := range a.modules.Modules {
+	for _, m := range ms.Modules {
 		if mods[m.Name] == nil {
 			mods[m.Name] = m
 			names = append(names, m.Name)
@@ -258,12 +302,12 @@ func (a *App) generateYangSchema(dirs, files, excludes []string) error {
 		entries[x] = yang.ToEntry(mods[n])
 	}
 
-	a.SchemaTree = buildRootEntry()
+	root := buildRootEntry()
 	excludeRegexes := make([]*regexp.Regexp, 0, len(excludes))
 	for _, e := range excludes {
 		r, err := regexp.Compile(e)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		excludeRegexes = append(excludeRegexes, r)
 	}
@@ -278,10 +322,10 @@ func (a *App) generateYangSchema(dirs, files, excludes []string) error {
 		}
 		if !skip {
 			updateAnnotation(entry)
-			a.SchemaTree.Dir[entry.Name] = entry
+			root.Dir[entry.Name] = entry
 		}
 	}
-	return nil
+	return root, nil
 }
 
 func (a *App) createSetRequestFile(m map[string]interface{}) (*config.SetRequestFile, error) {
@@ -311,7 +355,7 @@ func (a *App) createSetRequestFile(m map[string]interface{}) (*config.SetRequest
 		return setReqFile, nil
 	}
 	for _, p := range a.Config.GenerateSetRequestReplacePath {
-		uItem, err := pathToUpdateItem(p, m, new(keyOpts))
+		uItem, err := a.setRequestUpdateItem(p, m)
 		if err != nil {
 			return nil, err
 		}
@@ -319,7 +363,7 @@ func (a *App) createSetRequestFile(m map[string]interface{}) (*config.SetRequest
 		setReqFile.Replaces = append(setReqFile.Replaces, uItem)
 	}
 	for _, p := range a.Config.GenerateSetRequestUpdatePath {
-		uItem, err := pathToUpdateItem(p, m, new(keyOpts))
+		uItem, err := a.setRequestUpdateItem(p, m)
 		if err != nil {
 			return nil, err
 		}
@@ -329,6 +373,81 @@ func (a *App) createSetRequestFile(m map[string]interface{}) (*config.SetRequest
 	return setReqFile, nil
 }
 
+// setRequestUpdateItem returns the update item of path p.
+// With key placeholders, a path pointing to a list is turned into
+// a path to a single list entry, its value being the entry itself.
+func (a *App) setRequestUpdateItem(p string, m map[string]interface{}) (*config.UpdateItem, error) {
+	if !a.Config.GenerateKeyPlaceholders {
+		return pathToUpdateItem(p, m, new(keyOpts))
+	}
+	gp, err := utils.ParsePath(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse xpath %q: %v", p, err)
+	}
+	uItem, err := pathToUpdateItem(p, m, new(keyOpts))
+	if err != nil {
+		return nil, err
+	}
+	e := setKeyPlaceholders(a.SchemaTree, gp)
+	uItem.Path = xpath(gp)
+	if e == nil || !e.IsList() {
+		return uItem, nil
+	}
+	items, ok := uItem.Value.([]interface{})
+	if !ok || len(items) != 1 {
+		return uItem, nil
+	}
+	entry, ok := items[0].(map[string]interface{})
+	if !ok {
+		return uItem, nil
+	}
+	// copy the entry to set its keys to the path values
+	value := make(map[string]interface{}, len(entry))
+	for k, v := range entry {
+		value[k] = v
+	}
+	for k, v := range gp.GetElem()[len(gp.GetElem())-1].GetKey() {
+		if _, ok := value[k]; ok {
+			value[k] = v
+		}
+	}
+	uItem.Value = value
+	return uItem, nil
+}
+
+// setKeyPlaceholders sets the missing keys of the lists in path gp
+// to placeholders and returns the schema node gp points to,
+// nil if it is not found in the schema root.
+func setKeyPlaceholders(root *yang.Entry, gp *gnmi.Path) *yang.Entry {
+	var e *yang.Entry
+	for i, pe := range gp.GetElem() {
+		if i == 0 {
+			e = schemaTopLevel(root, pe.GetName())
+		} else {
+			e = schemaChild(e, pe.GetName())
+		}
+		if e == nil {
+			return nil
+		}
+		if !e.IsList() {
+			continue
+		}
+		for _, k := range strings.Fields(e.Key) {
+			if pe.Key == nil {
+				pe.Key = make(map[string]string)
+			}
+			if _, ok := pe.Key[k]; !ok {
+				pe.Key[k] = keyPlaceholder(k)
+			}
+		}
+	}
+	return e
+}
+
+func keyPlaceholder(name string) string {
+	return "<" + name + ">"
+}
+
 func buildRootEntry() *yang.Entry {
 	return &yang.Entry{
 		Name: "root",
@@ -379,32 +498,36 @@ func updateAnnotation(entry *yang.Entry) {
 	}
 }
 
-func toMap(e *yang.Entry, configOnly bool, kopts *keyOpts) interface{} {
+func toMap(e *yang.Entry, opts *generateOpts) interface{} {
 	if e == nil {
 		return nil
 	}
-	if e.Config == yang.TSFalse && configOnly {
+	if opts.configOnly && isState(e) {
+		return nil
+	}
+	if opts.skipWhen && hasWhen(e) {
 		return nil
 	}
 	m := make(map[string]interface{})
 	switch {
 	case e.Dir == nil && e.ListAttr != nil: // leaf-list
-		fallthrough
+		return leafListValue(e)
 	case e.Dir == nil: // leaf
-		if e.Config == yang.TSFalse && configOnly {
-			return nil
+		if opts.keyPlaceholders && isListKey(e) {
+			return keyPlaceholder(e.Name)
 		}
-		return e.Default
+		return leafValue(e)
 	case e.ListAttr != nil: // list
 		for n, child := range e.Dir {
-			gChild := toMap(child, configOnly, kopts)
+			gChild := toMap(child, opts)
 			switch gChild := gChild.(type) {
 			case map[string]interface{}:
 				for k, v := range gChild {
-					m[kopts.format(k)] = v
+					m[opts.keys.format(k)] = v
 				}
-			case []interface{}, string:
-				m[kopts.format(n)] = gChild
+			case nil:
+			default:
+				m[opts.keys.format(n)] = gChild
 			}
 		}
 		return []interface{}{m}
@@ -412,39 +535,133 @@ func toMap(e *yang.Entry, configOnly bool, kopts *keyOpts) interface{} {
 		nm := make(map[string]interface{})
 		for n, child := range e.Dir {
 			if child.IsCase() || child.IsChoice() {
+				if opts.skipWhen && hasWhen(child) {
+					continue
+				}
 				for _, gchild := range child.Dir {
-					nnm := toMap(gchild, configOnly, kopts)
+					nnm := toMap(gchild, opts)
 					switch nnm := nnm.(type) {
 					case map[string]interface{}:
 						if child.IsChoice() {
 							for k, v := range nnm {
-								nm[kopts.format(k)] = v
+								nm[opts.keys.format(k)] = v
 							}
 						}
 					case nil:
 					default:
-						nm[kopts.format(n)] = nnm
+						nm[opts.keys.format(n)] = nnm
 					}
 				}
 				continue
 			}
-			nnm := toMap(child, configOnly, kopts)
+			nnm := toMap(child, opts)
 			if nnm == nil {
 				continue
 			}
-			nm[kopts.format(n)] = nnm
+			nm[opts.keys.format(n)] = nnm
 		}
 		if e.Parent != nil && e.Parent.IsList() && !(e.IsCase() || e.IsChoice()) {
-			m[kopts.format(e.Name)] = nm
+			m[opts.keys.format(e.Name)] = nm
 			return m
 		}
 		for k, v := range nm {
-			m[kopts.format(k)] = v
+			m[opts.keys.format(k)] = v
 		}
 		return m
 	}
 }
 
+// leafValue returns the default value of leaf e,
+// an empty string if it has none.
+func leafValue(e *yang.Entry) interface{} {
+	dv, ok := e.SingleDefaultValue()
+	if !ok {
+		return ""
+	}
+	return typedDefault(e.Type, dv)
+}
+
+// leafListValue returns the default values of leaf-list e.
+func leafListValue(e *yang.Entry) interface{} {
+	dvs := e.DefaultValues()
+	vs := make([]interface{}, 0, len(dvs))
+	for _, dv := range dvs {
+		vs = append(vs, typedDefault(e.Type, dv))
+	}
+	return vs
+}
+
+// typedDefault converts the default value v to a boolean or a number
+// based on type t. 64-bit integers and decimal64 values are kept as strings,
+// as in JSON_IETF.
+func typedDefault(t *yang.YangType, v string) interface{} {
+	if t == nil {
+		return v
+	}
+	switch t.Kind {
+	case yang.Ybool:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	case yang.Yint8, yang.Yint16, yang.Yint32:
+		if i, err := strconv.ParseInt(v, 0, 32); err == nil {
+			return i
+		}
+	case yang.Yuint8, yang.Yuint16, yang.Yuint32:
+		if i, err := strconv.ParseUint(v, 0, 32); err == nil {
+			return i
+		}
+	}
+	return v
+}
+
+func isListKey(e *yang.Entry) bool {
+	if e.Parent == nil || !e.Parent.IsList() {
+		return false
+	}
+	for _, k := range strings.Fields(e.Parent.Key) {
+		if k == e.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWhen reports whether the node e or the augment
+// it is defined in has a when condition.
+func hasWhen(e *yang.Entry) bool {
+	if _, ok := e.GetWhenXPath(); ok {
+		return true
+	}
+	if e.Node == nil {
+		return false
+	}
+	if aug, ok := e.Node.ParentNode().(*yang.Augment); ok {
+		return aug.When != nil
+	}
+	return false
+}
+
+// mustConditions returns the must conditions of node e.
+func mustConditions(e *yang.Entry) []string {
+	var musts []*yang.Must
+	switch n := e.Node.(type) {
+	case *yang.Container:
+		musts = n.Must
+	case *yang.List:
+		musts = n.Must
+	case *yang.Leaf:
+		musts = n.Must
+	case *yang.LeafList:
+		musts = n.Must
+	}
+	conds := make([]string, 0, len(musts))
+	for _, m := range musts {
+		conds = append(conds, m.Name)
+	}
+	return conds
+}
+
 func pathToUpdateItem(p string, m map[string]interface{}, kopts *keyOpts) (*config.UpdateItem, error) {
 	v, err := getSubMapByPath(p, m, kopts)
 	return &config.UpdateItem{
@@ -0,0 +1,163 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openconfig/goyang/pkg/yang"
+
+	"github.com/openconfig/gnmic/utils"
+)
+
+const testGenerateModule = `
+module test-network {
+  namespace "urn:test:network";
+  prefix tn;
+
+  container network {
+    leaf type { type string; }
+    list vlan {
+      key id;
+      leaf id { type leafref { path "../config/id"; } }
+      container config {
+        leaf id { type uint16; }
+        leaf enabled { type boolean; default true; }
+        leaf mtu { type uint32; default 1500; }
+        leaf-list members { type string; }
+      }
+      container state {
+        config false;
+        leaf status { type string; }
+      }
+    }
+    container l3 {
+      when "../type = 'routed'";
+      leaf address { type string; }
+    }
+  }
+}
+`
+
+func testGenerateSchema(t *testing.T) *yang.Entry {
+	t.Helper()
+	ms := yang.NewModules()
+	err := ms.Parse(testGenerateModule, "test-network.yang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := New().schemaTree(ms, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestToMap(t *testing.T) {
+	root := testGenerateSchema(t)
+	mod := root.Dir["test-network"]
+	tests := map[string]struct {
+		opts *generateOpts
+		want interface{}
+	}{
+		"all": {
+			opts: &generateOpts{keys: new(keyOpts)},
+			want: map[string]interface{}{
+				"network": map[string]interface{}{
+					"type": "",
+					"vlan": []interface{}{map[string]interface{}{
+						"id": "",
+						"config": map[string]interface{}{
+							"id":      "",
+							"enabled": true,
+							"mtu":     uint64(1500),
+							"members": []interface{}{},
+						},
+						"state": map[string]interface{}{"status": ""},
+					}},
+					"l3": map[string]interface{}{"address": ""},
+				},
+			},
+		},
+		"config_only_skip_when_placeholders": {
+			opts: &generateOpts{
+				keys:            new(keyOpts),
+				configOnly:      true,
+				skipWhen:        true,
+				keyPlaceholders: true,
+			},
+			want: map[string]interface{}{
+				"network": map[string]interface{}{
+					"type": "",
+					"vlan": []interface{}{map[string]interface{}{
+						"id": "<id>",
+						"config": map[string]interface{}{
+							"id":      "",
+							"enabled": true,
+							"mtu":     uint64(1500),
+							"members": []interface{}{},
+						},
+					}},
+				},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := toMap(mod, tc.opts)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("unexpected map:\ngot:  %#v\nwant: %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetKeyPlaceholders(t *testing.T) {
+	root := testGenerateSchema(t)
+	tests := map[string]struct {
+		path   string
+		want   string
+		isList bool
+	}{
+		"list": {
+			path:   "/network/vlan",
+			want:   "/network/vlan[id=<id>]",
+			isList: true,
+		},
+		"keyed_list": {
+			path:   "/network/vlan[id=10]",
+			want:   "/network/vlan[id=10]",
+			isList: true,
+		},
+		"nested": {
+			path: "/network/vlan/config",
+			want: "/network/vlan[id=<id>]/config",
+		},
+		"unknown": {
+			path: "/network/vrf",
+			want: "/network/vrf",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			gp, err := utils.ParsePath(tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := setKeyPlaceholders(root, gp)
+			if got := xpath(gp); got != tc.want {
+				t.Errorf("expected path %q, got %q", tc.want, got)
+			}
+			if isList := e != nil && e.IsList(); isList != tc.isList {
+				t.Errorf("expected the path to point to a list: %v", tc.isList)
+			}
+		})
+	}
+}
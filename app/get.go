@@ -11,8 +11,10 @@ package app
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmic/config"
@@ -28,6 +30,9 @@ func (a *App) GetPreRunE(cmd *cobra.Command, args []string) error {
 	a.Config.LocalFlags.GetPath = config.SanitizeArrayFlagValue(a.Config.LocalFlags.GetPath)
 	a.Config.LocalFlags.GetModel = config.SanitizeArrayFlagValue(a.Config.LocalFlags.GetModel)
 	a.Config.LocalFlags.GetProcessor = config.SanitizeArrayFlagValue(a.Config.LocalFlags.GetProcessor)
+	if a.Config.LocalFlags.GetChunkPaths && a.Config.LocalFlags.GetChunkSize <= 0 {
+		return errors.New("chunk-size must be a positive number")
+	}
 
 	a.createCollectorDialOpts()
 	return a.initTunnelServer(tunnel.ServerConfig{
@@ -52,7 +57,7 @@ func (a *App) GetRun(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed reading actions config: %v", err)
 	}
-	evps, err := a.intializeEventProcessors()
+	evps, err := a.intializeEventProcessors(a.Config.GetProcessor)
 	if err != nil {
 		return fmt.Errorf("failed to init event processors: %v", err)
 	}
@@ -70,6 +75,7 @@ func (a *App) GetRun(cmd *cobra.Command, args []string) error {
 	if len(a.Config.GetProcessor) > 0 {
 		a.Config.Format = formatEvent
 	}
+	a.startRun(cmd.Name(), cancel)
 	if a.Config.Format == formatEvent {
 		return a.handleGetRequestEvent(ctx, req, evps)
 	}
@@ -81,16 +87,14 @@ func (a *App) GetRun(cmd *cobra.Command, args []string) error {
 		go a.GetRequest(ctx, tc, req)
 	}
 	a.wg.Wait()
-	err = a.checkErrors()
-	if err != nil {
-		return err
-	}
-	return nil
+	return a.finishRun(a.checkErrors())
 }
 
 func (a *App) GetRequest(ctx context.Context, tc *types.TargetConfig, req *gnmi.GetRequest) {
 	defer a.wg.Done()
+	start := time.Now()
 	response, err := a.getRequest(ctx, tc, req)
+	a.recordResult(tc.Name, time.Since(start), err)
 	if err != nil {
 		a.logError(fmt.Errorf("target %q get request failed: %v", tc.Name, err))
 		return
@@ -122,6 +126,14 @@ func (a *App) getRequest(ctx context.Context, tc *types.TargetConfig, req *gnmi.
 			a.logError(fmt.Errorf("target %q Get Request printing failed: %v", tc.Name, err))
 		}
 	}
+	if a.Config.LocalFlags.GetChunkPaths {
+		response, err := a.chunkedGetRequest(ctx, tc, xreq)
+		if err != nil {
+			a.logError(fmt.Errorf("target %q get request failed: %v", tc.Name, err))
+			return nil, err
+		}
+		return response, nil
+	}
 	a.Logger.Printf("sending gNMI GetRequest: prefix='%v', path='%v', type='%v', encoding='%v', models='%+v', extension='%+v' to %s",
 		xreq.Prefix, xreq.Path, xreq.Type, xreq.Encoding, xreq.UseModels, xreq.Extension, tc.Name)
 
@@ -169,19 +181,22 @@ func (a *App) InitGetFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&a.Config.LocalFlags.GetTarget, "target", "", "", "get request target")
 	cmd.Flags().BoolVarP(&a.Config.LocalFlags.GetValuesOnly, "values-only", "", false, "print GetResponse values only")
 	cmd.Flags().StringArrayVarP(&a.Config.LocalFlags.GetProcessor, "processor", "", []string{}, "list of processor names to run")
+	cmd.Flags().BoolVarP(&a.Config.LocalFlags.GetChunkPaths, "chunk-paths", "", false, "split the request in multiple Get RPCs, one per discovered list entry of paths with wildcard keys")
+	cmd.Flags().Uint32VarP(&a.Config.LocalFlags.GetDepth, "depth", "", 0, "depth extension level, limits the depth of the returned subtrees. 0 means no limit")
+	cmd.Flags().IntVarP(&a.Config.LocalFlags.GetChunkSize, "chunk-size", "", defaultGetChunkSize, "maximum number of paths per Get RPC in chunk-paths mode")
 
 	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
 		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
 	})
 }
 
-func (a *App) intializeEventProcessors() ([]formatters.EventProcessor, error) {
+func (a *App) intializeEventProcessors(names []string) ([]formatters.EventProcessor, error) {
 	_, err := a.Config.GetEventProcessors()
 	if err != nil {
 		return nil, fmt.Errorf("failed reading event processors config: %v", err)
 	}
 	var evps = make([]formatters.EventProcessor, 0)
-	for _, epName := range a.Config.GetProcessor {
+	for _, epName := range names {
 		if epCfg, ok := a.Config.Processors[epName]; ok {
 			epType := ""
 			for k := range epCfg {
@@ -216,7 +231,9 @@ func (a *App) handleGetRequestEvent(ctx context.Context, req *gnmi.GetRequest, e
 	for _, tc := range a.Config.Targets {
 		go func(tc *types.TargetConfig) {
 			defer a.wg.Done()
+			start := time.Now()
 			resp, err := a.getRequest(ctx, tc, req)
+			a.recordResult(tc.Name, time.Since(start), err)
 			if err != nil {
 				a.errCh <- err
 				return
@@ -235,7 +252,7 @@ func (a *App) handleGetRequestEvent(ctx context.Context, req *gnmi.GetRequest, e
 	for r := range rsps {
 		responses[r.name] = r.rsp
 	}
-	err := a.checkErrors()
+	err := a.finishRun(a.checkErrors())
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,234 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/types"
+	"google.golang.org/protobuf/proto"
+)
+
+const defaultGetChunkSize = 10
+
+// chunkedGetRequest splits the paths of req into multiple Get RPCs and merges the responses.
+// A path with a wildcard key, e.g: /interface[name=*], is expanded into one path per list entry,
+// the entries are discovered with a first Get RPC requesting the list key leaf only.
+// Paths without wildcard keys are sent as is.
+// The resulting paths are sent in batches of chunk-size paths.
+func (a *App) chunkedGetRequest(ctx context.Context, tc *types.TargetConfig, req *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+	paths := make([]*gnmi.Path, 0, len(req.GetPath()))
+	for _, p := range req.GetPath() {
+		ps, err := a.discoverChunkPaths(ctx, tc, req, p)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, ps...)
+	}
+	response := new(gnmi.GetResponse)
+	size := a.Config.LocalFlags.GetChunkSize
+	for i := 0; i < len(paths); i += size {
+		end := i + size
+		if end > len(paths) {
+			end = len(paths)
+		}
+		creq := proto.Clone(req).(*gnmi.GetRequest)
+		creq.Path = paths[i:end]
+		a.Logger.Printf("sending gNMI GetRequest chunk %d/%d: prefix='%v', path='%v', type='%v', encoding='%v', models='%+v', extension='%+v' to %s",
+			i/size+1, (len(paths)+size-1)/size, creq.Prefix, creq.Path, creq.Type, creq.Encoding, creq.UseModels, creq.Extension, tc.Name)
+		rsp, err := a.ClientGet(ctx, tc, creq)
+		if err != nil {
+			return nil, err
+		}
+		response.Notification = append(response.Notification, rsp.GetNotification()...)
+	}
+	return response, nil
+}
+
+// discoverChunkPaths returns the list of paths p is split into.
+func (a *App) discoverChunkPaths(ctx context.Context, tc *types.TargetConfig, req *gnmi.GetRequest, p *gnmi.Path) ([]*gnmi.Path, error) {
+	idx, key := chunkListElem(p)
+	if idx < 0 {
+		return []*gnmi.Path{p}, nil
+	}
+	dreq := proto.Clone(req).(*gnmi.GetRequest)
+	dreq.Path = []*gnmi.Path{chunkDiscoveryPath(p, idx, key)}
+	a.Logger.Printf("sending gNMI GetRequest to discover list %q entries: path='%v' to %s",
+		p.GetElem()[idx].GetName(), dreq.Path, tc.Name)
+	rsp, err := a.ClientGet(ctx, tc, dreq)
+	if err != nil {
+		return nil, fmt.Errorf("list %q entries discovery failed: %v", p.GetElem()[idx].GetName(), err)
+	}
+	entries, err := chunkListEntries(rsp, p.GetElem()[idx])
+	if err != nil {
+		return nil, err
+	}
+	a.Logger.Printf("discovered %d entries of list %q on %s", len(entries), p.GetElem()[idx].GetName(), tc.Name)
+	paths := make([]*gnmi.Path, 0, len(entries))
+	for _, e := range entries {
+		cp := proto.Clone(p).(*gnmi.Path)
+		for k, v := range cp.GetElem()[idx].GetKey() {
+			if v == "*" {
+				cp.Elem[idx].Key[k] = e[k]
+			}
+		}
+		paths = append(paths, cp)
+	}
+	return paths, nil
+}
+
+// chunkListElem returns the index of the first path element with a wildcard key
+// and the name of its first wildcard key, or -1 if there is none.
+func chunkListElem(p *gnmi.Path) (int, string) {
+	for i, pe := range p.GetElem() {
+		keys := make([]string, 0, len(pe.GetKey()))
+		for k, v := range pe.GetKey() {
+			if v == "*" {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		sort.Strings(keys)
+		return i, keys[0]
+	}
+	return -1, ""
+}
+
+// chunkDiscoveryPath builds the path of the key leaf of the list at index idx in p.
+func chunkDiscoveryPath(p *gnmi.Path, idx int, key string) *gnmi.Path {
+	dp := &gnmi.Path{
+		Origin: p.GetOrigin(),
+		Elem:   make([]*gnmi.PathElem, 0, idx+2),
+	}
+	for _, pe := range p.GetElem()[:idx+1] {
+		dp.Elem = append(dp.Elem, proto.Clone(pe).(*gnmi.PathElem))
+	}
+	dp.Elem = append(dp.Elem, &gnmi.PathElem{Name: key})
+	return dp
+}
+
+// chunkListEntries extracts the wildcard keys values of list element le from a discovery GetResponse.
+// The keys are read from the updates paths, or from the JSON values if the
+// target returned the list entries as a JSON value.
+func chunkListEntries(rsp *gnmi.GetResponse, le *gnmi.PathElem) ([]map[string]string, error) {
+	wildcards := make([]string, 0, len(le.GetKey()))
+	for k, v := range le.GetKey() {
+		if v == "*" {
+			wildcards = append(wildcards, k)
+		}
+	}
+	entries := make([]map[string]string, 0)
+	seen := make(map[string]struct{})
+	add := func(e map[string]string) {
+		ks := make([]string, 0, len(wildcards))
+		for _, k := range wildcards {
+			v, ok := e[k]
+			if !ok || v == "" || v == "*" {
+				return
+			}
+			ks = append(ks, k+"="+v)
+		}
+		sort.Strings(ks)
+		id := strings.Join(ks, ",")
+		if _, ok := seen[id]; ok {
+			return
+		}
+		seen[id] = struct{}{}
+		entries = append(entries, e)
+	}
+	for _, n := range rsp.GetNotification() {
+		for _, u := range n.GetUpdate() {
+			elems := append(append([]*gnmi.PathElem{}, n.GetPrefix().GetElem()...), u.GetPath().GetElem()...)
+			found := false
+			for _, pe := range elems {
+				if pe.GetName() != le.GetName() || len(pe.GetKey()) == 0 {
+					continue
+				}
+				add(pe.GetKey())
+				found = true
+				break
+			}
+			if found {
+				continue
+			}
+			// keys not in the path, look for them in the JSON value
+			var b []byte
+			switch u.GetVal().GetValue().(type) {
+			case *gnmi.TypedValue_JsonIetfVal:
+				b = u.GetVal().GetJsonIetfVal()
+			case *gnmi.TypedValue_JsonVal:
+				b = u.GetVal().GetJsonVal()
+			default:
+				continue
+			}
+			var v interface{}
+			dec := json.NewDecoder(bytes.NewReader(b))
+			dec.UseNumber()
+			err := dec.Decode(&v)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range jsonListEntries(v, le.GetName(), wildcards) {
+				add(e)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// jsonListEntries returns the keys of the list entries found in a JSON value.
+// v is either the list itself, or an object containing the list
+// under its name, optionally prefixed with a module name.
+func jsonListEntries(v interface{}, name string, keys []string) []map[string]string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, lv := range v {
+			if k == name || strings.HasSuffix(k, ":"+name) {
+				return jsonListEntries(lv, name, keys)
+			}
+		}
+		if e := jsonEntryKeys(v, keys); e != nil {
+			return []map[string]string{e}
+		}
+	case []interface{}:
+		entries := make([]map[string]string, 0, len(v))
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				if e := jsonEntryKeys(m, keys); e != nil {
+					entries = append(entries, e)
+				}
+			}
+		}
+		return entries
+	}
+	return nil
+}
+
+func jsonEntryKeys(m map[string]interface{}, keys []string) map[string]string {
+	e := make(map[string]string, len(keys))
+	for mk, mv := range m {
+		for _, k := range keys {
+			if mk == k || strings.HasSuffix(mk, ":"+k) {
+				e[k] = fmt.Sprint(mv)
+			}
+		}
+	}
+	if len(e) != len(keys) {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,95 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/utils"
+)
+
+func TestChunkListElem(t *testing.T) {
+	tests := []struct {
+		path string
+		idx  int
+		key  string
+	}{
+		{path: "/system/name", idx: -1},
+		{path: "/interface[name=ethernet-1/1]/statistics", idx: -1},
+		{path: "/interface[name=*]/statistics", idx: 0, key: "name"},
+		{path: "/network-instance[name=default]/protocols/bgp/neighbor[peer-address=*]", idx: 3, key: "peer-address"},
+		{path: "/acl/acl-sets/acl-set[type=*][name=*]", idx: 2, key: "name"},
+	}
+	for _, tt := range tests {
+		p, err := utils.ParsePath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		idx, key := chunkListElem(p)
+		if idx != tt.idx || key != tt.key {
+			t.Errorf("%s: expected (%d, %q), got (%d, %q)", tt.path, tt.idx, tt.key, idx, key)
+		}
+		if idx < 0 {
+			continue
+		}
+		dp := chunkDiscoveryPath(p, idx, key)
+		if got := dp.GetElem()[len(dp.GetElem())-1].GetName(); got != key || len(dp.GetElem()) != idx+2 {
+			t.Errorf("%s: unexpected discovery path %v", tt.path, dp)
+		}
+	}
+}
+
+func TestChunkListEntries(t *testing.T) {
+	le := &gnmi.PathElem{Name: "interface", Key: map[string]string{"name": "*"}}
+	// keys in the update paths
+	rsp := &gnmi.GetResponse{
+		Notification: []*gnmi.Notification{{
+			Update: []*gnmi.Update{
+				{
+					Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}, {Name: "name"}}},
+					Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "ethernet-1/1"}},
+				},
+				{
+					Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "mgmt0"}}, {Name: "name"}}},
+					Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "mgmt0"}},
+				},
+			},
+		}},
+	}
+	entries, err := chunkListEntries(rsp, le)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{{"name": "ethernet-1/1"}, {"name": "mgmt0"}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("unexpected entries: %v", entries)
+	}
+	// keys in a JSON value
+	rsp = &gnmi.GetResponse{
+		Notification: []*gnmi.Notification{{
+			Update: []*gnmi.Update{
+				{
+					Path: &gnmi.Path{},
+					Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{
+						JsonIetfVal: []byte(`{"srl_nokia-interfaces:interface":[{"name":"ethernet-1/1"},{"name":"mgmt0"},{"name":"mgmt0"}]}`),
+					}},
+				},
+			},
+		}},
+	}
+	entries, err = chunkListEntries(rsp, le)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("unexpected entries: %v", entries)
+	}
+}
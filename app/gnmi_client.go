@@ -11,10 +11,15 @@ package app
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmi/proto/gnmi_ext"
+	"github.com/openconfig/gnmic/target"
 	"github.com/openconfig/gnmic/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
 )
 
 func (a *App) ClientCapabilities(ctx context.Context, tc *types.TargetConfig, ext ...*gnmi_ext.Extension) (*gnmi.CapabilityResponse, error) {
@@ -32,11 +37,16 @@ func (a *App) ClientCapabilities(ctx context.Context, tc *types.TargetConfig, ex
 	if err != nil {
 		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(ctx, t.Config.Timeout)
-	defer cancel()
-	capResponse, err := t.Capabilities(ctx, ext...)
+	var capResponse *gnmi.CapabilityResponse
+	err = a.retryRPC(ctx, t, "Capabilities", nil, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, t.Config.Timeout)
+		defer cancel()
+		var err error
+		capResponse, err = t.Capabilities(ctx, ext...)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%q CapabilitiesRequest failed: %v", t.Config.Address, err)
+		return nil, fmt.Errorf("%q CapabilitiesRequest failed: %w", t.Config.Address, err)
 	}
 	return capResponse, nil
 
@@ -56,16 +66,95 @@ func (a *App) ClientGet(ctx context.Context, tc *types.TargetConfig, req *gnmi.G
 	if err != nil {
 		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(ctx, t.Config.Timeout)
-	defer cancel()
-	getResponse, err := t.Get(ctx, req)
+	req, err = a.negotiateGetEncoding(ctx, t, vendorGetRequest(t.Config, req))
+	if err != nil {
+		return nil, err
+	}
+	var getResponse *gnmi.GetResponse
+	err = a.retryRPC(ctx, t, "Get", nil, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, t.Config.Timeout)
+		defer cancel()
+		var err error
+		getResponse, err = t.Get(ctx, req)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%q GetRequest failed: %v", t.Config.Address, err)
+		return nil, fmt.Errorf("%q GetRequest failed: %w", t.Config.Address, err)
 	}
 	return getResponse, nil
 }
 
+// negotiateGetEncoding returns a Get request with an encoding supported by target t.
+// The target capabilities are only checked if it is configured with probe-capabilities
+// or if the encoding is "auto". The request is copied before its encoding is changed.
+func (a *App) negotiateGetEncoding(ctx context.Context, t *target.Target, req *gnmi.GetRequest) (*gnmi.GetRequest, error) {
+	auto := strings.EqualFold(a.Config.Encoding, encodingAuto)
+	if !auto && (t.Config.ProbeCapabilities == nil || !*t.Config.ProbeCapabilities) {
+		return req, nil
+	}
+	caps, err := t.CachedCapabilities(ctx, t.Config.EncodingPreference, t.Config.CapabilitiesTTL)
+	if err != nil {
+		t.SetLastError(err)
+		a.logWarning("target %q capabilities probe failed, using encoding %q: %v",
+			t.Config.Name, strings.ToLower(req.GetEncoding().String()), err)
+		return req, nil
+	}
+	enc := strings.ToLower(req.GetEncoding().String())
+	if auto {
+		enc = encodingAuto
+	}
+	selected, changed := caps.NegotiateEncoding(enc)
+	if !changed {
+		return req, nil
+	}
+	if !auto {
+		a.logWarning("target %q does not support encoding %q, using %q", t.Config.Name, enc, selected)
+	}
+	v, ok := gnmi.Encoding_value[strings.ToUpper(selected)]
+	if !ok {
+		return nil, fmt.Errorf("target %q: unknown encoding %q", t.Config.Name, selected)
+	}
+	nreq := proto.Clone(req).(*gnmi.GetRequest)
+	nreq.Encoding = gnmi.Encoding(v)
+	return nreq, nil
+}
+
 func (a *App) ClientSet(ctx context.Context, tc *types.TargetConfig, req *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+	a.operLock.Lock()
+	t, err := a.initTarget(tc)
+	a.operLock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	// acquire reader lock
+	a.operLock.RLock()
+	err = a.CreateGNMIClient(ctx, t)
+	a.operLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	req = vendorSetRequest(t.Config, req)
+	// the peer is only set if the request was sent over a connection,
+	// a Set RPC is not retried if the target might have applied it.
+	var p *peer.Peer
+	notSent := func() bool { return p.Addr == nil }
+	var setResponse *gnmi.SetResponse
+	err = a.retryRPC(ctx, t, "Set", notSent, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, t.Config.Timeout)
+		defer cancel()
+		p = new(peer.Peer)
+		var err error
+		setResponse, err = t.Set(ctx, req, grpc.Peer(p))
+		return err
+	})
+	a.auditSet(ctx, t.Config.Name, req, err)
+	if err != nil {
+		return nil, fmt.Errorf("target %q SetRequest failed: %w", t.Config.Name, err)
+	}
+	return setResponse, nil
+}
+
+func (a *App) ClientSubscribeOnce(ctx context.Context, tc *types.TargetConfig, req *gnmi.SubscribeRequest) ([]*gnmi.SubscribeResponse, error) {
 	a.operLock.Lock()
 	t, err := a.initTarget(tc)
 	a.operLock.Unlock()
@@ -81,9 +170,32 @@ func (a *App) ClientSet(ctx context.Context, tc *types.TargetConfig, req *gnmi.S
 	}
 	ctx, cancel := context.WithTimeout(ctx, t.Config.Timeout)
 	defer cancel()
-	setResponse, err := t.Set(ctx, req)
+	subscribeResponses, err := t.SubscribeOnce(ctx, vendorSubscribeRequest(t.Config, req))
 	if err != nil {
-		return nil, fmt.Errorf("target %q SetRequest failed: %v", t.Config.Name, err)
+		return nil, fmt.Errorf("target %q SubscribeRequest failed: %v", t.Config.Name, err)
 	}
-	return setResponse, nil
+	return subscribeResponses, nil
+}
+
+func (a *App) ClientFileGet(ctx context.Context, tc *types.TargetConfig, remoteFile string) ([]byte, error) {
+	a.operLock.Lock()
+	t, err := a.initTarget(tc)
+	a.operLock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	// acquire reader lock
+	a.operLock.RLock()
+	err = a.CreateGNMIClient(ctx, t)
+	a.operLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.Config.Timeout)
+	defer cancel()
+	b, err := t.FileGet(ctx, remoteFile)
+	if err != nil {
+		return nil, fmt.Errorf("target %q gNOI File Get %q failed: %v", t.Config.Name, remoteFile, err)
+	}
+	return b, nil
 }
@@ -25,11 +25,17 @@ import (
 	"google.golang.org/grpc"
 )
 
+const encodingAuto = "auto"
+
 type subscriptionRequest struct {
 	// subscription name
 	name string
 	// gNMI subscription request
 	req *gnmi.SubscribeRequest
+	// gNMI get request and interval of a get-poll subscription,
+	// req is nil if set.
+	getReq   *gnmi.GetRequest
+	interval time.Duration
 }
 
 func (a *App) TargetSubscribeStream(ctx context.Context, tc *types.TargetConfig) {
@@ -72,6 +78,7 @@ START:
 		}
 		a.Logger.Printf("queuing target %q", tc.Name)
 		a.targetsChan <- t
+		t.StartReachabilityProbe(nctx)
 		a.Logger.Printf("subscribing to target: %q", tc.Name)
 		go func() {
 			err := a.clientSubscribe(nctx, tc)
@@ -166,19 +173,11 @@ func (a *App) clientSubscribe(ctx context.Context, tc *types.TargetConfig) error
 	a.operLock.RUnlock()
 	subscriptionsConfigs := t.Subscriptions
 	if len(subscriptionsConfigs) == 0 {
-		subscriptionsConfigs = a.Config.Subscriptions
+		subscriptionsConfigs = a.namespaceSubscriptions(tc.Namespace)
 	}
 	if len(subscriptionsConfigs) == 0 {
 		return fmt.Errorf("target %q has no subscriptions defined", tc.Name)
 	}
-	subRequests := make([]subscriptionRequest, 0)
-	for _, sc := range subscriptionsConfigs {
-		req, err := a.Config.CreateSubscribeRequest(sc, tc.Name)
-		if err != nil {
-			return err
-		}
-		subRequests = append(subRequests, subscriptionRequest{name: sc.Name, req: req})
-	}
 	if t.Cfn != nil {
 		t.Cfn()
 	}
@@ -200,28 +199,70 @@ CRCLIENT:
 			// overwrite target address
 			t.Config.Address = t.Config.Name
 		}
-		err := t.CreateGNMIClient(ctx, targetDialOpts...)
+		t.SetConnecting()
+		err := a.loadTargetCredentials(gnmiCtx, t)
+		if err == nil {
+			err = t.CreateGNMIClient(ctx, targetDialOpts...)
+		}
 		if err != nil {
+			wait := t.ConnectionFailed(err)
 			if errors.Is(err, context.DeadlineExceeded) {
 				a.Logger.Printf("failed to initialize target %q timeout (%s) reached", tc.Name, t.Config.Timeout)
 			} else {
 				a.Logger.Printf("failed to initialize target %q: %v", tc.Name, err)
 			}
-			a.Logger.Printf("retrying target %q in %s", tc.Name, t.Config.RetryTimer)
-			time.Sleep(t.Config.RetryTimer)
+			a.Logger.Printf("retrying target %q in %s, state=%s", tc.Name, wait, t.Health().State)
+			select {
+			case <-gnmiCtx.Done():
+				return gnmiCtx.Err()
+			case <-time.After(wait):
+			}
 			goto CRCLIENT
 		}
 	}
+	t.SetUp()
 	a.Logger.Printf("target %q gNMI client created", t.Config.Name)
+	go a.watchTargetCredentials(gnmiCtx, t)
+	t.SetSubscribeContext(gnmiCtx)
+	err := a.subscribeTarget(gnmiCtx, t, subscriptionsConfigs)
+	if err != nil {
+		cancel()
+		return err
+	}
+	return nil
+}
 
+// subscribeTarget sends the subscriptions subscriptionsConfigs to target t
+// using its existing gNMI client.
+func (a *App) subscribeTarget(ctx context.Context, t *target.Target, subscriptionsConfigs map[string]*types.SubscriptionConfig) error {
+	subRequests, err := a.createSubscribeRequests(ctx, t, subscriptionsConfigs)
+	if err != nil {
+		return err
+	}
+	subRequests, err = a.multiplexSubscribeRequests(t, subscriptionsConfigs, subRequests)
+	if err != nil {
+		return err
+	}
 	for _, sreq := range subRequests {
-		a.Logger.Printf("sending gNMI SubscribeRequest: subscribe='%+v', mode='%+v', encoding='%+v', to %s",
-			sreq.req, sreq.req.GetSubscribe().GetMode(), sreq.req.GetSubscribe().GetEncoding(), t.Config.Name)
-		go t.Subscribe(gnmiCtx, sreq.req, sreq.name)
+		a.sendSubscriptionRequest(ctx, t, sreq)
 	}
 	return nil
 }
 
+// sendSubscriptionRequest starts the subscription sreq on target t,
+// as a Subscribe RPC or as periodic Get RPCs for get-poll subscriptions.
+func (a *App) sendSubscriptionRequest(ctx context.Context, t *target.Target, sreq subscriptionRequest) {
+	if sreq.getReq != nil {
+		a.Logger.Printf("sending gNMI GetRequest every %s: get='%+v', encoding='%+v', to %s",
+			sreq.interval, sreq.getReq, sreq.getReq.GetEncoding(), t.Config.Name)
+		go t.GetPoll(ctx, sreq.getReq, sreq.name, sreq.interval)
+		return
+	}
+	a.Logger.Printf("sending gNMI SubscribeRequest: subscribe='%+v', mode='%+v', encoding='%+v', to %s",
+		sreq.req, sreq.req.GetSubscribe().GetMode(), sreq.req.GetSubscribe().GetEncoding(), t.Config.Name)
+	go t.Subscribe(ctx, sreq.req, sreq.name)
+}
+
 func (a *App) clientSubscribeOnce(ctx context.Context, tc *types.TargetConfig) error {
 	var t *target.Target
 	var ok bool
@@ -234,19 +275,11 @@ func (a *App) clientSubscribeOnce(ctx context.Context, tc *types.TargetConfig) e
 
 	subscriptionsConfigs := t.Subscriptions
 	if len(subscriptionsConfigs) == 0 {
-		subscriptionsConfigs = a.Config.Subscriptions
+		subscriptionsConfigs = a.namespaceSubscriptions(tc.Namespace)
 	}
 	if len(subscriptionsConfigs) == 0 {
 		return fmt.Errorf("target %q has no subscriptions defined", tc.Name)
 	}
-	subRequests := make([]subscriptionRequest, 0)
-	for _, sc := range subscriptionsConfigs {
-		req, err := a.Config.CreateSubscribeRequest(sc, tc.Name)
-		if err != nil {
-			return err
-		}
-		subRequests = append(subRequests, subscriptionRequest{name: sc.Name, req: req})
-	}
 	gnmiCtx, cancel := context.WithCancel(ctx)
 	t.Cfn = cancel
 CRCLIENT:
@@ -261,18 +294,38 @@ CRCLIENT:
 		// overwrite target address
 		t.Config.Address = t.Config.Name
 	}
-	if err := t.CreateGNMIClient(ctx, targetDialOpts...); err != nil {
+	t.SetConnecting()
+	err := a.loadTargetCredentials(gnmiCtx, t)
+	if err == nil {
+		err = t.CreateGNMIClient(ctx, targetDialOpts...)
+	}
+	if err != nil {
+		wait := t.ConnectionFailed(err)
 		if errors.Is(err, context.DeadlineExceeded) {
 			a.Logger.Printf("failed to initialize target %q timeout (%s) reached", tc.Name, t.Config.Timeout)
 		} else {
 			a.Logger.Printf("failed to initialize target %q: %v", tc.Name, err)
 		}
-		a.Logger.Printf("retrying target %q in %s", tc.Name, t.Config.RetryTimer)
-		time.Sleep(t.Config.RetryTimer)
+		a.Logger.Printf("retrying target %q in %s, state=%s", tc.Name, wait, t.Health().State)
+		select {
+		case <-gnmiCtx.Done():
+			return gnmiCtx.Err()
+		case <-time.After(wait):
+		}
 		goto CRCLIENT
-
 	}
+	t.SetUp()
 	a.Logger.Printf("target %q gNMI client created", t.Config.Name)
+	subRequests, err := a.createSubscribeRequests(gnmiCtx, t, subscriptionsConfigs)
+	if err != nil {
+		cancel()
+		return err
+	}
+	subRequests, err = a.multiplexSubscribeRequests(t, subscriptionsConfigs, subRequests)
+	if err != nil {
+		cancel()
+		return err
+	}
 OUTER:
 	for _, sreq := range subRequests {
 		a.Logger.Printf("sending gNMI SubscribeRequest: subscribe='%+v', mode='%+v', encoding='%+v', to %s",
@@ -294,8 +347,15 @@ OUTER:
 					a.Logger.Printf("target %q, subscription %q received sync response", t.Config.Name, sreq.name)
 					return nil
 				default:
-					m := outputs.Meta{"source": t.Config.Name, "format": a.Config.Format, "subscription-name": sreq.name}
-					a.Export(ctx, rsp, m, t.Config.Outputs...)
+					// responses of multiplexed subscriptions are attributed to the matching subscription
+					rsps, err := t.RouteResponse(sreq.name, rsp)
+					if err != nil {
+						a.Logger.Printf("target %q, subscription %q: %v", t.Config.Name, sreq.name, err)
+					}
+					for _, r := range rsps {
+						m := outputs.Meta{"source": t.Config.Name, "format": a.Config.Format, "subscription-name": r.SubscriptionName}
+						a.Export(ctx, r.Response, m, t.Config.Outputs...)
+					}
 				}
 			}
 		}
@@ -303,6 +363,75 @@ OUTER:
 	return nil
 }
 
+// createSubscribeRequests builds the subscribe requests of target t.
+// If the target is configured with probe-capabilities, or if one of the subscriptions
+// has encoding "auto", a Capabilities RPC is sent first to select the encoding.
+// A subscription encoding the target does not support is replaced by the selected one.
+// The subscriptions overrides matching the target are applied,
+// the paused subscriptions and the ones outside of their schedule are skipped.
+func (a *App) createSubscribeRequests(ctx context.Context, t *target.Target, subs map[string]*types.SubscriptionConfig) ([]subscriptionRequest, error) {
+	probe := t.Config.ProbeCapabilities != nil && *t.Config.ProbeCapabilities
+	for _, sc := range subs {
+		if strings.EqualFold(sc.Encoding, encodingAuto) {
+			probe = true
+			break
+		}
+	}
+	var caps *target.CapabilitiesProbe
+	autoEncoding := strings.ToLower(gnmi.Encoding_JSON.String())
+	if probe {
+		var err error
+		caps, err = t.CachedCapabilities(ctx, t.Config.EncodingPreference, t.Config.CapabilitiesTTL)
+		if err != nil {
+			t.SetLastError(err)
+			a.Logger.Printf("target %q capabilities probe failed, using encoding %q: %v", t.Config.Name, autoEncoding, err)
+			caps = nil
+		} else {
+			autoEncoding = caps.SelectedEncoding
+			a.Logger.Printf("target %q supports encodings %v, selected encoding %q", t.Config.Name, caps.Encodings, autoEncoding)
+		}
+	}
+	subRequests := make([]subscriptionRequest, 0, len(subs))
+	now := time.Now()
+	for _, sc := range subs {
+		if !sc.Schedule.ActiveAt(now) {
+			t.PauseSubscription(sc.Name, target.PausedBySchedule)
+		}
+		if t.SubscriptionPaused(sc.Name) {
+			a.Logger.Printf("target %q: subscription %q is paused %v", t.Config.Name, sc.Name, t.SubscriptionPauseReasons(sc.Name))
+			continue
+		}
+		sc = sc.ForTarget(t.Config)
+		switch {
+		case strings.EqualFold(sc.Encoding, encodingAuto):
+			// do not modify the shared subscription config
+			nsc := *sc
+			nsc.Encoding = autoEncoding
+			sc = &nsc
+		case caps != nil && sc.Encoding != "" && !caps.Supports(sc.Encoding):
+			a.logWarning("target %q does not support encoding %q used by subscription %q, using %q",
+				t.Config.Name, sc.Encoding, sc.Name, caps.SelectedEncoding)
+			nsc := *sc
+			nsc.Encoding = caps.SelectedEncoding
+			sc = &nsc
+		}
+		if sc.IsGetPoll() {
+			getReq, err := a.Config.CreateGetPollRequest(sc, t.Config.Name)
+			if err != nil {
+				return nil, err
+			}
+			subRequests = append(subRequests, subscriptionRequest{name: sc.Name, getReq: getReq, interval: *sc.SampleInterval})
+			continue
+		}
+		req, err := a.Config.CreateSubscribeRequest(sc, t.Config.Name)
+		if err != nil {
+			return nil, err
+		}
+		subRequests = append(subRequests, subscriptionRequest{name: sc.Name, req: vendorSubscribeRequest(t.Config, req)})
+	}
+	return subRequests, nil
+}
+
 // clientSubscribePoll sends a gnmi.SubscribeRequest_Poll to targetName and returns the response and an error,
 // it uses the targetName and the subscriptionName strings to find the gnmi.GNMI_SubscribeClient
 func (a *App) clientSubscribePoll(targetName, subscriptionName string) (*gnmi.SubscribeResponse, error) {
@@ -0,0 +1,338 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/types"
+	"google.golang.org/grpc"
+)
+
+// capsTestGNMIServer advertises a fixed set of encodings
+// and records the encoding of the received Get requests.
+type capsTestGNMIServer struct {
+	gnmi.UnimplementedGNMIServer
+	encodings []gnmi.Encoding
+
+	m         sync.Mutex
+	capsCalls int
+	getEnc    []gnmi.Encoding
+}
+
+func (s *capsTestGNMIServer) Capabilities(context.Context, *gnmi.CapabilityRequest) (*gnmi.CapabilityResponse, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.capsCalls++
+	return &gnmi.CapabilityResponse{SupportedEncodings: s.encodings, GNMIVersion: "0.8.0"}, nil
+}
+
+func (s *capsTestGNMIServer) Get(_ context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.getEnc = append(s.getEnc, req.GetEncoding())
+	return &gnmi.GetResponse{}, nil
+}
+
+func startCapsTestServer(t *testing.T, encs ...gnmi.Encoding) (*capsTestGNMIServer, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := &capsTestGNMIServer{encodings: encs}
+	srv := grpc.NewServer()
+	gnmi.RegisterGNMIServer(srv, gs)
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+	return gs, l.Addr().String()
+}
+
+func TestClientGetEncodingNegotiation(t *testing.T) {
+	gs, addr := startCapsTestServer(t, gnmi.Encoding_JSON_IETF, gnmi.Encoding_PROTO)
+	a := New()
+	defer a.Cfn()
+	a.Config.Log = true
+	a.Config.Encoding = "json"
+	probe := true
+	insecure := true
+	tc := &types.TargetConfig{
+		Name:              "t1",
+		Address:           addr,
+		Insecure:          &insecure,
+		Timeout:           5 * time.Second,
+		ProbeCapabilities: &probe,
+		CapabilitiesTTL:   time.Minute,
+	}
+	req := &gnmi.GetRequest{Encoding: gnmi.Encoding_JSON}
+	for i := 0; i < 2; i++ {
+		_, err := a.ClientGet(context.Background(), tc, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if req.GetEncoding() != gnmi.Encoding_JSON {
+		t.Errorf("the original request was modified: %v", req.GetEncoding())
+	}
+	gs.m.Lock()
+	defer gs.m.Unlock()
+	if gs.capsCalls != 1 {
+		t.Errorf("expected the capabilities to be cached, got %d Capabilities RPCs", gs.capsCalls)
+	}
+	for _, enc := range gs.getEnc {
+		if enc != gnmi.Encoding_PROTO {
+			t.Errorf("expected the unsupported encoding to be replaced by PROTO, got %v", enc)
+		}
+	}
+}
+
+func TestClientGetEncodingAuto(t *testing.T) {
+	gs, addr := startCapsTestServer(t, gnmi.Encoding_JSON, gnmi.Encoding_JSON_IETF)
+	a := New()
+	defer a.Cfn()
+	a.Config.Log = true
+	a.Config.Encoding = "auto"
+	insecure := true
+	tc := &types.TargetConfig{
+		Name:               "t1",
+		Address:            addr,
+		Insecure:           &insecure,
+		Timeout:            5 * time.Second,
+		EncodingPreference: []string{"json-ietf", "json"},
+	}
+	for i := 0; i < 2; i++ {
+		_, err := a.ClientGet(context.Background(), tc, &gnmi.GetRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	gs.m.Lock()
+	defer gs.m.Unlock()
+	// without a TTL the target is probed before each request
+	if gs.capsCalls != 2 {
+		t.Errorf("expected 2 Capabilities RPCs, got %d", gs.capsCalls)
+	}
+	if len(gs.getEnc) != 2 || gs.getEnc[0] != gnmi.Encoding_JSON_IETF {
+		t.Errorf("expected the JSON_IETF encoding to be selected, got %v", gs.getEnc)
+	}
+}
+
+// getPollTestServer answers the Get requests with a notification without timestamp.
+type getPollTestServer struct {
+	gnmi.UnimplementedGNMIServer
+
+	m    sync.Mutex
+	reqs []*gnmi.GetRequest
+}
+
+func (s *getPollTestServer) Get(_ context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.reqs = append(s.reqs, req)
+	return &gnmi.GetResponse{
+		Notification: []*gnmi.Notification{{
+			Update: []*gnmi.Update{{
+				Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interfaces"}}},
+				Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonVal{JsonVal: []byte(`{"mtu":1500}`)}},
+			}},
+		}},
+	}, nil
+}
+
+func TestClientSubscribeGetPoll(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := new(getPollTestServer)
+	srv := grpc.NewServer()
+	gnmi.RegisterGNMIServer(srv, gs)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	a := New()
+	defer a.Cfn()
+	interval := 50 * time.Millisecond
+	a.Config.Subscriptions = map[string]*types.SubscriptionConfig{
+		"sub1": {
+			Name:           "sub1",
+			Paths:          []string{"/interfaces"},
+			Mode:           "get-poll",
+			Encoding:       "json",
+			SetTarget:      true,
+			SampleInterval: &interval,
+		},
+	}
+	insecure := true
+	tc := &types.TargetConfig{
+		Name:          "t1",
+		Address:       l.Addr().String(),
+		Insecure:      &insecure,
+		Timeout:       5 * time.Second,
+		Subscriptions: []string{"sub1"},
+	}
+	tg, err := a.initTarget(tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.clientSubscribe(a.ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rspCh, errCh := tg.ReadSubscriptions()
+	var updates, syncs int
+	timeout := time.After(5 * time.Second)
+	for updates < 2 {
+		select {
+		case rsp := <-rspCh:
+			if rsp.SubscriptionName != "sub1" {
+				t.Fatalf("unexpected subscription name %q", rsp.SubscriptionName)
+			}
+			switch r := rsp.Response.GetResponse().(type) {
+			case *gnmi.SubscribeResponse_Update:
+				if r.Update.GetTimestamp() == 0 {
+					t.Error("expected the notification to be timestamped")
+				}
+				if len(r.Update.GetUpdate()) != 1 {
+					t.Errorf("unexpected notification: %v", r.Update)
+				}
+				updates++
+			case *gnmi.SubscribeResponse_SyncResponse:
+				if updates != 1 {
+					t.Errorf("expected a sync response after the first poll, got it after %d updates", updates)
+				}
+				syncs++
+			}
+		case err := <-errCh:
+			t.Fatalf("unexpected error: %v", err.Err)
+		case <-timeout:
+			t.Fatalf("expected 2 polls, got %d", updates)
+		}
+	}
+	if syncs != 1 {
+		t.Errorf("expected a single sync response, got %d", syncs)
+	}
+	gs.m.Lock()
+	defer gs.m.Unlock()
+	req := gs.reqs[0]
+	if req.GetEncoding() != gnmi.Encoding_JSON || req.GetType() != gnmi.GetRequest_ALL ||
+		req.GetPrefix().GetTarget() != "t1" || len(req.GetPath()) != 1 {
+		t.Errorf("unexpected get request: %v", req)
+	}
+}
+
+// countingListener counts the accepted connections.
+type countingListener struct {
+	net.Listener
+	m        sync.Mutex
+	accepted int
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err == nil {
+		l.m.Lock()
+		l.accepted++
+		l.m.Unlock()
+	}
+	return c, err
+}
+
+func (l *countingListener) count() int {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.accepted
+}
+
+func TestResubscribeTargetKeepsConnection(t *testing.T) {
+	tl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &countingListener{Listener: tl}
+	gs := new(getPollTestServer)
+	srv := grpc.NewServer()
+	gnmi.RegisterGNMIServer(srv, gs)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	a := New()
+	defer a.Cfn()
+	interval := 20 * time.Millisecond
+	a.Config.Subscriptions = map[string]*types.SubscriptionConfig{
+		"sub1": {
+			Name:           "sub1",
+			Paths:          []string{"/interfaces"},
+			Mode:           "get-poll",
+			Encoding:       "json",
+			SampleInterval: &interval,
+		},
+	}
+	insecure := true
+	tc := &types.TargetConfig{
+		Name:          "t1",
+		Address:       l.Addr().String(),
+		Insecure:      &insecure,
+		Timeout:       5 * time.Second,
+		Subscriptions: []string{"sub1"},
+	}
+	tg, err := a.initTarget(tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	err = a.clientSubscribe(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	polls := func() int {
+		gs.m.Lock()
+		defer gs.m.Unlock()
+		return len(gs.reqs)
+	}
+	waitPolls := func(n int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for polls() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected at least %d polls, got %d", n, polls())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	go func() {
+		// drain the responses
+		rspCh, errCh := tg.ReadSubscriptions()
+		for {
+			select {
+			case <-rspCh:
+			case <-errCh:
+			case <-a.ctx.Done():
+				return
+			}
+		}
+	}()
+	waitPolls(1)
+	a.resubscribeTarget(tg)
+	waitPolls(polls() + 2)
+	if n := l.count(); n != 1 {
+		t.Errorf("expected the connection to be reused, got %d connections", n)
+	}
+	// the subscriptions are still bound to the original context
+	cancel()
+	time.Sleep(5 * interval)
+	n := polls()
+	time.Sleep(5 * interval)
+	if polls() != n {
+		t.Error("expected the polls to stop with the original context")
+	}
+}
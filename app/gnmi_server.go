@@ -39,41 +39,64 @@ import (
 type streamClient struct {
 	target string
 	req    *gnmi.SubscribeRequest
+	// targets selects the cached targets the subscription applies to,
+	// cacheTarget is the target used to query the cache.
+	targets     *targetSelector
+	cacheTarget string
 
 	stream  gnmi.GNMI_SubscribeServer
 	errChan chan<- error
 }
 
+// sendNotification sends notification n to the stream client
+// if its prefix target is selected by the subscription.
+func (sc *streamClient) sendNotification(n *gnmi.Notification) error {
+	if !sc.targets.matches(n.GetPrefix().GetTarget()) {
+		return nil
+	}
+	return sc.stream.Send(&gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: n,
+		},
+	})
+}
+
 func (a *App) startGnmiServer() {
 	if a.Config.GnmiServer == nil {
 		a.c = nil
 		return
 	}
 	var err error
-	a.c, err = cache.New(a.Config.GnmiServer.Cache, cache.WithLogger(a.Logger))
-	if err != nil {
-		a.Logger.Printf("failed to initialize gNMI cache: %v", err)
-		return
+	if a.Config.GnmiServer.Proxy {
+		a.c = nil
+		a.gnmiProxy = newGNMIProxy()
+	} else {
+		a.c, err = cache.New(a.Config.GnmiServer.Cache, cache.WithLogger(a.Logger))
+		if err != nil {
+			a.Logger.Printf("failed to initialize gNMI cache: %v", err)
+			return
+		}
+		if mc, ok := a.c.(cache.Mesh); ok {
+			a.startCacheMesh(a.ctx, mc)
+		}
 	}
 
 	a.subscribeRPCsem = semaphore.NewWeighted(a.Config.GnmiServer.MaxSubscriptions)
 	a.unaryRPCsem = semaphore.NewWeighted(a.Config.GnmiServer.MaxUnaryRPC)
+	a.gnmiServerSchema, err = a.loadGNMIServerSchema()
+	if err != nil {
+		a.Logger.Printf("failed to load gNMI server YANG schema: %v", err)
+		return
+	}
 	//
 	var l net.Listener
-	network := "tcp"
-	addr := a.Config.GnmiServer.Address
-	if strings.HasPrefix(a.Config.GnmiServer.Address, "unix://") {
-		network = "unix"
-		addr = strings.TrimPrefix(addr, "unix://")
-	}
-
 	opts, err := a.gRPCServerOpts()
 	if err != nil {
 		a.Logger.Printf("failed to build gRPC server options: %v", err)
 		return
 	}
 	for {
-		l, err = net.Listen(network, addr)
+		l, err = utils.Listen(a.Config.GnmiServer.Address, a.Config.GnmiServer.SocketPermissions)
 		if err != nil {
 			a.Logger.Printf("failed to start gRPC server listener: %v", err)
 			time.Sleep(time.Second)
@@ -202,12 +225,14 @@ func (a *App) gRPCServerOpts() ([]grpc.ServerOption, error) {
 		a.reg.MustRegister(grpcMetrics)
 	}
 
-	tlscfg, err := utils.NewTLSConfig(
+	tlscfg, err := a.serverTLSConfig(
 		a.Config.GnmiServer.CaFile,
 		a.Config.GnmiServer.CertFile,
 		a.Config.GnmiServer.KeyFile,
 		a.Config.GnmiServer.SkipVerify,
-		true,
+		a.Config.GnmiServer.ACME,
+		a.Config.GnmiServer.SpiffeIDs,
+		a.Config.GnmiServer.TLSPolicy,
 	)
 	if err != nil {
 		return nil, err
@@ -247,6 +272,10 @@ func (a *App) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse,
 	}
 	defer a.unaryRPCsem.Release(1)
 
+	if a.gnmiProxy != nil {
+		return a.proxyGet(ctx, req)
+	}
+
 	numPaths := len(req.GetPath())
 	if numPaths == 0 && req.GetPrefix() == nil {
 		return nil, status.Errorf(codes.InvalidArgument, "missing path")
@@ -255,18 +284,26 @@ func (a *App) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse,
 	a.configLock.RLock()
 	defer a.configLock.RUnlock()
 
-	origins := make(map[string]struct{})
-	for _, p := range req.GetPath() {
-		origins[p.GetOrigin()] = struct{}{}
-		if p.GetOrigin() != "gnmic" {
-			if _, ok := origins["gnmic"]; ok {
-				return nil, status.Errorf(codes.InvalidArgument, "combining `gnmic` origin with other origin values is not supported")
-			}
+	internalReq, proxiedReq, err := splitGetRequestOrigins(req)
+	if err != nil {
+		return nil, err
+	}
+	var internalNotifications []*gnmi.Notification
+	if internalReq != nil {
+		rsp, err := a.handlegNMIcInternalGet(ctx, internalReq)
+		if err != nil {
+			return nil, err
 		}
+		if proxiedReq == nil {
+			return rsp, nil
+		}
+		internalNotifications = rsp.GetNotification()
 	}
-
-	if _, ok := origins["gnmic"]; ok {
-		return a.handlegNMIcInternalGet(ctx, req)
+	req = proxiedReq
+	numPaths = len(req.GetPath())
+	filter, err := newGetFilter(a.gnmiServerSchema, req)
+	if err != nil {
+		return nil, err
 	}
 
 	targetName := req.GetPrefix().GetTarget()
@@ -286,8 +323,9 @@ func (a *App) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse,
 
 	response := &gnmi.GetResponse{
 		// assume one notification per path per target
-		Notification: make([]*gnmi.Notification, 0, numTargets*numPaths),
+		Notification: make([]*gnmi.Notification, 0, numTargets*numPaths+len(internalNotifications)),
 	}
+	response.Notification = append(response.Notification, internalNotifications...)
 	done := make(chan struct{})
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -342,6 +380,9 @@ func (a *App) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse,
 				if n.GetPrefix().GetTarget() == "" {
 					n.Prefix.Target = name
 				}
+				if !filter.filterNotification(n) {
+					continue
+				}
 				results <- n
 			}
 		}(name, tc)
@@ -366,6 +407,10 @@ func (a *App) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetResponse,
 	}
 	defer a.unaryRPCsem.Release(1)
 
+	if a.gnmiProxy != nil {
+		return a.proxySet(ctx, req)
+	}
+
 	numUpdates := len(req.GetUpdate())
 	numReplaces := len(req.GetReplace())
 	numDeletes := len(req.GetDelete())
@@ -441,6 +486,7 @@ func (a *App) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetResponse,
 				creq.Prefix.Target = name
 			}
 			res, err := t.Set(ctx, creq)
+			a.auditSet(ctx, name, creq, err)
 			if err != nil {
 				a.Logger.Printf("target %q err: %v", name, err)
 				errChan <- fmt.Errorf("target %q err: %v", name, err)
@@ -491,6 +537,12 @@ func (a *App) Subscribe(stream gnmi.GNMI_SubscribeServer) error {
 		}
 	}
 
+	sc.targets, err = newTargetSelector(sc.target)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	sc.cacheTarget = sc.targets.cacheTarget()
+
 	a.Logger.Printf("received a subscribe request mode=%v from %q for target %q", sc.req.GetSubscribe().GetMode(), pr.Addr, sc.target)
 	defer a.Logger.Printf("subscription from peer %q terminated", pr.Addr)
 
@@ -506,6 +558,14 @@ func (a *App) Subscribe(stream gnmi.GNMI_SubscribeServer) error {
 
 	a.Logger.Printf("acquired subscription spot for target %q", sc.target)
 
+	if a.gnmiProxy != nil {
+		return a.proxySubscribe(stream, sc.req)
+	}
+
+	if h := subscribeHistory(sc.req); h != nil {
+		return a.handleHistorySubscriptionRequest(sc, h)
+	}
+
 	switch sc.req.GetSubscribe().GetMode() {
 	case gnmi.SubscriptionList_ONCE:
 		go func() {
@@ -543,14 +603,14 @@ func (a *App) handleONCESubscriptionRequest(sc *streamClient) {
 			paths = append(paths,
 				&gnmi.Path{
 					Origin: pr.GetOrigin(),
-					Target: pr.GetTarget(),
+					Target: sc.cacheTarget,
 					Elem:   append(pr.GetElem(), sub.GetPath().GetElem()...),
 				})
 		}
 	}
 	//
 	ro := &cache.ReadOpts{
-		Target:      sc.target,
+		Target:      sc.cacheTarget,
 		Paths:       paths,
 		Mode:        "once",
 		UpdatesOnly: sc.req.GetSubscribe().GetUpdatesOnly(),
@@ -570,11 +630,7 @@ func (a *App) handleONCESubscriptionRequest(sc *streamClient) {
 			err = n.Err
 			return
 		}
-		err = sc.stream.Send(&gnmi.SubscribeResponse{
-			Response: &gnmi.SubscribeResponse_Update{
-				Update: n.Notification,
-			},
-		})
+		err = sc.sendNotification(n.Notification)
 		if err != nil {
 			return
 		}
@@ -624,11 +680,11 @@ func (a *App) handleStreamSubscriptionRequest(sc *streamClient) {
 			switch sub.GetMode() {
 			case gnmi.SubscriptionMode_ON_CHANGE, gnmi.SubscriptionMode_TARGET_DEFINED:
 				ro := &cache.ReadOpts{
-					Target: sc.target,
+					Target: sc.cacheTarget,
 					Paths: []*gnmi.Path{
 						{
 							Origin: pr.GetOrigin(),
-							Target: pr.GetTarget(),
+							Target: sc.cacheTarget,
 							Elem:   append(pr.GetElem(), sub.GetPath().GetElem()...),
 						},
 					},
@@ -643,11 +699,7 @@ func (a *App) handleStreamSubscriptionRequest(sc *streamClient) {
 						err = n.Err
 						return
 					}
-					err = sc.stream.Send(&gnmi.SubscribeResponse{
-						Response: &gnmi.SubscribeResponse_Update{
-							Update: n.Notification,
-						},
-					})
+					err = sc.sendNotification(n.Notification)
 					if err != nil {
 						return
 					}
@@ -661,11 +713,11 @@ func (a *App) handleStreamSubscriptionRequest(sc *streamClient) {
 					period = a.Config.GnmiServer.MinSampleInterval
 				}
 				ro := &cache.ReadOpts{
-					Target: sc.target,
+					Target: sc.cacheTarget,
 					Paths: []*gnmi.Path{
 						{
 							Origin: pr.GetOrigin(),
-							Target: pr.GetTarget(),
+							Target: sc.cacheTarget,
 							Elem:   append(pr.GetElem(), sub.GetPath().GetElem()...),
 						}},
 					Mode:              cache.ReadMode_StreamSample,
@@ -681,11 +733,7 @@ func (a *App) handleStreamSubscriptionRequest(sc *streamClient) {
 						a.Logger.Printf("cache subscribe failed: %+v: %v", ro, err)
 						return
 					}
-					err = sc.stream.Send(&gnmi.SubscribeResponse{
-						Response: &gnmi.SubscribeResponse_Update{
-							Update: n.Notification,
-						},
-					})
+					err = sc.sendNotification(n.Notification)
 					if err != nil {
 						return
 					}
@@ -724,6 +772,11 @@ func (a *App) handlePolledSubscription(sc *streamClient) {
 ////
 
 func (a *App) handlegNMIcInternalGet(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+	switch req.GetType() {
+	case gnmi.GetRequest_STATE, gnmi.GetRequest_OPERATIONAL:
+		// the gNMIc configuration has no state data
+		return &gnmi.GetResponse{}, nil
+	}
 	notifications := make([]*gnmi.Notification, 0, len(req.GetPath()))
 	a.configLock.RLock()
 	defer a.configLock.RUnlock()
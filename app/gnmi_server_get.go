@@ -0,0 +1,369 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// gnmicOrigin is the origin of the paths served
+// from the gNMIc configuration instead of the targets.
+const gnmicOrigin = "gnmic"
+
+// loadGNMIServerSchema reads the YANG files configured under gnmi-server.
+// It returns a nil schema if none is configured.
+func (a *App) loadGNMIServerSchema() (*yang.Entry, error) {
+	return a.loadYangSchema(a.Config.GnmiServer.YangFiles, a.Config.GnmiServer.YangDirs, a.Config.GnmiServer.YangExclude)
+}
+
+// loadYangSchema reads the YANG files, resolving their imports from dirs,
+// and returns the schema root entry. It returns a nil schema if no file is set.
+func (a *App) loadYangSchema(yangFiles, yangDirs, excludes []string) (*yang.Entry, error) {
+	if len(yangFiles) == 0 {
+		return nil, nil
+	}
+	dirs, err := resolveGlobs(yangDirs)
+	if err != nil {
+		return nil, err
+	}
+	files, err := resolveGlobs(yangFiles)
+	if err != nil {
+		return nil, err
+	}
+	files, err = findYangFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	ms := yang.NewModules()
+	for _, dir := range dirs {
+		expanded, err := yang.PathsWithModules(dir)
+		if err != nil {
+			return nil, err
+		}
+		ms.AddPath(expanded...)
+	}
+	for _, f := range files {
+		err = ms.Read(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a.schemaTree(ms, excludes)
+}
+
+// splitGetRequestOrigins splits the paths of req between the ones with origin gnmic,
+// served from the gNMIc configuration, and the ones sent to the targets.
+// The origin can be set either in the prefix, in which case it applies to all the paths,
+// or in the paths.
+func splitGetRequestOrigins(req *gnmi.GetRequest) (*gnmi.GetRequest, *gnmi.GetRequest, error) {
+	prefixOrigin := req.GetPrefix().GetOrigin()
+	if len(req.GetPath()) == 0 {
+		if prefixOrigin == gnmicOrigin {
+			return req, nil, nil
+		}
+		return nil, req, nil
+	}
+	var internal, proxied []*gnmi.Path
+	for _, p := range req.GetPath() {
+		origin := p.GetOrigin()
+		if prefixOrigin != "" {
+			if origin != "" {
+				return nil, nil, status.Errorf(codes.InvalidArgument,
+					"path origin %q cannot be set along with the prefix origin %q", origin, prefixOrigin)
+			}
+			origin = prefixOrigin
+		}
+		if origin == gnmicOrigin {
+			internal = append(internal, p)
+			continue
+		}
+		proxied = append(proxied, p)
+	}
+	return getRequestWithPaths(req, internal), getRequestWithPaths(req, proxied), nil
+}
+
+func getRequestWithPaths(req *gnmi.GetRequest, paths []*gnmi.Path) *gnmi.GetRequest {
+	if len(paths) == 0 {
+		return nil
+	}
+	if len(paths) == len(req.GetPath()) {
+		return req
+	}
+	nreq := proto.Clone(req).(*gnmi.GetRequest)
+	nreq.Path = make([]*gnmi.Path, 0, len(paths))
+	for _, p := range paths {
+		nreq.Path = append(nreq.Path, proto.Clone(p).(*gnmi.Path))
+	}
+	return nreq
+}
+
+// getFilter prunes the data returned by the targets
+// that does not match the Get request data type and models.
+type getFilter struct {
+	dataType gnmi.GetRequest_DataType
+	// top level schema node name to module name
+	topModules map[string]string
+	// modules selected with use_models, nil for all
+	models map[string]struct{}
+	root   *yang.Entry
+}
+
+// newGetFilter returns the filter applied to the responses to req,
+// nil if no schema is loaded or the request selects all the data.
+func newGetFilter(root *yang.Entry, req *gnmi.GetRequest) (*getFilter, error) {
+	if root == nil {
+		return nil, nil
+	}
+	if req.GetType() == gnmi.GetRequest_ALL && len(req.GetUseModels()) == 0 {
+		return nil, nil
+	}
+	f := &getFilter{
+		dataType:   req.GetType(),
+		topModules: make(map[string]string),
+		root:       root,
+	}
+	for modName, mod := range root.Dir {
+		for name := range mod.Dir {
+			f.topModules[name] = modName
+		}
+	}
+	if len(req.GetUseModels()) > 0 {
+		f.models = make(map[string]struct{}, len(req.GetUseModels()))
+		for _, m := range req.GetUseModels() {
+			if _, ok := root.Dir[m.GetName()]; !ok {
+				return nil, status.Errorf(codes.InvalidArgument, "unsupported model %q", m.GetName())
+			}
+			f.models[m.GetName()] = struct{}{}
+		}
+	}
+	return f, nil
+}
+
+// filterNotification removes the updates of n not matching the filter.
+// It returns false if n has no update nor delete left.
+func (f *getFilter) filterNotification(n *gnmi.Notification) bool {
+	if f == nil || len(n.GetUpdate()) == 0 {
+		return true
+	}
+	updates := n.Update[:0]
+	for _, upd := range n.GetUpdate() {
+		if f.filterUpdate(n.GetPrefix(), upd) {
+			updates = append(updates, upd)
+		}
+	}
+	n.Update = updates
+	return len(n.Update) > 0 || len(n.GetDelete()) > 0
+}
+
+// filterUpdate returns false if the update does not match the filter,
+// JSON values are pruned of the nodes not matching the data type.
+// Updates with a path not found in the schema are kept.
+func (f *getFilter) filterUpdate(prefix *gnmi.Path, upd *gnmi.Update) bool {
+	elems := make([]*gnmi.PathElem, 0, len(prefix.GetElem())+len(upd.GetPath().GetElem()))
+	elems = append(elems, prefix.GetElem()...)
+	elems = append(elems, upd.GetPath().GetElem()...)
+	if len(elems) == 0 {
+		// the value holds the whole tree
+		return f.filterValue(nil, upd)
+	}
+	modName, name := f.module(elems[0].GetName())
+	if f.models != nil {
+		if _, ok := f.models[modName]; !ok {
+			return false
+		}
+	}
+	e := f.root.Dir[modName]
+	if e == nil {
+		return true
+	}
+	e = e.Dir[name]
+	for _, pe := range elems[1:] {
+		if e == nil {
+			return true
+		}
+		e = e.Dir[stripModule(pe.GetName())]
+	}
+	if e == nil {
+		return true
+	}
+	return f.filterValue(e, upd)
+}
+
+// filterValue applies the data type filter to the value of upd,
+// e is the schema node of the update path, nil for the root.
+func (f *getFilter) filterValue(e *yang.Entry, upd *gnmi.Update) bool {
+	var b []byte
+	switch v := upd.GetVal().GetValue().(type) {
+	case *gnmi.TypedValue_JsonVal:
+		b = v.JsonVal
+	case *gnmi.TypedValue_JsonIetfVal:
+		b = v.JsonIetfVal
+	default:
+		return e == nil || f.matchType(e)
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return e == nil || f.matchType(e)
+	}
+	var ok bool
+	if e == nil {
+		v, ok = f.pruneRoot(v)
+	} else {
+		v, ok = f.prune(e, v)
+	}
+	if !ok {
+		return false
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	switch upd.GetVal().GetValue().(type) {
+	case *gnmi.TypedValue_JsonVal:
+		upd.Val = &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonVal{JsonVal: b}}
+	default:
+		upd.Val = &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: b}}
+	}
+	return true
+}
+
+// pruneRoot prunes a JSON value holding top level nodes.
+func (f *getFilter) pruneRoot(v interface{}) (interface{}, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v, true
+	}
+	for k, cv := range m {
+		modName, name := f.module(k)
+		if f.models != nil {
+			if _, ok := f.models[modName]; !ok {
+				delete(m, k)
+				continue
+			}
+		}
+		mod := f.root.Dir[modName]
+		if mod == nil || mod.Dir[name] == nil {
+			continue
+		}
+		pv, ok := f.prune(mod.Dir[name], cv)
+		if !ok {
+			delete(m, k)
+			continue
+		}
+		m[k] = pv
+	}
+	return m, len(m) > 0
+}
+
+// prune removes from the JSON value v of schema node e
+// the nodes not matching the data type.
+// It returns false if nothing is left.
+func (f *getFilter) prune(e *yang.Entry, v interface{}) (interface{}, bool) {
+	if f.dataType == gnmi.GetRequest_ALL {
+		return v, true
+	}
+	if !e.IsDir() {
+		return v, f.matchType(e)
+	}
+	switch v := v.(type) {
+	case []interface{}:
+		items := v[:0]
+		for _, item := range v {
+			if pi, ok := f.pruneContainer(e, item); ok {
+				items = append(items, pi)
+			}
+		}
+		return items, len(items) > 0
+	default:
+		return f.pruneContainer(e, v)
+	}
+}
+
+func (f *getFilter) pruneContainer(e *yang.Entry, v interface{}) (interface{}, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v, f.matchType(e)
+	}
+	var keys map[string]struct{}
+	if e.IsList() {
+		keys = make(map[string]struct{})
+		for _, k := range strings.Fields(e.Key) {
+			keys[k] = struct{}{}
+		}
+	}
+	kept := 0
+	for k, cv := range m {
+		name := stripModule(k)
+		if _, ok := keys[name]; ok {
+			// list keys are kept to identify the list entries
+			continue
+		}
+		ce := e.Dir[name]
+		if ce == nil {
+			kept++
+			continue
+		}
+		pv, ok := f.prune(ce, cv)
+		if !ok {
+			delete(m, k)
+			continue
+		}
+		m[k] = pv
+		kept++
+	}
+	return m, kept > 0
+}
+
+// matchType reports whether the schema node e is of the filter data type.
+// OPERATIONAL data is the read-only data without a configurable counterpart,
+// i.e. a node with the same name in a sibling "config" container.
+func (f *getFilter) matchType(e *yang.Entry) bool {
+	switch f.dataType {
+	case gnmi.GetRequest_CONFIG:
+		return !e.ReadOnly()
+	case gnmi.GetRequest_STATE:
+		return e.ReadOnly()
+	case gnmi.GetRequest_OPERATIONAL:
+		if !e.ReadOnly() {
+			return false
+		}
+		if e.Parent == nil || e.Parent.Parent == nil {
+			return true
+		}
+		cfg := e.Parent.Parent.Dir["config"]
+		return cfg == nil || cfg == e.Parent || cfg.Dir[e.Name] == nil
+	}
+	return true
+}
+
+// module returns the module name and the node name of
+// a top level path element name, optionally prefixed with its module name.
+func (f *getFilter) module(name string) (string, string) {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return f.topModules[name], name
+}
+
+func stripModule(name string) string {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,226 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+const testGetFilterModule = `
+module test-interfaces {
+  namespace "urn:test:interfaces";
+  prefix ti;
+
+  grouping config {
+    leaf name { type string; }
+    leaf mtu { type uint16; }
+  }
+
+  container interfaces {
+    list interface {
+      key name;
+      leaf name { type string; }
+      container config {
+        uses config;
+      }
+      container state {
+        config false;
+        uses config;
+        leaf oper-status { type string; }
+      }
+    }
+  }
+}
+`
+
+func testGetFilterSchema(t *testing.T) *yang.Entry {
+	t.Helper()
+	ms := yang.NewModules()
+	err := ms.Parse(testGetFilterModule, "test-interfaces.yang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := New().schemaTree(ms, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestSplitGetRequestOrigins(t *testing.T) {
+	p := func(origin, name string) *gnmi.Path {
+		return &gnmi.Path{Origin: origin, Elem: []*gnmi.PathElem{{Name: name}}}
+	}
+	tests := map[string]struct {
+		req      *gnmi.GetRequest
+		internal int
+		proxied  int
+		err      bool
+	}{
+		"proxied": {
+			req:     &gnmi.GetRequest{Path: []*gnmi.Path{p("", "interfaces"), p("openconfig", "system")}},
+			proxied: 2,
+		},
+		"internal": {
+			req:      &gnmi.GetRequest{Path: []*gnmi.Path{p("gnmic", "targets")}},
+			internal: 1,
+		},
+		"mixed": {
+			req:      &gnmi.GetRequest{Path: []*gnmi.Path{p("gnmic", "targets"), p("openconfig", "system"), p("", "interfaces")}},
+			internal: 1,
+			proxied:  2,
+		},
+		"prefix_origin": {
+			req: &gnmi.GetRequest{
+				Prefix: &gnmi.Path{Origin: "gnmic"},
+				Path:   []*gnmi.Path{p("", "targets"), p("", "subscriptions")},
+			},
+			internal: 2,
+		},
+		"prefix_and_path_origin": {
+			req: &gnmi.GetRequest{
+				Prefix: &gnmi.Path{Origin: "openconfig"},
+				Path:   []*gnmi.Path{p("gnmic", "targets")},
+			},
+			err: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			internal, proxied, err := splitGetRequestOrigins(tc.req)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := len(internal.GetPath()); n != tc.internal {
+				t.Errorf("expected %d internal paths, got %d", tc.internal, n)
+			}
+			if n := len(proxied.GetPath()); n != tc.proxied {
+				t.Errorf("expected %d proxied paths, got %d", tc.proxied, n)
+			}
+		})
+	}
+}
+
+func TestGetFilter(t *testing.T) {
+	root := testGetFilterSchema(t)
+	ifPath := &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interfaces"}}}
+	ifJSON := `{"interface":[{"name":"e1","config":{"name":"e1","mtu":1500},"state":{"name":"e1","mtu":1500,"oper-status":"UP"}}]}`
+	tests := map[string]struct {
+		req  *gnmi.GetRequest
+		path *gnmi.Path
+		val  *gnmi.TypedValue
+		// expected JSON value, empty if the update is dropped
+		want string
+		err  bool
+	}{
+		"config": {
+			req:  &gnmi.GetRequest{Type: gnmi.GetRequest_CONFIG},
+			path: ifPath,
+			val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: []byte(ifJSON)}},
+			want: `{"interface":[{"config":{"mtu":1500,"name":"e1"},"name":"e1"}]}`,
+		},
+		"state": {
+			req:  &gnmi.GetRequest{Type: gnmi.GetRequest_STATE},
+			path: ifPath,
+			val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: []byte(ifJSON)}},
+			want: `{"interface":[{"name":"e1","state":{"mtu":1500,"name":"e1","oper-status":"UP"}}]}`,
+		},
+		"operational": {
+			req:  &gnmi.GetRequest{Type: gnmi.GetRequest_OPERATIONAL},
+			path: ifPath,
+			val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: []byte(ifJSON)}},
+			want: `{"interface":[{"name":"e1","state":{"oper-status":"UP"}}]}`,
+		},
+		"state_leaf_dropped": {
+			req: &gnmi.GetRequest{Type: gnmi.GetRequest_CONFIG},
+			path: &gnmi.Path{Elem: []*gnmi.PathElem{
+				{Name: "interfaces"},
+				{Name: "interface", Key: map[string]string{"name": "e1"}},
+				{Name: "state"},
+				{Name: "oper-status"},
+			}},
+			val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "UP"}},
+		},
+		"unknown_path_kept": {
+			req:  &gnmi.GetRequest{Type: gnmi.GetRequest_CONFIG},
+			path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "system"}}},
+			val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonVal{JsonVal: []byte(`{"a":1}`)}},
+			want: `{"a":1}`,
+		},
+		"use_models": {
+			req: &gnmi.GetRequest{
+				UseModels: []*gnmi.ModelData{{Name: "test-interfaces"}},
+			},
+			path: ifPath,
+			val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: []byte(ifJSON)}},
+			want: ifJSON,
+		},
+		"use_models_unknown": {
+			req: &gnmi.GetRequest{
+				UseModels: []*gnmi.ModelData{{Name: "test-system"}},
+			},
+			err: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := newGetFilter(root, tc.req)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := &gnmi.Notification{Update: []*gnmi.Update{{Path: tc.path, Val: tc.val}}}
+			kept := f.filterNotification(n)
+			if tc.want == "" {
+				if kept {
+					t.Fatalf("expected the update to be dropped, got %v", n.GetUpdate())
+				}
+				return
+			}
+			if !kept {
+				t.Fatal("unexpected dropped update")
+			}
+			var got, want interface{}
+			err = json.Unmarshal(jsonValue(n.GetUpdate()[0].GetVal()), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = json.Unmarshal([]byte(tc.want), &want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("unexpected value: got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func jsonValue(v *gnmi.TypedValue) []byte {
+	if b := v.GetJsonIetfVal(); b != nil {
+		return b
+	}
+	return v.GetJsonVal()
+}
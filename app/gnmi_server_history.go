@@ -0,0 +1,81 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/proto/gnmi_ext"
+	"github.com/openconfig/gnmic/cache"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// subscribeHistory returns the History extension of the subscribe request, if any.
+func subscribeHistory(req *gnmi.SubscribeRequest) *gnmi_ext.History {
+	for _, ext := range req.GetExtension() {
+		if h := ext.GetHistory(); h != nil {
+			return h
+		}
+	}
+	return nil
+}
+
+// handleHistorySubscriptionRequest replays the cached notifications within the
+// History extension time range, sends a sync response then returns, terminating the RPC.
+func (a *App) handleHistorySubscriptionRequest(sc *streamClient, h *gnmi_ext.History) error {
+	if sc.req.GetSubscribe().GetMode() != gnmi.SubscriptionList_STREAM {
+		return status.Errorf(codes.InvalidArgument, "the history extension is only supported with STREAM subscriptions")
+	}
+	r := h.GetRange()
+	if r == nil {
+		return status.Errorf(codes.Unimplemented, "history snapshot_time is not supported")
+	}
+	if r.GetStart() <= 0 || (r.GetEnd() > 0 && r.GetEnd() < r.GetStart()) {
+		return status.Errorf(codes.InvalidArgument, "invalid history range: start=%d, end=%d", r.GetStart(), r.GetEnd())
+	}
+
+	pr := sc.req.GetSubscribe().GetPrefix()
+	subs := sc.req.GetSubscribe().GetSubscription()
+	paths := make([]*gnmi.Path, 0, len(subs))
+	for _, sub := range subs {
+		paths = append(paths, &gnmi.Path{
+			Origin: pr.GetOrigin(),
+			Target: sc.cacheTarget,
+			Elem:   append(pr.GetElem(), sub.GetPath().GetElem()...),
+		})
+	}
+	ro := &cache.ReadOpts{
+		Target:    sc.cacheTarget,
+		Paths:     paths,
+		Mode:      cache.ReadMode_Once,
+		StartTime: time.Unix(0, r.GetStart()),
+	}
+	if r.GetEnd() > 0 {
+		ro.EndTime = time.Unix(0, r.GetEnd())
+	}
+	a.Logger.Printf("replaying history of target %q from %s to %s", sc.target, ro.StartTime, ro.EndTime)
+	for n := range a.c.Subscribe(sc.stream.Context(), ro) {
+		if n.Err != nil {
+			if errors.Is(n.Err, cache.ErrHistoryNotSupported) {
+				return status.Errorf(codes.Unimplemented, "%v", n.Err)
+			}
+			return status.Errorf(codes.Internal, "%v", n.Err)
+		}
+		err := sc.sendNotification(n.Notification)
+		if err != nil {
+			return err
+		}
+	}
+	return sc.stream.Send(&gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true},
+	})
+}
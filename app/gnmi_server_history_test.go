@@ -0,0 +1,105 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/proto/gnmi_ext"
+	"github.com/openconfig/gnmic/cache"
+	"golang.org/x/sync/semaphore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestGNMIServerHistory(t *testing.T) {
+	a := New()
+	defer a.Cfn()
+	var err error
+	a.c, err = cache.New(&cache.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.subscribeRPCsem = semaphore.NewWeighted(10)
+	addr := startTestGRPCServer(t, a)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := gnmi.NewGNMIClient(conn)
+
+	start := time.Now().Add(-time.Minute).UnixNano()
+	tests := []struct {
+		name    string
+		mode    gnmi.SubscriptionList_Mode
+		history *gnmi_ext.History
+		code    codes.Code
+	}{
+		{
+			name:    "once",
+			mode:    gnmi.SubscriptionList_ONCE,
+			history: &gnmi_ext.History{Request: &gnmi_ext.History_Range{Range: &gnmi_ext.TimeRange{Start: start}}},
+			code:    codes.InvalidArgument,
+		},
+		{
+			name:    "snapshot_time",
+			mode:    gnmi.SubscriptionList_STREAM,
+			history: &gnmi_ext.History{Request: &gnmi_ext.History_SnapshotTime{SnapshotTime: start}},
+			code:    codes.Unimplemented,
+		},
+		{
+			name:    "invalid_range",
+			mode:    gnmi.SubscriptionList_STREAM,
+			history: &gnmi_ext.History{Request: &gnmi_ext.History_Range{Range: &gnmi_ext.TimeRange{Start: start, End: start - 1}}},
+			code:    codes.InvalidArgument,
+		},
+		{
+			// the oc cache does not retain history
+			name:    "cache_without_history",
+			mode:    gnmi.SubscriptionList_STREAM,
+			history: &gnmi_ext.History{Request: &gnmi_ext.History_Range{Range: &gnmi_ext.TimeRange{Start: start}}},
+			code:    codes.Unimplemented,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.Subscribe(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = stream.Send(&gnmi.SubscribeRequest{
+				Request: &gnmi.SubscribeRequest_Subscribe{
+					Subscribe: &gnmi.SubscriptionList{
+						Prefix: &gnmi.Path{Target: "router1"},
+						Mode:   tt.mode,
+					},
+				},
+				Extension: []*gnmi_ext.Extension{
+					{Ext: &gnmi_ext.Extension_History{History: tt.history}},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = stream.Recv()
+			if status.Code(err) != tt.code {
+				t.Errorf("expected a %v error, got: %v", tt.code, err)
+			}
+		})
+	}
+}
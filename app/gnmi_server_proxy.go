@@ -0,0 +1,151 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/openconfig/gnmic/target"
+)
+
+// gnmiProxy holds the pooled target connections used by the gNMI server in proxy mode.
+type gnmiProxy struct {
+	m       *sync.Mutex
+	targets map[string]*target.Target
+}
+
+func newGNMIProxy() *gnmiProxy {
+	return &gnmiProxy{
+		m:       new(sync.Mutex),
+		targets: make(map[string]*target.Target),
+	}
+}
+
+// proxyTarget returns a connected target for the target name found in the request prefix.
+// The connections are created on first use and reused by the following RPCs.
+func (a *App) proxyTarget(ctx context.Context, name string) (*target.Target, error) {
+	if name == "" || name == "*" || strings.Contains(name, ",") {
+		return nil, status.Errorf(codes.InvalidArgument, "proxy mode requires a single target in the request prefix, got %q", name)
+	}
+	a.configLock.RLock()
+	tc, ok := a.Config.Targets[name]
+	a.configLock.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "target %q is not known", name)
+	}
+
+	a.gnmiProxy.m.Lock()
+	defer a.gnmiProxy.m.Unlock()
+	if t, ok := a.gnmiProxy.targets[name]; ok {
+		return t, nil
+	}
+	t := target.NewTarget(tc)
+	ctx, cancel := context.WithTimeout(ctx, tc.Timeout)
+	defer cancel()
+	err := a.CreateGNMIClient(ctx, t)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "%v", err)
+	}
+	a.gnmiProxy.targets[name] = t
+	return t, nil
+}
+
+// closeProxyTarget closes the pooled connection to target name, if any.
+func (a *App) closeProxyTarget(name string) {
+	if a.gnmiProxy == nil {
+		return
+	}
+	a.gnmiProxy.m.Lock()
+	defer a.gnmiProxy.m.Unlock()
+	if t, ok := a.gnmiProxy.targets[name]; ok {
+		t.Close()
+		delete(a.gnmiProxy.targets, name)
+	}
+}
+
+func (a *App) proxyGet(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+	pr, _ := peer.FromContext(ctx)
+	a.Logger.Printf("proxying Get request from %q to target %q", pr.Addr, req.GetPrefix().GetTarget())
+	t, err := a.proxyTarget(ctx, req.GetPrefix().GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	return t.Get(ctx, req)
+}
+
+func (a *App) proxySet(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+	pr, _ := peer.FromContext(ctx)
+	a.Logger.Printf("proxying Set request from %q to target %q", pr.Addr, req.GetPrefix().GetTarget())
+	t, err := a.proxyTarget(ctx, req.GetPrefix().GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := t.Set(ctx, req)
+	a.auditSet(ctx, req.GetPrefix().GetTarget(), req, err)
+	return rsp, err
+}
+
+// proxySubscribe relays the subscribe request req and the following ones (polls)
+// received from the client stream to the target, and relays back the target responses.
+func (a *App) proxySubscribe(stream gnmi.GNMI_SubscribeServer, req *gnmi.SubscribeRequest) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	pr, _ := peer.FromContext(ctx)
+	name := req.GetSubscribe().GetPrefix().GetTarget()
+	a.Logger.Printf("proxying Subscribe request from %q to target %q", pr.Addr, name)
+	t, err := a.proxyTarget(ctx, name)
+	if err != nil {
+		return err
+	}
+	upstream, err := t.SubscribeClient(ctx)
+	if err != nil {
+		return err
+	}
+	err = upstream.Send(req)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					upstream.CloseSend()
+				}
+				return
+			}
+			err = upstream.Send(req)
+			if err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		rsp, err := upstream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		err = stream.Send(rsp)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"golang.org/x/sync/semaphore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+type proxyTestServer struct {
+	gnmi.UnimplementedGNMIServer
+	users chan string
+}
+
+func (s *proxyTestServer) user(ctx context.Context) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if u := md.Get("username"); len(u) > 0 {
+		s.users <- u[0]
+		return
+	}
+	s.users <- ""
+}
+
+func (s *proxyTestServer) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+	s.user(ctx)
+	return &gnmi.GetResponse{
+		Notification: []*gnmi.Notification{{Prefix: req.GetPrefix(), Timestamp: 42}},
+	}, nil
+}
+
+func (s *proxyTestServer) Subscribe(stream gnmi.GNMI_SubscribeServer) error {
+	s.user(stream.Context())
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	err = stream.Send(&gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{Prefix: req.GetSubscribe().GetPrefix(), Timestamp: 42},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return stream.Send(&gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true},
+	})
+}
+
+func startTestGRPCServer(t *testing.T, s gnmi.GNMIServer) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	gnmi.RegisterGNMIServer(srv, s)
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+	return l.Addr().String()
+}
+
+func TestGNMIServerProxy(t *testing.T) {
+	ts := &proxyTestServer{users: make(chan string, 10)}
+	targetAddr := startTestGRPCServer(t, ts)
+
+	a := New()
+	defer a.Cfn()
+	insecureConn := true
+	username := "admin"
+	password := "secret"
+	a.Config.Targets = map[string]*types.TargetConfig{
+		"t1": {
+			Name:     "t1",
+			Address:  targetAddr,
+			Insecure: &insecureConn,
+			Username: &username,
+			Password: &password,
+			Timeout:  5 * time.Second,
+		},
+	}
+	a.gnmiProxy = newGNMIProxy()
+	a.unaryRPCsem = semaphore.NewWeighted(10)
+	a.subscribeRPCsem = semaphore.NewWeighted(10)
+	proxyAddr := startTestGRPCServer(t, a)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, proxyAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := gnmi.NewGNMIClient(conn)
+
+	// Get RPCs are relayed and share the same target connection
+	for i := 0; i < 2; i++ {
+		rsp, err := client.Get(ctx, &gnmi.GetRequest{
+			Prefix: &gnmi.Path{Target: "t1"},
+			Path:   []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "interfaces"}}}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rsp.GetNotification()) != 1 || rsp.GetNotification()[0].GetTimestamp() != 42 {
+			t.Fatalf("unexpected Get response: %v", rsp)
+		}
+		if u := <-ts.users; u != username {
+			t.Errorf("expected the target credentials to be used, got username %q", u)
+		}
+	}
+	if len(a.gnmiProxy.targets) != 1 {
+		t.Errorf("expected 1 pooled target connection, got %d", len(a.gnmiProxy.targets))
+	}
+
+	// Subscribe RPCs are relayed
+	stream, err := client.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = stream.Send(&gnmi.SubscribeRequest{
+		Request: &gnmi.SubscribeRequest_Subscribe{
+			Subscribe: &gnmi.SubscriptionList{
+				Prefix: &gnmi.Path{Target: "t1"},
+				Mode:   gnmi.SubscriptionList_ONCE,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp.GetUpdate().GetPrefix().GetTarget() != "t1" || rsp.GetUpdate().GetTimestamp() != 42 {
+		t.Errorf("unexpected Subscribe response: %v", rsp)
+	}
+	rsp, err = stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rsp.GetSyncResponse() {
+		t.Errorf("expected a sync response, got: %v", rsp)
+	}
+	if u := <-ts.users; u != username {
+		t.Errorf("expected the target credentials to be used, got username %q", u)
+	}
+
+	// a single known target is required
+	for _, target := range []string{"", "*", "t1,t2"} {
+		_, err = client.Get(ctx, &gnmi.GetRequest{
+			Prefix: &gnmi.Path{Target: target},
+			Path:   []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "interfaces"}}}},
+		})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("target %q: expected an InvalidArgument error, got: %v", target, err)
+		}
+	}
+	_, err = client.Get(ctx, &gnmi.GetRequest{
+		Prefix: &gnmi.Path{Target: "t2"},
+		Path:   []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "interfaces"}}}},
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected a NotFound error, got: %v", err)
+	}
+}
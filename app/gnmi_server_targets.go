@@ -0,0 +1,69 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/openconfig/gnmic/utils"
+)
+
+// targetSelector selects the cached targets a gNMI server subscription applies to.
+// It is built from the subscribe request prefix target: "*" for all targets,
+// or a comma separated list of target names and glob patterns, e.g: "leaf*,spine[12]".
+// A target is selected if its name, or the host part of its name, matches.
+type targetSelector struct {
+	patterns []string
+}
+
+func newTargetSelector(target string) (*targetSelector, error) {
+	ts := new(targetSelector)
+	for _, p := range strings.Split(target, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if p == "*" {
+			return &targetSelector{}, nil
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid target pattern %q: %v", p, err)
+		}
+		ts.patterns = append(ts.patterns, p)
+	}
+	return ts, nil
+}
+
+// cacheTarget returns the target to query the cache with:
+// the target name if a single one is selected, "*" otherwise.
+func (ts *targetSelector) cacheTarget() string {
+	if len(ts.patterns) == 1 && !strings.ContainsAny(ts.patterns[0], `*?[\`) {
+		return ts.patterns[0]
+	}
+	return "*"
+}
+
+// matches returns true if target is selected.
+func (ts *targetSelector) matches(target string) bool {
+	if len(ts.patterns) == 0 {
+		return true
+	}
+	host := utils.GetHost(target)
+	for _, p := range ts.patterns {
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, host); ok {
+			return true
+		}
+	}
+	return false
+}
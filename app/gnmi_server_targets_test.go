@@ -0,0 +1,85 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import "testing"
+
+func TestTargetSelector(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		cacheTarget string
+		matches     map[string]bool
+		wantErr     bool
+	}{
+		{
+			name:        "all",
+			target:      "*",
+			cacheTarget: "*",
+			matches:     map[string]bool{"router1": true, "router2:57400": true},
+		},
+		{
+			name:        "single",
+			target:      "router1",
+			cacheTarget: "router1",
+			matches:     map[string]bool{"router1": true, "router1:57400": true, "router2": false},
+		},
+		{
+			name:        "list",
+			target:      "router1, router2",
+			cacheTarget: "*",
+			matches:     map[string]bool{"router1": true, "router2": true, "router3": false},
+		},
+		{
+			name:        "patterns",
+			target:      "leaf*,spine[12]",
+			cacheTarget: "*",
+			matches: map[string]bool{
+				"leaf1":         true,
+				"leaf2:57400":   true,
+				"spine1":        true,
+				"spine3":        false,
+				"super-spine1":  false,
+				"border-leaf1":  false,
+				"spine2:57400":  true,
+				"10.1.1.1:6030": false,
+			},
+		},
+		{
+			name:        "all in list",
+			target:      "router1,*",
+			cacheTarget: "*",
+			matches:     map[string]bool{"router2": true},
+		},
+		{
+			name:    "bad pattern",
+			target:  "leaf[1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, err := newTargetSelector(tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := ts.cacheTarget(); got != tt.cacheTarget {
+				t.Errorf("cache target: got %q, want %q", got, tt.cacheTarget)
+			}
+			for name, want := range tt.matches {
+				if got := ts.matches(name); got != want {
+					t.Errorf("target %q: got match=%v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
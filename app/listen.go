@@ -0,0 +1,303 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"text/template"
+
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/jhump/protoreflect/dynamic"
+	nokiasros "github.com/karimra/sros-dialout"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/utils"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const defaultListenMaxConcurrentStreams = 256
+
+type dialoutTelemetryServer struct {
+	a              *App
+	ctx            context.Context
+	targetTemplate *template.Template
+}
+
+func (a *App) ListenPreRunE(cmd *cobra.Command, args []string) error {
+	a.Config.SetLocalFlagsFromFile(cmd)
+	a.Config.LocalFlags.ListenOutput = config.SanitizeArrayFlagValue(a.Config.LocalFlags.ListenOutput)
+	return nil
+}
+
+func (a *App) ListenRunE(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+
+	if len(a.Config.Address) == 0 {
+		return errors.New("no address specified")
+	}
+	if len(a.Config.Address) > 1 {
+		fmt.Fprintf(a.out, "multiple addresses specified, listening only on %s\n", a.Config.Address[0])
+	}
+	err := a.readConfigs()
+	if err != nil {
+		return err
+	}
+	// the proto files are used to decode the proto bytes values
+	_, err = a.LoadProtoFiles()
+	if err != nil {
+		return fmt.Errorf("failed loading proto files: %v", err)
+	}
+	// the address flag is the listen address,
+	// targets are only read from the config file.
+	_, err = a.Config.GetTargetsFromFile()
+	if err != nil && !errors.Is(err, config.ErrNoTargetsFound) {
+		return fmt.Errorf("failed reading targets config: %v", err)
+	}
+	server := &dialoutTelemetryServer{
+		a:   a,
+		ctx: ctx,
+	}
+	if a.Config.LocalFlags.ListenTargetTemplate != "" {
+		server.targetTemplate, err = utils.CreateTemplate("listen-target-template", a.Config.LocalFlags.ListenTargetTemplate)
+		if err != nil {
+			return fmt.Errorf("failed to parse target template: %v", err)
+		}
+		server.targetTemplate = server.targetTemplate.Funcs(outputs.TemplateFuncs)
+	}
+	// start outputs
+	outs := a.Config.LocalFlags.ListenOutput
+	if len(outs) == 0 {
+		a.InitOutputs(ctx)
+	} else {
+		for _, name := range outs {
+			if _, ok := a.Config.Outputs[name]; !ok {
+				return fmt.Errorf("unknown output %q", name)
+			}
+			a.InitOutput(ctx, name, a.Config.Targets)
+		}
+	}
+	defer func() {
+		a.operLock.RLock()
+		defer a.operLock.RUnlock()
+		for _, o := range a.Outputs {
+			o.Close()
+		}
+	}()
+
+	listener, err := net.Listen("tcp", a.Config.Address[0])
+	if err != nil {
+		return err
+	}
+	a.Logger.Printf("waiting for connections on %s", a.Config.Address[0])
+	var opts []grpc.ServerOption
+	if a.Config.MaxMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(a.Config.MaxMsgSize))
+	}
+	opts = append(opts,
+		grpc.MaxConcurrentStreams(a.Config.LocalFlags.ListenMaxConcurrentStreams),
+		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor))
+
+	if a.Config.TLSKey != "" && a.Config.TLSCert != "" {
+		tlsConfig, err := utils.NewTLSConfig(
+			a.Config.TLSCa,
+			a.Config.TLSCert,
+			a.Config.TLSKey,
+			a.Config.SkipVerify,
+			false)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	grpcServer := grpc.NewServer(opts...)
+	nokiasros.RegisterDialoutTelemetryServer(grpcServer, server)
+
+	if a.Config.LocalFlags.ListenPrometheusAddress != "" {
+		grpc_prometheus.Register(grpcServer)
+
+		httpServer := &http.Server{
+			Handler: promhttp.Handler(),
+			Addr:    a.Config.LocalFlags.ListenPrometheusAddress,
+		}
+		go func() {
+			if err := httpServer.ListenAndServe(); err != nil {
+				a.Logger.Printf("Unable to start prometheus http server.")
+			}
+		}()
+		defer httpServer.Close()
+	}
+	defer grpcServer.Stop()
+	return grpcServer.Serve(listener)
+}
+
+func (a *App) InitListenFlags(cmd *cobra.Command) {
+	cmd.ResetFlags()
+
+	cmd.Flags().Uint32VarP(&a.Config.LocalFlags.ListenMaxConcurrentStreams, "max-concurrent-streams", "", defaultListenMaxConcurrentStreams, "max concurrent streams gnmic can receive per transport")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.ListenPrometheusAddress, "prometheus-address", "", "", "prometheus server address")
+	cmd.Flags().StringSliceVarP(&a.Config.LocalFlags.ListenOutput, "output", "", []string{}, "name(s) of the outputs to write the received updates to, defaults to all configured outputs")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.ListenTargetTemplate, "target-template", "", "", "Go template used to derive the target name from the dial-out metadata, defaults to the peer address")
+
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
+	})
+}
+
+func (s *dialoutTelemetryServer) Publish(stream nokiasros.DialoutTelemetry_PublishServer) error {
+	pr, ok := peer.FromContext(stream.Context())
+	if !ok {
+		return errors.New("failed to get peer from stream context")
+	}
+	if s.a.Config.Debug {
+		b, err := json.Marshal(pr)
+		if err != nil {
+			s.a.Logger.Printf("failed to marshal peer data: %v", err)
+		} else {
+			s.a.Logger.Printf("received Publish RPC from peer=%s", string(b))
+		}
+	}
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	if s.a.Config.Debug {
+		b, err := json.Marshal(md)
+		if err != nil {
+			s.a.Logger.Printf("failed to marshal context metadata: %v", err)
+		} else {
+			s.a.Logger.Printf("received http2_header=%s", string(b))
+		}
+	}
+	if len(md.Get("subscription-name")) == 0 {
+		s.a.Logger.Println("could not find subscription-name in http2 headers")
+	}
+	if len(md.Get("system-name")) == 0 {
+		s.a.Logger.Println("could not find system-name in http2 headers")
+	}
+	meta, outs, err := s.streamMeta(pr.Addr.String(), md)
+	if err != nil {
+		s.a.Logger.Printf("failed to derive target name from peer %s: %v", pr.Addr, err)
+		return err
+	}
+
+	for {
+		subResp, err := stream.Recv()
+		if err != nil {
+			if err != io.EOF {
+				s.a.Logger.Printf("gRPC dialout receive error: %v", err)
+			}
+			break
+		}
+		err = stream.Send(&nokiasros.PublishResponse{})
+		if err != nil {
+			s.a.Logger.Printf("error sending publish response to server: %v", err)
+		}
+		switch resp := subResp.Response.(type) {
+		case *gnmi.SubscribeResponse_Update:
+			if s.a.rootDesc != nil {
+				for _, update := range resp.Update.Update {
+					switch update.Val.Value.(type) {
+					case *gnmi.TypedValue_ProtoBytes:
+						m := dynamic.NewMessage(s.a.rootDesc.GetFile().FindMessage("Nokia.SROS.root"))
+						err := m.Unmarshal(update.Val.GetProtoBytes())
+						if err != nil {
+							s.a.Logger.Printf("failed to unmarshal m: %v", err)
+						}
+						jsondata, err := m.MarshalJSON()
+						if err != nil {
+							s.a.Logger.Printf("failed to marshal dynamic proto msg: %v", err)
+							continue
+						}
+						if s.a.Config.Debug {
+							s.a.Logger.Printf("json format=%s", string(jsondata))
+						}
+						update.Val.Value = &gnmi.TypedValue_JsonVal{JsonVal: jsondata}
+					}
+				}
+			}
+			s.a.Export(s.ctx, subResp, meta, outs...)
+		case *gnmi.SubscribeResponse_SyncResponse:
+			s.a.Logger.Printf("received sync response=%+v from %s\n", resp.SyncResponse, meta["source"])
+		}
+	}
+	return nil
+}
+
+// streamMeta returns the outputs metadata of the dial-out stream from peerAddr
+// and the outputs its updates are written to.
+// The outputs, event tags and vars of the target config matching the stream target name,
+// if any, are applied.
+func (s *dialoutTelemetryServer) streamMeta(peerAddr string, md metadata.MD) (outputs.Meta, []string, error) {
+	meta, err := dialoutMeta(peerAddr, md, s.targetTemplate)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.a.Config.Format != "" {
+		meta["format"] = s.a.Config.Format
+	}
+	var outs []string
+	s.a.configLock.RLock()
+	if tc, ok := s.a.Config.Targets[meta["source"]]; ok {
+		outs = tc.Outputs
+		meta.AddTargetConfig(tc)
+	}
+	s.a.configLock.RUnlock()
+	if len(outs) == 0 {
+		outs = s.a.Config.LocalFlags.ListenOutput
+	}
+	return meta, outs, nil
+}
+
+// dialoutMeta builds the outputs metadata of a dial-out stream.
+// The source is set to the peer address unless a target template is provided,
+// in which case it is set to the result of executing the template against
+// the stream http2 headers and the peer address (as "source").
+func dialoutMeta(peerAddr string, md metadata.MD, tpl *template.Template) (outputs.Meta, error) {
+	meta := outputs.Meta{
+		"source": peerAddr,
+	}
+	if sn := md.Get("subscription-name"); len(sn) > 0 {
+		meta["subscription-name"] = sn[0]
+	}
+	if tpl == nil {
+		return meta, nil
+	}
+	data := make(map[string]string, len(md)+1)
+	for k, vs := range md {
+		if len(vs) > 0 {
+			data[k] = vs[0]
+		}
+	}
+	data["source"] = peerAddr
+	b := new(bytes.Buffer)
+	err := tpl.Execute(b, data)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(b.String())
+	if name == "" {
+		return nil, errors.New("target template returned an empty name")
+	}
+	meta["source"] = name
+	return meta, nil
+}
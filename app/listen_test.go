@@ -0,0 +1,116 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+)
+
+func TestDialoutMeta(t *testing.T) {
+	md := metadata.Pairs("subscription-name", "sub1", "system-name", "router1")
+	tests := map[string]struct {
+		template string
+		md       metadata.MD
+		want     outputs.Meta
+		wantErr  bool
+	}{
+		"peer_address": {
+			md:   md,
+			want: outputs.Meta{"source": "10.0.0.1:50000", "subscription-name": "sub1"},
+		},
+		"system_name": {
+			template: `{{ index . "system-name" }}`,
+			md:       md,
+			want:     outputs.Meta{"source": "router1", "subscription-name": "sub1"},
+		},
+		"peer_host": {
+			template: `{{ .source | splitList ":" | first }}`,
+			md:       metadata.MD{},
+			want:     outputs.Meta{"source": "10.0.0.1"},
+		},
+		"empty_name": {
+			template: `{{ index . "missing" }}`,
+			md:       md,
+			wantErr:  true,
+		},
+		"template_error": {
+			template: `{{ .source.field }}`,
+			md:       md,
+			wantErr:  true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &dialoutTelemetryServer{a: New()}
+			if tt.template != "" {
+				tpl, err := utils.CreateTemplate(name, tt.template)
+				if err != nil {
+					t.Fatal(err)
+				}
+				s.targetTemplate = tpl.Funcs(outputs.TemplateFuncs)
+			}
+			meta, _, err := s.streamMeta("10.0.0.1:50000", tt.md)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", meta)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(meta, tt.want) {
+				t.Errorf("got %v, want %v", meta, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialoutStreamTargetConfig(t *testing.T) {
+	a := New()
+	a.Config.Format = "event"
+	a.Config.LocalFlags.ListenOutput = []string{"default"}
+	a.Config.Targets = map[string]*types.TargetConfig{
+		"router1": {
+			Name:      "router1",
+			Outputs:   []string{"out1"},
+			EventTags: map[string]string{"site": "dc1"},
+		},
+	}
+	tpl, err := utils.CreateTemplate("target", `{{ index . "system-name" }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &dialoutTelemetryServer{a: a, targetTemplate: tpl}
+
+	meta, outs, err := s.streamMeta("10.0.0.1:50000", metadata.Pairs("system-name", "router1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := outputs.Meta{"source": "router1", "format": "event", "site": "dc1"}
+	if !reflect.DeepEqual(meta, want) || !reflect.DeepEqual(outs, []string{"out1"}) {
+		t.Errorf("known target: got meta %v, outputs %v", meta, outs)
+	}
+
+	meta, outs, err = s.streamMeta("10.0.0.2:50000", metadata.Pairs("system-name", "router2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = outputs.Meta{"source": "router2", "format": "event"}
+	if !reflect.DeepEqual(meta, want) || !reflect.DeepEqual(outs, []string{"default"}) {
+		t.Errorf("unknown target: got meta %v, outputs %v", meta, outs)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/openconfig/gnmic/loaders"
+	"google.golang.org/grpc/connectivity"
 )
 
 func (a *App) startLoader(ctx context.Context) {
@@ -38,6 +39,7 @@ START:
 		loaders.WithRegistry(a.reg),
 		loaders.WithActions(a.Config.Actions),
 		loaders.WithTargetsDefaults(a.Config.SetTargetConfigDefaults),
+		loaders.WithTargetsStatus(a.targetStatus),
 	)
 	if err != nil {
 		a.Logger.Printf("failed to init loader type %q: %v", ldTypeS, err)
@@ -92,3 +94,20 @@ START:
 		goto START
 	}
 }
+
+// targetStatus returns the status of the target named name,
+// it returns nil if the target is not known to this instance.
+func (a *App) targetStatus(name string) *loaders.TargetStatus {
+	a.operLock.RLock()
+	t, ok := a.Targets[name]
+	a.operLock.RUnlock()
+	if !ok {
+		return nil
+	}
+	state := t.ConnState()
+	return &loaders.TargetStatus{
+		Connected: state == connectivity.Ready.String(),
+		State:     state,
+		LastError: t.LastError(),
+	}
+}
@@ -28,6 +28,16 @@ func (a *App) logError(err error) {
 	a.errCh <- err
 }
 
+// logWarning logs the message and prints it to stderr if logging is disabled,
+// unlike logError it does not fail the command.
+func (a *App) logWarning(format string, args ...interface{}) {
+	msg := fmt.Sprintf("warning: "+format, args...)
+	a.Logger.Print(msg)
+	if !a.Config.Log {
+		fmt.Fprintln(os.Stderr, msg)
+	}
+}
+
 func (a *App) checkErrors() error {
 	if a.errCh == nil {
 		return nil
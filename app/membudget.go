@@ -0,0 +1,165 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/membudget"
+	"github.com/openconfig/gnmic/outputs"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	spillReplayInterval        = time.Second
+	memoryBudgetReportInterval = 10 * time.Second
+)
+
+// spilledResponse is a subscribe response written to the memory budget spill storage.
+type spilledResponse struct {
+	Response []byte       `json:"response,omitempty"`
+	Meta     outputs.Meta `json:"meta,omitempty"`
+	Outputs  []string     `json:"outputs,omitempty"`
+}
+
+func (a *App) initMemoryBudget() error {
+	if a.Config.MemoryBudget == nil {
+		return nil
+	}
+	b, err := membudget.New(a.Config.MemoryBudget)
+	if err != nil {
+		return err
+	}
+	a.budget = b
+	membudget.SetDefault(b)
+	a.Logger.Printf("memory budget limit=%s, policy=%s", a.Config.MemoryBudget.Limit, b.Policy())
+	go a.reportMemoryBudget(a.ctx)
+	if b.Policy() == membudget.PolicySpill {
+		go a.replaySpilled(a.ctx)
+	}
+	return nil
+}
+
+// admitResponse applies the memory budget policy to a received response.
+// It returns false if the response must not be exported.
+func (a *App) admitResponse(ctx context.Context, rsp *gnmi.SubscribeResponse, m outputs.Meta, outs []string) bool {
+	b := a.budget
+	if b == nil {
+		return true
+	}
+	// keep the order of the responses while the spilled ones are replayed
+	spilling := b.Spool() != nil && b.Spool().Size() > 0
+	if !b.Exceeded() && !spilling {
+		return true
+	}
+	switch b.Policy() {
+	case membudget.PolicyPause:
+		// stop reading from the target until the buffered messages are written,
+		// the gRPC flow control pauses the subscription stream.
+		return b.Wait(ctx) == nil
+	case membudget.PolicySpill:
+		err := a.spillResponse(rsp, m, outs)
+		if err != nil {
+			a.slog.Debug("failed to spill response", "source", m["source"], "error", err)
+		}
+		return false
+	}
+	b.Drop()
+	return false
+}
+
+// exportResponse exports rsp, accounting its size in the memory budget
+// until it is written to the outputs.
+func (a *App) exportResponse(ctx context.Context, rsp *gnmi.SubscribeResponse, m outputs.Meta, outs ...string) {
+	if a.budget == nil {
+		a.Export(ctx, rsp, m, outs...)
+		return
+	}
+	size := int64(proto.Size(rsp))
+	a.budget.Acquire(membudget.Pipeline, size)
+	defer a.budget.Release(membudget.Pipeline, size)
+	a.Export(ctx, rsp, m, outs...)
+}
+
+func (a *App) spillResponse(rsp *gnmi.SubscribeResponse, m outputs.Meta, outs []string) error {
+	b, err := proto.Marshal(rsp)
+	if err != nil {
+		return err
+	}
+	rec, err := json.Marshal(&spilledResponse{Response: b, Meta: m, Outputs: outs})
+	if err != nil {
+		return err
+	}
+	return a.budget.Spill(rec)
+}
+
+// replaySpilled exports the spilled responses, oldest first,
+// while the memory usage is under the budget resume threshold.
+func (a *App) replaySpilled(ctx context.Context) {
+	sp := a.budget.Spool()
+	defer a.budget.Close()
+	ticker := time.NewTicker(spillReplayInterval)
+	defer ticker.Stop()
+	for {
+		err := a.budget.Wait(ctx)
+		if err != nil {
+			return
+		}
+		rec, err := sp.Read()
+		if err != nil {
+			if err != io.EOF {
+				a.Logger.Printf("failed to read spilled response: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			continue
+		}
+		sr := new(spilledResponse)
+		err = json.Unmarshal(rec, sr)
+		if err != nil {
+			a.Logger.Printf("failed to decode spilled response: %v", err)
+			continue
+		}
+		rsp := new(gnmi.SubscribeResponse)
+		err = proto.Unmarshal(sr.Response, rsp)
+		if err != nil {
+			a.Logger.Printf("failed to decode spilled response: %v", err)
+			continue
+		}
+		a.exportResponse(ctx, rsp, sr.Meta, sr.Outputs...)
+	}
+}
+
+// reportMemoryBudget periodically logs the number of messages
+// dropped because of the memory budget.
+func (a *App) reportMemoryBudget(ctx context.Context) {
+	ticker := time.NewTicker(memoryBudgetReportInterval)
+	defer ticker.Stop()
+	var lastDropped uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			st := a.budget.Stats()
+			if st.Dropped > lastDropped {
+				a.Logger.Printf("memory budget exceeded: dropped %d messages in the last %s, used=%d/%d bytes",
+					st.Dropped-lastDropped, memoryBudgetReportInterval, st.Used, st.Limit)
+			}
+			lastDropped = st.Dropped
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/membudget"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+)
+
+// recordOutput is an output keeping the written messages sources.
+type recordOutput struct {
+	m       sync.Mutex
+	sources []string
+	metas   []outputs.Meta
+}
+
+func (o *recordOutput) Init(context.Context, string, map[string]interface{}, ...outputs.Option) error {
+	return nil
+}
+
+func (o *recordOutput) Write(_ context.Context, _ proto.Message, m outputs.Meta) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.sources = append(o.sources, m["source"])
+	o.metas = append(o.metas, m)
+}
+
+func (o *recordOutput) written() []string {
+	o.m.Lock()
+	defer o.m.Unlock()
+	return append([]string(nil), o.sources...)
+}
+
+func (o *recordOutput) writtenMeta() []outputs.Meta {
+	o.m.Lock()
+	defer o.m.Unlock()
+	return append([]outputs.Meta(nil), o.metas...)
+}
+
+func (o *recordOutput) WriteEvent(context.Context, *formatters.EventMsg) {}
+func (o *recordOutput) Close() error                                     { return nil }
+func (o *recordOutput) RegisterMetrics(*prometheus.Registry)             {}
+func (o *recordOutput) String() string                                   { return "record" }
+func (o *recordOutput) SetLogger(*log.Logger)                            {}
+func (o *recordOutput) SetEventProcessors(map[string]map[string]interface{}, *log.Logger, map[string]*types.TargetConfig, map[string]map[string]interface{}) {
+}
+func (o *recordOutput) SetName(string)                                  {}
+func (o *recordOutput) SetClusterName(string)                           {}
+func (o *recordOutput) SetTargetsConfig(map[string]*types.TargetConfig) {}
+
+func testUpdateResponse() *gnmi.SubscribeResponse {
+	return &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Timestamp: 42,
+				Update: []*gnmi.Update{{
+					Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interface"}}},
+					Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "up"}},
+				}},
+			},
+		},
+	}
+}
+
+func newBudgetTestApp(t *testing.T, cfg *membudget.Config) *App {
+	a := New()
+	t.Cleanup(func() {
+		a.Cfn()
+		membudget.SetDefault(nil)
+	})
+	a.Config.MemoryBudget = cfg
+	err := a.initMemoryBudget()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestMemoryBudgetDrop(t *testing.T) {
+	a := newBudgetTestApp(t, &membudget.Config{Limit: "100"})
+	rsp := testUpdateResponse()
+	if !a.admitResponse(a.ctx, rsp, outputs.Meta{"source": "t1"}, nil) {
+		t.Fatal("response rejected under the budget")
+	}
+	a.budget.Acquire(membudget.Outputs, 100)
+	if a.admitResponse(a.ctx, rsp, outputs.Meta{"source": "t1"}, nil) {
+		t.Fatal("response admitted above the budget")
+	}
+	if st := a.budget.Stats(); st.Dropped != 1 {
+		t.Errorf("expected 1 dropped message, got %d", st.Dropped)
+	}
+}
+
+func TestMemoryBudgetPause(t *testing.T) {
+	a := newBudgetTestApp(t, &membudget.Config{Limit: "100", Policy: membudget.PolicyPause})
+	a.budget.Acquire(membudget.Outputs, 100)
+	admitted := make(chan bool)
+	go func() {
+		admitted <- a.admitResponse(a.ctx, testUpdateResponse(), outputs.Meta{"source": "t1"}, nil)
+	}()
+	select {
+	case <-admitted:
+		t.Fatal("response admitted while the budget is exceeded")
+	case <-time.After(50 * time.Millisecond):
+	}
+	a.budget.Release(membudget.Outputs, 100)
+	select {
+	case ok := <-admitted:
+		if !ok {
+			t.Fatal("response rejected after the budget was released")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription not resumed after the budget was released")
+	}
+}
+
+func TestMemoryBudgetSpill(t *testing.T) {
+	a := newBudgetTestApp(t, &membudget.Config{
+		Limit:          "100",
+		Policy:         membudget.PolicySpill,
+		SpillDirectory: t.TempDir(),
+	})
+	o := new(recordOutput)
+	a.Outputs["record"] = o
+
+	a.budget.Acquire(membudget.Outputs, 100)
+	for _, src := range []string{"t1", "t2", "t3"} {
+		if a.admitResponse(a.ctx, testUpdateResponse(), outputs.Meta{"source": src}, nil) {
+			t.Fatalf("response from %s admitted above the budget", src)
+		}
+	}
+	if len(o.written()) != 0 {
+		t.Fatalf("spilled responses written before the budget was released: %v", o.written())
+	}
+	a.budget.Release(membudget.Outputs, 100)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(o.written()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	got := o.written()
+	if len(got) != 3 || got[0] != "t1" || got[1] != "t2" || got[2] != "t3" {
+		t.Fatalf("unexpected replayed responses: %v", got)
+	}
+	if st := a.budget.Stats(); st.Spilled != 3 || st.Dropped != 0 || st.Used != 0 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
@@ -13,11 +13,14 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/openconfig/gnmic/target"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 const (
 	clusterMetricsUpdatePeriod = 10 * time.Second
+	targetMetricsUpdatePeriod  = 10 * time.Second
+	runtimeMetricsUpdatePeriod = 10 * time.Second
 )
 
 // subscribe
@@ -28,6 +31,38 @@ var subscribeResponseReceivedCounter = prometheus.NewCounterVec(prometheus.Count
 	Help:      "Total number of received subscribe response messages",
 }, []string{"source", "subscription"})
 
+// target
+var targetConnectionState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "target",
+	Name:      "connection_state",
+	Help:      "Has value 1 for the current target connection state, 0 for the other states",
+}, []string{"name", "state"})
+var targetConsecutiveFailures = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "target",
+	Name:      "consecutive_connection_failures",
+	Help:      "number of connection failures since the target connection was last up",
+}, []string{"name"})
+var targetFlaps = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "target",
+	Name:      "connection_flaps",
+	Help:      "number of times the target connection went down within the flap window",
+}, []string{"name"})
+var targetReachable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "target",
+	Name:      "reachable",
+	Help:      "Has value 1 if the last reachability probe of the target succeeded, 0 otherwise",
+}, []string{"name"})
+var targetProbeLatency = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "target",
+	Name:      "reachability_probe_latency_seconds",
+	Help:      "connect or health check latency measured by the last successful reachability probe of the target",
+}, []string{"name"})
+
 // cluster
 var clusterNumberOfLockedTargets = prometheus.NewGauge(prometheus.GaugeOpts{
 	Namespace: "gnmic",
@@ -41,6 +76,100 @@ var clusterIsLeader = prometheus.NewGauge(prometheus.GaugeOpts{
 	Name:      "is_leader",
 	Help:      "Has value 1 if this gnmic instance is the cluster leader, 0 otherwise",
 })
+var clusterUnassignedTargets = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "cluster",
+	Name:      "number_of_unassigned_targets",
+	Help:      "number of targets the leader could not assign to an instance in its last dispatch round",
+})
+var clusterMsgRate = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "cluster",
+	Name:      "msg_rate",
+	Help:      "rate of subscribe response messages received by this gnmic instance, in messages per second",
+})
+var clusterRejectedTargets = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "gnmic",
+	Subsystem: "cluster",
+	Name:      "number_of_rejected_targets_total",
+	Help:      "Total number of target assignments rejected by this gnmic instance because it is at capacity",
+})
+
+// runtime
+var runtimeGoroutines = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "runtime",
+	Name:      "goroutines",
+	Help:      "number of goroutines",
+})
+var runtimeGCPause = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "runtime",
+	Name:      "last_gc_pause_seconds",
+	Help:      "duration of the last garbage collection pause",
+})
+var runtimeHeapAlloc = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "runtime",
+	Name:      "heap_alloc_bytes",
+	Help:      "bytes of allocated heap objects",
+})
+var outputBufferLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "output",
+	Name:      "buffer_length",
+	Help:      "number of messages queued in the output buffer",
+}, []string{"name"})
+var outputBufferCapacity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "output",
+	Name:      "buffer_capacity",
+	Help:      "capacity of the output buffer",
+}, []string{"name"})
+var outputQueueLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "output",
+	Name:      "write_queue_length",
+	Help:      "number of messages waiting for an output write worker",
+}, []string{"name"})
+var outputQueueCapacity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "output",
+	Name:      "write_queue_capacity",
+	Help:      "capacity of the output write queue",
+}, []string{"name"})
+var outputWriteWorkers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "output",
+	Name:      "write_workers",
+	Help:      "number of output write workers",
+}, []string{"name"})
+
+// memory budget
+var memoryBudgetUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "memory_budget",
+	Name:      "used_bytes",
+	Help:      "bytes accounted in the memory budget per component",
+}, []string{"component"})
+var memoryBudgetLimit = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "memory_budget",
+	Name:      "limit_bytes",
+	Help:      "memory budget limit",
+})
+var memoryBudgetDropped = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "memory_budget",
+	Name:      "dropped_messages",
+	Help:      "number of messages dropped because the memory budget was exceeded",
+})
+var memoryBudgetSpilledBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "memory_budget",
+	Name:      "spilled_bytes",
+	Help:      "bytes of messages waiting in the spill storage",
+})
 
 func (a *App) startClusterMetrics() {
 	if a.Config.APIServer == nil || !a.Config.APIServer.EnableMetrics || a.Config.Clustering == nil {
@@ -55,6 +184,12 @@ func (a *App) startClusterMetrics() {
 	if err != nil {
 		a.Logger.Printf("failed to register metric: %v", err)
 	}
+	for _, c := range []prometheus.Collector{clusterUnassignedTargets, clusterMsgRate, clusterRejectedTargets} {
+		err = a.reg.Register(c)
+		if err != nil {
+			a.Logger.Printf("failed to register metric: %v", err)
+		}
+	}
 	ticker := time.NewTicker(clusterMetricsUpdatePeriod)
 	defer ticker.Stop()
 	for {
@@ -92,3 +227,102 @@ func (a *App) startClusterMetrics() {
 		}
 	}
 }
+
+func (a *App) startTargetsMetrics() {
+	var err error
+	for _, c := range []prometheus.Collector{targetConnectionState, targetConsecutiveFailures, targetFlaps,
+		targetReachable, targetProbeLatency} {
+		err = a.reg.Register(c)
+		if err != nil {
+			a.Logger.Printf("failed to register metric: %v", err)
+		}
+	}
+	ticker := time.NewTicker(targetMetricsUpdatePeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			// reset the metrics to remove deleted targets
+			targetConnectionState.Reset()
+			targetConsecutiveFailures.Reset()
+			targetFlaps.Reset()
+			targetReachable.Reset()
+			targetProbeLatency.Reset()
+			a.operLock.RLock()
+			for name, t := range a.Targets {
+				h := t.Health()
+				for _, s := range target.ConnectionStates {
+					if s == h.State {
+						targetConnectionState.WithLabelValues(name, s).Set(1)
+					} else {
+						targetConnectionState.WithLabelValues(name, s).Set(0)
+					}
+				}
+				targetConsecutiveFailures.WithLabelValues(name).Set(float64(h.ConsecutiveFailures))
+				targetFlaps.WithLabelValues(name).Set(float64(h.Flaps))
+				if r := h.Reachability; r != nil {
+					if r.Reachable {
+						targetReachable.WithLabelValues(name).Set(1)
+						targetProbeLatency.WithLabelValues(name).Set(r.Latency.Seconds())
+					} else {
+						targetReachable.WithLabelValues(name).Set(0)
+					}
+				}
+			}
+			a.operLock.RUnlock()
+		}
+	}
+}
+
+func (a *App) startRuntimeMetrics() {
+	var err error
+	collectors := []prometheus.Collector{runtimeGoroutines, runtimeGCPause, runtimeHeapAlloc, outputBufferLength, outputBufferCapacity,
+		outputQueueLength, outputQueueCapacity, outputWriteWorkers}
+	if a.budget != nil {
+		collectors = append(collectors, memoryBudgetUsed, memoryBudgetLimit, memoryBudgetDropped, memoryBudgetSpilledBytes)
+	}
+	for _, c := range collectors {
+		err = a.reg.Register(c)
+		if err != nil {
+			a.Logger.Printf("failed to register metric: %v", err)
+		}
+	}
+	ticker := time.NewTicker(runtimeMetricsUpdatePeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			rs := a.runtimeStats()
+			runtimeGoroutines.Set(float64(rs.Goroutines))
+			runtimeGCPause.Set(rs.GCPause.Seconds())
+			runtimeHeapAlloc.Set(float64(rs.HeapAlloc))
+			// reset the metrics to remove deleted outputs
+			outputBufferLength.Reset()
+			outputBufferCapacity.Reset()
+			for name, b := range rs.OutputBuffers {
+				outputBufferLength.WithLabelValues(name).Set(float64(b.Length))
+				outputBufferCapacity.WithLabelValues(name).Set(float64(b.Capacity))
+			}
+			outputQueueLength.Reset()
+			outputQueueCapacity.Reset()
+			outputWriteWorkers.Reset()
+			for name, q := range rs.OutputQueues {
+				outputQueueLength.WithLabelValues(name).Set(float64(q.Length))
+				outputQueueCapacity.WithLabelValues(name).Set(float64(q.Capacity))
+				outputWriteWorkers.WithLabelValues(name).Set(float64(q.Workers))
+			}
+			if rs.MemoryBudget != nil {
+				for c, used := range rs.MemoryBudget.Usage {
+					memoryBudgetUsed.WithLabelValues(c).Set(float64(used))
+				}
+				memoryBudgetLimit.Set(float64(rs.MemoryBudget.Limit))
+				memoryBudgetDropped.Set(float64(rs.MemoryBudget.Dropped))
+				memoryBudgetSpilledBytes.Set(float64(rs.MemoryBudget.SpilledBytes))
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/target"
+	"github.com/openconfig/gnmic/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// multiplexSubscribeRequests merges the compatible subscribe requests of target t
+// into a single request when the target is configured with multiplex-subscriptions,
+// or when the number of requests exceeds its max-streams.
+// POLL and get-poll subscriptions are never merged.
+// An error is returned if the number of streams still exceeds max-streams.
+func (a *App) multiplexSubscribeRequests(t *target.Target, subs map[string]*types.SubscriptionConfig, subRequests []subscriptionRequest) ([]subscriptionRequest, error) {
+	multiplex := t.Config.MultiplexSubscriptions != nil && *t.Config.MultiplexSubscriptions
+	maxStreams := t.Config.MaxStreams
+	if !multiplex && (maxStreams <= 0 || len(subRequests) <= maxStreams) {
+		return subRequests, nil
+	}
+	sort.Slice(subRequests, func(i, j int) bool {
+		return subRequests[i].name < subRequests[j].name
+	})
+	groups := make([][]subscriptionRequest, 0, len(subRequests))
+OUTER:
+	for _, sreq := range subRequests {
+		if sreq.getReq == nil && sreq.req.GetSubscribe().GetMode() != gnmi.SubscriptionList_POLL {
+			for i, g := range groups {
+				if compatibleSubscribeRequests(g[0].req, sreq.req) {
+					groups[i] = append(g, sreq)
+					continue OUTER
+				}
+			}
+		}
+		groups = append(groups, []subscriptionRequest{sreq})
+	}
+	result := make([]subscriptionRequest, 0, len(groups))
+	for _, g := range groups {
+		if len(g) == 1 {
+			result = append(result, g[0])
+			continue
+		}
+		names := make([]string, 0, len(g))
+		configs := make([]*types.SubscriptionConfig, 0, len(g))
+		req := proto.Clone(g[0].req).(*gnmi.SubscribeRequest)
+		for i, sreq := range g {
+			names = append(names, sreq.name)
+			configs = append(configs, subs[sreq.name])
+			if i == 0 {
+				continue
+			}
+			req.GetSubscribe().Subscription = append(req.GetSubscribe().Subscription, sreq.req.GetSubscribe().GetSubscription()...)
+		}
+		name := strings.Join(names, "+")
+		err := t.SetMultiplexedSubscriptions(name, configs)
+		if err != nil {
+			return nil, err
+		}
+		a.Logger.Printf("target %q: multiplexing subscriptions %v over a single stream", t.Config.Name, names)
+		result = append(result, subscriptionRequest{name: name, req: req})
+	}
+	if maxStreams > 0 && len(result) > maxStreams {
+		names := make([]string, 0, len(result))
+		for _, sreq := range result {
+			names = append(names, sreq.name)
+		}
+		err := fmt.Errorf("%d subscribe streams %v exceed max-streams %d", len(result), names, maxStreams)
+		t.SetLastError(err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// compatibleSubscribeRequests returns true if the subscribe requests r1 and r2
+// only differ by their subscriptions list.
+func compatibleSubscribeRequests(r1, r2 *gnmi.SubscribeRequest) bool {
+	l1 := proto.Clone(r1.GetSubscribe()).(*gnmi.SubscriptionList)
+	l2 := proto.Clone(r2.GetSubscribe()).(*gnmi.SubscriptionList)
+	l1.Subscription = nil
+	l2.Subscription = nil
+	return proto.Equal(l1, l2) && proto.Equal(
+		&gnmi.SubscribeRequest{Extension: r1.GetExtension()},
+		&gnmi.SubscribeRequest{Extension: r2.GetExtension()},
+	)
+}
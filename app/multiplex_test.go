@@ -0,0 +1,108 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/proto/gnmi_ext"
+	"github.com/openconfig/gnmic/target"
+	"github.com/openconfig/gnmic/types"
+)
+
+func testSubscribeRequest(mode gnmi.SubscriptionList_Mode, paths ...string) *gnmi.SubscribeRequest {
+	sl := &gnmi.SubscriptionList{
+		Prefix:   &gnmi.Path{Target: "t1"},
+		Mode:     mode,
+		Encoding: gnmi.Encoding_JSON,
+	}
+	for _, p := range paths {
+		sl.Subscription = append(sl.Subscription, &gnmi.Subscription{
+			Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: p}}},
+			Mode: gnmi.SubscriptionMode_SAMPLE,
+		})
+	}
+	return &gnmi.SubscribeRequest{Request: &gnmi.SubscribeRequest_Subscribe{Subscribe: sl}}
+}
+
+func TestCompatibleSubscribeRequests(t *testing.T) {
+	base := testSubscribeRequest(gnmi.SubscriptionList_STREAM, "interfaces")
+	for _, tc := range []struct {
+		name   string
+		modify func(r *gnmi.SubscribeRequest)
+		want   bool
+	}{
+		{name: "different subscriptions", modify: func(r *gnmi.SubscribeRequest) {
+			r.GetSubscribe().Subscription[0].Path.Elem[0].Name = "system"
+			r.GetSubscribe().Subscription[0].Mode = gnmi.SubscriptionMode_ON_CHANGE
+		}, want: true},
+		{name: "different mode", modify: func(r *gnmi.SubscribeRequest) {
+			r.GetSubscribe().Mode = gnmi.SubscriptionList_ONCE
+		}},
+		{name: "different encoding", modify: func(r *gnmi.SubscribeRequest) {
+			r.GetSubscribe().Encoding = gnmi.Encoding_PROTO
+		}},
+		{name: "different prefix", modify: func(r *gnmi.SubscribeRequest) {
+			r.GetSubscribe().Prefix.Target = "t2"
+		}},
+		{name: "updates only", modify: func(r *gnmi.SubscribeRequest) {
+			r.GetSubscribe().UpdatesOnly = true
+		}},
+		{name: "different extensions", modify: func(r *gnmi.SubscribeRequest) {
+			r.Extension = []*gnmi_ext.Extension{{Ext: &gnmi_ext.Extension_History{History: &gnmi_ext.History{}}}}
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := testSubscribeRequest(gnmi.SubscriptionList_STREAM, "interfaces")
+			tc.modify(r)
+			if got := compatibleSubscribeRequests(base, r); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMultiplexSubscribeRequests(t *testing.T) {
+	subs := map[string]*types.SubscriptionConfig{
+		"s1":   {Name: "s1", Paths: []string{"/s1"}},
+		"s2":   {Name: "s2", Paths: []string{"/s2"}},
+		"s3":   {Name: "s3", Paths: []string{"/s3"}},
+		"poll": {Name: "poll", Paths: []string{"/poll"}},
+	}
+	requests := func() []subscriptionRequest {
+		return []subscriptionRequest{
+			{name: "s2", req: testSubscribeRequest(gnmi.SubscriptionList_STREAM, "s2")},
+			{name: "s1", req: testSubscribeRequest(gnmi.SubscriptionList_STREAM, "s1")},
+			{name: "s3", req: testSubscribeRequest(gnmi.SubscriptionList_ONCE, "s3")},
+			{name: "poll", req: testSubscribeRequest(gnmi.SubscriptionList_POLL, "poll")},
+		}
+	}
+	a := New()
+	tg := target.NewTarget(&types.TargetConfig{Name: "t1", MaxStreams: 3})
+	got, err := a.multiplexSubscribeRequests(tg, subs, requests())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 subscribe streams, got %d", len(got))
+	}
+	if got[0].name != "poll" || got[1].name != "s1+s2" || got[2].name != "s3" {
+		t.Errorf("unexpected subscribe streams %q, %q, %q", got[0].name, got[1].name, got[2].name)
+	}
+	if n := len(got[1].req.GetSubscribe().GetSubscription()); n != 2 {
+		t.Errorf("expected 2 multiplexed subscriptions, got %d", n)
+	}
+	// the merged streams still exceed max-streams
+	tg = target.NewTarget(&types.TargetConfig{Name: "t1", MaxStreams: 2})
+	_, err = a.multiplexSubscribeRequests(tg, subs, requests())
+	if err == nil {
+		t.Error("expected an error when the subscribe streams exceed max-streams")
+	}
+}
@@ -0,0 +1,56 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/netconf"
+	"github.com/openconfig/gnmic/outputs"
+)
+
+// initNetconfPollers creates the configured NETCONF pollers,
+// loading the YANG modules describing their data.
+func (a *App) initNetconfPollers() error {
+	a.netconfPollers = make(map[string]*netconf.Poller, len(a.Config.NetconfPollers))
+	for name, pc := range a.Config.NetconfPollers {
+		schema, err := a.loadYangSchema(pc.YangFiles, pc.YangDirs, nil)
+		if err != nil {
+			return fmt.Errorf("netconf poller %q: failed to load YANG files: %v", name, err)
+		}
+		a.netconfPollers[name], err = netconf.NewPoller(pc, schema, a.Logger)
+		if err != nil {
+			return fmt.Errorf("netconf poller %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// startNetconfPollers starts the NETCONF pollers, their notifications
+// are exported like the ones received from the targets.
+// In a cluster, the leader-only pollers run on the instance holding their task lock.
+func (a *App) startNetconfPollers(ctx context.Context) {
+	for name, p := range a.netconfPollers {
+		pc := a.Config.NetconfPollers[name]
+		p := p
+		export := func(ctx context.Context, device string, rsp *gnmi.SubscribeResponse) {
+			a.Export(ctx, rsp, outputs.Meta{"source": device, "subscription-name": pc.Name}, pc.Outputs...)
+		}
+		a.Logger.Printf("starting netconf poller %q", name)
+		if pc.LeaderOnly && a.locker != nil {
+			go a.runSingleton(ctx, "netconf-poller-"+name, func(ctx context.Context) {
+				p.Run(ctx, export)
+			})
+			continue
+		}
+		go p.Run(ctx, export)
+	}
+}
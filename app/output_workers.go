@@ -0,0 +1,271 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"hash/fnv"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+	"google.golang.org/protobuf/proto"
+)
+
+const defaultOutputWriteQueueSize = 1000
+
+// outputWriteConfig holds the output config keys
+// controlling how messages are handed to the output.
+type outputWriteConfig struct {
+	// number of workers writing to the output
+	Workers int `mapstructure:"write-workers,omitempty"`
+	// number of messages queued for each worker before exporting blocks
+	QueueSize int `mapstructure:"write-queue-size,omitempty"`
+	// namespaces of the targets the output accepts messages from,
+	// all messages are accepted if empty.
+	Namespaces []string `mapstructure:"namespaces,omitempty"`
+	// in a cluster, only the leader writes to the output
+	LeaderOnly bool `mapstructure:"leader-only,omitempty"`
+	// event processors of the output
+	EventProcessors []string `mapstructure:"event-processors,omitempty"`
+}
+
+// syncWriteKey is the context key marking the messages
+// written to the output by the caller, bypassing the workers queues.
+type syncWriteKey struct{}
+
+// withSyncWrite returns a context under which the output workers
+// write the messages before returning, in the order they are exported.
+func withSyncWrite(ctx context.Context) context.Context {
+	return context.WithValue(ctx, syncWriteKey{}, true)
+}
+
+func isSyncWrite(ctx context.Context) bool {
+	v, _ := ctx.Value(syncWriteKey{}).(bool)
+	return v
+}
+
+type outputJob struct {
+	ctx context.Context
+	pm  *outputs.ProtoMsg
+}
+
+// outputWorkers wraps an output, messages written to it are queued
+// and handed to the output by a fixed number of workers.
+// Each worker has its own queue, the messages of a source always go
+// to the same worker so they are written in the order they were received.
+// A full queue blocks the writer until its worker frees a slot.
+type outputWorkers struct {
+	outputs.Output
+	name       string
+	workers    int
+	queues     []chan outputJob
+	namespaces map[string]struct{}
+	leaderOnly bool
+	// names of the output event processors
+	eventProcessors []string
+	// reports whether the instance is the cluster leader,
+	// messages are dropped when it returns false and the output is leader-only.
+	isLeader func() bool
+
+	wg        sync.WaitGroup
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newOutputWorkers(name string, o outputs.Output, cfg map[string]interface{}) (*outputWorkers, error) {
+	wc := new(outputWriteConfig)
+	err := outputs.DecodeConfig(cfg, wc)
+	if err != nil {
+		return nil, err
+	}
+	if wc.Workers <= 0 {
+		wc.Workers = runtime.NumCPU()
+	}
+	if wc.QueueSize <= 0 {
+		wc.QueueSize = defaultOutputWriteQueueSize
+	}
+	w := &outputWorkers{
+		Output:     o,
+		name:       name,
+		workers:    wc.Workers,
+		queues:     make([]chan outputJob, wc.Workers),
+		leaderOnly: wc.LeaderOnly,
+		done:       make(chan struct{}),
+
+		eventProcessors: wc.EventProcessors,
+	}
+	if len(wc.Namespaces) > 0 {
+		w.namespaces = make(map[string]struct{}, len(wc.Namespaces))
+		for _, ns := range wc.Namespaces {
+			w.namespaces[ns] = struct{}{}
+		}
+	}
+	w.wg.Add(w.workers)
+	for i := range w.queues {
+		w.queues[i] = make(chan outputJob, wc.QueueSize)
+		go w.worker(w.queues[i])
+	}
+	return w, nil
+}
+
+// Write queues the message for the worker of its source.
+// If ctx was returned by withSyncWrite, the message is written before Write returns.
+// Messages from targets outside the output namespaces are dropped,
+// as well as all messages if the output is leader-only and the instance is not the leader.
+func (w *outputWorkers) Write(ctx context.Context, msg proto.Message, meta outputs.Meta) {
+	if !w.acceptsNamespace(meta["namespace"]) || !w.writesAsLeader() {
+		return
+	}
+	if isSyncWrite(ctx) {
+		select {
+		case <-w.done:
+		default:
+			writeOutput(ctx, w.name, w.Output, msg, meta)
+		}
+		return
+	}
+	pm := outputs.NewProtoMsg(msg, meta).Account()
+	select {
+	case <-w.done:
+		pm.Release()
+		return
+	default:
+	}
+	select {
+	case w.queueOf(meta["source"]) <- outputJob{ctx: ctx, pm: pm}:
+	case <-ctx.Done():
+		pm.Release()
+	case <-w.done:
+		pm.Release()
+	}
+}
+
+// WriteEvent hands the event to the output
+// if it was received from a target of the output namespaces.
+func (w *outputWorkers) WriteEvent(ctx context.Context, ev *formatters.EventMsg) {
+	if !w.acceptsNamespace(ev.Tags["namespace"]) || !w.writesAsLeader() {
+		return
+	}
+	w.Output.WriteEvent(ctx, ev)
+}
+
+func (w *outputWorkers) acceptsNamespace(ns string) bool {
+	if w.namespaces == nil {
+		return true
+	}
+	_, ok := w.namespaces[ns]
+	return ok
+}
+
+func (w *outputWorkers) writesAsLeader() bool {
+	if !w.leaderOnly || w.isLeader == nil {
+		return true
+	}
+	return w.isLeader()
+}
+
+// queueOf returns the queue of the worker handling the messages from source.
+func (w *outputWorkers) queueOf(source string) chan outputJob {
+	if len(w.queues) == 1 {
+		return w.queues[0]
+	}
+	h := fnv.New32a()
+	h.Write([]byte(source))
+	return w.queues[h.Sum32()%uint32(len(w.queues))]
+}
+
+func (w *outputWorkers) worker(queue chan outputJob) {
+	defer w.wg.Done()
+	for {
+		select {
+		case j := <-queue:
+			w.write(j)
+		case <-w.done:
+			// write the queued messages before the output is closed
+			for {
+				select {
+				case j := <-queue:
+					w.write(j)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *outputWorkers) write(j outputJob) {
+	j.pm.Release()
+	writeOutput(j.ctx, w.name, w.Output, j.pm.GetMsg(), j.pm.GetMeta())
+}
+
+// flushEvery writes an empty notification to the output every interval,
+// for its processors to release the events they hold when no messages are received,
+// see formatters.FlushInterval.
+// The notification is not written if the queue is full.
+func (w *outputWorkers) flushEvery(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ctx := context.Background()
+	meta := outputs.Meta{formatters.MetaFlush: "true"}
+	queue := w.queueOf("")
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+				select {
+				case <-w.done:
+					return
+				default:
+				}
+				rsp := &gnmi.SubscribeResponse{
+					Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{}},
+				}
+				pm := outputs.NewProtoMsg(rsp, meta).Account()
+				select {
+				case queue <- outputJob{ctx: ctx, pm: pm}:
+				default:
+					pm.Release()
+				}
+			}
+		}
+	}()
+}
+
+// QueueSize returns the number of queued messages and the capacity
+// of the queues, summed over all the workers.
+func (w *outputWorkers) QueueSize() (int, int) {
+	var l, c int
+	for _, q := range w.queues {
+		l += len(q)
+		c += cap(q)
+	}
+	return l, c
+}
+
+// Close stops the workers once the queued messages are written,
+// then closes the output.
+func (w *outputWorkers) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		w.wg.Wait()
+	})
+	return w.Output.Close()
+}
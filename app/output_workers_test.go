@@ -0,0 +1,247 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+	"google.golang.org/protobuf/proto"
+)
+
+// blockingOutput is a recordOutput whose writes wait for the release channel.
+type blockingOutput struct {
+	recordOutput
+	release chan struct{}
+}
+
+func (o *blockingOutput) Write(ctx context.Context, msg proto.Message, m outputs.Meta) {
+	<-o.release
+	o.recordOutput.Write(ctx, msg, m)
+}
+
+func TestNewOutputWorkersConfig(t *testing.T) {
+	w, err := newOutputWorkers("o1", new(recordOutput), map[string]interface{}{"type": "file"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if w.workers != runtime.NumCPU() {
+		t.Errorf("expected %d workers, got %d", runtime.NumCPU(), w.workers)
+	}
+	if _, c := w.QueueSize(); c != runtime.NumCPU()*defaultOutputWriteQueueSize {
+		t.Errorf("expected a queue size of %d per worker, got %d in total", defaultOutputWriteQueueSize, c)
+	}
+	// values decoded from JSON are float64
+	w2, err := newOutputWorkers("o2", new(recordOutput), map[string]interface{}{
+		"type":             "file",
+		"write-workers":    float64(2),
+		"write-queue-size": 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w2.Close()
+	if w2.workers != 2 || len(w2.queues) != 2 || cap(w2.queues[1]) != 3 {
+		t.Errorf("expected 2 workers with a queue size of 3, got %d and %d queues", w2.workers, len(w2.queues))
+	}
+}
+
+func TestOutputWorkersSourceOrder(t *testing.T) {
+	o := new(recordOutput)
+	w, err := newOutputWorkers("o1", o, map[string]interface{}{"write-workers": 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	sources := []string{"t1", "t2", "t3", "t4", "t5"}
+	for i := 0; i < 50; i++ {
+		for _, src := range sources {
+			w.Write(ctx, testUpdateResponse(), outputs.Meta{"source": src, "seq": strconv.Itoa(i)})
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := make(map[string]int)
+	for _, m := range o.writtenMeta() {
+		if m["seq"] != strconv.Itoa(next[m["source"]]) {
+			t.Fatalf("source %q: expected message %d, got %s", m["source"], next[m["source"]], m["seq"])
+		}
+		next[m["source"]]++
+	}
+	for _, src := range sources {
+		if next[src] != 50 {
+			t.Errorf("source %q: expected 50 written messages, got %d", src, next[src])
+		}
+	}
+}
+
+func TestOutputWorkersSyncWrite(t *testing.T) {
+	o := &blockingOutput{release: make(chan struct{})}
+	w, err := newOutputWorkers("o1", o, map[string]interface{}{"write-workers": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Write(withSyncWrite(context.Background()), testUpdateResponse(), outputs.Meta{"source": "t1"})
+	}()
+	select {
+	case <-done:
+		t.Fatal("expected the write to wait for the output")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if l, _ := w.QueueSize(); l != 0 {
+		t.Errorf("expected no queued message, got %d", l)
+	}
+	close(o.release)
+	<-done
+	if got := o.written(); len(got) != 1 || got[0] != "t1" {
+		t.Errorf("expected the message from t1 to be written, got %v", got)
+	}
+	w.Close()
+}
+
+func TestOutputWorkersQueue(t *testing.T) {
+	o := &blockingOutput{release: make(chan struct{})}
+	w, err := newOutputWorkers("o1", o, map[string]interface{}{
+		"write-workers":    1,
+		"write-queue-size": 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, src := range []string{"t1", "t2", "t3"} {
+		w.Write(ctx, testUpdateResponse(), outputs.Meta{"source": src})
+	}
+	// the worker holds the first message, the others are queued.
+	l, c := w.QueueSize()
+	if l != 2 || c != 2 {
+		t.Fatalf("expected 2 queued messages out of 2, got %d out of %d", l, c)
+	}
+	// the queue is full, the write blocks until its context is done
+	cctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	w.Write(cctx, testUpdateResponse(), outputs.Meta{"source": "t4"})
+	if cctx.Err() == nil {
+		t.Errorf("expected the write to block until its context is done")
+	}
+	close(o.release)
+	// closing writes the queued messages
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := o.written()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 written messages, got %v", got)
+	}
+	for i, src := range []string{"t1", "t2", "t3"} {
+		if got[i] != src {
+			t.Errorf("expected message %d from %q, got %q", i, src, got[i])
+		}
+	}
+	// writes after close are dropped
+	w.Write(ctx, testUpdateResponse(), outputs.Meta{"source": "t5"})
+	if len(o.written()) != 3 {
+		t.Errorf("expected no write after close, got %v", o.written())
+	}
+}
+
+func TestOutputWorkersNamespaces(t *testing.T) {
+	o := new(recordOutput)
+	w, err := newOutputWorkers("o1", o, map[string]interface{}{
+		"write-workers": 1,
+		"namespaces":    []interface{}{"team-a"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	w.Write(ctx, testUpdateResponse(), outputs.Meta{"source": "t1", "namespace": "team-a"})
+	w.Write(ctx, testUpdateResponse(), outputs.Meta{"source": "t2", "namespace": "team-b"})
+	w.Write(ctx, testUpdateResponse(), outputs.Meta{"source": "t3"})
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := o.written()
+	if len(got) != 1 || got[0] != "t1" {
+		t.Errorf("expected only the message from t1, got %v", got)
+	}
+}
+
+func TestOutputWorkersLeaderOnly(t *testing.T) {
+	o := new(recordOutput)
+	w, err := newOutputWorkers("o1", o, map[string]interface{}{
+		"write-workers": 1,
+		"leader-only":   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leader := false
+	w.isLeader = func() bool { return leader }
+	ctx := context.Background()
+	w.Write(ctx, testUpdateResponse(), outputs.Meta{"source": "t1"})
+	leader = true
+	w.Write(ctx, testUpdateResponse(), outputs.Meta{"source": "t2"})
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := o.written()
+	if len(got) != 1 || got[0] != "t2" {
+		t.Errorf("expected only the message written as leader, got %v", got)
+	}
+}
+
+func TestOutputWorkersFlush(t *testing.T) {
+	o := new(recordOutput)
+	w, err := newOutputWorkers("o1", o, map[string]interface{}{
+		"write-workers":    1,
+		"event-processors": []interface{}{"seq"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.eventProcessors) != 1 || w.eventProcessors[0] != "seq" {
+		t.Fatalf("unexpected event processors %v", w.eventProcessors)
+	}
+	w.flushEvery(10 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	metas := o.writtenMeta()
+	if len(metas) == 0 {
+		t.Fatal("expected flush notifications to be written")
+	}
+	for _, m := range metas {
+		if m[formatters.MetaFlush] != "true" {
+			t.Errorf("unexpected meta %v", m)
+		}
+	}
+	// no flush after close
+	n := len(o.writtenMeta())
+	time.Sleep(30 * time.Millisecond)
+	if len(o.writtenMeta()) != n {
+		t.Error("expected no flush after close")
+	}
+}
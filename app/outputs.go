@@ -12,6 +12,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/openconfig/gnmic/formatters"
 	"github.com/openconfig/gnmic/outputs"
 	"github.com/openconfig/gnmic/types"
 )
@@ -27,11 +28,22 @@ func (a *App) InitOutput(ctx context.Context, name string, tcs map[string]*types
 			a.Logger.Printf("starting output type %s", outType)
 			if initializer, ok := outputs.Outputs[outType.(string)]; ok {
 				out := initializer()
+				cfg, ps := a.outputProcessors(cfg)
+				ow, err := newOutputWorkers(name, out, cfg)
+				if err != nil {
+					a.Logger.Printf("failed to init output %q: %v", name, err)
+					return
+				}
+				// leader-only outputs write all the messages when not clustered
+				if a.inCluster() {
+					ow.isLeader = a.leader
+				}
+				ow.flushEvery(formatters.FlushInterval(ow.eventProcessors, ps))
 				go func() {
 					err := out.Init(ctx, name, cfg,
 						outputs.WithLogger(a.Logger),
 						outputs.WithEventProcessors(
-							a.Config.Processors,
+							ps,
 							a.Logger,
 							a.Config.Targets,
 							a.Config.Actions,
@@ -46,13 +58,41 @@ func (a *App) InitOutput(ctx context.Context, name string, tcs map[string]*types
 					}
 				}()
 				a.operLock.Lock()
-				a.Outputs[name] = out
+				a.Outputs[name] = ow
 				a.operLock.Unlock()
 			}
 		}
 	}
 }
 
+// outputProcessors returns the output config cfg with the subscription profiles processors
+// prepended to its event processors, and the processors configs they refer to.
+// cfg is returned unchanged if no profile defines processors.
+func (a *App) outputProcessors(cfg map[string]interface{}) (map[string]interface{}, map[string]map[string]interface{}) {
+	names, pps := a.Config.ProfileProcessors()
+	if len(names) == 0 {
+		return cfg, a.Config.Processors
+	}
+	wc := new(outputWriteConfig)
+	err := outputs.DecodeConfig(cfg, wc)
+	if err != nil {
+		return cfg, a.Config.Processors
+	}
+	ncfg := make(map[string]interface{}, len(cfg))
+	for k, v := range cfg {
+		ncfg[k] = v
+	}
+	ncfg["event-processors"] = append(names, wc.EventProcessors...)
+	ps := make(map[string]map[string]interface{}, len(a.Config.Processors)+len(pps))
+	for n, p := range a.Config.Processors {
+		ps[n] = p
+	}
+	for n, p := range pps {
+		ps[n] = p
+	}
+	return ncfg, ps
+}
+
 func (a *App) InitOutputs(ctx context.Context) {
 	for name := range a.Config.Outputs {
 		a.InitOutput(ctx, name, a.Config.Targets)
@@ -37,13 +37,15 @@ type pathGenOpts struct {
 }
 
 type generatedPath struct {
-	Path           string `json:"path,omitempty"`
-	PathWithPrefix string `json:"path-with-prefix,omitempty"`
-	Type           string `json:"type,omitempty"`
-	Description    string `json:"description,omitempty"`
-	Default        string `json:"default,omitempty"`
-	IsState        bool   `json:"is-state,omitempty"`
-	Namespace      string `json:"namespace,omitempty"`
+	Path           string   `json:"path,omitempty"`
+	PathWithPrefix string   `json:"path-with-prefix,omitempty"`
+	Type           string   `json:"type,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	Default        string   `json:"default,omitempty"`
+	IsState        bool     `json:"is-state,omitempty"`
+	Namespace      string   `json:"namespace,omitempty"`
+	When           string   `json:"when,omitempty"`
+	Must           []string `json:"must,omitempty"`
 }
 
 func (a *App) PathCmdRun(d, f, e []string, pgo pathGenOpts) error {
@@ -183,6 +185,9 @@ func (a *App) PathPreRunE(cmd *cobra.Command, args []string) error {
 	if a.Config.PathSearch && a.Config.PathWithDescr {
 		return errors.New("flags --search and --descr cannot be used together")
 	}
+	if a.Config.PathLookup != "" && a.Config.PathServe != "" {
+		return errors.New("flags --lookup and --serve cannot be used together")
+	}
 	if a.Config.LocalFlags.PathPathType != "xpath" && a.Config.LocalFlags.PathPathType != "gnmi" {
 		return errors.New("path-type must be one of 'xpath' or 'gnmi'")
 	}
@@ -190,6 +195,16 @@ func (a *App) PathPreRunE(cmd *cobra.Command, args []string) error {
 }
 
 func (a *App) PathRunE(cmd *cobra.Command, args []string) error {
+	if a.Config.LocalFlags.PathLookup != "" || a.Config.LocalFlags.PathServe != "" {
+		err := a.generateYangSchema(a.Config.GlobalFlags.Dir, a.Config.GlobalFlags.File, a.Config.GlobalFlags.Exclude)
+		if err != nil {
+			return err
+		}
+		if a.Config.LocalFlags.PathServe != "" {
+			return a.servePaths(a.Config.LocalFlags.PathServe)
+		}
+		return a.printPathLookup(a.Config.LocalFlags.PathLookup)
+	}
 	return a.PathCmdRun(
 		a.Config.GlobalFlags.Dir,
 		a.Config.GlobalFlags.File,
@@ -214,6 +229,8 @@ func (a *App) InitPathFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&a.Config.LocalFlags.PathSearch, "search", "", false, "search through path list")
 	cmd.Flags().BoolVarP(&a.Config.LocalFlags.PathState, "state-only", "", false, "generate paths only for YANG leafs representing state data")
 	cmd.Flags().BoolVarP(&a.Config.LocalFlags.PathConfig, "config-only", "", false, "generate paths only for YANG leafs representing config data")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.PathLookup, "lookup", "", "", "print the YANG node information of a path, keys included")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.PathServe, "serve", "", "", "address to serve path conversions and lookups over HTTP on, e.g :8080")
 	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
 		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
 	})
@@ -285,6 +302,8 @@ func (a *App) generatePath(entry *yang.Entry, pType string) *generatedPath {
 
 	gp.IsState = isState(entry)
 	gp.Namespace = entry.Namespace().NName()
+	gp.When, _ = entry.GetWhenXPath()
+	gp.Must = mustConditions(entry)
 	if pType == "gnmi" {
 		gnmiPath, err := utils.ParsePath(gp.Path)
 		if err != nil {
@@ -0,0 +1,257 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/openconfig/gnmic/utils"
+)
+
+// pathLookup is the YANG node a concrete path resolves to.
+type pathLookup struct {
+	Path        string   `json:"path,omitempty"`
+	SchemaPath  string   `json:"schema-path,omitempty"`
+	Module      string   `json:"module,omitempty"`
+	Kind        string   `json:"kind,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Units       string   `json:"units,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	IsState     bool     `json:"is-state,omitempty"`
+	Keys        []string `json:"keys,omitempty"`
+
+	entry *yang.Entry
+}
+
+// lookupPath resolves the path p, with or without keys,
+// to its node in the schema tree.
+func (a *App) lookupPath(p string) (*pathLookup, error) {
+	if a.SchemaTree == nil || len(a.SchemaTree.Dir) == 0 {
+		return nil, errors.New("no YANG models loaded")
+	}
+	gp, err := utils.ParsePath(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse path %q: %v", p, err)
+	}
+	if len(gp.GetElem()) == 0 {
+		return nil, fmt.Errorf("path %q does not point to a YANG node", p)
+	}
+	var e *yang.Entry
+	for i, pe := range gp.GetElem() {
+		if i == 0 {
+			e = schemaTopLevel(a.SchemaTree, pe.GetName())
+		} else {
+			e = schemaChild(e, pe.GetName())
+		}
+		if e == nil {
+			return nil, fmt.Errorf("unknown path element %q in path %q", pe.GetName(), p)
+		}
+		if len(pe.GetKey()) == 0 {
+			continue
+		}
+		if !e.IsList() {
+			return nil, fmt.Errorf("path element %q in path %q is not a list", pe.GetName(), p)
+		}
+		keys := strings.Fields(e.Key)
+		for k := range pe.GetKey() {
+			if !isKeyName(keys, stripModule(k)) {
+				return nil, fmt.Errorf("unknown key %q of list %q, must be one of %q", k, pe.GetName(), keys)
+			}
+		}
+	}
+	pl := &pathLookup{
+		Path:        xpath(gp),
+		SchemaPath:  schemaPath(e),
+		Description: e.Description,
+		Units:       e.Units,
+		IsState:     isState(e),
+		entry:       e,
+	}
+	pl.Module, _ = e.InstantiatingModule()
+	switch {
+	case e.IsList():
+		pl.Kind = "list"
+		pl.Keys = strings.Fields(e.Key)
+	case e.IsLeafList():
+		pl.Kind = "leaf-list"
+		pl.Default = strings.Join(e.DefaultValues(), ", ")
+	case e.IsLeaf():
+		pl.Kind = "leaf"
+		pl.Default, _ = e.SingleDefaultValue()
+	default:
+		pl.Kind = "container"
+	}
+	if e.Type != nil {
+		pl.Type = e.Type.Name
+		if pl.Units == "" {
+			pl.Units = e.Type.Units
+		}
+	}
+	return pl, nil
+}
+
+// schemaPath returns the data path of e, without keys,
+// choice and case nodes are omitted.
+func schemaPath(e *yang.Entry) string {
+	elems := make([]string, 0)
+	for ; e != nil && e.Parent != nil; e = e.Parent {
+		if e.IsCase() || e.IsChoice() {
+			continue
+		}
+		elems = append(elems, e.Name)
+	}
+	sb := new(strings.Builder)
+	for i := len(elems) - 1; i >= 0; i-- {
+		sb.WriteString("/")
+		sb.WriteString(elems[i])
+	}
+	return sb.String()
+}
+
+func isKeyName(keys []string, name string) bool {
+	for _, k := range keys {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) printPathLookup(p string) error {
+	pl, err := a.lookupPath(p)
+	if err != nil {
+		return err
+	}
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "path: %s\n", pl.Path)
+	fmt.Fprintf(sb, "schema-path: %s\n", pl.SchemaPath)
+	if pl.Module != "" {
+		fmt.Fprintf(sb, "module: %s\n", pl.Module)
+	}
+	fmt.Fprintf(sb, "kind: %s\n", pl.Kind)
+	if len(pl.Keys) > 0 {
+		fmt.Fprintf(sb, "keys: %s\n", strings.Join(pl.Keys, ", "))
+	}
+	fmt.Fprintf(sb, "state: %t\n", pl.IsState)
+	if pl.Units != "" {
+		fmt.Fprintf(sb, "units: %s\n", pl.Units)
+	}
+	// type defaults are part of the type info
+	if pl.Default != "" && (pl.entry.Type == nil || pl.Default != pl.entry.Type.Default) {
+		fmt.Fprintf(sb, "default: %s\n", pl.Default)
+	}
+	if pl.Description != "" {
+		fmt.Fprintf(sb, "description:\n%s\n", indent("\t", pl.Description))
+	}
+	if pl.entry.Type != nil {
+		sb.WriteString(a.generateTypeInfo(pl.entry))
+	}
+	fmt.Fprint(a.out, sb.String())
+	return nil
+}
+
+// pathServerHandler returns the handler of the path conversion
+// and lookup HTTP service.
+func (a *App) pathServerHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xpath-to-gnmi", a.handlePathXPathToGNMI)
+	mux.HandleFunc("/gnmi-to-xpath", a.handlePathGNMIToXPath)
+	mux.HandleFunc("/lookup", a.handlePathLookup)
+	return mux
+}
+
+func (a *App) servePaths(addr string) error {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: a.pathServerHandler(),
+	}
+	go func() {
+		<-a.Context().Done()
+		srv.Close()
+	}()
+	a.Logger.Printf("serving path conversions on %s", addr)
+	fmt.Fprintf(a.out, "serving path conversions on %s\n", addr)
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (a *App) handlePathXPathToGNMI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writePathError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	gp, err := utils.ParsePath(r.URL.Query().Get("path"))
+	if err != nil {
+		writePathError(w, http.StatusBadRequest, err)
+		return
+	}
+	b, err := protojson.Marshal(gp)
+	if err != nil {
+		writePathError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func (a *App) handlePathGNMIToXPath(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writePathError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writePathError(w, http.StatusBadRequest, err)
+		return
+	}
+	gp := new(gnmi.Path)
+	err = protojson.Unmarshal(body, gp)
+	if err != nil {
+		writePathError(w, http.StatusBadRequest, err)
+		return
+	}
+	writePathJSON(w, map[string]string{"xpath": xpath(gp)})
+}
+
+func (a *App) handlePathLookup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writePathError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	pl, err := a.lookupPath(r.URL.Query().Get("path"))
+	if err != nil {
+		writePathError(w, http.StatusNotFound, err)
+		return
+	}
+	writePathJSON(w, pl)
+}
+
+func writePathJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writePathError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(APIErrors{Errors: []string{err.Error()}})
+}
@@ -8,7 +8,19 @@
 
 package app
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
 
 var collapseTestSet = map[string][]string{
 	"1": {
@@ -50,3 +62,152 @@ func TestCollapsePrefixes(t *testing.T) {
 		})
 	}
 }
+
+func TestLookupPath(t *testing.T) {
+	a := New()
+	a.SchemaTree = testGenerateSchema(t)
+	tests := map[string]struct {
+		path string
+		want *pathLookup
+		err  bool
+	}{
+		"leaf": {
+			path: "/network/vlan[id=10]/config/mtu",
+			want: &pathLookup{
+				Path:       "/network/vlan[id=10]/config/mtu",
+				SchemaPath: "/network/vlan/config/mtu",
+				Module:     "test-network",
+				Kind:       "leaf",
+				Type:       "uint32",
+				Default:    "1500",
+			},
+		},
+		"list": {
+			path: "/tn:network/vlan",
+			want: &pathLookup{
+				Path:       "/tn:network/vlan",
+				SchemaPath: "/network/vlan",
+				Module:     "test-network",
+				Kind:       "list",
+				Keys:       []string{"id"},
+			},
+		},
+		"state": {
+			path: "/network/vlan[id=*]/state/status",
+			want: &pathLookup{
+				Path:       "/network/vlan[id=*]/state/status",
+				SchemaPath: "/network/vlan/state/status",
+				Module:     "test-network",
+				Kind:       "leaf",
+				Type:       "string",
+				IsState:    true,
+			},
+		},
+		"unknown_node": {
+			path: "/network/vlan/config/speed",
+			err:  true,
+		},
+		"unknown_key": {
+			path: "/network/vlan[name=v10]",
+			err:  true,
+		},
+		"not_a_list": {
+			path: "/network/l3[name=v10]",
+			err:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := a.lookupPath(tc.path)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got.entry = nil
+			gb, _ := json.Marshal(got)
+			wb, _ := json.Marshal(tc.want)
+			if string(gb) != string(wb) {
+				t.Errorf("unexpected lookup:\ngot:  %s\nwant: %s", gb, wb)
+			}
+		})
+	}
+}
+
+func TestPathServer(t *testing.T) {
+	a := New()
+	a.SchemaTree = testGenerateSchema(t)
+	srv := httptest.NewServer(a.pathServerHandler())
+	defer srv.Close()
+
+	xp := "openconfig:/network/vlan[id=10]/config"
+	rsp, err := http.Get(srv.URL + "/xpath-to-gnmi?path=" + url.QueryEscape(xp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %s", rsp.Status)
+	}
+	b, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gp := new(gnmi.Path)
+	err = protojson.Unmarshal(b, gp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &gnmi.Path{
+		Origin: "openconfig",
+		Elem: []*gnmi.PathElem{
+			{Name: "network"},
+			{Name: "vlan", Key: map[string]string{"id": "10"}},
+			{Name: "config"},
+		},
+	}
+	if !proto.Equal(gp, want) {
+		t.Fatalf("unexpected gNMI path: %v", gp)
+	}
+
+	rsp2, err := http.Post(srv.URL+"/gnmi-to-xpath", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp2.Body.Close()
+	res := make(map[string]string)
+	err = json.NewDecoder(rsp2.Body).Decode(&res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res["xpath"] != xp {
+		t.Errorf("expected xpath %q, got %q", xp, res["xpath"])
+	}
+
+	rsp3, err := http.Get(srv.URL + "/lookup?path=" + url.QueryEscape("/network/vlan[id=10]/config/enabled"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp3.Body.Close()
+	pl := new(pathLookup)
+	err = json.NewDecoder(rsp3.Body).Decode(pl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl.Type != "boolean" || pl.Default != "true" {
+		t.Errorf("unexpected lookup: %+v", pl)
+	}
+
+	rsp4, err := http.Get(srv.URL + "/lookup?path=/network/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp4.Body.Close()
+	if rsp4.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404, got %s", rsp4.Status)
+	}
+}
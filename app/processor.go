@@ -0,0 +1,188 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	processorInputFormatEvent    = "event"
+	processorInputFormatResponse = "response"
+)
+
+func (a *App) ProcessorPreRunE(cmd *cobra.Command, args []string) error {
+	a.Config.SetLocalFlagsFromFile(cmd)
+	a.Config.LocalFlags.ProcessorName = config.SanitizeArrayFlagValue(a.Config.LocalFlags.ProcessorName)
+	switch a.Config.LocalFlags.ProcessorInputFormat {
+	case processorInputFormatEvent, processorInputFormatResponse:
+	default:
+		return fmt.Errorf("unknown input format %q, must be one of %q",
+			a.Config.LocalFlags.ProcessorInputFormat,
+			[]string{processorInputFormatEvent, processorInputFormatResponse})
+	}
+	return nil
+}
+
+func (a *App) ProcessorRunE(cmd *cobra.Command, args []string) error {
+	defer a.InitProcessorFlags(cmd)
+
+	_, err := a.Config.GetActions()
+	if err != nil {
+		return fmt.Errorf("failed reading actions config: %v", err)
+	}
+	evps, err := a.intializeEventProcessors(a.Config.LocalFlags.ProcessorName)
+	if err != nil {
+		return err
+	}
+	var r io.Reader
+	switch a.Config.LocalFlags.ProcessorInput {
+	case "", "-":
+		r = os.Stdin
+	default:
+		f, err := os.Open(a.Config.LocalFlags.ProcessorInput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var evs []*formatters.EventMsg
+	switch a.Config.LocalFlags.ProcessorInputFormat {
+	case processorInputFormatEvent:
+		evs, err = readEventMsgs(r)
+		if err != nil {
+			return err
+		}
+		for _, ep := range evps {
+			evs = ep.Apply(evs...)
+		}
+	case processorInputFormatResponse:
+		rsps, err := readSubscribeResponses(r)
+		if err != nil {
+			return err
+		}
+		evs = make([]*formatters.EventMsg, 0, len(rsps))
+		for _, rsp := range rsps {
+			revs, err := formatters.ResponseToEventMsgs("", rsp, nil, evps...)
+			if err != nil {
+				return err
+			}
+			evs = append(evs, revs...)
+		}
+	}
+	b, err := json.MarshalIndent(keptEvents(evs), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, string(b))
+	return nil
+}
+
+// keptEvents returns the events of evs not dropped by the processors,
+// the dropped events are emptied in place.
+func keptEvents(evs []*formatters.EventMsg) []*formatters.EventMsg {
+	kept := make([]*formatters.EventMsg, 0, len(evs))
+	for _, e := range evs {
+		if e == nil || (e.Name == "" && e.Timestamp == 0 &&
+			len(e.Tags) == 0 && len(e.Values) == 0 && len(e.Deletes) == 0) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+func (a *App) InitProcessorFlags(cmd *cobra.Command) {
+	cmd.ResetFlags()
+
+	cmd.Flags().StringArrayVarP(&a.Config.LocalFlags.ProcessorName, "name", "", []string{}, "list of processor names to run, in order")
+	cmd.MarkFlagRequired("name")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.ProcessorInput, "input", "", "", "path to a file containing the messages to process, defaults to stdin")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.ProcessorInputFormat, "input-format", "", processorInputFormatEvent,
+		fmt.Sprintf("input messages format, one of %q", []string{processorInputFormatEvent, processorInputFormatResponse}))
+
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
+	})
+}
+
+// readJSONValues reads a stream of JSON values from r.
+// A value that is a JSON array is split into its elements.
+func readJSONValues(r io.Reader) ([]json.RawMessage, error) {
+	dec := json.NewDecoder(r)
+	vals := make([]json.RawMessage, 0)
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return vals, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '[' {
+			elems := make([]json.RawMessage, 0)
+			err = json.Unmarshal(raw, &elems)
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, elems...)
+			continue
+		}
+		vals = append(vals, raw)
+	}
+}
+
+func readEventMsgs(r io.Reader) ([]*formatters.EventMsg, error) {
+	vals, err := readJSONValues(r)
+	if err != nil {
+		return nil, err
+	}
+	evs := make([]*formatters.EventMsg, 0, len(vals))
+	for _, v := range vals {
+		ev := new(formatters.EventMsg)
+		err = json.Unmarshal(v, ev)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event msg: %v", err)
+		}
+		evs = append(evs, ev)
+	}
+	return evs, nil
+}
+
+func readSubscribeResponses(r io.Reader) ([]*gnmi.SubscribeResponse, error) {
+	vals, err := readJSONValues(r)
+	if err != nil {
+		return nil, err
+	}
+	rsps := make([]*gnmi.SubscribeResponse, 0, len(vals))
+	for _, v := range vals {
+		rsp := new(gnmi.SubscribeResponse)
+		err = protojson.Unmarshal(v, rsp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal subscribe response: %v", err)
+		}
+		rsps = append(rsps, rsp)
+	}
+	return rsps, nil
+}
@@ -0,0 +1,163 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	_ "github.com/openconfig/gnmic/formatters/all"
+
+	"github.com/openconfig/gnmic/formatters"
+)
+
+// drop-eth2 only drops the events tagged by add-site,
+// it checks that the processors are applied in order.
+var processorTestConfig = []byte(`
+processors:
+  add-site:
+    event-add-tag:
+      value-names:
+        - ".*"
+      add:
+        site: dc1
+  drop-eth2:
+    event-drop:
+      condition: '.tags.site == "dc1" and .tags.interface_name == "ethernet-1/2"'
+`)
+
+func newProcessorTestApp(t *testing.T, input, format string, names ...string) (*App, *bytes.Buffer) {
+	t.Helper()
+	a := New()
+	t.Cleanup(a.Cfn)
+	a.Config.FileConfig.SetConfigType("yaml")
+	err := a.Config.FileConfig.ReadConfig(bytes.NewBuffer(processorTestConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := filepath.Join(t.TempDir(), "input.json")
+	err = os.WriteFile(f, []byte(input), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Config.LocalFlags.ProcessorName = names
+	a.Config.LocalFlags.ProcessorInput = f
+	a.Config.LocalFlags.ProcessorInputFormat = format
+	out := new(bytes.Buffer)
+	a.out = out
+	return a, out
+}
+
+func runProcessorCmd(t *testing.T, a *App, out *bytes.Buffer) []*formatters.EventMsg {
+	t.Helper()
+	cmd := &cobra.Command{Use: "processor"}
+	err := a.ProcessorPreRunE(cmd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.ProcessorRunE(cmd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evs := make([]*formatters.EventMsg, 0)
+	err = json.Unmarshal(out.Bytes(), &evs)
+	if err != nil {
+		t.Fatalf("failed to unmarshal the command output %q: %v", out.String(), err)
+	}
+	return evs
+}
+
+func checkProcessedEvents(t *testing.T, evs []*formatters.EventMsg) {
+	t.Helper()
+	if len(evs) != 1 {
+		t.Fatalf("expected 1 event, got %d: %v", len(evs), evs)
+	}
+	if evs[0].Tags["interface_name"] != "ethernet-1/1" || evs[0].Tags["site"] != "dc1" {
+		t.Errorf("unexpected event tags: %v", evs[0].Tags)
+	}
+}
+
+func TestProcessorEventInput(t *testing.T) {
+	// a JSON array followed by a single event
+	input := `[
+  {"name": "sub1", "timestamp": 1, "tags": {"interface_name": "ethernet-1/1"}, "values": {"oper-status": "UP"}}
+]
+{"name": "sub1", "timestamp": 2, "tags": {"interface_name": "ethernet-1/2"}, "values": {"oper-status": "DOWN"}}
+`
+	a, out := newProcessorTestApp(t, input, processorInputFormatEvent, "add-site", "drop-eth2")
+	checkProcessedEvents(t, runProcessorCmd(t, a, out))
+}
+
+func TestProcessorResponseInput(t *testing.T) {
+	input := `
+{"update": {"timestamp": "1", "prefix": {"elem": [{"name": "interface", "key": {"name": "ethernet-1/1"}}]},
+  "update": [{"path": {"elem": [{"name": "oper-status"}]}, "val": {"stringVal": "UP"}}]}}
+{"update": {"timestamp": "2", "prefix": {"elem": [{"name": "interface", "key": {"name": "ethernet-1/2"}}]},
+  "update": [{"path": {"elem": [{"name": "oper-status"}]}, "val": {"stringVal": "DOWN"}}]}}
+{"syncResponse": true}
+`
+	a, out := newProcessorTestApp(t, input, processorInputFormatResponse, "add-site", "drop-eth2")
+	evs := runProcessorCmd(t, a, out)
+	checkProcessedEvents(t, evs)
+	if evs[0].Values["/interface/oper-status"] != "UP" {
+		t.Errorf("unexpected event values: %v", evs[0].Values)
+	}
+}
+
+func TestProcessorChainOrder(t *testing.T) {
+	input := `{"name": "sub1", "timestamp": 2, "tags": {"interface_name": "ethernet-1/2"}, "values": {"oper-status": "DOWN"}}`
+	// the drop processor runs before the site tag is added
+	a, out := newProcessorTestApp(t, input, processorInputFormatEvent, "drop-eth2", "add-site")
+	evs := runProcessorCmd(t, a, out)
+	if len(evs) != 1 || evs[0].Tags["site"] != "dc1" {
+		t.Errorf("unexpected events: %v", evs)
+	}
+}
+
+func TestProcessorErrors(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		format string
+		names  []string
+	}{
+		"unknown_processor": {
+			input:  `{"name": "sub1"}`,
+			format: processorInputFormatEvent,
+			names:  []string{"unknown"},
+		},
+		"invalid_event": {
+			input:  `{"name": 1}`,
+			format: processorInputFormatEvent,
+			names:  []string{"add-site"},
+		},
+		"invalid_response": {
+			input:  `{"unknown": true}`,
+			format: processorInputFormatResponse,
+			names:  []string{"add-site"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			a, _ := newProcessorTestApp(t, tt.input, tt.format, tt.names...)
+			cmd := &cobra.Command{Use: "processor"}
+			if err := a.ProcessorRunE(cmd, nil); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+	a, _ := newProcessorTestApp(t, "", "proto", "add-site")
+	if err := a.ProcessorPreRunE(&cobra.Command{Use: "processor"}, nil); err == nil {
+		t.Error("expected an error for an unknown input format")
+	}
+}
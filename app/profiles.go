@@ -0,0 +1,84 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/openconfig/gnmic/config"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) ProfilesListRunE(cmd *cobra.Command, args []string) error {
+	profiles, err := a.Config.GetSubscriptionProfiles()
+	if err != nil {
+		return fmt.Errorf("failed reading subscription profiles config: %v", err)
+	}
+	names := make([]string, 0, len(profiles))
+	for pn := range profiles {
+		names = append(names, pn)
+	}
+	sort.Strings(names)
+	tabData := make([][]string, 0, len(names))
+	for _, pn := range names {
+		sp := profiles[pn]
+		tabData = append(tabData, []string{
+			sp.Name,
+			sp.Description,
+			strings.Join(sp.SubscriptionNames(), "\n"),
+			strings.Join(sp.Processors, "\n"),
+			strings.Join(sp.Outputs, "\n"),
+		})
+	}
+	table := tablewriter.NewWriter(a.out)
+	table.SetHeader([]string{"Name", "Description", "Subscriptions", "Processors", "Outputs"})
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoFormatHeaders(false)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	table.AppendBulk(tabData)
+	table.Render()
+	return nil
+}
+
+func (a *App) ProfilesLintRunE(cmd *cobra.Command, args []string) error {
+	_, err := a.Config.GetOutputs()
+	if err != nil {
+		return fmt.Errorf("failed reading outputs config: %v", err)
+	}
+	_, err = a.Config.GetEventProcessors()
+	if err != nil {
+		return fmt.Errorf("failed reading processors config: %v", err)
+	}
+	_, err = a.Config.GetSubscriptions(nil)
+	if err != nil {
+		return fmt.Errorf("failed reading subscriptions config: %v", err)
+	}
+	_, err = a.Config.GetTargetsFromFile()
+	if err != nil && !errors.Is(err, config.ErrNoTargetsFound) {
+		return fmt.Errorf("failed reading targets config: %v", err)
+	}
+	_, err = a.Config.GetSubscriptionProfiles()
+	if err != nil {
+		return fmt.Errorf("failed reading subscription profiles config: %v", err)
+	}
+	issues := a.Config.LintSubscriptionProfiles()
+	if len(issues) == 0 {
+		fmt.Fprintf(a.out, "%d subscription profile(s) OK\n", len(a.Config.SubscriptionProfiles))
+		return nil
+	}
+	for _, issue := range issues {
+		fmt.Fprintln(a.out, issue)
+	}
+	return fmt.Errorf("found %d issue(s) in subscription profiles", len(issues))
+}
@@ -28,6 +28,15 @@ func (a *App) PromptRunE(cmd *cobra.Command, args []string) error {
 			fmt.Fprintf(os.Stderr, "ERR: failed to load paths from yang: %v\n", err)
 		}
 	}
+	if a.Config.LocalFlags.PromptSchemaTarget != "" {
+		err = a.LoadTargetSchema(a.ctx, a.Config.LocalFlags.PromptSchemaTarget)
+		if err != nil {
+			a.Logger.Printf("failed to load schema from target %q: %v", a.Config.LocalFlags.PromptSchemaTarget, err)
+			if !a.Config.Log {
+				fmt.Fprintf(os.Stderr, "ERR: failed to load schema from target %q: %v\n", a.Config.LocalFlags.PromptSchemaTarget, err)
+			}
+		}
+	}
 	a.PromptMode = true
 	// load history
 	a.PromptHistory = make([]string, 0, 256)
@@ -87,6 +96,10 @@ func (a *App) InitPromptFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&a.Config.LocalFlags.PromptDescriptionWithPrefix, "description-with-prefix", false, "show YANG module prefix in XPATH suggestion description")
 	cmd.Flags().BoolVar(&a.Config.LocalFlags.PromptDescriptionWithTypes, "description-with-types", false, "show YANG types in XPATH suggestion description")
 	cmd.Flags().BoolVar(&a.Config.LocalFlags.PromptSuggestWithOrigin, "suggest-with-origin", false, "suggest XPATHs with origin prepended ")
+	cmd.Flags().StringVar(&a.Config.LocalFlags.PromptSchemaTarget, "schema-target", "", "target name to load the YANG schema from, based on its supported models")
+	cmd.Flags().StringVar(&a.Config.LocalFlags.PromptSchemaRepo, "schema-repo", "", "YANG modules repo, a local directory or a URL template such as https://host/yang/{{.Name}}.yang")
+	cmd.Flags().StringVar(&a.Config.LocalFlags.PromptSchemaGNOIDir, "schema-gnoi-dir", "", "directory on the target to download the YANG modules from using gNOI File.Get")
+	cmd.Flags().StringVar(&a.Config.LocalFlags.PromptSchemaCacheDir, "schema-cache-dir", "", "directory where the downloaded YANG modules are stored, defaults to $TMPDIR/gnmic-schemas")
 	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
 		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
 	})
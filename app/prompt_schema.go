@@ -0,0 +1,221 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+const defaultSchemaCacheDir = "gnmic-schemas"
+
+var yangImportRegex = regexp.MustCompile(`(?m)^\s*(?:import|include)\s+"?([\w.-]+)"?`)
+
+// schemaFetchFn returns the content of the YANG module described by m.
+type schemaFetchFn func(ctx context.Context, m *gnmi.ModelData) ([]byte, error)
+
+// LoadTargetSchema retrieves the models supported by target name using a Capabilities RPC,
+// gets the corresponding YANG modules from the configured repo or from the target using gNOI
+// and sets them as the prompt schema tree.
+func (a *App) LoadTargetSchema(ctx context.Context, name string) error {
+	repo := a.Config.LocalFlags.PromptSchemaRepo
+	gnoiDir := a.Config.LocalFlags.PromptSchemaGNOIDir
+	if repo == "" && gnoiDir == "" {
+		return errors.New("one of --schema-repo or --schema-gnoi-dir must be set")
+	}
+	targetsConfig, err := a.Config.GetTargets()
+	if err != nil {
+		return err
+	}
+	tc, ok := targetsConfig[name]
+	if !ok {
+		return fmt.Errorf("unknown target %q", name)
+	}
+	capRsp, err := a.ClientCapabilities(ctx, tc)
+	if err != nil {
+		return err
+	}
+	models := capRsp.GetSupportedModels()
+	if len(models) == 0 {
+		return fmt.Errorf("target %q does not advertise any supported model", name)
+	}
+	a.modules = yang.NewModules()
+	switch {
+	case gnoiDir == "" && !strings.Contains(repo, "://"):
+		// local repo, the modules are read from the directory tree
+		dirs, err := yang.PathsWithModules(repo)
+		if err != nil {
+			return err
+		}
+		a.modules.AddPath(dirs...)
+	default:
+		dir := a.Config.LocalFlags.PromptSchemaCacheDir
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), defaultSchemaCacheDir)
+		}
+		dir = filepath.Join(dir, name)
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+		fetch, err := a.schemaFetcher(tc)
+		if err != nil {
+			return err
+		}
+		err = a.fetchSchemaModules(ctx, dir, models, fetch)
+		if err != nil {
+			return err
+		}
+		a.modules.AddPath(dir)
+	}
+	numModels := 0
+	for _, m := range models {
+		err = a.modules.Read(m.GetName())
+		if err != nil {
+			a.Logger.Printf("target %q: skipping model %q: %v", name, m.GetName(), err)
+			continue
+		}
+		numModels++
+	}
+	if numModels == 0 {
+		return fmt.Errorf("none of the models supported by target %q could be found", name)
+	}
+	a.Logger.Printf("target %q: loaded %d/%d models", name, numModels, len(models))
+	err = a.buildSchemaTree(a.Config.GlobalFlags.Exclude)
+	if err != nil {
+		return err
+	}
+	a.SchemaTarget = name
+	return nil
+}
+
+// schemaFetcher returns a schemaFetchFn reading the YANG modules from the target using gNOI
+// if a gNOI directory is configured, from the repo URL template otherwise.
+func (a *App) schemaFetcher(tc *types.TargetConfig) (schemaFetchFn, error) {
+	if a.Config.LocalFlags.PromptSchemaGNOIDir != "" {
+		return func(ctx context.Context, m *gnmi.ModelData) ([]byte, error) {
+			return a.ClientFileGet(ctx, tc, path.Join(a.Config.LocalFlags.PromptSchemaGNOIDir, m.GetName()+".yang"))
+		}, nil
+	}
+	tpl, err := utils.CreateTemplate("schema-repo", a.Config.LocalFlags.PromptSchemaRepo)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, m *gnmi.ModelData) ([]byte, error) {
+		buf := new(bytes.Buffer)
+		err := tpl.Execute(buf, m)
+		if err != nil {
+			return nil, err
+		}
+		return utils.ReadFile(ctx, buf.String())
+	}, nil
+}
+
+// fetchSchemaModules stores the YANG modules listed in models and the modules
+// they import or include in directory dir.
+// Modules already present in dir are not fetched again.
+func (a *App) fetchSchemaModules(ctx context.Context, dir string, models []*gnmi.ModelData, fetch schemaFetchFn) error {
+	queue := make([]*gnmi.ModelData, 0, len(models))
+	queue = append(queue, models...)
+	seen := make(map[string]struct{})
+	for len(queue) > 0 {
+		m := queue[0]
+		queue = queue[1:]
+		if _, ok := seen[m.GetName()]; ok {
+			continue
+		}
+		seen[m.GetName()] = struct{}{}
+		fileName := filepath.Join(dir, m.GetName()+".yang")
+		b, err := os.ReadFile(fileName)
+		if err != nil {
+			b, err = fetch(ctx, m)
+			if err != nil {
+				a.Logger.Printf("failed to fetch module %q: %v", m.GetName(), err)
+				continue
+			}
+			err = os.WriteFile(fileName, b, 0644)
+			if err != nil {
+				return err
+			}
+		}
+		for _, dep := range yangImports(b) {
+			queue = append(queue, &gnmi.ModelData{Name: dep})
+		}
+	}
+	return nil
+}
+
+// yangImports returns the names of the modules imported or included by YANG module b.
+func yangImports(b []byte) []string {
+	matches := yangImportRegex.FindAllSubmatch(b, -1)
+	deps := make([]string, 0, len(matches))
+	for _, m := range matches {
+		deps = append(deps, string(m[1]))
+	}
+	return deps
+}
+
+// SchemaPathExists reports whether xpath p resolves in the prompt schema tree.
+// Wildcard path elements match any schema node.
+func (a *App) SchemaPathExists(p string) bool {
+	if a.SchemaTree == nil {
+		return false
+	}
+	gp, err := utils.ParsePath(p)
+	if err != nil {
+		return false
+	}
+	entries := make([]*yang.Entry, 0, len(a.SchemaTree.Dir))
+	for _, e := range a.SchemaTree.Dir {
+		entries = append(entries, e)
+	}
+	for _, pe := range gp.GetElem() {
+		name := pe.GetName()
+		if i := strings.Index(name, ":"); i >= 0 {
+			name = name[i+1:]
+		}
+		next := make([]*yang.Entry, 0)
+		for _, e := range entries {
+			next = append(next, schemaChildren(e, name)...)
+		}
+		if len(next) == 0 {
+			return false
+		}
+		entries = next
+	}
+	return true
+}
+
+// schemaChildren returns the data children of e named name,
+// choice and case nodes are transparent.
+func schemaChildren(e *yang.Entry, name string) []*yang.Entry {
+	children := make([]*yang.Entry, 0, 1)
+	for n, c := range e.Dir {
+		if c.IsChoice() || c.IsCase() {
+			children = append(children, schemaChildren(c, name)...)
+			continue
+		}
+		if name == "*" || name == "..." || n == name {
+			children = append(children, c)
+		}
+	}
+	return children
+}
@@ -0,0 +1,121 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+var testSchemaModules = map[string]string{
+	"test-system": `module test-system {
+  namespace "urn:test-system";
+  prefix sys;
+  import test-types { prefix tt; }
+  container system {
+    leaf name { type tt:name; }
+    choice location {
+      case rack { leaf rack-id { type string; } }
+    }
+    list user {
+      key name;
+      leaf name { type string; }
+    }
+  }
+}`,
+	"test-types": `module test-types {
+  namespace "urn:test-types";
+  prefix tt;
+  typedef name { type string; }
+}`,
+}
+
+func TestFetchSchemaModules(t *testing.T) {
+	dir := t.TempDir()
+	fetched := make(map[string]int)
+	fetch := func(ctx context.Context, m *gnmi.ModelData) ([]byte, error) {
+		fetched[m.GetName()]++
+		if s, ok := testSchemaModules[m.GetName()]; ok {
+			return []byte(s), nil
+		}
+		return nil, fmt.Errorf("unknown module %q", m.GetName())
+	}
+	a := &App{Logger: log.New(io.Discard, "", 0)}
+	models := []*gnmi.ModelData{{Name: "test-system"}, {Name: "unknown"}}
+	err := a.fetchSchemaModules(context.Background(), dir, models, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range testSchemaModules {
+		if fetched[name] != 1 {
+			t.Errorf("module %q fetched %d times", name, fetched[name])
+		}
+		if _, err := os.Stat(filepath.Join(dir, name+".yang")); err != nil {
+			t.Errorf("module %q not stored: %v", name, err)
+		}
+	}
+	// modules present in the cache directory are not fetched again
+	err = a.fetchSchemaModules(context.Background(), dir, models, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched["test-system"] != 1 || fetched["test-types"] != 1 {
+		t.Errorf("cached modules fetched again: %v", fetched)
+	}
+
+	a.modules = yang.NewModules()
+	a.modules.AddPath(dir)
+	err = a.modules.Read("test-system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.buildSchemaTree(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"/system/name":               true,
+		"sys:system/sys:name":        true,
+		"/system/rack-id":            true,
+		"/system/user[name=admin]":   true,
+		"/system/*/name":             true,
+		"openconfig:/system/user":    true,
+		"/system/location":           false,
+		"/system/unknown":            false,
+		"/interfaces/interface/name": false,
+	}
+	for p, exp := range tests {
+		if got := a.SchemaPathExists(p); got != exp {
+			t.Errorf("path %q: expected %v, got %v", p, exp, got)
+		}
+	}
+}
+
+func TestYangImports(t *testing.T) {
+	deps := yangImports([]byte(`module m {
+  import ietf-inet-types { prefix inet; }
+  import "ietf-yang-types" {
+    prefix yang;
+  }
+  include m-sub;
+  description "import nothing";
+}`))
+	exp := []string{"ietf-inet-types", "ietf-yang-types", "m-sub"}
+	if fmt.Sprint(deps) != fmt.Sprint(exp) {
+		t.Errorf("expected %v, got %v", exp, deps)
+	}
+}
@@ -0,0 +1,32 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+
+	"github.com/openconfig/gnmic/target"
+)
+
+// waitRPC blocks until both the global --rpc-rate and the target rpc-rate
+// allow sending an RPC to target t.
+func (a *App) waitRPC(ctx context.Context, t *target.Target) error {
+	a.rpcLimiterOnce.Do(func() {
+		if a.Config.RPCRate > 0 {
+			a.rpcLimiter = target.NewRPCLimiter(a.Config.RPCRate, a.Config.RPCBurst)
+		}
+	})
+	if a.rpcLimiter != nil {
+		err := a.rpcLimiter.Wait(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return t.WaitRPC(ctx)
+}
@@ -0,0 +1,64 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/types"
+)
+
+func TestClientGetRateLimit(t *testing.T) {
+	_, addr := startCapsTestServer(t, gnmi.Encoding_JSON)
+	insecure := true
+	tests := map[string]struct {
+		globalRate float64
+		targetRate float64
+	}{
+		"target": {targetRate: 20},
+		"global": {globalRate: 20},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			a := New()
+			defer a.Cfn()
+			a.Config.Log = true
+			a.Config.RPCRate = tc.globalRate
+			a.Config.RPCBurst = 1
+			tcs := []*types.TargetConfig{
+				{Name: "t1", Address: addr, Insecure: &insecure, Timeout: 5 * time.Second, RPCRate: tc.targetRate, RPCBurst: 1},
+			}
+			if tc.globalRate > 0 {
+				// the global rate applies across targets
+				tcs = append(tcs, &types.TargetConfig{Name: "t2", Address: addr, Insecure: &insecure, Timeout: 5 * time.Second})
+			}
+			// warm up the connections and the limiter bucket
+			for _, tc := range tcs {
+				_, err := a.ClientGet(context.Background(), tc, &gnmi.GetRequest{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			start := time.Now()
+			for i := 0; i < 4; i++ {
+				_, err := a.ClientGet(context.Background(), tcs[i%len(tcs)], &gnmi.GetRequest{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			// 4 RPCs at 20 per second take at least 150ms
+			if d := time.Since(start); d < 150*time.Millisecond {
+				t.Errorf("expected the RPCs to be rate limited, took %s", d)
+			}
+		})
+	}
+}
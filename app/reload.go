@@ -0,0 +1,313 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"syscall"
+
+	"github.com/openconfig/gnmic/config"
+)
+
+// configNames holds the names of the targets, subscriptions, outputs and processors
+// loaded from the configuration file.
+// A reload only deletes those, leaving the ones added by loaders, the tunnel server or the API.
+type configNames struct {
+	targets       map[string]struct{}
+	subscriptions map[string]struct{}
+	outputs       map[string]struct{}
+	processors    map[string]struct{}
+}
+
+// reloadChanges lists the names of the configuration items changed by a reload.
+type reloadChanges struct {
+	Added   []string `json:"added,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+	Deleted []string `json:"deleted,omitempty"`
+}
+
+type reloadResult struct {
+	Targets       *reloadChanges `json:"targets,omitempty"`
+	Subscriptions *reloadChanges `json:"subscriptions,omitempty"`
+	Outputs       *reloadChanges `json:"outputs,omitempty"`
+	Processors    *reloadChanges `json:"processors,omitempty"`
+	// errors that occurred while applying the changes
+	Errors []string `json:"errors,omitempty"`
+}
+
+func (rc *reloadChanges) empty() bool {
+	return len(rc.Added) == 0 && len(rc.Updated) == 0 && len(rc.Deleted) == 0
+}
+
+func mapKeys(m interface{}) map[string]struct{} {
+	v := reflect.ValueOf(m)
+	keys := make(map[string]struct{}, v.Len())
+	for _, k := range v.MapKeys() {
+		keys[k.String()] = struct{}{}
+	}
+	return keys
+}
+
+// diffConfigSection compares two configuration sections, both maps keyed by name.
+// Items missing from next are reported as deleted only if they were loaded from the file.
+func diffConfigSection(current, next interface{}, fromFile map[string]struct{}) *reloadChanges {
+	rc := new(reloadChanges)
+	cv := reflect.ValueOf(current)
+	nv := reflect.ValueOf(next)
+	for _, k := range nv.MapKeys() {
+		cur := cv.MapIndex(k)
+		switch {
+		case !cur.IsValid():
+			rc.Added = append(rc.Added, k.String())
+		case !reflect.DeepEqual(cur.Interface(), nv.MapIndex(k).Interface()):
+			rc.Updated = append(rc.Updated, k.String())
+		}
+	}
+	for _, k := range cv.MapKeys() {
+		if nv.MapIndex(k).IsValid() {
+			continue
+		}
+		if _, ok := fromFile[k.String()]; ok {
+			rc.Deleted = append(rc.Deleted, k.String())
+		}
+	}
+	sort.Strings(rc.Added)
+	sort.Strings(rc.Updated)
+	sort.Strings(rc.Deleted)
+	return rc
+}
+
+// recordConfigNames saves the names of the items loaded from the configuration file.
+func (a *App) recordConfigNames(c *config.Config) {
+	a.configNames = &configNames{
+		targets:       mapKeys(c.Targets),
+		subscriptions: mapKeys(c.Subscriptions),
+		outputs:       mapKeys(c.Outputs),
+		processors:    mapKeys(c.Processors),
+	}
+}
+
+// handleReloadSignal reloads the configuration each time gNMIc receives a SIGHUP.
+func (a *App) handleReloadSignal() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-sigCh:
+			a.Logger.Printf("received SIGHUP, reloading config...")
+			rr, err := a.ReloadConfig(a.ctx)
+			if err != nil {
+				a.Logger.Printf("failed to reload config: %v", err)
+				continue
+			}
+			for _, e := range rr.Errors {
+				a.Logger.Printf("config reload error: %s", e)
+			}
+		}
+	}
+}
+
+// ReloadConfig reads the configuration file again and applies the changes
+// of its targets, subscriptions, outputs and processors sections.
+// Targets, subscriptions and outputs that did not change are not restarted.
+func (a *App) ReloadConfig(ctx context.Context) (*reloadResult, error) {
+	err := a.sem.Acquire(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer a.sem.Release(1)
+
+	nc, err := a.Config.Reload(ctx, a.reloadCmd)
+	if err != nil {
+		return nil, err
+	}
+	if a.configNames == nil {
+		a.recordConfigNames(a.Config)
+	}
+	a.configLock.RLock()
+	rr := &reloadResult{
+		Targets:       diffConfigSection(a.Config.Targets, nc.Targets, a.configNames.targets),
+		Subscriptions: diffConfigSection(a.Config.Subscriptions, nc.Subscriptions, a.configNames.subscriptions),
+		Outputs:       diffConfigSection(a.Config.Outputs, nc.Outputs, a.configNames.outputs),
+		Processors:    diffConfigSection(a.Config.Processors, nc.Processors, a.configNames.processors),
+	}
+	a.configLock.RUnlock()
+
+	a.reloadProcessors(nc, rr)
+	a.reloadOutputs(nc, rr)
+	a.reloadSubscriptions(nc, rr)
+	a.reloadTargets(ctx, nc, rr)
+	a.recordConfigNames(nc)
+	a.Logger.Printf("config reloaded: targets=%+v, subscriptions=%+v, outputs=%+v, processors=%+v",
+		*rr.Targets, *rr.Subscriptions, *rr.Outputs, *rr.Processors)
+	return rr, nil
+}
+
+// reloadProcessors applies the processors configuration changes,
+// the outputs using them are restarted by reloadOutputs.
+func (a *App) reloadProcessors(nc *config.Config, rr *reloadResult) {
+	if rr.Processors.empty() {
+		return
+	}
+	a.configLock.Lock()
+	defer a.configLock.Unlock()
+	for _, name := range rr.Processors.Deleted {
+		delete(a.Config.Processors, name)
+	}
+	for _, name := range append(rr.Processors.Added, rr.Processors.Updated...) {
+		a.Config.Processors[name] = nc.Processors[name]
+	}
+}
+
+func (a *App) reloadOutputs(nc *config.Config, rr *reloadResult) {
+	changedProcessors := make(map[string]struct{})
+	for _, names := range [][]string{rr.Processors.Added, rr.Processors.Updated, rr.Processors.Deleted} {
+		for _, name := range names {
+			changedProcessors[name] = struct{}{}
+		}
+	}
+	// outputs using a changed processor are restarted
+	// even if their own configuration did not change.
+	restart := make(map[string]struct{})
+	for _, name := range rr.Outputs.Updated {
+		restart[name] = struct{}{}
+	}
+	if len(changedProcessors) > 0 {
+		for name, cfg := range nc.Outputs {
+			for _, p := range outputProcessors(cfg) {
+				if _, ok := changedProcessors[p]; ok {
+					restart[name] = struct{}{}
+					break
+				}
+			}
+		}
+	}
+	for _, name := range rr.Outputs.Deleted {
+		err := a.DeleteOutput(name)
+		if err != nil {
+			rr.Errors = append(rr.Errors, fmt.Sprintf("output %q: %v", name, err))
+		}
+		a.configLock.Lock()
+		delete(a.Config.Outputs, name)
+		a.configLock.Unlock()
+	}
+	for name := range restart {
+		a.operLock.RLock()
+		_, running := a.Outputs[name]
+		a.operLock.RUnlock()
+		if running {
+			err := a.DeleteOutput(name)
+			if err != nil {
+				rr.Errors = append(rr.Errors, fmt.Sprintf("output %q: %v", name, err))
+			}
+		}
+		a.configLock.Lock()
+		a.Config.Outputs[name] = nc.Outputs[name]
+		a.configLock.Unlock()
+		a.InitOutput(a.ctx, name, a.Config.Targets)
+	}
+	for _, name := range rr.Outputs.Added {
+		a.configLock.Lock()
+		a.Config.Outputs[name] = nc.Outputs[name]
+		a.configLock.Unlock()
+		a.InitOutput(a.ctx, name, a.Config.Targets)
+	}
+}
+
+func outputProcessors(cfg map[string]interface{}) []string {
+	switch eps := cfg["event-processors"].(type) {
+	case []string:
+		return eps
+	case []interface{}:
+		names := make([]string, 0, len(eps))
+		for _, ep := range eps {
+			if s, ok := ep.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+func (a *App) reloadSubscriptions(nc *config.Config, rr *reloadResult) {
+	for _, name := range rr.Subscriptions.Deleted {
+		err := a.DeleteSubscriptionConfig(name)
+		if err != nil {
+			rr.Errors = append(rr.Errors, fmt.Sprintf("subscription %q: %v", name, err))
+		}
+	}
+	for _, name := range rr.Subscriptions.Updated {
+		err := a.UpdateSubscriptionConfig(nc.Subscriptions[name])
+		if err != nil {
+			rr.Errors = append(rr.Errors, fmt.Sprintf("subscription %q: %v", name, err))
+		}
+	}
+	for _, name := range rr.Subscriptions.Added {
+		err := a.AddSubscriptionConfig(nc.Subscriptions[name])
+		if err != nil {
+			rr.Errors = append(rr.Errors, fmt.Sprintf("subscription %q: %v", name, err))
+		}
+	}
+}
+
+// reloadTargets applies the targets changes, an updated target is deleted and added back.
+// In a cluster, only the leader applies the changes by (re)dispatching the targets.
+func (a *App) reloadTargets(ctx context.Context, nc *config.Config, rr *reloadResult) {
+	if rr.Targets.empty() {
+		return
+	}
+	deleted := append(rr.Targets.Deleted, rr.Targets.Updated...)
+	added := append(rr.Targets.Added, rr.Targets.Updated...)
+	if !a.inCluster() {
+		for _, name := range deleted {
+			err := a.DeleteTarget(ctx, name)
+			if err != nil {
+				rr.Errors = append(rr.Errors, fmt.Sprintf("target %q: %v", name, err))
+			}
+		}
+		for _, name := range added {
+			tc := nc.Targets[name]
+			a.AddTargetConfig(tc)
+			a.wg.Add(1)
+			go a.subscribeStream(a.ctx, tc)
+		}
+		return
+	}
+	if !a.isLeader {
+		return
+	}
+	for _, name := range deleted {
+		err := a.deleteTarget(ctx, name)
+		if err != nil {
+			rr.Errors = append(rr.Errors, fmt.Sprintf("target %q: %v", name, err))
+		}
+		a.configLock.Lock()
+		delete(a.Config.Targets, name)
+		a.configLock.Unlock()
+	}
+	for _, name := range added {
+		tc := nc.Targets[name]
+		a.configLock.Lock()
+		a.Config.Targets[name] = tc
+		a.configLock.Unlock()
+		err := a.dispatchTarget(a.ctx, tc)
+		if err != nil {
+			rr.Errors = append(rr.Errors, fmt.Sprintf("target %q: %v", name, err))
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+func TestDiffConfigSection(t *testing.T) {
+	current := map[string]*types.SubscriptionConfig{
+		"unchanged": {Name: "unchanged", Paths: []string{"/a"}},
+		"updated":   {Name: "updated", Paths: []string{"/a"}},
+		"deleted":   {Name: "deleted"},
+		"dynamic":   {Name: "dynamic"},
+	}
+	next := map[string]*types.SubscriptionConfig{
+		"unchanged": {Name: "unchanged", Paths: []string{"/a"}},
+		"updated":   {Name: "updated", Paths: []string{"/b"}},
+		"added":     {Name: "added"},
+	}
+	fromFile := map[string]struct{}{"unchanged": {}, "updated": {}, "deleted": {}}
+	rc := diffConfigSection(current, next, fromFile)
+	expected := &reloadChanges{
+		Added:   []string{"added"},
+		Updated: []string{"updated"},
+		Deleted: []string{"deleted"},
+	}
+	if !reflect.DeepEqual(rc, expected) {
+		t.Errorf("expected %+v, got %+v", expected, rc)
+	}
+}
+
+const reloadTestConfig = `
+encoding: json_ietf
+subscriptions:
+  sub1:
+    paths:
+      - /interface
+    stream-mode: sample
+    sample-interval: 10s
+  sub2:
+    paths:
+      - /system
+processors:
+  p1:
+    event-drop:
+      condition: 'false'
+outputs:
+  o1:
+    type: file
+    file-type: stdout
+    event-processors:
+      - p1
+  o2:
+    type: file
+    file-type: stderr
+`
+
+const reloadTestConfigUpdated = `
+encoding: json_ietf
+subscriptions:
+  sub1:
+    paths:
+      - /interface
+    stream-mode: sample
+    sample-interval: 20s
+  sub3:
+    paths:
+      - /network-instance
+processors:
+  p1:
+    event-drop:
+      condition: 'true'
+outputs:
+  o1:
+    type: file
+    file-type: stdout
+    event-processors:
+      - p1
+  o2:
+    type: file
+    file-type: stderr
+`
+
+func TestReloadConfig(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "gnmic.yaml")
+	err := os.WriteFile(cfgFile, []byte(reloadTestConfig), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New()
+	defer a.Cfn()
+	a.Config.GlobalFlags.CfgFile = cfgFile
+	a.Config.LocalFlags.SubscribeMode = "stream"
+	err = a.Config.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = a.Config.GetSubscriptions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.readConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.recordConfigNames(a.Config)
+	a.routes()
+
+	err = os.WriteFile(cfgFile, []byte(reloadTestConfigUpdated), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/config/reload", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	rr := new(reloadResult)
+	err = json.Unmarshal(rec.Body.Bytes(), rr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rr.Errors) > 0 {
+		t.Errorf("unexpected errors: %v", rr.Errors)
+	}
+	expectedSubs := &reloadChanges{Added: []string{"sub3"}, Updated: []string{"sub1"}, Deleted: []string{"sub2"}}
+	if !reflect.DeepEqual(rr.Subscriptions, expectedSubs) {
+		t.Errorf("subscriptions: expected %+v, got %+v", expectedSubs, rr.Subscriptions)
+	}
+	if !reflect.DeepEqual(rr.Processors, &reloadChanges{Updated: []string{"p1"}}) {
+		t.Errorf("unexpected processors changes: %+v", rr.Processors)
+	}
+	if !rr.Outputs.empty() {
+		t.Errorf("unexpected outputs changes: %+v", rr.Outputs)
+	}
+	if _, ok := a.Config.Subscriptions["sub2"]; ok {
+		t.Errorf("subscription sub2 not deleted")
+	}
+	if sc, ok := a.Config.Subscriptions["sub3"]; !ok || sc.Paths[0] != "/network-instance" {
+		t.Errorf("subscription sub3 not added: %+v", sc)
+	}
+	if a.Config.Subscriptions["sub1"].SampleInterval.String() != "20s" {
+		t.Errorf("subscription sub1 not updated: %+v", a.Config.Subscriptions["sub1"])
+	}
+	cond := a.Config.Processors["p1"]["event-drop"].(map[string]interface{})["condition"]
+	if cond != "true" {
+		t.Errorf("processor p1 not updated: %v", a.Config.Processors["p1"])
+	}
+
+	// reloading the same file is a no-op
+	rr, err = a.ReloadConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, rc := range map[string]*reloadChanges{
+		"targets": rr.Targets, "subscriptions": rr.Subscriptions,
+		"outputs": rr.Outputs, "processors": rr.Processors,
+	} {
+		if !rc.empty() {
+			t.Errorf("unexpected %s changes on second reload: %+v", name, rc)
+		}
+	}
+}
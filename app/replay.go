@@ -0,0 +1,210 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/record"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	replayInputFormatJSON  = "json"
+	replayInputFormatProto = "proto"
+
+	defaultReplaySource           = "replay"
+	defaultReplaySubscriptionName = "replay"
+)
+
+func (a *App) ReplayPreRunE(cmd *cobra.Command, args []string) error {
+	a.Config.SetLocalFlagsFromFile(cmd)
+	a.Config.LocalFlags.ReplayOutput = config.SanitizeArrayFlagValue(a.Config.LocalFlags.ReplayOutput)
+	switch a.Config.LocalFlags.ReplayInputFormat {
+	case replayInputFormatJSON, replayInputFormatProto:
+	default:
+		return fmt.Errorf("unknown input format %q, must be one of %q",
+			a.Config.LocalFlags.ReplayInputFormat,
+			[]string{replayInputFormatJSON, replayInputFormatProto})
+	}
+	if a.Config.LocalFlags.ReplaySpeed < 0 {
+		return errors.New("speed must be a positive number")
+	}
+	return nil
+}
+
+func (a *App) ReplayRunE(cmd *cobra.Command, args []string) error {
+	defer a.InitReplayFlags(cmd)
+
+	rsps, err := a.readReplayInput()
+	if err != nil {
+		return err
+	}
+	if len(rsps) == 0 {
+		return errors.New("no subscribe responses found in input")
+	}
+	_, err = a.Config.GetOutputs()
+	if err != nil {
+		return fmt.Errorf("failed reading outputs config: %v", err)
+	}
+	_, err = a.Config.GetActions()
+	if err != nil {
+		return fmt.Errorf("failed reading actions config: %v", err)
+	}
+	_, err = a.Config.GetEventProcessors()
+	if err != nil {
+		return fmt.Errorf("failed reading event processors config: %v", err)
+	}
+	err = a.Config.GetGNMIServer()
+	if err != nil {
+		return err
+	}
+	if len(a.Config.Outputs) == 0 && a.Config.GnmiServer == nil {
+		return errors.New("no outputs or gnmi-server configured")
+	}
+	for _, name := range a.Config.LocalFlags.ReplayOutput {
+		if _, ok := a.Config.Outputs[name]; !ok {
+			return fmt.Errorf("unknown output %q", name)
+		}
+	}
+	a.InitOutputs(a.ctx)
+	defer func() {
+		for _, o := range a.Outputs {
+			o.Close()
+		}
+	}()
+	a.startGnmiServer()
+
+	for {
+		err = a.replay(a.ctx, rsps)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !a.Config.LocalFlags.ReplayLoop {
+			break
+		}
+	}
+	a.Logger.Printf("replayed %d subscribe responses", len(rsps))
+	if a.Config.GnmiServer != nil {
+		// keep serving the replayed data until interrupted
+		a.Logger.Printf("gNMI server listening on %s", a.Config.GnmiServer.Address)
+		<-a.ctx.Done()
+	}
+	return nil
+}
+
+func (a *App) InitReplayFlags(cmd *cobra.Command) {
+	cmd.ResetFlags()
+
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.ReplayInput, "input", "", "", "path to a file containing the recorded subscribe responses, defaults to stdin")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.ReplayInputFormat, "input-format", "", replayInputFormatJSON,
+		fmt.Sprintf("input messages format, one of %q", []string{replayInputFormatJSON, replayInputFormatProto}))
+	cmd.Flags().Float64VarP(&a.Config.LocalFlags.ReplaySpeed, "speed", "", 1,
+		"replay speed factor applied to the recorded timing, 0 replays the responses without delay")
+	cmd.Flags().StringSliceVarP(&a.Config.LocalFlags.ReplayOutput, "output", "", []string{}, "output names to replay to, defaults to all configured outputs")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.ReplaySource, "source", "", defaultReplaySource, "source name set on responses without a prefix target")
+	cmd.Flags().BoolVarP(&a.Config.LocalFlags.ReplayLoop, "loop", "", false, "replay the responses in a loop until interrupted")
+
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
+	})
+}
+
+func (a *App) readReplayInput() ([]*gnmi.SubscribeResponse, error) {
+	var r io.Reader
+	switch a.Config.LocalFlags.ReplayInput {
+	case "", "-":
+		r = os.Stdin
+	default:
+		f, err := os.Open(a.Config.LocalFlags.ReplayInput)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	if a.Config.LocalFlags.ReplayInputFormat == replayInputFormatProto {
+		return readRecordedResponses(r)
+	}
+	return readSubscribeResponses(r)
+}
+
+// readRecordedResponses reads length prefixed SubscribeResponses,
+// optionally zstd compressed, as written by the file output with format proto.
+func readRecordedResponses(r io.Reader) ([]*gnmi.SubscribeResponse, error) {
+	rr, err := record.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer rr.Close()
+	rsps := make([]*gnmi.SubscribeResponse, 0)
+	for {
+		rsp, err := rr.Read()
+		if errors.Is(err, io.EOF) {
+			return rsps, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read subscribe response %d: %v", len(rsps)+1, err)
+		}
+		rsps = append(rsps, rsp)
+	}
+}
+
+// replay exports the responses rsps, waiting between two consecutive responses
+// the difference between their timestamps divided by the replay speed.
+func (a *App) replay(ctx context.Context, rsps []*gnmi.SubscribeResponse) error {
+	var prevTs int64
+	for _, rsp := range rsps {
+		ts := rsp.GetUpdate().GetTimestamp()
+		if d := replayDelay(prevTs, ts, a.Config.LocalFlags.ReplaySpeed); d > 0 {
+			timer := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if ts > 0 {
+			prevTs = ts
+		}
+		source := rsp.GetUpdate().GetPrefix().GetTarget()
+		if source == "" {
+			source = a.Config.LocalFlags.ReplaySource
+		}
+		m := outputs.Meta{
+			"source":            source,
+			"format":            a.Config.Format,
+			"subscription-name": defaultReplaySubscriptionName,
+		}
+		a.Export(ctx, rsp, m, a.Config.LocalFlags.ReplayOutput...)
+	}
+	return ctx.Err()
+}
+
+// replayDelay returns the time to wait before sending a response with timestamp ts,
+// following a response with timestamp prevTs.
+func replayDelay(prevTs, ts int64, speed float64) time.Duration {
+	if speed <= 0 || prevTs <= 0 || ts <= prevTs {
+		return 0
+	}
+	return time.Duration(float64(ts-prevTs) / speed)
+}
@@ -0,0 +1,66 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReplayDelay(t *testing.T) {
+	tests := map[string]struct {
+		prevTs, ts int64
+		speed      float64
+		want       time.Duration
+	}{
+		"first_response": {
+			ts:    int64(time.Second),
+			speed: 1,
+		},
+		"original_timing": {
+			prevTs: int64(time.Second),
+			ts:     int64(3 * time.Second),
+			speed:  1,
+			want:   2 * time.Second,
+		},
+		"accelerated": {
+			prevTs: int64(time.Second),
+			ts:     int64(3 * time.Second),
+			speed:  4,
+			want:   500 * time.Millisecond,
+		},
+		"slowed_down": {
+			prevTs: int64(time.Second),
+			ts:     int64(2 * time.Second),
+			speed:  0.5,
+			want:   2 * time.Second,
+		},
+		"no_delay": {
+			prevTs: int64(time.Second),
+			ts:     int64(3 * time.Second),
+		},
+		"out_of_order": {
+			prevTs: int64(3 * time.Second),
+			ts:     int64(time.Second),
+			speed:  1,
+		},
+		"no_timestamp": {
+			prevTs: int64(time.Second),
+			speed:  1,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := replayDelay(tc.prevTs, tc.ts, tc.speed)
+			if got != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
@@ -11,51 +11,249 @@ package app
 import (
 	"net/http"
 
-	"github.com/gorilla/mux"
+	"github.com/openconfig/gnmic/backup"
+	"github.com/openconfig/gnmic/config"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/target"
+	"github.com/openconfig/gnmic/types"
 )
 
+// apiRoute describes an API endpoint.
+// Its metadata is used to build the OpenAPI document.
+type apiRoute struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+	tag     string
+	summary string
+	// query parameters name to description
+	query map[string]string
+	// values of the request and response body types,
+	// a nil response is documented as a free form object.
+	request  interface{}
+	response interface{}
+	// response content type, defaults to application/json
+	produces string
+	// success status code, defaults to 200
+	status int
+	// the route handler filters the resources by namespace,
+	// the other routes are not accessible to the namespace scoped clients.
+	namespaced bool
+}
+
+// apiVersion is a set of routes served under /api/<name>.
+// Breaking changes are introduced in a new version,
+// leaving the routes of the previous ones untouched.
+type apiVersion struct {
+	name   string
+	routes []*apiRoute
+}
+
+func (a *App) apiVersions() []*apiVersion {
+	v1 := a.apiV1Routes()
+	return []*apiVersion{
+		{name: "v1", routes: v1},
+		// v2 starts as a copy of v1
+		{name: "v2", routes: v1},
+	}
+}
+
+func (a *App) apiV1Routes() []*apiRoute {
+	routes := make([]*apiRoute, 0)
+	routes = append(routes, a.clusterRoutes()...)
+	routes = append(routes, a.configRoutes()...)
+	routes = append(routes, a.targetRoutes()...)
+	routes = append(routes, a.targetGroupRoutes()...)
+	routes = append(routes, a.subscriptionRoutes()...)
+	routes = append(routes, a.streamRoutes()...)
+	routes = append(routes, a.stateRoutes()...)
+	routes = append(routes, a.backupRoutes()...)
+	routes = append(routes, a.statusRoutes()...)
+	return routes
+}
+
 func (a *App) routes() {
-	apiV1 := a.router.PathPrefix("/api/v1").Subrouter()
-	a.clusterRoutes(apiV1)
-	a.configRoutes(apiV1)
-	a.targetRoutes(apiV1)
-
-}
-
-func (a *App) clusterRoutes(r *mux.Router) {
-	r.HandleFunc("/cluster", a.handleClusteringGet).Methods(http.MethodGet)
-	r.HandleFunc("/cluster/members", a.handleClusteringMembersGet).Methods(http.MethodGet)
-	r.HandleFunc("/cluster/leader", a.handleClusteringLeaderGet).Methods(http.MethodGet)
-}
-
-func (a *App) configRoutes(r *mux.Router) {
-	// config
-	r.HandleFunc("/config", a.handleConfig).Methods(http.MethodGet)
-	// config/targets
-	r.HandleFunc("/config/targets", a.handleConfigTargetsGet).Methods(http.MethodGet)
-	r.HandleFunc("/config/targets/{id}", a.handleConfigTargetsGet).Methods(http.MethodGet)
-	r.HandleFunc("/config/targets", a.handleConfigTargetsPost).Methods(http.MethodPost)
-	r.HandleFunc("/config/targets/{id}", a.handleConfigTargetsDelete).Methods(http.MethodDelete)
-	// config/subscriptions
-	r.HandleFunc("/config/subscriptions", a.handleConfigSubscriptions).Methods(http.MethodGet)
-	// config/outputs
-	r.HandleFunc("/config/outputs", a.handleConfigOutputs).Methods(http.MethodGet)
-	// config/inputs
-	r.HandleFunc("/config/inputs", a.handleConfigInputs).Methods(http.MethodGet)
-	// config/processors
-	r.HandleFunc("/config/processors", a.handleConfigProcessors).Methods(http.MethodGet)
-	// config/clustering
-	r.HandleFunc("/config/clustering", a.handleConfigClustering).Methods(http.MethodGet)
-	// config/api-server
-	r.HandleFunc("/config/api-server", a.handleConfigAPIServer).Methods(http.MethodGet)
-	// config/gnmi-server
-	r.HandleFunc("/config/gnmi-server", a.handleConfigGNMIServer).Methods(http.MethodGet)
-}
-
-func (a *App) targetRoutes(r *mux.Router) {
-	// targets
-	r.HandleFunc("/targets", a.handleTargetsGet).Methods(http.MethodGet)
-	r.HandleFunc("/targets/{id}", a.handleTargetsGet).Methods(http.MethodGet)
-	r.HandleFunc("/targets/{id}", a.handleTargetsPost).Methods(http.MethodPost)
-	r.HandleFunc("/targets/{id}", a.handleTargetsDelete).Methods(http.MethodDelete)
+	if a.Config.APIServer != nil {
+		a.apiAuth = newAPIAuthenticator(a.Config.APIServer.Auth)
+	}
+	for _, v := range a.apiVersions() {
+		prefix := "/api/" + v.name
+		// the OpenAPI document is served without authentication
+		a.router.HandleFunc(prefix+"/openapi.json", a.handleOpenAPI(v)).Methods(http.MethodGet)
+		sr := a.router.PathPrefix(prefix).Subrouter()
+		if a.apiAuth != nil {
+			sr.Use(a.authMiddleware)
+		}
+		sr.Use(a.auditMiddleware)
+		for _, rt := range v.routes {
+			h := rt.handler
+			if !rt.namespaced {
+				h = unscopedOnly(h)
+			}
+			sr.HandleFunc(rt.path, h).Methods(rt.method)
+		}
+	}
+}
+
+func (a *App) clusterRoutes() []*apiRoute {
+	return []*apiRoute{
+		{method: http.MethodGet, path: "/cluster", handler: a.handleClusteringGet,
+			tag: "cluster", summary: "Get the cluster state", response: clusteringResponse{}},
+		{method: http.MethodGet, path: "/cluster/members", handler: a.handleClusteringMembersGet,
+			tag: "cluster", summary: "List the cluster members", response: []clusterMember{}},
+		{method: http.MethodGet, path: "/cluster/leader", handler: a.handleClusteringLeaderGet,
+			tag: "cluster", summary: "Get the cluster leader", response: []clusterMember{}},
+		{method: http.MethodGet, path: "/cluster/assignment", handler: a.handleClusteringAssignmentGet,
+			tag: "cluster", summary: "List the targets served by each cluster instance", response: []*instanceAssignment{}},
+		{method: http.MethodGet, path: "/cluster/prometheus/sd", handler: a.handleClusteringPrometheusSDGet,
+			tag: "cluster", summary: "Get the prometheus outputs scrape endpoints in the HTTP service discovery format", response: []*promSDTargetGroup{}},
+	}
+}
+
+func (a *App) configRoutes() []*apiRoute {
+	return []*apiRoute{
+		// config
+		{method: http.MethodGet, path: "/config", handler: a.handleConfig,
+			tag: "config", summary: "Get the full configuration"},
+		{method: http.MethodPost, path: "/config/reload", handler: a.handleConfigReload,
+			tag: "config", summary: "Reload the configuration file", response: reloadResult{}},
+		// config/targets
+		{method: http.MethodGet, path: "/config/targets", handler: a.handleConfigTargetsGet,
+			tag: "config", summary: "List the targets configuration", response: map[string]*types.TargetConfig{},
+			namespaced: true},
+		{method: http.MethodGet, path: "/config/targets/{id}", handler: a.handleConfigTargetsGet,
+			tag: "config", summary: "Get a target configuration", response: types.TargetConfig{},
+			namespaced: true},
+		{method: http.MethodPost, path: "/config/targets", handler: a.handleConfigTargetsPost,
+			tag: "config", summary: "Add a target configuration", request: types.TargetConfig{},
+			namespaced: true},
+		{method: http.MethodDelete, path: "/config/targets/{id}", handler: a.handleConfigTargetsDelete,
+			tag: "config", summary: "Delete a target configuration",
+			namespaced: true},
+		// config/subscriptions
+		{method: http.MethodGet, path: "/config/subscriptions", handler: a.handleConfigSubscriptions,
+			tag: "config", summary: "List the subscriptions configuration", response: map[string]*types.SubscriptionConfig{},
+			namespaced: true},
+		// config/outputs
+		{method: http.MethodGet, path: "/config/outputs", handler: a.handleConfigOutputs,
+			tag: "config", summary: "List the outputs configuration", response: map[string]map[string]interface{}{}},
+		// config/inputs
+		{method: http.MethodGet, path: "/config/inputs", handler: a.handleConfigInputs,
+			tag: "config", summary: "List the inputs configuration", response: map[string]map[string]interface{}{}},
+		// config/processors
+		{method: http.MethodGet, path: "/config/processors", handler: a.handleConfigProcessors,
+			tag: "config", summary: "List the processors configuration", response: map[string]map[string]interface{}{}},
+		// config/clustering
+		{method: http.MethodGet, path: "/config/clustering", handler: a.handleConfigClustering,
+			tag: "config", summary: "Get the clustering configuration"},
+		// config/api-server
+		{method: http.MethodGet, path: "/config/api-server", handler: a.handleConfigAPIServer,
+			tag: "config", summary: "Get the API server configuration", response: config.APIServer{}},
+		// config/gnmi-server
+		{method: http.MethodGet, path: "/config/gnmi-server", handler: a.handleConfigGNMIServer,
+			tag: "config", summary: "Get the gNMI server configuration"},
+	}
+}
+
+func (a *App) targetRoutes() []*apiRoute {
+	proxyQuery := map[string]string{"format": "response format: json (default), protojson or event"}
+	return []*apiRoute{
+		// targets
+		{method: http.MethodGet, path: "/targets", handler: a.handleTargetsGet,
+			tag: "targets", summary: "List the running targets", response: map[string]*target.Target{},
+			namespaced: true},
+		{method: http.MethodGet, path: "/targets/{id}", handler: a.handleTargetsGet,
+			tag: "targets", summary: "Get a running target", response: target.Target{},
+			namespaced: true},
+		{method: http.MethodPost, path: "/targets/{id}", handler: a.handleTargetsPost,
+			tag: "targets", summary: "Start a target subscriptions",
+			namespaced: true},
+		{method: http.MethodDelete, path: "/targets/{id}", handler: a.handleTargetsDelete,
+			tag: "targets", summary: "Stop a target subscriptions",
+			namespaced: true},
+		{method: http.MethodGet, path: "/targets/{id}/capabilities", handler: a.handleTargetsCapabilitiesGet,
+			tag: "targets", summary: "Get a target last capabilities probe", response: target.CapabilitiesProbe{},
+			namespaced: true},
+		{method: http.MethodGet, path: "/targets/{id}/health", handler: a.handleTargetsHealthGet,
+			tag: "targets", summary: "Get a target health", response: target.Health{},
+			namespaced: true},
+		{method: http.MethodGet, path: "/targets/{id}/subscriptions", handler: a.handleTargetsSubscriptionsGet,
+			tag: "targets", summary: "List a target subscriptions state", response: []*targetSubscriptionState{},
+			namespaced: true},
+		{method: http.MethodPost, path: "/targets/{id}/subscriptions/{name}/pause", handler: a.handleTargetsSubscriptionPause,
+			tag: "targets", summary: "Pause a target subscription", response: targetSubscriptionState{},
+			namespaced: true},
+		{method: http.MethodPost, path: "/targets/{id}/subscriptions/{name}/resume", handler: a.handleTargetsSubscriptionResume,
+			tag: "targets", summary: "Resume a paused target subscription", response: targetSubscriptionState{},
+			namespaced: true},
+		{method: http.MethodPost, path: "/targets/{id}/get", handler: a.handleTargetsGetRequest,
+			tag: "targets", summary: "Send a gNMI Get request to a target", query: proxyQuery, request: config.ApplyGet{},
+			namespaced: true},
+		{method: http.MethodPost, path: "/targets/{id}/set", handler: a.handleTargetsSetRequest,
+			tag: "targets", summary: "Send a gNMI Set request to a target", query: proxyQuery, request: config.SetRequestFile{},
+			namespaced: true},
+	}
+}
+
+func (a *App) subscriptionRoutes() []*apiRoute {
+	return []*apiRoute{
+		// subscriptions
+		{method: http.MethodGet, path: "/subscriptions", handler: a.handleSubscriptionsGet,
+			tag: "subscriptions", summary: "List the subscriptions", response: []*subscriptionResponse{},
+			namespaced: true},
+		{method: http.MethodGet, path: "/subscriptions/{id}", handler: a.handleSubscriptionsGet,
+			tag: "subscriptions", summary: "Get a subscription", response: subscriptionResponse{},
+			namespaced: true},
+		{method: http.MethodPost, path: "/subscriptions", handler: a.handleSubscriptionsPost,
+			tag: "subscriptions", summary: "Add a subscription", request: types.SubscriptionConfig{}, response: subscriptionResponse{},
+			status: http.StatusCreated, namespaced: true},
+		{method: http.MethodPut, path: "/subscriptions/{id}", handler: a.handleSubscriptionsPut,
+			tag: "subscriptions", summary: "Update a subscription", request: types.SubscriptionConfig{}, response: subscriptionResponse{},
+			namespaced: true},
+		{method: http.MethodDelete, path: "/subscriptions/{id}", handler: a.handleSubscriptionsDelete,
+			tag: "subscriptions", summary: "Delete a subscription",
+			namespaced: true},
+	}
+}
+
+func (a *App) streamRoutes() []*apiRoute {
+	return []*apiRoute{
+		{method: http.MethodGet, path: "/stream", handler: a.handleStream,
+			tag: "stream", summary: "Stream the received events as server-sent events",
+			query: map[string]string{
+				"target":       "only stream events from this target",
+				"subscription": "only stream events from this subscription",
+				"expression":   "CEL expression the events must match",
+			},
+			produces: "text/event-stream", namespaced: true},
+	}
+}
+
+func (a *App) stateRoutes() []*apiRoute {
+	return []*apiRoute{
+		{method: http.MethodGet, path: "/state", handler: a.handleStateGet,
+			tag: "state", summary: "Get the latest values of a target from the gNMI server cache",
+			query: map[string]string{
+				"target":       "target name, required",
+				"path":         "path of the values, can be repeated, defaults to all the target values",
+				"subscription": "only return the values of this subscription",
+			},
+			response: []*formatters.EventMsg{}, namespaced: true},
+	}
+}
+
+func (a *App) backupRoutes() []*apiRoute {
+	return []*apiRoute{
+		{method: http.MethodGet, path: "/backup/targets/{id}/snapshots", handler: a.handleBackupSnapshotsGet,
+			tag: "backup", summary: "List a target configuration snapshots", response: []*backup.Snapshot{}},
+		{method: http.MethodGet, path: "/backup/targets/{id}/snapshots/{snapshot}", handler: a.handleBackupSnapshotGet,
+			tag: "backup", summary: "Get a target configuration snapshot"},
+		{method: http.MethodGet, path: "/backup/targets/{id}/diff", handler: a.handleBackupDiffGet,
+			tag: "backup", summary: "Get the changes between two snapshots",
+			query: map[string]string{
+				"from": "snapshot ID, defaults to the second most recent snapshot",
+				"to":   "snapshot ID, defaults to the most recent snapshot",
+			},
+			response: backupDiff{}},
+	}
 }
@@ -0,0 +1,50 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/openconfig/gnmic/target"
+	"google.golang.org/grpc/status"
+)
+
+// retryRPC calls rpc until it succeeds or fails with an error the target rpc-retry
+// configuration does not retry on, up to the configured number of retries.
+// If notSent is set, the RPC is retried only if it returns true after the failed attempt.
+// Each attempt waits for the RPC rate limits.
+func (a *App) retryRPC(ctx context.Context, t *target.Target, name string, notSent func() bool, rpc func(ctx context.Context) error) error {
+	rr := t.Config.RPCRetry
+	for attempt := 1; ; attempt++ {
+		err := a.waitRPC(ctx, t)
+		if err != nil {
+			return err
+		}
+		err = rpc(ctx)
+		if err == nil || rr == nil || attempt > rr.Count {
+			return err
+		}
+		st, ok := status.FromError(err)
+		if !ok || !rr.RetriesOn(st.Code()) {
+			return err
+		}
+		if notSent != nil && !notSent() {
+			a.Logger.Printf("target %q: %s RPC failed after being sent, not retried: %v", t.Config.Name, name, err)
+			return err
+		}
+		d := rr.Delay(attempt)
+		a.Logger.Printf("target %q: %s RPC failed: %v, retry %d/%d in %s", t.Config.Name, name, err, attempt, rr.Count, d)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(d):
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/target"
+	"github.com/openconfig/gnmic/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flakyGNMIServer fails the first Get and Set RPCs it receives with code Unavailable.
+type flakyGNMIServer struct {
+	gnmi.UnimplementedGNMIServer
+
+	m     sync.Mutex
+	fails int
+	gets  int
+	sets  int
+}
+
+func (s *flakyGNMIServer) Get(context.Context, *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.gets++
+	if s.gets <= s.fails {
+		return nil, status.Error(codes.Unavailable, "management plane busy")
+	}
+	return &gnmi.GetResponse{}, nil
+}
+
+func (s *flakyGNMIServer) Set(context.Context, *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.sets++
+	if s.sets <= s.fails {
+		return nil, status.Error(codes.Unavailable, "management plane busy")
+	}
+	return &gnmi.SetResponse{}, nil
+}
+
+func startFlakyTestServer(t *testing.T, fails int) (*flakyGNMIServer, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := &flakyGNMIServer{fails: fails}
+	srv := grpc.NewServer()
+	gnmi.RegisterGNMIServer(srv, gs)
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+	return gs, l.Addr().String()
+}
+
+func retryTestTarget(addr string, retries int) *types.TargetConfig {
+	insecure := true
+	tc := &types.TargetConfig{
+		Name:     "t1",
+		Address:  addr,
+		Insecure: &insecure,
+		Timeout:  5 * time.Second,
+		RPCRetry: &types.RPCRetry{Count: retries, Backoff: time.Millisecond},
+	}
+	tc.RPCRetry.Validate()
+	return tc
+}
+
+func TestClientGetRetry(t *testing.T) {
+	gs, addr := startFlakyTestServer(t, 2)
+	a := New()
+	defer a.Cfn()
+	a.Config.Log = true
+	_, err := a.ClientGet(context.Background(), retryTestTarget(addr, 2), &gnmi.GetRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs.m.Lock()
+	defer gs.m.Unlock()
+	if gs.gets != 3 {
+		t.Errorf("expected 3 Get RPCs, got %d", gs.gets)
+	}
+}
+
+func TestClientGetRetryExhausted(t *testing.T) {
+	gs, addr := startFlakyTestServer(t, 3)
+	a := New()
+	defer a.Cfn()
+	a.Config.Log = true
+	_, err := a.ClientGet(context.Background(), retryTestTarget(addr, 1), &gnmi.GetRequest{})
+	if errorClass(err) != "unavailable" {
+		t.Fatalf("expected an unavailable error, got %v", err)
+	}
+	gs.m.Lock()
+	defer gs.m.Unlock()
+	if gs.gets != 2 {
+		t.Errorf("expected 2 Get RPCs, got %d", gs.gets)
+	}
+}
+
+func TestClientSetNotRetriedOnceSent(t *testing.T) {
+	gs, addr := startFlakyTestServer(t, 1)
+	a := New()
+	defer a.Cfn()
+	a.Config.Log = true
+	_, err := a.ClientSet(context.Background(), retryTestTarget(addr, 3), &gnmi.SetRequest{})
+	if err == nil {
+		t.Fatal("expected the Set RPC to fail")
+	}
+	gs.m.Lock()
+	defer gs.m.Unlock()
+	if gs.sets != 1 {
+		t.Errorf("expected a single Set RPC, got %d", gs.sets)
+	}
+}
+
+func TestRetryRPCNotSent(t *testing.T) {
+	a := New()
+	defer a.Cfn()
+	t1 := target.NewTarget(retryTestTarget("", 2))
+	for _, sent := range []bool{false, true} {
+		calls := 0
+		err := a.retryRPC(context.Background(), t1, "Set", func() bool { return !sent }, func(context.Context) error {
+			calls++
+			return status.Error(codes.Unavailable, "connection refused")
+		})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		exp := 3
+		if sent {
+			exp = 1
+		}
+		if calls != exp {
+			t.Errorf("sent=%v: expected %d calls, got %d", sent, exp, calls)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"crypto/tls"
+	"errors"
+
+	"github.com/openconfig/gnmic/spiffe"
+	"github.com/openconfig/gnmic/utils"
+)
+
+// serverTLSConfig returns the TLS config of an embedded server,
+// based on the gnmic SVID if spiffeIDs is not empty, on the TLS files or ACME otherwise.
+// The TLS policy, if not nil, restricts the negotiated versions, cipher suites and curves.
+func (a *App) serverTLSConfig(ca, cert, key string, skipVerify bool, acme *utils.ACMEConfig, spiffeIDs []string, policy *utils.TLSPolicy) (*tls.Config, error) {
+	var tlsConfig *tls.Config
+	var err error
+	if len(spiffeIDs) == 0 {
+		tlsConfig, err = utils.NewServerTLSConfig(a.ctx, ca, cert, key, skipVerify, acme, a.Logger)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		src := spiffe.Default()
+		if src == nil {
+			return nil, errors.New("spiffe-ids is set but the spiffe workload API is not configured")
+		}
+		tlsConfig = src.ServerTLSConfig(spiffeIDs)
+	}
+	err = policy.Apply(tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return tlsConfig, nil
+}
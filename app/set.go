@@ -11,6 +11,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmic/config"
@@ -26,6 +27,18 @@ func (a *App) SetPreRunE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	// load the YANG models used to validate the set request values,
+	// in prompt mode the schema is already loaded.
+	if !a.PromptMode && !a.Config.LocalFlags.SetNoValidate && len(a.Config.GlobalFlags.File) > 0 {
+		err = a.yangFilesPreProcessing()
+		if err != nil {
+			return err
+		}
+		err = a.generateYangSchema(a.Config.GlobalFlags.Dir, a.Config.GlobalFlags.File, a.Config.GlobalFlags.Exclude)
+		if err != nil {
+			return fmt.Errorf("failed to load YANG models: %v", err)
+		}
+	}
 
 	a.createCollectorDialOpts()
 	return a.initTunnelServer(tunnel.ServerConfig{
@@ -58,6 +71,7 @@ func (a *App) SetRunE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed reading set request files: %v", err)
 	}
+	a.startRun(cmd.Name(), cancel)
 	numTargets := len(a.Config.Targets)
 	a.errCh = make(chan error, numTargets*2)
 	a.wg.Add(numTargets)
@@ -65,22 +79,40 @@ func (a *App) SetRunE(cmd *cobra.Command, args []string) error {
 		go a.SetRequest(ctx, tc)
 	}
 	a.wg.Wait()
-	return a.checkErrors()
+	return a.finishRun(a.checkErrors())
 }
 
 func (a *App) SetRequest(ctx context.Context, tc *types.TargetConfig) {
 	defer a.wg.Done()
 	reqs, err := a.Config.CreateSetRequest(tc.Name)
 	if err != nil {
+		a.recordResult(tc.Name, 0, err)
 		a.logError(fmt.Errorf("target %q: failed to create set request: %v", tc.Name, err))
 		return
 	}
+	if !a.Config.LocalFlags.SetNoValidate && a.SchemaTree != nil && len(a.SchemaTree.Dir) > 0 {
+		for _, req := range reqs {
+			err = validateSetRequest(a.SchemaTree, req)
+			if err != nil {
+				a.recordResult(tc.Name, 0, err)
+				a.logError(fmt.Errorf("target %q: invalid set request, not sent: %v", tc.Name, err))
+				return
+			}
+		}
+	}
+	// the target result is the first failed request one
+	var rerr error
+	start := time.Now()
 	for _, req := range reqs {
-		a.setRequest(ctx, tc, req)
+		err = a.setRequest(ctx, tc, req)
+		if err != nil && rerr == nil {
+			rerr = err
+		}
 	}
+	a.recordResult(tc.Name, time.Since(start), rerr)
 }
 
-func (a *App) setRequest(ctx context.Context, tc *types.TargetConfig, req *gnmi.SetRequest) {
+func (a *App) setRequest(ctx context.Context, tc *types.TargetConfig, req *gnmi.SetRequest) error {
 	a.Logger.Printf("sending gNMI SetRequest: prefix='%v', delete='%v', replace='%v', update='%v', extension='%v' to %s",
 		req.Prefix, req.Delete, req.Replace, req.Update, req.Extension, tc.Name)
 	if a.Config.PrintRequest || a.Config.SetDryRun {
@@ -90,17 +122,18 @@ func (a *App) setRequest(ctx context.Context, tc *types.TargetConfig, req *gnmi.
 		}
 	}
 	if a.Config.SetDryRun {
-		return
+		return nil
 	}
 	response, err := a.ClientSet(ctx, tc, req)
 	if err != nil {
 		a.logError(fmt.Errorf("target %q set request failed: %v", tc.Name, err))
-		return
+		return err
 	}
 	err = a.PrintMsg(tc.Name, "Set Response:", response)
 	if err != nil {
 		a.logError(fmt.Errorf("target %q: %v", tc.Name, err))
 	}
+	return nil
 }
 
 // InitSetFlags used to init or reset setCmd flags for gnmic-prompt mode
@@ -124,6 +157,7 @@ func (a *App) InitSetFlags(cmd *cobra.Command) {
 	cmd.Flags().StringArrayVarP(&a.Config.LocalFlags.SetRequestFile, "request-file", "", []string{}, "set request template file(s)")
 	cmd.Flags().StringVarP(&a.Config.LocalFlags.SetRequestVars, "request-vars", "", "", "set request variables file")
 	cmd.Flags().BoolVarP(&a.Config.LocalFlags.SetDryRun, "dry-run", "", false, "prints the set request without initiating a gRPC connection")
+	cmd.Flags().BoolVarP(&a.Config.LocalFlags.SetNoValidate, "no-validate", "", false, "do not validate the update and replace values against the YANG models loaded with --file")
 
 	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
 		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
@@ -0,0 +1,444 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+
+	"github.com/openconfig/gnmic/utils"
+)
+
+// maximum number of leafrefs followed to find a leaf type.
+const maxLeafrefDepth = 8
+
+var leafrefPredicate = regexp.MustCompile(`\[[^\]]*\]`)
+
+// setValidator checks the values of a SetRequest updates and replaces
+// against a YANG schema tree.
+type setValidator struct {
+	root *yang.Entry
+	errs []string
+}
+
+// validateSetRequest returns an error listing the update and replace values
+// of req that do not match the YANG schema root.
+func validateSetRequest(root *yang.Entry, req *gnmi.SetRequest) error {
+	v := &setValidator{root: root}
+	for _, upd := range req.GetReplace() {
+		v.validateUpdate(req.GetPrefix(), upd)
+	}
+	for _, upd := range req.GetUpdate() {
+		v.validateUpdate(req.GetPrefix(), upd)
+	}
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(v.errs, "; "))
+}
+
+func (v *setValidator) errorf(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (v *setValidator) validateUpdate(prefix *gnmi.Path, upd *gnmi.Update) {
+	elems := make([]*gnmi.PathElem, 0, len(prefix.GetElem())+len(upd.GetPath().GetElem()))
+	elems = append(elems, prefix.GetElem()...)
+	elems = append(elems, upd.GetPath().GetElem()...)
+	path := utils.GnmiPathToXPath(&gnmi.Path{Elem: elems}, false)
+	if path == "" {
+		path = "/"
+	}
+	val, err := typedValue(upd.GetVal())
+	if err != nil {
+		v.errorf(path, "%v", err)
+		return
+	}
+	if len(elems) == 0 {
+		v.validateRoot(path, val)
+		return
+	}
+	e := v.topLevel(elems[0].GetName())
+	if e == nil {
+		v.errorf(path, "unknown node %q", elems[0].GetName())
+		return
+	}
+	for _, pe := range elems[1:] {
+		ce := schemaChild(e, pe.GetName())
+		if ce == nil {
+			v.errorf(path, "unknown node %q", pe.GetName())
+			return
+		}
+		e = ce
+	}
+	if e.IsList() && len(elems[len(elems)-1].GetKey()) > 0 {
+		// the value is a single list entry
+		v.validateContainer(path, e, val)
+		return
+	}
+	v.validateNode(path, e, val)
+}
+
+// topLevel returns the schema node of a top level path element name,
+// optionally prefixed with its module name.
+func (v *setValidator) topLevel(name string) *yang.Entry {
+	return schemaTopLevel(v.root, name)
+}
+
+func (v *setValidator) validateRoot(path string, val interface{}) {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		v.errorf(path, "expected a JSON object, got %T", val)
+		return
+	}
+	for _, k := range sortedKeys(m) {
+		e := v.topLevel(k)
+		if e == nil {
+			v.errorf(path, "unknown node %q", k)
+			continue
+		}
+		v.validateNode(path+stripModule(k), e, m[k])
+	}
+}
+
+// validateNode validates the value val of the schema node e.
+func (v *setValidator) validateNode(path string, e *yang.Entry, val interface{}) {
+	if e.ReadOnly() {
+		v.errorf(path, "node is read-only")
+		return
+	}
+	switch {
+	case e.IsList():
+		items, ok := val.([]interface{})
+		if !ok {
+			// a single list entry
+			v.validateContainer(path, e, val)
+			return
+		}
+		for _, item := range items {
+			v.validateContainer(path, e, item)
+		}
+	case e.IsDir():
+		v.validateContainer(path, e, val)
+	case e.IsLeafList():
+		items, ok := val.([]interface{})
+		if !ok {
+			items = []interface{}{val}
+		}
+		for _, item := range items {
+			v.validateLeaf(path, e, item)
+		}
+	default:
+		v.validateLeaf(path, e, val)
+	}
+}
+
+func (v *setValidator) validateContainer(path string, e *yang.Entry, val interface{}) {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		v.errorf(path, "expected a JSON object, got %T", val)
+		return
+	}
+	if e.IsList() {
+		for _, k := range strings.Fields(e.Key) {
+			if !hasMember(m, k) {
+				v.errorf(path, "list entry missing key %q", k)
+			}
+		}
+	}
+	for _, k := range sortedKeys(m) {
+		ce := schemaChild(e, k)
+		if ce == nil {
+			v.errorf(path, "unknown node %q", k)
+			continue
+		}
+		v.validateNode(path+"/"+stripModule(k), ce, m[k])
+	}
+}
+
+func (v *setValidator) validateLeaf(path string, e *yang.Entry, val interface{}) {
+	if e.Type == nil {
+		return
+	}
+	if err := v.checkType(e, e.Type, val, 0); err != nil {
+		v.errorf(path, "%v", err)
+	}
+}
+
+// checkType returns an error if val is not a valid value of type t,
+// e is the leaf the type belongs to, used to resolve leafrefs.
+func (v *setValidator) checkType(e *yang.Entry, t *yang.YangType, val interface{}, depth int) error {
+	switch t.Kind {
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yint64,
+		yang.Yuint8, yang.Yuint16, yang.Yuint32, yang.Yuint64:
+		s, ok := numberString(val)
+		if !ok {
+			return fmt.Errorf("expected a %s value, got %v", t.Kind, val)
+		}
+		n, err := yang.ParseInt(s)
+		if err != nil {
+			return fmt.Errorf("invalid %s value %q", t.Kind, s)
+		}
+		if !inRange(t.Range, n) {
+			return fmt.Errorf("value %s out of range %s", s, t.Range)
+		}
+	case yang.Ydecimal64:
+		s, ok := numberString(val)
+		if !ok {
+			return fmt.Errorf("expected a decimal64 value, got %v", val)
+		}
+		n, err := yang.ParseDecimal(s, uint8(t.FractionDigits))
+		if err != nil {
+			return fmt.Errorf("invalid decimal64 value %q: %v", s, err)
+		}
+		if !inRange(t.Range, n) {
+			return fmt.Errorf("value %s out of range %s", s, t.Range)
+		}
+	case yang.Ystring:
+		s, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("expected a string value, got %v", val)
+		}
+		if !inRange(t.Length, yang.FromInt(int64(len([]rune(s))))) {
+			return fmt.Errorf("length of %q out of range %s", s, t.Length)
+		}
+		for _, p := range t.Pattern {
+			re, err := regexp.Compile("^(?:" + p + ")$")
+			if err != nil {
+				// not a Go compatible expression
+				continue
+			}
+			if !re.MatchString(s) {
+				return fmt.Errorf("value %q does not match pattern %q", s, p)
+			}
+		}
+	case yang.Ybool:
+		switch val := val.(type) {
+		case bool:
+		case string:
+			if val != "true" && val != "false" {
+				return fmt.Errorf("expected a boolean value, got %q", val)
+			}
+		default:
+			return fmt.Errorf("expected a boolean value, got %v", val)
+		}
+	case yang.Yenum:
+		s, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("expected an enumeration value, got %v", val)
+		}
+		if t.Enum != nil && !t.Enum.IsDefined(s) {
+			return fmt.Errorf("unknown enumeration value %q, must be one of %q", s, t.Enum.Names())
+		}
+	case yang.Yidentityref:
+		s, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("expected an identityref value, got %v", val)
+		}
+		if t.IdentityBase == nil {
+			return nil
+		}
+		name := stripModule(s)
+		for _, id := range t.IdentityBase.Values {
+			if id.Name == name {
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown identity %q, must be derived from %q", s, t.IdentityBase.Name)
+	case yang.Yempty:
+		switch val := val.(type) {
+		case nil:
+		case []interface{}:
+			if len(val) != 1 || val[0] != nil {
+				return fmt.Errorf("expected an empty value, got %v", val)
+			}
+		default:
+			return fmt.Errorf("expected an empty value, got %v", val)
+		}
+	case yang.Yunion:
+		errs := make([]string, 0, len(t.Type))
+		for _, ut := range t.Type {
+			err := v.checkType(e, ut, val, depth)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("value %v does not match any of the union types: %s", val, strings.Join(errs, ", "))
+		}
+	case yang.Yleafref:
+		if depth >= maxLeafrefDepth {
+			return nil
+		}
+		ref := v.leafrefTarget(e, t.Path)
+		if ref == nil || ref.Type == nil {
+			// the referenced leaf is not in the loaded models
+			return nil
+		}
+		return v.checkType(ref, ref.Type, val, depth+1)
+	}
+	return nil
+}
+
+// leafrefTarget returns the schema node referenced by the leafref path p
+// of leaf e, nil if it is not found.
+func (v *setValidator) leafrefTarget(e *yang.Entry, p string) *yang.Entry {
+	p = leafrefPredicate.ReplaceAllString(p, "")
+	parts := strings.Split(strings.TrimSpace(p), "/")
+	if len(parts) == 0 {
+		return nil
+	}
+	if parts[0] == "" {
+		// absolute path
+		if len(parts) < 2 {
+			return nil
+		}
+		e = v.topLevel(stripModule(parts[1]))
+		parts = parts[2:]
+	}
+	for _, part := range parts {
+		if e == nil {
+			return nil
+		}
+		switch part {
+		case "", ".":
+		case "..":
+			e = e.Parent
+			// choice and case nodes are not part of the data tree
+			for e != nil && (e.IsChoice() || e.IsCase()) {
+				e = e.Parent
+			}
+		default:
+			e = schemaChild(e, part)
+		}
+	}
+	return e
+}
+
+// schemaTopLevel returns the top level schema node of root named name,
+// optionally prefixed with its module name.
+func schemaTopLevel(root *yang.Entry, name string) *yang.Entry {
+	if i := strings.Index(name, ":"); i >= 0 {
+		if mod := root.Dir[name[:i]]; mod != nil {
+			return schemaChild(mod, name[i+1:])
+		}
+		// not a module name, e.g. a module prefix
+		name = name[i+1:]
+	}
+	for _, mod := range root.Dir {
+		if e := schemaChild(mod, name); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// schemaChild returns the data child of e named name,
+// optionally prefixed with its module name.
+func schemaChild(e *yang.Entry, name string) *yang.Entry {
+	name = stripModule(name)
+	if name == "*" || name == "..." {
+		return nil
+	}
+	children := schemaChildren(e, name)
+	if len(children) == 0 {
+		return nil
+	}
+	return children[0]
+}
+
+// typedValue returns the value of tv as decoded from JSON,
+// numbers are returned as json.Number.
+func typedValue(tv *gnmi.TypedValue) (interface{}, error) {
+	switch tv := tv.GetValue().(type) {
+	case *gnmi.TypedValue_JsonVal:
+		return decodeJSONValue(tv.JsonVal)
+	case *gnmi.TypedValue_JsonIetfVal:
+		return decodeJSONValue(tv.JsonIetfVal)
+	case *gnmi.TypedValue_StringVal:
+		return tv.StringVal, nil
+	case *gnmi.TypedValue_AsciiVal:
+		return tv.AsciiVal, nil
+	case *gnmi.TypedValue_BoolVal:
+		return tv.BoolVal, nil
+	case *gnmi.TypedValue_IntVal:
+		return json.Number(strconv.FormatInt(tv.IntVal, 10)), nil
+	case *gnmi.TypedValue_UintVal:
+		return json.Number(strconv.FormatUint(tv.UintVal, 10)), nil
+	case *gnmi.TypedValue_FloatVal:
+		return json.Number(strconv.FormatFloat(float64(tv.FloatVal), 'f', -1, 32)), nil
+	case *gnmi.TypedValue_DoubleVal:
+		return json.Number(strconv.FormatFloat(tv.DoubleVal, 'f', -1, 64)), nil
+	case *gnmi.TypedValue_LeaflistVal:
+		items := make([]interface{}, 0, len(tv.LeaflistVal.GetElement()))
+		for _, el := range tv.LeaflistVal.GetElement() {
+			item, err := typedValue(el)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", tv.GetValue())
+}
+
+func decodeJSONValue(b []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid JSON value: %v", err)
+	}
+	return v, nil
+}
+
+// numberString returns the string representation of a numeric value,
+// 64-bit and decimal64 values are encoded as strings in JSON_IETF.
+func numberString(val interface{}) (string, bool) {
+	switch val := val.(type) {
+	case json.Number:
+		return val.String(), true
+	case string:
+		return val, true
+	}
+	return "", false
+}
+
+func inRange(r yang.YangRange, n yang.Number) bool {
+	return r.Contains(yang.YangRange{{Min: n, Max: n}})
+}
+
+func hasMember(m map[string]interface{}, name string) bool {
+	for k := range m {
+		if stripModule(k) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
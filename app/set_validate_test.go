@@ -0,0 +1,203 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+
+	"github.com/openconfig/gnmic/utils"
+)
+
+const testSetValidateModule = `
+module test-system {
+  namespace "urn:test:system";
+  prefix ts;
+
+  identity protocol;
+  identity tcp { base protocol; }
+  identity udp { base protocol; }
+
+  container system {
+    container config {
+      leaf hostname {
+        type string {
+          length "1..16";
+          pattern "[a-z][a-z0-9-]*";
+        }
+      }
+      leaf mtu {
+        type uint16 { range "68..9216"; }
+      }
+      leaf mode {
+        type enumeration {
+          enum active;
+          enum standby;
+        }
+      }
+      leaf ratio { type decimal64 { fraction-digits 2; } }
+      leaf enabled { type boolean; }
+      leaf-list dns { type string; }
+      choice transport {
+        leaf protocol { type identityref { base protocol; } }
+        leaf port { type union { type uint16; type enumeration { enum any; } } }
+      }
+    }
+    container state {
+      config false;
+      leaf uptime { type uint64; }
+    }
+    list server {
+      key name;
+      leaf name {
+        type leafref { path "../config/name"; }
+      }
+      container config {
+        leaf name { type string; }
+        leaf weight { type uint8 { range "1..10"; } }
+      }
+    }
+    leaf primary {
+      type leafref { path "/ts:system/ts:config/ts:mtu"; }
+    }
+  }
+}
+`
+
+func testSetValidateSchema(t *testing.T) *yang.Entry {
+	t.Helper()
+	ms := yang.NewModules()
+	err := ms.Parse(testSetValidateModule, "test-system.yang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := New().schemaTree(ms, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestValidateSetRequest(t *testing.T) {
+	root := testSetValidateSchema(t)
+	jsonVal := func(s string) *gnmi.TypedValue {
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: []byte(s)}}
+	}
+	tests := map[string]struct {
+		path  string
+		val   *gnmi.TypedValue
+		valid bool
+	}{
+		"container": {
+			path:  "/system/config",
+			val:   jsonVal(`{"hostname":"r1","mtu":1500,"mode":"active","ratio":"1.25","enabled":true,"dns":["ns1"],"protocol":"test-system:tcp"}`),
+			valid: true,
+		},
+		"root": {
+			path:  "/",
+			val:   jsonVal(`{"test-system:system":{"config":{"hostname":"r1"}}}`),
+			valid: true,
+		},
+		"leaf": {
+			path:  "/system/config/mtu",
+			val:   &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 9000}},
+			valid: true,
+		},
+		"out_of_range": {
+			path: "/system/config/mtu",
+			val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 10000}},
+		},
+		"wrong_type": {
+			path: "/system/config",
+			val:  jsonVal(`{"mtu":"large"}`),
+		},
+		"length": {
+			path: "/system/config/hostname",
+			val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "a-very-long-hostname"}},
+		},
+		"pattern": {
+			path: "/system/config/hostname",
+			val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "1router"}},
+		},
+		"enum": {
+			path: "/system/config/mode",
+			val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "passive"}},
+		},
+		"decimal_precision": {
+			path: "/system/config/ratio",
+			val:  jsonVal(`"1.255"`),
+		},
+		"identity": {
+			path: "/system/config/protocol",
+			val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "sctp"}},
+		},
+		"union": {
+			path:  "/system/config/port",
+			val:   &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "any"}},
+			valid: true,
+		},
+		"union_invalid": {
+			path: "/system/config/port",
+			val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "none"}},
+		},
+		"unknown_node": {
+			path: "/system/config",
+			val:  jsonVal(`{"domain":"example.com"}`),
+		},
+		"unknown_path": {
+			path: "/system/clock",
+			val:  jsonVal(`{}`),
+		},
+		"read_only": {
+			path: "/system/state",
+			val:  jsonVal(`{"uptime":"42"}`),
+		},
+		"list": {
+			path:  "/system/server",
+			val:   jsonVal(`[{"name":"s1","config":{"name":"s1","weight":5}}]`),
+			valid: true,
+		},
+		"list_entry": {
+			path:  "/system/server[name=s1]/config",
+			val:   jsonVal(`{"weight":5}`),
+			valid: true,
+		},
+		"list_missing_key": {
+			path: "/system/server",
+			val:  jsonVal(`[{"config":{"weight":5}}]`),
+		},
+		"leafref": {
+			path:  "/system/primary",
+			val:   jsonVal(`1500`),
+			valid: true,
+		},
+		"leafref_invalid": {
+			path: "/system/primary",
+			val:  jsonVal(`20`),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := utils.ParsePath(tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &gnmi.SetRequest{Update: []*gnmi.Update{{Path: p, Val: tc.val}}}
+			err = validateSetRequest(root, req)
+			if tc.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import "github.com/openconfig/gnmic/spiffe"
+
+// initSPIFFE connects to the SPIFFE Workload API if configured,
+// the obtained SVID is used by the targets and servers with SPIFFE IDs set.
+func (a *App) initSPIFFE() error {
+	err := a.Config.GetSPIFFE()
+	if err != nil {
+		return err
+	}
+	if a.Config.Spiffe == nil {
+		return nil
+	}
+	src, err := spiffe.NewSource(a.ctx, a.Config.Spiffe, a.Logger)
+	if err != nil {
+		return err
+	}
+	spiffe.SetDefault(src)
+	a.Logger.Printf("using SPIFFE ID %q from %q", src.SVID().ID, a.Config.Spiffe.SocketPath)
+	return nil
+}
@@ -0,0 +1,76 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/openconfig/gnmic/target"
+)
+
+// statusResponse aggregates the instance, cluster and targets state,
+// it is the data source of the web UI.
+type statusResponse struct {
+	Instance string              `json:"instance,omitempty"`
+	Version  string              `json:"version,omitempty"`
+	Cluster  *clusteringResponse `json:"cluster,omitempty"`
+	// set if the cluster state could not be retrieved
+	ClusterError string          `json:"cluster-error,omitempty"`
+	Targets      []*targetStatus `json:"targets"`
+}
+
+type targetStatus struct {
+	Name          string                      `json:"name,omitempty"`
+	Address       string                      `json:"address,omitempty"`
+	Health        *target.Health              `json:"health,omitempty"`
+	LastError     string                      `json:"last-error,omitempty"`
+	Subscriptions []*target.SubscriptionStats `json:"subscriptions"`
+}
+
+func (a *App) statusRoutes() []*apiRoute {
+	return []*apiRoute{
+		{method: http.MethodGet, path: "/status", handler: a.handleStatusGet,
+			tag: "status", summary: "Get the instance, cluster and targets status", response: statusResponse{}},
+	}
+}
+
+func (a *App) handleStatusGet(w http.ResponseWriter, r *http.Request) {
+	rsp := &statusResponse{
+		Instance: a.Config.InstanceName,
+		Version:  version,
+	}
+	if a.Config.Clustering != nil && a.locker != nil {
+		cl, err := a.clusteringState(r.Context())
+		if err != nil {
+			rsp.ClusterError = err.Error()
+		} else {
+			rsp.Cluster = cl
+		}
+	}
+	a.operLock.RLock()
+	rsp.Targets = make([]*targetStatus, 0, len(a.Targets))
+	for name, t := range a.Targets {
+		ts := &targetStatus{
+			Name:          name,
+			Health:        t.Health(),
+			LastError:     t.LastError(),
+			Subscriptions: t.SubscriptionStats(),
+		}
+		if t.Config != nil {
+			ts.Address = t.Config.Address
+		}
+		rsp.Targets = append(rsp.Targets, ts)
+	}
+	a.operLock.RUnlock()
+	sort.Slice(rsp.Targets, func(i, j int) bool {
+		return rsp.Targets[i].Name < rsp.Targets[j].Name
+	})
+	a.handlerCommonGet(w, r, rsp)
+}
@@ -37,13 +37,19 @@ func (a *App) SubscribePreRunE(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func (a *App) SubscribeRunE(cmd *cobra.Command, args []string) error {
+func (a *App) SubscribeRunE(cmd *cobra.Command, args []string) (err error) {
 	defer a.InitSubscribeFlags(cmd)
 
 	// prompt mode
 	if a.PromptMode {
 		return a.SubscribeRunPrompt(cmd, args)
 	}
+	// a subscribe stopped by --duration or --max-messages is not an error
+	defer func() {
+		if a.captureStopped() {
+			err = nil
+		}
+	}()
 	//
 	subCfg, err := a.Config.GetSubscriptions(cmd)
 	if err != nil {
@@ -54,6 +60,14 @@ func (a *App) SubscribeRunE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	a.updateFilter, err = newUpdateFilter(a.Config.LocalFlags.SubscribeFilter)
+	if err != nil {
+		return err
+	}
+	if a.Config.LocalFlags.SubscribeMaxMessages < 0 {
+		return errors.New("max-messages must be a positive number")
+	}
+	a.startCapture()
 	err = a.Config.GetClustering()
 	if err != nil {
 		return err
@@ -70,9 +84,40 @@ func (a *App) SubscribeRunE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	numInputs := len(a.Config.Inputs)
-	if len(subCfg) == 0 && numInputs == 0 {
-		return errors.New("no subscriptions or inputs configuration found")
+	err = a.Config.GetTracing()
+	if err != nil {
+		return err
+	}
+	stopTracing, err := a.startTracing()
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+	err = a.Config.GetMemoryBudget()
+	if err != nil {
+		return err
+	}
+	err = a.initMemoryBudget()
+	if err != nil {
+		return err
+	}
+	_, err = a.Config.GetNetconfPollers()
+	if err != nil {
+		return fmt.Errorf("failed reading netconf pollers config: %v", err)
+	}
+	err = a.initNetconfPollers()
+	if err != nil {
+		return err
+	}
+	numInputs := len(a.Config.Inputs) + len(a.netconfPollers)
+	if len(subCfg) == 0 && numInputs == 0 && len(a.Config.SubscriptionProfiles) == 0 {
+		return errors.New("no subscriptions, subscription profiles, inputs or netconf pollers configuration found")
+	}
+	if a.Config.LocalFlags.SubscribeTUI {
+		if allSubscriptionsModeOnce(subCfg) || allSubscriptionsModePoll(subCfg) {
+			return errors.New("tui mode requires stream subscriptions")
+		}
+		a.tui = newTUI()
 	}
 	// only once mode subscriptions requested
 	if allSubscriptionsModeOnce(subCfg) {
@@ -115,6 +160,10 @@ func (a *App) SubscribeRunE(cmd *cobra.Command, args []string) error {
 		break
 	}
 
+	a.reloadCmd = cmd
+	a.recordConfigNames(a.Config)
+	go a.handleReloadSignal()
+
 	a.startAPIServer()
 	a.startGnmiServer()
 	go a.startCluster()
@@ -123,6 +172,9 @@ func (a *App) SubscribeRunE(cmd *cobra.Command, args []string) error {
 	if a.Config.LocalFlags.SubscribeWatchConfig {
 		go a.watchConfig()
 	}
+	if a.tui != nil {
+		return a.runTUI()
+	}
 
 	for range a.ctx.Done() {
 		return a.ctx.Err()
@@ -158,7 +210,7 @@ func (a *App) InitSubscribeFlags(cmd *cobra.Command) {
 	//cmd.MarkFlagRequired("path")
 	cmd.Flags().Uint32VarP(&a.Config.LocalFlags.SubscribeQos, "qos", "q", 0, "qos marking")
 	cmd.Flags().BoolVarP(&a.Config.LocalFlags.SubscribeUpdatesOnly, "updates-only", "", false, "only updates to current state should be sent")
-	cmd.Flags().StringVarP(&a.Config.LocalFlags.SubscribeMode, "mode", "", "stream", "one of: once, stream, poll")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.SubscribeMode, "mode", "", "stream", "one of: once, stream, poll, get-poll")
 	cmd.Flags().StringVarP(&a.Config.LocalFlags.SubscribeStreamMode, "stream-mode", "", "target-defined", "one of: on-change, sample, target-defined")
 	cmd.Flags().DurationVarP(&a.Config.LocalFlags.SubscribeSampleInterval, "sample-interval", "i", 0,
 		"sample interval as a decimal number and a suffix unit, such as \"10s\" or \"1m30s\"")
@@ -176,6 +228,11 @@ func (a *App) InitSubscribeFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&a.Config.LocalFlags.SubscribeHistorySnapshot, "history-snapshot", "", "", "sets the snapshot time in a historical subscription, nanoseconds since Unix epoch or RFC3339 format")
 	cmd.Flags().StringVarP(&a.Config.LocalFlags.SubscribeHistoryStart, "history-start", "", "", "sets the start time in a historical range subscription, nanoseconds since Unix epoch or RFC3339 format")
 	cmd.Flags().StringVarP(&a.Config.LocalFlags.SubscribeHistoryEnd, "history-end", "", "", "sets the end time in a historical range subscription, nanoseconds since Unix epoch or RFC3339 format")
+	cmd.Flags().Uint32VarP(&a.Config.LocalFlags.SubscribeDepth, "depth", "", 0, "depth extension level, limits the depth of the returned subtrees. 0 means no limit")
+	cmd.Flags().DurationVarP(&a.Config.LocalFlags.SubscribeDuration, "duration", "", 0, "stop the subscription(s) and exit after the given duration")
+	cmd.Flags().IntVarP(&a.Config.LocalFlags.SubscribeMaxMessages, "max-messages", "", 0, "stop the subscription(s) and exit after receiving the given number of update messages")
+	cmd.Flags().BoolVarP(&a.Config.LocalFlags.SubscribeTUI, "tui", "", false, "display the received updates in a terminal UI with per target panes, rates and value history")
+	cmd.Flags().StringArrayVarP(&a.Config.LocalFlags.SubscribeFilter, "filter", "", []string{}, "client side filter applied to received updates, a path starting with '/' or a regular expression matched against the update xpath")
 	//
 	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
 		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
@@ -200,6 +257,18 @@ func (a *App) readConfigs() error {
 	if err != nil {
 		return fmt.Errorf("failed reading event processors config: %v", err)
 	}
+	_, err = a.Config.GetSubscriptionProfiles()
+	if err != nil {
+		return fmt.Errorf("failed reading subscription profiles config: %v", err)
+	}
+	_, err = a.Config.GetConnectionProfiles()
+	if err != nil {
+		return fmt.Errorf("failed reading connection profiles config: %v", err)
+	}
+	_, err = a.Config.GetCredentialsProviders()
+	if err != nil {
+		return fmt.Errorf("failed reading credentials providers config: %v", err)
+	}
 	_, err = a.LoadProtoFiles()
 	if err != nil {
 		return fmt.Errorf("failed loading proto files: %v", err)
@@ -263,6 +332,11 @@ func (a *App) handlePolledSubscriptions() {
 					waitChan <- struct{}{}
 					continue
 				}
+				response = a.updateFilter.apply(response)
+				if response == nil {
+					waitChan <- struct{}{}
+					continue
+				}
 				b, err := mo.Marshal(response, nil)
 				if err != nil {
 					fmt.Printf("target '%s', subscription '%s': poll response formatting error:%v\n", name, subName, err)
@@ -282,8 +356,10 @@ func (a *App) handlePolledSubscriptions() {
 
 func (a *App) startIO() {
 	go a.StartCollector(a.ctx)
+	go a.runSubscriptionSchedules(a.ctx)
 	a.InitOutputs(a.ctx)
 	a.InitInputs(a.ctx)
+	a.startNetconfPollers(a.ctx)
 
 	if !a.inCluster() {
 		go a.startLoader(a.ctx)
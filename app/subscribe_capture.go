@@ -0,0 +1,79 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// capture bounds a subscribe command run by a duration and/or
+// a maximum number of received update messages.
+type capture struct {
+	maxMessages uint64
+	count       uint64
+	once        *sync.Once
+	stopped     int32
+}
+
+// startCapture sets up the subscribe capture limits,
+// the App context is canceled once one of the limits is reached.
+func (a *App) startCapture() {
+	d := a.Config.LocalFlags.SubscribeDuration
+	maxMsgs := a.Config.LocalFlags.SubscribeMaxMessages
+	if d <= 0 && maxMsgs <= 0 {
+		return
+	}
+	a.capture = &capture{
+		maxMessages: uint64(maxMsgs),
+		once:        new(sync.Once),
+	}
+	if d <= 0 {
+		return
+	}
+	go func() {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			a.stopCapture("duration %s elapsed", d)
+		case <-a.ctx.Done():
+		}
+	}()
+}
+
+// countMessage counts the update notifications, it returns false if the max number
+// of messages is already reached, and true as first value for the last message.
+func (c *capture) countMessage(rsp *gnmi.SubscribeResponse) (bool, bool) {
+	if c == nil || c.maxMessages == 0 || rsp.GetUpdate() == nil {
+		return false, true
+	}
+	n := atomic.AddUint64(&c.count, 1)
+	if n > c.maxMessages {
+		return false, false
+	}
+	return n == c.maxMessages, true
+}
+
+func (a *App) stopCapture(format string, args ...interface{}) {
+	a.capture.once.Do(func() {
+		atomic.StoreInt32(&a.capture.stopped, 1)
+		a.Logger.Printf("stopping subscribe: "+format, args...)
+		a.Cfn()
+	})
+}
+
+// captureStopped returns true if the subscribe command
+// was stopped by one of the capture limits.
+func (a *App) captureStopped() bool {
+	return a.capture != nil && atomic.LoadInt32(&a.capture.stopped) == 1
+}
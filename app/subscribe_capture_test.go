@@ -0,0 +1,116 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+	"google.golang.org/grpc"
+)
+
+func TestCaptureCountMessage(t *testing.T) {
+	upd := &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{}}}
+	sync := &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true}}
+
+	var nilCapture *capture
+	if last, ok := nilCapture.countMessage(upd); last || !ok {
+		t.Errorf("nil capture: expected (false, true), got (%v, %v)", last, ok)
+	}
+
+	c := &capture{maxMessages: 2}
+	if last, ok := c.countMessage(upd); last || !ok {
+		t.Errorf("message 1: expected (false, true), got (%v, %v)", last, ok)
+	}
+	// sync responses are not counted
+	if last, ok := c.countMessage(sync); last || !ok {
+		t.Errorf("sync response: expected (false, true), got (%v, %v)", last, ok)
+	}
+	if last, ok := c.countMessage(upd); !last || !ok {
+		t.Errorf("message 2: expected (true, true), got (%v, %v)", last, ok)
+	}
+	if last, ok := c.countMessage(upd); last || ok {
+		t.Errorf("message 3: expected (false, false), got (%v, %v)", last, ok)
+	}
+}
+
+func TestCaptureGetPoll(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := new(getPollTestServer)
+	srv := grpc.NewServer()
+	gnmi.RegisterGNMIServer(srv, gs)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	a := New()
+	defer a.Cfn()
+	a.Config.LocalFlags.SubscribeMaxMessages = 2
+	a.Config.LocalFlags.SubscribeDuration = time.Minute
+	interval := 20 * time.Millisecond
+	a.Config.Subscriptions = map[string]*types.SubscriptionConfig{
+		"sub1": {
+			Name:           "sub1",
+			Paths:          []string{"/interfaces"},
+			Mode:           "get-poll",
+			Encoding:       "json",
+			SampleInterval: &interval,
+		},
+	}
+	insecure := true
+	tc := &types.TargetConfig{
+		Name:          "t1",
+		Address:       l.Addr().String(),
+		Insecure:      &insecure,
+		Timeout:       5 * time.Second,
+		Subscriptions: []string{"sub1"},
+	}
+	tg, err := a.initTarget(tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.startCapture()
+	err = a.clientSubscribe(a.ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rspCh, _ := tg.ReadSubscriptions()
+	timeout := time.After(5 * time.Second)
+LOOP:
+	for {
+		select {
+		case rsp := <-rspCh:
+			a.Export(a.ctx, rsp.Response, outputs.Meta{"source": "t1"})
+		case <-a.ctx.Done():
+			break LOOP
+		case <-timeout:
+			t.Fatal("get-poll subscription not stopped after 2 messages")
+		}
+	}
+	if !a.captureStopped() {
+		t.Error("expected the capture to be stopped")
+	}
+	// the get-poll goroutine stops with the App context
+	time.Sleep(5 * interval)
+	gs.m.Lock()
+	n := len(gs.reqs)
+	gs.m.Unlock()
+	time.Sleep(5 * interval)
+	gs.m.Lock()
+	defer gs.m.Unlock()
+	if len(gs.reqs) != n {
+		t.Errorf("get requests sent after the capture stopped: %d, then %d", n, len(gs.reqs))
+	}
+}
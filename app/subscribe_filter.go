@@ -0,0 +1,168 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/utils"
+)
+
+// updateFilter is a client side filter applied to the received
+// subscribe responses, updates and deletes not matching any of
+// the filter paths or regular expressions are removed.
+type updateFilter struct {
+	paths [][]*gnmi.PathElem
+	res   []*regexp.Regexp
+}
+
+// newUpdateFilter parses the filters, a filter starting with "/" is a gNMI path
+// matching the updates under it, wildcards are allowed for names and key values.
+// Any other filter is a regular expression matched against the update xpath.
+// It returns nil if no filters are set.
+func newUpdateFilter(filters []string) (*updateFilter, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	f := &updateFilter{
+		paths: make([][]*gnmi.PathElem, 0, len(filters)),
+		res:   make([]*regexp.Regexp, 0, len(filters)),
+	}
+	for _, flt := range filters {
+		flt = strings.TrimSpace(flt)
+		if flt == "" {
+			continue
+		}
+		if strings.HasPrefix(flt, "/") {
+			p, err := utils.ParsePath(flt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter path %q: %v", flt, err)
+			}
+			f.paths = append(f.paths, p.GetElem())
+			continue
+		}
+		re, err := regexp.Compile(flt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter regex %q: %v", flt, err)
+		}
+		f.res = append(f.res, re)
+	}
+	return f, nil
+}
+
+// apply returns a copy of rsp holding only the matching updates and deletes.
+// It returns nil if none of the notification updates and deletes match.
+// Responses other than update notifications are returned as is.
+func (f *updateFilter) apply(rsp *gnmi.SubscribeResponse) *gnmi.SubscribeResponse {
+	if f == nil || rsp == nil {
+		return rsp
+	}
+	n := rsp.GetUpdate()
+	if n == nil {
+		return rsp
+	}
+	fn := &gnmi.Notification{
+		Timestamp: n.GetTimestamp(),
+		Prefix:    n.GetPrefix(),
+		Alias:     n.GetAlias(),
+		Atomic:    n.GetAtomic(),
+	}
+	for _, upd := range n.GetUpdate() {
+		if f.match(n.GetPrefix(), upd.GetPath()) {
+			fn.Update = append(fn.Update, upd)
+		}
+	}
+	for _, del := range n.GetDelete() {
+		if f.match(n.GetPrefix(), del) {
+			fn.Delete = append(fn.Delete, del)
+		}
+	}
+	if len(fn.Update) == 0 && len(fn.Delete) == 0 {
+		return nil
+	}
+	return &gnmi.SubscribeResponse{
+		Response:  &gnmi.SubscribeResponse_Update{Update: fn},
+		Extension: rsp.GetExtension(),
+	}
+}
+
+func (f *updateFilter) match(prefix, p *gnmi.Path) bool {
+	elems := utils.PathElems(prefix, p)
+	for _, fp := range f.paths {
+		if filterPathMatch(fp, elems) {
+			return true
+		}
+	}
+	if len(f.res) == 0 {
+		return false
+	}
+	xpath := sortedXPath(prefix.GetOrigin(), elems)
+	for _, re := range f.res {
+		if re.MatchString(xpath) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterPathMatch returns true if the filter path fp matches
+// the beginning of the update path up.
+// Wildcard names and key values in fp match any value,
+// keys missing from fp match any key value.
+func filterPathMatch(fp, up []*gnmi.PathElem) bool {
+	if len(fp) > len(up) {
+		return false
+	}
+	for i, pe := range fp {
+		if pe.GetName() != "*" && pe.GetName() != up[i].GetName() {
+			return false
+		}
+		for k, v := range pe.GetKey() {
+			if v == "*" {
+				continue
+			}
+			if up[i].GetKey()[k] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sortedXPath builds an xpath with the keys sorted by name to get a stable string,
+// e.g: /interface[name=ethernet-1/1]/statistics.
+// It is used to match the filter regular expressions and to index the diff updates.
+func sortedXPath(origin string, elems []*gnmi.PathElem) string {
+	sb := strings.Builder{}
+	if origin != "" {
+		sb.WriteString(origin)
+		sb.WriteString(":")
+	}
+	for _, pe := range elems {
+		sb.WriteString("/")
+		sb.WriteString(pe.GetName())
+		keys := make([]string, 0, len(pe.GetKey()))
+		for k := range pe.GetKey() {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sb.WriteString("[")
+			sb.WriteString(k)
+			sb.WriteString("=")
+			sb.WriteString(pe.GetKey()[k])
+			sb.WriteString("]")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,108 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/utils"
+)
+
+func TestUpdateFilter(t *testing.T) {
+	mustPath := func(p string) *gnmi.Path {
+		gp, err := utils.ParsePath(p)
+		if err != nil {
+			t.Fatalf("failed to parse path %q: %v", p, err)
+		}
+		return gp
+	}
+	rsp := &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Prefix: mustPath("/interface[name=ethernet-1/1]"),
+				Update: []*gnmi.Update{
+					{Path: mustPath("statistics/in-octets")},
+					{Path: mustPath("statistics/out-octets")},
+					{Path: mustPath("oper-state")},
+				},
+				Delete: []*gnmi.Path{
+					mustPath("subinterface[index=0]"),
+				},
+			},
+		},
+	}
+	tests := map[string]struct {
+		filters     []string
+		wantUpdates int
+		wantDeletes int
+		wantNil     bool
+	}{
+		"no_filter": {
+			wantUpdates: 3,
+			wantDeletes: 1,
+		},
+		"path": {
+			filters:     []string{"/interface/statistics"},
+			wantUpdates: 2,
+		},
+		"path_with_keys": {
+			filters:     []string{"/interface[name=ethernet-1/1]/subinterface[index=*]"},
+			wantDeletes: 1,
+		},
+		"path_wildcard": {
+			filters:     []string{"/*/oper-state"},
+			wantUpdates: 1,
+		},
+		"regex": {
+			filters:     []string{"in-octets$", `^/interface\[name=ethernet-1/1\]/oper`},
+			wantUpdates: 2,
+		},
+		"no_match": {
+			filters: []string{"/interface[name=ethernet-1/2]"},
+			wantNil: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := newUpdateFilter(tc.filters)
+			if err != nil {
+				t.Fatalf("failed to create filter: %v", err)
+			}
+			out := f.apply(rsp)
+			if tc.wantNil {
+				if out != nil {
+					t.Errorf("expected a nil response, got %v", out)
+				}
+				return
+			}
+			if out == nil {
+				t.Fatalf("unexpected nil response")
+			}
+			if len(out.GetUpdate().GetUpdate()) != tc.wantUpdates {
+				t.Errorf("expected %d updates, got %d", tc.wantUpdates, len(out.GetUpdate().GetUpdate()))
+			}
+			if len(out.GetUpdate().GetDelete()) != tc.wantDeletes {
+				t.Errorf("expected %d deletes, got %d", tc.wantDeletes, len(out.GetUpdate().GetDelete()))
+			}
+		})
+	}
+	// the original response must not be modified
+	if len(rsp.GetUpdate().GetUpdate()) != 3 {
+		t.Errorf("original response updated")
+	}
+	// sync responses are not filtered
+	f, _ := newUpdateFilter([]string{"/foo"})
+	if f.apply(&gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true}}) == nil {
+		t.Errorf("sync response filtered")
+	}
+	if _, err := newUpdateFilter([]string{"in-octets[("}); err == nil {
+		t.Errorf("expected an invalid regex error")
+	}
+}
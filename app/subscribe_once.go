@@ -35,6 +35,12 @@ func (a *App) SubscribeRunONCE(cmd *cobra.Command, args []string, subCfg map[str
 	}
 	//
 	a.InitOutputs(a.ctx)
+	// closing the outputs writes the messages still queued for them
+	defer func() {
+		for _, o := range a.Outputs {
+			o.Close()
+		}
+	}()
 
 	var limiter *time.Ticker
 	if a.Config.LocalFlags.SubscribeBackoff > 0 {
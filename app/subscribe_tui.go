@@ -0,0 +1,378 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nsf/termbox-go"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/utils"
+)
+
+const (
+	tuiRefreshInterval = time.Second
+	tuiHistorySize     = 20
+	tuiValueWidth      = 24
+	tuiCountWidth      = 8
+)
+
+var sparkRunes = []rune("▁▂▃▄▅▆▇█")
+
+// tui is the subscribe terminal UI state,
+// it holds the latest value of each received path per target.
+// Received responses are ignored while paused.
+type tui struct {
+	m       *sync.Mutex
+	targets map[string]*tuiTarget
+	// display state
+	paused  bool
+	filter  string
+	editing bool
+	input   []rune
+	// index of the displayed target in the sorted target names,
+	// -1 displays all the targets
+	focus int
+}
+
+type tuiTarget struct {
+	count     uint64
+	lastCount uint64
+	rate      float64
+	paths     map[string]*tuiPath
+}
+
+type tuiPath struct {
+	value   string
+	count   uint64
+	history []float64
+}
+
+type tuiLine struct {
+	text   string
+	fg, bg termbox.Attribute
+}
+
+func newTUI() *tui {
+	return &tui{
+		m:       new(sync.Mutex),
+		targets: make(map[string]*tuiTarget),
+		focus:   -1,
+	}
+}
+
+// update stores the values and deletes of a subscribe response received from target name.
+func (t *tui) update(name string, rsp *gnmi.SubscribeResponse) {
+	n := rsp.GetUpdate()
+	if n == nil {
+		return
+	}
+	t.m.Lock()
+	defer t.m.Unlock()
+	if t.paused {
+		return
+	}
+	tt, ok := t.targets[name]
+	if !ok {
+		tt = &tuiTarget{paths: make(map[string]*tuiPath)}
+		t.targets[name] = tt
+	}
+	tt.count++
+	prefix := utils.GnmiPathToXPath(n.GetPrefix(), false)
+	for _, d := range n.GetDelete() {
+		dp := strings.TrimRight(prefix+"/"+strings.TrimLeft(utils.GnmiPathToXPath(d, false), "/"), "/")
+		for p := range tt.paths {
+			if p == dp || strings.HasPrefix(p, dp+"/") {
+				delete(tt.paths, p)
+			}
+		}
+	}
+	values, err := formatters.ResponsesFlat(rsp)
+	if err != nil {
+		return
+	}
+	for p, v := range values {
+		if !strings.HasPrefix(p, "/") {
+			p = "/" + p
+		}
+		tp, ok := tt.paths[p]
+		if !ok {
+			tp = new(tuiPath)
+			tt.paths[p] = tp
+		}
+		tp.count++
+		tp.value = fmt.Sprint(v)
+		if f, ok := tuiNumber(v); ok {
+			tp.history = append(tp.history, f)
+			if len(tp.history) > tuiHistorySize {
+				tp.history = tp.history[len(tp.history)-tuiHistorySize:]
+			}
+		}
+	}
+}
+
+// tick updates the targets messages rate given the time elapsed since the last tick.
+func (t *tui) tick(elapsed time.Duration) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	for _, tt := range t.targets {
+		tt.rate = float64(tt.count-tt.lastCount) / elapsed.Seconds()
+		tt.lastCount = tt.count
+	}
+}
+
+func tuiNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	f, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+	return f, err == nil
+}
+
+// sparkline renders values as a string of block characters scaled between their min and max.
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	sb := new(strings.Builder)
+	for _, v := range values {
+		i := 0
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(sparkRunes)-1))
+		}
+		sb.WriteRune(sparkRunes[i])
+	}
+	return sb.String()
+}
+
+// render returns the lines of a width x height screen.
+func (t *tui) render(width, height int) []tuiLine {
+	t.m.Lock()
+	defer t.m.Unlock()
+	names := make([]string, 0, len(t.targets))
+	var total uint64
+	for name, tt := range t.targets {
+		names = append(names, name)
+		total += tt.count
+	}
+	sort.Strings(names)
+	if t.focus >= len(names) {
+		t.focus = -1
+	}
+	header := fmt.Sprintf(" gnmic subscribe | targets: %d | messages: %d", len(names), total)
+	if t.paused {
+		header += " | PAUSED"
+	}
+	if t.filter != "" {
+		header += fmt.Sprintf(" | filter: %s", t.filter)
+	}
+	header += " | q:quit p:pause /:filter tab:target"
+	lines := make([]tuiLine, 0, height)
+	lines = append(lines, tuiLine{text: header, fg: termbox.ColorBlack, bg: termbox.ColorCyan})
+	if t.focus >= 0 {
+		names = names[t.focus : t.focus+1]
+	}
+	avail := height - 1
+	if t.editing {
+		avail--
+	}
+	if len(names) > 0 && avail > 0 {
+		paneHeight := avail / len(names)
+		if paneHeight < 2 {
+			paneHeight = 2
+		}
+		pathWidth := width - tuiValueWidth - tuiCountWidth - tuiHistorySize - 3
+		if pathWidth < 10 {
+			pathWidth = 10
+		}
+		for _, name := range names {
+			if len(lines)+2 > avail+1 {
+				break
+			}
+			tt := t.targets[name]
+			paths := make([]string, 0, len(tt.paths))
+			for p := range tt.paths {
+				if t.filter == "" || strings.Contains(p, t.filter) {
+					paths = append(paths, p)
+				}
+			}
+			sort.Strings(paths)
+			lines = append(lines, tuiLine{
+				text: fmt.Sprintf(" %s | messages: %d | rate: %.1f msg/s | paths: %d", name, tt.count, tt.rate, len(paths)),
+				fg:   termbox.ColorWhite | termbox.AttrBold,
+				bg:   termbox.ColorBlue,
+			})
+			for i, p := range paths {
+				if i >= paneHeight-1 || len(lines) >= avail+1 {
+					break
+				}
+				tp := tt.paths[p]
+				lines = append(lines, tuiLine{
+					text: fmt.Sprintf("%-*s %-*s %*d %s",
+						pathWidth, truncate(p, pathWidth),
+						tuiValueWidth, truncate(tp.value, tuiValueWidth),
+						tuiCountWidth, tp.count,
+						sparkline(tp.history)),
+					fg: termbox.ColorDefault,
+					bg: termbox.ColorDefault,
+				})
+			}
+		}
+	}
+	if t.editing {
+		for len(lines) < height-1 {
+			lines = append(lines, tuiLine{})
+		}
+		lines = append(lines, tuiLine{text: "filter: " + string(t.input) + "_", fg: termbox.ColorYellow})
+	}
+	return lines
+}
+
+// truncate shortens s to n runes, keeping its end.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string(r[len(r)-n:])
+	}
+	return "…" + string(r[len(r)-n+1:])
+}
+
+// handleKey applies a key event to the UI state,
+// it returns false if the UI should exit.
+func (t *tui) handleKey(ev termbox.Event) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+	if t.editing {
+		switch ev.Key {
+		case termbox.KeyEnter:
+			t.filter = string(t.input)
+			t.editing = false
+		case termbox.KeyEsc:
+			t.editing = false
+		case termbox.KeyBackspace, termbox.KeyBackspace2:
+			if len(t.input) > 0 {
+				t.input = t.input[:len(t.input)-1]
+			}
+		case termbox.KeyCtrlC:
+			return false
+		default:
+			if ev.Ch != 0 {
+				t.input = append(t.input, ev.Ch)
+			}
+		}
+		return true
+	}
+	switch {
+	case ev.Key == termbox.KeyCtrlC, ev.Key == termbox.KeyEsc, ev.Ch == 'q':
+		return false
+	case ev.Ch == 'p', ev.Key == termbox.KeySpace:
+		t.paused = !t.paused
+	case ev.Ch == '/':
+		t.editing = true
+		t.input = []rune(t.filter)
+	case ev.Key == termbox.KeyTab:
+		t.focus++
+		if t.focus >= len(t.targets) {
+			t.focus = -1
+		}
+	}
+	return true
+}
+
+func (t *tui) draw() {
+	w, h := termbox.Size()
+	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+	for y, l := range t.render(w, h) {
+		x := 0
+		for _, r := range l.text {
+			if x >= w {
+				break
+			}
+			termbox.SetCell(x, y, r, l.fg, l.bg)
+			x++
+		}
+		if l.bg != termbox.ColorDefault {
+			for ; x < w; x++ {
+				termbox.SetCell(x, y, ' ', l.fg, l.bg)
+			}
+		}
+	}
+	termbox.Flush()
+}
+
+// runTUI displays the subscribe terminal UI until the user quits or the App context is done.
+func (a *App) runTUI() error {
+	err := termbox.Init()
+	if err != nil {
+		return fmt.Errorf("could not initialize a terminal box: %v", err)
+	}
+	defer termbox.Close()
+	events := make(chan termbox.Event)
+	go func() {
+		for {
+			ev := termbox.PollEvent()
+			if ev.Type == termbox.EventInterrupt {
+				return
+			}
+			events <- ev
+		}
+	}()
+	ticker := time.NewTicker(tuiRefreshInterval)
+	defer ticker.Stop()
+	last := time.Now()
+	a.tui.draw()
+	for {
+		select {
+		case <-a.ctx.Done():
+			termbox.Interrupt()
+			return nil
+		case now := <-ticker.C:
+			a.tui.tick(now.Sub(last))
+			last = now
+			a.tui.draw()
+		case ev := <-events:
+			switch ev.Type {
+			case termbox.EventKey:
+				if !a.tui.handleKey(ev) {
+					a.Cfn()
+					continue
+				}
+			case termbox.EventError:
+				a.Cfn()
+				return ev.Err
+			}
+			a.tui.draw()
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nsf/termbox-go"
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func tuiTestResponse(prefix string, path string, v int64) *gnmi.SubscribeResponse {
+	return &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Prefix: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: prefix}}},
+				Update: []*gnmi.Update{
+					{
+						Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: path}}},
+						Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_IntVal{IntVal: v}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestTUIUpdate(t *testing.T) {
+	ui := newTUI()
+	for i := int64(0); i < 25; i++ {
+		ui.update("r1", tuiTestResponse("counters", "in-octets", i))
+	}
+	ui.update("r2", tuiTestResponse("system", "name", 1))
+
+	tp := ui.targets["r1"].paths["/counters/in-octets"]
+	if tp == nil {
+		t.Fatalf("missing path, got %v", ui.targets["r1"].paths)
+	}
+	if tp.value != "24" || tp.count != 25 {
+		t.Errorf("unexpected path state: value=%s count=%d", tp.value, tp.count)
+	}
+	if len(tp.history) != tuiHistorySize || tp.history[tuiHistorySize-1] != 24 {
+		t.Errorf("unexpected history: %v", tp.history)
+	}
+	ui.tick(5 * time.Second)
+	if ui.targets["r1"].rate != 5 {
+		t.Errorf("unexpected rate: %v", ui.targets["r1"].rate)
+	}
+
+	// deletes remove the path and its children
+	ui.update("r1", &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Delete: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "counters"}}}},
+			},
+		},
+	})
+	if len(ui.targets["r1"].paths) != 0 {
+		t.Errorf("expected deleted paths, got %v", ui.targets["r1"].paths)
+	}
+
+	// responses are ignored while paused
+	ui.handleKey(termbox.Event{Type: termbox.EventKey, Ch: 'p'})
+	ui.update("r1", tuiTestResponse("counters", "in-octets", 1))
+	if len(ui.targets["r1"].paths) != 0 {
+		t.Errorf("expected no update while paused, got %v", ui.targets["r1"].paths)
+	}
+}
+
+func TestTUIRender(t *testing.T) {
+	ui := newTUI()
+	ui.update("r1", tuiTestResponse("counters", "in-octets", 1))
+	ui.update("r1", tuiTestResponse("system", "name", 2))
+	ui.update("r2", tuiTestResponse("system", "name", 3))
+
+	lines := ui.render(120, 20)
+	text := make([]string, 0, len(lines))
+	for _, l := range lines {
+		text = append(text, l.text)
+	}
+	out := strings.Join(text, "\n")
+	for _, s := range []string{"targets: 2", " r1 |", " r2 |", "/counters/in-octets", "/system/name"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q in:\n%s", s, out)
+		}
+	}
+	// filter
+	for _, ev := range []termbox.Event{{Ch: '/'}, {Ch: 'c'}, {Ch: 'o'}, {Key: termbox.KeyEnter}} {
+		ui.handleKey(ev)
+	}
+	if ui.filter != "co" {
+		t.Fatalf("unexpected filter %q", ui.filter)
+	}
+	lines = ui.render(120, 20)
+	for _, l := range lines {
+		if strings.Contains(l.text, "/system/name") {
+			t.Errorf("filtered path rendered: %q", l.text)
+		}
+	}
+	// focus on the second target
+	ui.handleKey(termbox.Event{Key: termbox.KeyTab})
+	ui.handleKey(termbox.Event{Key: termbox.KeyTab})
+	lines = ui.render(120, 20)
+	for _, l := range lines {
+		if strings.HasPrefix(l.text, " r1 |") {
+			t.Errorf("unfocused target rendered: %q", l.text)
+		}
+	}
+	if !ui.handleKey(termbox.Event{Key: termbox.KeyTab}) || ui.focus != -1 {
+		t.Errorf("expected focus reset, got %d", ui.focus)
+	}
+	if ui.handleKey(termbox.Event{Ch: 'q'}) {
+		t.Errorf("expected quit on q")
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := map[string]struct {
+		in  []float64
+		out string
+	}{
+		"empty":    {in: nil, out: ""},
+		"constant": {in: []float64{3, 3, 3}, out: "▁▁▁"},
+		"ramp":     {in: []float64{0, 1, 2, 3, 4, 5, 6, 7}, out: "▁▂▃▄▅▆▇█"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := sparkline(tc.in); got != tc.out {
+				t.Errorf("expected %q, got %q", tc.out, got)
+			}
+		})
+	}
+}
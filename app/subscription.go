@@ -0,0 +1,270 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openconfig/gnmic/target"
+	"github.com/openconfig/gnmic/types"
+)
+
+// interval at which the subscriptions schedules are checked
+const subscriptionScheduleInterval = time.Minute
+
+// AddSubscriptionConfig adds the subscription sc to the configuration
+// and starts it on the running targets it applies to.
+func (a *App) AddSubscriptionConfig(sc *types.SubscriptionConfig) error {
+	err := a.Config.ValidateDynamicSubscription(sc)
+	if err != nil {
+		return err
+	}
+	a.configLock.Lock()
+	if _, ok := a.Config.Subscriptions[sc.Name]; ok {
+		a.configLock.Unlock()
+		return fmt.Errorf("subscription %q already exists", sc.Name)
+	}
+	a.Config.Subscriptions[sc.Name] = sc
+	a.configLock.Unlock()
+	a.Logger.Printf("subscription %q added to config", sc.Name)
+
+	a.operLock.RLock()
+	defer a.operLock.RUnlock()
+	for _, t := range a.Targets {
+		if subscriptionAppliesTo(t.Config, sc) {
+			a.startTargetSubscription(t, sc)
+		}
+	}
+	return nil
+}
+
+// UpdateSubscriptionConfig replaces the configuration of an existing subscription
+// and re-subscribes the running targets it applies to with the new parameters.
+func (a *App) UpdateSubscriptionConfig(sc *types.SubscriptionConfig) error {
+	err := a.Config.ValidateDynamicSubscription(sc)
+	if err != nil {
+		return err
+	}
+	a.configLock.Lock()
+	if _, ok := a.Config.Subscriptions[sc.Name]; !ok {
+		a.configLock.Unlock()
+		return fmt.Errorf("subscription %q does not exist", sc.Name)
+	}
+	a.Config.Subscriptions[sc.Name] = sc
+	a.configLock.Unlock()
+	a.Logger.Printf("subscription %q updated", sc.Name)
+
+	a.operLock.RLock()
+	defer a.operLock.RUnlock()
+	for _, t := range a.Targets {
+		if _, ok := t.Subscriptions[sc.Name]; ok || subscriptionAppliesTo(t.Config, sc) {
+			a.startTargetSubscription(t, sc)
+		}
+	}
+	return nil
+}
+
+// DeleteSubscriptionConfig removes a subscription from the configuration
+// and tears down its streams on the running targets.
+func (a *App) DeleteSubscriptionConfig(name string) error {
+	a.configLock.Lock()
+	if _, ok := a.Config.Subscriptions[name]; !ok {
+		a.configLock.Unlock()
+		return fmt.Errorf("subscription %q does not exist", name)
+	}
+	delete(a.Config.Subscriptions, name)
+	a.configLock.Unlock()
+	a.Logger.Printf("subscription %q deleted from config", name)
+
+	a.operLock.RLock()
+	defer a.operLock.RUnlock()
+	for _, t := range a.Targets {
+		if _, ok := t.Subscriptions[name]; !ok {
+			continue
+		}
+		a.Logger.Printf("target %q: stopping subscription %q", t.Config.Name, name)
+		t.DeleteSubscription(name)
+		if !targetMultiplexesSubscriptions(t) {
+			continue
+		}
+		// the subscription might be carried by a shared stream,
+		// re-subscribe with the remaining ones.
+		if len(t.Subscriptions) == 0 {
+			if t.Cfn != nil {
+				t.Cfn()
+			}
+			continue
+		}
+		go a.resubscribeTarget(t)
+	}
+	return nil
+}
+
+func (a *App) subscriptionConfigExists(name string) bool {
+	a.configLock.RLock()
+	_, ok := a.Config.Subscriptions[name]
+	a.configLock.RUnlock()
+	return ok
+}
+
+// subscriptionAppliesTo reports whether the subscription sc is one of
+// the subscriptions of target tc, either explicitly or because
+// the target does not select any subscription nor profile.
+// A subscription never applies to targets of another namespace.
+func subscriptionAppliesTo(tc *types.TargetConfig, sc *types.SubscriptionConfig) bool {
+	if !sc.InNamespace(tc.Namespace) {
+		return false
+	}
+	for _, sn := range tc.Subscriptions {
+		if sn == sc.Name {
+			return true
+		}
+	}
+	return len(tc.Subscriptions) == 0 && len(tc.Profiles) == 0
+}
+
+// targetMultiplexesSubscriptions reports whether the subscriptions of target t
+// can share a stream, in which case they are re-subscribed as a whole.
+func targetMultiplexesSubscriptions(t *target.Target) bool {
+	return (t.Config.MultiplexSubscriptions != nil && *t.Config.MultiplexSubscriptions) ||
+		t.Config.MaxStreams > 0
+}
+
+// startTargetSubscription starts, or restarts, the subscription sc on the running target t.
+// If the target gNMI client is not created yet, the subscription is sent along the others
+// once it is.
+func (a *App) startTargetSubscription(t *target.Target, sc *types.SubscriptionConfig) {
+	t.SetSubscription(sc)
+	if t.Client == nil {
+		return
+	}
+	if targetMultiplexesSubscriptions(t) {
+		go a.resubscribeTarget(t)
+		return
+	}
+	subRequests, err := a.createSubscribeRequests(a.ctx, t, map[string]*types.SubscriptionConfig{sc.Name: sc})
+	if err != nil {
+		a.Logger.Printf("target %q: failed to create subscribe request %q: %v", t.Config.Name, sc.Name, err)
+		return
+	}
+	for _, sreq := range subRequests {
+		a.sendSubscriptionRequest(a.ctx, t, sreq)
+	}
+}
+
+// resubscribeTarget restarts all the subscriptions of target t.
+// The subscribe streams are re-opened over the existing gNMI client connection,
+// in the context the target subscriptions were first started in.
+func (a *App) resubscribeTarget(t *target.Target) {
+	ctx := t.SubscribeContext()
+	// the subscriptions are sent once the gNMI client is created
+	if ctx == nil || ctx.Err() != nil || t.Client == nil {
+		return
+	}
+	a.Logger.Printf("target %q: re-subscribing", t.Config.Name)
+	subscriptionsConfigs := t.Subscriptions
+	if len(subscriptionsConfigs) == 0 {
+		subscriptionsConfigs = a.namespaceSubscriptions(t.Config.Namespace)
+	}
+	t.StopStreams()
+	err := a.subscribeTarget(ctx, t, subscriptionsConfigs)
+	if err != nil {
+		a.Logger.Printf("target %q: failed to re-subscribe: %v", t.Config.Name, err)
+	}
+}
+
+// targetSubscriptionState is the state of a subscription of a running target.
+type targetSubscriptionState struct {
+	Name   string `json:"name,omitempty"`
+	Paused bool   `json:"paused"`
+	// reasons the subscription is paused for: api or schedule
+	PauseReasons []string `json:"pause-reasons,omitempty"`
+	// false if the subscription schedule keeps it inactive
+	InSchedule bool `json:"in-schedule"`
+}
+
+func targetSubscriptionStatus(t *target.Target, sc *types.SubscriptionConfig, now time.Time) *targetSubscriptionState {
+	return &targetSubscriptionState{
+		Name:         sc.Name,
+		Paused:       t.SubscriptionPaused(sc.Name),
+		PauseReasons: t.SubscriptionPauseReasons(sc.Name),
+		InSchedule:   sc.Schedule.ActiveAt(now),
+	}
+}
+
+// pauseTargetSubscription stops the subscription name of target t until it is resumed
+// for the same reason.
+func (a *App) pauseTargetSubscription(t *target.Target, name, reason string) {
+	a.Logger.Printf("target %q: pausing subscription %q, reason=%s", t.Config.Name, name, reason)
+	t.PauseSubscription(name, reason)
+	if targetMultiplexesSubscriptions(t) {
+		// the subscription might be carried by a shared stream,
+		// re-subscribe with the remaining ones.
+		go a.resubscribeTarget(t)
+	}
+}
+
+// resumeTargetSubscription clears the pause reason of subscription name of target t,
+// the subscription is started if nothing else keeps it paused.
+func (a *App) resumeTargetSubscription(t *target.Target, name, reason string) {
+	if !t.ResumeSubscription(name, reason) {
+		return
+	}
+	sc, ok := t.Subscriptions[name]
+	if !ok {
+		return
+	}
+	a.Logger.Printf("target %q: resuming subscription %q, reason=%s", t.Config.Name, name, reason)
+	a.startTargetSubscription(t, sc)
+}
+
+// runSubscriptionSchedules pauses and resumes the running targets subscriptions
+// according to their schedule, until ctx is done.
+func (a *App) runSubscriptionSchedules(ctx context.Context) {
+	ticker := time.NewTicker(subscriptionScheduleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.applySubscriptionSchedules(now)
+		}
+	}
+}
+
+func (a *App) applySubscriptionSchedules(now time.Time) {
+	a.operLock.RLock()
+	defer a.operLock.RUnlock()
+	for _, t := range a.Targets {
+		subs := make([]*types.SubscriptionConfig, 0, len(t.Subscriptions))
+		for _, sc := range t.Subscriptions {
+			if sc.Schedule != nil {
+				subs = append(subs, sc)
+			}
+		}
+		for _, sc := range subs {
+			st := targetSubscriptionStatus(t, sc, now)
+			scheduled := false
+			for _, r := range st.PauseReasons {
+				if r == target.PausedBySchedule {
+					scheduled = true
+				}
+			}
+			switch {
+			case !st.InSchedule && !scheduled:
+				a.pauseTargetSubscription(t, sc.Name, target.PausedBySchedule)
+			case st.InSchedule && scheduled:
+				a.resumeTargetSubscription(t, sc.Name, target.PausedBySchedule)
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/target"
+	"github.com/openconfig/gnmic/types"
+)
+
+func TestSubscriptionAppliesTo(t *testing.T) {
+	tests := map[string]struct {
+		tc     *types.TargetConfig
+		ns     string
+		result bool
+	}{
+		"no_subscriptions": {
+			tc:     &types.TargetConfig{Name: "t1"},
+			result: true,
+		},
+		"listed": {
+			tc:     &types.TargetConfig{Name: "t1", Subscriptions: []string{"sub0", "sub1"}},
+			result: true,
+		},
+		"not_listed": {
+			tc:     &types.TargetConfig{Name: "t1", Subscriptions: []string{"sub0"}},
+			result: false,
+		},
+		"profiles_only": {
+			tc:     &types.TargetConfig{Name: "t1", Profiles: []string{"p1"}},
+			result: false,
+		},
+		"same_namespace": {
+			tc:     &types.TargetConfig{Name: "t1", Namespace: "team-a"},
+			ns:     "team-a",
+			result: true,
+		},
+		"other_namespace": {
+			tc:     &types.TargetConfig{Name: "t1", Namespace: "team-b", Subscriptions: []string{"sub1"}},
+			ns:     "team-a",
+			result: false,
+		},
+		"target_without_namespace": {
+			tc:     &types.TargetConfig{Name: "t1"},
+			ns:     "team-a",
+			result: false,
+		},
+		"subscription_without_namespace": {
+			tc:     &types.TargetConfig{Name: "t1", Namespace: "team-a"},
+			result: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			sc := &types.SubscriptionConfig{Name: "sub1", Namespace: tc.ns}
+			if r := subscriptionAppliesTo(tc.tc, sc); r != tc.result {
+				t.Errorf("expected %v, got %v", tc.result, r)
+			}
+		})
+	}
+}
+
+func TestSubscriptionsAPI(t *testing.T) {
+	a := New()
+	a.routes()
+	t1 := target.NewTarget(&types.TargetConfig{Name: "t1"})
+	t2 := target.NewTarget(&types.TargetConfig{Name: "t2", Subscriptions: []string{"other"}})
+	a.Targets["t1"] = t1
+	a.Targets["t2"] = t2
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		a.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// create
+	rec := do(http.MethodPost, "/api/v1/subscriptions", `{"name":"sub1","paths":["/interface"],"stream-mode":"sample"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, ok := t1.Subscriptions["sub1"]; !ok {
+		t.Errorf("subscription not added to target t1")
+	}
+	if _, ok := t2.Subscriptions["sub1"]; ok {
+		t.Errorf("subscription unexpectedly added to target t2")
+	}
+	rec = do(http.MethodPost, "/api/v1/subscriptions", `{"name":"sub1","paths":["/interface"]}`)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("duplicate create: unexpected status %d", rec.Code)
+	}
+	rec = do(http.MethodPost, "/api/v1/subscriptions", `{"name":"sub2","paths":["/interface"],"mode":"once"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("once create: unexpected status %d", rec.Code)
+	}
+	// read
+	rec = do(http.MethodGet, "/api/v1/subscriptions/sub1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get: unexpected status %d", rec.Code)
+	}
+	rsp := new(subscriptionResponse)
+	err := json.Unmarshal(rec.Body.Bytes(), rsp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp.Config.Mode != "STREAM" || len(rsp.Targets) != 1 || rsp.Targets[0] != "t1" {
+		t.Errorf("get: unexpected response: %s", rec.Body.String())
+	}
+	// update
+	rec = do(http.MethodPut, "/api/v1/subscriptions/sub1", `{"paths":["/network-instance"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	if p := t1.Subscriptions["sub1"].Paths; len(p) != 1 || p[0] != "/network-instance" {
+		t.Errorf("update: unexpected target subscription paths: %v", p)
+	}
+	rec = do(http.MethodPut, "/api/v1/subscriptions/unknown", `{"paths":["/interface"]}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("update unknown: unexpected status %d", rec.Code)
+	}
+	// delete
+	rec = do(http.MethodDelete, "/api/v1/subscriptions/sub1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete: unexpected status %d", rec.Code)
+	}
+	if _, ok := t1.Subscriptions["sub1"]; ok {
+		t.Errorf("subscription not removed from target t1")
+	}
+	if _, ok := a.Config.Subscriptions["sub1"]; ok {
+		t.Errorf("subscription not removed from config")
+	}
+	rec = do(http.MethodDelete, "/api/v1/subscriptions/sub1", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("delete unknown: unexpected status %d", rec.Code)
+	}
+}
+
+func TestTargetSubscriptionPauseResume(t *testing.T) {
+	a := New()
+	a.routes()
+	t1 := target.NewTarget(&types.TargetConfig{Name: "t1"})
+	// active on week days from 08:00 to 18:00 UTC
+	t1.SetSubscription(&types.SubscriptionConfig{
+		Name:  "sub1",
+		Paths: []string{"/interface"},
+		Schedule: &types.SubscriptionSchedule{
+			Timezone: "UTC",
+			Active:   []*types.TimeWindow{{Days: []string{"mon-fri"}, Start: "08:00", End: "18:00"}},
+		},
+	})
+	a.Targets["t1"] = t1
+
+	do := func(method, url string) *targetSubscriptionState {
+		req := httptest.NewRequest(method, url, nil)
+		rec := httptest.NewRecorder()
+		a.router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s: unexpected status %d: %s", method, url, rec.Code, rec.Body.String())
+		}
+		st := new(targetSubscriptionState)
+		err := json.Unmarshal(rec.Body.Bytes(), st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st
+	}
+
+	st := do(http.MethodPost, "/api/v1/targets/t1/subscriptions/sub1/pause")
+	if !st.Paused || len(st.PauseReasons) != 1 || st.PauseReasons[0] != target.PausedByAPI {
+		t.Errorf("pause: unexpected state %+v", st)
+	}
+	// a saturday, outside of the schedule
+	a.applySubscriptionSchedules(time.Date(2022, 10, 15, 12, 0, 0, 0, time.UTC))
+	if r := t1.SubscriptionPauseReasons("sub1"); len(r) != 2 {
+		t.Errorf("expected the subscription to be paused by the api and the schedule, got %v", r)
+	}
+	// resuming keeps the subscription paused by its schedule
+	st = do(http.MethodPost, "/api/v1/targets/t1/subscriptions/sub1/resume")
+	if !st.Paused || len(st.PauseReasons) != 1 || st.PauseReasons[0] != target.PausedBySchedule {
+		t.Errorf("resume: unexpected state %+v", st)
+	}
+	// a monday, within the schedule
+	a.applySubscriptionSchedules(time.Date(2022, 10, 17, 12, 0, 0, 0, time.UTC))
+	if t1.SubscriptionPaused("sub1") {
+		t.Errorf("expected the subscription to be resumed by its schedule, got %v", t1.SubscriptionPauseReasons("sub1"))
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/targets/t1/subscriptions/unknown/pause", nil)
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown subscription: unexpected status %d", rec.Code)
+	}
+}
@@ -0,0 +1,193 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/openconfig/gnmic/types"
+	"google.golang.org/grpc/status"
+)
+
+// exit codes of the multi target get, set and capabilities commands
+const (
+	// all the targets failed or the command failed
+	exitCodeFailure = 1
+	// some targets failed, the others succeeded
+	exitCodePartialFailure = 2
+)
+
+// summary destination writing it to stderr instead of a file
+const summaryStderr = "stderr"
+
+// ExitError is an error carrying the exit code of the command returning it.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// connectionError wraps the errors of a failed connection to a target.
+type connectionError struct {
+	error
+}
+
+func (e connectionError) Unwrap() error { return e.error }
+
+// targetResult is the result of the requests sent to a single target.
+type targetResult struct {
+	Target  string `json:"target"`
+	Success bool   `json:"success"`
+	// class of the error: connection, timeout, canceled, other
+	// or the gRPC status code, e.g: unavailable, permission_denied.
+	ErrorClass string `json:"error-class,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// RPC latency in milliseconds
+	Latency float64 `json:"latency-ms"`
+}
+
+// runSummary collects the targets results of a multi target command run.
+type runSummary struct {
+	Command   string          `json:"command"`
+	Targets   int             `json:"targets"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+	ExitCode  int             `json:"exit-code"`
+	Results   []*targetResult `json:"results"`
+
+	m sync.Mutex
+	// cancels the other targets requests on the first failure if set
+	cancel context.CancelFunc
+}
+
+// startRun starts collecting the results of a command run.
+// With --fail-fast, cancel is called on the first failed target.
+func (a *App) startRun(command string, cancel context.CancelFunc) {
+	s := &runSummary{
+		Command: command,
+		Results: make([]*targetResult, 0, len(a.Config.Targets)),
+	}
+	if a.Config.FailFast {
+		s.cancel = cancel
+	}
+	a.summary = s
+}
+
+// recordResult records the result of the requests sent to target name.
+func (a *App) recordResult(name string, latency time.Duration, err error) {
+	s := a.summary
+	if s == nil {
+		return
+	}
+	r := &targetResult{
+		Target:  name,
+		Success: err == nil,
+		Latency: float64(latency.Microseconds()) / 1000,
+	}
+	if err != nil {
+		r.ErrorClass = errorClass(err)
+		r.Error = err.Error()
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.Results = append(s.Results, r)
+	if err != nil && s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// finishRun applies the exit code policy to the results of the current run
+// and writes its summary if requested.
+// err is the error returned by checkErrors.
+func (a *App) finishRun(err error) error {
+	s := a.summary
+	if s == nil {
+		return err
+	}
+	a.summary = nil
+	s.m.Lock()
+	defer s.m.Unlock()
+	sort.Slice(s.Results, func(i, j int) bool {
+		return s.Results[i].Target < s.Results[j].Target
+	})
+	s.Targets = len(s.Results)
+	for _, r := range s.Results {
+		if r.Success {
+			s.Succeeded++
+			continue
+		}
+		s.Failed++
+	}
+	switch {
+	case s.Failed == 0:
+		if err != nil {
+			s.ExitCode = exitCodeFailure
+		}
+	case s.Succeeded == 0:
+		s.ExitCode = exitCodeFailure
+		err = &ExitError{Code: exitCodeFailure, Err: errors.New("all requests failed")}
+	case a.Config.AllowPartial:
+		a.logWarning("requests failed for %d out of %d targets", s.Failed, s.Targets)
+		err = nil
+	default:
+		s.ExitCode = exitCodePartialFailure
+		err = &ExitError{
+			Code: exitCodePartialFailure,
+			Err:  fmt.Errorf("requests failed for %d out of %d targets", s.Failed, s.Targets),
+		}
+	}
+	if a.Config.Summary != "" {
+		werr := writeSummary(a.Config.Summary, s)
+		if werr != nil {
+			a.logWarning("failed to write the summary: %v", werr)
+		}
+	}
+	return err
+}
+
+func writeSummary(dst string, s *runSummary) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dst == summaryStderr {
+		_, err = fmt.Fprintln(os.Stderr, string(b))
+		return err
+	}
+	return os.WriteFile(dst, append(b, '\n'), 0644)
+}
+
+// errorClass returns the class of a target request error.
+func errorClass(err error) string {
+	var cerr connectionError
+	if errors.As(err, &cerr) {
+		return "connection"
+	}
+	var se interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &se) {
+		return types.GRPCCodeName(se.GRPCStatus().Code())
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	return "other"
+}
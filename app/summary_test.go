@@ -0,0 +1,136 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRunSummaryExitCode(t *testing.T) {
+	tests := map[string]struct {
+		errs         []error
+		allowPartial bool
+		code         int
+	}{
+		"success":         {errs: []error{nil, nil}},
+		"partial":         {errs: []error{nil, errors.New("failed")}, code: exitCodePartialFailure},
+		"partial_allowed": {errs: []error{nil, errors.New("failed")}, allowPartial: true},
+		"all_failed":      {errs: []error{errors.New("failed"), errors.New("failed")}, allowPartial: true, code: exitCodeFailure},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			a := New()
+			a.Config.AllowPartial = tc.allowPartial
+			a.startRun("get", func() {})
+			for i, err := range tc.errs {
+				a.recordResult(fmt.Sprintf("t%d", i), time.Millisecond, err)
+			}
+			err := a.finishRun(nil)
+			if tc.code == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			var eerr *ExitError
+			if !errors.As(err, &eerr) || eerr.Code != tc.code {
+				t.Fatalf("expected exit code %d, got %v", tc.code, err)
+			}
+		})
+	}
+}
+
+func TestRunSummaryFailFast(t *testing.T) {
+	a := New()
+	a.Config.FailFast = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.startRun("set", cancel)
+	a.recordResult("t1", time.Millisecond, nil)
+	if ctx.Err() != nil {
+		t.Fatal("context canceled after a success")
+	}
+	a.recordResult("t2", time.Millisecond, errors.New("failed"))
+	if ctx.Err() == nil {
+		t.Fatal("context not canceled after a failure")
+	}
+}
+
+func TestRunSummaryFile(t *testing.T) {
+	a := New()
+	a.Config.Summary = filepath.Join(t.TempDir(), "summary.json")
+	a.startRun("capabilities", func() {})
+	a.recordResult("t2", 1500*time.Microsecond, status.Error(codes.Unavailable, "connection refused"))
+	a.recordResult("t1", 2*time.Millisecond, nil)
+	a.finishRun(nil)
+
+	b, err := os.ReadFile(a.Config.Summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := new(runSummary)
+	err = json.Unmarshal(b, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Command != "capabilities" || s.Targets != 2 || s.Succeeded != 1 || s.Failed != 1 || s.ExitCode != exitCodePartialFailure {
+		t.Fatalf("unexpected summary: %s", b)
+	}
+	if s.Results[0].Target != "t1" || !s.Results[0].Success || s.Results[0].Latency != 2 {
+		t.Errorf("unexpected t1 result: %+v", s.Results[0])
+	}
+	if s.Results[1].Target != "t2" || s.Results[1].ErrorClass != "unavailable" || s.Results[1].Latency != 1.5 {
+		t.Errorf("unexpected t2 result: %+v", s.Results[1])
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := map[string]struct {
+		err   error
+		class string
+	}{
+		"grpc_status": {
+			err:   fmt.Errorf("%q GetRequest failed: %w", "t1", status.Error(codes.PermissionDenied, "denied")),
+			class: "permission_denied",
+		},
+		"connection": {
+			err:   connectionError{errors.New("failed to create a gRPC client")},
+			class: "connection",
+		},
+		"canceled": {
+			err:   fmt.Errorf("failed: %w", context.Canceled),
+			class: "canceled",
+		},
+		"timeout": {
+			err:   context.DeadlineExceeded,
+			class: "timeout",
+		},
+		"other": {
+			err:   errors.New("invalid set request"),
+			class: "other",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if c := errorClass(tc.err); c != tc.class {
+				t.Errorf("expected %q, got %q", tc.class, c)
+			}
+		})
+	}
+}
@@ -26,12 +26,25 @@ func (a *App) initTarget(tc *types.TargetConfig) (*target.Target, error) {
 		t := target.NewTarget(tc)
 		for _, subName := range tc.Subscriptions {
 			if sub, ok := a.Config.Subscriptions[subName]; ok {
+				if !sub.InNamespace(tc.Namespace) {
+					a.Logger.Printf("target %q: ignoring subscription %q from namespace %q", tc.Name, subName, sub.Namespace)
+					continue
+				}
 				t.Subscriptions[subName] = sub
 			}
 		}
-		if len(t.Subscriptions) == 0 {
-			for _, sub := range a.Config.Subscriptions {
-				t.Subscriptions[sub.Name] = sub
+		profileSubs, profileOuts := a.Config.ApplySubscriptionProfiles(tc)
+		for subName, sub := range profileSubs {
+			if _, ok := t.Subscriptions[subName]; !ok && sub.InNamespace(tc.Namespace) {
+				t.Subscriptions[subName] = sub
+			}
+		}
+		if len(tc.Outputs) == 0 && len(profileOuts) > 0 {
+			tc.Outputs = profileOuts
+		}
+		if len(t.Subscriptions) == 0 && len(tc.Profiles) == 0 {
+			for name, sub := range a.namespaceSubscriptions(tc.Namespace) {
+				t.Subscriptions[name] = sub
 			}
 		}
 		err := a.parseProtoFiles(t)
@@ -45,6 +58,18 @@ func (a *App) initTarget(tc *types.TargetConfig) (*target.Target, error) {
 
 }
 
+// namespaceSubscriptions returns the subscriptions
+// available to the targets of namespace ns.
+func (a *App) namespaceSubscriptions(ns string) map[string]*types.SubscriptionConfig {
+	subs := make(map[string]*types.SubscriptionConfig, len(a.Config.Subscriptions))
+	for name, sub := range a.Config.Subscriptions {
+		if sub.InNamespace(ns) {
+			subs[name] = sub
+		}
+	}
+	return subs
+}
+
 func (a *App) stopTarget(ctx context.Context, name string) error {
 	if a.Targets == nil {
 		return nil
@@ -76,6 +101,12 @@ func (a *App) DeleteTarget(ctx context.Context, name string) error {
 	delete(a.Config.Targets, name)
 	a.configLock.Unlock()
 	a.Logger.Printf("target %q deleted from config", name)
+	return a.StopTarget(ctx, name)
+}
+
+// StopTarget stops the subscriptions of the running target name and closes its connection.
+// Unlike DeleteTarget, the target configuration is kept so it can be started again.
+func (a *App) StopTarget(ctx context.Context, name string) error {
 	// delete from oper map
 	a.operLock.Lock()
 	defer a.operLock.Unlock()
@@ -85,6 +116,7 @@ func (a *App) DeleteTarget(ctx context.Context, name string) error {
 	if a.c != nil {
 		a.c.DeleteTarget(name)
 	}
+	a.closeProxyTarget(name)
 	if t, ok := a.Targets[name]; ok {
 		delete(a.Targets, name)
 		t.Close()
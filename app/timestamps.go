@@ -0,0 +1,37 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+)
+
+// adjustTimestamp applies the subscription timestamp options tc to the notification
+// of rsp received at arrival. If tc.Record is set, the device and arrival timestamps
+// are added to the metadata m.
+func adjustTimestamp(tc *types.TimestampConfig, rsp *gnmi.SubscribeResponse, arrival time.Time, m outputs.Meta) {
+	if tc == nil {
+		return
+	}
+	n := rsp.GetUpdate()
+	if n == nil {
+		return
+	}
+	if tc.Record {
+		m[formatters.DeviceTimestampMetaKey] = strconv.FormatInt(n.GetTimestamp(), 10)
+		m[formatters.ArrivalTimestampMetaKey] = strconv.FormatInt(arrival.UnixNano(), 10)
+	}
+	n.Timestamp = tc.Adjust(n.GetTimestamp(), arrival)
+}
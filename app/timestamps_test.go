@@ -0,0 +1,105 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+)
+
+func TestAdjustTimestamp(t *testing.T) {
+	arrival := time.Date(2022, 10, 17, 8, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		tc     *types.TimestampConfig
+		device time.Time
+		want   time.Time
+	}{
+		"no_options": {
+			device: arrival.Add(-time.Second),
+			want:   arrival.Add(-time.Second),
+		},
+		"arrival": {
+			tc:     &types.TimestampConfig{Source: "Arrival"},
+			device: arrival.Add(-time.Second),
+			want:   arrival,
+		},
+		"offset": {
+			tc:     &types.TimestampConfig{Offset: 2 * time.Second},
+			device: arrival.Add(-time.Second),
+			want:   arrival.Add(time.Second),
+		},
+		"within_max_skew": {
+			tc:     &types.TimestampConfig{MaxSkew: time.Minute},
+			device: arrival.Add(-time.Second),
+			want:   arrival.Add(-time.Second),
+		},
+		"years_off": {
+			tc:     &types.TimestampConfig{MaxSkew: time.Hour},
+			device: time.Date(1970, 1, 1, 0, 0, 1, 0, time.UTC),
+			want:   arrival,
+		},
+		"in_the_future": {
+			tc:     &types.TimestampConfig{MaxSkew: time.Hour},
+			device: arrival.Add(2 * time.Hour),
+			want:   arrival,
+		},
+		"offset_within_max_skew": {
+			tc:     &types.TimestampConfig{Offset: -2 * time.Hour, MaxSkew: time.Hour},
+			device: arrival.Add(2 * time.Hour),
+			want:   arrival,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rsp := &gnmi.SubscribeResponse{
+				Response: &gnmi.SubscribeResponse_Update{
+					Update: &gnmi.Notification{Timestamp: tt.device.UnixNano()},
+				},
+			}
+			m := outputs.Meta{}
+			adjustTimestamp(tt.tc, rsp, arrival, m)
+			if got := rsp.GetUpdate().GetTimestamp(); got != tt.want.UnixNano() {
+				t.Errorf("expected %s, got %s", tt.want, time.Unix(0, got).UTC())
+			}
+			if len(m) != 0 {
+				t.Errorf("unexpected meta: %v", m)
+			}
+		})
+	}
+}
+
+func TestAdjustTimestampRecord(t *testing.T) {
+	arrival := time.Unix(0, 2000)
+	rsp := &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{Timestamp: 1000},
+		},
+	}
+	m := outputs.Meta{}
+	adjustTimestamp(&types.TimestampConfig{Source: types.TimestampSourceArrival, Record: true}, rsp, arrival, m)
+	if rsp.GetUpdate().GetTimestamp() != 2000 {
+		t.Errorf("unexpected timestamp %d", rsp.GetUpdate().GetTimestamp())
+	}
+	if m[formatters.DeviceTimestampMetaKey] != "1000" || m[formatters.ArrivalTimestampMetaKey] != "2000" {
+		t.Errorf("unexpected meta: %v", m)
+	}
+	// sync responses are not modified
+	m = outputs.Meta{}
+	adjustTimestamp(&types.TimestampConfig{Record: true}, &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true},
+	}, arrival, m)
+	if len(m) != 0 {
+		t.Errorf("unexpected meta: %v", m)
+	}
+}
@@ -0,0 +1,38 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/openconfig/gnmic/tracing"
+)
+
+const tracingShutdownTimeout = 5 * time.Second
+
+// startTracing starts exporting the telemetry pipeline spans if tracing is configured,
+// the returned function flushes the pending spans.
+func (a *App) startTracing() (func(), error) {
+	if a.Config.Tracing == nil {
+		return func() {}, nil
+	}
+	shutdown, err := tracing.Start(a.ctx, a.Config.Tracing)
+	if err != nil {
+		return nil, err
+	}
+	a.Logger.Printf("exporting traces to %q, sample-ratio=%v", a.Config.Tracing.Address, a.Config.Tracing.SampleRatio)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			a.Logger.Printf("failed to flush traces: %v", err)
+		}
+	}, nil
+}
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/openconfig/gnmic/config"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
 	tpb "github.com/openconfig/grpctunnel/proto/tunnel"
@@ -97,14 +98,19 @@ func (a *App) startTunnelServer(tsc tunnel.ServerConfig) error {
 
 func (a *App) gRPCTunnelServerOpts() ([]grpc.ServerOption, error) {
 	opts := make([]grpc.ServerOption, 0)
+	streamInterceptors := make([]grpc.StreamServerInterceptor, 0)
 	if a.Config.TunnelServer.EnableMetrics && a.reg != nil {
 		grpcMetrics := grpc_prometheus.NewServerMetrics()
-		opts = append(opts,
-			grpc.StreamInterceptor(grpcMetrics.StreamServerInterceptor()),
-			grpc.UnaryInterceptor(grpcMetrics.UnaryServerInterceptor()),
-		)
+		streamInterceptors = append(streamInterceptors, grpcMetrics.StreamServerInterceptor())
+		opts = append(opts, grpc.UnaryInterceptor(grpcMetrics.UnaryServerInterceptor()))
 		a.reg.MustRegister(grpcMetrics)
 	}
+	if a.Config.TunnelServer.ACL != nil {
+		streamInterceptors = append(streamInterceptors, a.tunnelACLStreamInterceptor)
+	}
+	if len(streamInterceptors) > 0 {
+		opts = append(opts, grpc.ChainStreamInterceptor(streamInterceptors...))
+	}
 
 	tlscfg, err := utils.NewTLSConfig(
 		a.Config.TunnelServer.CaFile,
@@ -116,6 +122,10 @@ func (a *App) gRPCTunnelServerOpts() ([]grpc.ServerOption, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = a.Config.TunnelServer.TLSPolicy.Apply(tlscfg)
+	if err != nil {
+		return nil, err
+	}
 	if tlscfg != nil {
 		opts = append(opts, grpc.Creds(credentials.NewTLS(tlscfg)))
 	}
@@ -125,6 +135,7 @@ func (a *App) gRPCTunnelServerOpts() ([]grpc.ServerOption, error) {
 
 func (a *App) tunServerAddTargetHandler(tt tunnel.Target) error {
 	a.Logger.Printf("tunnel server discovered target %+v", tt)
+	a.notifyTunnelWebhooks(config.TunnelEventRegister, tt)
 	tc := a.getTunnelTargetMatch(tt)
 	if tc == nil {
 		a.Logger.Printf("target %+v ignored", tt)
@@ -138,6 +149,7 @@ func (a *App) tunServerAddTargetHandler(tt tunnel.Target) error {
 
 func (a *App) tunServerAddTargetSubscribeHandler(tt tunnel.Target) error {
 	a.Logger.Printf("tunnel server discovered target %+v", tt)
+	a.notifyTunnelWebhooks(config.TunnelEventRegister, tt)
 	tc := a.getTunnelTargetMatch(tt)
 	if tc == nil {
 		a.Logger.Printf("target %+v ignored", tt)
@@ -162,6 +174,7 @@ func (a *App) tunServerAddTargetSubscribeHandler(tt tunnel.Target) error {
 
 func (a *App) tunServerDeleteTargetHandler(tt tunnel.Target) error {
 	a.Logger.Printf("tunnel server target %+v deregister request", tt)
+	a.notifyTunnelWebhooks(config.TunnelEventDeregister, tt)
 	a.ttm.Lock()
 	defer a.ttm.Unlock()
 	if cfn, ok := a.tunTargetCfn[tt]; ok {
@@ -0,0 +1,177 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	tpb "github.com/openconfig/grpctunnel/proto/tunnel"
+	"github.com/openconfig/grpctunnel/tunnel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// tunnelACLStreamInterceptor wraps the tunnel Register stream so that
+// target registrations are checked against the tunnel server ACL
+// before they reach the tunnel server.
+func (a *App) tunnelACLStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !strings.HasSuffix(info.FullMethod, "/Register") {
+		return handler(srv, ss)
+	}
+	return handler(srv, &tunnelACLStream{
+		ServerStream: ss,
+		a:            a,
+		cn:           peerCommonName(ss.Context()),
+		m:            new(sync.Mutex),
+	})
+}
+
+type tunnelACLStream struct {
+	grpc.ServerStream
+	a  *App
+	cn string
+	// serializes the sends from the tunnel server and the ACL rejections
+	m *sync.Mutex
+}
+
+func (s *tunnelACLStream) SendMsg(m interface{}) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *tunnelACLStream) RecvMsg(m interface{}) error {
+	for {
+		err := s.ServerStream.RecvMsg(m)
+		if err != nil {
+			return err
+		}
+		reg, ok := m.(*tpb.RegisterOp)
+		if !ok {
+			return nil
+		}
+		t := reg.GetTarget()
+		if t == nil || t.GetOp() != tpb.Target_ADD {
+			return nil
+		}
+		allowed, err := s.a.Config.TunnelTargetAllowed(t.GetTarget(), t.GetTargetType(), s.cn)
+		if err != nil {
+			s.a.Logger.Printf("failed to evaluate tunnel server acl for target {ID:%s Type:%s}: %v", t.GetTarget(), t.GetTargetType(), err)
+		}
+		if err == nil && allowed {
+			return nil
+		}
+		s.a.Logger.Printf("tunnel server acl denied target {ID:%s Type:%s} registration, client-cn=%q", t.GetTarget(), t.GetTargetType(), s.cn)
+		err = s.SendMsg(&tpb.RegisterOp{Registration: &tpb.RegisterOp_Target{Target: &tpb.Target{
+			Target:     t.GetTarget(),
+			TargetType: t.GetTargetType(),
+			Error:      "target registration denied",
+		}}})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func peerCommonName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ""
+	}
+	return tlsInfo.State.PeerCertificates[0].Subject.CommonName
+}
+
+type tunnelEvent struct {
+	Event string    `json:"event,omitempty"`
+	ID    string    `json:"id,omitempty"`
+	Type  string    `json:"type,omitempty"`
+	Time  time.Time `json:"time,omitempty"`
+}
+
+// notifyTunnelWebhooks sends the tunnel target event to the configured webhooks.
+func (a *App) notifyTunnelWebhooks(event string, tt tunnel.Target) {
+	if a.Config.TunnelServer == nil || len(a.Config.TunnelServer.Webhooks) == 0 {
+		return
+	}
+	b, err := json.Marshal(&tunnelEvent{
+		Event: event,
+		ID:    tt.ID,
+		Type:  tt.Type,
+		Time:  time.Now(),
+	})
+	if err != nil {
+		a.Logger.Printf("failed to marshal tunnel %s event: %v", event, err)
+		return
+	}
+	for _, wh := range a.Config.TunnelServer.Webhooks {
+		if !hasEvent(wh.Events, event) {
+			continue
+		}
+		go func(url string, headers map[string]string, timeout time.Duration, skipVerify bool) {
+			err := a.sendTunnelWebhook(url, headers, timeout, skipVerify, b)
+			if err != nil {
+				a.Logger.Printf("tunnel %s webhook for target %+v failed: %v", event, tt, err)
+			}
+		}(wh.URL, wh.Headers, wh.Timeout, wh.SkipVerify)
+	}
+}
+
+func (a *App) sendTunnelWebhook(url string, headers map[string]string, timeout time.Duration, skipVerify bool, b []byte) error {
+	client := &http.Client{
+		Timeout: timeout,
+	}
+	if skipVerify {
+		client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		}
+	}
+	ctx, cancel := context.WithTimeout(a.ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status: %s", rsp.Status)
+	}
+	return nil
+}
+
+func hasEvent(events []string, event string) bool {
+	for _, ev := range events {
+		if ev == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed ui
+var uiFiles embed.FS
+
+// uiRoutes serves the embedded web UI under /ui/.
+// The UI is a static page polling the status API endpoint.
+func (a *App) uiRoutes() {
+	sub, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		a.Logger.Printf("failed to load the web UI files: %v", err)
+		return
+	}
+	fileServer := http.StripPrefix("/ui/", http.FileServer(http.FS(sub)))
+	a.router.PathPrefix("/ui").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ui" {
+			http.Redirect(w, r, "/ui/", http.StatusMovedPermanently)
+			return
+		}
+		// let the file server set the content type
+		w.Header().Del("Content-Type")
+		fileServer.ServeHTTP(w, r)
+	}))
+}
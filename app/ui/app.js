@@ -0,0 +1,139 @@
+// gNMIc web UI, polls the status API endpoint and renders
+// the cluster membership and the targets state.
+(function () {
+  "use strict";
+
+  const statusURL = "../api/v1/status";
+  const refreshInterval = 5000;
+  const tokenKey = "gnmic-api-token";
+
+  // previous received counters, used to compute the message rates.
+  let previous = { time: 0, counters: {} };
+
+  function el(tag, attrs, children) {
+    const e = document.createElement(tag);
+    for (const [k, v] of Object.entries(attrs || {})) {
+      e.setAttribute(k, v);
+    }
+    for (const c of [].concat(children || [])) {
+      e.append(c instanceof Node ? c : document.createTextNode(c));
+    }
+    return e;
+  }
+
+  function formatTime(ts) {
+    if (!ts || ts.startsWith("0001-")) {
+      return "";
+    }
+    return new Date(ts).toLocaleString();
+  }
+
+  function renderCluster(status) {
+    const section = document.getElementById("cluster");
+    const clusterErr = document.getElementById("cluster-error");
+    section.hidden = !status.cluster && !status["cluster-error"];
+    clusterErr.hidden = !status["cluster-error"];
+    clusterErr.textContent = status["cluster-error"] || "";
+    if (!status.cluster) {
+      return;
+    }
+    document.getElementById("cluster-name").textContent = status.cluster.name || "";
+    const rows = (status.cluster.members || []).map((m) =>
+      el("tr", {}, [
+        el("td", {}, m.name || ""),
+        el("td", {}, m["api-endpoint"] || ""),
+        el("td", {}, m["is-leader"] ? "yes" : ""),
+        el("td", {}, String(m["number-of-locked-nodes"] || 0)),
+        el("td", {}, (m["locked-targets"] || []).sort().join(", ")),
+      ])
+    );
+    document.getElementById("members").replaceChildren(...rows);
+  }
+
+  function renderSubscriptions(target, now, counters) {
+    const subs = target.subscriptions || [];
+    if (subs.length === 0) {
+      return el("span", { class: "muted" }, "none");
+    }
+    const rows = subs.map((s) => {
+      const key = target.name + "/" + s.name;
+      counters[key] = s.received;
+      let rate = "";
+      if (previous.time && key in previous.counters) {
+        const elapsed = (now - previous.time) / 1000;
+        rate = ((s.received - previous.counters[key]) / elapsed).toFixed(1) + " msg/s";
+      }
+      return el("tr", {}, [
+        el("td", {}, s.name),
+        el("td", {}, s.running ? "running" : "stopped"),
+        el("td", {}, String(s.received) + " msgs"),
+        el("td", {}, rate),
+        el("td", { class: "muted" }, formatTime(s["last-received"])),
+      ]);
+    });
+    return el("table", { class: "subscriptions" }, rows);
+  }
+
+  function renderTargets(status) {
+    const now = Date.now();
+    const counters = {};
+    const targets = status.targets || [];
+    const rows = targets.map((t) => {
+      const h = t.health || {};
+      const state = h.state || "unknown";
+      return el("tr", {}, [
+        el("td", {}, t.name),
+        el("td", {}, t.address || ""),
+        el("td", {}, el("span", { class: "state state-" + state }, state)),
+        el("td", {}, formatTime(h.since)),
+        el("td", {}, String(h.flaps || 0)),
+        el("td", {}, renderSubscriptions(t, now, counters)),
+        el("td", { class: "error" }, t["last-error"] || ""),
+      ]);
+    });
+    previous = { time: now, counters: counters };
+    document.getElementById("targets-count").textContent = "(" + targets.length + ")";
+    document.getElementById("targets").replaceChildren(...rows);
+  }
+
+  function showError(msg) {
+    const e = document.getElementById("error");
+    e.hidden = !msg;
+    e.textContent = msg || "";
+  }
+
+  async function refresh() {
+    const headers = {};
+    const token = localStorage.getItem(tokenKey);
+    if (token) {
+      headers["Authorization"] = "Bearer " + token;
+    }
+    try {
+      const rsp = await fetch(statusURL, { headers: headers });
+      document.getElementById("auth").hidden = rsp.status !== 401;
+      if (!rsp.ok) {
+        const body = await rsp.json().catch(() => ({}));
+        showError((body.errors || [rsp.statusText]).join(", "));
+        return;
+      }
+      const status = await rsp.json();
+      showError("");
+      document.getElementById("instance").textContent = status.instance || "";
+      document.getElementById("version").textContent = status.version ? "version " + status.version : "";
+      document.getElementById("updated").textContent = "updated " + new Date().toLocaleTimeString();
+      renderCluster(status);
+      renderTargets(status);
+    } catch (err) {
+      showError("failed to get status: " + err);
+    }
+  }
+
+  document.getElementById("auth-form").addEventListener("submit", (ev) => {
+    ev.preventDefault();
+    localStorage.setItem(tokenKey, document.getElementById("auth-token").value);
+    refresh();
+  });
+
+  refresh();
+  setInterval(refresh, refreshInterval);
+})();
@@ -0,0 +1,95 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openconfig/gnmic/target"
+	"github.com/openconfig/gnmic/types"
+)
+
+func TestUIRoutes(t *testing.T) {
+	a := New()
+	a.routes()
+	a.uiRoutes()
+
+	tests := map[string]struct {
+		path        string
+		code        int
+		contentType string
+	}{
+		"index":    {path: "/ui/", code: http.StatusOK, contentType: "text/html"},
+		"script":   {path: "/ui/app.js", code: http.StatusOK, contentType: "text/javascript"},
+		"style":    {path: "/ui/style.css", code: http.StatusOK, contentType: "text/css"},
+		"redirect": {path: "/ui", code: http.StatusMovedPermanently},
+		"missing":  {path: "/ui/missing.js", code: http.StatusNotFound},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if rec.Code != tc.code {
+				t.Fatalf("expected status %d, got %d", tc.code, rec.Code)
+			}
+			if tc.contentType != "" && !strings.HasPrefix(rec.Header().Get("Content-Type"), tc.contentType) {
+				t.Errorf("expected content type %q, got %q", tc.contentType, rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestStatusGet(t *testing.T) {
+	a := New()
+	a.routes()
+	t1 := target.NewTarget(&types.TargetConfig{Name: "t1", Address: "10.0.0.1:57400"})
+	t1.Subscriptions["sub1"] = &types.SubscriptionConfig{Name: "sub1"}
+	t1.Subscriptions["sub2"] = &types.SubscriptionConfig{Name: "sub2"}
+	t1.ResponseReceived("sub1")
+	t1.ResponseReceived("sub1")
+	t1.SetUp()
+	a.Targets["t1"] = t1
+
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	rsp := new(statusResponse)
+	err := json.Unmarshal(rec.Body.Bytes(), rsp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp.Cluster != nil {
+		t.Errorf("unexpected cluster state: %+v", rsp.Cluster)
+	}
+	if len(rsp.Targets) != 1 {
+		t.Fatalf("expected 1 target, got %d", len(rsp.Targets))
+	}
+	ts := rsp.Targets[0]
+	if ts.Name != "t1" || ts.Address != "10.0.0.1:57400" {
+		t.Errorf("unexpected target: %+v", ts)
+	}
+	if ts.Health == nil || ts.Health.State != target.StateUp {
+		t.Errorf("unexpected target health: %+v", ts.Health)
+	}
+	if len(ts.Subscriptions) != 2 {
+		t.Fatalf("expected 2 subscriptions, got %d", len(ts.Subscriptions))
+	}
+	if ts.Subscriptions[0].Name != "sub1" || ts.Subscriptions[0].Received != 2 {
+		t.Errorf("unexpected subscription stats: %+v", ts.Subscriptions[0])
+	}
+	if ts.Subscriptions[1].Name != "sub2" || ts.Subscriptions[1].Received != 0 || ts.Subscriptions[1].Running {
+		t.Errorf("unexpected subscription stats: %+v", ts.Subscriptions[1])
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"strings"
 
 	"github.com/openconfig/gnmi/proto/gnmi"
+
+	"github.com/openconfig/gnmic/utils"
 )
 
 func (a *App) printCapResponse(printPrefix string, msg *gnmi.CapabilityResponse) {
@@ -50,3 +52,12 @@ func indent(prefix, s string) string {
 	lines := strings.Split(s, "\n")
 	return strings.TrimLeft(fmt.Sprintf("%s%s", prefix, strings.Join(lines, prefix)), "\n")
 }
+
+// xpath returns the xpath of p, prefixed with its origin if set.
+func xpath(p *gnmi.Path) string {
+	xp := "/" + utils.GnmiPathToXPath(&gnmi.Path{Elem: p.GetElem()}, false)
+	if p.GetOrigin() != "" {
+		return p.GetOrigin() + ":" + xp
+	}
+	return xp
+}
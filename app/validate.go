@@ -0,0 +1,105 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/openconfig/gnmic/config"
+)
+
+func (a *App) ValidateRunE(cmd *cobra.Command, args []string) error {
+	if a.Config.LocalFlags.ValidateSchema {
+		b, err := config.JSONSchema(a.configFlagKeys()...)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(a.out, string(b))
+		return nil
+	}
+	file := a.Config.FileConfig.ConfigFileUsed()
+	issues, err := a.Config.ValidateFile(cmd.Context(), a.configFlagKeys()...)
+	if err != nil {
+		return err
+	}
+	numErrs := 0
+	for _, vi := range issues {
+		if !vi.Warning {
+			numErrs++
+		}
+		fmt.Fprintf(a.out, "%s:%s\n", file, vi)
+	}
+	if numErrs > 0 {
+		return fmt.Errorf("found %d error(s) in config file %q", numErrs, file)
+	}
+	fmt.Fprintf(a.out, "config file %q is valid\n", file)
+	return nil
+}
+
+func (a *App) InitValidateFlags(cmd *cobra.Command) {
+	cmd.ResetFlags()
+
+	cmd.Flags().BoolVarP(&a.Config.LocalFlags.ValidateSchema, "schema", "", false, "print the configuration file JSON schema instead of validating the config file")
+
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
+	})
+}
+
+// checkConfigFile validates the config file in use when running with --strict-config.
+// warnings are logged, errors are returned.
+func (a *App) checkConfigFile() error {
+	if a.Config.FileConfig.ConfigFileUsed() == "" {
+		return nil
+	}
+	issues, err := a.Config.ValidateFile(a.Context(), a.configFlagKeys()...)
+	if err != nil {
+		return err
+	}
+	numErrs := 0
+	for _, vi := range issues {
+		if !vi.Warning {
+			numErrs++
+		}
+		a.Logger.Printf("config file %s:%s", a.Config.FileConfig.ConfigFileUsed(), vi)
+	}
+	if numErrs == 0 {
+		return nil
+	}
+	for _, vi := range issues {
+		if !vi.Warning {
+			fmt.Fprintf(os.Stderr, "%s:%s\n", a.Config.FileConfig.ConfigFileUsed(), vi)
+		}
+	}
+	return fmt.Errorf("found %d error(s) in config file %q", numErrs, a.Config.FileConfig.ConfigFileUsed())
+}
+
+// configFlagKeys returns the config file keys bound to the commands local flags.
+func (a *App) configFlagKeys() []string {
+	keys := make([]string, 0)
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for _, sub := range cmd.Commands() {
+			sub.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+				keys = append(keys, fmt.Sprintf("%s-%s", sub.Name(), flag.Name))
+			})
+			walk(sub)
+		}
+	}
+	if a.RootCmd != nil {
+		walk(a.RootCmd)
+	}
+	sort.Strings(keys)
+	return keys
+}
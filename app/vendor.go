@@ -0,0 +1,100 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// vendorProfile returns the vendor profile of target tc,
+// nil if the target has no vendor or if it is unknown.
+func vendorProfile(tc *types.TargetConfig) *types.VendorProfile {
+	if tc == nil || tc.Vendor == "" {
+		return nil
+	}
+	vp, err := types.GetVendorProfile(tc.Vendor)
+	if err != nil {
+		return nil
+	}
+	return vp
+}
+
+// vendorGetRequest returns a copy of req following the vendor conventions of target tc,
+// req is returned as is if the target has no vendor.
+func vendorGetRequest(tc *types.TargetConfig, req *gnmi.GetRequest) *gnmi.GetRequest {
+	vp := vendorProfile(tc)
+	if vp == nil || req == nil {
+		return req
+	}
+	req = proto.Clone(req).(*gnmi.GetRequest)
+	applyVendorOrigin(vp, req.GetPrefix(), req.GetPath()...)
+	return req
+}
+
+// vendorSetRequest returns a copy of req following the vendor conventions of target tc,
+// req is returned as is if the target has no vendor.
+func vendorSetRequest(tc *types.TargetConfig, req *gnmi.SetRequest) *gnmi.SetRequest {
+	vp := vendorProfile(tc)
+	if vp == nil || req == nil {
+		return req
+	}
+	req = proto.Clone(req).(*gnmi.SetRequest)
+	paths := make([]*gnmi.Path, 0, len(req.GetDelete())+len(req.GetReplace())+len(req.GetUpdate()))
+	paths = append(paths, req.GetDelete()...)
+	for _, upd := range req.GetReplace() {
+		paths = append(paths, upd.GetPath())
+	}
+	for _, upd := range req.GetUpdate() {
+		paths = append(paths, upd.GetPath())
+	}
+	applyVendorOrigin(vp, req.GetPrefix(), paths...)
+	return req
+}
+
+// vendorSubscribeRequest returns a copy of req following the vendor conventions of target tc,
+// req is returned as is if the target has no vendor or if it is not a subscription list.
+func vendorSubscribeRequest(tc *types.TargetConfig, req *gnmi.SubscribeRequest) *gnmi.SubscribeRequest {
+	vp := vendorProfile(tc)
+	if vp == nil || req.GetSubscribe() == nil {
+		return req
+	}
+	req = proto.Clone(req).(*gnmi.SubscribeRequest)
+	sl := req.GetSubscribe()
+	paths := make([]*gnmi.Path, 0, len(sl.GetSubscription()))
+	for _, sub := range sl.GetSubscription() {
+		paths = append(paths, sub.GetPath())
+	}
+	applyVendorOrigin(vp, sl.GetPrefix(), paths...)
+	return req
+}
+
+// applyVendorOrigin removes the origins rejected by the vendor from the prefix and paths,
+// then sets the vendor origin on the paths without one, unless the prefix has an origin.
+func applyVendorOrigin(vp *types.VendorProfile, prefix *gnmi.Path, paths ...*gnmi.Path) {
+	prefixOrigin := ""
+	if prefix != nil {
+		if vp.RejectsOrigin(prefix.GetOrigin()) {
+			prefix.Origin = ""
+		}
+		prefixOrigin = prefix.GetOrigin()
+	}
+	for _, p := range paths {
+		if p == nil {
+			continue
+		}
+		if vp.RejectsOrigin(p.GetOrigin()) {
+			p.Origin = ""
+		}
+		if p.GetOrigin() == "" && prefixOrigin == "" {
+			p.Origin = vp.Origin
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/types"
+)
+
+func testPath(origin, name string) *gnmi.Path {
+	return &gnmi.Path{Origin: origin, Elem: []*gnmi.PathElem{{Name: name}}}
+}
+
+func TestVendorGetRequest(t *testing.T) {
+	req := &gnmi.GetRequest{
+		Path: []*gnmi.Path{
+			testPath("", "interfaces"),
+			testPath("openconfig", "network-instances"),
+			testPath("cli", "show version"),
+		},
+	}
+	tests := []struct {
+		vendor string
+		want   []string
+	}{
+		{vendor: "", want: []string{"", "openconfig", "cli"}},
+		{vendor: "arista", want: []string{"openconfig", "openconfig", "cli"}},
+		{vendor: "juniper", want: []string{"", "", "cli"}},
+	}
+	for _, tt := range tests {
+		got := vendorGetRequest(&types.TargetConfig{Vendor: tt.vendor}, req)
+		for i, p := range got.GetPath() {
+			if p.GetOrigin() != tt.want[i] {
+				t.Errorf("vendor %q: path %d: expected origin %q, got %q", tt.vendor, i, tt.want[i], p.GetOrigin())
+			}
+		}
+	}
+	// the original request is not modified
+	if req.GetPath()[0].GetOrigin() != "" || req.GetPath()[1].GetOrigin() != "openconfig" {
+		t.Errorf("original request modified: %v", req)
+	}
+}
+
+func TestVendorPrefixOrigin(t *testing.T) {
+	tc := &types.TargetConfig{Vendor: "cisco"}
+	// the paths are left without origin if the prefix has one
+	req := vendorSetRequest(tc, &gnmi.SetRequest{
+		Prefix: &gnmi.Path{Origin: "Cisco-IOS-XR-native"},
+		Delete: []*gnmi.Path{testPath("", "interfaces")},
+	})
+	if req.GetDelete()[0].GetOrigin() != "" {
+		t.Errorf("expected no path origin with a prefix origin, got %q", req.GetDelete()[0].GetOrigin())
+	}
+	req = vendorSetRequest(tc, &gnmi.SetRequest{
+		Update: []*gnmi.Update{{Path: testPath("", "interfaces")}},
+	})
+	if req.GetUpdate()[0].GetPath().GetOrigin() != "openconfig" {
+		t.Errorf("expected the vendor origin, got %q", req.GetUpdate()[0].GetPath().GetOrigin())
+	}
+	sreq := vendorSubscribeRequest(&types.TargetConfig{Vendor: "nokia"}, &gnmi.SubscribeRequest{
+		Request: &gnmi.SubscribeRequest_Subscribe{
+			Subscribe: &gnmi.SubscriptionList{
+				Prefix:       &gnmi.Path{Origin: "openconfig"},
+				Subscription: []*gnmi.Subscription{{Path: testPath("", "interfaces")}},
+			},
+		},
+	})
+	if o := sreq.GetSubscribe().GetPrefix().GetOrigin(); o != "" {
+		t.Errorf("expected the rejected prefix origin to be removed, got %q", o)
+	}
+}
@@ -0,0 +1,213 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/itchyny/gojq"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/grpctunnel/tunnel"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func (a *App) WatchPreRunE(cmd *cobra.Command, args []string) error {
+	a.Config.SetLocalFlagsFromFile(cmd)
+	err := a.Config.ValidateWatchInput()
+	if err != nil {
+		return err
+	}
+
+	a.createCollectorDialOpts()
+	return a.initTunnelServer(tunnel.ServerConfig{
+		AddTargetHandler:    a.tunServerAddTargetHandler,
+		DeleteTargetHandler: a.tunServerDeleteTargetHandler,
+		RegisterHandler:     a.tunServerRegisterHandler,
+		Handler:             a.tunServerHandler,
+	})
+}
+
+func (a *App) WatchRunE(cmd *cobra.Command, args []string) error {
+	defer a.InitWatchFlags(cmd)
+
+	match, err := a.watchMatcher()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+	if a.Config.LocalFlags.WatchWaitTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, a.Config.LocalFlags.WatchWaitTimeout)
+		defer cancel()
+	}
+	targetsConfig, err := a.GetTargets()
+	if err != nil {
+		return fmt.Errorf("failed getting targets config: %v", err)
+	}
+	if !a.PromptMode {
+		for _, tc := range targetsConfig {
+			a.AddTargetConfig(tc)
+		}
+	}
+	numTargets := len(a.Config.Targets)
+	a.errCh = make(chan error, numTargets)
+	a.wg.Add(numTargets)
+	for _, tc := range a.Config.Targets {
+		go func(tc *types.TargetConfig) {
+			defer a.wg.Done()
+			err := a.watchTarget(ctx, tc, match)
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				a.logError(fmt.Errorf("target %q: condition not met after %s", tc.Name, a.Config.LocalFlags.WatchWaitTimeout))
+			case err != nil:
+				a.logError(fmt.Errorf("target %q: %v", tc.Name, err))
+			}
+		}(tc)
+	}
+	a.wg.Wait()
+	return a.checkErrors()
+}
+
+// eventMatcher reports whether an event satisfies the watch condition.
+type eventMatcher func(*formatters.EventMsg) (bool, error)
+
+// watchMatcher builds the eventMatcher from either the jq condition
+// or the CEL expression set by the user.
+func (a *App) watchMatcher() (eventMatcher, error) {
+	if a.Config.LocalFlags.WatchExpression != "" {
+		prg, err := formatters.CompileExpression(a.Config.LocalFlags.WatchExpression)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile expression: %v", err)
+		}
+		return func(e *formatters.EventMsg) (bool, error) {
+			return formatters.CheckExpression(prg, e)
+		}, nil
+	}
+	q, err := gojq.Parse(a.Config.LocalFlags.WatchCondition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse condition: %v", err)
+	}
+	code, err := gojq.Compile(q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile condition: %v", err)
+	}
+	return func(e *formatters.EventMsg) (bool, error) {
+		return formatters.CheckCondition(code, e)
+	}, nil
+}
+
+// watchTarget subscribes to the watched paths of target tc and returns
+// once one of the received events satisfies match.
+func (a *App) watchTarget(ctx context.Context, tc *types.TargetConfig, match eventMatcher) error {
+	a.operLock.Lock()
+	t, err := a.initTarget(tc)
+	a.operLock.Unlock()
+	if err != nil {
+		return err
+	}
+	// acquire reader lock
+	a.operLock.RLock()
+	err = a.CreateGNMIClient(ctx, t)
+	a.operLock.RUnlock()
+	if err != nil {
+		return err
+	}
+	req, err := a.Config.CreateWatchSubscribeRequest(tc.Name)
+	if err != nil {
+		return err
+	}
+	if a.Config.PrintRequest {
+		err = a.PrintMsg(tc.Name, "Subscribe Request:", req)
+		if err != nil {
+			return err
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	start := time.Now()
+	rspCh, errCh := t.SubscribeStreamChan(ctx, req)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errCh:
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("subscription failed: %v", err)
+		case rsp := <-rspCh:
+			ev, err := a.watchMatch(tc.Name, rsp, match)
+			if err != nil {
+				return err
+			}
+			if ev == nil {
+				continue
+			}
+			a.Logger.Printf("target %q: condition met after %s", tc.Name, time.Since(start))
+			b, err := json.MarshalIndent(ev, "", "  ")
+			if err != nil {
+				return err
+			}
+			a.printLock.Lock()
+			fmt.Fprintf(os.Stdout, "target %q: condition met:\n%s\n", tc.Name, string(b))
+			a.printLock.Unlock()
+			return nil
+		}
+	}
+}
+
+// watchMatch returns the first event of rsp satisfying match, nil if there is none.
+func (a *App) watchMatch(name string, rsp *gnmi.SubscribeResponse, match eventMatcher) (*formatters.EventMsg, error) {
+	if rsp.GetUpdate() == nil {
+		return nil, nil
+	}
+	evs, err := formatters.ResponseToEventMsgs("watch", rsp, map[string]string{"source": name})
+	if err != nil {
+		return nil, err
+	}
+	for _, ev := range evs {
+		ok, err := match(ev)
+		if err != nil {
+			if a.Config.Debug {
+				a.Logger.Printf("target %q: condition evaluation failed: %v", name, err)
+			}
+			continue
+		}
+		if ok {
+			return ev, nil
+		}
+	}
+	return nil, nil
+}
+
+// InitWatchFlags used to init or reset watchCmd flags for gnmic-prompt mode
+func (a *App) InitWatchFlags(cmd *cobra.Command) {
+	cmd.ResetFlags()
+
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.WatchPrefix, "prefix", "", "", "subscribe request prefix")
+	cmd.Flags().StringArrayVarP(&a.Config.LocalFlags.WatchPath, "path", "", []string{}, "subscribe request paths")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.WatchStreamMode, "stream-mode", "", "on-change", "one of: on-change, sample, target-defined")
+	cmd.Flags().DurationVarP(&a.Config.LocalFlags.WatchSampleInterval, "sample-interval", "i", 0,
+		"sample interval as a decimal number and a suffix unit, such as \"10s\" or \"1m30s\"")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.WatchCondition, "condition", "", "", "jq expression evaluated against each received event, the command exits once it returns true")
+	cmd.Flags().StringVarP(&a.Config.LocalFlags.WatchExpression, "expression", "", "", "CEL expression evaluated against each received event, alternative to --condition")
+	cmd.Flags().DurationVarP(&a.Config.LocalFlags.WatchWaitTimeout, "wait-timeout", "", 0, "maximum time to wait for the condition, 0 means no limit")
+	//
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		a.Config.FileConfig.BindPFlag(fmt.Sprintf("%s-%s", cmd.Name(), flag.Name), flag)
+	})
+}
@@ -0,0 +1,91 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"io"
+	"log"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/config"
+)
+
+func watchTestResponse(ifName, status string) *gnmi.SubscribeResponse {
+	return &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Timestamp: 42,
+				Prefix: &gnmi.Path{Elem: []*gnmi.PathElem{
+					{Name: "interface", Key: map[string]string{"name": ifName}},
+				}},
+				Update: []*gnmi.Update{
+					{
+						Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "oper-state"}}},
+						Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: status}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestWatchMatch(t *testing.T) {
+	tests := map[string]struct {
+		rsp   *gnmi.SubscribeResponse
+		match bool
+	}{
+		"match": {
+			rsp:   watchTestResponse("ethernet-1/1", "up"),
+			match: true,
+		},
+		"other_value": {
+			rsp:   watchTestResponse("ethernet-1/1", "down"),
+			match: false,
+		},
+		"other_interface": {
+			rsp:   watchTestResponse("ethernet-1/2", "up"),
+			match: false,
+		},
+		"sync_response": {
+			rsp:   &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true}},
+			match: false,
+		},
+	}
+	conditions := map[string]func(*config.Config){
+		"jq": func(c *config.Config) {
+			c.LocalFlags.WatchCondition = `.tags.interface_name == "ethernet-1/1" and .values["/interface/oper-state"] == "up"`
+		},
+		"cel": func(c *config.Config) {
+			c.LocalFlags.WatchExpression = `tags.interface_name == "ethernet-1/1" && values["/interface/oper-state"] == "up"`
+		},
+	}
+	for cname, setCond := range conditions {
+		a := &App{Config: config.New(), Logger: log.New(io.Discard, "", 0)}
+		setCond(a.Config)
+		match, err := a.watchMatcher()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, tc := range tests {
+			t.Run(cname+"_"+name, func(t *testing.T) {
+				ev, err := a.watchMatch("router1", tc.rsp, match)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if (ev != nil) != tc.match {
+					t.Errorf("expected match=%v, got %+v", tc.match, ev)
+				}
+				if ev != nil && ev.Tags["source"] != "router1" {
+					t.Errorf("unexpected event tags: %v", ev.Tags)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,257 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package audit records the Set requests sent to the targets
+// and the mutating API calls in an append-only log.
+// The records can be chained with an HMAC to make them tamper evident.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	KindSet = "set"
+	KindAPI = "api"
+
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Config is the audit log configuration.
+type Config struct {
+	// path of the file the records are appended to, as JSON lines
+	File string `mapstructure:"file,omitempty" json:"file,omitempty"`
+	// names of the outputs the records are written to as events
+	Outputs []string `mapstructure:"outputs,omitempty" json:"outputs,omitempty"`
+	// key used to chain the records with an HMAC-SHA256, the chaining is disabled if empty
+	HMACKey string `mapstructure:"hmac-key,omitempty" json:"hmac-key,omitempty"`
+	// file holding the HMAC key, used if HMACKey is not set
+	HMACKeyFile string `mapstructure:"hmac-key-file,omitempty" json:"hmac-key-file,omitempty"`
+}
+
+// Validate checks that the config has at least one destination
+// and loads the HMAC key from its file.
+func (c *Config) Validate() error {
+	if c.File == "" && len(c.Outputs) == 0 {
+		return errors.New("audit: a file or at least one output must be set")
+	}
+	if c.HMACKey == "" && c.HMACKeyFile != "" {
+		b, err := os.ReadFile(c.HMACKeyFile)
+		if err != nil {
+			return fmt.Errorf("audit: failed to read the HMAC key file: %v", err)
+		}
+		c.HMACKey = string(bytes.TrimSpace(b))
+		if c.HMACKey == "" {
+			return fmt.Errorf("audit: HMAC key file %q is empty", c.HMACKeyFile)
+		}
+	}
+	return nil
+}
+
+// Record is an audit log entry.
+type Record struct {
+	Sequence  uint64    `json:"sequence"`
+	Timestamp time.Time `json:"timestamp"`
+	// set or api
+	Kind string `json:"kind"`
+	// the user that triggered the operation and its address
+	User   string `json:"user,omitempty"`
+	Client string `json:"client,omitempty"`
+	Target string `json:"target,omitempty"`
+	// API call method, path and response status code
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Status int    `json:"status,omitempty"`
+	// Set request operations paths, prefixed with the operation type, e.g: update:/interface
+	Paths []string `json:"paths,omitempty"`
+	// SHA256 of the Set request values
+	ValuesHash string `json:"values-hash,omitempty"`
+	Result     string `json:"result"`
+	Error      string `json:"error,omitempty"`
+	// HMAC of the previous record HMAC and of this record without its HMAC
+	HMAC string `json:"hmac,omitempty"`
+}
+
+// Sink receives the records written to the audit log.
+type Sink func(*Record)
+
+// Log is an append-only audit log.
+type Log struct {
+	m     sync.Mutex
+	w     io.WriteCloser
+	key   []byte
+	seq   uint64
+	prev  string
+	sinks []Sink
+	now   func() time.Time
+}
+
+// New returns an audit log writing to the config file, if set,
+// and to the sinks. When the file exists, the records sequence
+// and HMAC chain continue from its last record.
+func New(cfg *Config, sinks ...Sink) (*Log, error) {
+	l := &Log{
+		key:   []byte(cfg.HMACKey),
+		sinks: sinks,
+		now:   time.Now,
+	}
+	if cfg.File == "" {
+		return l, nil
+	}
+	last, err := lastRecord(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		l.seq = last.Sequence
+		l.prev = last.HMAC
+	}
+	l.w, err = os.OpenFile(cfg.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to open %q: %v", cfg.File, err)
+	}
+	return l, nil
+}
+
+// Write sets the record sequence, timestamp and HMAC,
+// then appends it to the log file and hands it to the sinks.
+func (l *Log) Write(r *Record) error {
+	if l == nil {
+		return nil
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.seq++
+	r.Sequence = l.seq
+	r.Timestamp = l.now().UTC()
+	if r.Result == "" {
+		r.Result = ResultSuccess
+	}
+	if len(l.key) > 0 {
+		mac, err := sign(l.key, l.prev, r)
+		if err != nil {
+			return err
+		}
+		r.HMAC = mac
+		l.prev = mac
+	}
+	for _, s := range l.sinks {
+		s(r)
+	}
+	if l.w == nil {
+		return nil
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = l.w.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	if l == nil || l.w == nil {
+		return nil
+	}
+	return l.w.Close()
+}
+
+// sign returns the hex encoded HMAC of the previous record HMAC prev
+// and of the record r without its HMAC.
+func sign(key []byte, prev string, r *Record) (string, error) {
+	nr := *r
+	nr.HMAC = ""
+	b, err := json.Marshal(nr)
+	if err != nil {
+		return "", err
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(prev))
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify reads the records from rd and checks their HMAC chain with key.
+// It returns the number of verified records and an error pointing to
+// the first record that was modified, removed or inserted.
+func Verify(rd io.Reader, key []byte) (int, error) {
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var prev string
+	var seq uint64
+	n := 0
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		r := new(Record)
+		err := json.Unmarshal(sc.Bytes(), r)
+		if err != nil {
+			return n, fmt.Errorf("line %d: %v", n+1, err)
+		}
+		if n > 0 && r.Sequence != seq+1 {
+			return n, fmt.Errorf("record %d: unexpected sequence, expected %d", r.Sequence, seq+1)
+		}
+		mac, err := sign(key, prev, r)
+		if err != nil {
+			return n, err
+		}
+		if !hmac.Equal([]byte(mac), []byte(r.HMAC)) {
+			return n, fmt.Errorf("record %d: HMAC mismatch", r.Sequence)
+		}
+		prev = r.HMAC
+		seq = r.Sequence
+		n++
+	}
+	return n, sc.Err()
+}
+
+// lastRecord returns the last record of the file, nil if it does not exist or is empty.
+func lastRecord(file string) (*Record, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("audit: failed to open %q: %v", file, err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var last []byte
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) > 0 {
+			last = append(last[:0], sc.Bytes()...)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("audit: failed to read %q: %v", file, err)
+	}
+	if last == nil {
+		return nil, nil
+	}
+	r := new(Record)
+	err = json.Unmarshal(last, r)
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to decode the last record of %q: %v", file, err)
+	}
+	return r, nil
+}
@@ -0,0 +1,117 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func TestLogChain(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	cfg := &Config{File: file, HMACKey: "secret"}
+	var sunk []*Record
+	l, err := New(cfg, func(r *Record) { sunk = append(sunk, r) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []*Record{
+		{Kind: KindAPI, User: "admin", Method: "POST", Path: "/api/v1/config/targets", Status: 200},
+		{Kind: KindSet, Target: "router1", Paths: []string{"update:/system/name"}},
+	} {
+		if err := l.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+	if len(sunk) != 2 || sunk[1].Sequence != 2 {
+		t.Fatalf("unexpected records handed to the sink: %+v", sunk)
+	}
+
+	// the sequence and the chain continue after a restart
+	l, err = New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Write(&Record{Kind: KindSet, Target: "router2", Result: ResultFailure}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Verify(bytes.NewReader(b), []byte(cfg.HMACKey))
+	if err != nil || n != 3 {
+		t.Fatalf("verify: n=%d, err=%v", n, err)
+	}
+	if _, err = Verify(bytes.NewReader(b), []byte("other")); err == nil {
+		t.Error("expected an HMAC mismatch with a different key")
+	}
+
+	lines := strings.SplitAfter(string(b), "\n")
+	tampered := map[string]string{
+		"modified": strings.Replace(string(b), "router1", "router9", 1),
+		"removed":  lines[0] + lines[2],
+	}
+	for name, s := range tampered {
+		if _, err := Verify(strings.NewReader(s), []byte(cfg.HMACKey)); err == nil {
+			t.Errorf("%s: expected a verification error", name)
+		}
+	}
+}
+
+func TestSetRecord(t *testing.T) {
+	req := &gnmi.SetRequest{
+		Prefix: &gnmi.Path{Origin: "openconfig", Elem: []*gnmi.PathElem{{Name: "system"}}},
+		Delete: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "ntp"}}}},
+		Update: []*gnmi.Update{{
+			Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "config"}, {Name: "hostname"}}},
+			Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "router1"}},
+		}},
+	}
+	r := SetRecord("router1", req, errors.New("permission denied"))
+	want := []string{"delete:openconfig:/system/ntp", "update:openconfig:/system/config/hostname"}
+	if !reflect.DeepEqual(r.Paths, want) {
+		t.Errorf("got paths %v, want %v", r.Paths, want)
+	}
+	if r.Result != ResultFailure || r.Error != "permission denied" {
+		t.Errorf("unexpected result %q, error %q", r.Result, r.Error)
+	}
+	// same values, same hash
+	if h := SetRecord("router2", req, nil).ValuesHash; h == "" || h != r.ValuesHash {
+		t.Errorf("unexpected values hash %q, want %q", h, r.ValuesHash)
+	}
+	req.Update[0].Val = &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "router2"}}
+	if SetRecord("router1", req, nil).ValuesHash == r.ValuesHash {
+		t.Error("expected a different values hash")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := (&Config{}).Validate(); err == nil {
+		t.Error("expected a missing destination error")
+	}
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{Outputs: []string{"out1"}, HMACKeyFile: keyFile}
+	if err := cfg.Validate(); err != nil || cfg.HMACKey != "secret" {
+		t.Errorf("unexpected key %q, err=%v", cfg.HMACKey, err)
+	}
+}
@@ -0,0 +1,118 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/utils"
+)
+
+// SetRecord returns the record of the Set request req sent to target,
+// err is the request error, if any.
+func SetRecord(target string, req *gnmi.SetRequest, err error) *Record {
+	r := &Record{
+		Kind:   KindSet,
+		Target: target,
+		Result: ResultSuccess,
+	}
+	prefix := req.GetPrefix()
+	for _, p := range req.GetDelete() {
+		r.Paths = append(r.Paths, "delete:"+xpath(prefix, p))
+	}
+	h := sha256.New()
+	mo := proto.MarshalOptions{Deterministic: true}
+	for _, op := range []struct {
+		name string
+		upds []*gnmi.Update
+	}{
+		{"replace", req.GetReplace()},
+		{"update", req.GetUpdate()},
+	} {
+		for _, upd := range op.upds {
+			r.Paths = append(r.Paths, op.name+":"+xpath(prefix, upd.GetPath()))
+			b, _ := mo.Marshal(upd.GetVal())
+			h.Write(b)
+		}
+	}
+	if len(r.Paths) > len(req.GetDelete()) {
+		r.ValuesHash = hex.EncodeToString(h.Sum(nil))
+	}
+	if err != nil {
+		r.Result = ResultFailure
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// xpath returns the xpath of p, relative to prefix.
+func xpath(prefix, p *gnmi.Path) string {
+	origin := prefix.GetOrigin()
+	if origin == "" {
+		origin = p.GetOrigin()
+	}
+	x := "/" + utils.GnmiPathToXPath(&gnmi.Path{Elem: utils.PathElems(prefix, p)}, false)
+	if origin != "" {
+		return origin + ":" + x
+	}
+	return x
+}
+
+// EventMsg returns the record as an event named "audit",
+// written to the audit outputs.
+func (r *Record) EventMsg() *formatters.EventMsg {
+	ev := &formatters.EventMsg{
+		Name:      "audit",
+		Timestamp: r.Timestamp.UnixNano(),
+		Tags: map[string]string{
+			"kind":   r.Kind,
+			"result": r.Result,
+		},
+		Values: map[string]interface{}{
+			"sequence": r.Sequence,
+		},
+	}
+	if r.Timestamp.IsZero() {
+		ev.Timestamp = time.Now().UnixNano()
+	}
+	for k, v := range map[string]string{
+		"user":   r.User,
+		"client": r.Client,
+		"target": r.Target,
+		"method": r.Method,
+		"path":   r.Path,
+	} {
+		if v != "" {
+			ev.Tags[k] = v
+		}
+	}
+	if r.Status != 0 {
+		ev.Values["status"] = r.Status
+	}
+	for i, p := range r.Paths {
+		ev.Values["paths/"+strconv.Itoa(i)] = p
+	}
+	for k, v := range map[string]string{
+		"values-hash": r.ValuesHash,
+		"error":       r.Error,
+		"hmac":        r.HMAC,
+	} {
+		if v != "" {
+			ev.Values[k] = v
+		}
+	}
+	return ev
+}
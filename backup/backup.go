@@ -0,0 +1,154 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package backup stores and compares configuration snapshots of gNMI targets.
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	StoreTypeFile = "file"
+	StoreTypeS3   = "s3"
+	StoreTypeGit  = "git"
+)
+
+// snapshotIDLayout is the time layout used to name file and s3 snapshots,
+// it sorts lexically in chronological order.
+const snapshotIDLayout = "20060102T150405.000Z"
+
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// Snapshot describes a stored configuration snapshot.
+type Snapshot struct {
+	ID        string    `json:"id,omitempty"`
+	Target    string    `json:"target,omitempty"`
+	Timestamp time.Time `json:"timestamp,omitempty"`
+}
+
+// Store persists snapshots.
+// List returns the snapshots of a target ordered from the oldest to the newest.
+type Store interface {
+	Save(ctx context.Context, target string, ts time.Time, b []byte) (*Snapshot, error)
+	List(ctx context.Context, target string) ([]*Snapshot, error)
+	Read(ctx context.Context, target, id string) ([]byte, error)
+	Delete(ctx context.Context, target, id string) error
+}
+
+// NewStore creates a Store from the storage configuration cfg,
+// the store type is set under the key "type", it defaults to file.
+func NewStore(ctx context.Context, cfg map[string]interface{}, logger *log.Logger) (Store, error) {
+	typ, _ := cfg["type"].(string)
+	switch typ {
+	case "", StoreTypeFile:
+		return newFileStore(cfg, logger)
+	case StoreTypeS3:
+		return newS3Store(cfg, logger)
+	case StoreTypeGit:
+		return newGitStore(ctx, cfg, logger)
+	}
+	return nil, fmt.Errorf("unknown backup storage type %q", typ)
+}
+
+// Prune deletes the oldest snapshots of target, keeping the last retention ones.
+func Prune(ctx context.Context, s Store, target string, retention int) (int, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+	snaps, err := s.List(ctx, target)
+	if err != nil {
+		return 0, err
+	}
+	n := len(snaps) - retention
+	if n <= 0 {
+		return 0, nil
+	}
+	for _, snap := range snaps[:n] {
+		err = s.Delete(ctx, target, snap.ID)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// Change is a single difference between two snapshots.
+type Change struct {
+	Path string      `json:"path,omitempty"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Diff compares two snapshots made of flattened path/value JSON objects,
+// a change with a nil Old value is an addition, a nil New value is a deletion.
+func Diff(from, to []byte) ([]*Change, error) {
+	m1 := make(map[string]interface{})
+	err := json.Unmarshal(from, &m1)
+	if err != nil {
+		return nil, err
+	}
+	m2 := make(map[string]interface{})
+	err = json.Unmarshal(to, &m2)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]*Change, 0)
+	for p, v1 := range m1 {
+		v2, ok := m2[p]
+		switch {
+		case !ok:
+			changes = append(changes, &Change{Path: p, Old: v1})
+		case !reflect.DeepEqual(v1, v2):
+			changes = append(changes, &Change{Path: p, Old: v1, New: v2})
+		}
+	}
+	for p, v2 := range m2 {
+		if _, ok := m1[p]; !ok {
+			changes = append(changes, &Change{Path: p, New: v2})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+func decodeConfig(src, dst interface{}) error {
+	decoder, err := mapstructure.NewDecoder(
+		&mapstructure.DecoderConfig{
+			DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+			Result:     dst,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(src)
+}
+
+func snapshotID(ts time.Time) string {
+	return ts.UTC().Format(snapshotIDLayout)
+}
+
+func parseSnapshotID(target, id string) (*Snapshot, bool) {
+	ts, err := time.Parse(snapshotIDLayout, id)
+	if err != nil {
+		return nil, false
+	}
+	return &Snapshot{ID: id, Target: target, Timestamp: ts}, true
+}
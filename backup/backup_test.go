@@ -0,0 +1,120 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"context"
+	"io"
+	"log"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewStore(ctx, map[string]interface{}{
+		"type":      "file",
+		"directory": t.TempDir(),
+	}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s, true)
+}
+
+func TestGitStore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	ctx := context.Background()
+	s, err := NewStore(ctx, map[string]interface{}{
+		"type":      "git",
+		"directory": t.TempDir(),
+	}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s, false)
+}
+
+func testStore(t *testing.T, s Store, prunable bool) {
+	ctx := context.Background()
+	snaps, err := s.List(ctx, "router1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 0 {
+		t.Fatalf("expected no snapshots, got %d", len(snaps))
+	}
+	now := time.Now()
+	contents := []string{`{"/a":1}`, `{"/a":2}`, `{"/a":3}`}
+	for i, c := range contents {
+		_, err = s.Save(ctx, "router1", now.Add(time.Duration(i)*time.Second), []byte(c))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	snaps, err = s.List(ctx, "router1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != len(contents) {
+		t.Fatalf("expected %d snapshots, got %d", len(contents), len(snaps))
+	}
+	for i, snap := range snaps {
+		b, err := s.Read(ctx, "router1", snap.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != contents[i] {
+			t.Errorf("snapshot %d: expected %s, got %s", i, contents[i], b)
+		}
+	}
+	_, err = s.Read(ctx, "router1", "unknown")
+	if err != ErrSnapshotNotFound {
+		t.Errorf("expected ErrSnapshotNotFound, got %v", err)
+	}
+	if !prunable {
+		return
+	}
+	n, err := Prune(ctx, s, "router1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 deleted snapshots, got %d", n)
+	}
+	snaps, err = s.List(ctx, "router1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 1 {
+		t.Fatalf("expected 1 snapshot, got %d", len(snaps))
+	}
+}
+
+func TestDiff(t *testing.T) {
+	changes, err := Diff(
+		[]byte(`{"/a": 1, "/b": "x", "/c": true}`),
+		[]byte(`{"/a": 2, "/b": "x", "/d": [1, 2]}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Change{
+		{Path: "/a", Old: float64(1), New: float64(2)},
+		{Path: "/c", Old: true},
+		{Path: "/d", New: []interface{}{float64(1), float64(2)}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+}
@@ -0,0 +1,108 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const snapshotFileExt = ".json"
+
+// fileStore writes the snapshots of each target
+// under $directory/$target/$id.json
+type fileStore struct {
+	cfg    *fileStoreConfig
+	logger *log.Logger
+}
+
+type fileStoreConfig struct {
+	Type      string `mapstructure:"type,omitempty" json:"type,omitempty"`
+	Directory string `mapstructure:"directory,omitempty" json:"directory,omitempty"`
+}
+
+func newFileStore(cfg map[string]interface{}, logger *log.Logger) (*fileStore, error) {
+	s := &fileStore{
+		cfg:    new(fileStoreConfig),
+		logger: logger,
+	}
+	err := decodeConfig(cfg, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.Directory == "" {
+		return nil, errors.New("file backup storage: missing directory")
+	}
+	err = os.MkdirAll(s.cfg.Directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileStore) Save(ctx context.Context, target string, ts time.Time, b []byte) (*Snapshot, error) {
+	dir := filepath.Join(s.cfg.Directory, target)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	id := snapshotID(ts)
+	err = os.WriteFile(filepath.Join(dir, id+snapshotFileExt), b, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{ID: id, Target: target, Timestamp: ts}, nil
+}
+
+func (s *fileStore) List(ctx context.Context, target string) ([]*Snapshot, error) {
+	entries, err := os.ReadDir(filepath.Join(s.cfg.Directory, target))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snaps := make([]*Snapshot, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), snapshotFileExt) {
+			continue
+		}
+		if snap, ok := parseSnapshotID(target, strings.TrimSuffix(e.Name(), snapshotFileExt)); ok {
+			snaps = append(snaps, snap)
+		}
+	}
+	sort.Slice(snaps, func(i, j int) bool {
+		return snaps[i].ID < snaps[j].ID
+	})
+	return snaps, nil
+}
+
+func (s *fileStore) Read(ctx context.Context, target, id string) ([]byte, error) {
+	if _, ok := parseSnapshotID(target, id); !ok {
+		return nil, ErrSnapshotNotFound
+	}
+	b, err := os.ReadFile(filepath.Join(s.cfg.Directory, target, id+snapshotFileExt))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSnapshotNotFound
+	}
+	return b, err
+}
+
+func (s *fileStore) Delete(ctx context.Context, target, id string) error {
+	if _, ok := parseSnapshotID(target, id); !ok {
+		return ErrSnapshotNotFound
+	}
+	return os.Remove(filepath.Join(s.cfg.Directory, target, id+snapshotFileExt))
+}
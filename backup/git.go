@@ -0,0 +1,187 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGitAuthorName  = "gnmic"
+	defaultGitAuthorEmail = "gnmic@localhost"
+)
+
+// gitStore keeps the last snapshot of each target in $directory/$target.json
+// and commits every change to a git repository, the snapshots IDs are the commit hashes.
+// Snapshots are never deleted, the repository keeps the full history.
+type gitStore struct {
+	cfg    *gitStoreConfig
+	m      *sync.Mutex
+	logger *log.Logger
+}
+
+type gitStoreConfig struct {
+	Type      string `mapstructure:"type,omitempty" json:"type,omitempty"`
+	Directory string `mapstructure:"directory,omitempty" json:"directory,omitempty"`
+	// remote to push the commits to, e.g: origin
+	Remote      string `mapstructure:"remote,omitempty" json:"remote,omitempty"`
+	AuthorName  string `mapstructure:"author-name,omitempty" json:"author-name,omitempty"`
+	AuthorEmail string `mapstructure:"author-email,omitempty" json:"author-email,omitempty"`
+}
+
+func newGitStore(ctx context.Context, cfg map[string]interface{}, logger *log.Logger) (*gitStore, error) {
+	s := &gitStore{
+		cfg:    new(gitStoreConfig),
+		m:      new(sync.Mutex),
+		logger: logger,
+	}
+	err := decodeConfig(cfg, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.Directory == "" {
+		return nil, errors.New("git backup storage: missing directory")
+	}
+	if s.cfg.AuthorName == "" {
+		s.cfg.AuthorName = defaultGitAuthorName
+	}
+	if s.cfg.AuthorEmail == "" {
+		s.cfg.AuthorEmail = defaultGitAuthorEmail
+	}
+	err = os.MkdirAll(s.cfg.Directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = os.Stat(filepath.Join(s.cfg.Directory, ".git")); errors.Is(err, os.ErrNotExist) {
+		_, err = s.git(ctx, "init")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *gitStore) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = s.cfg.Directory
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+s.cfg.AuthorName,
+		"GIT_AUTHOR_EMAIL="+s.cfg.AuthorEmail,
+		"GIT_COMMITTER_NAME="+s.cfg.AuthorName,
+		"GIT_COMMITTER_EMAIL="+s.cfg.AuthorEmail,
+	)
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (s *gitStore) Save(ctx context.Context, target string, ts time.Time, b []byte) (*Snapshot, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	fname := target + snapshotFileExt
+	err := os.WriteFile(filepath.Join(s.cfg.Directory, fname), b, 0644)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.git(ctx, "add", "--", fname)
+	if err != nil {
+		return nil, err
+	}
+	// nothing to commit if the configuration did not change
+	_, err = s.git(ctx, "diff", "--cached", "--quiet", "--", fname)
+	if err != nil {
+		_, err = s.git(ctx, "commit", "-m", fmt.Sprintf("%s: %s", target, ts.UTC().Format(time.RFC3339)),
+			"--date", ts.UTC().Format(time.RFC3339), "--", fname)
+		if err != nil {
+			return nil, err
+		}
+		if s.cfg.Remote != "" {
+			_, err = s.git(ctx, "push", s.cfg.Remote, "HEAD")
+			if err != nil {
+				s.logger.Printf("failed to push snapshot of %q: %v", target, err)
+			}
+		}
+	}
+	out, err := s.git(ctx, "log", "-1", "--format=%H", "--", fname)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{ID: strings.TrimSpace(string(out)), Target: target, Timestamp: ts}, nil
+}
+
+func (s *gitStore) List(ctx context.Context, target string) ([]*Snapshot, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	out, err := s.git(ctx, "log", "--reverse", "--format=%H %at", "--", target+snapshotFileExt)
+	if err != nil {
+		// no commits yet
+		if _, herr := s.git(ctx, "rev-parse", "--verify", "HEAD"); herr != nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	snaps := make([]*Snapshot, 0)
+	for _, l := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(l)
+		if len(fields) != 2 {
+			continue
+		}
+		sec, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, &Snapshot{ID: fields[0], Target: target, Timestamp: time.Unix(sec, 0).UTC()})
+	}
+	return snaps, nil
+}
+
+func (s *gitStore) Read(ctx context.Context, target, id string) ([]byte, error) {
+	if !isCommitHash(id) {
+		return nil, ErrSnapshotNotFound
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	out, err := s.git(ctx, "show", id+":"+target+snapshotFileExt)
+	if err != nil {
+		return nil, ErrSnapshotNotFound
+	}
+	return out, nil
+}
+
+// Delete is a noop, the git history is kept.
+func (s *gitStore) Delete(ctx context.Context, target, id string) error {
+	return nil
+}
+
+func isCommitHash(id string) bool {
+	if len(id) < 4 || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
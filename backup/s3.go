@@ -0,0 +1,155 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// s3Store writes the snapshots of each target
+// as objects with key $prefix/$target/$id.json
+type s3Store struct {
+	cfg    *s3StoreConfig
+	client *s3.S3
+	logger *log.Logger
+}
+
+type s3StoreConfig struct {
+	Type   string `mapstructure:"type,omitempty" json:"type,omitempty"`
+	Bucket string `mapstructure:"bucket,omitempty" json:"bucket,omitempty"`
+	// object keys prefix
+	Prefix string `mapstructure:"prefix,omitempty" json:"prefix,omitempty"`
+	// AWS region, defaults to the AWS_REGION env variable or the shared config
+	Region string `mapstructure:"region,omitempty" json:"region,omitempty"`
+	// shared config profile name
+	Profile string `mapstructure:"profile,omitempty" json:"profile,omitempty"`
+	// custom endpoint, e.g: a MinIO server
+	Endpoint string `mapstructure:"endpoint,omitempty" json:"endpoint,omitempty"`
+	// use path style addressing, required by most S3 compatible servers
+	ForcePathStyle bool `mapstructure:"force-path-style,omitempty" json:"force-path-style,omitempty"`
+}
+
+func newS3Store(cfg map[string]interface{}, logger *log.Logger) (*s3Store, error) {
+	s := &s3Store{
+		cfg:    new(s3StoreConfig),
+		logger: logger,
+	}
+	err := decodeConfig(cfg, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.Bucket == "" {
+		return nil, errors.New("s3 backup storage: missing bucket")
+	}
+	awsConfig := aws.NewConfig().WithS3ForcePathStyle(s.cfg.ForcePathStyle)
+	if s.cfg.Region != "" {
+		awsConfig = awsConfig.WithRegion(s.cfg.Region)
+	}
+	if s.cfg.Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(s.cfg.Endpoint)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *awsConfig,
+		Profile:           s.cfg.Profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.client = s3.New(sess)
+	return s, nil
+}
+
+func (s *s3Store) key(target, id string) string {
+	return path.Join(s.cfg.Prefix, target, id+snapshotFileExt)
+}
+
+func (s *s3Store) Save(ctx context.Context, target string, ts time.Time, b []byte) (*Snapshot, error) {
+	id := snapshotID(ts)
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.cfg.Bucket),
+		Key:         aws.String(s.key(target, id)),
+		Body:        bytes.NewReader(b),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{ID: id, Target: target, Timestamp: ts}, nil
+}
+
+func (s *s3Store) List(ctx context.Context, target string) ([]*Snapshot, error) {
+	prefix := path.Join(s.cfg.Prefix, target) + "/"
+	snaps := make([]*Snapshot, 0)
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.cfg.Bucket),
+		Prefix: aws.String(prefix),
+	}, func(out *s3.ListObjectsV2Output, last bool) bool {
+		for _, obj := range out.Contents {
+			name := strings.TrimPrefix(aws.StringValue(obj.Key), prefix)
+			if strings.Contains(name, "/") || !strings.HasSuffix(name, snapshotFileExt) {
+				continue
+			}
+			if snap, ok := parseSnapshotID(target, strings.TrimSuffix(name, snapshotFileExt)); ok {
+				snaps = append(snaps, snap)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(snaps, func(i, j int) bool {
+		return snaps[i].ID < snaps[j].ID
+	})
+	return snaps, nil
+}
+
+func (s *s3Store) Read(ctx context.Context, target, id string) ([]byte, error) {
+	if _, ok := parseSnapshotID(target, id); !ok {
+		return nil, ErrSnapshotNotFound
+	}
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(s.key(target, id)),
+	})
+	if err != nil {
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrSnapshotNotFound
+		}
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (s *s3Store) Delete(ctx context.Context, target, id string) error {
+	if _, ok := parseSnapshotID(target, id); !ok {
+		return ErrSnapshotNotFound
+	}
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(s.key(target, id)),
+	})
+	return err
+}
@@ -10,6 +10,7 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -26,6 +27,7 @@ const (
 	cacheType_Redis CacheType = "redis"
 	cacheType_NATS  CacheType = "nats"
 	cacheType_JS    CacheType = "jetstream"
+	cacheType_Mesh  CacheType = "mesh"
 )
 
 const (
@@ -55,6 +57,8 @@ type Config struct {
 	Timeout    time.Duration `mapstructure:"timeout,omitempty" json:"timeout,omitempty"`
 	Expiration time.Duration `mapstructure:"expiration,omitempty" json:"expiration,omitempty"`
 	Debug      bool          `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+	// OC cfg options
+	Shards int `mapstructure:"shards,omitempty" json:"shards,omitempty"`
 	// NATS, JS and Redis cfg options
 	Username string `mapstructure:"username,omitempty" json:"username,omitempty"`
 	Password string `mapstructure:"password,omitempty" json:"password,omitempty"`
@@ -64,6 +68,26 @@ type Config struct {
 	MaxMsgsPerSubscription int64         `mapstructure:"max-msgs-per-subscription,omitempty" json:"max-msgs-per-subscription,omitempty"`
 	FetchBatchSize         int           `mapstructure:"fetch-batch-size,omitempty" json:"fetch-batch-size,omitempty"`
 	FetchWaitTime          time.Duration `mapstructure:"fetch-wait-time,omitempty" json:"fetch-wait-time,omitempty"`
+	// Retention is the max age of the messages kept in each stream,
+	// it bounds how far back in time a history replay can go.
+	Retention time.Duration `mapstructure:"retention,omitempty" json:"retention,omitempty"`
+	// replay downsampling, applied per path.
+	ReplaySampleEvery int           `mapstructure:"replay-sample-every,omitempty" json:"replay-sample-every,omitempty"`
+	ReplayMinInterval time.Duration `mapstructure:"replay-min-interval,omitempty" json:"replay-min-interval,omitempty"`
+
+	// Mesh cfg options
+	TLS *TLSConfig `mapstructure:"tls,omitempty" json:"tls,omitempty"`
+	// AllowInsecure allows the mesh streams to be sent in clear text
+	AllowInsecure bool `mapstructure:"allow-insecure,omitempty" json:"allow-insecure,omitempty"`
+}
+
+// TLSConfig is the TLS configuration of the mesh server and of its connections to the peers.
+// If a CA file is set, the peers are required to present a certificate signed by it (mTLS).
+type TLSConfig struct {
+	CAFile     string `mapstructure:"ca-file,omitempty" json:"ca-file,omitempty"`
+	CertFile   string `mapstructure:"cert-file,omitempty" json:"cert-file,omitempty"`
+	KeyFile    string `mapstructure:"key-file,omitempty" json:"key-file,omitempty"`
+	SkipVerify bool   `mapstructure:"skip-verify,omitempty" json:"skip-verify,omitempty"`
 }
 
 func (c *Config) setDefaults() {
@@ -73,6 +97,8 @@ func (c *Config) setDefaults() {
 			c.Address = defaultRedisAddress
 		case cacheType_JS, cacheType_NATS:
 			c.Address = defaultNATSAddress
+		case cacheType_Mesh:
+			c.Address = defaultMeshAddress
 		}
 	}
 	if c.Timeout == 0 {
@@ -81,6 +107,9 @@ func (c *Config) setDefaults() {
 	if c.Expiration <= 0 {
 		c.Expiration = defaultExpiration
 	}
+	if c.Shards <= 0 {
+		c.Shards = defaultShards
+	}
 
 	if c.Type != cacheType_JS {
 		return
@@ -98,6 +127,12 @@ func (c *Config) setDefaults() {
 	if c.FetchWaitTime <= 0 {
 		c.FetchWaitTime = defaultFetchWaitTime
 	}
+	if c.Retention <= 0 {
+		c.Retention = c.Expiration
+	}
+	if c.ReplaySampleEvery <= 0 {
+		c.ReplaySampleEvery = 1
+	}
 }
 
 func New(c *Config, opts ...Option) (Cache, error) {
@@ -116,6 +151,8 @@ func New(c *Config, opts ...Option) (Cache, error) {
 		return newJetStreamCache(c, opts...)
 	case cacheType_Redis:
 		return newRedisCache(c, opts...)
+	case cacheType_Mesh:
+		return newMeshCache(c, opts...)
 	default:
 		return nil, fmt.Errorf("unknown cache type: %q", c.Type)
 	}
@@ -131,6 +168,11 @@ type ReadOpts struct {
 	SuppressRedundant bool
 	UpdatesOnly       bool
 	OverrideTS        bool
+	// StartTime and EndTime select the stored notifications to replay,
+	// only supported by caches retaining history (jetstream).
+	// A zero EndTime means up to the time of the read.
+	StartTime time.Time
+	EndTime   time.Time
 
 	m        *sync.RWMutex
 	lastSent map[string]*gnmi.TypedValue
@@ -155,6 +197,10 @@ func (ro *ReadOpts) setDefaults() {
 	}
 }
 
+// ErrHistoryNotSupported is returned when a read with a StartTime
+// is sent to a cache not retaining history.
+var ErrHistoryNotSupported = errors.New("history reads are not supported by this cache type")
+
 type Notification struct {
 	Name         string
 	Notification *gnmi.Notification
@@ -23,7 +23,9 @@ import (
 	"github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
 	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/tracing"
 	"github.com/openconfig/gnmic/utils"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -53,7 +55,9 @@ type jetStreamCache struct {
 
 	m       *sync.RWMutex
 	streams map[string]struct{}
-	logger  *log.Logger
+	// streams synced from NATS, local or created by other gnmic instances
+	synced map[string]struct{}
+	logger *log.Logger
 }
 
 func newJetStreamCache(cfg *Config, opts ...Option) (*jetStreamCache, error) {
@@ -69,6 +73,7 @@ func newJetStreamCache(cfg *Config, opts ...Option) (*jetStreamCache, error) {
 		streamChan: make(chan string),
 		m:          new(sync.RWMutex),
 		streams:    make(map[string]struct{}),
+		synced:     make(map[string]struct{}),
 	}
 	for _, opt := range opts {
 		opt(c)
@@ -174,7 +179,7 @@ func (c *jetStreamCache) createStream(streamName string, subjects []string) erro
 				MaxMsgs:  c.cfg.MaxMsgsPerSubscription,
 				MaxBytes: c.cfg.MaxBytes,
 				Discard:  nats.DiscardOld,
-				MaxAge:   c.cfg.Expiration,
+				MaxAge:   c.cfg.Retention,
 				Storage:  nats.MemoryStorage,
 			})
 		return err
@@ -255,8 +260,11 @@ func (c *jetStreamCache) publishNotificationJS(ctx context.Context, subscription
 		return fmt.Errorf("failed to marshal proto message: %w", err)
 	}
 
-	_, err = c.js.Publish(subjectName, b, nats.Context(ctx))
+	ctx, span := tracing.StartChildSpan(ctx, "cache.publish", attribute.String("subject", subjectName))
+	defer span.End()
+	_, err = c.js.PublishMsg(newNATSMsg(ctx, c.nc, subjectName, b), nats.Context(ctx))
 	if err != nil {
+		tracing.SetError(span, err)
 		return fmt.Errorf("failed to publish to JetStream cache: %w", err)
 	}
 	return nil
@@ -264,8 +272,6 @@ func (c *jetStreamCache) publishNotificationJS(ctx context.Context, subscription
 
 func (c *jetStreamCache) sync(ctx context.Context) {
 	c.logger.Printf("start JetStream sync")
-	// this map keeps track of streams already queued
-	streams := make(map[string]struct{})
 	go func() {
 	START:
 		subjectSub, err := c.nc.Subscribe(cacheSubjects, func(m *nats.Msg) {
@@ -285,9 +291,12 @@ func (c *jetStreamCache) sync(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case cc := <-c.streamChan:
-			if _, ok := streams[cc]; !ok {
+			c.m.Lock()
+			_, ok := c.synced[cc]
+			c.synced[cc] = struct{}{}
+			c.m.Unlock()
+			if !ok {
 				c.logger.Printf("start JetStream stream %q sync", cc)
-				streams[cc] = struct{}{}
 				go c.syncStream(ctx, cc)
 			}
 		}
@@ -305,7 +314,9 @@ START:
 				return
 			}
 			_ = msg.Ack()
-			c.oc.Write(ctx, subject, m)
+			sctx, span := consumeSpan(ctx, msg)
+			c.oc.Write(sctx, subject, m)
+			span.End()
 		},
 		nats.DeliverNew(),
 		nats.Durable(jetStreamSyncName),
@@ -325,7 +336,13 @@ func (c *jetStreamCache) Read() (map[string][]*gnmi.Notification, error) {
 }
 
 func (c *jetStreamCache) Subscribe(ctx context.Context, ro *ReadOpts) chan *Notification {
-	return c.oc.Subscribe(ctx, ro)
+	if ro == nil || ro.StartTime.IsZero() {
+		return c.oc.Subscribe(ctx, ro)
+	}
+	ro.setDefaults()
+	ch := make(chan *Notification)
+	go c.replay(ctx, ro, ch)
+	return ch
 }
 
 func (c *jetStreamCache) Stop() {
@@ -0,0 +1,217 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/protobuf/proto"
+)
+
+// replay sends the notifications stored in the JetStream streams between ro.StartTime and ro.EndTime,
+// downsampled per subject (path) according to the cache replay config.
+func (c *jetStreamCache) replay(ctx context.Context, ro *ReadOpts, ch chan *Notification) {
+	defer close(ch)
+	end := ro.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if c.cfg.Debug {
+		c.logger.Printf("replaying target %q notifications from %s to %s", ro.Target, ro.StartTime, end)
+	}
+	for _, streamName := range c.replayStreams(ro.Subscription) {
+		err := c.replayStream(ctx, streamName, ro, end, ch)
+		if err != nil {
+			select {
+			case ch <- &Notification{Err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+	}
+}
+
+func (c *jetStreamCache) replayStreams(sub string) []string {
+	if sub != "" {
+		return []string{sub}
+	}
+	c.m.RLock()
+	defer c.m.RUnlock()
+	streams := make([]string, 0, len(c.synced))
+	for name := range c.synced {
+		streams = append(streams, name)
+	}
+	sort.Strings(streams)
+	return streams
+}
+
+func (c *jetStreamCache) replayStream(ctx context.Context, streamName string, ro *ReadOpts, end time.Time, ch chan *Notification) error {
+	subject := fmt.Sprintf("%s.>", streamName)
+	if ro.Target != "*" {
+		subject = fmt.Sprintf("%s.%s.>", streamName, ro.Target)
+	}
+	sub, err := c.js.SubscribeSync(subject,
+		nats.OrderedConsumer(),
+		nats.StartTime(ro.StartTime),
+		nats.BindStream(streamName),
+	)
+	if err != nil {
+		if errors.Is(err, nats.ErrStreamNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to replay stream %q: %w", streamName, err)
+	}
+	defer sub.Unsubscribe()
+
+	ci, err := sub.ConsumerInfo()
+	if err != nil {
+		return fmt.Errorf("failed to replay stream %q: %w", streamName, err)
+	}
+	// nothing stored since the start time
+	if ci.Delivered.Consumer+ci.NumPending == 0 {
+		return nil
+	}
+	rs := newReplaySampler(c.cfg.ReplaySampleEvery, c.cfg.ReplayMinInterval)
+	for {
+		nctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+		msg, err := sub.NextMsgWithContext(nctx)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, nats.ErrTimeout) {
+				return nil
+			}
+			return fmt.Errorf("failed to replay stream %q: %w", streamName, err)
+		}
+		meta, err := msg.Metadata()
+		if err != nil {
+			return fmt.Errorf("failed to replay stream %q: %w", streamName, err)
+		}
+		if meta.Timestamp.After(end) {
+			return nil
+		}
+		rsp := new(gnmi.SubscribeResponse)
+		err = proto.Unmarshal(msg.Data, rsp)
+		if err != nil {
+			c.logger.Printf("failed to unmarshal proto msg: %v", err)
+		} else if n := replayFilter(ro.Paths, rsp.GetUpdate()); n != nil &&
+			rs.keep(msg.Subject, time.Unix(0, n.GetTimestamp())) {
+			select {
+			case ch <- &Notification{Name: streamName, Notification: n}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		if meta.NumPending == 0 {
+			return nil
+		}
+	}
+}
+
+// replaySampler decides which replayed samples are sent per subject:
+// every Nth sample, no closer than minInterval to the previous sent one.
+type replaySampler struct {
+	every       int
+	minInterval time.Duration
+	count       map[string]int
+	last        map[string]time.Time
+}
+
+func newReplaySampler(every int, minInterval time.Duration) *replaySampler {
+	if every <= 0 {
+		every = 1
+	}
+	return &replaySampler{
+		every:       every,
+		minInterval: minInterval,
+		count:       make(map[string]int),
+		last:        make(map[string]time.Time),
+	}
+}
+
+func (rs *replaySampler) keep(subject string, ts time.Time) bool {
+	n := rs.count[subject]
+	rs.count[subject] = n + 1
+	if n%rs.every != 0 {
+		return false
+	}
+	if rs.minInterval > 0 {
+		if last, ok := rs.last[subject]; ok && ts.Sub(last) < rs.minInterval {
+			return false
+		}
+	}
+	rs.last[subject] = ts
+	return true
+}
+
+// replayFilter returns the notification n with only the updates and deletes
+// matching one of the paths, nil if none matches.
+func replayFilter(paths []*gnmi.Path, n *gnmi.Notification) *gnmi.Notification {
+	if n == nil {
+		return nil
+	}
+	rn := &gnmi.Notification{
+		Timestamp: n.GetTimestamp(),
+		Prefix:    n.GetPrefix(),
+		Alias:     n.GetAlias(),
+		Atomic:    n.GetAtomic(),
+	}
+	prefixElems := n.GetPrefix().GetElem()
+	for _, upd := range n.GetUpdate() {
+		if replayPathMatch(paths, append(append([]*gnmi.PathElem{}, prefixElems...), upd.GetPath().GetElem()...)) {
+			rn.Update = append(rn.Update, upd)
+		}
+	}
+	for _, del := range n.GetDelete() {
+		if replayPathMatch(paths, append(append([]*gnmi.PathElem{}, prefixElems...), del.GetElem()...)) {
+			rn.Delete = append(rn.Delete, del)
+		}
+	}
+	if len(rn.Update) == 0 && len(rn.Delete) == 0 {
+		return nil
+	}
+	return rn
+}
+
+// replayPathMatch reports whether elems is equal to or under one of the paths.
+// Wildcard names "*" and "...", and wildcard key values "*" are supported.
+func replayPathMatch(paths []*gnmi.Path, elems []*gnmi.PathElem) bool {
+PATHS:
+	for _, p := range paths {
+		for i, pe := range p.GetElem() {
+			if pe.GetName() == "..." {
+				return true
+			}
+			if i >= len(elems) {
+				continue PATHS
+			}
+			if pe.GetName() != "*" && pe.GetName() != elems[i].GetName() {
+				continue PATHS
+			}
+			for k, v := range pe.GetKey() {
+				if v == "*" {
+					continue
+				}
+				if elems[i].GetKey()[k] != v {
+					continue PATHS
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func Test_replaySampler(t *testing.T) {
+	base := time.Unix(0, 0)
+	tests := []struct {
+		name        string
+		every       int
+		minInterval time.Duration
+		// sample offsets in seconds
+		samples []int
+		want    []bool
+	}{
+		{
+			name:    "no_downsampling",
+			samples: []int{0, 1, 2},
+			want:    []bool{true, true, true},
+		},
+		{
+			name:    "every_2",
+			every:   2,
+			samples: []int{0, 1, 2, 3, 4},
+			want:    []bool{true, false, true, false, true},
+		},
+		{
+			name:        "min_interval",
+			minInterval: 10 * time.Second,
+			samples:     []int{0, 5, 10, 12, 25},
+			want:        []bool{true, false, true, false, true},
+		},
+		{
+			name:        "every_2_min_interval",
+			every:       2,
+			minInterval: 10 * time.Second,
+			samples:     []int{0, 5, 10, 15, 20, 25},
+			want:        []bool{true, false, true, false, true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := newReplaySampler(tt.every, tt.minInterval)
+			for i, s := range tt.samples {
+				got := rs.keep("sub1.router1.interface", base.Add(time.Duration(s)*time.Second))
+				if got != tt.want[i] {
+					t.Errorf("sample %d: got %v, want %v", i, got, tt.want[i])
+				}
+				// other subjects are sampled independently
+				if !rs.keep("sub1.router1.other", base.Add(time.Duration(1000*(i+1))*time.Second)) && tt.every <= 1 {
+					t.Errorf("sample %d: unexpected drop of an independent subject", i)
+				}
+			}
+		})
+	}
+}
+
+func Test_replayPathMatch(t *testing.T) {
+	elems := []*gnmi.PathElem{
+		{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}},
+		{Name: "statistics"},
+		{Name: "in-octets"},
+	}
+	tests := []struct {
+		name  string
+		paths []*gnmi.Path
+		want  bool
+	}{
+		{name: "root", paths: []*gnmi.Path{{}}, want: true},
+		{name: "prefix", paths: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "interface"}}}}, want: true},
+		{name: "key", paths: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}}}}, want: true},
+		{name: "wildcard_key", paths: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "*"}}}}}, want: true},
+		{name: "other_key", paths: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/2"}}}}}, want: false},
+		{name: "wildcard_name", paths: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "*"}, {Name: "statistics"}}}}, want: true},
+		{name: "other_path", paths: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "network-instance"}}}}, want: false},
+		{name: "longer_path", paths: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "interface"}, {Name: "statistics"}, {Name: "in-octets"}, {Name: "x"}}}}, want: false},
+		{name: "any", paths: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "interface"}, {Name: "..."}}}}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replayPathMatch(tt.paths, elems); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_jetStreamCache_replay(t *testing.T) {
+	c, err := newJetStreamCache(&Config{
+		Type:              cacheType_JS,
+		ReplaySampleEvery: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		c.Write(ctx, "sub1", &gnmi.SubscribeResponse{
+			Response: &gnmi.SubscribeResponse_Update{
+				Update: &gnmi.Notification{
+					Timestamp: int64(i + 1),
+					Prefix:    &gnmi.Path{Target: "router1"},
+					Update: []*gnmi.Update{{
+						Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}, {Name: "oper-state"}}},
+						Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "up"}},
+					}},
+				},
+			},
+		})
+	}
+	// wait for the stream to be synced
+	time.Sleep(time.Second)
+
+	var got []int64
+	for n := range c.Subscribe(ctx, &ReadOpts{
+		Target:    "router1",
+		Mode:      ReadMode_Once,
+		StartTime: start,
+	}) {
+		if n.Err != nil {
+			t.Fatal(n.Err)
+		}
+		if n.Name != "sub1" {
+			t.Errorf("unexpected subscription name %q", n.Name)
+		}
+		got = append(got, n.Notification.GetTimestamp())
+	}
+	want := []int64{1, 3, 5}
+	if len(got) != len(want) {
+		t.Fatalf("got timestamps %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got timestamps %v, want %v", got, want)
+		}
+	}
+
+	// notifications of other targets are not replayed
+	for n := range c.Subscribe(ctx, &ReadOpts{
+		Target:    "router2",
+		Mode:      ReadMode_Once,
+		StartTime: start,
+	}) {
+		t.Errorf("unexpected notification: %v", n)
+	}
+}
+
+func Test_gnmiCache_historyNotSupported(t *testing.T) {
+	gc := newGNMICache(&Config{}, "")
+	n, ok := <-gc.Subscribe(context.Background(), &ReadOpts{StartTime: time.Now()})
+	if !ok || n.Err != ErrHistoryNotSupported {
+		t.Fatalf("expected ErrHistoryNotSupported, got %v", n)
+	}
+}
@@ -0,0 +1,299 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	loggingPrefixMesh  = "[cache:mesh] "
+	defaultMeshAddress = ":57500"
+	// number of updates queued per peer before they are dropped
+	meshPeerQueueSize = 1000
+	meshServiceName   = "gnmic.cache.Mesh"
+	meshReplicate     = "/" + meshServiceName + "/Replicate"
+	// stream metadata key holding the subscription name of the streamed updates
+	meshSubscriptionKey = "gnmic-cache-subscription"
+)
+
+// Mesh is implemented by the caches replicating their writes
+// to peer gNMIc instances over gRPC streams.
+type Mesh interface {
+	Cache
+	// ListenAddress returns the address the peers replicate their writes to.
+	ListenAddress() string
+	// SetPeers sets the addresses, by instance name, the writes are replicated to.
+	SetPeers(peers map[string]string)
+}
+
+var meshStreamDesc = &grpc.StreamDesc{
+	StreamName:    "Replicate",
+	ClientStreams: true,
+}
+
+// meshCache is a local cache that replicates its writes to its peers,
+// each peer is sent a client stream per subscription.
+// The updates received from the peers are only written to the local cache.
+type meshCache struct {
+	cfg *Config
+	oc  *gnmiCache
+	cfn context.CancelFunc
+	ctx context.Context
+
+	lis net.Listener
+	srv *grpc.Server
+	// credentials of the connections to the peers
+	creds credentials.TransportCredentials
+
+	m      *sync.Mutex
+	peers  map[string]*meshPeer
+	logger *log.Logger
+}
+
+type meshPeer struct {
+	name string
+	addr string
+	ch   chan *meshUpdate
+	cfn  context.CancelFunc
+}
+
+type meshUpdate struct {
+	sub string
+	rsp *gnmi.SubscribeResponse
+}
+
+func newMeshCache(cfg *Config, opts ...Option) (*meshCache, error) {
+	if cfg == nil {
+		cfg = &Config{Type: cacheType_Mesh}
+	}
+	cfg.setDefaults()
+	c := &meshCache{
+		cfg:    cfg,
+		oc:     newGNMICache(cfg, "mesh", opts...),
+		m:      new(sync.Mutex),
+		peers:  make(map[string]*meshPeer),
+		logger: log.New(os.Stderr, loggingPrefixMesh, utils.DefaultLoggingFlags),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	srvOpts, err := c.setCredentials()
+	if err != nil {
+		return nil, err
+	}
+	c.lis, err = net.Listen("tcp", cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	c.ctx, c.cfn = context.WithCancel(context.Background())
+	c.srv = grpc.NewServer(srvOpts...)
+	c.srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: meshServiceName,
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    meshStreamDesc.StreamName,
+			Handler:       c.replicateHandler,
+			ClientStreams: true,
+		}},
+	}, c)
+	go func() {
+		err := c.srv.Serve(c.lis)
+		if err != nil {
+			c.logger.Printf("mesh server stopped: %v", err)
+		}
+	}()
+	c.logger.Printf("receiving peers updates on %s", c.lis.Addr())
+	return c, nil
+}
+
+// setCredentials sets the credentials of the connections to the peers
+// and returns the mesh server options.
+// The mesh is only run without TLS if it is explicitly allowed.
+func (c *meshCache) setCredentials() ([]grpc.ServerOption, error) {
+	if c.cfg.TLS == nil {
+		if !c.cfg.AllowInsecure {
+			return nil, errors.New("mesh cache: missing tls config, set allow-insecure to replicate the updates in clear text")
+		}
+		c.logger.Printf("replicating updates without TLS")
+		c.creds = insecure.NewCredentials()
+		return nil, nil
+	}
+	if c.cfg.TLS.CertFile == "" || c.cfg.TLS.KeyFile == "" {
+		return nil, errors.New("mesh cache: the tls config requires a cert-file and a key-file")
+	}
+	tlsConfig, err := utils.NewTLSConfig(c.cfg.TLS.CAFile, c.cfg.TLS.CertFile, c.cfg.TLS.KeyFile, c.cfg.TLS.SkipVerify, false)
+	if err != nil {
+		return nil, err
+	}
+	// the peers present the same certificate as clients,
+	// the server requires it if a CA is set.
+	c.creds = credentials.NewTLS(tlsConfig)
+	srvConfig := tlsConfig.Clone()
+	if srvConfig.RootCAs != nil {
+		srvConfig.ClientCAs = srvConfig.RootCAs
+		srvConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(srvConfig))}, nil
+}
+
+// replicateHandler writes the updates streamed by a peer to the local cache.
+func (c *meshCache) replicateHandler(_ interface{}, stream grpc.ServerStream) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	subs := md.Get(meshSubscriptionKey)
+	if len(subs) == 0 || subs[0] == "" {
+		return status.Error(codes.InvalidArgument, "missing subscription name")
+	}
+	for {
+		rsp := new(gnmi.SubscribeResponse)
+		err := stream.RecvMsg(rsp)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		c.oc.Write(stream.Context(), subs[0], rsp)
+	}
+}
+
+func (c *meshCache) ListenAddress() string {
+	return c.lis.Addr().String()
+}
+
+// SetPeers starts replicating to the new peers
+// and stops replicating to the ones not in peers.
+func (c *meshCache) SetPeers(peers map[string]string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for name, p := range c.peers {
+		if addr, ok := peers[name]; ok && addr == p.addr {
+			continue
+		}
+		c.logger.Printf("removing peer %q at %s", name, p.addr)
+		p.cfn()
+		delete(c.peers, name)
+	}
+	for name, addr := range peers {
+		if _, ok := c.peers[name]; ok {
+			continue
+		}
+		c.logger.Printf("adding peer %q at %s", name, addr)
+		ctx, cancel := context.WithCancel(c.ctx)
+		p := &meshPeer{
+			name: name,
+			addr: addr,
+			ch:   make(chan *meshUpdate, meshPeerQueueSize),
+			cfn:  cancel,
+		}
+		c.peers[name] = p
+		go c.replicate(ctx, p)
+	}
+}
+
+// replicate sends the updates queued for the peer,
+// (re)opening the subscription streams as needed.
+func (c *meshCache) replicate(ctx context.Context, p *meshPeer) {
+	conn, err := grpc.DialContext(ctx, p.addr, grpc.WithTransportCredentials(c.creds))
+	if err != nil {
+		c.logger.Printf("peer %q: failed to create connection: %v", p.name, err)
+		return
+	}
+	defer conn.Close()
+	streams := make(map[string]grpc.ClientStream)
+	for {
+		select {
+		case <-ctx.Done():
+			for _, s := range streams {
+				s.CloseSend()
+			}
+			return
+		case u := <-p.ch:
+			s, ok := streams[u.sub]
+			if !ok {
+				sctx := metadata.AppendToOutgoingContext(ctx, meshSubscriptionKey, u.sub)
+				s, err = conn.NewStream(sctx, meshStreamDesc, meshReplicate)
+				if err != nil {
+					c.logger.Printf("peer %q: failed to open subscription %q stream: %v", p.name, u.sub, err)
+					time.Sleep(c.cfg.Timeout / 10)
+					continue
+				}
+				streams[u.sub] = s
+			}
+			err = s.SendMsg(u.rsp)
+			if err != nil {
+				c.logger.Printf("peer %q: failed to send subscription %q update: %v", p.name, u.sub, err)
+				delete(streams, u.sub)
+			}
+		}
+	}
+}
+
+func (c *meshCache) Write(ctx context.Context, subscriptionName string, m proto.Message) {
+	c.oc.Write(ctx, subscriptionName, m)
+	rsp, ok := m.ProtoReflect().Interface().(*gnmi.SubscribeResponse)
+	if !ok || rsp.GetUpdate() == nil {
+		return
+	}
+	u := &meshUpdate{sub: subscriptionName, rsp: rsp}
+	c.m.Lock()
+	defer c.m.Unlock()
+	for _, p := range c.peers {
+		select {
+		case p.ch <- u:
+		default:
+			if c.cfg.Debug {
+				c.logger.Printf("peer %q: queue full, dropping update", p.name)
+			}
+		}
+	}
+}
+
+func (c *meshCache) Read() (map[string][]*gnmi.Notification, error) {
+	return c.oc.Read()
+}
+
+func (c *meshCache) Subscribe(ctx context.Context, ro *ReadOpts) chan *Notification {
+	return c.oc.Subscribe(ctx, ro)
+}
+
+func (c *meshCache) Stop() {
+	c.cfn()
+	c.srv.Stop()
+}
+
+func (c *meshCache) DeleteTarget(name string) {
+	c.oc.DeleteTarget(name)
+}
+
+func (c *meshCache) SetLogger(logger *log.Logger) {
+	if logger != nil && c.logger != nil {
+		c.logger.SetOutput(logger.Writer())
+		c.logger.SetFlags(logger.Flags())
+		c.logger.SetPrefix(loggingPrefixMesh)
+	}
+}
@@ -0,0 +1,182 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestMeshCache(t *testing.T) *meshCache {
+	return newTestMeshCacheConfig(t, &Config{Type: cacheType_Mesh, Address: "127.0.0.1:0", AllowInsecure: true})
+}
+
+func newTestMeshCacheConfig(t *testing.T, cfg *Config) *meshCache {
+	t.Helper()
+	c, err := newMeshCache(cfg, WithLogger(log.New(io.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Stop)
+	return c
+}
+
+func Test_meshCache_replicate(t *testing.T) {
+	c1 := newTestMeshCache(t)
+	c2 := newTestMeshCache(t)
+	c1.SetPeers(map[string]string{"gnmic2": c2.ListenAddress()})
+	c2.SetPeers(map[string]string{"gnmic1": c1.ListenAddress()})
+
+	ctx := context.Background()
+	now := time.Now().UnixNano()
+	c1.Write(ctx, "sub1", testCacheResponse("router1", now, "d1"))
+	c2.Write(ctx, "sub2", testCacheResponse("router2", now, "d2"))
+
+	// both caches end up with both targets, each written once
+	for _, c := range []*meshCache{c1, c2} {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			rs, err := c.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rs["sub1"]) == 1 && len(rs["sub2"]) == 1 {
+				if rs["sub1"][0].GetPrefix().GetTarget() != "router1" || rs["sub2"][0].GetPrefix().GetTarget() != "router2" {
+					t.Errorf("unexpected replicated notifications: %v", rs)
+				}
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("updates not replicated: %v", rs)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// a removed peer is no longer written to
+	c1.SetPeers(nil)
+	if len(c1.peers) != 0 {
+		t.Errorf("expected no peers, got %d", len(c1.peers))
+	}
+	c1.Write(ctx, "sub3", testCacheResponse("router1", now, "d3"))
+	time.Sleep(100 * time.Millisecond)
+	rs, err := c2.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rs["sub3"]; ok {
+		t.Errorf("update replicated to a removed peer")
+	}
+}
+
+// testMeshTLS writes a self signed certificate valid for 127.0.0.1,
+// used by the peers as their CA, server and client certificate.
+func testMeshTLS(t *testing.T) *TLSConfig {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gnmic-mesh"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	cfg := &TLSConfig{
+		CAFile:   filepath.Join(dir, "cert.pem"),
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}
+	err = os.WriteFile(cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func Test_meshCache_requiresTLS(t *testing.T) {
+	for name, cfg := range map[string]*Config{
+		"no_tls":          {Type: cacheType_Mesh, Address: "127.0.0.1:0"},
+		"missing_keypair": {Type: cacheType_Mesh, Address: "127.0.0.1:0", TLS: &TLSConfig{CAFile: "ca.pem"}},
+	} {
+		if _, err := newMeshCache(cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func Test_meshCache_replicateTLS(t *testing.T) {
+	tlsCfg := testMeshTLS(t)
+	c1 := newTestMeshCacheConfig(t, &Config{Type: cacheType_Mesh, Address: "127.0.0.1:0", TLS: tlsCfg})
+	c2 := newTestMeshCacheConfig(t, &Config{Type: cacheType_Mesh, Address: "127.0.0.1:0", TLS: tlsCfg})
+	// a peer without a client certificate
+	c3 := newTestMeshCache(t)
+	c2.SetPeers(map[string]string{"gnmic1": c1.ListenAddress()})
+	c3.SetPeers(map[string]string{"gnmic1": c1.ListenAddress()})
+
+	ctx := context.Background()
+	now := time.Now().UnixNano()
+	c2.Write(ctx, "sub2", testCacheResponse("router2", now, "d2"))
+	c3.Write(ctx, "sub3", testCacheResponse("router3", now, "d3"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rs, err := c1.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rs["sub2"]) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("updates not replicated over TLS: %v", rs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	rs, err := c1.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rs["sub3"]; ok {
+		t.Errorf("update replicated by a peer without a client certificate")
+	}
+}
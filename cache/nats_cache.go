@@ -19,7 +19,11 @@ import (
 	"github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
 	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/tracing"
 	"github.com/openconfig/gnmic/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -184,7 +188,9 @@ START:
 				c.logger.Printf("failed to unmarshal proto msg: %v", err)
 				return
 			}
-			c.oc.Write(ctx, subject, m)
+			sctx, span := consumeSpan(ctx, msg)
+			c.oc.Write(sctx, subject, m)
+			span.End()
 		})
 	if err != nil {
 		time.Sleep(time.Second)
@@ -233,18 +239,41 @@ func (c *natsCache) writeRemoteNATS(ctx context.Context, subscriptionName string
 	}
 }
 
-func (c *natsCache) publishNotificationNATS(_ context.Context, subscriptionName, targetName string, r *gnmi.SubscribeResponse) error {
+func (c *natsCache) publishNotificationNATS(ctx context.Context, subscriptionName, targetName string, r *gnmi.SubscribeResponse) error {
 	b, err := proto.Marshal(r)
 	if err != nil {
 		return fmt.Errorf("failed to marshal proto message: %w", err)
 	}
-	err = c.nc.Publish(fmt.Sprintf("%s.%s", subscriptionName, targetName), b)
+	subject := fmt.Sprintf("%s.%s", subscriptionName, targetName)
+	ctx, span := tracing.StartChildSpan(ctx, "cache.publish", attribute.String("subject", subject))
+	defer span.End()
+	err = c.nc.PublishMsg(newNATSMsg(ctx, c.nc, subject, b))
 	if err != nil {
+		tracing.SetError(span, err)
 		return fmt.Errorf("failed to publish to NATS cache: %w", err)
 	}
 	return nil
 }
 
+// newNATSMsg returns a NATS message carrying b,
+// with the trace context found in ctx, if any, in its headers.
+func newNATSMsg(ctx context.Context, nc *nats.Conn, subject string, b []byte) *nats.Msg {
+	msg := &nats.Msg{Subject: subject, Data: b}
+	if tracing.HasSpan(ctx) && nc.HeadersSupported() {
+		msg.Header = make(nats.Header)
+		tracing.Inject(ctx, propagation.HeaderCarrier(msg.Header))
+	}
+	return msg
+}
+
+// consumeSpan continues the trace found in the headers of a message received from the cache.
+func consumeSpan(ctx context.Context, msg *nats.Msg) (context.Context, trace.Span) {
+	if len(msg.Header) > 0 {
+		ctx = tracing.Extract(ctx, propagation.HeaderCarrier(msg.Header))
+	}
+	return tracing.StartChildSpan(ctx, "cache.consume", attribute.String("subject", msg.Subject))
+}
+
 func (c *natsCache) Read() (map[string][]*gnmi.Notification, error) {
 	return c.oc.Read()
 }
@@ -23,6 +23,7 @@ import (
 	"github.com/openconfig/gnmi/path"
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmi/subscribe"
+	"github.com/openconfig/gnmic/membudget"
 	"github.com/openconfig/gnmic/utils"
 	"google.golang.org/protobuf/proto"
 )
@@ -30,21 +31,36 @@ import (
 const (
 	loggingPrefixOC = "[cache:%s] "
 	defaultTimeout  = 10 * time.Second
+	// default number of shards the targets are spread over.
+	defaultShards = 32
 )
 
 type gnmiCache struct {
-	m      *sync.Mutex
-	caches map[string]*subCache
-	// match  *match.Match
+	// the caches are sharded by target name,
+	// each shard holds a cache per subscription.
+	shards []*cacheShard
+	// on-change queries are registered per subscription,
+	// mm protects the matches map.
+	mm      *sync.Mutex
+	matches map[string]*match.Match
 
 	logger     *log.Logger
 	expiration time.Duration
 	debug      bool
 }
 
+type cacheShard struct {
+	m      *sync.RWMutex
+	caches map[string]*subCache
+}
+
 type subCache struct {
 	c     *ocCache.Cache
 	match *match.Match
+	// encoded size of the cached leaves, per target and leaf path,
+	// accounted in the memory budget.
+	sm    *sync.Mutex
+	sizes map[string]map[string]int64
 }
 
 func (gc *gnmiCache) loadConfig(gcc *Config) {
@@ -57,12 +73,18 @@ func newGNMICache(cfg *Config, loggingPrefix string, opts ...Option) *gnmiCache
 	if cfg == nil {
 		cfg = new(Config)
 	}
+	cfg.setDefaults()
 	gc := &gnmiCache{
-		m: new(sync.Mutex),
-		// match:  match.New(),
-		caches: make(map[string]*subCache),
+		shards:  make([]*cacheShard, cfg.Shards),
+		mm:      new(sync.Mutex),
+		matches: make(map[string]*match.Match),
+	}
+	for i := range gc.shards {
+		gc.shards[i] = &cacheShard{
+			m:      new(sync.RWMutex),
+			caches: make(map[string]*subCache),
+		}
 	}
-	cfg.setDefaults()
 
 	gc.loadConfig(cfg)
 	for _, opt := range opts {
@@ -77,9 +99,63 @@ func newGNMICache(cfg *Config, loggingPrefix string, opts ...Option) *gnmiCache
 	return gc
 }
 
+// shard returns the shard holding the target,
+// picked using the FNV-1a hash of its name.
+func (gc *gnmiCache) shard(target string) *cacheShard {
+	h := uint32(2166136261)
+	for i := 0; i < len(target); i++ {
+		h ^= uint32(target[i])
+		h *= 16777619
+	}
+	return gc.shards[h%uint32(len(gc.shards))]
+}
+
+// getMatch returns the on-change queries registry of a subscription,
+// it is shared by the subscription caches of all shards.
+func (gc *gnmiCache) getMatch(name string) *match.Match {
+	gc.mm.Lock()
+	defer gc.mm.Unlock()
+	if m, ok := gc.matches[name]; ok {
+		return m
+	}
+	m := match.New()
+	gc.matches[name] = m
+	return m
+}
+
+// subCache returns the cache of subscription name holding the target.
+// Most writes only take the shard read lock.
+func (gc *gnmiCache) subCache(name, target string) *subCache {
+	sh := gc.shard(target)
+	sh.m.RLock()
+	sCache, ok := sh.caches[name]
+	sh.m.RUnlock()
+	if ok && sCache.c.HasTarget(target) {
+		return sCache
+	}
+	sh.m.Lock()
+	defer sh.m.Unlock()
+	if sCache, ok = sh.caches[name]; !ok {
+		sCache = &subCache{
+			c:     ocCache.New(nil),
+			match: gc.getMatch(name),
+			sm:    new(sync.Mutex),
+			sizes: make(map[string]map[string]int64),
+		}
+		sCache.c.SetClient(sCache.update)
+		sh.caches[name] = sCache
+	}
+	if !sCache.c.HasTarget(target) {
+		sCache.c.Add(target)
+		gc.logger.Printf("target %q added to local cache %q", target, name)
+	}
+	return sCache
+}
+
 func (gc *subCache) update(n *ctree.Leaf) {
 	switch v := n.Value().(type) {
 	case *gnmi.Notification:
+		gc.account(membudget.Default(), v)
 		pathElems := path.ToStrings(v.GetPrefix(), true)
 		subscribe.UpdateNotification(gc.match, n, v, pathElems)
 	default:
@@ -87,6 +163,59 @@ func (gc *subCache) update(n *ctree.Leaf) {
 	}
 }
 
+// account updates the cache size accounted in the memory budget b with the change
+// notified by n: an added or overwritten leaf, a deleted leaf or a removed target.
+func (gc *subCache) account(b *membudget.Budget, n *gnmi.Notification) {
+	if b == nil {
+		return
+	}
+	target := n.GetPrefix().GetTarget()
+	gc.sm.Lock()
+	defer gc.sm.Unlock()
+	leaves := gc.sizes[target]
+	switch {
+	case len(n.GetUpdate()) > 0:
+		// atomic notifications are stored as a single leaf under their prefix
+		var suffix *gnmi.Path
+		if !n.GetAtomic() {
+			suffix = n.GetUpdate()[0].GetPath()
+		}
+		if leaves == nil {
+			leaves = make(map[string]int64)
+			gc.sizes[target] = leaves
+		}
+		key := leafKey(n.GetPrefix(), suffix)
+		size := int64(proto.Size(n))
+		old := leaves[key]
+		leaves[key] = size
+		b.Acquire(membudget.Cache, size-old)
+		b.Release(membudget.Cache, old-size)
+	case len(n.GetDelete()) > 0:
+		d := n.GetDelete()[0]
+		// the target was removed from the cache
+		if len(d.GetElem()) == 1 && d.GetElem()[0].GetName() == "*" && len(d.GetElem()[0].GetKey()) == 0 {
+			var size int64
+			for _, s := range leaves {
+				size += s
+			}
+			delete(gc.sizes, target)
+			b.Release(membudget.Cache, size)
+			return
+		}
+		key := leafKey(n.GetPrefix(), d)
+		if size, ok := leaves[key]; ok {
+			delete(leaves, key)
+			b.Release(membudget.Cache, size)
+		}
+	}
+}
+
+// leafKey returns the key of the cache leaf at path p under prefix,
+// the deleted leaves are notified with their full path and a prefix without elements.
+func leafKey(prefix, p *gnmi.Path) string {
+	return strings.Join(append(path.ToStrings(prefix, true), path.ToStrings(p, false)...), "\x00")
+}
+
 func (gc *gnmiCache) SetLogger(logger *log.Logger) {
 	if logger != nil && gc.logger != nil {
 		gc.logger.SetOutput(logger.Writer())
@@ -105,21 +234,7 @@ func (gc *gnmiCache) Write(ctx context.Context, measName string, m proto.Message
 				gc.logger.Printf("subscription=%q: response missing target: %v", measName, rsp)
 				return
 			}
-			var sCache *subCache
-			gc.m.Lock()
-			if _, ok := gc.caches[measName]; !ok {
-				sCache = &subCache{
-					c:     ocCache.New(nil),
-					match: match.New(),
-				}
-				sCache.c.SetClient(sCache.update)
-				sCache.c.Add(target)
-				gc.caches[measName] = sCache
-			} else if sCache = gc.caches[measName]; !sCache.c.HasTarget(target) {
-				sCache.c.Add(target)
-				gc.logger.Printf("target %q added to local cache %q", target, measName)
-			}
-			gc.m.Unlock()
+			sCache := gc.subCache(measName, target)
 			// do not write updates with nil values to cache.
 			notif := &gnmi.Notification{
 				Timestamp: rsp.Update.GetTimestamp(),
@@ -158,6 +273,16 @@ func (gc *gnmiCache) Subscribe(ctx context.Context, ro *ReadOpts) chan *Notifica
 
 	ro.setDefaults()
 	ch := make(chan *Notification)
+	if !ro.StartTime.IsZero() {
+		go func() {
+			defer close(ch)
+			select {
+			case ch <- &Notification{Err: ErrHistoryNotSupported}:
+			case <-ctx.Done():
+			}
+		}()
+		return ch
+	}
 	go gc.subscribe(ctx, ro, ch)
 
 	return ch
@@ -181,93 +306,98 @@ func (gc *gnmiCache) handleSingleQuery(ctx context.Context, ro *ReadOpts, ch cha
 		gc.logger.Printf("running single query for target %q", ro.Target)
 	}
 
-	caches := gc.getCaches(ro.Subscription)
+	caches := gc.getCaches(ro.Target, ro.Subscription)
 
 	if gc.debug {
 		gc.logger.Printf("single query got %d caches", len(caches))
 	}
 	wg := new(sync.WaitGroup)
-	wg.Add(len(caches))
 
-	for name, c := range caches {
-		go func(name string, c *subCache) {
-			defer wg.Done()
-			for _, p := range ro.Paths {
-				fp, err := path.CompletePath(p, nil)
+	for name, cs := range caches {
+		wg.Add(len(cs))
+		for _, c := range cs {
+			go gc.singleQuery(wg, ro, name, c, ch)
+		}
+	}
+	wg.Wait()
+}
+
+// singleQuery runs a query against a single subscription cache.
+func (gc *gnmiCache) singleQuery(wg *sync.WaitGroup, ro *ReadOpts, name string, c *subCache, ch chan *Notification) {
+	defer wg.Done()
+	for _, p := range ro.Paths {
+		fp, err := path.CompletePath(p, nil)
+		if err != nil {
+			gc.logger.Printf("failed to generate CompletePath from %v", p)
+			ch <- &Notification{Name: name, Err: err}
+			return
+		}
+		err = c.c.Query(ro.Target, fp,
+			func(_ []string, l *ctree.Leaf, _ interface{}) error {
 				if err != nil {
-					gc.logger.Printf("failed to generate CompletePath from %v", p)
-					ch <- &Notification{Name: name, Err: err}
-					return
+					return err
 				}
-				err = c.c.Query(ro.Target, fp,
-					func(_ []string, l *ctree.Leaf, _ interface{}) error {
-						if err != nil {
-							return err
-						}
-						switch gl := l.Value().(type) {
-						case *gnmi.Notification:
-							if ro.OverrideTS {
-								// override timestamp
-								gl = proto.Clone(gl).(*gnmi.Notification)
-								gl.Timestamp = time.Now().UnixNano()
-							}
-							//no suppress redundant, send to channel and return
-							if !ro.SuppressRedundant {
-								ch <- &Notification{Name: name, Notification: gl}
-								return nil
-							}
-							// suppress redundant part
-							if ro.lastSent == nil {
-								ro.lastSent = make(map[string]*gnmi.TypedValue)
-								ro.m = new(sync.RWMutex)
-							}
+				switch gl := l.Value().(type) {
+				case *gnmi.Notification:
+					if ro.OverrideTS {
+						// override timestamp
+						gl = proto.Clone(gl).(*gnmi.Notification)
+						gl.Timestamp = time.Now().UnixNano()
+					}
+					//no suppress redundant, send to channel and return
+					if !ro.SuppressRedundant {
+						ch <- &Notification{Name: name, Notification: gl}
+						return nil
+					}
+					// suppress redundant part
+					if ro.lastSent == nil {
+						ro.lastSent = make(map[string]*gnmi.TypedValue)
+						ro.m = new(sync.RWMutex)
+					}
 
-							prefix := utils.GnmiPathToXPath(gl.GetPrefix(), true)
-							target := gl.GetPrefix().GetTarget()
-							for _, upd := range gl.GetUpdate() {
-								path := utils.GnmiPathToXPath(upd.GetPath(), true)
-								valXPath := strings.Join([]string{target, prefix, path}, "/")
-								ro.m.RLock()
-								sv, ok := ro.lastSent[valXPath]
-								ro.m.RUnlock()
-								if !ok || !proto.Equal(sv, upd.Val) {
-									ch <- &Notification{
-										Name: name,
-										Notification: &gnmi.Notification{
-											Timestamp: gl.GetTimestamp(),
-											Prefix:    gl.GetPrefix(),
-											Update:    []*gnmi.Update{upd},
-										},
-									}
-									ro.m.Lock()
-									ro.lastSent[valXPath] = upd.Val
-									ro.m.Unlock()
-								}
+					prefix := utils.GnmiPathToXPath(gl.GetPrefix(), true)
+					target := gl.GetPrefix().GetTarget()
+					for _, upd := range gl.GetUpdate() {
+						path := utils.GnmiPathToXPath(upd.GetPath(), true)
+						valXPath := strings.Join([]string{target, prefix, path}, "/")
+						ro.m.RLock()
+						sv, ok := ro.lastSent[valXPath]
+						ro.m.RUnlock()
+						if !ok || !proto.Equal(sv, upd.Val) {
+							ch <- &Notification{
+								Name: name,
+								Notification: &gnmi.Notification{
+									Timestamp: gl.GetTimestamp(),
+									Prefix:    gl.GetPrefix(),
+									Update:    []*gnmi.Update{upd},
+								},
 							}
+							ro.m.Lock()
+							ro.lastSent[valXPath] = upd.Val
+							ro.m.Unlock()
+						}
+					}
 
-							if gl.GetDelete() != nil {
-								ch <- &Notification{
-									Name: name,
-									Notification: &gnmi.Notification{
-										Timestamp: gl.GetTimestamp(),
-										Prefix:    gl.GetPrefix(),
-										Delete:    gl.GetDelete(),
-									},
-								}
-							}
-							return nil
+					if gl.GetDelete() != nil {
+						ch <- &Notification{
+							Name: name,
+							Notification: &gnmi.Notification{
+								Timestamp: gl.GetTimestamp(),
+								Prefix:    gl.GetPrefix(),
+								Delete:    gl.GetDelete(),
+							},
 						}
-						return nil
-					})
-				if err != nil {
-					gc.logger.Printf("target %q failed internal cache query: %v", ro.Target, err)
-					ch <- &Notification{Name: name, Err: err}
-					return
+					}
+					return nil
 				}
-			}
-		}(name, c)
+				return nil
+			})
+		if err != nil {
+			gc.logger.Printf("target %q failed internal cache query: %v", ro.Target, err)
+			ch <- &Notification{Name: name, Err: err}
+			return
+		}
 	}
-	wg.Wait()
 }
 
 func (gc *gnmiCache) handleSampledQuery(ctx context.Context, ro *ReadOpts, ch chan *Notification) {
@@ -289,14 +419,14 @@ func (gc *gnmiCache) handleSampledQuery(ctx context.Context, ro *ReadOpts, ch ch
 }
 
 func (gc *gnmiCache) handleOnChangeQuery(ctx context.Context, ro *ReadOpts, ch chan *Notification) {
-	caches := gc.getCaches(ro.Subscription)
+	caches := gc.getCaches(ro.Target, ro.Subscription)
 	numCaches := len(caches)
 	gc.logger.Printf("on-change query got %d caches", numCaches)
 	wg := new(sync.WaitGroup)
 	wg.Add(numCaches)
 
-	for name, c := range caches {
-		go func(name string, c *subCache) {
+	for name, cs := range caches {
+		go func(name string, cs []*subCache) {
 			defer wg.Done()
 
 			for _, p := range ro.Paths {
@@ -308,25 +438,28 @@ func (gc *gnmiCache) handleOnChangeQuery(ctx context.Context, ro *ReadOpts, ch c
 						ch <- &Notification{Name: name, Err: err}
 						return
 					}
-					err = c.c.Query(ro.Target, cp,
-						func(_ []string, l *ctree.Leaf, _ interface{}) error {
-							switch gl := l.Value().(type) {
-							case *gnmi.Notification:
-								ch <- &Notification{Name: name, Notification: gl}
-							}
-							return nil
-						})
-					if err != nil {
-						gc.logger.Printf("failed to run cache query for target %q and path %q: %v", ro.Target, cp, err)
-						ch <- &Notification{Name: name, Err: err}
-						return
+					for _, c := range cs {
+						err = c.c.Query(ro.Target, cp,
+							func(_ []string, l *ctree.Leaf, _ interface{}) error {
+								switch gl := l.Value().(type) {
+								case *gnmi.Notification:
+									ch <- &Notification{Name: name, Notification: gl}
+								}
+								return nil
+							})
+						if err != nil {
+							gc.logger.Printf("failed to run cache query for target %q and path %q: %v", ro.Target, cp, err)
+							ch <- &Notification{Name: name, Err: err}
+							return
+						}
 					}
 				}
-				// main on-change subscription
+				// main on-change subscription,
+				// registered once for all the shards of the subscription.
 				fp := path.ToStrings(p, true)
 				// set callback
 				mc := &matchClient{name: name, ch: ch}
-				remove := c.match.AddQuery(fp, mc)
+				remove := gc.getMatch(name).AddQuery(fp, mc)
 				defer remove()
 
 				// handle on-change heartbeat
@@ -345,7 +478,7 @@ func (gc *gnmiCache) handleOnChangeQuery(ctx context.Context, ro *ReadOpts, ch c
 
 			for range ctx.Done() {
 			}
-		}(name, c)
+		}(name, cs)
 	}
 	wg.Wait()
 }
@@ -353,8 +486,6 @@ func (gc *gnmiCache) handleOnChangeQuery(ctx context.Context, ro *ReadOpts, ch c
 func (gc *gnmiCache) Stop() {}
 
 func (gc *gnmiCache) readNotifications() map[string][]*gnmi.Notification {
-	var err error
-
 	notificationChan := make(chan *Notification)
 	notifications := make(map[string][]*gnmi.Notification, 0)
 	doneCh := make(chan struct{})
@@ -372,34 +503,32 @@ func (gc *gnmiCache) readNotifications() map[string][]*gnmi.Notification {
 
 	now := time.Now()
 	wg := new(sync.WaitGroup)
-	caches := gc.getCaches()
-	wg.Add(len(caches))
-	for name, c := range caches {
-		go func(c *subCache, name string) {
-			defer wg.Done()
-			err = c.c.Query("*", []string{},
-				func(_ []string, _ *ctree.Leaf, v interface{}) error {
-					if err != nil {
-						return err
-					}
-					switch notif := v.(type) {
-					case *gnmi.Notification:
-						if gc.expiration > 0 &&
-							time.Unix(0, notif.Timestamp).Before(now.Add(time.Duration(-gc.expiration))) {
-							return nil
-						}
-						notificationChan <- &Notification{
-							Name:         name,
-							Notification: notif,
+	for name, cs := range gc.getCaches("*") {
+		wg.Add(len(cs))
+		for _, c := range cs {
+			go func(c *subCache, name string) {
+				defer wg.Done()
+				err := c.c.Query("*", []string{},
+					func(_ []string, _ *ctree.Leaf, v interface{}) error {
+						switch notif := v.(type) {
+						case *gnmi.Notification:
+							if gc.expiration > 0 &&
+								time.Unix(0, notif.Timestamp).Before(now.Add(time.Duration(-gc.expiration))) {
+								return nil
+							}
+							notificationChan <- &Notification{
+								Name:         name,
+								Notification: notif,
+							}
 						}
-					}
-					return nil
-				})
-			if err != nil {
-				gc.logger.Printf("failed cache query:%v", err)
-				return
-			}
-		}(c, name)
+						return nil
+					})
+				if err != nil {
+					gc.logger.Printf("failed cache query:%v", err)
+					return
+				}
+			}(c, name)
+		}
 	}
 	wg.Wait()
 	close(notificationChan)
@@ -408,30 +537,45 @@ func (gc *gnmiCache) readNotifications() map[string][]*gnmi.Notification {
 	return notifications
 }
 
-func (gc *gnmiCache) getCaches(names ...string) map[string]*subCache {
-	gc.m.Lock()
-	defer gc.m.Unlock()
-
-	caches := make(map[string]*subCache)
-	numCaches := len(names)
-	if numCaches == 0 || (numCaches == 1 && names[0] == "") {
-		for n, c := range gc.caches {
-			caches[n] = c
+// getCaches returns the caches of the given subscriptions, all of them if no name is set,
+// grouped by subscription name.
+// Unless target is "*", only the caches holding the target are returned.
+func (gc *gnmiCache) getCaches(target string, names ...string) map[string][]*subCache {
+	shards := gc.shards
+	anyTarget := target == "*" || target == ""
+	if !anyTarget {
+		shards = []*cacheShard{gc.shard(target)}
+	}
+	all := len(names) == 0 || (len(names) == 1 && names[0] == "")
+	caches := make(map[string][]*subCache)
+	add := func(n string, c *subCache) {
+		if anyTarget || c.c.HasTarget(target) {
+			caches[n] = append(caches[n], c)
 		}
-		return caches
 	}
-	for _, n := range names {
-		if c, ok := gc.caches[n]; ok {
-			caches[n] = c
+	for _, sh := range shards {
+		sh.m.RLock()
+		if all {
+			for n, c := range sh.caches {
+				add(n, c)
+			}
+		} else {
+			for _, n := range names {
+				if c, ok := sh.caches[n]; ok {
+					add(n, c)
+				}
+			}
 		}
+		sh.m.RUnlock()
 	}
 	return caches
 }
 
 func (gc *gnmiCache) DeleteTarget(name string) {
-	caches := gc.getCaches()
-	for _, c := range caches {
-		c.c.Remove(name)
+	for _, cs := range gc.getCaches(name) {
+		for _, c := range cs {
+			c.c.Remove(name)
+		}
 	}
 }
 
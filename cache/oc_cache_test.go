@@ -0,0 +1,190 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/membudget"
+)
+
+func testCacheResponse(target string, ts int64, val string) *gnmi.SubscribeResponse {
+	return &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Prefix:    &gnmi.Path{Target: target},
+				Timestamp: ts,
+				Update: []*gnmi.Update{
+					{
+						Path: &gnmi.Path{
+							Elem: []*gnmi.PathElem{
+								{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}},
+								{Name: "description"},
+							},
+						},
+						Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_AsciiVal{AsciiVal: val}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func Test_gnmiCache_shards(t *testing.T) {
+	gc := newGNMICache(&Config{Shards: 4}, "")
+	ctx := context.Background()
+	now := time.Now().UnixNano()
+	numTargets := 20
+	for i := 0; i < numTargets; i++ {
+		gc.Write(ctx, "sub1", testCacheResponse(fmt.Sprintf("router%d", i), now, "d1"))
+		if i%2 == 0 {
+			gc.Write(ctx, "sub2", testCacheResponse(fmt.Sprintf("router%d", i), now, "d2"))
+		}
+	}
+	rs, err := gc.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs["sub1"]) != numTargets || len(rs["sub2"]) != numTargets/2 {
+		t.Errorf("expected %d and %d notifications, got %d and %d", numTargets, numTargets/2, len(rs["sub1"]), len(rs["sub2"]))
+	}
+	// a query for a single target only returns that target notifications
+	ch := gc.Subscribe(ctx, &ReadOpts{Target: "router3", Mode: ReadMode_Once})
+	count := 0
+	for n := range ch {
+		if n.Err != nil {
+			t.Fatal(n.Err)
+		}
+		if n.Name != "sub1" || n.Notification.GetPrefix().GetTarget() != "router3" {
+			t.Errorf("unexpected notification %q: %v", n.Name, n.Notification)
+		}
+		count++
+	}
+	if count != 1 {
+		t.Errorf("expected a single notification, got %d", count)
+	}
+	gc.DeleteTarget("router3")
+	rs, err = gc.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs["sub1"]) != numTargets-1 {
+		t.Errorf("expected %d notifications after deleting a target, got %d", numTargets-1, len(rs["sub1"]))
+	}
+}
+
+func Test_gnmiCache_onChangeNewShard(t *testing.T) {
+	gc := newGNMICache(&Config{Shards: 8}, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	now := time.Now().UnixNano()
+	gc.Write(ctx, "sub1", testCacheResponse("router0", now, "d1"))
+	ch := gc.Subscribe(ctx, &ReadOpts{Subscription: "sub1", UpdatesOnly: true})
+	// wait for the query to be registered
+	time.Sleep(50 * time.Millisecond)
+	// targets landing in other shards are created after the subscription.
+	// the on-change notifications are sent by the writer, write from another goroutine.
+	targets := make(map[string]struct{})
+	for i := 1; i < 16; i++ {
+		targets[fmt.Sprintf("router%d", i)] = struct{}{}
+	}
+	go func() {
+		for i := 1; i < 16; i++ {
+			gc.Write(ctx, "sub1", testCacheResponse(fmt.Sprintf("router%d", i), now, "d1"))
+		}
+	}()
+	timeout := time.After(5 * time.Second)
+	for len(targets) > 0 {
+		select {
+		case n := <-ch:
+			delete(targets, n.Notification.GetPrefix().GetTarget())
+		case <-timeout:
+			t.Fatalf("missing on-change notifications for targets %v", targets)
+		}
+	}
+}
+
+func Test_gnmiCache_memoryBudget(t *testing.T) {
+	b, err := membudget.New(&membudget.Config{Limit: "1MiB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	membudget.SetDefault(b)
+	defer membudget.SetDefault(nil)
+	usage := func() int64 { return b.Stats().Usage[membudget.Cache.String()] }
+
+	gc := newGNMICache(&Config{Shards: 4}, "")
+	ctx := context.Background()
+	gc.Write(ctx, "sub1", testCacheResponse("t1", 1, "a"))
+	u1 := usage()
+	if u1 <= 0 {
+		t.Fatalf("expected the cached leaf to be accounted, got %d", u1)
+	}
+	// an overwritten leaf is accounted once, with its new size
+	gc.Write(ctx, "sub1", testCacheResponse("t1", 2, "a longer description"))
+	u2 := usage()
+	if u2 <= u1 {
+		t.Fatalf("expected the usage to grow from %d, got %d", u1, u2)
+	}
+	gc.Write(ctx, "sub1", testCacheResponse("t1", 3, "a"))
+	if u := usage(); u != u1 {
+		t.Fatalf("expected the usage to shrink back to %d, got %d", u1, u)
+	}
+	gc.Write(ctx, "sub1", testCacheResponse("t2", 1, "a"))
+	if u := usage(); u != 2*u1 {
+		t.Fatalf("expected the usage of 2 targets to be %d, got %d", 2*u1, u)
+	}
+	// a deleted leaf is released
+	rsp := testCacheResponse("t1", 4, "up")
+	n := rsp.GetUpdate()
+	n.Update[0].Path = &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}, {Name: "oper-state"}}}
+	n.Delete = []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}, {Name: "description"}}}}
+	gc.Write(ctx, "sub1", rsp)
+	u3 := usage()
+	if u3 <= u1 || u3 >= 3*u1 {
+		t.Fatalf("expected the deleted leaf to be released, got %d", u3)
+	}
+	// a removed target is released
+	gc.DeleteTarget("t1")
+	if u := usage(); u != u1 {
+		t.Fatalf("expected the usage of the remaining target to be %d, got %d", u1, u)
+	}
+	gc.DeleteTarget("t2")
+	if u := usage(); u != 0 {
+		t.Fatalf("expected no usage after removing all the targets, got %d", u)
+	}
+}
+
+func Benchmark_gnmiCache_Write(b *testing.B) {
+	for _, numTargets := range []int{10, 100, 500} {
+		for _, shards := range []int{1, defaultShards} {
+			b.Run(fmt.Sprintf("targets=%d/shards=%d", numTargets, shards), func(b *testing.B) {
+				gc := newGNMICache(&Config{Shards: shards}, "")
+				ctx := context.Background()
+				targets := make([]string, numTargets)
+				for i := range targets {
+					targets[i] = fmt.Sprintf("router%d", i)
+				}
+				var ts int64
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						n := atomic.AddInt64(&ts, 1)
+						gc.Write(ctx, "sub1", testCacheResponse(targets[n%int64(numTargets)], n, "d1"))
+					}
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// newProfilesCmd represents the profiles command
+func newProfilesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "profiles",
+		Short:        "manage subscription profiles",
+		SilenceUsage: true,
+	}
+	cmd.AddCommand(newProfilesListCmd())
+	cmd.AddCommand(newProfilesLintCmd())
+	return cmd
+}
+
+// newProfilesListCmd represents the profiles list command
+func newProfilesListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "list",
+		Aliases:      []string{"ls"},
+		Short:        "list the subscription profiles defined in the config file",
+		RunE:         gApp.ProfilesListRunE,
+		SilenceUsage: true,
+	}
+	return cmd
+}
+
+// newProfilesLintCmd represents the profiles lint command
+func newProfilesLintCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "lint",
+		Short:        "check the subscription profiles for conflicts and invalid settings",
+		RunE:         gApp.ProfilesLintRunE,
+		SilenceUsage: true,
+	}
+	return cmd
+}
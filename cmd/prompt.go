@@ -9,6 +9,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -236,6 +237,34 @@ var outputListCmd = &cobra.Command{
 	},
 }
 
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "manipulate the prompt YANG schema",
+}
+
+var schemaLoadCmd = &cobra.Command{
+	Use:   "load",
+	Short: "load the YANG schema of a target based on its supported models",
+	Annotations: map[string]string{
+		"--name": "TARGET",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if name == "" {
+			fmt.Println("provide a target name with --name")
+			return nil
+		}
+		err := gApp.LoadTargetSchema(context.Background(), name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("loaded %d YANG modules from target %q\n", len(gApp.SchemaTree.Dir), name)
+		return nil
+	},
+	PostRun: func(cmd *cobra.Command, args []string) {
+		name = ""
+	},
+}
+
 func renderTable(tabData [][]string, header []string) {
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader(header)
@@ -849,6 +878,7 @@ func initPromptCmds() {
 	gApp.RootCmd.AddCommand(targetCmd)
 	gApp.RootCmd.AddCommand(subscriptionCmd)
 	gApp.RootCmd.AddCommand(outputCmd)
+	gApp.RootCmd.AddCommand(schemaCmd)
 
 	targetCmd.AddCommand(targetListCmd)
 	targetCmd.AddCommand(targetShowCmd)
@@ -860,6 +890,9 @@ func initPromptCmds() {
 
 	outputCmd.AddCommand(outputListCmd)
 
+	schemaCmd.AddCommand(schemaLoadCmd)
+	schemaLoadCmd.Flags().StringVarP(&name, "name", "", "", "target name")
+
 	gApp.RootCmd.RemoveCommand(promptModeCmd)
 }
 
@@ -886,6 +919,7 @@ func (co cmdPrompt) Run() {
 			}
 			os.Args = append([]string{os.Args[0]}, promptArgs...)
 			if len(promptArgs) > 0 {
+				validateXPATHArgs(co.RootCmd, promptArgs)
 				err := co.RootCmd.Execute()
 				if err == nil && in != "" {
 					gApp.PromptHistory = append(gApp.PromptHistory, in)
@@ -900,6 +934,47 @@ func (co cmdPrompt) Run() {
 	p.Run()
 }
 
+// validateXPATHArgs prints a warning for each XPATH flag value
+// not found in the schema loaded from a target.
+func validateXPATHArgs(root *cobra.Command, args []string) {
+	if gApp.SchemaTarget == "" {
+		return
+	}
+	command, _, err := root.Find(args)
+	if err != nil {
+		return
+	}
+	prefix := ""
+	xpaths := make([]string, 0)
+	for i, arg := range args {
+		flagName, value := arg, ""
+		if j := strings.Index(arg, "="); j >= 0 {
+			flagName, value = arg[:j], arg[j+1:]
+		} else if i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch command.Annotations[flagName] {
+		case "PREFIX":
+			prefix = value
+		case "XPATH":
+			// remove the type and value of the set command update/replace flags
+			value = strings.SplitN(value, ":::", 2)[0]
+			if value != "" {
+				xpaths = append(xpaths, value)
+			}
+		}
+	}
+	for _, xpath := range xpaths {
+		p := xpath
+		if prefix != "" {
+			p = strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(xpath, "/")
+		}
+		if !gApp.SchemaPathExists(p) {
+			fmt.Fprintf(os.Stderr, "WARN: path %q not found in the schema of target %q\n", p, gApp.SchemaTarget)
+		}
+	}
+}
+
 func parsePromptArgs(in string) ([]string, error) {
 	var m = []string{}
 	var s string
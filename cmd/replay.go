@@ -0,0 +1,29 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// replayCmd represents the replay command
+func newReplayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "replay recorded subscribe responses to the configured outputs and gNMI server",
+		Annotations: map[string]string{
+			"--input": "FILE",
+		},
+		PreRunE:      gApp.ReplayPreRunE,
+		RunE:         gApp.ReplayRunE,
+		SilenceUsage: true,
+	}
+	gApp.InitReplayFlags(cmd)
+	return cmd
+}
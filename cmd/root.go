@@ -10,6 +10,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -50,12 +51,17 @@ func newRootCmd() *cobra.Command {
 		PersistentPreRunE: gApp.PreRunE,
 	}
 	gApp.InitGlobalFlags()
+	gApp.RootCmd.AddCommand(newApplyCmd())
+	gApp.RootCmd.AddCommand(newBackupCmd())
 	gApp.RootCmd.AddCommand(newCompletionCmd())
 	gApp.RootCmd.AddCommand(newCapabilitiesCmd())
 	gApp.RootCmd.AddCommand(newGetCmd())
 	gApp.RootCmd.AddCommand(newGetSetCmd())
 	gApp.RootCmd.AddCommand(newListenCmd())
 	gApp.RootCmd.AddCommand(newPathCmd())
+	gApp.RootCmd.AddCommand(newProcessorCmd())
+	gApp.RootCmd.AddCommand(newProfilesCmd())
+	gApp.RootCmd.AddCommand(newReplayCmd())
 	gApp.RootCmd.AddCommand(newDiffCmd())
 	//
 	genCmd := newGenerateCmd()
@@ -66,6 +72,8 @@ func newRootCmd() *cobra.Command {
 	gApp.RootCmd.AddCommand(newPromptCmd())
 	gApp.RootCmd.AddCommand(newSetCmd())
 	gApp.RootCmd.AddCommand(newSubscribeCmd())
+	gApp.RootCmd.AddCommand(newValidateCmd())
+	gApp.RootCmd.AddCommand(newWatchCmd())
 	//
 	versionCmd := newVersionCmd()
 	versionCmd.AddCommand(newVersionUpgradeCmd())
@@ -77,9 +85,13 @@ func newRootCmd() *cobra.Command {
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	setupCloseHandler(gApp.Cfn)
+	setupCloseHandler(gApp.Shutdown)
 	if err := newRootCmd().Execute(); err != nil {
 		//fmt.Println(err)
+		var eerr *app.ExitError
+		if errors.As(err, &eerr) {
+			os.Exit(eerr.Code)
+		}
 		os.Exit(1)
 	}
 	if gApp.PromptMode {
@@ -0,0 +1,40 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/openconfig/gnmic/utils"
+)
+
+// getACME reads the ACME config under the server config key,
+// it returns nil if it is not set.
+func (c *Config) getACME(key string) (*utils.ACMEConfig, error) {
+	if !c.FileConfig.IsSet(key + "/acme") {
+		return nil, nil
+	}
+	acme := &utils.ACMEConfig{
+		Email:        os.ExpandEnv(c.FileConfig.GetString(key + "/acme/email")),
+		DirectoryURL: os.ExpandEnv(c.FileConfig.GetString(key + "/acme/directory-url")),
+		CacheDir:     os.ExpandEnv(c.FileConfig.GetString(key + "/acme/cache-dir")),
+		Challenge:    os.ExpandEnv(c.FileConfig.GetString(key + "/acme/challenge")),
+		DNSHook:      os.ExpandEnv(c.FileConfig.GetString(key + "/acme/dns-hook")),
+		RenewBefore:  c.FileConfig.GetDuration(key + "/acme/renew-before"),
+	}
+	for _, d := range c.FileConfig.GetStringSlice(key + "/acme/domains") {
+		acme.Domains = append(acme.Domains, os.ExpandEnv(d))
+	}
+	err := acme.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", key, err)
+	}
+	return acme, nil
+}
@@ -9,8 +9,13 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/openconfig/gnmic/utils"
 )
 
 const (
@@ -22,14 +27,71 @@ const (
 type APIServer struct {
 	Address string        `mapstructure:"address,omitempty" json:"address,omitempty"`
 	Timeout time.Duration `mapstructure:"timeout,omitempty" json:"timeout,omitempty"`
+	// unix socket file permissions, e.g: 0660
+	SocketPermissions string `mapstructure:"socket-permissions,omitempty" json:"socket-permissions,omitempty"`
 	// TLS
 	SkipVerify bool   `mapstructure:"skip-verify,omitempty" json:"skip-verify,omitempty"`
 	CaFile     string `mapstructure:"ca-file,omitempty" json:"ca-file,omitempty"`
 	CertFile   string `mapstructure:"cert-file,omitempty" json:"cert-file,omitempty"`
 	KeyFile    string `mapstructure:"key-file,omitempty" json:"key-file,omitempty"`
+	// obtain the server certificate from an ACME CA instead of the cert and key files
+	ACME *utils.ACMEConfig `mapstructure:"acme,omitempty" json:"acme,omitempty"`
+	// SPIFFE IDs or trust domains of the authorized clients,
+	// when set, the server uses the gnmic X.509 SVID and requires the clients to present one
+	SpiffeIDs []string `mapstructure:"spiffe-ids,omitempty" json:"spiffe-ids,omitempty"`
+	// TLS versions, cipher suites and curves accepted by the server
+	TLSPolicy *utils.TLSPolicy `mapstructure:"tls-policy,omitempty" json:"tls-policy,omitempty"`
+	// address of the admin gRPC service, disabled if empty
+	GRPCAddress string `mapstructure:"grpc-address,omitempty" json:"grpc-address,omitempty"`
+	// API authentication, disabled if nil
+	Auth *APIAuth `mapstructure:"auth,omitempty" json:"auth,omitempty"`
 	//
 	EnableMetrics bool `mapstructure:"enable-metrics,omitempty" json:"enable-metrics,omitempty"`
-	Debug         bool `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+	// serve the web UI under /ui
+	EnableUI bool `mapstructure:"enable-ui,omitempty" json:"enable-ui,omitempty"`
+	// serve pprof, expvar and the dump triggers under /debug
+	EnableProfiling bool `mapstructure:"enable-profiling,omitempty" json:"enable-profiling,omitempty"`
+	// directory the goroutine and heap dumps are written to
+	DumpDirectory string `mapstructure:"dump-directory,omitempty" json:"dump-directory,omitempty"`
+	Debug         bool   `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+}
+
+// APIAuth holds the credentials accepted by the API server.
+// A request is authenticated if it matches any of the configured methods.
+type APIAuth struct {
+	// static bearer tokens
+	Tokens []string `mapstructure:"tokens,omitempty" json:"-"`
+	// static bearer tokens restricted to a set of namespaces
+	ScopedTokens []*APIToken `mapstructure:"scoped-tokens,omitempty" json:"scoped-tokens,omitempty"`
+	// basic authentication users
+	Users []*APIUser `mapstructure:"users,omitempty" json:"-"`
+	// OIDC bearer tokens (JWT) validation
+	OIDC *APIOIDC `mapstructure:"oidc,omitempty" json:"oidc,omitempty"`
+}
+
+type APIUser struct {
+	Username string `mapstructure:"username,omitempty" json:"username,omitempty"`
+	// plain text password or bcrypt hash
+	Password string `mapstructure:"password,omitempty" json:"-"`
+	// namespaces the user is restricted to, all if empty
+	Namespaces []string `mapstructure:"namespaces,omitempty" json:"namespaces,omitempty"`
+}
+
+// APIToken is a bearer token only granting access
+// to the targets and subscriptions of its namespaces.
+type APIToken struct {
+	Token      string   `mapstructure:"token,omitempty" json:"-"`
+	Namespaces []string `mapstructure:"namespaces,omitempty" json:"namespaces,omitempty"`
+}
+
+type APIOIDC struct {
+	// expected token issuer, used for discovery if JWKSURL is not set
+	Issuer string `mapstructure:"issuer,omitempty" json:"issuer,omitempty"`
+	// expected token audience, not checked if empty
+	Audience string `mapstructure:"audience,omitempty" json:"audience,omitempty"`
+	// URL of the issuer JSON Web Key Set
+	JWKSURL    string `mapstructure:"jwks-url,omitempty" json:"jwks-url,omitempty"`
+	SkipVerify bool   `mapstructure:"skip-verify,omitempty" json:"skip-verify,omitempty"`
 }
 
 func (c *Config) GetAPIServer() error {
@@ -42,12 +104,38 @@ func (c *Config) GetAPIServer() error {
 		c.APIServer.Address = os.ExpandEnv(c.FileConfig.GetString("api"))
 	}
 	c.APIServer.Timeout = c.FileConfig.GetDuration("api-server/timeout")
+	c.APIServer.SocketPermissions = os.ExpandEnv(c.FileConfig.GetString("api-server/socket-permissions"))
 	c.APIServer.SkipVerify = os.ExpandEnv(c.FileConfig.GetString("api-server/skip-verify")) == trueString
 	c.APIServer.CaFile = os.ExpandEnv(c.FileConfig.GetString("api-server/ca-file"))
 	c.APIServer.CertFile = os.ExpandEnv(c.FileConfig.GetString("api-server/cert-file"))
 	c.APIServer.KeyFile = os.ExpandEnv(c.FileConfig.GetString("api-server/key-file"))
+	var err error
+	c.APIServer.ACME, err = c.getACME("api-server")
+	if err != nil {
+		return err
+	}
+	for _, id := range c.FileConfig.GetStringSlice("api-server/spiffe-ids") {
+		c.APIServer.SpiffeIDs = append(c.APIServer.SpiffeIDs, os.ExpandEnv(id))
+	}
+	if len(c.APIServer.SpiffeIDs) > 0 && c.APIServer.ACME != nil {
+		return errors.New("api-server: spiffe-ids and acme are mutually exclusive")
+	}
+	c.APIServer.TLSPolicy, err = c.getTLSPolicy("api-server")
+	if err != nil {
+		return err
+	}
+	c.APIServer.GRPCAddress = os.ExpandEnv(c.FileConfig.GetString("api-server/grpc-address"))
+	if c.FileConfig.IsSet("api-server/auth") {
+		err := c.getAPIServerAuth()
+		if err != nil {
+			return err
+		}
+	}
 
 	c.APIServer.EnableMetrics = os.ExpandEnv(c.FileConfig.GetString("api-server/enable-metrics")) == trueString
+	c.APIServer.EnableUI = os.ExpandEnv(c.FileConfig.GetString("api-server/enable-ui")) == trueString
+	c.APIServer.EnableProfiling = os.ExpandEnv(c.FileConfig.GetString("api-server/enable-profiling")) == trueString
+	c.APIServer.DumpDirectory = os.ExpandEnv(c.FileConfig.GetString("api-server/dump-directory"))
 	c.APIServer.Debug = os.ExpandEnv(c.FileConfig.GetString("api-server/debug")) == trueString
 	c.setAPIServerDefaults()
 	return nil
@@ -60,4 +148,67 @@ func (c *Config) setAPIServerDefaults() {
 	if c.APIServer.Timeout <= 0 {
 		c.APIServer.Timeout = defaultAPIServerTimeout
 	}
+	if c.APIServer.DumpDirectory == "" {
+		c.APIServer.DumpDirectory = os.TempDir()
+	}
+}
+
+func (c *Config) getAPIServerAuth() error {
+	c.APIServer.Auth = new(APIAuth)
+	for _, t := range c.FileConfig.GetStringSlice("api-server/auth/tokens") {
+		t = os.ExpandEnv(t)
+		if t == "" {
+			continue
+		}
+		c.APIServer.Auth.Tokens = append(c.APIServer.Auth.Tokens, t)
+	}
+	// users are decoded from a list rather than a map
+	// so that the usernames keep their case.
+	if users := c.FileConfig.Get("api-server/auth/users"); users != nil {
+		err := mapstructure.Decode(users, &c.APIServer.Auth.Users)
+		if err != nil {
+			return fmt.Errorf("failed to decode api-server auth users: %v", err)
+		}
+		for i, u := range c.APIServer.Auth.Users {
+			if u == nil || u.Username == "" {
+				return fmt.Errorf("api-server auth user index %d: missing username", i)
+			}
+			u.Username = os.ExpandEnv(u.Username)
+			u.Password = os.ExpandEnv(u.Password)
+		}
+	}
+	if tokens := c.FileConfig.Get("api-server/auth/scoped-tokens"); tokens != nil {
+		err := mapstructure.Decode(tokens, &c.APIServer.Auth.ScopedTokens)
+		if err != nil {
+			return fmt.Errorf("failed to decode api-server auth scoped-tokens: %v", err)
+		}
+		for i, t := range c.APIServer.Auth.ScopedTokens {
+			if t == nil {
+				return fmt.Errorf("api-server auth scoped-token index %d: missing token", i)
+			}
+			t.Token = os.ExpandEnv(t.Token)
+			if t.Token == "" {
+				return fmt.Errorf("api-server auth scoped-token index %d: missing token", i)
+			}
+			if len(t.Namespaces) == 0 {
+				return fmt.Errorf("api-server auth scoped-token index %d: missing namespaces", i)
+			}
+		}
+	}
+	if c.FileConfig.IsSet("api-server/auth/oidc") {
+		c.APIServer.Auth.OIDC = &APIOIDC{
+			Issuer:     os.ExpandEnv(c.FileConfig.GetString("api-server/auth/oidc/issuer")),
+			Audience:   os.ExpandEnv(c.FileConfig.GetString("api-server/auth/oidc/audience")),
+			JWKSURL:    os.ExpandEnv(c.FileConfig.GetString("api-server/auth/oidc/jwks-url")),
+			SkipVerify: os.ExpandEnv(c.FileConfig.GetString("api-server/auth/oidc/skip-verify")) == trueString,
+		}
+		if c.APIServer.Auth.OIDC.Issuer == "" && c.APIServer.Auth.OIDC.JWKSURL == "" {
+			return errors.New("api-server auth oidc: one of issuer or jwks-url must be set")
+		}
+	}
+	if len(c.APIServer.Auth.Tokens) == 0 && len(c.APIServer.Auth.ScopedTokens) == 0 &&
+		len(c.APIServer.Auth.Users) == 0 && c.APIServer.Auth.OIDC == nil {
+		return errors.New("api-server auth: no authentication method configured")
+	}
+	return nil
 }
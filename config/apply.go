@@ -0,0 +1,211 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/api"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// applyVarsTargetsKey is the vars file key holding the per target variables.
+const applyVarsTargetsKey = "targets"
+
+// ApplyFile is the content of a rendered apply request file.
+type ApplyFile struct {
+	Requests []*ApplyRequest `json:"requests,omitempty" yaml:"requests,omitempty"`
+}
+
+// ApplyRequest is a single step of an apply request file,
+// exactly one of Get, Set or Subscribe must be set.
+type ApplyRequest struct {
+	Name      string                    `json:"name,omitempty" yaml:"name,omitempty"`
+	Get       *ApplyGet                 `json:"get,omitempty" yaml:"get,omitempty"`
+	Set       *SetRequestFile           `json:"set,omitempty" yaml:"set,omitempty"`
+	Subscribe *types.SubscriptionConfig `json:"subscribe,omitempty" yaml:"subscribe,omitempty"`
+}
+
+type ApplyGet struct {
+	Prefix   string   `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Paths    []string `json:"paths,omitempty" yaml:"paths,omitempty"`
+	Type     string   `json:"type,omitempty" yaml:"type,omitempty"`
+	Encoding string   `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+	Models   []string `json:"models,omitempty" yaml:"models,omitempty"`
+}
+
+type applyTemplateInput struct {
+	TargetName string
+	Target     *types.TargetConfig
+	Vars       map[string]interface{}
+}
+
+// ReadApplyFiles reads the apply request template files and the variables file.
+func (c *Config) ReadApplyFiles() error {
+	var err error
+	c.LocalFlags.ApplyFile = SanitizeArrayFlagValue(c.LocalFlags.ApplyFile)
+	if len(c.LocalFlags.ApplyFile) == 0 {
+		return errors.New("missing apply request file")
+	}
+	c.LocalFlags.ApplyFile, err = ExpandOSPaths(c.LocalFlags.ApplyFile)
+	if err != nil {
+		return err
+	}
+	c.LocalFlags.ApplyVars, err = expandOSPath(c.LocalFlags.ApplyVars)
+	if err != nil {
+		return err
+	}
+	c.applyTemplates = make([]*template.Template, len(c.LocalFlags.ApplyFile))
+	for i, f := range c.LocalFlags.ApplyFile {
+		var b []byte
+		b, err = utils.ReadFile(context.TODO(), f)
+		if err != nil {
+			return err
+		}
+		c.applyTemplates[i], err = utils.CreateTemplate(f, string(b))
+		if err != nil {
+			return err
+		}
+	}
+	c.applyVars = make(map[string]interface{})
+	if c.LocalFlags.ApplyVars == "" {
+		return nil
+	}
+	b, err := readFile(c.LocalFlags.ApplyVars)
+	if err != nil {
+		return err
+	}
+	var vars interface{}
+	err = yaml.Unmarshal(b, &vars)
+	if err != nil {
+		return err
+	}
+	switch vars := convert(vars).(type) {
+	case map[string]interface{}:
+		c.applyVars = vars
+	case nil:
+	default:
+		return fmt.Errorf("unexpected apply variables file format: %T", vars)
+	}
+	return nil
+}
+
+// applyTargetVars returns the variables of target tc:
+// the vars file global variables, overridden by the target event-tags and vars,
+// overridden by the vars file variables under targets/$target_name.
+func (c *Config) applyTargetVars(tc *types.TargetConfig) map[string]interface{} {
+	vars := make(map[string]interface{})
+	for k, v := range c.applyVars {
+		if k == applyVarsTargetsKey {
+			continue
+		}
+		vars[k] = v
+	}
+	for k, v := range tc.EventTags {
+		vars[k] = v
+	}
+	for k, v := range tc.Vars {
+		vars[k] = v
+	}
+	if targets, ok := c.applyVars[applyVarsTargetsKey].(map[string]interface{}); ok {
+		if tvars, ok := targets[tc.Name].(map[string]interface{}); ok {
+			for k, v := range tvars {
+				vars[k] = v
+			}
+		}
+	}
+	return vars
+}
+
+// CreateApplyRequests renders the apply request files for target tc.
+func (c *Config) CreateApplyRequests(tc *types.TargetConfig) ([]*ApplyRequest, error) {
+	input := &applyTemplateInput{
+		TargetName: tc.Name,
+		Target:     tc,
+		Vars:       c.applyTargetVars(tc),
+	}
+	reqs := make([]*ApplyRequest, 0)
+	buf := new(bytes.Buffer)
+	for _, tpl := range c.applyTemplates {
+		buf.Reset()
+		err := tpl.Execute(buf, input)
+		if err != nil {
+			return nil, err
+		}
+		if c.Debug {
+			c.logger.Printf("target %q apply template %q result:\n%s", tc.Name, tpl.Name(), buf.String())
+		}
+		af := new(ApplyFile)
+		err = yaml.Unmarshal(buf.Bytes(), af)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", tpl.Name(), err)
+		}
+		for i, r := range af.Requests {
+			if r.Name == "" {
+				r.Name = fmt.Sprintf("%s#%d", tpl.Name(), i)
+			}
+			n := 0
+			if r.Get != nil {
+				n++
+			}
+			if r.Set != nil {
+				n++
+			}
+			if r.Subscribe != nil {
+				n++
+			}
+			if n != 1 {
+				return nil, fmt.Errorf("request %q: exactly one of get, set or subscribe must be set", r.Name)
+			}
+			reqs = append(reqs, r)
+		}
+	}
+	return reqs, nil
+}
+
+func (c *Config) CreateApplyGetRequest(g *ApplyGet) (*gnmi.GetRequest, error) {
+	enc := g.Encoding
+	if enc == "" {
+		enc = c.Encoding
+	}
+	gnmiOpts := make([]api.GNMIOption, 0, 3+len(g.Paths))
+	gnmiOpts = append(gnmiOpts,
+		api.Encoding(enc),
+		api.DataType(g.Type),
+		api.Prefix(g.Prefix),
+	)
+	for _, p := range g.Paths {
+		gnmiOpts = append(gnmiOpts, api.Path(strings.TrimSpace(p)))
+	}
+	return api.NewGetRequest(gnmiOpts...)
+}
+
+func (c *Config) CreateApplySetRequest(s *SetRequestFile) (*gnmi.SetRequest, error) {
+	return c.setRequestFromFile(s)
+}
+
+// CreateApplySubscribeRequest creates a subscribe request in ONCE mode.
+func (c *Config) CreateApplySubscribeRequest(sc *types.SubscriptionConfig, target string) (*gnmi.SubscribeRequest, error) {
+	if sc.Mode != "" && strings.ToUpper(sc.Mode) != "ONCE" {
+		return nil, fmt.Errorf("unsupported subscription mode %q, only ONCE is supported", sc.Mode)
+	}
+	sc.Mode = "ONCE"
+	if sc.Encoding == "" {
+		sc.Encoding = c.Encoding
+	}
+	return c.CreateSubscribeRequest(sc, target)
+}
@@ -0,0 +1,133 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+const testApplyFile = `
+requests:
+  - name: get-system
+    get:
+      paths:
+        - /system/name
+      type: CONFIG
+  - set:
+      updates:
+        - path: /system/name/host-name
+          value: {{ .TargetName }}
+          encoding: json_ietf
+        - path: /system/location
+          value: {{ index .Vars "location" }}
+          encoding: json_ietf
+  - subscribe:
+      paths:
+        - /interface[name={{ .Vars.uplink }}]/statistics
+`
+
+const testApplyVars = `
+location: default
+uplink: ethernet-1/1
+targets:
+  router1:
+    location: dc1
+`
+
+func TestConfig_CreateApplyRequests(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "apply.yaml")
+	varsName := filepath.Join(dir, "vars.yaml")
+	err := os.WriteFile(fileName, []byte(testApplyFile), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(varsName, []byte(testApplyVars), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New()
+	c.Encoding = "json"
+	c.LocalFlags.ApplyFile = []string{fileName}
+	c.LocalFlags.ApplyVars = varsName
+	err = c.ReadApplyFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		target   *types.TargetConfig
+		location string
+	}{
+		"target_vars": {
+			target:   &types.TargetConfig{Name: "router1"},
+			location: "dc1",
+		},
+		"global_vars": {
+			target:   &types.TargetConfig{Name: "router2"},
+			location: "default",
+		},
+		"event_tags": {
+			target:   &types.TargetConfig{Name: "router3", EventTags: map[string]string{"uplink": "ethernet-1/2"}},
+			location: "default",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			reqs, err := c.CreateApplyRequests(tc.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(reqs) != 3 {
+				t.Fatalf("expected 3 requests, got %d", len(reqs))
+			}
+			if reqs[0].Name != "get-system" || reqs[0].Get == nil {
+				t.Errorf("unexpected first request: %+v", reqs[0])
+			}
+			getReq, err := c.CreateApplyGetRequest(reqs[0].Get)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(getReq.GetPath()) != 1 || getReq.GetType().String() != "CONFIG" {
+				t.Errorf("unexpected get request: %v", getReq)
+			}
+			setReq, err := c.CreateApplySetRequest(reqs[1].Set)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(setReq.GetUpdate()) != 2 {
+				t.Fatalf("unexpected set request: %v", setReq)
+			}
+			if v := string(setReq.GetUpdate()[0].GetVal().GetJsonIetfVal()); v != `"`+tc.target.Name+`"` {
+				t.Errorf("unexpected host-name value: %s", v)
+			}
+			if v := string(setReq.GetUpdate()[1].GetVal().GetJsonIetfVal()); v != `"`+tc.location+`"` {
+				t.Errorf("unexpected location value: %s", v)
+			}
+			subReq, err := c.CreateApplySubscribeRequest(reqs[2].Subscribe, tc.target.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if subReq.GetSubscribe().GetMode().String() != "ONCE" {
+				t.Errorf("unexpected subscription mode: %v", subReq.GetSubscribe().GetMode())
+			}
+			elems := subReq.GetSubscribe().GetSubscription()[0].GetPath().GetElem()
+			uplink := "ethernet-1/1"
+			if tc.target.EventTags != nil {
+				uplink = tc.target.EventTags["uplink"]
+			}
+			if elems[0].GetKey()["name"] != uplink {
+				t.Errorf("unexpected interface name: %v", elems[0].GetKey())
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+
+	"github.com/openconfig/gnmic/audit"
+)
+
+func (c *Config) GetAudit() error {
+	if !c.FileConfig.IsSet("audit") {
+		return nil
+	}
+	c.Audit = new(audit.Config)
+	c.Audit.File = os.ExpandEnv(c.FileConfig.GetString("audit/file"))
+	c.Audit.Outputs = c.FileConfig.GetStringSlice("audit/outputs")
+	c.Audit.HMACKey = os.ExpandEnv(c.FileConfig.GetString("audit/hmac-key"))
+	c.Audit.HMACKeyFile = os.ExpandEnv(c.FileConfig.GetString("audit/hmac-key-file"))
+	return c.Audit.Validate()
+}
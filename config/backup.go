@@ -0,0 +1,135 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/api"
+	"github.com/openconfig/gnmic/utils"
+)
+
+const (
+	defaultBackupInterval  = time.Hour
+	defaultBackupRetention = 10
+	defaultBackupDataType  = "CONFIG"
+	defaultBackupEncoding  = "json_ietf"
+	defaultBackupDirectory = "backups"
+)
+
+type backup struct {
+	// interval between two backups of all the targets
+	Interval time.Duration `mapstructure:"interval,omitempty" json:"interval,omitempty"`
+	// paths retrieved with the backup Get RPC
+	Paths []string `mapstructure:"paths,omitempty" json:"paths,omitempty"`
+	// Get RPC data type, defaults to CONFIG
+	DataType string `mapstructure:"data-type,omitempty" json:"data-type,omitempty"`
+	// Get RPC encoding, defaults to json_ietf
+	Encoding string `mapstructure:"encoding,omitempty" json:"encoding,omitempty"`
+	// number of snapshots kept per target
+	Retention int `mapstructure:"retention,omitempty" json:"retention,omitempty"`
+	// snapshots storage, file, s3 or git
+	Storage map[string]interface{} `mapstructure:"storage,omitempty" json:"storage,omitempty"`
+	// in a cluster, only the instance holding the backup task lock runs the backups
+	LeaderOnly bool `mapstructure:"leader-only,omitempty" json:"leader-only,omitempty"`
+	Debug      bool `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+}
+
+func (c *Config) GetBackup() error {
+	c.Backup = new(backup)
+	c.Backup.Interval = c.FileConfig.GetDuration("backup/interval")
+	c.Backup.Paths = c.FileConfig.GetStringSlice("backup/paths")
+	for i := range c.Backup.Paths {
+		c.Backup.Paths[i] = os.ExpandEnv(c.Backup.Paths[i])
+	}
+	c.Backup.DataType = os.ExpandEnv(c.FileConfig.GetString("backup/data-type"))
+	c.Backup.Encoding = os.ExpandEnv(c.FileConfig.GetString("backup/encoding"))
+	c.Backup.Retention = c.FileConfig.GetInt("backup/retention")
+	c.Backup.LeaderOnly = os.ExpandEnv(c.FileConfig.GetString("backup/leader-only")) == trueString
+	c.Backup.Debug = os.ExpandEnv(c.FileConfig.GetString("backup/debug")) == trueString
+	c.Backup.Storage = make(map[string]interface{})
+	switch storage := utils.Convert(c.FileConfig.Get("backup/storage")).(type) {
+	case map[string]interface{}:
+		for k, v := range storage {
+			c.Backup.Storage[k] = expandBackupStorageValue(v)
+		}
+	case nil:
+	default:
+		return fmt.Errorf("backup has an unexpected storage configuration type %T", storage)
+	}
+	// flags take precedence over the file configuration
+	if c.LocalFlags.BackupInterval > 0 {
+		c.Backup.Interval = c.LocalFlags.BackupInterval
+	}
+	if len(c.LocalFlags.BackupPath) > 0 {
+		c.Backup.Paths = c.LocalFlags.BackupPath
+	}
+	if c.LocalFlags.BackupDir != "" {
+		c.Backup.Storage = map[string]interface{}{
+			"type":      "file",
+			"directory": c.LocalFlags.BackupDir,
+		}
+	}
+	c.setBackupDefaults()
+	if c.Backup.Retention < 0 {
+		return fmt.Errorf("backup retention must be a positive number")
+	}
+	return nil
+}
+
+func (c *Config) setBackupDefaults() {
+	if c.Backup.Interval <= 0 {
+		c.Backup.Interval = defaultBackupInterval
+	}
+	if len(c.Backup.Paths) == 0 {
+		c.Backup.Paths = []string{"/"}
+	}
+	if c.Backup.DataType == "" {
+		c.Backup.DataType = defaultBackupDataType
+	}
+	c.Backup.DataType = strings.ToUpper(c.Backup.DataType)
+	if c.Backup.Encoding == "" {
+		c.Backup.Encoding = defaultBackupEncoding
+	}
+	if c.Backup.Retention == 0 {
+		c.Backup.Retention = defaultBackupRetention
+	}
+	if len(c.Backup.Storage) == 0 {
+		c.Backup.Storage = map[string]interface{}{
+			"type":      "file",
+			"directory": defaultBackupDirectory,
+		}
+	}
+}
+
+func expandBackupStorageValue(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		return os.ExpandEnv(s)
+	}
+	return v
+}
+
+func (c *Config) CreateBackupGetRequest() (*gnmi.GetRequest, error) {
+	if c == nil || c.Backup == nil {
+		return nil, fmt.Errorf("%w", ErrInvalidConfig)
+	}
+	gnmiOpts := make([]api.GNMIOption, 0, 2+len(c.Backup.Paths))
+	gnmiOpts = append(gnmiOpts,
+		api.Encoding(c.Backup.Encoding),
+		api.DataType(c.Backup.DataType),
+	)
+	for _, p := range c.Backup.Paths {
+		gnmiOpts = append(gnmiOpts, api.Path(strings.TrimSpace(p)))
+	}
+	return api.NewGetRequest(gnmiOpts...)
+}
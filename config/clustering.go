@@ -9,10 +9,14 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
 )
 
 const (
@@ -22,6 +26,13 @@ const (
 	defaultLeaderWaitTimer         = 5 * time.Second
 )
 
+const (
+	// targets that do not fit in the cluster are left unassigned
+	CapacityFallbackReject = "reject"
+	// targets that do not fit in the cluster are assigned to the least loaded instance
+	CapacityFallbackLeastLoaded = "least-loaded"
+)
+
 type clustering struct {
 	ClusterName             string                 `mapstructure:"cluster-name,omitempty" json:"cluster-name,omitempty" yaml:"cluster-name,omitempty"`
 	InstanceName            string                 `mapstructure:"instance-name,omitempty" json:"instance-name,omitempty" yaml:"instance-name,omitempty"`
@@ -31,6 +42,15 @@ type clustering struct {
 	TargetAssignmentTimeout time.Duration          `mapstructure:"target-assignment-timeout,omitempty" json:"target-assignment-timeout,omitempty" yaml:"target-assignment-timeout,omitempty"`
 	LeaderWaitTimer         time.Duration          `mapstructure:"leader-wait-timer,omitempty" json:"leader-wait-timer,omitempty" yaml:"leader-wait-timer,omitempty"`
 	Tags                    []string               `mapstructure:"tags,omitempty" json:"tags,omitempty" yaml:"tags,omitempty"`
+	Meta                    map[string]string      `mapstructure:"meta,omitempty" json:"meta,omitempty" yaml:"meta,omitempty"`
+	CheckInterval           time.Duration          `mapstructure:"check-interval,omitempty" json:"check-interval,omitempty" yaml:"check-interval,omitempty"`
+	MaxFail                 int                    `mapstructure:"max-fail,omitempty" json:"max-fail,omitempty" yaml:"max-fail,omitempty"`
+	Zone                    string                 `mapstructure:"zone,omitempty" json:"zone,omitempty" yaml:"zone,omitempty"`
+	Affinity                []*AffinityRule        `mapstructure:"affinity,omitempty" json:"affinity,omitempty" yaml:"affinity,omitempty"`
+	StickyTargets           bool                   `mapstructure:"sticky-targets,omitempty" json:"sticky-targets,omitempty" yaml:"sticky-targets,omitempty"`
+	MaxTargets              int                    `mapstructure:"max-targets,omitempty" json:"max-targets,omitempty" yaml:"max-targets,omitempty"`
+	MaxMsgRate              float64                `mapstructure:"max-msg-rate,omitempty" json:"max-msg-rate,omitempty" yaml:"max-msg-rate,omitempty"`
+	CapacityFallback        string                 `mapstructure:"capacity-fallback,omitempty" json:"capacity-fallback,omitempty" yaml:"capacity-fallback,omitempty"`
 	Locker                  map[string]interface{} `mapstructure:"locker,omitempty" json:"locker,omitempty" yaml:"locker,omitempty"`
 }
 
@@ -50,10 +70,105 @@ func (c *Config) GetClustering() error {
 	for i := range c.Clustering.Tags {
 		c.Clustering.Tags[i] = os.ExpandEnv(c.Clustering.Tags[i])
 	}
+	c.Clustering.Meta = c.FileConfig.GetStringMapString("clustering/meta")
+	for k, v := range c.Clustering.Meta {
+		c.Clustering.Meta[k] = os.ExpandEnv(v)
+	}
+	c.Clustering.CheckInterval = c.FileConfig.GetDuration("clustering/check-interval")
+	c.Clustering.MaxFail = c.FileConfig.GetInt("clustering/max-fail")
+	c.Clustering.Zone = os.ExpandEnv(c.FileConfig.GetString("clustering/zone"))
+	c.Clustering.StickyTargets = os.ExpandEnv(c.FileConfig.GetString("clustering/sticky-targets")) == trueString
+	c.Clustering.MaxTargets = c.FileConfig.GetInt("clustering/max-targets")
+	c.Clustering.MaxMsgRate = c.FileConfig.GetFloat64("clustering/max-msg-rate")
+	c.Clustering.CapacityFallback = os.ExpandEnv(c.FileConfig.GetString("clustering/capacity-fallback"))
+	switch c.Clustering.CapacityFallback {
+	case "":
+		c.Clustering.CapacityFallback = CapacityFallbackReject
+	case CapacityFallbackReject, CapacityFallbackLeastLoaded:
+	default:
+		return fmt.Errorf("unknown clustering capacity-fallback %q", c.Clustering.CapacityFallback)
+	}
+	if c.Clustering.MaxTargets < 0 || c.Clustering.MaxMsgRate < 0 {
+		return fmt.Errorf("clustering max-targets and max-msg-rate must be positive")
+	}
+	err := c.getAffinityRules()
+	if err != nil {
+		return err
+	}
 	c.setClusteringDefaults()
 	return c.getLocker()
 }
 
+// AffinityRule restricts the cluster instances its targets can be assigned to.
+// The rule targets are listed by name, selected by their variables or by target group.
+type AffinityRule struct {
+	types.TargetGroup `mapstructure:",squash" yaml:",inline"`
+	// names of the target groups the rule applies to
+	Groups []string `mapstructure:"groups,omitempty" json:"groups,omitempty" yaml:"groups,omitempty"`
+	// names of the instances the targets are assigned to
+	Instances []string `mapstructure:"instances,omitempty" json:"instances,omitempty" yaml:"instances,omitempty"`
+	// zones of the instances the targets are assigned to
+	Zones []string `mapstructure:"zones,omitempty" json:"zones,omitempty" yaml:"zones,omitempty"`
+	// if true, the targets are assigned to any instance but the listed ones
+	AntiAffinity bool `mapstructure:"anti-affinity,omitempty" json:"anti-affinity,omitempty" yaml:"anti-affinity,omitempty"`
+}
+
+// Applies returns true if the target tc is one of the rule targets.
+func (r *AffinityRule) Applies(tc *types.TargetConfig, groups map[string]*types.TargetGroup) bool {
+	if r.Contains(tc) {
+		return true
+	}
+	for _, gn := range r.Groups {
+		if g, ok := groups[gn]; ok && g.Contains(tc) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows returns true if the rule targets can be assigned to the instance,
+// zone is empty if the instance has no zone.
+func (r *AffinityRule) Allows(instance, zone string) bool {
+	match := false
+	for _, n := range r.Instances {
+		if n == instance {
+			match = true
+			break
+		}
+	}
+	if !match && zone != "" {
+		for _, z := range r.Zones {
+			if z == zone {
+				match = true
+				break
+			}
+		}
+	}
+	return match != r.AntiAffinity
+}
+
+func (c *Config) getAffinityRules() error {
+	rules, ok := utils.Convert(c.FileConfig.Get("clustering/affinity")).([]interface{})
+	if !ok {
+		return nil
+	}
+	for i, r := range rules {
+		rule := new(AffinityRule)
+		err := mapstructure.Decode(r, rule)
+		if err != nil {
+			return fmt.Errorf("clustering affinity rule %d: %v", i, err)
+		}
+		if len(rule.Targets) == 0 && len(rule.Selector) == 0 && len(rule.Groups) == 0 {
+			return fmt.Errorf("clustering affinity rule %d: no targets, selector nor groups defined", i)
+		}
+		if len(rule.Instances) == 0 && len(rule.Zones) == 0 {
+			return fmt.Errorf("clustering affinity rule %d: no instances nor zones defined", i)
+		}
+		c.Clustering.Affinity = append(c.Clustering.Affinity, rule)
+	}
+	return nil
+}
+
 func (c *Config) setClusteringDefaults() {
 	// set $clustering.cluster-name to $cluster-name if it's empty string
 	if c.Clustering.ClusterName == "" {
@@ -86,4 +201,10 @@ func (c *Config) setClusteringDefaults() {
 	if c.Clustering.LeaderWaitTimer <= defaultLeaderWaitTimer {
 		c.Clustering.LeaderWaitTimer = defaultLeaderWaitTimer
 	}
+	if c.Clustering.CheckInterval <= 0 {
+		c.Clustering.CheckInterval = defaultRegistrationCheckInterval
+	}
+	if c.Clustering.MaxFail <= 0 {
+		c.Clustering.MaxFail = defaultMaxServiceFail
+	}
 }
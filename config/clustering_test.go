@@ -0,0 +1,183 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+func TestClusteringAffinity(t *testing.T) {
+	in := []byte(`
+clustering:
+  cluster-name: c1
+  instance-name: gnmic1
+  zone: paris
+  sticky-targets: true
+  locker:
+    type: consul
+  affinity:
+    - targets: [router1]
+      instances: [gnmic1]
+    - selector:
+        site: lyon
+      zones: [lyon]
+    - groups: [edge]
+      instances: [gnmic3]
+      anti-affinity: true
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	err = cfg.GetClustering()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Clustering.Zone != "paris" || !cfg.Clustering.StickyTargets {
+		t.Errorf("unexpected zone %q and sticky targets %v", cfg.Clustering.Zone, cfg.Clustering.StickyTargets)
+	}
+	rules := cfg.Clustering.Affinity
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 affinity rules, got %d", len(rules))
+	}
+	groups := map[string]*types.TargetGroup{"edge": {Name: "edge", Targets: []string{"router3"}}}
+	router1 := &types.TargetConfig{Name: "router1"}
+	router2 := &types.TargetConfig{Name: "router2", Vars: map[string]string{"site": "lyon"}}
+	router3 := &types.TargetConfig{Name: "router3"}
+
+	if !rules[0].Applies(router1, groups) || rules[0].Applies(router2, groups) {
+		t.Errorf("rule 0 expected to only apply to router1")
+	}
+	if !rules[0].Allows("gnmic1", "") || rules[0].Allows("gnmic2", "") {
+		t.Errorf("rule 0 expected to only allow gnmic1")
+	}
+	if !rules[1].Applies(router2, groups) || rules[1].Applies(router1, groups) {
+		t.Errorf("rule 1 expected to only apply to router2")
+	}
+	if !rules[1].Allows("gnmic2", "lyon") || rules[1].Allows("gnmic1", "paris") || rules[1].Allows("gnmic3", "") {
+		t.Errorf("rule 1 expected to only allow the instances in zone lyon")
+	}
+	if !rules[2].Applies(router3, groups) || rules[2].Applies(router1, groups) {
+		t.Errorf("rule 2 expected to only apply to router3")
+	}
+	if rules[2].Allows("gnmic3", "") || !rules[2].Allows("gnmic1", "") {
+		t.Errorf("rule 2 expected to allow all instances but gnmic3")
+	}
+}
+
+func TestInvalidClusteringAffinity(t *testing.T) {
+	tests := map[string]string{
+		"no_targets": `
+clustering:
+  locker:
+    type: consul
+  affinity:
+    - instances: [gnmic1]
+`,
+		"no_instances": `
+clustering:
+  locker:
+    type: consul
+  affinity:
+    - targets: [router1]
+`,
+	}
+	for name, in := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := New()
+			cfg.FileConfig.SetConfigType("yaml")
+			err := cfg.FileConfig.ReadConfig(bytes.NewBufferString(in))
+			if err != nil {
+				t.Fatalf("failed reading config: %v", err)
+			}
+			err = cfg.GetClustering()
+			if err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestClusteringCapacity(t *testing.T) {
+	tests := map[string]struct {
+		in       string
+		fallback string
+		err      bool
+	}{
+		"default_fallback": {
+			in: `
+clustering:
+  max-targets: 100
+  max-msg-rate: 2500.5
+  locker:
+    type: consul
+`,
+			fallback: CapacityFallbackReject,
+		},
+		"least_loaded": {
+			in: `
+clustering:
+  max-targets: 100
+  capacity-fallback: least-loaded
+  locker:
+    type: consul
+`,
+			fallback: CapacityFallbackLeastLoaded,
+		},
+		"unknown_fallback": {
+			in: `
+clustering:
+  capacity-fallback: random
+  locker:
+    type: consul
+`,
+			err: true,
+		},
+		"negative_max_targets": {
+			in: `
+clustering:
+  max-targets: -1
+  locker:
+    type: consul
+`,
+			err: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := New()
+			cfg.FileConfig.SetConfigType("yaml")
+			err := cfg.FileConfig.ReadConfig(bytes.NewBufferString(tt.in))
+			if err != nil {
+				t.Fatalf("failed reading config: %v", err)
+			}
+			err = cfg.GetClustering()
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Clustering.CapacityFallback != tt.fallback {
+				t.Errorf("expected capacity fallback %q, got %q", tt.fallback, cfg.Clustering.CapacityFallback)
+			}
+			if cfg.Clustering.MaxTargets != 100 {
+				t.Errorf("expected max-targets 100, got %d", cfg.Clustering.MaxTargets)
+			}
+		})
+	}
+}
@@ -27,6 +27,12 @@ import (
 	"github.com/mitchellh/go-homedir"
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmic/api"
+	"github.com/openconfig/gnmic/audit"
+	"github.com/openconfig/gnmic/logging"
+	"github.com/openconfig/gnmic/membudget"
+	"github.com/openconfig/gnmic/netconf"
+	"github.com/openconfig/gnmic/spiffe"
+	"github.com/openconfig/gnmic/tracing"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
 	"github.com/spf13/cobra"
@@ -62,10 +68,24 @@ type Config struct {
 	Loader        map[string]interface{}               `mapstructure:"loader,omitempty" json:"loader,omitempty" yaml:"loader,omitempty"`
 	Actions       map[string]map[string]interface{}    `mapstructure:"actions,omitempty" json:"actions,omitempty" yaml:"actions,omitempty"`
 	TunnelServer  *tunnelServer                        `mapstructure:"tunnel-server,omitempty" json:"tunnel-server,omitempty" yaml:"tunnel-server,omitempty"`
+	Backup        *backup                              `mapstructure:"backup,omitempty" json:"backup,omitempty" yaml:"backup,omitempty"`
+	Tracing       *tracing.Config                      `mapstructure:"tracing,omitempty" json:"tracing,omitempty" yaml:"tracing,omitempty"`
+	MemoryBudget  *membudget.Config                    `mapstructure:"memory-budget,omitempty" json:"memory-budget,omitempty" yaml:"memory-budget,omitempty"`
+	Spiffe        *spiffe.Config                       `mapstructure:"spiffe,omitempty" json:"spiffe,omitempty" yaml:"spiffe,omitempty"`
+	Audit         *audit.Config                        `mapstructure:"audit,omitempty" json:"audit,omitempty" yaml:"audit,omitempty"`
+
+	SubscriptionProfiles map[string]*types.SubscriptionProfile `mapstructure:"subscription-profiles,omitempty" json:"subscription-profiles,omitempty" yaml:"subscription-profiles,omitempty"`
+	ConnectionProfiles   map[string]*types.TargetConfig        `mapstructure:"connection-profiles,omitempty" json:"connection-profiles,omitempty" yaml:"connection-profiles,omitempty"`
+	CredentialsProviders map[string]map[string]interface{}     `mapstructure:"credentials-providers,omitempty" json:"credentials-providers,omitempty" yaml:"credentials-providers,omitempty"`
+	TargetGroups         map[string]*types.TargetGroup         `mapstructure:"target-groups,omitempty" json:"target-groups,omitempty" yaml:"target-groups,omitempty"`
+	NetconfPollers       map[string]*netconf.PollerConfig      `mapstructure:"netconf-pollers,omitempty" json:"netconf-pollers,omitempty" yaml:"netconf-pollers,omitempty"`
 	//
 	logger             *log.Logger
+	redactor           *utils.Redactor
 	setRequestTemplate []*template.Template
 	setRequestVars     map[string]interface{}
+	applyTemplates     []*template.Template
+	applyVars          map[string]interface{}
 }
 
 var ValueTypes = []string{"json", "json_ietf", "string", "int", "uint", "bool", "decimal", "float", "bytes", "ascii"}
@@ -90,12 +110,15 @@ type GlobalFlags struct {
 	SkipVerify    bool          `mapstructure:"skip-verify,omitempty" json:"skip-verify,omitempty" yaml:"skip-verify,omitempty"`
 	NoPrefix      bool          `mapstructure:"no-prefix,omitempty" json:"no-prefix,omitempty" yaml:"no-prefix,omitempty"`
 	ProxyFromEnv  bool          `mapstructure:"proxy-from-env,omitempty" json:"proxy-from-env,omitempty" yaml:"proxy-from-env,omitempty"`
+	Proxy         string        `mapstructure:"proxy,omitempty" json:"proxy,omitempty" yaml:"proxy,omitempty"`
 	Format        string        `mapstructure:"format,omitempty" json:"format,omitempty" yaml:"format,omitempty"`
 	LogFile       string        `mapstructure:"log-file,omitempty" json:"log-file,omitempty" yaml:"log-file,omitempty"`
 	Log           bool          `mapstructure:"log,omitempty" json:"log,omitempty" yaml:"log,omitempty"`
 	LogMaxSize    int           `mapstructure:"log-max-size,omitempty" json:"log-max-size,omitempty" yaml:"log-max-size,omitempty"`
 	LogMaxBackups int           `mapstructure:"log-max-backups,omitempty" json:"log-max-backups,omitempty" yaml:"log-max-backups,omitempty"`
 	LogCompress   bool          `mapstructure:"log-compress,omitempty" json:"log-compress,omitempty" yaml:"log-compress,omitempty"`
+	LogFormat     string        `mapstructure:"log-format,omitempty" json:"log-format,omitempty" yaml:"log-format,omitempty"`
+	LogLevel      string        `mapstructure:"log-level,omitempty" json:"log-level,omitempty" yaml:"log-level,omitempty"`
 	MaxMsgSize    int           `mapstructure:"max-msg-size,omitempty" json:"max-msg-size,omitempty" yaml:"max-msg-size,omitempty"`
 	//PrometheusAddress string        `mapstructure:"prometheus-address,omitempty" json:"prometheus-address,omitempty" yaml:"prometheus-address,omitempty"`
 	PrintRequest     bool          `mapstructure:"print-request,omitempty" json:"print-request,omitempty" yaml:"print-request,omitempty"`
@@ -113,6 +136,23 @@ type GlobalFlags struct {
 	Exclude          []string      `mapstructure:"exclude,omitempty" json:"exclude,omitempty" yaml:"exclude,omitempty"`
 	Token            string        `mapstructure:"token,omitempty" json:"token,omitempty" yaml:"token,omitempty"`
 	UseTunnelServer  bool          `mapstructure:"use-tunnel-server,omitempty" json:"use-tunnel-server,omitempty" yaml:"use-tunnel-server,omitempty"`
+	StrictConfig     bool          `mapstructure:"strict-config,omitempty" json:"strict-config,omitempty" yaml:"strict-config,omitempty"`
+	Vendor           string        `mapstructure:"vendor,omitempty" json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	Group            []string      `mapstructure:"group,omitempty" json:"group,omitempty" yaml:"group,omitempty"`
+	Summary          string        `mapstructure:"summary,omitempty" json:"summary,omitempty" yaml:"summary,omitempty"`
+	FailFast         bool          `mapstructure:"fail-fast,omitempty" json:"fail-fast,omitempty" yaml:"fail-fast,omitempty"`
+	AllowPartial     bool          `mapstructure:"allow-partial,omitempty" json:"allow-partial,omitempty" yaml:"allow-partial,omitempty"`
+	RPCRetries       int           `mapstructure:"rpc-retries,omitempty" json:"rpc-retries,omitempty" yaml:"rpc-retries,omitempty"`
+	RPCRetryBackoff  time.Duration `mapstructure:"rpc-retry-backoff,omitempty" json:"rpc-retry-backoff,omitempty" yaml:"rpc-retry-backoff,omitempty"`
+	RPCRate          float64       `mapstructure:"rpc-rate,omitempty" json:"rpc-rate,omitempty" yaml:"rpc-rate,omitempty"`
+	RPCBurst         int           `mapstructure:"rpc-burst,omitempty" json:"rpc-burst,omitempty" yaml:"rpc-burst,omitempty"`
+
+	// additional regular expressions matching the secrets redacted from the logs,
+	// the printed requests and the API responses
+	RedactPatterns []string `mapstructure:"redact-patterns,omitempty" json:"redact-patterns,omitempty" yaml:"redact-patterns,omitempty"`
+
+	// per module log level overrides
+	LogLevels map[string]string `mapstructure:"log-levels,omitempty" json:"log-levels,omitempty" yaml:"log-levels,omitempty"`
 }
 
 type LocalFlags struct {
@@ -126,6 +166,9 @@ type LocalFlags struct {
 	GetTarget     string   `mapstructure:"get-target,omitempty" json:"get-target,omitempty" yaml:"get-target,omitempty"`
 	GetValuesOnly bool     `mapstructure:"get-values-only,omitempty" json:"get-values-only,omitempty" yaml:"get-values-only,omitempty"`
 	GetProcessor  []string `mapstructure:"get-processor,omitempty" json:"get-processor,omitempty" yaml:"get-processor,omitempty"`
+	GetChunkPaths bool     `mapstructure:"get-chunk-paths,omitempty" json:"get-chunk-paths,omitempty" yaml:"get-chunk-paths,omitempty"`
+	GetChunkSize  int      `mapstructure:"get-chunk-size,omitempty" json:"get-chunk-size,omitempty" yaml:"get-chunk-size,omitempty"`
+	GetDepth      uint32   `mapstructure:"get-depth,omitempty" json:"get-depth,omitempty" yaml:"get-depth,omitempty"`
 	// Set
 	SetPrefix       string   `mapstructure:"set-prefix,omitempty" json:"set-prefix,omitempty" yaml:"set-prefix,omitempty"`
 	SetDelete       []string `mapstructure:"set-delete,omitempty" json:"set-delete,omitempty" yaml:"set-delete,omitempty"`
@@ -142,6 +185,7 @@ type LocalFlags struct {
 	SetRequestFile  []string `mapstructure:"set-request-file,omitempty" json:"set-request-file,omitempty" yaml:"set-request-file,omitempty"`
 	SetRequestVars  string   `mapstructure:"set-request-vars,omitempty" json:"set-request-vars,omitempty" yaml:"set-request-vars,omitempty"`
 	SetDryRun       bool     `mapstructure:"set-dry-run,omitempty" json:"set-dry-run,omitempty" yaml:"set-dry-run,omitempty"`
+	SetNoValidate   bool     `mapstructure:"set-no-validate,omitempty" json:"set-no-validate,omitempty" yaml:"set-no-validate,omitempty"`
 	// Sub
 	SubscribePrefix            string        `mapstructure:"subscribe-prefix,omitempty" json:"subscribe-prefix,omitempty" yaml:"subscribe-prefix,omitempty"`
 	SubscribePath              []string      `mapstructure:"subscribe-path,omitempty" json:"subscribe-path,omitempty" yaml:"subscribe-path,omitempty"`
@@ -164,6 +208,11 @@ type LocalFlags struct {
 	SubscribeHistorySnapshot   string        `mapstructure:"subscribe-history-snapshot,omitempty" json:"subscribe-history-snapshot,omitempty" yaml:"subscribe-history-snapshot,omitempty"`
 	SubscribeHistoryStart      string        `mapstructure:"subscribe-history-start,omitempty" json:"subscribe-history-start,omitempty" yaml:"subscribe-history-start,omitempty"`
 	SubscribeHistoryEnd        string        `mapstructure:"subscribe-history-end,omitempty" json:"subscribe-history-end,omitempty" yaml:"subscribe-history-end,omitempty"`
+	SubscribeFilter            []string      `mapstructure:"subscribe-filter,omitempty" json:"subscribe-filter,omitempty" yaml:"subscribe-filter,omitempty"`
+	SubscribeDuration          time.Duration `mapstructure:"subscribe-duration,omitempty" json:"subscribe-duration,omitempty" yaml:"subscribe-duration,omitempty"`
+	SubscribeMaxMessages       int           `mapstructure:"subscribe-max-messages,omitempty" json:"subscribe-max-messages,omitempty" yaml:"subscribe-max-messages,omitempty"`
+	SubscribeDepth             uint32        `mapstructure:"subscribe-depth,omitempty" json:"subscribe-depth,omitempty" yaml:"subscribe-depth,omitempty"`
+	SubscribeTUI               bool          `mapstructure:"subscribe-tui,omitempty" json:"subscribe-tui,omitempty" yaml:"subscribe-tui,omitempty"`
 	// Path
 	PathPathType   string `mapstructure:"path-path-type,omitempty" json:"path-path-type,omitempty" yaml:"path-path-type,omitempty"`
 	PathWithDescr  bool   `mapstructure:"path-descr,omitempty" json:"path-descr,omitempty" yaml:"path-descr,omitempty"`
@@ -172,6 +221,8 @@ type LocalFlags struct {
 	PathSearch     bool   `mapstructure:"path-search,omitempty" json:"path-search,omitempty" yaml:"path-search,omitempty"`
 	PathState      bool   `mapstructure:"path-state,omitempty" json:"path-state,omitempty" yaml:"path-state,omitempty"`
 	PathConfig     bool   `mapstructure:"path-config,omitempty" json:"path-config,omitempty" yaml:"path-config,omitempty"`
+	PathLookup     string `mapstructure:"path-lookup,omitempty" json:"path-lookup,omitempty" yaml:"path-lookup,omitempty"`
+	PathServe      string `mapstructure:"path-serve,omitempty" json:"path-serve,omitempty" yaml:"path-serve,omitempty"`
 	// Prompt
 	PromptFile                  []string `mapstructure:"prompt-file,omitempty" json:"prompt-file,omitempty" yaml:"prompt-file,omitempty"`
 	PromptExclude               []string `mapstructure:"prompt-exclude,omitempty" json:"prompt-exclude,omitempty" yaml:"prompt-exclude,omitempty"`
@@ -184,9 +235,15 @@ type LocalFlags struct {
 	PromptDescriptionWithPrefix bool     `mapstructure:"prompt-description-with-prefix,omitempty" json:"prompt-description-with-prefix,omitempty" yaml:"prompt-description-with-prefix,omitempty"`
 	PromptDescriptionWithTypes  bool     `mapstructure:"prompt-description-with-types,omitempty" json:"prompt-description-with-types,omitempty" yaml:"prompt-description-with-types,omitempty"`
 	PromptSuggestWithOrigin     bool     `mapstructure:"prompt-suggest-with-origin,omitempty" json:"prompt-suggest-with-origin,omitempty" yaml:"prompt-suggest-with-origin,omitempty"`
+	PromptSchemaTarget          string   `mapstructure:"prompt-schema-target,omitempty" json:"prompt-schema-target,omitempty" yaml:"prompt-schema-target,omitempty"`
+	PromptSchemaRepo            string   `mapstructure:"prompt-schema-repo,omitempty" json:"prompt-schema-repo,omitempty" yaml:"prompt-schema-repo,omitempty"`
+	PromptSchemaGNOIDir         string   `mapstructure:"prompt-schema-gnoi-dir,omitempty" json:"prompt-schema-gnoi-dir,omitempty" yaml:"prompt-schema-gnoi-dir,omitempty"`
+	PromptSchemaCacheDir        string   `mapstructure:"prompt-schema-cache-dir,omitempty" json:"prompt-schema-cache-dir,omitempty" yaml:"prompt-schema-cache-dir,omitempty"`
 	// Listen
-	ListenMaxConcurrentStreams uint32 `mapstructure:"listen-max-concurrent-streams,omitempty" json:"listen-max-concurrent-streams,omitempty" yaml:"listen-max-concurrent-streams,omitempty"`
-	ListenPrometheusAddress    string `mapstructure:"listen-prometheus-address,omitempty" json:"listen-prometheus-address,omitempty" yaml:"listen-prometheus-address,omitempty"`
+	ListenMaxConcurrentStreams uint32   `mapstructure:"listen-max-concurrent-streams,omitempty" json:"listen-max-concurrent-streams,omitempty" yaml:"listen-max-concurrent-streams,omitempty"`
+	ListenPrometheusAddress    string   `mapstructure:"listen-prometheus-address,omitempty" json:"listen-prometheus-address,omitempty" yaml:"listen-prometheus-address,omitempty"`
+	ListenOutput               []string `mapstructure:"listen-output,omitempty" json:"listen-output,omitempty" yaml:"listen-output,omitempty"`
+	ListenTargetTemplate       string   `mapstructure:"listen-target-template,omitempty" json:"listen-target-template,omitempty" yaml:"listen-target-template,omitempty"`
 	// VersionUpgrade
 	UpgradeUsePkg bool `mapstructure:"upgrade-use-pkg" json:"upgrade-use-pkg,omitempty" yaml:"upgrade-use-pkg,omitempty"`
 	// GetSet
@@ -201,12 +258,14 @@ type LocalFlags struct {
 	GetSetDelete    string `mapstructure:"getset-delete,omitempty" json:"getset-delete,omitempty" yaml:"getset-delete,omitempty"`
 	GetSetValue     string `mapstructure:"getset-value,omitempty" json:"getset-value,omitempty" yaml:"getset-value,omitempty"`
 	// Generate
-	GenerateOutput     string `mapstructure:"generate-output,omitempty" json:"generate-output,omitempty" yaml:"generate-output,omitempty"`
-	GenerateJSON       bool   `mapstructure:"generate-json,omitempty" json:"generate-json,omitempty" yaml:"generate-json,omitempty"`
-	GenerateConfigOnly bool   `mapstructure:"generate-config-only,omitempty" json:"generate-config-only,omitempty" yaml:"generate-config-only,omitempty"`
-	GeneratePath       string `mapstructure:"generate-path,omitempty" json:"generate-path,omitempty" yaml:"generate-path,omitempty"`
-	GenerateCamelCase  bool   `mapstructure:"generate-camel-case,omitempty" json:"generate-camel-case" yaml:"generate-camel-case,omitempty"`
-	GenerateSnakeCase  bool   `mapstructure:"generate-snake-case,omitempty" json:"generate-snake-case" yaml:"generate-snake-case,omitempty"`
+	GenerateOutput          string `mapstructure:"generate-output,omitempty" json:"generate-output,omitempty" yaml:"generate-output,omitempty"`
+	GenerateJSON            bool   `mapstructure:"generate-json,omitempty" json:"generate-json,omitempty" yaml:"generate-json,omitempty"`
+	GenerateConfigOnly      bool   `mapstructure:"generate-config-only,omitempty" json:"generate-config-only,omitempty" yaml:"generate-config-only,omitempty"`
+	GeneratePath            string `mapstructure:"generate-path,omitempty" json:"generate-path,omitempty" yaml:"generate-path,omitempty"`
+	GenerateCamelCase       bool   `mapstructure:"generate-camel-case,omitempty" json:"generate-camel-case" yaml:"generate-camel-case,omitempty"`
+	GenerateSnakeCase       bool   `mapstructure:"generate-snake-case,omitempty" json:"generate-snake-case" yaml:"generate-snake-case,omitempty"`
+	GenerateSkipWhen        bool   `mapstructure:"generate-skip-when,omitempty" json:"generate-skip-when,omitempty" yaml:"generate-skip-when,omitempty"`
+	GenerateKeyPlaceholders bool   `mapstructure:"generate-key-placeholders,omitempty" json:"generate-key-placeholders,omitempty" yaml:"generate-key-placeholders,omitempty"`
 	// Generate Set Request
 	GenerateSetRequestUpdatePath  []string `mapstructure:"generate-update-path,omitempty" json:"generate-update-path,omitempty" yaml:"generate-update-path,omitempty"`
 	GenerateSetRequestReplacePath []string `mapstructure:"generate-replace-path,omitempty" json:"generate-replace-path,omitempty" yaml:"generate-replace-path,omitempty"`
@@ -229,6 +288,40 @@ type LocalFlags struct {
 	DiffRef     string   `mapstructure:"diff-ref,omitempty" json:"diff-ref,omitempty" yaml:"diff-ref,omitempty"`
 	DiffCompare []string `mapstructure:"diff-compare,omitempty" json:"diff-compare,omitempty" yaml:"diff-compare,omitempty"`
 	DiffQos     uint32   `mapstructure:"diff-qos,omitempty" json:"diff-qos,omitempty" yaml:"diff-qos,omitempty"`
+	// reference snapshot file
+	DiffRefFile string `mapstructure:"diff-ref-file,omitempty" json:"diff-ref-file,omitempty" yaml:"diff-ref-file,omitempty"`
+	// converging SetRequest output file
+	DiffSetRequestFile string `mapstructure:"diff-set-request-file,omitempty" json:"diff-set-request-file,omitempty" yaml:"diff-set-request-file,omitempty"`
+	// Processor
+	ProcessorName        []string `mapstructure:"processor-name,omitempty" json:"processor-name,omitempty" yaml:"processor-name,omitempty"`
+	ProcessorInput       string   `mapstructure:"processor-input,omitempty" json:"processor-input,omitempty" yaml:"processor-input,omitempty"`
+	ProcessorInputFormat string   `mapstructure:"processor-input-format,omitempty" json:"processor-input-format,omitempty" yaml:"processor-input-format,omitempty"`
+	// Replay
+	ReplayInput       string   `mapstructure:"replay-input,omitempty" json:"replay-input,omitempty" yaml:"replay-input,omitempty"`
+	ReplayInputFormat string   `mapstructure:"replay-input-format,omitempty" json:"replay-input-format,omitempty" yaml:"replay-input-format,omitempty"`
+	ReplaySpeed       float64  `mapstructure:"replay-speed,omitempty" json:"replay-speed,omitempty" yaml:"replay-speed,omitempty"`
+	ReplayOutput      []string `mapstructure:"replay-output,omitempty" json:"replay-output,omitempty" yaml:"replay-output,omitempty"`
+	ReplaySource      string   `mapstructure:"replay-source,omitempty" json:"replay-source,omitempty" yaml:"replay-source,omitempty"`
+	ReplayLoop        bool     `mapstructure:"replay-loop,omitempty" json:"replay-loop,omitempty" yaml:"replay-loop,omitempty"`
+	// Backup
+	BackupInterval time.Duration `mapstructure:"backup-interval,omitempty" json:"backup-interval,omitempty" yaml:"backup-interval,omitempty"`
+	BackupPath     []string      `mapstructure:"backup-path,omitempty" json:"backup-path,omitempty" yaml:"backup-path,omitempty"`
+	BackupDir      string        `mapstructure:"backup-dir,omitempty" json:"backup-dir,omitempty" yaml:"backup-dir,omitempty"`
+	BackupOnce     bool          `mapstructure:"backup-once,omitempty" json:"backup-once,omitempty" yaml:"backup-once,omitempty"`
+	// Watch
+	WatchPrefix         string        `mapstructure:"watch-prefix,omitempty" json:"watch-prefix,omitempty" yaml:"watch-prefix,omitempty"`
+	WatchPath           []string      `mapstructure:"watch-path,omitempty" json:"watch-path,omitempty" yaml:"watch-path,omitempty"`
+	WatchStreamMode     string        `mapstructure:"watch-stream-mode,omitempty" json:"watch-stream-mode,omitempty" yaml:"watch-stream-mode,omitempty"`
+	WatchSampleInterval time.Duration `mapstructure:"watch-sample-interval,omitempty" json:"watch-sample-interval,omitempty" yaml:"watch-sample-interval,omitempty"`
+	WatchCondition      string        `mapstructure:"watch-condition,omitempty" json:"watch-condition,omitempty" yaml:"watch-condition,omitempty"`
+	WatchExpression     string        `mapstructure:"watch-expression,omitempty" json:"watch-expression,omitempty" yaml:"watch-expression,omitempty"`
+	WatchWaitTimeout    time.Duration `mapstructure:"watch-wait-timeout,omitempty" json:"watch-wait-timeout,omitempty" yaml:"watch-wait-timeout,omitempty"`
+	// Apply
+	ApplyFile   []string `mapstructure:"apply-file,omitempty" json:"apply-file,omitempty" yaml:"apply-file,omitempty"`
+	ApplyVars   string   `mapstructure:"apply-vars,omitempty" json:"apply-vars,omitempty" yaml:"apply-vars,omitempty"`
+	ApplyDryRun bool     `mapstructure:"apply-dry-run,omitempty" json:"apply-dry-run,omitempty" yaml:"apply-dry-run,omitempty"`
+	// Validate
+	ValidateSchema bool `mapstructure:"validate-schema,omitempty" json:"validate-schema,omitempty" yaml:"validate-schema,omitempty"`
 	//
 	TunnelServerSubscribe bool
 }
@@ -249,8 +342,21 @@ func New() *Config {
 		nil,
 		nil,
 		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		make(map[string]*types.SubscriptionProfile),
+		make(map[string]*types.TargetConfig),
+		make(map[string]map[string]interface{}),
+		make(map[string]*types.TargetGroup),
+		make(map[string]*netconf.PollerConfig),
 		log.New(io.Discard, configLogPrefix, utils.DefaultLoggingFlags),
 		nil,
+		nil,
+		make(map[string]interface{}),
+		nil,
 		make(map[string]interface{}),
 	}
 }
@@ -270,6 +376,10 @@ func (c *Config) Load(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		err = c.interpolate(ctx, configBytes)
+		if err != nil {
+			return err
+		}
 	} else {
 		// discover gnmic config file
 		home, err := homedir.Dir()
@@ -287,6 +397,16 @@ func (c *Config) Load(ctx context.Context) error {
 				return err
 			}
 		}
+		if c.FileConfig.ConfigFileUsed() != "" {
+			configBytes, err := os.ReadFile(c.FileConfig.ConfigFileUsed())
+			if err != nil {
+				return err
+			}
+			err = c.interpolate(ctx, configBytes)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	err := c.FileConfig.Unmarshal(c)
@@ -328,11 +448,64 @@ func (c *Config) SetLogger() (io.Writer, int, error) {
 	if c.Debug {
 		loggingFlags |= log.Llongfile
 	}
+	c.redactor, err = utils.NewRedactor(c.RedactPatterns...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if f != io.Discard {
+		f = c.redactor.Writer(f)
+	}
+	if f != io.Discard && (c.LogFormat != "" || c.LogLevel != "" || len(c.FileConfig.GetStringMapString("log-levels")) > 0) {
+		h, err := c.logHandler(f)
+		if err != nil {
+			return nil, 0, err
+		}
+		f = h
+		// the handler adds the time to the records
+		loggingFlags &^= log.LstdFlags | log.Lmicroseconds | log.LUTC
+	}
 	c.logger.SetOutput(f)
 	c.logger.SetFlags(loggingFlags)
 	return f, loggingFlags, nil
 }
 
+// Redactor returns the secrets redactor built from the redact-patterns,
+// it uses the default patterns until SetLogger is called.
+func (c *Config) Redactor() *utils.Redactor {
+	return c.redactor
+}
+
+// logHandler returns a structured logging handler writing to w,
+// based on the log-format, log-level and log-levels options.
+func (c *Config) logHandler(w io.Writer) (*logging.Handler, error) {
+	format, err := logging.ParseFormat(c.LogFormat)
+	if err != nil {
+		return nil, err
+	}
+	level := logging.LevelInfo
+	if c.Debug {
+		level = logging.LevelDebug
+	}
+	if c.LogLevel != "" {
+		level, err = logging.ParseLevel(c.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+	}
+	moduleLevels := make(map[string]logging.Level)
+	for m, l := range c.FileConfig.GetStringMapString("log-levels") {
+		moduleLevels[m], err = logging.ParseLevel(os.ExpandEnv(l))
+		if err != nil {
+			return nil, fmt.Errorf("log-levels %q: %v", m, err)
+		}
+	}
+	return logging.NewHandler(w,
+		logging.WithFormat(format),
+		logging.WithLevel(level),
+		logging.WithModuleLevels(moduleLevels),
+	), nil
+}
+
 func (c *Config) SetPersistentFlagsFromFile(cmd *cobra.Command) {
 	// set debug and log values from file before other persistent flags
 	cmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
@@ -410,8 +583,11 @@ func (c *Config) CreateGetRequest() (*gnmi.GetRequest, error) {
 		return nil, fmt.Errorf("%w", ErrInvalidConfig)
 	}
 	gnmiOpts := make([]api.GNMIOption, 0, 4+len(c.LocalFlags.GetPath))
+	// encoding "auto" is selected per target once its capabilities are known
+	if !strings.EqualFold(c.Encoding, "auto") {
+		gnmiOpts = append(gnmiOpts, api.Encoding(c.Encoding))
+	}
 	gnmiOpts = append(gnmiOpts,
-		api.Encoding(c.Encoding),
 		api.DataType(c.LocalFlags.GetType),
 		api.Prefix(c.LocalFlags.GetPrefix),
 		api.Target(c.LocalFlags.GetTarget),
@@ -419,6 +595,9 @@ func (c *Config) CreateGetRequest() (*gnmi.GetRequest, error) {
 	for _, p := range c.LocalFlags.GetPath {
 		gnmiOpts = append(gnmiOpts, api.Path(strings.TrimSpace(p)))
 	}
+	if c.LocalFlags.GetDepth > 0 {
+		gnmiOpts = append(gnmiOpts, api.Extension_Depth(c.LocalFlags.GetDepth))
+	}
 	return api.NewGetRequest(gnmiOpts...)
 }
 
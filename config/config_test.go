@@ -36,7 +36,7 @@ var createGetRequestTestSet = map[string]struct {
 				Encoding: "dummy",
 			},
 			LocalFlags{},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: nil,
 		err: api.ErrInvalidValue,
@@ -49,7 +49,7 @@ var createGetRequestTestSet = map[string]struct {
 			LocalFlags{
 				GetPrefix: "/invalid/]prefix",
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: nil,
 		err: api.ErrInvalidValue,
@@ -62,7 +62,7 @@ var createGetRequestTestSet = map[string]struct {
 			LocalFlags{
 				GetPrefix: "/invalid/]path",
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: nil,
 		err: api.ErrInvalidValue,
@@ -76,7 +76,7 @@ var createGetRequestTestSet = map[string]struct {
 				GetPrefix: "/valid/path",
 				GetType:   "dummy",
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: nil,
 		err: api.ErrInvalidValue,
@@ -89,7 +89,7 @@ var createGetRequestTestSet = map[string]struct {
 			LocalFlags{
 				GetPath: []string{"/valid/path"},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.GetRequest{
 			Path: []*gnmi.Path{
@@ -112,7 +112,7 @@ var createGetRequestTestSet = map[string]struct {
 				GetPath: []string{"/valid/path"},
 				GetType: "state",
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.GetRequest{
 			Path: []*gnmi.Path{
@@ -135,7 +135,7 @@ var createGetRequestTestSet = map[string]struct {
 			LocalFlags{
 				GetPath: []string{"/valid/path"},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.GetRequest{
 			Path: []*gnmi.Path{
@@ -159,7 +159,7 @@ var createGetRequestTestSet = map[string]struct {
 				GetPrefix: "/valid/prefix",
 				GetPath:   []string{"/valid/path"},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.GetRequest{
 			Prefix: &gnmi.Path{
@@ -191,7 +191,7 @@ var createGetRequestTestSet = map[string]struct {
 					"/valid/path2",
 				},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.GetRequest{
 			Path: []*gnmi.Path{
@@ -226,7 +226,7 @@ var createSetRequestTestSet = map[string]struct {
 				SetDelimiter: ":::",
 				SetUpdate:    []string{"/valid/path:::json:::value"},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Update: []*gnmi.Update{
@@ -254,7 +254,7 @@ var createSetRequestTestSet = map[string]struct {
 				SetDelimiter: ":::",
 				SetReplace:   []string{"/valid/path:::json:::value"},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Replace: []*gnmi.Update{
@@ -281,7 +281,7 @@ var createSetRequestTestSet = map[string]struct {
 			LocalFlags{
 				SetDelete: []string{"/valid/path"},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Delete: []*gnmi.Path{
@@ -305,7 +305,7 @@ var createSetRequestTestSet = map[string]struct {
 					"/valid/path2:::json_ietf:::value2",
 				},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Update: []*gnmi.Update{
@@ -349,7 +349,7 @@ var createSetRequestTestSet = map[string]struct {
 					"/valid/path2:::json_ietf:::value2",
 				},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Replace: []*gnmi.Update{
@@ -392,7 +392,7 @@ var createSetRequestTestSet = map[string]struct {
 					"/valid/path2",
 				},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Delete: []*gnmi.Path{
@@ -421,7 +421,7 @@ var createSetRequestTestSet = map[string]struct {
 				SetReplace:   []string{"/valid/path2:::json:::value2"},
 				SetDelete:    []string{"/valid/path"},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Update: []*gnmi.Update{
@@ -474,7 +474,7 @@ var createSetRequestTestSet = map[string]struct {
 				SetUpdatePath:  []string{"/valid/path"},
 				SetUpdateValue: []string{"value"},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Update: []*gnmi.Update{
@@ -504,7 +504,7 @@ var createSetRequestTestSet = map[string]struct {
 				SetReplacePath:  []string{"/valid/path"},
 				SetReplaceValue: []string{"value"},
 			},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Replace: []*gnmi.Update{
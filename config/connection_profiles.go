@@ -0,0 +1,297 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/openconfig/gnmic/types"
+)
+
+// GetConnectionProfiles reads the connection profiles from the config file
+func (c *Config) GetConnectionProfiles() (map[string]*types.TargetConfig, error) {
+	profilesDef := c.FileConfig.GetStringMap("connection-profiles")
+	for pn, p := range profilesDef {
+		cp := new(types.TargetConfig)
+		switch p := p.(type) {
+		case map[string]interface{}:
+			decoder, err := mapstructure.NewDecoder(
+				&mapstructure.DecoderConfig{
+					DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+					Result:     cp,
+				},
+			)
+			if err != nil {
+				return nil, err
+			}
+			err = decoder.Decode(p)
+			if err != nil {
+				return nil, fmt.Errorf("connection profile %q: %v", pn, err)
+			}
+		case nil:
+		default:
+			return nil, fmt.Errorf("connection profile %q: unexpected format, got a %T", pn, p)
+		}
+		// a profile does not define a target identity
+		cp.Name = pn
+		cp.Address = ""
+		cp.ConnectionProfile = ""
+		// read the password as a string to maintain its case,
+		// see GetTargetsFromFile
+		pass := c.FileConfig.GetString(fmt.Sprintf("connection-profiles/%s/password", pn))
+		if pass != "" {
+			cp.Password = &pass
+		}
+		expandTargetEnv(cp)
+		for _, p := range []*string{cp.TLSCA, cp.TLSCert, cp.TLSKey} {
+			if p == nil || *p == "" {
+				continue
+			}
+			var err error
+			*p, err = expandOSPath(*p)
+			if err != nil {
+				return nil, fmt.Errorf("connection profile %q: %v", pn, err)
+			}
+		}
+		c.ConnectionProfiles[pn] = cp
+	}
+	if c.Debug {
+		c.logger.Printf("connection profiles: %v", c.ConnectionProfiles)
+	}
+	return c.ConnectionProfiles, nil
+}
+
+// applyConnectionProfile sets the fields of tc that are not set
+// to the values of the connection profile it references.
+func (c *Config) applyConnectionProfile(tc *types.TargetConfig) error {
+	if tc.ConnectionProfile == "" {
+		return nil
+	}
+	cp, ok := c.ConnectionProfiles[tc.ConnectionProfile]
+	if !ok {
+		return fmt.Errorf("target %q: unknown connection profile %q", tc.Name, tc.ConnectionProfile)
+	}
+	if tc.Username == nil {
+		tc.Username = copyString(cp.Username)
+	}
+	if tc.Password == nil {
+		tc.Password = copyString(cp.Password)
+	}
+	if tc.Token == nil {
+		tc.Token = copyString(cp.Token)
+	}
+	if tc.Timeout == 0 {
+		tc.Timeout = cp.Timeout
+	}
+	if tc.Insecure == nil {
+		tc.Insecure = copyBool(cp.Insecure)
+	}
+	if tc.SkipVerify == nil {
+		tc.SkipVerify = copyBool(cp.SkipVerify)
+	}
+	if tc.TLSCA == nil {
+		tc.TLSCA = copyString(cp.TLSCA)
+	}
+	if tc.TLSCert == nil {
+		tc.TLSCert = copyString(cp.TLSCert)
+	}
+	if tc.TLSKey == nil {
+		tc.TLSKey = copyString(cp.TLSKey)
+	}
+	if tc.TLSVersion == "" {
+		tc.TLSVersion = cp.TLSVersion
+	}
+	if tc.TLSMinVersion == "" {
+		tc.TLSMinVersion = cp.TLSMinVersion
+	}
+	if tc.TLSMaxVersion == "" {
+		tc.TLSMaxVersion = cp.TLSMaxVersion
+	}
+	if tc.LogTLSSecret == nil {
+		tc.LogTLSSecret = copyBool(cp.LogTLSSecret)
+	}
+	if len(tc.TLSCipherSuites) == 0 {
+		tc.TLSCipherSuites = append(tc.TLSCipherSuites, cp.TLSCipherSuites...)
+	}
+	if len(tc.TLSCurvePreferences) == 0 {
+		tc.TLSCurvePreferences = append(tc.TLSCurvePreferences, cp.TLSCurvePreferences...)
+	}
+	if tc.Gzip == nil {
+		tc.Gzip = copyBool(cp.Gzip)
+	}
+	if tc.SpiffeID == "" {
+		tc.SpiffeID = cp.SpiffeID
+	}
+	if tc.Vendor == "" {
+		tc.Vendor = cp.Vendor
+	}
+	if tc.ProbeCapabilities == nil {
+		tc.ProbeCapabilities = copyBool(cp.ProbeCapabilities)
+	}
+	if tc.CapabilitiesTTL == 0 {
+		tc.CapabilitiesTTL = cp.CapabilitiesTTL
+	}
+	if len(tc.EncodingPreference) == 0 {
+		tc.EncodingPreference = append(tc.EncodingPreference, cp.EncodingPreference...)
+	}
+	if tc.Proxy == "" {
+		tc.Proxy = cp.Proxy
+	}
+	if tc.RetryTimer == 0 {
+		tc.RetryTimer = cp.RetryTimer
+	}
+	if tc.MaxRetryTimer == 0 {
+		tc.MaxRetryTimer = cp.MaxRetryTimer
+	}
+	if tc.FlapThreshold == 0 {
+		tc.FlapThreshold = cp.FlapThreshold
+	}
+	if tc.FlapWindow == 0 {
+		tc.FlapWindow = cp.FlapWindow
+	}
+	if tc.FlapHoldDown == 0 {
+		tc.FlapHoldDown = cp.FlapHoldDown
+	}
+	if tc.MultiplexSubscriptions == nil {
+		tc.MultiplexSubscriptions = copyBool(cp.MultiplexSubscriptions)
+	}
+	if tc.MaxStreams == 0 {
+		tc.MaxStreams = cp.MaxStreams
+	}
+	if tc.GRPCKeepalive == nil && cp.GRPCKeepalive != nil {
+		ka := *cp.GRPCKeepalive
+		tc.GRPCKeepalive = &ka
+	}
+	if tc.RPCRetry == nil && cp.RPCRetry != nil {
+		rr := *cp.RPCRetry
+		rr.RetryOn = append([]string(nil), cp.RPCRetry.RetryOn...)
+		tc.RPCRetry = &rr
+	}
+	if tc.RPCRate == 0 {
+		tc.RPCRate = cp.RPCRate
+	}
+	if tc.RPCBurst == 0 {
+		tc.RPCBurst = cp.RPCBurst
+	}
+	if tc.InitialWindowSize == 0 {
+		tc.InitialWindowSize = cp.InitialWindowSize
+	}
+	if tc.InitialConnWindowSize == 0 {
+		tc.InitialConnWindowSize = cp.InitialConnWindowSize
+	}
+	if tc.MaxMsgSize == 0 {
+		tc.MaxMsgSize = cp.MaxMsgSize
+	}
+	if tc.UserAgent == "" {
+		tc.UserAgent = cp.UserAgent
+	}
+	if tc.DialStrategy == "" {
+		tc.DialStrategy = cp.DialStrategy
+	}
+	if tc.DialDelay == 0 {
+		tc.DialDelay = cp.DialDelay
+	}
+	if tc.Namespace == "" {
+		tc.Namespace = cp.Namespace
+	}
+	if tc.TokenAuth == nil && cp.TokenAuth != nil {
+		ta := *cp.TokenAuth
+		ta.Scopes = append([]string(nil), cp.TokenAuth.Scopes...)
+		ta.Command = append([]string(nil), cp.TokenAuth.Command...)
+		if cp.TokenAuth.Params != nil {
+			ta.Params = make(map[string]string, len(cp.TokenAuth.Params))
+			for k, v := range cp.TokenAuth.Params {
+				ta.Params[k] = v
+			}
+		}
+		tc.TokenAuth = &ta
+	}
+	if tc.ReachabilityProbe == nil && cp.ReachabilityProbe != nil {
+		p := *cp.ReachabilityProbe
+		tc.ReachabilityProbe = &p
+	}
+	if tc.Resolver == nil && cp.Resolver != nil {
+		r := *cp.Resolver
+		r.Servers = append([]string(nil), cp.Resolver.Servers...)
+		if cp.Resolver.Hosts != nil {
+			r.Hosts = make(map[string][]string, len(cp.Resolver.Hosts))
+			for h, addrs := range cp.Resolver.Hosts {
+				r.Hosts[h] = append([]string(nil), addrs...)
+			}
+		}
+		tc.Resolver = &r
+	}
+	if tc.SSHTunnel == nil && cp.SSHTunnel != nil {
+		st := *cp.SSHTunnel
+		tc.SSHTunnel = &st
+	}
+	if tc.Credentials == nil && cp.Credentials != nil {
+		cr := *cp.Credentials
+		tc.Credentials = &cr
+	}
+	if tc.BufferSize == 0 {
+		tc.BufferSize = cp.BufferSize
+	}
+	if len(tc.Subscriptions) == 0 {
+		tc.Subscriptions = append(tc.Subscriptions, cp.Subscriptions...)
+	}
+	if len(tc.Profiles) == 0 {
+		tc.Profiles = append(tc.Profiles, cp.Profiles...)
+	}
+	if len(tc.Outputs) == 0 {
+		tc.Outputs = append(tc.Outputs, cp.Outputs...)
+	}
+	if len(tc.Tags) == 0 {
+		tc.Tags = append(tc.Tags, cp.Tags...)
+	}
+	if len(tc.ProtoFiles) == 0 {
+		tc.ProtoFiles = append(tc.ProtoFiles, cp.ProtoFiles...)
+	}
+	if len(tc.ProtoDirs) == 0 {
+		tc.ProtoDirs = append(tc.ProtoDirs, cp.ProtoDirs...)
+	}
+	if len(cp.EventTags) > 0 {
+		eventTags := make(map[string]string, len(cp.EventTags)+len(tc.EventTags))
+		for k, v := range cp.EventTags {
+			eventTags[k] = v
+		}
+		for k, v := range tc.EventTags {
+			eventTags[k] = v
+		}
+		tc.EventTags = eventTags
+	}
+	if len(cp.Vars) > 0 {
+		vars := make(map[string]string, len(cp.Vars)+len(tc.Vars))
+		for k, v := range cp.Vars {
+			vars[k] = v
+		}
+		for k, v := range tc.Vars {
+			vars[k] = v
+		}
+		tc.Vars = vars
+	}
+	return nil
+}
+
+func copyString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
+
+func copyBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	v := *b
+	return &v
+}
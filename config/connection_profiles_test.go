@@ -0,0 +1,157 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"testing"
+	"time"
+)
+
+func TestConnectionProfiles(t *testing.T) {
+	in := []byte(`
+port: 57400
+connection-profiles:
+  spine:
+    username: admin
+    password: SpinePass
+    skip-verify: true
+    timeout: 5s
+    subscriptions:
+      - sub1
+    event-tags:
+      role: spine
+      dc: dc1
+    vars:
+      site: site1
+      vendor: nokia
+targets:
+  spine1:
+    connection-profile: spine
+  spine2:
+    connection-profile: spine
+    username: operator
+    subscriptions:
+      - sub2
+    event-tags:
+      dc: dc2
+    vars:
+      site: site2
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	tcs, err := cfg.GetTargetsFromFile()
+	if err != nil {
+		t.Fatalf("failed getting targets: %v", err)
+	}
+	spine1 := tcs["spine1"]
+	if spine1.Address != "spine1:57400" {
+		t.Errorf("spine1: unexpected address %q", spine1.Address)
+	}
+	if *spine1.Username != "admin" || *spine1.Password != "SpinePass" {
+		t.Errorf("spine1: unexpected credentials %q/%q", *spine1.Username, *spine1.Password)
+	}
+	if !*spine1.SkipVerify || spine1.Timeout != 5*time.Second {
+		t.Errorf("spine1: profile not applied: %s", spine1)
+	}
+	if len(spine1.Subscriptions) != 1 || spine1.Subscriptions[0] != "sub1" {
+		t.Errorf("spine1: unexpected subscriptions %v", spine1.Subscriptions)
+	}
+	spine2 := tcs["spine2"]
+	if *spine2.Username != "operator" || *spine2.Password != "SpinePass" {
+		t.Errorf("spine2: unexpected credentials %q/%q", *spine2.Username, *spine2.Password)
+	}
+	if len(spine2.Subscriptions) != 1 || spine2.Subscriptions[0] != "sub2" {
+		t.Errorf("spine2: unexpected subscriptions %v", spine2.Subscriptions)
+	}
+	if spine2.EventTags["dc"] != "dc2" || spine2.EventTags["role"] != "spine" {
+		t.Errorf("spine2: unexpected event tags %v", spine2.EventTags)
+	}
+	if spine2.Vars["site"] != "site2" || spine2.Vars["vendor"] != "nokia" {
+		t.Errorf("spine2: unexpected vars %v", spine2.Vars)
+	}
+	// the profile must not be modified by the targets
+	*spine1.Username = "changed"
+	if *cfg.ConnectionProfiles["spine"].Username != "admin" {
+		t.Errorf("connection profile modified by target")
+	}
+}
+
+func TestConnectionProfileTLSPolicy(t *testing.T) {
+	in := []byte(`
+port: 57400
+skip-verify: true
+connection-profiles:
+  legacy:
+    tls-min-version: "1.0"
+    tls-cipher-suites:
+      - TLS_RSA_WITH_AES_128_CBC_SHA
+targets:
+  old-device:
+    connection-profile: legacy
+  new-device:
+    tls-curve-preferences:
+      - X25519MLKEM768
+      - X25519
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	tcs, err := cfg.GetTargetsFromFile()
+	if err != nil {
+		t.Fatalf("failed getting targets: %v", err)
+	}
+	tlsConfig, err := tcs["old-device"].NewTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS10 {
+		t.Errorf("old-device: unexpected min version %x", tlsConfig.MinVersion)
+	}
+	if len(tlsConfig.CipherSuites) != 1 || tlsConfig.CipherSuites[0] != tls.TLS_RSA_WITH_AES_128_CBC_SHA {
+		t.Errorf("old-device: unexpected cipher suites %v", tlsConfig.CipherSuites)
+	}
+	// the downgrade is scoped to the profile targets
+	tlsConfig, err = tcs["new-device"].NewTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig.MinVersion != 0 || tlsConfig.CipherSuites != nil {
+		t.Errorf("new-device: unexpected TLS policy %x/%v", tlsConfig.MinVersion, tlsConfig.CipherSuites)
+	}
+	if len(tlsConfig.CurvePreferences) != 2 || tlsConfig.CurvePreferences[1] != tls.X25519 {
+		t.Errorf("new-device: unexpected curves %v", tlsConfig.CurvePreferences)
+	}
+}
+
+func TestUnknownConnectionProfile(t *testing.T) {
+	in := []byte(`
+targets:
+  leaf1:
+    connection-profile: leaf
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	_, err = cfg.GetTargetsFromFile()
+	if err == nil {
+		t.Fatalf("expected an unknown connection profile error")
+	}
+}
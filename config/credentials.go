@@ -0,0 +1,43 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/openconfig/gnmic/credentials"
+	_ "github.com/openconfig/gnmic/credentials/all"
+)
+
+// GetCredentialsProviders reads the credentials providers from the config file
+func (c *Config) GetCredentialsProviders() (map[string]map[string]interface{}, error) {
+	providersDef := c.FileConfig.GetStringMap("credentials-providers")
+	for pn, p := range providersDef {
+		switch p := p.(type) {
+		case map[string]interface{}:
+			providerType, ok := p["type"]
+			if !ok {
+				return nil, fmt.Errorf("credentials provider %q: missing type", pn)
+			}
+			switch providerType := providerType.(type) {
+			case string:
+				if _, ok := credentials.Providers[providerType]; !ok {
+					return nil, fmt.Errorf("credentials provider %q: unknown type %q", pn, providerType)
+				}
+			default:
+				return nil, fmt.Errorf("credentials provider %q: wrong type format", pn)
+			}
+			expandMapEnv(p)
+			c.CredentialsProviders[pn] = p
+		default:
+			return nil, fmt.Errorf("credentials provider %q: unexpected format, got a %T", pn, p)
+		}
+	}
+	return c.CredentialsProviders, nil
+}
@@ -0,0 +1,79 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGetCredentialsProviders(t *testing.T) {
+	tests := map[string]struct {
+		in      []byte
+		want    []string
+		wantErr bool
+	}{
+		"valid": {
+			in: []byte(`
+credentials-providers:
+  vault1:
+    type: vault
+    address: https://vault:8200
+  secrets:
+    type: file
+    base-dir: /run/secrets
+`),
+			want: []string{"vault1", "secrets"},
+		},
+		"unknown_type": {
+			in: []byte(`
+credentials-providers:
+  p1:
+    type: keyring
+`),
+			wantErr: true,
+		},
+		"missing_type": {
+			in: []byte(`
+credentials-providers:
+  p1:
+    address: https://vault:8200
+`),
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := New()
+			cfg.FileConfig.SetConfigType("yaml")
+			err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(tc.in))
+			if err != nil {
+				t.Fatalf("failed reading config: %v", err)
+			}
+			providers, err := cfg.GetCredentialsProviders()
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got providers: %v", providers)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(providers) != len(tc.want) {
+				t.Errorf("expected %d providers, got %d", len(tc.want), len(providers))
+			}
+			for _, pn := range tc.want {
+				if _, ok := providers[pn]; !ok {
+					t.Errorf("missing provider %q", pn)
+				}
+			}
+		})
+	}
+}
@@ -9,11 +9,13 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"strconv"
 	"time"
 
 	"github.com/openconfig/gnmic/cache"
+	"github.com/openconfig/gnmic/utils"
 )
 
 const (
@@ -36,18 +38,35 @@ type gnmiServer struct {
 	MinHeartbeatInterval  time.Duration `mapstructure:"min-heartbeat-interval,omitempty" json:"min-heartbeat-interval,omitempty"`
 	MaxSubscriptions      int64         `mapstructure:"max-subscriptions,omitempty" json:"max-subscriptions,omitempty"`
 	MaxUnaryRPC           int64         `mapstructure:"max-unary-rpc,omitempty" json:"max-unary-rpc,omitempty"`
+	// unix socket file permissions, e.g: 0660
+	SocketPermissions string `mapstructure:"socket-permissions,omitempty" json:"socket-permissions,omitempty"`
 	// TLS
 	SkipVerify bool   `mapstructure:"skip-verify,omitempty" json:"skip-verify,omitempty"`
 	CaFile     string `mapstructure:"ca-file,omitempty" json:"ca-file,omitempty"`
 	CertFile   string `mapstructure:"cert-file,omitempty" json:"cert-file,omitempty"`
 	KeyFile    string `mapstructure:"key-file,omitempty" json:"key-file,omitempty"`
+	// obtain the server certificate from an ACME CA instead of the cert and key files
+	ACME *utils.ACMEConfig `mapstructure:"acme,omitempty" json:"acme,omitempty"`
+	// SPIFFE IDs or trust domains of the authorized clients,
+	// when set, the server uses the gnmic X.509 SVID and requires the clients to present one
+	SpiffeIDs []string `mapstructure:"spiffe-ids,omitempty" json:"spiffe-ids,omitempty"`
+	// TLS versions, cipher suites and curves accepted by the server
+	TLSPolicy *utils.TLSPolicy `mapstructure:"tls-policy,omitempty" json:"tls-policy,omitempty"`
 	//
 	EnableMetrics bool `mapstructure:"enable-metrics,omitempty" json:"enable-metrics,omitempty"`
 	Debug         bool `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+	// proxy mode, the RPCs are relayed as is to the target set in the request prefix,
+	// no cache is used.
+	Proxy bool `mapstructure:"proxy,omitempty" json:"proxy,omitempty"`
 	// ServiceRegistration
 	ServiceRegistration *serviceRegistration `mapstructure:"service-registration,omitempty" json:"service-registration,omitempty"`
 	// cache config
 	Cache *cache.Config `mapstructure:"cache,omitempty" json:"cache,omitempty"`
+	// YANG files and directories describing the targets data,
+	// used to filter the Get responses by data type and models.
+	YangFiles   []string `mapstructure:"yang-files,omitempty" json:"yang-files,omitempty"`
+	YangDirs    []string `mapstructure:"yang-dirs,omitempty" json:"yang-dirs,omitempty"`
+	YangExclude []string `mapstructure:"yang-exclude,omitempty" json:"yang-exclude,omitempty"`
 }
 
 type serviceRegistration struct {
@@ -70,6 +89,7 @@ func (c *Config) GetGNMIServer() error {
 	}
 	c.GnmiServer = new(gnmiServer)
 	c.GnmiServer.Address = os.ExpandEnv(c.FileConfig.GetString("gnmi-server/address"))
+	c.GnmiServer.SocketPermissions = os.ExpandEnv(c.FileConfig.GetString("gnmi-server/socket-permissions"))
 
 	maxSubVal := os.ExpandEnv(c.FileConfig.GetString("gnmi-server/max-subscriptions"))
 	if maxSubVal != "" {
@@ -92,9 +112,33 @@ func (c *Config) GetGNMIServer() error {
 	c.GnmiServer.CaFile = os.ExpandEnv(c.FileConfig.GetString("gnmi-server/ca-file"))
 	c.GnmiServer.CertFile = os.ExpandEnv(c.FileConfig.GetString("gnmi-server/cert-file"))
 	c.GnmiServer.KeyFile = os.ExpandEnv(c.FileConfig.GetString("gnmi-server/key-file"))
+	acme, err := c.getACME("gnmi-server")
+	if err != nil {
+		return err
+	}
+	c.GnmiServer.ACME = acme
+	for _, id := range c.FileConfig.GetStringSlice("gnmi-server/spiffe-ids") {
+		c.GnmiServer.SpiffeIDs = append(c.GnmiServer.SpiffeIDs, os.ExpandEnv(id))
+	}
+	if len(c.GnmiServer.SpiffeIDs) > 0 && c.GnmiServer.ACME != nil {
+		return errors.New("gnmi-server: spiffe-ids and acme are mutually exclusive")
+	}
+	c.GnmiServer.TLSPolicy, err = c.getTLSPolicy("gnmi-server")
+	if err != nil {
+		return err
+	}
+
+	for _, f := range c.FileConfig.GetStringSlice("gnmi-server/yang-files") {
+		c.GnmiServer.YangFiles = append(c.GnmiServer.YangFiles, os.ExpandEnv(f))
+	}
+	for _, d := range c.FileConfig.GetStringSlice("gnmi-server/yang-dirs") {
+		c.GnmiServer.YangDirs = append(c.GnmiServer.YangDirs, os.ExpandEnv(d))
+	}
+	c.GnmiServer.YangExclude = c.FileConfig.GetStringSlice("gnmi-server/yang-exclude")
 
 	c.GnmiServer.EnableMetrics = os.ExpandEnv(c.FileConfig.GetString("gnmi-server/enable-metrics")) == trueString
 	c.GnmiServer.Debug = os.ExpandEnv(c.FileConfig.GetString("gnmi-server/debug")) == trueString
+	c.GnmiServer.Proxy = os.ExpandEnv(c.FileConfig.GetString("gnmi-server/proxy")) == trueString
 	c.setGnmiServerDefaults()
 
 	if c.FileConfig.IsSet("gnmi-server/service-registration") {
@@ -112,6 +156,9 @@ func (c *Config) GetGNMIServer() error {
 	}
 
 	if c.FileConfig.IsSet("gnmi-server/cache") {
+		if c.GnmiServer.Proxy {
+			return errors.New("gnmi-server: proxy and cache are mutually exclusive")
+		}
 		c.GnmiServer.Cache = new(cache.Config)
 		c.GnmiServer.Cache.Type = cache.CacheType(os.ExpandEnv(c.FileConfig.GetString("gnmi-server/cache/type")))
 		c.GnmiServer.Cache.Address = os.ExpandEnv(c.FileConfig.GetString("gnmi-server/cache/address"))
@@ -126,6 +173,10 @@ func (c *Config) GetGNMIServer() error {
 		//
 		c.GnmiServer.Cache.FetchBatchSize = c.FileConfig.GetInt("gnmi-server/cache/fetch-batch-size")
 		c.GnmiServer.Cache.FetchWaitTime = c.FileConfig.GetDuration("gnmi-server/cache/fetch-wait-time")
+		//
+		c.GnmiServer.Cache.Retention = c.FileConfig.GetDuration("gnmi-server/cache/retention")
+		c.GnmiServer.Cache.ReplaySampleEvery = c.FileConfig.GetInt("gnmi-server/cache/replay-sample-every")
+		c.GnmiServer.Cache.ReplayMinInterval = c.FileConfig.GetDuration("gnmi-server/cache/replay-min-interval")
 	}
 	return nil
 }
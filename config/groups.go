@@ -0,0 +1,62 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/openconfig/gnmic/types"
+)
+
+// GetTargetGroups reads the target groups from the config file
+func (c *Config) GetTargetGroups() (map[string]*types.TargetGroup, error) {
+	groupsDef := c.FileConfig.GetStringMap("target-groups")
+	for gn, g := range groupsDef {
+		tg := new(types.TargetGroup)
+		switch g := g.(type) {
+		case map[string]interface{}:
+			err := mapstructure.Decode(g, tg)
+			if err != nil {
+				return nil, fmt.Errorf("target group %q: %v", gn, err)
+			}
+		case nil:
+		default:
+			return nil, fmt.Errorf("target group %q: unexpected format, got a %T", gn, g)
+		}
+		tg.Name = gn
+		if len(tg.Targets) == 0 && len(tg.Selector) == 0 {
+			return nil, fmt.Errorf("target group %q: no targets nor selector defined", gn)
+		}
+		c.TargetGroups[gn] = tg
+	}
+	if c.Debug {
+		c.logger.Printf("target groups: %v", c.TargetGroups)
+	}
+	return c.TargetGroups, nil
+}
+
+// filterTargetsByGroups keeps the targets belonging to at least one of the groups.
+func (c *Config) filterTargetsByGroups(groups []string) error {
+	selected := make(map[string]*types.TargetConfig)
+	for _, gn := range groups {
+		g, ok := c.TargetGroups[gn]
+		if !ok {
+			return fmt.Errorf("unknown target group %q", gn)
+		}
+		for _, n := range g.Members(c.Targets) {
+			selected[n] = c.Targets[n]
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("target groups %q: %w", groups, ErrNoTargetsFound)
+	}
+	c.Targets = selected
+	return nil
+}
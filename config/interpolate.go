@@ -0,0 +1,252 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/gnmic/credentials"
+	"github.com/openconfig/gnmic/utils"
+	"github.com/spf13/viper"
+)
+
+const (
+	interpolationEnv   = "env"
+	interpolationFile  = "file"
+	interpolationVault = "vault"
+)
+
+// matches ${env:VAR}, ${file:/path} and ${vault:secret/path#key}
+var interpolationRegex = regexp.MustCompile(`\$\{(env|file|vault):([^}]+)\}`)
+
+// interpolate resolves the ${env:VAR}, ${file:/path} and ${vault:secret/path#key}
+// references found in the string values of the config file bytes b,
+// and merges the resolved values into the file configuration layer.
+// The vault references are resolved using the credentials provider named "vault",
+// or the first provider of type vault, or the VAULT_ADDR and VAULT_TOKEN env variables.
+func (c *Config) interpolate(ctx context.Context, b []byte) error {
+	if !interpolationRegex.Match(b) {
+		return nil
+	}
+	v := viper.NewWithOptions(viper.KeyDelimiter("/"))
+	configType := strings.TrimPrefix(filepath.Ext(c.FileConfig.ConfigFileUsed()), ".")
+	if configType == "" {
+		configType = "yaml"
+	}
+	v.SetConfigType(configType)
+	err := v.ReadConfig(bytes.NewBuffer(b))
+	if err != nil {
+		return err
+	}
+	settings := v.AllSettings()
+	in := &interpolator{secrets: make(map[string]map[string]interface{})}
+	// env and file references are resolved first,
+	// they can be used in the vault credentials provider config.
+	n, err := in.resolve(ctx, settings, interpolationEnv, interpolationFile)
+	if err != nil {
+		return err
+	}
+	if in.has(settings, interpolationVault) {
+		in.vault, err = c.secretReader(ctx, settings)
+		if err != nil {
+			return err
+		}
+		nv, err := in.resolve(ctx, settings, interpolationVault)
+		if err != nil {
+			return err
+		}
+		n += nv
+	}
+	if c.Debug {
+		c.logger.Printf("resolved %d config file reference(s)", n)
+	}
+	return c.FileConfig.MergeConfigMap(settings)
+}
+
+// secretReader initializes the vault credentials provider used to resolve
+// the ${vault:} references.
+func (c *Config) secretReader(ctx context.Context, settings map[string]interface{}) (credentials.SecretReader, error) {
+	var pcfg map[string]interface{}
+	providers, _ := settings["credentials-providers"].(map[string]interface{})
+	if p, ok := providers[interpolationVault].(map[string]interface{}); ok && p["type"] == interpolationVault {
+		pcfg = p
+	} else {
+		names := make([]string, 0, len(providers))
+		for pn := range providers {
+			names = append(names, pn)
+		}
+		sort.Strings(names)
+		for _, pn := range names {
+			if p, ok := providers[pn].(map[string]interface{}); ok && p["type"] == interpolationVault {
+				pcfg = p
+				break
+			}
+		}
+	}
+	if pcfg == nil {
+		pcfg = make(map[string]interface{})
+	}
+	cp := make(map[string]interface{}, len(pcfg))
+	for k, v := range pcfg {
+		cp[k] = v
+	}
+	expandMapEnv(cp)
+
+	in, ok := credentials.Providers[interpolationVault]
+	if !ok {
+		return nil, fmt.Errorf("unknown credentials provider type %q", interpolationVault)
+	}
+	p := in()
+	sr, ok := p.(credentials.SecretReader)
+	if !ok {
+		return nil, fmt.Errorf("credentials provider type %q cannot read secrets", interpolationVault)
+	}
+	err := p.Init(ctx, cp, credentials.WithLogger(c.logger))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize vault client: %v", err)
+	}
+	return sr, nil
+}
+
+type interpolator struct {
+	vault credentials.SecretReader
+	// secrets read from vault, by path
+	secrets map[string]map[string]interface{}
+}
+
+// has returns true if v contains a reference of kind.
+func (in *interpolator) has(v interface{}, kind string) bool {
+	switch v := v.(type) {
+	case string:
+		for _, m := range interpolationRegex.FindAllStringSubmatch(v, -1) {
+			if m[1] == kind {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, vv := range v {
+			if in.has(vv, kind) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, vv := range v {
+			if in.has(vv, kind) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolve replaces, in place, the references of the given kinds found in m.
+// It returns the number of resolved references.
+func (in *interpolator) resolve(ctx context.Context, m map[string]interface{}, kinds ...string) (int, error) {
+	total := 0
+	for k, v := range m {
+		nv, n, err := in.resolveValue(ctx, v, kinds)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %v", k, err)
+		}
+		m[k] = nv
+		total += n
+	}
+	return total, nil
+}
+
+func (in *interpolator) resolveValue(ctx context.Context, v interface{}, kinds []string) (interface{}, int, error) {
+	switch v := v.(type) {
+	case string:
+		return in.resolveString(ctx, v, kinds)
+	case map[string]interface{}:
+		n, err := in.resolve(ctx, v, kinds...)
+		return v, n, err
+	case []interface{}:
+		total := 0
+		for i, item := range v {
+			nv, n, err := in.resolveValue(ctx, item, kinds)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%d: %v", i, err)
+			}
+			v[i] = nv
+			total += n
+		}
+		return v, total, nil
+	}
+	return v, 0, nil
+}
+
+func (in *interpolator) resolveString(ctx context.Context, s string, kinds []string) (string, int, error) {
+	var err error
+	n := 0
+	r := interpolationRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		m := interpolationRegex.FindStringSubmatch(ref)
+		if !strInlist(m[1], kinds) {
+			return ref
+		}
+		var val string
+		val, err = in.lookup(ctx, m[1], m[2])
+		if err != nil {
+			return ref
+		}
+		n++
+		return val
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return r, n, nil
+}
+
+func (in *interpolator) lookup(ctx context.Context, kind, ref string) (string, error) {
+	switch kind {
+	case interpolationEnv:
+		val, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("environment variable %q is not set", ref)
+		}
+		return val, nil
+	case interpolationFile:
+		b, err := utils.ReadFile(ctx, ref)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file %q: %v", ref, err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	case interpolationVault:
+		path, key, ok := strings.Cut(ref, "#")
+		if !ok || path == "" || key == "" {
+			return "", fmt.Errorf("malformed vault reference %q, expected ${vault:secret/path#key}", ref)
+		}
+		data, ok := in.secrets[path]
+		if !ok {
+			var err error
+			data, err = in.vault.ReadSecret(ctx, path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read vault secret %q: %v", path, err)
+			}
+			in.secrets[path] = data
+		}
+		val, ok := data[key]
+		if !ok {
+			return "", fmt.Errorf("vault secret %q has no key %q", path, key)
+		}
+		return fmt.Sprint(val), nil
+	}
+	return "", fmt.Errorf("unknown reference type %q", kind)
+}
@@ -0,0 +1,196 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "password"), []byte("file-secret\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GNMIC_TEST_USERNAME", "admin")
+	t.Setenv("GNMIC_TEST_TOKEN", "tok")
+
+	tests := map[string]struct {
+		in      string
+		want    map[string]string
+		wantErr string
+	}{
+		"env": {
+			in: `
+username: ${env:GNMIC_TEST_USERNAME}
+targets:
+  router1:
+    token: Bearer ${env:GNMIC_TEST_TOKEN}
+`,
+			want: map[string]string{
+				"username":              "admin",
+				"targets/router1/token": "Bearer tok",
+			},
+		},
+		"file": {
+			in: `
+password: ${file:` + filepath.Join(dir, "password") + `}
+`,
+			want: map[string]string{
+				"password": "file-secret",
+			},
+		},
+		"lists": {
+			in: `
+api-server:
+  auth:
+    tokens:
+      - ${env:GNMIC_TEST_TOKEN}
+      - static
+`,
+			want: map[string]string{
+				"api-server/auth/tokens": "[tok static]",
+			},
+		},
+		"plain_env_vars_untouched": {
+			in: `
+username: ${GNMIC_TEST_USERNAME}
+`,
+			want: map[string]string{
+				"username": "${GNMIC_TEST_USERNAME}",
+			},
+		},
+		"missing_env": {
+			in: `
+username: ${env:GNMIC_TEST_NOT_SET}
+`,
+			wantErr: `environment variable "GNMIC_TEST_NOT_SET" is not set`,
+		},
+		"missing_file": {
+			in: `
+password: ${file:` + filepath.Join(dir, "not-found") + `}
+`,
+			wantErr: "failed to read file",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := New()
+			cfg.FileConfig.SetConfigType("yaml")
+			err := cfg.FileConfig.ReadConfig(bytes.NewBufferString(tc.in))
+			if err != nil {
+				t.Fatalf("failed reading config: %v", err)
+			}
+			err = cfg.interpolate(context.Background(), []byte(tc.in))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for k, v := range tc.want {
+				var got string
+				switch val := cfg.FileConfig.Get(k).(type) {
+				case []interface{}:
+					ss := make([]string, 0, len(val))
+					for _, s := range val {
+						ss = append(ss, s.(string))
+					}
+					got = "[" + strings.Join(ss, " ") + "]"
+				default:
+					got = cfg.FileConfig.GetString(k)
+				}
+				if got != v {
+					t.Errorf("%s: expected %q, got %q", k, v, got)
+				}
+			}
+		})
+	}
+}
+
+func TestInterpolateVault(t *testing.T) {
+	reads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/routers" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		reads++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{
+					"username": "vault-user",
+					"password": "vault-pass",
+				},
+			},
+		})
+	}))
+	defer srv.Close()
+	t.Setenv("GNMIC_TEST_VAULT_TOKEN", "root")
+
+	in := `
+credentials-providers:
+  vault:
+    type: vault
+    address: ` + srv.URL + `
+    token: ${env:GNMIC_TEST_VAULT_TOKEN}
+targets:
+  router1:
+    username: ${vault:secret/data/routers#username}
+    password: ${vault:secret/data/routers#password}
+`
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBufferString(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	err = cfg.interpolate(context.Background(), []byte(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.FileConfig.GetString("targets/router1/username"); got != "vault-user" {
+		t.Errorf("expected username %q, got %q", "vault-user", got)
+	}
+	if got := cfg.FileConfig.GetString("targets/router1/password"); got != "vault-pass" {
+		t.Errorf("expected password %q, got %q", "vault-pass", got)
+	}
+	if reads != 1 {
+		t.Errorf("expected the secret to be read once, got %d reads", reads)
+	}
+
+	in = `
+targets:
+  router1:
+    password: ${vault:secret/data/routers#enable}
+`
+	cfg = New()
+	cfg.FileConfig.SetConfigType("yaml")
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+	err = cfg.interpolate(context.Background(), []byte(in))
+	if err == nil || !strings.Contains(err.Error(), `has no key "enable"`) {
+		t.Fatalf("expected a missing key error, got %v", err)
+	}
+}
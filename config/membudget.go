@@ -0,0 +1,29 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+
+	"github.com/openconfig/gnmic/membudget"
+)
+
+func (c *Config) GetMemoryBudget() error {
+	if !c.FileConfig.IsSet("memory-budget") {
+		return nil
+	}
+	c.MemoryBudget = new(membudget.Config)
+	c.MemoryBudget.Limit = os.ExpandEnv(c.FileConfig.GetString("memory-budget/limit"))
+	c.MemoryBudget.Policy = os.ExpandEnv(c.FileConfig.GetString("memory-budget/policy"))
+	c.MemoryBudget.ResumeRatio = c.FileConfig.GetFloat64("memory-budget/resume-ratio")
+	c.MemoryBudget.SpillDirectory = os.ExpandEnv(c.FileConfig.GetString("memory-budget/spill-directory"))
+	c.MemoryBudget.SpillMaxSize = os.ExpandEnv(c.FileConfig.GetString("memory-budget/spill-max-size"))
+	c.MemoryBudget.SetDefaults()
+	return nil
+}
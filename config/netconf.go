@@ -0,0 +1,68 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/openconfig/gnmic/netconf"
+)
+
+// GetNetconfPollers reads the NETCONF pollers from the config file.
+func (c *Config) GetNetconfPollers() (map[string]*netconf.PollerConfig, error) {
+	pollersDef := c.FileConfig.GetStringMap("netconf-pollers")
+	c.NetconfPollers = make(map[string]*netconf.PollerConfig, len(pollersDef))
+	for pn, p := range pollersDef {
+		pc := new(netconf.PollerConfig)
+		decoder, err := mapstructure.NewDecoder(
+			&mapstructure.DecoderConfig{
+				DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+				Result:     pc,
+			})
+		if err != nil {
+			return nil, err
+		}
+		err = decoder.Decode(convert(p))
+		if err != nil {
+			return nil, fmt.Errorf("netconf poller %q: %v", pn, err)
+		}
+		pc.Name = pn
+		expandNetconfPollerEnv(pc)
+		err = pc.SetDefaults()
+		if err != nil {
+			return nil, fmt.Errorf("netconf poller %q: %v", pn, err)
+		}
+		c.NetconfPollers[pn] = pc
+	}
+	if c.Debug {
+		c.logger.Printf("netconf pollers: %v", c.NetconfPollers)
+	}
+	return c.NetconfPollers, nil
+}
+
+func expandNetconfPollerEnv(pc *netconf.PollerConfig) {
+	pc.RPC = os.ExpandEnv(pc.RPC)
+	pc.Source = os.ExpandEnv(pc.Source)
+	for i := range pc.Paths {
+		pc.Paths[i] = os.ExpandEnv(pc.Paths[i])
+	}
+	for _, d := range pc.Devices {
+		if d == nil {
+			continue
+		}
+		d.Address = os.ExpandEnv(d.Address)
+		d.Username = os.ExpandEnv(d.Username)
+		d.Password = os.ExpandEnv(d.Password)
+		d.PrivateKey = os.ExpandEnv(d.PrivateKey)
+		d.Passphrase = os.ExpandEnv(d.Passphrase)
+		d.KnownHosts = os.ExpandEnv(d.KnownHosts)
+	}
+}
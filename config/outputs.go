@@ -19,7 +19,8 @@ import (
 
 func (c *Config) GetOutputs() (map[string]map[string]interface{}, error) {
 	outDef := c.FileConfig.GetStringMap("outputs")
-	if len(outDef) == 0 && !c.FileConfig.GetBool("subscribe-quiet") {
+	// the TUI replaces the default stdout output
+	if len(outDef) == 0 && !c.FileConfig.GetBool("subscribe-quiet") && !c.FileConfig.GetBool("subscribe-tui") {
 		stdoutConfig := map[string]interface{}{
 			"type":      "file",
 			"file-type": "stdout",
@@ -0,0 +1,180 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/types"
+)
+
+// GetSubscriptionProfiles reads the subscription profiles from the config file
+func (c *Config) GetSubscriptionProfiles() (map[string]*types.SubscriptionProfile, error) {
+	profilesDef := c.FileConfig.GetStringMap("subscription-profiles")
+	for pn, p := range profilesDef {
+		sp := new(types.SubscriptionProfile)
+		decoder, err := mapstructure.NewDecoder(
+			&mapstructure.DecoderConfig{
+				DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+				Result:     sp,
+			})
+		if err != nil {
+			return nil, err
+		}
+		err = decoder.Decode(convert(p))
+		if err != nil {
+			return nil, fmt.Errorf("subscription profile %q: %v", pn, err)
+		}
+		sp.Name = pn
+		if sp.Subscriptions == nil {
+			sp.Subscriptions = make(map[string]*types.SubscriptionConfig)
+		}
+		for sn, sub := range sp.Subscriptions {
+			if sub == nil {
+				sub = new(types.SubscriptionConfig)
+				sp.Subscriptions[sn] = sub
+			}
+			sub.Name = sn
+			c.setSubscriptionDefaults(sub, nil)
+			expandSubscriptionEnv(sub)
+		}
+		c.SubscriptionProfiles[pn] = sp
+	}
+	if c.Debug {
+		c.logger.Printf("subscription profiles: %v", c.SubscriptionProfiles)
+	}
+	return c.SubscriptionProfiles, nil
+}
+
+// LintSubscriptionProfiles checks the subscription profiles consistency
+// with the rest of the configuration.
+// It returns a sorted list of the found issues.
+func (c *Config) LintSubscriptionProfiles() []string {
+	issues := make([]string, 0)
+	subOwner := make(map[string]string)
+	for sn := range c.Subscriptions {
+		subOwner[sn] = "subscriptions section"
+	}
+	pNames := make([]string, 0, len(c.SubscriptionProfiles))
+	for pn := range c.SubscriptionProfiles {
+		pNames = append(pNames, pn)
+	}
+	sort.Strings(pNames)
+	for _, pn := range pNames {
+		sp := c.SubscriptionProfiles[pn]
+		if len(sp.Subscriptions) == 0 {
+			issues = append(issues, fmt.Sprintf("profile %q: no subscriptions defined", pn))
+		}
+		for _, sn := range sp.SubscriptionNames() {
+			if owner, ok := subOwner[sn]; ok {
+				issues = append(issues, fmt.Sprintf("profile %q: subscription %q already defined in %s", pn, sn, owner))
+			} else {
+				subOwner[sn] = fmt.Sprintf("profile %q", pn)
+			}
+			for _, issue := range lintSubscription(sp.Subscriptions[sn]) {
+				issues = append(issues, fmt.Sprintf("profile %q: subscription %q: %s", pn, sn, issue))
+			}
+		}
+		for _, o := range sp.Outputs {
+			if _, ok := c.Outputs[o]; !ok {
+				issues = append(issues, fmt.Sprintf("profile %q: unknown output %q", pn, o))
+			}
+		}
+		for _, ep := range sp.Processors {
+			if _, ok := c.Processors[ep]; !ok {
+				issues = append(issues, fmt.Sprintf("profile %q: unknown processor %q", pn, ep))
+			}
+		}
+	}
+	for tn, tc := range c.Targets {
+		for _, pn := range tc.Profiles {
+			if _, ok := c.SubscriptionProfiles[pn]; !ok {
+				issues = append(issues, fmt.Sprintf("target %q: unknown profile %q", tn, pn))
+			}
+		}
+	}
+	sort.Strings(issues)
+	return issues
+}
+
+func lintSubscription(sc *types.SubscriptionConfig) []string {
+	issues := make([]string, 0)
+	if len(sc.Paths) == 0 {
+		issues = append(issues, "no paths defined")
+	}
+	mode := strings.ToUpper(sc.Mode)
+	switch mode {
+	case "", "STREAM", "ONCE", "POLL":
+	default:
+		issues = append(issues, fmt.Sprintf("unknown mode %q", sc.Mode))
+	}
+	streamMode := strings.ToUpper(strings.ReplaceAll(sc.StreamMode, "-", "_"))
+	switch streamMode {
+	case "", "TARGET_DEFINED", "SAMPLE", "ON_CHANGE":
+	default:
+		issues = append(issues, fmt.Sprintf("unknown stream-mode %q", sc.StreamMode))
+	}
+	if mode != "" && mode != "STREAM" && sc.StreamMode != "" {
+		issues = append(issues, fmt.Sprintf("stream-mode set with mode %q", sc.Mode))
+	}
+	if streamMode == "ON_CHANGE" && sc.SampleInterval != nil {
+		issues = append(issues, "sample-interval set with stream-mode on-change")
+	}
+	return issues
+}
+
+// ProfileProcessors returns the configs of the processors applying
+// the event processors of each subscription profile to the events of its subscriptions,
+// keyed by a name derived from the profile name.
+// The returned names are sorted.
+func (c *Config) ProfileProcessors() ([]string, map[string]map[string]interface{}) {
+	names := make([]string, 0)
+	ps := make(map[string]map[string]interface{})
+	for pn, sp := range c.SubscriptionProfiles {
+		if len(sp.Processors) == 0 {
+			continue
+		}
+		name := "profile:" + pn
+		names = append(names, name)
+		ps[name] = formatters.SubscriptionScope(sp.SubscriptionNames(), sp.Processors, c.Processors)
+	}
+	sort.Strings(names)
+	return names, ps
+}
+
+// ApplySubscriptionProfiles returns the subscriptions and the outputs
+// resulting from the profiles referenced by the target config tc.
+// Unknown profiles are ignored, they are reported by LintSubscriptionProfiles.
+func (c *Config) ApplySubscriptionProfiles(tc *types.TargetConfig) (map[string]*types.SubscriptionConfig, []string) {
+	subs := make(map[string]*types.SubscriptionConfig)
+	outs := make([]string, 0)
+	for _, pn := range tc.Profiles {
+		sp, ok := c.SubscriptionProfiles[pn]
+		if !ok {
+			continue
+		}
+		for sn, sc := range sp.Subscriptions {
+			subs[sn] = sc
+		}
+	OUTPUTS:
+		for _, o := range sp.Outputs {
+			for _, eo := range outs {
+				if eo == o {
+					continue OUTPUTS
+				}
+			}
+			outs = append(outs, o)
+		}
+	}
+	return subs, outs
+}
@@ -0,0 +1,200 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+var lintSubscriptionProfilesTestSet = map[string]struct {
+	in  []byte
+	out []string
+}{
+	"valid": {
+		in: []byte(`
+outputs:
+  out1:
+    type: file
+processors:
+  proc1:
+    event-drop:
+      condition: 'false'
+subscriptions:
+  sub1:
+    paths:
+      - /valid/path
+subscription-profiles:
+  p1:
+    subscriptions:
+      sub2:
+        paths:
+          - /interfaces
+        stream-mode: sample
+        sample-interval: 10s
+    outputs:
+      - out1
+    processors:
+      - proc1
+targets:
+  target1:
+    profiles:
+      - p1
+`),
+		out: []string{},
+	},
+	"conflicts": {
+		in: []byte(`
+subscriptions:
+  sub1:
+    paths:
+      - /valid/path
+subscription-profiles:
+  p1:
+    subscriptions:
+      sub1:
+        paths:
+          - /interfaces
+  p2:
+    subscriptions:
+      sub2:
+        mode: once
+        stream-mode: on-change
+    outputs:
+      - out1
+    processors:
+      - proc1
+  p3:
+targets:
+  target1:
+    profiles:
+      - p4
+`),
+		out: []string{
+			`profile "p1": subscription "sub1" already defined in subscriptions section`,
+			`profile "p2": subscription "sub2": no paths defined`,
+			`profile "p2": subscription "sub2": stream-mode set with mode "once"`,
+			`profile "p2": unknown output "out1"`,
+			`profile "p2": unknown processor "proc1"`,
+			`profile "p3": no subscriptions defined`,
+			`target "target1": unknown profile "p4"`,
+		},
+	},
+}
+
+func TestLintSubscriptionProfiles(t *testing.T) {
+	for name, data := range lintSubscriptionProfilesTestSet {
+		t.Run(name, func(t *testing.T) {
+			cfg := New()
+			cfg.FileConfig.SetConfigType("yaml")
+			err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(data.in))
+			if err != nil {
+				t.Fatalf("failed reading config: %v", err)
+			}
+			_, err = cfg.GetOutputs()
+			if err != nil {
+				t.Fatalf("failed getting outputs: %v", err)
+			}
+			_, err = cfg.GetEventProcessors()
+			if err != nil {
+				t.Fatalf("failed getting processors: %v", err)
+			}
+			_, err = cfg.GetSubscriptions(nil)
+			if err != nil {
+				t.Fatalf("failed getting subscriptions: %v", err)
+			}
+			_, err = cfg.GetTargetsFromFile()
+			if err != nil && err != ErrNoTargetsFound {
+				t.Fatalf("failed getting targets: %v", err)
+			}
+			_, err = cfg.GetSubscriptionProfiles()
+			if err != nil {
+				t.Fatalf("failed getting subscription profiles: %v", err)
+			}
+			issues := cfg.LintSubscriptionProfiles()
+			if !reflect.DeepEqual(issues, data.out) {
+				t.Logf("exp value: %q", data.out)
+				t.Logf("got value: %q", issues)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestApplySubscriptionProfiles(t *testing.T) {
+	interval := 10 * time.Second
+	cfg := New()
+	cfg.SubscriptionProfiles = map[string]*types.SubscriptionProfile{
+		"p1": {
+			Name: "p1",
+			Subscriptions: map[string]*types.SubscriptionConfig{
+				"sub1": {Name: "sub1", Paths: []string{"/interfaces"}, SampleInterval: &interval},
+			},
+			Outputs: []string{"out1"},
+		},
+		"p2": {
+			Name: "p2",
+			Subscriptions: map[string]*types.SubscriptionConfig{
+				"sub2": {Name: "sub2", Paths: []string{"/system"}},
+			},
+			Outputs: []string{"out1", "out2"},
+		},
+	}
+	subs, outs := cfg.ApplySubscriptionProfiles(&types.TargetConfig{
+		Name:     "target1",
+		Profiles: []string{"p1", "p2", "unknown"},
+	})
+	if len(subs) != 2 || subs["sub1"] == nil || subs["sub2"] == nil {
+		t.Errorf("unexpected subscriptions: %v", subs)
+	}
+	if !reflect.DeepEqual(outs, []string{"out1", "out2"}) {
+		t.Errorf("unexpected outputs: %v", outs)
+	}
+}
+
+func TestProfileProcessors(t *testing.T) {
+	cfg := New()
+	cfg.Processors = map[string]map[string]interface{}{
+		"proc1": {"event-drop": map[string]interface{}{"condition": "true"}},
+	}
+	cfg.SubscriptionProfiles = map[string]*types.SubscriptionProfile{
+		"p1": {
+			Name: "p1",
+			Subscriptions: map[string]*types.SubscriptionConfig{
+				"sub1": {Name: "sub1"},
+			},
+			Processors: []string{"proc1", "unknown"},
+		},
+		"p2": {
+			Name: "p2",
+			Subscriptions: map[string]*types.SubscriptionConfig{
+				"sub2": {Name: "sub2"},
+			},
+		},
+	}
+	names, ps := cfg.ProfileProcessors()
+	if !reflect.DeepEqual(names, []string{"profile:p1"}) {
+		t.Fatalf("unexpected names: %v", names)
+	}
+	want := map[string]interface{}{
+		"subscription-scope": map[string]interface{}{
+			"subscriptions": []string{"sub1"},
+			"processors": []interface{}{
+				map[string]interface{}{"event-drop": map[string]interface{}{"condition": "true"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(ps["profile:p1"], want) {
+		t.Errorf("unexpected config: %v", ps["profile:p1"])
+	}
+}
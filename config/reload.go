@@ -0,0 +1,71 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/openconfig/gnmic/utils"
+	"github.com/spf13/cobra"
+)
+
+// Reload reads the configuration file again and returns a new Config
+// with its targets, subscriptions, outputs and processors sections loaded.
+// The flags of the receiver are kept, the other file sections are not reloaded.
+// Only the file layer of the receiver FileConfig is modified.
+func (c *Config) Reload(ctx context.Context, cmd *cobra.Command) (*Config, error) {
+	if c.FileConfig.ConfigFileUsed() == "" {
+		return nil, errors.New("no configuration file to reload")
+	}
+	configBytes, err := utils.ReadFile(ctx, c.FileConfig.ConfigFileUsed())
+	if err != nil {
+		return nil, err
+	}
+	err = c.FileConfig.ReadConfig(bytes.NewBuffer(configBytes))
+	if err != nil {
+		return nil, err
+	}
+	err = c.interpolate(ctx, configBytes)
+	if err != nil {
+		return nil, err
+	}
+	nc := New()
+	nc.GlobalFlags = c.GlobalFlags
+	nc.LocalFlags = c.LocalFlags
+	nc.FileConfig = c.FileConfig
+	nc.logger = c.logger
+
+	_, err = nc.GetTargets()
+	if err != nil && !errors.Is(err, ErrNoTargetsFound) {
+		return nil, err
+	}
+	_, err = nc.GetSubscriptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+	// the defaults are set on the running subscriptions
+	// when their subscribe request is created.
+	for _, sc := range nc.Subscriptions {
+		err = setDefaults(sc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = nc.GetOutputs()
+	if err != nil {
+		return nil, err
+	}
+	_, err = nc.GetEventProcessors()
+	if err != nil {
+		return nil, err
+	}
+	return nc, nil
+}
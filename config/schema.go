@@ -0,0 +1,332 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openconfig/gnmic/actions"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/inputs"
+	"github.com/openconfig/gnmic/loaders"
+	"github.com/openconfig/gnmic/lockers"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+type schemaKind int
+
+const (
+	schemaAny schemaKind = iota
+	// a set of known keys
+	schemaObject
+	// arbitrary keys, all values described by elem
+	schemaMap
+	schemaList
+	schemaString
+	schemaBool
+	schemaInt
+	schemaFloat
+	schemaDuration
+)
+
+// schemaNode describes the expected shape of a configuration value.
+// It is derived from the mapstructure tags of the structs
+// the configuration is decoded into.
+type schemaNode struct {
+	kind   schemaKind
+	fields map[string]*schemaNode
+	elem   *schemaNode
+	// plugin sections (outputs, inputs, loader,...) select
+	// their fields based on the value of the "type" key.
+	// a nil variant accepts any key.
+	variants map[string]*schemaNode
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// schemaFromType builds a schemaNode from a Go type,
+// following the rules mapstructure uses to decode into it.
+func schemaFromType(t reflect.Type) *schemaNode {
+	return schemaFromTypeSeen(t, map[reflect.Type]bool{})
+}
+
+func schemaFromTypeSeen(t reflect.Type, seen map[reflect.Type]bool) *schemaNode {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == durationType {
+		return &schemaNode{kind: schemaDuration}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schemaNode{kind: schemaBool}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schemaNode{kind: schemaInt}
+	case reflect.Float32, reflect.Float64:
+		return &schemaNode{kind: schemaFloat}
+	case reflect.String:
+		return &schemaNode{kind: schemaString}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &schemaNode{kind: schemaString}
+		}
+		return &schemaNode{kind: schemaList, elem: schemaFromTypeSeen(t.Elem(), seen)}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return &schemaNode{kind: schemaAny}
+		}
+		return &schemaNode{kind: schemaMap, elem: schemaFromTypeSeen(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return &schemaNode{kind: schemaAny}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		fields := make(map[string]*schemaNode)
+		if !structFields(t, fields, seen) || len(fields) == 0 {
+			return &schemaNode{kind: schemaAny}
+		}
+		return &schemaNode{kind: schemaObject, fields: fields}
+	}
+	return &schemaNode{kind: schemaAny}
+}
+
+// structFields adds the fields of struct t to fields.
+// It returns false if the struct accepts arbitrary keys.
+func structFields(t reflect.Type, fields map[string]*schemaNode, seen map[reflect.Type]bool) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("mapstructure")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "remain") {
+			return false
+		}
+		if strings.Contains(opts, "squash") {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !structFields(ft, fields, seen) {
+				return false
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = schemaFromTypeSeen(f.Type, seen)
+	}
+	return true
+}
+
+// pluginSchema returns the schema of a plugin section,
+// the fields of each plugin type are taken from the struct
+// held in its "Cfg" (or "cfg") field when it has one.
+func pluginSchema(typeNames []string, instance func(string) interface{}) *schemaNode {
+	n := &schemaNode{
+		kind:     schemaObject,
+		fields:   map[string]*schemaNode{"type": {kind: schemaString}},
+		variants: make(map[string]*schemaNode, len(typeNames)),
+	}
+	for _, typ := range typeNames {
+		n.variants[typ] = nil
+		if instance == nil {
+			continue
+		}
+		p := instance(typ)
+		if p == nil {
+			continue
+		}
+		t := reflect.TypeOf(p)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			continue
+		}
+		for _, fn := range []string{"Cfg", "cfg"} {
+			f, ok := t.FieldByName(fn)
+			if !ok {
+				continue
+			}
+			vn := schemaFromType(f.Type)
+			if vn.kind == schemaObject {
+				n.variants[typ] = vn
+			}
+			break
+		}
+	}
+	return n
+}
+
+// configSchema returns the schema of the configuration file.
+// extraKeys are additional top level keys, typically the
+// names of the commands local flags.
+func configSchema(extraKeys ...string) *schemaNode {
+	root := schemaFromType(reflect.TypeOf(Config{}))
+	if root.fields == nil {
+		root.fields = make(map[string]*schemaNode)
+	}
+	for _, k := range extraKeys {
+		k = strings.ToLower(k)
+		if _, ok := root.fields[k]; !ok {
+			root.fields[k] = &schemaNode{kind: schemaAny}
+		}
+	}
+
+	outputTypes := make([]string, 0, len(outputs.OutputTypes))
+	for typ := range outputs.OutputTypes {
+		outputTypes = append(outputTypes, typ)
+	}
+	sort.Strings(outputTypes)
+	root.fields["outputs"] = &schemaNode{kind: schemaMap, elem: pluginSchema(outputTypes,
+		func(typ string) interface{} {
+			if in, ok := outputs.Outputs[typ]; ok {
+				return in()
+			}
+			return nil
+		})}
+	root.fields["inputs"] = &schemaNode{kind: schemaMap, elem: pluginSchema(inputs.InputTypes,
+		func(typ string) interface{} {
+			if in, ok := inputs.Inputs[typ]; ok {
+				return in()
+			}
+			return nil
+		})}
+	root.fields["loader"] = pluginSchema(loaders.LoadersTypes,
+		func(typ string) interface{} {
+			if in, ok := loaders.Loaders[typ]; ok {
+				return in()
+			}
+			return nil
+		})
+	root.fields["actions"] = &schemaNode{kind: schemaMap, elem: pluginSchema(actions.ActionTypes, nil)}
+
+	if cl := root.fields["clustering"]; cl != nil && cl.kind == schemaObject {
+		cl.fields["locker"] = pluginSchema(lockers.LockerTypes,
+			func(typ string) interface{} {
+				if in, ok := lockers.Lockers[typ]; ok {
+					return in()
+				}
+				return nil
+			})
+	}
+	// a processor is a map with a single key: its type.
+	procs := &schemaNode{kind: schemaObject, fields: make(map[string]*schemaNode)}
+	for _, typ := range formatters.EventProcessorTypes {
+		in, ok := formatters.EventProcessors[typ]
+		if !ok {
+			procs.fields[typ] = &schemaNode{kind: schemaAny}
+			continue
+		}
+		procs.fields[typ] = schemaFromType(reflect.TypeOf(in()))
+	}
+	root.fields["processors"] = &schemaNode{kind: schemaMap, elem: procs}
+	// targets can be defined without a body
+	root.fields["targets"] = &schemaNode{kind: schemaMap, elem: schemaFromType(reflect.TypeOf(types.TargetConfig{}))}
+	return root
+}
+
+// JSONSchema returns a JSON schema (draft-07) describing the configuration file.
+// extraKeys are additional top level keys, typically the names of the commands local flags.
+func JSONSchema(extraKeys ...string) ([]byte, error) {
+	s := configSchema(extraKeys...).jsonSchema()
+	s["$schema"] = jsonSchemaDraft
+	s["title"] = "gNMIc configuration file"
+	return json.MarshalIndent(s, "", "  ")
+}
+
+func (n *schemaNode) jsonSchema() map[string]interface{} {
+	switch n.kind {
+	case schemaObject:
+		props := make(map[string]interface{}, len(n.fields))
+		for k, f := range n.fields {
+			props[k] = f.jsonSchema()
+		}
+		s := map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"properties":           props,
+			"additionalProperties": false,
+		}
+		if n.variants == nil {
+			return s
+		}
+		typeNames := make([]string, 0, len(n.variants))
+		for typ := range n.variants {
+			typeNames = append(typeNames, typ)
+		}
+		sort.Strings(typeNames)
+		props["type"] = map[string]interface{}{"enum": typeNames}
+		s["required"] = []string{"type"}
+		conds := make([]interface{}, 0, len(typeNames))
+		for _, typ := range typeNames {
+			v := n.variants[typ]
+			if v == nil {
+				continue
+			}
+			vs := v.jsonSchema()
+			vprops := vs["properties"].(map[string]interface{})
+			vprops["type"] = map[string]interface{}{"const": typ}
+			conds = append(conds, map[string]interface{}{
+				"if":   map[string]interface{}{"properties": map[string]interface{}{"type": map[string]interface{}{"const": typ}}},
+				"then": vs,
+			})
+		}
+		// the accepted keys depend on the type
+		delete(s, "additionalProperties")
+		if len(conds) > 0 {
+			s["allOf"] = conds
+		}
+		return s
+	case schemaMap:
+		return map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"additionalProperties": n.elem.jsonSchema(),
+		}
+	case schemaList:
+		// a single value is accepted where a list is expected
+		return map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"type": "array", "items": n.elem.jsonSchema()},
+				n.elem.jsonSchema(),
+			},
+		}
+	case schemaString:
+		return map[string]interface{}{"type": []string{"string", "number", "boolean", "null"}}
+	case schemaBool:
+		return map[string]interface{}{"type": []string{"boolean", "string", "null"}}
+	case schemaInt:
+		return map[string]interface{}{"type": []string{"integer", "string", "null"}}
+	case schemaFloat:
+		return map[string]interface{}{"type": []string{"number", "string", "null"}}
+	case schemaDuration:
+		return map[string]interface{}{
+			"type":        []string{"string", "integer", "null"},
+			"description": "duration, e.g: 10s, 1m30s, 1h",
+		}
+	}
+	return map[string]interface{}{}
+}
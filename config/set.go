@@ -124,59 +124,65 @@ func (c *Config) CreateSetRequestFromFile(targetName string) ([]*gnmi.SetRequest
 		if err != nil {
 			return nil, err
 		}
-		gnmiOpts := make([]api.GNMIOption, 0)
-		buf.Reset()
-		for _, upd := range reqFile.Updates {
-			if upd.Path == "" {
-				upd.Path = "/"
-			}
+		setReq, err := c.setRequestFromFile(reqFile)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, setReq)
+	}
+	return reqs, nil
+}
+
+// setRequestFromFile creates a SetRequest from a rendered set request file.
+func (c *Config) setRequestFromFile(reqFile *SetRequestFile) (*gnmi.SetRequest, error) {
+	var err error
+	buf := new(bytes.Buffer)
+	gnmiOpts := make([]api.GNMIOption, 0)
+	for _, upd := range reqFile.Updates {
+		if upd.Path == "" {
+			upd.Path = "/"
+		}
 
-			enc := upd.Encoding
-			if enc == "" {
-				enc = c.GlobalFlags.Encoding
-			}
-			buf.Reset()
-			err = json.NewEncoder(buf).Encode(convert(upd.Value))
-			if err != nil {
-				return nil, err
-			}
-			gnmiOpts = append(gnmiOpts,
-				api.Update(
-					api.Path(strings.TrimSpace(upd.Path)),
-					api.Value(strings.TrimSpace(buf.String()), enc),
-				),
-			)
+		enc := upd.Encoding
+		if enc == "" {
+			enc = c.GlobalFlags.Encoding
 		}
-		for _, upd := range reqFile.Replaces {
-			if upd.Path == "" {
-				upd.Path = "/"
-			}
-			enc := upd.Encoding
-			if enc == "" {
-				enc = c.GlobalFlags.Encoding
-			}
-			buf.Reset()
-			err = json.NewEncoder(buf).Encode(convert(upd.Value))
-			if err != nil {
-				return nil, err
-			}
-			gnmiOpts = append(gnmiOpts, api.Replace(
+		buf.Reset()
+		err = json.NewEncoder(buf).Encode(convert(upd.Value))
+		if err != nil {
+			return nil, err
+		}
+		gnmiOpts = append(gnmiOpts,
+			api.Update(
 				api.Path(strings.TrimSpace(upd.Path)),
 				api.Value(strings.TrimSpace(buf.String()), enc),
 			),
-			)
+		)
+	}
+	for _, upd := range reqFile.Replaces {
+		if upd.Path == "" {
+			upd.Path = "/"
 		}
-		for _, s := range reqFile.Deletes {
-			gnmiOpts = append(gnmiOpts, api.Delete(strings.TrimSpace(s)))
+		enc := upd.Encoding
+		if enc == "" {
+			enc = c.GlobalFlags.Encoding
 		}
-
-		setReq, err := api.NewSetRequest(gnmiOpts...)
+		buf.Reset()
+		err = json.NewEncoder(buf).Encode(convert(upd.Value))
 		if err != nil {
 			return nil, err
 		}
-		reqs = append(reqs, setReq)
+		gnmiOpts = append(gnmiOpts, api.Replace(
+			api.Path(strings.TrimSpace(upd.Path)),
+			api.Value(strings.TrimSpace(buf.String()), enc),
+		),
+		)
 	}
-	return reqs, nil
+	for _, s := range reqFile.Deletes {
+		gnmiOpts = append(gnmiOpts, api.Delete(strings.TrimSpace(s)))
+	}
+
+	return api.NewSetRequest(gnmiOpts...)
 }
 
 type templateInput struct {
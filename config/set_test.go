@@ -30,7 +30,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				Encoding: "json",
 			},
 			LocalFlags{},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 			[]*template.Template{
 				template.Must(template.New("set-request").Parse(`{
 				"updates": [
@@ -41,6 +41,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				]
 			}`))},
 			nil,
+			nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Update: []*gnmi.Update{
@@ -67,7 +68,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				Encoding: "json",
 			},
 			LocalFlags{},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 			[]*template.Template{
 				template.Must(template.New("set-request").Parse(`{
 				"replaces": [
@@ -78,6 +79,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				]
 			}`))},
 			nil,
+			nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Replace: []*gnmi.Update{
@@ -104,7 +106,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				Encoding: "json",
 			},
 			LocalFlags{},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 			[]*template.Template{
 				template.Must(template.New("set-request").Parse(`{
 				"deletes": [
@@ -112,6 +114,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				]
 			}`))},
 			nil,
+			nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Delete: []*gnmi.Path{
@@ -131,7 +134,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				Encoding: "json",
 			},
 			LocalFlags{},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 			[]*template.Template{
 				template.Must(template.New("set-request").Parse(`{
 				"updates": [
@@ -147,6 +150,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				]
 			}`))},
 			nil,
+			nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Update: []*gnmi.Update{
@@ -186,7 +190,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				Encoding: "json",
 			},
 			LocalFlags{},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 			[]*template.Template{
 				template.Must(template.New("set-request").Parse(`{
 				"replaces": [
@@ -202,6 +206,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				]
 			}`))},
 			nil,
+			nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Replace: []*gnmi.Update{
@@ -241,7 +246,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				Encoding: "json",
 			},
 			LocalFlags{},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 			[]*template.Template{
 				template.Must(template.New("set-request").Parse(`{
 				"deletes": [
@@ -250,6 +255,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				]
 			}`))},
 			nil,
+			nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Delete: []*gnmi.Path{
@@ -275,7 +281,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				Encoding: "json",
 			},
 			LocalFlags{},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 			[]*template.Template{template.Must(template.New("set-request").Parse(`{
 				"updates": [
 					{
@@ -294,6 +300,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				]
 			}`))},
 			nil,
+			nil, nil,
 		},
 		out: &gnmi.SetRequest{
 			Update: []*gnmi.Update{
@@ -343,7 +350,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 				Encoding: "json",
 			},
 			LocalFlags{},
-			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 			[]*template.Template{
 				template.Must(template.New("set-request").Parse(`replaces:
 {{- range $interface := index .Vars .TargetName "interfaces" }}
@@ -376,6 +383,7 @@ var createSetRequestFromFileTestSet = map[string]struct {
 					},
 				},
 			},
+			nil, nil,
 		},
 		targetName: "target1",
 		out: &gnmi.SetRequest{
@@ -0,0 +1,25 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+
+	"github.com/openconfig/gnmic/spiffe"
+)
+
+func (c *Config) GetSPIFFE() error {
+	if !c.FileConfig.IsSet("spiffe") {
+		return nil
+	}
+	c.Spiffe = new(spiffe.Config)
+	c.Spiffe.SocketPath = os.ExpandEnv(c.FileConfig.GetString("spiffe/socket-path"))
+	c.Spiffe.Timeout = c.FileConfig.GetDuration("spiffe/timeout")
+	return c.Spiffe.SetDefaults()
+}
@@ -27,6 +27,8 @@ const (
 	subscriptionDefaultMode       = "STREAM"
 	subscriptionDefaultStreamMode = "TARGET_DEFINED"
 	subscriptionDefaultEncoding   = "JSON"
+	// interval between two Get RPCs of a get-poll subscription
+	subscriptionDefaultGetPollInterval = 30 * time.Second
 )
 
 func (c *Config) GetSubscriptions(cmd *cobra.Command) (map[string]*types.SubscriptionConfig, error) {
@@ -56,6 +58,7 @@ func (c *Config) GetSubscriptions(cmd *cobra.Command) (map[string]*types.Subscri
 		sub.SuppressRedundant = c.LocalFlags.SubscribeSuppressRedundant
 		sub.UpdatesOnly = c.LocalFlags.SubscribeUpdatesOnly
 		sub.Models = c.LocalFlags.SubscribeModel
+		sub.Depth = c.LocalFlags.SubscribeDepth
 		if flagIsSet(cmd, "history-snapshot") {
 			sub.History = &types.HistoryConfig{
 				Snapshot: c.LocalFlags.SubscribeHistorySnapshot,
@@ -150,6 +153,9 @@ func (c *Config) setSubscriptionDefaults(sub *types.SubscriptionConfig, cmd *cob
 	if sub.Qos == nil && flagIsSet(cmd, "qos") {
 		sub.Qos = &c.LocalFlags.SubscribeQos
 	}
+	if sub.Depth == 0 && flagIsSet(cmd, "depth") {
+		sub.Depth = c.LocalFlags.SubscribeDepth
+	}
 	if sub.History == nil && flagIsSet(cmd, "history-snapshot") {
 		sub.History = &types.HistoryConfig{
 			Snapshot: c.LocalFlags.SubscribeHistorySnapshot,
@@ -165,6 +171,25 @@ func (c *Config) setSubscriptionDefaults(sub *types.SubscriptionConfig, cmd *cob
 	}
 }
 
+// ValidateDynamicSubscription sets the defaults of a subscription added or
+// modified at runtime (e.g via the API) and checks that it can be started
+// next to the running ones: only STREAM and get-poll subscriptions are allowed.
+func (c *Config) ValidateDynamicSubscription(sc *types.SubscriptionConfig) error {
+	if sc.Name == "" {
+		return errors.New("missing subscription name")
+	}
+	c.setSubscriptionDefaults(sc, nil)
+	expandSubscriptionEnv(sc)
+	err := setDefaults(sc)
+	if err != nil {
+		return err
+	}
+	if strings.ToUpper(sc.Mode) != "STREAM" && !sc.IsGetPoll() {
+		return fmt.Errorf("subscription %q: only STREAM and get-poll subscriptions can be changed at runtime, got mode %q", sc.Name, sc.Mode)
+	}
+	return validateSubscription(sc)
+}
+
 func (c *Config) GetSubscriptionsFromFile() []*types.SubscriptionConfig {
 	subs, err := c.GetSubscriptions(nil)
 	if err != nil {
@@ -201,6 +226,10 @@ func (*Config) CreateSubscribeRequest(sc *types.SubscriptionConfig, target strin
 			gnmiOpts = append(gnmiOpts, api.Extension_HistoryRange(sc.History.Start, sc.History.End))
 		}
 	}
+	// depth extension
+	if sc.Depth > 0 {
+		gnmiOpts = append(gnmiOpts, api.Extension_Depth(sc.Depth))
+	}
 	if sc.Qos != nil {
 		gnmiOpts = append(gnmiOpts, api.Qos(*sc.Qos))
 	}
@@ -247,6 +276,36 @@ func (*Config) CreateSubscribeRequest(sc *types.SubscriptionConfig, target strin
 	return api.NewSubscribeRequest(gnmiOpts...)
 }
 
+// CreateGetPollRequest returns the GetRequest periodically sent
+// to target by the get-poll subscription sc.
+func (*Config) CreateGetPollRequest(sc *types.SubscriptionConfig, target string) (*gnmi.GetRequest, error) {
+	err := setDefaults(sc)
+	if err != nil {
+		return nil, err
+	}
+	if !sc.IsGetPoll() {
+		return nil, fmt.Errorf("subscription %q is not a get-poll subscription", sc.Name)
+	}
+	gnmiOpts := make([]api.GNMIOption, 0, 3+len(sc.Paths)+len(sc.Models))
+	gnmiOpts = append(gnmiOpts,
+		api.Prefix(sc.Prefix),
+		api.Encoding(sc.Encoding),
+		api.DataTypeALL(),
+	)
+	if sc.Target != "" {
+		gnmiOpts = append(gnmiOpts, api.Target(sc.Target))
+	} else if sc.SetTarget {
+		gnmiOpts = append(gnmiOpts, api.Target(target))
+	}
+	for _, p := range sc.Paths {
+		gnmiOpts = append(gnmiOpts, api.Path(p))
+	}
+	for _, m := range sc.Models {
+		gnmiOpts = append(gnmiOpts, api.UseModel(m, "", ""))
+	}
+	return api.NewGetRequest(gnmiOpts...)
+}
+
 func setDefaults(sc *types.SubscriptionConfig) error {
 	if len(sc.Paths) == 0 {
 		return fmt.Errorf("missing path(s) in subscription '%s'", sc.Name)
@@ -257,6 +316,10 @@ func setDefaults(sc *types.SubscriptionConfig) error {
 	if strings.ToUpper(sc.Mode) == "STREAM" && sc.StreamMode == "" {
 		sc.StreamMode = subscriptionDefaultStreamMode
 	}
+	if sc.IsGetPoll() && (sc.SampleInterval == nil || *sc.SampleInterval <= 0) {
+		interval := subscriptionDefaultGetPollInterval
+		sc.SampleInterval = &interval
+	}
 	if sc.Encoding == "" {
 		sc.Encoding = subscriptionDefaultEncoding
 	}
@@ -267,7 +330,12 @@ func validateSubscriptionsConfig(subs map[string]*types.SubscriptionConfig) erro
 	var hasPoll bool
 	var hasOnce bool
 	var hasStream bool
+	var hasGetPoll bool
 	for _, sc := range subs {
+		err := validateSubscription(sc)
+		if err != nil {
+			return err
+		}
 		switch strings.ToUpper(sc.Mode) {
 		case "POLL":
 			hasPoll = true
@@ -275,11 +343,42 @@ func validateSubscriptionsConfig(subs map[string]*types.SubscriptionConfig) erro
 			hasOnce = true
 		case "STREAM":
 			hasStream = true
+		case "GET-POLL":
+			hasGetPoll = true
 		}
 	}
 	if hasPoll && hasOnce || hasPoll && hasStream {
 		return errors.New("subscriptions with mode Poll cannot be mixed with Stream or Once")
 	}
+	if hasGetPoll && (hasPoll || hasOnce) {
+		return errors.New("subscriptions with mode Get-Poll cannot be mixed with Poll or Once")
+	}
+	return nil
+}
+
+// validateSubscription checks the subscription overrides, schedule and timestamp options.
+func validateSubscription(sc *types.SubscriptionConfig) error {
+	if sc.Schedule != nil {
+		err := sc.Schedule.Validate()
+		if err != nil {
+			return fmt.Errorf("subscription %q: %v", sc.Name, err)
+		}
+	}
+	if sc.Timestamp != nil {
+		err := sc.Timestamp.Validate()
+		if err != nil {
+			return fmt.Errorf("subscription %q: %v", sc.Name, err)
+		}
+	}
+	for i, o := range sc.Overrides {
+		if o == nil {
+			continue
+		}
+		err := o.Validate()
+		if err != nil {
+			return fmt.Errorf("subscription %q: override %d: %v", sc.Name, i, err)
+		}
+	}
 	return nil
 }
 
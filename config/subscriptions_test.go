@@ -16,6 +16,7 @@ import (
 	"strings"
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmi/proto/gnmi_ext"
@@ -639,3 +640,284 @@ func TestConfig_CreateSubscribeRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscriptionOverrides(t *testing.T) {
+	in := []byte(`
+subscriptions:
+  interfaces:
+    paths:
+      - /interfaces/interface/state/counters
+    stream-mode: sample
+    sample-interval: 5s
+    overrides:
+      - vars:
+          role: cpe
+        sample-interval: 60s
+        suppress-redundant: true
+      - targets:
+          - cpe-lab-.*
+        stream-mode: on_change
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	subs, err := cfg.GetSubscriptions(nil)
+	if err != nil {
+		t.Fatalf("failed getting subscriptions: %v", err)
+	}
+	sc := subs["interfaces"]
+	if len(sc.Overrides) != 2 {
+		t.Fatalf("expected 2 overrides, got %d", len(sc.Overrides))
+	}
+	tests := []struct {
+		tc                *types.TargetConfig
+		streamMode        string
+		sampleInterval    string
+		suppressRedundant bool
+	}{
+		{
+			tc:             &types.TargetConfig{Name: "core1", Vars: map[string]string{"role": "core"}},
+			streamMode:     "sample",
+			sampleInterval: "5s",
+		},
+		{
+			tc:                &types.TargetConfig{Name: "cpe1", Vars: map[string]string{"role": "cpe"}},
+			streamMode:        "sample",
+			sampleInterval:    "1m0s",
+			suppressRedundant: true,
+		},
+		{
+			tc:                &types.TargetConfig{Name: "cpe-lab-1", Vars: map[string]string{"role": "cpe"}},
+			streamMode:        "on_change",
+			sampleInterval:    "1m0s",
+			suppressRedundant: true,
+		},
+		{
+			// the targets expression matches the whole name
+			tc:             &types.TargetConfig{Name: "x-cpe-lab-1"},
+			streamMode:     "sample",
+			sampleInterval: "5s",
+		},
+	}
+	for _, tt := range tests {
+		got := sc.ForTarget(tt.tc)
+		if got.StreamMode != tt.streamMode || got.SampleInterval.String() != tt.sampleInterval || got.SuppressRedundant != tt.suppressRedundant {
+			t.Errorf("target %q: unexpected subscription: stream-mode=%s sample-interval=%s suppress-redundant=%t",
+				tt.tc.Name, got.StreamMode, got.SampleInterval, got.SuppressRedundant)
+		}
+	}
+	if sc.SampleInterval.String() != "5s" || sc.SuppressRedundant {
+		t.Errorf("the subscription config was modified: %v", sc)
+	}
+}
+
+func TestInvalidSubscriptionOverride(t *testing.T) {
+	in := []byte(`
+subscriptions:
+  interfaces:
+    paths:
+      - /interfaces
+    overrides:
+      - targets:
+          - "cpe-("
+        sample-interval: 60s
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	_, err = cfg.GetSubscriptions(nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid override target") {
+		t.Fatalf("expected an invalid override error, got %v", err)
+	}
+}
+
+func TestSubscriptionSchedule(t *testing.T) {
+	in := []byte(`
+subscriptions:
+  interfaces:
+    paths:
+      - /interfaces
+    schedule:
+      timezone: UTC
+      active:
+        - days: [mon-fri]
+          start: "08:00"
+          end: "18:00"
+        - days: [sat]
+          start: "22:00"
+          end: "02:00"
+      inactive:
+        - days: [wed]
+          start: "12:00"
+          end: "13:00"
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	subs, err := cfg.GetSubscriptions(nil)
+	if err != nil {
+		t.Fatalf("failed getting subscriptions: %v", err)
+	}
+	s := subs["interfaces"].Schedule
+	if s == nil {
+		t.Fatal("schedule not decoded")
+	}
+	tests := map[string]bool{
+		"2022-10-17T08:00:00Z": true,  // monday
+		"2022-10-17T18:00:00Z": false, // monday, end of the window
+		"2022-10-19T12:30:00Z": false, // wednesday, inactive window
+		"2022-10-15T12:00:00Z": false, // saturday
+		"2022-10-15T23:00:00Z": true,  // saturday night
+		"2022-10-16T01:59:00Z": true,  // sunday, saturday window
+		"2022-10-16T02:00:00Z": false,
+	}
+	for ts, active := range tests {
+		tm, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.ActiveAt(tm) != active {
+			t.Errorf("%s: expected active=%t", ts, active)
+		}
+	}
+}
+
+func TestInvalidSubscriptionSchedule(t *testing.T) {
+	for _, sched := range []string{
+		"timezone: Mars/Olympus",
+		"active: [{days: [someday], start: '08:00', end: '18:00'}]",
+		"inactive: [{start: '8h', end: '18:00'}]",
+	} {
+		in := []byte("subscriptions:\n  sub1:\n    paths: [/interfaces]\n    schedule:\n      " + sched + "\n")
+		cfg := New()
+		cfg.FileConfig.SetConfigType("yaml")
+		err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+		if err != nil {
+			t.Fatalf("failed reading config: %v", err)
+		}
+		_, err = cfg.GetSubscriptions(nil)
+		if err == nil {
+			t.Errorf("%s: expected an invalid schedule error", sched)
+		}
+	}
+}
+
+func TestGetPollSubscription(t *testing.T) {
+	in := []byte(`
+subscriptions:
+  interfaces:
+    mode: get-poll
+    prefix: /interfaces
+    paths:
+      - interface/state/counters
+    models:
+      - openconfig-interfaces
+    encoding: json_ietf
+    set-target: true
+  system:
+    mode: get-poll
+    paths:
+      - /system
+    sample-interval: 10s
+  bgp:
+    paths:
+      - /network-instances
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	subs, err := cfg.GetSubscriptions(nil)
+	if err != nil {
+		t.Fatalf("failed getting subscriptions: %v", err)
+	}
+	if !subs["interfaces"].IsGetPoll() || subs["bgp"].IsGetPoll() {
+		t.Fatalf("unexpected subscriptions modes: %v", subs)
+	}
+	req, err := cfg.CreateGetPollRequest(subs["interfaces"], "router1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *subs["interfaces"].SampleInterval != subscriptionDefaultGetPollInterval {
+		t.Errorf("expected the default interval, got %s", subs["interfaces"].SampleInterval)
+	}
+	if req.GetEncoding() != gnmi.Encoding_JSON_IETF || req.GetType() != gnmi.GetRequest_ALL ||
+		req.GetPrefix().GetTarget() != "router1" || len(req.GetPrefix().GetElem()) != 1 ||
+		len(req.GetPath()) != 1 || len(req.GetUseModels()) != 1 {
+		t.Errorf("unexpected get request: %v", req)
+	}
+	_, err = cfg.CreateGetPollRequest(subs["system"], "router1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subs["system"].SampleInterval.String() != "10s" {
+		t.Errorf("unexpected interval: %s", subs["system"].SampleInterval)
+	}
+	_, err = cfg.CreateGetPollRequest(subs["bgp"], "router1")
+	if err == nil {
+		t.Error("expected an error creating a get request of a stream subscription")
+	}
+
+	in = []byte("subscriptions:\n  sub1:\n    mode: get-poll\n    paths: [/system]\n  sub2:\n    mode: once\n    paths: [/interfaces]\n")
+	cfg = New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err = cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	_, err = cfg.GetSubscriptions(nil)
+	if err == nil || !strings.Contains(err.Error(), "Get-Poll") {
+		t.Errorf("expected a mixed modes error, got %v", err)
+	}
+}
+
+func TestSubscriptionTimestamp(t *testing.T) {
+	in := []byte(`
+subscriptions:
+  interfaces:
+    paths:
+      - /interfaces
+    timestamp:
+      offset: -1s
+      max-skew: 24h
+      record: true
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	subs, err := cfg.GetSubscriptions(nil)
+	if err != nil {
+		t.Fatalf("failed getting subscriptions: %v", err)
+	}
+	want := &types.TimestampConfig{Offset: -time.Second, MaxSkew: 24 * time.Hour, Record: true}
+	if !reflect.DeepEqual(subs["interfaces"].Timestamp, want) {
+		t.Errorf("unexpected timestamp config: %+v", subs["interfaces"].Timestamp)
+	}
+	for _, ts := range []string{"source: gps", "max-skew: -1h"} {
+		in := []byte("subscriptions:\n  sub1:\n    paths: [/interfaces]\n    timestamp:\n      " + ts + "\n")
+		cfg := New()
+		cfg.FileConfig.SetConfigType("yaml")
+		err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+		if err != nil {
+			t.Fatalf("failed reading config: %v", err)
+		}
+		_, err = cfg.GetSubscriptions(nil)
+		if err == nil {
+			t.Errorf("%s: expected an error", ts)
+		}
+	}
+}
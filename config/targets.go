@@ -63,6 +63,48 @@ func (c *Config) GetTargets() (map[string]*types.TargetConfig, error) {
 		return c.Targets, nil
 	}
 	// case targets is defined in config file
+	_, err = c.GetTargetsFromFile()
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.GetTargetGroups()
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Group) > 0 {
+		err = c.filterTargetsByGroups(c.Group)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	subNames := c.FileConfig.GetStringSlice("subscribe-name")
+	if len(subNames) == 0 {
+		if c.Debug {
+			c.logger.Printf("targets: %v", c.Targets)
+		}
+		return c.Targets, nil
+	}
+	for n := range c.Targets {
+		c.Targets[n].Subscriptions = subNames
+	}
+	if c.Debug {
+		c.logger.Printf("targets: %v", c.Targets)
+	}
+	return c.Targets, nil
+}
+
+// GetTargetsFromFile reads the targets configuration from the config file "targets" section,
+// ignoring the targets set using the address flag.
+func (c *Config) GetTargetsFromFile() (map[string]*types.TargetConfig, error) {
+	_, err := c.GetConnectionProfiles()
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.GetCredentialsProviders()
+	if err != nil {
+		return nil, err
+	}
 	targetsInt := c.FileConfig.Get("targets")
 	targetsMap := make(map[string]interface{})
 	switch targetsInt := targetsInt.(type) {
@@ -86,6 +128,14 @@ func (c *Config) GetTargets() (map[string]*types.TargetConfig, error) {
 		tc := new(types.TargetConfig)
 		switch t := t.(type) {
 		case map[string]interface{}:
+			// the addresses can be listed instead of comma separated
+			if addrs, ok := t["address"].([]interface{}); ok {
+				sAddrs := make([]string, 0, len(addrs))
+				for _, addr := range addrs {
+					sAddrs = append(sAddrs, fmt.Sprint(addr))
+				}
+				t["address"] = strings.Join(sAddrs, ",")
+			}
 			decoder, err := mapstructure.NewDecoder(
 				&mapstructure.DecoderConfig{
 					DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
@@ -132,20 +182,6 @@ func (c *Config) GetTargets() (map[string]*types.TargetConfig, error) {
 		newTargetsConfig[name] = tc
 	}
 	c.Targets = newTargetsConfig
-
-	subNames := c.FileConfig.GetStringSlice("subscribe-name")
-	if len(subNames) == 0 {
-		if c.Debug {
-			c.logger.Printf("targets: %v", c.Targets)
-		}
-		return c.Targets, nil
-	}
-	for n := range c.Targets {
-		c.Targets[n].Subscriptions = subNames
-	}
-	if c.Debug {
-		c.logger.Printf("targets: %v", c.Targets)
-	}
 	return c.Targets, nil
 }
 
@@ -169,6 +205,10 @@ func readPassword() (string, error) {
 }
 
 func (c *Config) SetTargetConfigDefaults(tc *types.TargetConfig) error {
+	err := c.applyConnectionProfile(tc)
+	if err != nil {
+		return err
+	}
 	defGrpcPort := c.FileConfig.GetString("port")
 	if !strings.HasPrefix(tc.Address, "unix://") {
 		addrList := strings.Split(tc.Address, ",")
@@ -225,6 +265,9 @@ func (c *Config) SetTargetConfigDefaults(tc *types.TargetConfig) error {
 	if tc.RetryTimer == 0 {
 		tc.RetryTimer = c.Retry
 	}
+	if tc.Proxy == "" {
+		tc.Proxy = c.Proxy
+	}
 	if tc.TLSVersion == "" {
 		tc.TLSVersion = c.TLSVersion
 	}
@@ -243,6 +286,61 @@ func (c *Config) SetTargetConfigDefaults(tc *types.TargetConfig) error {
 	if tc.BufferSize == 0 {
 		tc.BufferSize = defaultTargetBufferSize
 	}
+	if tc.RPCRetry == nil && c.RPCRetries > 0 {
+		tc.RPCRetry = &types.RPCRetry{
+			Count:   c.RPCRetries,
+			Backoff: c.RPCRetryBackoff,
+		}
+	}
+	if tc.RPCRetry != nil {
+		err = tc.RPCRetry.Validate()
+		if err != nil {
+			return fmt.Errorf("target %q: %v", tc.Name, err)
+		}
+	}
+	switch tc.DialStrategy {
+	case "", types.DialStrategyOrdered:
+	case types.DialStrategyHappyEyeballs:
+		if tc.DialDelay <= 0 {
+			tc.DialDelay = types.DefaultDialDelay
+		}
+	default:
+		return fmt.Errorf("target %q: unknown dial-strategy %q, must be one of %q", tc.Name, tc.DialStrategy,
+			[]string{types.DialStrategyOrdered, types.DialStrategyHappyEyeballs})
+	}
+	if tc.ReachabilityProbe != nil {
+		err = tc.ReachabilityProbe.Validate()
+		if err != nil {
+			return fmt.Errorf("target %q: %v", tc.Name, err)
+		}
+	}
+	if tc.Resolver != nil {
+		err = tc.Resolver.Validate()
+		if err != nil {
+			return fmt.Errorf("target %q: %v", tc.Name, err)
+		}
+	}
+	if tc.TokenAuth != nil {
+		err = tc.TokenAuth.Validate()
+		if err != nil {
+			return fmt.Errorf("target %q: %v", tc.Name, err)
+		}
+	}
+	if tc.RPCRate < 0 || tc.RPCBurst < 0 {
+		return fmt.Errorf("target %q: rpc-rate and rpc-burst must not be negative", tc.Name)
+	}
+	if tc.Vendor == "" {
+		tc.Vendor = c.Vendor
+	}
+	if tc.Vendor != "" {
+		vp, err := types.GetVendorProfile(tc.Vendor)
+		if err != nil {
+			return fmt.Errorf("target %q: %v", tc.Name, err)
+		}
+		if len(tc.EncodingPreference) == 0 {
+			tc.EncodingPreference = append(tc.EncodingPreference, vp.EncodingPreference...)
+		}
+	}
 	return nil
 }
 
@@ -288,6 +386,14 @@ func expandCertPaths(tc *types.TargetConfig) error {
 func expandTargetEnv(tc *types.TargetConfig) {
 	tc.Name = os.ExpandEnv(tc.Name)
 	tc.Address = os.ExpandEnv(tc.Address)
+	tc.Proxy = os.ExpandEnv(tc.Proxy)
+	if tc.SSHTunnel != nil {
+		tc.SSHTunnel.Address = os.ExpandEnv(tc.SSHTunnel.Address)
+		tc.SSHTunnel.Username = os.ExpandEnv(tc.SSHTunnel.Username)
+		tc.SSHTunnel.Password = os.ExpandEnv(tc.SSHTunnel.Password)
+		tc.SSHTunnel.PrivateKey = os.ExpandEnv(tc.SSHTunnel.PrivateKey)
+		tc.SSHTunnel.Passphrase = os.ExpandEnv(tc.SSHTunnel.Passphrase)
+	}
 	if tc.Username != nil {
 		*tc.Username = os.ExpandEnv(*tc.Username)
 	}
@@ -314,9 +420,30 @@ func expandTargetEnv(tc *types.TargetConfig) {
 	for i := range tc.Outputs {
 		tc.Outputs[i] = os.ExpandEnv(tc.Outputs[i])
 	}
+	tc.SpiffeID = os.ExpandEnv(tc.SpiffeID)
 	tc.TLSMinVersion = os.ExpandEnv(tc.TLSMinVersion)
 	tc.TLSMaxVersion = os.ExpandEnv(tc.TLSMaxVersion)
 	tc.TLSVersion = os.ExpandEnv(tc.TLSVersion)
+	if tc.Resolver != nil {
+		for i := range tc.Resolver.Servers {
+			tc.Resolver.Servers[i] = os.ExpandEnv(tc.Resolver.Servers[i])
+		}
+	}
+	if tc.TokenAuth != nil {
+		tc.TokenAuth.Token = os.ExpandEnv(tc.TokenAuth.Token)
+		tc.TokenAuth.TokenURL = os.ExpandEnv(tc.TokenAuth.TokenURL)
+		tc.TokenAuth.ClientID = os.ExpandEnv(tc.TokenAuth.ClientID)
+		tc.TokenAuth.ClientSecret = os.ExpandEnv(tc.TokenAuth.ClientSecret)
+		for k, v := range tc.TokenAuth.Params {
+			tc.TokenAuth.Params[k] = os.ExpandEnv(v)
+		}
+	}
+	for i := range tc.TLSCipherSuites {
+		tc.TLSCipherSuites[i] = os.ExpandEnv(tc.TLSCipherSuites[i])
+	}
+	for i := range tc.TLSCurvePreferences {
+		tc.TLSCurvePreferences[i] = os.ExpandEnv(tc.TLSCurvePreferences[i])
+	}
 	for i := range tc.ProtoFiles {
 		tc.ProtoFiles[i] = os.ExpandEnv(tc.ProtoFiles[i])
 	}
@@ -336,9 +463,13 @@ func (c *Config) GetDiffTargets() (*types.TargetConfig, map[string]*types.Target
 		}
 	}
 	var refConfig *types.TargetConfig
-	if rc, ok := targetsConfig[c.DiffRef]; ok {
+	rc, ok := targetsConfig[c.DiffRef]
+	switch {
+	case c.DiffRef == "":
+		// the reference is a snapshot file
+	case ok:
 		refConfig = rc
-	} else {
+	default:
 		refConfig = &types.TargetConfig{
 			Name:    c.DiffRef,
 			Address: c.DiffRef,
@@ -12,8 +12,10 @@ import (
 	"bytes"
 	"os"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/AlekSi/pointer"
 	"github.com/openconfig/gnmic/types"
@@ -336,3 +338,196 @@ func TestGetTargets(t *testing.T) {
 		})
 	}
 }
+
+func TestTargetVendor(t *testing.T) {
+	in := []byte(`
+port: 57400
+connection-profiles:
+  junos:
+    vendor: juniper
+targets:
+  router1:
+    connection-profile: junos
+  router2:
+    vendor: Cisco
+    encoding-preference:
+      - json
+  router3:
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	tcs, err := cfg.GetTargetsFromFile()
+	if err != nil {
+		t.Fatalf("failed getting targets: %v", err)
+	}
+	if tcs["router1"].Vendor != "juniper" {
+		t.Errorf("router1: expected the vendor from the connection profile, got %q", tcs["router1"].Vendor)
+	}
+	if !reflect.DeepEqual(tcs["router1"].EncodingPreference, types.VendorProfiles["juniper"].EncodingPreference) {
+		t.Errorf("router1: expected the vendor encoding preference, got %v", tcs["router1"].EncodingPreference)
+	}
+	if !reflect.DeepEqual(tcs["router2"].EncodingPreference, []string{"json"}) {
+		t.Errorf("router2: expected the target encoding preference, got %v", tcs["router2"].EncodingPreference)
+	}
+	if tcs["router3"].Vendor != "" || len(tcs["router3"].EncodingPreference) != 0 {
+		t.Errorf("router3: unexpected vendor defaults: %q %v", tcs["router3"].Vendor, tcs["router3"].EncodingPreference)
+	}
+}
+
+func TestUnknownTargetVendor(t *testing.T) {
+	in := []byte(`
+targets:
+  router1:
+    vendor: acme
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	_, err = cfg.GetTargetsFromFile()
+	if err == nil || !strings.Contains(err.Error(), "unknown vendor") {
+		t.Fatalf("expected an unknown vendor error, got %v", err)
+	}
+}
+
+func TestTargetGroups(t *testing.T) {
+	in := []byte(`
+targets:
+  spine1:
+    vars:
+      role: spine
+  spine2:
+    vars:
+      role: spine
+  leaf1:
+    vars:
+      role: leaf
+  leaf2:
+target-groups:
+  spine:
+    selector:
+      role: spine
+  edge:
+    targets:
+      - leaf2
+`)
+	tests := map[string]struct {
+		groups []string
+		out    []string
+		err    bool
+	}{
+		"no_group":      {out: []string{"leaf1", "leaf2", "spine1", "spine2"}},
+		"selector":      {groups: []string{"spine"}, out: []string{"spine1", "spine2"}},
+		"list":          {groups: []string{"edge"}, out: []string{"leaf2"}},
+		"several":       {groups: []string{"spine", "edge"}, out: []string{"leaf2", "spine1", "spine2"}},
+		"unknown_group": {groups: []string{"leaf"}, err: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := New()
+			cfg.FileConfig.SetConfigType("yaml")
+			err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+			if err != nil {
+				t.Fatalf("failed reading config: %v", err)
+			}
+			cfg.Group = tc.groups
+			tcs, err := cfg.GetTargets()
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got targets %v", tcs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed getting targets: %v", err)
+			}
+			names := make([]string, 0, len(tcs))
+			for n := range tcs {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tc.out) {
+				t.Errorf("expected targets %v, got %v", tc.out, names)
+			}
+		})
+	}
+}
+
+func TestInvalidTargetGroup(t *testing.T) {
+	in := []byte(`
+targets:
+  router1:
+target-groups:
+  empty:
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	_, err = cfg.GetTargetGroups()
+	if err == nil || !strings.Contains(err.Error(), "no targets nor selector") {
+		t.Fatalf("expected a target group error, got %v", err)
+	}
+}
+
+func TestTargetRPCRetry(t *testing.T) {
+	in := []byte(`
+targets:
+  router1:
+  router2:
+    rpc-retry:
+      count: 1
+      retry-on:
+        - unavailable
+        - resource_exhausted
+`)
+	cfg := New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(in))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	cfg.RPCRetries = 3
+	tcs, err := cfg.GetTargetsFromFile()
+	if err != nil {
+		t.Fatalf("failed getting targets: %v", err)
+	}
+	exp := &types.RPCRetry{
+		Count:      3,
+		Backoff:    500 * time.Millisecond,
+		MaxBackoff: 10 * time.Second,
+		RetryOn:    []string{"unavailable"},
+	}
+	if !reflect.DeepEqual(tcs["router1"].RPCRetry, exp) {
+		t.Errorf("router1: expected the global rpc retries, got %+v", tcs["router1"].RPCRetry)
+	}
+	if rr := tcs["router2"].RPCRetry; rr.Count != 1 || len(rr.RetryOn) != 2 {
+		t.Errorf("router2: expected the target rpc-retry, got %+v", rr)
+	}
+
+	cfg = New()
+	cfg.FileConfig.SetConfigType("yaml")
+	err = cfg.FileConfig.ReadConfig(bytes.NewBuffer([]byte(`
+targets:
+  router1:
+    rpc-retry:
+      count: 1
+      retry-on:
+        - busy
+`)))
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+	_, err = cfg.GetTargetsFromFile()
+	if err == nil || !strings.Contains(err.Error(), "unknown rpc-retry retry-on code") {
+		t.Fatalf("expected an unknown code error, got %v", err)
+	}
+}
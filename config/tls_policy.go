@@ -0,0 +1,39 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/openconfig/gnmic/utils"
+)
+
+// getTLSPolicy reads the TLS policy under the server config key,
+// it returns nil if it is not set.
+func (c *Config) getTLSPolicy(key string) (*utils.TLSPolicy, error) {
+	if !c.FileConfig.IsSet(key + "/tls-policy") {
+		return nil, nil
+	}
+	p := &utils.TLSPolicy{
+		MinVersion: os.ExpandEnv(c.FileConfig.GetString(key + "/tls-policy/min-version")),
+		MaxVersion: os.ExpandEnv(c.FileConfig.GetString(key + "/tls-policy/max-version")),
+	}
+	for _, cs := range c.FileConfig.GetStringSlice(key + "/tls-policy/cipher-suites") {
+		p.CipherSuites = append(p.CipherSuites, os.ExpandEnv(cs))
+	}
+	for _, cv := range c.FileConfig.GetStringSlice(key + "/tls-policy/curve-preferences") {
+		p.CurvePreferences = append(p.CurvePreferences, os.ExpandEnv(cv))
+	}
+	err := p.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: tls-policy: %v", key, err)
+	}
+	return p, nil
+}
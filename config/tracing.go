@@ -0,0 +1,37 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+
+	"github.com/openconfig/gnmic/tracing"
+)
+
+func (c *Config) GetTracing() error {
+	if !c.FileConfig.IsSet("tracing") {
+		return nil
+	}
+	c.Tracing = new(tracing.Config)
+	c.Tracing.Address = os.ExpandEnv(c.FileConfig.GetString("tracing/address"))
+	c.Tracing.ServiceName = os.ExpandEnv(c.FileConfig.GetString("tracing/service-name"))
+	c.Tracing.SampleRatio = c.FileConfig.GetFloat64("tracing/sample-ratio")
+	c.Tracing.Timeout = c.FileConfig.GetDuration("tracing/timeout")
+	c.Tracing.Headers = make(map[string]string)
+	for k, v := range c.FileConfig.GetStringMapString("tracing/headers") {
+		c.Tracing.Headers[k] = os.ExpandEnv(v)
+	}
+	c.Tracing.Insecure = os.ExpandEnv(c.FileConfig.GetString("tracing/insecure")) == trueString
+	c.Tracing.SkipVerify = os.ExpandEnv(c.FileConfig.GetString("tracing/skip-verify")) == trueString
+	c.Tracing.CaFile = os.ExpandEnv(c.FileConfig.GetString("tracing/ca-file"))
+	c.Tracing.CertFile = os.ExpandEnv(c.FileConfig.GetString("tracing/cert-file"))
+	c.Tracing.KeyFile = os.ExpandEnv(c.FileConfig.GetString("tracing/key-file"))
+	c.Tracing.SetDefaults()
+	return nil
+}
@@ -9,8 +9,10 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -20,6 +22,15 @@ import (
 
 const (
 	defaultTargetWaitTime = 2 * time.Second
+	defaultWebhookTimeout = 5 * time.Second
+)
+
+const (
+	TunnelACLActionAllow = "allow"
+	TunnelACLActionDeny  = "deny"
+
+	TunnelEventRegister   = "register"
+	TunnelEventDeregister = "deregister"
 )
 
 type tunnelServer struct {
@@ -29,6 +40,8 @@ type tunnelServer struct {
 	CaFile     string `mapstructure:"ca-file,omitempty" json:"ca-file,omitempty"`
 	CertFile   string `mapstructure:"cert-file,omitempty" json:"cert-file,omitempty"`
 	KeyFile    string `mapstructure:"key-file,omitempty" json:"key-file,omitempty"`
+	// TLS versions, cipher suites and curves accepted by the server
+	TLSPolicy *utils.TLSPolicy `mapstructure:"tls-policy,omitempty" json:"tls-policy,omitempty"`
 	//
 	TargetWaitTime time.Duration `mapstructure:"target-wait-time,omitempty" json:"target-wait-time,omitempty"`
 	//
@@ -36,6 +49,37 @@ type tunnelServer struct {
 	Debug         bool `mapstructure:"debug,omitempty" json:"debug,omitempty"`
 	// targets
 	Targets []*targetMatch `mapstructure:"targets,omitempty" json:"targets,omitempty"`
+	// registration access control
+	ACL *tunnelACL `mapstructure:"acl,omitempty" json:"acl,omitempty"`
+	// webhooks notified on target register/deregister
+	Webhooks []*tunnelWebhook `mapstructure:"webhooks,omitempty" json:"webhooks,omitempty"`
+}
+
+type tunnelACL struct {
+	// action applied to targets not matching any rule, allow or deny
+	DefaultAction string `mapstructure:"default-action,omitempty" json:"default-action,omitempty"`
+	// ordered list of rules, the first matching rule wins
+	Rules []*tunnelACLRule `mapstructure:"rules,omitempty" json:"rules,omitempty"`
+}
+
+type tunnelACLRule struct {
+	// allow or deny
+	Action string `mapstructure:"action,omitempty" json:"action,omitempty"`
+	// a Regex pattern to check the target ID
+	ID string `mapstructure:"id,omitempty" json:"id,omitempty"`
+	// a Regex pattern to check the target Type
+	Type string `mapstructure:"type,omitempty" json:"type,omitempty"`
+	// a Regex pattern to check the Common Name of
+	// the certificate presented by the tunnel client
+	CommonName string `mapstructure:"common-name,omitempty" json:"common-name,omitempty"`
+}
+
+type tunnelWebhook struct {
+	URL        string            `mapstructure:"url,omitempty" json:"url,omitempty"`
+	Events     []string          `mapstructure:"events,omitempty" json:"events,omitempty"`
+	Headers    map[string]string `mapstructure:"headers,omitempty" json:"headers,omitempty"`
+	Timeout    time.Duration     `mapstructure:"timeout,omitempty" json:"timeout,omitempty"`
+	SkipVerify bool              `mapstructure:"skip-verify,omitempty" json:"skip-verify,omitempty"`
 }
 
 type targetMatch struct {
@@ -59,11 +103,15 @@ func (c *Config) GetTunnelServer() error {
 	c.TunnelServer.CaFile = os.ExpandEnv(c.FileConfig.GetString("tunnel-server/ca-file"))
 	c.TunnelServer.CertFile = os.ExpandEnv(c.FileConfig.GetString("tunnel-server/cert-file"))
 	c.TunnelServer.KeyFile = os.ExpandEnv(c.FileConfig.GetString("tunnel-server/key-file"))
+	tlsPolicy, err := c.getTLSPolicy("tunnel-server")
+	if err != nil {
+		return err
+	}
+	c.TunnelServer.TLSPolicy = tlsPolicy
 	c.TunnelServer.TargetWaitTime = c.FileConfig.GetDuration("tunnel-server/target-wait-time")
 	c.TunnelServer.EnableMetrics = os.ExpandEnv(c.FileConfig.GetString("tunnel-server/enable-metrics")) == "true"
 	c.TunnelServer.Debug = os.ExpandEnv(c.FileConfig.GetString("tunnel-server/debug")) == "true"
 
-	var err error
 	c.TunnelServer.Targets = make([]*targetMatch, 0)
 	targetMatches := c.FileConfig.Get("tunnel-server/targets")
 	switch targetMatches := targetMatches.(type) {
@@ -81,10 +129,119 @@ func (c *Config) GetTunnelServer() error {
 		return fmt.Errorf("tunnel-server has an unexpected target configuration type %T", targetMatches)
 	}
 
+	if c.FileConfig.IsSet("tunnel-server/acl") {
+		c.TunnelServer.ACL = new(tunnelACL)
+		err = decodeTunnelServerConfig(c.FileConfig.Get("tunnel-server/acl"), c.TunnelServer.ACL)
+		if err != nil {
+			return fmt.Errorf("tunnel-server acl: %v", err)
+		}
+	}
+	c.TunnelServer.Webhooks = make([]*tunnelWebhook, 0)
+	webhooks := c.FileConfig.Get("tunnel-server/webhooks")
+	switch webhooks := webhooks.(type) {
+	case []interface{}:
+		for _, whi := range webhooks {
+			wh := new(tunnelWebhook)
+			err = decodeTunnelServerConfig(whi, wh)
+			if err != nil {
+				return fmt.Errorf("tunnel-server webhook: %v", err)
+			}
+			c.TunnelServer.Webhooks = append(c.TunnelServer.Webhooks, wh)
+		}
+	case nil:
+	default:
+		return fmt.Errorf("tunnel-server has an unexpected webhooks configuration type %T", webhooks)
+	}
+
 	c.setTunnelServerDefaults()
+	return c.validateTunnelServer()
+}
+
+func decodeTunnelServerConfig(in, out interface{}) error {
+	decoder, err := mapstructure.NewDecoder(
+		&mapstructure.DecoderConfig{
+			DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+			Result:     out,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(utils.Convert(in))
+}
+
+func (c *Config) validateTunnelServer() error {
+	if c.TunnelServer.ACL != nil {
+		switch c.TunnelServer.ACL.DefaultAction {
+		case TunnelACLActionAllow, TunnelACLActionDeny:
+		default:
+			return fmt.Errorf("tunnel-server acl: unknown default-action %q", c.TunnelServer.ACL.DefaultAction)
+		}
+		for i, r := range c.TunnelServer.ACL.Rules {
+			switch r.Action {
+			case TunnelACLActionAllow, TunnelACLActionDeny:
+			default:
+				return fmt.Errorf("tunnel-server acl: rule %d: unknown action %q", i, r.Action)
+			}
+			for _, re := range []string{r.ID, r.Type, r.CommonName} {
+				if _, err := regexp.Compile(re); err != nil {
+					return fmt.Errorf("tunnel-server acl: rule %d: %v", i, err)
+				}
+			}
+		}
+	}
+	for i, wh := range c.TunnelServer.Webhooks {
+		if wh.URL == "" {
+			return fmt.Errorf("tunnel-server webhook %d: missing url", i)
+		}
+		for _, ev := range wh.Events {
+			switch ev {
+			case TunnelEventRegister, TunnelEventDeregister:
+			default:
+				return fmt.Errorf("tunnel-server webhook %d: unknown event %q", i, ev)
+			}
+		}
+	}
 	return nil
 }
 
+// TunnelTargetAllowed evaluates the tunnel server ACL against a target ID, Type
+// and the Common Name of the tunnel client certificate.
+func (c *Config) TunnelTargetAllowed(id, typ, cn string) (bool, error) {
+	if c.TunnelServer == nil || c.TunnelServer.ACL == nil {
+		return true, nil
+	}
+	for _, r := range c.TunnelServer.ACL.Rules {
+		ok, err := matchTunnelACLRule(r, id, typ, cn)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return r.Action == TunnelACLActionAllow, nil
+		}
+	}
+	return c.TunnelServer.ACL.DefaultAction == TunnelACLActionAllow, nil
+}
+
+func matchTunnelACLRule(r *tunnelACLRule, id, typ, cn string) (bool, error) {
+	if r == nil {
+		return false, errors.New("nil acl rule")
+	}
+	for _, m := range [][2]string{{r.ID, id}, {r.Type, typ}, {r.CommonName, cn}} {
+		if m[0] == "" {
+			continue
+		}
+		ok, err := regexp.MatchString(m[0], m[1])
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (c *Config) setTunnelServerDefaults() {
 	if c.TunnelServer.Address == "" {
 		c.TunnelServer.Address = defaultAddress
@@ -92,4 +249,15 @@ func (c *Config) setTunnelServerDefaults() {
 	if c.TunnelServer.TargetWaitTime <= 0 {
 		c.TunnelServer.TargetWaitTime = defaultTargetWaitTime
 	}
+	if c.TunnelServer.ACL != nil && c.TunnelServer.ACL.DefaultAction == "" {
+		c.TunnelServer.ACL.DefaultAction = TunnelACLActionDeny
+	}
+	for _, wh := range c.TunnelServer.Webhooks {
+		if wh.Timeout <= 0 {
+			wh.Timeout = defaultWebhookTimeout
+		}
+		if len(wh.Events) == 0 {
+			wh.Events = []string{TunnelEventRegister, TunnelEventDeregister}
+		}
+	}
 }
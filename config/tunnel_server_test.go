@@ -0,0 +1,124 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTunnelTargetAllowed(t *testing.T) {
+	type check struct {
+		id, typ, cn string
+		want        bool
+	}
+	tests := map[string]struct {
+		in      []byte
+		checks  []check
+		wantErr bool
+	}{
+		"no_acl": {
+			in: []byte(`
+tunnel-server:
+  address: :57401
+`),
+			checks: []check{
+				{id: "sr1", typ: "GNMI_GNOI", want: true},
+			},
+		},
+		"default_deny": {
+			in: []byte(`
+tunnel-server:
+  acl:
+    rules:
+      - action: allow
+        id: ^sr
+        type: GNMI_GNOI
+      - action: allow
+        common-name: ^router-.*\.example\.com$
+`),
+			checks: []check{
+				{id: "sr1", typ: "GNMI_GNOI", want: true},
+				{id: "sr1", typ: "SSH", want: false},
+				{id: "leaf1", typ: "GNMI_GNOI", want: false},
+				{id: "leaf1", typ: "GNMI_GNOI", cn: "router-1.example.com", want: true},
+			},
+		},
+		"first_match_wins": {
+			in: []byte(`
+tunnel-server:
+  acl:
+    default-action: allow
+    rules:
+      - action: deny
+        id: ^lab-
+`),
+			checks: []check{
+				{id: "lab-sr1", typ: "GNMI_GNOI", want: false},
+				{id: "sr1", typ: "GNMI_GNOI", want: true},
+			},
+		},
+		"invalid_action": {
+			in: []byte(`
+tunnel-server:
+  acl:
+    rules:
+      - action: reject
+`),
+			wantErr: true,
+		},
+		"invalid_regex": {
+			in: []byte(`
+tunnel-server:
+  acl:
+    rules:
+      - action: allow
+        id: "sr[1"
+`),
+			wantErr: true,
+		},
+		"webhook_missing_url": {
+			in: []byte(`
+tunnel-server:
+  webhooks:
+    - timeout: 2s
+`),
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := New()
+			cfg.FileConfig.SetConfigType("yaml")
+			err := cfg.FileConfig.ReadConfig(bytes.NewBuffer(tc.in))
+			if err != nil {
+				t.Fatalf("failed reading config: %v", err)
+			}
+			err = cfg.GetTunnelServer()
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, c := range tc.checks {
+				ok, err := cfg.TunnelTargetAllowed(c.id, c.typ, c.cn)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if ok != c.want {
+					t.Errorf("target {ID:%s Type:%s CN:%s}: expected allowed=%v, got %v", c.id, c.typ, c.cn, c.want, ok)
+				}
+			}
+		})
+	}
+}
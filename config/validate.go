@@ -0,0 +1,454 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openconfig/gnmic/utils"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ValidationIssue is a problem found while validating a configuration file.
+type ValidationIssue struct {
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message,omitempty"`
+	Warning bool   `json:"warning,omitempty"`
+}
+
+func (vi *ValidationIssue) String() string {
+	severity := "error"
+	if vi.Warning {
+		severity = "warning"
+	}
+	if vi.Path == "" {
+		return fmt.Sprintf("%d:%d: %s: %s", vi.Line, vi.Column, severity, vi.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s: %s", vi.Line, vi.Column, severity, vi.Path, vi.Message)
+}
+
+// HasValidationErrors returns true if any of the issues is not a warning.
+func HasValidationErrors(issues []*ValidationIssue) bool {
+	for _, vi := range issues {
+		if !vi.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateFile validates the configuration file in use against the configuration schema.
+// extraKeys are additional accepted top level keys, typically the names of the commands local flags.
+func (c *Config) ValidateFile(ctx context.Context, extraKeys ...string) ([]*ValidationIssue, error) {
+	file := c.FileConfig.ConfigFileUsed()
+	if file == "" {
+		return nil, errors.New("no configuration file to validate")
+	}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case "", ".yaml", ".yml", ".json":
+	default:
+		return nil, fmt.Errorf("validation of %q files is not supported, only YAML and JSON files can be validated", filepath.Ext(file))
+	}
+	b, err := utils.ReadFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateConfig(b, extraKeys...)
+}
+
+// ValidateConfig validates the YAML (or JSON) configuration b against the configuration schema.
+// It checks for unknown keys, malformed values and references to undefined
+// outputs, processors, subscriptions and profiles.
+// The returned error is not nil if b cannot be parsed.
+func ValidateConfig(b []byte, extraKeys ...string) ([]*ValidationIssue, error) {
+	doc := new(yamlv3.Node)
+	err := yamlv3.Unmarshal(b, doc)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Kind != yamlv3.DocumentNode || len(doc.Content) == 0 {
+		// empty file
+		return nil, nil
+	}
+	v := new(validator)
+	root := resolveAlias(doc.Content[0])
+	v.check(root, configSchema(extraKeys...), "")
+	if root.Kind == yamlv3.MappingNode {
+		v.checkReferences(root)
+	}
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		if v.issues[i].Line == v.issues[j].Line {
+			return v.issues[i].Column < v.issues[j].Column
+		}
+		return v.issues[i].Line < v.issues[j].Line
+	})
+	return v.issues, nil
+}
+
+type validator struct {
+	issues []*ValidationIssue
+}
+
+func (v *validator) report(n *yamlv3.Node, warn bool, path, format string, args ...interface{}) {
+	v.issues = append(v.issues, &ValidationIssue{
+		Line:    n.Line,
+		Column:  n.Column,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+		Warning: warn,
+	})
+}
+
+func (v *validator) errorf(n *yamlv3.Node, path, format string, args ...interface{}) {
+	v.report(n, false, path, format, args...)
+}
+
+func (v *validator) warnf(n *yamlv3.Node, path, format string, args ...interface{}) {
+	v.report(n, true, path, format, args...)
+}
+
+func (v *validator) check(n *yamlv3.Node, s *schemaNode, path string) {
+	n = resolveAlias(n)
+	if s == nil || s.kind == schemaAny || isNull(n) {
+		return
+	}
+	switch s.kind {
+	case schemaObject:
+		if n.Kind != yamlv3.MappingNode {
+			v.errorf(n, path, "expected an object, got %s", nodeKindName(n))
+			return
+		}
+		v.checkObject(n, s, path)
+	case schemaMap:
+		if n.Kind != yamlv3.MappingNode {
+			v.errorf(n, path, "expected an object, got %s", nodeKindName(n))
+			return
+		}
+		for _, kv := range mappingPairs(n) {
+			v.check(kv[1], s.elem, joinPath(path, kv[0].Value))
+		}
+	case schemaList:
+		if n.Kind != yamlv3.SequenceNode {
+			// a single value is accepted where a list is expected
+			v.check(n, s.elem, path)
+			return
+		}
+		for i, item := range n.Content {
+			v.check(item, s.elem, joinPath(path, strconv.Itoa(i)))
+		}
+	default:
+		if n.Kind != yamlv3.ScalarNode {
+			v.errorf(n, path, "expected a single value, got %s", nodeKindName(n))
+			return
+		}
+		v.checkScalar(n, s.kind, path)
+	}
+}
+
+func (v *validator) checkObject(n *yamlv3.Node, s *schemaNode, path string) {
+	pairs := mappingPairs(n)
+	fields := s.fields
+	if s.variants != nil {
+		var typeNode *yamlv3.Node
+		for _, kv := range pairs {
+			if strings.ToLower(kv[0].Value) == "type" {
+				typeNode = resolveAlias(kv[1])
+			}
+		}
+		if typeNode == nil || isNull(typeNode) {
+			v.errorf(n, path, "missing %q", "type")
+			return
+		}
+		variant, ok := s.variants[typeNode.Value]
+		if !ok {
+			v.errorf(typeNode, joinPath(path, "type"), "unknown type %q, expected one of %s", typeNode.Value, variantNames(s))
+			return
+		}
+		if variant == nil {
+			// no schema for this type, accept any key
+			return
+		}
+		fields = make(map[string]*schemaNode, len(s.fields)+len(variant.fields))
+		for k, f := range variant.fields {
+			fields[k] = f
+		}
+		for k, f := range s.fields {
+			fields[k] = f
+		}
+	}
+	for _, kv := range pairs {
+		key := strings.ToLower(kv[0].Value)
+		fs, ok := fields[key]
+		if !ok {
+			if alt := closestKey(key, fields); alt != "" {
+				v.errorf(kv[0], path, "unknown key %q, did you mean %q?", kv[0].Value, alt)
+			} else {
+				v.errorf(kv[0], path, "unknown key %q", kv[0].Value)
+			}
+			continue
+		}
+		v.check(kv[1], fs, joinPath(path, key))
+	}
+}
+
+func (v *validator) checkScalar(n *yamlv3.Node, kind schemaKind, path string) {
+	// values containing environment variables are expanded later
+	if strings.Contains(n.Value, "$") {
+		return
+	}
+	switch kind {
+	case schemaBool:
+		if _, err := strconv.ParseBool(n.Value); err != nil {
+			v.errorf(n, path, "invalid boolean %q", n.Value)
+		}
+	case schemaInt:
+		if _, err := strconv.ParseInt(n.Value, 0, 64); err != nil {
+			if _, err := strconv.ParseUint(n.Value, 0, 64); err != nil {
+				v.errorf(n, path, "invalid integer %q", n.Value)
+			}
+		}
+	case schemaFloat:
+		if _, err := strconv.ParseFloat(n.Value, 64); err != nil {
+			v.errorf(n, path, "invalid number %q", n.Value)
+		}
+	case schemaDuration:
+		if _, err := strconv.ParseInt(n.Value, 10, 64); err == nil {
+			return
+		}
+		if _, err := time.ParseDuration(n.Value); err != nil {
+			v.errorf(n, path, "invalid duration %q, valid formats: 10s, 1m30s, 1h", n.Value)
+		}
+	}
+}
+
+// checkReferences reports references to undefined outputs, processors,
+// subscriptions and profiles, as well as processors that are never used.
+func (v *validator) checkReferences(root *yamlv3.Node) {
+	outputNames := sectionNames(root, "outputs")
+	processorNames := sectionNames(root, "processors")
+	subscriptionNames := sectionNames(root, "subscriptions")
+	subscriptionProfiles := sectionNames(root, "subscription-profiles")
+	connectionProfiles := sectionNames(root, "connection-profiles")
+	// subscriptions defined in a subscription profile can be referenced by name
+	for _, kv := range mappingPairs(mappingValue(root, "subscription-profiles")) {
+		for sn := range sectionNames(kv[1], "subscriptions") {
+			subscriptionNames[sn] = struct{}{}
+		}
+	}
+	usedProcessors := make(map[string]struct{})
+
+	checkRefs := func(n *yamlv3.Node, path, kind string, defined map[string]struct{}) {
+		for _, item := range scalarItems(n) {
+			if kind == "processor" {
+				usedProcessors[strings.ToLower(item.Value)] = struct{}{}
+			}
+			if strings.Contains(item.Value, "$") {
+				continue
+			}
+			if _, ok := defined[strings.ToLower(item.Value)]; !ok {
+				v.errorf(item, path, "%s %q is not defined", kind, item.Value)
+			}
+		}
+	}
+
+	for _, section := range []string{"targets", "connection-profiles"} {
+		for _, kv := range mappingPairs(mappingValue(root, section)) {
+			path := joinPath(section, kv[0].Value)
+			checkRefs(mappingValue(kv[1], "outputs"), joinPath(path, "outputs"), "output", outputNames)
+			checkRefs(mappingValue(kv[1], "subscriptions"), joinPath(path, "subscriptions"), "subscription", subscriptionNames)
+			checkRefs(mappingValue(kv[1], "profiles"), joinPath(path, "profiles"), "subscription profile", subscriptionProfiles)
+			checkRefs(mappingValue(kv[1], "connection-profile"), joinPath(path, "connection-profile"), "connection profile", connectionProfiles)
+		}
+	}
+	for _, kv := range mappingPairs(mappingValue(root, "subscription-profiles")) {
+		path := joinPath("subscription-profiles", kv[0].Value)
+		checkRefs(mappingValue(kv[1], "outputs"), joinPath(path, "outputs"), "output", outputNames)
+	}
+	for _, kv := range mappingPairs(mappingValue(root, "outputs")) {
+		path := joinPath("outputs", kv[0].Value)
+		checkRefs(mappingValue(kv[1], "event-processors"), joinPath(path, "event-processors"), "processor", processorNames)
+	}
+	for _, kv := range mappingPairs(mappingValue(root, "inputs")) {
+		path := joinPath("inputs", kv[0].Value)
+		checkRefs(mappingValue(kv[1], "event-processors"), joinPath(path, "event-processors"), "processor", processorNames)
+		checkRefs(mappingValue(kv[1], "outputs"), joinPath(path, "outputs"), "output", outputNames)
+	}
+	for _, kv := range mappingPairs(root) {
+		key := strings.ToLower(kv[0].Value)
+		switch {
+		case key == "subscribe-output":
+			checkRefs(kv[1], key, "output", outputNames)
+		case key == "subscribe-name":
+			checkRefs(kv[1], key, "subscription", subscriptionNames)
+		case strings.HasSuffix(key, "-processor"), key == "processor-name":
+			checkRefs(kv[1], key, "processor", processorNames)
+		}
+	}
+	for _, kv := range mappingPairs(mappingValue(root, "processors")) {
+		if _, ok := usedProcessors[strings.ToLower(kv[0].Value)]; !ok {
+			v.warnf(kv[0], "processors", "processor %q is not referenced by any output or input", kv[0].Value)
+		}
+	}
+}
+
+func resolveAlias(n *yamlv3.Node) *yamlv3.Node {
+	for n != nil && n.Kind == yamlv3.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+func isNull(n *yamlv3.Node) bool {
+	return n == nil || (n.Kind == yamlv3.ScalarNode && n.Tag == "!!null")
+}
+
+func nodeKindName(n *yamlv3.Node) string {
+	switch n.Kind {
+	case yamlv3.MappingNode:
+		return "an object"
+	case yamlv3.SequenceNode:
+		return "a list"
+	}
+	return fmt.Sprintf("%q", n.Value)
+}
+
+// mappingPairs returns the key/value pairs of a mapping node,
+// pairs brought in with the merge key (<<) come first.
+func mappingPairs(n *yamlv3.Node) [][2]*yamlv3.Node {
+	n = resolveAlias(n)
+	if n == nil || n.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	merged := make([][2]*yamlv3.Node, 0)
+	pairs := make([][2]*yamlv3.Node, 0, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, val := n.Content[i], n.Content[i+1]
+		if k.Tag == "!!merge" || (k.Value == "<<" && k.Style == 0) {
+			val = resolveAlias(val)
+			if val.Kind == yamlv3.SequenceNode {
+				for _, m := range val.Content {
+					merged = append(merged, mappingPairs(m)...)
+				}
+				continue
+			}
+			merged = append(merged, mappingPairs(val)...)
+			continue
+		}
+		pairs = append(pairs, [2]*yamlv3.Node{k, val})
+	}
+	return append(merged, pairs...)
+}
+
+// mappingValue returns the value of key in mapping node n, if any.
+func mappingValue(n *yamlv3.Node, key string) *yamlv3.Node {
+	var val *yamlv3.Node
+	for _, kv := range mappingPairs(n) {
+		if strings.EqualFold(kv[0].Value, key) {
+			val = resolveAlias(kv[1])
+		}
+	}
+	return val
+}
+
+// sectionNames returns the lower cased keys of section key in mapping node n.
+func sectionNames(n *yamlv3.Node, key string) map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, kv := range mappingPairs(mappingValue(n, key)) {
+		names[strings.ToLower(kv[0].Value)] = struct{}{}
+	}
+	return names
+}
+
+// scalarItems returns the scalar items of a list node,
+// or the node itself if it is a scalar.
+func scalarItems(n *yamlv3.Node) []*yamlv3.Node {
+	n = resolveAlias(n)
+	if isNull(n) {
+		return nil
+	}
+	switch n.Kind {
+	case yamlv3.ScalarNode:
+		return []*yamlv3.Node{n}
+	case yamlv3.SequenceNode:
+		items := make([]*yamlv3.Node, 0, len(n.Content))
+		for _, item := range n.Content {
+			item = resolveAlias(item)
+			if item.Kind == yamlv3.ScalarNode && !isNull(item) {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+func joinPath(path, elem string) string {
+	if path == "" {
+		return elem
+	}
+	return path + "/" + elem
+}
+
+func variantNames(s *schemaNode) string {
+	names := make([]string, 0, len(s.variants))
+	for typ := range s.variants {
+		names = append(names, typ)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("%q", names)
+}
+
+// closestKey returns the field name closest to key,
+// if it is within a small edit distance.
+func closestKey(key string, fields map[string]*schemaNode) string {
+	best := ""
+	bestDist := len(key)/3 + 1
+	for k := range fields {
+		d := editDistance(key, k)
+		if d < bestDist || (d == bestDist && best != "" && k < best) {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
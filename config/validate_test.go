@@ -0,0 +1,241 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var validateConfigTestSet = map[string]struct {
+	in        []byte
+	extraKeys []string
+	out       []string
+}{
+	"valid": {
+		in: []byte(`
+username: admin
+timeout: 5s
+subscribe-sample-interval: 10s
+targets:
+  router1:57400:
+  router2:
+    address: router2:57400
+    outputs: [out1]
+    subscriptions:
+      - sub1
+subscriptions:
+  sub1:
+    paths:
+      - /interface
+    sample-interval: 10s
+outputs:
+  out1:
+    type: file
+    file-type: stdout
+    event-processors:
+      - proc1
+processors:
+  proc1:
+    event-drop:
+      condition: 'true'
+`),
+		extraKeys: []string{"subscribe-sample-interval"},
+		out:       []string{},
+	},
+	"unknown_keys": {
+		in: []byte(`
+usrname: admin
+targets:
+  router1:
+    adress: router1:57400
+subscriptions:
+  sub1:
+    path: /interface
+`),
+		out: []string{
+			`2:1: error: unknown key "usrname", did you mean "username"?`,
+			`5:5: error: targets/router1: unknown key "adress", did you mean "address"?`,
+			`8:5: error: subscriptions/sub1: unknown key "path", did you mean "paths"?`,
+		},
+	},
+	"bad_durations": {
+		in: []byte(`
+timeout: 10
+retry: 10x
+subscriptions:
+  sub1:
+    sample-interval: ${INTERVAL}
+    heartbeat-interval: 1 minute
+`),
+		out: []string{
+			`3:8: error: retry: invalid duration "10x", valid formats: 10s, 1m30s, 1h`,
+			`7:25: error: subscriptions/sub1/heartbeat-interval: invalid duration "1 minute", valid formats: 10s, 1m30s, 1h`,
+		},
+	},
+	"plugin_types": {
+		in: []byte(`
+outputs:
+  out1:
+    type: fille
+  out2:
+    file-type: stdout
+  out3:
+    type: file
+    file-typ: stdout
+inputs:
+  in1:
+    type: nats
+    outputs: out1
+`),
+		out: []string{
+			`4:11: error: outputs/out1/type: unknown type "fille", expected one of ["alerting" "file" "gnmi" "influxdb" "jetstream" "kafka" "loki" "nats" "prometheus" "prometheus_write" "stan" "tcp" "udp"]`,
+			`6:5: error: outputs/out2: missing "type"`,
+			`9:5: error: outputs/out3: unknown key "file-typ", did you mean "file-type"?`,
+		},
+	},
+	"references": {
+		in: []byte(`
+targets:
+  router1:
+    outputs:
+      - out1
+      - out2
+    subscriptions: sub2
+outputs:
+  out1:
+    type: file
+    event-processors:
+      - proc2
+processors:
+  proc1:
+    event-drop:
+      condition: 'true'
+subscriptions:
+  sub1:
+    paths: [/]
+`),
+		out: []string{
+			`6:9: error: targets/router1/outputs: output "out2" is not defined`,
+			`7:20: error: targets/router1/subscriptions: subscription "sub2" is not defined`,
+			`12:9: error: outputs/out1/event-processors: processor "proc2" is not defined`,
+			`14:3: warning: processors: processor "proc1" is not referenced by any output or input`,
+		},
+	},
+	"processors": {
+		in: []byte(`
+outputs:
+  out1:
+    type: file
+    event-processors: [proc1, proc2]
+processors:
+  proc1:
+    event-dorp:
+      condition: 'true'
+  proc2:
+    event-strings:
+      value-names: [".*"]
+      transfroms: []
+`),
+		out: []string{
+			`8:5: error: processors/proc1: unknown key "event-dorp", did you mean "event-drop"?`,
+			`13:7: error: processors/proc2/event-strings: unknown key "transfroms", did you mean "transforms"?`,
+		},
+	},
+	"wrong_kinds": {
+		in: []byte(`
+address:
+  router1: x
+targets: [router1]
+api-server:
+  enable-metrics: maybe
+`),
+		out: []string{
+			`3:3: error: address: expected a single value, got an object`,
+			`4:10: error: targets: expected an object, got a list`,
+			`6:19: error: api-server/enable-metrics: invalid boolean "maybe"`,
+		},
+	},
+	"anchors": {
+		in: []byte(`
+common: &common
+  type: file
+  file-type: stdout
+`),
+		out: []string{
+			`2:1: error: unknown key "common"`,
+		},
+	},
+	"merge_keys": {
+		in: []byte(`
+outputs:
+  out1: &out
+    type: file
+    file-type: stdout
+  out2:
+    <<: *out
+    file-type: stderr
+    formatt: json
+`),
+		out: []string{
+			`9:5: error: outputs/out2: unknown key "formatt", did you mean "format"?`,
+		},
+	},
+}
+
+func TestValidateConfig(t *testing.T) {
+	for name, data := range validateConfigTestSet {
+		t.Run(name, func(t *testing.T) {
+			issues, err := ValidateConfig(data.in, data.extraKeys...)
+			if err != nil {
+				t.Fatalf("failed validating config: %v", err)
+			}
+			got := make([]string, 0, len(issues))
+			for _, vi := range issues {
+				got = append(got, vi.String())
+			}
+			if len(got) != len(data.out) {
+				t.Fatalf("expected %d issue(s), got %d: %q", len(data.out), len(got), got)
+			}
+			for i := range got {
+				if got[i] != data.out[i] {
+					t.Errorf("issue %d: expected %q, got %q", i, data.out[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfigSyntaxError(t *testing.T) {
+	_, err := ValidateConfig([]byte("targets:\n  - a\n b: c\n"))
+	if err == nil {
+		t.Fatal("expected an error for a malformed file")
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	b, err := JSONSchema("subscribe-sample-interval")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := make(map[string]interface{})
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, ok := s["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("missing top level properties")
+	}
+	for _, k := range []string{"targets", "subscriptions", "outputs", "processors", "api-server", "timeout", "subscribe-sample-interval"} {
+		if _, ok := props[k]; !ok {
+			t.Errorf("missing property %q", k)
+		}
+	}
+}
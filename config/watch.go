@@ -0,0 +1,50 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"errors"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/types"
+)
+
+func (c *Config) ValidateWatchInput() error {
+	c.LocalFlags.WatchPath = SanitizeArrayFlagValue(c.LocalFlags.WatchPath)
+	if len(c.LocalFlags.WatchPath) == 0 {
+		return errors.New("missing watch path")
+	}
+	if c.LocalFlags.WatchCondition == "" && c.LocalFlags.WatchExpression == "" {
+		return errors.New("missing watch condition or expression")
+	}
+	if c.LocalFlags.WatchCondition != "" && c.LocalFlags.WatchExpression != "" {
+		return errors.New("flags --condition and --expression are mutually exclusive")
+	}
+	if c.LocalFlags.WatchWaitTimeout < 0 {
+		return errors.New("wait-timeout must be a positive duration")
+	}
+	return nil
+}
+
+// CreateWatchSubscribeRequest creates the stream subscribe request
+// of the watch command for target.
+func (c *Config) CreateWatchSubscribeRequest(target string) (*gnmi.SubscribeRequest, error) {
+	sc := &types.SubscriptionConfig{
+		Name:       "watch",
+		Prefix:     c.LocalFlags.WatchPrefix,
+		Paths:      c.LocalFlags.WatchPath,
+		Mode:       "STREAM",
+		StreamMode: c.LocalFlags.WatchStreamMode,
+		Encoding:   c.Encoding,
+	}
+	if c.LocalFlags.WatchSampleInterval > 0 {
+		sc.SampleInterval = &c.LocalFlags.WatchSampleInterval
+	}
+	return c.CreateSubscribeRequest(sc, target)
+}
@@ -0,0 +1,145 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aws_credentials
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/openconfig/gnmic/credentials"
+	"github.com/openconfig/gnmic/utils"
+)
+
+const (
+	defaultRefreshInterval = 5 * time.Minute
+	loggingPrefix          = "[aws_credentials] "
+)
+
+func init() {
+	credentials.Register("aws-secrets-manager", func() credentials.Provider {
+		return &awsProvider{
+			cfg:    new(config),
+			logger: log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags),
+		}
+	})
+}
+
+// awsProvider reads credentials from AWS Secrets Manager.
+// The secret reference is the secret name or ARN,
+// the secret string must be a JSON object.
+type awsProvider struct {
+	cfg    *config
+	client *secretsmanager.SecretsManager
+	logger *log.Logger
+}
+
+type config struct {
+	// AWS region, defaults to the AWS_REGION env variable or the shared config
+	Region string `mapstructure:"region,omitempty" json:"region,omitempty"`
+	// shared config profile name
+	Profile string `mapstructure:"profile,omitempty" json:"profile,omitempty"`
+	// custom endpoint, e.g: a VPC endpoint or a local emulator
+	Endpoint string `mapstructure:"endpoint,omitempty" json:"endpoint,omitempty"`
+	// version stage of the secrets, defaults to AWSCURRENT
+	VersionStage string            `mapstructure:"version-stage,omitempty" json:"version-stage,omitempty"`
+	Keys         *credentials.Keys `mapstructure:"keys,omitempty" json:"keys,omitempty"`
+	// interval at which the secrets are re-read
+	RefreshInterval time.Duration `mapstructure:"refresh-interval,omitempty" json:"refresh-interval,omitempty"`
+	Debug           bool          `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+}
+
+func (p *awsProvider) Init(ctx context.Context, cfg map[string]interface{}, opts ...credentials.Option) error {
+	err := credentials.DecodeConfig(cfg, p.cfg)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.setDefaults()
+	awsConfig := aws.NewConfig()
+	if p.cfg.Region != "" {
+		awsConfig = awsConfig.WithRegion(p.cfg.Region)
+	}
+	if p.cfg.Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(p.cfg.Endpoint)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *awsConfig,
+		Profile:           p.cfg.Profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return err
+	}
+	p.client = secretsmanager.New(sess)
+	p.logger.Printf("initialized aws secrets manager credentials provider: region=%s", aws.StringValue(sess.Config.Region))
+	return nil
+}
+
+func (p *awsProvider) Get(ctx context.Context, secret string) (*credentials.Credentials, error) {
+	if secret == "" {
+		return nil, errors.New("missing secret id")
+	}
+	out, err := p.client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:     aws.String(secret),
+		VersionStage: aws.String(p.cfg.VersionStage),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.SecretString == nil {
+		return nil, fmt.Errorf("secret %q has no secret string", secret)
+	}
+	if p.cfg.Debug {
+		p.logger.Printf("read secret %q version %s", secret, aws.StringValue(out.VersionId))
+	}
+	data := make(map[string]interface{})
+	err = json.Unmarshal([]byte(*out.SecretString), &data)
+	if err != nil {
+		return nil, fmt.Errorf("secret %q: failed to decode secret string: %v", secret, err)
+	}
+	c, err := p.cfg.Keys.FromMap(data)
+	if err != nil {
+		return nil, fmt.Errorf("secret %q: %v", secret, err)
+	}
+	return c, nil
+}
+
+func (p *awsProvider) RefreshInterval() time.Duration {
+	return p.cfg.RefreshInterval
+}
+
+func (p *awsProvider) SetLogger(logger *log.Logger) {
+	if logger != nil && p.logger != nil {
+		p.logger.SetOutput(logger.Writer())
+		p.logger.SetFlags(logger.Flags())
+	}
+}
+
+func (p *awsProvider) setDefaults() {
+	if p.cfg.Keys == nil {
+		p.cfg.Keys = new(credentials.Keys)
+	}
+	p.cfg.Keys.SetDefaults()
+	if p.cfg.VersionStage == "" {
+		p.cfg.VersionStage = "AWSCURRENT"
+	}
+	if p.cfg.RefreshInterval <= 0 {
+		p.cfg.RefreshInterval = defaultRefreshInterval
+	}
+}
@@ -0,0 +1,158 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Provider reads target credentials from an external secret store.
+type Provider interface {
+	Init(context.Context, map[string]interface{}, ...Option) error
+	// Get returns the credentials stored in the provider specific secret reference.
+	Get(ctx context.Context, secret string) (*Credentials, error)
+	// RefreshInterval is the interval at which the credentials should be re-read.
+	RefreshInterval() time.Duration
+	SetLogger(*log.Logger)
+}
+
+// SecretReader is implemented by the providers able to read arbitrary secrets.
+// It is used to resolve the secret references found in the config file.
+type SecretReader interface {
+	// ReadSecret returns the key/value pairs stored in secret path.
+	ReadSecret(ctx context.Context, path string) (map[string]interface{}, error)
+}
+
+type Initializer func() Provider
+
+var Providers = map[string]Initializer{}
+
+type Option func(Provider)
+
+func WithLogger(logger *log.Logger) Option {
+	return func(p Provider) {
+		p.SetLogger(logger)
+	}
+}
+
+var ProviderTypes = []string{
+	"vault",
+	"aws-secrets-manager",
+	"file",
+}
+
+func Register(name string, initFn Initializer) {
+	Providers[name] = initFn
+}
+
+func DecodeConfig(src, dst interface{}) error {
+	decoder, err := mapstructure.NewDecoder(
+		&mapstructure.DecoderConfig{
+			DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+			Result:     dst,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(src)
+}
+
+// Credentials are the credentials read from a provider.
+// The TLS fields hold PEM encoded data.
+type Credentials struct {
+	Username string
+	Password string
+	Token    string
+	TLSCA    []byte
+	TLSCert  []byte
+	TLSKey   []byte
+}
+
+// Equal returns true if c and o hold the same values.
+func (c *Credentials) Equal(o *Credentials) bool {
+	if c == nil || o == nil {
+		return c == o
+	}
+	return c.Username == o.Username &&
+		c.Password == o.Password &&
+		c.Token == o.Token &&
+		bytes.Equal(c.TLSCA, o.TLSCA) &&
+		bytes.Equal(c.TLSCert, o.TLSCert) &&
+		bytes.Equal(c.TLSKey, o.TLSKey)
+}
+
+// Keys are the names of the secret keys holding each credential.
+type Keys struct {
+	Username string `mapstructure:"username,omitempty" json:"username,omitempty"`
+	Password string `mapstructure:"password,omitempty" json:"password,omitempty"`
+	Token    string `mapstructure:"token,omitempty" json:"token,omitempty"`
+	TLSCA    string `mapstructure:"tls-ca,omitempty" json:"tls-ca,omitempty"`
+	TLSCert  string `mapstructure:"tls-cert,omitempty" json:"tls-cert,omitempty"`
+	TLSKey   string `mapstructure:"tls-key,omitempty" json:"tls-key,omitempty"`
+}
+
+// SetDefaults sets the unset key names to their default values.
+func (k *Keys) SetDefaults() {
+	if k.Username == "" {
+		k.Username = "username"
+	}
+	if k.Password == "" {
+		k.Password = "password"
+	}
+	if k.Token == "" {
+		k.Token = "token"
+	}
+	if k.TLSCA == "" {
+		k.TLSCA = "tls-ca"
+	}
+	if k.TLSCert == "" {
+		k.TLSCert = "tls-cert"
+	}
+	if k.TLSKey == "" {
+		k.TLSKey = "tls-key"
+	}
+}
+
+// FromMap builds Credentials from the key/value pairs of a secret.
+// It returns an error if none of the keys is found.
+func (k *Keys) FromMap(m map[string]interface{}) (*Credentials, error) {
+	c := new(Credentials)
+	var found bool
+	for key, dst := range map[string]*string{
+		k.Username: &c.Username,
+		k.Password: &c.Password,
+		k.Token:    &c.Token,
+	} {
+		if v, ok := m[key]; ok {
+			*dst = fmt.Sprint(v)
+			found = true
+		}
+	}
+	for key, dst := range map[string]*[]byte{
+		k.TLSCA:   &c.TLSCA,
+		k.TLSCert: &c.TLSCert,
+		k.TLSKey:  &c.TLSKey,
+	} {
+		if v, ok := m[key]; ok {
+			*dst = []byte(fmt.Sprint(v))
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("secret has none of the credentials keys")
+	}
+	return c, nil
+}
@@ -0,0 +1,177 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/openconfig/gnmic/credentials"
+	"github.com/openconfig/gnmic/utils"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultRefreshInterval = 30 * time.Second
+	loggingPrefix          = "[file_credentials] "
+)
+
+func init() {
+	credentials.Register("file", func() credentials.Provider {
+		return &fileProvider{
+			cfg:    new(config),
+			logger: log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags),
+		}
+	})
+}
+
+// fileProvider reads credentials from files.
+// The secret reference is either a directory containing one file per key,
+// e.g: a mounted Kubernetes secret, or a YAML/JSON file containing the keys.
+// Relative references are resolved against the base-dir.
+type fileProvider struct {
+	cfg    *config
+	logger *log.Logger
+}
+
+type config struct {
+	BaseDir string            `mapstructure:"base-dir,omitempty" json:"base-dir,omitempty"`
+	Keys    *credentials.Keys `mapstructure:"keys,omitempty" json:"keys,omitempty"`
+	// interval at which the files are re-read
+	RefreshInterval time.Duration `mapstructure:"refresh-interval,omitempty" json:"refresh-interval,omitempty"`
+	Debug           bool          `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+}
+
+func (f *fileProvider) Init(ctx context.Context, cfg map[string]interface{}, opts ...credentials.Option) error {
+	err := credentials.DecodeConfig(cfg, f.cfg)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	f.setDefaults()
+	if f.cfg.BaseDir != "" {
+		f.cfg.BaseDir, err = homedir.Expand(f.cfg.BaseDir)
+		if err != nil {
+			return err
+		}
+	}
+	f.logger.Printf("initialized file credentials provider: base-dir=%q", f.cfg.BaseDir)
+	return nil
+}
+
+func (f *fileProvider) Get(ctx context.Context, secret string) (*credentials.Credentials, error) {
+	if secret == "" {
+		return nil, errors.New("missing secret path")
+	}
+	path, err := homedir.Expand(secret)
+	if err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(path) && f.cfg.BaseDir != "" {
+		path = filepath.Join(f.cfg.BaseDir, path)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if fi.IsDir() {
+		data, err = readDir(path)
+	} else {
+		data, err = readFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.cfg.Debug {
+		f.logger.Printf("read secret %q", path)
+	}
+	c, err := f.cfg.Keys.FromMap(data)
+	if err != nil {
+		return nil, fmt.Errorf("secret %q: %v", path, err)
+	}
+	return c, nil
+}
+
+func (f *fileProvider) RefreshInterval() time.Duration {
+	return f.cfg.RefreshInterval
+}
+
+func (f *fileProvider) SetLogger(logger *log.Logger) {
+	if logger != nil && f.logger != nil {
+		f.logger.SetOutput(logger.Writer())
+		f.logger.SetFlags(logger.Flags())
+	}
+}
+
+func (f *fileProvider) setDefaults() {
+	if f.cfg.Keys == nil {
+		f.cfg.Keys = new(credentials.Keys)
+	}
+	f.cfg.Keys.SetDefaults()
+	if f.cfg.RefreshInterval <= 0 {
+		f.cfg.RefreshInterval = defaultRefreshInterval
+	}
+}
+
+// readDir reads the regular files of dir, the file names are the keys.
+// Hidden files are skipped, e.g: the ..data symlink of a mounted Kubernetes secret.
+func readDir(dir string) (map[string]interface{}, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]interface{})
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		p := filepath.Join(dir, e.Name())
+		// follow symlinks
+		fi, err := os.Stat(p)
+		if err != nil || fi.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		v := string(b)
+		// keep the trailing new line of PEM data
+		if !strings.HasPrefix(v, "-----BEGIN") {
+			v = strings.TrimRight(v, "\r\n")
+		}
+		data[e.Name()] = v
+	}
+	return data, nil
+}
+
+// readFile reads a YAML or JSON file containing the keys.
+func readFile(path string) (map[string]interface{}, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[string]interface{})
+	err = yaml.Unmarshal(b, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %q: %v", path, err)
+	}
+	return raw, nil
+}
@@ -0,0 +1,77 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openconfig/gnmic/credentials"
+)
+
+func TestGet(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "router1")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"username": "admin\n",
+		"password": "secret\r\n",
+		"tls-ca":   "-----BEGIN CERTIFICATE-----\nabc\n-----END CERTIFICATE-----\n",
+		".data":    "skipped",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(base, "router2.yaml"), []byte("user: admin2\npassword: secret2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "empty.yaml"), []byte("other: value\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := credentials.Providers["file"]()
+	err := f.Init(context.TODO(), map[string]interface{}{
+		"base-dir": base,
+		"keys":     map[string]interface{}{"username": "user"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// directory secret, the username key is "user" so it is not found
+	c, err := f.Get(context.TODO(), "router1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &credentials.Credentials{
+		Password: "secret",
+		TLSCA:    []byte("-----BEGIN CERTIFICATE-----\nabc\n-----END CERTIFICATE-----\n"),
+	}
+	if !c.Equal(want) {
+		t.Errorf("got %+v, want %+v", c, want)
+	}
+	// file secret, absolute path
+	c, err = f.Get(context.TODO(), filepath.Join(base, "router2.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &credentials.Credentials{Username: "admin2", Password: "secret2"}
+	if !c.Equal(want) {
+		t.Errorf("got %+v, want %+v", c, want)
+	}
+	for _, secret := range []string{"", "empty.yaml", "missing"} {
+		if _, err := f.Get(context.TODO(), secret); err == nil {
+			t.Errorf("secret %q: expected an error", secret)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vault_credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/openconfig/gnmic/credentials"
+	"github.com/openconfig/gnmic/utils"
+)
+
+const (
+	defaultRefreshInterval = 5 * time.Minute
+	loggingPrefix          = "[vault_credentials] "
+)
+
+func init() {
+	credentials.Register("vault", func() credentials.Provider {
+		return &vaultProvider{
+			cfg:    new(config),
+			logger: log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags),
+		}
+	})
+}
+
+// vaultProvider reads credentials from a HashiCorp Vault KV secrets engine (v1 or v2).
+// The secret reference is the logical path of the secret, e.g: secret/data/routers/r1.
+type vaultProvider struct {
+	cfg    *config
+	client *api.Client
+	logger *log.Logger
+}
+
+type config struct {
+	// Vault address, defaults to the VAULT_ADDR env variable
+	Address string `mapstructure:"address,omitempty" json:"address,omitempty"`
+	// Vault token, defaults to the VAULT_TOKEN env variable
+	Token      string            `mapstructure:"token,omitempty" json:"token,omitempty"`
+	Namespace  string            `mapstructure:"namespace,omitempty" json:"namespace,omitempty"`
+	TLSCA      string            `mapstructure:"tls-ca,omitempty" json:"tls-ca,omitempty"`
+	TLSCert    string            `mapstructure:"tls-cert,omitempty" json:"tls-cert,omitempty"`
+	TLSKey     string            `mapstructure:"tls-key,omitempty" json:"tls-key,omitempty"`
+	SkipVerify bool              `mapstructure:"skip-verify,omitempty" json:"skip-verify,omitempty"`
+	Keys       *credentials.Keys `mapstructure:"keys,omitempty" json:"keys,omitempty"`
+	// interval at which the secrets are re-read
+	RefreshInterval time.Duration `mapstructure:"refresh-interval,omitempty" json:"refresh-interval,omitempty"`
+	Debug           bool          `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+}
+
+func (v *vaultProvider) Init(ctx context.Context, cfg map[string]interface{}, opts ...credentials.Option) error {
+	err := credentials.DecodeConfig(cfg, v.cfg)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	v.setDefaults()
+	clientConfig := api.DefaultConfig()
+	if clientConfig.Error != nil {
+		return clientConfig.Error
+	}
+	if v.cfg.Address != "" {
+		clientConfig.Address = v.cfg.Address
+	}
+	if v.cfg.TLSCA != "" || v.cfg.TLSCert != "" || v.cfg.SkipVerify {
+		err = clientConfig.ConfigureTLS(&api.TLSConfig{
+			CACert:     v.cfg.TLSCA,
+			ClientCert: v.cfg.TLSCert,
+			ClientKey:  v.cfg.TLSKey,
+			Insecure:   v.cfg.SkipVerify,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	v.client, err = api.NewClient(clientConfig)
+	if err != nil {
+		return err
+	}
+	if v.cfg.Token != "" {
+		v.client.SetToken(v.cfg.Token)
+	}
+	if v.cfg.Namespace != "" {
+		v.client.SetNamespace(v.cfg.Namespace)
+	}
+	v.logger.Printf("initialized vault credentials provider: address=%s", clientConfig.Address)
+	return nil
+}
+
+func (v *vaultProvider) Get(ctx context.Context, secret string) (*credentials.Credentials, error) {
+	data, err := v.ReadSecret(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+	c, err := v.cfg.Keys.FromMap(data)
+	if err != nil {
+		return nil, fmt.Errorf("secret %q: %v", secret, err)
+	}
+	return c, nil
+}
+
+func (v *vaultProvider) ReadSecret(ctx context.Context, path string) (map[string]interface{}, error) {
+	if path == "" {
+		return nil, errors.New("missing secret path")
+	}
+	s, err := v.client.Logical().Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil || s.Data == nil {
+		return nil, fmt.Errorf("secret %q not found", path)
+	}
+	data := s.Data
+	// KV v2 secrets are nested under a "data" key
+	if d, ok := data["data"].(map[string]interface{}); ok {
+		data = d
+	}
+	if v.cfg.Debug {
+		v.logger.Printf("read secret %q", path)
+	}
+	return data, nil
+}
+
+func (v *vaultProvider) RefreshInterval() time.Duration {
+	return v.cfg.RefreshInterval
+}
+
+func (v *vaultProvider) SetLogger(logger *log.Logger) {
+	if logger != nil && v.logger != nil {
+		v.logger.SetOutput(logger.Writer())
+		v.logger.SetFlags(logger.Flags())
+	}
+}
+
+func (v *vaultProvider) setDefaults() {
+	if v.cfg.Keys == nil {
+		v.cfg.Keys = new(credentials.Keys)
+	}
+	v.cfg.Keys.SetDefaults()
+	if v.cfg.RefreshInterval <= 0 {
+		v.cfg.RefreshInterval = defaultRefreshInterval
+	}
+}
@@ -0,0 +1,69 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vault_credentials
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openconfig/gnmic/credentials"
+)
+
+func TestGet(t *testing.T) {
+	secrets := map[string]string{
+		// KV v1
+		"/v1/kv/router1": `{"data":{"username":"admin","password":"secret1"}}`,
+		// KV v2
+		"/v1/secret/data/router2": `{"data":{"data":{"username":"admin","token":"tok2"},"metadata":{"version":1}}}`,
+		"/v1/kv/other":            `{"data":{"key":"value"}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		s, ok := secrets[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(s))
+	}))
+	defer srv.Close()
+
+	v := credentials.Providers["vault"]()
+	err := v.Init(context.TODO(), map[string]interface{}{
+		"address": srv.URL,
+		"token":   "root",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for secret, want := range map[string]*credentials.Credentials{
+		"kv/router1":          {Username: "admin", Password: "secret1"},
+		"secret/data/router2": {Username: "admin", Token: "tok2"},
+	} {
+		c, err := v.Get(context.TODO(), secret)
+		if err != nil {
+			t.Errorf("secret %q: %v", secret, err)
+			continue
+		}
+		if !c.Equal(want) {
+			t.Errorf("secret %q: got %+v, want %+v", secret, c, want)
+		}
+	}
+	for _, secret := range []string{"", "kv/other", "kv/missing"} {
+		if _, err := v.Get(context.TODO(), secret); err == nil {
+			t.Errorf("secret %q: expected an error", secret)
+		}
+	}
+}
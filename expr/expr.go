@@ -0,0 +1,84 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package expr implements the CEL (https://github.com/google/cel-spec)
+// expressions evaluated by gNMIc against events: in event processors conditions,
+// in the watch command and in triggers.
+package expr
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+)
+
+// Program is a compiled CEL expression.
+type Program struct {
+	src string
+	prg cel.Program
+}
+
+// Compile parses and checks the CEL expression src.
+// The expression can reference the given variables names, all of dynamic type.
+func Compile(src string, vars ...string) (*Program, error) {
+	opts := make([]cel.EnvOption, 0, len(vars)+2)
+	opts = append(opts,
+		ext.Strings(),
+		cel.CrossTypeNumericComparisons(true),
+	)
+	for _, v := range vars {
+		opts = append(opts, cel.Variable(v, cel.DynType))
+	}
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := env.Compile(src)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{src: src, prg: prg}, nil
+}
+
+// String returns the expression source.
+func (p *Program) String() string {
+	return p.src
+}
+
+// Eval evaluates the program with the given variables values
+// and returns the result as a native Go value.
+func (p *Program) Eval(vars map[string]interface{}) (interface{}, error) {
+	val, _, err := p.prg.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if types.IsUnknownOrError(val) {
+		return nil, fmt.Errorf("%v", val)
+	}
+	return val.Value(), nil
+}
+
+// EvalBool evaluates the program with the given variables values,
+// the expression must return a boolean.
+func (p *Program) EvalBool(vars map[string]interface{}) (bool, error) {
+	res, err := p.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := res.(bool)
+	if !ok {
+		return false, fmt.Errorf("unexpected expression return type: %T | %v", res, res)
+	}
+	return b, nil
+}
@@ -0,0 +1,93 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	vars := map[string]interface{}{
+		"name": "sub1",
+		"tags": map[string]string{"source": "router1:57400", "interface_name": "ethernet-1/1"},
+		"values": map[string]interface{}{
+			"/interface/statistics/in-octets": int64(42),
+			"/interface/oper-state":           "up",
+		},
+	}
+	tests := map[string]struct {
+		src    string
+		result interface{}
+		err    bool
+	}{
+		"string_equal": {
+			src:    `tags.interface_name == "ethernet-1/1"`,
+			result: true,
+		},
+		"numeric_comparison": {
+			src:    `values["/interface/statistics/in-octets"] > 40.5`,
+			result: true,
+		},
+		"string_extension": {
+			src:    `tags.source.split(":")[0]`,
+			result: "router1",
+		},
+		"key_presence": {
+			src:    `"/interface/admin-state" in values`,
+			result: false,
+		},
+		"missing_key": {
+			src: `values["/interface/admin-state"] == "enable"`,
+			err: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := Compile(tc.src, "name", "tags", "values")
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := p.Eval(vars)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error, got %v", res)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(res, tc.result) {
+				t.Errorf("expected (%T)%v, got (%T)%v", tc.result, tc.result, res, res)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{
+		`unknown_var == 1`,
+		`tags.name ==`,
+	} {
+		if _, err := Compile(src, "tags"); err == nil {
+			t.Errorf("expected compile error for %q", src)
+		}
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	p, err := Compile(`name + "_suffix"`, "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.EvalBool(map[string]interface{}{"name": "sub1"}); err == nil {
+		t.Error("expected an error for a non boolean expression")
+	}
+}
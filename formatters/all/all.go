@@ -11,9 +11,11 @@ package all
 import (
 	_ "github.com/openconfig/gnmic/formatters/event_add_tag"
 	_ "github.com/openconfig/gnmic/formatters/event_allow"
+	_ "github.com/openconfig/gnmic/formatters/event_cardinality"
 	_ "github.com/openconfig/gnmic/formatters/event_convert"
 	_ "github.com/openconfig/gnmic/formatters/event_data_convert"
 	_ "github.com/openconfig/gnmic/formatters/event_date_string"
+	_ "github.com/openconfig/gnmic/formatters/event_dedup"
 	_ "github.com/openconfig/gnmic/formatters/event_delete"
 	_ "github.com/openconfig/gnmic/formatters/event_drop"
 	_ "github.com/openconfig/gnmic/formatters/event_duration_convert"
@@ -22,6 +24,8 @@ import (
 	_ "github.com/openconfig/gnmic/formatters/event_jq"
 	_ "github.com/openconfig/gnmic/formatters/event_merge"
 	_ "github.com/openconfig/gnmic/formatters/event_override_ts"
+	_ "github.com/openconfig/gnmic/formatters/event_recording_rules"
+	_ "github.com/openconfig/gnmic/formatters/event_sequence"
 	_ "github.com/openconfig/gnmic/formatters/event_strings"
 	_ "github.com/openconfig/gnmic/formatters/event_to_tag"
 	_ "github.com/openconfig/gnmic/formatters/event_trigger"
@@ -0,0 +1,69 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package formatters
+
+import (
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/utils"
+)
+
+// DeleteMsg is a path deleted from a target,
+// described the same way an event describes the values of an updated path.
+type DeleteMsg struct {
+	// Name is the subscription name
+	Name      string
+	Timestamp int64
+	// Path is the deleted path, including the prefix and without keys.
+	// In events, the names of the values under that path start with it.
+	Path string
+	// XPath is the deleted path, including the prefix and the keys.
+	XPath string
+	// Tags are the prefix and path keys as well as the metadata tags,
+	// as set on the events of updates to the deleted path.
+	Tags map[string]string
+	// ListEntry is true if the deleted path ends with a list element
+	// identified by its keys.
+	ListEntry bool
+}
+
+// NotificationDeletes returns a DeleteMsg for each deleted path of the notification n.
+func NotificationDeletes(name string, n *gnmi.Notification, meta map[string]string) []*DeleteMsg {
+	if len(n.GetDelete()) == 0 {
+		return nil
+	}
+	namePrefix, prefixTags := TagsFromGNMIPath(n.GetPrefix())
+	dels := make([]*DeleteMsg, 0, len(n.GetDelete()))
+	for _, p := range n.GetDelete() {
+		// the tags of an update without value to the deleted path
+		e := &EventMsg{}
+		updateToEvent(e, namePrefix, &gnmi.Update{Path: p}, prefixTags, len(meta))
+		addMetaTags(e.Tags, meta, "meta_")
+		elems := p.GetElem()
+		if len(elems) == 0 && p.GetOrigin() == "" {
+			elems = n.GetPrefix().GetElem()
+		}
+		fp := &gnmi.Path{Origin: p.GetOrigin(), Elem: p.GetElem()}
+		if fp.Origin == "" {
+			fp.Origin = n.GetPrefix().GetOrigin()
+			fp.Elem = append(append(make([]*gnmi.PathElem, 0, len(n.GetPrefix().GetElem())+len(p.GetElem())),
+				n.GetPrefix().GetElem()...), p.GetElem()...)
+		}
+		dels = append(dels, &DeleteMsg{
+			Name:      name,
+			Timestamp: n.GetTimestamp(),
+			Path:      strings.TrimRight(valueName(namePrefix, p), "/"),
+			XPath:     utils.GnmiPathToXPath(fp, false),
+			Tags:      e.Tags,
+			ListEntry: len(elems) > 0 && len(elems[len(elems)-1].GetKey()) > 0,
+		})
+	}
+	return dels
+}
@@ -0,0 +1,80 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package formatters
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func TestNotificationDeletes(t *testing.T) {
+	n := &gnmi.Notification{
+		Timestamp: 42,
+		Prefix: &gnmi.Path{
+			Target: "router1",
+			Elem:   []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}},
+		},
+		Delete: []*gnmi.Path{
+			{Elem: []*gnmi.PathElem{{Name: "subinterface", Key: map[string]string{"index": "0"}}}},
+			{Elem: []*gnmi.PathElem{{Name: "description"}}},
+			{},
+		},
+	}
+	meta := map[string]string{"source": "router1:57400", "subscription-name": "sub1"}
+	got := NotificationDeletes("sub1", n, meta)
+	want := []*DeleteMsg{
+		{
+			Name:      "sub1",
+			Timestamp: 42,
+			Path:      "/interface/subinterface",
+			XPath:     "interface[name=ethernet-1/1]/subinterface[index=0]",
+			Tags: map[string]string{
+				"target":             "router1",
+				"interface_name":     "ethernet-1/1",
+				"subinterface_index": "0",
+				"source":             "router1:57400",
+				"subscription-name":  "sub1",
+			},
+			ListEntry: true,
+		},
+		{
+			Name:      "sub1",
+			Timestamp: 42,
+			Path:      "/interface/description",
+			XPath:     "interface[name=ethernet-1/1]/description",
+			Tags: map[string]string{
+				"target":            "router1",
+				"interface_name":    "ethernet-1/1",
+				"source":            "router1:57400",
+				"subscription-name": "sub1",
+			},
+		},
+		{
+			Name:      "sub1",
+			Timestamp: 42,
+			Path:      "/interface",
+			XPath:     "interface[name=ethernet-1/1]",
+			Tags: map[string]string{
+				"target":            "router1",
+				"interface_name":    "ethernet-1/1",
+				"source":            "router1:57400",
+				"subscription-name": "sub1",
+			},
+			ListEntry: true,
+		},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("NotificationDeletes() mismatch: %s", cmp.Diff(want, got))
+	}
+	if dels := NotificationDeletes("sub1", &gnmi.Notification{}, meta); dels != nil {
+		t.Errorf("expected no deletes, got %v", dels)
+	}
+}
@@ -0,0 +1,54 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package formatters
+
+import (
+	"fmt"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/proto/envelope"
+	"google.golang.org/protobuf/proto"
+)
+
+// FormatProtoEnvelope is the format name of binary marshaled
+// responses wrapped in an envelope carrying their metadata.
+const FormatProtoEnvelope = "proto-envelope"
+
+// marshalEnvelope marshals msg in binary wire format and wraps it,
+// together with meta, in an envelope.Envelope.
+func marshalEnvelope(msg proto.Message, meta map[string]string) ([]byte, error) {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(&envelope.Envelope{
+		Response: b,
+		Meta:     meta,
+	})
+}
+
+// UnmarshalEnvelope decodes b, as written by an output with format proto-envelope,
+// into a SubscribeResponse and its metadata.
+func UnmarshalEnvelope(b []byte) (*gnmi.SubscribeResponse, map[string]string, error) {
+	env := new(envelope.Envelope)
+	err := proto.Unmarshal(b, env)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal envelope: %v", err)
+	}
+	rsp := new(gnmi.SubscribeResponse)
+	err = proto.Unmarshal(env.GetResponse(), rsp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal envelope response: %v", err)
+	}
+	meta := env.GetMeta()
+	if meta == nil {
+		meta = make(map[string]string)
+	}
+	return rsp, meta, nil
+}
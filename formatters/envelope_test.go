@@ -0,0 +1,75 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package formatters
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProtoEnvelope(t *testing.T) {
+	rsp := &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Timestamp: 42,
+				Prefix:    &gnmi.Path{Target: "router1"},
+				Update: []*gnmi.Update{
+					{
+						Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}, {Name: "description"}}},
+						Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "uplink"}},
+					},
+				},
+			},
+		},
+	}
+	meta := map[string]string{
+		"source":            "router1:57400",
+		"subscription-name": "sub1",
+	}
+	mo := &MarshalOptions{Format: FormatProtoEnvelope}
+	b, err := mo.Marshal(rsp, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := mo.Marshal(rsp, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != string(b2) {
+		t.Errorf("envelope encoding is not deterministic")
+	}
+	gotRsp, gotMeta, err := UnmarshalEnvelope(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(gotRsp, rsp) {
+		t.Errorf("response mismatch: got %v, want %v", gotRsp, rsp)
+	}
+	if !cmp.Equal(gotMeta, meta) {
+		t.Errorf("meta mismatch: %s", cmp.Diff(meta, gotMeta))
+	}
+	// an envelope without meta returns an empty meta map
+	b, err = mo.Marshal(rsp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, gotMeta, err = UnmarshalEnvelope(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotMeta == nil || len(gotMeta) != 0 {
+		t.Errorf("expected an empty meta map, got %v", gotMeta)
+	}
+	if _, _, err = UnmarshalEnvelope([]byte{0xff}); err == nil {
+		t.Errorf("expected an error decoding an invalid envelope")
+	}
+}
@@ -11,13 +11,23 @@ package formatters
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
-	flattener "github.com/karimra/go-map-flattener"
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmic/utils"
 )
 
+// VarsMetaPrefix is the prefix of the metadata keys carrying the target variables.
+const VarsMetaPrefix = "vars."
+
+// metadata keys carrying the device and arrival timestamps of a notification,
+// they are added to the events values instead of their tags.
+const (
+	DeviceTimestampMetaKey  = "device-timestamp"
+	ArrivalTimestampMetaKey = "arrival-timestamp"
+)
+
 // EventMsg represents a gNMI update message,
 // The name is derived from the subscription in case the update was received in a subscribeResponse
 // the tags are derived from the keys in gNMI path as well as some metadata from the subscription.
@@ -39,63 +49,70 @@ func ResponseToEventMsgs(name string, rsp *gnmi.SubscribeResponse, meta map[stri
 	if rsp == nil {
 		return nil, nil
 	}
-	evs := make([]*EventMsg, 0)
 	switch rsp := rsp.Response.(type) {
 	case *gnmi.SubscribeResponse_Update:
-		namePrefix, prefixTags := TagsFromGNMIPath(rsp.Update.Prefix)
-		// notification updates
-		for _, upd := range rsp.Update.GetUpdate() {
-			e, err := updateToEvent(name, namePrefix, rsp.Update.Timestamp, upd, prefixTags)
-			if err != nil {
-				return nil, err
-			}
-			for k, v := range meta {
-				if k == "format" {
-					continue
-				}
-				if _, ok := e.Tags[k]; ok {
-					e.Tags[fmt.Sprintf("meta_%s", k)] = v
-					continue
-				}
-				e.Tags[k] = v
-			}
-			if (e != nil && e != &EventMsg{}) {
-				evs = append(evs, e)
-			}
+		evs, del, err := notificationToEvents(name, rsp.Update, meta, false)
+		if err != nil {
+			return nil, err
 		}
 		for _, ep := range eps {
 			evs = ep.Apply(evs...)
 		}
-		// notification deletes
-		if len(rsp.Update.Delete) > 0 {
-			e := &EventMsg{
-				Name:      name,
-				Timestamp: rsp.Update.Timestamp,
-				Tags:      make(map[string]string),
-				Deletes:   make([]string, 0, len(rsp.Update.Delete)),
-			}
-			// build tags
-			for k, v := range prefixTags {
-				e.Tags[k] = v
-			}
-			for k, v := range meta {
-				if k == "format" {
-					continue
-				}
-				if _, ok := e.Tags[k]; ok {
-					e.Tags[fmt.Sprintf("meta_%s", k)] = v
-					continue
-				}
-				e.Tags[k] = v
+		if del != nil {
+			evs = append(evs, del)
+		}
+		return evs, nil
+	}
+	return make([]*EventMsg, 0), nil
+}
+
+// notificationToEvents converts the updates of a notification to a list of events,
+// and its deletes to a single event.
+// If pooled is true, the events are taken from the event pool.
+func notificationToEvents(name string, n *gnmi.Notification, meta map[string]string, pooled bool) ([]*EventMsg, *EventMsg, error) {
+	evs := make([]*EventMsg, 0, len(n.GetUpdate())+1)
+	namePrefix, prefixTags := TagsFromGNMIPath(n.GetPrefix())
+	tsValues := timestampValues(meta)
+	// notification updates
+	for _, upd := range n.GetUpdate() {
+		e := newEventMsg(name, n.GetTimestamp(), pooled)
+		err := updateToEvent(e, namePrefix, upd, prefixTags, len(meta))
+		if err != nil {
+			if pooled {
+				releaseEventMsgs(evs...)
+				releaseEventMsgs(e)
 			}
-			// add paths
-			for _, del := range rsp.Update.Delete {
-				e.Deletes = append(e.Deletes, utils.GnmiPathToXPath(del, false))
+			return nil, nil, err
+		}
+		addMetaTags(e.Tags, meta, "meta_")
+		if e.Values != nil {
+			for k, v := range tsValues {
+				e.Values[k] = v
 			}
-			evs = append(evs, e)
 		}
+		evs = append(evs, e)
 	}
-	return evs, nil
+	if len(n.GetDelete()) == 0 {
+		return evs, nil, nil
+	}
+	// notification deletes
+	e := newEventMsg(name, n.GetTimestamp(), pooled)
+	if e.Tags == nil {
+		e.Tags = make(map[string]string, len(prefixTags)+len(meta))
+	}
+	if e.Deletes == nil {
+		e.Deletes = make([]string, 0, len(n.GetDelete()))
+	}
+	// build tags
+	for k, v := range prefixTags {
+		e.Tags[k] = v
+	}
+	addMetaTags(e.Tags, meta, "meta_")
+	// add paths
+	for _, del := range n.GetDelete() {
+		e.Deletes = append(e.Deletes, utils.GnmiPathToXPath(del, false))
+	}
+	return evs, e, nil
 }
 
 func GetResponseToEventMsgs(rsp *gnmi.GetResponse, meta map[string]string, eps ...EventProcessor) ([]*EventMsg, error) {
@@ -106,23 +123,13 @@ func GetResponseToEventMsgs(rsp *gnmi.GetResponse, meta map[string]string, eps .
 	for _, notif := range rsp.GetNotification() {
 		namePrefix, prefixTags := TagsFromGNMIPath(notif.GetPrefix())
 		for _, upd := range notif.GetUpdate() {
-			e, err := updateToEvent("get-request", namePrefix, notif.GetTimestamp(), upd, prefixTags)
+			e := newEventMsg("get-request", notif.GetTimestamp(), false)
+			err := updateToEvent(e, namePrefix, upd, prefixTags, len(meta))
 			if err != nil {
 				return nil, err
 			}
-			for k, v := range meta {
-				if k == "format" {
-					continue
-				}
-				if _, ok := e.Tags[k]; ok {
-					e.Tags["meta:"+k] = v
-					continue
-				}
-				e.Tags[k] = v
-			}
-			if (e != nil && e != &EventMsg{}) {
-				evs = append(evs, e)
-			}
+			addMetaTags(e.Tags, meta, "meta:")
+			evs = append(evs, e)
 		}
 		for _, ep := range eps {
 			evs = ep.Apply(evs...)
@@ -131,37 +138,104 @@ func GetResponseToEventMsgs(rsp *gnmi.GetResponse, meta map[string]string, eps .
 	return evs, nil
 }
 
-func updateToEvent(name, prefix string, ts int64, upd *gnmi.Update, tags map[string]string) (*EventMsg, error) {
-	e := &EventMsg{
-		Name:      name,
-		Timestamp: ts,
-		Tags:      make(map[string]string),
-		Values:    make(map[string]interface{}),
+// addMetaTags adds the meta values to tags, except for the format and timestamps.
+// a meta key that is already a tag is added with the given prefix.
+func addMetaTags(tags map[string]string, meta map[string]string, prefix string) {
+	for k, v := range meta {
+		switch k {
+		case "format", DeviceTimestampMetaKey, ArrivalTimestampMetaKey:
+			continue
+		}
+		// target variables are added as tags named after the variable.
+		k = strings.TrimPrefix(k, VarsMetaPrefix)
+		if _, ok := tags[k]; ok {
+			tags[prefix+k] = v
+			continue
+		}
+		tags[k] = v
+	}
+}
+
+// timestampValues returns the device and arrival timestamps found in meta.
+func timestampValues(meta map[string]string) map[string]interface{} {
+	var vals map[string]interface{}
+	for _, k := range []string{DeviceTimestampMetaKey, ArrivalTimestampMetaKey} {
+		v, ok := meta[k]
+		if !ok {
+			continue
+		}
+		ts, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		if vals == nil {
+			vals = make(map[string]interface{}, 2)
+		}
+		vals[k] = ts
+	}
+	return vals
+}
+
+// updateToEvent sets the tags and values of e from a gNMI update.
+// the tags are the prefix tags followed by the keys in the update path,
+// a path key that conflicts with a prefix tag is named after the full path.
+// sizeHint is the number of extra tags the caller will add.
+func updateToEvent(e *EventMsg, prefix string, upd *gnmi.Update, tags map[string]string, sizeHint int) error {
+	p := upd.GetPath()
+	numKeys := 0
+	for _, pe := range p.GetElem() {
+		numKeys += len(pe.GetKey())
+	}
+	if e.Tags == nil {
+		e.Tags = make(map[string]string, len(tags)+numKeys+sizeHint)
 	}
 	for k, v := range tags {
 		e.Tags[k] = v
 	}
-	pathName, pTags := TagsFromGNMIPath(upd.Path)
-	psb := strings.Builder{}
-	psb.WriteString(strings.TrimRight(prefix, "/"))
-	psb.WriteString("/")
-	psb.WriteString(strings.TrimLeft(pathName, "/"))
-	pathName = psb.String()
-	for k, v := range pTags {
-		if vv, ok := e.Tags[k]; ok {
-			if v != vv {
-				e.Tags[fmt.Sprintf("%s_%s", pathName, k)] = v
+	pathName := valueName(prefix, p)
+	if numKeys > 0 || p.GetTarget() != "" {
+		addPathTag := func(k, v string) {
+			if vv, ok := tags[k]; ok {
+				if v != vv {
+					e.Tags[pathName+"_"+k] = v
+				}
+				return
 			}
-			continue
+			e.Tags[k] = v
+		}
+		for _, pe := range p.GetElem() {
+			for k, v := range pe.GetKey() {
+				addPathTag(pathKeyTag(pe.GetName(), k), v)
+			}
+		}
+		if p.GetTarget() != "" {
+			addPathTag("target", p.GetTarget())
 		}
-		e.Tags[k] = v
 	}
-	var err error
-	e.Values, err = getValueFlat(pathName, upd.GetVal())
-	if err != nil {
-		return nil, err
+	if upd.GetVal() == nil {
+		e.Values = nil
+		return nil
 	}
-	return e, nil
+	if e.Values == nil {
+		e.Values = make(map[string]interface{})
+	}
+	return flattenValue(e.Values, pathName, upd.GetVal())
+}
+
+// valueName returns the name of the values of path p under the prefix name prefix.
+func valueName(prefix string, p *gnmi.Path) string {
+	psb := strings.Builder{}
+	prefix = strings.TrimRight(prefix, "/")
+	nameLen := pathNameLen(p)
+	psb.Grow(len(prefix) + 1 + nameLen)
+	psb.WriteString(prefix)
+	// without an origin, a non empty path name starts with
+	// the "/" separating it from the prefix.
+	if p.GetOrigin() != "" || nameLen == 0 {
+		psb.WriteString("/")
+	}
+	writePathName(&psb, p)
+	return psb.String()
 }
 
 // TagsFromGNMIPath returns a string representation of the gNMI path without keys,
@@ -173,28 +247,11 @@ func TagsFromGNMIPath(p *gnmi.Path) (string, map[string]string) {
 	}
 	tags := make(map[string]string)
 	sb := strings.Builder{}
-	if p.Origin != "" {
-		sb.WriteString(p.Origin)
-		sb.WriteString(":")
-	}
+	sb.Grow(pathNameLen(p))
+	writePathName(&sb, p)
 	for _, e := range p.Elem {
-		if e.Name != "" {
-			sb.WriteString("/")
-			sb.WriteString(e.Name)
-		}
-		if e.Key != nil {
-			for k, v := range e.Key {
-				if e.Name == "" {
-					tags[k] = v
-					continue
-				}
-				elems := strings.Split(e.Name, ":")
-				ksb := strings.Builder{}
-				ksb.WriteString(elems[len(elems)-1])
-				ksb.WriteString("_")
-				ksb.WriteString(k)
-				tags[ksb.String()] = v
-			}
+		for k, v := range e.Key {
+			tags[pathKeyTag(e.Name, k)] = v
 		}
 	}
 	if p.GetTarget() != "" {
@@ -203,12 +260,64 @@ func TagsFromGNMIPath(p *gnmi.Path) (string, map[string]string) {
 	return sb.String(), tags
 }
 
+// writePathName writes the origin and element names of the gNMI path to sb.
+func writePathName(sb *strings.Builder, p *gnmi.Path) {
+	if p.GetOrigin() != "" {
+		sb.WriteString(p.GetOrigin())
+		sb.WriteString(":")
+	}
+	for _, e := range p.GetElem() {
+		if e.GetName() != "" {
+			sb.WriteString("/")
+			sb.WriteString(e.GetName())
+		}
+	}
+}
+
+func pathNameLen(p *gnmi.Path) int {
+	l := 0
+	if p.GetOrigin() != "" {
+		l += len(p.GetOrigin()) + 1
+	}
+	for _, e := range p.GetElem() {
+		if e.GetName() != "" {
+			l += len(e.GetName()) + 1
+		}
+	}
+	return l
+}
+
+// pathKeyTag returns the tag name of a path element key,
+// it is the element name without its module prefix and the key name.
+func pathKeyTag(name, key string) string {
+	if name == "" {
+		return key
+	}
+	if i := strings.LastIndexByte(name, ':'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name + "_" + key
+}
+
 func getValueFlat(prefix string, updValue *gnmi.TypedValue) (map[string]interface{}, error) {
 	if updValue == nil {
 		return nil, nil
 	}
-	var jsondata []byte
 	values := make(map[string]interface{})
+	err := flattenValue(values, prefix, updValue)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// flattenValue adds the gNMI typed value to values,
+// JSON values are flattened into one value per leaf, named after their path under prefix.
+func flattenValue(values map[string]interface{}, prefix string, updValue *gnmi.TypedValue) error {
+	if updValue == nil {
+		return nil
+	}
+	var jsondata []byte
 	switch updValue.Value.(type) {
 	case *gnmi.TypedValue_AsciiVal:
 		values[prefix] = updValue.GetAsciiVal()
@@ -222,6 +331,8 @@ func getValueFlat(prefix string, updValue *gnmi.TypedValue) (map[string]interfac
 	case *gnmi.TypedValue_FloatVal:
 		//lint:ignore SA1019 still need GetFloatVal for backward compatibility
 		values[prefix] = updValue.GetFloatVal()
+	case *gnmi.TypedValue_DoubleVal:
+		values[prefix] = updValue.GetDoubleVal()
 	case *gnmi.TypedValue_IntVal:
 		values[prefix] = updValue.GetIntVal()
 	case *gnmi.TypedValue_StringVal:
@@ -229,11 +340,12 @@ func getValueFlat(prefix string, updValue *gnmi.TypedValue) (map[string]interfac
 	case *gnmi.TypedValue_UintVal:
 		values[prefix] = updValue.GetUintVal()
 	case *gnmi.TypedValue_LeaflistVal:
-		leafListVals := make([]interface{}, 0)
-		for _, tv := range updValue.GetLeaflistVal().GetElement() {
+		elems := updValue.GetLeaflistVal().GetElement()
+		leafListVals := make([]interface{}, 0, len(elems))
+		for _, tv := range elems {
 			v, err := getValue(tv)
 			if err != nil {
-				return nil, err
+				return err
 			}
 			leafListVals = append(leafListVals, v)
 		}
@@ -247,25 +359,54 @@ func getValueFlat(prefix string, updValue *gnmi.TypedValue) (map[string]interfac
 	case *gnmi.TypedValue_JsonVal:
 		jsondata = updValue.GetJsonVal()
 	}
-	if len(jsondata) != 0 {
-		var value interface{}
-		err := json.Unmarshal(jsondata, &value)
-		if err != nil {
-			return nil, err
+	if len(jsondata) == 0 {
+		return nil
+	}
+	var value interface{}
+	err := json.Unmarshal(jsondata, &value)
+	if err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		key := make([]byte, 0, len(prefix)+64)
+		key = append(key, prefix...)
+		for k, v := range value {
+			err = flattenJSON(values, append(append(key, '/'), k...), v)
+			if err != nil {
+				return err
+			}
 		}
-		switch value := value.(type) {
-		case map[string]interface{}:
-			f := flattener.NewFlattener()
-			f.SetPrefix(prefix)
-			values, err = f.Flatten(value)
-		default:
-			values[prefix] = value
+	default:
+		values[prefix] = value
+	}
+	return nil
+}
+
+// flattenJSON adds the leaves of a decoded JSON value to values.
+// nested objects are separated with "/" and list elements are indexed with ".".
+// key is reused as a scratch buffer by the recursive calls.
+// leaves with an unexpected type, such as null, are skipped within objects
+// and reported as an error within lists.
+func flattenJSON(values map[string]interface{}, key []byte, v interface{}) error {
+	switch v := v.(type) {
+	case bool, float64, string:
+		values[string(key)] = v
+	case map[string]interface{}:
+		for k, vv := range v {
+			flattenJSON(values, append(append(key, '/'), k...), vv)
 		}
-		if err != nil {
-			return nil, err
+	case []interface{}:
+		for i, vv := range v {
+			err := flattenJSON(values, strconv.AppendInt(append(key, '.'), int64(i), 10), vv)
+			if err != nil {
+				return err
+			}
 		}
+	default:
+		return fmt.Errorf("unexpected type: %T, %v", v, v)
 	}
-	return values, nil
+	return nil
 }
 
 func (e *EventMsg) ToMap() map[string]interface{} {
@@ -10,6 +10,7 @@ package event_add_tag
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"os"
@@ -17,6 +18,7 @@ import (
 	"strings"
 
 	"github.com/itchyny/gojq"
+	"github.com/openconfig/gnmic/expr"
 	"github.com/openconfig/gnmic/formatters"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
@@ -30,6 +32,7 @@ const (
 // AddTag adds a set of tags to the event message if tag
 type AddTag struct {
 	Condition  string            `mapstructure:"condition,omitempty"`
+	Expression string            `mapstructure:"expression,omitempty" json:"expression,omitempty"`
 	Tags       []string          `mapstructure:"tags,omitempty" json:"tags,omitempty"`
 	Values     []string          `mapstructure:"values,omitempty" json:"values,omitempty"`
 	TagNames   []string          `mapstructure:"tag-names,omitempty" json:"tag-names,omitempty"`
@@ -43,6 +46,7 @@ type AddTag struct {
 	tagNames   []*regexp.Regexp
 	valueNames []*regexp.Regexp
 	code       *gojq.Code
+	prg        *expr.Program
 	logger     *log.Logger
 }
 
@@ -73,6 +77,15 @@ func (p *AddTag) Init(cfg interface{}, opts ...formatters.Option) error {
 			return err
 		}
 	}
+	if p.Expression != "" {
+		if p.Condition != "" {
+			return errors.New("condition and expression are mutually exclusive")
+		}
+		p.prg, err = formatters.CompileExpression(p.Expression)
+		if err != nil {
+			return err
+		}
+	}
 	// init tags regex
 	p.tags = make([]*regexp.Regexp, 0, len(p.Tags))
 	for _, reg := range p.Tags {
@@ -137,6 +150,17 @@ func (p *AddTag) Apply(es ...*formatters.EventMsg) []*formatters.EventMsg {
 			}
 			continue
 		}
+		// expression is set
+		if p.prg != nil {
+			ok, err := formatters.CheckExpression(p.prg, e)
+			if err != nil {
+				p.logger.Printf("expression check failed: %v", err)
+			}
+			if ok {
+				p.addTags(e)
+			}
+			continue
+		}
 		// no condition, check regexes
 		for k, v := range e.Values {
 			for _, re := range p.valueNames {
@@ -73,6 +73,54 @@ var testset = map[string]struct {
 			},
 		},
 	},
+	"match_expression": {
+		processorType: processorType,
+		processor: map[string]interface{}{
+			"expression": `"value" in values && values.value == 1`,
+			"add":        map[string]string{"tag1": "new_tag"},
+		},
+		tests: []item{
+			{
+				input:  nil,
+				output: nil,
+			},
+			{
+				input:  make([]*formatters.EventMsg, 0),
+				output: make([]*formatters.EventMsg, 0),
+			},
+			{
+				input: []*formatters.EventMsg{
+					{
+						Values: map[string]interface{}{"value": 1},
+						Tags:   map[string]string{"tag1": "1"},
+					},
+				},
+				output: []*formatters.EventMsg{
+					{
+						Values: map[string]interface{}{"value": 1},
+						Tags: map[string]string{
+							"tag1": "1",
+						},
+					},
+				},
+			},
+			{
+				input: []*formatters.EventMsg{
+					{
+						Values: map[string]interface{}{"value": 1},
+					},
+				},
+				output: []*formatters.EventMsg{
+					{
+						Values: map[string]interface{}{"value": 1},
+						Tags: map[string]string{
+							"tag1": "new_tag",
+						},
+					},
+				},
+			},
+		},
+	},
 	"match_condition_overwrite": {
 		processorType: processorType,
 		processor: map[string]interface{}{
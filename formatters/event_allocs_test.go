@@ -0,0 +1,36 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !race
+
+package formatters
+
+import "testing"
+
+// maxMarshalEventAllocs is the allocation ceiling for marshaling a notification
+// with 21 updates and a delete to the event format.
+// Going through encoding/json reflection took over 500.
+const maxMarshalEventAllocs = 160
+
+// TestMarshalEventAllocs guards the allocation count of the event format,
+// the hot path of most outputs.
+// It is skipped with the race detector, which randomly drops pooled items.
+func TestMarshalEventAllocs(t *testing.T) {
+	rsp := benchmarkResponse(20)
+	meta := map[string]string{"source": "r1", "subscription-name": "sub1"}
+	mo := &MarshalOptions{Format: "event"}
+	allocs := testing.AllocsPerRun(100, func() {
+		_, err := mo.Marshal(rsp, meta)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > maxMarshalEventAllocs {
+		t.Errorf("marshaling to format 'event' allocated %.0f times, expected at most %d", allocs, maxMarshalEventAllocs)
+	}
+}
@@ -58,6 +58,39 @@ var testset = map[string]struct {
 			},
 		},
 	},
+	"allow_expression": {
+		processorType: processorType,
+		processor: map[string]interface{}{
+			"expression": `"value" in values && values.value == 1`,
+		},
+		tests: []item{
+			{
+				input:  nil,
+				output: nil,
+			},
+			{
+				input: []*formatters.EventMsg{
+					{
+						Values: map[string]interface{}{}},
+				},
+				output: []*formatters.EventMsg{
+					{},
+				},
+			},
+			{
+				input: []*formatters.EventMsg{
+					{
+						Values: map[string]interface{}{"value": 1},
+					},
+				},
+				output: []*formatters.EventMsg{
+					{
+						Values: map[string]interface{}{"value": 1},
+					},
+				},
+			},
+		},
+	},
 	"allow_value_names": {
 		processorType: processorType,
 		processor: map[string]interface{}{
@@ -0,0 +1,294 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package event_cardinality
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+)
+
+const (
+	processorType = "event-cardinality"
+	loggingPrefix = "[" + processorType + "] "
+
+	defaultLimit      = 10000
+	defaultHashValues = 100
+	defaultExpiration = time.Hour
+
+	actionDropTags  = "drop-tags"
+	actionHash      = "hash"
+	actionDropEvent = "drop-event"
+)
+
+var defaultKeepTags = []string{"source", "subscription-name"}
+
+var cardinalityLimitedEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gnmic",
+	Subsystem: "event_cardinality",
+	Name:      "limited_events_total",
+	Help:      "Number of events over the cardinality limit of their measurement",
+}, []string{"measurement", "action"})
+
+var cardinalityTagSets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "event_cardinality",
+	Name:      "tag_sets",
+	Help:      "Number of distinct tag sets tracked per measurement",
+}, []string{"measurement"})
+
+// Cardinality limits the number of distinct tag sets per measurement (event name).
+// Once Limit is reached, the events with a new tag set have their high cardinality tags
+// dropped or hashed, or are dropped entirely, depending on Action.
+type Cardinality struct {
+	// max number of distinct tag sets per measurement
+	Limit int `mapstructure:"limit,omitempty" json:"limit,omitempty"`
+	// one of drop-tags, hash, drop-event
+	Action string `mapstructure:"action,omitempty" json:"action,omitempty"`
+	// regular expressions selecting the tags to drop or hash, all the tags not in KeepTags if empty
+	Tags []string `mapstructure:"tags,omitempty" json:"tags,omitempty"`
+	// tags never dropped nor hashed
+	KeepTags []string `mapstructure:"keep-tags,omitempty" json:"keep-tags,omitempty"`
+	// number of distinct values a hashed tag can take
+	HashValues int `mapstructure:"hash-values,omitempty" json:"hash-values,omitempty"`
+	// duration after which a tag set not seen is no longer counted
+	Expiration time.Duration `mapstructure:"expiration,omitempty" json:"expiration,omitempty"`
+	Debug      bool          `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+
+	tags     []*regexp.Regexp
+	keepTags map[string]struct{}
+
+	m sync.Mutex
+	// measurement name to tag set key to last seen time
+	sets map[string]map[string]time.Time
+	// measurements over the limit, used to log once
+	limited   map[string]struct{}
+	lastSweep time.Time
+	now       func() time.Time
+	logger    *log.Logger
+	// logs the measurements reaching the limit, regardless of Debug
+	warnLogger *log.Logger
+}
+
+func init() {
+	formatters.Register(processorType, func() formatters.EventProcessor {
+		return &Cardinality{
+			logger:     log.New(io.Discard, "", 0),
+			warnLogger: log.New(io.Discard, "", 0),
+			now:        time.Now,
+		}
+	})
+	formatters.RegisterMetrics(cardinalityLimitedEvents, cardinalityTagSets)
+}
+
+func (c *Cardinality) Init(cfg interface{}, opts ...formatters.Option) error {
+	err := formatters.DecodeConfig(cfg, c)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.Limit <= 0 {
+		c.Limit = defaultLimit
+	}
+	switch c.Action {
+	case "":
+		c.Action = actionDropTags
+	case actionDropTags, actionHash, actionDropEvent:
+	default:
+		return fmt.Errorf("unknown action %q, must be one of %q",
+			c.Action, []string{actionDropTags, actionHash, actionDropEvent})
+	}
+	if c.HashValues <= 0 {
+		c.HashValues = defaultHashValues
+	}
+	if c.Expiration == 0 {
+		c.Expiration = defaultExpiration
+	}
+	if c.KeepTags == nil {
+		c.KeepTags = defaultKeepTags
+	}
+	c.keepTags = make(map[string]struct{}, len(c.KeepTags))
+	for _, t := range c.KeepTags {
+		c.keepTags[t] = struct{}{}
+	}
+	c.tags = make([]*regexp.Regexp, 0, len(c.Tags))
+	for _, t := range c.Tags {
+		re, err := regexp.Compile(t)
+		if err != nil {
+			return fmt.Errorf("invalid tags regex %q: %v", t, err)
+		}
+		c.tags = append(c.tags, re)
+	}
+	c.sets = make(map[string]map[string]time.Time)
+	c.limited = make(map[string]struct{})
+	if c.logger.Writer() != io.Discard {
+		b, err := json.Marshal(c)
+		if err != nil {
+			c.logger.Printf("initialized processor '%s': %+v", processorType, c)
+			return nil
+		}
+		c.logger.Printf("initialized processor '%s': %s", processorType, string(b))
+	}
+	return nil
+}
+
+func (c *Cardinality) Apply(es ...*formatters.EventMsg) []*formatters.EventMsg {
+	c.m.Lock()
+	defer c.m.Unlock()
+	now := c.now()
+	c.sweep(now)
+	result := make([]*formatters.EventMsg, 0, len(es))
+	for _, e := range es {
+		if e == nil {
+			continue
+		}
+		if len(e.Tags) == 0 {
+			result = append(result, e)
+			continue
+		}
+		sets, ok := c.sets[e.Name]
+		if !ok {
+			sets = make(map[string]time.Time)
+			c.sets[e.Name] = sets
+		}
+		key := tagsKey(e.Tags)
+		if _, ok := sets[key]; ok || len(sets) < c.Limit {
+			sets[key] = now
+			cardinalityTagSets.WithLabelValues(e.Name).Set(float64(len(sets)))
+			result = append(result, e)
+			continue
+		}
+		// new tag set over the limit
+		cardinalityLimitedEvents.WithLabelValues(e.Name, c.Action).Inc()
+		if _, ok := c.limited[e.Name]; !ok {
+			c.limited[e.Name] = struct{}{}
+			c.warnLogger.Printf("measurement %q reached the cardinality limit of %d tag sets, applying action %q",
+				e.Name, c.Limit, c.Action)
+		}
+		if c.Action == actionDropEvent {
+			c.logger.Printf("dropping event %q with tags %v", e.Name, e.Tags)
+			continue
+		}
+		e.Tags = c.limitTags(e.Tags)
+		result = append(result, e)
+	}
+	return result
+}
+
+// limitTags returns a copy of tags with the selected tags dropped or hashed.
+func (c *Cardinality) limitTags(tags map[string]string) map[string]string {
+	ntags := make(map[string]string, len(tags))
+	for k, v := range tags {
+		if !c.selected(k) {
+			ntags[k] = v
+			continue
+		}
+		if c.Action == actionHash {
+			ntags[k] = c.hash(v)
+		}
+	}
+	c.logger.Printf("limited tags %v to %v", tags, ntags)
+	return ntags
+}
+
+// selected returns true if the tag name can be dropped or hashed.
+func (c *Cardinality) selected(name string) bool {
+	if _, ok := c.keepTags[name]; ok {
+		return false
+	}
+	if len(c.tags) == 0 {
+		return true
+	}
+	for _, re := range c.tags {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hash maps the value v to one of HashValues values.
+func (c *Cardinality) hash(v string) string {
+	h := fnv.New32a()
+	h.Write([]byte(v))
+	return fmt.Sprintf("hash-%d", h.Sum32()%uint32(c.HashValues))
+}
+
+// sweep removes the tag sets not seen within the expiration duration,
+// it runs at most twice per expiration period.
+func (c *Cardinality) sweep(now time.Time) {
+	if c.Expiration < 0 || now.Sub(c.lastSweep) < c.Expiration/2 {
+		return
+	}
+	c.lastSweep = now
+	expiry := now.Add(-c.Expiration)
+	for name, sets := range c.sets {
+		for k, t := range sets {
+			if t.Before(expiry) {
+				delete(sets, k)
+			}
+		}
+		if len(sets) < c.Limit {
+			delete(c.limited, name)
+		}
+		if len(sets) == 0 {
+			delete(c.sets, name)
+			cardinalityTagSets.DeleteLabelValues(name)
+			continue
+		}
+		cardinalityTagSets.WithLabelValues(name).Set(float64(len(sets)))
+	}
+}
+
+func tagsKey(tags map[string]string) string {
+	names := make([]string, 0, len(tags))
+	for k := range tags {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	sb := new(strings.Builder)
+	for _, k := range names {
+		sb.WriteString(k)
+		sb.WriteByte(0)
+		sb.WriteString(tags[k])
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
+
+func (c *Cardinality) WithLogger(l *log.Logger) {
+	if l != nil {
+		c.warnLogger = log.New(l.Writer(), loggingPrefix, l.Flags())
+	}
+	if c.Debug && l != nil {
+		c.logger = log.New(l.Writer(), loggingPrefix, l.Flags())
+	} else if c.Debug {
+		c.logger = log.New(os.Stderr, loggingPrefix, utils.DefaultLoggingFlags)
+	}
+}
+
+func (c *Cardinality) WithTargets(tcs map[string]*types.TargetConfig) {}
+
+func (c *Cardinality) WithActions(act map[string]map[string]interface{}) {}
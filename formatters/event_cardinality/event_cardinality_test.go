@@ -0,0 +1,117 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package event_cardinality
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/formatters"
+)
+
+func newEvent(flow int) *formatters.EventMsg {
+	return &formatters.EventMsg{
+		Name: "flows",
+		Tags: map[string]string{
+			"source":  "router1",
+			"flow_id": fmt.Sprintf("%d", flow),
+			"proto":   "tcp",
+		},
+		Values: map[string]interface{}{"/flow/bytes": 1},
+	}
+}
+
+func TestEventCardinality(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  map[string]interface{}
+		// tags of the event over the limit, nil if dropped
+		want map[string]string
+	}{
+		{
+			name: "drop_tags",
+			cfg:  map[string]interface{}{"limit": 2},
+			want: map[string]string{"source": "router1"},
+		},
+		{
+			name: "drop_selected_tags",
+			cfg:  map[string]interface{}{"limit": 2, "tags": []string{"^flow_"}},
+			want: map[string]string{"source": "router1", "proto": "tcp"},
+		},
+		{
+			name: "hash",
+			cfg:  map[string]interface{}{"limit": 2, "action": "hash", "tags": []string{"^flow_"}, "hash-values": 1},
+			want: map[string]string{"source": "router1", "proto": "tcp", "flow_id": "hash-0"},
+		},
+		{
+			name: "drop_event",
+			cfg:  map[string]interface{}{"limit": 2, "action": "drop-event"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := formatters.EventProcessors[processorType]()
+			err := p.Init(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := p.Apply(newEvent(1), newEvent(2), newEvent(1))
+			if len(out) != 3 {
+				t.Fatalf("expected the events under the limit to be kept, got %v", out)
+			}
+			for _, e := range out {
+				if e.Tags["flow_id"] == "" {
+					t.Errorf("unexpected tags change: %v", e.Tags)
+				}
+			}
+			out = p.Apply(newEvent(3))
+			if tt.want == nil {
+				if len(out) != 0 {
+					t.Errorf("expected the event to be dropped, got %v", out)
+				}
+				return
+			}
+			if len(out) != 1 {
+				t.Fatalf("expected 1 event, got %v", out)
+			}
+			if fmt.Sprint(out[0].Tags) != fmt.Sprint(tt.want) {
+				t.Errorf("got tags %v, want %v", out[0].Tags, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventCardinalityExpiration(t *testing.T) {
+	p := formatters.EventProcessors[processorType]().(*Cardinality)
+	now := time.Now()
+	p.now = func() time.Time { return now }
+	err := p.Init(map[string]interface{}{"limit": 1, "expiration": "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Apply(newEvent(1))
+	out := p.Apply(newEvent(2))
+	if len(out) != 1 || out[0].Tags["flow_id"] != "" {
+		t.Fatalf("expected the flow_id tag to be dropped, got %v", out)
+	}
+	// the first tag set expires
+	now = now.Add(2 * time.Minute)
+	out = p.Apply(newEvent(2))
+	if len(out) != 1 || out[0].Tags["flow_id"] != "2" {
+		t.Errorf("expected the tag set to be accepted after expiration, got %v", out)
+	}
+}
+
+func TestEventCardinalityInitError(t *testing.T) {
+	p := formatters.EventProcessors[processorType]()
+	if err := p.Init(map[string]interface{}{"action": "truncate"}); err == nil {
+		t.Error("expected an unknown action error")
+	}
+}
@@ -0,0 +1,175 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package event_dedup
+
+import (
+	"container/list"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+)
+
+const (
+	processorType = "event-dedup"
+	loggingPrefix = "[" + processorType + "] "
+
+	defaultSize = 10000
+)
+
+// Dedup drops the values and deletes already seen with the same event name, tags, path and timestamp,
+// e.g: the values received from two collectors subscribed to the same target for redundancy.
+// The seen keys are kept in an LRU window of Size entries.
+type Dedup struct {
+	// tag names excluded from the key, e.g. a tag identifying the collector
+	ExcludeTags []string `mapstructure:"exclude-tags,omitempty" json:"exclude-tags,omitempty"`
+	// number of (series, path, timestamp) keys remembered
+	Size  int  `mapstructure:"size,omitempty" json:"size,omitempty"`
+	Debug bool `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+
+	excludeTags map[string]struct{}
+
+	m      sync.Mutex
+	keys   map[string]*list.Element
+	lru    *list.List
+	logger *log.Logger
+}
+
+func init() {
+	formatters.Register(processorType, func() formatters.EventProcessor {
+		return &Dedup{
+			logger: log.New(io.Discard, "", 0),
+		}
+	})
+}
+
+func (d *Dedup) Init(cfg interface{}, opts ...formatters.Option) error {
+	err := formatters.DecodeConfig(cfg, d)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.excludeTags = make(map[string]struct{}, len(d.ExcludeTags))
+	for _, t := range d.ExcludeTags {
+		d.excludeTags[t] = struct{}{}
+	}
+	if d.Size <= 0 {
+		d.Size = defaultSize
+	}
+	d.keys = make(map[string]*list.Element, d.Size)
+	d.lru = list.New()
+	if d.logger.Writer() != io.Discard {
+		b, err := json.Marshal(d)
+		if err != nil {
+			d.logger.Printf("initialized processor '%s': %+v", processorType, d)
+			return nil
+		}
+		d.logger.Printf("initialized processor '%s': %s", processorType, string(b))
+	}
+	return nil
+}
+
+func (d *Dedup) Apply(es ...*formatters.EventMsg) []*formatters.EventMsg {
+	d.m.Lock()
+	defer d.m.Unlock()
+	result := make([]*formatters.EventMsg, 0, len(es))
+	for _, e := range es {
+		if e == nil {
+			continue
+		}
+		if len(e.Values) == 0 && len(e.Deletes) == 0 {
+			result = append(result, e)
+			continue
+		}
+		prefix := d.keyPrefix(e)
+		for k := range e.Values {
+			if d.seen(prefix + "v\x00" + k) {
+				delete(e.Values, k)
+			}
+		}
+		if len(e.Deletes) > 0 {
+			deletes := make([]string, 0, len(e.Deletes))
+			for _, p := range e.Deletes {
+				if !d.seen(prefix + "d\x00" + p) {
+					deletes = append(deletes, p)
+				}
+			}
+			e.Deletes = deletes
+		}
+		if len(e.Values) == 0 && len(e.Deletes) == 0 {
+			d.logger.Printf("dropping duplicate event %q from %v", e.Name, e.Tags)
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// keyPrefix returns the series (name and tags) and timestamp part of the keys of event e.
+// All the tags are part of the key, so that the values of different list entries
+// reported in the same notification are not considered duplicates.
+func (d *Dedup) keyPrefix(e *formatters.EventMsg) string {
+	names := make([]string, 0, len(e.Tags))
+	for k := range e.Tags {
+		if _, ok := d.excludeTags[k]; !ok {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	sb := new(strings.Builder)
+	sb.WriteString(e.Name)
+	sb.WriteByte(0)
+	for _, k := range names {
+		sb.WriteString(k)
+		sb.WriteByte(0)
+		sb.WriteString(e.Tags[k])
+		sb.WriteByte(0)
+	}
+	sb.WriteString(strconv.FormatInt(e.Timestamp, 10))
+	sb.WriteByte(0)
+	return sb.String()
+}
+
+// seen returns true if key is in the LRU window, it is added to it otherwise,
+// evicting the least recently seen key if the window is full.
+func (d *Dedup) seen(key string) bool {
+	if el, ok := d.keys[key]; ok {
+		d.lru.MoveToFront(el)
+		return true
+	}
+	d.keys[key] = d.lru.PushFront(key)
+	if d.lru.Len() > d.Size {
+		el := d.lru.Back()
+		d.lru.Remove(el)
+		delete(d.keys, el.Value.(string))
+	}
+	return false
+}
+
+func (d *Dedup) WithLogger(l *log.Logger) {
+	if d.Debug && l != nil {
+		d.logger = log.New(l.Writer(), loggingPrefix, l.Flags())
+	} else if d.Debug {
+		d.logger = log.New(os.Stderr, loggingPrefix, utils.DefaultLoggingFlags)
+	}
+}
+
+func (d *Dedup) WithTargets(tcs map[string]*types.TargetConfig) {}
+
+func (d *Dedup) WithActions(act map[string]map[string]interface{}) {}
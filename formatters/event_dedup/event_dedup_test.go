@@ -0,0 +1,114 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package event_dedup
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openconfig/gnmic/formatters"
+)
+
+func newEvent(source string, ts int64, values map[string]interface{}, deletes ...string) *formatters.EventMsg {
+	return &formatters.EventMsg{
+		Name:      "sub1",
+		Timestamp: ts,
+		Tags:      map[string]string{"source": source, "subscription-name": "sub1"},
+		Values:    values,
+		Deletes:   deletes,
+	}
+}
+
+func TestEventDedup(t *testing.T) {
+	p := formatters.EventProcessors[processorType]()
+	err := p.Init(map[string]interface{}{"size": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// first collector
+	out := p.Apply(
+		newEvent("r1", 1, map[string]interface{}{"/a": 1, "/b": 2}),
+		newEvent("r1", 1, nil, "/c"),
+	)
+	if len(out) != 2 {
+		t.Fatalf("expected the events to be kept, got %v", out)
+	}
+	// second collector, same values with an extra one
+	out = p.Apply(
+		newEvent("r1", 1, map[string]interface{}{"/a": 1, "/b": 2, "/d": 4}),
+		newEvent("r1", 1, nil, "/c"),
+		// other target and other timestamp
+		newEvent("r2", 1, map[string]interface{}{"/a": 1}),
+	)
+	want := []*formatters.EventMsg{
+		newEvent("r1", 1, map[string]interface{}{"/d": 4}),
+		newEvent("r2", 1, map[string]interface{}{"/a": 1}),
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("unexpected events:\n got: %+v\nwant: %+v", out, want)
+	}
+	out = p.Apply(newEvent("r1", 2, map[string]interface{}{"/a": 1}))
+	if len(out) != 1 {
+		t.Errorf("expected a new timestamp to be accepted, got %v", out)
+	}
+}
+
+func TestEventDedupWindow(t *testing.T) {
+	p := formatters.EventProcessors[processorType]()
+	err := p.Init(map[string]interface{}{"size": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// /c evicts /a, then /a evicts /b
+	for _, path := range []string{"/a", "/b", "/c", "/a"} {
+		if out := p.Apply(newEvent("r1", 1, map[string]interface{}{path: 1})); len(out) != 1 {
+			t.Errorf("expected %s to be accepted, got %v", path, out)
+		}
+	}
+	for _, tt := range []struct {
+		path string
+		n    int
+	}{{"/c", 0}, {"/b", 1}} {
+		if out := p.Apply(newEvent("r1", 1, map[string]interface{}{tt.path: 1})); len(out) != tt.n {
+			t.Errorf("%s: expected %d events, got %v", tt.path, tt.n, out)
+		}
+	}
+}
+
+func TestEventDedupListEntries(t *testing.T) {
+	p := formatters.EventProcessors[processorType]()
+	err := p.Init(map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev1 := newEvent("r1", 1, map[string]interface{}{"/interface/oper-state": "up"})
+	ev1.Tags["interface_name"] = "eth1"
+	ev2 := newEvent("r1", 1, map[string]interface{}{"/interface/oper-state": "up"})
+	ev2.Tags["interface_name"] = "eth2"
+	out := p.Apply(ev1, ev2)
+	if len(out) != 2 || len(out[1].Values) != 1 {
+		t.Errorf("expected the values of both list entries to be kept, got %+v", out)
+	}
+}
+
+func TestEventDedupExcludeTags(t *testing.T) {
+	p := formatters.EventProcessors[processorType]()
+	err := p.Init(map[string]interface{}{"exclude-tags": []string{"collector"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev1 := newEvent("r1", 1, map[string]interface{}{"/a": 1})
+	ev1.Tags["collector"] = "c1"
+	ev2 := newEvent("r1", 1, map[string]interface{}{"/a": 1})
+	ev2.Tags["collector"] = "c2"
+	out := p.Apply(ev1, ev2, &formatters.EventMsg{Name: "tags-only", Tags: map[string]string{"source": "r1"}})
+	if len(out) != 2 || out[0] != ev1 || out[1].Name != "tags-only" {
+		t.Errorf("unexpected events: %+v", out)
+	}
+}
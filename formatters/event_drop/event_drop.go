@@ -10,6 +10,7 @@ package event_drop
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"os"
@@ -17,6 +18,7 @@ import (
 	"strings"
 
 	"github.com/itchyny/gojq"
+	"github.com/openconfig/gnmic/expr"
 	"github.com/openconfig/gnmic/formatters"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
@@ -30,6 +32,7 @@ const (
 // Drop Drops the msg if ANY of the Tags or Values regexes are matched
 type Drop struct {
 	Condition  string   `mapstructure:"condition,omitempty"`
+	Expression string   `mapstructure:"expression,omitempty" json:"expression,omitempty"`
 	TagNames   []string `mapstructure:"tag-names,omitempty" json:"tag-names,omitempty"`
 	ValueNames []string `mapstructure:"value-names,omitempty" json:"value-names,omitempty"`
 	Tags       []string `mapstructure:"tags,omitempty" json:"tags,omitempty"`
@@ -41,6 +44,7 @@ type Drop struct {
 	tags       []*regexp.Regexp
 	values     []*regexp.Regexp
 	code       *gojq.Code
+	prg        *expr.Program
 	logger     *log.Logger
 }
 
@@ -69,6 +73,15 @@ func (d *Drop) Init(cfg interface{}, opts ...formatters.Option) error {
 	if err != nil {
 		return err
 	}
+	if d.Expression != "" {
+		if d.Condition != "" {
+			return errors.New("condition and expression are mutually exclusive")
+		}
+		d.prg, err = formatters.CompileExpression(d.Expression)
+		if err != nil {
+			return err
+		}
+	}
 	// init tag keys regex
 	d.tagNames = make([]*regexp.Regexp, 0, len(d.TagNames))
 	for _, reg := range d.TagNames {
@@ -131,6 +144,17 @@ func (d *Drop) Apply(es ...*formatters.EventMsg) []*formatters.EventMsg {
 				continue
 			}
 		}
+		if d.prg != nil {
+			ok, err := formatters.CheckExpression(d.prg, e)
+			if err != nil {
+				d.logger.Printf("expression check failed: %v", err)
+				continue
+			}
+			if ok {
+				*e = formatters.EventMsg{}
+				continue
+			}
+		}
 		for k, v := range e.Values {
 			for _, re := range d.valueNames {
 				if re.MatchString(k) {
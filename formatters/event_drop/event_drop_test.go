@@ -58,6 +58,39 @@ var testset = map[string]struct {
 			},
 		},
 	},
+	"drop_expression": {
+		processorType: processorType,
+		processor: map[string]interface{}{
+			"expression": `"value" in values && values.value == 1`,
+			"debug":      true,
+		},
+		tests: []item{
+			{
+				input:  nil,
+				output: nil,
+			},
+			{
+				input: []*formatters.EventMsg{
+					{
+						Values: map[string]interface{}{}},
+				},
+				output: []*formatters.EventMsg{
+					{
+						Values: map[string]interface{}{}},
+				},
+			},
+			{
+				input: []*formatters.EventMsg{
+					{
+						Values: map[string]interface{}{"value": 1},
+					},
+				},
+				output: []*formatters.EventMsg{
+					{},
+				},
+			},
+		},
+	},
 	"drop_values": {
 		processorType: processorType,
 		processor: map[string]interface{}{
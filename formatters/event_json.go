@@ -0,0 +1,258 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// encoder buffers larger than this are not returned to the pool.
+const maxPooledBufferSize = 1 << 20
+
+// eventEncoder writes a list of events as JSON,
+// producing the same output as encoding/json without going through reflection.
+type eventEncoder struct {
+	version int
+	buf     []byte
+	keys    []string
+	ibuf    bytes.Buffer
+}
+
+var eventEncoderPool = sync.Pool{
+	New: func() interface{} {
+		return &eventEncoder{
+			buf:  make([]byte, 0, 4096),
+			keys: make([]string, 0, 32),
+		}
+	},
+}
+
+// marshalEventsJSON returns the JSON encoding of evs in the configured event version,
+// indented if Multiline is set, using a pooled encoder.
+func (o *MarshalOptions) marshalEventsJSON(evs []*EventMsg) ([]byte, error) {
+	enc := eventEncoderPool.Get().(*eventEncoder)
+	defer enc.release()
+	enc.version = o.EventVersion
+	err := enc.encode(evs)
+	if err != nil {
+		return nil, err
+	}
+	out := enc.buf
+	if o.Multiline {
+		err = json.Indent(&enc.ibuf, enc.buf, "", o.Indent)
+		if err != nil {
+			return nil, err
+		}
+		out = enc.ibuf.Bytes()
+	}
+	b := make([]byte, len(out))
+	copy(b, out)
+	return b, nil
+}
+
+func (enc *eventEncoder) release() {
+	if cap(enc.buf) > maxPooledBufferSize || enc.ibuf.Cap() > maxPooledBufferSize {
+		return
+	}
+	enc.buf = enc.buf[:0]
+	for i := range enc.keys {
+		enc.keys[i] = ""
+	}
+	enc.keys = enc.keys[:0]
+	enc.ibuf.Reset()
+	eventEncoderPool.Put(enc)
+}
+
+func (enc *eventEncoder) encode(evs []*EventMsg) error {
+	if evs == nil {
+		enc.buf = append(enc.buf, "null"...)
+		return nil
+	}
+	enc.buf = append(enc.buf, '[')
+	for i, e := range evs {
+		if i > 0 {
+			enc.buf = append(enc.buf, ',')
+		}
+		var err error
+		if enc.version == EventVersion2 {
+			err = enc.encodeEventV2(e)
+		} else {
+			err = enc.encodeEvent(e)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	enc.buf = append(enc.buf, ']')
+	return nil
+}
+
+func (enc *eventEncoder) encodeEvent(e *EventMsg) error {
+	if e == nil {
+		enc.buf = append(enc.buf, "null"...)
+		return nil
+	}
+	enc.buf = append(enc.buf, '{')
+	sep := false
+	field := func(name string) {
+		if sep {
+			enc.buf = append(enc.buf, ',')
+		}
+		sep = true
+		enc.buf = append(enc.buf, '"')
+		enc.buf = append(enc.buf, name...)
+		enc.buf = append(enc.buf, '"', ':')
+	}
+	if e.Name != "" {
+		field("name")
+		enc.encodeString(e.Name)
+	}
+	if e.Timestamp != 0 {
+		field("timestamp")
+		enc.buf = strconv.AppendInt(enc.buf, e.Timestamp, 10)
+	}
+	if len(e.Tags) > 0 {
+		field("tags")
+		enc.buf = append(enc.buf, '{')
+		for i, k := range enc.sortedKeys(len(e.Tags), func(add func(string)) {
+			for k := range e.Tags {
+				add(k)
+			}
+		}) {
+			if i > 0 {
+				enc.buf = append(enc.buf, ',')
+			}
+			enc.encodeString(k)
+			enc.buf = append(enc.buf, ':')
+			enc.encodeString(e.Tags[k])
+		}
+		enc.buf = append(enc.buf, '}')
+	}
+	if len(e.Values) > 0 {
+		field("values")
+		enc.buf = append(enc.buf, '{')
+		for i, k := range enc.sortedKeys(len(e.Values), func(add func(string)) {
+			for k := range e.Values {
+				add(k)
+			}
+		}) {
+			if i > 0 {
+				enc.buf = append(enc.buf, ',')
+			}
+			enc.encodeString(k)
+			enc.buf = append(enc.buf, ':')
+			err := enc.encodeValue(e.Values[k])
+			if err != nil {
+				return err
+			}
+		}
+		enc.buf = append(enc.buf, '}')
+	}
+	if len(e.Deletes) > 0 {
+		field("deletes")
+		enc.buf = append(enc.buf, '[')
+		for i, d := range e.Deletes {
+			if i > 0 {
+				enc.buf = append(enc.buf, ',')
+			}
+			enc.encodeString(d)
+		}
+		enc.buf = append(enc.buf, ']')
+	}
+	enc.buf = append(enc.buf, '}')
+	return nil
+}
+
+// sortedKeys returns the keys added by fn, sorted.
+// the returned slice is only valid until the next call.
+func (enc *eventEncoder) sortedKeys(n int, fn func(add func(string))) []string {
+	enc.keys = enc.keys[:0]
+	if cap(enc.keys) < n {
+		enc.keys = make([]string, 0, n)
+	}
+	fn(func(k string) { enc.keys = append(enc.keys, k) })
+	sort.Strings(enc.keys)
+	return enc.keys
+}
+
+// encodeValue writes the common event value types directly,
+// and falls back to encoding/json for the others.
+func (enc *eventEncoder) encodeValue(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		enc.buf = append(enc.buf, "null"...)
+	case string:
+		enc.encodeString(v)
+	case bool:
+		enc.buf = strconv.AppendBool(enc.buf, v)
+	case int64:
+		enc.buf = strconv.AppendInt(enc.buf, v, 10)
+	case uint64:
+		enc.buf = strconv.AppendUint(enc.buf, v, 10)
+	case int:
+		enc.buf = strconv.AppendInt(enc.buf, int64(v), 10)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return enc.encodeJSON(v)
+		}
+		enc.buf = appendFloat(enc.buf, v)
+	default:
+		return enc.encodeJSON(v)
+	}
+	return nil
+}
+
+func (enc *eventEncoder) encodeJSON(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	enc.buf = append(enc.buf, b...)
+	return nil
+}
+
+// encodeString writes s as a JSON string,
+// strings that need escaping are handed to encoding/json.
+func (enc *eventEncoder) encodeString(s string) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c >= utf8.RuneSelf || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			b, _ := json.Marshal(s)
+			enc.buf = append(enc.buf, b...)
+			return
+		}
+	}
+	enc.buf = append(enc.buf, '"')
+	enc.buf = append(enc.buf, s...)
+	enc.buf = append(enc.buf, '"')
+}
+
+// appendFloat formats f like encoding/json does.
+func appendFloat(b []byte, f float64) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
@@ -0,0 +1,82 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package formatters
+
+import (
+	"sync"
+)
+
+// events holding more tags or values than this are not returned to the pool,
+// so that a single large notification does not pin memory.
+const maxPooledEventEntries = 256
+
+var eventPool = sync.Pool{
+	New: func() interface{} {
+		return &EventMsg{
+			Tags:   make(map[string]string),
+			Values: make(map[string]interface{}),
+		}
+	},
+}
+
+// newEventMsg returns an EventMsg with the given name and timestamp.
+// If pooled is true, the event is taken from the event pool,
+// it comes with empty Tags and Values maps and an empty Deletes slice
+// and must be returned with releaseEventMsgs once it is no longer referenced.
+func newEventMsg(name string, ts int64, pooled bool) *EventMsg {
+	if !pooled {
+		return &EventMsg{Name: name, Timestamp: ts}
+	}
+	e := eventPool.Get().(*EventMsg)
+	e.Name = name
+	e.Timestamp = ts
+	return e
+}
+
+// releaseEventMsgs resets the events and returns them to the event pool.
+func releaseEventMsgs(evs ...*EventMsg) {
+	for _, e := range evs {
+		if e == nil {
+			continue
+		}
+		if len(e.Tags) > maxPooledEventEntries || len(e.Values) > maxPooledEventEntries {
+			continue
+		}
+		e.Name = ""
+		e.Timestamp = 0
+		if e.Tags == nil {
+			e.Tags = make(map[string]string)
+		}
+		for k := range e.Tags {
+			delete(e.Tags, k)
+		}
+		if e.Values == nil {
+			e.Values = make(map[string]interface{})
+		}
+		for k := range e.Values {
+			delete(e.Values, k)
+		}
+		for i := range e.Deletes {
+			e.Deletes[i] = ""
+		}
+		e.Deletes = e.Deletes[:0]
+		eventPool.Put(e)
+	}
+}
+
+// retainsEvents returns true if any of the processors
+// keeps a reference to the events after Apply returns.
+func retainsEvents(eps []EventProcessor) bool {
+	for _, ep := range eps {
+		if r, ok := ep.(interface{ RetainsEvents() bool }); ok && r.RetainsEvents() {
+			return true
+		}
+	}
+	return false
+}
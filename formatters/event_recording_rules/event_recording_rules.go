@@ -0,0 +1,380 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package event_recording_rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openconfig/gnmic/expr"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+)
+
+const (
+	processorType = "event-recording-rules"
+	loggingPrefix = "[" + processorType + "] "
+)
+
+const (
+	aggregationSum   = "sum"
+	aggregationAvg   = "avg"
+	aggregationMin   = "min"
+	aggregationMax   = "max"
+	aggregationCount = "count"
+)
+
+// RecordingRules computes derived values from the received events
+// and adds them to the event stream as new events.
+type RecordingRules struct {
+	Rules []*Rule `mapstructure:"rules,omitempty" json:"rules,omitempty"`
+	Debug bool    `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+
+	m      sync.Mutex
+	rules  []*rule
+	now    func() time.Time
+	logger *log.Logger
+}
+
+// Rule defines a derived value.
+type Rule struct {
+	// name of the derived events
+	Name string `mapstructure:"name,omitempty" json:"name,omitempty"`
+	// name of the derived value, defaults to Name
+	ValueName string `mapstructure:"value-name,omitempty" json:"value-name,omitempty"`
+	// CEL expression selecting the events the rule applies to, all events if empty
+	Filter string `mapstructure:"filter,omitempty" json:"filter,omitempty"`
+	// CEL expression computing a value from each selected event
+	Expression string `mapstructure:"expression,omitempty" json:"expression,omitempty"`
+	// aggregation of the computed values across series: sum, avg, min, max or count.
+	// If empty, a derived event is added for each selected event.
+	Aggregation string `mapstructure:"aggregation,omitempty" json:"aggregation,omitempty"`
+	// tag names the aggregated values are grouped by
+	By []string `mapstructure:"by,omitempty" json:"by,omitempty"`
+	// duration after which a series not updated is removed from the aggregation,
+	// never if 0
+	Expiration time.Duration `mapstructure:"expiration,omitempty" json:"expiration,omitempty"`
+}
+
+type rule struct {
+	*Rule
+	filter *expr.Program
+	prg    *expr.Program
+	// aggregation groups by group key
+	groups map[string]*group
+}
+
+// group holds the last value of each series of an aggregation group.
+type group struct {
+	tags      map[string]string
+	timestamp int64
+	series    map[string]*sample
+}
+
+type sample struct {
+	value    float64
+	lastSeen time.Time
+}
+
+func init() {
+	formatters.Register(processorType, func() formatters.EventProcessor {
+		return &RecordingRules{
+			now:    time.Now,
+			logger: log.New(io.Discard, "", 0),
+		}
+	})
+}
+
+func (r *RecordingRules) Init(cfg interface{}, opts ...formatters.Option) error {
+	err := formatters.DecodeConfig(cfg, r)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if len(r.Rules) == 0 {
+		return errors.New("missing rules")
+	}
+	r.rules = make([]*rule, 0, len(r.Rules))
+	for i, rc := range r.Rules {
+		rl, err := newRule(rc)
+		if err != nil {
+			return fmt.Errorf("rule %d: %v", i, err)
+		}
+		r.rules = append(r.rules, rl)
+	}
+	if r.logger.Writer() != io.Discard {
+		b, err := json.Marshal(r)
+		if err != nil {
+			r.logger.Printf("initialized processor '%s': %+v", processorType, r)
+			return nil
+		}
+		r.logger.Printf("initialized processor '%s': %s", processorType, string(b))
+	}
+	return nil
+}
+
+func newRule(rc *Rule) (*rule, error) {
+	if rc.Name == "" {
+		return nil, errors.New("missing name")
+	}
+	if rc.ValueName == "" {
+		rc.ValueName = rc.Name
+	}
+	if rc.Expression == "" {
+		return nil, errors.New("missing expression")
+	}
+	switch rc.Aggregation {
+	case "":
+		if len(rc.By) > 0 {
+			return nil, errors.New("by requires an aggregation")
+		}
+	case aggregationSum, aggregationAvg, aggregationMin, aggregationMax, aggregationCount:
+	default:
+		return nil, fmt.Errorf("unknown aggregation %q", rc.Aggregation)
+	}
+	rl := &rule{
+		Rule:   rc,
+		groups: make(map[string]*group),
+	}
+	var err error
+	if rc.Filter != "" {
+		rl.filter, err = formatters.CompileExpression(rc.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("filter: %v", err)
+		}
+	}
+	rl.prg, err = formatters.CompileExpression(rc.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("expression: %v", err)
+	}
+	return rl, nil
+}
+
+// Apply returns the events es followed by the derived events.
+// The rules are applied in order, a rule is applied to the received events
+// and to the events derived by the previous rules.
+func (r *RecordingRules) Apply(es ...*formatters.EventMsg) []*formatters.EventMsg {
+	r.m.Lock()
+	defer r.m.Unlock()
+	now := r.now()
+	for _, rl := range r.rules {
+		es = append(es, r.applyRule(rl, es, now)...)
+	}
+	return es
+}
+
+func (r *RecordingRules) applyRule(rl *rule, es []*formatters.EventMsg, now time.Time) []*formatters.EventMsg {
+	var derived []*formatters.EventMsg
+	// updated aggregation groups, in order
+	var updated []string
+	for _, e := range es {
+		if e == nil || len(e.Values) == 0 {
+			continue
+		}
+		if rl.filter != nil {
+			ok, err := formatters.CheckExpression(rl.filter, e)
+			if err != nil {
+				r.logger.Printf("rule %q: failed to evaluate filter: %v", rl.Name, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+		}
+		v, err := formatters.EvalExpression(rl.prg, e)
+		if err != nil {
+			r.logger.Printf("rule %q: failed to evaluate expression: %v", rl.Name, err)
+			continue
+		}
+		if rl.Aggregation == "" {
+			derived = append(derived, &formatters.EventMsg{
+				Name:      rl.Name,
+				Timestamp: e.Timestamp,
+				Tags:      copyTags(e.Tags, nil),
+				Values:    map[string]interface{}{rl.ValueName: v},
+			})
+			continue
+		}
+		f, ok := toFloat(v)
+		if !ok {
+			r.logger.Printf("rule %q: unexpected expression result type: %T | %v", rl.Name, v, v)
+			continue
+		}
+		key := groupKey(e.Tags, rl.By)
+		g, ok := rl.groups[key]
+		if !ok {
+			g = &group{
+				tags:   copyTags(e.Tags, rl.By),
+				series: make(map[string]*sample),
+			}
+			rl.groups[key] = g
+		}
+		if !containsString(updated, key) {
+			updated = append(updated, key)
+		}
+		g.series[seriesKey(e)] = &sample{value: f, lastSeen: now}
+		if e.Timestamp > g.timestamp {
+			g.timestamp = e.Timestamp
+		}
+	}
+	if rl.Aggregation == "" {
+		return derived
+	}
+	rl.expire(now)
+	for _, key := range updated {
+		g, ok := rl.groups[key]
+		if !ok {
+			continue
+		}
+		derived = append(derived, &formatters.EventMsg{
+			Name:      rl.Name,
+			Timestamp: g.timestamp,
+			Tags:      copyTags(g.tags, nil),
+			Values:    map[string]interface{}{rl.ValueName: g.aggregate(rl.Aggregation)},
+		})
+	}
+	return derived
+}
+
+// expire removes the series not updated within the rule expiration,
+// and the groups left without series.
+func (rl *rule) expire(now time.Time) {
+	if rl.Expiration <= 0 {
+		return
+	}
+	limit := now.Add(-rl.Expiration)
+	for key, g := range rl.groups {
+		for sk, s := range g.series {
+			if s.lastSeen.Before(limit) {
+				delete(g.series, sk)
+			}
+		}
+		if len(g.series) == 0 {
+			delete(rl.groups, key)
+		}
+	}
+}
+
+func (g *group) aggregate(agg string) interface{} {
+	if agg == aggregationCount {
+		return int64(len(g.series))
+	}
+	var res float64
+	first := true
+	for _, s := range g.series {
+		switch {
+		case first:
+			res = s.value
+			first = false
+		case agg == aggregationMin:
+			if s.value < res {
+				res = s.value
+			}
+		case agg == aggregationMax:
+			if s.value > res {
+				res = s.value
+			}
+		default:
+			res += s.value
+		}
+	}
+	if agg == aggregationAvg {
+		res = res / float64(len(g.series))
+	}
+	return res
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// copyTags returns a copy of tags, limited to the given names if any.
+func copyTags(tags map[string]string, names []string) map[string]string {
+	if names == nil {
+		res := make(map[string]string, len(tags))
+		for k, v := range tags {
+			res[k] = v
+		}
+		return res
+	}
+	res := make(map[string]string, len(names))
+	for _, n := range names {
+		if v, ok := tags[n]; ok {
+			res[n] = v
+		}
+	}
+	return res
+}
+
+// groupKey returns the aggregation group of an event with the given tags.
+func groupKey(tags map[string]string, by []string) string {
+	parts := make([]string, 0, len(by))
+	for _, n := range by {
+		parts = append(parts, n+"\x00"+tags[n])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// seriesKey returns the identity of the series of e: its name, tags and value names.
+func seriesKey(e *formatters.EventMsg) string {
+	parts := make([]string, 0, len(e.Tags)+len(e.Values))
+	for k, v := range e.Tags {
+		parts = append(parts, "t\x00"+k+"\x00"+v)
+	}
+	for k := range e.Values {
+		parts = append(parts, "v\x00"+k)
+	}
+	sort.Strings(parts)
+	return e.Name + "\x00" + strings.Join(parts, "\x00")
+}
+
+func containsString(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RecordingRules) WithLogger(l *log.Logger) {
+	if r.Debug && l != nil {
+		r.logger = log.New(l.Writer(), loggingPrefix, l.Flags())
+	} else if r.Debug {
+		r.logger = log.New(os.Stderr, loggingPrefix, utils.DefaultLoggingFlags)
+	}
+}
+
+func (r *RecordingRules) WithTargets(tcs map[string]*types.TargetConfig) {}
+
+func (r *RecordingRules) WithActions(act map[string]map[string]interface{}) {}
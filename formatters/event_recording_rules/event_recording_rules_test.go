@@ -0,0 +1,147 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package event_recording_rules
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/formatters"
+)
+
+func newEvent(source, ifName string, ts int64, values map[string]interface{}) *formatters.EventMsg {
+	return &formatters.EventMsg{
+		Name:      "sub1",
+		Timestamp: ts,
+		Tags:      map[string]string{"source": source, "interface_name": ifName},
+		Values:    values,
+	}
+}
+
+func newRecordingRules(t *testing.T, cfg map[string]interface{}) *RecordingRules {
+	t.Helper()
+	p := formatters.EventProcessors[processorType]()
+	err := p.Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*RecordingRules)
+}
+
+func TestRecordingRulesPerEvent(t *testing.T) {
+	r := newRecordingRules(t, map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{
+				"name":       "utilization",
+				"filter":     `"in-octets-rate" in values && "speed" in values`,
+				"expression": `double(values["in-octets-rate"]) * 8.0 * 100.0 / double(values["speed"])`,
+			},
+		},
+	})
+	in := []*formatters.EventMsg{
+		newEvent("r1", "e1", 1, map[string]interface{}{"in-octets-rate": uint64(125), "speed": uint64(10000)}),
+		newEvent("r1", "e2", 1, map[string]interface{}{"oper-state": "up"}),
+	}
+	out := r.Apply(in...)
+	if len(out) != 3 {
+		t.Fatalf("expected 3 events, got %d: %v", len(out), out)
+	}
+	want := &formatters.EventMsg{
+		Name:      "utilization",
+		Timestamp: 1,
+		Tags:      map[string]string{"source": "r1", "interface_name": "e1"},
+		Values:    map[string]interface{}{"utilization": float64(10)},
+	}
+	if !reflect.DeepEqual(out[2], want) {
+		t.Errorf("expected %v, got %v", want, out[2])
+	}
+}
+
+func TestRecordingRulesAggregation(t *testing.T) {
+	r := newRecordingRules(t, map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{
+				"name":        "total_in_octets",
+				"filter":      `"in-octets" in values`,
+				"expression":  `values["in-octets"]`,
+				"aggregation": "sum",
+				"by":          []interface{}{"source"},
+				"expiration":  "10s",
+			},
+			map[string]interface{}{
+				"name":        "devices",
+				"filter":      `name == "total_in_octets"`,
+				"expression":  `1`,
+				"aggregation": "count",
+			},
+		},
+	})
+	now := time.Unix(0, 0)
+	r.now = func() time.Time { return now }
+	check := func(out []*formatters.EventMsg, want map[string]interface{}) {
+		t.Helper()
+		got := make(map[string]interface{})
+		for _, e := range out {
+			for k, v := range e.Values {
+				if k == "total_in_octets" {
+					k = k + "/" + e.Tags["source"]
+				}
+				if k == "in-octets" {
+					continue
+				}
+				got[k] = v
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+	check(r.Apply(
+		newEvent("r1", "e1", 1, map[string]interface{}{"in-octets": int64(10)}),
+		newEvent("r1", "e2", 1, map[string]interface{}{"in-octets": int64(20)}),
+		newEvent("r2", "e1", 1, map[string]interface{}{"in-octets": uint64(5)}),
+	), map[string]interface{}{
+		"total_in_octets/r1": float64(30),
+		"total_in_octets/r2": float64(5),
+		"devices":            int64(2),
+	})
+	// the last value of each series is used
+	now = now.Add(5 * time.Second)
+	check(r.Apply(
+		newEvent("r1", "e2", 2, map[string]interface{}{"in-octets": int64(25)}),
+	), map[string]interface{}{
+		"total_in_octets/r1": float64(35),
+		"devices":            int64(2),
+	})
+	// expired series are removed
+	now = now.Add(6 * time.Second)
+	check(r.Apply(
+		newEvent("r1", "e2", 3, map[string]interface{}{"in-octets": int64(30)}),
+	), map[string]interface{}{
+		"total_in_octets/r1": float64(30),
+		"devices":            int64(2),
+	})
+}
+
+func TestRecordingRulesInit(t *testing.T) {
+	for name, cfg := range map[string]map[string]interface{}{
+		"no rules":            {},
+		"missing name":        {"rules": []interface{}{map[string]interface{}{"expression": "1"}}},
+		"missing expression":  {"rules": []interface{}{map[string]interface{}{"name": "r"}}},
+		"bad expression":      {"rules": []interface{}{map[string]interface{}{"name": "r", "expression": "values["}}},
+		"unknown aggregation": {"rules": []interface{}{map[string]interface{}{"name": "r", "expression": "1", "aggregation": "rate"}}},
+		"by without agg":      {"rules": []interface{}{map[string]interface{}{"name": "r", "expression": "1", "by": []interface{}{"source"}}}},
+	} {
+		p := formatters.EventProcessors[processorType]()
+		if err := p.Init(cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package event_sequence
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+)
+
+const (
+	processorType = "event-sequence"
+	loggingPrefix = "[" + processorType + "] "
+
+	defaultValueName    = "sequence"
+	defaultSeriesExpiry = time.Hour
+)
+
+// Sequence adds to each event a sequence number, monotonically increasing
+// per series: the events with the same tags (target, path keys, ...) and value names.
+// If Window is set, the events are held for Window after they are received
+// and released in timestamp order, reordering slightly out of order updates.
+// The series without events for SeriesExpiry are forgotten.
+type Sequence struct {
+	// name of the value holding the sequence number
+	ValueName string `mapstructure:"value-name,omitempty" json:"value-name,omitempty"`
+	// duration the events are held to be reordered, no reordering if 0
+	Window time.Duration `mapstructure:"window,omitempty" json:"window,omitempty"`
+	// if true, the events older than the last released event
+	// of the same series are dropped
+	DropLate bool `mapstructure:"drop-late,omitempty" json:"drop-late,omitempty"`
+	// duration after which a series without events is forgotten,
+	// its sequence restarts from 1.
+	SeriesExpiry time.Duration `mapstructure:"series-expiry,omitempty" json:"series-expiry,omitempty"`
+	Debug        bool          `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+
+	m sync.Mutex
+	// per series last sequence number and timestamp
+	series map[string]*seriesState
+	// last time the idle series were expired
+	lastExpiry time.Time
+	// events held for reordering
+	buffer []*bufferedEvent
+	now    func() time.Time
+	logger *log.Logger
+}
+
+type seriesState struct {
+	seq       int64
+	timestamp int64
+	// last time an event of the series was sequenced
+	seen time.Time
+}
+
+type bufferedEvent struct {
+	ev      *formatters.EventMsg
+	arrival time.Time
+}
+
+func init() {
+	formatters.Register(processorType, func() formatters.EventProcessor {
+		return &Sequence{
+			now:    time.Now,
+			logger: log.New(io.Discard, "", 0),
+		}
+	})
+}
+
+func (s *Sequence) Init(cfg interface{}, opts ...formatters.Option) error {
+	err := formatters.DecodeConfig(cfg, s)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.ValueName == "" {
+		s.ValueName = defaultValueName
+	}
+	if s.SeriesExpiry <= 0 {
+		s.SeriesExpiry = defaultSeriesExpiry
+	}
+	s.series = make(map[string]*seriesState)
+	s.lastExpiry = s.now()
+	if s.logger.Writer() != io.Discard {
+		b, err := json.Marshal(s)
+		if err != nil {
+			s.logger.Printf("initialized processor '%s': %+v", processorType, s)
+			return nil
+		}
+		s.logger.Printf("initialized processor '%s': %s", processorType, string(b))
+	}
+	return nil
+}
+
+// Apply holds the events es for the configured window and returns the events
+// released by this call, sorted by timestamp. The held events are released
+// by the following calls, once their window has elapsed, including calls without events.
+func (s *Sequence) Apply(es ...*formatters.EventMsg) []*formatters.EventMsg {
+	s.m.Lock()
+	defer s.m.Unlock()
+	now := s.now()
+	s.expireSeries(now)
+	if s.Window <= 0 {
+		result := make([]*formatters.EventMsg, 0, len(es))
+		for _, e := range es {
+			if e != nil && s.sequence(e, now) {
+				result = append(result, e)
+			}
+		}
+		return result
+	}
+	for _, e := range es {
+		if e == nil {
+			continue
+		}
+		s.buffer = append(s.buffer, &bufferedEvent{ev: e, arrival: now})
+	}
+	// release the events received before the window,
+	// along with the held events with an older timestamp.
+	limit := now.Add(-s.Window)
+	var maxTs int64
+	var n int
+	for _, be := range s.buffer {
+		if be.arrival.After(limit) {
+			continue
+		}
+		n++
+		if be.ev.Timestamp > maxTs {
+			maxTs = be.ev.Timestamp
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	released := make([]*formatters.EventMsg, 0, n)
+	held := s.buffer[:0]
+	for _, be := range s.buffer {
+		if !be.arrival.After(limit) || be.ev.Timestamp <= maxTs {
+			released = append(released, be.ev)
+			continue
+		}
+		held = append(held, be)
+	}
+	for i := len(held); i < len(s.buffer); i++ {
+		s.buffer[i] = nil
+	}
+	s.buffer = held
+	sort.SliceStable(released, func(i, j int) bool {
+		return released[i].Timestamp < released[j].Timestamp
+	})
+	result := released[:0]
+	for _, e := range released {
+		if s.sequence(e, now) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// sequence sets the sequence number of e, it returns false if e is dropped.
+// Events without values, e.g: deletes, are not sequenced.
+func (s *Sequence) sequence(e *formatters.EventMsg, now time.Time) bool {
+	if len(e.Values) == 0 {
+		return true
+	}
+	key := seriesKey(e)
+	st, ok := s.series[key]
+	if !ok {
+		st = new(seriesState)
+		s.series[key] = st
+	}
+	st.seen = now
+	if e.Timestamp < st.timestamp {
+		if s.DropLate {
+			s.logger.Printf("dropping late event %q: timestamp %d before %d", e.Name, e.Timestamp, st.timestamp)
+			return false
+		}
+		s.logger.Printf("late event %q: timestamp %d before %d", e.Name, e.Timestamp, st.timestamp)
+	} else {
+		st.timestamp = e.Timestamp
+	}
+	st.seq++
+	e.Values[s.ValueName] = st.seq
+	return true
+}
+
+// expireSeries forgets the series without events for SeriesExpiry,
+// the series are checked at most once per SeriesExpiry.
+func (s *Sequence) expireSeries(now time.Time) {
+	if now.Sub(s.lastExpiry) < s.SeriesExpiry {
+		return
+	}
+	s.lastExpiry = now
+	limit := now.Add(-s.SeriesExpiry)
+	for key, st := range s.series {
+		if st.seen.Before(limit) {
+			delete(s.series, key)
+		}
+	}
+}
+
+// seriesKey returns the identity of the series of e: its name, tags and value names.
+func seriesKey(e *formatters.EventMsg) string {
+	parts := make([]string, 0, len(e.Tags)+len(e.Values))
+	for k, v := range e.Tags {
+		parts = append(parts, "t\x00"+k+"\x00"+v)
+	}
+	for k := range e.Values {
+		parts = append(parts, "v\x00"+k)
+	}
+	sort.Strings(parts)
+	return e.Name + "\x00" + strings.Join(parts, "\x00")
+}
+
+// RetainsEvents returns true if the events are held for reordering.
+func (s *Sequence) RetainsEvents() bool {
+	return s.Window > 0
+}
+
+// FlushInterval returns the interval at which Apply is called without events
+// to release the held events when no new events are received.
+// The events are held for at most 1.5 times the window.
+func (s *Sequence) FlushInterval() time.Duration {
+	return s.Window / 2
+}
+
+func (s *Sequence) WithLogger(l *log.Logger) {
+	if s.Debug && l != nil {
+		s.logger = log.New(l.Writer(), loggingPrefix, l.Flags())
+	} else if s.Debug {
+		s.logger = log.New(os.Stderr, loggingPrefix, utils.DefaultLoggingFlags)
+	}
+}
+
+func (s *Sequence) WithTargets(tcs map[string]*types.TargetConfig) {}
+
+func (s *Sequence) WithActions(act map[string]map[string]interface{}) {}
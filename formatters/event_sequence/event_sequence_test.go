@@ -0,0 +1,149 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package event_sequence
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+)
+
+func newEvent(ifName string, ts int64) *formatters.EventMsg {
+	return &formatters.EventMsg{
+		Name:      "sub1",
+		Timestamp: ts,
+		Tags:      map[string]string{"source": "router1", "interface_name": ifName},
+		Values:    map[string]interface{}{"/interface/statistics/in-octets": ts},
+	}
+}
+
+func newSequence(t *testing.T, cfg map[string]interface{}) *Sequence {
+	t.Helper()
+	p := formatters.EventProcessors[processorType]()
+	err := p.Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*Sequence)
+}
+
+func check(t *testing.T, evs []*formatters.EventMsg, want ...[2]int64) {
+	t.Helper()
+	if len(evs) != len(want) {
+		t.Fatalf("expected %d events, got %d: %v", len(want), len(evs), evs)
+	}
+	for i, e := range evs {
+		if e.Timestamp != want[i][0] || e.Values[defaultValueName] != want[i][1] {
+			t.Errorf("event %d: expected timestamp=%d sequence=%d, got %v", i, want[i][0], want[i][1], e)
+		}
+	}
+}
+
+func TestSequence(t *testing.T) {
+	s := newSequence(t, map[string]interface{}{})
+	check(t, s.Apply(newEvent("e1", 1), newEvent("e2", 1), newEvent("e1", 2)), [2]int64{1, 1}, [2]int64{1, 1}, [2]int64{2, 2})
+	// out of order events are sequenced but not reordered without window
+	check(t, s.Apply(newEvent("e1", 4), newEvent("e1", 3)), [2]int64{4, 3}, [2]int64{3, 4})
+	// deletes are not sequenced
+	del := &formatters.EventMsg{Name: "sub1", Timestamp: 5, Deletes: []string{"/interface"}}
+	evs := s.Apply(del)
+	if len(evs) != 1 || len(evs[0].Values) != 0 {
+		t.Errorf("unexpected delete event: %v", evs)
+	}
+}
+
+func TestSequenceWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newSequence(t, map[string]interface{}{"window": "1s", "drop-late": true})
+	s.now = func() time.Time { return now }
+	if !s.RetainsEvents() {
+		t.Error("expected the processor to retain events")
+	}
+	// held for the window
+	check(t, s.Apply(newEvent("e1", 20), newEvent("e1", 10)))
+	now = now.Add(500 * time.Millisecond)
+	check(t, s.Apply(newEvent("e1", 15), newEvent("e1", 30)))
+	// the first events are released in order, along with the held ones
+	// with an older timestamp
+	now = now.Add(600 * time.Millisecond)
+	check(t, s.Apply(), [2]int64{10, 1}, [2]int64{15, 2}, [2]int64{20, 3})
+	// late event is dropped
+	check(t, s.Apply(newEvent("e1", 19)))
+	// a new event older than the released ones is not held
+	now = now.Add(time.Second)
+	check(t, s.Apply(newEvent("e2", 1)), [2]int64{1, 1}, [2]int64{30, 4})
+	now = now.Add(time.Second)
+	check(t, s.Apply(newEvent("e2", 40)))
+	now = now.Add(time.Second)
+	check(t, s.Apply(), [2]int64{40, 2})
+}
+
+func TestSequenceSeriesExpiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newSequence(t, map[string]interface{}{"series-expiry": "1m"})
+	s.now = func() time.Time { return now }
+	s.lastExpiry = now
+	check(t, s.Apply(newEvent("e1", 1), newEvent("e2", 1)), [2]int64{1, 1}, [2]int64{1, 1})
+	now = now.Add(40 * time.Second)
+	check(t, s.Apply(newEvent("e1", 2)), [2]int64{2, 2})
+	// e2 is idle for more than the expiry, its sequence restarts
+	now = now.Add(40 * time.Second)
+	check(t, s.Apply(newEvent("e1", 3), newEvent("e2", 3)), [2]int64{3, 3}, [2]int64{3, 1})
+	if len(s.series) != 2 {
+		t.Errorf("expected 2 series, got %d", len(s.series))
+	}
+	now = now.Add(2 * time.Minute)
+	s.Apply()
+	if len(s.series) != 0 {
+		t.Errorf("expected the idle series to expire, got %d", len(s.series))
+	}
+}
+
+func TestSequenceFlush(t *testing.T) {
+	ps := map[string]map[string]interface{}{
+		"seq":    {processorType: map[string]interface{}{"window": "2s"}},
+		"no-win": {processorType: map[string]interface{}{}},
+	}
+	if d := formatters.FlushInterval([]string{"no-win", "seq", "unknown"}, ps); d != time.Second {
+		t.Errorf("expected a flush interval of 1s, got %s", d)
+	}
+	if d := formatters.FlushInterval([]string{"no-win"}, ps); d != 0 {
+		t.Errorf("expected no flush interval, got %s", d)
+	}
+
+	now := time.Unix(0, 0)
+	s := newSequence(t, map[string]interface{}{"window": "2s"})
+	s.now = func() time.Time { return now }
+	flush := &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{}}}
+	meta := map[string]string{formatters.MetaFlush: "true"}
+	mo := &formatters.MarshalOptions{Format: "event"}
+	// nothing held, nothing written
+	b, err := mo.Marshal(flush, meta, s)
+	if err != nil || b != nil {
+		t.Fatalf("expected nothing to write, got %q, err=%v", b, err)
+	}
+	check(t, s.Apply(newEvent("e1", 1)))
+	now = now.Add(3 * time.Second)
+	// the flush releases the held event without new events
+	b, err = mo.Marshal(flush, meta, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"sequence":1`) {
+		t.Errorf("expected the held event to be released, got %s", b)
+	}
+	// other formats ignore the flush
+	b, err = (&formatters.MarshalOptions{Format: "json"}).Marshal(flush, meta, s)
+	if err != nil || b != nil {
+		t.Errorf("expected nothing to write, got %q, err=%v", b, err)
+	}
+}
@@ -9,13 +9,17 @@
 package formatters
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
+	"math"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/types"
 )
 
 type item struct {
@@ -178,6 +182,96 @@ func TestResponseToEventMsgs(t *testing.T) {
 	t.Logf("%v", evs)
 }
 
+func TestResponseToEventMsgsVars(t *testing.T) {
+	rsp := &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Timestamp: 42,
+				Update: []*gnmi.Update{
+					{
+						Path: &gnmi.Path{
+							Elem: []*gnmi.PathElem{
+								{Name: "a", Key: map[string]string{"site": "k"}},
+								{Name: "b"},
+							},
+						},
+						Val: &gnmi.TypedValue{
+							Value: &gnmi.TypedValue_StringVal{StringVal: "value"},
+						},
+					},
+				},
+			},
+		},
+	}
+	meta := map[string]string{
+		"source":                "router1",
+		VarsMetaPrefix + "role": "spine",
+		VarsMetaPrefix + "site": "site1",
+		"format":                "event",
+	}
+	evs, err := ResponseToEventMsgs("sub1", rsp, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 1 {
+		t.Fatalf("unexpected number of events: %d", len(evs))
+	}
+	want := map[string]string{
+		"source": "router1",
+		"role":   "spine",
+		"a_site": "k",
+		"site":   "site1",
+	}
+	if !reflect.DeepEqual(evs[0].Tags, want) {
+		t.Errorf("unexpected tags: got %v, want %v", evs[0].Tags, want)
+	}
+}
+
+func TestResponseToEventMsgsTimestamps(t *testing.T) {
+	rsp := &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Timestamp: 42,
+				Update: []*gnmi.Update{
+					{
+						Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "a"}}},
+						Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_IntVal{IntVal: 1}},
+					},
+				},
+				Delete: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "b"}}}},
+			},
+		},
+	}
+	meta := map[string]string{
+		"source":                "router1",
+		DeviceTimestampMetaKey:  "41",
+		ArrivalTimestampMetaKey: "42",
+	}
+	evs, err := ResponseToEventMsgs("sub1", rsp, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 2 {
+		t.Fatalf("unexpected number of events: %d", len(evs))
+	}
+	want := map[string]interface{}{
+		"/a":                    int64(1),
+		DeviceTimestampMetaKey:  int64(41),
+		ArrivalTimestampMetaKey: int64(42),
+	}
+	if !reflect.DeepEqual(evs[0].Values, want) {
+		t.Errorf("unexpected values: got %v, want %v", evs[0].Values, want)
+	}
+	for _, e := range evs {
+		if !reflect.DeepEqual(e.Tags, map[string]string{"source": "router1"}) {
+			t.Errorf("unexpected tags: %v", e.Tags)
+		}
+	}
+	if len(evs[1].Values) != 0 {
+		t.Errorf("unexpected delete event values: %v", evs[1].Values)
+	}
+}
+
 func TestTagsFromGNMIPath(t *testing.T) {
 	type args struct {
 		p *gnmi.Path
@@ -394,3 +488,188 @@ func Test_getValueFlat(t *testing.T) {
 		})
 	}
 }
+
+func TestMarshalEventsJSON(t *testing.T) {
+	evs := []*EventMsg{
+		{
+			Name:      "sub1",
+			Timestamp: 100,
+			Tags: map[string]string{
+				"source":         "r1:57400",
+				"interface_name": "ethernet-1/1",
+				"html":           "<a & b>",
+				"quote":          `"q" \\ \t`,
+				"unicode":        "caf\u00e9 \u2028",
+			},
+			Values: map[string]interface{}{
+				"string":  "value",
+				"int":     int64(-42),
+				"uint":    uint64(math.MaxUint64),
+				"bool":    true,
+				"float":   0.1,
+				"small":   1e-7,
+				"large":   1e21,
+				"zero":    float64(0),
+				"float32": float32(1.5),
+				"nil":     nil,
+				"list":    []interface{}{"a", 1.0, false},
+				"bytes":   []byte("abc"),
+				"decimal": &gnmi.Decimal64{Digits: 1234, Precision: 2},
+			},
+		},
+		nil,
+		{Name: "sub1", Deletes: []string{"/a/b", "/a/c"}},
+		{},
+	}
+	for _, o := range []*MarshalOptions{
+		{Format: "event"},
+		{Format: "event", Multiline: true, Indent: "  "},
+	} {
+		got, err := o.marshalEventsJSON(evs)
+		if err != nil {
+			t.Fatalf("marshalEventsJSON failed: %v", err)
+		}
+		var want []byte
+		if o.Multiline {
+			want, err = json.MarshalIndent(evs, "", o.Indent)
+		} else {
+			want, err = json.Marshal(evs)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("marshalEventsJSON() multiline=%v\ngot:  %s\nwant: %s", o.Multiline, got, want)
+		}
+	}
+	_, err := (&MarshalOptions{}).marshalEventsJSON([]*EventMsg{{Values: map[string]interface{}{"nan": math.NaN()}}})
+	if err == nil {
+		t.Errorf("marshalEventsJSON() expected an error for a NaN value")
+	}
+}
+
+// retainingProcessor keeps the events it is applied to.
+type retainingProcessor struct {
+	noopProcessor
+	evs []*EventMsg
+}
+
+func (p *retainingProcessor) Apply(es ...*EventMsg) []*EventMsg {
+	p.evs = append(p.evs, es...)
+	return es
+}
+
+func (p *retainingProcessor) RetainsEvents() bool { return true }
+
+type noopProcessor struct{}
+
+func (noopProcessor) Init(interface{}, ...Option) error             { return nil }
+func (noopProcessor) Apply(es ...*EventMsg) []*EventMsg             { return es }
+func (noopProcessor) WithTargets(map[string]*types.TargetConfig)    {}
+func (noopProcessor) WithLogger(*log.Logger)                        {}
+func (noopProcessor) WithActions(map[string]map[string]interface{}) {}
+
+func TestMarshalEventPooled(t *testing.T) {
+	rsp := benchmarkResponse(10)
+	meta := map[string]string{"source": "r1", "subscription-name": "sub1"}
+	evs, err := ResponseToEventMsgs("sub1", rsp, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(evs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mo := &MarshalOptions{Format: "event"}
+	for i := 0; i < 3; i++ {
+		got, err := mo.Marshal(rsp, meta, noopProcessor{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("run %d: got %s, want %s", i, got, want)
+		}
+	}
+	// events referenced by a processor must not be reused
+	p := &retainingProcessor{}
+	_, err = mo.Marshal(rsp, meta, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = mo.Marshal(benchmarkResponse(1), meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(p.evs, evs[:len(evs)-1]) {
+		t.Errorf("retained events were modified: %v", cmp.Diff(p.evs, evs[:len(evs)-1]))
+	}
+}
+
+// benchmarkResponse returns a subscribe response with numUpdates scalar updates,
+// a JSON update and a delete.
+func benchmarkResponse(numUpdates int) *gnmi.SubscribeResponse {
+	n := &gnmi.Notification{
+		Timestamp: 1,
+		Prefix: &gnmi.Path{
+			Target: "r1",
+			Elem: []*gnmi.PathElem{
+				{Name: "interfaces"},
+				{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}},
+			},
+		},
+		Delete: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "description"}}}},
+	}
+	for i := 0; i < numUpdates; i++ {
+		n.Update = append(n.Update, &gnmi.Update{
+			Path: &gnmi.Path{
+				Elem: []*gnmi.PathElem{
+					{Name: "subinterface", Key: map[string]string{"index": fmt.Sprint(i)}},
+					{Name: "statistics"},
+					{Name: "in-octets"},
+				},
+			},
+			Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: uint64(i)}},
+		})
+	}
+	n.Update = append(n.Update, &gnmi.Update{
+		Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "statistics"}}},
+		Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{
+			JsonIetfVal: []byte(`{"in-octets":"1","out-octets":"2","in-errors":{"fcs":0,"other":[1,2]}}`),
+		}},
+	})
+	return &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: n}}
+}
+
+func BenchmarkResponseToEventMsgs(b *testing.B) {
+	rsp := benchmarkResponse(20)
+	meta := map[string]string{"source": "r1", "subscription-name": "sub1"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := ResponseToEventMsgs("sub1", rsp, meta)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalEvent(b *testing.B) {
+	benchmarkMarshal(b, &MarshalOptions{Format: "event"})
+}
+
+func BenchmarkMarshalEventMultiline(b *testing.B) {
+	benchmarkMarshal(b, &MarshalOptions{Format: "event", Multiline: true, Indent: "  "})
+}
+
+func benchmarkMarshal(b *testing.B, mo *MarshalOptions) {
+	rsp := benchmarkResponse(20)
+	meta := map[string]string{"source": "r1", "subscription-name": "sub1"}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, err := mo.Marshal(rsp, meta)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/itchyny/gojq"
 	"github.com/openconfig/gnmic/actions"
 	_ "github.com/openconfig/gnmic/actions/all"
+	"github.com/openconfig/gnmic/expr"
 	"github.com/openconfig/gnmic/formatters"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
@@ -37,6 +38,7 @@ const (
 // Trigger triggers an action when certain conditions are met
 type Trigger struct {
 	Condition      string                 `mapstructure:"condition,omitempty"`
+	Expression     string                 `mapstructure:"expression,omitempty"`
 	MinOccurrences int                    `mapstructure:"min-occurrences,omitempty"`
 	MaxOccurrences int                    `mapstructure:"max-occurrences,omitempty"`
 	Window         time.Duration          `mapstructure:"window,omitempty"`
@@ -49,6 +51,7 @@ type Trigger struct {
 	occurrencesTimes []time.Time
 	lastTrigger      time.Time
 	code             *gojq.Code
+	prg              *expr.Program
 	actions          []actions.Action
 	vars             map[string]interface{}
 
@@ -74,19 +77,29 @@ func (p *Trigger) Init(cfg interface{}, opts ...formatters.Option) error {
 		opt(p)
 	}
 
+	if p.Expression != "" && p.Condition != "" {
+		return errors.New("condition and expression are mutually exclusive")
+	}
 	err = p.setDefaults()
 	if err != nil {
 		return err
 	}
 
-	p.Condition = strings.TrimSpace(p.Condition)
-	q, err := gojq.Parse(p.Condition)
-	if err != nil {
-		return err
-	}
-	p.code, err = gojq.Compile(q)
-	if err != nil {
-		return err
+	if p.Expression != "" {
+		p.prg, err = formatters.CompileExpression(p.Expression)
+		if err != nil {
+			return err
+		}
+	} else {
+		p.Condition = strings.TrimSpace(p.Condition)
+		q, err := gojq.Parse(p.Condition)
+		if err != nil {
+			return err
+		}
+		p.code, err = gojq.Compile(q)
+		if err != nil {
+			return err
+		}
 	}
 
 	for _, name := range p.Actions {
@@ -115,13 +128,13 @@ func (p *Trigger) Apply(es ...*formatters.EventMsg) []*formatters.EventMsg {
 		if e == nil {
 			continue
 		}
-		res, err := formatters.CheckCondition(p.code, e)
+		res, err := p.check(e)
 		if err != nil {
-			p.logger.Printf("failed evaluating condition %q: %v", p.Condition, err)
+			p.logger.Printf("failed evaluating condition %q: %v", p.condition(), err)
 			continue
 		}
 		if p.Debug {
-			p.logger.Printf("msg=%+v, condition %q result: (%T)%v", e, p.Condition, res, res)
+			p.logger.Printf("msg=%+v, condition %q result: (%T)%v", e, p.condition(), res, res)
 		}
 		if res {
 			if p.evalOccurrencesWithinWindow(now) {
@@ -136,6 +149,26 @@ func (p *Trigger) Apply(es ...*formatters.EventMsg) []*formatters.EventMsg {
 	return es
 }
 
+// RetainsEvents returns true if the actions are triggered asynchronously,
+// in which case the events are referenced after Apply returns.
+func (p *Trigger) RetainsEvents() bool {
+	return p.Async
+}
+
+func (p *Trigger) check(e *formatters.EventMsg) (bool, error) {
+	if p.prg != nil {
+		return formatters.CheckExpression(p.prg, e)
+	}
+	return formatters.CheckCondition(p.code, e)
+}
+
+func (p *Trigger) condition() string {
+	if p.Expression != "" {
+		return p.Expression
+	}
+	return p.Condition
+}
+
 func (p *Trigger) WithLogger(l *log.Logger) {
 	if p.Debug && l != nil {
 		p.logger = log.New(l.Writer(), loggingPrefix, l.Flags())
@@ -191,7 +224,7 @@ func (p *Trigger) String() string {
 }
 
 func (p *Trigger) setDefaults() error {
-	if p.Condition == "" {
+	if p.Condition == "" && p.Expression == "" {
 		p.Condition = defaultCondition
 	}
 	if p.MinOccurrences <= 0 {
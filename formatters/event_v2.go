@@ -0,0 +1,447 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package formatters
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// event format versions.
+// Version 1 is the EventMsg JSON encoding, values are written as plain JSON values.
+// Version 2 keeps the gNMI value types, writes the timestamp as a string
+// to preserve its nanosecond precision and marks delete events explicitly.
+const (
+	EventVersion1 = 1
+	EventVersion2 = 2
+)
+
+// version 2 value types
+const (
+	eventTypeString   = "string"
+	eventTypeBool     = "bool"
+	eventTypeInt      = "int"
+	eventTypeUint     = "uint"
+	eventTypeFloat    = "float"
+	eventTypeDouble   = "double"
+	eventTypeDecimal  = "decimal"
+	eventTypeBytes    = "bytes"
+	eventTypeLeafList = "leaflist"
+	eventTypeJSON     = "json"
+)
+
+// version 2 event operations
+const (
+	eventOperationUpdate = "update"
+	eventOperationDelete = "delete"
+)
+
+// ValidateEventVersion returns an error if v is not a known event format version.
+func ValidateEventVersion(v int) error {
+	switch v {
+	case EventVersion1, EventVersion2:
+		return nil
+	}
+	return fmt.Errorf("unknown event version %d, must be one of %v", v, []int{EventVersion1, EventVersion2})
+}
+
+func (enc *eventEncoder) encodeEventV2(e *EventMsg) error {
+	if e == nil {
+		enc.buf = append(enc.buf, "null"...)
+		return nil
+	}
+	enc.buf = append(enc.buf, `{"version":2`...)
+	field := func(name string) {
+		enc.buf = append(enc.buf, ',', '"')
+		enc.buf = append(enc.buf, name...)
+		enc.buf = append(enc.buf, '"', ':')
+	}
+	field("operation")
+	if len(e.Deletes) > 0 && len(e.Values) == 0 {
+		enc.encodeString(eventOperationDelete)
+	} else {
+		enc.encodeString(eventOperationUpdate)
+	}
+	if e.Name != "" {
+		field("name")
+		enc.encodeString(e.Name)
+	}
+	field("timestamp")
+	enc.buf = append(enc.buf, '"')
+	enc.buf = strconv.AppendInt(enc.buf, e.Timestamp, 10)
+	enc.buf = append(enc.buf, '"')
+	if len(e.Tags) > 0 {
+		field("tags")
+		enc.buf = append(enc.buf, '{')
+		for i, k := range enc.sortedKeys(len(e.Tags), func(add func(string)) {
+			for k := range e.Tags {
+				add(k)
+			}
+		}) {
+			if i > 0 {
+				enc.buf = append(enc.buf, ',')
+			}
+			enc.encodeString(k)
+			enc.buf = append(enc.buf, ':')
+			enc.encodeString(e.Tags[k])
+		}
+		enc.buf = append(enc.buf, '}')
+	}
+	if len(e.Values) > 0 {
+		field("values")
+		enc.buf = append(enc.buf, '{')
+		for i, k := range enc.sortedKeys(len(e.Values), func(add func(string)) {
+			for k := range e.Values {
+				add(k)
+			}
+		}) {
+			if i > 0 {
+				enc.buf = append(enc.buf, ',')
+			}
+			enc.encodeString(k)
+			enc.buf = append(enc.buf, ':')
+			err := enc.encodeTypedValue(e.Values[k])
+			if err != nil {
+				return err
+			}
+		}
+		enc.buf = append(enc.buf, '}')
+	}
+	if len(e.Deletes) > 0 {
+		field("deletes")
+		enc.buf = append(enc.buf, '[')
+		for i, d := range e.Deletes {
+			if i > 0 {
+				enc.buf = append(enc.buf, ',')
+			}
+			enc.encodeString(d)
+		}
+		enc.buf = append(enc.buf, ']')
+	}
+	enc.buf = append(enc.buf, '}')
+	return nil
+}
+
+// encodeTypedValue writes v as an object holding its type and its value.
+// 64 bit integers and decimals are written as strings to preserve their precision.
+func (enc *eventEncoder) encodeTypedValue(v interface{}) error {
+	enc.buf = append(enc.buf, `{"type":`...)
+	switch v := v.(type) {
+	case string:
+		enc.encodeString(eventTypeString)
+		enc.buf = append(enc.buf, `,"value":`...)
+		enc.encodeString(v)
+	case bool:
+		enc.encodeString(eventTypeBool)
+		enc.buf = append(enc.buf, `,"value":`...)
+		enc.buf = strconv.AppendBool(enc.buf, v)
+	case int, int8, int16, int32, int64:
+		enc.encodeString(eventTypeInt)
+		enc.buf = append(enc.buf, `,"value":"`...)
+		enc.buf = strconv.AppendInt(enc.buf, toInt64(v), 10)
+		enc.buf = append(enc.buf, '"')
+	case uint, uint8, uint16, uint32, uint64:
+		enc.encodeString(eventTypeUint)
+		enc.buf = append(enc.buf, `,"value":"`...)
+		enc.buf = strconv.AppendUint(enc.buf, toUint64(v), 10)
+		enc.buf = append(enc.buf, '"')
+	case float32:
+		enc.encodeString(eventTypeFloat)
+		enc.buf = append(enc.buf, `,"value":`...)
+		enc.appendTypedFloat(float64(v), 32)
+	case float64:
+		enc.encodeString(eventTypeDouble)
+		enc.buf = append(enc.buf, `,"value":`...)
+		enc.appendTypedFloat(v, 64)
+	case *gnmi.Decimal64:
+		enc.encodeString(eventTypeDecimal)
+		enc.buf = append(enc.buf, `,"value":`...)
+		enc.encodeString(decimalString(v))
+	case []byte:
+		enc.encodeString(eventTypeBytes)
+		enc.buf = append(enc.buf, `,"value":"`...)
+		n := base64.StdEncoding.EncodedLen(len(v))
+		l := len(enc.buf)
+		enc.buf = append(enc.buf, make([]byte, n)...)
+		base64.StdEncoding.Encode(enc.buf[l:], v)
+		enc.buf = append(enc.buf, '"')
+	case []interface{}:
+		enc.encodeString(eventTypeLeafList)
+		enc.buf = append(enc.buf, `,"value":[`...)
+		for i, e := range v {
+			if i > 0 {
+				enc.buf = append(enc.buf, ',')
+			}
+			err := enc.encodeTypedValue(e)
+			if err != nil {
+				return err
+			}
+		}
+		enc.buf = append(enc.buf, ']')
+	default:
+		enc.encodeString(eventTypeJSON)
+		enc.buf = append(enc.buf, `,"value":`...)
+		err := enc.encodeJSON(v)
+		if err != nil {
+			return err
+		}
+	}
+	enc.buf = append(enc.buf, '}')
+	return nil
+}
+
+// appendTypedFloat writes f as a JSON number,
+// NaN and infinite values are written as strings.
+func (enc *eventEncoder) appendTypedFloat(f float64, bitSize int) {
+	switch {
+	case math.IsNaN(f):
+		enc.buf = append(enc.buf, `"NaN"`...)
+	case math.IsInf(f, 1):
+		enc.buf = append(enc.buf, `"Infinity"`...)
+	case math.IsInf(f, -1):
+		enc.buf = append(enc.buf, `"-Infinity"`...)
+	default:
+		enc.buf = strconv.AppendFloat(enc.buf, f, 'g', -1, bitSize)
+	}
+}
+
+func toInt64(v interface{}) int64 {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	}
+	return 0
+}
+
+func toUint64(v interface{}) uint64 {
+	switch v := v.(type) {
+	case uint:
+		return uint64(v)
+	case uint8:
+		return uint64(v)
+	case uint16:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	case uint64:
+		return v
+	}
+	return 0
+}
+
+// decimalString formats d as a decimal number without losing precision.
+func decimalString(d *gnmi.Decimal64) string {
+	s := strconv.FormatInt(d.GetDigits(), 10)
+	prec := int(d.GetPrecision())
+	if prec == 0 {
+		return s
+	}
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	if len(s) <= prec {
+		s = strings.Repeat("0", prec-len(s)+1) + s
+	}
+	s = s[:len(s)-prec] + "." + s[len(s)-prec:]
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+// parseDecimal is the inverse of decimalString.
+func parseDecimal(s string) (*gnmi.Decimal64, error) {
+	var prec uint32
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		prec = uint32(len(s) - i - 1)
+		s = s[:i] + s[i+1:]
+	}
+	digits, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &gnmi.Decimal64{Digits: digits, Precision: prec}, nil
+}
+
+// eventV2 is used to decode events of both versions.
+type eventV2 struct {
+	Version   int                        `json:"version,omitempty"`
+	Name      string                     `json:"name,omitempty"`
+	Timestamp json.Number                `json:"timestamp,omitempty"`
+	Tags      map[string]string          `json:"tags,omitempty"`
+	Values    map[string]json.RawMessage `json:"values,omitempty"`
+	Deletes   []string                   `json:"deletes,omitempty"`
+}
+
+type typedValue struct {
+	Type  string          `json:"type,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// UnmarshalEvents decodes a list of events written with the event format,
+// in either version 1 or version 2.
+// Version 2 values are decoded to the Go type they were encoded from.
+func UnmarshalEvents(b []byte) ([]*EventMsg, error) {
+	raw := make([]*eventV2, 0)
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return nil, err
+	}
+	evs := make([]*EventMsg, 0, len(raw))
+	for _, r := range raw {
+		if r == nil {
+			continue
+		}
+		e, err := r.toEventMsg()
+		if err != nil {
+			return nil, err
+		}
+		evs = append(evs, e)
+	}
+	return evs, nil
+}
+
+func (r *eventV2) toEventMsg() (*EventMsg, error) {
+	e := &EventMsg{
+		Name:    r.Name,
+		Tags:    r.Tags,
+		Deletes: r.Deletes,
+	}
+	var err error
+	if r.Timestamp != "" {
+		e.Timestamp, err = r.Timestamp.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid event timestamp: %v", err)
+		}
+	}
+	if len(r.Values) == 0 {
+		return e, nil
+	}
+	e.Values = make(map[string]interface{}, len(r.Values))
+	for k, rv := range r.Values {
+		if r.Version < EventVersion2 {
+			var v interface{}
+			err = json.Unmarshal(rv, &v)
+			if err != nil {
+				return nil, err
+			}
+			e.Values[k] = v
+			continue
+		}
+		tv := new(typedValue)
+		err = json.Unmarshal(rv, tv)
+		if err != nil {
+			return nil, err
+		}
+		e.Values[k], err = tv.decode()
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %v", k, err)
+		}
+	}
+	return e, nil
+}
+
+func (tv *typedValue) decode() (interface{}, error) {
+	switch tv.Type {
+	case eventTypeString:
+		var s string
+		err := json.Unmarshal(tv.Value, &s)
+		return s, err
+	case eventTypeBool:
+		var b bool
+		err := json.Unmarshal(tv.Value, &b)
+		return b, err
+	case eventTypeInt:
+		var s string
+		err := json.Unmarshal(tv.Value, &s)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseInt(s, 10, 64)
+	case eventTypeUint:
+		var s string
+		err := json.Unmarshal(tv.Value, &s)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseUint(s, 10, 64)
+	case eventTypeFloat:
+		f, err := tv.float(32)
+		return float32(f), err
+	case eventTypeDouble:
+		return tv.float(64)
+	case eventTypeDecimal:
+		var s string
+		err := json.Unmarshal(tv.Value, &s)
+		if err != nil {
+			return nil, err
+		}
+		return parseDecimal(s)
+	case eventTypeBytes:
+		var b []byte
+		err := json.Unmarshal(tv.Value, &b)
+		return b, err
+	case eventTypeLeafList:
+		elems := make([]*typedValue, 0)
+		err := json.Unmarshal(tv.Value, &elems)
+		if err != nil {
+			return nil, err
+		}
+		vals := make([]interface{}, 0, len(elems))
+		for _, elem := range elems {
+			v, err := elem.decode()
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, v)
+		}
+		return vals, nil
+	case eventTypeJSON:
+		var v interface{}
+		err := json.Unmarshal(tv.Value, &v)
+		return v, err
+	}
+	return nil, fmt.Errorf("unknown value type %q", tv.Type)
+}
+
+func (tv *typedValue) float(bitSize int) (float64, error) {
+	if len(tv.Value) > 0 && tv.Value[0] == '"' {
+		var s string
+		err := json.Unmarshal(tv.Value, &s)
+		if err != nil {
+			return 0, err
+		}
+		switch s {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return 0, errors.New("invalid float value " + s)
+	}
+	return strconv.ParseFloat(string(tv.Value), bitSize)
+}
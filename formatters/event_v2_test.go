@@ -0,0 +1,141 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package formatters
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func TestMarshalEventsV2(t *testing.T) {
+	evs := []*EventMsg{
+		{
+			Name:      "sub1",
+			Timestamp: 1595491618677407414,
+			Tags:      map[string]string{"source": "r1:57400"},
+			Values: map[string]interface{}{
+				"int":     int64(-42),
+				"uint":    uint64(math.MaxUint64),
+				"decimal": &gnmi.Decimal64{Digits: -1234, Precision: 5},
+				"list":    []interface{}{"a", true},
+			},
+		},
+		{Name: "sub1", Timestamp: 1, Deletes: []string{"/a/b"}},
+	}
+	o := &MarshalOptions{Format: "event", EventVersion: EventVersion2}
+	got, err := o.marshalEventsJSON(evs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"version":2,"operation":"update","name":"sub1","timestamp":"1595491618677407414",` +
+		`"tags":{"source":"r1:57400"},` +
+		`"values":{"decimal":{"type":"decimal","value":"-0.01234"},` +
+		`"int":{"type":"int","value":"-42"},` +
+		`"list":{"type":"leaflist","value":[{"type":"string","value":"a"},{"type":"bool","value":true}]},` +
+		`"uint":{"type":"uint","value":"18446744073709551615"}}},` +
+		`{"version":2,"operation":"delete","name":"sub1","timestamp":"1","deletes":["/a/b"]}]`
+	if string(got) != want {
+		t.Errorf("marshalEventsJSON()\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestUnmarshalEvents(t *testing.T) {
+	evs := []*EventMsg{
+		{
+			Name:      "sub1",
+			Timestamp: 1595491618677407414,
+			Tags:      map[string]string{"source": "r1:57400", "interface_name": "ethernet-1/1"},
+			Values: map[string]interface{}{
+				"string":  "value",
+				"bool":    true,
+				"int":     int64(math.MinInt64),
+				"uint":    uint64(math.MaxUint64),
+				"float":   float32(1.5),
+				"double":  0.1,
+				"inf":     math.Inf(-1),
+				"decimal": &gnmi.Decimal64{Digits: 1234, Precision: 2},
+				"bytes":   []byte("abc"),
+				"list":    []interface{}{"a", uint64(1), int64(-1)},
+				"json":    map[string]interface{}{"a": "b"},
+			},
+		},
+		{Name: "sub1", Timestamp: 1595491618677407415, Deletes: []string{"/a/b", "/a/c"}},
+	}
+	o := &MarshalOptions{Format: "event", Multiline: true, Indent: "  ", EventVersion: EventVersion2}
+	b, err := o.marshalEventsJSON(evs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalEvents(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, evs) {
+		t.Errorf("UnmarshalEvents() v2\ngot:  %v\nwant: %v", got, evs)
+	}
+	// version 1 events are decoded as plain JSON values
+	b, err = (&MarshalOptions{Format: "event"}).marshalEventsJSON([]*EventMsg{
+		{Name: "sub1", Timestamp: 100, Values: map[string]interface{}{"int": int64(42), "string": "value"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = UnmarshalEvents(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*EventMsg{{Name: "sub1", Timestamp: 100, Values: map[string]interface{}{"int": float64(42), "string": "value"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalEvents() v1\ngot:  %v\nwant: %v", got, want)
+	}
+	// NaN values are kept
+	b, err = o.marshalEventsJSON([]*EventMsg{{Values: map[string]interface{}{"nan": math.NaN()}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = UnmarshalEvents(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := got[0].Values["nan"].(float64); !ok || !math.IsNaN(f) {
+		t.Errorf("UnmarshalEvents() expected a NaN value, got %v", got[0].Values["nan"])
+	}
+	_, err = UnmarshalEvents([]byte(`[{"version":2,"values":{"a":{"type":"unknown","value":1}}}]`))
+	if err == nil {
+		t.Errorf("UnmarshalEvents() expected an error for an unknown value type")
+	}
+}
+
+func TestDecimalString(t *testing.T) {
+	tests := []struct {
+		d    *gnmi.Decimal64
+		want string
+	}{
+		{d: &gnmi.Decimal64{Digits: 1234, Precision: 0}, want: "1234"},
+		{d: &gnmi.Decimal64{Digits: 1234, Precision: 2}, want: "12.34"},
+		{d: &gnmi.Decimal64{Digits: 1234, Precision: 4}, want: "0.1234"},
+		{d: &gnmi.Decimal64{Digits: -5, Precision: 3}, want: "-0.005"},
+	}
+	for _, tt := range tests {
+		got := decimalString(tt.d)
+		if got != tt.want {
+			t.Errorf("decimalString(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+		d, err := parseDecimal(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.GetDigits() != tt.d.GetDigits() || d.GetPrecision() != tt.d.GetPrecision() {
+			t.Errorf("parseDecimal(%q) = %v, want %v", got, d, tt.d)
+		}
+	}
+}
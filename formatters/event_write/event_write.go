@@ -10,6 +10,7 @@ package event_write
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"os"
@@ -17,6 +18,7 @@ import (
 	"strings"
 
 	"github.com/itchyny/gojq"
+	"github.com/openconfig/gnmic/expr"
 	"github.com/openconfig/gnmic/formatters"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
@@ -29,6 +31,7 @@ const (
 
 type Write struct {
 	Condition  string   `mapstructure:"condition,omitempty"`
+	Expression string   `mapstructure:"expression,omitempty" json:"expression,omitempty"`
 	Tags       []string `mapstructure:"tags,omitempty" json:"tags,omitempty"`
 	Values     []string `mapstructure:"values,omitempty" json:"values,omitempty"`
 	TagNames   []string `mapstructure:"tag-names,omitempty" json:"tag-names,omitempty"`
@@ -45,6 +48,7 @@ type Write struct {
 	dst        io.Writer
 	sep        []byte
 	code       *gojq.Code
+	prg        *expr.Program
 	logger     *log.Logger
 }
 
@@ -73,6 +77,15 @@ func (p *Write) Init(cfg interface{}, opts ...formatters.Option) error {
 	if err != nil {
 		return err
 	}
+	if p.Expression != "" {
+		if p.Condition != "" {
+			return errors.New("condition and expression are mutually exclusive")
+		}
+		p.prg, err = formatters.CompileExpression(p.Expression)
+		if err != nil {
+			return err
+		}
+	}
 	if p.Separator == "" {
 		p.sep = []byte("\n")
 	} else {
@@ -141,6 +154,19 @@ OUTER:
 			p.dst.Write([]byte(""))
 			continue
 		}
+		if p.prg != nil {
+			ok, err := formatters.CheckExpression(p.prg, e)
+			if err != nil {
+				p.logger.Printf("expression check failed: %v", err)
+			}
+			if ok {
+				err := p.write(e)
+				if err != nil {
+					p.logger.Printf("failed to write to destination: %v", err)
+				}
+			}
+			continue
+		}
 		if p.code != nil {
 			ok, err := formatters.CheckCondition(p.code, e)
 			if err != nil {
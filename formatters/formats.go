@@ -27,16 +27,23 @@ type MarshalOptions struct {
 	Format     string
 	OverrideTS bool
 	ValuesOnly bool
+	// EventVersion is the version of the event format, defaults to EventVersion1
+	EventVersion int
 }
 
 // Marshal //
 func (o *MarshalOptions) Marshal(msg proto.Message, meta map[string]string, eps ...EventProcessor) ([]byte, error) {
+	if meta[MetaFlush] != "" && o.Format != "event" {
+		return nil, nil
+	}
 	msg = o.OverrideTimestamp(msg)
 	switch o.Format {
 	default: // json
 		return o.FormatJSON(msg, meta)
 	case "proto":
 		return proto.Marshal(msg)
+	case FormatProtoEnvelope:
+		return marshalEnvelope(msg, meta)
 	case "protojson":
 		return protojson.MarshalOptions{Multiline: o.Multiline, Indent: o.Indent}.Marshal(msg)
 	case "prototext":
@@ -52,18 +59,7 @@ func (o *MarshalOptions) Marshal(msg proto.Message, meta map[string]string, eps
 			}
 			switch msg.GetResponse().(type) {
 			case *gnmi.SubscribeResponse_Update:
-				events, err := ResponseToEventMsgs(subscriptionName, msg, meta, eps...)
-				if err != nil {
-					return nil, fmt.Errorf("failed converting response to events: %v", err)
-				}
-				if o.Multiline {
-					b, err = json.MarshalIndent(events, "", o.Indent)
-				} else {
-					b, err = json.Marshal(events)
-				}
-				if err != nil {
-					return nil, fmt.Errorf("failed marshaling format 'event': %v", err)
-				}
+				return o.marshalEvents(subscriptionName, msg.GetUpdate(), meta, eps...)
 			}
 			return b, nil
 		case *gnmi.GetResponse:
@@ -71,8 +67,9 @@ func (o *MarshalOptions) Marshal(msg proto.Message, meta map[string]string, eps
 			if err != nil {
 				return nil, fmt.Errorf("failed converting response to events: %v", err)
 			}
-
-			if o.Multiline {
+			if o.EventVersion == EventVersion2 {
+				b, err = o.marshalEventsJSON(events)
+			} else if o.Multiline {
 				b, err = json.MarshalIndent(events, "", o.Indent)
 			} else {
 				b, err = json.Marshal(events)
@@ -108,6 +105,42 @@ func (o *MarshalOptions) Marshal(msg proto.Message, meta map[string]string, eps
 	}
 }
 
+// marshalEvents converts a notification to events and marshals them to JSON.
+// the events are taken from the event pool and returned to it once marshaled,
+// unless one of the processors keeps a reference to them.
+func (o *MarshalOptions) marshalEvents(name string, n *gnmi.Notification, meta map[string]string, eps ...EventProcessor) ([]byte, error) {
+	pooled := !retainsEvents(eps)
+	events, del, err := notificationToEvents(name, n, meta, pooled)
+	if err != nil {
+		return nil, fmt.Errorf("failed converting response to events: %v", err)
+	}
+	if pooled {
+		// processors may drop or replace events,
+		// keep track of the ones that came from the pool.
+		created := make([]*EventMsg, len(events), len(events)+1)
+		copy(created, events)
+		defer func() {
+			releaseEventMsgs(created...)
+			releaseEventMsgs(del)
+		}()
+	}
+	for _, ep := range eps {
+		events = ep.Apply(events...)
+	}
+	if del != nil {
+		events = append(events, del)
+	}
+	// nothing released by the processors for an empty notification
+	if len(events) == 0 && len(n.GetUpdate()) == 0 {
+		return nil, nil
+	}
+	b, err := o.marshalEventsJSON(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed marshaling format 'event': %v", err)
+	}
+	return b, nil
+}
+
 func (o *MarshalOptions) OverrideTimestamp(msg proto.Message) proto.Message {
 	if o.OverrideTS {
 		ts := time.Now().UnixNano()
@@ -12,10 +12,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/itchyny/gojq"
 	"github.com/mitchellh/mapstructure"
+	"github.com/openconfig/gnmic/expr"
 	"github.com/openconfig/gnmic/types"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var EventProcessors = map[string]Initializer{}
@@ -38,6 +41,10 @@ var EventProcessorTypes = []string{
 	"event-group-by",
 	"event-data-convert",
 	"event-value-tag",
+	"event-dedup",
+	"event-sequence",
+	"event-recording-rules",
+	"event-cardinality",
 }
 
 type Initializer func() EventProcessor
@@ -46,6 +53,19 @@ func Register(name string, initFn Initializer) {
 	EventProcessors[name] = initFn
 }
 
+var processorsMetrics []prometheus.Collector
+
+// RegisterMetrics adds event processors metrics,
+// they are exposed by the API server if its metrics are enabled.
+func RegisterMetrics(cs ...prometheus.Collector) {
+	processorsMetrics = append(processorsMetrics, cs...)
+}
+
+// Metrics returns the event processors metrics.
+func Metrics() []prometheus.Collector {
+	return processorsMetrics
+}
+
 type Option func(EventProcessor)
 type EventProcessor interface {
 	Init(interface{}, ...Option) error
@@ -56,6 +76,35 @@ type EventProcessor interface {
 	WithActions(act map[string]map[string]interface{})
 }
 
+// MetaFlush is the meta key set on the empty notifications written to an output
+// to release the events held by its processors, see FlushInterval.
+// They are marshaled to nothing if the output format is not "event".
+const MetaFlush = "flush"
+
+// FlushInterval returns the interval at which the event processors named names,
+// configured in ps, must be applied without events to release the events they hold,
+// or 0 if none of them holds events.
+func FlushInterval(names []string, ps map[string]map[string]interface{}) time.Duration {
+	var d time.Duration
+	for _, name := range names {
+		for epType, epCfg := range ps[name] {
+			in, ok := EventProcessors[epType]
+			if !ok {
+				continue
+			}
+			ep := in()
+			f, ok := ep.(interface{ FlushInterval() time.Duration })
+			if !ok || ep.Init(epCfg) != nil {
+				continue
+			}
+			if fi := f.FlushInterval(); fi > 0 && (d == 0 || fi < d) {
+				d = fi
+			}
+		}
+	}
+	return d
+}
+
 func DecodeConfig(src, dst interface{}) error {
 	decoder, err := mapstructure.NewDecoder(
 		&mapstructure.DecoderConfig{
@@ -87,6 +136,45 @@ func WithActions(acts map[string]map[string]interface{}) Option {
 	}
 }
 
+// eventExprVariables are the variables available to
+// the CEL expressions evaluated against an event.
+var eventExprVariables = []string{"name", "timestamp", "tags", "values"}
+
+// CompileExpression compiles the CEL expression src,
+// it can reference the event name, timestamp, tags and values.
+func CompileExpression(src string) (*expr.Program, error) {
+	return expr.Compile(src, eventExprVariables...)
+}
+
+// CheckExpression evaluates the CEL expression p against event e,
+// the expression must return a boolean.
+func CheckExpression(p *expr.Program, e *EventMsg) (bool, error) {
+	return p.EvalBool(eventExprValues(e))
+}
+
+// EvalExpression evaluates the CEL expression p against event e
+// and returns its result as a native Go value.
+func EvalExpression(p *expr.Program, e *EventMsg) (interface{}, error) {
+	return p.Eval(eventExprValues(e))
+}
+
+func eventExprValues(e *EventMsg) map[string]interface{} {
+	tags := e.Tags
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	values := e.Values
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"name":      e.Name,
+		"timestamp": e.Timestamp,
+		"tags":      tags,
+		"values":    values,
+	}
+}
+
 func CheckCondition(code *gojq.Code, e *EventMsg) (bool, error) {
 	var res interface{}
 	if code != nil {
@@ -0,0 +1,137 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package formatters
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+// subscriptionScopeType is the type of the processors applying a chain of processors
+// to the events of a set of subscriptions only.
+// Their configs are built by SubscriptionScope, they are not meant to be configured by users.
+const subscriptionScopeType = "subscription-scope"
+
+func init() {
+	Register(subscriptionScopeType, func() EventProcessor {
+		return &subscriptionScope{
+			logger: log.New(io.Discard, "", 0),
+		}
+	})
+}
+
+// SubscriptionScope returns the config of a processor applying the processors named names,
+// configured in ps, to the events of the subscriptions subs only.
+// The other events are returned unchanged.
+func SubscriptionScope(subs, names []string, ps map[string]map[string]interface{}) map[string]interface{} {
+	procs := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		if epCfg, ok := ps[name]; ok {
+			procs = append(procs, epCfg)
+		}
+	}
+	return map[string]interface{}{
+		subscriptionScopeType: map[string]interface{}{
+			"subscriptions": subs,
+			"processors":    procs,
+		},
+	}
+}
+
+type subscriptionScope struct {
+	Subscriptions []string                 `mapstructure:"subscriptions,omitempty"`
+	Processors    []map[string]interface{} `mapstructure:"processors,omitempty"`
+
+	subs   map[string]struct{}
+	eps    []EventProcessor
+	logger *log.Logger
+}
+
+func (s *subscriptionScope) Init(cfg interface{}, opts ...Option) error {
+	err := DecodeConfig(cfg, s)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.subs = make(map[string]struct{}, len(s.Subscriptions))
+	for _, sub := range s.Subscriptions {
+		s.subs[sub] = struct{}{}
+	}
+	for _, epCfg := range s.Processors {
+		for epType, c := range epCfg {
+			in, ok := EventProcessors[epType]
+			if !ok {
+				return fmt.Errorf("unknown event processor type %q", epType)
+			}
+			ep := in()
+			err = ep.Init(c, opts...)
+			if err != nil {
+				return fmt.Errorf("failed initializing event processor of type %q: %v", epType, err)
+			}
+			s.eps = append(s.eps, ep)
+		}
+	}
+	return nil
+}
+
+func (s *subscriptionScope) Apply(es ...*EventMsg) []*EventMsg {
+	scoped := make([]*EventMsg, 0, len(es))
+	res := make([]*EventMsg, 0, len(es))
+	for _, e := range es {
+		if _, ok := s.subs[e.Name]; ok {
+			scoped = append(scoped, e)
+			continue
+		}
+		res = append(res, e)
+	}
+	// processors holding events are applied on flushes too
+	if len(scoped) == 0 && len(es) > 0 {
+		return es
+	}
+	for _, ep := range s.eps {
+		scoped = ep.Apply(scoped...)
+	}
+	return append(res, scoped...)
+}
+
+// RetainsEvents reports whether any of the scoped processors keeps the events.
+func (s *subscriptionScope) RetainsEvents() bool {
+	return retainsEvents(s.eps)
+}
+
+// FlushInterval returns the shortest flush interval of the scoped processors.
+func (s *subscriptionScope) FlushInterval() time.Duration {
+	var d time.Duration
+	for _, ep := range s.eps {
+		f, ok := ep.(interface{ FlushInterval() time.Duration })
+		if !ok {
+			continue
+		}
+		if fi := f.FlushInterval(); fi > 0 && (d == 0 || fi < d) {
+			d = fi
+		}
+	}
+	return d
+}
+
+func (s *subscriptionScope) WithLogger(l *log.Logger) {
+	if l != nil {
+		s.logger = log.New(l.Writer(), l.Prefix(), l.Flags())
+	}
+}
+
+func (s *subscriptionScope) WithTargets(map[string]*types.TargetConfig) {}
+
+func (s *subscriptionScope) WithActions(map[string]map[string]interface{}) {}
@@ -0,0 +1,58 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package formatters
+
+import (
+	"testing"
+)
+
+// dropProcessor drops all the events it is applied to.
+type dropProcessor struct {
+	noopProcessor
+	calls int
+}
+
+func (p *dropProcessor) Apply(es ...*EventMsg) []*EventMsg {
+	p.calls++
+	return nil
+}
+
+func TestSubscriptionScope(t *testing.T) {
+	dp := &dropProcessor{}
+	Register("test-drop", func() EventProcessor { return dp })
+	defer delete(EventProcessors, "test-drop")
+
+	ps := map[string]map[string]interface{}{
+		"drop": {"test-drop": map[string]interface{}{}},
+	}
+	cfg := SubscriptionScope([]string{"sub1"}, []string{"drop", "unknown"}, ps)
+	ep := EventProcessors[subscriptionScopeType]()
+	err := ep.Init(cfg[subscriptionScopeType])
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := ep.Apply(
+		&EventMsg{Name: "sub1"},
+		&EventMsg{Name: "sub2"},
+		&EventMsg{Name: "sub1"},
+	)
+	if len(res) != 1 || res[0].Name != "sub2" {
+		t.Errorf("unexpected events: %v", res)
+	}
+	// events of other subscriptions only are not handed to the scoped processors
+	res = ep.Apply(&EventMsg{Name: "sub2"})
+	if len(res) != 1 || dp.calls != 1 {
+		t.Errorf("unexpected events %v after %d calls", res, dp.calls)
+	}
+	// flushes are
+	ep.Apply()
+	if dp.calls != 2 {
+		t.Errorf("scoped processors not applied on flush, calls=%d", dp.calls)
+	}
+}
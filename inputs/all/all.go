@@ -9,7 +9,11 @@
 package all
 
 import (
+	_ "github.com/openconfig/gnmic/inputs/cisco_mdt_input"
+	_ "github.com/openconfig/gnmic/inputs/file_input"
+	_ "github.com/openconfig/gnmic/inputs/juniper_native_input"
 	_ "github.com/openconfig/gnmic/inputs/kafka_input"
 	_ "github.com/openconfig/gnmic/inputs/nats_input"
+	_ "github.com/openconfig/gnmic/inputs/snmp_input"
 	_ "github.com/openconfig/gnmic/inputs/stan_input"
 )
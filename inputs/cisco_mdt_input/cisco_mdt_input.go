@@ -0,0 +1,409 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cisco_mdt_input
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/inputs"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	loggingPrefix     = "[cisco_mdt_input] "
+	transportGRPC     = "grpc"
+	transportUDP      = "udp"
+	defaultTransport  = transportGRPC
+	defaultBufferSize = 64 * 1024
+	// UDP dial-out messages header length
+	udpHeaderLen = 12
+	// MdtDialoutArgs message fields
+	dialoutArgsData   = 2
+	dialoutArgsErrors = 3
+)
+
+func init() {
+	inputs.Register("cisco-mdt", func() inputs.Input {
+		return &CiscoMDTInput{
+			Cfg:    &Config{},
+			logger: log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags),
+			wg:     new(sync.WaitGroup),
+		}
+	})
+}
+
+// CiscoMDTInput receives Cisco model driven telemetry dial-out messages
+// over gRPC or UDP and exports them to the outputs as gNMI notifications.
+type CiscoMDTInput struct {
+	Cfg    *Config
+	cfn    context.CancelFunc
+	logger *log.Logger
+
+	wg      *sync.WaitGroup
+	outputs []outputs.Output
+	evps    []formatters.EventProcessor
+
+	grpcSrv *grpc.Server
+	conn    net.PacketConn
+}
+
+// Config //
+type Config struct {
+	Name string `mapstructure:"name,omitempty"`
+	// listen address
+	Address string `mapstructure:"address,omitempty"`
+	// grpc or udp
+	Transport string `mapstructure:"transport,omitempty"`
+	// gRPC server TLS
+	SkipVerify bool   `mapstructure:"skip-verify,omitempty"`
+	CaFile     string `mapstructure:"ca-file,omitempty"`
+	CertFile   string `mapstructure:"cert-file,omitempty"`
+	KeyFile    string `mapstructure:"key-file,omitempty"`
+	// gRPC max received message size
+	MaxMsgSize int `mapstructure:"max-msg-size,omitempty"`
+	// UDP read buffer size
+	BufferSize      int      `mapstructure:"buffer-size,omitempty"`
+	Debug           bool     `mapstructure:"debug,omitempty"`
+	Outputs         []string `mapstructure:"outputs,omitempty"`
+	EventProcessors []string `mapstructure:"event-processors,omitempty"`
+}
+
+// Start //
+func (c *CiscoMDTInput) Start(ctx context.Context, name string, cfg map[string]interface{}, opts ...inputs.Option) error {
+	err := outputs.DecodeConfig(cfg, c.Cfg)
+	if err != nil {
+		return err
+	}
+	if c.Cfg.Name == "" {
+		c.Cfg.Name = name
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	err = c.setDefaults()
+	if err != nil {
+		return err
+	}
+	ctx, c.cfn = context.WithCancel(ctx)
+	c.logger.Printf("input starting with config: %+v", c.Cfg)
+	switch c.Cfg.Transport {
+	case transportGRPC:
+		return c.startGRPC(ctx)
+	default:
+		return c.startUDP(ctx)
+	}
+}
+
+func (c *CiscoMDTInput) startGRPC(ctx context.Context) error {
+	l, err := net.Listen("tcp", c.Cfg.Address)
+	if err != nil {
+		return err
+	}
+	opts := []grpc.ServerOption{grpc.ForceServerCodec(rawCodec{})}
+	if c.Cfg.MaxMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(c.Cfg.MaxMsgSize))
+	}
+	tlsConfig, err := utils.NewTLSConfig(c.Cfg.CaFile, c.Cfg.CertFile, c.Cfg.KeyFile, c.Cfg.SkipVerify, false)
+	if err != nil {
+		l.Close()
+		return err
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	c.grpcSrv = grpc.NewServer(opts...)
+	c.grpcSrv.RegisterService(&mdtDialoutServiceDesc, &dialoutServer{ctx: ctx, c: c})
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		err := c.grpcSrv.Serve(l)
+		if err != nil {
+			c.logger.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (c *CiscoMDTInput) startUDP(ctx context.Context) error {
+	var err error
+	c.conn, err = net.ListenPacket("udp", c.Cfg.Address)
+	if err != nil {
+		return err
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		buf := make([]byte, c.Cfg.BufferSize)
+		for {
+			n, addr, err := c.conn.ReadFrom(buf)
+			if err != nil {
+				if ctx.Err() == nil {
+					c.logger.Printf("failed to read UDP message: %v", err)
+					continue
+				}
+				return
+			}
+			b, err := udpPayload(buf[:n])
+			if err != nil {
+				if c.Cfg.Debug {
+					c.logger.Printf("invalid UDP message from %s: %v", addr, err)
+				}
+				continue
+			}
+			c.handle(ctx, b, addr)
+		}
+	}()
+	return nil
+}
+
+// udpPayload returns the Telemetry message following the UDP message header:
+// message type, encapsulation, header version, flags (2 bytes each) and message length (4 bytes).
+func udpPayload(b []byte) ([]byte, error) {
+	if len(b) < udpHeaderLen {
+		return nil, errors.New("message shorter than its header")
+	}
+	l := int(binary.BigEndian.Uint32(b[8:udpHeaderLen]))
+	if l > len(b)-udpHeaderLen {
+		return nil, fmt.Errorf("message length %d exceeds the datagram length", l)
+	}
+	return b[udpHeaderLen : udpHeaderLen+l], nil
+}
+
+// handle exports a GPB or JSON encoded Telemetry message received from addr.
+func (c *CiscoMDTInput) handle(ctx context.Context, b []byte, addr net.Addr) {
+	t, err := decodeTelemetry(b)
+	if err != nil {
+		if c.Cfg.Debug {
+			c.logger.Printf("%s: %v", addr, err)
+		}
+		return
+	}
+	rsps, err := t.responses()
+	if err != nil {
+		c.logger.Printf("%s: encoding path %q: %v", addr, t.EncodingPath, err)
+		return
+	}
+	source := t.NodeID
+	if source == "" {
+		source, _, _ = net.SplitHostPort(addr.String())
+	}
+	for _, rsp := range rsps {
+		msg := &inputs.Message{
+			Format:   inputs.FormatProto,
+			Response: rsp,
+			Meta:     outputs.Meta{"source": source},
+		}
+		if t.SubscriptionID != "" {
+			msg.Meta["subscription-name"] = t.SubscriptionID
+		}
+		err = msg.Process(c.evps)
+		if err != nil {
+			c.logger.Printf("failed to process message: %v", err)
+			continue
+		}
+		msg.Write(ctx, c.outputs)
+	}
+}
+
+// Close //
+func (c *CiscoMDTInput) Close() error {
+	if c.cfn != nil {
+		c.cfn()
+	}
+	if c.grpcSrv != nil {
+		c.grpcSrv.Stop()
+	}
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	c.wg.Wait()
+	return nil
+}
+
+// SetLogger //
+func (c *CiscoMDTInput) SetLogger(logger *log.Logger) {
+	if logger != nil && c.logger != nil {
+		c.logger.SetOutput(logger.Writer())
+		c.logger.SetFlags(logger.Flags())
+	}
+}
+
+// SetOutputs //
+func (c *CiscoMDTInput) SetOutputs(outs map[string]outputs.Output) {
+	if len(c.Cfg.Outputs) == 0 {
+		for _, o := range outs {
+			c.outputs = append(c.outputs, o)
+		}
+		return
+	}
+	for _, name := range c.Cfg.Outputs {
+		if o, ok := outs[name]; ok {
+			c.outputs = append(c.outputs, o)
+		}
+	}
+}
+
+func (c *CiscoMDTInput) SetName(name string) {}
+
+func (c *CiscoMDTInput) SetEventProcessors(ps map[string]map[string]interface{}, logger *log.Logger, tcs map[string]*types.TargetConfig) {
+	for _, epName := range c.Cfg.EventProcessors {
+		if epCfg, ok := ps[epName]; ok {
+			epType := ""
+			for k := range epCfg {
+				epType = k
+				break
+			}
+			if in, ok := formatters.EventProcessors[epType]; ok {
+				ep := in()
+				err := ep.Init(epCfg[epType], formatters.WithLogger(logger), formatters.WithTargets(tcs))
+				if err != nil {
+					c.logger.Printf("failed initializing event processor %q of type=%q: %v", epName, epType, err)
+					continue
+				}
+				c.evps = append(c.evps, ep)
+				c.logger.Printf("added event processor %q of type=%q to cisco-mdt input", epName, epType)
+			}
+		}
+	}
+}
+
+// helper functions
+
+func (c *CiscoMDTInput) setDefaults() error {
+	if c.Cfg.Address == "" {
+		return errors.New("missing address")
+	}
+	if c.Cfg.Transport == "" {
+		c.Cfg.Transport = defaultTransport
+	}
+	c.Cfg.Transport = strings.ToLower(c.Cfg.Transport)
+	switch c.Cfg.Transport {
+	case transportGRPC, transportUDP:
+	default:
+		return fmt.Errorf("unsupported transport %q, must be one of %q", c.Cfg.Transport, []string{transportGRPC, transportUDP})
+	}
+	if c.Cfg.BufferSize <= 0 {
+		c.Cfg.BufferSize = defaultBufferSize
+	}
+	return nil
+}
+
+// gRPC dial-out service
+
+// dialoutServer implements the mdt_dialout.gRPCMdtDialout service,
+// the MdtDialoutArgs messages are decoded by hand from their raw bytes.
+type dialoutServer struct {
+	ctx context.Context
+	c   *CiscoMDTInput
+}
+
+type mdtDialoutHandler interface {
+	mdtDialout(grpc.ServerStream) error
+}
+
+var mdtDialoutServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mdt_dialout.gRPCMdtDialout",
+	HandlerType: (*mdtDialoutHandler)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "MdtDialout",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				return srv.(mdtDialoutHandler).mdtDialout(stream)
+			},
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "mdt_grpc_dialout.proto",
+}
+
+func (s *dialoutServer) mdtDialout(stream grpc.ServerStream) error {
+	var addr net.Addr = &net.TCPAddr{}
+	if pr, ok := peer.FromContext(stream.Context()); ok {
+		addr = pr.Addr
+	}
+	if s.c.Cfg.Debug {
+		s.c.logger.Printf("received MdtDialout RPC from peer=%s", addr)
+	}
+	for {
+		var b []byte
+		err := stream.RecvMsg(&b)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.c.logger.Printf("gRPC dialout receive error from %s: %v", addr, err)
+			}
+			return nil
+		}
+		data, errs, err := decodeDialoutArgs(b)
+		if err != nil {
+			s.c.logger.Printf("failed to decode dialout message from %s: %v", addr, err)
+			continue
+		}
+		if errs != "" {
+			s.c.logger.Printf("dialout error from %s: %s", addr, errs)
+		}
+		if len(data) > 0 {
+			s.c.handle(s.ctx, data, addr)
+		}
+	}
+}
+
+// decodeDialoutArgs returns the data and errors fields of an MdtDialoutArgs message.
+func decodeDialoutArgs(b []byte) ([]byte, string, error) {
+	var data []byte
+	var errs string
+	err := consumeFields(b, func(num protowire.Number, v []byte, _ uint64) error {
+		switch num {
+		case dialoutArgsData:
+			data = v
+		case dialoutArgsErrors:
+			errs = string(v)
+		}
+		return nil
+	})
+	return data, errs, err
+}
+
+// rawCodec passes the gRPC messages bytes as is.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+// Name is proto since the dialing routers send application/grpc+proto messages.
+func (rawCodec) Name() string { return "proto" }
@@ -0,0 +1,285 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cisco_mdt_input
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/inputs"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// testOutput records the messages written to it.
+type testOutput struct {
+	m     sync.Mutex
+	msgs  []proto.Message
+	metas []outputs.Meta
+}
+
+func (o *testOutput) Init(context.Context, string, map[string]interface{}, ...outputs.Option) error {
+	return nil
+}
+func (o *testOutput) Write(_ context.Context, m proto.Message, meta outputs.Meta) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.msgs = append(o.msgs, m)
+	o.metas = append(o.metas, meta)
+}
+func (o *testOutput) WriteEvent(context.Context, *formatters.EventMsg) {}
+func (o *testOutput) Close() error                                     { return nil }
+func (o *testOutput) RegisterMetrics(*prometheus.Registry)             {}
+func (o *testOutput) String() string                                   { return "test" }
+func (o *testOutput) SetLogger(*log.Logger)                            {}
+func (o *testOutput) SetEventProcessors(map[string]map[string]interface{}, *log.Logger, map[string]*types.TargetConfig, map[string]map[string]interface{}) {
+}
+func (o *testOutput) SetName(string)                                  {}
+func (o *testOutput) SetClusterName(string)                           {}
+func (o *testOutput) SetTargetsConfig(map[string]*types.TargetConfig) {}
+
+func (o *testOutput) wait(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		o.m.Lock()
+		l := len(o.msgs)
+		o.m.Unlock()
+		if l >= n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d messages, got %d", n, len(o.msgs))
+}
+
+// kvField encodes a self-describing GPB TelemetryField.
+func kvField(name string, value interface{}, fields ...[]byte) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, fieldName, protowire.BytesType)
+	b = protowire.AppendString(b, name)
+	switch v := value.(type) {
+	case string:
+		b = protowire.AppendTag(b, fieldString, protowire.BytesType)
+		b = protowire.AppendString(b, v)
+	case uint64:
+		b = protowire.AppendTag(b, fieldUint64, protowire.VarintType)
+		b = protowire.AppendVarint(b, v)
+	case int64:
+		b = protowire.AppendTag(b, fieldSint64, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeZigZag(v))
+	case float64:
+		b = protowire.AppendTag(b, fieldDouble, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(v))
+	}
+	for _, f := range fields {
+		b = protowire.AppendTag(b, fieldFields, protowire.BytesType)
+		b = protowire.AppendBytes(b, f)
+	}
+	return b
+}
+
+func kvTelemetry() []byte {
+	var row []byte
+	row = protowire.AppendTag(row, fieldTimestamp, protowire.VarintType)
+	row = protowire.AppendVarint(row, 1600000000000)
+	row = protowire.AppendTag(row, fieldFields, protowire.BytesType)
+	row = protowire.AppendBytes(row, kvField("keys", nil, kvField("interface-name", "GigabitEthernet0/0/0/0")))
+	row = protowire.AppendTag(row, fieldFields, protowire.BytesType)
+	row = protowire.AppendBytes(row, kvField("content", nil,
+		kvField("bytes-received", uint64(42)),
+		kvField("load", float64(0.5)),
+		kvField("drift", int64(-3)),
+	))
+
+	var b []byte
+	b = protowire.AppendTag(b, telemetryNodeID, protowire.BytesType)
+	b = protowire.AppendString(b, "xr1")
+	b = protowire.AppendTag(b, telemetrySubscriptionID, protowire.BytesType)
+	b = protowire.AppendString(b, "sub1")
+	b = protowire.AppendTag(b, telemetryEncodingPath, protowire.BytesType)
+	b = protowire.AppendString(b, "Cisco-IOS-XR-infra-statsd-oper:infra-statistics/interfaces/interface/latest/generic-counters")
+	b = protowire.AppendTag(b, telemetryDataGPBKV, protowire.BytesType)
+	b = protowire.AppendBytes(b, row)
+	return b
+}
+
+const jsonTelemetry = `{"node_id_str":"xr1","subscription_id_str":"sub1",
+"encoding_path":"Cisco-IOS-XR-infra-statsd-oper:infra-statistics/interfaces/interface/latest/generic-counters",
+"collection_id":"7","msg_timestamp":"1600000000000",
+"data_json":[{"timestamp":1600000000000,"keys":[{"interface-name":"GigabitEthernet0/0/0/0"}],
+"content":{"bytes-received":42,"load":0.5,"drift":-3}}]}`
+
+func checkResponse(t *testing.T, rsp *gnmi.SubscribeResponse) {
+	t.Helper()
+	n := rsp.GetUpdate()
+	if n.GetTimestamp() != 1600000000000*1000000 {
+		t.Errorf("unexpected timestamp %d", n.GetTimestamp())
+	}
+	prefix := n.GetPrefix()
+	if prefix.GetOrigin() != "Cisco-IOS-XR-infra-statsd-oper" || len(prefix.GetElem()) != 5 {
+		t.Fatalf("unexpected prefix: %v", prefix)
+	}
+	if prefix.GetElem()[4].GetKey()["interface-name"] != "GigabitEthernet0/0/0/0" {
+		t.Errorf("expected the keys on the last prefix element: %v", prefix)
+	}
+	vals := make(map[string]*gnmi.TypedValue)
+	for _, upd := range n.GetUpdate() {
+		vals[upd.GetPath().GetElem()[0].GetName()] = upd.GetVal()
+	}
+	if len(vals) != 3 || vals["load"].GetDoubleVal() != 0.5 || vals["drift"].GetIntVal() != -3 {
+		t.Errorf("unexpected updates: %v", n.GetUpdate())
+	}
+	if vals["bytes-received"].GetUintVal() != 42 && vals["bytes-received"].GetIntVal() != 42 {
+		t.Errorf("unexpected bytes-received value: %v", vals["bytes-received"])
+	}
+}
+
+func TestTelemetryResponses(t *testing.T) {
+	for name, b := range map[string][]byte{"gpbkv": kvTelemetry(), "json": []byte(jsonTelemetry)} {
+		t.Run(name, func(t *testing.T) {
+			tm, err := decodeTelemetry(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tm.NodeID != "xr1" || tm.SubscriptionID != "sub1" {
+				t.Errorf("unexpected telemetry header: %+v", tm)
+			}
+			rsps, err := tm.responses()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rsps) != 1 {
+				t.Fatalf("expected 1 response, got %d", len(rsps))
+			}
+			checkResponse(t, rsps[0])
+		})
+	}
+	// compact GPB
+	var b []byte
+	b = protowire.AppendTag(b, telemetryDataGPB, protowire.BytesType)
+	b = protowire.AppendBytes(b, []byte{})
+	tm, err := decodeTelemetry(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tm.responses(); err != errCompactGPB {
+		t.Errorf("expected a compact GPB error, got %v", err)
+	}
+}
+
+// freeAddr returns a local address not in use.
+func freeAddr(t *testing.T, transport string) string {
+	t.Helper()
+	if transport == transportUDP {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return conn.LocalAddr().String()
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func startInput(t *testing.T, transport string, out outputs.Output) string {
+	t.Helper()
+	addr := freeAddr(t, transport)
+	c := &CiscoMDTInput{
+		Cfg:    &Config{},
+		logger: log.New(testWriter{t}, "", 0),
+		wg:     new(sync.WaitGroup),
+	}
+	err := c.Start(context.Background(), "mdt", map[string]interface{}{
+		"address":   addr,
+		"transport": transport,
+	}, func(i inputs.Input) { i.SetOutputs(map[string]outputs.Output{"out": out}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return addr
+}
+
+type testWriter struct{ t *testing.T }
+
+func (w testWriter) Write(b []byte) (int, error) {
+	w.t.Log(string(b))
+	return len(b), nil
+}
+
+func TestCiscoMDTInputGRPC(t *testing.T) {
+	out := new(testOutput)
+	addr := startInput(t, transportGRPC, out)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(rawCodec{})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	stream, err := conn.NewStream(ctx, &mdtDialoutServiceDesc.Streams[0], "/mdt_dialout.gRPCMdtDialout/MdtDialout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args []byte
+	args = protowire.AppendTag(args, 1, protowire.VarintType)
+	args = protowire.AppendVarint(args, 1)
+	args = protowire.AppendTag(args, dialoutArgsData, protowire.BytesType)
+	args = protowire.AppendBytes(args, kvTelemetry())
+	if err = stream.SendMsg(&args); err != nil {
+		t.Fatal(err)
+	}
+	out.wait(t, 1)
+	if out.metas[0]["source"] != "xr1" || out.metas[0]["subscription-name"] != "sub1" {
+		t.Errorf("unexpected meta: %v", out.metas[0])
+	}
+	checkResponse(t, out.msgs[0].(*gnmi.SubscribeResponse))
+}
+
+func TestCiscoMDTInputUDP(t *testing.T) {
+	out := new(testOutput)
+	conn, err := net.Dial("udp", startInput(t, transportUDP, out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	payload := []byte(jsonTelemetry)
+	hdr := make([]byte, udpHeaderLen)
+	binary.BigEndian.PutUint16(hdr[0:], 1)
+	binary.BigEndian.PutUint16(hdr[2:], 2)
+	binary.BigEndian.PutUint16(hdr[4:], 1)
+	binary.BigEndian.PutUint32(hdr[8:], uint32(len(payload)))
+	if _, err = conn.Write(append(hdr, payload...)); err != nil {
+		t.Fatal(err)
+	}
+	out.wait(t, 1)
+	checkResponse(t, out.msgs[0].(*gnmi.SubscribeResponse))
+}
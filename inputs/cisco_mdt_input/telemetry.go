@@ -0,0 +1,310 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cisco_mdt_input
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/inputs"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var errCompactGPB = errors.New("compact GPB encoding is not supported, use self-describing-gpb or json")
+
+// telemetry is a decoded Cisco MDT Telemetry message,
+// see https://github.com/cisco-ie/cisco-proto/blob/master/proto/telemetry/telemetry.proto
+type telemetry struct {
+	NodeID         string      `json:"node_id_str,omitempty"`
+	SubscriptionID string      `json:"subscription_id_str,omitempty"`
+	EncodingPath   string      `json:"encoding_path,omitempty"`
+	MsgTimestamp   json.Number `json:"msg_timestamp,omitempty"`
+	Rows           []*jsonRow  `json:"data_json,omitempty"`
+
+	msgTimestamp uint64
+	gpbkv        []*field
+	gpb          bool
+}
+
+// jsonRow is a row of a JSON encoded Telemetry message.
+type jsonRow struct {
+	Timestamp json.Number            `json:"timestamp,omitempty"`
+	Keys      interface{}            `json:"keys,omitempty"`
+	Content   map[string]interface{} `json:"content,omitempty"`
+}
+
+// field is a self-describing GPB TelemetryField.
+type field struct {
+	timestamp uint64
+	name      string
+	value     interface{}
+	fields    []*field
+	delete    bool
+}
+
+// Telemetry message fields
+const (
+	telemetryNodeID         = 1
+	telemetrySubscriptionID = 3
+	telemetryEncodingPath   = 6
+	telemetryMsgTimestamp   = 10
+	telemetryDataGPBKV      = 11
+	telemetryDataGPB        = 12
+)
+
+// TelemetryField message fields
+const (
+	fieldTimestamp = 1
+	fieldName      = 2
+	fieldBytes     = 4
+	fieldString    = 5
+	fieldBool      = 6
+	fieldUint32    = 7
+	fieldUint64    = 8
+	fieldSint32    = 9
+	fieldSint64    = 10
+	fieldDouble    = 11
+	fieldFloat     = 12
+	fieldFields    = 15
+	fieldDelete    = 16
+)
+
+// decodeTelemetry decodes a GPB or JSON encoded Telemetry message.
+func decodeTelemetry(b []byte) (*telemetry, error) {
+	if tb := bytes.TrimLeft(b, " \t\r\n"); len(tb) > 0 && tb[0] == '{' {
+		t := new(telemetry)
+		d := json.NewDecoder(bytes.NewReader(tb))
+		d.UseNumber()
+		err := d.Decode(t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal JSON telemetry msg: %v", err)
+		}
+		t.msgTimestamp, _ = strconv.ParseUint(t.MsgTimestamp.String(), 10, 64)
+		return t, nil
+	}
+	t := new(telemetry)
+	err := consumeFields(b, func(num protowire.Number, v []byte, u uint64) error {
+		switch num {
+		case telemetryNodeID:
+			t.NodeID = string(v)
+		case telemetrySubscriptionID:
+			t.SubscriptionID = string(v)
+		case telemetryEncodingPath:
+			t.EncodingPath = string(v)
+		case telemetryMsgTimestamp:
+			t.msgTimestamp = u
+		case telemetryDataGPBKV:
+			f, err := decodeField(v)
+			if err != nil {
+				return err
+			}
+			t.gpbkv = append(t.gpbkv, f)
+		case telemetryDataGPB:
+			t.gpb = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal GPB telemetry msg: %v", err)
+	}
+	return t, nil
+}
+
+func decodeField(b []byte) (*field, error) {
+	f := new(field)
+	err := consumeFields(b, func(num protowire.Number, v []byte, u uint64) error {
+		switch num {
+		case fieldTimestamp:
+			f.timestamp = u
+		case fieldName:
+			f.name = string(v)
+		case fieldBytes:
+			f.value = v
+		case fieldString:
+			f.value = string(v)
+		case fieldBool:
+			f.value = u != 0
+		case fieldUint32:
+			f.value = uint32(u)
+		case fieldUint64:
+			f.value = u
+		case fieldSint32:
+			f.value = int32(protowire.DecodeZigZag(u & math.MaxUint32))
+		case fieldSint64:
+			f.value = protowire.DecodeZigZag(u)
+		case fieldDouble:
+			f.value = math.Float64frombits(u)
+		case fieldFloat:
+			f.value = math.Float32frombits(uint32(u))
+		case fieldFields:
+			sf, err := decodeField(v)
+			if err != nil {
+				return err
+			}
+			f.fields = append(f.fields, sf)
+		case fieldDelete:
+			f.delete = u != 0
+		}
+		return nil
+	})
+	return f, err
+}
+
+// consumeFields calls fn for each field of the protobuf message b,
+// with the field bytes if it is length delimited or its numeric value otherwise.
+func consumeFields(b []byte, fn func(num protowire.Number, v []byte, u uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var v []byte
+		var u uint64
+		switch typ {
+		case protowire.VarintType:
+			u, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var u32 uint32
+			u32, n = protowire.ConsumeFixed32(b)
+			u = uint64(u32)
+		case protowire.Fixed64Type:
+			u, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		err := fn(num, v, u)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// responses translates the telemetry rows into gNMI notifications,
+// prefixed with the encoding path and keyed by the row keys.
+// The rows timestamps are in milliseconds.
+func (t *telemetry) responses() ([]*gnmi.SubscribeResponse, error) {
+	if t.gpb && len(t.gpbkv) == 0 {
+		return nil, errCompactGPB
+	}
+	rsps := make([]*gnmi.SubscribeResponse, 0, len(t.gpbkv)+len(t.Rows))
+	for _, row := range t.gpbkv {
+		var keys, content map[string]interface{}
+		for _, f := range row.fields {
+			switch f.name {
+			case "keys":
+				keys = fieldsTree(f.fields)
+			case "content":
+				content = fieldsTree(f.fields)
+			}
+		}
+		rsps = append(rsps, t.response(row.timestamp, keys, content, row.delete))
+	}
+	for _, row := range t.Rows {
+		ts, _ := row.Timestamp.Int64()
+		var keys map[string]interface{}
+		switch rk := row.Keys.(type) {
+		case map[string]interface{}:
+			keys = rk
+		case []interface{}:
+			keys = make(map[string]interface{})
+			for _, k := range rk {
+				if km, ok := k.(map[string]interface{}); ok {
+					for n, v := range km {
+						keys[n] = v
+					}
+				}
+			}
+		}
+		rsps = append(rsps, t.response(uint64(ts), keys, row.Content, false))
+	}
+	return rsps, nil
+}
+
+func (t *telemetry) response(ts uint64, keys, content map[string]interface{}, del bool) *gnmi.SubscribeResponse {
+	if ts == 0 {
+		ts = t.msgTimestamp
+	}
+	n := &gnmi.Notification{
+		Timestamp: int64(ts) * 1000000,
+		Prefix:    encodingPathPrefix(t.EncodingPath, keys),
+	}
+	if del {
+		n.Delete = []*gnmi.Path{{}}
+	} else {
+		n.Update = inputs.TreeUpdates(content)
+	}
+	return &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: n}}
+}
+
+// fieldsTree converts self-describing GPB fields to a tree,
+// fields sharing a name are the entries of a list.
+func fieldsTree(fs []*field) map[string]interface{} {
+	tree := make(map[string]interface{}, len(fs))
+	for _, f := range fs {
+		var v interface{} = f.value
+		if len(f.fields) > 0 {
+			v = fieldsTree(f.fields)
+		}
+		if v == nil {
+			continue
+		}
+		switch ev := tree[f.name].(type) {
+		case nil:
+			tree[f.name] = v
+		case []interface{}:
+			tree[f.name] = append(ev, v)
+		default:
+			tree[f.name] = []interface{}{ev, v}
+		}
+	}
+	return tree
+}
+
+// encodingPathPrefix converts an encoding path such as
+// Cisco-IOS-XR-infra-statsd-oper:infra-statistics/interfaces/interface/latest/generic-counters
+// to a gNMI path, the YANG module being the origin.
+// The keys are set on the last path element.
+func encodingPathPrefix(p string, keys map[string]interface{}) *gnmi.Path {
+	prefix := new(gnmi.Path)
+	if i := strings.Index(p, ":"); i >= 0 && !strings.Contains(p[:i], "/") {
+		prefix.Origin = p[:i]
+		p = p[i+1:]
+	}
+	for _, name := range strings.Split(strings.Trim(p, "/"), "/") {
+		if name != "" {
+			prefix.Elem = append(prefix.Elem, &gnmi.PathElem{Name: name})
+		}
+	}
+	if len(keys) == 0 || len(prefix.Elem) == 0 {
+		return prefix
+	}
+	last := prefix.Elem[len(prefix.Elem)-1]
+	last.Key = make(map[string]string, len(keys))
+	for k, v := range keys {
+		if _, ok := v.(map[string]interface{}); ok {
+			continue
+		}
+		last.Key[k] = fmt.Sprint(v)
+	}
+	return prefix
+}
@@ -0,0 +1,451 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_input
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/inputs"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/record"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	loggingPrefix       = "[file_input] "
+	defaultFormat       = "event"
+	defaultPattern      = "*"
+	defaultPollInterval = time.Second
+)
+
+func init() {
+	inputs.Register("file", func() inputs.Input {
+		return &FileInput{
+			Cfg:    &Config{},
+			logger: log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags),
+			wg:     new(sync.WaitGroup),
+		}
+	})
+}
+
+// FileInput reads the messages written by file outputs to the files of a directory,
+// as they are appended to them.
+type FileInput struct {
+	Cfg    *Config
+	cfn    context.CancelFunc
+	logger *log.Logger
+
+	wg      *sync.WaitGroup
+	outputs []outputs.Output
+	evps    []formatters.EventProcessor
+
+	// file path to the position following the last message read,
+	// persisted to the offsets file
+	offsets map[string]int64
+	// file path to its size when it was last read
+	sizes map[string]int64
+}
+
+// Config //
+type Config struct {
+	Name string `mapstructure:"name,omitempty"`
+	// directory the files are read from
+	Path string `mapstructure:"path,omitempty"`
+	// glob pattern the read file names must match
+	Pattern string `mapstructure:"pattern,omitempty"`
+	// event, protojson, proto or proto-envelope
+	Format string `mapstructure:"format,omitempty"`
+	// interval between two checks of the directory files
+	PollInterval time.Duration `mapstructure:"poll-interval,omitempty"`
+	// file the read offsets are persisted to
+	OffsetsFile     string   `mapstructure:"offsets-file,omitempty"`
+	Debug           bool     `mapstructure:"debug,omitempty"`
+	Outputs         []string `mapstructure:"outputs,omitempty"`
+	EventProcessors []string `mapstructure:"event-processors,omitempty"`
+}
+
+// Start //
+func (f *FileInput) Start(ctx context.Context, name string, cfg map[string]interface{}, opts ...inputs.Option) error {
+	err := outputs.DecodeConfig(cfg, f.Cfg)
+	if err != nil {
+		return err
+	}
+	if f.Cfg.Name == "" {
+		f.Cfg.Name = name
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	err = f.setDefaults()
+	if err != nil {
+		return err
+	}
+	f.offsets, err = readOffsets(f.Cfg.OffsetsFile)
+	if err != nil {
+		return err
+	}
+	f.sizes = make(map[string]int64)
+	ctx, f.cfn = context.WithCancel(ctx)
+	f.logger.Printf("input starting with config: %+v", f.Cfg)
+	f.wg.Add(1)
+	go f.run(ctx)
+	return nil
+}
+
+func (f *FileInput) run(ctx context.Context) {
+	defer f.wg.Done()
+	ticker := time.NewTicker(f.Cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		f.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll reads the new messages of the directory files,
+// the files are read in modification time order.
+func (f *FileInput) poll(ctx context.Context) {
+	files, err := f.listFiles()
+	if err != nil {
+		f.logger.Printf("failed to list files: %v", err)
+		return
+	}
+	changed := false
+	present := make(map[string]struct{}, len(files))
+	for _, fi := range files {
+		if ctx.Err() != nil {
+			return
+		}
+		path := filepath.Join(f.Cfg.Path, fi.Name())
+		present[path] = struct{}{}
+		if size, ok := f.sizes[path]; ok && size == fi.Size() {
+			continue
+		}
+		offset := f.offsets[path]
+		if fi.Size() < f.sizes[path] || (!f.framed() && fi.Size() < offset) {
+			f.logger.Printf("file %q was truncated, reading it from the beginning", path)
+			offset = 0
+		}
+		n, err := f.readFile(ctx, path, offset)
+		if err != nil {
+			f.logger.Printf("failed to read file %q: %v", path, err)
+		}
+		if n != f.offsets[path] {
+			f.offsets[path] = n
+			changed = true
+		}
+		if err == nil {
+			f.sizes[path] = fi.Size()
+		}
+	}
+	// forget the removed files
+	for path := range f.offsets {
+		if _, ok := present[path]; !ok {
+			delete(f.offsets, path)
+			delete(f.sizes, path)
+			changed = true
+		}
+	}
+	if changed {
+		err = writeOffsets(f.Cfg.OffsetsFile, f.offsets)
+		if err != nil {
+			f.logger.Printf("failed to save the offsets: %v", err)
+		}
+	}
+}
+
+func (f *FileInput) listFiles() ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(f.Cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	offsetsFile, _ := filepath.Abs(f.Cfg.OffsetsFile)
+	files := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if ok, _ := filepath.Match(f.Cfg.Pattern, e.Name()); !ok {
+			continue
+		}
+		if p, _ := filepath.Abs(filepath.Join(f.Cfg.Path, e.Name())); p == offsetsFile || p == offsetsFile+".tmp" {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, fi)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].ModTime().Equal(files[j].ModTime()) {
+			return files[i].Name() < files[j].Name()
+		}
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	return files, nil
+}
+
+// readFile sends the messages of file path following offset to the outputs,
+// it returns the offset following the last complete message read.
+// A message still being written is left for the next poll.
+func (f *FileInput) readFile(ctx context.Context, path string, offset int64) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return offset, err
+	}
+	defer file.Close()
+	if f.framed() {
+		return f.readFramed(ctx, file, offset)
+	}
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return offset, err
+	}
+	br := bufio.NewReader(file)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return offset, nil
+			}
+			return offset, err
+		}
+		offset += int64(len(line))
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		f.processLine(ctx, line)
+	}
+}
+
+func (f *FileInput) readFramed(ctx context.Context, file *os.File, offset int64) (int64, error) {
+	r, err := record.NewReader(file)
+	if err != nil {
+		return offset, err
+	}
+	defer r.Close()
+	err = r.Skip(offset)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			// the file was replaced by a shorter one
+			return 0, fmt.Errorf("offset %d is past the end of the file", offset)
+		}
+		return offset, err
+	}
+	for {
+		b, err := r.ReadRaw()
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return r.Offset(), nil
+			}
+			return r.Offset(), err
+		}
+		var rsp *gnmi.SubscribeResponse
+		meta := outputs.Meta{}
+		switch f.Cfg.Format {
+		case formatters.FormatProtoEnvelope:
+			rsp, meta, err = formatters.UnmarshalEnvelope(b)
+		default:
+			rsp = new(gnmi.SubscribeResponse)
+			err = proto.Unmarshal(b, rsp)
+		}
+		if err != nil {
+			if f.Cfg.Debug {
+				f.logger.Printf("failed to unmarshal proto msg: %v", err)
+			}
+			continue
+		}
+		f.write(ctx, rsp, meta)
+	}
+}
+
+func (f *FileInput) processLine(ctx context.Context, b []byte) {
+	switch f.Cfg.Format {
+	case "event":
+		evMsgs, err := formatters.UnmarshalEvents(b)
+		if err != nil {
+			if f.Cfg.Debug {
+				f.logger.Printf("failed to unmarshal event msg: %v", err)
+			}
+			return
+		}
+		for _, p := range f.evps {
+			evMsgs = p.Apply(evMsgs...)
+		}
+		for _, o := range f.outputs {
+			for _, ev := range evMsgs {
+				o.WriteEvent(ctx, ev)
+			}
+		}
+	case "protojson":
+		rsp := new(gnmi.SubscribeResponse)
+		err := protojson.Unmarshal(b, rsp)
+		if err != nil {
+			if f.Cfg.Debug {
+				f.logger.Printf("failed to unmarshal protojson msg: %v", err)
+			}
+			return
+		}
+		f.write(ctx, rsp, outputs.Meta{})
+	}
+}
+
+func (f *FileInput) write(ctx context.Context, rsp *gnmi.SubscribeResponse, meta outputs.Meta) {
+	if _, ok := meta["source"]; !ok {
+		if t := rsp.GetUpdate().GetPrefix().GetTarget(); t != "" {
+			meta["source"] = t
+		}
+	}
+	for _, o := range f.outputs {
+		o.Write(ctx, rsp, meta)
+	}
+}
+
+// framed returns true if the messages are length prefixed records.
+func (f *FileInput) framed() bool {
+	return f.Cfg.Format == "proto" || f.Cfg.Format == formatters.FormatProtoEnvelope
+}
+
+// Close //
+func (f *FileInput) Close() error {
+	f.cfn()
+	f.wg.Wait()
+	return nil
+}
+
+// SetLogger //
+func (f *FileInput) SetLogger(logger *log.Logger) {
+	if logger != nil && f.logger != nil {
+		f.logger.SetOutput(logger.Writer())
+		f.logger.SetFlags(logger.Flags())
+	}
+}
+
+// SetOutputs //
+func (f *FileInput) SetOutputs(outs map[string]outputs.Output) {
+	if len(f.Cfg.Outputs) == 0 {
+		for _, o := range outs {
+			f.outputs = append(f.outputs, o)
+		}
+		return
+	}
+	for _, name := range f.Cfg.Outputs {
+		if o, ok := outs[name]; ok {
+			f.outputs = append(f.outputs, o)
+		}
+	}
+}
+
+func (f *FileInput) SetName(name string) {}
+
+func (f *FileInput) SetEventProcessors(ps map[string]map[string]interface{}, logger *log.Logger, tcs map[string]*types.TargetConfig) {
+	for _, epName := range f.Cfg.EventProcessors {
+		if epCfg, ok := ps[epName]; ok {
+			epType := ""
+			for k := range epCfg {
+				epType = k
+				break
+			}
+			if in, ok := formatters.EventProcessors[epType]; ok {
+				ep := in()
+				err := ep.Init(epCfg[epType], formatters.WithLogger(logger), formatters.WithTargets(tcs))
+				if err != nil {
+					f.logger.Printf("failed initializing event processor %q of type=%q: %v", epName, epType, err)
+					continue
+				}
+				f.evps = append(f.evps, ep)
+				f.logger.Printf("added event processor %q of type=%q to file input", epName, epType)
+			}
+		}
+	}
+}
+
+// helper functions
+
+func (f *FileInput) setDefaults() error {
+	if f.Cfg.Path == "" {
+		return errors.New("missing path")
+	}
+	if f.Cfg.Format == "" {
+		f.Cfg.Format = defaultFormat
+	}
+	f.Cfg.Format = strings.ToLower(f.Cfg.Format)
+	switch f.Cfg.Format {
+	case "event", "protojson", "proto", formatters.FormatProtoEnvelope:
+	default:
+		return fmt.Errorf("unsupported input format %q", f.Cfg.Format)
+	}
+	if f.Cfg.Pattern == "" {
+		f.Cfg.Pattern = defaultPattern
+	}
+	if _, err := filepath.Match(f.Cfg.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", f.Cfg.Pattern, err)
+	}
+	if f.Cfg.PollInterval <= 0 {
+		f.Cfg.PollInterval = defaultPollInterval
+	}
+	if f.Cfg.OffsetsFile == "" {
+		f.Cfg.OffsetsFile = filepath.Join(f.Cfg.Path, "."+f.Cfg.Name+".offsets")
+	}
+	return nil
+}
+
+func readOffsets(path string) (map[string]int64, error) {
+	offsets := make(map[string]int64)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return offsets, nil
+		}
+		return nil, err
+	}
+	err = json.Unmarshal(b, &offsets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid offsets file %q: %v", path, err)
+	}
+	return offsets, nil
+}
+
+// writeOffsets replaces the offsets file atomically.
+func writeOffsets(path string, offsets map[string]int64) error {
+	b, err := json.Marshal(offsets)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, b, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
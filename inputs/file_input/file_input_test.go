@@ -0,0 +1,174 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_input
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/record"
+	"github.com/openconfig/gnmic/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+)
+
+// testOutput records the messages and events written to it.
+type testOutput struct {
+	m      sync.Mutex
+	msgs   []proto.Message
+	metas  []outputs.Meta
+	events []*formatters.EventMsg
+}
+
+func (o *testOutput) Init(context.Context, string, map[string]interface{}, ...outputs.Option) error {
+	return nil
+}
+func (o *testOutput) Write(_ context.Context, m proto.Message, meta outputs.Meta) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.msgs = append(o.msgs, m)
+	o.metas = append(o.metas, meta)
+}
+func (o *testOutput) WriteEvent(_ context.Context, ev *formatters.EventMsg) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.events = append(o.events, ev)
+}
+func (o *testOutput) Close() error                         { return nil }
+func (o *testOutput) RegisterMetrics(*prometheus.Registry) {}
+func (o *testOutput) String() string                       { return "test" }
+func (o *testOutput) SetLogger(*log.Logger)                {}
+func (o *testOutput) SetEventProcessors(map[string]map[string]interface{}, *log.Logger, map[string]*types.TargetConfig, map[string]map[string]interface{}) {
+}
+func (o *testOutput) SetName(string)                                  {}
+func (o *testOutput) SetClusterName(string)                           {}
+func (o *testOutput) SetTargetsConfig(map[string]*types.TargetConfig) {}
+
+func newTestInput(t *testing.T, dir, format string, out outputs.Output) *FileInput {
+	t.Helper()
+	f := &FileInput{
+		Cfg:    &Config{Name: "replay", Path: dir, Format: format},
+		logger: log.New(io.Discard, "", 0),
+		wg:     new(sync.WaitGroup),
+		sizes:  make(map[string]int64),
+	}
+	if err := f.setDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	f.offsets, err = readOffsets(f.Cfg.OffsetsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetOutputs(map[string]outputs.Output{"out": out})
+	return f
+}
+
+func appendFile(t *testing.T, path string, b []byte) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err = file.Write(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileInputEvents(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "capture.json")
+	appendFile(t, path, []byte(`[{"name":"sub1","timestamp":1,"tags":{"source":"r1"},"values":{"counter":1}}]`+"\n"))
+	// a message still being written
+	appendFile(t, path, []byte(`[{"name":"sub1","timestamp":2,`))
+
+	out := new(testOutput)
+	f := newTestInput(t, dir, "event", out)
+	ctx := context.Background()
+	f.poll(ctx)
+	if len(out.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(out.events))
+	}
+	appendFile(t, path, []byte(`"tags":{"source":"r1"},"values":{"counter":2}}]`+"\n"))
+	f.poll(ctx)
+	if len(out.events) != 2 || out.events[1].Timestamp != 2 {
+		t.Fatalf("expected the completed event, got %d events", len(out.events))
+	}
+
+	// a restarted input resumes after the last read message
+	appendFile(t, path, []byte(`[{"name":"sub1","timestamp":3,"tags":{"source":"r1"},"values":{"counter":3}}]`+"\n"))
+	out = new(testOutput)
+	f = newTestInput(t, dir, "event", out)
+	f.poll(ctx)
+	if len(out.events) != 1 || out.events[0].Timestamp != 3 {
+		t.Fatalf("expected only the new event after a restart, got %d events", len(out.events))
+	}
+}
+
+func TestFileInputProto(t *testing.T) {
+	for _, compression := range []string{record.CompressionNone, record.CompressionZstd} {
+		t.Run("compression="+compression, func(t *testing.T) {
+			dir := t.TempDir()
+			writeCapture := func(name string, ts ...int64) {
+				file, err := os.Create(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer file.Close()
+				w, err := record.NewWriter(file, compression)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, n := range ts {
+					_, err = w.Write(&gnmi.SubscribeResponse{
+						Response: &gnmi.SubscribeResponse_Update{
+							Update: &gnmi.Notification{Timestamp: n, Prefix: &gnmi.Path{Target: "r1"}},
+						},
+					})
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err = w.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			writeCapture("capture-1.pb", 1, 2)
+
+			out := new(testOutput)
+			f := newTestInput(t, dir, "proto", out)
+			ctx := context.Background()
+			f.poll(ctx)
+			if len(out.msgs) != 2 {
+				t.Fatalf("expected 2 messages, got %d", len(out.msgs))
+			}
+			if out.metas[0]["source"] != "r1" {
+				t.Errorf("expected the source to be set from the prefix target, got %v", out.metas[0])
+			}
+
+			// after a restart, only the new file is read
+			writeCapture("capture-2.pb", 3)
+			out = new(testOutput)
+			f = newTestInput(t, dir, "proto", out)
+			f.poll(ctx)
+			if len(out.msgs) != 1 || out.msgs[0].(*gnmi.SubscribeResponse).GetUpdate().GetTimestamp() != 3 {
+				t.Fatalf("expected only the new message after a restart, got %d messages", len(out.msgs))
+			}
+		})
+	}
+}
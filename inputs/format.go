@@ -0,0 +1,189 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inputs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	FormatAuto      = "auto"
+	FormatEvent     = "event"
+	FormatProto     = "proto"
+	FormatProtoJSON = "protojson"
+)
+
+// Formats lists the formats of the messages consumed by the inputs.
+var Formats = []string{FormatAuto, FormatEvent, FormatProto, formatters.FormatProtoEnvelope, FormatProtoJSON}
+
+// ValidFormat returns true if f is one of the inputs formats.
+func ValidFormat(f string) bool {
+	for _, vf := range Formats {
+		if f == vf {
+			return true
+		}
+	}
+	return false
+}
+
+// Message is a message consumed by an input, decoded either
+// as a list of events or as a SubscribeResponse and its metadata.
+type Message struct {
+	// format the message was decoded from, never auto
+	Format   string
+	Events   []*formatters.EventMsg
+	Response *gnmi.SubscribeResponse
+	Meta     outputs.Meta
+}
+
+// DetectFormat guesses the format of b:
+// a JSON array is a list of events, a JSON object a protojson SubscribeResponse,
+// anything else is either a proto-envelope or a proto SubscribeResponse.
+func DetectFormat(b []byte) string {
+	tb := bytes.TrimLeft(b, " \t\r\n")
+	if len(tb) > 0 {
+		switch tb[0] {
+		case '[':
+			return FormatEvent
+		case '{':
+			return FormatProtoJSON
+		}
+	}
+	if isEnvelope(b) {
+		return formatters.FormatProtoEnvelope
+	}
+	return FormatProto
+}
+
+// isEnvelope returns true if b looks like a marshaled envelope.Envelope
+// rather than a gnmi.SubscribeResponse.
+// Both messages start with a length delimited field 1, the envelope response
+// or the SubscribeResponse update. The envelope also has a field 2, its meta.
+// Without meta, the field 1 content is inspected: a SubscribeResponse starts
+// with its update (1, bytes) or sync_response (3, varint) field,
+// a Notification with neither of them.
+func isEnvelope(b []byte) bool {
+	var first []byte
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return false
+		}
+		b = b[n:]
+		if num == 2 && typ == protowire.BytesType {
+			return true
+		}
+		if num == 1 && typ == protowire.BytesType && first == nil {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return false
+			}
+			first = v
+			b = b[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return false
+		}
+		b = b[n:]
+	}
+	if len(first) == 0 {
+		return false
+	}
+	num, typ, n := protowire.ConsumeTag(first)
+	if n < 0 {
+		return false
+	}
+	return (num == 1 && typ == protowire.BytesType) || (num == 3 && typ == protowire.VarintType)
+}
+
+// Decode decodes b according to format, if format is auto it is detected from b.
+func Decode(format string, b []byte) (*Message, error) {
+	if format == FormatAuto {
+		format = DetectFormat(b)
+	}
+	m := &Message{Format: format, Meta: outputs.Meta{}}
+	var err error
+	switch format {
+	case FormatEvent:
+		m.Events, err = formatters.UnmarshalEvents(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event msg: %v", err)
+		}
+	case FormatProto:
+		m.Response = new(gnmi.SubscribeResponse)
+		err = proto.Unmarshal(b, m.Response)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal proto msg: %v", err)
+		}
+	case formatters.FormatProtoEnvelope:
+		m.Response, m.Meta, err = formatters.UnmarshalEnvelope(b)
+		if err != nil {
+			return nil, err
+		}
+	case FormatProtoJSON:
+		m.Response = new(gnmi.SubscribeResponse)
+		err = protojson.Unmarshal(b, m.Response)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal protojson msg: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported input format %q", format)
+	}
+	return m, nil
+}
+
+// Process runs the event processors eps on the message.
+// A SubscribeResponse is converted to events first,
+// so that the processors apply whatever the consumed format is.
+func (m *Message) Process(eps []formatters.EventProcessor) error {
+	if len(eps) == 0 {
+		return nil
+	}
+	if m.Response != nil {
+		name, ok := m.Meta["subscription-name"]
+		if !ok {
+			name = "default"
+		}
+		evs, err := formatters.ResponseToEventMsgs(name, m.Response, m.Meta, eps...)
+		if err != nil {
+			return fmt.Errorf("failed converting response to events: %v", err)
+		}
+		m.Events = evs
+		m.Response = nil
+		return nil
+	}
+	for _, p := range eps {
+		m.Events = p.Apply(m.Events...)
+	}
+	return nil
+}
+
+// Write writes the message to outs, as events or as a SubscribeResponse.
+func (m *Message) Write(ctx context.Context, outs []outputs.Output) {
+	for _, o := range outs {
+		if m.Response != nil {
+			o.Write(ctx, m.Response, m.Meta)
+			continue
+		}
+		for _, ev := range m.Events {
+			o.WriteEvent(ctx, ev)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inputs
+
+import (
+	"log"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/types"
+)
+
+var testResponses = map[string]*gnmi.SubscribeResponse{
+	"update": {
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Timestamp: 42,
+				Prefix:    &gnmi.Path{Target: "router1"},
+				Update: []*gnmi.Update{{
+					Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "counter"}}},
+					Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 1}},
+				}},
+			},
+		},
+	},
+	"update_no_timestamp": {
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{Prefix: &gnmi.Path{Target: "router1"}},
+		},
+	},
+	"sync": {
+		Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true},
+	},
+}
+
+func TestDetectFormat(t *testing.T) {
+	for name, rsp := range testResponses {
+		for _, format := range []string{FormatProto, formatters.FormatProtoEnvelope, FormatProtoJSON} {
+			for _, meta := range []map[string]string{nil, {"source": "router1"}} {
+				mo := &formatters.MarshalOptions{Format: format}
+				b, err := mo.Marshal(rsp, meta)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := DetectFormat(b); got != format {
+					t.Errorf("%s: expected format %s, got %s (meta=%v)", name, format, got, meta)
+				}
+			}
+		}
+	}
+	mo := &formatters.MarshalOptions{Format: FormatEvent, EventVersion: formatters.EventVersion2}
+	b, err := mo.Marshal(testResponses["update"], map[string]string{"subscription-name": "sub1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DetectFormat(b); got != FormatEvent {
+		t.Errorf("expected format event, got %s", got)
+	}
+	m, err := Decode(FormatAuto, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Events) != 1 || m.Events[0].Name != "sub1" {
+		t.Errorf("unexpected events: %v", m.Events)
+	}
+}
+
+// tagProcessor adds a tag to the events.
+type tagProcessor struct{}
+
+func (tagProcessor) Init(interface{}, ...formatters.Option) error { return nil }
+func (tagProcessor) Apply(evs ...*formatters.EventMsg) []*formatters.EventMsg {
+	for _, ev := range evs {
+		if ev.Tags == nil {
+			ev.Tags = make(map[string]string)
+		}
+		ev.Tags["processed"] = "true"
+	}
+	return evs
+}
+func (tagProcessor) WithTargets(map[string]*types.TargetConfig)    {}
+func (tagProcessor) WithLogger(*log.Logger)                        {}
+func (tagProcessor) WithActions(map[string]map[string]interface{}) {}
+
+func TestMessageProcess(t *testing.T) {
+	mo := &formatters.MarshalOptions{Format: formatters.FormatProtoEnvelope}
+	b, err := mo.Marshal(testResponses["update"], map[string]string{"source": "router1", "subscription-name": "sub1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Decode(FormatAuto, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// without processors, the response is kept as is
+	if err = m.Process(nil); err != nil {
+		t.Fatal(err)
+	}
+	if m.Response == nil {
+		t.Fatal("expected the response to be kept")
+	}
+	// with processors, the response is converted to events
+	if err = m.Process([]formatters.EventProcessor{tagProcessor{}}); err != nil {
+		t.Fatal(err)
+	}
+	if m.Response != nil || len(m.Events) != 1 {
+		t.Fatalf("expected the response to be converted to 1 event, got %v", m.Events)
+	}
+	ev := m.Events[0]
+	if ev.Name != "sub1" || ev.Tags["source"] != "router1" || ev.Tags["processed"] != "true" {
+		t.Errorf("unexpected event: %v", ev)
+	}
+}
@@ -31,6 +31,10 @@ var InputTypes = []string{
 	"nats",
 	"stan",
 	"kafka",
+	"file",
+	"cisco-mdt",
+	"juniper-native",
+	"snmp",
 }
 
 var Inputs = map[string]Initializer{}
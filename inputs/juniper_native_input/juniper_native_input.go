@@ -0,0 +1,285 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package juniper_native_input
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/fullstorydev/grpcurl"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/inputs"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	loggingPrefix     = "[juniper_native_input] "
+	defaultBufferSize = 64 * 1024
+)
+
+func init() {
+	inputs.Register("juniper-native", func() inputs.Input {
+		return &JuniperNativeInput{
+			Cfg:    &Config{},
+			logger: log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags),
+			wg:     new(sync.WaitGroup),
+		}
+	})
+}
+
+// JuniperNativeInput receives Junos telemetry interface native sensors
+// streamed over UDP and exports them to the outputs as gNMI notifications.
+// The sensors payloads are decoded using the Junos telemetry protos.
+type JuniperNativeInput struct {
+	Cfg    *Config
+	cfn    context.CancelFunc
+	logger *log.Logger
+
+	wg      *sync.WaitGroup
+	outputs []outputs.Output
+	evps    []formatters.EventProcessor
+
+	conn net.PacketConn
+	// sensors messages by extendee and extension number
+	sensors map[string]map[protowire.Number]*desc.MessageDescriptor
+}
+
+// Config //
+type Config struct {
+	Name string `mapstructure:"name,omitempty"`
+	// listen address
+	Address string `mapstructure:"address,omitempty"`
+	// Junos telemetry proto files, telemetry_top.proto and the sensors protos
+	ProtoFiles []string `mapstructure:"proto-files,omitempty"`
+	// directories the proto files and their imports are searched in
+	ProtoDirs []string `mapstructure:"proto-dirs,omitempty"`
+	// UDP read buffer size
+	BufferSize      int      `mapstructure:"buffer-size,omitempty"`
+	Debug           bool     `mapstructure:"debug,omitempty"`
+	Outputs         []string `mapstructure:"outputs,omitempty"`
+	EventProcessors []string `mapstructure:"event-processors,omitempty"`
+}
+
+// Start //
+func (j *JuniperNativeInput) Start(ctx context.Context, name string, cfg map[string]interface{}, opts ...inputs.Option) error {
+	err := outputs.DecodeConfig(cfg, j.Cfg)
+	if err != nil {
+		return err
+	}
+	if j.Cfg.Name == "" {
+		j.Cfg.Name = name
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	err = j.setDefaults()
+	if err != nil {
+		return err
+	}
+	err = j.loadProtoFiles()
+	if err != nil {
+		return err
+	}
+	j.conn, err = net.ListenPacket("udp", j.Cfg.Address)
+	if err != nil {
+		return err
+	}
+	ctx, j.cfn = context.WithCancel(ctx)
+	j.logger.Printf("input starting with config: %+v", j.Cfg)
+	j.wg.Add(1)
+	go j.run(ctx)
+	return nil
+}
+
+func (j *JuniperNativeInput) run(ctx context.Context) {
+	defer j.wg.Done()
+	buf := make([]byte, j.Cfg.BufferSize)
+	for {
+		n, addr, err := j.conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() == nil {
+				j.logger.Printf("failed to read UDP message: %v", err)
+				continue
+			}
+			return
+		}
+		j.handle(ctx, buf[:n], addr)
+	}
+}
+
+// loadProtoFiles indexes the sensors messages by the extension numbers
+// they are carried in.
+func (j *JuniperNativeInput) loadProtoFiles() error {
+	ds, err := grpcurl.DescriptorSourceFromProtoFiles(j.Cfg.ProtoDirs, j.Cfg.ProtoFiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load proto files: %v", err)
+	}
+	j.sensors = make(map[string]map[protowire.Number]*desc.MessageDescriptor)
+	for _, extendee := range []string{juniperSensorsType, ietfSensorsType} {
+		exts, err := ds.AllExtensionsForType(extendee)
+		if err != nil {
+			continue
+		}
+		for _, ext := range exts {
+			if ext.GetMessageType() == nil {
+				continue
+			}
+			if j.sensors[extendee] == nil {
+				j.sensors[extendee] = make(map[protowire.Number]*desc.MessageDescriptor)
+			}
+			j.sensors[extendee][protowire.Number(ext.GetNumber())] = ext.GetMessageType()
+		}
+	}
+	if len(j.sensors) == 0 {
+		return errors.New("no sensor found in the proto files")
+	}
+	return nil
+}
+
+// handle exports the sensors of a TelemetryStream message received from addr.
+func (j *JuniperNativeInput) handle(ctx context.Context, b []byte, addr net.Addr) {
+	ts, err := decodeTelemetryStream(b)
+	if err != nil {
+		if j.Cfg.Debug {
+			j.logger.Printf("%s: %v", addr, err)
+		}
+		return
+	}
+	source := ts.systemID
+	if source == "" {
+		source, _, _ = net.SplitHostPort(addr.String())
+	}
+	for _, s := range ts.sensors {
+		md, ok := j.sensors[s.extendee][s.number]
+		if !ok {
+			if j.Cfg.Debug {
+				j.logger.Printf("%s: sensor %q: no %s extension %d in the proto files", source, ts.sensorName, s.extendee, s.number)
+			}
+			continue
+		}
+		m := dynamic.NewMessage(md)
+		err = m.Unmarshal(s.data)
+		if err != nil {
+			j.logger.Printf("%s: sensor %q: failed to unmarshal %s: %v", source, ts.sensorName, md.GetFullyQualifiedName(), err)
+			continue
+		}
+		msg := &inputs.Message{
+			Format: inputs.FormatProto,
+			Response: &gnmi.SubscribeResponse{
+				Response: &gnmi.SubscribeResponse_Update{
+					Update: &gnmi.Notification{
+						Timestamp: int64(ts.timestamp) * 1000000,
+						Prefix:    sensorPrefix(ts.sensorName),
+						Update:    inputs.TreeUpdates(messageTree(m)),
+					},
+				},
+			},
+			Meta: outputs.Meta{
+				"source":           source,
+				"component-id":     strconv.FormatUint(ts.componentID, 10),
+				"sub-component-id": strconv.FormatUint(ts.subComponentID, 10),
+			},
+		}
+		if name := subscriptionName(ts.sensorName); name != "" {
+			msg.Meta["subscription-name"] = name
+		}
+		err = msg.Process(j.evps)
+		if err != nil {
+			j.logger.Printf("failed to process message: %v", err)
+			continue
+		}
+		msg.Write(ctx, j.outputs)
+	}
+}
+
+// Close //
+func (j *JuniperNativeInput) Close() error {
+	if j.cfn != nil {
+		j.cfn()
+	}
+	if j.conn != nil {
+		j.conn.Close()
+	}
+	j.wg.Wait()
+	return nil
+}
+
+// SetLogger //
+func (j *JuniperNativeInput) SetLogger(logger *log.Logger) {
+	if logger != nil && j.logger != nil {
+		j.logger.SetOutput(logger.Writer())
+		j.logger.SetFlags(logger.Flags())
+	}
+}
+
+// SetOutputs //
+func (j *JuniperNativeInput) SetOutputs(outs map[string]outputs.Output) {
+	if len(j.Cfg.Outputs) == 0 {
+		for _, o := range outs {
+			j.outputs = append(j.outputs, o)
+		}
+		return
+	}
+	for _, name := range j.Cfg.Outputs {
+		if o, ok := outs[name]; ok {
+			j.outputs = append(j.outputs, o)
+		}
+	}
+}
+
+func (j *JuniperNativeInput) SetName(name string) {}
+
+func (j *JuniperNativeInput) SetEventProcessors(ps map[string]map[string]interface{}, logger *log.Logger, tcs map[string]*types.TargetConfig) {
+	for _, epName := range j.Cfg.EventProcessors {
+		if epCfg, ok := ps[epName]; ok {
+			epType := ""
+			for k := range epCfg {
+				epType = k
+				break
+			}
+			if in, ok := formatters.EventProcessors[epType]; ok {
+				ep := in()
+				err := ep.Init(epCfg[epType], formatters.WithLogger(logger), formatters.WithTargets(tcs))
+				if err != nil {
+					j.logger.Printf("failed initializing event processor %q of type=%q: %v", epName, epType, err)
+					continue
+				}
+				j.evps = append(j.evps, ep)
+				j.logger.Printf("added event processor %q of type=%q to juniper-native input", epName, epType)
+			}
+		}
+	}
+}
+
+// helper functions
+
+func (j *JuniperNativeInput) setDefaults() error {
+	if j.Cfg.Address == "" {
+		return errors.New("missing address")
+	}
+	if len(j.Cfg.ProtoFiles) == 0 {
+		return errors.New("missing proto-files")
+	}
+	if j.Cfg.BufferSize <= 0 {
+		j.Cfg.BufferSize = defaultBufferSize
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package juniper_native_input
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// testOutput records the messages written to it.
+type testOutput struct {
+	m     sync.Mutex
+	msgs  []proto.Message
+	metas []outputs.Meta
+}
+
+func (o *testOutput) Init(context.Context, string, map[string]interface{}, ...outputs.Option) error {
+	return nil
+}
+func (o *testOutput) Write(_ context.Context, m proto.Message, meta outputs.Meta) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.msgs = append(o.msgs, m)
+	o.metas = append(o.metas, meta)
+}
+func (o *testOutput) WriteEvent(context.Context, *formatters.EventMsg) {}
+func (o *testOutput) Close() error                                     { return nil }
+func (o *testOutput) RegisterMetrics(*prometheus.Registry)             {}
+func (o *testOutput) String() string                                   { return "test" }
+func (o *testOutput) SetLogger(*log.Logger)                            {}
+func (o *testOutput) SetEventProcessors(map[string]map[string]interface{}, *log.Logger, map[string]*types.TargetConfig, map[string]map[string]interface{}) {
+}
+func (o *testOutput) SetName(string)                                  {}
+func (o *testOutput) SetClusterName(string)                           {}
+func (o *testOutput) SetTargetsConfig(map[string]*types.TargetConfig) {}
+
+func newTestInput(t *testing.T) *JuniperNativeInput {
+	t.Helper()
+	j := &JuniperNativeInput{
+		Cfg: &Config{
+			Address:    "127.0.0.1:0",
+			ProtoFiles: []string{"telemetry_top.proto", "port.proto"},
+			ProtoDirs:  []string{"testdata"},
+		},
+		logger: log.New(io.Discard, "", 0),
+		wg:     new(sync.WaitGroup),
+	}
+	if err := j.setDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.loadProtoFiles(); err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+// testTelemetryStream encodes a TelemetryStream message carrying a port sensor.
+func testTelemetryStream(t *testing.T, j *JuniperNativeInput) []byte {
+	t.Helper()
+	md := j.sensors[juniperSensorsType][3]
+	if md == nil {
+		t.Fatal("port sensor not loaded")
+	}
+	port := dynamic.NewMessage(md)
+	ifd := md.FindFieldByName("interface_stats").GetMessageType()
+	intf := dynamic.NewMessage(ifd)
+	intf.SetFieldByName("if_name", "ge-0/0/0")
+	intf.SetFieldByName("init_time", uint64(1600000000))
+	intf.SetFieldByName("if_oper_status", int32(1))
+	stats := dynamic.NewMessage(ifd.FindFieldByName("ingress_stats").GetMessageType())
+	stats.SetFieldByName("if_pkts", uint64(10))
+	stats.SetFieldByName("if_octets", uint64(1000))
+	intf.SetFieldByName("ingress_stats", stats)
+	port.AddRepeatedFieldByName("interface_stats", intf)
+	pb, err := port.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jnpr []byte
+	jnpr = protowire.AppendTag(jnpr, 3, protowire.BytesType)
+	jnpr = protowire.AppendBytes(jnpr, pb)
+	var ent []byte
+	ent = protowire.AppendTag(ent, enterpriseJuniperNetworks, protowire.BytesType)
+	ent = protowire.AppendBytes(ent, jnpr)
+
+	var b []byte
+	b = protowire.AppendTag(b, streamSystemID, protowire.BytesType)
+	b = protowire.AppendString(b, "mx1:10.0.0.1")
+	b = protowire.AppendTag(b, streamComponentID, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1)
+	b = protowire.AppendTag(b, streamSensorName, protowire.BytesType)
+	b = protowire.AppendString(b, "sensor_1000:/junos/system/linecard/interface/:/junos/system/linecard/interface/:PFE")
+	b = protowire.AppendTag(b, streamTimestamp, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1600000000000)
+	b = protowire.AppendTag(b, streamEnterprise, protowire.BytesType)
+	b = protowire.AppendBytes(b, ent)
+	return b
+}
+
+func TestJuniperNativeInput(t *testing.T) {
+	j := newTestInput(t)
+	out := new(testOutput)
+	j.SetOutputs(map[string]outputs.Output{"out": out})
+	var err error
+	j.conn, err = net.ListenPacket("udp", j.Cfg.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cfn = cancel
+	j.wg.Add(1)
+	go j.run(ctx)
+	defer j.Close()
+
+	conn, err := net.Dial("udp", j.conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err = conn.Write(testTelemetryStream(t, j)); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		out.m.Lock()
+		n := len(out.msgs)
+		out.m.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no message received")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	meta := out.metas[0]
+	if meta["source"] != "mx1:10.0.0.1" || meta["subscription-name"] != "sensor_1000" || meta["component-id"] != "1" {
+		t.Errorf("unexpected meta: %v", meta)
+	}
+	n := out.msgs[0].(*gnmi.SubscribeResponse).GetUpdate()
+	if n.GetTimestamp() != 1600000000000*1000000 {
+		t.Errorf("unexpected timestamp: %d", n.GetTimestamp())
+	}
+	if len(n.GetPrefix().GetElem()) != 4 || n.GetPrefix().GetElem()[3].GetName() != "interface" {
+		t.Errorf("unexpected prefix: %v", n.GetPrefix())
+	}
+	vals := make(map[string]*gnmi.TypedValue)
+	for _, upd := range n.GetUpdate() {
+		elems := upd.GetPath().GetElem()
+		if elems[0].GetName() != "interface_stats" || elems[0].GetKey()["if_name"] != "ge-0/0/0" {
+			t.Fatalf("expected the entries to be keyed by if_name: %v", upd.GetPath())
+		}
+		name := ""
+		for _, e := range elems[1:] {
+			name += "/" + e.GetName()
+		}
+		vals[name] = upd.GetVal()
+	}
+	if len(vals) != 4 {
+		t.Fatalf("unexpected updates: %v", n.GetUpdate())
+	}
+	if vals["/ingress_stats/if_octets"].GetUintVal() != 1000 || vals["/init_time"].GetUintVal() != 1600000000 {
+		t.Errorf("unexpected values: %v", vals)
+	}
+	if vals["/if_oper_status"].GetStringVal() != "UP" {
+		t.Errorf("expected the enum name, got %v", vals["/if_oper_status"])
+	}
+}
@@ -0,0 +1,251 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package juniper_native_input
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/inputs"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// TelemetryStream message fields,
+// see telemetry_top.proto in the Junos telemetry interface protos.
+const (
+	streamSystemID       = 1
+	streamComponentID    = 2
+	streamSubComponentID = 3
+	streamSensorName     = 4
+	streamSequenceNumber = 5
+	streamTimestamp      = 6
+	streamIETF           = 100
+	streamEnterprise     = 101
+	// EnterpriseSensors extension holding the Juniper sensors
+	enterpriseJuniperNetworks = 2636
+	// google.protobuf.FieldOptions extension marking the keys of a sensor list entries
+	telemetryOptionsExt = 1024
+	telemetryOptionsKey = 1
+)
+
+const (
+	juniperSensorsType = "JuniperNetworksSensors"
+	ietfSensorsType    = "IETFSensors"
+)
+
+// telemetryStream is a decoded TelemetryStream message,
+// the sensors payloads are the extensions of IETFSensors and JuniperNetworksSensors.
+type telemetryStream struct {
+	systemID       string
+	componentID    uint64
+	subComponentID uint64
+	sensorName     string
+	sequenceNumber uint64
+	// milliseconds since epoch
+	timestamp uint64
+	sensors   []*sensor
+}
+
+// sensor is an extension field of IETFSensors or JuniperNetworksSensors.
+type sensor struct {
+	extendee string
+	number   protowire.Number
+	data     []byte
+}
+
+func decodeTelemetryStream(b []byte) (*telemetryStream, error) {
+	ts := new(telemetryStream)
+	err := consumeFields(b, func(num protowire.Number, v []byte, u uint64) error {
+		switch num {
+		case streamSystemID:
+			ts.systemID = string(v)
+		case streamComponentID:
+			ts.componentID = u
+		case streamSubComponentID:
+			ts.subComponentID = u
+		case streamSensorName:
+			ts.sensorName = string(v)
+		case streamSequenceNumber:
+			ts.sequenceNumber = u
+		case streamTimestamp:
+			ts.timestamp = u
+		case streamIETF:
+			return consumeFields(v, func(num protowire.Number, v []byte, _ uint64) error {
+				ts.sensors = append(ts.sensors, &sensor{extendee: ietfSensorsType, number: num, data: v})
+				return nil
+			})
+		case streamEnterprise:
+			return consumeFields(v, func(num protowire.Number, v []byte, _ uint64) error {
+				if num != enterpriseJuniperNetworks {
+					return nil
+				}
+				return consumeFields(v, func(num protowire.Number, v []byte, _ uint64) error {
+					ts.sensors = append(ts.sensors, &sensor{extendee: juniperSensorsType, number: num, data: v})
+					return nil
+				})
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal TelemetryStream msg: %v", err)
+	}
+	return ts, nil
+}
+
+// consumeFields calls fn for each field of the protobuf message b,
+// with the field bytes if it is length delimited or its numeric value otherwise.
+func consumeFields(b []byte, fn func(num protowire.Number, v []byte, u uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var v []byte
+		var u uint64
+		switch typ {
+		case protowire.VarintType:
+			u, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var u32 uint32
+			u32, n = protowire.ConsumeFixed32(b)
+			u = uint64(u32)
+		case protowire.Fixed64Type:
+			u, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		err := fn(num, v, u)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sensorPrefix returns the gNMI prefix of a sensor data, built from
+// the resource path of the sensor name: <name>:<resource path>:<resource path>:<component>.
+func sensorPrefix(sensorName string) *gnmi.Path {
+	prefix := new(gnmi.Path)
+	parts := strings.Split(sensorName, ":")
+	if len(parts) < 2 {
+		return prefix
+	}
+	for _, name := range strings.Split(strings.Trim(parts[1], "/"), "/") {
+		if name != "" {
+			prefix.Elem = append(prefix.Elem, &gnmi.PathElem{Name: name})
+		}
+	}
+	return prefix
+}
+
+// subscriptionName returns the name part of a sensor name.
+func subscriptionName(sensorName string) string {
+	if i := strings.Index(sensorName, ":"); i >= 0 {
+		return sensorName[:i]
+	}
+	return sensorName
+}
+
+// messageTree converts a decoded sensor message to a telemetry tree,
+// the entries of repeated message fields are keyed by their fields
+// marked with the is_key telemetry option.
+func messageTree(m *dynamic.Message) map[string]interface{} {
+	fds := m.GetKnownFields()
+	tree := make(map[string]interface{}, len(fds))
+	for _, fd := range fds {
+		if !m.HasField(fd) {
+			continue
+		}
+		v := m.GetField(fd)
+		if !fd.IsRepeated() {
+			tree[fd.GetName()] = fieldValue(fd, v)
+			continue
+		}
+		vs, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		items := make([]interface{}, 0, len(vs))
+		for _, item := range vs {
+			im, ok := item.(*dynamic.Message)
+			if !ok {
+				items = append(items, fieldValue(fd, item))
+				continue
+			}
+			entry := &inputs.ListEntry{Fields: messageTree(im)}
+			for _, kfd := range im.GetMessageDescriptor().GetFields() {
+				if !isKey(kfd) {
+					continue
+				}
+				kv, ok := entry.Fields[kfd.GetName()]
+				if !ok {
+					continue
+				}
+				if entry.Keys == nil {
+					entry.Keys = make(map[string]string)
+				}
+				entry.Keys[kfd.GetName()] = fmt.Sprint(kv)
+				delete(entry.Fields, kfd.GetName())
+			}
+			items = append(items, entry)
+		}
+		tree[fd.GetName()] = items
+	}
+	return tree
+}
+
+// fieldValue returns the tree value of a sensor message field value,
+// enums are converted to their names.
+func fieldValue(fd *desc.FieldDescriptor, v interface{}) interface{} {
+	switch v := v.(type) {
+	case *dynamic.Message:
+		return messageTree(v)
+	case int32:
+		if et := fd.GetEnumType(); et != nil {
+			if ev := et.FindValueByNumber(v); ev != nil {
+				return ev.GetName()
+			}
+		}
+	}
+	return v
+}
+
+// isKey returns true if the field has the (telemetry_options).is_key option set.
+// The option extension is not known to the protobuf registry,
+// so it is read from the field options unknown fields.
+func isKey(fd *desc.FieldDescriptor) bool {
+	opts := fd.GetFieldOptions()
+	if opts == nil {
+		return false
+	}
+	key := false
+	consumeFields(opts.ProtoReflect().GetUnknown(), func(num protowire.Number, v []byte, _ uint64) error {
+		if num != telemetryOptionsExt {
+			return nil
+		}
+		return consumeFields(v, func(num protowire.Number, _ []byte, u uint64) error {
+			if num == telemetryOptionsKey {
+				key = u != 0
+			}
+			return nil
+		})
+	})
+	return key
+}
@@ -10,7 +10,6 @@ package kafka_input
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -27,7 +26,6 @@ import (
 	"github.com/openconfig/gnmic/outputs"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
-	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -161,44 +159,21 @@ START:
 			if k.Cfg.Debug {
 				k.logger.Printf("%s client=%s received msg, topic=%s, partition=%d, key=%q, length=%d, value=%s", workerLogPrefix, config.ClientID, m.Topic, m.Partition, string(m.Key), len(m.Value), string(m.Value))
 			}
-			switch k.Cfg.Format {
-			case "event":
-				evMsgs := make([]*formatters.EventMsg, 1)
-				err = json.Unmarshal(m.Value, &evMsgs)
-				if err != nil {
-					if k.Cfg.Debug {
-						k.logger.Printf("%s failed to unmarshal event msg: %v", workerLogPrefix, err)
-					}
-					continue
-				}
-
-				for _, p := range k.evps {
-					evMsgs = p.Apply(evMsgs...)
+			msg, err := inputs.Decode(k.Cfg.Format, m.Value)
+			if err != nil {
+				if k.Cfg.Debug {
+					k.logger.Printf("%s %v", workerLogPrefix, err)
 				}
-
-				go func() {
-					for _, o := range k.outputs {
-						for _, ev := range evMsgs {
-							o.WriteEvent(ctx, ev)
-						}
-					}
-				}()
-			case "proto":
-				var protoMsg proto.Message
-				err = proto.Unmarshal(m.Value, protoMsg)
-				if err != nil {
-					if k.Cfg.Debug {
-						k.logger.Printf("%s failed to unmarshal proto msg: %v", workerLogPrefix, err)
-					}
-					continue
+				continue
+			}
+			err = msg.Process(k.evps)
+			if err != nil {
+				if k.Cfg.Debug {
+					k.logger.Printf("%s %v", workerLogPrefix, err)
 				}
-				meta := outputs.Meta{}
-				go func() {
-					for _, o := range k.outputs {
-						o.Write(ctx, protoMsg, meta)
-					}
-				}()
+				continue
 			}
+			go msg.Write(ctx, k.outputs)
 		case err := <-consumerGrp.Errors():
 			k.logger.Printf("%s client=%s, consumer-group=%s error: %v", workerLogPrefix, config.ClientID, k.Cfg.GroupID, err)
 			time.Sleep(k.Cfg.RecoveryWaitTime)
@@ -283,8 +258,9 @@ func (k *KafkaInput) setDefaults() error {
 	if k.Cfg.Format == "" {
 		k.Cfg.Format = defaultFormat
 	}
-	if !(strings.ToLower(k.Cfg.Format) == "event" || strings.ToLower(k.Cfg.Format) == "proto") {
-		return fmt.Errorf("unsupported input format")
+	k.Cfg.Format = strings.ToLower(k.Cfg.Format)
+	if !inputs.ValidFormat(k.Cfg.Format) {
+		return fmt.Errorf("unsupported input format %q, must be one of %q", k.Cfg.Format, inputs.Formats)
 	}
 	if k.Cfg.Topics == "" {
 		k.Cfg.Topics = defaultTopic
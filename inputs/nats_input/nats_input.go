@@ -10,7 +10,6 @@ package nats_input
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -26,7 +25,6 @@ import (
 	"github.com/openconfig/gnmic/outputs"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
-	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -149,50 +147,28 @@ START:
 				n.logger.Printf("received msg, subject=%s, queue=%s, len=%d, data=%s", m.Subject, m.Sub.Queue, len(m.Data), string(m.Data))
 			}
 
-			switch n.Cfg.Format {
-			case "event":
-				evMsgs := make([]*formatters.EventMsg, 1)
-				err = json.Unmarshal(m.Data, &evMsgs)
-				if err != nil {
-					if n.Cfg.Debug {
-						n.logger.Printf("%s failed to unmarshal event msg: %v", workerLogPrefix, err)
-					}
-					continue
-				}
-
-				for _, p := range n.evps {
-					evMsgs = p.Apply(evMsgs...)
-				}
-
-				go func() {
-					for _, o := range n.outputs {
-						for _, ev := range evMsgs {
-							o.WriteEvent(ctx, ev)
-						}
-					}
-				}()
-			case "proto":
-				var protoMsg proto.Message
-				err = proto.Unmarshal(m.Data, protoMsg)
-				if err != nil {
-					if n.Cfg.Debug {
-						n.logger.Printf("failed to unmarshal proto msg: %v", err)
-					}
-					continue
+			msg, err := inputs.Decode(n.Cfg.Format, m.Data)
+			if err != nil {
+				if n.Cfg.Debug {
+					n.logger.Printf("%s %v", workerLogPrefix, err)
 				}
-				meta := outputs.Meta{}
+				continue
+			}
+			if msg.Format == inputs.FormatProto {
 				subjectSections := strings.SplitN(m.Subject, ".", 3)
 				if len(subjectSections) == 3 {
-					meta["source"] = strings.ReplaceAll(subjectSections[1], "-", ".")
-					meta["subscription-name"] = subjectSections[2]
+					msg.Meta["source"] = strings.ReplaceAll(subjectSections[1], "-", ".")
+					msg.Meta["subscription-name"] = subjectSections[2]
 				}
-				go func() {
-					for _, o := range n.outputs {
-						o.Write(ctx, protoMsg, meta)
-					}
-				}()
 			}
-
+			err = msg.Process(n.evps)
+			if err != nil {
+				if n.Cfg.Debug {
+					n.logger.Printf("%s %v", workerLogPrefix, err)
+				}
+				continue
+			}
+			go msg.Write(ctx, n.outputs)
 		}
 	}
 }
@@ -266,8 +242,9 @@ func (n *NatsInput) setDefaults() error {
 	if n.Cfg.Format == "" {
 		n.Cfg.Format = defaultFormat
 	}
-	if !(strings.ToLower(n.Cfg.Format) == "event" || strings.ToLower(n.Cfg.Format) == "proto") {
-		return fmt.Errorf("unsupported input format")
+	n.Cfg.Format = strings.ToLower(n.Cfg.Format)
+	if !inputs.ValidFormat(n.Cfg.Format) {
+		return fmt.Errorf("unsupported input format %q, must be one of %q", n.Cfg.Format, inputs.Formats)
 	}
 	if n.Cfg.Name == "" {
 		n.Cfg.Name = "gnmic-" + uuid.New().String()
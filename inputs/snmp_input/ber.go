@@ -0,0 +1,386 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package snmp_input
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BER tags of the SNMP messages, see RFC 3416.
+const (
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagNull        = 0x05
+	tagOID         = 0x06
+	tagSequence    = 0x30
+
+	tagIPAddress = 0x40
+	tagCounter32 = 0x41
+	tagGauge32   = 0x42
+	tagTimeTicks = 0x43
+	tagOpaque    = 0x44
+	tagCounter64 = 0x46
+
+	tagNoSuchObject   = 0x80
+	tagNoSuchInstance = 0x81
+	tagEndOfMibView   = 0x82
+
+	pduGetRequest     = 0xa0
+	pduGetNextRequest = 0xa1
+	pduResponse       = 0xa2
+	pduGetBulkRequest = 0xa5
+)
+
+var errTruncated = errors.New("truncated message")
+
+// pdu is an SNMP protocol data unit.
+// For GetBulk requests, errorStatus and errorIndex are
+// the non-repeaters and max-repetitions fields.
+type pdu struct {
+	typ         byte
+	requestID   int64
+	errorStatus int64
+	errorIndex  int64
+	varbinds    []*varbind
+}
+
+type varbind struct {
+	oid string
+	// value tag and its decoded value,
+	// int64, uint64, string or nil
+	typ   byte
+	value interface{}
+}
+
+// message is an SNMPv1 or v2c message.
+type message struct {
+	version   int64
+	community string
+	pdu       *pdu
+}
+
+func (m *message) marshal() ([]byte, error) {
+	var vbs []byte
+	for _, vb := range m.pdu.varbinds {
+		oid, err := encodeOID(vb.oid)
+		if err != nil {
+			return nil, err
+		}
+		val, err := encodeValue(vb.typ, vb.value)
+		if err != nil {
+			return nil, fmt.Errorf("oid %s: %v", vb.oid, err)
+		}
+		vbs = append(vbs, tlv(tagSequence, append(tlv(tagOID, oid), val...))...)
+	}
+	var p []byte
+	p = append(p, tlv(tagInteger, encodeInt(m.pdu.requestID))...)
+	p = append(p, tlv(tagInteger, encodeInt(m.pdu.errorStatus))...)
+	p = append(p, tlv(tagInteger, encodeInt(m.pdu.errorIndex))...)
+	p = append(p, tlv(tagSequence, vbs)...)
+
+	var b []byte
+	b = append(b, tlv(tagInteger, encodeInt(m.version))...)
+	b = append(b, tlv(tagOctetString, []byte(m.community))...)
+	b = append(b, tlv(m.pdu.typ, p)...)
+	return tlv(tagSequence, b), nil
+}
+
+func unmarshalMessage(b []byte) (*message, error) {
+	tag, body, _, err := readTLV(b)
+	if err != nil {
+		return nil, err
+	}
+	if tag != tagSequence {
+		return nil, fmt.Errorf("unexpected message tag 0x%x", tag)
+	}
+	m := new(message)
+	tag, v, body, err := readTLV(body)
+	if err != nil {
+		return nil, err
+	}
+	if tag != tagInteger {
+		return nil, errors.New("missing version")
+	}
+	m.version = decodeInt(v)
+	tag, v, body, err = readTLV(body)
+	if err != nil {
+		return nil, err
+	}
+	if tag != tagOctetString {
+		return nil, errors.New("missing community")
+	}
+	m.community = string(v)
+	tag, v, _, err = readTLV(body)
+	if err != nil {
+		return nil, err
+	}
+	m.pdu, err = unmarshalPDU(tag, v)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func unmarshalPDU(typ byte, b []byte) (*pdu, error) {
+	p := &pdu{typ: typ}
+	for _, f := range []*int64{&p.requestID, &p.errorStatus, &p.errorIndex} {
+		tag, v, rest, err := readTLV(b)
+		if err != nil {
+			return nil, err
+		}
+		if tag != tagInteger {
+			return nil, fmt.Errorf("unexpected pdu field tag 0x%x", tag)
+		}
+		*f = decodeInt(v)
+		b = rest
+	}
+	tag, vbs, _, err := readTLV(b)
+	if err != nil {
+		return nil, err
+	}
+	if tag != tagSequence {
+		return nil, errors.New("missing varbinds")
+	}
+	for len(vbs) > 0 {
+		var vb []byte
+		tag, vb, vbs, err = readTLV(vbs)
+		if err != nil {
+			return nil, err
+		}
+		if tag != tagSequence {
+			return nil, errors.New("invalid varbind")
+		}
+		tag, oid, rest, err := readTLV(vb)
+		if err != nil {
+			return nil, err
+		}
+		if tag != tagOID {
+			return nil, errors.New("invalid varbind oid")
+		}
+		tag, val, _, err := readTLV(rest)
+		if err != nil {
+			return nil, err
+		}
+		v := &varbind{oid: decodeOID(oid), typ: tag}
+		v.value, err = decodeValue(tag, val)
+		if err != nil {
+			return nil, fmt.Errorf("oid %s: %v", v.oid, err)
+		}
+		p.varbinds = append(p.varbinds, v)
+	}
+	return p, nil
+}
+
+func tlv(tag byte, v []byte) []byte {
+	b := append([]byte{tag}, encodeLength(len(v))...)
+	return append(b, v...)
+}
+
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// readTLV returns the tag and value of the first element of b and the bytes following it.
+func readTLV(b []byte) (byte, []byte, []byte, error) {
+	if len(b) < 2 {
+		return 0, nil, nil, errTruncated
+	}
+	tag := b[0]
+	l := int(b[1])
+	b = b[2:]
+	if l&0x80 != 0 {
+		n := l & 0x7f
+		if n == 0 || n > 4 || len(b) < n {
+			return 0, nil, nil, errors.New("invalid length")
+		}
+		l = 0
+		for _, c := range b[:n] {
+			l = l<<8 | int(c)
+		}
+		b = b[n:]
+	}
+	if l > len(b) {
+		return 0, nil, nil, errTruncated
+	}
+	return tag, b[:l], b[l:], nil
+}
+
+func encodeInt(i int64) []byte {
+	b := []byte{byte(i)}
+	for i >>= 8; ; i >>= 8 {
+		// stop once the remaining bytes are only the sign extension
+		if (i == 0 && b[0]&0x80 == 0) || (i == -1 && b[0]&0x80 != 0) {
+			return b
+		}
+		b = append([]byte{byte(i)}, b...)
+	}
+}
+
+func encodeUint(u uint64) []byte {
+	b := []byte{byte(u)}
+	for u >>= 8; u > 0; u >>= 8 {
+		b = append([]byte{byte(u)}, b...)
+	}
+	if b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return b
+}
+
+func decodeInt(b []byte) int64 {
+	var i int64
+	for j, c := range b {
+		if j == 0 && c&0x80 != 0 {
+			i = -1
+		}
+		i = i<<8 | int64(c)
+	}
+	return i
+}
+
+func decodeUint(b []byte) uint64 {
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u
+}
+
+func encodeOID(oid string) ([]byte, error) {
+	parts := strings.Split(strings.Trim(oid, "."), ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid oid %q", oid)
+	}
+	arcs := make([]uint64, len(parts))
+	for i, p := range parts {
+		a, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid oid %q", oid)
+		}
+		arcs[i] = a
+	}
+	b := encodeArc(arcs[0]*40 + arcs[1])
+	for _, a := range arcs[2:] {
+		b = append(b, encodeArc(a)...)
+	}
+	return b, nil
+}
+
+func encodeArc(a uint64) []byte {
+	b := []byte{byte(a & 0x7f)}
+	for a >>= 7; a > 0; a >>= 7 {
+		b = append([]byte{byte(a&0x7f) | 0x80}, b...)
+	}
+	return b
+}
+
+func decodeOID(b []byte) string {
+	var arcs []string
+	var a uint64
+	for _, c := range b {
+		a = a<<7 | uint64(c&0x7f)
+		if c&0x80 != 0 {
+			continue
+		}
+		if len(arcs) == 0 {
+			first := a / 40
+			if first > 2 {
+				first = 2
+			}
+			arcs = append(arcs, strconv.FormatUint(first, 10), strconv.FormatUint(a-first*40, 10))
+		} else {
+			arcs = append(arcs, strconv.FormatUint(a, 10))
+		}
+		a = 0
+	}
+	return strings.Join(arcs, ".")
+}
+
+func encodeValue(typ byte, v interface{}) ([]byte, error) {
+	switch typ {
+	case tagNull, tagNoSuchObject, tagNoSuchInstance, tagEndOfMibView:
+		return tlv(typ, nil), nil
+	case tagInteger:
+		i, ok := v.(int64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected integer value %T", v)
+		}
+		return tlv(typ, encodeInt(i)), nil
+	case tagCounter32, tagGauge32, tagTimeTicks, tagCounter64:
+		u, ok := v.(uint64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected unsigned value %T", v)
+		}
+		return tlv(typ, encodeUint(u)), nil
+	case tagOctetString, tagOpaque:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected string value %T", v)
+		}
+		return tlv(typ, []byte(s)), nil
+	case tagOID:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected oid value %T", v)
+		}
+		b, err := encodeOID(s)
+		if err != nil {
+			return nil, err
+		}
+		return tlv(typ, b), nil
+	case tagIPAddress:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected ip address value %T", v)
+		}
+		b := make([]byte, 0, 4)
+		for _, p := range strings.Split(s, ".") {
+			o, err := strconv.ParseUint(p, 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ip address %q", s)
+			}
+			b = append(b, byte(o))
+		}
+		return tlv(typ, b), nil
+	}
+	return nil, fmt.Errorf("unsupported value type 0x%x", typ)
+}
+
+// decodeValue decodes a varbind value, the octet strings
+// are returned as raw strings, see formatOctetString.
+func decodeValue(typ byte, b []byte) (interface{}, error) {
+	switch typ {
+	case tagInteger:
+		return decodeInt(b), nil
+	case tagCounter32, tagGauge32, tagTimeTicks, tagCounter64:
+		return decodeUint(b), nil
+	case tagOctetString, tagOpaque:
+		return string(b), nil
+	case tagOID:
+		return decodeOID(b), nil
+	case tagIPAddress:
+		if len(b) != 4 {
+			return nil, errors.New("invalid ip address")
+		}
+		return fmt.Sprintf("%d.%d.%d.%d", b[0], b[1], b[2], b[3]), nil
+	case tagNull, tagNoSuchObject, tagNoSuchInstance, tagEndOfMibView:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported value type 0x%x", typ)
+}
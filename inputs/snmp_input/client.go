@@ -0,0 +1,181 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package snmp_input
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	version1  = 0
+	version2c = 1
+
+	// maximum SNMP message size read
+	maxMessageSize = 65535
+)
+
+var errEndOfWalk = errors.New("end of walk")
+
+// client is an SNMPv1 or v2c client of a single agent.
+// Requests are sent one at a time and retried on timeout.
+type client struct {
+	conn           net.Conn
+	version        int64
+	community      string
+	timeout        time.Duration
+	retries        int
+	maxRepetitions int64
+
+	requestID int64
+	buf       []byte
+}
+
+func newClient(ctx context.Context, t *TargetConfig, timeout time.Duration, retries int, maxRepetitions int64) (*client, error) {
+	addr := t.Address
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, defaultPort)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &client{
+		conn:           conn,
+		version:        version2c,
+		community:      t.Community,
+		timeout:        timeout,
+		retries:        retries,
+		maxRepetitions: maxRepetitions,
+		requestID:      rand.Int63n(1 << 30),
+		buf:            make([]byte, maxMessageSize),
+	}
+	if t.Version == "1" {
+		c.version = version1
+	}
+	return c, nil
+}
+
+func (c *client) Close() error {
+	return c.conn.Close()
+}
+
+// get returns the values of the oids.
+func (c *client) get(ctx context.Context, oids ...string) ([]*varbind, error) {
+	vbs := make([]*varbind, 0, len(oids))
+	for _, oid := range oids {
+		vbs = append(vbs, &varbind{oid: oid, typ: tagNull})
+	}
+	p, err := c.request(ctx, &pdu{typ: pduGetRequest, varbinds: vbs})
+	if err != nil {
+		return nil, err
+	}
+	return p.varbinds, nil
+}
+
+// walk returns the values of the oids under root,
+// using GetBulk requests with SNMPv2c and GetNext requests with SNMPv1.
+func (c *client) walk(ctx context.Context, root string) ([]*varbind, error) {
+	root = strings.Trim(root, ".")
+	var result []*varbind
+	oid := root
+	for {
+		req := &pdu{typ: pduGetNextRequest, varbinds: []*varbind{{oid: oid, typ: tagNull}}}
+		if c.version == version2c {
+			req.typ = pduGetBulkRequest
+			req.errorIndex = c.maxRepetitions
+		}
+		p, err := c.request(ctx, req)
+		if err == errEndOfWalk {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(p.varbinds) == 0 {
+			return result, nil
+		}
+		for _, vb := range p.varbinds {
+			if vb.typ == tagEndOfMibView || !strings.HasPrefix(vb.oid, root+".") {
+				return result, nil
+			}
+			if vb.oid == oid {
+				return nil, fmt.Errorf("agent returned a non increasing oid %s", vb.oid)
+			}
+			result = append(result, vb)
+			oid = vb.oid
+		}
+	}
+}
+
+// request sends the pdu and returns the response pdu with the same request id.
+func (c *client) request(ctx context.Context, req *pdu) (*pdu, error) {
+	c.requestID++
+	req.requestID = c.requestID
+	b, err := (&message{version: c.version, community: c.community, pdu: req}).marshal()
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		_, err = c.conn.Write(b)
+		if err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(c.timeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		c.conn.SetReadDeadline(deadline)
+		for {
+			var n int
+			n, err = c.conn.Read(c.buf)
+			if err != nil {
+				break
+			}
+			m, err := unmarshalMessage(c.buf[:n])
+			if err != nil || m.pdu.typ != pduResponse || m.pdu.requestID != req.requestID {
+				// ignore invalid or stale responses
+				continue
+			}
+			return c.checkResponse(req, m.pdu)
+		}
+		var nerr net.Error
+		if !errors.As(err, &nerr) || !nerr.Timeout() {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("request timeout after %d attempt(s)", c.retries+1)
+}
+
+// checkResponse returns the error status of an SNMP response.
+// With SNMPv1, a noSuchName error in response to a GetNext request ends a walk.
+func (c *client) checkResponse(req, rsp *pdu) (*pdu, error) {
+	switch rsp.errorStatus {
+	case 0:
+		return rsp, nil
+	case 2:
+		if req.typ == pduGetNextRequest {
+			return nil, errEndOfWalk
+		}
+	}
+	oid := ""
+	if rsp.errorIndex > 0 && int(rsp.errorIndex) <= len(req.varbinds) {
+		oid = req.varbinds[rsp.errorIndex-1].oid
+	}
+	return nil, fmt.Errorf("agent error status %d, oid %q", rsp.errorStatus, oid)
+}
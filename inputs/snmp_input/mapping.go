@@ -0,0 +1,245 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package snmp_input
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/openconfig/gnmic/formatters"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	formatString = "string"
+	formatHex    = "hex"
+
+	defaultIndexTag = "index"
+)
+
+// mapping maps the polled SNMP objects to gNMI like paths and tags.
+type mapping struct {
+	// scalar objects, polled with Get requests
+	Scalars []*scalarMapping `yaml:"scalars,omitempty"`
+	// table columns, walked with GetBulk or GetNext requests
+	Tables []*tableMapping `yaml:"tables,omitempty"`
+}
+
+type scalarMapping struct {
+	OID  string `yaml:"oid,omitempty"`
+	Path string `yaml:"path,omitempty"`
+	// octet strings rendering, string or hex,
+	// printable strings are rendered as strings and the others in hex by default
+	Format string `yaml:"format,omitempty"`
+}
+
+type tableMapping struct {
+	// events name
+	Name string `yaml:"name,omitempty"`
+	// path of the table rows, the columns paths are relative to it
+	Path string `yaml:"path,omitempty"`
+	// name of the tag set to the rows index, defaults to index
+	IndexTag string           `yaml:"index-tag,omitempty"`
+	Columns  []*columnMapping `yaml:"columns,omitempty"`
+}
+
+type columnMapping struct {
+	OID string `yaml:"oid,omitempty"`
+	// value path, relative to the table path
+	Path string `yaml:"path,omitempty"`
+	// if set, the column is a tag of the rows instead of a value
+	Tag    string `yaml:"tag,omitempty"`
+	Format string `yaml:"format,omitempty"`
+}
+
+func loadMapping(file string) (*mapping, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	m := new(mapping)
+	err = yaml.Unmarshal(b, m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mapping file %q: %v", file, err)
+	}
+	err = m.validate()
+	if err != nil {
+		return nil, fmt.Errorf("mapping file %q: %v", file, err)
+	}
+	return m, nil
+}
+
+func (m *mapping) validate() error {
+	if len(m.Scalars) == 0 && len(m.Tables) == 0 {
+		return errors.New("no scalars nor tables defined")
+	}
+	for _, s := range m.Scalars {
+		s.OID = strings.Trim(s.OID, ".")
+		if s.OID == "" || s.Path == "" {
+			return fmt.Errorf("scalar %q: oid and path are required", s.OID)
+		}
+		if err := validFormat(s.Format); err != nil {
+			return fmt.Errorf("scalar %q: %v", s.OID, err)
+		}
+		s.Path = absPath(s.Path)
+	}
+	for _, t := range m.Tables {
+		if t.Name == "" {
+			return errors.New("table name is required")
+		}
+		if len(t.Columns) == 0 {
+			return fmt.Errorf("table %q: no columns defined", t.Name)
+		}
+		if t.IndexTag == "" {
+			t.IndexTag = defaultIndexTag
+		}
+		t.Path = absPath(t.Path)
+		for _, c := range t.Columns {
+			c.OID = strings.Trim(c.OID, ".")
+			if c.OID == "" || (c.Path == "" && c.Tag == "") {
+				return fmt.Errorf("table %q: column %q: oid and either path or tag are required", t.Name, c.OID)
+			}
+			if err := validFormat(c.Format); err != nil {
+				return fmt.Errorf("table %q: column %q: %v", t.Name, c.OID, err)
+			}
+			if c.Path != "" {
+				c.Path = path.Join(t.Path, c.Path)
+			}
+		}
+	}
+	return nil
+}
+
+func validFormat(f string) error {
+	switch f {
+	case "", formatString, formatHex:
+		return nil
+	}
+	return fmt.Errorf("unknown format %q", f)
+}
+
+func absPath(p string) string {
+	return "/" + strings.Trim(p, "/")
+}
+
+// scalarsEvent returns the event holding the polled scalars values.
+func (m *mapping) scalarsEvent(name string, ts int64, vbs []*varbind) *formatters.EventMsg {
+	ev := &formatters.EventMsg{
+		Name:      name,
+		Timestamp: ts,
+		Tags:      make(map[string]string),
+		Values:    make(map[string]interface{}),
+	}
+	byOID := make(map[string]*varbind, len(vbs))
+	for _, vb := range vbs {
+		byOID[vb.oid] = vb
+	}
+	for _, s := range m.Scalars {
+		vb, ok := byOID[s.OID]
+		if !ok || vb.value == nil {
+			continue
+		}
+		ev.Values[s.Path] = formatValue(vb, s.Format)
+	}
+	return ev
+}
+
+// tableEvents returns an event per row of the table,
+// columns holds the walked values of each column, by column OID.
+func (t *tableMapping) tableEvents(ts int64, columns map[string][]*varbind) []*formatters.EventMsg {
+	rows := make(map[string]*formatters.EventMsg)
+	for _, c := range t.Columns {
+		for _, vb := range columns[c.OID] {
+			if vb.value == nil {
+				continue
+			}
+			index := strings.TrimPrefix(vb.oid, c.OID+".")
+			ev, ok := rows[index]
+			if !ok {
+				ev = &formatters.EventMsg{
+					Name:      t.Name,
+					Timestamp: ts,
+					Tags:      map[string]string{t.IndexTag: index},
+					Values:    make(map[string]interface{}),
+				}
+				rows[index] = ev
+			}
+			v := formatValue(vb, c.Format)
+			if c.Tag != "" {
+				ev.Tags[c.Tag] = fmt.Sprint(v)
+				continue
+			}
+			ev.Values[c.Path] = v
+		}
+	}
+	indexes := make([]string, 0, len(rows))
+	for index, ev := range rows {
+		// rows with tags only
+		if len(ev.Values) == 0 {
+			continue
+		}
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return oidLess(indexes[i], indexes[j]) })
+	evs := make([]*formatters.EventMsg, 0, len(indexes))
+	for _, index := range indexes {
+		evs = append(evs, rows[index])
+	}
+	return evs
+}
+
+// formatValue returns the event value of a varbind,
+// octet strings are rendered according to format.
+func formatValue(vb *varbind, format string) interface{} {
+	if vb.typ != tagOctetString && vb.typ != tagOpaque {
+		return vb.value
+	}
+	s, _ := vb.value.(string)
+	switch format {
+	case formatString:
+		return s
+	case formatHex:
+		return hexString(s)
+	}
+	for _, r := range s {
+		if r == unicode.ReplacementChar || (!unicode.IsPrint(r) && !unicode.IsSpace(r)) {
+			return hexString(s)
+		}
+	}
+	return s
+}
+
+// hexString renders b as colon separated hex bytes, e.g: a MAC address.
+func hexString(b string) string {
+	parts := make([]string, len(b))
+	for i := 0; i < len(b); i++ {
+		parts[i] = fmt.Sprintf("%02x", b[i])
+	}
+	return strings.Join(parts, ":")
+}
+
+// oidLess compares two OIDs arc by arc.
+func oidLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		if len(as[i]) != len(bs[i]) {
+			return len(as[i]) < len(bs[i])
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,309 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package snmp_input
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/inputs"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+)
+
+const (
+	loggingPrefix         = "[snmp_input] "
+	defaultPort           = "161"
+	defaultCommunity      = "public"
+	defaultVersion        = "2c"
+	defaultInterval       = time.Minute
+	defaultTimeout        = 5 * time.Second
+	defaultRetries        = 1
+	defaultMaxRepetitions = 10
+	// maximum number of scalars requested with a single Get request
+	maxGetOIDs = 32
+)
+
+func init() {
+	inputs.Register("snmp", func() inputs.Input {
+		return &SNMPInput{
+			Cfg:    &Config{},
+			logger: log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags),
+			wg:     new(sync.WaitGroup),
+		}
+	})
+}
+
+// SNMPInput polls SNMP agents and exports the polled objects as events,
+// the objects are mapped to gNMI like paths and tags using a mapping file.
+type SNMPInput struct {
+	Cfg    *Config
+	cfn    context.CancelFunc
+	logger *log.Logger
+
+	wg      *sync.WaitGroup
+	outputs []outputs.Output
+	evps    []formatters.EventProcessor
+
+	mapping *mapping
+}
+
+// Config //
+type Config struct {
+	Name string `mapstructure:"name,omitempty"`
+	// polled agents, by name
+	Targets map[string]*TargetConfig `mapstructure:"targets,omitempty"`
+	// interval between two polls
+	Interval time.Duration `mapstructure:"interval,omitempty"`
+	// timeout of each SNMP request
+	Timeout time.Duration `mapstructure:"timeout,omitempty"`
+	// number of times a request is retried on timeout
+	Retries int `mapstructure:"retries,omitempty"`
+	// GetBulk max-repetitions
+	MaxRepetitions int `mapstructure:"max-repetitions,omitempty"`
+	// file mapping the SNMP objects to paths and tags
+	MappingFile     string   `mapstructure:"mapping-file,omitempty"`
+	Debug           bool     `mapstructure:"debug,omitempty"`
+	Outputs         []string `mapstructure:"outputs,omitempty"`
+	EventProcessors []string `mapstructure:"event-processors,omitempty"`
+}
+
+// TargetConfig is the configuration of a polled agent.
+type TargetConfig struct {
+	// agent address, the port defaults to 161
+	Address string `mapstructure:"address,omitempty"`
+	// SNMP version, 1 or 2c
+	Version   string `mapstructure:"version,omitempty"`
+	Community string `mapstructure:"community,omitempty"`
+}
+
+// Start //
+func (s *SNMPInput) Start(ctx context.Context, name string, cfg map[string]interface{}, opts ...inputs.Option) error {
+	err := outputs.DecodeConfig(cfg, s.Cfg)
+	if err != nil {
+		return err
+	}
+	if s.Cfg.Name == "" {
+		s.Cfg.Name = name
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	err = s.setDefaults()
+	if err != nil {
+		return err
+	}
+	s.mapping, err = loadMapping(s.Cfg.MappingFile)
+	if err != nil {
+		return err
+	}
+	ctx, s.cfn = context.WithCancel(ctx)
+	s.logger.Printf("input starting with config: %+v", s.Cfg)
+	for tName, t := range s.Cfg.Targets {
+		s.wg.Add(1)
+		go s.run(ctx, tName, t)
+	}
+	return nil
+}
+
+// run polls the target every interval until ctx is done.
+func (s *SNMPInput) run(ctx context.Context, name string, t *TargetConfig) {
+	defer s.wg.Done()
+	var c *client
+	defer func() {
+		if c != nil {
+			c.Close()
+		}
+	}()
+	ticker := time.NewTicker(s.Cfg.Interval)
+	defer ticker.Stop()
+	for {
+		var err error
+		if c == nil {
+			c, err = newClient(ctx, t, s.Cfg.Timeout, s.Cfg.Retries, int64(s.Cfg.MaxRepetitions))
+			if err != nil {
+				s.logger.Printf("target %q: failed to create SNMP client: %v", name, err)
+			}
+		}
+		if c != nil {
+			s.poll(ctx, name, c)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll retrieves the mapped objects from the target and writes them to the outputs.
+func (s *SNMPInput) poll(ctx context.Context, name string, c *client) {
+	now := time.Now().UnixNano()
+	var evs []*formatters.EventMsg
+	if len(s.mapping.Scalars) > 0 {
+		vbs := make([]*varbind, 0, len(s.mapping.Scalars))
+		for i := 0; i < len(s.mapping.Scalars); i += maxGetOIDs {
+			end := i + maxGetOIDs
+			if end > len(s.mapping.Scalars) {
+				end = len(s.mapping.Scalars)
+			}
+			oids := make([]string, 0, end-i)
+			for _, sc := range s.mapping.Scalars[i:end] {
+				oids = append(oids, sc.OID)
+			}
+			rvbs, err := c.get(ctx, oids...)
+			if err != nil {
+				s.logger.Printf("target %q: failed to get scalars: %v", name, err)
+				continue
+			}
+			vbs = append(vbs, rvbs...)
+		}
+		if ev := s.mapping.scalarsEvent(s.Cfg.Name, now, vbs); len(ev.Values) > 0 {
+			evs = append(evs, ev)
+		}
+	}
+	for _, t := range s.mapping.Tables {
+		columns := make(map[string][]*varbind, len(t.Columns))
+		for _, col := range t.Columns {
+			vbs, err := c.walk(ctx, col.OID)
+			if err != nil {
+				s.logger.Printf("target %q: table %q: failed to walk %s: %v", name, t.Name, col.OID, err)
+				continue
+			}
+			columns[col.OID] = vbs
+		}
+		evs = append(evs, t.tableEvents(now, columns)...)
+	}
+	if ctx.Err() != nil || len(evs) == 0 {
+		return
+	}
+	for _, ev := range evs {
+		ev.Tags["source"] = name
+	}
+	if s.Cfg.Debug {
+		s.logger.Printf("target %q: polled %d events", name, len(evs))
+	}
+	msg := &inputs.Message{
+		Format: inputs.FormatEvent,
+		Events: evs,
+		Meta:   outputs.Meta{"source": name},
+	}
+	err := msg.Process(s.evps)
+	if err != nil {
+		s.logger.Printf("failed to process message: %v", err)
+		return
+	}
+	msg.Write(ctx, s.outputs)
+}
+
+// Close //
+func (s *SNMPInput) Close() error {
+	if s.cfn != nil {
+		s.cfn()
+	}
+	s.wg.Wait()
+	return nil
+}
+
+// SetLogger //
+func (s *SNMPInput) SetLogger(logger *log.Logger) {
+	if logger != nil && s.logger != nil {
+		s.logger.SetOutput(logger.Writer())
+		s.logger.SetFlags(logger.Flags())
+	}
+}
+
+// SetOutputs //
+func (s *SNMPInput) SetOutputs(outs map[string]outputs.Output) {
+	if len(s.Cfg.Outputs) == 0 {
+		for _, o := range outs {
+			s.outputs = append(s.outputs, o)
+		}
+		return
+	}
+	for _, name := range s.Cfg.Outputs {
+		if o, ok := outs[name]; ok {
+			s.outputs = append(s.outputs, o)
+		}
+	}
+}
+
+func (s *SNMPInput) SetName(name string) {}
+
+func (s *SNMPInput) SetEventProcessors(ps map[string]map[string]interface{}, logger *log.Logger, tcs map[string]*types.TargetConfig) {
+	for _, epName := range s.Cfg.EventProcessors {
+		if epCfg, ok := ps[epName]; ok {
+			epType := ""
+			for k := range epCfg {
+				epType = k
+				break
+			}
+			if in, ok := formatters.EventProcessors[epType]; ok {
+				ep := in()
+				err := ep.Init(epCfg[epType], formatters.WithLogger(logger), formatters.WithTargets(tcs))
+				if err != nil {
+					s.logger.Printf("failed initializing event processor %q of type=%q: %v", epName, epType, err)
+					continue
+				}
+				s.evps = append(s.evps, ep)
+				s.logger.Printf("added event processor %q of type=%q to snmp input", epName, epType)
+			}
+		}
+	}
+}
+
+// helper functions
+
+func (s *SNMPInput) setDefaults() error {
+	if len(s.Cfg.Targets) == 0 {
+		return errors.New("missing targets")
+	}
+	if s.Cfg.MappingFile == "" {
+		return errors.New("missing mapping-file")
+	}
+	for name, t := range s.Cfg.Targets {
+		if t == nil {
+			t = new(TargetConfig)
+			s.Cfg.Targets[name] = t
+		}
+		if t.Address == "" {
+			t.Address = name
+		}
+		if t.Community == "" {
+			t.Community = defaultCommunity
+		}
+		switch t.Version {
+		case "":
+			t.Version = defaultVersion
+		case "1", "2c":
+		default:
+			return fmt.Errorf("target %q: unsupported SNMP version %q, must be 1 or 2c", name, t.Version)
+		}
+	}
+	if s.Cfg.Interval <= 0 {
+		s.Cfg.Interval = defaultInterval
+	}
+	if s.Cfg.Timeout <= 0 {
+		s.Cfg.Timeout = defaultTimeout
+	}
+	if s.Cfg.Retries <= 0 {
+		s.Cfg.Retries = defaultRetries
+	}
+	if s.Cfg.MaxRepetitions <= 0 {
+		s.Cfg.MaxRepetitions = defaultMaxRepetitions
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package snmp_input
+
+import (
+	"context"
+	"io"
+	"log"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/inputs"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBER(t *testing.T) {
+	for _, i := range []int64{0, 1, -1, 127, 128, -128, -129, 255, 256, math.MaxInt32, math.MinInt64} {
+		if got := decodeInt(encodeInt(i)); got != i {
+			t.Errorf("integer %d: got %d", i, got)
+		}
+	}
+	for _, u := range []uint64{0, 127, 128, 255, math.MaxUint32, math.MaxUint64} {
+		if got := decodeUint(encodeUint(u)); got != u {
+			t.Errorf("unsigned %d: got %d", u, got)
+		}
+	}
+	for _, oid := range []string{"1.3.6.1.2.1.1.3.0", "1.3.6.1.4.1.2636.3.1.13.1.8.9.1.0.0", "2.999.3"} {
+		b, err := encodeOID(oid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := decodeOID(b); got != oid {
+			t.Errorf("oid %s: got %s", oid, got)
+		}
+	}
+}
+
+// testMIB is an SNMP agent MIB.
+var testMIB = map[string]*varbind{
+	"1.3.6.1.2.1.1.3.0":      {typ: tagTimeTicks, value: uint64(4200)},
+	"1.3.6.1.2.1.1.5.0":      {typ: tagOctetString, value: "router1"},
+	"1.3.6.1.2.1.2.2.1.2.1":  {typ: tagOctetString, value: "eth0"},
+	"1.3.6.1.2.1.2.2.1.2.2":  {typ: tagOctetString, value: "eth1"},
+	"1.3.6.1.2.1.2.2.1.6.1":  {typ: tagOctetString, value: "\x00\x1a\x2b\x3c\x4d\x5e"},
+	"1.3.6.1.2.1.2.2.1.6.2":  {typ: tagOctetString, value: "\x00\x1a\x2b\x3c\x4d\x5f"},
+	"1.3.6.1.2.1.2.2.1.8.1":  {typ: tagInteger, value: int64(1)},
+	"1.3.6.1.2.1.2.2.1.8.2":  {typ: tagInteger, value: int64(2)},
+	"1.3.6.1.2.1.2.2.1.10.1": {typ: tagCounter32, value: uint64(1000)},
+	"1.3.6.1.2.1.2.2.1.10.2": {typ: tagCounter32, value: uint64(2000)},
+	"1.3.6.1.2.1.4.1.0":      {typ: tagInteger, value: int64(1)},
+}
+
+// testAgent answers the Get, GetNext and GetBulk requests from testMIB.
+func testAgent(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	oids := make([]string, 0, len(testMIB))
+	for oid := range testMIB {
+		oids = append(oids, oid)
+	}
+	sort.Slice(oids, func(i, j int) bool { return oidLess(oids[i], oids[j]) })
+	next := func(oid string) *varbind {
+		for _, o := range oids {
+			if oidLess(oid, o) {
+				return &varbind{oid: o, typ: testMIB[o].typ, value: testMIB[o].value}
+			}
+		}
+		return &varbind{oid: oid, typ: tagEndOfMibView}
+	}
+	go func() {
+		buf := make([]byte, maxMessageSize)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			m, err := unmarshalMessage(buf[:n])
+			if err != nil || m.community != "public" {
+				continue
+			}
+			rsp := &pdu{typ: pduResponse, requestID: m.pdu.requestID}
+			switch m.pdu.typ {
+			case pduGetRequest:
+				for _, vb := range m.pdu.varbinds {
+					if v, ok := testMIB[vb.oid]; ok {
+						rsp.varbinds = append(rsp.varbinds, &varbind{oid: vb.oid, typ: v.typ, value: v.value})
+						continue
+					}
+					rsp.varbinds = append(rsp.varbinds, &varbind{oid: vb.oid, typ: tagNoSuchObject})
+				}
+			case pduGetNextRequest:
+				vb := next(m.pdu.varbinds[0].oid)
+				if vb.typ == tagEndOfMibView {
+					rsp.errorStatus, rsp.errorIndex = 2, 1
+					rsp.varbinds = m.pdu.varbinds
+					break
+				}
+				rsp.varbinds = []*varbind{vb}
+			case pduGetBulkRequest:
+				oid := m.pdu.varbinds[0].oid
+				for i := int64(0); i < m.pdu.errorIndex; i++ {
+					vb := next(oid)
+					rsp.varbinds = append(rsp.varbinds, vb)
+					if vb.typ == tagEndOfMibView {
+						break
+					}
+					oid = vb.oid
+				}
+			}
+			b, err := (&message{version: m.version, community: m.community, pdu: rsp}).marshal()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.WriteTo(b, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+const testMapping = `
+scalars:
+  - oid: 1.3.6.1.2.1.1.3.0
+    path: /system/state/up-time
+  - oid: .1.3.6.1.2.1.1.5.0
+    path: system/state/hostname
+  - oid: 1.3.6.1.2.1.1.99.0
+    path: /system/state/unknown
+tables:
+  - name: interfaces
+    path: /interfaces/interface
+    index-tag: ifIndex
+    columns:
+      - oid: 1.3.6.1.2.1.2.2.1.2
+        tag: interface_name
+      - oid: 1.3.6.1.2.1.2.2.1.6
+        path: state/mac-address
+      - oid: 1.3.6.1.2.1.2.2.1.8
+        path: state/oper-status
+      - oid: 1.3.6.1.2.1.2.2.1.10
+        path: state/counters/in-octets
+`
+
+// testOutput records the events written to it.
+type testOutput struct {
+	m   sync.Mutex
+	evs []*formatters.EventMsg
+}
+
+func (o *testOutput) Init(context.Context, string, map[string]interface{}, ...outputs.Option) error {
+	return nil
+}
+func (o *testOutput) Write(context.Context, proto.Message, outputs.Meta) {}
+func (o *testOutput) WriteEvent(_ context.Context, ev *formatters.EventMsg) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.evs = append(o.evs, ev)
+}
+func (o *testOutput) Close() error                         { return nil }
+func (o *testOutput) RegisterMetrics(*prometheus.Registry) {}
+func (o *testOutput) String() string                       { return "test" }
+func (o *testOutput) SetLogger(*log.Logger)                {}
+func (o *testOutput) SetEventProcessors(map[string]map[string]interface{}, *log.Logger, map[string]*types.TargetConfig, map[string]map[string]interface{}) {
+}
+func (o *testOutput) SetName(string)                                  {}
+func (o *testOutput) SetClusterName(string)                           {}
+func (o *testOutput) SetTargetsConfig(map[string]*types.TargetConfig) {}
+
+func TestSNMPInput(t *testing.T) {
+	mappingFile := filepath.Join(t.TempDir(), "mapping.yaml")
+	if err := os.WriteFile(mappingFile, []byte(testMapping), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []string{"1", "2c"} {
+		t.Run("v"+version, func(t *testing.T) {
+			out := new(testOutput)
+			s := &SNMPInput{
+				Cfg:    &Config{},
+				logger: log.New(io.Discard, "", 0),
+				wg:     new(sync.WaitGroup),
+			}
+			err := s.Start(context.Background(), "snmp1", map[string]interface{}{
+				"targets": map[string]interface{}{
+					"router1": map[string]interface{}{
+						"address": testAgent(t),
+						"version": version,
+					},
+				},
+				"mapping-file":    mappingFile,
+				"timeout":         "1s",
+				"max-repetitions": 3,
+			}, inputs.WithOutputs(map[string]outputs.Output{"out": out}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				out.m.Lock()
+				n := len(out.evs)
+				out.m.Unlock()
+				if n >= 3 {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("expected 3 events, got %d", n)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			out.m.Lock()
+			defer out.m.Unlock()
+			scalars := out.evs[0]
+			if scalars.Name != "snmp1" || scalars.Tags["source"] != "router1" || len(scalars.Values) != 2 {
+				t.Errorf("unexpected scalars event: %+v", scalars)
+			}
+			if scalars.Values["/system/state/up-time"] != uint64(4200) || scalars.Values["/system/state/hostname"] != "router1" {
+				t.Errorf("unexpected scalars values: %v", scalars.Values)
+			}
+			eth1 := out.evs[2]
+			if eth1.Name != "interfaces" || eth1.Tags["ifIndex"] != "2" || eth1.Tags["interface_name"] != "eth1" || eth1.Tags["source"] != "router1" {
+				t.Errorf("unexpected row tags: %+v", eth1.Tags)
+			}
+			if len(eth1.Values) != 3 ||
+				eth1.Values["/interfaces/interface/state/counters/in-octets"] != uint64(2000) ||
+				eth1.Values["/interfaces/interface/state/oper-status"] != int64(2) ||
+				eth1.Values["/interfaces/interface/state/mac-address"] != "00:1a:2b:3c:4d:5f" {
+				t.Errorf("unexpected row values: %v", eth1.Values)
+			}
+		})
+	}
+}
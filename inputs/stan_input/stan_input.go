@@ -26,7 +26,6 @@ import (
 	"github.com/openconfig/gnmic/outputs"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
-	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -197,8 +196,9 @@ func (s *StanInput) setDefaults() error {
 	if s.Cfg.Format == "" {
 		s.Cfg.Format = defaultFormat
 	}
-	if !(strings.ToLower(s.Cfg.Format) == "event" || strings.ToLower(s.Cfg.Format) == "proto") {
-		return fmt.Errorf("unsupported input format")
+	s.Cfg.Format = strings.ToLower(s.Cfg.Format)
+	if !inputs.ValidFormat(s.Cfg.Format) {
+		return fmt.Errorf("unsupported input format %q, must be one of %q", s.Cfg.Format, inputs.Formats)
 	}
 	if s.Cfg.Name == "" {
 		s.Cfg.Name = "gnmic-" + uuid.New().String()
@@ -274,50 +274,28 @@ func (s *StanInput) handleMsg(m *stan.Msg) {
 	if s.Cfg.Debug {
 		s.logger.Printf("received msg, subject=%q, queue=%q, len=%d, data=%s", m.Subject, s.Cfg.Queue, len(m.Data), string(m.Data))
 	}
-	var err error
-	switch s.Cfg.Format {
-	case "event":
-		evMsgs := make([]*formatters.EventMsg, 1)
-		err = json.Unmarshal(m.Data, &evMsgs)
-		if err != nil {
-			if s.Cfg.Debug {
-				s.logger.Printf("failed to unmarshal event msg: %v", err)
-			}
-			return
-		}
-
-		for _, p := range s.evps {
-			evMsgs = p.Apply(evMsgs...)
-		}
-
-		go func() {
-			for _, o := range s.outputs {
-				for _, ev := range evMsgs {
-					o.WriteEvent(s.ctx, ev)
-				}
-			}
-		}()
-	case "proto":
-		var protoMsg proto.Message
-		err = proto.Unmarshal(m.Data, protoMsg)
-		if err != nil {
-			if s.Cfg.Debug {
-				s.logger.Printf("failed to unmarshal proto msg: %v", err)
-			}
-			return
+	msg, err := inputs.Decode(s.Cfg.Format, m.Data)
+	if err != nil {
+		if s.Cfg.Debug {
+			s.logger.Printf("%v", err)
 		}
-		meta := outputs.Meta{}
+		return
+	}
+	if msg.Format == inputs.FormatProto {
 		subjectSections := strings.SplitN(m.Subject, ".", 3)
 		if len(subjectSections) == 3 {
-			meta["source"] = strings.ReplaceAll(subjectSections[1], "-", ".")
-			meta["subscription-name"] = subjectSections[2]
+			msg.Meta["source"] = strings.ReplaceAll(subjectSections[1], "-", ".")
+			msg.Meta["subscription-name"] = subjectSections[2]
 		}
-		go func() {
-			for _, o := range s.outputs {
-				o.Write(s.ctx, protoMsg, meta)
-			}
-		}()
 	}
+	err = msg.Process(s.evps)
+	if err != nil {
+		if s.Cfg.Debug {
+			s.logger.Printf("%v", err)
+		}
+		return
+	}
+	go msg.Write(s.ctx, s.outputs)
 }
 
 func (s *StanInput) String() string {
@@ -0,0 +1,138 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inputs
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// ListEntry is an entry of a list in a decoded telemetry tree,
+// its keys are set on the list path element.
+type ListEntry struct {
+	Keys   map[string]string
+	Fields map[string]interface{}
+}
+
+// TreeUpdates flattens a telemetry tree decoded from a vendor format
+// into gNMI updates, with one update per leaf and paths relative to the tree root.
+// Maps are containers, lists of ListEntry or maps are lists and other values are leaves.
+// A list entry without keys is keyed by its position in the list.
+func TreeUpdates(tree map[string]interface{}) []*gnmi.Update {
+	upds := make([]*gnmi.Update, 0, len(tree))
+	return appendTreeUpdates(upds, nil, tree)
+}
+
+func appendTreeUpdates(upds []*gnmi.Update, elems []*gnmi.PathElem, tree map[string]interface{}) []*gnmi.Update {
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch v := tree[name].(type) {
+		case map[string]interface{}:
+			upds = appendTreeUpdates(upds, appendElem(elems, name, nil), v)
+		case []interface{}:
+			if !isList(v) {
+				if tv := TypedValue(v); tv != nil {
+					upds = append(upds, &gnmi.Update{Path: &gnmi.Path{Elem: appendElem(elems, name, nil)}, Val: tv})
+				}
+				continue
+			}
+			for i, item := range v {
+				switch item := item.(type) {
+				case *ListEntry:
+					keys := item.Keys
+					if len(keys) == 0 {
+						keys = map[string]string{"index": strconv.Itoa(i)}
+					}
+					upds = appendTreeUpdates(upds, appendElem(elems, name, keys), item.Fields)
+				case map[string]interface{}:
+					upds = appendTreeUpdates(upds, appendElem(elems, name, map[string]string{"index": strconv.Itoa(i)}), item)
+				}
+			}
+		default:
+			if tv := TypedValue(v); tv != nil {
+				upds = append(upds, &gnmi.Update{Path: &gnmi.Path{Elem: appendElem(elems, name, nil)}, Val: tv})
+			}
+		}
+	}
+	return upds
+}
+
+// appendElem returns a copy of elems followed by an element named name.
+func appendElem(elems []*gnmi.PathElem, name string, keys map[string]string) []*gnmi.PathElem {
+	nelems := make([]*gnmi.PathElem, len(elems), len(elems)+1)
+	copy(nelems, elems)
+	return append(nelems, &gnmi.PathElem{Name: name, Key: keys})
+}
+
+// isList returns true if vs is a list of entries rather than a leaf-list.
+func isList(vs []interface{}) bool {
+	for _, v := range vs {
+		switch v.(type) {
+		case *ListEntry, map[string]interface{}:
+			return true
+		}
+	}
+	return false
+}
+
+// TypedValue converts a decoded leaf value to a gNMI typed value,
+// it returns nil if the value type is not supported.
+func TypedValue(v interface{}) *gnmi.TypedValue {
+	switch v := v.(type) {
+	case string:
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: v}}
+	case bool:
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_BoolVal{BoolVal: v}}
+	case []byte:
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_BytesVal{BytesVal: v}}
+	case int:
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_IntVal{IntVal: int64(v)}}
+	case int32:
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_IntVal{IntVal: int64(v)}}
+	case int64:
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_IntVal{IntVal: v}}
+	case uint:
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: uint64(v)}}
+	case uint32:
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: uint64(v)}}
+	case uint64:
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: v}}
+	case float32:
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_DoubleVal{DoubleVal: float64(v)}}
+	case float64:
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_DoubleVal{DoubleVal: v}}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return &gnmi.TypedValue{Value: &gnmi.TypedValue_IntVal{IntVal: i}}
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: u}}
+		}
+		if f, err := v.Float64(); err == nil {
+			return &gnmi.TypedValue{Value: &gnmi.TypedValue_DoubleVal{DoubleVal: f}}
+		}
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: v.String()}}
+	case []interface{}:
+		elems := make([]*gnmi.TypedValue, 0, len(v))
+		for _, item := range v {
+			if tv := TypedValue(item); tv != nil {
+				elems = append(elems, tv)
+			}
+		}
+		return &gnmi.TypedValue{Value: &gnmi.TypedValue_LeaflistVal{LeaflistVal: &gnmi.ScalarArray{Element: elems}}}
+	}
+	return nil
+}
@@ -10,7 +10,11 @@ package all
 
 import (
 	_ "github.com/openconfig/gnmic/loaders/consul_loader"
+	_ "github.com/openconfig/gnmic/loaders/dns_loader"
 	_ "github.com/openconfig/gnmic/loaders/docker_loader"
 	_ "github.com/openconfig/gnmic/loaders/file_loader"
+	_ "github.com/openconfig/gnmic/loaders/graphql_loader"
 	_ "github.com/openconfig/gnmic/loaders/http_loader"
+	_ "github.com/openconfig/gnmic/loaders/k8s_loader"
+	_ "github.com/openconfig/gnmic/loaders/netbox_loader"
 )
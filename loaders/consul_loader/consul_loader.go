@@ -370,6 +370,16 @@ func (c *consulLoader) serviceEntryToTargetConfig(se *api.ServiceEntry) (*types.
 			}
 			tc.Address = net.JoinHostPort(tc.Address, strconv.Itoa(se.Service.Port))
 			tc.Name = se.Service.ID
+			// the service meta is added to the target vars,
+			// overriding the vars from the service config.
+			if len(se.Service.Meta) > 0 {
+				if tc.Vars == nil {
+					tc.Vars = make(map[string]string, len(se.Service.Meta))
+				}
+				for k, v := range se.Service.Meta {
+					tc.Vars[k] = v
+				}
+			}
 			return tc, nil
 		}
 	}
@@ -0,0 +1,636 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dns_loader
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/openconfig/gnmic/loaders"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	loggingPrefix      = "[dns_loader] "
+	loaderType         = "dns"
+	defaultInterval    = 5 * time.Minute
+	defaultMinInterval = 10 * time.Second
+	defaultTimeout     = 2 * time.Second
+	resolvConfPath     = "/etc/resolv.conf"
+	mdnsAddress        = "224.0.0.251:5353"
+	maxUDPMessageSize  = 65535
+)
+
+// defaultTXTKeys are the TXT record keys allowed when txt-keys is not set,
+// none of them affects the security of the connection to the target.
+var defaultTXTKeys = []string{
+	"subscriptions",
+	"outputs",
+	"profiles",
+	"tags",
+	"event-tags",
+	"vendor",
+	"encoding-preference",
+	"timeout",
+	"buffer-size",
+	"retry",
+	"gzip",
+	"probe-capabilities",
+	"capabilities-ttl",
+	"multiplex-subscriptions",
+	"max-streams",
+}
+
+// deniedTXTKeys are the TXT record keys never applied to a target:
+// its identity, credentials, TLS settings and the paths of local files.
+var deniedTXTKeys = map[string]struct{}{
+	"name":                  {},
+	"address":               {},
+	"username":              {},
+	"password":              {},
+	"token":                 {},
+	"token-auth":            {},
+	"credentials":           {},
+	"insecure":              {},
+	"skip-verify":           {},
+	"tls-ca":                {},
+	"tls-cert":              {},
+	"tls-key":               {},
+	"tls-min-version":       {},
+	"tls-max-version":       {},
+	"tls-version":           {},
+	"tls-cipher-suites":     {},
+	"tls-curve-preferences": {},
+	"log-tls-secret":        {},
+	"spiffe-id":             {},
+	"proxy":                 {},
+	"ssh-tunnel":            {},
+	"resolver":              {},
+	"connection-profile":    {},
+	"proto-files":           {},
+	"proto-dirs":            {},
+	"namespace":             {},
+}
+
+const (
+	modeSRV   = "srv"
+	modeDNSSD = "dns-sd"
+	modeMDNS  = "mdns"
+)
+
+func init() {
+	loaders.Register(loaderType, func() loaders.TargetLoader {
+		logger := log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags)
+		return &dnsLoader{
+			cfg:     &cfg{},
+			tracker: loaders.NewTracker(logger),
+			logger:  logger,
+		}
+	})
+}
+
+type dnsLoader struct {
+	cfg            *cfg
+	tracker        *loaders.Tracker
+	targetConfigFn func(*types.TargetConfig) error
+	logger         *log.Logger
+	//
+	servers       []string
+	txtKeys       map[string]struct{}
+	actionsConfig map[string]map[string]interface{}
+}
+
+type cfg struct {
+	// discovery mode, one of "srv", "dns-sd" or "mdns"
+	Mode string `json:"mode,omitempty" mapstructure:"mode,omitempty"`
+	// list of names to query.
+	// SRV record names in "srv" mode, e.g: _gnmi._tcp.lab.example.com,
+	// service types to browse in "dns-sd" and "mdns" modes, e.g: _gnmi._tcp.local
+	Services []string `json:"services,omitempty" mapstructure:"services,omitempty"`
+	// list of DNS servers addresses, defaults to the nameservers in /etc/resolv.conf.
+	// not used in "mdns" mode.
+	Servers []string `json:"servers,omitempty" mapstructure:"servers,omitempty"`
+	// DNS query timeout
+	Timeout time.Duration `json:"timeout,omitempty" mapstructure:"timeout,omitempty"`
+	// maximum refresh interval, used when no records are found
+	Interval time.Duration `json:"interval,omitempty" mapstructure:"interval,omitempty"`
+	// minimum refresh interval, applied when the records TTL is lower
+	MinInterval time.Duration `json:"min-interval,omitempty" mapstructure:"min-interval,omitempty"`
+	// if true, the TXT records key=value pairs are applied to the targets configuration
+	UseTXT bool `json:"use-txt,omitempty" mapstructure:"use-txt,omitempty"`
+	// list of TXT record keys allowed to set a target configuration field,
+	// defaults to defaultTXTKeys. The credentials and TLS keys are always rejected.
+	TXTKeys []string `json:"txt-keys,omitempty" mapstructure:"txt-keys,omitempty"`
+	// time to wait before the first query
+	StartDelay time.Duration `json:"start-delay,omitempty" mapstructure:"start-delay,omitempty"`
+	// if true, registers dnsLoader prometheus metrics with the provided
+	// prometheus registry
+	EnableMetrics bool `json:"enable-metrics,omitempty" mapstructure:"enable-metrics,omitempty"`
+	// enable Debug
+	Debug bool `json:"debug,omitempty" mapstructure:"debug,omitempty"`
+	// variables definitions to be passed to the actions
+	Vars map[string]interface{}
+	// variable file, values in this file will be overwritten by
+	// the ones defined in Vars
+	VarsFile string `mapstructure:"vars-file,omitempty"`
+	// list of Actions to run on new target discovery
+	OnAdd []string `json:"on-add,omitempty" mapstructure:"on-add,omitempty"`
+	// list of Actions to run on target removal
+	OnDelete []string `json:"on-delete,omitempty" mapstructure:"on-delete,omitempty"`
+}
+
+func (d *dnsLoader) Init(ctx context.Context, cfg map[string]interface{}, logger *log.Logger, opts ...loaders.Option) error {
+	err := loaders.DecodeConfig(cfg, d.cfg)
+	if err != nil {
+		return err
+	}
+	err = d.setDefaults()
+	if err != nil {
+		return err
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	if logger != nil {
+		d.logger.SetOutput(logger.Writer())
+		d.logger.SetFlags(logger.Flags())
+	}
+	return d.tracker.Init(ctx, &loaders.TrackerConfig{
+		OnAdd:    d.cfg.OnAdd,
+		OnDelete: d.cfg.OnDelete,
+		Actions:  d.actionsConfig,
+		Vars:     d.cfg.Vars,
+		VarsFile: d.cfg.VarsFile,
+		Timeout:  d.cfg.Interval,
+		Debug:    d.cfg.Debug,
+	})
+}
+
+func (d *dnsLoader) Start(ctx context.Context) chan *loaders.TargetOperation {
+	opChan := make(chan *loaders.TargetOperation)
+	go func() {
+		defer close(opChan)
+		time.Sleep(d.cfg.StartDelay)
+		for {
+			next := d.update(ctx, opChan)
+			dnsLoaderRefreshInterval.WithLabelValues(loaderType).Set(next.Seconds())
+			if d.cfg.Debug {
+				d.logger.Printf("next refresh in %s", next)
+			}
+			select {
+			case <-ctx.Done():
+				d.logger.Printf("%q context done: %v", loaderType, ctx.Err())
+				return
+			case <-time.After(next):
+			}
+		}
+	}()
+	return opChan
+}
+
+func (d *dnsLoader) RunOnce(ctx context.Context) (map[string]*types.TargetConfig, error) {
+	readTargets, _, err := d.getTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if d.cfg.Debug {
+		d.logger.Printf("dns loader discovered %d target(s)", len(readTargets))
+	}
+	return readTargets, nil
+}
+
+// update queries the DNS records and updates the targets,
+// it returns the duration to wait before the next refresh.
+func (d *dnsLoader) update(ctx context.Context, opChan chan *loaders.TargetOperation) time.Duration {
+	readTargets, ttl, err := d.getTargets(ctx)
+	if err != nil {
+		d.logger.Printf("failed to read targets from DNS: %v", err)
+		return d.cfg.MinInterval
+	}
+	select {
+	case <-ctx.Done():
+		return 0
+	default:
+		d.updateTargets(ctx, readTargets, opChan)
+	}
+	return d.refreshInterval(ttl)
+}
+
+// refreshInterval returns the refresh interval derived from the
+// records minimum TTL, bounded by the configured min-interval and interval.
+func (d *dnsLoader) refreshInterval(ttl time.Duration) time.Duration {
+	switch {
+	case ttl <= 0:
+		return d.cfg.Interval
+	case ttl < d.cfg.MinInterval:
+		return d.cfg.MinInterval
+	case ttl > d.cfg.Interval:
+		return d.cfg.Interval
+	}
+	return ttl
+}
+
+func (d *dnsLoader) setDefaults() error {
+	if len(d.cfg.Services) == 0 {
+		return errors.New("missing services")
+	}
+	switch d.cfg.Mode {
+	case "":
+		d.cfg.Mode = modeSRV
+	case modeSRV, modeDNSSD, modeMDNS:
+	default:
+		return fmt.Errorf("unknown mode %q, must be one of %q", d.cfg.Mode, []string{modeSRV, modeDNSSD, modeMDNS})
+	}
+	if d.cfg.Timeout <= 0 {
+		d.cfg.Timeout = defaultTimeout
+	}
+	if d.cfg.Interval <= 0 {
+		d.cfg.Interval = defaultInterval
+	}
+	if d.cfg.MinInterval <= 0 {
+		d.cfg.MinInterval = defaultMinInterval
+	}
+	if d.cfg.MinInterval > d.cfg.Interval {
+		d.cfg.MinInterval = d.cfg.Interval
+	}
+	if len(d.cfg.TXTKeys) == 0 {
+		d.cfg.TXTKeys = defaultTXTKeys
+	}
+	d.txtKeys = make(map[string]struct{}, len(d.cfg.TXTKeys))
+	for _, k := range d.cfg.TXTKeys {
+		k = strings.ToLower(k)
+		if _, ok := deniedTXTKeys[k]; ok {
+			return fmt.Errorf("txt-keys: key %q cannot be set from TXT records", k)
+		}
+		d.txtKeys[k] = struct{}{}
+	}
+	if d.cfg.Mode == modeMDNS {
+		return nil
+	}
+	for _, s := range d.cfg.Servers {
+		if _, _, err := net.SplitHostPort(s); err != nil {
+			s = net.JoinHostPort(s, "53")
+		}
+		d.servers = append(d.servers, s)
+	}
+	if len(d.servers) == 0 {
+		servers, err := readResolvConf(resolvConfPath)
+		if err != nil {
+			return fmt.Errorf("no DNS servers configured and failed to read %s: %v", resolvConfPath, err)
+		}
+		d.servers = servers
+	}
+	if len(d.servers) == 0 {
+		return errors.New("no DNS servers configured")
+	}
+	return nil
+}
+
+// getTargets queries the configured services and builds the targets configurations.
+// It returns the targets and the minimum TTL of the used records.
+func (d *dnsLoader) getTargets(ctx context.Context) (map[string]*types.TargetConfig, time.Duration, error) {
+	rs := newRecordSet()
+	result := make(map[string]*types.TargetConfig)
+	for _, svc := range d.cfg.Services {
+		svc = fqdn(svc)
+		switch d.cfg.Mode {
+		case modeSRV:
+			err := d.lookup(ctx, rs, svc, dnsmessage.TypeSRV, dnsmessage.TypeTXT)
+			if err != nil {
+				return nil, 0, err
+			}
+			d.addTargets(result, rs, svc, "")
+		case modeDNSSD, modeMDNS:
+			err := d.lookup(ctx, rs, svc, dnsmessage.TypePTR)
+			if err != nil {
+				return nil, 0, err
+			}
+			for _, instance := range rs.ptr[svc] {
+				err = d.lookup(ctx, rs, instance, dnsmessage.TypeSRV, dnsmessage.TypeTXT)
+				if err != nil {
+					return nil, 0, err
+				}
+				d.addTargets(result, rs, instance, instanceName(instance, svc))
+			}
+		}
+	}
+	if d.cfg.Debug {
+		d.logger.Printf("result: %v", result)
+	}
+	return result, rs.minTTL, nil
+}
+
+// addTargets adds a target config per SRV record of name to result.
+// If targetName is empty the SRV record target host is used as the target name.
+func (d *dnsLoader) addTargets(result map[string]*types.TargetConfig, rs *recordSet, name, targetName string) {
+	for _, srv := range rs.srv[name] {
+		host := strings.TrimSuffix(srv.target, ".")
+		if host == "" {
+			// a "." target means the service is not available
+			continue
+		}
+		addr := host
+		// use the address records from the additional section if present,
+		// mDNS .local names are not resolvable by the system resolver
+		if ips := rs.addr[srv.target]; len(ips) > 0 {
+			addr = ips[0]
+		}
+		tc := &types.TargetConfig{
+			Name:    targetName,
+			Address: net.JoinHostPort(addr, strconv.Itoa(int(srv.port))),
+		}
+		if tc.Name == "" {
+			tc.Name = host
+		}
+		if _, ok := result[tc.Name]; ok {
+			tc.Name = fmt.Sprintf("%s:%d", tc.Name, srv.port)
+		}
+		if d.cfg.UseTXT {
+			err := d.applyTXT(tc, rs.txt[name])
+			if err != nil {
+				d.logger.Printf("failed to apply TXT records of %q to target %q: %v", name, tc.Name, err)
+			}
+		}
+		result[tc.Name] = tc
+	}
+}
+
+// applyTXT sets the target config fields from the key=value TXT record strings.
+func (d *dnsLoader) applyTXT(tc *types.TargetConfig, txt []string) error {
+	fields := make(map[string]interface{})
+	for _, s := range txt {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok || k == "" {
+			continue
+		}
+		k = strings.ToLower(k)
+		if _, ok := deniedTXTKeys[k]; ok {
+			continue
+		}
+		if _, ok := d.txtKeys[k]; !ok {
+			continue
+		}
+		fields[k] = v
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	decoder, err := mapstructure.NewDecoder(
+		&mapstructure.DecoderConfig{
+			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+				mapstructure.StringToTimeDurationHookFunc(),
+				mapstructure.StringToSliceHookFunc(","),
+			),
+			WeaklyTypedInput: true,
+			Result:           tc,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(fields)
+}
+
+// lookup queries name for the given record types,
+// unless the records were already received as part of a previous response.
+func (d *dnsLoader) lookup(ctx context.Context, rs *recordSet, name string, qtypes ...dnsmessage.Type) error {
+	for _, qt := range qtypes {
+		if rs.has(name, qt) {
+			continue
+		}
+		msgs, err := d.exchange(ctx, name, qt)
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			rs.add(msg)
+		}
+	}
+	return nil
+}
+
+// exchange sends a query for name and type qt and returns the received responses.
+func (d *dnsLoader) exchange(ctx context.Context, name string, qt dnsmessage.Type) ([]*dnsmessage.Message, error) {
+	n, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, err
+	}
+	q := &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               uint16(rand.Intn(1 << 16)),
+			RecursionDesired: d.cfg.Mode != modeMDNS,
+		},
+		Questions: []dnsmessage.Question{
+			{Name: n, Type: qt, Class: dnsmessage.ClassINET},
+		},
+	}
+	b, err := q.Pack()
+	if err != nil {
+		return nil, err
+	}
+	dnsLoaderQueriesTotal.WithLabelValues(loaderType).Add(1)
+	if d.cfg.Debug {
+		d.logger.Printf("querying %s %s", qt, name)
+	}
+	if d.cfg.Mode == modeMDNS {
+		msgs, err := d.exchangeMulticast(ctx, b)
+		if err != nil {
+			dnsLoaderFailedQueries.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+			return nil, err
+		}
+		return msgs, nil
+	}
+	for _, server := range d.servers {
+		var msg *dnsmessage.Message
+		msg, err = d.exchangeUnicast(ctx, "udp", server, b, q.ID)
+		if err == nil && msg.Truncated {
+			msg, err = d.exchangeUnicast(ctx, "tcp", server, b, q.ID)
+		}
+		if err != nil {
+			dnsLoaderFailedQueries.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+			d.logger.Printf("query %s %s to %s failed: %v", qt, name, server, err)
+			continue
+		}
+		switch msg.RCode {
+		case dnsmessage.RCodeSuccess, dnsmessage.RCodeNameError:
+			return []*dnsmessage.Message{msg}, nil
+		}
+		err = fmt.Errorf("query %s %s to %s failed: %s", qt, name, server, msg.RCode)
+		dnsLoaderFailedQueries.WithLabelValues(loaderType, msg.RCode.String()).Add(1)
+	}
+	return nil, err
+}
+
+func (d *dnsLoader) exchangeUnicast(ctx context.Context, network, server string, b []byte, id uint16) (*dnsmessage.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+	conn, err := new(net.Dialer).DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if network == "tcp" {
+		// TCP messages are prefixed with a 2 bytes length field
+		_, err = conn.Write(append([]byte{byte(len(b) >> 8), byte(len(b))}, b...))
+		if err != nil {
+			return nil, err
+		}
+		l := make([]byte, 2)
+		if _, err = io.ReadFull(conn, l); err != nil {
+			return nil, err
+		}
+		rb := make([]byte, int(l[0])<<8|int(l[1]))
+		if _, err = io.ReadFull(conn, rb); err != nil {
+			return nil, err
+		}
+		return parseResponse(rb, id)
+	}
+	if _, err = conn.Write(b); err != nil {
+		return nil, err
+	}
+	rb := make([]byte, maxUDPMessageSize)
+	for {
+		n, err := conn.Read(rb)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := parseResponse(rb[:n], id)
+		if err != nil {
+			// ignore unrelated or malformed responses
+			continue
+		}
+		return msg, nil
+	}
+}
+
+// exchangeMulticast sends a one-shot mDNS query from an ephemeral port,
+// the responders reply directly to that port (RFC 6762 section 5.1).
+// It returns all the responses received before the timeout expires.
+func (d *dnsLoader) exchangeMulticast(ctx context.Context, b []byte) ([]*dnsmessage.Message, error) {
+	maddr, err := net.ResolveUDPAddr("udp4", mdnsAddress)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(d.cfg.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+	if _, err = conn.WriteTo(b, maddr); err != nil {
+		return nil, err
+	}
+	msgs := make([]*dnsmessage.Message, 0)
+	rb := make([]byte, maxUDPMessageSize)
+	for {
+		n, _, err := conn.ReadFrom(rb)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return msgs, nil
+			}
+			return msgs, err
+		}
+		msg := new(dnsmessage.Message)
+		if err := msg.Unpack(rb[:n]); err != nil || !msg.Response {
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
+func (d *dnsLoader) updateTargets(ctx context.Context, tcs map[string]*types.TargetConfig, opChan chan *loaders.TargetOperation) {
+	var err error
+	for _, tc := range tcs {
+		err = d.targetConfigFn(tc)
+		if err != nil {
+			d.logger.Printf("failed running target config fn on target %q", tc.Name)
+		}
+	}
+	targetOp := d.tracker.Update(ctx, tcs, d.cfg.Interval)
+	numAdds := len(targetOp.Add)
+	numDels := len(targetOp.Del)
+	defer func() {
+		dnsLoaderLoadedTargets.WithLabelValues(loaderType).Set(float64(numAdds))
+		dnsLoaderDeletedTargets.WithLabelValues(loaderType).Set(float64(numDels))
+	}()
+	if numAdds+numDels == 0 {
+		return
+	}
+	opChan <- targetOp
+}
+
+func parseResponse(b []byte, id uint16) (*dnsmessage.Message, error) {
+	msg := new(dnsmessage.Message)
+	err := msg.Unpack(b)
+	if err != nil {
+		return nil, err
+	}
+	if !msg.Response || msg.ID != id {
+		return nil, errors.New("unexpected DNS message")
+	}
+	return msg, nil
+}
+
+// readResolvConf returns the nameservers addresses listed in a resolv.conf file.
+func readResolvConf(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	servers := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		servers = append(servers, net.JoinHostPort(fields[1], "53"))
+	}
+	return servers, scanner.Err()
+}
+
+// instanceName returns the DNS-SD instance name of a service instance,
+// e.g: router1 for router1._gnmi._tcp.local.
+func instanceName(instance, service string) string {
+	name := strings.TrimSuffix(instance, "."+service)
+	if name == instance {
+		return strings.TrimSuffix(instance, ".")
+	}
+	return name
+}
+
+func fqdn(name string) string {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
@@ -0,0 +1,75 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dns_loader
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var dnsLoaderLoadedTargets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "dns_loader",
+	Name:      "number_of_loaded_targets",
+	Help:      "Number of new targets successfully loaded",
+}, []string{"loader_type"})
+
+var dnsLoaderDeletedTargets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "dns_loader",
+	Name:      "number_of_deleted_targets",
+	Help:      "Number of targets successfully deleted",
+}, []string{"loader_type"})
+
+var dnsLoaderFailedQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gnmic",
+	Subsystem: "dns_loader",
+	Name:      "number_of_failed_dns_queries",
+	Help:      "Number of times a DNS query failed",
+}, []string{"loader_type", "error"})
+
+var dnsLoaderQueriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gnmic",
+	Subsystem: "dns_loader",
+	Name:      "number_of_dns_queries_total",
+	Help:      "Number of DNS queries sent by the loader",
+}, []string{"loader_type"})
+
+var dnsLoaderRefreshInterval = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "dns_loader",
+	Name:      "refresh_interval_seconds",
+	Help:      "Interval until the next DNS records refresh, derived from the records TTL",
+}, []string{"loader_type"})
+
+func initMetrics() {
+	dnsLoaderLoadedTargets.WithLabelValues(loaderType).Set(0)
+	dnsLoaderDeletedTargets.WithLabelValues(loaderType).Set(0)
+	dnsLoaderFailedQueries.WithLabelValues(loaderType, "").Add(0)
+	dnsLoaderQueriesTotal.WithLabelValues(loaderType).Add(0)
+	dnsLoaderRefreshInterval.WithLabelValues(loaderType).Set(0)
+}
+
+func registerMetrics(reg *prometheus.Registry) error {
+	initMetrics()
+	var err error
+	if err = reg.Register(dnsLoaderLoadedTargets); err != nil {
+		return err
+	}
+	if err = reg.Register(dnsLoaderDeletedTargets); err != nil {
+		return err
+	}
+	if err = reg.Register(dnsLoaderFailedQueries); err != nil {
+		return err
+	}
+	if err = reg.Register(dnsLoaderQueriesTotal); err != nil {
+		return err
+	}
+	if err = reg.Register(dnsLoaderRefreshInterval); err != nil {
+		return err
+	}
+	return nil
+}
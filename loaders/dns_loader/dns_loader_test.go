@@ -0,0 +1,205 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dns_loader
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+func newTestLoader(t *testing.T, c *cfg) *dnsLoader {
+	t.Helper()
+	d := &dnsLoader{
+		cfg:    c,
+		logger: log.New(io.Discard, "", 0),
+	}
+	if len(c.Servers) == 0 {
+		c.Servers = []string{"127.0.0.1"}
+	}
+	if err := d.setDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// testDNSResponse packs a response to the SRV query of name,
+// with the TXT records and the A/AAAA records of the targets.
+func testDNSResponse(t *testing.T, id uint16, name string) []byte {
+	t.Helper()
+	n := dnsmessage.MustNewName(name)
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, Response: true})
+	b.EnableCompression()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(b.StartQuestions())
+	must(b.Question(dnsmessage.Question{Name: n, Type: dnsmessage.TypeSRV, Class: dnsmessage.ClassINET}))
+	must(b.StartAnswers())
+	hdr := func(name string, ttl uint32) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Class: dnsmessage.ClassINET, TTL: ttl}
+	}
+	must(b.SRVResource(hdr(name, 300), dnsmessage.SRVResource{Port: 57400, Target: dnsmessage.MustNewName("Leaf1.lab.example.com.")}))
+	must(b.SRVResource(hdr(name, 300), dnsmessage.SRVResource{Port: 57401, Target: dnsmessage.MustNewName("leaf2.lab.example.com.")}))
+	// the service is not available on this record
+	must(b.SRVResource(hdr(name, 300), dnsmessage.SRVResource{Port: 57400, Target: dnsmessage.MustNewName(".")}))
+	must(b.TXTResource(hdr(name, 60), dnsmessage.TXTResource{TXT: []string{
+		"subscriptions=port-stats,bgp",
+		"Timeout=5s",
+		"password=hijacked",
+		"skip-verify=true",
+		"address=10.9.9.9:57400",
+		"user-agent=not-allowed",
+		"invalid",
+	}}))
+	must(b.StartAdditionals())
+	must(b.AResource(hdr("leaf1.lab.example.com.", 120), dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}}))
+	must(b.AAAAResource(hdr("leaf2.lab.example.com.", 120), dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 2}}))
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestParseResponse(t *testing.T) {
+	name := "_gnmi._tcp.lab.example.com."
+	b := testDNSResponse(t, 42, name)
+	if _, err := parseResponse(b, 43); err == nil {
+		t.Error("expected an error for a mismatching ID")
+	}
+	if _, err := parseResponse([]byte{0, 1, 2}, 42); err == nil {
+		t.Error("expected an error for a truncated message")
+	}
+	msg, err := parseResponse(b, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := newRecordSet()
+	rs.add(msg)
+	wantSRV := []srvRecord{
+		{target: "leaf1.lab.example.com.", port: 57400},
+		{target: "leaf2.lab.example.com.", port: 57401},
+		{target: ".", port: 57400},
+	}
+	if !reflect.DeepEqual(rs.srv[name], wantSRV) {
+		t.Errorf("got SRV records %+v, want %+v", rs.srv[name], wantSRV)
+	}
+	if len(rs.txt[name]) != 7 {
+		t.Errorf("unexpected TXT records %q", rs.txt[name])
+	}
+	if got := rs.addr["leaf1.lab.example.com."]; !reflect.DeepEqual(got, []string{"10.0.0.1"}) {
+		t.Errorf("unexpected leaf1 addresses %v", got)
+	}
+	if got := rs.addr["leaf2.lab.example.com."]; !reflect.DeepEqual(got, []string{"2001:db8::2"}) {
+		t.Errorf("unexpected leaf2 addresses %v", got)
+	}
+	for _, qt := range []dnsmessage.Type{dnsmessage.TypeSRV, dnsmessage.TypeTXT} {
+		if !rs.has(name, qt) {
+			t.Errorf("expected %v to be marked as seen", qt)
+		}
+	}
+	if rs.minTTL != time.Minute {
+		t.Errorf("unexpected min TTL %s", rs.minTTL)
+	}
+}
+
+func TestAddTargets(t *testing.T) {
+	name := "_gnmi._tcp.lab.example.com."
+	msg, err := parseResponse(testDNSResponse(t, 1, name), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := newRecordSet()
+	rs.add(msg)
+
+	d := newTestLoader(t, &cfg{Services: []string{name}, UseTXT: true})
+	result := make(map[string]*types.TargetConfig)
+	d.addTargets(result, rs, name, "")
+	if len(result) != 2 {
+		t.Fatalf("expected 2 targets, got %v", result)
+	}
+	for tName, addr := range map[string]string{
+		"leaf1.lab.example.com": "10.0.0.1:57400",
+		"leaf2.lab.example.com": "[2001:db8::2]:57401",
+	} {
+		tc, ok := result[tName]
+		if !ok {
+			t.Errorf("missing target %q", tName)
+			continue
+		}
+		if tc.Address != addr {
+			t.Errorf("target %q: got address %q, want %q", tName, tc.Address, addr)
+		}
+		if !reflect.DeepEqual(tc.Subscriptions, []string{"port-stats", "bgp"}) || tc.Timeout != 5*time.Second {
+			t.Errorf("target %q: the allowed TXT keys are not applied: %+v", tName, tc)
+		}
+		if tc.Password != nil || tc.SkipVerify != nil || tc.UserAgent != "" {
+			t.Errorf("target %q: a denied or not allowed TXT key was applied: %+v", tName, tc)
+		}
+	}
+}
+
+func TestTXTKeys(t *testing.T) {
+	d := newTestLoader(t, &cfg{Services: []string{"_gnmi._tcp.local"}, TXTKeys: []string{"Tags"}})
+	tc := new(types.TargetConfig)
+	err := d.applyTXT(tc, []string{"tags=a,b", "subscriptions=sub1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tc.Tags, []string{"a", "b"}) || tc.Subscriptions != nil {
+		t.Errorf("unexpected target config %+v", tc)
+	}
+
+	for _, k := range []string{"password", "TLS-Key", "insecure", "proxy"} {
+		d := &dnsLoader{cfg: &cfg{Services: []string{"_gnmi._tcp.local"}, Servers: []string{"127.0.0.1"}, TXTKeys: []string{k}}}
+		if err := d.setDefaults(); err == nil {
+			t.Errorf("expected txt-keys %q to be rejected", k)
+		}
+	}
+}
+
+func TestReadResolvConf(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "resolv.conf")
+	err := os.WriteFile(p, []byte("# comment\nsearch lab\nnameserver 10.0.0.53\nnameserver 2001:db8::53\nnameserver\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers, err := readResolvConf(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"10.0.0.53:53", "[2001:db8::53]:53"}
+	if !reflect.DeepEqual(servers, want) {
+		t.Errorf("got %v, want %v", servers, want)
+	}
+}
+
+func TestInstanceName(t *testing.T) {
+	for instance, want := range map[string]string{
+		"router1._gnmi._tcp.local.":  "router1",
+		"router 2._gnmi._tcp.local.": "router 2",
+		"other.example.com.":         "other.example.com",
+	} {
+		if got := instanceName(instance, "_gnmi._tcp.local."); got != want {
+			t.Errorf("instanceName(%q) = %q, want %q", instance, got, want)
+		}
+	}
+}
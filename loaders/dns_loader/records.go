@@ -0,0 +1,98 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dns_loader
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type srvRecord struct {
+	target string
+	port   uint16
+}
+
+// recordSet holds the DNS records received during a single refresh,
+// so that records included in the additional section of a response
+// are not queried again.
+type recordSet struct {
+	// queried names and types
+	seen map[string]map[dnsmessage.Type]struct{}
+	srv  map[string][]srvRecord
+	txt  map[string][]string
+	ptr  map[string][]string
+	addr map[string][]string
+	// minimum TTL of the received records
+	minTTL time.Duration
+}
+
+func newRecordSet() *recordSet {
+	return &recordSet{
+		seen: make(map[string]map[dnsmessage.Type]struct{}),
+		srv:  make(map[string][]srvRecord),
+		txt:  make(map[string][]string),
+		ptr:  make(map[string][]string),
+		addr: make(map[string][]string),
+	}
+}
+
+func (rs *recordSet) has(name string, t dnsmessage.Type) bool {
+	if types, ok := rs.seen[name]; ok {
+		_, ok = types[t]
+		return ok
+	}
+	return false
+}
+
+func (rs *recordSet) setSeen(name string, t dnsmessage.Type) {
+	if _, ok := rs.seen[name]; !ok {
+		rs.seen[name] = make(map[dnsmessage.Type]struct{})
+	}
+	rs.seen[name][t] = struct{}{}
+}
+
+func (rs *recordSet) add(msg *dnsmessage.Message) {
+	for _, q := range msg.Questions {
+		rs.setSeen(strings.ToLower(q.Name.String()), q.Type)
+	}
+	for _, rrs := range [][]dnsmessage.Resource{msg.Answers, msg.Additionals} {
+		for _, rr := range rrs {
+			rs.addResource(rr)
+		}
+	}
+}
+
+func (rs *recordSet) addResource(rr dnsmessage.Resource) {
+	name := strings.ToLower(rr.Header.Name.String())
+	switch body := rr.Body.(type) {
+	case *dnsmessage.SRVResource:
+		rs.srv[name] = append(rs.srv[name], srvRecord{
+			target: strings.ToLower(body.Target.String()),
+			port:   body.Port,
+		})
+	case *dnsmessage.TXTResource:
+		rs.txt[name] = append(rs.txt[name], body.TXT...)
+	case *dnsmessage.PTRResource:
+		rs.ptr[name] = append(rs.ptr[name], strings.ToLower(body.PTR.String()))
+	case *dnsmessage.AResource:
+		rs.addr[name] = append(rs.addr[name], net.IP(body.A[:]).String())
+	case *dnsmessage.AAAAResource:
+		rs.addr[name] = append(rs.addr[name], net.IP(body.AAAA[:]).String())
+	default:
+		return
+	}
+	rs.setSeen(name, rr.Header.Type)
+	ttl := time.Duration(rr.Header.TTL) * time.Second
+	if rs.minTTL == 0 || ttl < rs.minTTL {
+		rs.minTTL = ttl
+	}
+}
@@ -0,0 +1,331 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package graphql_loader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/openconfig/gnmic/loaders"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	loggingPrefix   = "[graphql_loader] "
+	loaderType      = "graphql"
+	defaultInterval = 1 * time.Minute
+	defaultTimeout  = 50 * time.Second
+)
+
+func init() {
+	loaders.Register(loaderType, func() loaders.TargetLoader {
+		logger := log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags)
+		return &graphqlLoader{
+			cfg:     &cfg{},
+			tracker: loaders.NewTracker(logger),
+			logger:  logger,
+		}
+	})
+}
+
+type graphqlLoader struct {
+	cfg            *cfg
+	tracker        *loaders.Tracker
+	targetConfigFn func(*types.TargetConfig) error
+	logger         *log.Logger
+	//
+	tpl           *template.Template
+	actionsConfig map[string]map[string]interface{}
+}
+
+type cfg struct {
+	// the GraphQL endpoint URL, must include http or https as a prefix
+	URL string `json:"url,omitempty" mapstructure:"url,omitempty"`
+	// the GraphQL query
+	Query string `json:"query,omitempty" mapstructure:"query,omitempty"`
+	// the GraphQL query variables
+	Variables map[string]interface{} `json:"variables,omitempty" mapstructure:"variables,omitempty"`
+	// server query interval
+	Interval time.Duration `json:"interval,omitempty" mapstructure:"interval,omitempty"`
+	// query timeout
+	Timeout time.Duration `json:"timeout,omitempty" mapstructure:"timeout,omitempty"`
+	// TLS config
+	SkipVerify bool   `json:"skip-verify,omitempty" mapstructure:"skip-verify,omitempty"`
+	CAFile     string `json:"ca-file,omitempty" mapstructure:"ca-file,omitempty"`
+	CertFile   string `json:"cert-file,omitempty" mapstructure:"cert-file,omitempty"`
+	KeyFile    string `json:"key-file,omitempty" mapstructure:"key-file,omitempty"`
+	// HTTP basicAuth
+	Username string `json:"username,omitempty" mapstructure:"username,omitempty"`
+	Password string `json:"password,omitempty" mapstructure:"password,omitempty"`
+	// token, sent in the Authorization header using the configured token type
+	Token string `json:"token,omitempty" mapstructure:"token,omitempty"`
+	// token type, defaults to "Bearer". Nautobot expects "Token"
+	TokenType string `json:"token-type,omitempty" mapstructure:"token-type,omitempty"`
+	// additional HTTP headers
+	Headers map[string]string `json:"headers,omitempty" mapstructure:"headers,omitempty"`
+	// a Go text template used to transform the GraphQL query result (the response "data" field)
+	// into a map of targets configurations
+	Template string `json:"template,omitempty" mapstructure:"template,omitempty"`
+	// time to wait before the first query
+	StartDelay time.Duration `json:"start-delay,omitempty" mapstructure:"start-delay,omitempty"`
+	// if true, registers graphqlLoader prometheus metrics with the provided
+	// prometheus registry
+	EnableMetrics bool `json:"enable-metrics,omitempty" mapstructure:"enable-metrics,omitempty"`
+	// enable Debug
+	Debug bool `json:"debug,omitempty" mapstructure:"debug,omitempty"`
+	// variables definitions to be passed to the actions
+	Vars map[string]interface{}
+	// variable file, values in this file will be overwritten by
+	// the ones defined in Vars
+	VarsFile string `mapstructure:"vars-file,omitempty"`
+	// list of Actions to run on new target discovery
+	OnAdd []string `json:"on-add,omitempty" mapstructure:"on-add,omitempty"`
+	// list of Actions to run on target removal
+	OnDelete []string `json:"on-delete,omitempty" mapstructure:"on-delete,omitempty"`
+}
+
+type graphqlRequest struct {
+	Query     string                 `json:"query,omitempty"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []struct {
+		Message string `json:"message,omitempty"`
+	} `json:"errors,omitempty"`
+}
+
+func (g *graphqlLoader) Init(ctx context.Context, cfg map[string]interface{}, logger *log.Logger, opts ...loaders.Option) error {
+	err := loaders.DecodeConfig(cfg, g.cfg)
+	if err != nil {
+		return err
+	}
+	err = g.setDefaults()
+	if err != nil {
+		return err
+	}
+	for _, o := range opts {
+		o(g)
+	}
+	if logger != nil {
+		g.logger.SetOutput(logger.Writer())
+		g.logger.SetFlags(logger.Flags())
+	}
+	g.tpl, err = utils.CreateTemplate("graphql-loader-template", g.cfg.Template)
+	if err != nil {
+		return err
+	}
+	return g.tracker.Init(ctx, &loaders.TrackerConfig{
+		OnAdd:    g.cfg.OnAdd,
+		OnDelete: g.cfg.OnDelete,
+		Actions:  g.actionsConfig,
+		Vars:     g.cfg.Vars,
+		VarsFile: g.cfg.VarsFile,
+		Timeout:  g.cfg.Interval,
+		Debug:    g.cfg.Debug,
+	})
+}
+
+func (g *graphqlLoader) Start(ctx context.Context) chan *loaders.TargetOperation {
+	opChan := make(chan *loaders.TargetOperation)
+	ticker := time.NewTicker(g.cfg.Interval)
+	go func() {
+		defer close(opChan)
+		defer ticker.Stop()
+		time.Sleep(g.cfg.StartDelay)
+		g.update(ctx, opChan)
+		for {
+			select {
+			case <-ctx.Done():
+				g.logger.Printf("%q context done: %v", loaderType, ctx.Err())
+				return
+			case <-ticker.C:
+				g.update(ctx, opChan)
+			}
+		}
+	}()
+	return opChan
+}
+
+func (g *graphqlLoader) RunOnce(ctx context.Context) (map[string]*types.TargetConfig, error) {
+	readTargets, err := g.getTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if g.cfg.Debug {
+		g.logger.Printf("graphql loader discovered %d target(s)", len(readTargets))
+	}
+	return readTargets, nil
+}
+
+func (g *graphqlLoader) update(ctx context.Context, opChan chan *loaders.TargetOperation) {
+	readTargets, err := g.getTargets(ctx)
+	if err != nil {
+		g.logger.Printf("failed to read targets from GraphQL server: %v", err)
+		return
+	}
+	select {
+	case <-ctx.Done():
+		return
+	default:
+		g.updateTargets(ctx, readTargets, opChan)
+	}
+}
+
+func (g *graphqlLoader) setDefaults() error {
+	if g.cfg.URL == "" {
+		return errors.New("missing URL")
+	}
+	if g.cfg.Query == "" {
+		return errors.New("missing query")
+	}
+	if g.cfg.Template == "" {
+		return errors.New("missing template")
+	}
+	if g.cfg.Interval <= 0 {
+		g.cfg.Interval = defaultInterval
+	}
+	if g.cfg.Timeout <= 0 {
+		g.cfg.Timeout = defaultTimeout
+	}
+	if g.cfg.TokenType == "" {
+		g.cfg.TokenType = "Bearer"
+	}
+	return nil
+}
+
+// query sends the configured GraphQL query and returns the response "data" field.
+func (g *graphqlLoader) query(ctx context.Context) (interface{}, error) {
+	c := resty.New()
+	tlsCfg, err := utils.NewTLSConfig(g.cfg.CAFile, g.cfg.CertFile, g.cfg.KeyFile, g.cfg.SkipVerify, false)
+	if err != nil {
+		graphqlLoaderFailedRequests.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+		return nil, err
+	}
+	if tlsCfg != nil {
+		c = c.SetTLSClientConfig(tlsCfg)
+	}
+	c.SetTimeout(g.cfg.Timeout)
+	c.SetHeaders(g.cfg.Headers)
+	if g.cfg.Username != "" && g.cfg.Password != "" {
+		c.SetBasicAuth(g.cfg.Username, g.cfg.Password)
+	}
+	if g.cfg.Token != "" {
+		c.SetAuthScheme(g.cfg.TokenType)
+		c.SetAuthToken(g.cfg.Token)
+	}
+	start := time.Now()
+	graphqlLoaderRequestsTotal.WithLabelValues(loaderType).Add(1)
+	rsp, err := c.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Accept", "application/json").
+		SetBody(&graphqlRequest{
+			Query:     g.cfg.Query,
+			Variables: g.cfg.Variables,
+		}).
+		Post(g.cfg.URL)
+	if err != nil {
+		graphqlLoaderFailedRequests.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+		return nil, err
+	}
+	graphqlLoaderRequestDuration.WithLabelValues(loaderType).Set(float64(time.Since(start).Nanoseconds()))
+	if rsp.StatusCode() != 200 {
+		graphqlLoaderFailedRequests.WithLabelValues(loaderType, rsp.Status()).Add(1)
+		return nil, fmt.Errorf("failed request, code=%d", rsp.StatusCode())
+	}
+	result := new(graphqlResponse)
+	err = json.Unmarshal(rsp.Body(), result)
+	if err != nil {
+		graphqlLoaderFailedRequests.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+		return nil, err
+	}
+	if len(result.Errors) > 0 {
+		errs := make([]string, 0, len(result.Errors))
+		for _, e := range result.Errors {
+			errs = append(errs, e.Message)
+		}
+		graphqlLoaderFailedRequests.WithLabelValues(loaderType, "graphql_error").Add(1)
+		return nil, fmt.Errorf("query failed: %s", strings.Join(errs, ", "))
+	}
+	return result.Data, nil
+}
+
+func (g *graphqlLoader) getTargets(ctx context.Context) (map[string]*types.TargetConfig, error) {
+	data, err := g.query(ctx)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	err = g.tpl.Execute(buf, data)
+	if err != nil {
+		graphqlLoaderFailedRequests.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+		return nil, err
+	}
+	result := make(map[string]*types.TargetConfig)
+	// unmarshal the template result into a map of targetConfigs
+	err = yaml.Unmarshal(buf.Bytes(), result)
+	if err != nil {
+		graphqlLoaderFailedRequests.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+		return nil, err
+	}
+	// properly initialize address and name if not set
+	for n, t := range result {
+		if t == nil && n != "" {
+			result[n] = &types.TargetConfig{
+				Name:    n,
+				Address: n,
+			}
+			continue
+		}
+		if t.Name == "" {
+			t.Name = n
+		}
+		if t.Address == "" {
+			t.Address = n
+		}
+	}
+	if g.cfg.Debug {
+		g.logger.Printf("result: %s", result)
+	}
+	return result, nil
+}
+
+func (g *graphqlLoader) updateTargets(ctx context.Context, tcs map[string]*types.TargetConfig, opChan chan *loaders.TargetOperation) {
+	var err error
+	for _, tc := range tcs {
+		err = g.targetConfigFn(tc)
+		if err != nil {
+			g.logger.Printf("failed running target config fn on target %q", tc.Name)
+		}
+	}
+	targetOp := g.tracker.Update(ctx, tcs, g.cfg.Interval)
+	numAdds := len(targetOp.Add)
+	numDels := len(targetOp.Del)
+	defer func() {
+		graphqlLoaderLoadedTargets.WithLabelValues(loaderType).Set(float64(numAdds))
+		graphqlLoaderDeletedTargets.WithLabelValues(loaderType).Set(float64(numDels))
+	}()
+	if numAdds+numDels == 0 {
+		return
+	}
+	opChan <- targetOp
+}
@@ -0,0 +1,75 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package graphql_loader
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var graphqlLoaderLoadedTargets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "graphql_loader",
+	Name:      "number_of_loaded_targets",
+	Help:      "Number of new targets successfully loaded",
+}, []string{"loader_type"})
+
+var graphqlLoaderDeletedTargets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "graphql_loader",
+	Name:      "number_of_deleted_targets",
+	Help:      "Number of targets successfully deleted",
+}, []string{"loader_type"})
+
+var graphqlLoaderFailedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gnmic",
+	Subsystem: "graphql_loader",
+	Name:      "number_of_failed_graphql_requests",
+	Help:      "Number of times the GraphQL query failed",
+}, []string{"loader_type", "error"})
+
+var graphqlLoaderRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gnmic",
+	Subsystem: "graphql_loader",
+	Name:      "number_of_graphql_requests_total",
+	Help:      "Number of times the loader sent a GraphQL query",
+}, []string{"loader_type"})
+
+var graphqlLoaderRequestDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "graphql_loader",
+	Name:      "graphql_request_duration_ns",
+	Help:      "Duration of GraphQL query in ns",
+}, []string{"loader_type"})
+
+func initMetrics() {
+	graphqlLoaderLoadedTargets.WithLabelValues(loaderType).Set(0)
+	graphqlLoaderDeletedTargets.WithLabelValues(loaderType).Set(0)
+	graphqlLoaderFailedRequests.WithLabelValues(loaderType, "").Add(0)
+	graphqlLoaderRequestsTotal.WithLabelValues(loaderType).Add(0)
+	graphqlLoaderRequestDuration.WithLabelValues(loaderType).Set(0)
+}
+
+func registerMetrics(reg *prometheus.Registry) error {
+	initMetrics()
+	var err error
+	if err = reg.Register(graphqlLoaderLoadedTargets); err != nil {
+		return err
+	}
+	if err = reg.Register(graphqlLoaderDeletedTargets); err != nil {
+		return err
+	}
+	if err = reg.Register(graphqlLoaderFailedRequests); err != nil {
+		return err
+	}
+	if err = reg.Register(graphqlLoaderRequestsTotal); err != nil {
+		return err
+	}
+	if err = reg.Register(graphqlLoaderRequestDuration); err != nil {
+		return err
+	}
+	return nil
+}
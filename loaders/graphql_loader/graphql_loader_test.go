@@ -0,0 +1,172 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package graphql_loader
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/openconfig/gnmic/loaders"
+)
+
+const testQuery = `query ($site: [String]) {
+  devices(site: $site, status: "active") {
+    name
+    primary_ip4 {
+      host
+    }
+    config_context
+  }
+}`
+
+const testTemplate = `
+{{- range .devices }}
+{{- if .primary_ip4 }}
+{{ .name }}:
+  address: {{ .primary_ip4.host }}:57400
+  username: {{ .config_context.gnmi.username }}
+  skip-verify: true
+{{- end }}
+{{- end }}
+{{- range .hosts }}
+{{ . }}:
+{{- end }}`
+
+// graphqlServer checks the GraphQL request and replies with rsp.
+func graphqlServer(t *testing.T, status int, rsp string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("unexpected method %s", r.Method)
+		}
+		if got := r.Header.Get("Authorization"); got != "Token secret" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+		if got := r.Header.Get("X-Tenant"); got != "lab" {
+			t.Errorf("unexpected X-Tenant header %q", got)
+		}
+		req := new(graphqlRequest)
+		err := json.NewDecoder(r.Body).Decode(req)
+		if err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if req.Query != testQuery {
+			t.Errorf("unexpected query %q", req.Query)
+		}
+		if !reflect.DeepEqual(req.Variables, map[string]interface{}{"site": []interface{}{"dc1"}}) {
+			t.Errorf("unexpected variables %v", req.Variables)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(rsp))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestLoader(t *testing.T, url string) *graphqlLoader {
+	t.Helper()
+	g := loaders.Loaders[loaderType]().(*graphqlLoader)
+	err := g.Init(context.Background(), map[string]interface{}{
+		"url":        url,
+		"query":      testQuery,
+		"variables":  map[string]interface{}{"site": []interface{}{"dc1"}},
+		"template":   testTemplate,
+		"token":      "secret",
+		"token-type": "Token",
+		"headers":    map[string]string{"X-Tenant": "lab"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestGraphqlRunOnce(t *testing.T) {
+	srv := graphqlServer(t, http.StatusOK, `{
+  "data": {
+    "devices": [
+      {"name": "leaf1", "primary_ip4": {"host": "10.0.0.1"}, "config_context": {"gnmi": {"username": "admin"}}},
+      {"name": "leaf2", "primary_ip4": null, "config_context": {}}
+    ],
+    "hosts": ["10.0.0.3:57400"]
+  }
+}`)
+	g := newTestLoader(t, srv.URL)
+	tcs, err := g.RunOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tcs) != 2 {
+		t.Fatalf("unexpected targets: %v", tcs)
+	}
+	leaf1 := tcs["leaf1"]
+	if leaf1 == nil || leaf1.Name != "leaf1" || leaf1.Address != "10.0.0.1:57400" ||
+		leaf1.UsernameString() != "admin" || leaf1.SkipVerify == nil || !*leaf1.SkipVerify {
+		t.Errorf("unexpected target leaf1: %v", leaf1)
+	}
+	// targets without config are named and addressed after their key
+	host := tcs["10.0.0.3:57400"]
+	if host == nil || host.Name != "10.0.0.3:57400" || host.Address != "10.0.0.3:57400" {
+		t.Errorf("unexpected target 10.0.0.3:57400: %v", host)
+	}
+}
+
+func TestGraphqlRunOnceErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		rsp    string
+	}{
+		{
+			name:   "graphql errors",
+			status: http.StatusOK,
+			rsp:    `{"data": null, "errors": [{"message": "unknown field devices"}]}`,
+		},
+		{
+			name:   "http error",
+			status: http.StatusUnauthorized,
+			rsp:    `{"detail": "invalid token"}`,
+		},
+		{
+			name:   "invalid response",
+			status: http.StatusOK,
+			rsp:    `not json`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := graphqlServer(t, tt.status, tt.rsp)
+			g := newTestLoader(t, srv.URL)
+			_, err := g.RunOnce(context.Background())
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestGraphqlInitErrors(t *testing.T) {
+	for name, cfg := range map[string]map[string]interface{}{
+		"missing url":      {"query": testQuery, "template": testTemplate},
+		"missing query":    {"url": "http://localhost", "template": testTemplate},
+		"missing template": {"url": "http://localhost", "query": testQuery},
+		"invalid template": {"url": "http://localhost", "query": testQuery, "template": "{{ .devices"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			g := loaders.Loaders[loaderType]()
+			if err := g.Init(context.Background(), cfg, nil); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -16,12 +16,10 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"sync"
 	"text/template"
 	"time"
 
 	"github.com/go-resty/resty/v2"
-	"github.com/openconfig/gnmic/actions"
 	"github.com/openconfig/gnmic/loaders"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
@@ -37,28 +35,23 @@ const (
 
 func init() {
 	loaders.Register(loaderType, func() loaders.TargetLoader {
+		logger := log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags)
 		return &httpLoader{
-			cfg:         &cfg{},
-			m:           new(sync.RWMutex),
-			lastTargets: make(map[string]*types.TargetConfig),
-			logger:      log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags),
+			cfg:     &cfg{},
+			tracker: loaders.NewTracker(logger),
+			logger:  logger,
 		}
 	})
 }
 
 type httpLoader struct {
 	cfg            *cfg
-	m              *sync.RWMutex
-	lastTargets    map[string]*types.TargetConfig
+	tracker        *loaders.Tracker
 	targetConfigFn func(*types.TargetConfig) error
 	logger         *log.Logger
 	//
 	tpl           *template.Template
-	vars          map[string]interface{}
 	actionsConfig map[string]map[string]interface{}
-	addActions    []actions.Action
-	delActions    []actions.Action
-	numActions    int
 }
 
 type cfg struct {
@@ -121,35 +114,15 @@ func (h *httpLoader) Init(ctx context.Context, cfg map[string]interface{}, logge
 			return err
 		}
 	}
-	err = h.readVars(ctx)
-	if err != nil {
-		return err
-	}
-	for _, actName := range h.cfg.OnAdd {
-		if cfg, ok := h.actionsConfig[actName]; ok {
-			a, err := h.initializeAction(cfg)
-			if err != nil {
-				return err
-			}
-			h.addActions = append(h.addActions, a)
-			continue
-		}
-		return fmt.Errorf("unknown action name %q", actName)
-
-	}
-	for _, actName := range h.cfg.OnDelete {
-		if cfg, ok := h.actionsConfig[actName]; ok {
-			a, err := h.initializeAction(cfg)
-			if err != nil {
-				return err
-			}
-			h.delActions = append(h.delActions, a)
-			continue
-		}
-		return fmt.Errorf("unknown action name %q", actName)
-	}
-	h.numActions = len(h.addActions) + len(h.delActions)
-	return nil
+	return h.tracker.Init(ctx, &loaders.TrackerConfig{
+		OnAdd:    h.cfg.OnAdd,
+		OnDelete: h.cfg.OnDelete,
+		Actions:  h.actionsConfig,
+		Vars:     h.cfg.Vars,
+		VarsFile: h.cfg.VarsFile,
+		Timeout:  h.cfg.Interval,
+		Debug:    h.cfg.Debug,
+	})
 }
 
 func (h *httpLoader) Start(ctx context.Context) chan *loaders.TargetOperation {
@@ -291,11 +264,7 @@ func (h *httpLoader) updateTargets(ctx context.Context, tcs map[string]*types.Ta
 			h.logger.Printf("failed running target config fn on target %q", tc.Name)
 		}
 	}
-	targetOp, err := h.runActions(ctx, tcs, loaders.Diff(h.lastTargets, tcs))
-	if err != nil {
-		h.logger.Printf("failed to run actions: %v", err)
-		return
-	}
+	targetOp := h.tracker.Update(ctx, tcs, h.cfg.Interval)
 	numAdds := len(targetOp.Add)
 	numDels := len(targetOp.Del)
 	defer func() {
@@ -305,161 +274,5 @@ func (h *httpLoader) updateTargets(ctx context.Context, tcs map[string]*types.Ta
 	if numAdds+numDels == 0 {
 		return
 	}
-	h.m.Lock()
-	for _, t := range targetOp.Add {
-		if _, ok := h.lastTargets[t.Name]; !ok {
-			h.lastTargets[t.Name] = t
-		}
-	}
-	for _, n := range targetOp.Del {
-		delete(h.lastTargets, n)
-	}
-	h.m.Unlock()
 	opChan <- targetOp
 }
-
-func (h *httpLoader) readVars(ctx context.Context) error {
-	if h.cfg.VarsFile == "" {
-		h.vars = h.cfg.Vars
-		return nil
-	}
-	ctx, cancel := context.WithTimeout(ctx, h.cfg.Interval)
-	defer cancel()
-	b, err := utils.ReadFile(ctx, h.cfg.VarsFile)
-	if err != nil {
-		return err
-	}
-	v := make(map[string]interface{})
-	err = yaml.Unmarshal(b, &v)
-	if err != nil {
-		return err
-	}
-	h.vars = utils.MergeMaps(v, h.cfg.Vars)
-	return nil
-}
-
-func (h *httpLoader) initializeAction(cfg map[string]interface{}) (actions.Action, error) {
-	if len(cfg) == 0 {
-		return nil, errors.New("missing action definition")
-	}
-	if actType, ok := cfg["type"]; ok {
-		switch actType := actType.(type) {
-		case string:
-			if in, ok := actions.Actions[actType]; ok {
-				act := in()
-				err := act.Init(cfg, actions.WithLogger(h.logger), actions.WithTargets(nil))
-				if err != nil {
-					return nil, err
-				}
-
-				return act, nil
-			}
-			return nil, fmt.Errorf("unknown action type %q", actType)
-		default:
-			return nil, fmt.Errorf("unexpected action field type %T", actType)
-		}
-	}
-	return nil, errors.New("missing type field under action")
-}
-
-func (f *httpLoader) runActions(ctx context.Context, tcs map[string]*types.TargetConfig, targetOp *loaders.TargetOperation) (*loaders.TargetOperation, error) {
-	if f.numActions == 0 {
-		return targetOp, nil
-	}
-	opChan := make(chan *loaders.TargetOperation)
-	// some actions are defined,
-	doneCh := make(chan struct{})
-	result := &loaders.TargetOperation{
-		Add: make([]*types.TargetConfig, 0, len(targetOp.Add)),
-		Del: make([]string, 0, len(targetOp.Del)),
-	}
-	ctx, cancel := context.WithTimeout(ctx, f.cfg.Interval)
-	defer cancel()
-	// start operation gathering goroutine
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case op, ok := <-opChan:
-				if !ok {
-					close(doneCh)
-					return
-				}
-				result.Add = append(result.Add, op.Add...)
-				result.Del = append(result.Del, op.Del...)
-			}
-		}
-	}()
-	// create waitGroup and add the number of target operations to it
-	wg := new(sync.WaitGroup)
-	wg.Add(len(targetOp.Add) + len(targetOp.Del))
-	// run OnAdd actions
-	for _, tAdd := range targetOp.Add {
-		go func(tc *types.TargetConfig) {
-			defer wg.Done()
-			err := f.runOnAddActions(ctx, tc.Name, tcs)
-			if err != nil {
-				f.logger.Printf("failed running OnAdd actions: %v", err)
-				return
-			}
-			opChan <- &loaders.TargetOperation{Add: []*types.TargetConfig{tc}}
-		}(tAdd)
-	}
-	// run OnDelete actions
-	for _, tDel := range targetOp.Del {
-		go func(name string) {
-			defer wg.Done()
-			err := f.runOnDeleteActions(ctx, name, tcs)
-			if err != nil {
-				f.logger.Printf("failed running OnDelete actions: %v", err)
-				return
-			}
-			opChan <- &loaders.TargetOperation{Del: []string{name}}
-		}(tDel)
-	}
-	wg.Wait()
-	close(opChan)
-	<-doneCh //wait for gathering goroutine to finish
-	return result, nil
-}
-
-func (d *httpLoader) runOnAddActions(ctx context.Context, tName string, tcs map[string]*types.TargetConfig) error {
-	aCtx := &actions.Context{
-		Input:   tName,
-		Env:     make(map[string]interface{}),
-		Vars:    d.vars,
-		Targets: tcs,
-	}
-	for _, act := range d.addActions {
-		d.logger.Printf("running action %q for target %q", act.NName(), tName)
-		res, err := act.Run(ctx, aCtx)
-		if err != nil {
-			// delete target from known targets map
-			d.m.Lock()
-			delete(d.lastTargets, tName)
-			d.m.Unlock()
-			return fmt.Errorf("action %q for target %q failed: %v", act.NName(), tName, err)
-		}
-
-		aCtx.Env[act.NName()] = utils.Convert(res)
-		if d.cfg.Debug {
-			d.logger.Printf("action %q, target %q result: %+v", act.NName(), tName, res)
-			b, _ := json.MarshalIndent(aCtx, "", "  ")
-			d.logger.Printf("action %q context:\n%s", act.NName(), string(b))
-		}
-	}
-	return nil
-}
-
-func (d *httpLoader) runOnDeleteActions(ctx context.Context, tName string, tcs map[string]*types.TargetConfig) error {
-	env := make(map[string]interface{})
-	for _, act := range d.delActions {
-		res, err := act.Run(ctx, &actions.Context{Input: tName, Env: env, Vars: d.vars})
-		if err != nil {
-			return fmt.Errorf("action %q for target %q failed: %v", act.NName(), tName, err)
-		}
-		env[act.NName()] = res
-	}
-	return nil
-}
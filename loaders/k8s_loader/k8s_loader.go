@@ -0,0 +1,538 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s_loader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/openconfig/gnmic/loaders"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	loggingPrefix         = "[k8s_loader] "
+	loaderType            = "k8s"
+	defaultNamespace      = "default"
+	defaultGroup          = "gnmic.openconfig.net"
+	defaultVersion        = "v1alpha1"
+	defaultResource       = "targets"
+	defaultResyncPeriod   = 1 * time.Minute
+	defaultStatusInterval = 10 * time.Second
+	defaultTimeout        = 10 * time.Second
+	watchRetryTimer       = 2 * time.Second
+	// target spec field referencing the credentials Secret
+	credentialsSecretField = "credentialsSecret"
+	// credentials Secret keys
+	usernameKey = "username"
+	passwordKey = "password"
+	tokenKey    = "token"
+)
+
+func init() {
+	loaders.Register(loaderType, func() loaders.TargetLoader {
+		logger := log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags)
+		return &k8sLoader{
+			cfg:          &cfg{},
+			m:            new(sync.RWMutex),
+			tracker:      loaders.NewTracker(logger),
+			lastVersions: make(map[string]string),
+			objects:      make(map[string]objectRef),
+			lastStatus:   make(map[string]*loaders.TargetStatus),
+			logger:       logger,
+		}
+	})
+}
+
+type k8sLoader struct {
+	cfg            *cfg
+	m              *sync.RWMutex
+	tracker        *loaders.Tracker
+	targetConfigFn func(*types.TargetConfig) error
+	logger         *log.Logger
+	//
+	dynClient dynamic.Interface
+	clientset kubernetes.Interface
+	gvr       schema.GroupVersionResource
+	// known target name to the version of its Target resource
+	// and of its credentials Secret
+	lastVersions map[string]string
+	// target name to Target resource
+	objects map[string]objectRef
+	// status
+	statusFn   func(name string) *loaders.TargetStatus
+	lastStatus map[string]*loaders.TargetStatus
+	//
+	actionsConfig map[string]map[string]interface{}
+}
+
+type cfg struct {
+	// path to a kubeconfig file, if not set the in-cluster config is used
+	Kubeconfig string `json:"kubeconfig,omitempty" mapstructure:"kubeconfig,omitempty"`
+	// namespace to watch for Target resources, all namespaces if set to "*"
+	Namespace string `json:"namespace,omitempty" mapstructure:"namespace,omitempty"`
+	// Target custom resource group, version and plural name
+	Group    string `json:"group,omitempty" mapstructure:"group,omitempty"`
+	Version  string `json:"version,omitempty" mapstructure:"version,omitempty"`
+	Resource string `json:"resource,omitempty" mapstructure:"resource,omitempty"`
+	// label selector used to filter the Target resources
+	LabelSelector string `json:"label-selector,omitempty" mapstructure:"label-selector,omitempty"`
+	// watch the Secrets referenced by the Target resources for credentials changes
+	WatchSecrets bool `json:"watch-secrets,omitempty" mapstructure:"watch-secrets,omitempty"`
+	// period at which the Target resources are fully listed,
+	// in addition to the changes received from the watch
+	ResyncPeriod time.Duration `json:"resync-period,omitempty" mapstructure:"resync-period,omitempty"`
+	// k8s API requests timeout
+	Timeout time.Duration `json:"timeout,omitempty" mapstructure:"timeout,omitempty"`
+	// if true, the loader writes the targets status to the status
+	// subresource of the Target resources
+	ReportStatus bool `json:"report-status,omitempty" mapstructure:"report-status,omitempty"`
+	// interval at which the targets status is checked
+	StatusInterval time.Duration `json:"status-interval,omitempty" mapstructure:"status-interval,omitempty"`
+	// time to wait before the first list
+	StartDelay time.Duration `json:"start-delay,omitempty" mapstructure:"start-delay,omitempty"`
+	// if true, registers k8sLoader prometheus metrics with the provided
+	// prometheus registry
+	EnableMetrics bool `json:"enable-metrics,omitempty" mapstructure:"enable-metrics,omitempty"`
+	// enable Debug
+	Debug bool `json:"debug,omitempty" mapstructure:"debug,omitempty"`
+	// variables definitions to be passed to the actions
+	Vars map[string]interface{}
+	// variable file, values in this file will be overwritten by
+	// the ones defined in Vars
+	VarsFile string `mapstructure:"vars-file,omitempty"`
+	// list of Actions to run on new target discovery
+	OnAdd []string `json:"on-add,omitempty" mapstructure:"on-add,omitempty"`
+	// list of Actions to run on target removal
+	OnDelete []string `json:"on-delete,omitempty" mapstructure:"on-delete,omitempty"`
+}
+
+type objectRef struct {
+	namespace string
+	name      string
+}
+
+func (k *k8sLoader) Init(ctx context.Context, cfg map[string]interface{}, logger *log.Logger, opts ...loaders.Option) error {
+	err := loaders.DecodeConfig(cfg, k.cfg)
+	if err != nil {
+		return err
+	}
+	k.setDefaults()
+	for _, o := range opts {
+		o(k)
+	}
+	if logger != nil {
+		k.logger.SetOutput(logger.Writer())
+		k.logger.SetFlags(logger.Flags())
+	}
+	var restConfig *rest.Config
+	if k.cfg.Kubeconfig != "" {
+		restConfig, err = clientcmd.BuildConfigFromFlags("", k.cfg.Kubeconfig)
+	} else {
+		restConfig, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return err
+	}
+	k.dynClient, err = dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	k.clientset, err = kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	k.gvr = schema.GroupVersionResource{
+		Group:    k.cfg.Group,
+		Version:  k.cfg.Version,
+		Resource: k.cfg.Resource,
+	}
+	return k.tracker.Init(ctx, &loaders.TrackerConfig{
+		OnAdd:    k.cfg.OnAdd,
+		OnDelete: k.cfg.OnDelete,
+		Actions:  k.actionsConfig,
+		Vars:     k.cfg.Vars,
+		VarsFile: k.cfg.VarsFile,
+		Timeout:  k.cfg.Timeout,
+		Debug:    k.cfg.Debug,
+	})
+}
+
+func (k *k8sLoader) Start(ctx context.Context) chan *loaders.TargetOperation {
+	opChan := make(chan *loaders.TargetOperation)
+	go func() {
+		defer close(opChan)
+		time.Sleep(k.cfg.StartDelay)
+		// changes notifications, buffered so that a burst of
+		// watch events results in a single update.
+		eventCh := make(chan struct{}, 1)
+		go k.watch(ctx, "targets", k.watchTargets, eventCh)
+		if k.cfg.WatchSecrets {
+			go k.watch(ctx, "secrets", k.watchSecrets, eventCh)
+		}
+		ticker := time.NewTicker(k.cfg.ResyncPeriod)
+		defer ticker.Stop()
+		var statusCh <-chan time.Time
+		if k.cfg.ReportStatus {
+			statusTicker := time.NewTicker(k.cfg.StatusInterval)
+			defer statusTicker.Stop()
+			statusCh = statusTicker.C
+		}
+		k.update(ctx, opChan)
+		for {
+			select {
+			case <-ctx.Done():
+				k.logger.Printf("%q context done: %v", loaderType, ctx.Err())
+				return
+			case <-eventCh:
+				k.update(ctx, opChan)
+			case <-ticker.C:
+				k.update(ctx, opChan)
+			case <-statusCh:
+				k.reportStatus(ctx)
+			}
+		}
+	}()
+	return opChan
+}
+
+func (k *k8sLoader) RunOnce(ctx context.Context) (map[string]*types.TargetConfig, error) {
+	readTargets, _, _, err := k.getTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if k.cfg.Debug {
+		k.logger.Printf("k8s loader discovered %d target(s)", len(readTargets))
+	}
+	return readTargets, nil
+}
+
+func (k *k8sLoader) setDefaults() {
+	switch k.cfg.Namespace {
+	case "":
+		k.cfg.Namespace = defaultNamespace
+	case "*":
+		k.cfg.Namespace = metav1.NamespaceAll
+	}
+	if k.cfg.Group == "" {
+		k.cfg.Group = defaultGroup
+	}
+	if k.cfg.Version == "" {
+		k.cfg.Version = defaultVersion
+	}
+	if k.cfg.Resource == "" {
+		k.cfg.Resource = defaultResource
+	}
+	if k.cfg.ResyncPeriod <= 0 {
+		k.cfg.ResyncPeriod = defaultResyncPeriod
+	}
+	if k.cfg.Timeout <= 0 {
+		k.cfg.Timeout = defaultTimeout
+	}
+	if k.cfg.StatusInterval <= 0 {
+		k.cfg.StatusInterval = defaultStatusInterval
+	}
+}
+
+func (k *k8sLoader) watchTargets(ctx context.Context) (watch.Interface, error) {
+	return k.dynClient.Resource(k.gvr).Namespace(k.cfg.Namespace).Watch(ctx, metav1.ListOptions{
+		LabelSelector: k.cfg.LabelSelector,
+	})
+}
+
+func (k *k8sLoader) watchSecrets(ctx context.Context) (watch.Interface, error) {
+	return k.clientset.CoreV1().Secrets(k.cfg.Namespace).Watch(ctx, metav1.ListOptions{})
+}
+
+// watch runs watchFn and notifies eventCh of every received event,
+// the watch is restarted if it is closed by the API server.
+func (k *k8sLoader) watch(ctx context.Context, name string, watchFn func(context.Context) (watch.Interface, error), eventCh chan struct{}) {
+	for {
+		w, err := watchFn(ctx)
+		if err != nil {
+			k8sLoaderFailedRequests.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+			k.logger.Printf("failed to watch %s: %v", name, err)
+		} else {
+			k.readEvents(ctx, name, w, eventCh)
+			w.Stop()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRetryTimer):
+		}
+	}
+}
+
+func (k *k8sLoader) readEvents(ctx context.Context, name string, w watch.Interface, eventCh chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+			if ev.Type == watch.Error {
+				k.logger.Printf("%s watch error: %v", name, ev.Object)
+				return
+			}
+			if k.cfg.Debug {
+				k.logger.Printf("received %s watch event %q", name, ev.Type)
+			}
+			select {
+			case eventCh <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func (k *k8sLoader) update(ctx context.Context, opChan chan *loaders.TargetOperation) {
+	readTargets, versions, objects, err := k.getTargets(ctx)
+	if err != nil {
+		k.logger.Printf("failed to read targets from k8s: %v", err)
+		return
+	}
+	select {
+	case <-ctx.Done():
+		return
+	default:
+		k.updateTargets(ctx, readTargets, versions, objects, opChan)
+	}
+}
+
+// getTargets lists the Target resources and builds the corresponding target configs.
+// It returns the target configs, the resource versions and the Target resource of each target.
+func (k *k8sLoader) getTargets(ctx context.Context) (map[string]*types.TargetConfig, map[string]string, map[string]objectRef, error) {
+	ctx, cancel := context.WithTimeout(ctx, k.cfg.Timeout)
+	defer cancel()
+	start := time.Now()
+	k8sLoaderRequestsTotal.WithLabelValues(loaderType).Add(1)
+	list, err := k.dynClient.Resource(k.gvr).Namespace(k.cfg.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: k.cfg.LabelSelector,
+	})
+	if err != nil {
+		k8sLoaderFailedRequests.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+		return nil, nil, nil, err
+	}
+	k8sLoaderRequestDuration.WithLabelValues(loaderType).Set(float64(time.Since(start).Nanoseconds()))
+	result := make(map[string]*types.TargetConfig)
+	versions := make(map[string]string)
+	objects := make(map[string]objectRef)
+	secrets := make(map[objectRef]*corev1.Secret)
+	for i := range list.Items {
+		obj := &list.Items[i]
+		tc, version, err := k.targetFromObject(ctx, obj, secrets)
+		if err != nil {
+			k.logger.Printf("failed to build target from %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+			continue
+		}
+		if ref, ok := objects[tc.Name]; ok {
+			k.logger.Printf("duplicate target name %q in %s/%s and %s/%s",
+				tc.Name, ref.namespace, ref.name, obj.GetNamespace(), obj.GetName())
+			continue
+		}
+		result[tc.Name] = tc
+		versions[tc.Name] = version
+		objects[tc.Name] = objectRef{namespace: obj.GetNamespace(), name: obj.GetName()}
+	}
+	if k.cfg.Debug {
+		k.logger.Printf("result: %v", result)
+	}
+	return result, versions, objects, nil
+}
+
+// targetFromObject builds a target config from a Target resource spec.
+// It returns the target config and a version string that changes when the resource spec
+// or the referenced credentials Secret change.
+func (k *k8sLoader) targetFromObject(ctx context.Context, obj *unstructured.Unstructured, secrets map[objectRef]*corev1.Secret) (*types.TargetConfig, string, error) {
+	spec, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return nil, "", err
+	}
+	secretName, _, err := unstructured.NestedString(spec, credentialsSecretField)
+	if err != nil {
+		return nil, "", err
+	}
+	delete(spec, credentialsSecretField)
+	tc := new(types.TargetConfig)
+	err = loaders.DecodeConfig(kebabKeys(spec), tc)
+	if err != nil {
+		return nil, "", err
+	}
+	if tc.Name == "" {
+		tc.Name = obj.GetName()
+	}
+	if tc.Address == "" {
+		tc.Address = tc.Name
+	}
+	// the generation changes on spec updates only,
+	// unlike the resourceVersion which changes on status updates as well.
+	version := fmt.Sprintf("%d", obj.GetGeneration())
+	if secretName == "" {
+		return tc, version, nil
+	}
+	ref := objectRef{namespace: obj.GetNamespace(), name: secretName}
+	secret, ok := secrets[ref]
+	if !ok {
+		secret, err = k.clientset.CoreV1().Secrets(ref.namespace).Get(ctx, ref.name, metav1.GetOptions{})
+		if err != nil {
+			k8sLoaderFailedRequests.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+			return nil, "", fmt.Errorf("failed to get credentials secret %q: %v", secretName, err)
+		}
+		secrets[ref] = secret
+	}
+	if v, ok := secret.Data[usernameKey]; ok {
+		tc.Username = stringPtr(string(v))
+	}
+	if v, ok := secret.Data[passwordKey]; ok {
+		tc.Password = stringPtr(string(v))
+	}
+	if v, ok := secret.Data[tokenKey]; ok {
+		tc.Token = stringPtr(string(v))
+	}
+	return tc, version + "/" + secret.GetResourceVersion(), nil
+}
+
+func (k *k8sLoader) updateTargets(ctx context.Context, tcs map[string]*types.TargetConfig, versions map[string]string, objects map[string]objectRef, opChan chan *loaders.TargetOperation) {
+	var err error
+	for _, tc := range tcs {
+		err = k.targetConfigFn(tc)
+		if err != nil {
+			k.logger.Printf("failed running target config fn on target %q", tc.Name)
+		}
+	}
+	k.m.Lock()
+	k.objects = objects
+	// targets with an updated spec or credentials are deleted and re-added
+	changed := make([]string, 0)
+	for n := range tcs {
+		if v, ok := k.lastVersions[n]; ok && v != versions[n] {
+			changed = append(changed, n)
+		}
+	}
+	k.m.Unlock()
+	targetOp := k.tracker.UpdateChanged(ctx, tcs, changed, k.cfg.ResyncPeriod)
+	numAdds := len(targetOp.Add)
+	numDels := len(targetOp.Del)
+	defer func() {
+		k8sLoaderLoadedTargets.WithLabelValues(loaderType).Set(float64(numAdds))
+		k8sLoaderDeletedTargets.WithLabelValues(loaderType).Set(float64(numDels))
+	}()
+	if numAdds+numDels == 0 {
+		return
+	}
+	k.m.Lock()
+	for _, name := range targetOp.Del {
+		delete(k.lastVersions, name)
+		delete(k.lastStatus, name)
+	}
+	for _, t := range targetOp.Add {
+		k.lastVersions[t.Name] = versions[t.Name]
+	}
+	k.m.Unlock()
+	opChan <- targetOp
+}
+
+// reportStatus writes the status of the known targets to the status
+// subresource of their Target resource, if it changed since the last report.
+func (k *k8sLoader) reportStatus(ctx context.Context) {
+	if k.statusFn == nil {
+		return
+	}
+	k.m.RLock()
+	objects := make(map[string]objectRef, len(k.lastVersions))
+	for name := range k.lastVersions {
+		if ref, ok := k.objects[name]; ok {
+			objects[name] = ref
+		}
+	}
+	k.m.RUnlock()
+	for name, ref := range objects {
+		st := k.statusFn(name)
+		if st == nil {
+			// target not handled by this gNMIc instance
+			continue
+		}
+		if last, ok := k.lastStatus[name]; ok && *last == *st {
+			continue
+		}
+		b, err := json.Marshal(map[string]interface{}{
+			"status": map[string]interface{}{
+				"connected":   st.Connected,
+				"state":       st.State,
+				"lastError":   st.LastError,
+				"lastUpdated": time.Now().UTC().Format(time.RFC3339),
+			},
+		})
+		if err != nil {
+			k.logger.Printf("failed to marshal target %q status: %v", name, err)
+			continue
+		}
+		rctx, cancel := context.WithTimeout(ctx, k.cfg.Timeout)
+		_, err = k.dynClient.Resource(k.gvr).Namespace(ref.namespace).
+			Patch(rctx, ref.name, ktypes.MergePatchType, b, metav1.PatchOptions{}, "status")
+		cancel()
+		if err != nil {
+			k8sLoaderFailedRequests.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+			k.logger.Printf("failed to update target %q status: %v", name, err)
+			continue
+		}
+		k.lastStatus[name] = st
+	}
+}
+
+// kebabKeys converts the camelCase top level keys of the Target resource spec
+// to the kebab-case keys used in the target configuration, e.g: skipVerify to skip-verify.
+func kebabKeys(m map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		res[toKebab(k)] = v
+	}
+	return res
+}
+
+func toKebab(s string) string {
+	sb := new(strings.Builder)
+	rs := []rune(s)
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1])) {
+				sb.WriteRune('-')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,75 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s_loader
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var k8sLoaderLoadedTargets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "k8s_loader",
+	Name:      "number_of_loaded_targets",
+	Help:      "Number of new targets successfully loaded",
+}, []string{"loader_type"})
+
+var k8sLoaderDeletedTargets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "k8s_loader",
+	Name:      "number_of_deleted_targets",
+	Help:      "Number of targets successfully deleted",
+}, []string{"loader_type"})
+
+var k8sLoaderFailedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gnmic",
+	Subsystem: "k8s_loader",
+	Name:      "number_of_failed_k8s_requests",
+	Help:      "Number of failed k8s API requests",
+}, []string{"loader_type", "error"})
+
+var k8sLoaderRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gnmic",
+	Subsystem: "k8s_loader",
+	Name:      "number_of_k8s_requests_total",
+	Help:      "Number of times the loader listed the Target resources",
+}, []string{"loader_type"})
+
+var k8sLoaderRequestDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "k8s_loader",
+	Name:      "k8s_list_duration_ns",
+	Help:      "Duration of the Target resources list request in ns",
+}, []string{"loader_type"})
+
+func initMetrics() {
+	k8sLoaderLoadedTargets.WithLabelValues(loaderType).Set(0)
+	k8sLoaderDeletedTargets.WithLabelValues(loaderType).Set(0)
+	k8sLoaderFailedRequests.WithLabelValues(loaderType, "").Add(0)
+	k8sLoaderRequestsTotal.WithLabelValues(loaderType).Add(0)
+	k8sLoaderRequestDuration.WithLabelValues(loaderType).Set(0)
+}
+
+func registerMetrics(reg *prometheus.Registry) error {
+	initMetrics()
+	var err error
+	if err = reg.Register(k8sLoaderLoadedTargets); err != nil {
+		return err
+	}
+	if err = reg.Register(k8sLoaderDeletedTargets); err != nil {
+		return err
+	}
+	if err = reg.Register(k8sLoaderFailedRequests); err != nil {
+		return err
+	}
+	if err = reg.Register(k8sLoaderRequestsTotal); err != nil {
+		return err
+	}
+	if err = reg.Register(k8sLoaderRequestDuration); err != nil {
+		return err
+	}
+	return nil
+}
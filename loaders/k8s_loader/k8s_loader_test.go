@@ -0,0 +1,281 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s_loader
+
+import (
+	"context"
+	"io"
+	"log"
+	"sort"
+	"testing"
+
+	"github.com/openconfig/gnmic/loaders"
+	"github.com/openconfig/gnmic/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func targetObject(name string, generation int64, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": defaultGroup + "/" + defaultVersion,
+		"kind":       "Target",
+		"metadata": map[string]interface{}{
+			"name":       name,
+			"namespace":  defaultNamespace,
+			"generation": generation,
+		},
+		"spec": spec,
+	}}
+}
+
+func credentialsSecret(name, version, password string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNamespace, ResourceVersion: version},
+		Data: map[string][]byte{
+			usernameKey: []byte("admin"),
+			passwordKey: []byte(password),
+		},
+	}
+}
+
+func newTestLoader(t *testing.T, objs []runtime.Object, secrets ...runtime.Object) *k8sLoader {
+	t.Helper()
+	k := loaders.Loaders[loaderType]().(*k8sLoader)
+	err := loaders.DecodeConfig(map[string]interface{}{}, k.cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.setDefaults()
+	k.gvr.Group, k.gvr.Version, k.gvr.Resource = k.cfg.Group, k.cfg.Version, k.cfg.Resource
+	k.dynClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{k.gvr: "TargetList"}, objs...)
+	k.clientset = fake.NewSimpleClientset(secrets...)
+	k.targetConfigFn = func(*types.TargetConfig) error { return nil }
+	k.logger = log.New(io.Discard, "", 0)
+	err = k.tracker.Init(context.Background(), &loaders.TrackerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestK8sLoaderUpdate(t *testing.T) {
+	spec := func(addr string) map[string]interface{} {
+		return map[string]interface{}{
+			"address":           addr,
+			"skipVerify":        true,
+			"credentialsSecret": "router1-creds",
+		}
+	}
+	k := newTestLoader(t,
+		[]runtime.Object{
+			targetObject("router1", 1, spec("10.0.0.1:57400")),
+			targetObject("router2", 1, map[string]interface{}{}),
+		},
+		credentialsSecret("router1-creds", "100", "secret1"),
+	)
+	ctx := context.Background()
+	targets := k.dynClient.Resource(k.gvr).Namespace(defaultNamespace)
+	secrets := k.clientset.CoreV1().Secrets(defaultNamespace)
+
+	tests := []struct {
+		name    string
+		change  func(t *testing.T)
+		wantAdd []string
+		wantDel []string
+	}{
+		{
+			name:    "initial_list",
+			change:  func(*testing.T) {},
+			wantAdd: []string{"router1", "router2"},
+		},
+		{
+			name:   "no_change",
+			change: func(*testing.T) {},
+		},
+		{
+			// a status update does not change the generation
+			name: "status_update",
+			change: func(t *testing.T) {
+				obj := targetObject("router2", 1, map[string]interface{}{})
+				obj.Object["status"] = map[string]interface{}{"connected": true}
+				_, err := targets.Update(ctx, obj, metav1.UpdateOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "spec_update",
+			change: func(t *testing.T) {
+				_, err := targets.Update(ctx, targetObject("router1", 2, spec("10.0.0.2:57400")), metav1.UpdateOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantAdd: []string{"router1"},
+			wantDel: []string{"router1"},
+		},
+		{
+			name: "secret_update",
+			change: func(t *testing.T) {
+				_, err := secrets.Update(ctx, credentialsSecret("router1-creds", "101", "secret2"), metav1.UpdateOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantAdd: []string{"router1"},
+			wantDel: []string{"router1"},
+		},
+		{
+			name: "target_delete",
+			change: func(t *testing.T) {
+				err := targets.Delete(ctx, "router2", metav1.DeleteOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantDel: []string{"router2"},
+		},
+	}
+	opChan := make(chan *loaders.TargetOperation, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change(t)
+			k.update(ctx, opChan)
+			var op *loaders.TargetOperation
+			select {
+			case op = <-opChan:
+			default:
+				op = new(loaders.TargetOperation)
+			}
+			gotAdd := make([]string, 0, len(op.Add))
+			for _, tc := range op.Add {
+				gotAdd = append(gotAdd, tc.Name)
+			}
+			sort.Strings(gotAdd)
+			sort.Strings(op.Del)
+			if !equalNames(gotAdd, tt.wantAdd) {
+				t.Errorf("added targets: got %v, want %v", gotAdd, tt.wantAdd)
+			}
+			if !equalNames(op.Del, tt.wantDel) {
+				t.Errorf("deleted targets: got %v, want %v", op.Del, tt.wantDel)
+			}
+		})
+	}
+	// the re-added target has the latest spec and credentials
+	tcs, _, _, err := k.getTargets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := tcs["router1"]
+	if tc == nil || tc.Address != "10.0.0.2:57400" || tc.PasswordString() != "secret2" ||
+		tc.SkipVerify == nil || !*tc.SkipVerify {
+		t.Errorf("unexpected target config: %+v", tc)
+	}
+}
+
+func TestK8sLoaderReportStatus(t *testing.T) {
+	k := newTestLoader(t, []runtime.Object{
+		targetObject("router1", 1, map[string]interface{}{}),
+		targetObject("router2", 1, map[string]interface{}{}),
+	})
+	ctx := context.Background()
+	statuses := map[string]*loaders.TargetStatus{
+		"router1": {Connected: true, State: "READY"},
+		// router2 is not handled by this instance
+	}
+	k.statusFn = func(name string) *loaders.TargetStatus {
+		st, ok := statuses[name]
+		if !ok {
+			return nil
+		}
+		cp := *st
+		return &cp
+	}
+	opChan := make(chan *loaders.TargetOperation, 1)
+	k.update(ctx, opChan)
+	<-opChan
+
+	tests := []struct {
+		name        string
+		status      *loaders.TargetStatus
+		wantPatches int
+		wantState   string
+	}{
+		{
+			name:        "first_report",
+			status:      &loaders.TargetStatus{Connected: true, State: "READY"},
+			wantPatches: 1,
+			wantState:   "READY",
+		},
+		{
+			name:        "unchanged",
+			status:      &loaders.TargetStatus{Connected: true, State: "READY"},
+			wantPatches: 0,
+			wantState:   "READY",
+		},
+		{
+			name:        "changed",
+			status:      &loaders.TargetStatus{State: "TRANSIENT_FAILURE", LastError: "connection refused"},
+			wantPatches: 1,
+			wantState:   "TRANSIENT_FAILURE",
+		},
+	}
+	fakeDyn := k.dynClient.(*dynamicfake.FakeDynamicClient)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses["router1"] = tt.status
+			fakeDyn.ClearActions()
+			k.reportStatus(ctx)
+			var patches int
+			for _, a := range fakeDyn.Actions() {
+				pa, ok := a.(k8stesting.PatchAction)
+				if !ok {
+					continue
+				}
+				if pa.GetSubresource() != "status" || pa.GetName() != "router1" {
+					t.Errorf("unexpected patch: %s %s/%s", pa.GetName(), pa.GetResource().Resource, pa.GetSubresource())
+				}
+				patches++
+			}
+			if patches != tt.wantPatches {
+				t.Errorf("expected %d status patches, got %d", tt.wantPatches, patches)
+			}
+			obj, err := k.dynClient.Resource(k.gvr).Namespace(defaultNamespace).Get(ctx, "router1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			state, _, _ := unstructured.NestedString(obj.Object, "status", "state")
+			connected, _, _ := unstructured.NestedBool(obj.Object, "status", "connected")
+			lastErr, _, _ := unstructured.NestedString(obj.Object, "status", "lastError")
+			if state != tt.wantState || connected != tt.status.Connected || lastErr != tt.status.LastError {
+				t.Errorf("unexpected status: %v", obj.Object["status"])
+			}
+		})
+	}
+}
+
+func equalNames(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
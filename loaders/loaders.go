@@ -47,6 +47,10 @@ var LoadersTypes = []string{
 	"consul",
 	"docker",
 	"http",
+	"netbox",
+	"graphql",
+	"k8s",
+	"dns",
 }
 
 func Register(name string, initFn Initializer) {
@@ -58,6 +62,24 @@ type TargetOperation struct {
 	Del []string
 }
 
+// TargetStatus is the status of a target as seen by gNMIc.
+type TargetStatus struct {
+	// true if the target gRPC connection is ready
+	Connected bool
+	// the target gRPC connection state
+	State string
+	// last error seen on the target
+	LastError string
+}
+
+// TargetStatusReporter is an optional interface implemented by target loaders
+// able to report the status of the loaded targets back to their source.
+type TargetStatusReporter interface {
+	// WithTargetsStatus passes a callback function that returns the status
+	// of a target given its name, or nil if the target is unknown.
+	WithTargetsStatus(func(name string) *TargetStatus)
+}
+
 func DecodeConfig(src, dst interface{}) error {
 	decoder, err := mapstructure.NewDecoder(
 		&mapstructure.DecoderConfig{
@@ -0,0 +1,419 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package netbox_loader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/openconfig/gnmic/loaders"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+)
+
+const (
+	loggingPrefix   = "[netbox_loader] "
+	loaderType      = "netbox"
+	defaultInterval = 1 * time.Minute
+	defaultTimeout  = 50 * time.Second
+	defaultStatus   = "active"
+	devicesPath     = "/api/dcim/devices/"
+	pageLimit       = 1000
+	//
+	defaultNameTemplate    = `{{ .name }}`
+	defaultAddressTemplate = `{{ if .primary_ip }}{{ index (.primary_ip.address | strings.Split "/") 0 }}{{ end }}`
+)
+
+func init() {
+	loaders.Register(loaderType, func() loaders.TargetLoader {
+		logger := log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags)
+		return &netboxLoader{
+			cfg:     &cfg{},
+			tracker: loaders.NewTracker(logger),
+			logger:  logger,
+		}
+	})
+}
+
+type netboxLoader struct {
+	cfg            *cfg
+	tracker        *loaders.Tracker
+	targetConfigFn func(*types.TargetConfig) error
+	logger         *log.Logger
+	//
+	nameTpl       *template.Template
+	addressTpl    *template.Template
+	actionsConfig map[string]map[string]interface{}
+}
+
+type cfg struct {
+	// the NetBox server URL, must include http or https as a prefix
+	URL string `json:"url,omitempty" mapstructure:"url,omitempty"`
+	// NetBox API token
+	Token string `json:"token,omitempty" mapstructure:"token,omitempty"`
+	// server query interval
+	Interval time.Duration `json:"interval,omitempty" mapstructure:"interval,omitempty"`
+	// query timeout
+	Timeout time.Duration `json:"timeout,omitempty" mapstructure:"timeout,omitempty"`
+	// TLS config
+	SkipVerify bool   `json:"skip-verify,omitempty" mapstructure:"skip-verify,omitempty"`
+	CAFile     string `json:"ca-file,omitempty" mapstructure:"ca-file,omitempty"`
+	CertFile   string `json:"cert-file,omitempty" mapstructure:"cert-file,omitempty"`
+	KeyFile    string `json:"key-file,omitempty" mapstructure:"key-file,omitempty"`
+	// devices filters, a device is selected if it matches
+	// any of the values of each of the set filters.
+	Site   []string `json:"site,omitempty" mapstructure:"site,omitempty"`
+	Role   []string `json:"role,omitempty" mapstructure:"role,omitempty"`
+	Tag    []string `json:"tag,omitempty" mapstructure:"tag,omitempty"`
+	Status []string `json:"status,omitempty" mapstructure:"status,omitempty"`
+	// additional query parameters to filter the devices
+	Query map[string]string `json:"query,omitempty" mapstructure:"query,omitempty"`
+	// a Go text template used to build the target name from the device
+	NameTemplate string `json:"name-template,omitempty" mapstructure:"name-template,omitempty"`
+	// a Go text template used to build the target address from the device
+	AddressTemplate string `json:"address-template,omitempty" mapstructure:"address-template,omitempty"`
+	// target configuration applied to all discovered devices,
+	// string values are Go text templates executed against the device.
+	Config map[string]interface{} `json:"config,omitempty" mapstructure:"config,omitempty"`
+	// time to wait before the first NetBox query
+	StartDelay time.Duration `json:"start-delay,omitempty" mapstructure:"start-delay,omitempty"`
+	// if true, registers netboxLoader prometheus metrics with the provided
+	// prometheus registry
+	EnableMetrics bool `json:"enable-metrics,omitempty" mapstructure:"enable-metrics,omitempty"`
+	// enable Debug
+	Debug bool `json:"debug,omitempty" mapstructure:"debug,omitempty"`
+	// variables definitions to be passed to the actions
+	Vars map[string]interface{}
+	// variable file, values in this file will be overwritten by
+	// the ones defined in Vars
+	VarsFile string `mapstructure:"vars-file,omitempty"`
+	// list of Actions to run on new target discovery
+	OnAdd []string `json:"on-add,omitempty" mapstructure:"on-add,omitempty"`
+	// list of Actions to run on target removal
+	OnDelete []string `json:"on-delete,omitempty" mapstructure:"on-delete,omitempty"`
+}
+
+type devicesResponse struct {
+	Count   int                      `json:"count,omitempty"`
+	Next    string                   `json:"next,omitempty"`
+	Results []map[string]interface{} `json:"results,omitempty"`
+}
+
+func (n *netboxLoader) Init(ctx context.Context, cfg map[string]interface{}, logger *log.Logger, opts ...loaders.Option) error {
+	err := loaders.DecodeConfig(cfg, n.cfg)
+	if err != nil {
+		return err
+	}
+	err = n.setDefaults()
+	if err != nil {
+		return err
+	}
+	for _, o := range opts {
+		o(n)
+	}
+	if logger != nil {
+		n.logger.SetOutput(logger.Writer())
+		n.logger.SetFlags(logger.Flags())
+	}
+	n.nameTpl, err = utils.CreateTemplate("netbox-loader-name-template", n.cfg.NameTemplate)
+	if err != nil {
+		return err
+	}
+	n.addressTpl, err = utils.CreateTemplate("netbox-loader-address-template", n.cfg.AddressTemplate)
+	if err != nil {
+		return err
+	}
+	return n.tracker.Init(ctx, &loaders.TrackerConfig{
+		OnAdd:    n.cfg.OnAdd,
+		OnDelete: n.cfg.OnDelete,
+		Actions:  n.actionsConfig,
+		Vars:     n.cfg.Vars,
+		VarsFile: n.cfg.VarsFile,
+		Timeout:  n.cfg.Interval,
+		Debug:    n.cfg.Debug,
+	})
+}
+
+func (n *netboxLoader) Start(ctx context.Context) chan *loaders.TargetOperation {
+	opChan := make(chan *loaders.TargetOperation)
+	ticker := time.NewTicker(n.cfg.Interval)
+	go func() {
+		defer close(opChan)
+		defer ticker.Stop()
+		time.Sleep(n.cfg.StartDelay)
+		n.update(ctx, opChan)
+		for {
+			select {
+			case <-ctx.Done():
+				n.logger.Printf("%q context done: %v", loaderType, ctx.Err())
+				return
+			case <-ticker.C:
+				n.update(ctx, opChan)
+			}
+		}
+	}()
+	return opChan
+}
+
+func (n *netboxLoader) RunOnce(ctx context.Context) (map[string]*types.TargetConfig, error) {
+	readTargets, err := n.getTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if n.cfg.Debug {
+		n.logger.Printf("netbox loader discovered %d target(s)", len(readTargets))
+	}
+	return readTargets, nil
+}
+
+func (n *netboxLoader) update(ctx context.Context, opChan chan *loaders.TargetOperation) {
+	readTargets, err := n.getTargets(ctx)
+	if err != nil {
+		n.logger.Printf("failed to read targets from NetBox: %v", err)
+		return
+	}
+	select {
+	case <-ctx.Done():
+		return
+	default:
+		n.updateTargets(ctx, readTargets, opChan)
+	}
+}
+
+func (n *netboxLoader) setDefaults() error {
+	if n.cfg.URL == "" {
+		return errors.New("missing URL")
+	}
+	n.cfg.URL = strings.TrimSuffix(n.cfg.URL, "/")
+	if n.cfg.Interval <= 0 {
+		n.cfg.Interval = defaultInterval
+	}
+	if n.cfg.Timeout <= 0 {
+		n.cfg.Timeout = defaultTimeout
+	}
+	if len(n.cfg.Status) == 0 {
+		n.cfg.Status = []string{defaultStatus}
+	}
+	if n.cfg.NameTemplate == "" {
+		n.cfg.NameTemplate = defaultNameTemplate
+	}
+	if n.cfg.AddressTemplate == "" {
+		n.cfg.AddressTemplate = defaultAddressTemplate
+	}
+	return nil
+}
+
+func (n *netboxLoader) createClient() (*resty.Client, error) {
+	c := resty.New()
+	tlsCfg, err := utils.NewTLSConfig(n.cfg.CAFile, n.cfg.CertFile, n.cfg.KeyFile, n.cfg.SkipVerify, false)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		c = c.SetTLSClientConfig(tlsCfg)
+	}
+	c.SetTimeout(n.cfg.Timeout)
+	c.SetHeader("Accept", "application/json")
+	if n.cfg.Token != "" {
+		c.SetHeader("Authorization", "Token "+n.cfg.Token)
+	}
+	return c, nil
+}
+
+func (n *netboxLoader) queryParams() map[string][]string {
+	params := map[string][]string{
+		"limit": {strconv.Itoa(pageLimit)},
+	}
+	if len(n.cfg.Site) > 0 {
+		params["site"] = n.cfg.Site
+	}
+	if len(n.cfg.Role) > 0 {
+		params["role"] = n.cfg.Role
+	}
+	if len(n.cfg.Tag) > 0 {
+		params["tag"] = n.cfg.Tag
+	}
+	if len(n.cfg.Status) > 0 {
+		params["status"] = n.cfg.Status
+	}
+	for k, v := range n.cfg.Query {
+		params[k] = append(params[k], v)
+	}
+	return params
+}
+
+// getDevices queries the NetBox devices API following the pagination
+// links until all the devices matching the configured filters are read.
+func (n *netboxLoader) getDevices(ctx context.Context) ([]map[string]interface{}, error) {
+	c, err := n.createClient()
+	if err != nil {
+		netboxLoaderFailedGetRequests.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+		return nil, err
+	}
+	devices := make([]map[string]interface{}, 0)
+	req := c.R().SetContext(ctx).SetQueryParamsFromValues(n.queryParams())
+	url := n.cfg.URL + devicesPath
+	for url != "" {
+		start := time.Now()
+		netboxLoaderGetRequestsTotal.WithLabelValues(loaderType).Add(1)
+		rsp, err := req.Get(url)
+		if err != nil {
+			netboxLoaderFailedGetRequests.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+			return nil, err
+		}
+		netboxLoaderGetRequestDuration.WithLabelValues(loaderType).Set(float64(time.Since(start).Nanoseconds()))
+		if rsp.StatusCode() != 200 {
+			netboxLoaderFailedGetRequests.WithLabelValues(loaderType, rsp.Status()).Add(1)
+			return nil, fmt.Errorf("failed request, code=%d", rsp.StatusCode())
+		}
+		result := new(devicesResponse)
+		err = json.Unmarshal(rsp.Body(), result)
+		if err != nil {
+			netboxLoaderFailedGetRequests.WithLabelValues(loaderType, fmt.Sprintf("%v", err)).Add(1)
+			return nil, err
+		}
+		devices = append(devices, result.Results...)
+		// the next URL already includes the query parameters
+		url = result.Next
+		req = c.R().SetContext(ctx)
+	}
+	return devices, nil
+}
+
+func (n *netboxLoader) getTargets(ctx context.Context) (map[string]*types.TargetConfig, error) {
+	devices, err := n.getDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*types.TargetConfig)
+	for _, dev := range devices {
+		tc, err := n.deviceToTargetConfig(dev)
+		if err != nil {
+			n.logger.Printf("failed to build target config from device %v: %v", dev["name"], err)
+			continue
+		}
+		if tc == nil {
+			if n.cfg.Debug {
+				n.logger.Printf("ignoring device %v: no name or address", dev["name"])
+			}
+			continue
+		}
+		result[tc.Name] = tc
+	}
+	if n.cfg.Debug {
+		n.logger.Printf("result: %s", result)
+	}
+	return result, nil
+}
+
+// deviceToTargetConfig builds a target config from a NetBox device.
+// It returns a nil target config if the device name or address cannot be determined.
+func (n *netboxLoader) deviceToTargetConfig(dev map[string]interface{}) (*types.TargetConfig, error) {
+	tc := new(types.TargetConfig)
+	if len(n.cfg.Config) > 0 {
+		rcfg, err := renderConfig(n.cfg.Config, dev)
+		if err != nil {
+			return nil, err
+		}
+		err = loaders.DecodeConfig(rcfg, tc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	tc.Name, err = execTemplate(n.nameTpl, dev)
+	if err != nil {
+		return nil, err
+	}
+	tc.Address, err = execTemplate(n.addressTpl, dev)
+	if err != nil {
+		return nil, err
+	}
+	if tc.Name == "" || tc.Address == "" {
+		return nil, nil
+	}
+	return tc, nil
+}
+
+// execTemplate executes tpl against data,
+// the device fields missing from data are rendered as empty strings.
+func execTemplate(tpl *template.Template, data interface{}) (string, error) {
+	buf := new(bytes.Buffer)
+	err := tpl.Execute(buf, data)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.ReplaceAll(buf.String(), "<no value>", "")), nil
+}
+
+// renderConfig walks the config v, executing all the string values
+// as Go text templates against data.
+func renderConfig(v interface{}, data interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		tpl, err := utils.CreateTemplate("netbox-loader-config", v)
+		if err != nil {
+			return nil, err
+		}
+		return execTemplate(tpl, data)
+	case map[string]interface{}:
+		r := make(map[string]interface{}, len(v))
+		for k, vv := range v {
+			rv, err := renderConfig(vv, data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", k, err)
+			}
+			r[k] = rv
+		}
+		return r, nil
+	case []interface{}:
+		r := make([]interface{}, 0, len(v))
+		for _, vv := range v {
+			rv, err := renderConfig(vv, data)
+			if err != nil {
+				return nil, err
+			}
+			r = append(r, rv)
+		}
+		return r, nil
+	default:
+		return v, nil
+	}
+}
+
+func (n *netboxLoader) updateTargets(ctx context.Context, tcs map[string]*types.TargetConfig, opChan chan *loaders.TargetOperation) {
+	var err error
+	for _, tc := range tcs {
+		err = n.targetConfigFn(tc)
+		if err != nil {
+			n.logger.Printf("failed running target config fn on target %q", tc.Name)
+		}
+	}
+	targetOp := n.tracker.Update(ctx, tcs, n.cfg.Interval)
+	numAdds := len(targetOp.Add)
+	numDels := len(targetOp.Del)
+	defer func() {
+		netboxLoaderLoadedTargets.WithLabelValues(loaderType).Set(float64(numAdds))
+		netboxLoaderDeletedTargets.WithLabelValues(loaderType).Set(float64(numDels))
+	}()
+	if numAdds+numDels == 0 {
+		return
+	}
+	opChan <- targetOp
+}
@@ -0,0 +1,75 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package netbox_loader
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var netboxLoaderLoadedTargets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "netbox_loader",
+	Name:      "number_of_loaded_targets",
+	Help:      "Number of new targets successfully loaded",
+}, []string{"loader_type"})
+
+var netboxLoaderDeletedTargets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "netbox_loader",
+	Name:      "number_of_deleted_targets",
+	Help:      "Number of targets successfully deleted",
+}, []string{"loader_type"})
+
+var netboxLoaderFailedGetRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gnmic",
+	Subsystem: "netbox_loader",
+	Name:      "number_of_failed_http_requests",
+	Help:      "Number of times the http Get request failed",
+}, []string{"loader_type", "error"})
+
+var netboxLoaderGetRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gnmic",
+	Subsystem: "netbox_loader",
+	Name:      "number_of_http_requests_total",
+	Help:      "Number of times the loader sent an HTTP request",
+}, []string{"loader_type"})
+
+var netboxLoaderGetRequestDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "netbox_loader",
+	Name:      "http_request_duration_ns",
+	Help:      "Duration of http request in ns",
+}, []string{"loader_type"})
+
+func initMetrics() {
+	netboxLoaderLoadedTargets.WithLabelValues(loaderType).Set(0)
+	netboxLoaderDeletedTargets.WithLabelValues(loaderType).Set(0)
+	netboxLoaderFailedGetRequests.WithLabelValues(loaderType, "").Add(0)
+	netboxLoaderGetRequestsTotal.WithLabelValues(loaderType).Add(0)
+	netboxLoaderGetRequestDuration.WithLabelValues(loaderType).Set(0)
+}
+
+func registerMetrics(reg *prometheus.Registry) error {
+	initMetrics()
+	var err error
+	if err = reg.Register(netboxLoaderLoadedTargets); err != nil {
+		return err
+	}
+	if err = reg.Register(netboxLoaderDeletedTargets); err != nil {
+		return err
+	}
+	if err = reg.Register(netboxLoaderFailedGetRequests); err != nil {
+		return err
+	}
+	if err = reg.Register(netboxLoaderGetRequestsTotal); err != nil {
+		return err
+	}
+	if err = reg.Register(netboxLoaderGetRequestDuration); err != nil {
+		return err
+	}
+	return nil
+}
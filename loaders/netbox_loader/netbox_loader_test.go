@@ -0,0 +1,197 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package netbox_loader
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/openconfig/gnmic/loaders"
+	"github.com/openconfig/gnmic/types"
+)
+
+// netboxServer serves the devices in two pages,
+// it fails the requests without the expected token and filters.
+func netboxServer(t *testing.T, pages ...[]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != devicesPath {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Token secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		q := r.URL.Query()
+		page := 0
+		if q.Get("page") == "2" {
+			page = 1
+		} else if !reflect.DeepEqual(q["site"], []string{"dc1", "dc2"}) ||
+			q.Get("status") != "active" ||
+			q.Get("limit") != "1000" ||
+			q.Get("platform") != "srl" {
+			t.Errorf("unexpected query parameters: %v", q)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		rsp := devicesResponse{Results: pages[page]}
+		if page+1 < len(pages) {
+			rsp.Next = srv.URL + devicesPath + "?page=2"
+		}
+		json.NewEncoder(w).Encode(rsp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestLoader(t *testing.T, cfg map[string]interface{}) *netboxLoader {
+	t.Helper()
+	n := loaders.Loaders[loaderType]().(*netboxLoader)
+	err := n.Init(context.Background(), cfg, nil, loaders.WithTargetsDefaults(func(*types.TargetConfig) error { return nil }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestNetboxRunOnce(t *testing.T) {
+	srv := netboxServer(t,
+		[]map[string]interface{}{
+			{
+				"name":       "leaf1",
+				"primary_ip": map[string]interface{}{"address": "10.0.0.1/32"},
+				"site":       map[string]interface{}{"slug": "dc1"},
+			},
+			// no primary IP, ignored
+			{
+				"name":       "leaf2",
+				"primary_ip": nil,
+				"site":       map[string]interface{}{"slug": "dc1"},
+			},
+		},
+		[]map[string]interface{}{
+			{
+				"name":       "spine1",
+				"primary_ip": map[string]interface{}{"address": "10.0.1.1/24"},
+				"site":       map[string]interface{}{"slug": "dc2"},
+			},
+		},
+	)
+	n := newTestLoader(t, map[string]interface{}{
+		"url":   srv.URL + "/",
+		"token": "secret",
+		"site":  []string{"dc1", "dc2"},
+		"query": map[string]string{"platform": "srl"},
+		"config": map[string]interface{}{
+			"username": "admin",
+			"tags":     []interface{}{"{{ .site.slug }}"},
+		},
+	})
+	tcs, err := n.RunOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(tcs))
+	for name := range tcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"leaf1", "spine1"}) {
+		t.Fatalf("unexpected targets: %v", names)
+	}
+	if tcs["leaf1"].Address != "10.0.0.1" || tcs["spine1"].Address != "10.0.1.1" {
+		t.Errorf("unexpected addresses: %q, %q", tcs["leaf1"].Address, tcs["spine1"].Address)
+	}
+	if tcs["spine1"].UsernameString() != "admin" || !reflect.DeepEqual(tcs["spine1"].Tags, []string{"dc2"}) {
+		t.Errorf("unexpected config: %s", tcs["spine1"])
+	}
+}
+
+func TestNetboxRunOnceUnauthorized(t *testing.T) {
+	srv := netboxServer(t, nil)
+	n := newTestLoader(t, map[string]interface{}{
+		"url":   srv.URL,
+		"token": "wrong",
+	})
+	_, err := n.RunOnce(context.Background())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestDeviceToTargetConfig(t *testing.T) {
+	dev := map[string]interface{}{
+		"name":       "leaf1",
+		"primary_ip": map[string]interface{}{"address": "2001:db8::1/128"},
+		"site":       map[string]interface{}{"slug": "dc1"},
+		"custom_fields": map[string]interface{}{
+			"gnmi_port": 57401,
+		},
+	}
+	tests := []struct {
+		name string
+		cfg  map[string]interface{}
+		dev  map[string]interface{}
+		want *types.TargetConfig
+	}{
+		{
+			name: "defaults",
+			dev:  dev,
+			want: &types.TargetConfig{Name: "leaf1", Address: "2001:db8::1"},
+		},
+		{
+			name: "templates",
+			cfg: map[string]interface{}{
+				"name-template":    "{{ .site.slug }}-{{ .name }}",
+				"address-template": `[{{ index (.primary_ip.address | strings.Split "/") 0 }}]:{{ .custom_fields.gnmi_port }}`,
+			},
+			dev:  dev,
+			want: &types.TargetConfig{Name: "dc1-leaf1", Address: "[2001:db8::1]:57401"},
+		},
+		{
+			name: "no address",
+			dev:  map[string]interface{}{"name": "leaf1"},
+		},
+		{
+			name: "no name",
+			dev:  map[string]interface{}{"primary_ip": map[string]interface{}{"address": "10.0.0.1/32"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := map[string]interface{}{"url": "http://netbox"}
+			for k, v := range tt.cfg {
+				cfg[k] = v
+			}
+			n := newTestLoader(t, cfg)
+			got, err := n.deviceToTargetConfig(tt.dev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNetboxMissingURL(t *testing.T) {
+	n := loaders.Loaders[loaderType]()
+	err := n.Init(context.Background(), map[string]interface{}{}, nil)
+	if err == nil {
+		t.Fatal("expected an error for a missing URL")
+	}
+}
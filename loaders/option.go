@@ -38,3 +38,11 @@ func WithTargetsDefaults(fn func(tc *types.TargetConfig) error) Option {
 		l.WithTargetsDefaults(fn)
 	}
 }
+
+func WithTargetsStatus(fn func(name string) *TargetStatus) Option {
+	return func(l TargetLoader) {
+		if r, ok := l.(TargetStatusReporter); ok {
+			r.WithTargetsStatus(fn)
+		}
+	}
+}
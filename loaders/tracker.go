@@ -0,0 +1,252 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loaders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/openconfig/gnmic/actions"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// Tracker keeps track of the targets discovered by a loader
+// and runs its on-add and on-delete actions when targets are added or removed.
+type Tracker struct {
+	m           *sync.RWMutex
+	lastTargets map[string]*types.TargetConfig
+	addActions  []actions.Action
+	delActions  []actions.Action
+	vars        map[string]interface{}
+	logger      *log.Logger
+	debug       bool
+}
+
+// NewTracker returns a Tracker logging to logger.
+func NewTracker(logger *log.Logger) *Tracker {
+	return &Tracker{
+		m:           new(sync.RWMutex),
+		lastTargets: make(map[string]*types.TargetConfig),
+		logger:      logger,
+	}
+}
+
+// TrackerConfig holds the actions and variables of a Tracker.
+type TrackerConfig struct {
+	// names of the actions to run on target discovery and removal
+	OnAdd    []string
+	OnDelete []string
+	// actions definitions
+	Actions map[string]map[string]interface{}
+	// variables passed to the actions, they override the ones read from VarsFile
+	Vars     map[string]interface{}
+	VarsFile string
+	// timeout for reading VarsFile
+	Timeout time.Duration
+	Debug   bool
+}
+
+// Init initializes the tracker actions and reads its variables.
+func (t *Tracker) Init(ctx context.Context, cfg *TrackerConfig) error {
+	t.debug = cfg.Debug
+	err := t.readVars(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	t.addActions, err = t.initializeActions(cfg.OnAdd, cfg.Actions)
+	if err != nil {
+		return err
+	}
+	t.delActions, err = t.initializeActions(cfg.OnDelete, cfg.Actions)
+	return err
+}
+
+// Update returns the operation bringing the tracked targets to tcs.
+// The actions of the added and removed targets are run within timeout,
+// the targets whose actions fail are left out of the operation.
+// The targets of the returned operation are recorded as the tracked ones.
+func (t *Tracker) Update(ctx context.Context, tcs map[string]*types.TargetConfig, timeout time.Duration) *TargetOperation {
+	return t.UpdateChanged(ctx, tcs, nil, timeout)
+}
+
+// UpdateChanged is like Update, the tracked targets listed in changed
+// are deleted and re-added as well, e.g: when their configuration changed.
+func (t *Tracker) UpdateChanged(ctx context.Context, tcs map[string]*types.TargetConfig, changed []string, timeout time.Duration) *TargetOperation {
+	t.m.RLock()
+	targetOp := Diff(t.lastTargets, tcs)
+	for _, name := range changed {
+		tc, ok := tcs[name]
+		if !ok {
+			continue
+		}
+		if _, ok := t.lastTargets[name]; ok {
+			targetOp.Del = append(targetOp.Del, name)
+			targetOp.Add = append(targetOp.Add, tc)
+		}
+	}
+	t.m.RUnlock()
+	targetOp = t.runActions(ctx, tcs, targetOp, timeout)
+	t.m.Lock()
+	defer t.m.Unlock()
+	for _, name := range targetOp.Del {
+		delete(t.lastTargets, name)
+	}
+	for _, tc := range targetOp.Add {
+		t.lastTargets[tc.Name] = tc
+	}
+	return targetOp
+}
+
+func (t *Tracker) readVars(ctx context.Context, cfg *TrackerConfig) error {
+	if cfg.VarsFile == "" {
+		t.vars = cfg.Vars
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	b, err := utils.ReadFile(ctx, cfg.VarsFile)
+	if err != nil {
+		return err
+	}
+	v := make(map[string]interface{})
+	err = yaml.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	t.vars = utils.MergeMaps(v, cfg.Vars)
+	return nil
+}
+
+func (t *Tracker) initializeActions(names []string, acts map[string]map[string]interface{}) ([]actions.Action, error) {
+	result := make([]actions.Action, 0, len(names))
+	for _, actName := range names {
+		cfg, ok := acts[actName]
+		if !ok {
+			return nil, fmt.Errorf("unknown action name %q", actName)
+		}
+		a, err := t.initializeAction(cfg)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+func (t *Tracker) initializeAction(cfg map[string]interface{}) (actions.Action, error) {
+	if len(cfg) == 0 {
+		return nil, errors.New("missing action definition")
+	}
+	if actType, ok := cfg["type"]; ok {
+		switch actType := actType.(type) {
+		case string:
+			if in, ok := actions.Actions[actType]; ok {
+				act := in()
+				err := act.Init(cfg, actions.WithLogger(t.logger), actions.WithTargets(nil))
+				if err != nil {
+					return nil, err
+				}
+
+				return act, nil
+			}
+			return nil, fmt.Errorf("unknown action type %q", actType)
+		default:
+			return nil, fmt.Errorf("unexpected action field type %T", actType)
+		}
+	}
+	return nil, errors.New("missing type field under action")
+}
+
+func (t *Tracker) runActions(ctx context.Context, tcs map[string]*types.TargetConfig, targetOp *TargetOperation, timeout time.Duration) *TargetOperation {
+	if len(t.addActions)+len(t.delActions) == 0 {
+		return targetOp
+	}
+	result := &TargetOperation{
+		Add: make([]*types.TargetConfig, 0, len(targetOp.Add)),
+		Del: make([]string, 0, len(targetOp.Del)),
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	m := new(sync.Mutex)
+	wg := new(sync.WaitGroup)
+	wg.Add(len(targetOp.Add) + len(targetOp.Del))
+	// run OnAdd actions
+	for _, tAdd := range targetOp.Add {
+		go func(tc *types.TargetConfig) {
+			defer wg.Done()
+			err := t.runOnAddActions(ctx, tc.Name, tcs)
+			if err != nil {
+				t.logger.Printf("failed running OnAdd actions: %v", err)
+				return
+			}
+			m.Lock()
+			result.Add = append(result.Add, tc)
+			m.Unlock()
+		}(tAdd)
+	}
+	// run OnDelete actions
+	for _, tDel := range targetOp.Del {
+		go func(name string) {
+			defer wg.Done()
+			err := t.runOnDeleteActions(ctx, name)
+			if err != nil {
+				t.logger.Printf("failed running OnDelete actions: %v", err)
+				return
+			}
+			m.Lock()
+			result.Del = append(result.Del, name)
+			m.Unlock()
+		}(tDel)
+	}
+	wg.Wait()
+	return result
+}
+
+func (t *Tracker) runOnAddActions(ctx context.Context, tName string, tcs map[string]*types.TargetConfig) error {
+	aCtx := &actions.Context{
+		Input:   tName,
+		Env:     make(map[string]interface{}),
+		Vars:    t.vars,
+		Targets: tcs,
+	}
+	for _, act := range t.addActions {
+		t.logger.Printf("running action %q for target %q", act.NName(), tName)
+		res, err := act.Run(ctx, aCtx)
+		if err != nil {
+			return fmt.Errorf("action %q for target %q failed: %v", act.NName(), tName, err)
+		}
+
+		aCtx.Env[act.NName()] = utils.Convert(res)
+		if t.debug {
+			t.logger.Printf("action %q, target %q result: %+v", act.NName(), tName, res)
+			b, _ := json.MarshalIndent(aCtx, "", "  ")
+			t.logger.Printf("action %q context:\n%s", act.NName(), string(b))
+		}
+	}
+	return nil
+}
+
+func (t *Tracker) runOnDeleteActions(ctx context.Context, tName string) error {
+	env := make(map[string]interface{})
+	for _, act := range t.delActions {
+		res, err := act.Run(ctx, &actions.Context{Input: tName, Env: env, Vars: t.vars})
+		if err != nil {
+			return fmt.Errorf("action %q for target %q failed: %v", act.NName(), tName, err)
+		}
+		env[act.NName()] = res
+	}
+	return nil
+}
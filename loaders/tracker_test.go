@@ -0,0 +1,141 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loaders
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/actions"
+	"github.com/openconfig/gnmic/types"
+)
+
+// testAction fails for the targets listed in its "fail" config field
+// and records the targets it ran for in its "ran" config field.
+type testAction struct {
+	name string
+	fail map[string]bool
+	ran  chan string
+}
+
+func (a *testAction) Init(cfg map[string]interface{}, opts ...actions.Option) error {
+	a.name, _ = cfg["name"].(string)
+	a.fail = make(map[string]bool)
+	for _, n := range cfg["fail"].([]string) {
+		a.fail[n] = true
+	}
+	a.ran = cfg["ran"].(chan string)
+	return nil
+}
+
+func (a *testAction) Run(_ context.Context, aCtx *actions.Context) (interface{}, error) {
+	name := aCtx.Input.(string)
+	a.ran <- name
+	if a.fail[name] {
+		return nil, errors.New("failed")
+	}
+	return nil, nil
+}
+
+func (a *testAction) NName() string                              { return a.name }
+func (a *testAction) WithTargets(map[string]*types.TargetConfig) {}
+func (a *testAction) WithLogger(*log.Logger)                     {}
+
+func TestTrackerUpdate(t *testing.T) {
+	actions.Register("test-tracker", func() actions.Action { return new(testAction) })
+	defer delete(actions.Actions, "test-tracker")
+
+	ran := make(chan string, 10)
+	tr := NewTracker(log.New(io.Discard, "", 0))
+	err := tr.Init(context.Background(), &TrackerConfig{
+		OnAdd:    []string{"add"},
+		OnDelete: []string{"del"},
+		Actions: map[string]map[string]interface{}{
+			"add": {"type": "test-tracker", "name": "add", "fail": []string{"t2"}, "ran": ran},
+			"del": {"type": "test-tracker", "name": "del", "fail": []string{}, "ran": ran},
+		},
+		Timeout: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcs := map[string]*types.TargetConfig{
+		"t1": {Name: "t1"},
+		"t2": {Name: "t2"},
+	}
+	op := tr.Update(context.Background(), tcs, time.Second)
+	if len(op.Add) != 1 || op.Add[0].Name != "t1" || len(op.Del) != 0 {
+		t.Fatalf("unexpected operation: %+v", op)
+	}
+	if len(ran) != 2 {
+		t.Errorf("expected the add action to run for 2 targets, ran %d times", len(ran))
+	}
+	// the actions of t2 are retried on the next update
+	op = tr.Update(context.Background(), tcs, time.Second)
+	if len(op.Add) != 0 {
+		t.Errorf("unexpected added targets: %v", op.Add)
+	}
+	op = tr.Update(context.Background(), map[string]*types.TargetConfig{"t3": {Name: "t3"}}, time.Second)
+	if len(op.Add) != 1 || op.Add[0].Name != "t3" {
+		t.Errorf("unexpected added targets: %v", op.Add)
+	}
+	sort.Strings(op.Del)
+	if len(op.Del) != 1 || op.Del[0] != "t1" {
+		t.Errorf("unexpected deleted targets: %v", op.Del)
+	}
+}
+
+func TestTrackerUpdateChanged(t *testing.T) {
+	tr := NewTracker(log.New(io.Discard, "", 0))
+	err := tr.Init(context.Background(), &TrackerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcs := map[string]*types.TargetConfig{
+		"t1": {Name: "t1", Address: "10.0.0.1"},
+		"t2": {Name: "t2"},
+	}
+	tr.Update(context.Background(), tcs, time.Second)
+	tcs = map[string]*types.TargetConfig{
+		"t1": {Name: "t1", Address: "10.0.0.2"},
+		"t2": {Name: "t2"},
+		"t3": {Name: "t3"},
+	}
+	// t3 is new, it is added once even if listed as changed
+	op := tr.UpdateChanged(context.Background(), tcs, []string{"t1", "t3"}, time.Second)
+	names := make([]string, 0, len(op.Add))
+	for _, tc := range op.Add {
+		names = append(names, tc.Name)
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "t1" || names[1] != "t3" {
+		t.Errorf("unexpected added targets: %v", names)
+	}
+	if len(op.Del) != 1 || op.Del[0] != "t1" {
+		t.Errorf("unexpected deleted targets: %v", op.Del)
+	}
+	// the re-added target is still tracked
+	op = tr.Update(context.Background(), map[string]*types.TargetConfig{"t2": {Name: "t2"}, "t3": {Name: "t3"}}, time.Second)
+	if len(op.Add) != 0 || len(op.Del) != 1 || op.Del[0] != "t1" {
+		t.Errorf("unexpected operation: %+v", op)
+	}
+}
+
+func TestTrackerInitUnknownAction(t *testing.T) {
+	tr := NewTracker(log.New(io.Discard, "", 0))
+	err := tr.Init(context.Background(), &TrackerConfig{OnAdd: []string{"unknown"}})
+	if err == nil {
+		t.Fatal("expected an error for an unknown action")
+	}
+}
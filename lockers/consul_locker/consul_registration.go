@@ -18,19 +18,28 @@ import (
 	"github.com/openconfig/gnmic/lockers"
 )
 
-const defaultWatchTimeout = 1 * time.Minute
+const (
+	defaultWatchTimeout    = 1 * time.Minute
+	defaultDeregisterAfter = 5 * time.Second
+)
 
 func (c *ConsulLocker) Register(ctx context.Context, s *lockers.ServiceRegistration) error {
+	deregisterAfter := s.DeregisterAfter
+	if deregisterAfter <= 0 {
+		deregisterAfter = defaultDeregisterAfter
+	}
 	service := &api.AgentServiceRegistration{
 		ID:      s.ID,
 		Name:    s.Name,
 		Address: s.Address,
 		Port:    s.Port,
 		Tags:    s.Tags,
+		Meta:    s.Meta,
 		Checks: api.AgentServiceChecks{
 			{
+				Name:                           s.ID + " TTL",
 				TTL:                            s.TTL.String(),
-				DeregisterCriticalServiceAfter: "5s",
+				DeregisterCriticalServiceAfter: deregisterAfter.String(),
 			},
 		},
 	}
@@ -57,8 +66,12 @@ func (c *ConsulLocker) Register(ctx context.Context, s *lockers.ServiceRegistrat
 				return err
 			}
 		case <-sctx.Done():
-			c.client.Agent().UpdateTTL(ttlCheckID, ctx.Err().Error(), api.HealthCritical)
 			ticker.Stop()
+			// the service was deregistered
+			if ctx.Err() == nil {
+				return nil
+			}
+			c.client.Agent().UpdateTTL(ttlCheckID, ctx.Err().Error(), api.HealthCritical)
 			return nil
 		}
 	}
@@ -68,6 +81,7 @@ func (c *ConsulLocker) Deregister(s string) error {
 	c.m.Lock()
 	if cfn, ok := c.services[s]; ok {
 		cfn()
+		delete(c.services, s)
 	}
 	c.m.Unlock()
 	return c.client.Agent().ServiceDeregister(s)
@@ -141,6 +155,7 @@ func (c *ConsulLocker) watch(qOpts *api.QueryOptions, serviceName string, tags [
 			ID:      srv.Service.ID,
 			Address: net.JoinHostPort(addr, strconv.Itoa(srv.Service.Port)),
 			Tags:    srv.Service.Tags,
+			Meta:    srv.Service.Meta,
 		})
 	}
 	sChan <- newSrvs
@@ -162,6 +177,7 @@ func (c *ConsulLocker) GetServices(ctx context.Context, serviceName string, tags
 			ID:      srv.Service.ID,
 			Address: net.JoinHostPort(addr, strconv.Itoa(srv.Service.Port)),
 			Tags:    srv.Service.Tags,
+			Meta:    srv.Service.Meta,
 		})
 	}
 	return newSrvs, nil
@@ -80,11 +80,16 @@ type ServiceRegistration struct {
 	Address string
 	Port    int
 	Tags    []string
+	Meta    map[string]string
 	TTL     time.Duration
+	// duration after which a service with a failing TTL check
+	// is removed by the locker.
+	DeregisterAfter time.Duration
 }
 
 type Service struct {
 	ID      string
 	Address string
 	Tags    []string
+	Meta    map[string]string
 }
@@ -0,0 +1,243 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const textTimeFormat = "2006/01/02 15:04:05.000000"
+
+var (
+	// file:line header added by the log.Llongfile and log.Lshortfile flags
+	callerRegex = regexp.MustCompile(`^(\S+\.go:\d+): `)
+	// [module] or [module:name]
+	prefixRegex = regexp.MustCompile(`^\[([^\]:\s]+)(?::([^\]]+))?\] `)
+	// target, subscription and output names mentioned in the message,
+	// e.g: target "r1", target=r1, subscription 'sub1'
+	fieldsRegex = regexp.MustCompile(`\b(target|subscription|output)(?:=["']?([^\s"',:()]+)|\s+["']([^"']+)["'])`)
+)
+
+// modules whose prefix name is stored under a specific field
+var nameFields = map[string]string{
+	"output":      "output",
+	"input":       "input",
+	"loader":      "loader",
+	"locker":      "locker",
+	"action":      "action",
+	"credentials": "provider",
+}
+
+// Handler is an io.Writer the standard library loggers write to.
+// Each write is turned into a log record, filtered based on its level
+// and module, then written to the underlying writer as text or JSON.
+// The loggers writing to a Handler should not add a date or time
+// to their messages, the Handler adds it.
+type Handler struct {
+	m       sync.Mutex
+	out     io.Writer
+	format  Format
+	level   Level
+	modules map[string]Level
+
+	now func() time.Time
+}
+
+type Option func(*Handler)
+
+// WithFormat sets the output format, defaults to FormatText.
+func WithFormat(f Format) Option {
+	return func(h *Handler) {
+		h.format = f
+	}
+}
+
+// WithLevel sets the minimum level of the written records, defaults to LevelInfo.
+func WithLevel(l Level) Option {
+	return func(h *Handler) {
+		h.level = l
+	}
+}
+
+// WithModuleLevels overrides the minimum level per module.
+// The keys are module names (e.g: file_output, cache)
+// or module and instance names (e.g: file_output:output1).
+func WithModuleLevels(levels map[string]Level) Option {
+	return func(h *Handler) {
+		for m, l := range levels {
+			h.modules[strings.ToLower(m)] = l
+		}
+	}
+}
+
+func NewHandler(out io.Writer, opts ...Option) *Handler {
+	h := &Handler{
+		out:     out,
+		format:  FormatText,
+		level:   LevelInfo,
+		modules: make(map[string]Level),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Record is a parsed log message.
+type Record struct {
+	Time    time.Time
+	Level   Level
+	Caller  string
+	Module  string
+	Name    string
+	Message string
+	Fields  map[string]string
+	// key/value attributes of a leveled logger record, in order.
+	// They are also part of Fields.
+	attrs [][2]string
+}
+
+// Write parses p into a Record and writes it if its level is enabled.
+func (h *Handler) Write(p []byte) (int, error) {
+	r := ParseRecord(string(p))
+	r.Time = h.now()
+	err := h.write(r)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// write encodes the record r to the underlying writer if its level is enabled.
+func (h *Handler) write(r *Record) error {
+	if !h.enabled(r) {
+		return nil
+	}
+	b := h.encode(r)
+	h.m.Lock()
+	defer h.m.Unlock()
+	_, err := h.out.Write(b)
+	return err
+}
+
+func (h *Handler) enabled(r *Record) bool {
+	minLevel := h.level
+	if r.Module != "" {
+		if l, ok := h.modules[r.Module]; ok {
+			minLevel = l
+		}
+		if r.Name != "" {
+			if l, ok := h.modules[r.Module+":"+strings.ToLower(r.Name)]; ok {
+				minLevel = l
+			}
+		}
+	}
+	return r.Level >= minLevel
+}
+
+func (h *Handler) encode(r *Record) []byte {
+	if h.format == FormatJSON {
+		m := make(map[string]interface{}, len(r.Fields)+5)
+		for k, v := range r.Fields {
+			m[k] = v
+		}
+		m["time"] = r.Time.Format(time.RFC3339Nano)
+		m["level"] = r.Level.String()
+		m["msg"] = r.Message
+		if r.Module != "" {
+			m["module"] = r.Module
+		}
+		if r.Caller != "" {
+			m["caller"] = r.Caller
+		}
+		b, err := json.Marshal(m)
+		if err != nil {
+			b, _ = json.Marshal(map[string]string{"level": r.Level.String(), "msg": r.Message})
+		}
+		return append(b, '\n')
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString(r.Time.Format(textTimeFormat))
+	buf.WriteByte(' ')
+	buf.WriteString(strings.ToUpper(r.Level.String()))
+	buf.WriteByte(' ')
+	if r.Caller != "" {
+		buf.WriteString(r.Caller)
+		buf.WriteString(": ")
+	}
+	if r.Module != "" {
+		buf.WriteByte('[')
+		buf.WriteString(r.Module)
+		if r.Name != "" {
+			buf.WriteByte(':')
+			buf.WriteString(r.Name)
+		}
+		buf.WriteString("] ")
+	}
+	buf.WriteString(r.Message)
+	for _, kv := range r.attrs {
+		fmt.Fprintf(buf, " %s=%s", kv[0], kv[1])
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// ParseRecord parses a message written by a standard library logger
+// with the log.Lmsgprefix flag and an optional file:line header.
+// The message has no level, it is logged as info.
+func ParseRecord(s string) *Record {
+	s = strings.TrimRight(s, "\n")
+	r := &Record{Fields: make(map[string]string)}
+	if m := callerRegex.FindStringSubmatch(s); m != nil {
+		r.Caller = m[1]
+		s = s[len(m[0]):]
+	}
+	if m := prefixRegex.FindStringSubmatch(s); m != nil {
+		r.Module = strings.ToLower(m[1])
+		r.Name = m[2]
+		s = s[len(m[0]):]
+	}
+	r.Level = LevelInfo
+	r.Message = s
+	r.setNameField()
+	for _, m := range fieldsRegex.FindAllStringSubmatch(s, -1) {
+		if _, ok := r.Fields[m[1]]; ok {
+			continue
+		}
+		if m[2] != "" {
+			r.Fields[m[1]] = m[2]
+		} else {
+			r.Fields[m[1]] = m[3]
+		}
+	}
+	return r
+}
+
+// setNameField stores the module instance name under the field
+// matching the module kind, e.g: output for file_output.
+func (r *Record) setNameField() {
+	if r.Name == "" {
+		return
+	}
+	key := "name"
+	if i := strings.LastIndex(r.Module, "_"); i >= 0 {
+		if f, ok := nameFields[r.Module[i+1:]]; ok {
+			key = f
+		}
+	}
+	r.Fields[key] = r.Name
+}
@@ -0,0 +1,176 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var parseRecordTestSet = map[string]struct {
+	in  string
+	out *Record
+}{
+	"plain": {
+		in: "starting gnmic\n",
+		out: &Record{
+			Level:   LevelInfo,
+			Message: "starting gnmic",
+			Fields:  map[string]string{},
+		},
+	},
+	"module_and_name": {
+		in: "[file_output:output1] initialized file output: {}\n",
+		out: &Record{
+			Level:   LevelInfo,
+			Module:  "file_output",
+			Name:    "output1",
+			Message: "initialized file output: {}",
+			Fields:  map[string]string{"output": "output1"},
+		},
+	},
+	"caller": {
+		in: "/src/app/collector.go:71: [gnmic] target \"r1\": subscription=sub1 closed\n",
+		out: &Record{
+			Level:   LevelInfo,
+			Caller:  "/src/app/collector.go:71",
+			Module:  "gnmic",
+			Message: "target \"r1\": subscription=sub1 closed",
+			Fields:  map[string]string{"target": "r1", "subscription": "sub1"},
+		},
+	},
+	"no_level_inferred": {
+		in: "[cache:redis] failed to connect: connection refused\n",
+		out: &Record{
+			Level:   LevelInfo,
+			Module:  "cache",
+			Name:    "redis",
+			Message: "failed to connect: connection refused",
+			Fields:  map[string]string{"name": "redis"},
+		},
+	},
+	"level_marker_kept": {
+		in: "[config] warning: subscription has no paths\n",
+		out: &Record{
+			Level:   LevelInfo,
+			Module:  "config",
+			Message: "warning: subscription has no paths",
+			Fields:  map[string]string{},
+		},
+	},
+}
+
+func TestParseRecord(t *testing.T) {
+	for name, tc := range parseRecordTestSet {
+		t.Run(name, func(t *testing.T) {
+			r := ParseRecord(tc.in)
+			if !reflect.DeepEqual(r, tc.out) {
+				t.Errorf("expected %+v, got %+v", tc.out, r)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ts := time.Date(2022, 10, 1, 10, 0, 0, 0, time.UTC)
+	buf := new(bytes.Buffer)
+	h := NewHandler(buf,
+		WithFormat(FormatJSON),
+		WithLevel(LevelInfo),
+		WithModuleLevels(map[string]Level{
+			"cache":               LevelError,
+			"file_output:verbose": LevelDebug,
+		}),
+	)
+	h.now = func() time.Time { return ts }
+
+	logger := log.New(h, "[gnmic] ", log.Lmsgprefix)
+	NewLogger(logger, LevelError).Debug("dropped")
+	logger.Printf("target %q: subscription %q started", "r1", "sub1")
+	cache := NewLogger(log.New(h, "[cache:redis] ", log.Lmsgprefix), LevelDebug)
+	cache.Info("connected")
+	cache.Error("failed to read", "error", io.EOF)
+	NewLogger(log.New(h, "[file_output:verbose] ", log.Lmsgprefix), LevelInfo).
+		With("target", "r1").WithGroup("batch").Debug("written", "messages", 1)
+
+	expected := []map[string]interface{}{
+		{
+			"time":         "2022-10-01T10:00:00Z",
+			"level":        "info",
+			"module":       "gnmic",
+			"msg":          `target "r1": subscription "sub1" started`,
+			"target":       "r1",
+			"subscription": "sub1",
+		},
+		{
+			"time":   "2022-10-01T10:00:00Z",
+			"level":  "error",
+			"module": "cache",
+			"name":   "redis",
+			"msg":    "failed to read",
+			"error":  "EOF",
+		},
+		{
+			"time":           "2022-10-01T10:00:00Z",
+			"level":          "debug",
+			"module":         "file_output",
+			"output":         "verbose",
+			"msg":            "written",
+			"target":         "r1",
+			"batch.messages": "1",
+		},
+	}
+	dec := json.NewDecoder(buf)
+	for i, exp := range expected {
+		got := make(map[string]interface{})
+		err := dec.Decode(&got)
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, exp) {
+			t.Errorf("record %d: expected %v, got %v", i, exp, got)
+		}
+	}
+	if dec.More() {
+		t.Errorf("unexpected extra records")
+	}
+}
+
+func TestHandlerText(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h := NewHandler(buf)
+	h.now = func() time.Time { return time.Date(2022, 10, 1, 10, 0, 0, 0, time.UTC) }
+	logger := log.New(h, "[kafka_output:k1] ", log.Lmsgprefix)
+	logger.Printf("failed to send: timeout")
+	NewLogger(logger, LevelInfo).Warn("queue full", "size", 100)
+	exp := "2022/10/01 10:00:00.000000 INFO [kafka_output:k1] failed to send: timeout\n" +
+		"2022/10/01 10:00:00.000000 WARN [kafka_output:k1] queue full size=100\n"
+	if buf.String() != exp {
+		t.Errorf("expected %q, got %q", exp, buf.String())
+	}
+}
+
+func TestLoggerPlain(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewLogger(log.New(buf, "[gnmic] ", log.Lmsgprefix|log.Lshortfile), LevelInfo)
+	l.Debug("dropped")
+	l.Info("updating target cache", "target", "r1")
+	// the caller is the line logging the message
+	got := buf.String()
+	if !strings.HasPrefix(got, "handler_test.go:") ||
+		!strings.HasSuffix(got, ": [gnmic] updating target cache target=r1\n") {
+		t.Errorf("unexpected log line %q", got)
+	}
+}
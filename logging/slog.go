@@ -0,0 +1,170 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// NewLogger returns a leveled logger writing to the standard library logger l.
+// If l writes to a Handler, the records are passed to it along with their
+// level and attributes, and the module is taken from the "[module:name] " prefix of l.
+// Otherwise the records below level are dropped and the others are printed by l
+// as the message followed by the key=value attributes.
+func NewLogger(l *log.Logger, level Level) *slog.Logger {
+	if h, ok := l.Writer().(*Handler); ok {
+		sh := &slogHandler{h: h}
+		if m := prefixRegex.FindStringSubmatch(l.Prefix()); m != nil {
+			sh.module = strings.ToLower(m[1])
+			sh.name = m[2]
+		}
+		return slog.New(sh)
+	}
+	return slog.New(&logHandler{l: l, level: level})
+}
+
+func fromSlogLevel(l slog.Level) Level {
+	switch {
+	case l < slog.LevelInfo:
+		return LevelDebug
+	case l < slog.LevelWarn:
+		return LevelInfo
+	case l < slog.LevelError:
+		return LevelWarn
+	}
+	return LevelError
+}
+
+// attrs is the list of attributes added to a logger with With,
+// keys are prefixed with the groups they are in.
+type attrs struct {
+	prefix string
+	kvs    []slog.Attr
+}
+
+func (a attrs) withAttrs(as []slog.Attr) attrs {
+	kvs := make([]slog.Attr, 0, len(a.kvs)+len(as))
+	kvs = append(kvs, a.kvs...)
+	for _, at := range as {
+		kvs = append(kvs, slog.Attr{Key: a.prefix + at.Key, Value: at.Value})
+	}
+	return attrs{prefix: a.prefix, kvs: kvs}
+}
+
+func (a attrs) withGroup(name string) attrs {
+	if name == "" {
+		return a
+	}
+	return attrs{prefix: a.prefix + name + ".", kvs: a.kvs}
+}
+
+// each calls fn with the logger attributes and the record r attributes,
+// the group attributes are flattened.
+func (a attrs) each(r slog.Record, fn func(k, v string)) {
+	var add func(prefix string, at slog.Attr)
+	add = func(prefix string, at slog.Attr) {
+		v := at.Value.Resolve()
+		if v.Kind() == slog.KindGroup {
+			for _, ga := range v.Group() {
+				add(prefix+at.Key+".", ga)
+			}
+			return
+		}
+		if at.Key == "" {
+			return
+		}
+		fn(prefix+at.Key, v.String())
+	}
+	for _, at := range a.kvs {
+		add("", at)
+	}
+	r.Attrs(func(at slog.Attr) bool {
+		add(a.prefix, at)
+		return true
+	})
+}
+
+// slogHandler is a slog.Handler writing to a Handler.
+type slogHandler struct {
+	h      *Handler
+	module string
+	name   string
+	attrs  attrs
+}
+
+func (s *slogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return s.h.enabled(&Record{Level: fromSlogLevel(l), Module: s.module, Name: s.name})
+}
+
+func (s *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	rec := &Record{
+		Time:    s.h.now(),
+		Level:   fromSlogLevel(r.Level),
+		Module:  s.module,
+		Name:    s.name,
+		Message: r.Message,
+		Fields:  make(map[string]string),
+	}
+	rec.setNameField()
+	s.attrs.each(r, func(k, v string) {
+		rec.Fields[k] = v
+		rec.attrs = append(rec.attrs, [2]string{k, v})
+	})
+	return s.h.write(rec)
+}
+
+func (s *slogHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	ns := *s
+	ns.attrs = s.attrs.withAttrs(as)
+	return &ns
+}
+
+func (s *slogHandler) WithGroup(name string) slog.Handler {
+	ns := *s
+	ns.attrs = s.attrs.withGroup(name)
+	return &ns
+}
+
+// logHandler is a slog.Handler printing the records with a standard library logger.
+type logHandler struct {
+	l     *log.Logger
+	level Level
+	attrs attrs
+}
+
+func (lh *logHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return fromSlogLevel(l) >= lh.level
+}
+
+func (lh *logHandler) Handle(_ context.Context, r slog.Record) error {
+	sb := new(strings.Builder)
+	sb.WriteString(r.Message)
+	lh.attrs.each(r, func(k, v string) {
+		fmt.Fprintf(sb, " %s=%s", k, v)
+	})
+	// skip this function, the slog.Logger log method and
+	// its Debug, Info, Warn or Error caller.
+	return lh.l.Output(4, sb.String())
+}
+
+func (lh *logHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	nh := *lh
+	nh.attrs = lh.attrs.withAttrs(as)
+	return &nh
+}
+
+func (lh *logHandler) WithGroup(name string) slog.Handler {
+	nh := *lh
+	nh.attrs = lh.attrs.withGroup(name)
+	return &nh
+}
@@ -0,0 +1,355 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package membudget accounts the bytes buffered by gNMIc's telemetry pipeline
+// against a global memory budget, and applies a policy when the budget is exceeded.
+package membudget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	PolicyDrop  = "drop"
+	PolicyPause = "pause"
+	PolicySpill = "spill"
+
+	defaultPolicy       = PolicyDrop
+	defaultResumeRatio  = 0.8
+	defaultSpillMaxSize = "1GiB"
+)
+
+// Component is a part of the pipeline buffering messages.
+type Component int
+
+const (
+	// received responses being processed and written to the outputs
+	Pipeline Component = iota
+	// messages queued by the outputs writing asynchronously
+	Outputs
+	// notifications retained by the gNMI cache
+	Cache
+	numComponents
+)
+
+func (c Component) String() string {
+	switch c {
+	case Pipeline:
+		return "pipeline"
+	case Outputs:
+		return "outputs"
+	case Cache:
+		return "cache"
+	}
+	return "unknown"
+}
+
+// Config is the memory budget configuration.
+type Config struct {
+	// maximum number of bytes buffered, e.g 512MiB
+	Limit string `mapstructure:"limit,omitempty" json:"limit,omitempty"`
+	// action taken when the limit is reached: drop, pause or spill
+	Policy string `mapstructure:"policy,omitempty" json:"policy,omitempty"`
+	// ratio of the limit the usage must fall under before
+	// the paused subscriptions or the spilled messages are resumed
+	ResumeRatio float64 `mapstructure:"resume-ratio,omitempty" json:"resume-ratio,omitempty"`
+	// spill policy, directory the messages are written to
+	SpillDirectory string `mapstructure:"spill-directory,omitempty" json:"spill-directory,omitempty"`
+	// spill policy, maximum size of the spilled messages, the messages are dropped beyond it
+	SpillMaxSize string `mapstructure:"spill-max-size,omitempty" json:"spill-max-size,omitempty"`
+}
+
+// SetDefaults sets the default values of unset fields.
+func (c *Config) SetDefaults() {
+	if c.Policy == "" {
+		c.Policy = defaultPolicy
+	}
+	c.Policy = strings.ToLower(c.Policy)
+	if c.ResumeRatio <= 0 {
+		c.ResumeRatio = defaultResumeRatio
+	}
+	if c.SpillMaxSize == "" {
+		c.SpillMaxSize = defaultSpillMaxSize
+	}
+}
+
+// Stats is a snapshot of the budget usage.
+type Stats struct {
+	Limit   int64            `json:"limit"`
+	Used    int64            `json:"used"`
+	Usage   map[string]int64 `json:"usage,omitempty"`
+	Dropped uint64           `json:"dropped"`
+	// spill policy
+	Spilled      uint64 `json:"spilled,omitempty"`
+	SpilledBytes int64  `json:"spilled-bytes,omitempty"`
+}
+
+// Budget tracks the buffered bytes against a limit.
+// A nil *Budget is valid and unlimited.
+type Budget struct {
+	policy string
+	limit  int64
+	resume int64
+
+	used    int64
+	usage   [numComponents]int64
+	dropped uint64
+	spilled uint64
+
+	m    *sync.Mutex
+	cond *sync.Cond
+
+	spool *Spool
+}
+
+// New creates a Budget from cfg.
+func New(cfg *Config) (*Budget, error) {
+	if cfg == nil {
+		return nil, errors.New("missing memory budget config")
+	}
+	cfg.SetDefaults()
+	limit, err := ParseSize(cfg.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memory budget limit: %w", err)
+	}
+	if limit <= 0 {
+		return nil, errors.New("memory budget limit must be set")
+	}
+	if cfg.ResumeRatio > 1 {
+		return nil, fmt.Errorf("invalid memory budget resume-ratio %v, must be between 0 and 1", cfg.ResumeRatio)
+	}
+	b := &Budget{
+		policy: cfg.Policy,
+		limit:  limit,
+		resume: int64(float64(limit) * cfg.ResumeRatio),
+		m:      new(sync.Mutex),
+	}
+	b.cond = sync.NewCond(b.m)
+	switch cfg.Policy {
+	case PolicyDrop, PolicyPause:
+	case PolicySpill:
+		if cfg.SpillDirectory == "" {
+			return nil, errors.New("memory budget spill policy requires a spill-directory")
+		}
+		maxSize, err := ParseSize(cfg.SpillMaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory budget spill-max-size: %w", err)
+		}
+		b.spool, err = NewSpool(cfg.SpillDirectory, maxSize)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown memory budget policy %q, must be one of %s, %s or %s",
+			cfg.Policy, PolicyDrop, PolicyPause, PolicySpill)
+	}
+	return b, nil
+}
+
+// Policy returns the policy applied when the budget is exceeded.
+func (b *Budget) Policy() string {
+	if b == nil {
+		return ""
+	}
+	return b.policy
+}
+
+// Acquire adds n bytes to the usage of component c.
+func (b *Budget) Acquire(c Component, n int64) {
+	if b == nil || n <= 0 {
+		return
+	}
+	atomic.AddInt64(&b.usage[c], n)
+	atomic.AddInt64(&b.used, n)
+}
+
+// Release removes n bytes from the usage of component c,
+// and wakes up the callers of Wait if the usage fell under the resume threshold.
+func (b *Budget) Release(c Component, n int64) {
+	if b == nil || n <= 0 {
+		return
+	}
+	atomic.AddInt64(&b.usage[c], -n)
+	if atomic.AddInt64(&b.used, -n) <= b.resume {
+		b.m.Lock()
+		b.cond.Broadcast()
+		b.m.Unlock()
+	}
+}
+
+// Used returns the number of bytes currently accounted.
+func (b *Budget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&b.used)
+}
+
+// Exceeded returns true if the usage reached the limit.
+func (b *Budget) Exceeded() bool {
+	if b == nil {
+		return false
+	}
+	return atomic.LoadInt64(&b.used) >= b.limit
+}
+
+// Resumable returns true if the usage is under the resume threshold.
+func (b *Budget) Resumable() bool {
+	if b == nil {
+		return true
+	}
+	return atomic.LoadInt64(&b.used) <= b.resume
+}
+
+// Wait blocks until the usage falls under the resume threshold or ctx is done.
+func (b *Budget) Wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.m.Lock()
+			b.cond.Broadcast()
+			b.m.Unlock()
+		case <-stop:
+		}
+	}()
+	b.m.Lock()
+	defer b.m.Unlock()
+	for !b.Resumable() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		b.cond.Wait()
+	}
+	return nil
+}
+
+// Drop records a message dropped because of the budget.
+func (b *Budget) Drop() {
+	if b == nil {
+		return
+	}
+	atomic.AddUint64(&b.dropped, 1)
+}
+
+// Spool returns the spill storage, nil unless the policy is spill.
+func (b *Budget) Spool() *Spool {
+	if b == nil {
+		return nil
+	}
+	return b.spool
+}
+
+// Spill writes a message to the spill storage,
+// the message is dropped if the storage is full.
+func (b *Budget) Spill(rec []byte) error {
+	if b == nil || b.spool == nil {
+		return errors.New("memory budget spill storage not configured")
+	}
+	err := b.spool.Write(rec)
+	if err != nil {
+		b.Drop()
+		return err
+	}
+	atomic.AddUint64(&b.spilled, 1)
+	return nil
+}
+
+// Stats returns a snapshot of the budget usage.
+func (b *Budget) Stats() *Stats {
+	if b == nil {
+		return nil
+	}
+	s := &Stats{
+		Limit:   b.limit,
+		Used:    atomic.LoadInt64(&b.used),
+		Usage:   make(map[string]int64, numComponents),
+		Dropped: atomic.LoadUint64(&b.dropped),
+		Spilled: atomic.LoadUint64(&b.spilled),
+	}
+	for c := Component(0); c < numComponents; c++ {
+		s.Usage[c.String()] = atomic.LoadInt64(&b.usage[c])
+	}
+	if b.spool != nil {
+		s.SpilledBytes = b.spool.Size()
+	}
+	return s
+}
+
+// Close closes the spill storage, if any.
+func (b *Budget) Close() error {
+	if b == nil || b.spool == nil {
+		return nil
+	}
+	return b.spool.Close()
+}
+
+var defaultBudget atomic.Value
+
+// SetDefault sets the budget used by the components
+// that do not hold a reference to it, such as the outputs.
+func SetDefault(b *Budget) {
+	defaultBudget.Store(b)
+}
+
+// Default returns the budget set with SetDefault, nil if none.
+func Default() *Budget {
+	b, _ := defaultBudget.Load().(*Budget)
+	return b
+}
+
+var sizeUnits = []struct {
+	suffix string
+	mult   int64
+}{
+	{"kib", 1 << 10},
+	{"mib", 1 << 20},
+	{"gib", 1 << 30},
+	{"tib", 1 << 40},
+	{"kb", 1000},
+	{"mb", 1000 * 1000},
+	{"gb", 1000 * 1000 * 1000},
+	{"tb", 1000 * 1000 * 1000 * 1000},
+	{"k", 1 << 10},
+	{"m", 1 << 20},
+	{"g", 1 << 30},
+	{"t", 1 << 40},
+	{"b", 1},
+}
+
+// ParseSize parses a size in bytes with an optional unit suffix,
+// e.g 1048576, 512MiB, 1GB or 64k.
+func ParseSize(size string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(size))
+	if s == "" {
+		return 0, nil
+	}
+	mult := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			mult = u.mult
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return int64(f * float64(mult)), nil
+}
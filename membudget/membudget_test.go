@@ -0,0 +1,209 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package membudget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"":        0,
+		"1024":    1024,
+		"10b":     10,
+		"64k":     64 << 10,
+		"512MiB":  512 << 20,
+		"1.5GiB":  3 << 29,
+		"1GB":     1000 * 1000 * 1000,
+		" 2 mb ":  2 * 1000 * 1000,
+		"100 KiB": 100 << 10,
+	}
+	for in, want := range tests {
+		got, err := ParseSize(in)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: got %d, want %d", in, got, want)
+		}
+	}
+	for _, in := range []string{"ten", "-1MB", "1XB"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *Config
+		err  bool
+	}{
+		{name: "defaults", cfg: &Config{Limit: "1MiB"}},
+		{name: "pause", cfg: &Config{Limit: "1MiB", Policy: "Pause"}},
+		{name: "missing_limit", cfg: &Config{}, err: true},
+		{name: "unknown_policy", cfg: &Config{Limit: "1MiB", Policy: "block"}, err: true},
+		{name: "invalid_ratio", cfg: &Config{Limit: "1MiB", ResumeRatio: 2}, err: true},
+		{name: "spill_without_directory", cfg: &Config{Limit: "1MiB", Policy: PolicySpill}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestBudgetAccounting(t *testing.T) {
+	b, err := New(&Config{Limit: "100", ResumeRatio: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Acquire(Pipeline, 60)
+	b.Acquire(Outputs, 40)
+	if !b.Exceeded() {
+		t.Fatalf("expected the budget to be exceeded, used=%d", b.Used())
+	}
+	b.Release(Outputs, 40)
+	if b.Exceeded() || b.Resumable() {
+		t.Fatalf("unexpected state at used=%d", b.Used())
+	}
+	b.Drop()
+	st := b.Stats()
+	if st.Used != 60 || st.Usage["pipeline"] != 60 || st.Usage["outputs"] != 0 || st.Dropped != 1 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+
+	done := make(chan error)
+	go func() { done <- b.Wait(context.Background()) }()
+	select {
+	case <-done:
+		t.Fatal("Wait returned above the resume threshold")
+	case <-time.After(50 * time.Millisecond):
+	}
+	b.Release(Pipeline, 10)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return under the resume threshold")
+	}
+}
+
+func TestBudgetWaitCanceled(t *testing.T) {
+	b, err := New(&Config{Limit: "10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Acquire(Cache, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline exceeded error, got %v", err)
+	}
+}
+
+func TestNilBudget(t *testing.T) {
+	var b *Budget
+	b.Acquire(Pipeline, 10)
+	b.Release(Pipeline, 10)
+	if b.Exceeded() || !b.Resumable() || b.Used() != 0 || b.Stats() != nil {
+		t.Error("a nil budget must be unlimited")
+	}
+	if err := b.Wait(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpool(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Read(); err != io.EOF {
+		t.Fatalf("expected io.EOF from an empty spool, got %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := s.Write([]byte(fmt.Sprintf("record-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		rec, err := s.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(rec) != fmt.Sprintf("record-%d", i) {
+			t.Fatalf("unexpected record %q at %d", rec, i)
+		}
+	}
+	// writes after a read go to a new segment
+	if err := s.Write([]byte("record-10")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the unread segment is replayed from its start after a restart
+	s, err = NewSpool(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	var got []string
+	for {
+		rec, err := s.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(rec))
+	}
+	if len(got) != 11 || got[0] != "record-0" || got[10] != "record-10" {
+		t.Fatalf("unexpected records after restart: %v", got)
+	}
+	if s.Size() != 0 {
+		t.Errorf("expected an empty spool, size=%d", s.Size())
+	}
+}
+
+func TestSpoolFull(t *testing.T) {
+	s, err := NewSpool(t.TempDir(), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.Write(make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(make([]byte, 10)); !errors.Is(err, ErrSpoolFull) {
+		t.Fatalf("expected ErrSpoolFull, got %v", err)
+	}
+	if _, err := s.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(make([]byte, 10)); err != nil {
+		t.Fatalf("unexpected error after a read: %v", err)
+	}
+}
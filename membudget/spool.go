@@ -0,0 +1,231 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package membudget
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	spoolSegmentSuffix  = ".spill"
+	spoolSegmentMaxSize = 16 * 1024 * 1024
+)
+
+// ErrSpoolFull is returned when writing to a spool that reached its maximum size.
+var ErrSpoolFull = errors.New("spill storage is full")
+
+// Spool stores records in length prefixed segment files,
+// they are read back in the order they were written.
+// The records left by a previous run are read first.
+type Spool struct {
+	dir     string
+	maxSize int64
+
+	m    *sync.Mutex
+	size int64
+	// segments sequence numbers, oldest first
+	segments []uint64
+	nextSeq  uint64
+	// segment being written
+	w     *os.File
+	bw    *bufio.Writer
+	wSize int64
+	// segment being read
+	r    *os.File
+	br   *bufio.Reader
+	rSeq uint64
+	// bytes of the records read from the segment
+	rRead int64
+}
+
+// NewSpool creates a Spool storing up to maxSize bytes in dir.
+func NewSpool(dir string, maxSize int64) (*Spool, error) {
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, err
+	}
+	s := &Spool{
+		dir:     dir,
+		maxSize: maxSize,
+		m:       new(sync.Mutex),
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), spoolSegmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(e.Name(), spoolSegmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		s.segments = append(s.segments, seq)
+		s.size += fi.Size()
+		if seq >= s.nextSeq {
+			s.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i] < s.segments[j] })
+	return s, nil
+}
+
+func (s *Spool) segmentPath(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, spoolSegmentSuffix))
+}
+
+// Write appends a record to the spool.
+func (s *Spool) Write(rec []byte) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	n := int64(len(rec)) + 4
+	if s.size+n > s.maxSize {
+		return ErrSpoolFull
+	}
+	if s.w != nil && s.wSize+n > spoolSegmentMaxSize {
+		err := s.closeWriter()
+		if err != nil {
+			return err
+		}
+	}
+	if s.w == nil {
+		f, err := os.OpenFile(s.segmentPath(s.nextSeq), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+		if err != nil {
+			return err
+		}
+		s.w = f
+		s.bw = bufio.NewWriter(f)
+		s.wSize = 0
+		s.segments = append(s.segments, s.nextSeq)
+		s.nextSeq++
+	}
+	var hdr [4]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(rec)))
+	if _, err := s.bw.Write(hdr[:]); err != nil {
+		return err
+	}
+	if _, err := s.bw.Write(rec); err != nil {
+		return err
+	}
+	s.wSize += n
+	s.size += n
+	return nil
+}
+
+func (s *Spool) closeWriter() error {
+	if s.w == nil {
+		return nil
+	}
+	err := s.bw.Flush()
+	if err != nil {
+		return err
+	}
+	err = s.w.Close()
+	s.w = nil
+	s.bw = nil
+	return err
+}
+
+// Read returns the oldest record, it returns io.EOF if the spool is empty.
+func (s *Spool) Read() ([]byte, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for {
+		if s.r == nil {
+			if len(s.segments) == 0 {
+				return nil, io.EOF
+			}
+			seq := s.segments[0]
+			// the segment being written is closed so that it can be read,
+			// the next write starts a new segment.
+			if s.w != nil && seq == s.nextSeq-1 {
+				if s.wSize == 0 {
+					return nil, io.EOF
+				}
+				err := s.closeWriter()
+				if err != nil {
+					return nil, err
+				}
+			}
+			f, err := os.Open(s.segmentPath(seq))
+			if err != nil {
+				return nil, err
+			}
+			s.r = f
+			s.br = bufio.NewReader(f)
+			s.rSeq = seq
+			s.rRead = 0
+		}
+		var hdr [4]byte
+		_, err := io.ReadFull(s.br, hdr[:])
+		if err == nil {
+			rec := make([]byte, binary.BigEndian.Uint32(hdr[:]))
+			_, err = io.ReadFull(s.br, rec)
+			if err == nil {
+				s.rRead += int64(len(rec)) + 4
+				s.size -= int64(len(rec)) + 4
+				return rec, nil
+			}
+		}
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		// end of segment, or truncated record
+		err = s.removeReader()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *Spool) removeReader() error {
+	// remove the bytes of a truncated last record from the size
+	if fi, err := s.r.Stat(); err == nil && fi.Size() > s.rRead {
+		s.size -= fi.Size() - s.rRead
+	}
+	s.r.Close()
+	s.r = nil
+	s.br = nil
+	s.segments = s.segments[1:]
+	return os.Remove(s.segmentPath(s.rSeq))
+}
+
+// Size returns the number of bytes stored in the spool.
+func (s *Spool) Size() int64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.size
+}
+
+// Close flushes the pending writes and closes the segment files.
+// The records not read yet are kept for the next run.
+func (s *Spool) Close() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.r != nil {
+		s.r.Close()
+		s.r = nil
+	}
+	return s.closeWriter()
+}
@@ -0,0 +1,364 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package netconf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	baseNamespace = "urn:ietf:params:xml:ns:netconf:base:1.0"
+	capBase10     = "urn:ietf:params:netconf:base:1.0"
+	capBase11     = "urn:ietf:params:netconf:base:1.1"
+	// NETCONF 1.0 end of message delimiter
+	endOfMessage = "]]>]]>"
+)
+
+// Session is a NETCONF session with a device.
+// It supports the 1.0 end of message framing as well as the 1.1 chunked framing,
+// the latter is used if both peers advertise the base:1.1 capability.
+type Session struct {
+	r       *bufio.Reader
+	w       io.Writer
+	closers []io.Closer
+
+	chunked      bool
+	id           string
+	capabilities []string
+	msgID        uint64
+}
+
+// Dial opens a NETCONF over SSH session with the device.
+func Dial(ctx context.Context, d *DeviceConfig, timeout time.Duration) (*Session, error) {
+	sshConfig, err := clientConfig(d)
+	if err != nil {
+		return nil, err
+	}
+	sshConfig.Timeout = timeout
+	addr := d.Address
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, defaultPort)
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, sshConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	client := ssh.NewClient(c, chans, reqs)
+	sess, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	w, err := sess.StdinPipe()
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	r, err := sess.StdoutPipe()
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	err = sess.RequestSubsystem("netconf")
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to start the netconf subsystem: %v", err)
+	}
+	s := newSession(r, w, sess, client)
+	err = s.hello(ctx)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func newSession(r io.Reader, w io.Writer, closers ...io.Closer) *Session {
+	return &Session{
+		r:       bufio.NewReader(r),
+		w:       w,
+		closers: closers,
+	}
+}
+
+// ID returns the session-id assigned by the device.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Capabilities returns the capabilities advertised by the device.
+func (s *Session) Capabilities() []string {
+	return s.capabilities
+}
+
+// Close closes the session and its underlying connection.
+func (s *Session) Close() error {
+	var err error
+	for _, c := range s.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+type hello struct {
+	XMLName      xml.Name `xml:"hello"`
+	Capabilities []string `xml:"capabilities>capability"`
+	SessionID    string   `xml:"session-id"`
+}
+
+// hello exchanges the hello messages and selects the framing.
+func (s *Session) hello(ctx context.Context) error {
+	msg := `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<hello xmlns="` + baseNamespace + `"><capabilities>` +
+		`<capability>` + capBase10 + `</capability>` +
+		`<capability>` + capBase11 + `</capability>` +
+		`</capabilities></hello>`
+	err := s.writeMessage([]byte(msg))
+	if err != nil {
+		return err
+	}
+	b, err := s.readMessageContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the device hello: %v", err)
+	}
+	h := new(hello)
+	err = xml.Unmarshal(b, h)
+	if err != nil {
+		return fmt.Errorf("failed to parse the device hello: %v", err)
+	}
+	s.id = h.SessionID
+	s.capabilities = h.Capabilities
+	for _, c := range h.Capabilities {
+		if strings.TrimSpace(c) == capBase11 {
+			s.chunked = true
+			break
+		}
+	}
+	return nil
+}
+
+// Get runs a get RPC with the subtree filter and returns the reply data element.
+func (s *Session) Get(ctx context.Context, filter string) (*Node, error) {
+	return s.RPC(ctx, "<get>"+filter+"</get>")
+}
+
+// GetConfig runs a get-config RPC against the source datastore
+// with the subtree filter and returns the reply data element.
+func (s *Session) GetConfig(ctx context.Context, source, filter string) (*Node, error) {
+	return s.RPC(ctx, "<get-config><source><"+source+"/></source>"+filter+"</get-config>")
+}
+
+// RPC sends the operation in an rpc element and returns the data element of the reply,
+// which is nil if the reply has none, e.g: <ok/>.
+// The rpc-error elements of the reply with severity error are returned as an error.
+func (s *Session) RPC(ctx context.Context, operation string) (*Node, error) {
+	id := strconv.FormatUint(atomic.AddUint64(&s.msgID, 1), 10)
+	msg := `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<rpc message-id="` + id + `" xmlns="` + baseNamespace + `">` + operation + `</rpc>`
+	err := s.writeMessage([]byte(msg))
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.readMessageContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := parseXML(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rpc reply: %v", err)
+	}
+	if reply.Name.Local != "rpc-reply" {
+		return nil, fmt.Errorf("unexpected message %q", reply.Name.Local)
+	}
+	if err = replyError(reply); err != nil {
+		return nil, err
+	}
+	return reply.Child("data"), nil
+}
+
+// replyError returns the rpc-error elements with severity error of the reply.
+func replyError(reply *Node) error {
+	var errs []string
+	for _, e := range reply.Children {
+		if e.Name.Local != "rpc-error" {
+			continue
+		}
+		if e.ChildText("error-severity") == "warning" {
+			continue
+		}
+		msg := e.ChildText("error-message")
+		if msg == "" {
+			msg = e.ChildText("error-tag")
+		}
+		errs = append(errs, fmt.Sprintf("%s: %s", e.ChildText("error-type"), msg))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("rpc error: %s", strings.Join(errs, ", "))
+}
+
+func (s *Session) writeMessage(b []byte) error {
+	var err error
+	if s.chunked {
+		_, err = fmt.Fprintf(s.w, "\n#%d\n%s\n##\n", len(b), b)
+	} else {
+		_, err = fmt.Fprintf(s.w, "%s%s", b, endOfMessage)
+	}
+	return err
+}
+
+// readMessageContext reads a message, the session is closed
+// if ctx is done before the message is read.
+func (s *Session) readMessageContext(ctx context.Context) ([]byte, error) {
+	type result struct {
+		b   []byte
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		b, err := s.readMessage()
+		ch <- result{b, err}
+	}()
+	select {
+	case <-ctx.Done():
+		s.Close()
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.b, r.err
+	}
+}
+
+func (s *Session) readMessage() ([]byte, error) {
+	if !s.chunked {
+		var msg []byte
+		for {
+			b, err := s.r.ReadBytes('>')
+			msg = append(msg, b...)
+			if bytes.HasSuffix(msg, []byte(endOfMessage)) {
+				return msg[:len(msg)-len(endOfMessage)], nil
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	var msg []byte
+	for {
+		hdr := make([]byte, 3)
+		_, err := io.ReadFull(s.r, hdr)
+		if err != nil {
+			return nil, err
+		}
+		if hdr[0] != '\n' || hdr[1] != '#' {
+			return nil, errors.New("invalid chunk header")
+		}
+		// end of chunks
+		if hdr[2] == '#' {
+			c, err := s.r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			if c != '\n' {
+				return nil, errors.New("invalid end of chunks")
+			}
+			return msg, nil
+		}
+		size, err := s.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.ParseUint(string(hdr[2])+strings.TrimSuffix(size, "\n"), 10, 32)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid chunk size %q", string(hdr[2])+size)
+		}
+		chunk := make([]byte, n)
+		_, err = io.ReadFull(s.r, chunk)
+		if err != nil {
+			return nil, err
+		}
+		msg = append(msg, chunk...)
+	}
+}
+
+func clientConfig(d *DeviceConfig) (*ssh.ClientConfig, error) {
+	auths := make([]ssh.AuthMethod, 0, 2)
+	if d.PrivateKey != "" {
+		keyFile, err := homedir.Expand(d.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		b, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		var signer ssh.Signer
+		if d.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(b, []byte(d.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(b)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key %q: %v", keyFile, err)
+		}
+		auths = append(auths, ssh.PublicKeys(signer))
+	}
+	if d.Password != "" {
+		auths = append(auths, ssh.Password(d.Password))
+	}
+	if len(auths) == 0 {
+		return nil, errors.New("no authentication method configured")
+	}
+	var hostKeyCallback ssh.HostKeyCallback
+	if d.InsecureIgnoreHostKey {
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	} else {
+		knownHostsFile, err := homedir.Expand(d.KnownHosts)
+		if err != nil {
+			return nil, err
+		}
+		hostKeyCallback, err = knownhosts.New(knownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read known hosts file %q: %v", knownHostsFile, err)
+		}
+	}
+	return &ssh.ClientConfig{
+		User:            d.Username,
+		Auth:            auths,
+		HostKeyCallback: hostKeyCallback,
+	}, nil
+}
@@ -0,0 +1,132 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package netconf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	RPCGet       = "get"
+	RPCGetConfig = "get-config"
+
+	defaultPort      = "830"
+	defaultInterval  = time.Minute
+	defaultTimeout   = 30 * time.Second
+	defaultSource    = "running"
+	defaultKnownHost = "~/.ssh/known_hosts"
+)
+
+// PollerConfig is the configuration of a NETCONF poller,
+// it retrieves the same paths from a set of devices every interval.
+type PollerConfig struct {
+	Name string `mapstructure:"name,omitempty" json:"name,omitempty"`
+	// devices polled, by name
+	Devices map[string]*DeviceConfig `mapstructure:"devices,omitempty" json:"devices,omitempty"`
+	// interval between two polls, defaults to 1m
+	Interval time.Duration `mapstructure:"interval,omitempty" json:"interval,omitempty"`
+	// timeout of the connection and of each RPC, defaults to 30s
+	Timeout time.Duration `mapstructure:"timeout,omitempty" json:"timeout,omitempty"`
+	// NETCONF RPC used to retrieve the paths, get or get-config
+	RPC string `mapstructure:"rpc,omitempty" json:"rpc,omitempty"`
+	// get-config source datastore, defaults to running
+	Source string `mapstructure:"source,omitempty" json:"source,omitempty"`
+	// gNMI style paths converted to a subtree filter
+	Paths []string `mapstructure:"paths,omitempty" json:"paths,omitempty"`
+	// YANG modules describing the retrieved data,
+	// used to find the namespaces, the lists keys and the leaves types
+	YangFiles []string `mapstructure:"yang-files,omitempty" json:"yang-files,omitempty"`
+	YangDirs  []string `mapstructure:"yang-dirs,omitempty" json:"yang-dirs,omitempty"`
+	// outputs the notifications are written to, all of them if empty
+	Outputs []string `mapstructure:"outputs,omitempty" json:"outputs,omitempty"`
+	// in a cluster, only the instance holding the poller task lock polls the devices
+	LeaderOnly bool `mapstructure:"leader-only,omitempty" json:"leader-only,omitempty"`
+	Debug      bool `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+}
+
+// DeviceConfig is the NETCONF over SSH connection configuration of a device.
+type DeviceConfig struct {
+	Name string `mapstructure:"name,omitempty" json:"name,omitempty"`
+	// device address, the port defaults to 830
+	Address  string `mapstructure:"address,omitempty" json:"address,omitempty"`
+	Username string `mapstructure:"username,omitempty" json:"username,omitempty"`
+	Password string `mapstructure:"password,omitempty" json:"password,omitempty"`
+	// path to a private key file and its optional passphrase
+	PrivateKey string `mapstructure:"private-key,omitempty" json:"private-key,omitempty"`
+	Passphrase string `mapstructure:"passphrase,omitempty" json:"passphrase,omitempty"`
+	// known hosts file used to verify the device key, defaults to ~/.ssh/known_hosts
+	KnownHosts            string `mapstructure:"known-hosts,omitempty" json:"known-hosts,omitempty"`
+	InsecureIgnoreHostKey bool   `mapstructure:"insecure-ignore-host-key,omitempty" json:"insecure-ignore-host-key,omitempty"`
+}
+
+func (c PollerConfig) String() string {
+	devices := make(map[string]*DeviceConfig, len(c.Devices))
+	for n, d := range c.Devices {
+		dc := *d
+		if dc.Password != "" {
+			dc.Password = "****"
+		}
+		if dc.Passphrase != "" {
+			dc.Passphrase = "****"
+		}
+		devices[n] = &dc
+	}
+	c.Devices = devices
+	type plain PollerConfig
+	return fmt.Sprintf("%+v", plain(c))
+}
+
+// SetDefaults sets the poller defaults and validates it.
+func (c *PollerConfig) SetDefaults() error {
+	if len(c.Devices) == 0 {
+		return errors.New("no devices configured")
+	}
+	for n, d := range c.Devices {
+		if d == nil {
+			return fmt.Errorf("device %q: missing address", n)
+		}
+		d.Name = n
+		if d.Address == "" {
+			d.Address = n
+		}
+		if d.Username == "" {
+			return fmt.Errorf("device %q: missing username", n)
+		}
+		if d.Password == "" && d.PrivateKey == "" {
+			return fmt.Errorf("device %q: missing password or private-key", n)
+		}
+		if d.KnownHosts == "" {
+			d.KnownHosts = defaultKnownHost
+		}
+	}
+	if c.Interval <= 0 {
+		c.Interval = defaultInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	c.RPC = strings.ToLower(c.RPC)
+	switch c.RPC {
+	case "":
+		c.RPC = RPCGet
+	case RPCGet, RPCGetConfig:
+	default:
+		return fmt.Errorf("unknown rpc %q, must be one of %q or %q", c.RPC, RPCGet, RPCGetConfig)
+	}
+	if c.Source == "" {
+		c.Source = defaultSource
+	}
+	if len(c.Paths) == 0 {
+		return errors.New("missing paths")
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package netconf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// SubtreeFilter returns the subtree filter element selecting the paths.
+// The paths keys are converted to content match nodes, wildcard keys are ignored
+// and a path stops at its first wildcard element.
+// The namespace of the top element of each path is set from the schema module defining it,
+// either the path origin or the element prefix if set, or any module otherwise.
+// An empty filter is returned if one of the paths selects the whole datastore.
+func SubtreeFilter(paths []*gnmi.Path, schema *yang.Entry) string {
+	buf := new(bytes.Buffer)
+	for _, p := range paths {
+		elems := p.GetElem()
+		if len(elems) == 0 || elems[0].GetName() == "*" {
+			return ""
+		}
+		module := p.GetOrigin()
+		name := elems[0].GetName()
+		if i := strings.Index(name, ":"); i >= 0 {
+			module = name[:i]
+		}
+		ns := namespace(schema, module, localName(name))
+		var closing []string
+		for i, e := range elems {
+			name := localName(e.GetName())
+			if name == "*" {
+				break
+			}
+			buf.WriteString("<" + name)
+			if i == 0 && ns != "" {
+				buf.WriteString(` xmlns="`)
+				xml.EscapeText(buf, []byte(ns))
+				buf.WriteString(`"`)
+			}
+			buf.WriteString(">")
+			keys := make([]string, 0, len(e.GetKey()))
+			for k := range e.GetKey() {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				v := e.GetKey()[k]
+				if v == "*" {
+					continue
+				}
+				buf.WriteString("<" + k + ">")
+				xml.EscapeText(buf, []byte(v))
+				buf.WriteString("</" + k + ">")
+			}
+			closing = append(closing, "</"+name+">")
+		}
+		for i := len(closing) - 1; i >= 0; i-- {
+			buf.WriteString(closing[i])
+		}
+	}
+	if buf.Len() == 0 {
+		return ""
+	}
+	return `<filter type="subtree">` + buf.String() + `</filter>`
+}
+
+// namespace returns the namespace of the top level schema node name,
+// looked up in module if set.
+func namespace(schema *yang.Entry, module, name string) string {
+	if schema == nil {
+		return ""
+	}
+	if module != "" {
+		if m, ok := schema.Dir[module]; ok {
+			if e := childEntry(m, name); e != nil {
+				return e.Namespace().Name
+			}
+		}
+	}
+	names := make([]string, 0, len(schema.Dir))
+	for n := range schema.Dir {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if e := childEntry(schema.Dir[n], name); e != nil {
+			return e.Namespace().Name
+		}
+	}
+	return ""
+}
+
+func localName(name string) string {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
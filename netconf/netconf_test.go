@@ -0,0 +1,281 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package netconf
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/utils"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+const testReply = `<rpc-reply message-id="1" xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">
+<data>
+  <interfaces xmlns="urn:example">
+    <interface>
+      <name>eth0</name>
+      <mtu>1500</mtu>
+      <enabled>true</enabled>
+      <address>10.0.0.1</address>
+      <address>10.0.0.2</address>
+      <counters><in-octets>42</in-octets><drift>-3</drift></counters>
+    </interface>
+    <interface>
+      <name>eth1</name>
+      <mtu>9000</mtu>
+    </interface>
+  </interfaces>
+</data>
+</rpc-reply>`
+
+func testSchema(t *testing.T) *yang.Entry {
+	t.Helper()
+	ms := yang.NewModules()
+	if err := ms.Read("testdata/example.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	m, err := ms.FindModuleByNamespace("urn:example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &yang.Entry{
+		Name: "root",
+		Kind: yang.DirectoryEntry,
+		Dir:  map[string]*yang.Entry{m.Name: yang.ToEntry(m)},
+	}
+}
+
+// testServer is a NETCONF server answering each rpc with reply.
+type testServer struct {
+	r       *bufio.Reader
+	w       io.Writer
+	chunked bool
+}
+
+func (s *testServer) write(msg string) {
+	if s.chunked {
+		// split the message in two chunks
+		half := len(msg) / 2
+		fmt.Fprintf(s.w, "\n#%d\n%s\n#%d\n%s\n##\n", half, msg[:half], len(msg)-half, msg[half:])
+		return
+	}
+	fmt.Fprintf(s.w, "%s%s", msg, endOfMessage)
+}
+
+func (s *testServer) serve(t *testing.T, reply string, rpcs chan<- string) {
+	caps := "<capability>" + capBase10 + "</capability>"
+	if s.chunked {
+		caps += "<capability>" + capBase11 + "</capability>"
+	}
+	// the hellos are sent simultaneously and framed with the end of message delimiter
+	go fmt.Fprintf(s.w, "%s%s", `<hello xmlns="`+baseNamespace+`"><capabilities>`+caps+`</capabilities><session-id>7</session-id></hello>`, endOfMessage)
+	b, err := (&Session{r: s.r}).readMessage()
+	if err != nil {
+		return
+	}
+	if !strings.Contains(string(b), "<hello") {
+		t.Errorf("expected a hello, got %s", b)
+	}
+	for {
+		b, err := (&Session{r: s.r, chunked: s.chunked}).readMessage()
+		if err != nil {
+			return
+		}
+		rpcs <- string(b)
+		s.write(reply)
+	}
+}
+
+// testSession returns a session with a test server answering with reply.
+func testSession(t *testing.T, chunked bool, reply string) (*Session, chan string) {
+	t.Helper()
+	cr, sw := io.Pipe()
+	sr, cw := io.Pipe()
+	rpcs := make(chan string, 1)
+	srv := &testServer{r: bufio.NewReader(sr), w: sw, chunked: chunked}
+	go srv.serve(t, reply, rpcs)
+	s := newSession(cr, cw, cr, cw)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.hello(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close(); sr.Close(); sw.Close() })
+	return s, rpcs
+}
+
+func TestSession(t *testing.T) {
+	for _, chunked := range []bool{false, true} {
+		t.Run(fmt.Sprintf("chunked=%v", chunked), func(t *testing.T) {
+			s, rpcs := testSession(t, chunked, testReply)
+			if s.chunked != chunked || s.ID() != "7" {
+				t.Fatalf("unexpected session state: chunked=%v id=%q", s.chunked, s.ID())
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			data, err := s.GetConfig(ctx, "running", `<filter type="subtree"><interfaces xmlns="urn:example"/></filter>`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rpc := <-rpcs
+			if !strings.Contains(rpc, "<get-config><source><running/></source><filter") {
+				t.Errorf("unexpected rpc: %s", rpc)
+			}
+			if data.Child("interfaces") == nil {
+				t.Errorf("unexpected data: %+v", data)
+			}
+		})
+	}
+}
+
+func TestSessionRPCError(t *testing.T) {
+	s, _ := testSession(t, true, `<rpc-reply message-id="1" xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">
+<rpc-error><error-type>application</error-type><error-tag>invalid-value</error-tag>
+<error-severity>error</error-severity><error-message>bad filter</error-message></rpc-error></rpc-reply>`)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := s.Get(ctx, "")
+	if err == nil || !strings.Contains(err.Error(), "application: bad filter") {
+		t.Errorf("expected the rpc error, got %v", err)
+	}
+}
+
+func TestSubtreeFilter(t *testing.T) {
+	schema := testSchema(t)
+	tests := map[string]struct {
+		paths  []string
+		schema *yang.Entry
+		out    string
+	}{
+		"keyed": {
+			paths:  []string{"/interfaces/interface[name=eth0]/counters"},
+			schema: schema,
+			out:    `<filter type="subtree"><interfaces xmlns="urn:example"><interface><name>eth0</name><counters></counters></interface></interfaces></filter>`,
+		},
+		"wildcards": {
+			paths:  []string{"/interfaces/interface[name=*]/*/in-octets"},
+			schema: schema,
+			out:    `<filter type="subtree"><interfaces xmlns="urn:example"><interface></interface></interfaces></filter>`,
+		},
+		"no schema": {
+			paths: []string{"/system/name", "/interfaces"},
+			out:   `<filter type="subtree"><system><name></name></system><interfaces></interfaces></filter>`,
+		},
+		"root": {
+			paths:  []string{"/interfaces", "/"},
+			schema: schema,
+			out:    "",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			paths := make([]*gnmi.Path, 0, len(tt.paths))
+			for _, p := range tt.paths {
+				gp, err := utils.ParsePath(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				paths = append(paths, gp)
+			}
+			if got := SubtreeFilter(paths, tt.schema); got != tt.out {
+				t.Errorf("got %s, expected %s", got, tt.out)
+			}
+		})
+	}
+}
+
+func updatesByPath(upds []*gnmi.Update) map[string]*gnmi.TypedValue {
+	vals := make(map[string]*gnmi.TypedValue, len(upds))
+	for _, upd := range upds {
+		vals[utils.GnmiPathToXPath(upd.GetPath(), false)] = upd.GetVal()
+	}
+	return vals
+}
+
+func TestUpdates(t *testing.T) {
+	reply, err := parseXML([]byte(testReply))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals := updatesByPath(Updates(reply.Child("data"), testSchema(t)))
+	if len(vals) != 8 {
+		t.Fatalf("unexpected updates: %v", vals)
+	}
+	if v := vals["interfaces/interface[name=eth0]/mtu"]; v.GetUintVal() != 1500 {
+		t.Errorf("unexpected mtu: %v", v)
+	}
+	if v := vals["interfaces/interface[name=eth0]/enabled"]; !v.GetBoolVal() {
+		t.Errorf("unexpected enabled: %v", v)
+	}
+	if v := vals["interfaces/interface[name=eth0]/counters/drift"]; v.GetIntVal() != -3 {
+		t.Errorf("unexpected drift: %v", v)
+	}
+	if v := vals["interfaces/interface[name=eth0]/address"]; len(v.GetLeaflistVal().GetElement()) != 2 {
+		t.Errorf("unexpected address: %v", v)
+	}
+	if v := vals["interfaces/interface[name=eth1]/name"]; v.GetStringVal() != "eth1" {
+		t.Errorf("unexpected name: %v", v)
+	}
+
+	// without schema the entries are indexed and the values are strings
+	vals = updatesByPath(Updates(reply.Child("data"), nil))
+	if v := vals["interfaces/interface[index=1]/mtu"]; v.GetStringVal() != "9000" {
+		t.Errorf("unexpected updates without schema: %v", vals)
+	}
+}
+
+func TestPoller(t *testing.T) {
+	cfg := &PollerConfig{
+		Name:    "p1",
+		Devices: map[string]*DeviceConfig{"dev1": {Username: "admin", Password: "admin"}},
+		Paths:   []string{"/interfaces"},
+		Timeout: 5 * time.Second,
+	}
+	if err := cfg.SetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPoller(cfg, testSchema(t), log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rpcs chan string
+	dials := 0
+	p.dial = func(ctx context.Context, d *DeviceConfig, timeout time.Duration) (*Session, error) {
+		dials++
+		var s *Session
+		s, rpcs = testSession(t, true, testReply)
+		return s, nil
+	}
+	for i := 0; i < 2; i++ {
+		rsp, err := p.Poll(context.Background(), cfg.Devices["dev1"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rpc := <-rpcs; !strings.Contains(rpc, `<get><filter type="subtree"><interfaces xmlns="urn:example">`) {
+			t.Errorf("unexpected rpc: %s", rpc)
+		}
+		if len(rsp.GetUpdate().GetUpdate()) != 8 {
+			t.Errorf("unexpected notification: %v", rsp.GetUpdate())
+		}
+	}
+	if dials != 1 {
+		t.Errorf("expected the session to be reused, got %d dials", dials)
+	}
+}
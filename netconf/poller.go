@@ -0,0 +1,157 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package netconf
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/utils"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// ExportFn is called with the notification built from a device poll.
+type ExportFn func(ctx context.Context, device string, rsp *gnmi.SubscribeResponse)
+
+// Poller retrieves the configured paths from its devices every interval.
+// The device sessions are kept open between polls and re-opened on the next poll if they fail.
+type Poller struct {
+	cfg    *PollerConfig
+	schema *yang.Entry
+	logger *log.Logger
+	filter string
+
+	m        *sync.Mutex
+	sessions map[string]*Session
+	// opens a session with a device, replaced in tests
+	dial func(ctx context.Context, d *DeviceConfig, timeout time.Duration) (*Session, error)
+}
+
+// NewPoller creates a poller, the schema is used to build the subtree filter
+// and to convert the replies, it can be nil.
+func NewPoller(cfg *PollerConfig, schema *yang.Entry, logger *log.Logger) (*Poller, error) {
+	paths := make([]*gnmi.Path, 0, len(cfg.Paths))
+	for _, p := range cfg.Paths {
+		gp, err := utils.ParsePath(p)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, gp)
+	}
+	return &Poller{
+		cfg:      cfg,
+		schema:   schema,
+		logger:   logger,
+		filter:   SubtreeFilter(paths, schema),
+		m:        new(sync.Mutex),
+		sessions: make(map[string]*Session),
+		dial:     Dial,
+	}, nil
+}
+
+// Run polls the devices every interval and calls fn with the notifications,
+// until ctx is done.
+func (p *Poller) Run(ctx context.Context, fn ExportFn) {
+	defer p.closeSessions()
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		wg := new(sync.WaitGroup)
+		wg.Add(len(p.cfg.Devices))
+		for _, d := range p.cfg.Devices {
+			go func(d *DeviceConfig) {
+				defer wg.Done()
+				rsp, err := p.Poll(ctx, d)
+				if err != nil {
+					if ctx.Err() == nil {
+						p.logger.Printf("netconf poller %q: device %q: %v", p.cfg.Name, d.Name, err)
+					}
+					return
+				}
+				fn(ctx, d.Name, rsp)
+			}(d)
+		}
+		wg.Wait()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll retrieves the paths from the device and returns them as a notification.
+func (p *Poller) Poll(ctx context.Context, d *DeviceConfig) (*gnmi.SubscribeResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+	s, err := p.session(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	var data *Node
+	switch p.cfg.RPC {
+	case RPCGetConfig:
+		data, err = s.GetConfig(ctx, p.cfg.Source, p.filter)
+	default:
+		data, err = s.Get(ctx, p.filter)
+	}
+	if err != nil {
+		p.closeSession(d.Name, s)
+		return nil, err
+	}
+	if p.cfg.Debug {
+		p.logger.Printf("netconf poller %q: device %q: session %s: received %s reply", p.cfg.Name, d.Name, s.ID(), p.cfg.RPC)
+	}
+	return &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Timestamp: time.Now().UnixNano(),
+				Update:    Updates(data, p.schema),
+			},
+		},
+	}, nil
+}
+
+func (p *Poller) session(ctx context.Context, d *DeviceConfig) (*Session, error) {
+	p.m.Lock()
+	s, ok := p.sessions[d.Name]
+	p.m.Unlock()
+	if ok {
+		return s, nil
+	}
+	s, err := p.dial(ctx, d, p.cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	p.m.Lock()
+	p.sessions[d.Name] = s
+	p.m.Unlock()
+	return s, nil
+}
+
+func (p *Poller) closeSession(name string, s *Session) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if p.sessions[name] == s {
+		delete(p.sessions, name)
+	}
+	s.Close()
+}
+
+func (p *Poller) closeSessions() {
+	p.m.Lock()
+	defer p.m.Unlock()
+	for name, s := range p.sessions {
+		s.Close()
+		delete(p.sessions, name)
+	}
+}
@@ -0,0 +1,224 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package netconf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/inputs"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// Node is an XML element.
+type Node struct {
+	Name     xml.Name
+	Text     string
+	Children []*Node
+}
+
+// Child returns the first child element named name.
+func (n *Node) Child(name string) *Node {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.Children {
+		if c.Name.Local == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// ChildText returns the trimmed text of the first child element named name.
+func (n *Node) ChildText(name string) string {
+	c := n.Child(name)
+	if c == nil {
+		return ""
+	}
+	return strings.TrimSpace(c.Text)
+}
+
+// parseXML parses the root element of b.
+func parseXML(b []byte) (*Node, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	var stack []*Node
+	var root *Node
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &Node{Name: tok.Name}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, n)
+			} else if root == nil {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(tok)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("no element found")
+	}
+	return root, nil
+}
+
+// Updates converts the elements of a reply data element to gNMI updates.
+// The schema, if not nil, is used to find the lists keys and the leaves types,
+// without it, repeated elements are lists indexed by their position and the leaves are strings.
+func Updates(data *Node, schema *yang.Entry) []*gnmi.Update {
+	if data == nil {
+		return nil
+	}
+	return inputs.TreeUpdates(nodesTree(data.Children, schema, true))
+}
+
+// nodesTree converts sibling elements to a telemetry tree,
+// the elements of the top level of the data are looked up in the schema modules.
+func nodesTree(nodes []*Node, parent *yang.Entry, top bool) map[string]interface{} {
+	tree := make(map[string]interface{})
+	// group the siblings by name, keeping the order of the entries
+	var names []string
+	groups := make(map[string][]*Node)
+	for _, n := range nodes {
+		if _, ok := groups[n.Name.Local]; !ok {
+			names = append(names, n.Name.Local)
+		}
+		groups[n.Name.Local] = append(groups[n.Name.Local], n)
+	}
+	for _, name := range names {
+		group := groups[name]
+		var e *yang.Entry
+		if top {
+			e = topEntry(parent, group[0].Name)
+		} else {
+			e = childEntry(parent, name)
+		}
+		hasChildren := len(group[0].Children) > 0
+		switch {
+		case e != nil && e.IsList(), e == nil && hasChildren && len(group) > 1:
+			items := make([]interface{}, 0, len(group))
+			for _, n := range group {
+				entry := &inputs.ListEntry{Fields: nodesTree(n.Children, e, false)}
+				if e != nil {
+					for _, k := range strings.Fields(e.Key) {
+						if kv, ok := entry.Fields[k]; ok {
+							if entry.Keys == nil {
+								entry.Keys = make(map[string]string)
+							}
+							entry.Keys[k] = fmt.Sprint(kv)
+						}
+					}
+				}
+				items = append(items, entry)
+			}
+			tree[name] = items
+		case hasChildren:
+			tree[name] = nodesTree(group[0].Children, e, false)
+		case e != nil && e.IsLeafList(), len(group) > 1:
+			vals := make([]interface{}, 0, len(group))
+			for _, n := range group {
+				vals = append(vals, leafValue(n.Text, e))
+			}
+			tree[name] = vals
+		default:
+			tree[name] = leafValue(group[0].Text, e)
+		}
+	}
+	return tree
+}
+
+// topEntry returns the top level schema node named name,
+// preferring the one of the module with the element namespace.
+func topEntry(root *yang.Entry, name xml.Name) *yang.Entry {
+	if root == nil {
+		return nil
+	}
+	var found *yang.Entry
+	for _, m := range root.Dir {
+		e := childEntry(m, name.Local)
+		if e == nil {
+			continue
+		}
+		if name.Space == "" || e.Namespace().Name == name.Space {
+			return e
+		}
+		if found == nil {
+			found = e
+		}
+	}
+	return found
+}
+
+// childEntry returns the schema node named name under e,
+// looking through the choice and case nodes.
+func childEntry(e *yang.Entry, name string) *yang.Entry {
+	if e == nil {
+		return nil
+	}
+	if c, ok := e.Dir[name]; ok {
+		return c
+	}
+	for _, c := range e.Dir {
+		if c.IsChoice() || c.IsCase() {
+			if cc := childEntry(c, name); cc != nil {
+				return cc
+			}
+		}
+	}
+	return nil
+}
+
+// leafValue converts the text of a leaf element to a value of the leaf type.
+func leafValue(text string, e *yang.Entry) interface{} {
+	text = strings.TrimSpace(text)
+	if e == nil || e.Type == nil {
+		return text
+	}
+	switch e.Type.Kind {
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yint64:
+		if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return v
+		}
+	case yang.Yuint8, yang.Yuint16, yang.Yuint32, yang.Yuint64:
+		if v, err := strconv.ParseUint(text, 10, 64); err == nil {
+			return v
+		}
+	case yang.Ybool:
+		if v, err := strconv.ParseBool(text); err == nil {
+			return v
+		}
+	case yang.Yempty:
+		return true
+	case yang.Ydecimal64:
+		if v, err := strconv.ParseFloat(text, 64); err == nil {
+			return v
+		}
+	}
+	return text
+}
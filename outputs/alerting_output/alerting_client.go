@@ -0,0 +1,137 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package alerting_output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/openconfig/gnmic/utils"
+)
+
+// webhookMessage is the body sent to the webhooks,
+// it follows the Alertmanager webhook format.
+type webhookMessage struct {
+	Version  string          `json:"version"`
+	Receiver string          `json:"receiver"`
+	Status   string          `json:"status"`
+	Alerts   []*alertPayload `json:"alerts"`
+}
+
+func (o *alertingOutput) createHTTPClient() error {
+	c := &http.Client{
+		Timeout: o.Cfg.Timeout,
+	}
+	if o.Cfg.TLS != nil {
+		tlsCfg, err := utils.NewTLSConfig(
+			o.Cfg.TLS.CAFile,
+			o.Cfg.TLS.CertFile,
+			o.Cfg.TLS.KeyFile,
+			o.Cfg.TLS.SkipVerify,
+			false)
+		if err != nil {
+			return err
+		}
+		c.Transport = &http.Transport{
+			TLSClientConfig: tlsCfg,
+		}
+	}
+	o.httpClient = c
+	return nil
+}
+
+func (o *alertingOutput) sender(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case alerts := <-o.alertsCh:
+			o.send(ctx, alerts)
+		}
+	}
+}
+
+// send posts the alerts to the Alertmanager and webhook URLs.
+func (o *alertingOutput) send(ctx context.Context, alerts []*alertPayload) {
+	if len(o.Cfg.AlertmanagerURLs) > 0 {
+		// Alertmanager derives the alert status from its end time
+		amAlerts := make([]*alertPayload, 0, len(alerts))
+		for _, a := range alerts {
+			ac := *a
+			ac.Status = ""
+			amAlerts = append(amAlerts, &ac)
+		}
+		b, err := json.Marshal(amAlerts)
+		if err != nil {
+			o.logger.Printf("failed to marshal alerts: %v", err)
+			return
+		}
+		for _, u := range o.Cfg.AlertmanagerURLs {
+			err = o.post(ctx, strings.TrimSuffix(u, "/")+"/api/v2/alerts", b)
+			if err != nil {
+				o.logger.Printf("failed to send alerts to alertmanager %q: %v", u, err)
+			}
+		}
+	}
+	if len(o.Cfg.WebhookURLs) > 0 {
+		msg := &webhookMessage{
+			Version:  "4",
+			Receiver: o.Cfg.Name,
+			Status:   statusResolved,
+			Alerts:   alerts,
+		}
+		for _, a := range alerts {
+			if a.Status == statusFiring {
+				msg.Status = statusFiring
+				break
+			}
+		}
+		b, err := json.Marshal(msg)
+		if err != nil {
+			o.logger.Printf("failed to marshal alerts: %v", err)
+			return
+		}
+		for _, u := range o.Cfg.WebhookURLs {
+			err = o.post(ctx, u, b)
+			if err != nil {
+				o.logger.Printf("failed to send alerts to webhook %q: %v", u, err)
+			}
+		}
+	}
+	if o.Cfg.Debug {
+		o.logger.Printf("sent %d alert(s)", len(alerts))
+	}
+}
+
+func (o *alertingOutput) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	for k, v := range o.Cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	rsp, err := o.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(rsp.Body)
+		return fmt.Errorf("status code %d: %s", rsp.StatusCode, string(msg))
+	}
+	return nil
+}
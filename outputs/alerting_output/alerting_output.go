@@ -0,0 +1,329 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package alerting_output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	outputType                = "alerting"
+	loggingPrefix             = "[alerting_output:%s] "
+	defaultTimeout            = 10 * time.Second
+	defaultEvaluationInterval = 15 * time.Second
+	defaultResendInterval     = time.Minute
+	defaultBufferSize         = 1000
+	userAgent                 = "gNMIc alerting"
+)
+
+func init() {
+	outputs.Register(outputType,
+		func() outputs.Output {
+			return &alertingOutput{
+				Cfg:    &config{},
+				logger: log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags),
+				now:    time.Now,
+			}
+		})
+}
+
+// alertingOutput evaluates alerting rules against the received events
+// and sends the firing and resolved alerts to Alertmanager and webhooks.
+type alertingOutput struct {
+	Cfg    *config
+	logger *log.Logger
+
+	httpClient *http.Client
+	eventCh    chan *formatters.EventMsg
+	alertsCh   chan []*alertPayload
+	rules      []*rule
+	now        func() time.Time
+
+	evps      []formatters.EventProcessor
+	targetTpl *template.Template
+	cfn       context.CancelFunc
+}
+
+type config struct {
+	Name string `mapstructure:"name,omitempty" json:"name,omitempty"`
+	// Alertmanager addresses, the alerts are sent to their /api/v2/alerts endpoint
+	AlertmanagerURLs []string `mapstructure:"alertmanager-urls,omitempty" json:"alertmanager-urls,omitempty"`
+	// webhook URLs, the alerts are sent in the Alertmanager webhook format
+	WebhookURLs        []string          `mapstructure:"webhook-urls,omitempty" json:"webhook-urls,omitempty"`
+	Timeout            time.Duration     `mapstructure:"timeout,omitempty" json:"timeout,omitempty"`
+	Headers            map[string]string `mapstructure:"headers,omitempty" json:"headers,omitempty"`
+	TLS                *tlsConfig        `mapstructure:"tls,omitempty" json:"tls,omitempty"`
+	EvaluationInterval time.Duration     `mapstructure:"evaluation-interval,omitempty" json:"evaluation-interval,omitempty"`
+	ResendInterval     time.Duration     `mapstructure:"resend-interval,omitempty" json:"resend-interval,omitempty"`
+	BufferSize         int               `mapstructure:"buffer-size,omitempty" json:"buffer-size,omitempty"`
+	Rules              []*ruleConfig     `mapstructure:"rules,omitempty" json:"rules,omitempty"`
+	AddTarget          string            `mapstructure:"add-target,omitempty" json:"add-target,omitempty"`
+	TargetTemplate     string            `mapstructure:"target-template,omitempty" json:"target-template,omitempty"`
+	EventProcessors    []string          `mapstructure:"event-processors,omitempty" json:"event-processors,omitempty"`
+	Debug              bool              `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+}
+
+type tlsConfig struct {
+	CAFile     string `mapstructure:"ca-file,omitempty" json:"ca-file,omitempty"`
+	CertFile   string `mapstructure:"cert-file,omitempty" json:"cert-file,omitempty"`
+	KeyFile    string `mapstructure:"key-file,omitempty" json:"key-file,omitempty"`
+	SkipVerify bool   `mapstructure:"skip-verify,omitempty" json:"skip-verify,omitempty"`
+}
+
+func (o *alertingOutput) Init(ctx context.Context, name string, cfg map[string]interface{}, opts ...outputs.Option) error {
+	err := outputs.DecodeConfig(cfg, o.Cfg)
+	if err != nil {
+		return err
+	}
+	if o.Cfg.Name == "" {
+		o.Cfg.Name = name
+	}
+	o.logger.SetPrefix(fmt.Sprintf(loggingPrefix, o.Cfg.Name))
+
+	for _, opt := range opts {
+		opt(o)
+	}
+	if len(o.Cfg.AlertmanagerURLs) == 0 && len(o.Cfg.WebhookURLs) == 0 {
+		return errors.New("missing alertmanager-urls or webhook-urls")
+	}
+	if len(o.Cfg.Rules) == 0 {
+		return errors.New("missing rules")
+	}
+	o.setDefaults()
+	o.rules = make([]*rule, 0, len(o.Cfg.Rules))
+	for i, rc := range o.Cfg.Rules {
+		r, err := newRule(rc)
+		if err != nil {
+			return fmt.Errorf("rule %d: %v", i, err)
+		}
+		o.rules = append(o.rules, r)
+	}
+
+	if o.Cfg.TargetTemplate == "" {
+		o.targetTpl = outputs.DefaultTargetTemplate
+	} else if o.Cfg.AddTarget != "" {
+		o.targetTpl, err = utils.CreateTemplate("target-template", o.Cfg.TargetTemplate)
+		if err != nil {
+			return err
+		}
+		o.targetTpl = o.targetTpl.Funcs(outputs.TemplateFuncs)
+	}
+
+	err = o.createHTTPClient()
+	if err != nil {
+		return err
+	}
+	o.eventCh = make(chan *formatters.EventMsg, o.Cfg.BufferSize)
+	o.alertsCh = make(chan []*alertPayload, o.Cfg.BufferSize)
+
+	ctx, o.cfn = context.WithCancel(ctx)
+	go o.worker(ctx)
+	go o.sender(ctx)
+	o.logger.Printf("initialized alerting output %s: %s", o.Cfg.Name, o.String())
+	return nil
+}
+
+func (o *alertingOutput) setDefaults() {
+	if o.Cfg.Timeout <= 0 {
+		o.Cfg.Timeout = defaultTimeout
+	}
+	if o.Cfg.EvaluationInterval <= 0 {
+		o.Cfg.EvaluationInterval = defaultEvaluationInterval
+	}
+	if o.Cfg.ResendInterval <= 0 {
+		o.Cfg.ResendInterval = defaultResendInterval
+	}
+	if o.Cfg.BufferSize <= 0 {
+		o.Cfg.BufferSize = defaultBufferSize
+	}
+}
+
+func (o *alertingOutput) Write(ctx context.Context, rsp proto.Message, meta outputs.Meta) {
+	if rsp == nil {
+		return
+	}
+	switch rsp := rsp.(type) {
+	case *gnmi.SubscribeResponse:
+		measName := "default"
+		if subName, ok := meta["subscription-name"]; ok {
+			measName = subName
+		}
+		var err error
+		rsp, err = outputs.AddSubscriptionTarget(rsp, meta, o.Cfg.AddTarget, o.targetTpl)
+		if err != nil {
+			o.logger.Printf("failed to add target to the response: %v", err)
+		}
+		events, err := formatters.ResponseToEventMsgs(measName, rsp, meta, o.evps...)
+		if err != nil {
+			o.logger.Printf("failed to convert message to event: %v", err)
+			return
+		}
+		for _, ev := range events {
+			select {
+			case <-ctx.Done():
+				return
+			case o.eventCh <- ev:
+			}
+		}
+	}
+}
+
+func (o *alertingOutput) WriteEvent(ctx context.Context, ev *formatters.EventMsg) {
+	select {
+	case <-ctx.Done():
+		return
+	default:
+		var evs = []*formatters.EventMsg{ev}
+		for _, proc := range o.evps {
+			evs = proc.Apply(evs...)
+		}
+		for _, pev := range evs {
+			select {
+			case <-ctx.Done():
+				return
+			case o.eventCh <- pev:
+			}
+		}
+	}
+}
+
+func (o *alertingOutput) Close() error {
+	if o.cfn == nil {
+		return nil
+	}
+	o.cfn()
+	return nil
+}
+
+func (o *alertingOutput) RegisterMetrics(_ *prometheus.Registry) {}
+
+func (o *alertingOutput) String() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (o *alertingOutput) SetLogger(logger *log.Logger) {
+	if logger != nil && o.logger != nil {
+		o.logger.SetOutput(logger.Writer())
+		o.logger.SetFlags(logger.Flags())
+	}
+}
+
+func (o *alertingOutput) SetEventProcessors(ps map[string]map[string]interface{},
+	logger *log.Logger,
+	tcs map[string]*types.TargetConfig,
+	acts map[string]map[string]interface{}) {
+	for _, epName := range o.Cfg.EventProcessors {
+		if epCfg, ok := ps[epName]; ok {
+			epType := ""
+			for k := range epCfg {
+				epType = k
+				break
+			}
+			if in, ok := formatters.EventProcessors[epType]; ok {
+				ep := in()
+				err := ep.Init(epCfg[epType],
+					formatters.WithLogger(logger),
+					formatters.WithTargets(tcs),
+					formatters.WithActions(acts),
+				)
+				if err != nil {
+					o.logger.Printf("failed initializing event processor '%s' of type='%s': %v", epName, epType, err)
+					continue
+				}
+				o.evps = append(o.evps, ep)
+				o.logger.Printf("added event processor '%s' of type=%s to alerting output", epName, epType)
+				continue
+			}
+			o.logger.Printf("%q event processor has an unknown type=%q", epName, epType)
+			continue
+		}
+		o.logger.Printf("%q event processor not found!", epName)
+	}
+}
+
+func (o *alertingOutput) SetName(name string) {}
+
+func (o *alertingOutput) SetClusterName(_ string) {}
+
+func (o *alertingOutput) SetTargetsConfig(map[string]*types.TargetConfig) {}
+
+// worker evaluates the rules against the received events,
+// and periodically to fire the alerts whose condition held long enough.
+func (o *alertingOutput) worker(ctx context.Context) {
+	ticker := time.NewTicker(o.Cfg.EvaluationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-o.eventCh:
+			o.queue(ctx, o.processEvent(ev))
+		case <-ticker.C:
+			o.queue(ctx, o.evaluate())
+		}
+	}
+}
+
+func (o *alertingOutput) processEvent(ev *formatters.EventMsg) []*alertPayload {
+	if ev == nil || len(ev.Values) == 0 {
+		return nil
+	}
+	now := o.now()
+	var res []*alertPayload
+	for _, r := range o.rules {
+		alerts, err := r.process(ev, now)
+		if err != nil {
+			if o.Cfg.Debug {
+				o.logger.Printf("rule %q: failed to process event: %v", r.Name, err)
+			}
+			continue
+		}
+		res = append(res, alerts...)
+	}
+	return res
+}
+
+func (o *alertingOutput) evaluate() []*alertPayload {
+	now := o.now()
+	var res []*alertPayload
+	for _, r := range o.rules {
+		res = append(res, r.evaluate(now, o.Cfg.ResendInterval)...)
+	}
+	return res
+}
+
+func (o *alertingOutput) queue(ctx context.Context, alerts []*alertPayload) {
+	if len(alerts) == 0 {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case o.alertsCh <- alerts:
+	}
+}
@@ -0,0 +1,269 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package alerting_output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/openconfig/gnmic/expr"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/utils"
+)
+
+const (
+	alertNameLabel = "alertname"
+	statusFiring   = "firing"
+	statusResolved = "resolved"
+)
+
+type ruleConfig struct {
+	// alert name, set as the alertname label
+	Name string `mapstructure:"name,omitempty" json:"name,omitempty"`
+	// CEL expression selecting the events the rule applies to, all events if empty
+	Filter string `mapstructure:"filter,omitempty" json:"filter,omitempty"`
+	// CEL expression, the alert is active for a series while it evaluates to true
+	Expression string `mapstructure:"expression,omitempty" json:"expression,omitempty"`
+	// the alert fires if no event is received for a series within this duration,
+	// mutually exclusive with Expression
+	Absent time.Duration `mapstructure:"absent,omitempty" json:"absent,omitempty"`
+	// duration the expression must be true before the alert fires
+	For time.Duration `mapstructure:"for,omitempty" json:"for,omitempty"`
+	// tag names identifying the alert series, all the event tags if empty
+	By []string `mapstructure:"by,omitempty" json:"by,omitempty"`
+	// labels and annotations added to the alerts, the values are Go templates
+	// executed with the event as input
+	Labels      map[string]string `mapstructure:"labels,omitempty" json:"labels,omitempty"`
+	Annotations map[string]string `mapstructure:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+type rule struct {
+	*ruleConfig
+	filter      *expr.Program
+	prg         *expr.Program
+	labels      map[string]*template.Template
+	annotations map[string]*template.Template
+	// alerts by series key
+	alerts map[string]*alert
+}
+
+type alert struct {
+	labels      map[string]string
+	annotations map[string]string
+	// time the expression became true
+	activeSince time.Time
+	// time the last event of the series was received
+	lastSeen time.Time
+	firing   bool
+	firedAt  time.Time
+	lastSent time.Time
+}
+
+// alertPayload is an alert as sent to the receivers.
+type alertPayload struct {
+	Status      string            `json:"status,omitempty"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+}
+
+func newRule(rc *ruleConfig) (*rule, error) {
+	if rc.Name == "" {
+		return nil, errors.New("missing name")
+	}
+	switch {
+	case rc.Expression == "" && rc.Absent <= 0:
+		return nil, errors.New("one of expression or absent must be set")
+	case rc.Expression != "" && rc.Absent > 0:
+		return nil, errors.New("expression and absent are mutually exclusive")
+	}
+	r := &rule{
+		ruleConfig:  rc,
+		labels:      make(map[string]*template.Template, len(rc.Labels)),
+		annotations: make(map[string]*template.Template, len(rc.Annotations)),
+		alerts:      make(map[string]*alert),
+	}
+	var err error
+	if rc.Filter != "" {
+		r.filter, err = formatters.CompileExpression(rc.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("filter: %v", err)
+		}
+	}
+	if rc.Expression != "" {
+		r.prg, err = formatters.CompileExpression(rc.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("expression: %v", err)
+		}
+	}
+	for k, v := range rc.Labels {
+		r.labels[k], err = utils.CreateTemplate(k, v)
+		if err != nil {
+			return nil, fmt.Errorf("label %q: %v", k, err)
+		}
+	}
+	for k, v := range rc.Annotations {
+		r.annotations[k], err = utils.CreateTemplate(k, v)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %q: %v", k, err)
+		}
+	}
+	return r, nil
+}
+
+// process updates the alerts state with event e,
+// it returns the alerts resolved by e.
+func (r *rule) process(e *formatters.EventMsg, now time.Time) ([]*alertPayload, error) {
+	if r.filter != nil {
+		ok, err := formatters.CheckExpression(r.filter, e)
+		if err != nil || !ok {
+			return nil, err
+		}
+	}
+	key := r.seriesKey(e)
+	a, exists := r.alerts[key]
+	if r.prg != nil {
+		ok, err := formatters.CheckExpression(r.prg, e)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if !exists {
+				return nil, nil
+			}
+			delete(r.alerts, key)
+			if a.firing {
+				return []*alertPayload{a.payload(statusResolved, now)}, nil
+			}
+			return nil, nil
+		}
+	}
+	if !exists {
+		a = &alert{activeSince: now}
+		r.alerts[key] = a
+	}
+	a.lastSeen = now
+	if err := r.render(a, e); err != nil {
+		return nil, err
+	}
+	if r.Absent > 0 && a.firing {
+		a.firing = false
+		return []*alertPayload{a.payload(statusResolved, now)}, nil
+	}
+	return nil, nil
+}
+
+// evaluate fires the alerts whose condition held long enough,
+// it returns the newly firing alerts and the ones due to be sent again.
+func (r *rule) evaluate(now time.Time, resendInterval time.Duration) []*alertPayload {
+	var res []*alertPayload
+	for _, a := range r.alerts {
+		if !a.firing {
+			switch {
+			case r.Absent > 0:
+				if now.Sub(a.lastSeen) < r.Absent {
+					continue
+				}
+			case now.Sub(a.activeSince) < r.For:
+				continue
+			}
+			a.firing = true
+			a.firedAt = now
+			a.lastSent = time.Time{}
+		}
+		if !a.lastSent.IsZero() && now.Sub(a.lastSent) < resendInterval {
+			continue
+		}
+		a.lastSent = now
+		p := a.payload(statusFiring, now)
+		// the alert is resolved by the receivers
+		// if it is not sent again in time
+		p.EndsAt = now.Add(4 * resendInterval)
+		res = append(res, p)
+	}
+	return res
+}
+
+// render sets the alert labels and annotations from event e.
+func (r *rule) render(a *alert, e *formatters.EventMsg) error {
+	a.labels = make(map[string]string, len(r.labels)+len(e.Tags)+1)
+	if len(r.By) == 0 {
+		for k, v := range e.Tags {
+			a.labels[k] = v
+		}
+	} else {
+		for _, k := range r.By {
+			if v, ok := e.Tags[k]; ok {
+				a.labels[k] = v
+			}
+		}
+	}
+	var err error
+	for k, tpl := range r.labels {
+		a.labels[k], err = execTemplate(tpl, e)
+		if err != nil {
+			return fmt.Errorf("label %q: %v", k, err)
+		}
+	}
+	a.labels[alertNameLabel] = r.Name
+	a.annotations = make(map[string]string, len(r.annotations))
+	for k, tpl := range r.annotations {
+		a.annotations[k], err = execTemplate(tpl, e)
+		if err != nil {
+			return fmt.Errorf("annotation %q: %v", k, err)
+		}
+	}
+	return nil
+}
+
+// seriesKey returns the identity of the series of e:
+// the values of the By tags, or its name and tags if By is not set.
+func (r *rule) seriesKey(e *formatters.EventMsg) string {
+	if len(r.By) > 0 {
+		parts := make([]string, 0, len(r.By))
+		for _, k := range r.By {
+			parts = append(parts, e.Tags[k])
+		}
+		return strings.Join(parts, "\x00")
+	}
+	parts := make([]string, 0, len(e.Tags))
+	for k, v := range e.Tags {
+		parts = append(parts, k+"\x00"+v)
+	}
+	sort.Strings(parts)
+	return e.Name + "\x00" + strings.Join(parts, "\x00")
+}
+
+func (a *alert) payload(status string, now time.Time) *alertPayload {
+	p := &alertPayload{
+		Status:      status,
+		Labels:      a.labels,
+		Annotations: a.annotations,
+		StartsAt:    a.firedAt,
+	}
+	if status == statusResolved {
+		p.EndsAt = now
+	}
+	return p
+}
+
+func execTemplate(tpl *template.Template, e *formatters.EventMsg) (string, error) {
+	b := new(bytes.Buffer)
+	err := tpl.Execute(b, e)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
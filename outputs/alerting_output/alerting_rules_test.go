@@ -0,0 +1,188 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package alerting_output
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/formatters"
+)
+
+func operState(source, ifName, state string) *formatters.EventMsg {
+	return &formatters.EventMsg{
+		Name:   "sub1",
+		Tags:   map[string]string{"source": source, "interface_name": ifName},
+		Values: map[string]interface{}{"/interface/oper-state": state},
+	}
+}
+
+func TestRuleThreshold(t *testing.T) {
+	r, err := newRule(&ruleConfig{
+		Name:        "InterfaceDown",
+		Expression:  `values["/interface/oper-state"] == "down"`,
+		For:         time.Minute,
+		Labels:      map[string]string{"severity": "critical"},
+		Annotations: map[string]string{"summary": `{{ .Tags.interface_name }} of {{ .Tags.source }} is down`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(0, 0)
+	resend := 5 * time.Minute
+	mustProcess := func(e *formatters.EventMsg) []*alertPayload {
+		t.Helper()
+		res, err := r.process(e, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	mustProcess(operState("r1", "e1", "down"))
+	mustProcess(operState("r1", "e2", "up"))
+	if alerts := r.evaluate(now, resend); len(alerts) != 0 {
+		t.Fatalf("expected no firing alert before the for duration, got %v", alerts)
+	}
+	now = now.Add(time.Minute)
+	alerts := r.evaluate(now, resend)
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 firing alert, got %d", len(alerts))
+	}
+	a := alerts[0]
+	if a.Status != statusFiring || a.Labels[alertNameLabel] != "InterfaceDown" ||
+		a.Labels["severity"] != "critical" || a.Labels["interface_name"] != "e1" {
+		t.Errorf("unexpected alert: %+v", a)
+	}
+	if a.Annotations["summary"] != "e1 of r1 is down" {
+		t.Errorf("unexpected summary annotation: %q", a.Annotations["summary"])
+	}
+	// not sent again before the resend interval
+	now = now.Add(time.Minute)
+	if alerts := r.evaluate(now, resend); len(alerts) != 0 {
+		t.Errorf("unexpected alerts: %v", alerts)
+	}
+	now = now.Add(resend)
+	if alerts := r.evaluate(now, resend); len(alerts) != 1 {
+		t.Errorf("expected the alert to be sent again, got %v", alerts)
+	}
+	// resolved
+	alerts = mustProcess(operState("r1", "e1", "up"))
+	if len(alerts) != 1 || alerts[0].Status != statusResolved || !alerts[0].EndsAt.Equal(now) {
+		t.Errorf("expected a resolved alert, got %v", alerts)
+	}
+	if len(r.alerts) != 0 {
+		t.Errorf("expected no active alert, got %d", len(r.alerts))
+	}
+}
+
+func TestRuleAbsent(t *testing.T) {
+	r, err := newRule(&ruleConfig{
+		Name:   "NoData",
+		Filter: `"/interface/oper-state" in values`,
+		Absent: time.Minute,
+		By:     []string{"source"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(0, 0)
+	r.process(operState("r1", "e1", "up"), now)
+	r.process(operState("r1", "e2", "up"), now)
+	r.process(operState("r2", "e1", "up"), now)
+	now = now.Add(30 * time.Second)
+	r.process(operState("r2", "e1", "up"), now)
+	now = now.Add(40 * time.Second)
+	alerts := r.evaluate(now, time.Minute)
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 firing alert, got %d", len(alerts))
+	}
+	if len(alerts[0].Labels) != 2 || alerts[0].Labels["source"] != "r1" {
+		t.Errorf("unexpected alert labels: %v", alerts[0].Labels)
+	}
+	alerts, _ = r.process(operState("r1", "e1", "up"), now)
+	if len(alerts) != 1 || alerts[0].Status != statusResolved {
+		t.Errorf("expected a resolved alert, got %v", alerts)
+	}
+}
+
+func TestNewRuleErrors(t *testing.T) {
+	for name, rc := range map[string]*ruleConfig{
+		"missing name":      {Expression: "true"},
+		"missing condition": {Name: "a"},
+		"both":              {Name: "a", Expression: "true", Absent: time.Minute},
+		"bad expression":    {Name: "a", Expression: "values["},
+		"bad label":         {Name: "a", Expression: "true", Labels: map[string]string{"l": "{{ .Tags"}},
+	} {
+		if _, err := newRule(rc); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestAlertingOutputSend(t *testing.T) {
+	bodies := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- r.URL.Path + " " + string(b)
+	}))
+	defer srv.Close()
+
+	o := &alertingOutput{
+		Cfg: &config{
+			Name:             "alerts",
+			AlertmanagerURLs: []string{srv.URL + "/"},
+			WebhookURLs:      []string{srv.URL + "/hook"},
+		},
+		logger: log.New(io.Discard, "", 0),
+	}
+	o.setDefaults()
+	if err := o.createHTTPClient(); err != nil {
+		t.Fatal(err)
+	}
+	o.send(context.Background(), []*alertPayload{{
+		Status: statusFiring,
+		Labels: map[string]string{alertNameLabel: "InterfaceDown"},
+	}})
+
+	path, body := splitBody(<-bodies)
+	if path != "/api/v2/alerts" {
+		t.Errorf("unexpected alertmanager path %q", path)
+	}
+	var amAlerts []map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &amAlerts); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := amAlerts[0]["status"]; ok || len(amAlerts) != 1 {
+		t.Errorf("unexpected alertmanager alerts: %s", body)
+	}
+
+	path, body = splitBody(<-bodies)
+	if path != "/hook" {
+		t.Errorf("unexpected webhook path %q", path)
+	}
+	msg := new(webhookMessage)
+	if err := json.Unmarshal([]byte(body), msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Status != statusFiring || msg.Receiver != "alerts" || len(msg.Alerts) != 1 {
+		t.Errorf("unexpected webhook message: %s", body)
+	}
+}
+
+func splitBody(s string) (string, string) {
+	path, body, _ := strings.Cut(s, " ")
+	return path, body
+}
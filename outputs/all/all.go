@@ -9,10 +9,12 @@
 package all
 
 import (
+	_ "github.com/openconfig/gnmic/outputs/alerting_output"
 	_ "github.com/openconfig/gnmic/outputs/file"
 	_ "github.com/openconfig/gnmic/outputs/gnmi_output"
 	_ "github.com/openconfig/gnmic/outputs/influxdb_output"
 	_ "github.com/openconfig/gnmic/outputs/kafka_output"
+	_ "github.com/openconfig/gnmic/outputs/loki_output"
 	_ "github.com/openconfig/gnmic/outputs/nats_outputs/jetstream"
 	_ "github.com/openconfig/gnmic/outputs/nats_outputs/nats"
 	_ "github.com/openconfig/gnmic/outputs/nats_outputs/stan"
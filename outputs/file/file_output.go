@@ -21,6 +21,7 @@ import (
 
 	"github.com/openconfig/gnmic/formatters"
 	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/record"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
 	"github.com/prometheus/client_golang/prometheus"
@@ -30,6 +31,8 @@ import (
 
 const (
 	defaultFormat           = "json"
+	formatProto             = "proto"
+	formatProtoEnvelope     = "proto-envelope"
 	defaultWriteConcurrency = 1000
 	defaultSeparator        = "\n"
 	loggingPrefix           = "[file_output:%s] "
@@ -55,6 +58,10 @@ type File struct {
 
 	targetTpl *template.Template
 	msgTpl    *template.Template
+	// set if the messages are written to time partitioned files
+	pf *partitionedFile
+	// set if the messages are written in proto format to a single file
+	rw *record.Writer
 }
 
 // Config //
@@ -62,10 +69,12 @@ type Config struct {
 	FileName           string   `mapstructure:"filename,omitempty"`
 	FileType           string   `mapstructure:"file-type,omitempty"`
 	Format             string   `mapstructure:"format,omitempty"`
+	Compression        string   `mapstructure:"compression,omitempty"`
 	Multiline          bool     `mapstructure:"multiline,omitempty"`
 	Indent             string   `mapstructure:"indent,omitempty"`
 	Separator          string   `mapstructure:"separator,omitempty"`
 	OverrideTimestamps bool     `mapstructure:"override-timestamps,omitempty"`
+	EventVersion       int      `mapstructure:"event-version,omitempty"`
 	AddTarget          string   `mapstructure:"add-target,omitempty"`
 	TargetTemplate     string   `mapstructure:"target-template,omitempty"`
 	EventProcessors    []string `mapstructure:"event-processors,omitempty"`
@@ -73,6 +82,10 @@ type Config struct {
 	ConcurrencyLimit   int      `mapstructure:"concurrency-limit,omitempty"`
 	EnableMetrics      bool     `mapstructure:"enable-metrics,omitempty"`
 	Debug              bool     `mapstructure:"debug,omitempty"`
+	// time partitioning
+	PartitionBy           string `mapstructure:"partition-by,omitempty"`
+	PartitionFormat       string `mapstructure:"partition-format,omitempty"`
+	PartitionMaxOpenFiles int    `mapstructure:"partition-max-open-files,omitempty"`
 }
 
 func (f *File) String() string {
@@ -135,8 +148,19 @@ func (f *File) Init(ctx context.Context, name string, cfg map[string]interface{}
 	for _, opt := range opts {
 		opt(f)
 	}
-	if f.Cfg.Format == "proto" {
-		return fmt.Errorf("proto format not supported in output type 'file'")
+	if f.Cfg.Compression != "" {
+		if !f.framed() {
+			return fmt.Errorf("compression is only supported with formats %q", []string{formatProto, formatProtoEnvelope})
+		}
+		if f.Cfg.PartitionBy != "" {
+			return errors.New("compression is not supported with time partitioned files")
+		}
+	}
+	if f.Cfg.EventVersion == 0 {
+		f.Cfg.EventVersion = formatters.EventVersion2
+	}
+	if err := formatters.ValidateEventVersion(f.Cfg.EventVersion); err != nil {
+		return err
 	}
 	if f.Cfg.Separator == "" {
 		f.Cfg.Separator = defaultSeparator
@@ -151,6 +175,13 @@ func (f *File) Init(ctx context.Context, name string, cfg map[string]interface{}
 	case "stderr":
 		f.file = os.Stderr
 	default:
+		if f.Cfg.PartitionBy != "" {
+			f.pf, err = newPartitionedFile(f.Cfg.FileName, f.Cfg.PartitionBy, f.Cfg.PartitionFormat, f.Cfg.PartitionMaxOpenFiles)
+			if err != nil {
+				return err
+			}
+			break
+		}
 	CRFILE:
 		f.file, err = os.OpenFile(f.Cfg.FileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 		if err != nil {
@@ -163,6 +194,12 @@ func (f *File) Init(ctx context.Context, name string, cfg map[string]interface{}
 	if f.Cfg.Format == "" {
 		f.Cfg.Format = defaultFormat
 	}
+	if f.framed() && f.pf == nil {
+		f.rw, err = record.NewWriter(f.file, f.Cfg.Compression)
+		if err != nil {
+			return err
+		}
+	}
 	if f.Cfg.FileType == "stdout" || f.Cfg.FileType == "stderr" {
 		f.Cfg.Indent = "  "
 		f.Cfg.Multiline = true
@@ -177,10 +214,11 @@ func (f *File) Init(ctx context.Context, name string, cfg map[string]interface{}
 	f.sem = semaphore.NewWeighted(int64(f.Cfg.ConcurrencyLimit))
 
 	f.mo = &formatters.MarshalOptions{
-		Multiline:  f.Cfg.Multiline,
-		Indent:     f.Cfg.Indent,
-		Format:     f.Cfg.Format,
-		OverrideTS: f.Cfg.OverrideTimestamps,
+		Multiline:    f.Cfg.Multiline,
+		Indent:       f.Cfg.Indent,
+		Format:       f.Cfg.Format,
+		OverrideTS:   f.Cfg.OverrideTimestamps,
+		EventVersion: f.Cfg.EventVersion,
 	}
 	if f.Cfg.TargetTemplate == "" {
 		f.targetTpl = outputs.DefaultTargetTemplate
@@ -223,7 +261,7 @@ func (f *File) Write(ctx context.Context, rsp proto.Message, meta outputs.Meta)
 	}
 	defer f.sem.Release(1)
 
-	numberOfReceivedMsgs.WithLabelValues(f.file.Name()).Inc()
+	numberOfReceivedMsgs.WithLabelValues(f.fileName()).Inc()
 	rsp, err = outputs.AddSubscriptionTarget(rsp, meta, f.Cfg.AddTarget, f.targetTpl)
 	if err != nil {
 		f.logger.Printf("failed to add target to the response: %v", err)
@@ -234,7 +272,24 @@ func (f *File) Write(ctx context.Context, rsp proto.Message, meta outputs.Meta)
 		if f.Cfg.Debug {
 			f.logger.Printf("failed marshaling proto msg: %v", err)
 		}
-		numberOfFailWriteMsgs.WithLabelValues(f.file.Name(), "marshal_error").Inc()
+		numberOfFailWriteMsgs.WithLabelValues(f.fileName(), "marshal_error").Inc()
+		return
+	}
+	if len(b) == 0 {
+		return
+	}
+
+	if f.framed() {
+		n, err := f.writeFramed(rsp, record.Frame(b))
+		if err != nil {
+			if f.Cfg.Debug {
+				f.logger.Printf("failed to write to file '%s': %v", f.fileName(), err)
+			}
+			numberOfFailWriteMsgs.WithLabelValues(f.fileName(), "write_error").Inc()
+			return
+		}
+		numberOfWrittenBytes.WithLabelValues(f.fileName()).Add(float64(n))
+		numberOfWrittenMsgs.WithLabelValues(f.fileName()).Inc()
 		return
 	}
 
@@ -244,28 +299,69 @@ func (f *File) Write(ctx context.Context, rsp proto.Message, meta outputs.Meta)
 			if f.Cfg.Debug {
 				log.Printf("failed to execute template: %v", err)
 			}
-			numberOfFailWriteMsgs.WithLabelValues(f.file.Name(), "template_error").Inc()
+			numberOfFailWriteMsgs.WithLabelValues(f.fileName(), "template_error").Inc()
 			return
 		}
 	}
 
-	n, err := f.file.Write(append(b, []byte(f.Cfg.Separator)...))
+	n, err := f.write(rsp, append(b, []byte(f.Cfg.Separator)...))
 	if err != nil {
 		if f.Cfg.Debug {
-			f.logger.Printf("failed to write to file '%s': %v", f.file.Name(), err)
+			f.logger.Printf("failed to write to file '%s': %v", f.fileName(), err)
 		}
-		numberOfFailWriteMsgs.WithLabelValues(f.file.Name(), "write_error").Inc()
+		numberOfFailWriteMsgs.WithLabelValues(f.fileName(), "write_error").Inc()
 		return
 	}
-	numberOfWrittenBytes.WithLabelValues(f.file.Name()).Add(float64(n))
-	numberOfWrittenMsgs.WithLabelValues(f.file.Name()).Inc()
+	numberOfWrittenBytes.WithLabelValues(f.fileName()).Add(float64(n))
+	numberOfWrittenMsgs.WithLabelValues(f.fileName()).Inc()
 }
 
 func (f *File) WriteEvent(ctx context.Context, ev *formatters.EventMsg) {}
 
+func (f *File) write(rsp proto.Message, b []byte) (int, error) {
+	if f.pf == nil {
+		return f.file.Write(b)
+	}
+	var ts time.Time
+	if !f.Cfg.OverrideTimestamps {
+		ts = outputs.MsgTimestamp(rsp)
+	}
+	return f.pf.write(ts, b)
+}
+
+// writeFramed writes a length prefixed proto message,
+// through the record writer unless the file is time partitioned.
+func (f *File) writeFramed(rsp proto.Message, b []byte) (int, error) {
+	if f.rw == nil {
+		return f.write(rsp, b)
+	}
+	return f.rw.WriteFramed(b)
+}
+
+func (f *File) fileName() string {
+	if f.file == nil {
+		return f.Cfg.FileName
+	}
+	return f.file.Name()
+}
+
+// framed returns true if the configured format is binary,
+// in which case messages are written as length prefixed records.
+func (f *File) framed() bool {
+	return f.Cfg.Format == formatProto || f.Cfg.Format == formatProtoEnvelope
+}
+
 // Close //
 func (f *File) Close() error {
-	f.logger.Printf("closing file '%s' output", f.file.Name())
+	f.logger.Printf("closing file '%s' output", f.fileName())
+	if f.pf != nil {
+		return f.pf.close()
+	}
+	if f.rw != nil {
+		if err := f.rw.Close(); err != nil {
+			f.logger.Printf("failed to flush file '%s': %v", f.fileName(), err)
+		}
+	}
 	return f.file.Close()
 }
 
@@ -0,0 +1,125 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/openconfig/gnmic/outputs"
+)
+
+const defaultPartitionMaxOpenFiles = 16
+
+// partitionedFile writes messages to a set of files,
+// one per time partition. The partition of a message is derived
+// from its timestamp, so that replayed messages land in the partition
+// they belong to rather than the one of the current time.
+type partitionedFile struct {
+	m       *sync.Mutex
+	dir     string
+	base    string
+	by      string
+	layout  string
+	maxOpen int
+	files   map[string]*partitionFile
+}
+
+type partitionFile struct {
+	f        *os.File
+	lastUsed time.Time
+}
+
+func newPartitionedFile(fileName, by, layout string, maxOpen int) (*partitionedFile, error) {
+	if fileName == "" {
+		return nil, errors.New("partitioning requires a filename")
+	}
+	layout, err := outputs.PartitionLayout(by, layout)
+	if err != nil {
+		return nil, err
+	}
+	if maxOpen <= 0 {
+		maxOpen = defaultPartitionMaxOpenFiles
+	}
+	return &partitionedFile{
+		m:       new(sync.Mutex),
+		dir:     filepath.Dir(fileName),
+		base:    filepath.Base(fileName),
+		by:      by,
+		layout:  layout,
+		maxOpen: maxOpen,
+		files:   make(map[string]*partitionFile),
+	}, nil
+}
+
+// write writes b to the file of the partition ts belongs to.
+// If ts is the zero time, the current time is used.
+func (p *partitionedFile) write(ts time.Time, b []byte) (int, error) {
+	now := time.Now()
+	if ts.IsZero() {
+		ts = now
+	}
+	key := outputs.PartitionKey(ts, p.by, p.layout)
+	p.m.Lock()
+	defer p.m.Unlock()
+	pf, ok := p.files[key]
+	if !ok {
+		f, err := p.open(key)
+		if err != nil {
+			return 0, err
+		}
+		pf = &partitionFile{f: f}
+		p.files[key] = pf
+	}
+	pf.lastUsed = now
+	return pf.f.Write(b)
+}
+
+// open opens (or creates) the file of partition key,
+// closing the least recently used file if the maximum number
+// of open files is reached.
+// it assumes the lock is acquired.
+func (p *partitionedFile) open(key string) (*os.File, error) {
+	if len(p.files) >= p.maxOpen {
+		var lruKey string
+		var lru *partitionFile
+		for k, pf := range p.files {
+			if lru == nil || pf.lastUsed.Before(lru.lastUsed) {
+				lruKey = k
+				lru = pf
+			}
+		}
+		if lru != nil {
+			lru.f.Close()
+			delete(p.files, lruKey)
+		}
+	}
+	dir := filepath.Join(p.dir, key)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(dir, p.base), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+}
+
+func (p *partitionedFile) close() error {
+	p.m.Lock()
+	defer p.m.Unlock()
+	var err error
+	for k, pf := range p.files {
+		if cerr := pf.f.Close(); cerr != nil {
+			err = cerr
+		}
+		delete(p.files, k)
+	}
+	return err
+}
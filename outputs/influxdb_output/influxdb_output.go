@@ -16,6 +16,7 @@ import (
 	"io"
 	"log"
 	"math"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -40,6 +41,10 @@ const (
 
 	numWorkers    = 1
 	loggingPrefix = "[influxdb_output:%s] "
+
+	// deletes modes
+	deletesIgnore = "ignore"
+	deletesDelete = "delete"
 )
 
 func init() {
@@ -66,7 +71,8 @@ type InfluxDBOutput struct {
 	evps      []formatters.EventProcessor
 	dbVersion string
 
-	targetTpl *template.Template
+	targetTpl  *template.Template
+	fieldTypes []*fieldType
 
 	gnmiCache   cache.Cache
 	cacheTicker *time.Ticker
@@ -90,6 +96,11 @@ type Config struct {
 	OverrideTimestamps bool          `mapstructure:"override-timestamps,omitempty"`
 	CacheConfig        *cache.Config `mapstructure:"cache,omitempty"`
 	CacheFlushTimer    time.Duration `mapstructure:"cache-flush-timer,omitempty"`
+	Deletes            string        `mapstructure:"deletes,omitempty"`
+	// ordered measurement routing rules
+	Measurements []*measurementRule `mapstructure:"measurements,omitempty"`
+	// field name or regex to field type, one of int, float, string, bool
+	FieldTypes map[string]string `mapstructure:"field-types,omitempty"`
 }
 
 func (k *InfluxDBOutput) String() string {
@@ -161,6 +172,17 @@ func (i *InfluxDBOutput) Init(ctx context.Context, name string, cfg map[string]i
 	if i.Cfg.HealthCheckPeriod == 0 {
 		i.Cfg.HealthCheckPeriod = defaultHealthCheckPeriod
 	}
+	switch i.Cfg.Deletes {
+	case "", deletesIgnore:
+		i.Cfg.Deletes = deletesIgnore
+	case deletesDelete:
+	default:
+		return fmt.Errorf("unknown deletes mode %q, must be one of %q", i.Cfg.Deletes, []string{deletesIgnore, deletesDelete})
+	}
+	err = i.initRules()
+	if err != nil {
+		return err
+	}
 	if i.Cfg.CacheConfig != nil {
 		if i.Cfg.CacheFlushTimer == 0 {
 			i.Cfg.CacheFlushTimer = defaultCacheFlushTimer
@@ -235,6 +257,9 @@ func (i *InfluxDBOutput) Write(ctx context.Context, rsp proto.Message, meta outp
 			i.gnmiCache.Write(ctx, measName, rsp)
 			return
 		}
+		if i.Cfg.Deletes == deletesDelete {
+			i.deleteSeries(ctx, formatters.NotificationDeletes(measName, rsp.GetUpdate(), meta))
+		}
 		events, err := formatters.ResponseToEventMsgs(measName, rsp, meta, i.evps...)
 		if err != nil {
 			i.logger.Printf("failed to convert message to event: %v", err)
@@ -361,7 +386,10 @@ START:
 				ev.Timestamp = time.Now().UnixNano()
 			}
 			i.convertUints(ev)
-			writer.WritePoint(influxdb2.NewPoint(ev.Name, ev.Tags, ev.Values, time.Unix(0, ev.Timestamp)))
+			i.enforceFieldTypes(ev)
+			for _, rev := range i.routeEvent(ev) {
+				writer.WritePoint(influxdb2.NewPoint(rev.Name, rev.Tags, rev.Values, time.Unix(0, rev.Timestamp)))
+			}
 		case <-i.reset:
 			firstStart = false
 			i.logger.Printf("resetting worker-%d...", idx)
@@ -372,6 +400,70 @@ START:
 	}
 }
 
+// deleteSeries deletes the series of the deleted list entries,
+// InfluxDB does not support deleting a single field so other deletes are ignored.
+func (i *InfluxDBOutput) deleteSeries(ctx context.Context, dels []*formatters.DeleteMsg) {
+	for _, d := range dels {
+		if !d.ListEntry {
+			continue
+		}
+		stop := time.Now()
+		if d.Timestamp > 0 && !i.Cfg.OverrideTimestamps {
+			stop = time.Unix(0, d.Timestamp)
+		}
+		predicate := deletePredicate(d)
+		err := i.client.DeleteAPI().DeleteWithName(ctx, i.Cfg.Org, i.Cfg.Bucket, time.Unix(0, 0), stop, predicate)
+		if err != nil {
+			i.logger.Printf("failed to delete series %q: %v", predicate, err)
+			continue
+		}
+		if i.Cfg.Debug {
+			i.logger.Printf("deleted series %q", predicate)
+		}
+	}
+}
+
+// deletePredicate returns the delete predicate selecting
+// the series with the measurement and tags of d.
+func deletePredicate(d *formatters.DeleteMsg) string {
+	keys := make([]string, 0, len(d.Tags))
+	for k := range d.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sb := new(strings.Builder)
+	sb.WriteString("_measurement=")
+	sb.WriteString(quotePredicate(d.Name))
+	for _, k := range keys {
+		sb.WriteString(" AND ")
+		if isPredicateIdent(k) {
+			sb.WriteString(k)
+		} else {
+			sb.WriteString(quotePredicate(k))
+		}
+		sb.WriteString("=")
+		sb.WriteString(quotePredicate(d.Tags[k]))
+	}
+	return sb.String()
+}
+
+func quotePredicate(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func isPredicateIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
 func (i *InfluxDBOutput) SetName(name string)                             {}
 func (i *InfluxDBOutput) SetClusterName(name string)                      {}
 func (i *InfluxDBOutput) SetTargetsConfig(map[string]*types.TargetConfig) {}
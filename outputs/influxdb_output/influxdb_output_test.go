@@ -0,0 +1,73 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package influxdb_output
+
+import (
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/utils"
+)
+
+func TestDeletePredicate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		d    *formatters.DeleteMsg
+		want string
+	}{
+		{
+			name: "measurement only",
+			d:    &formatters.DeleteMsg{Name: "sub1"},
+			want: `_measurement="sub1"`,
+		},
+		{
+			name: "sorted tags",
+			d: &formatters.DeleteMsg{
+				Name: "sub1",
+				Tags: map[string]string{"source": "router1:57400", "interface_name": "ethernet-1/1"},
+			},
+			want: `_measurement="sub1" AND interface_name="ethernet-1/1" AND source="router1:57400"`,
+		},
+		{
+			name: "quoted keys and values",
+			d: &formatters.DeleteMsg{
+				Name: `sub "1"`,
+				Tags: map[string]string{"subinterface-index": `a\b`, "1st": "x"},
+			},
+			want: `_measurement="sub \"1\"" AND "1st"="x" AND "subinterface-index"="a\\b"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := deletePredicate(tc.d); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDeletePredicateFromNotification(t *testing.T) {
+	prefix, err := utils.ParsePath("/interface[name=ethernet-1/1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := utils.ParsePath("/subinterface[index=0]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dels := formatters.NotificationDeletes("sub1", &gnmi.Notification{Prefix: prefix, Delete: []*gnmi.Path{p}},
+		map[string]string{"source": "router1"})
+	if len(dels) != 1 || !dels[0].ListEntry {
+		t.Fatalf("expected a deleted list entry, got %+v", dels)
+	}
+	want := `_measurement="sub1" AND interface_name="ethernet-1/1" AND source="router1" AND subinterface_index="0"`
+	if got := deletePredicate(dels[0]); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
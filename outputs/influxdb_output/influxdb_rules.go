@@ -0,0 +1,310 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package influxdb_output
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/openconfig/gnmic/formatters"
+)
+
+const (
+	fieldTypeInt    = "int"
+	fieldTypeFloat  = "float"
+	fieldTypeString = "string"
+	fieldTypeBool   = "bool"
+)
+
+// measurementRule routes the values of an event to the measurement Name.
+// A value matches if its name matches one of the Paths regular expressions (all values if Paths is empty)
+// and the event tags match all of the Tags regular expressions.
+type measurementRule struct {
+	Name  string            `mapstructure:"name,omitempty" json:"name,omitempty"`
+	Paths []string          `mapstructure:"paths,omitempty" json:"paths,omitempty"`
+	Tags  map[string]string `mapstructure:"tags,omitempty" json:"tags,omitempty"`
+
+	paths []*regexp.Regexp
+	tags  map[string]*regexp.Regexp
+}
+
+func (r *measurementRule) init() error {
+	if r.Name == "" {
+		return fmt.Errorf("missing measurement name")
+	}
+	r.paths = make([]*regexp.Regexp, 0, len(r.Paths))
+	for _, p := range r.Paths {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("measurement %q: invalid path regex %q: %v", r.Name, p, err)
+		}
+		r.paths = append(r.paths, re)
+	}
+	r.tags = make(map[string]*regexp.Regexp, len(r.Tags))
+	for k, v := range r.Tags {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return fmt.Errorf("measurement %q: invalid tag %q regex %q: %v", r.Name, k, v, err)
+		}
+		r.tags[k] = re
+	}
+	return nil
+}
+
+func (r *measurementRule) matchTags(tags map[string]string) bool {
+	for k, re := range r.tags {
+		v, ok := tags[k]
+		if !ok || !re.MatchString(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *measurementRule) matchPath(name string) bool {
+	if len(r.paths) == 0 {
+		return true
+	}
+	for _, re := range r.paths {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldType enforces the type of the fields with a name matching re.
+type fieldType struct {
+	re  *regexp.Regexp
+	typ string
+}
+
+func (i *InfluxDBOutput) initRules() error {
+	for idx, r := range i.Cfg.Measurements {
+		err := r.init()
+		if err != nil {
+			return fmt.Errorf("measurements index %d: %v", idx, err)
+		}
+	}
+	// sorted for a deterministic match order
+	names := make([]string, 0, len(i.Cfg.FieldTypes))
+	for k := range i.Cfg.FieldTypes {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	i.fieldTypes = make([]*fieldType, 0, len(names))
+	for _, k := range names {
+		typ := i.Cfg.FieldTypes[k]
+		switch typ {
+		case fieldTypeInt, fieldTypeFloat, fieldTypeString, fieldTypeBool:
+		default:
+			return fmt.Errorf("field-types %q: unknown type %q, must be one of %q",
+				k, typ, []string{fieldTypeInt, fieldTypeFloat, fieldTypeString, fieldTypeBool})
+		}
+		re, err := regexp.Compile(k)
+		if err != nil {
+			return fmt.Errorf("field-types %q: invalid regex: %v", k, err)
+		}
+		i.fieldTypes = append(i.fieldTypes, &fieldType{re: re, typ: typ})
+	}
+	return nil
+}
+
+// routeEvent splits the event ev into one event per measurement,
+// following the measurement rules order. The values not matching any rule keep the event name.
+func (i *InfluxDBOutput) routeEvent(ev *formatters.EventMsg) []*formatters.EventMsg {
+	if len(i.Cfg.Measurements) == 0 {
+		return []*formatters.EventMsg{ev}
+	}
+	rest := make(map[string]interface{}, len(ev.Values))
+	for k, v := range ev.Values {
+		rest[k] = v
+	}
+	evs := make([]*formatters.EventMsg, 0, 1)
+	for _, r := range i.Cfg.Measurements {
+		if len(rest) == 0 {
+			break
+		}
+		if !r.matchTags(ev.Tags) {
+			continue
+		}
+		matched := make(map[string]interface{})
+		for k, v := range rest {
+			if r.matchPath(k) {
+				matched[k] = v
+				delete(rest, k)
+			}
+		}
+		if len(matched) > 0 {
+			evs = append(evs, &formatters.EventMsg{
+				Name:      r.Name,
+				Timestamp: ev.Timestamp,
+				Tags:      ev.Tags,
+				Values:    matched,
+			})
+		}
+	}
+	if len(rest) > 0 {
+		evs = append(evs, &formatters.EventMsg{
+			Name:      ev.Name,
+			Timestamp: ev.Timestamp,
+			Tags:      ev.Tags,
+			Values:    rest,
+		})
+	}
+	return evs
+}
+
+// enforceFieldTypes converts the values of ev to the type configured for their name,
+// the values that cannot be converted are removed.
+func (i *InfluxDBOutput) enforceFieldTypes(ev *formatters.EventMsg) {
+	if len(i.fieldTypes) == 0 {
+		return
+	}
+	for k, v := range ev.Values {
+		typ := i.fieldTypeOf(k)
+		if typ == "" {
+			continue
+		}
+		nv, err := convertFieldType(v, typ)
+		if err != nil {
+			if i.Cfg.Debug {
+				i.logger.Printf("dropping field %q of measurement %q: %v", k, ev.Name, err)
+			}
+			delete(ev.Values, k)
+			continue
+		}
+		ev.Values[k] = nv
+	}
+}
+
+func (i *InfluxDBOutput) fieldTypeOf(name string) string {
+	// an exact name takes precedence over regular expressions
+	if typ, ok := i.Cfg.FieldTypes[name]; ok {
+		return typ
+	}
+	for _, ft := range i.fieldTypes {
+		if ft.re.MatchString(name) {
+			return ft.typ
+		}
+	}
+	return ""
+}
+
+func convertFieldType(v interface{}, typ string) (interface{}, error) {
+	switch typ {
+	case fieldTypeString:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(v), nil
+	case fieldTypeBool:
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+		f, err := toFloat(v)
+		if err != nil {
+			return nil, err
+		}
+		return f != 0, nil
+	case fieldTypeFloat:
+		return toFloat(v)
+	case fieldTypeInt:
+		switch v := v.(type) {
+		case int:
+			return int64(v), nil
+		case int8:
+			return int64(v), nil
+		case int16:
+			return int64(v), nil
+		case int32:
+			return int64(v), nil
+		case int64:
+			return v, nil
+		case uint8:
+			return int64(v), nil
+		case uint16:
+			return int64(v), nil
+		case uint32:
+			return int64(v), nil
+		case uint, uint64:
+			u := toUint64(v)
+			if u > math.MaxInt64 {
+				return nil, fmt.Errorf("value %d overflows int64", u)
+			}
+			return int64(u), nil
+		case string:
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return n, nil
+			}
+		}
+		f, err := toFloat(v)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(f) || f > math.MaxInt64 || f < math.MinInt64 {
+			return nil, fmt.Errorf("value %v overflows int64", f)
+		}
+		return int64(f), nil
+	}
+	return nil, fmt.Errorf("unknown type %q", typ)
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("cannot convert %T to a number", v)
+}
+
+func toUint64(v interface{}) uint64 {
+	switch v := v.(type) {
+	case uint:
+		return uint64(v)
+	case uint64:
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,128 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package influxdb_output
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openconfig/gnmic/formatters"
+)
+
+func TestRouteEvent(t *testing.T) {
+	i := &InfluxDBOutput{Cfg: &Config{
+		Measurements: []*measurementRule{
+			{Name: "counters", Paths: []string{"/statistics/"}},
+			{Name: "srl", Tags: map[string]string{"source": "^srl"}},
+		},
+	}}
+	err := i.initRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		ev   *formatters.EventMsg
+		want map[string]map[string]interface{}
+	}{
+		{
+			name: "path_and_tags",
+			ev: &formatters.EventMsg{
+				Name: "sub1",
+				Tags: map[string]string{"source": "srl1"},
+				Values: map[string]interface{}{
+					"/interface/statistics/in-octets": 1,
+					"/interface/oper-state":           "up",
+				},
+			},
+			want: map[string]map[string]interface{}{
+				"counters": {"/interface/statistics/in-octets": 1},
+				"srl":      {"/interface/oper-state": "up"},
+			},
+		},
+		{
+			name: "leftovers",
+			ev: &formatters.EventMsg{
+				Name: "sub1",
+				Tags: map[string]string{"source": "router1"},
+				Values: map[string]interface{}{
+					"/interface/statistics/in-octets": 1,
+					"/interface/oper-state":           "up",
+				},
+			},
+			want: map[string]map[string]interface{}{
+				"counters": {"/interface/statistics/in-octets": 1},
+				"sub1":     {"/interface/oper-state": "up"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]map[string]interface{})
+			for _, ev := range i.routeEvent(tt.ev) {
+				got[ev.Name] = ev.Values
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnforceFieldTypes(t *testing.T) {
+	i := &InfluxDBOutput{Cfg: &Config{
+		FieldTypes: map[string]string{
+			"count":    "int",
+			"^rate":    "float",
+			"state":    "string",
+			"enabled":  "bool",
+			"^counter": "int",
+		},
+	}}
+	err := i.initRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := &formatters.EventMsg{
+		Values: map[string]interface{}{
+			"count":    "42",
+			"rate_in":  int64(3),
+			"state":    1,
+			"enabled":  "true",
+			"counter1": "not-a-number",
+			"other":    uint64(1),
+		},
+	}
+	i.enforceFieldTypes(ev)
+	want := map[string]interface{}{
+		"count":   int64(42),
+		"rate_in": float64(3),
+		"state":   "1",
+		"enabled": true,
+		"other":   uint64(1),
+	}
+	if !reflect.DeepEqual(ev.Values, want) {
+		t.Errorf("got %v, want %v", ev.Values, want)
+	}
+}
+
+func TestInitRulesErrors(t *testing.T) {
+	cfgs := []*Config{
+		{FieldTypes: map[string]string{"a": "uint"}},
+		{FieldTypes: map[string]string{"(": "int"}},
+		{Measurements: []*measurementRule{{Paths: []string{"a"}}}},
+		{Measurements: []*measurementRule{{Name: "m", Paths: []string{"("}}}},
+	}
+	for idx, cfg := range cfgs {
+		i := &InfluxDBOutput{Cfg: cfg}
+		if err := i.initRules(); err == nil {
+			t.Errorf("config %d: expected an error", idx)
+		}
+	}
+}
@@ -23,11 +23,14 @@ import (
 	"github.com/Shopify/sarama"
 	"github.com/damiannolan/sasl/oauthbearer"
 	"github.com/google/uuid"
+	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmic/formatters"
 	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/tracing"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -40,6 +43,13 @@ const (
 	defaultRecoveryWaitTime = 10 * time.Second
 	defaultAddress          = "localhost:9092"
 	loggingPrefix           = "[kafka_output:%s] "
+
+	// deletes modes
+	deletesInclude   = "include"
+	deletesTombstone = "tombstone"
+
+	// key of the data and tombstone messages with deletes: tombstone
+	defaultKeyTemplate = `{{ index . "source" }}:{{ index . "path" }}`
 )
 
 func init() {
@@ -62,8 +72,10 @@ type KafkaOutput struct {
 	wg       *sync.WaitGroup
 	evps     []formatters.EventProcessor
 
-	targetTpl *template.Template
-	msgTpl    *template.Template
+	targetTpl   *template.Template
+	msgTpl      *template.Template
+	keyTpl      *template.Template
+	sizeLimiter *outputs.SizeLimiter
 }
 
 // Config //
@@ -84,8 +96,15 @@ type Config struct {
 	Debug              bool          `mapstructure:"debug,omitempty"`
 	BufferSize         int           `mapstructure:"buffer-size,omitempty"`
 	OverrideTimestamps bool          `mapstructure:"override-timestamps,omitempty"`
+	EventVersion       int           `mapstructure:"event-version,omitempty"`
+	Deletes            string        `mapstructure:"deletes,omitempty"`
+	KeyTemplate        string        `mapstructure:"key-template,omitempty"`
 	EnableMetrics      bool          `mapstructure:"enable-metrics,omitempty"`
 	EventProcessors    []string      `mapstructure:"event-processors,omitempty"`
+	MaxMessageSize     int           `mapstructure:"max-message-size,omitempty"`
+	OversizePolicy     string        `mapstructure:"oversize-policy,omitempty"`
+	TruncateMarker     string        `mapstructure:"truncate-marker,omitempty"`
+	DLQTopic           string        `mapstructure:"dlq-topic,omitempty"`
 }
 type sasl struct {
 	User      string `mapstructure:"user,omitempty"`
@@ -167,8 +186,9 @@ func (k *KafkaOutput) Init(ctx context.Context, name string, cfg map[string]inte
 	}
 	k.msgChan = make(chan *outputs.ProtoMsg, uint(k.Cfg.BufferSize))
 	k.mo = &formatters.MarshalOptions{
-		Format:     k.Cfg.Format,
-		OverrideTS: k.Cfg.OverrideTimestamps,
+		Format:       k.Cfg.Format,
+		OverrideTS:   k.Cfg.OverrideTimestamps,
+		EventVersion: k.Cfg.EventVersion,
 	}
 
 	if k.Cfg.TargetTemplate == "" {
@@ -189,6 +209,23 @@ func (k *KafkaOutput) Init(ctx context.Context, name string, cfg map[string]inte
 		k.msgTpl = k.msgTpl.Funcs(outputs.TemplateFuncs)
 	}
 
+	keyTemplate := k.Cfg.KeyTemplate
+	if keyTemplate == "" && k.Cfg.Deletes == deletesTombstone {
+		keyTemplate = defaultKeyTemplate
+	}
+	if keyTemplate != "" {
+		k.keyTpl, err = utils.CreateTemplate("key-template", keyTemplate)
+		if err != nil {
+			return err
+		}
+		k.keyTpl = k.keyTpl.Funcs(outputs.TemplateFuncs)
+	}
+
+	k.sizeLimiter, err = outputs.NewSizeLimiter(k.Cfg.MaxMessageSize, k.Cfg.OversizePolicy, k.Cfg.TruncateMarker)
+	if err != nil {
+		return err
+	}
+
 	config, err := k.createConfig()
 	if err != nil {
 		return err
@@ -211,7 +248,7 @@ func (k *KafkaOutput) setDefaults() error {
 	if k.Cfg.Format == "" {
 		k.Cfg.Format = defaultFormat
 	}
-	if !(k.Cfg.Format == "event" || k.Cfg.Format == "protojson" || k.Cfg.Format == "prototext" || k.Cfg.Format == "proto" || k.Cfg.Format == "json") {
+	if !(k.Cfg.Format == "event" || k.Cfg.Format == "protojson" || k.Cfg.Format == "prototext" || k.Cfg.Format == "proto" || k.Cfg.Format == formatters.FormatProtoEnvelope || k.Cfg.Format == "json") {
 		return fmt.Errorf("unsupported output format '%s' for output type kafka", k.Cfg.Format)
 	}
 	if k.Cfg.Address == "" {
@@ -247,6 +284,19 @@ func (k *KafkaOutput) setDefaults() error {
 			return errors.New("missing token-url for kafka SASL mechanism OAUTHBEARER")
 		}
 	}
+	switch k.Cfg.Deletes {
+	case "", deletesInclude:
+		k.Cfg.Deletes = deletesInclude
+	case deletesTombstone:
+	default:
+		return fmt.Errorf("unknown deletes mode %q, must be one of %q", k.Cfg.Deletes, []string{deletesInclude, deletesTombstone})
+	}
+	if k.Cfg.EventVersion == 0 {
+		k.Cfg.EventVersion = formatters.EventVersion2
+	}
+	if err := formatters.ValidateEventVersion(k.Cfg.EventVersion); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -259,11 +309,14 @@ func (k *KafkaOutput) Write(ctx context.Context, rsp proto.Message, meta outputs
 	wctx, cancel := context.WithTimeout(ctx, k.Cfg.Timeout)
 	defer cancel()
 
+	pm := outputs.NewProtoMsg(rsp, meta).WithSpanContext(ctx).Account()
 	select {
 	case <-ctx.Done():
+		pm.Release()
 		return
-	case k.msgChan <- outputs.NewProtoMsg(rsp, meta):
+	case k.msgChan <- pm:
 	case <-wctx.Done():
+		pm.Release()
 		if k.Cfg.Debug {
 			k.logger.Printf("writing expired after %s, Kafka output might not be initialized", k.Cfg.Timeout)
 		}
@@ -314,11 +367,15 @@ CRPROD:
 			k.logger.Printf("%s shutting down", workerLogPrefix)
 			return
 		case m := <-k.msgChan:
+			m.Release()
 			pmsg := m.GetMsg()
 			pmsg, err = outputs.AddSubscriptionTarget(pmsg, m.GetMeta(), k.Cfg.AddTarget, k.targetTpl)
 			if err != nil {
 				k.logger.Printf("failed to add target to the response: %v", err)
 			}
+			mctx := m.Context(ctx)
+			_, pspan := tracing.StartSpan(mctx, "output.process",
+				attribute.String("output", k.Cfg.Name))
 			b, err := k.mo.Marshal(pmsg, m.GetMeta(), k.evps...)
 			if err != nil {
 				if k.Cfg.Debug {
@@ -327,6 +384,12 @@ CRPROD:
 				if k.Cfg.EnableMetrics {
 					kafkaNumberOfFailSendMsgs.WithLabelValues(config.ClientID, "marshal_error").Inc()
 				}
+				tracing.SetError(pspan, err)
+				pspan.End()
+				continue
+			}
+			if len(b) == 0 {
+				pspan.End()
 				continue
 			}
 
@@ -337,40 +400,230 @@ CRPROD:
 						log.Printf("failed to execute template: %v", err)
 					}
 					kafkaNumberOfFailSendMsgs.WithLabelValues(config.ClientID, "template_error").Inc()
+					tracing.SetError(pspan, err)
+					pspan.End()
 					return
 				}
 			}
+			pspan.End()
 
-			msg := &sarama.ProducerMessage{
-				Topic: k.Cfg.Topic,
-				Value: sarama.ByteEncoder(b),
+			key, err := k.messageKey(m.GetMeta(), responsePath(pmsg))
+			if err != nil {
+				k.logger.Printf("%s failed to execute key template: %v", workerLogPrefix, err)
 			}
-
-			var start time.Time
-			if k.Cfg.EnableMetrics {
-				start = time.Now()
+			msgs := k.producerMessages(workerLogPrefix, config.ClientID, key, b)
+			if len(msgs) > 0 {
+				err = k.send(mctx, producer, config.ClientID, msgs)
+				if err != nil {
+					if k.Cfg.Debug {
+						k.logger.Printf("%s failed to send a kafka msg to topic '%s': %v", workerLogPrefix, k.Cfg.Topic, err)
+					}
+					if k.Cfg.EnableMetrics {
+						kafkaNumberOfFailSendMsgs.WithLabelValues(config.ClientID, "send_error").Inc()
+					}
+					producer.Close()
+					time.Sleep(k.Cfg.RecoveryWaitTime)
+					goto CRPROD
+				}
 			}
-			_, _, err = producer.SendMessage(msg)
-			if err != nil {
-				if k.Cfg.Debug {
-					k.logger.Printf("%s failed to send a kafka msg to topic '%s': %v", workerLogPrefix, k.Cfg.Topic, err)
+			if k.Cfg.Deletes == deletesTombstone {
+				tombstones := k.tombstones(workerLogPrefix, pmsg, m.GetMeta())
+				if len(tombstones) == 0 {
+					continue
 				}
-				if k.Cfg.EnableMetrics {
-					kafkaNumberOfFailSendMsgs.WithLabelValues(config.ClientID, "send_error").Inc()
+				err = producer.SendMessages(tombstones)
+				if err != nil {
+					if k.Cfg.Debug {
+						k.logger.Printf("%s failed to send tombstones to topic '%s': %v", workerLogPrefix, k.Cfg.Topic, err)
+					}
+					if k.Cfg.EnableMetrics {
+						kafkaNumberOfFailSendMsgs.WithLabelValues(config.ClientID, "send_error").Inc()
+					}
 				}
-				producer.Close()
-				time.Sleep(k.Cfg.RecoveryWaitTime)
-				goto CRPROD
-			}
-			if k.Cfg.EnableMetrics {
-				kafkaSendDuration.WithLabelValues(config.ClientID).Set(float64(time.Since(start).Nanoseconds()))
-				kafkaNumberOfSentMsgs.WithLabelValues(config.ClientID).Inc()
-				kafkaNumberOfSentBytes.WithLabelValues(config.ClientID).Add(float64(len(b)))
 			}
 		}
 	}
 }
 
+// producerMessages returns the messages to send for the marshaled message b, keyed by key.
+// The messages larger than max-message-size are split or truncated according to the oversize-policy,
+// the ones that still do not fit are sent to the dlq-topic if set, or dropped.
+func (k *KafkaOutput) producerMessages(workerLogPrefix, clientID string, key sarama.Encoder, b []byte) []*sarama.ProducerMessage {
+	fit, oversized := k.sizeLimiter.Limit(b)
+	msgs := make([]*sarama.ProducerMessage, 0, len(fit)+len(oversized))
+	for _, fb := range fit {
+		msgs = append(msgs, &sarama.ProducerMessage{
+			Topic: k.Cfg.Topic,
+			Key:   key,
+			Value: sarama.ByteEncoder(fb),
+		})
+	}
+	for _, ob := range oversized {
+		if k.Cfg.DLQTopic != "" {
+			msgs = append(msgs, &sarama.ProducerMessage{
+				Topic: k.Cfg.DLQTopic,
+				Key:   key,
+				Value: sarama.ByteEncoder(ob),
+			})
+			continue
+		}
+		k.logger.Printf("%s dropping a %d bytes message exceeding max-message-size %d", workerLogPrefix, len(ob), k.Cfg.MaxMessageSize)
+		if k.Cfg.EnableMetrics {
+			kafkaNumberOfFailSendMsgs.WithLabelValues(clientID, "oversized").Inc()
+		}
+	}
+	return msgs
+}
+
+func (k *KafkaOutput) send(ctx context.Context, producer sarama.SyncProducer, clientID string, msgs []*sarama.ProducerMessage) error {
+	sctx, sspan := tracing.StartSpan(ctx, "kafka.send",
+		attribute.String("output", k.Cfg.Name),
+		attribute.String("topic", k.Cfg.Topic))
+	defer sspan.End()
+	for _, msg := range msgs {
+		tracing.Inject(sctx, headersCarrier{msg: msg})
+	}
+
+	var start time.Time
+	if k.Cfg.EnableMetrics {
+		start = time.Now()
+	}
+	var err error
+	if len(msgs) == 1 {
+		_, _, err = producer.SendMessage(msgs[0])
+	} else {
+		err = producer.SendMessages(msgs)
+	}
+	tracing.SetError(sspan, err)
+	if err != nil {
+		return err
+	}
+	if k.Cfg.EnableMetrics {
+		kafkaSendDuration.WithLabelValues(clientID).Set(float64(time.Since(start).Nanoseconds()))
+		kafkaNumberOfSentMsgs.WithLabelValues(clientID).Add(float64(len(msgs)))
+		for _, msg := range msgs {
+			kafkaNumberOfSentBytes.WithLabelValues(clientID).Add(float64(msg.Value.Length()))
+		}
+	}
+	return nil
+}
+
+// tombstones returns a message with a null value for each path deleted in msg,
+// keyed like the data messages of that path.
+func (k *KafkaOutput) tombstones(workerLogPrefix string, msg proto.Message, meta outputs.Meta) []*sarama.ProducerMessage {
+	rsp, ok := msg.(*gnmi.SubscribeResponse)
+	if !ok {
+		return nil
+	}
+	dels := formatters.NotificationDeletes(meta["subscription-name"], rsp.GetUpdate(), meta)
+	if len(dels) == 0 {
+		return nil
+	}
+	msgs := make([]*sarama.ProducerMessage, 0, len(dels))
+	for _, d := range dels {
+		key, err := k.messageKey(meta, d.XPath)
+		if err != nil {
+			k.logger.Printf("%s failed to execute key template: %v", workerLogPrefix, err)
+			continue
+		}
+		msgs = append(msgs, &sarama.ProducerMessage{
+			Topic: k.Cfg.Topic,
+			Key:   key,
+		})
+	}
+	return msgs
+}
+
+// messageKey returns the key of a message about path, executing the key template
+// with the message meta and the path under the "path" key.
+// It returns nil if no key template is set.
+func (k *KafkaOutput) messageKey(meta outputs.Meta, path string) (sarama.Encoder, error) {
+	if k.keyTpl == nil {
+		return nil, nil
+	}
+	input := make(map[string]string, len(meta)+1)
+	for mk, mv := range meta {
+		input[mk] = mv
+	}
+	input["path"] = path
+	sb := new(strings.Builder)
+	err := k.keyTpl.Execute(sb, input)
+	if err != nil {
+		return nil, err
+	}
+	return sarama.StringEncoder(sb.String()), nil
+}
+
+// responsePath returns the xpath, with keys, of the notification of msg:
+// the longest path common to its updates and deletes, including the prefix.
+// It is the path of the update or delete if the notification has a single one.
+func responsePath(msg proto.Message) string {
+	rsp, ok := msg.(*gnmi.SubscribeResponse)
+	if !ok || rsp.GetUpdate() == nil {
+		return ""
+	}
+	n := rsp.GetUpdate()
+	paths := make([]*gnmi.Path, 0, len(n.GetUpdate())+len(n.GetDelete()))
+	for _, upd := range n.GetUpdate() {
+		paths = append(paths, upd.GetPath())
+	}
+	paths = append(paths, n.GetDelete()...)
+	origin := n.GetPrefix().GetOrigin()
+	var common []*gnmi.PathElem
+	for i, p := range paths {
+		if origin == "" {
+			origin = p.GetOrigin()
+		}
+		elems := p.GetElem()
+		if i == 0 {
+			common = elems
+			continue
+		}
+		l := 0
+		for l < len(common) && l < len(elems) && proto.Equal(common[l], elems[l]) {
+			l++
+		}
+		common = common[:l]
+	}
+	return utils.GnmiPathToXPath(&gnmi.Path{Origin: origin, Elem: utils.PathElems(n.GetPrefix(), &gnmi.Path{Elem: common})}, false)
+}
+
+// headersCarrier propagates the trace context in the Kafka message headers.
+type headersCarrier struct {
+	msg *sarama.ProducerMessage
+}
+
+func (c headersCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c headersCarrier) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c headersCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, string(h.Key))
+	}
+	return keys
+}
+
+func (k *KafkaOutput) BufferSize() (int, int) {
+	return len(k.msgChan), cap(k.msgChan)
+}
+
 func (k *KafkaOutput) SetName(name string) {
 	sb := strings.Builder{}
 	if name != "" {
@@ -389,6 +642,9 @@ func (k *KafkaOutput) SetTargetsConfig(map[string]*types.TargetConfig) {}
 func (k *KafkaOutput) createConfig() (*sarama.Config, error) {
 	cfg := sarama.NewConfig()
 	cfg.ClientID = k.Cfg.Name
+	if k.Cfg.MaxMessageSize > cfg.Producer.MaxMessageBytes {
+		cfg.Producer.MaxMessageBytes = k.Cfg.MaxMessageSize
+	}
 	// SASL_PLAINTEXT or SASL_SSL
 	if k.Cfg.SASL != nil {
 		cfg.Net.SASL.Enable = true
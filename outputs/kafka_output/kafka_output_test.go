@@ -0,0 +1,162 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka_output
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/utils"
+)
+
+func testKafkaOutput(t *testing.T, keyTemplate string) *KafkaOutput {
+	t.Helper()
+	k := &KafkaOutput{Cfg: &Config{Topic: "telemetry"}}
+	tpl, err := utils.CreateTemplate("key-template", keyTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.keyTpl = tpl.Funcs(outputs.TemplateFuncs)
+	return k
+}
+
+func mustPath(t *testing.T, p string) *gnmi.Path {
+	t.Helper()
+	gp, err := utils.ParsePath(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gp
+}
+
+func encodedKey(t *testing.T, e sarama.Encoder) string {
+	t.Helper()
+	if e == nil {
+		return ""
+	}
+	b, err := e.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestResponsePath(t *testing.T) {
+	prefix := mustPath(t, "/interface[name=ethernet-1/1]")
+	for _, tc := range []struct {
+		name string
+		n    *gnmi.Notification
+		want string
+	}{
+		{
+			name: "single update",
+			n: &gnmi.Notification{
+				Prefix: prefix,
+				Update: []*gnmi.Update{{Path: mustPath(t, "/subinterface[index=0]/admin-state")}},
+			},
+			want: "interface[name=ethernet-1/1]/subinterface[index=0]/admin-state",
+		},
+		{
+			name: "common path",
+			n: &gnmi.Notification{
+				Prefix: prefix,
+				Update: []*gnmi.Update{
+					{Path: mustPath(t, "/subinterface[index=0]/admin-state")},
+					{Path: mustPath(t, "/subinterface[index=0]/description")},
+				},
+				Delete: []*gnmi.Path{mustPath(t, "/subinterface[index=0]/ipv4")},
+			},
+			want: "interface[name=ethernet-1/1]/subinterface[index=0]",
+		},
+		{
+			name: "different keys",
+			n: &gnmi.Notification{
+				Prefix: prefix,
+				Update: []*gnmi.Update{
+					{Path: mustPath(t, "/subinterface[index=0]/admin-state")},
+					{Path: mustPath(t, "/subinterface[index=1]/admin-state")},
+				},
+			},
+			want: "interface[name=ethernet-1/1]",
+		},
+		{
+			name: "origin",
+			n: &gnmi.Notification{
+				Prefix: &gnmi.Path{Origin: "native"},
+				Delete: []*gnmi.Path{mustPath(t, "/interface[name=1]")},
+			},
+			want: "native:interface[name=1]",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := responsePath(&gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: tc.n}})
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+	if got := responsePath(&gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_SyncResponse{}}); got != "" {
+		t.Errorf("expected an empty path for a sync response, got %q", got)
+	}
+}
+
+func TestTombstonesKey(t *testing.T) {
+	k := testKafkaOutput(t, defaultKeyTemplate)
+	meta := outputs.Meta{"source": "router1:57400", "subscription-name": "sub1"}
+	upd := &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{
+		Prefix: mustPath(t, "/interface[name=ethernet-1/1]"),
+		Update: []*gnmi.Update{{
+			Path: mustPath(t, "/subinterface[index=0]"),
+			Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonVal{JsonVal: []byte(`{"admin-state":"enable"}`)}},
+		}},
+	}}}
+	del := &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{
+		Prefix: mustPath(t, "/interface[name=ethernet-1/1]"),
+		Delete: []*gnmi.Path{mustPath(t, "/subinterface[index=0]")},
+	}}}
+
+	dataKey, err := k.messageKey(meta, responsePath(upd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := k.producerMessages("", "", dataKey, []byte("data"))
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 data message, got %d", len(msgs))
+	}
+	want := "router1:57400:interface[name=ethernet-1/1]/subinterface[index=0]"
+	if got := encodedKey(t, msgs[0].Key); got != want {
+		t.Errorf("expected the data message key %q, got %q", want, got)
+	}
+	tombstones := k.tombstones("", del, meta)
+	if len(tombstones) != 1 {
+		t.Fatalf("expected 1 tombstone, got %d", len(tombstones))
+	}
+	if got := encodedKey(t, tombstones[0].Key); got != want {
+		t.Errorf("expected the tombstone key %q, got %q", want, got)
+	}
+	if tombstones[0].Value != nil || tombstones[0].Topic != "telemetry" {
+		t.Errorf("unexpected tombstone %+v", tombstones[0])
+	}
+
+	// custom key template
+	k = testKafkaOutput(t, `{{ index . "subscription-name" }}/{{ index . "path" }}`)
+	tombstones = k.tombstones("", del, meta)
+	if got := encodedKey(t, tombstones[0].Key); got != "sub1/interface[name=ethernet-1/1]/subinterface[index=0]" {
+		t.Errorf("unexpected tombstone key %q", got)
+	}
+
+	// no key template, no key
+	k.keyTpl = nil
+	key, err := k.messageKey(meta, responsePath(upd))
+	if err != nil || key != nil {
+		t.Errorf("expected no key, got %v, err=%v", key, err)
+	}
+}
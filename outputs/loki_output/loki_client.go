@@ -0,0 +1,167 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loki_output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/openconfig/gnmic/utils"
+)
+
+var backoff = 100 * time.Millisecond
+
+// pushRequest is the body of a Loki push API request.
+type pushRequest struct {
+	Streams []*pushStream `json:"streams"`
+}
+
+type pushStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (o *lokiOutput) createHTTPClient() error {
+	c := &http.Client{
+		Timeout: o.Cfg.Timeout,
+	}
+	if o.Cfg.TLS != nil {
+		tlsCfg, err := utils.NewTLSConfig(
+			o.Cfg.TLS.CAFile,
+			o.Cfg.TLS.CertFile,
+			o.Cfg.TLS.KeyFile,
+			o.Cfg.TLS.SkipVerify,
+			false)
+		if err != nil {
+			return err
+		}
+		c.Transport = &http.Transport{
+			TLSClientConfig: tlsCfg,
+		}
+	}
+	o.httpClient = c
+	return nil
+}
+
+// pushRequest builds the push request of batch b,
+// the entries of each stream are sorted by timestamp.
+func (b *batch) pushRequest() *pushRequest {
+	keys := make([]string, 0, len(b.streams))
+	for k := range b.streams {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	req := &pushRequest{Streams: make([]*pushStream, 0, len(keys))}
+	for _, k := range keys {
+		s := b.streams[k]
+		sort.SliceStable(s.entries, func(i, j int) bool {
+			return s.entries[i].timestamp < s.entries[j].timestamp
+		})
+		ps := &pushStream{
+			Stream: s.labels,
+			Values: make([][2]string, 0, len(s.entries)),
+		}
+		for _, e := range s.entries {
+			ps.Values = append(ps.Values, [2]string{strconv.FormatInt(e.timestamp, 10), e.line})
+		}
+		req.Streams = append(req.Streams, ps)
+	}
+	return req
+}
+
+// push sends the batch b to Loki, retrying up to max-retries times
+// on connection errors, rate limiting and server errors.
+func (o *lokiOutput) push(ctx context.Context, b *batch) {
+	body, err := json.Marshal(b.pushRequest())
+	if err != nil {
+		o.logger.Printf("failed to marshal push request: %v", err)
+		lokiNumberOfFailedLines.WithLabelValues(o.Cfg.Name, "marshal_error").Add(float64(b.size))
+		return
+	}
+	if o.Cfg.UseGzip {
+		buf := new(bytes.Buffer)
+		zw := gzip.NewWriter(buf)
+		_, err = zw.Write(body)
+		if err == nil {
+			err = zw.Close()
+		}
+		if err != nil {
+			o.logger.Printf("failed to compress push request: %v", err)
+			lokiNumberOfFailedLines.WithLabelValues(o.Cfg.Name, "marshal_error").Add(float64(b.size))
+			return
+		}
+		body = buf.Bytes()
+	}
+	start := time.Now()
+	retries := 0
+	for {
+		var retry bool
+		retry, err = o.post(ctx, body)
+		if err == nil {
+			break
+		}
+		o.logger.Printf("failed to push %d line(s): %v", b.size, err)
+		if !retry || retries >= o.Cfg.MaxRetries {
+			lokiNumberOfFailedLines.WithLabelValues(o.Cfg.Name, "push_error").Add(float64(b.size))
+			return
+		}
+		retries++
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff * time.Duration(retries)):
+		}
+	}
+	lokiNumberOfSentLines.WithLabelValues(o.Cfg.Name).Add(float64(b.size))
+	lokiPushDuration.WithLabelValues(o.Cfg.Name).Set(float64(time.Since(start).Nanoseconds()))
+	if o.Cfg.Debug {
+		o.logger.Printf("pushed %d line(s) in %d stream(s)", b.size, len(b.streams))
+	}
+}
+
+// post sends a push request, it returns an error and whether it is worth retrying.
+func (o *lokiOutput) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.Cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	if o.Cfg.UseGzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if o.Cfg.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", o.Cfg.TenantID)
+	}
+	if o.Cfg.Authentication != nil {
+		req.SetBasicAuth(o.Cfg.Authentication.Username, o.Cfg.Authentication.Password)
+	}
+	for k, v := range o.Cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	rsp, err := o.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(rsp.Body)
+		retry := rsp.StatusCode == http.StatusTooManyRequests || rsp.StatusCode >= 500
+		return retry, fmt.Errorf("status code %d: %s", rsp.StatusCode, string(msg))
+	}
+	return false, nil
+}
@@ -0,0 +1,242 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loki_output
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/utils"
+)
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// entry is a log line and the labels of the stream it belongs to.
+type entry struct {
+	labels    map[string]string
+	timestamp int64
+	line      string
+}
+
+// stream groups the entries sharing the same labels.
+type stream struct {
+	labels  map[string]string
+	entries []*entry
+}
+
+// batch holds the entries to push in a single request.
+type batch struct {
+	streams map[string]*stream
+	size    int
+}
+
+func newBatch() *batch {
+	return &batch{streams: make(map[string]*stream)}
+}
+
+func (b *batch) add(e *entry) {
+	k := labelsKey(e.labels)
+	s, ok := b.streams[k]
+	if !ok {
+		s = &stream{labels: e.labels}
+		b.streams[k] = s
+	}
+	s.entries = append(s.entries, e)
+	b.size++
+}
+
+func labelsKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	sb := new(strings.Builder)
+	for _, k := range names {
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(strconv.Quote(labels[k]))
+		sb.WriteString(",")
+	}
+	return sb.String()
+}
+
+// labelName turns a tag name into a valid Loki label name.
+func labelName(s string) string {
+	s = invalidLabelChars.ReplaceAllString(s, "_")
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "_" + s
+	}
+	return s
+}
+
+// pathClass returns the first element of a value path stripped of its origin,
+// e.g: "interface" for "/openconfig-interfaces:interface/state/oper-status".
+func pathClass(p string) string {
+	p = strings.TrimPrefix(p, "/")
+	if i := strings.Index(p, "/"); i >= 0 {
+		p = p[:i]
+	}
+	if i := strings.Index(p, ":"); i >= 0 {
+		p = p[i+1:]
+	}
+	return p
+}
+
+func (o *lokiOutput) initLines() error {
+	o.labels = make(map[string]string, len(o.Cfg.Labels))
+	for _, l := range o.Cfg.Labels {
+		o.labels[l] = labelName(l)
+	}
+	o.paths = make([]*regexp.Regexp, 0, len(o.Cfg.Paths))
+	for _, p := range o.Cfg.Paths {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid path regex %q: %v", p, err)
+		}
+		o.paths = append(o.paths, re)
+	}
+	if o.Cfg.LineTemplate != "" {
+		var err error
+		o.lineTpl, err = utils.CreateTemplate("line-template", o.Cfg.LineTemplate)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *lokiOutput) selected(name string) bool {
+	if len(o.paths) == 0 {
+		return true
+	}
+	for _, re := range o.paths {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// entries builds one log entry per path class of the selected values of the event ev.
+func (o *lokiOutput) entries(ev *formatters.EventMsg) []*entry {
+	if ev == nil || len(ev.Values) == 0 {
+		return nil
+	}
+	groups := make(map[string]map[string]interface{})
+	for k, v := range ev.Values {
+		if !o.selected(k) {
+			continue
+		}
+		c := pathClass(k)
+		if groups[c] == nil {
+			groups[c] = make(map[string]interface{})
+		}
+		groups[c][k] = v
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	classes := make([]string, 0, len(groups))
+	for c := range groups {
+		classes = append(classes, c)
+	}
+	sort.Strings(classes)
+
+	ts := ev.Timestamp
+	if ts <= 0 {
+		ts = time.Now().UnixNano()
+	}
+	entries := make([]*entry, 0, len(classes))
+	for _, c := range classes {
+		labels := make(map[string]string, len(o.Cfg.StaticLabels)+len(o.labels)+1)
+		for k, v := range o.Cfg.StaticLabels {
+			labels[labelName(k)] = v
+		}
+		for k, v := range ev.Tags {
+			if ln, ok := o.labels[k]; ok && v != "" {
+				labels[ln] = v
+			}
+		}
+		if c != "" {
+			labels[labelName(o.Cfg.PathClassLabel)] = c
+		}
+		line, err := o.line(&formatters.EventMsg{
+			Name:      ev.Name,
+			Timestamp: ev.Timestamp,
+			Tags:      ev.Tags,
+			Values:    groups[c],
+		})
+		if err != nil {
+			o.logger.Printf("failed to build log line: %v", err)
+			lokiNumberOfFailedLines.WithLabelValues(o.Cfg.Name, "line_template").Inc()
+			continue
+		}
+		entries = append(entries, &entry{
+			labels:    labels,
+			timestamp: ts,
+			line:      line,
+		})
+	}
+	return entries
+}
+
+// line renders the log line of the event ev, using the configured line template if any.
+// By default, the event tags not used as labels and its values are formatted as logfmt key/value pairs.
+func (o *lokiOutput) line(ev *formatters.EventMsg) (string, error) {
+	if o.lineTpl != nil {
+		b := new(bytes.Buffer)
+		err := o.lineTpl.Execute(b, ev)
+		if err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+	tags := make([]string, 0, len(ev.Tags))
+	for k := range ev.Tags {
+		if _, ok := o.labels[k]; ok {
+			continue
+		}
+		tags = append(tags, k)
+	}
+	sort.Strings(tags)
+	values := make([]string, 0, len(ev.Values))
+	for k := range ev.Values {
+		values = append(values, k)
+	}
+	sort.Strings(values)
+
+	sb := new(strings.Builder)
+	for _, k := range tags {
+		writeLogfmt(sb, k, ev.Tags[k])
+	}
+	for _, k := range values {
+		writeLogfmt(sb, k, fmt.Sprint(ev.Values[k]))
+	}
+	return sb.String(), nil
+}
+
+func writeLogfmt(sb *strings.Builder, k, v string) {
+	if sb.Len() > 0 {
+		sb.WriteByte(' ')
+	}
+	sb.WriteString(k)
+	sb.WriteByte('=')
+	if v == "" || strings.ContainsAny(v, " =\"\t\n") {
+		sb.WriteString(strconv.Quote(v))
+		return
+	}
+	sb.WriteString(v)
+}
@@ -0,0 +1,53 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loki_output
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var lokiNumberOfSentLines = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gnmic",
+	Subsystem: "loki_output",
+	Name:      "number_of_loki_lines_sent_success_total",
+	Help:      "Number of log lines successfully pushed by gnmic loki output",
+}, []string{"name"})
+
+var lokiNumberOfFailedLines = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gnmic",
+	Subsystem: "loki_output",
+	Name:      "number_of_loki_lines_sent_fail_total",
+	Help:      "Number of log lines gnmic loki output failed to push",
+}, []string{"name", "reason"})
+
+var lokiPushDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gnmic",
+	Subsystem: "loki_output",
+	Name:      "push_duration_ns",
+	Help:      "gnmic loki output push request duration in ns",
+}, []string{"name"})
+
+func initMetrics() {
+	lokiNumberOfSentLines.WithLabelValues("").Add(0)
+	lokiNumberOfFailedLines.WithLabelValues("", "").Add(0)
+	lokiPushDuration.WithLabelValues("").Set(0)
+}
+
+func registerMetrics(reg *prometheus.Registry) error {
+	initMetrics()
+	var err error
+	if err = reg.Register(lokiNumberOfSentLines); err != nil {
+		return err
+	}
+	if err = reg.Register(lokiNumberOfFailedLines); err != nil {
+		return err
+	}
+	if err = reg.Register(lokiPushDuration); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loki_output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"text/template"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	outputType            = "loki"
+	loggingPrefix         = "[loki_output:%s] "
+	defaultTimeout        = 10 * time.Second
+	defaultBatchSize      = 1000
+	defaultFlushTimer     = 5 * time.Second
+	defaultBufferSize     = 1000
+	defaultPathClassLabel = "path_class"
+	userAgent             = "gNMIc loki"
+)
+
+var defaultLabels = []string{"source", "subscription-name"}
+
+func init() {
+	outputs.Register(outputType,
+		func() outputs.Output {
+			return &lokiOutput{
+				Cfg:    &config{},
+				logger: log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags),
+			}
+		})
+}
+
+// lokiOutput pushes the received events as log lines to Loki,
+// using some of the event tags as stream labels.
+type lokiOutput struct {
+	Cfg    *config
+	logger *log.Logger
+
+	httpClient *http.Client
+	eventCh    chan *formatters.EventMsg
+	labels     map[string]string // tag name to label name
+	paths      []*regexp.Regexp
+	lineTpl    *template.Template
+
+	evps      []formatters.EventProcessor
+	targetTpl *template.Template
+	cfn       context.CancelFunc
+}
+
+type config struct {
+	Name string `mapstructure:"name,omitempty" json:"name,omitempty"`
+	// Loki push API URL, e.g: http://localhost:3100/loki/api/v1/push
+	URL string `mapstructure:"url,omitempty" json:"url,omitempty"`
+	// tenant ID, sent in the X-Scope-OrgID header
+	TenantID       string            `mapstructure:"tenant-id,omitempty" json:"tenant-id,omitempty"`
+	Timeout        time.Duration     `mapstructure:"timeout,omitempty" json:"timeout,omitempty"`
+	Headers        map[string]string `mapstructure:"headers,omitempty" json:"headers,omitempty"`
+	Authentication *auth             `mapstructure:"authentication,omitempty" json:"authentication,omitempty"`
+	TLS            *tlsConfig        `mapstructure:"tls,omitempty" json:"tls,omitempty"`
+	UseGzip        bool              `mapstructure:"use-gzip,omitempty" json:"use-gzip,omitempty"`
+	// max number of log lines per push request
+	BatchSize  int           `mapstructure:"batch-size,omitempty" json:"batch-size,omitempty"`
+	FlushTimer time.Duration `mapstructure:"flush-timer,omitempty" json:"flush-timer,omitempty"`
+	BufferSize int           `mapstructure:"buffer-size,omitempty" json:"buffer-size,omitempty"`
+	MaxRetries int           `mapstructure:"max-retries,omitempty" json:"max-retries,omitempty"`
+	// event tags used as stream labels
+	Labels []string `mapstructure:"labels,omitempty" json:"labels,omitempty"`
+	// labels added to all the streams
+	StaticLabels map[string]string `mapstructure:"static-labels,omitempty" json:"static-labels,omitempty"`
+	// name of the label set to the first element of the values path
+	PathClassLabel string `mapstructure:"path-class-label,omitempty" json:"path-class-label,omitempty"`
+	// regular expressions selecting the values to push, all values if empty
+	Paths []string `mapstructure:"paths,omitempty" json:"paths,omitempty"`
+	// Go template rendering the log line of an event
+	LineTemplate    string   `mapstructure:"line-template,omitempty" json:"line-template,omitempty"`
+	AddTarget       string   `mapstructure:"add-target,omitempty" json:"add-target,omitempty"`
+	TargetTemplate  string   `mapstructure:"target-template,omitempty" json:"target-template,omitempty"`
+	EventProcessors []string `mapstructure:"event-processors,omitempty" json:"event-processors,omitempty"`
+	EnableMetrics   bool     `mapstructure:"enable-metrics,omitempty" json:"enable-metrics,omitempty"`
+	Debug           bool     `mapstructure:"debug,omitempty" json:"debug,omitempty"`
+}
+
+type auth struct {
+	Username string `mapstructure:"username,omitempty" json:"username,omitempty"`
+	Password string `mapstructure:"password,omitempty" json:"password,omitempty"`
+}
+
+type tlsConfig struct {
+	CAFile     string `mapstructure:"ca-file,omitempty" json:"ca-file,omitempty"`
+	CertFile   string `mapstructure:"cert-file,omitempty" json:"cert-file,omitempty"`
+	KeyFile    string `mapstructure:"key-file,omitempty" json:"key-file,omitempty"`
+	SkipVerify bool   `mapstructure:"skip-verify,omitempty" json:"skip-verify,omitempty"`
+}
+
+func (o *lokiOutput) Init(ctx context.Context, name string, cfg map[string]interface{}, opts ...outputs.Option) error {
+	err := outputs.DecodeConfig(cfg, o.Cfg)
+	if err != nil {
+		return err
+	}
+	if o.Cfg.URL == "" {
+		return errors.New("missing url field")
+	}
+	if o.Cfg.Name == "" {
+		o.Cfg.Name = name
+	}
+	o.logger.SetPrefix(fmt.Sprintf(loggingPrefix, o.Cfg.Name))
+
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.setDefaults()
+	err = o.initLines()
+	if err != nil {
+		return err
+	}
+
+	if o.Cfg.TargetTemplate == "" {
+		o.targetTpl = outputs.DefaultTargetTemplate
+	} else if o.Cfg.AddTarget != "" {
+		o.targetTpl, err = utils.CreateTemplate("target-template", o.Cfg.TargetTemplate)
+		if err != nil {
+			return err
+		}
+		o.targetTpl = o.targetTpl.Funcs(outputs.TemplateFuncs)
+	}
+
+	err = o.createHTTPClient()
+	if err != nil {
+		return err
+	}
+	o.eventCh = make(chan *formatters.EventMsg, o.Cfg.BufferSize)
+
+	ctx, o.cfn = context.WithCancel(ctx)
+	go o.worker(ctx)
+	o.logger.Printf("initialized loki output %s: %s", o.Cfg.Name, o.String())
+	return nil
+}
+
+func (o *lokiOutput) setDefaults() {
+	if o.Cfg.Timeout <= 0 {
+		o.Cfg.Timeout = defaultTimeout
+	}
+	if o.Cfg.BatchSize <= 0 {
+		o.Cfg.BatchSize = defaultBatchSize
+	}
+	if o.Cfg.FlushTimer <= 0 {
+		o.Cfg.FlushTimer = defaultFlushTimer
+	}
+	if o.Cfg.BufferSize <= 0 {
+		o.Cfg.BufferSize = defaultBufferSize
+	}
+	if o.Cfg.Labels == nil {
+		o.Cfg.Labels = defaultLabels
+	}
+	if o.Cfg.PathClassLabel == "" {
+		o.Cfg.PathClassLabel = defaultPathClassLabel
+	}
+}
+
+func (o *lokiOutput) Write(ctx context.Context, rsp proto.Message, meta outputs.Meta) {
+	if rsp == nil {
+		return
+	}
+	switch rsp := rsp.(type) {
+	case *gnmi.SubscribeResponse:
+		measName := "default"
+		if subName, ok := meta["subscription-name"]; ok {
+			measName = subName
+		}
+		var err error
+		rsp, err = outputs.AddSubscriptionTarget(rsp, meta, o.Cfg.AddTarget, o.targetTpl)
+		if err != nil {
+			o.logger.Printf("failed to add target to the response: %v", err)
+		}
+		events, err := formatters.ResponseToEventMsgs(measName, rsp, meta, o.evps...)
+		if err != nil {
+			o.logger.Printf("failed to convert message to event: %v", err)
+			return
+		}
+		for _, ev := range events {
+			select {
+			case <-ctx.Done():
+				return
+			case o.eventCh <- ev:
+			}
+		}
+	}
+}
+
+func (o *lokiOutput) WriteEvent(ctx context.Context, ev *formatters.EventMsg) {
+	select {
+	case <-ctx.Done():
+		return
+	default:
+		var evs = []*formatters.EventMsg{ev}
+		for _, proc := range o.evps {
+			evs = proc.Apply(evs...)
+		}
+		for _, pev := range evs {
+			select {
+			case <-ctx.Done():
+				return
+			case o.eventCh <- pev:
+			}
+		}
+	}
+}
+
+func (o *lokiOutput) Close() error {
+	if o.cfn == nil {
+		return nil
+	}
+	o.cfn()
+	return nil
+}
+
+func (o *lokiOutput) RegisterMetrics(reg *prometheus.Registry) {
+	if !o.Cfg.EnableMetrics {
+		return
+	}
+	if err := registerMetrics(reg); err != nil {
+		o.logger.Printf("failed to register metric: %v", err)
+	}
+}
+
+func (o *lokiOutput) String() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (o *lokiOutput) SetLogger(logger *log.Logger) {
+	if logger != nil && o.logger != nil {
+		o.logger.SetOutput(logger.Writer())
+		o.logger.SetFlags(logger.Flags())
+	}
+}
+
+func (o *lokiOutput) SetEventProcessors(ps map[string]map[string]interface{},
+	logger *log.Logger,
+	tcs map[string]*types.TargetConfig,
+	acts map[string]map[string]interface{}) {
+	for _, epName := range o.Cfg.EventProcessors {
+		if epCfg, ok := ps[epName]; ok {
+			epType := ""
+			for k := range epCfg {
+				epType = k
+				break
+			}
+			if in, ok := formatters.EventProcessors[epType]; ok {
+				ep := in()
+				err := ep.Init(epCfg[epType],
+					formatters.WithLogger(logger),
+					formatters.WithTargets(tcs),
+					formatters.WithActions(acts),
+				)
+				if err != nil {
+					o.logger.Printf("failed initializing event processor '%s' of type='%s': %v", epName, epType, err)
+					continue
+				}
+				o.evps = append(o.evps, ep)
+				o.logger.Printf("added event processor '%s' of type=%s to loki output", epName, epType)
+				continue
+			}
+			o.logger.Printf("%q event processor has an unknown type=%q", epName, epType)
+			continue
+		}
+		o.logger.Printf("%q event processor not found!", epName)
+	}
+}
+
+func (o *lokiOutput) SetName(name string) {
+	if o.Cfg.Name == "" {
+		o.Cfg.Name = name
+	}
+}
+
+func (o *lokiOutput) SetClusterName(_ string) {}
+
+func (o *lokiOutput) SetTargetsConfig(map[string]*types.TargetConfig) {}
+
+// worker batches the log lines built from the received events,
+// and pushes them when the batch is full or the flush timer expires.
+func (o *lokiOutput) worker(ctx context.Context) {
+	ticker := time.NewTicker(o.Cfg.FlushTimer)
+	defer ticker.Stop()
+	b := newBatch()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-o.eventCh:
+			for _, e := range o.entries(ev) {
+				b.add(e)
+				if b.size >= o.Cfg.BatchSize {
+					o.push(ctx, b)
+					b = newBatch()
+				}
+			}
+		case <-ticker.C:
+			if b.size == 0 {
+				continue
+			}
+			o.push(ctx, b)
+			b = newBatch()
+		}
+	}
+}
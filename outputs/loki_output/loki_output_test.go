@@ -0,0 +1,153 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loki_output
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/formatters"
+	"github.com/openconfig/gnmic/outputs"
+)
+
+func TestEntries(t *testing.T) {
+	o := outputs.Outputs[outputType]().(*lokiOutput)
+	o.Cfg.Paths = []string{"oper-state$", "admin-state$"}
+	o.Cfg.StaticLabels = map[string]string{"job": "gnmic"}
+	o.setDefaults()
+	err := o.initLines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	es := o.entries(&formatters.EventMsg{
+		Name:      "sub1",
+		Timestamp: 42,
+		Tags: map[string]string{
+			"source":            "router1",
+			"subscription-name": "sub1",
+			"interface_name":    "ethernet-1/1",
+		},
+		Values: map[string]interface{}{
+			"/srl_nokia-interfaces:interface/oper-state":  "down",
+			"/srl_nokia-interfaces:interface/admin-state": "enable",
+			"/srl_nokia-interfaces:interface/description": "uplink to spine1",
+		},
+	})
+	if len(es) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(es))
+	}
+	wantLabels := map[string]string{
+		"job":               "gnmic",
+		"source":            "router1",
+		"subscription_name": "sub1",
+		"path_class":        "interface",
+	}
+	if !reflect.DeepEqual(es[0].labels, wantLabels) {
+		t.Errorf("got labels %v, want %v", es[0].labels, wantLabels)
+	}
+	wantLine := "interface_name=ethernet-1/1 /srl_nokia-interfaces:interface/admin-state=enable /srl_nokia-interfaces:interface/oper-state=down"
+	if es[0].line != wantLine {
+		t.Errorf("got line %q, want %q", es[0].line, wantLine)
+	}
+	if es[0].timestamp != 42 {
+		t.Errorf("got timestamp %d, want 42", es[0].timestamp)
+	}
+}
+
+func TestLineTemplate(t *testing.T) {
+	o := outputs.Outputs[outputType]().(*lokiOutput)
+	o.Cfg.LineTemplate = `{{ index .Tags "interface_name" }} is {{ index .Values "/interface/oper-state" }}`
+	o.setDefaults()
+	err := o.initLines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	es := o.entries(&formatters.EventMsg{
+		Tags:   map[string]string{"interface_name": "ethernet-1/1"},
+		Values: map[string]interface{}{"/interface/oper-state": "down"},
+	})
+	if len(es) != 1 || es[0].line != "ethernet-1/1 is down" {
+		t.Errorf("unexpected entries: %+v", es)
+	}
+}
+
+func TestPush(t *testing.T) {
+	reqCh := make(chan *http.Request, 1)
+	bodyCh := make(chan *pushRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pr := new(pushRequest)
+		err := json.NewDecoder(r.Body).Decode(pr)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reqCh <- r
+		bodyCh <- pr
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	o := outputs.Outputs[outputType]()
+	err := o.Init(ctx, "loki1", map[string]interface{}{
+		"url":         srv.URL + "/loki/api/v1/push",
+		"tenant-id":   "tenant1",
+		"batch-size":  2,
+		"flush-timer": "1m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+	for i := int64(2); i > 0; i-- {
+		o.WriteEvent(ctx, &formatters.EventMsg{
+			Timestamp: i,
+			Tags:      map[string]string{"source": "router1"},
+			Values:    map[string]interface{}{"/interface/oper-state": "up"},
+		})
+	}
+	select {
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for the push request")
+	case r := <-reqCh:
+		if r.Header.Get("X-Scope-OrgID") != "tenant1" {
+			t.Errorf("unexpected tenant header %q", r.Header.Get("X-Scope-OrgID"))
+		}
+	}
+	pr := <-bodyCh
+	if len(pr.Streams) != 1 {
+		t.Fatalf("expected 1 stream, got %d", len(pr.Streams))
+	}
+	want := [][2]string{
+		{"1", "/interface/oper-state=up"},
+		{"2", "/interface/oper-state=up"},
+	}
+	if !reflect.DeepEqual(pr.Streams[0].Values, want) {
+		t.Errorf("got values %v, want %v", pr.Streams[0].Values, want)
+	}
+}
+
+func TestLabelName(t *testing.T) {
+	for in, want := range map[string]string{
+		"source":            "source",
+		"subscription-name": "subscription_name",
+		"1st":               "_1st",
+		"a.b/c":             "a_b_c",
+	} {
+		if got := labelName(in); got != want {
+			t.Errorf("labelName(%q): got %q, want %q", in, got, want)
+		}
+	}
+}
@@ -28,9 +28,12 @@ import (
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmic/formatters"
 	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/tracing"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -80,11 +83,16 @@ type config struct {
 	TargetTemplate     string              `mapstructure:"target-template,omitempty" json:"target-template,omitempty"`
 	MsgTemplate        string              `mapstructure:"msg-template,omitempty" json:"msg-template,omitempty"`
 	OverrideTimestamps bool                `mapstructure:"override-timestamps,omitempty" json:"override-timestamps,omitempty"`
+	EventVersion       int                 `mapstructure:"event-version,omitempty" json:"event-version,omitempty"`
 	NumWorkers         int                 `mapstructure:"num-workers,omitempty" json:"num-workers,omitempty"`
 	WriteTimeout       time.Duration       `mapstructure:"write-timeout,omitempty" json:"write-timeout,omitempty"`
 	Debug              bool                `mapstructure:"debug,omitempty" json:"debug,omitempty"`
 	EnableMetrics      bool                `mapstructure:"enable-metrics,omitempty" json:"enable-metrics,omitempty"`
 	EventProcessors    []string            `mapstructure:"event-processors,omitempty" json:"event-processors,omitempty"`
+	MaxMessageSize     int                 `mapstructure:"max-message-size,omitempty" json:"max-message-size,omitempty"`
+	OversizePolicy     string              `mapstructure:"oversize-policy,omitempty" json:"oversize-policy,omitempty"`
+	TruncateMarker     string              `mapstructure:"truncate-marker,omitempty" json:"truncate-marker,omitempty"`
+	DLQSubject         string              `mapstructure:"dlq-subject,omitempty" json:"dlq-subject,omitempty"`
 }
 
 type createStreamConfig struct {
@@ -115,8 +123,9 @@ type jetstreamOutput struct {
 	mo       *formatters.MarshalOptions
 	evps     []formatters.EventProcessor
 
-	targetTpl *template.Template
-	msgTpl    *template.Template
+	targetTpl   *template.Template
+	msgTpl      *template.Template
+	sizeLimiter *outputs.SizeLimiter
 }
 
 func (n *jetstreamOutput) Init(ctx context.Context, name string, cfg map[string]interface{}, opts ...outputs.Option) error {
@@ -140,8 +149,9 @@ func (n *jetstreamOutput) Init(ctx context.Context, name string, cfg map[string]
 	n.msgChan = make(chan *outputs.ProtoMsg)
 	initMetrics()
 	n.mo = &formatters.MarshalOptions{
-		Format:     n.Cfg.Format,
-		OverrideTS: n.Cfg.OverrideTimestamps,
+		Format:       n.Cfg.Format,
+		OverrideTS:   n.Cfg.OverrideTimestamps,
+		EventVersion: n.Cfg.EventVersion,
 	}
 	if n.Cfg.TargetTemplate == "" {
 		n.targetTpl = outputs.DefaultTargetTemplate
@@ -161,6 +171,11 @@ func (n *jetstreamOutput) Init(ctx context.Context, name string, cfg map[string]
 		n.msgTpl = n.msgTpl.Funcs(outputs.TemplateFuncs)
 	}
 
+	n.sizeLimiter, err = outputs.NewSizeLimiter(n.Cfg.MaxMessageSize, n.Cfg.OversizePolicy, n.Cfg.TruncateMarker)
+	if err != nil {
+		return err
+	}
+
 	n.ctx, n.cancelFn = context.WithCancel(ctx)
 
 	n.wg.Add(n.Cfg.NumWorkers)
@@ -225,6 +240,12 @@ func (n *jetstreamOutput) setDefaults() error {
 		}
 		return nil
 	}
+	if n.Cfg.EventVersion == 0 {
+		n.Cfg.EventVersion = formatters.EventVersion2
+	}
+	if err := formatters.ValidateEventVersion(n.Cfg.EventVersion); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -235,11 +256,14 @@ func (n *jetstreamOutput) Write(ctx context.Context, rsp proto.Message, meta out
 	wctx, cancel := context.WithTimeout(ctx, n.Cfg.WriteTimeout)
 	defer cancel()
 
+	pm := outputs.NewProtoMsg(rsp, meta).WithSpanContext(ctx).Account()
 	select {
 	case <-ctx.Done():
+		pm.Release()
 		return
-	case n.msgChan <- outputs.NewProtoMsg(rsp, meta):
+	case n.msgChan <- pm:
 	case <-wctx.Done():
+		pm.Release()
 		if n.Cfg.Debug {
 			n.logger.Printf("writing expired after %s, JetStream output might not be initialized", n.Cfg.WriteTimeout)
 		}
@@ -322,6 +346,10 @@ func (n *jetstreamOutput) SetEventProcessors(ps map[string]map[string]interface{
 	}
 }
 
+func (n *jetstreamOutput) BufferSize() (int, int) {
+	return len(n.msgChan), cap(n.msgChan)
+}
+
 func (n *jetstreamOutput) SetName(name string) {
 	sb := strings.Builder{}
 	if name != "" {
@@ -385,6 +413,7 @@ CRCONN:
 			n.logger.Printf("%s shutting down", workerLogPrefix)
 			return
 		case m := <-n.msgChan:
+			m.Release()
 			pmsg := m.GetMsg()
 			pmsg, err = outputs.AddSubscriptionTarget(pmsg, m.GetMeta(), n.Cfg.AddTarget, n.targetTpl)
 			if err != nil {
@@ -400,6 +429,10 @@ CRCONN:
 					switch rsp := rsp.Response.(type) {
 					case *gnmi.SubscribeResponse_Update:
 						rs = splitSubscribeResponse(rsp)
+						// empty notifications release the events held by the processors
+						if len(rs) == 0 {
+							rs = []proto.Message{pmsg}
+						}
 					}
 				}
 			}
@@ -414,6 +447,9 @@ CRCONN:
 					}
 					continue
 				}
+				if len(b) == 0 {
+					continue
+				}
 
 				if n.msgTpl != nil && len(b) > 0 {
 					b, err = outputs.ExecTemplate(b, n.msgTpl)
@@ -436,32 +472,65 @@ CRCONN:
 					}
 					continue
 				}
-				var start time.Time
-				if n.Cfg.EnableMetrics {
-					start = time.Now()
-				}
-				_, err = js.Publish(subject, b)
-				if err != nil {
-					if n.Cfg.Debug {
-						n.logger.Printf("%s failed to write to subject '%s': %v", workerLogPrefix, subject, err)
+				for _, msg := range n.natsMsgs(workerLogPrefix, subject, b) {
+					sctx, sspan := tracing.StartSpan(m.Context(ctx), "jetstream.publish",
+						attribute.String("output", n.Cfg.Name),
+						attribute.String("subject", msg.Subject))
+					if tracing.HasSpan(sctx) && natsConn.HeadersSupported() {
+						msg.Header = make(nats.Header)
+						tracing.Inject(sctx, propagation.HeaderCarrier(msg.Header))
 					}
+					var start time.Time
 					if n.Cfg.EnableMetrics {
-						jetStreamNumberOfFailSendMsgs.WithLabelValues(cfg.Name, "publish_error").Inc()
+						start = time.Now()
+					}
+					_, err = js.PublishMsg(msg)
+					tracing.SetError(sspan, err)
+					sspan.End()
+					if err != nil {
+						if n.Cfg.Debug {
+							n.logger.Printf("%s failed to write to subject '%s': %v", workerLogPrefix, msg.Subject, err)
+						}
+						if n.Cfg.EnableMetrics {
+							jetStreamNumberOfFailSendMsgs.WithLabelValues(cfg.Name, "publish_error").Inc()
+						}
+						natsConn.Close()
+						time.Sleep(cfg.ConnectTimeWait)
+						goto CRCONN
+					}
+					if n.Cfg.EnableMetrics {
+						jetStreamSendDuration.WithLabelValues(cfg.Name).Set(float64(time.Since(start).Nanoseconds()))
+						jetStreamNumberOfSentMsgs.WithLabelValues(cfg.Name, msg.Subject).Inc()
+						jetStreamNumberOfSentBytes.WithLabelValues(cfg.Name, msg.Subject).Add(float64(len(msg.Data)))
 					}
-					natsConn.Close()
-					time.Sleep(cfg.ConnectTimeWait)
-					goto CRCONN
-				}
-				if n.Cfg.EnableMetrics {
-					jetStreamSendDuration.WithLabelValues(cfg.Name).Set(float64(time.Since(start).Nanoseconds()))
-					jetStreamNumberOfSentMsgs.WithLabelValues(cfg.Name, subject).Inc()
-					jetStreamNumberOfSentBytes.WithLabelValues(cfg.Name, subject).Add(float64(len(b)))
 				}
 			}
 		}
 	}
 }
 
+// natsMsgs returns the messages to publish for the marshaled message b.
+// The messages larger than max-message-size are split or truncated according to the oversize-policy,
+// the ones that still do not fit are published to the dlq-subject if set, or dropped.
+func (n *jetstreamOutput) natsMsgs(workerLogPrefix, subject string, b []byte) []*nats.Msg {
+	fit, oversized := n.sizeLimiter.Limit(b)
+	msgs := make([]*nats.Msg, 0, len(fit)+len(oversized))
+	for _, fb := range fit {
+		msgs = append(msgs, &nats.Msg{Subject: subject, Data: fb})
+	}
+	for _, ob := range oversized {
+		if n.Cfg.DLQSubject != "" {
+			msgs = append(msgs, &nats.Msg{Subject: n.Cfg.DLQSubject, Data: ob})
+			continue
+		}
+		n.logger.Printf("%s dropping a %d bytes message exceeding max-message-size %d", workerLogPrefix, len(ob), n.Cfg.MaxMessageSize)
+		if n.Cfg.EnableMetrics {
+			jetStreamNumberOfFailSendMsgs.WithLabelValues(n.Cfg.Name, "oversized").Inc()
+		}
+	}
+	return msgs
+}
+
 // Dial //
 func (n *jetstreamOutput) Dial(network, address string) (net.Conn, error) {
 	ctx, cancel := context.WithCancel(n.ctx)
@@ -24,9 +24,12 @@ import (
 	"github.com/nats-io/nats.go"
 	"github.com/openconfig/gnmic/formatters"
 	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/tracing"
 	"github.com/openconfig/gnmic/types"
 	"github.com/openconfig/gnmic/utils"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -62,8 +65,9 @@ type NatsOutput struct {
 	mo       *formatters.MarshalOptions
 	evps     []formatters.EventProcessor
 
-	targetTpl *template.Template
-	msgTpl    *template.Template
+	targetTpl   *template.Template
+	msgTpl      *template.Template
+	sizeLimiter *outputs.SizeLimiter
 }
 
 // Config //
@@ -80,11 +84,16 @@ type Config struct {
 	TargetTemplate     string        `mapstructure:"target-template,omitempty"`
 	MsgTemplate        string        `mapstructure:"msg-template,omitempty"`
 	OverrideTimestamps bool          `mapstructure:"override-timestamps,omitempty"`
+	EventVersion       int           `mapstructure:"event-version,omitempty"`
 	NumWorkers         int           `mapstructure:"num-workers,omitempty"`
 	WriteTimeout       time.Duration `mapstructure:"write-timeout,omitempty"`
 	Debug              bool          `mapstructure:"debug,omitempty"`
 	EnableMetrics      bool          `mapstructure:"enable-metrics,omitempty"`
 	EventProcessors    []string      `mapstructure:"event-processors,omitempty"`
+	MaxMessageSize     int           `mapstructure:"max-message-size,omitempty"`
+	OversizePolicy     string        `mapstructure:"oversize-policy,omitempty"`
+	TruncateMarker     string        `mapstructure:"truncate-marker,omitempty"`
+	DLQSubject         string        `mapstructure:"dlq-subject,omitempty"`
 }
 
 func (n *NatsOutput) String() string {
@@ -156,8 +165,9 @@ func (n *NatsOutput) Init(ctx context.Context, name string, cfg map[string]inter
 	n.msgChan = make(chan *outputs.ProtoMsg)
 	initMetrics()
 	n.mo = &formatters.MarshalOptions{
-		Format:     n.Cfg.Format,
-		OverrideTS: n.Cfg.OverrideTimestamps,
+		Format:       n.Cfg.Format,
+		OverrideTS:   n.Cfg.OverrideTimestamps,
+		EventVersion: n.Cfg.EventVersion,
 	}
 	if n.Cfg.TargetTemplate == "" {
 		n.targetTpl = outputs.DefaultTargetTemplate
@@ -177,6 +187,11 @@ func (n *NatsOutput) Init(ctx context.Context, name string, cfg map[string]inter
 		n.msgTpl = n.msgTpl.Funcs(outputs.TemplateFuncs)
 	}
 
+	n.sizeLimiter, err = outputs.NewSizeLimiter(n.Cfg.MaxMessageSize, n.Cfg.OversizePolicy, n.Cfg.TruncateMarker)
+	if err != nil {
+		return err
+	}
+
 	n.ctx, n.cancelFn = context.WithCancel(ctx)
 	n.wg.Add(n.Cfg.NumWorkers)
 	for i := 0; i < n.Cfg.NumWorkers; i++ {
@@ -196,7 +211,7 @@ func (n *NatsOutput) setDefaults() error {
 	if n.Cfg.Format == "" {
 		n.Cfg.Format = defaultFormat
 	}
-	if !(n.Cfg.Format == "event" || n.Cfg.Format == "protojson" || n.Cfg.Format == "proto" || n.Cfg.Format == "json") {
+	if !(n.Cfg.Format == "event" || n.Cfg.Format == "protojson" || n.Cfg.Format == "proto" || n.Cfg.Format == formatters.FormatProtoEnvelope || n.Cfg.Format == "json") {
 		return fmt.Errorf("unsupported output format '%s' for output type NATS", n.Cfg.Format)
 	}
 	if n.Cfg.Address == "" {
@@ -217,6 +232,12 @@ func (n *NatsOutput) setDefaults() error {
 	if n.Cfg.WriteTimeout <= 0 {
 		n.Cfg.WriteTimeout = defaultWriteTimeout
 	}
+	if n.Cfg.EventVersion == 0 {
+		n.Cfg.EventVersion = formatters.EventVersion2
+	}
+	if err := formatters.ValidateEventVersion(n.Cfg.EventVersion); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -229,11 +250,14 @@ func (n *NatsOutput) Write(ctx context.Context, rsp proto.Message, meta outputs.
 	wctx, cancel := context.WithTimeout(ctx, n.Cfg.WriteTimeout)
 	defer cancel()
 
+	pm := outputs.NewProtoMsg(rsp, meta).WithSpanContext(ctx).Account()
 	select {
 	case <-ctx.Done():
+		pm.Release()
 		return
-	case n.msgChan <- outputs.NewProtoMsg(rsp, meta):
+	case n.msgChan <- pm:
 	case <-wctx.Done():
+		pm.Release()
 		if n.Cfg.Debug {
 			n.logger.Printf("writing expired after %s, NATS output might not be initialized", n.Cfg.WriteTimeout)
 		}
@@ -338,11 +362,15 @@ CRCONN:
 			n.logger.Printf("%s shutting down", workerLogPrefix)
 			return
 		case m := <-n.msgChan:
+			m.Release()
 			pmsg := m.GetMsg()
 			pmsg, err = outputs.AddSubscriptionTarget(pmsg, m.GetMeta(), n.Cfg.AddTarget, n.targetTpl)
 			if err != nil {
 				n.logger.Printf("failed to add target to the response: %v", err)
 			}
+			mctx := m.Context(ctx)
+			_, pspan := tracing.StartSpan(mctx, "output.process",
+				attribute.String("output", n.Cfg.Name))
 			b, err := n.mo.Marshal(pmsg, m.GetMeta(), n.evps...)
 			if err != nil {
 				if n.Cfg.Debug {
@@ -351,6 +379,12 @@ CRCONN:
 				if n.Cfg.EnableMetrics {
 					NatsNumberOfFailSendMsgs.WithLabelValues(cfg.Name, "marshal_error").Inc()
 				}
+				tracing.SetError(pspan, err)
+				pspan.End()
+				continue
+			}
+			if len(b) == 0 {
+				pspan.End()
 				continue
 			}
 
@@ -361,36 +395,72 @@ CRCONN:
 						log.Printf("failed to execute template: %v", err)
 					}
 					NatsNumberOfFailSendMsgs.WithLabelValues(cfg.Name, "template_error").Inc()
+					tracing.SetError(pspan, err)
+					pspan.End()
 					return
 				}
 			}
+			pspan.End()
 
 			subject := n.subjectName(cfg, m.GetMeta())
-			var start time.Time
-			if n.Cfg.EnableMetrics {
-				start = time.Now()
-			}
-			err = natsConn.Publish(subject, b)
-			if err != nil {
-				if n.Cfg.Debug {
-					n.logger.Printf("%s failed to write to nats subject '%s': %v", workerLogPrefix, subject, err)
+			for _, msg := range n.natsMsgs(workerLogPrefix, subject, b) {
+				sctx, sspan := tracing.StartSpan(mctx, "nats.publish",
+					attribute.String("output", n.Cfg.Name),
+					attribute.String("subject", msg.Subject))
+				if tracing.HasSpan(sctx) && natsConn.HeadersSupported() {
+					msg.Header = make(nats.Header)
+					tracing.Inject(sctx, propagation.HeaderCarrier(msg.Header))
 				}
+				var start time.Time
 				if n.Cfg.EnableMetrics {
-					NatsNumberOfFailSendMsgs.WithLabelValues(cfg.Name, "publish_error").Inc()
+					start = time.Now()
+				}
+				err = natsConn.PublishMsg(msg)
+				tracing.SetError(sspan, err)
+				sspan.End()
+				if err != nil {
+					if n.Cfg.Debug {
+						n.logger.Printf("%s failed to write to nats subject '%s': %v", workerLogPrefix, msg.Subject, err)
+					}
+					if n.Cfg.EnableMetrics {
+						NatsNumberOfFailSendMsgs.WithLabelValues(cfg.Name, "publish_error").Inc()
+					}
+					natsConn.Close()
+					time.Sleep(cfg.ConnectTimeWait)
+					goto CRCONN
+				}
+				if n.Cfg.EnableMetrics {
+					NatsSendDuration.WithLabelValues(cfg.Name).Set(float64(time.Since(start).Nanoseconds()))
+					NatsNumberOfSentMsgs.WithLabelValues(cfg.Name, msg.Subject).Inc()
+					NatsNumberOfSentBytes.WithLabelValues(cfg.Name, msg.Subject).Add(float64(len(msg.Data)))
 				}
-				natsConn.Close()
-				time.Sleep(cfg.ConnectTimeWait)
-				goto CRCONN
-			}
-			if n.Cfg.EnableMetrics {
-				NatsSendDuration.WithLabelValues(cfg.Name).Set(float64(time.Since(start).Nanoseconds()))
-				NatsNumberOfSentMsgs.WithLabelValues(cfg.Name, subject).Inc()
-				NatsNumberOfSentBytes.WithLabelValues(cfg.Name, subject).Add(float64(len(b)))
 			}
 		}
 	}
 }
 
+// natsMsgs returns the messages to publish for the marshaled message b.
+// The messages larger than max-message-size are split or truncated according to the oversize-policy,
+// the ones that still do not fit are published to the dlq-subject if set, or dropped.
+func (n *NatsOutput) natsMsgs(workerLogPrefix, subject string, b []byte) []*nats.Msg {
+	fit, oversized := n.sizeLimiter.Limit(b)
+	msgs := make([]*nats.Msg, 0, len(fit)+len(oversized))
+	for _, fb := range fit {
+		msgs = append(msgs, &nats.Msg{Subject: subject, Data: fb})
+	}
+	for _, ob := range oversized {
+		if n.Cfg.DLQSubject != "" {
+			msgs = append(msgs, &nats.Msg{Subject: n.Cfg.DLQSubject, Data: ob})
+			continue
+		}
+		n.logger.Printf("%s dropping a %d bytes message exceeding max-message-size %d", workerLogPrefix, len(ob), n.Cfg.MaxMessageSize)
+		if n.Cfg.EnableMetrics {
+			NatsNumberOfFailSendMsgs.WithLabelValues(n.Cfg.Name, "oversized").Inc()
+		}
+	}
+	return msgs
+}
+
 func (n *NatsOutput) subjectName(c *Config, meta outputs.Meta) string {
 	if c.SubjectPrefix != "" {
 		ssb := strings.Builder{}
@@ -410,6 +480,10 @@ func (n *NatsOutput) subjectName(c *Config, meta outputs.Meta) string {
 	return strings.ReplaceAll(n.Cfg.Subject, " ", "_")
 }
 
+func (n *NatsOutput) BufferSize() (int, int) {
+	return len(n.msgChan), cap(n.msgChan)
+}
+
 func (n *NatsOutput) SetName(name string) {
 	sb := strings.Builder{}
 	if name != "" {
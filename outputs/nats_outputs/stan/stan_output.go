@@ -82,6 +82,7 @@ type Config struct {
 	AddTarget          string        `mapstructure:"add-target,omitempty"`
 	TargetTemplate     string        `mapstructure:"target-template,omitempty"`
 	OverrideTimestamps bool          `mapstructure:"override-timestamps,omitempty"`
+	EventVersion       int           `mapstructure:"event-version,omitempty"`
 	RecoveryWaitTime   time.Duration `mapstructure:"recovery-wait-time,omitempty"`
 	NumWorkers         int           `mapstructure:"num-workers,omitempty"`
 	Debug              bool          `mapstructure:"debug,omitempty"`
@@ -155,8 +156,9 @@ func (s *StanOutput) Init(ctx context.Context, name string, cfg map[string]inter
 	s.msgChan = make(chan *outputs.ProtoMsg)
 
 	s.mo = &formatters.MarshalOptions{
-		Format:     s.Cfg.Format,
-		OverrideTS: s.Cfg.OverrideTimestamps,
+		Format:       s.Cfg.Format,
+		OverrideTS:   s.Cfg.OverrideTimestamps,
+		EventVersion: s.Cfg.EventVersion,
 	}
 
 	if s.Cfg.TargetTemplate == "" {
@@ -188,7 +190,7 @@ func (s *StanOutput) setDefaults() error {
 	if s.Cfg.Format == "" {
 		s.Cfg.Format = defaultFormat
 	}
-	if !(s.Cfg.Format == "event" || s.Cfg.Format == "protojson" || s.Cfg.Format == "proto" || s.Cfg.Format == "json") {
+	if !(s.Cfg.Format == "event" || s.Cfg.Format == "protojson" || s.Cfg.Format == "proto" || s.Cfg.Format == formatters.FormatProtoEnvelope || s.Cfg.Format == "json") {
 		return fmt.Errorf("unsupported output format: %q for output type STAN", s.Cfg.Format)
 	}
 	if s.Cfg.Address == "" {
@@ -218,6 +220,12 @@ func (s *StanOutput) setDefaults() error {
 	if s.Cfg.PingRetry == 0 {
 		s.Cfg.PingRetry = stanDefaultPingRetry
 	}
+	if s.Cfg.EventVersion == 0 {
+		s.Cfg.EventVersion = formatters.EventVersion2
+	}
+	if err := formatters.ValidateEventVersion(s.Cfg.EventVersion); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -230,11 +238,14 @@ func (s *StanOutput) Write(ctx context.Context, rsp protoreflect.ProtoMessage, m
 	wctx, cancel := context.WithTimeout(ctx, s.Cfg.WriteTimeout)
 	defer cancel()
 
+	pm := outputs.NewProtoMsg(rsp, meta).Account()
 	select {
 	case <-ctx.Done():
+		pm.Release()
 		return
-	case s.msgChan <- outputs.NewProtoMsg(rsp, meta):
+	case s.msgChan <- pm:
 	case <-wctx.Done():
+		pm.Release()
 		if s.Cfg.Debug {
 			s.logger.Printf("writing expired after %s, STAN output might not be initialized", s.Cfg.WriteTimeout)
 		}
@@ -319,6 +330,7 @@ CRCONN:
 			s.logger.Printf("%s shutting down", workerLogPrefix)
 			return
 		case m := <-s.msgChan:
+			m.Release()
 			pmsg := m.GetMsg()
 			pmsg, err = outputs.AddSubscriptionTarget(pmsg, m.GetMeta(), s.Cfg.AddTarget, s.targetTpl)
 			if err != nil {
@@ -334,6 +346,9 @@ CRCONN:
 				}
 				continue
 			}
+			if len(b) == 0 {
+				continue
+			}
 			subject := s.subjectName(c, m.GetMeta())
 			start := time.Now()
 			err = stanConn.Publish(subject, b)
@@ -377,6 +392,10 @@ func (s *StanOutput) subjectName(c *Config, meta outputs.Meta) string {
 	return strings.ReplaceAll(s.Cfg.Subject, " ", "_")
 }
 
+func (s *StanOutput) BufferSize() (int, int) {
+	return len(s.msgChan), cap(s.msgChan)
+}
+
 func (s *StanOutput) SetName(name string) {
 	sb := strings.Builder{}
 	if name != "" {
@@ -42,6 +42,13 @@ type Output interface {
 	SetTargetsConfig(map[string]*types.TargetConfig)
 }
 
+// Buffered is implemented by the outputs queuing the written messages
+// before they are processed by their workers.
+type Buffered interface {
+	// BufferSize returns the number of queued messages and the buffer capacity.
+	BufferSize() (int, int)
+}
+
 type Initializer func() Output
 
 var Outputs = map[string]Initializer{}
@@ -58,6 +65,8 @@ var OutputTypes = map[string]struct{}{
 	"udp":              {},
 	"gnmi":             {},
 	"jetstream":        {},
+	"alerting":         {},
+	"loki":             {},
 }
 
 func Register(name string, initFn Initializer) {
@@ -66,6 +75,31 @@ func Register(name string, initFn Initializer) {
 
 type Meta map[string]string
 
+// AddTargetConfig adds the target namespace, event tags and variables to the metadata.
+func (m Meta) AddTargetConfig(tc *types.TargetConfig) {
+	if tc.Namespace != "" {
+		m["namespace"] = tc.Namespace
+	}
+	for k, v := range tc.EventTags {
+		m[k] = v
+	}
+	for k, v := range tc.Vars {
+		m[formatters.VarsMetaPrefix+k] = v
+	}
+}
+
+// Vars returns the target variables carried in the metadata,
+// templates can reference them as {{ .Vars.site }}.
+func (m Meta) Vars() map[string]string {
+	vars := make(map[string]string)
+	for k, v := range m {
+		if strings.HasPrefix(k, formatters.VarsMetaPrefix) {
+			vars[strings.TrimPrefix(k, formatters.VarsMetaPrefix)] = v
+		}
+	}
+	return vars
+}
+
 func DecodeConfig(src, dst interface{}) error {
 	decoder, err := mapstructure.NewDecoder(
 		&mapstructure.DecoderConfig{
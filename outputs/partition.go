@@ -0,0 +1,81 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package outputs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	PartitionByHour = "hour"
+	PartitionByDay  = "day"
+
+	defaultHourPartitionLayout = "date=2006-01-02/hour=15"
+	defaultDayPartitionLayout  = "date=2006-01-02"
+)
+
+// PartitionLayout returns the Go time layout used to build
+// the partition key for the given partitioning period.
+// If layout is not empty, it is returned as is.
+func PartitionLayout(by, layout string) (string, error) {
+	switch by {
+	case PartitionByHour:
+		if layout == "" {
+			return defaultHourPartitionLayout, nil
+		}
+	case PartitionByDay:
+		if layout == "" {
+			return defaultDayPartitionLayout, nil
+		}
+	default:
+		return "", fmt.Errorf("unknown partition period %q, must be one of %q", by, []string{PartitionByHour, PartitionByDay})
+	}
+	return layout, nil
+}
+
+// PartitionKey returns the partition key of a message with timestamp ts.
+// The timestamp is truncated to the start of its partition period (in UTC)
+// before being formatted using layout.
+func PartitionKey(ts time.Time, by, layout string) string {
+	ts = ts.UTC()
+	switch by {
+	case PartitionByHour:
+		ts = ts.Truncate(time.Hour)
+	case PartitionByDay:
+		ts = time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return ts.Format(layout)
+}
+
+// MsgTimestamp returns the timestamp carried by a gNMI message.
+// It returns the zero time if the message does not have a timestamp,
+// e.g: a SubscribeResponse of type SyncResponse.
+func MsgTimestamp(msg proto.Message) time.Time {
+	var ts int64
+	switch msg := msg.(type) {
+	case *gnmi.SubscribeResponse:
+		ts = msg.GetUpdate().GetTimestamp()
+	case *gnmi.GetResponse:
+		for _, n := range msg.GetNotification() {
+			if n.GetTimestamp() > ts {
+				ts = n.GetTimestamp()
+			}
+		}
+	case *gnmi.SetResponse:
+		ts = msg.GetTimestamp()
+	}
+	if ts <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ts)
+}
@@ -0,0 +1,168 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package outputs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPartitionLayout(t *testing.T) {
+	tests := []struct {
+		by, layout string
+		want       string
+		err        bool
+	}{
+		{by: PartitionByHour, want: defaultHourPartitionLayout},
+		{by: PartitionByDay, want: defaultDayPartitionLayout},
+		{by: PartitionByDay, layout: "2006/01/02", want: "2006/01/02"},
+		{by: "week", err: true},
+		{by: "", err: true},
+	}
+	for _, tt := range tests {
+		got, err := PartitionLayout(tt.by, tt.layout)
+		if (err != nil) != tt.err {
+			t.Errorf("PartitionLayout(%q, %q) error=%v, expected error=%v", tt.by, tt.layout, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("PartitionLayout(%q, %q)=%q, want %q", tt.by, tt.layout, got, tt.want)
+		}
+	}
+}
+
+func TestPartitionKey(t *testing.T) {
+	cest := time.FixedZone("CEST", 2*60*60)
+	tests := []struct {
+		name   string
+		ts     time.Time
+		by     string
+		layout string
+		want   string
+	}{
+		{
+			name:   "hour",
+			ts:     time.Date(2022, 6, 1, 13, 45, 12, 500, time.UTC),
+			by:     PartitionByHour,
+			layout: defaultHourPartitionLayout,
+			want:   "date=2022-06-01/hour=13",
+		},
+		{
+			name:   "day",
+			ts:     time.Date(2022, 6, 1, 23, 59, 59, 0, time.UTC),
+			by:     PartitionByDay,
+			layout: defaultDayPartitionLayout,
+			want:   "date=2022-06-01",
+		},
+		{
+			name:   "hour from another time zone",
+			ts:     time.Date(2022, 6, 1, 1, 30, 0, 0, cest),
+			by:     PartitionByHour,
+			layout: defaultHourPartitionLayout,
+			want:   "date=2022-05-31/hour=23",
+		},
+		{
+			name:   "day from another time zone",
+			ts:     time.Date(2022, 6, 1, 1, 30, 0, 0, cest),
+			by:     PartitionByDay,
+			layout: defaultDayPartitionLayout,
+			want:   "date=2022-05-31",
+		},
+		{
+			name:   "day truncated with a custom layout",
+			ts:     time.Date(2022, 6, 1, 13, 45, 0, 0, time.UTC),
+			by:     PartitionByDay,
+			layout: "2006/01/02/15",
+			want:   "2022/06/01/00",
+		},
+		{
+			name:   "hour truncated with a custom layout",
+			ts:     time.Date(2022, 6, 1, 13, 45, 30, 0, time.UTC),
+			by:     PartitionByHour,
+			layout: "20060102T150405",
+			want:   "20220601T130000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PartitionKey(tt.ts, tt.by, tt.layout); got != tt.want {
+				t.Errorf("PartitionKey()=%q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgTimestamp(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  proto.Message
+		want int64
+	}{
+		{
+			name: "subscribe response update",
+			msg: &gnmi.SubscribeResponse{
+				Response: &gnmi.SubscribeResponse_Update{
+					Update: &gnmi.Notification{Timestamp: 1654091112000000000},
+				},
+			},
+			want: 1654091112000000000,
+		},
+		{
+			name: "subscribe response sync",
+			msg: &gnmi.SubscribeResponse{
+				Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true},
+			},
+		},
+		{
+			name: "get response latest notification",
+			msg: &gnmi.GetResponse{
+				Notification: []*gnmi.Notification{
+					{Timestamp: 1654091112000000000},
+					{Timestamp: 1654091113000000000},
+					{Timestamp: 1654091111000000000},
+				},
+			},
+			want: 1654091113000000000,
+		},
+		{
+			name: "empty get response",
+			msg:  &gnmi.GetResponse{},
+		},
+		{
+			name: "set response",
+			msg:  &gnmi.SetResponse{Timestamp: 1654091114000000000},
+			want: 1654091114000000000,
+		},
+		{
+			name: "negative timestamp",
+			msg:  &gnmi.SetResponse{Timestamp: -1},
+		},
+		{
+			name: "other message",
+			msg:  &gnmi.CapabilityResponse{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MsgTimestamp(tt.msg)
+			if tt.want == 0 {
+				if !got.IsZero() {
+					t.Errorf("MsgTimestamp()=%v, want the zero time", got)
+				}
+				return
+			}
+			if got.UnixNano() != tt.want {
+				t.Errorf("MsgTimestamp()=%d, want %d", got.UnixNano(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prometheus_output
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/openconfig/gnmic/formatters"
+)
+
+// histogramConfig selects the values exposed as histograms instead of gauges.
+type histogramConfig struct {
+	// regular expressions matched against the value names
+	Paths []string `mapstructure:"paths,omitempty" json:"paths,omitempty"`
+	// histogram buckets upper bounds, defaults to the Prometheus client default buckets
+	Buckets []float64 `mapstructure:"buckets,omitempty" json:"buckets,omitempty"`
+
+	paths []*regexp.Regexp
+}
+
+// promHistogram is a histogram built from the received samples of a value.
+type promHistogram struct {
+	name    string
+	labels  []prompb.Label
+	buckets []float64
+	// per bucket observations count, not cumulative
+	counts []uint64
+	count  uint64
+	sum    float64
+	// time of the last sample, set if ExportTimestamps is true
+	time *time.Time
+	// time of the last observation, used to expire the histogram
+	addedAt time.Time
+}
+
+func (p *prometheusOutput) initHistograms() error {
+	for i, hc := range p.Cfg.Histograms {
+		if len(hc.Paths) == 0 {
+			return fmt.Errorf("histograms index %d: missing paths", i)
+		}
+		hc.paths = make([]*regexp.Regexp, 0, len(hc.Paths))
+		for _, path := range hc.Paths {
+			re, err := regexp.Compile(path)
+			if err != nil {
+				return fmt.Errorf("histograms index %d: invalid path regex %q: %v", i, path, err)
+			}
+			hc.paths = append(hc.paths, re)
+		}
+		if len(hc.Buckets) == 0 {
+			hc.Buckets = prometheus.DefBuckets
+			continue
+		}
+		sort.Float64s(hc.Buckets)
+		for j := 1; j < len(hc.Buckets); j++ {
+			if hc.Buckets[j] == hc.Buckets[j-1] {
+				return fmt.Errorf("histograms index %d: duplicate bucket %v", i, hc.Buckets[j])
+			}
+		}
+	}
+	if len(p.Cfg.Histograms) > 0 && p.Cfg.CacheConfig != nil {
+		p.logger.Printf("histograms are not supported when the cache is enabled, they will be ignored")
+	}
+	return nil
+}
+
+// histogramConfig returns the histogram config matching the value name vName, if any.
+func (p *prometheusOutput) histogramConfig(vName string) *histogramConfig {
+	if p.gnmiCache != nil {
+		return nil
+	}
+	for _, hc := range p.Cfg.Histograms {
+		for _, re := range hc.paths {
+			if re.MatchString(vName) {
+				return hc
+			}
+		}
+	}
+	return nil
+}
+
+// observeHistograms adds the numeric values of the event ev matching a histogram config
+// to their histogram.
+// Must be called with the lock held.
+func (p *prometheusOutput) observeHistograms(ev *formatters.EventMsg, now time.Time) {
+	if len(p.Cfg.Histograms) == 0 {
+		return
+	}
+	var labels []prompb.Label
+	for vName, val := range ev.Values {
+		hc := p.histogramConfig(vName)
+		if hc == nil {
+			continue
+		}
+		v, err := getFloat(val)
+		if err != nil {
+			continue
+		}
+		if labels == nil {
+			labels = p.mb.GetLabels(ev)
+		}
+		name := p.mb.MetricName(ev.Name, vName)
+		key := (&promMetric{name: name, labels: labels}).calculateKey()
+		h, ok := p.histograms[key]
+		if !ok {
+			h = &promHistogram{
+				name:    name,
+				labels:  labels,
+				buckets: hc.Buckets,
+				counts:  make([]uint64, len(hc.Buckets)),
+			}
+			p.histograms[key] = h
+		}
+		h.observe(v)
+		h.addedAt = now
+		if p.Cfg.ExportTimestamps {
+			tm := time.Unix(0, ev.Timestamp)
+			if p.Cfg.OverrideTimestamps {
+				tm = now
+			}
+			h.time = &tm
+		}
+		if p.Cfg.Debug {
+			p.logger.Printf("observed value %v in histogram key=%d, name=%s", v, key, name)
+		}
+	}
+}
+
+func (h *promHistogram) observe(v float64) {
+	// index of the first bucket with an upper bound >= v
+	i := sort.SearchFloat64s(h.buckets, v)
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// metric returns the histogram as a prometheus.Metric.
+func (h *promHistogram) metric() (prometheus.Metric, error) {
+	labelNames := make([]string, 0, len(h.labels))
+	labelValues := make([]string, 0, len(h.labels))
+	for _, l := range h.labels {
+		labelNames = append(labelNames, l.Name)
+		labelValues = append(labelValues, l.Value)
+	}
+	buckets := make(map[float64]uint64, len(h.buckets))
+	var cumulative uint64
+	for i, b := range h.buckets {
+		cumulative += h.counts[i]
+		buckets[b] = cumulative
+	}
+	desc := prometheus.NewDesc(h.name, defaultMetricHelp, labelNames, nil)
+	m, err := prometheus.NewConstHistogram(desc, h.count, h.sum, buckets, labelValues...)
+	if err != nil {
+		return nil, err
+	}
+	if h.time != nil {
+		return prometheus.NewMetricWithTimestamp(*h.time, m), nil
+	}
+	return m, nil
+}
@@ -0,0 +1,99 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prometheus_output
+
+import (
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/openconfig/gnmic/formatters"
+	promcom "github.com/openconfig/gnmic/outputs/prometheus_output"
+)
+
+func TestHistograms(t *testing.T) {
+	p := &prometheusOutput{
+		Cfg: &config{
+			Histograms: []*histogramConfig{
+				{Paths: []string{"latency$"}, Buckets: []float64{100, 10, 50}},
+			},
+		},
+		entries:    make(map[uint64]*promMetric),
+		histograms: make(map[uint64]*promHistogram),
+		mb:         &promcom.MetricBuilder{},
+	}
+	err := p.initHistograms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for _, v := range []float64{5, 20, 20, 70, 500} {
+		ev := &formatters.EventMsg{
+			Name: "sub1",
+			Tags: map[string]string{"source": "router1"},
+			Values: map[string]interface{}{
+				"/probe/latency":   v,
+				"/probe/sent-pkts": 1,
+			},
+		}
+		p.observeHistograms(ev, now)
+		pms := p.metricsFromEvent(ev, now)
+		if len(pms) != 1 || pms[0].name != "probe_sent_pkts" {
+			t.Fatalf("unexpected gauges: %v", pms)
+		}
+	}
+	if len(p.histograms) != 1 {
+		t.Fatalf("expected 1 histogram, got %d", len(p.histograms))
+	}
+	for _, h := range p.histograms {
+		m, err := h.metric()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := new(dto.Metric)
+		err = m.Write(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hist := out.GetHistogram()
+		if hist.GetSampleCount() != 5 || hist.GetSampleSum() != 615 {
+			t.Errorf("unexpected count=%d, sum=%v", hist.GetSampleCount(), hist.GetSampleSum())
+		}
+		want := map[float64]uint64{10: 1, 50: 3, 100: 4}
+		for _, b := range hist.GetBucket() {
+			if want[b.GetUpperBound()] != b.GetCumulativeCount() {
+				t.Errorf("bucket %v: got %d, want %d", b.GetUpperBound(), b.GetCumulativeCount(), want[b.GetUpperBound()])
+			}
+		}
+	}
+
+	// expired histograms are removed
+	p.Cfg.Expiration = time.Minute
+	for _, h := range p.histograms {
+		h.addedAt = now.Add(-2 * time.Minute)
+	}
+	p.expireMetrics()
+	if len(p.histograms) != 0 {
+		t.Errorf("expected the histogram to expire")
+	}
+}
+
+func TestInitHistogramsErrors(t *testing.T) {
+	for i, hcs := range [][]*histogramConfig{
+		{{}},
+		{{Paths: []string{"("}}},
+		{{Paths: []string{"a"}, Buckets: []float64{1, 1}}},
+	} {
+		p := &prometheusOutput{Cfg: &config{Histograms: hcs}}
+		if err := p.initHistograms(); err == nil {
+			t.Errorf("config %d: expected an error", i)
+		}
+	}
+}
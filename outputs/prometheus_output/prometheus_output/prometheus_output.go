@@ -54,6 +54,10 @@ const (
 	// this is used to timeout the collection method
 	// in case it drags for too long
 	defaultTimeout = 10 * time.Second
+
+	// deletes modes
+	deletesIgnore = "ignore"
+	deletesExpire = "expire"
 )
 
 type promMetric struct {
@@ -69,11 +73,12 @@ type promMetric struct {
 func init() {
 	outputs.Register(outputType, func() outputs.Output {
 		return &prometheusOutput{
-			Cfg:       &config{},
-			eventChan: make(chan *formatters.EventMsg),
-			wg:        new(sync.WaitGroup),
-			entries:   make(map[uint64]*promMetric),
-			logger:    log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags),
+			Cfg:        &config{},
+			eventChan:  make(chan *formatters.EventMsg),
+			wg:         new(sync.WaitGroup),
+			entries:    make(map[uint64]*promMetric),
+			histograms: make(map[uint64]*promHistogram),
+			logger:     log.New(io.Discard, loggingPrefix, utils.DefaultLoggingFlags),
 		}
 	})
 }
@@ -86,7 +91,8 @@ type prometheusOutput struct {
 	wg     *sync.WaitGroup
 	server *http.Server
 	sync.Mutex
-	entries map[uint64]*promMetric
+	entries    map[uint64]*promMetric
+	histograms map[uint64]*promHistogram
 
 	mb           *promcom.MetricBuilder
 	evps         []formatters.EventProcessor
@@ -101,6 +107,7 @@ type prometheusOutput struct {
 type config struct {
 	Name                   string               `mapstructure:"name,omitempty" json:"name,omitempty"`
 	Listen                 string               `mapstructure:"listen,omitempty" json:"listen,omitempty"`
+	SocketPermissions      string               `mapstructure:"socket-permissions,omitempty" json:"socket-permissions,omitempty"`
 	Path                   string               `mapstructure:"path,omitempty" json:"path,omitempty"`
 	Expiration             time.Duration        `mapstructure:"expiration,omitempty" json:"expiration,omitempty"`
 	MetricPrefix           string               `mapstructure:"metric-prefix,omitempty" json:"metric-prefix,omitempty"`
@@ -115,6 +122,13 @@ type config struct {
 	ServiceRegistration    *serviceRegistration `mapstructure:"service-registration,omitempty" json:"service-registration,omitempty"`
 	Timeout                time.Duration        `mapstructure:"timeout,omitempty" json:"timeout,omitempty"`
 	CacheConfig            *cache.Config        `mapstructure:"cache,omitempty" json:"cache-config,omitempty"`
+	Deletes                string               `mapstructure:"deletes,omitempty" json:"deletes,omitempty"`
+	Histograms             []*histogramConfig   `mapstructure:"histograms,omitempty" json:"histograms,omitempty"`
+	// TLS, the certificate is reloaded when the files change
+	CaFile   string            `mapstructure:"ca-file,omitempty" json:"ca-file,omitempty"`
+	CertFile string            `mapstructure:"cert-file,omitempty" json:"cert-file,omitempty"`
+	KeyFile  string            `mapstructure:"key-file,omitempty" json:"key-file,omitempty"`
+	ACME     *utils.ACMEConfig `mapstructure:"acme,omitempty" json:"acme,omitempty"`
 
 	clusterName string
 	address     string
@@ -213,6 +227,10 @@ func (p *prometheusOutput) Init(ctx context.Context, name string, cfg map[string
 		}
 		p.targetsMeta = ttlcache.New(ttlcache.WithTTL[string, outputs.Meta](p.Cfg.Expiration))
 	}
+	err = p.initHistograms()
+	if err != nil {
+		return err
+	}
 
 	// create prometheus registry
 	registry := prometheus.NewRegistry()
@@ -231,9 +249,15 @@ func (p *prometheusOutput) Init(ctx context.Context, name string, cfg map[string
 		Addr:    p.Cfg.Listen,
 		Handler: mux,
 	}
+	if p.tlsEnabled() {
+		p.server.TLSConfig, err = utils.NewServerTLSConfig(ctx, p.Cfg.CaFile, p.Cfg.CertFile, p.Cfg.KeyFile, false, p.Cfg.ACME, p.logger)
+		if err != nil {
+			return err
+		}
+	}
 
-	// create tcp listener
-	listener, err := net.Listen("tcp", p.Cfg.Listen)
+	// create tcp or unix socket listener
+	listener, err := utils.Listen(p.Cfg.Listen, p.Cfg.SocketPermissions)
 	if err != nil {
 		return err
 	}
@@ -244,7 +268,11 @@ func (p *prometheusOutput) Init(ctx context.Context, name string, cfg map[string
 	go p.expireMetricsPeriodic(wctx)
 	go func() {
 		defer p.wg.Done()
-		err = p.server.Serve(listener)
+		if p.server.TLSConfig != nil {
+			err = p.server.ServeTLS(listener, "", "")
+		} else {
+			err = p.server.Serve(listener)
+		}
 		if err != nil && err != http.ErrServerClosed {
 			p.logger.Printf("prometheus server error: %v", err)
 		}
@@ -281,6 +309,13 @@ func (p *prometheusOutput) Write(ctx context.Context, rsp proto.Message, meta ou
 			p.targetsMeta.Set(measName+"/"+target, meta, ttlcache.DefaultTTL)
 			return
 		}
+		if p.Cfg.Deletes == deletesExpire {
+			if dels := formatters.NotificationDeletes(measName, rsp.GetUpdate(), meta); len(dels) > 0 {
+				p.Lock()
+				p.deleteMetrics(dels)
+				p.Unlock()
+			}
+		}
 		events, err := formatters.ResponseToEventMsgs(measName, rsp, meta, p.evps...)
 		if err != nil {
 			p.logger.Printf("failed to convert message to event: %v", err)
@@ -361,6 +396,19 @@ func (p *prometheusOutput) Collect(ch chan<- prometheus.Metric) {
 		case ch <- entry:
 		}
 	}
+	for key, h := range p.histograms {
+		m, err := h.metric()
+		if err != nil {
+			p.logger.Printf("failed to build histogram key=%d: %v", key, err)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			p.logger.Printf("collection context terminated: %v", ctx.Err())
+			return
+		case ch <- m:
+		}
+	}
 }
 
 func (p *prometheusOutput) worker(ctx context.Context) {
@@ -373,8 +421,10 @@ func (p *prometheusOutput) worker(ctx context.Context) {
 			if p.Cfg.Debug {
 				p.logger.Printf("got event to store: %+v", ev)
 			}
+			now := time.Now()
 			p.Lock()
-			for _, pm := range p.metricsFromEvent(ev, time.Now()) {
+			p.observeHistograms(ev, now)
+			for _, pm := range p.metricsFromEvent(ev, now) {
 				key := pm.calculateKey()
 				if e, ok := p.entries[key]; ok && pm.time != nil {
 					if e.time.Before(*pm.time) {
@@ -408,6 +458,64 @@ func (p *prometheusOutput) expireMetrics() {
 			delete(p.entries, k)
 		}
 	}
+	for k, h := range p.histograms {
+		if h.addedAt.Before(expiry) {
+			delete(p.histograms, k)
+		}
+	}
+}
+
+// deleteMetrics removes the stored metrics under the deleted paths,
+// they are then reported as stale by Prometheus on its next scrape.
+// A metric is under a deleted path if its name starts with the deleted path metric name
+// and its labels include the deleted path labels.
+// Must be called with the lock held.
+func (p *prometheusOutput) deleteMetrics(dels []*formatters.DeleteMsg) {
+	for _, d := range dels {
+		name := strings.TrimRight(p.mb.MetricName(d.Name, d.Path), "_")
+		labels := p.mb.GetLabels(&formatters.EventMsg{Tags: d.Tags})
+		for k, e := range p.entries {
+			if e.name != name && !strings.HasPrefix(e.name, name+"_") {
+				continue
+			}
+			if !includesLabels(e.labels, labels) {
+				continue
+			}
+			delete(p.entries, k)
+			if p.Cfg.Debug {
+				p.logger.Printf("deleted key=%d, metric: %+v", k, e)
+			}
+		}
+		for k, h := range p.histograms {
+			if h.name != name && !strings.HasPrefix(h.name, name+"_") {
+				continue
+			}
+			if !includesLabels(h.labels, labels) {
+				continue
+			}
+			delete(p.histograms, k)
+			if p.Cfg.Debug {
+				p.logger.Printf("deleted histogram key=%d, name=%s", k, h.name)
+			}
+		}
+	}
+}
+
+// includesLabels returns true if all the labels in subset are in labels.
+func includesLabels(labels, subset []prompb.Label) bool {
+OUTER:
+	for _, sl := range subset {
+		for _, l := range labels {
+			if l.Name == sl.Name {
+				if l.Value != sl.Value {
+					return false
+				}
+				continue OUTER
+			}
+		}
+		return false
+	}
+	return true
 }
 
 func (p *prometheusOutput) expireMetricsPeriodic(ctx context.Context) {
@@ -444,8 +552,24 @@ func (p *prometheusOutput) setDefaults() error {
 	if p.Cfg.Timeout <= 0 {
 		p.Cfg.Timeout = defaultTimeout
 	}
+	switch p.Cfg.Deletes {
+	case "", deletesIgnore:
+		p.Cfg.Deletes = deletesIgnore
+	case deletesExpire:
+	default:
+		return fmt.Errorf("unknown deletes mode %q, must be one of %q", p.Cfg.Deletes, []string{deletesIgnore, deletesExpire})
+	}
 
+	if p.Cfg.ACME != nil {
+		err := p.Cfg.ACME.Validate()
+		if err != nil {
+			return err
+		}
+	}
 	p.setServiceRegistrationDefaults()
+	if utils.IsUnixSocketAddress(p.Cfg.Listen) {
+		return nil
+	}
 	var err error
 	var port string
 	p.Cfg.address, port, err = net.SplitHostPort(p.Cfg.Listen)
@@ -609,6 +733,9 @@ func (p *prometheusOutput) metricsFromEvent(ev *formatters.EventMsg, now time.Ti
 	pms := make([]*promMetric, 0, len(ev.Values))
 	labels := p.mb.GetLabels(ev)
 	for vName, val := range ev.Values {
+		if p.histogramConfig(vName) != nil {
+			continue
+		}
 		v, err := getFloat(val)
 		if err != nil {
 			if !p.Cfg.StringsAsLabels {
@@ -633,3 +760,8 @@ func (p *prometheusOutput) metricsFromEvent(ev *formatters.EventMsg, now time.Ti
 	}
 	return pms
 }
+
+// tlsEnabled returns true if the metrics are served over HTTPS.
+func (p *prometheusOutput) tlsEnabled() bool {
+	return p.Cfg.ACME != nil || (p.Cfg.CertFile != "" && p.Cfg.KeyFile != "")
+}
@@ -18,6 +18,8 @@ import (
 
 	"github.com/hashicorp/consul/api"
 	"github.com/openconfig/gnmic/lockers"
+	"github.com/openconfig/gnmic/outputs"
+	"github.com/openconfig/gnmic/utils"
 )
 
 const (
@@ -50,6 +52,10 @@ func (p *prometheusOutput) registerService(ctx context.Context) {
 	if p.Cfg.ServiceRegistration == nil {
 		return
 	}
+	if utils.IsUnixSocketAddress(p.Cfg.Listen) {
+		p.logger.Printf("service registration is not supported with a unix socket listen address %q", p.Cfg.Listen)
+		return
+	}
 	var err error
 	clientConfig := &api.Config{
 		Address:    p.Cfg.ServiceRegistration.Address,
@@ -170,13 +176,13 @@ func (p *prometheusOutput) setServiceRegistrationDefaults() {
 	if p.Cfg.ServiceRegistration.httpCheckAddress != "" {
 		p.Cfg.ServiceRegistration.httpCheckAddress = filepath.Join(p.Cfg.ServiceRegistration.httpCheckAddress, p.Cfg.Path)
 		if !strings.HasPrefix(p.Cfg.ServiceRegistration.httpCheckAddress, "http") {
-			p.Cfg.ServiceRegistration.httpCheckAddress = "http://" + p.Cfg.ServiceRegistration.httpCheckAddress
+			p.Cfg.ServiceRegistration.httpCheckAddress = p.scheme() + "://" + p.Cfg.ServiceRegistration.httpCheckAddress
 		}
 		return
 	}
 	p.Cfg.ServiceRegistration.httpCheckAddress = filepath.Join(p.Cfg.Listen, p.Cfg.Path)
 	if !strings.HasPrefix(p.Cfg.ServiceRegistration.httpCheckAddress, "http") {
-		p.Cfg.ServiceRegistration.httpCheckAddress = "http://" + p.Cfg.ServiceRegistration.httpCheckAddress
+		p.Cfg.ServiceRegistration.httpCheckAddress = p.scheme() + "://" + p.Cfg.ServiceRegistration.httpCheckAddress
 	}
 }
 
@@ -275,3 +281,27 @@ func (p *prometheusOutput) acquireAndKeepLock(ctx context.Context, key string, v
 	}()
 	return doneCh, nil
 }
+
+// ScrapeEndpoint returns the listen address, the metrics path and the scheme (http or https)
+// of the prometheus output configuration cfg, defaults applied.
+func ScrapeEndpoint(cfg map[string]interface{}) (string, string, string, error) {
+	p := &prometheusOutput{Cfg: new(config)}
+	err := outputs.DecodeConfig(cfg, p.Cfg)
+	if err != nil {
+		return "", "", "", err
+	}
+	if p.Cfg.Listen == "" {
+		p.Cfg.Listen = defaultListen
+	}
+	if p.Cfg.Path == "" {
+		p.Cfg.Path = defaultPath
+	}
+	return p.Cfg.Listen, p.Cfg.Path, p.scheme(), nil
+}
+
+func (p *prometheusOutput) scheme() string {
+	if p.tlsEnabled() {
+		return "https"
+	}
+	return "http"
+}
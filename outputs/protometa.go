@@ -9,12 +9,20 @@
 package outputs
 
 import (
+	"context"
+
+	"github.com/openconfig/gnmic/membudget"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/proto"
 )
 
 type ProtoMsg struct {
 	m    proto.Message
 	meta Meta
+	// span context of the write that queued the message
+	sc trace.SpanContext
+	// bytes accounted in the memory budget
+	size int64
 }
 
 func NewProtoMsg(m proto.Message, meta Meta) *ProtoMsg {
@@ -37,3 +45,39 @@ func (m *ProtoMsg) GetMeta() Meta {
 	}
 	return m.meta
 }
+
+// WithSpanContext stores the span context found in ctx in the message,
+// so that it can be restored by the output worker processing it.
+func (m *ProtoMsg) WithSpanContext(ctx context.Context) *ProtoMsg {
+	m.sc = trace.SpanContextFromContext(ctx)
+	return m
+}
+
+// Context returns a copy of ctx holding the message span context, if any.
+func (m *ProtoMsg) Context(ctx context.Context) context.Context {
+	if m == nil || !m.sc.IsValid() {
+		return ctx
+	}
+	return trace.ContextWithSpanContext(ctx, m.sc)
+}
+
+// Account adds the message size to the outputs usage of the default memory budget,
+// it is released by the worker dequeuing the message using Release.
+func (m *ProtoMsg) Account() *ProtoMsg {
+	b := membudget.Default()
+	if b == nil {
+		return m
+	}
+	m.size = int64(proto.Size(m.m))
+	b.Acquire(membudget.Outputs, m.size)
+	return m
+}
+
+// Release removes the message size from the memory budget.
+func (m *ProtoMsg) Release() {
+	if m == nil || m.size == 0 {
+		return
+	}
+	membudget.Default().Release(membudget.Outputs, m.size)
+	m.size = 0
+}
@@ -0,0 +1,194 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package outputs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	OversizeDrop     = "drop"
+	OversizeSplit    = "split"
+	OversizeTruncate = "truncate"
+
+	defaultTruncateMarker = "...[truncated]"
+)
+
+// SizeLimiter enforces an output max message size.
+// Oversized messages are handled according to the policy:
+//   - drop: the message is not written.
+//   - split: a JSON array (e.g: format event) is split into multiple arrays that fit.
+//   - truncate: the longest JSON string values are truncated and suffixed with the marker.
+//
+// The messages that still do not fit are returned separately so that the output
+// can route them to a dead letter queue or drop them.
+type SizeLimiter struct {
+	maxSize int
+	policy  string
+	marker  string
+}
+
+// NewSizeLimiter returns a SizeLimiter, or nil if maxSize is not a positive number.
+func NewSizeLimiter(maxSize int, policy, marker string) (*SizeLimiter, error) {
+	if maxSize <= 0 {
+		return nil, nil
+	}
+	switch policy {
+	case "":
+		policy = OversizeDrop
+	case OversizeDrop, OversizeSplit, OversizeTruncate:
+	default:
+		return nil, fmt.Errorf("unknown oversize-policy %q, must be one of %q",
+			policy, []string{OversizeDrop, OversizeSplit, OversizeTruncate})
+	}
+	if marker == "" {
+		marker = defaultTruncateMarker
+	}
+	return &SizeLimiter{
+		maxSize: maxSize,
+		policy:  policy,
+		marker:  marker,
+	}, nil
+}
+
+// Limit returns the messages to write in place of b, each within the max size,
+// and the oversized messages that could not be made to fit.
+// A nil SizeLimiter returns b as is.
+func (l *SizeLimiter) Limit(b []byte) (fit, oversized [][]byte) {
+	if l == nil || len(b) <= l.maxSize {
+		return [][]byte{b}, nil
+	}
+	switch l.policy {
+	case OversizeSplit:
+		return l.split(b)
+	case OversizeTruncate:
+		tb, ok := l.truncate(b)
+		if ok {
+			return [][]byte{tb}, nil
+		}
+	}
+	return nil, [][]byte{b}
+}
+
+// split packs the elements of the JSON array b into arrays of at most maxSize bytes.
+func (l *SizeLimiter) split(b []byte) (fit, oversized [][]byte) {
+	var elems []json.RawMessage
+	err := json.Unmarshal(b, &elems)
+	if err != nil || len(elems) < 2 {
+		return nil, [][]byte{b}
+	}
+	buf := new(bytes.Buffer)
+	flush := func() {
+		if buf.Len() == 0 {
+			return
+		}
+		buf.WriteByte(']')
+		fit = append(fit, append([]byte(nil), buf.Bytes()...))
+		buf.Reset()
+	}
+	for _, e := range elems {
+		e = bytes.TrimSpace(e)
+		// brackets
+		if len(e)+2 > l.maxSize {
+			oversized = append(oversized, append(append([]byte{'['}, e...), ']'))
+			continue
+		}
+		// current content + separator + element + closing bracket
+		if buf.Len() > 0 && buf.Len()+1+len(e)+1 > l.maxSize {
+			flush()
+		}
+		if buf.Len() == 0 {
+			buf.WriteByte('[')
+		} else {
+			buf.WriteByte(',')
+		}
+		buf.Write(e)
+	}
+	flush()
+	return fit, oversized
+}
+
+// truncate shortens the longest string values of the JSON document b until it fits.
+func (l *SizeLimiter) truncate(b []byte) ([]byte, bool) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, false
+	}
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	for {
+		buf.Reset()
+		err = enc.Encode(v)
+		if err != nil {
+			return nil, false
+		}
+		nb := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+		if len(nb) <= l.maxSize {
+			return append([]byte(nil), nb...), true
+		}
+		s, set := longestString(v)
+		if len(s) <= len(l.marker) {
+			return nil, false
+		}
+		keep := len(s) - (len(nb) - l.maxSize) - len(l.marker)
+		if keep < 0 {
+			keep = 0
+		}
+		// do not cut a multi byte character
+		for keep > 0 && !utf8.RuneStart(s[keep]) {
+			keep--
+		}
+		set(s[:keep] + l.marker)
+	}
+}
+
+// longestString returns the longest string value found in v,
+// as well as a function setting its value.
+func longestString(v interface{}) (string, func(string)) {
+	var longest string
+	var set func(string)
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, e := range v {
+				if s, ok := e.(string); ok {
+					if len(s) > len(longest) {
+						k := k
+						longest = s
+						set = func(ns string) { v[k] = ns }
+					}
+					continue
+				}
+				walk(e)
+			}
+		case []interface{}:
+			for i, e := range v {
+				if s, ok := e.(string); ok {
+					if len(s) > len(longest) {
+						i := i
+						longest = s
+						set = func(ns string) { v[i] = ns }
+					}
+					continue
+				}
+				walk(e)
+			}
+		}
+	}
+	walk(v)
+	return longest, set
+}
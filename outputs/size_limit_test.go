@@ -0,0 +1,127 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package outputs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSizeLimiter(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	tests := []struct {
+		name          string
+		maxSize       int
+		policy        string
+		in            string
+		wantFit       []string
+		wantOversized []string
+	}{
+		{
+			name:    "within_limit",
+			maxSize: 100,
+			policy:  OversizeSplit,
+			in:      `[{"a":1}]`,
+			wantFit: []string{`[{"a":1}]`},
+		},
+		{
+			name:          "drop",
+			maxSize:       10,
+			policy:        OversizeDrop,
+			in:            `[{"a":1},{"b":2}]`,
+			wantOversized: []string{`[{"a":1},{"b":2}]`},
+		},
+		{
+			name:    "split",
+			maxSize: 20,
+			policy:  OversizeSplit,
+			in:      `[{"a":1}, {"b":2}, {"c":3}, {"d":4}]`,
+			wantFit: []string{`[{"a":1},{"b":2}]`, `[{"c":3},{"d":4}]`},
+		},
+		{
+			name:          "split_oversized_element",
+			maxSize:       20,
+			policy:        OversizeSplit,
+			in:            `[{"a":1},{"b":"` + long + `"},{"c":3}]`,
+			wantFit:       []string{`[{"a":1},{"c":3}]`},
+			wantOversized: []string{`[{"b":"` + long + `"}]`},
+		},
+		{
+			name:          "split_not_an_array",
+			maxSize:       10,
+			policy:        OversizeSplit,
+			in:            `{"a":"` + long + `"}`,
+			wantOversized: []string{`{"a":"` + long + `"}`},
+		},
+		{
+			name:    "truncate",
+			maxSize: 60,
+			policy:  OversizeTruncate,
+			in:      `[{"name":"sub1","values":{"v":"` + long + `","n":42}}]`,
+			wantFit: []string{`[{"name":"sub1","values":{"n":42,"v":"xxxx...[truncated]"}}]`},
+		},
+		{
+			name:          "truncate_not_enough_strings",
+			maxSize:       10,
+			policy:        OversizeTruncate,
+			in:            `[{"n":1234567890}]`,
+			wantOversized: []string{`[{"n":1234567890}]`},
+		},
+		{
+			name:          "truncate_not_json",
+			maxSize:       10,
+			policy:        OversizeTruncate,
+			in:            long,
+			wantOversized: []string{long},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewSizeLimiter(tt.maxSize, tt.policy, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			fit, oversized := l.Limit([]byte(tt.in))
+			assertMsgs(t, "fit", fit, tt.wantFit)
+			assertMsgs(t, "oversized", oversized, tt.wantOversized)
+			for _, b := range fit {
+				if len(b) > tt.maxSize {
+					t.Errorf("message %q exceeds the max size %d", b, tt.maxSize)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSizeLimiter(t *testing.T) {
+	l, err := NewSizeLimiter(0, "", "")
+	if err != nil || l != nil {
+		t.Fatalf("expected no limiter, got %v, %v", l, err)
+	}
+	fit, oversized := l.Limit([]byte("msg"))
+	if len(fit) != 1 || len(oversized) != 0 {
+		t.Errorf("expected a nil limiter to return the message as is")
+	}
+	_, err = NewSizeLimiter(10, "compress", "")
+	if err == nil {
+		t.Errorf("expected an unknown policy error")
+	}
+}
+
+func assertMsgs(t *testing.T, kind string, got [][]byte, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d messages %q, want %d", kind, len(got), got, len(want))
+	}
+	for i := range want {
+		if string(got[i]) != want[i] {
+			t.Errorf("%s message %d: got %s, want %s", kind, i, got[i], want[i])
+		}
+	}
+}
@@ -62,6 +62,7 @@ type Config struct {
 	AddTarget          string        `mapstructure:"add-target,omitempty"`
 	TargetTemplate     string        `mapstructure:"target-template,omitempty"`
 	OverrideTimestamps bool          `mapstructure:"override-timestamps,omitempty"`
+	EventVersion       int           `mapstructure:"event-version,omitempty"`
 	KeepAlive          time.Duration `mapstructure:"keep-alive,omitempty"`
 	RetryInterval      time.Duration `mapstructure:"retry-interval,omitempty"`
 	NumWorkers         int           `mapstructure:"num-workers,omitempty"`
@@ -119,6 +120,12 @@ func (t *TCPOutput) Init(ctx context.Context, name string, cfg map[string]interf
 	if err != nil {
 		return fmt.Errorf("wrong address format: %v", err)
 	}
+	if t.Cfg.EventVersion == 0 {
+		t.Cfg.EventVersion = formatters.EventVersion2
+	}
+	if err := formatters.ValidateEventVersion(t.Cfg.EventVersion); err != nil {
+		return err
+	}
 	t.buffer = make(chan []byte, t.Cfg.BufferSize)
 	if t.Cfg.Rate > 0 {
 		t.limiter = time.NewTicker(t.Cfg.Rate)
@@ -131,8 +138,9 @@ func (t *TCPOutput) Init(ctx context.Context, name string, cfg map[string]interf
 	}
 
 	t.mo = &formatters.MarshalOptions{
-		Format:     t.Cfg.Format,
-		OverrideTS: t.Cfg.OverrideTimestamps,
+		Format:       t.Cfg.Format,
+		OverrideTS:   t.Cfg.OverrideTimestamps,
+		EventVersion: t.Cfg.EventVersion,
 	}
 
 	if t.Cfg.TargetTemplate == "" {
@@ -173,6 +181,9 @@ func (t *TCPOutput) Write(ctx context.Context, m proto.Message, meta outputs.Met
 			t.logger.Printf("failed marshaling proto msg: %v", err)
 			return
 		}
+		if len(b) == 0 {
+			return
+		}
 		t.buffer <- b
 	}
 }
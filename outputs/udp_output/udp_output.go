@@ -62,6 +62,7 @@ type Config struct {
 	AddTarget          string        `mapstructure:"add-target,omitempty"`
 	TargetTemplate     string        `mapstructure:"target-template,omitempty"`
 	OverrideTimestamps bool          `mapstructure:"override-timestamps,omitempty"`
+	EventVersion       int           `mapstructure:"event-version,omitempty"`
 	RetryInterval      time.Duration `mapstructure:"retry-interval,omitempty"`
 	EnableMetrics      bool          `mapstructure:"enable-metrics,omitempty"`
 	EventProcessors    []string      `mapstructure:"event-processors,omitempty"`
@@ -119,6 +120,12 @@ func (u *UDPSock) Init(ctx context.Context, name string, cfg map[string]interfac
 	if err != nil {
 		return fmt.Errorf("wrong address format: %v", err)
 	}
+	if u.Cfg.EventVersion == 0 {
+		u.Cfg.EventVersion = formatters.EventVersion2
+	}
+	if err := formatters.ValidateEventVersion(u.Cfg.EventVersion); err != nil {
+		return err
+	}
 	if u.Cfg.RetryInterval == 0 {
 		u.Cfg.RetryInterval = defaultRetryTimer
 	}
@@ -133,8 +140,9 @@ func (u *UDPSock) Init(ctx context.Context, name string, cfg map[string]interfac
 	}()
 	ctx, u.cancelFn = context.WithCancel(ctx)
 	u.mo = &formatters.MarshalOptions{
-		Format:     u.Cfg.Format,
-		OverrideTS: u.Cfg.OverrideTimestamps,
+		Format:       u.Cfg.Format,
+		OverrideTS:   u.Cfg.OverrideTimestamps,
+		EventVersion: u.Cfg.EventVersion,
 	}
 	if u.Cfg.TargetTemplate == "" {
 		u.targetTpl = outputs.DefaultTargetTemplate
@@ -167,6 +175,9 @@ func (u *UDPSock) Write(ctx context.Context, m proto.Message, meta outputs.Meta)
 			u.logger.Printf("failed marshaling proto msg: %v", err)
 			return
 		}
+		if len(b) == 0 {
+			return
+		}
 		u.buffer <- b
 	}
 }
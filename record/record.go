@@ -0,0 +1,221 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package record implements a lossless and compact format to record
+// gNMI SubscribeResponse messages: a stream of protobuf messages,
+// each one prefixed with its length encoded as a varint, optionally
+// compressed with zstd.
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	CompressionNone = ""
+	CompressionZstd = "zstd"
+
+	// max size of a single recorded message
+	maxMessageSize = 256 * 1024 * 1024
+)
+
+// zstd frames magic number, used to detect compressed records
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// Frame prefixes the marshaled message b with its length.
+func Frame(b []byte) []byte {
+	fb := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(b))
+	n := binary.PutUvarint(fb, uint64(len(b)))
+	return append(fb[:n], b...)
+}
+
+// Writer writes length prefixed messages to an underlying io.Writer.
+// It is safe for concurrent use.
+type Writer struct {
+	m  *sync.Mutex
+	w  io.Writer
+	zw *zstd.Encoder
+}
+
+// NewWriter returns a Writer writing to w, compression is
+// either CompressionNone or CompressionZstd.
+func NewWriter(w io.Writer, compression string) (*Writer, error) {
+	rw := &Writer{
+		m: new(sync.Mutex),
+		w: w,
+	}
+	switch compression {
+	case CompressionNone:
+	case CompressionZstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		rw.zw = zw
+		rw.w = zw
+	default:
+		return nil, fmt.Errorf("unknown compression %q, must be one of %q", compression, []string{CompressionZstd})
+	}
+	return rw, nil
+}
+
+// Write marshals msg and writes it prefixed with its length.
+func (w *Writer) Write(msg proto.Message) (int, error) {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+	return w.WriteFramed(Frame(b))
+}
+
+// WriteFramed writes the already framed message b, see Frame.
+func (w *Writer) WriteFramed(b []byte) (int, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.w.Write(b)
+}
+
+// Close flushes and closes the zstd encoder if compression is enabled,
+// it does not close the underlying io.Writer.
+func (w *Writer) Close() error {
+	if w.zw == nil {
+		return nil
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.zw.Close()
+}
+
+// Reader reads the length prefixed messages written by a Writer,
+// zstd compressed records are detected automatically.
+type Reader struct {
+	r  *bufio.Reader
+	zr *zstd.Decoder
+	// number of uncompressed bytes of the complete messages read
+	offset int64
+}
+
+// NewReader returns a Reader reading from r.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if !bytes.Equal(magic, zstdMagic) {
+		return &Reader{r: br}, nil
+	}
+	zr, err := zstd.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{
+		r:  bufio.NewReader(zr),
+		zr: zr,
+	}, nil
+}
+
+// Read reads the next SubscribeResponse, it returns io.EOF
+// when there are no more messages.
+func (r *Reader) Read() (*gnmi.SubscribeResponse, error) {
+	rsp := new(gnmi.SubscribeResponse)
+	err := r.ReadMsg(rsp)
+	if err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
+
+// ReadMsg reads the next message into msg, it returns io.EOF
+// when there are no more messages.
+func (r *Reader) ReadMsg(msg proto.Message) error {
+	b, err := r.ReadRaw()
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(b, msg)
+}
+
+// ReadRaw reads the next message without unmarshaling it, it returns io.EOF
+// when there are no more messages.
+// A message truncated by the end of the stream returns an error wrapping io.ErrUnexpectedEOF.
+func (r *Reader) ReadRaw() ([]byte, error) {
+	size, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read message size: %w", err)
+	}
+	if size > maxMessageSize {
+		return nil, fmt.Errorf("message size %d exceeds the max message size %d", size, maxMessageSize)
+	}
+	b := make([]byte, size)
+	_, err = io.ReadFull(r.r, b)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			// the size was read but not the message
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+	r.offset += int64(uvarintLen(size)) + int64(size)
+	return b, nil
+}
+
+// Offset returns the position, in uncompressed bytes,
+// following the last complete message read.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Skip discards the first n uncompressed bytes of the stream,
+// it is used to resume reading at a previously returned Offset.
+func (r *Reader) Skip(n int64) error {
+	for n > 0 {
+		c := n
+		if c > math.MaxInt32 {
+			c = math.MaxInt32
+		}
+		d, err := r.r.Discard(int(c))
+		r.offset += int64(d)
+		if err != nil {
+			return err
+		}
+		n -= int64(d)
+	}
+	return nil
+}
+
+func uvarintLen(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+// Close releases the zstd decoder resources,
+// it does not close the underlying io.Reader.
+func (r *Reader) Close() {
+	if r.zr != nil {
+		r.zr.Close()
+	}
+}
@@ -0,0 +1,152 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package record
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/protobuf/proto"
+)
+
+func testResponses() []*gnmi.SubscribeResponse {
+	return []*gnmi.SubscribeResponse{
+		{
+			Response: &gnmi.SubscribeResponse_Update{
+				Update: &gnmi.Notification{
+					Timestamp: 42,
+					Prefix:    &gnmi.Path{Target: "router1"},
+					Update: []*gnmi.Update{
+						{
+							Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}}},
+							Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 42}},
+						},
+					},
+				},
+			},
+		},
+		{
+			Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true},
+		},
+		// empty message
+		{},
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	for _, compression := range []string{CompressionNone, CompressionZstd} {
+		t.Run("compression="+compression, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			w, err := NewWriter(buf, compression)
+			if err != nil {
+				t.Fatalf("failed to create writer: %v", err)
+			}
+			rsps := testResponses()
+			for _, rsp := range rsps {
+				if _, err = w.Write(rsp); err != nil {
+					t.Fatalf("failed to write: %v", err)
+				}
+			}
+			if err = w.Close(); err != nil {
+				t.Fatalf("failed to close writer: %v", err)
+			}
+			r, err := NewReader(buf)
+			if err != nil {
+				t.Fatalf("failed to create reader: %v", err)
+			}
+			defer r.Close()
+			for i, want := range rsps {
+				got, err := r.Read()
+				if err != nil {
+					t.Fatalf("message %d: failed to read: %v", i, err)
+				}
+				if !proto.Equal(got, want) {
+					t.Errorf("message %d: expected %v, got %v", i, want, got)
+				}
+			}
+			if _, err = r.Read(); !errors.Is(err, io.EOF) {
+				t.Errorf("expected io.EOF, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	b, err := proto.Marshal(testResponses()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	framed := Frame(b)
+	r, err := NewReader(bytes.NewReader(framed[:len(framed)-2]))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if _, err = r.Read(); err == nil || errors.Is(err, io.EOF) {
+		t.Errorf("expected a truncated message error, got %v", err)
+	}
+}
+
+func TestUnknownCompression(t *testing.T) {
+	if _, err := NewWriter(new(bytes.Buffer), "gzip"); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func TestReaderOffset(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w, err := NewWriter(buf, CompressionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsps := testResponses()
+	for _, rsp := range rsps {
+		if _, err = w.Write(rsp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b := buf.Bytes()
+	r, err := NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	offset := r.Offset()
+	// resume after the first message
+	r, err = NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Skip(offset); err != nil {
+		t.Fatal(err)
+	}
+	rsp, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(rsp, rsps[1]) {
+		t.Errorf("expected the second message after skipping the first one, got %v", rsp)
+	}
+	// a truncated message is reported as an unexpected EOF and does not move the offset
+	r, err = NewReader(bytes.NewReader(b[:offset+1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Read()
+	if _, err = r.Read(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected an unexpected EOF, got %v", err)
+	}
+	if r.Offset() != offset {
+		t.Errorf("expected offset %d, got %d", offset, r.Offset())
+	}
+}
@@ -0,0 +1,242 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package spiffe obtains X.509 SVIDs from the SPIFFE Workload API
+// and builds the TLS configs authenticating gnmic and its peers with them.
+package spiffe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// environment variable holding the Workload API address
+	endpointSocketEnv  = "SPIFFE_ENDPOINT_SOCKET"
+	defaultTimeout     = 10 * time.Second
+	defaultRetryPeriod = 5 * time.Second
+)
+
+type Config struct {
+	// Workload API address, unix:///path/to/socket or tcp://ip:port.
+	// Defaults to the SPIFFE_ENDPOINT_SOCKET environment variable.
+	SocketPath string `mapstructure:"socket-path,omitempty" json:"socket-path,omitempty"`
+	// time to wait for the first SVID
+	Timeout time.Duration `mapstructure:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+func (c *Config) SetDefaults() error {
+	if c.SocketPath == "" {
+		c.SocketPath = os.Getenv(endpointSocketEnv)
+	}
+	if c.SocketPath == "" {
+		return fmt.Errorf("spiffe: missing socket-path and %s is not set", endpointSocketEnv)
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	return nil
+}
+
+// SVID is an X.509 SVID and the trust bundle of its trust domain.
+type SVID struct {
+	ID          string
+	Certificate *tls.Certificate
+	Bundle      *x509.CertPool
+}
+
+// Source keeps the workload X.509 SVID up to date
+// with the updates streamed by the Workload API.
+type Source struct {
+	cfg    *Config
+	logger *log.Logger
+
+	m     sync.RWMutex
+	svid  *SVID
+	ready chan struct{}
+	once  sync.Once
+}
+
+// NewSource connects to the Workload API and waits for the first SVID,
+// the SVID is then rotated until ctx is done.
+func NewSource(ctx context.Context, cfg *Config, logger *log.Logger) (*Source, error) {
+	err := cfg.SetDefaults()
+	if err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	s := &Source{
+		cfg:    cfg,
+		logger: logger,
+		ready:  make(chan struct{}),
+	}
+	go s.watch(ctx)
+	timer := time.NewTimer(cfg.Timeout)
+	defer timer.Stop()
+	select {
+	case <-s.ready:
+		return s, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("spiffe: no SVID received from %q after %s", cfg.SocketPath, cfg.Timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Source) watch(ctx context.Context) {
+	for {
+		err := fetchX509SVIDs(ctx, s.cfg.SocketPath, s.update)
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Printf("spiffe: workload API stream failed: %v, retrying in %s", err, defaultRetryPeriod)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(defaultRetryPeriod):
+		}
+	}
+}
+
+func (s *Source) update(svid *SVID) {
+	s.m.Lock()
+	s.svid = svid
+	s.m.Unlock()
+	s.logger.Printf("spiffe: received SVID %q", svid.ID)
+	s.once.Do(func() { close(s.ready) })
+}
+
+// SVID returns the current SVID.
+func (s *Source) SVID() *SVID {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.svid
+}
+
+// ServerTLSConfig returns a TLS config serving the workload SVID
+// and requiring the clients to present an SVID matching one of the authorized IDs.
+func (s *Source) ServerTLSConfig(authorized []string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAnyClientCert,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return s.SVID().Certificate, nil
+		},
+		VerifyPeerCertificate: s.verifyPeer(authorized),
+	}
+}
+
+// ClientTLSConfig returns a TLS config presenting the workload SVID
+// and requiring the server to present an SVID matching one of the authorized IDs.
+// The server name is not verified, the peer is identified by its SPIFFE ID.
+func (s *Source) ClientTLSConfig(authorized []string) *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return s.SVID().Certificate, nil
+		},
+		VerifyPeerCertificate: s.verifyPeer(authorized),
+	}
+}
+
+func (s *Source) verifyPeer(authorized []string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("spiffe: peer presented no certificate")
+		}
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			c, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs = append(certs, c)
+		}
+		intermediates := x509.NewCertPool()
+		for _, c := range certs[1:] {
+			intermediates.AddCert(c)
+		}
+		_, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         s.SVID().Bundle,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return fmt.Errorf("spiffe: %v", err)
+		}
+		id, err := IDFromCertificate(certs[0])
+		if err != nil {
+			return err
+		}
+		if !Authorized(id, authorized) {
+			return fmt.Errorf("spiffe: peer ID %q is not authorized", id)
+		}
+		return nil
+	}
+}
+
+// IDFromCertificate returns the SPIFFE ID of an X.509 SVID, its single spiffe URI SAN.
+func IDFromCertificate(c *x509.Certificate) (string, error) {
+	var id string
+	for _, u := range c.URIs {
+		if u.Scheme != "spiffe" {
+			continue
+		}
+		if id != "" {
+			return "", errors.New("spiffe: certificate has more than one SPIFFE ID")
+		}
+		id = u.String()
+	}
+	if id == "" {
+		return "", errors.New("spiffe: certificate has no SPIFFE ID")
+	}
+	return id, nil
+}
+
+// Authorized returns true if id matches one of the authorized IDs.
+// An authorized ID without a path, e.g: spiffe://example.org, matches any ID of that trust domain.
+func Authorized(id string, authorized []string) bool {
+	for _, a := range authorized {
+		if a == id {
+			return true
+		}
+		u, err := url.Parse(a)
+		if err == nil && (u.Path == "" || u.Path == "/") && strings.HasPrefix(id, "spiffe://"+u.Host+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+var defaultSource atomic.Value
+
+// SetDefault sets the source used by the components
+// that do not hold a reference to it, such as the targets.
+func SetDefault(s *Source) {
+	defaultSource.Store(s)
+}
+
+// Default returns the source set with SetDefault, nil if none.
+func Default() *Source {
+	s, _ := defaultSource.Load().(*Source)
+	return s
+}
@@ -0,0 +1,220 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package spiffe
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, td string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: td},
+		URIs:                  []*url.URL{{Scheme: "spiffe", Host: td}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// svid returns an X509SVID message for id, signed by the CA.
+func (ca *testCA) svid(t *testing.T, id string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		URIs:         []*url.URL{u},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, id)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendBytes(b, der)
+	b = protowire.AppendTag(b, 3, protowire.BytesType)
+	b = protowire.AppendBytes(b, keyBytes)
+	b = protowire.AppendTag(b, 4, protowire.BytesType)
+	b = protowire.AppendBytes(b, ca.cert.Raw)
+	return b
+}
+
+// startWorkloadAPI serves the given X509SVID messages on a unix socket,
+// one per X509SVIDResponse.
+func startWorkloadAPI(t *testing.T, svids ...[]byte) string {
+	t.Helper()
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}))
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "SpiffeWorkloadAPI",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "FetchX509SVID",
+			ServerStreams: true,
+			Handler: func(_ interface{}, stream grpc.ServerStream) error {
+				var req []byte
+				if err := stream.RecvMsg(&req); err != nil {
+					return err
+				}
+				for _, svid := range svids {
+					var rsp []byte
+					rsp = protowire.AppendTag(rsp, 1, protowire.BytesType)
+					rsp = protowire.AppendBytes(rsp, svid)
+					if err := stream.SendMsg(&rsp); err != nil {
+						return err
+					}
+				}
+				<-stream.Context().Done()
+				return nil
+			},
+		}},
+	}, struct{}{})
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+	return "unix://" + sock
+}
+
+func TestAuthorized(t *testing.T) {
+	tests := []struct {
+		id         string
+		authorized []string
+		want       bool
+	}{
+		{"spiffe://example.org/gnmic", []string{"spiffe://example.org/gnmic"}, true},
+		{"spiffe://example.org/gnmic", []string{"spiffe://example.org/router1"}, false},
+		{"spiffe://example.org/gnmic", []string{"spiffe://example.org"}, true},
+		{"spiffe://example.org/gnmic", []string{"spiffe://example.org/"}, true},
+		{"spiffe://example.com/gnmic", []string{"spiffe://example.org"}, false},
+		{"spiffe://example.org.evil/gnmic", []string{"spiffe://example.org"}, false},
+		{"spiffe://example.org/gnmic", nil, false},
+	}
+	for _, tt := range tests {
+		if got := Authorized(tt.id, tt.authorized); got != tt.want {
+			t.Errorf("Authorized(%q, %v) = %v, want %v", tt.id, tt.authorized, got, tt.want)
+		}
+	}
+}
+
+func TestSourceMutualTLS(t *testing.T) {
+	ca := newTestCA(t, "example.org")
+	addr := startWorkloadAPI(t, ca.svid(t, "spiffe://example.org/gnmic"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := NewSource(ctx, &Config{SocketPath: addr}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := s.SVID().ID; id != "spiffe://example.org/gnmic" {
+		t.Fatalf("unexpected SVID ID %q", id)
+	}
+
+	handshake := func(serverIDs, clientIDs []string) (error, error) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		errCh := make(chan error, 1)
+		go func() {
+			c, err := l.Accept()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			defer c.Close()
+			errCh <- tls.Server(c, s.ServerTLSConfig(serverIDs)).Handshake()
+		}()
+		c, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		cerr := tls.Client(c, s.ClientTLSConfig(clientIDs)).Handshake()
+		c.Close()
+		return <-errCh, cerr
+	}
+	serr, cerr := handshake([]string{"spiffe://example.org"}, []string{"spiffe://example.org/gnmic"})
+	if serr != nil || cerr != nil {
+		t.Fatalf("expected a successful handshake: server=%v, client=%v", serr, cerr)
+	}
+	_, cerr = handshake([]string{"spiffe://example.org"}, []string{"spiffe://example.org/router1"})
+	if cerr == nil {
+		t.Fatal("expected the client to reject the server ID")
+	}
+	serr, _ = handshake([]string{"spiffe://example.com"}, []string{"spiffe://example.org"})
+	if serr == nil {
+		t.Fatal("expected the server to reject the client ID")
+	}
+}
+
+func TestNewSourceTimeout(t *testing.T) {
+	addr := startWorkloadAPI(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := NewSource(ctx, &Config{SocketPath: addr, Timeout: 200 * time.Millisecond}, nil)
+	if err == nil {
+		t.Fatal("expected an error when no SVID is received")
+	}
+}
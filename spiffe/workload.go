@@ -0,0 +1,218 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package spiffe
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The Workload API messages are decoded by hand to avoid
+// depending on the SPIFFE generated protobuf packages.
+// See https://github.com/spiffe/spiffe/blob/main/standards/SPIFFE_Workload_API.md
+
+const fetchX509SVIDMethod = "/SpiffeWorkloadAPI/FetchX509SVID"
+
+var fetchX509SVIDDesc = &grpc.StreamDesc{
+	StreamName:    "FetchX509SVID",
+	ServerStreams: true,
+}
+
+// rawCodec marshals the messages as raw protobuf bytes.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string { return "proto" }
+
+// fetchX509SVIDs streams the X.509 SVIDs from the Workload API at addr,
+// fn is called with the default SVID of each update.
+// It returns when the stream fails or ctx is done.
+func fetchX509SVIDs(ctx context.Context, addr string, fn func(*SVID)) error {
+	conn, err := grpc.DialContext(ctx, dialTarget(addr),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(rawCodec{})),
+	)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "workload.spiffe.io", "true")
+	stream, err := conn.NewStream(ctx, fetchX509SVIDDesc, fetchX509SVIDMethod)
+	if err != nil {
+		return err
+	}
+	// X509SVIDRequest is empty
+	req := []byte{}
+	err = stream.SendMsg(&req)
+	if err != nil {
+		return err
+	}
+	err = stream.CloseSend()
+	if err != nil {
+		return err
+	}
+	for {
+		var rsp []byte
+		err = stream.RecvMsg(&rsp)
+		if err != nil {
+			return err
+		}
+		svid, err := parseX509SVIDResponse(rsp)
+		if err != nil {
+			return err
+		}
+		fn(svid)
+	}
+}
+
+// dialTarget converts a Workload API address to a gRPC dial target.
+func dialTarget(addr string) string {
+	if strings.HasPrefix(addr, "tcp://") {
+		return strings.TrimPrefix(addr, "tcp://")
+	}
+	if !strings.HasPrefix(addr, "unix:") {
+		return "unix://" + addr
+	}
+	return addr
+}
+
+// parseX509SVIDResponse returns the first SVID of an X509SVIDResponse:
+//
+//	message X509SVIDResponse {
+//	  repeated X509SVID svids = 1;
+//	  ...
+//	}
+func parseX509SVIDResponse(b []byte) (*SVID, error) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if num == 1 && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			return parseX509SVID(v)
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil, errors.New("spiffe: workload API response has no SVID")
+}
+
+// parseX509SVID decodes an X509SVID:
+//
+//	message X509SVID {
+//	  string spiffe_id = 1;
+//	  bytes x509_svid = 2;     // ASN.1 DER certificates chain
+//	  bytes x509_svid_key = 3; // ASN.1 DER PKCS#8 private key
+//	  bytes bundle = 4;        // ASN.1 DER CA certificates
+//	  ...
+//	}
+func parseX509SVID(b []byte) (*SVID, error) {
+	var id string
+	var chain, key, bundle []byte
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType || num > 4 {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch num {
+		case 1:
+			id = string(v)
+		case 2:
+			chain = v
+		case 3:
+			key = v
+		case 4:
+			bundle = v
+		}
+	}
+	certs, err := x509.ParseCertificates(chain)
+	if err != nil {
+		return nil, fmt.Errorf("spiffe: invalid SVID %q: %v", id, err)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("spiffe: SVID %q has no certificate", id)
+	}
+	pk, err := x509.ParsePKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("spiffe: invalid SVID %q key: %v", id, err)
+	}
+	signer, ok := pk.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("spiffe: unsupported SVID %q key type %T", id, pk)
+	}
+	roots, err := x509.ParseCertificates(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("spiffe: invalid SVID %q bundle: %v", id, err)
+	}
+	svid := &SVID{
+		ID: id,
+		Certificate: &tls.Certificate{
+			PrivateKey: signer,
+			Leaf:       certs[0],
+		},
+		Bundle: x509.NewCertPool(),
+	}
+	for _, c := range certs {
+		svid.Certificate.Certificate = append(svid.Certificate.Certificate, c.Raw)
+	}
+	for _, c := range roots {
+		svid.Bundle.AddCert(c)
+	}
+	return svid, nil
+}
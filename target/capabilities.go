@@ -0,0 +1,133 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// DefaultEncodingPreference is the order in which the encodings
+// supported by a target are selected when the subscription encoding is "auto".
+var DefaultEncodingPreference = []string{"proto", "json_ietf", "json"}
+
+// CapabilitiesProbe is the result of the Capabilities RPC
+// sent to a target before subscribing to it.
+type CapabilitiesProbe struct {
+	Time             time.Time    `json:"time,omitempty"`
+	GNMIVersion      string       `json:"gnmi-version,omitempty"`
+	Encodings        []string     `json:"encodings,omitempty"`
+	Models           []*ModelInfo `json:"models,omitempty"`
+	SelectedEncoding string       `json:"selected-encoding,omitempty"`
+	Error            string       `json:"error,omitempty"`
+}
+
+type ModelInfo struct {
+	Name         string `json:"name,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Version      string `json:"version,omitempty"`
+}
+
+// ProbeCapabilities sends a Capabilities RPC to the target, records the supported
+// encodings and models and selects the subscriptions encoding using the preference list pref.
+// The result is kept and can be retrieved using LastCapabilitiesProbe.
+func (t *Target) ProbeCapabilities(ctx context.Context, pref []string) (*CapabilitiesProbe, error) {
+	if len(pref) == 0 {
+		pref = DefaultEncodingPreference
+	}
+	if t.Config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Config.Timeout)
+		defer cancel()
+	}
+	p := &CapabilitiesProbe{Time: time.Now()}
+	rsp, err := t.Capabilities(ctx)
+	if err != nil {
+		p.Error = err.Error()
+		t.setCapabilitiesProbe(p)
+		return p, err
+	}
+	p.GNMIVersion = rsp.GetGNMIVersion()
+	p.Encodings = make([]string, 0, len(rsp.GetSupportedEncodings()))
+	for _, enc := range rsp.GetSupportedEncodings() {
+		p.Encodings = append(p.Encodings, strings.ToLower(enc.String()))
+	}
+	p.Models = make([]*ModelInfo, 0, len(rsp.GetSupportedModels()))
+	for _, m := range rsp.GetSupportedModels() {
+		p.Models = append(p.Models, &ModelInfo{
+			Name:         m.GetName(),
+			Organization: m.GetOrganization(),
+			Version:      m.GetVersion(),
+		})
+	}
+	p.SelectedEncoding = p.selectEncoding(pref)
+	t.setCapabilitiesProbe(p)
+	return p, nil
+}
+
+// CachedCapabilities returns the last Capabilities probe if it succeeded less than ttl ago,
+// otherwise the target is probed again. A zero ttl disables the cache.
+func (t *Target) CachedCapabilities(ctx context.Context, pref []string, ttl time.Duration) (*CapabilitiesProbe, error) {
+	if p := t.LastCapabilitiesProbe(); p != nil && p.Error == "" && ttl > 0 && time.Since(p.Time) < ttl {
+		return p, nil
+	}
+	return t.ProbeCapabilities(ctx, pref)
+}
+
+// LastCapabilitiesProbe returns the result of the last Capabilities probe,
+// nil if the target was not probed.
+func (t *Target) LastCapabilitiesProbe() *CapabilitiesProbe {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.capabilitiesProbe
+}
+
+func (t *Target) setCapabilitiesProbe(p *CapabilitiesProbe) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.capabilitiesProbe = p
+}
+
+// Supports returns true if the probed target supports the encoding enc.
+func (p *CapabilitiesProbe) Supports(enc string) bool {
+	enc = strings.ToLower(strings.ReplaceAll(enc, "-", "_"))
+	for _, e := range p.Encodings {
+		if e == enc {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateEncoding returns the encoding to use for a request configured with enc.
+// If enc is "auto" or is not supported by the target, the selected encoding is returned
+// and changed is true.
+func (p *CapabilitiesProbe) NegotiateEncoding(enc string) (string, bool) {
+	if strings.EqualFold(enc, "auto") || (enc != "" && !p.Supports(enc)) {
+		return p.SelectedEncoding, true
+	}
+	return enc, false
+}
+
+// selectEncoding returns the first encoding in pref supported by the target.
+// If none is supported, the first encoding advertised by the target is returned.
+func (p *CapabilitiesProbe) selectEncoding(pref []string) string {
+	for _, enc := range pref {
+		if p.Supports(enc) {
+			return strings.ToLower(strings.ReplaceAll(enc, "-", "_"))
+		}
+	}
+	if len(p.Encodings) > 0 {
+		return p.Encodings[0]
+	}
+	return strings.ToLower(gnmi.Encoding_JSON.String())
+}
@@ -0,0 +1,82 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import "testing"
+
+func TestSelectEncoding(t *testing.T) {
+	tests := []struct {
+		name      string
+		encodings []string
+		pref      []string
+		want      string
+	}{
+		{
+			name:      "first preferred",
+			encodings: []string{"json", "json_ietf", "proto"},
+			pref:      DefaultEncodingPreference,
+			want:      "proto",
+		},
+		{
+			name:      "second preferred",
+			encodings: []string{"json", "json_ietf"},
+			pref:      DefaultEncodingPreference,
+			want:      "json_ietf",
+		},
+		{
+			name:      "preference with dashes and upper case",
+			encodings: []string{"json", "json_ietf"},
+			pref:      []string{"JSON-IETF"},
+			want:      "json_ietf",
+		},
+		{
+			name:      "none preferred",
+			encodings: []string{"ascii", "bytes"},
+			pref:      DefaultEncodingPreference,
+			want:      "ascii",
+		},
+		{
+			name: "no encodings advertised",
+			pref: DefaultEncodingPreference,
+			want: "json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &CapabilitiesProbe{Encodings: tt.encodings}
+			if got := p.selectEncoding(tt.pref); got != tt.want {
+				t.Errorf("selectEncoding()=%q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	p := &CapabilitiesProbe{
+		Encodings:        []string{"json", "json_ietf"},
+		SelectedEncoding: "json_ietf",
+	}
+	tests := []struct {
+		enc     string
+		want    string
+		changed bool
+	}{
+		{enc: "", want: "", changed: false},
+		{enc: "json", want: "json", changed: false},
+		{enc: "json-ietf", want: "json-ietf", changed: false},
+		{enc: "AUTO", want: "json_ietf", changed: true},
+		{enc: "proto", want: "json_ietf", changed: true},
+	}
+	for _, tt := range tests {
+		got, changed := p.NegotiateEncoding(tt.enc)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("NegotiateEncoding(%q)=(%q, %v), want (%q, %v)", tt.enc, got, changed, tt.want, tt.changed)
+		}
+	}
+}
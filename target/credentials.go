@@ -0,0 +1,77 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+// Credentials are the target credentials read from a credentials provider,
+// the non nil fields override the ones of the target config.
+type Credentials struct {
+	Username *string
+	Password *string
+	Token    *string
+	// paths of the TLS files
+	TLSCA   *string
+	TLSCert *string
+	TLSKey  *string
+}
+
+// SetCredentials sets the credentials used by the next RPCs and dials of the target.
+// It is safe to call while the target is connected.
+func (t *Target) SetCredentials(c *Credentials) {
+	t.credsMu.Lock()
+	defer t.credsMu.Unlock()
+	t.creds = c
+}
+
+// dialConfig returns the target config with the credentials set by SetCredentials applied,
+// the target config itself is not modified.
+func (t *Target) dialConfig() *types.TargetConfig {
+	t.credsMu.RLock()
+	defer t.credsMu.RUnlock()
+	c := t.creds
+	if c == nil {
+		return t.Config
+	}
+	tc := *t.Config
+	for _, f := range []struct {
+		src *string
+		dst **string
+	}{
+		{c.Username, &tc.Username},
+		{c.Password, &tc.Password},
+		{c.Token, &tc.Token},
+		{c.TLSCA, &tc.TLSCA},
+		{c.TLSCert, &tc.TLSCert},
+		{c.TLSKey, &tc.TLSKey},
+	} {
+		if f.src != nil {
+			*f.dst = f.src
+		}
+	}
+	return &tc
+}
+
+// withCredentials adds the target username and password to the outgoing metadata of ctx.
+func (t *Target) withCredentials(ctx context.Context) context.Context {
+	tc := t.dialConfig()
+	if tc.Username != nil {
+		ctx = metadata.AppendToOutgoingContext(ctx, "username", *tc.Username)
+	}
+	if tc.Password != nil {
+		ctx = metadata.AppendToOutgoingContext(ctx, "password", *tc.Password)
+	}
+	return ctx
+}
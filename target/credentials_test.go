@@ -0,0 +1,50 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestSetCredentials(t *testing.T) {
+	tc := &types.TargetConfig{
+		Name:     "router1",
+		Username: strPtr("admin"),
+		Password: strPtr("old"),
+		TLSCA:    strPtr("/ca.pem"),
+	}
+	tg := NewTarget(tc)
+	if got := tg.dialConfig(); got != tc {
+		t.Errorf("expected the target config without credentials, got %+v", got)
+	}
+
+	tg.SetCredentials(&Credentials{Password: strPtr("new"), TLSCert: strPtr("/cert.pem")})
+	dc := tg.dialConfig()
+	if *dc.Username != "admin" || *dc.Password != "new" || *dc.TLSCA != "/ca.pem" || *dc.TLSCert != "/cert.pem" {
+		t.Errorf("unexpected dial config %+v", dc)
+	}
+	// the target config is left untouched
+	if *tc.Password != "old" || tc.TLSCert != nil {
+		t.Errorf("target config modified: %+v", tc)
+	}
+
+	md, _ := metadata.FromOutgoingContext(tg.withCredentials(context.TODO()))
+	want := metadata.Pairs("username", "admin", "password", "new")
+	if !reflect.DeepEqual(md, want) {
+		t.Errorf("got metadata %v, want %v", md, want)
+	}
+}
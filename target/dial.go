@@ -0,0 +1,139 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+// addressesDialer returns a dial function connecting to one of the target addresses,
+// whichever address gRPC asks for, according to the target dial strategy.
+// Since the addresses are dialed again each time the gRPC connection is re-established,
+// a target whose subscribe streams died fails over to its next reachable address.
+func (t *Target) addressesDialer(addrs []string, dial dialFn) dialFn {
+	return func(ctx context.Context, _ string) (net.Conn, error) {
+		var conn net.Conn
+		var addr string
+		var err error
+		switch t.Config.DialStrategy {
+		case types.DialStrategyHappyEyeballs:
+			conn, addr, err = happyEyeballsDial(ctx, addrs, t.Config.DialDelay, dial)
+		default:
+			conn, addr, err = orderedDial(ctx, addrs, t.Config.Timeout, dial)
+		}
+		if err != nil {
+			return nil, err
+		}
+		t.setConnectedAddress(addr)
+		return conn, nil
+	}
+}
+
+// orderedDial dials addrs in order until one succeeds,
+// each attempt is bounded by timeout.
+func orderedDial(ctx context.Context, addrs []string, timeout time.Duration, dial dialFn) (net.Conn, string, error) {
+	errs := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		actx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			actx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		conn, err := dial(actx, addr)
+		cancel()
+		if err == nil {
+			return conn, addr, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", addr, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, "", fmt.Errorf("%s", strings.Join(errs, ", "))
+}
+
+type dialResult struct {
+	conn net.Conn
+	addr string
+	err  error
+}
+
+// happyEyeballsDial races connection attempts to addrs, RFC 8305 style:
+// the attempts are started in order, each one delay after the previous one
+// or as soon as the previous one fails. The first established connection is returned,
+// the others are closed.
+func happyEyeballsDial(ctx context.Context, addrs []string, delay time.Duration, dial dialFn) (net.Conn, string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan dialResult, len(addrs))
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	next, pending := 0, 0
+	start := func() {
+		addr := addrs[next]
+		next++
+		pending++
+		go func() {
+			conn, err := dial(ctx, addr)
+			results <- dialResult{conn: conn, addr: addr, err: err}
+		}()
+		if next < len(addrs) {
+			timer.Reset(delay)
+		}
+	}
+	start()
+	errs := make([]string, 0, len(addrs))
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if next < len(addrs) {
+				start()
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				cancel()
+				// close the connections established by the attempts still running
+				go func(n int) {
+					for ; n > 0; n-- {
+						if l := <-results; l.conn != nil {
+							l.conn.Close()
+						}
+					}
+				}(pending)
+				return r.conn, r.addr, nil
+			}
+			errs = append(errs, fmt.Sprintf("%s: %v", r.addr, r.err))
+			if next < len(addrs) && ctx.Err() == nil {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				start()
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("%s", strings.Join(errs, ", "))
+}
+
+// setConnectedAddress records the address the target is connected to.
+func (t *Target) setConnectedAddress(addr string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.health.Address = addr
+}
@@ -0,0 +1,152 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+// fakeDialer returns a dial function failing for the addresses in down,
+// blocking until the context is done for the addresses in blackholed,
+// and returning a pipe connection otherwise.
+func fakeDialer(down, blackholed map[string]bool) (dialFn, func() []string) {
+	var m sync.Mutex
+	var dialed []string
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+			m.Lock()
+			dialed = append(dialed, addr)
+			m.Unlock()
+			switch {
+			case down[addr]:
+				return nil, errors.New("connection refused")
+			case blackholed[addr]:
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			c, _ := net.Pipe()
+			return c, nil
+		}, func() []string {
+			m.Lock()
+			defer m.Unlock()
+			return append([]string(nil), dialed...)
+		}
+}
+
+func TestOrderedDial(t *testing.T) {
+	addrs := []string{"10.0.0.1:57400", "[2001:db8::1]:57400", "192.168.0.1:57400"}
+	dial, dialed := fakeDialer(
+		map[string]bool{"10.0.0.1:57400": true},
+		map[string]bool{"[2001:db8::1]:57400": true},
+	)
+	conn, addr, err := orderedDial(context.Background(), addrs, 50*time.Millisecond, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if addr != "192.168.0.1:57400" {
+		t.Errorf("expected the third address, got %s", addr)
+	}
+	if n := len(dialed()); n != 3 {
+		t.Errorf("expected 3 attempts, got %d", n)
+	}
+
+	dial, _ = fakeDialer(map[string]bool{"10.0.0.1:57400": true, "192.168.0.1:57400": true}, nil)
+	_, _, err = orderedDial(context.Background(), []string{"10.0.0.1:57400", "192.168.0.1:57400"}, time.Second, dial)
+	if err == nil {
+		t.Fatal("expected an error when all the addresses are down")
+	}
+}
+
+func TestHappyEyeballsDial(t *testing.T) {
+	addrs := []string{"[2001:db8::1]:57400", "10.0.0.1:57400"}
+	// the first address does not answer, the second one is dialed after the delay
+	dial, dialed := fakeDialer(nil, map[string]bool{"[2001:db8::1]:57400": true})
+	start := time.Now()
+	conn, addr, err := happyEyeballsDial(context.Background(), addrs, 50*time.Millisecond, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if addr != "10.0.0.1:57400" {
+		t.Errorf("expected the second address, got %s", addr)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("expected the second attempt to start after the delay, took %s", d)
+	}
+	// the first address is refused, the second one is dialed without waiting for the delay
+	dial, _ = fakeDialer(map[string]bool{"[2001:db8::1]:57400": true}, nil)
+	start = time.Now()
+	conn, addr, err = happyEyeballsDial(context.Background(), addrs, time.Minute, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if addr != "10.0.0.1:57400" || time.Since(start) > time.Second {
+		t.Errorf("expected an immediate fallback to the second address, got %s", addr)
+	}
+	// the first address answers, the second one is not dialed
+	dial, dialed = fakeDialer(nil, nil)
+	conn, addr, err = happyEyeballsDial(context.Background(), addrs, time.Minute, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if addr != addrs[0] || len(dialed()) != 1 {
+		t.Errorf("expected a single attempt to the first address, got %v", dialed())
+	}
+}
+
+func TestAddressesDialerFailover(t *testing.T) {
+	down := map[string]bool{}
+	var m sync.Mutex
+	dial, _ := fakeDialer(nil, nil)
+	flaky := func(ctx context.Context, addr string) (net.Conn, error) {
+		m.Lock()
+		isDown := down[addr]
+		m.Unlock()
+		if isDown {
+			return nil, errors.New("connection refused")
+		}
+		return dial(ctx, addr)
+	}
+	tg := NewTarget(&types.TargetConfig{
+		Name:         "router1",
+		Address:      "10.0.0.1:57400,10.1.0.1:57400",
+		DialStrategy: types.DialStrategyOrdered,
+		Timeout:      time.Second,
+	})
+	d := tg.addressesDialer([]string{"10.0.0.1:57400", "10.1.0.1:57400"}, flaky)
+	conn, err := d(context.Background(), "10.0.0.1:57400")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if a := tg.Health().Address; a != "10.0.0.1:57400" {
+		t.Fatalf("expected the first address, got %s", a)
+	}
+	// the in-band address goes down, the next dial fails over to the out-of-band one
+	m.Lock()
+	down["10.0.0.1:57400"] = true
+	m.Unlock()
+	conn, err = d(context.Background(), "10.0.0.1:57400")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if a := tg.Health().Address; a != "10.1.0.1:57400" {
+		t.Fatalf("expected a failover to the second address, got %s", a)
+	}
+}
@@ -0,0 +1,84 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// GetPoll sends the gnmi.GetRequest req to the target *t every interval, until ctx is done
+// or the subscription is stopped. The notifications of each GetResponse are sent to the target
+// channel as subscribe updates of subscriptionName, followed by a sync response after the first poll.
+func (t *Target) GetPoll(ctx context.Context, req *gnmi.GetRequest, subscriptionName string, interval time.Duration) {
+	nctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	t.m.Lock()
+	if cfn, ok := t.subscribeCancelFn[subscriptionName]; ok {
+		cfn()
+	}
+	t.subscribeCancelFn[subscriptionName] = cancel
+	subConfig := t.Subscriptions[subscriptionName]
+	t.m.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var synced bool
+	for {
+		rsp, err := t.getPoll(nctx, req)
+		switch {
+		case nctx.Err() != nil:
+			return
+		case err != nil:
+			t.errors <- &TargetError{
+				SubscriptionName: subscriptionName,
+				Err:              fmt.Errorf("get-poll failed, retrying in %s: %v", interval, err),
+			}
+		default:
+			t.SetUp()
+			for _, n := range rsp.GetNotification() {
+				t.sendResponse(subscriptionName, subConfig, nil, getPollUpdate(n))
+			}
+			if !synced {
+				t.sendResponse(subscriptionName, subConfig, nil, &gnmi.SubscribeResponse{
+					Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true},
+				})
+				synced = true
+			}
+		}
+		select {
+		case <-nctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *Target) getPoll(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+	if t.Config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Config.Timeout)
+		defer cancel()
+	}
+	return t.Get(ctx, req)
+}
+
+// getPollUpdate wraps the notification n of a GetResponse in a SubscribeResponse,
+// the notifications without timestamp are stamped with the current time.
+func getPollUpdate(n *gnmi.Notification) *gnmi.SubscribeResponse {
+	if n.GetTimestamp() == 0 {
+		n.Timestamp = time.Now().UnixNano()
+	}
+	return &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{Update: n},
+	}
+}
@@ -0,0 +1,42 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/openconfig/gnoi/file"
+)
+
+// FileGet retrieves the content of file remoteFile from the target *t using the gNOI File.Get RPC.
+// The gNOI service is reached over the connection created by CreateGNMIClient.
+func (t *Target) FileGet(ctx context.Context, remoteFile string) ([]byte, error) {
+	if t.conn == nil {
+		return nil, errors.New("target is not connected")
+	}
+	ctx = t.withCredentials(ctx)
+	stream, err := file.NewFileClient(t.conn).Get(ctx, &file.GetRequest{RemoteFile: remoteFile})
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	for {
+		rsp, err := stream.Recv()
+		if err == io.EOF {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(rsp.GetContents())
+	}
+}
@@ -0,0 +1,203 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	StateConnecting = "connecting"
+	StateUp         = "up"
+	StateDown       = "down"
+	StateFlapping   = "flapping"
+
+	defaultRetryTimer    = 10 * time.Second
+	defaultMaxRetryTimer = 5 * time.Minute
+	defaultFlapThreshold = 5
+	defaultFlapWindow    = 5 * time.Minute
+	defaultFlapHoldDown  = 10 * time.Minute
+)
+
+// ConnectionStates lists the possible target connection states.
+var ConnectionStates = []string{StateConnecting, StateUp, StateDown, StateFlapping}
+
+// Health is a snapshot of a target connection health.
+type Health struct {
+	State string    `json:"state,omitempty"`
+	Since time.Time `json:"since,omitempty"`
+	// address the target is connected to, one of its configured addresses
+	Address string `json:"address,omitempty"`
+	// reason of the last failure, set when the state is down or flapping
+	Reason string `json:"reason,omitempty"`
+	// number of failed connection attempts since the connection was last up,
+	// used to compute the redial backoff
+	ConsecutiveFailures int `json:"consecutive-failures,omitempty"`
+	// number of times the connection went down within the flap window
+	Flaps         int       `json:"flaps,omitempty"`
+	NextRetry     time.Time `json:"next-retry,omitempty"`
+	HoldDownUntil time.Time `json:"hold-down-until,omitempty"`
+	// result of the last reachability probe, if the target has one
+	Reachability *Reachability `json:"reachability,omitempty"`
+
+	flapTimes []time.Time
+}
+
+// Health returns a copy of the target connection health.
+func (t *Target) Health() *Health {
+	t.m.Lock()
+	defer t.m.Unlock()
+	h := t.health
+	h.flapTimes = nil
+	return &h
+}
+
+// SetConnecting marks the target as connecting.
+func (t *Target) SetConnecting() {
+	t.m.Lock()
+	defer t.m.Unlock()
+	if t.health.State == StateFlapping && time.Now().Before(t.health.HoldDownUntil) {
+		return
+	}
+	t.setState(StateConnecting)
+}
+
+// SetUp marks the target connection as up and resets the redial backoff.
+func (t *Target) SetUp() {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.health.ConsecutiveFailures = 0
+	t.health.Reason = ""
+	t.health.NextRetry = time.Time{}
+	t.setState(StateUp)
+}
+
+// ConnectionFailed records a connection failure and returns how long to wait before redialing.
+// The wait is an exponential backoff with jitter, starting at the target retry timer
+// and capped at its max-retry timer.
+// If the connection went down flap-threshold times within flap-window,
+// the target is marked as flapping and held down for flap-hold-down.
+// The subscriptions of a target share its connection, the failures reported
+// before the scheduled retry belong to the same connection attempt:
+// they are counted once and wait for the same retry.
+func (t *Target) ConnectionFailed(err error) time.Duration {
+	now := time.Now()
+	t.m.Lock()
+	defer t.m.Unlock()
+	if err != nil {
+		t.health.Reason = err.Error()
+		t.lastError = err.Error()
+	}
+	if (t.health.State == StateDown || t.health.State == StateFlapping) && now.Before(t.health.NextRetry) {
+		return t.health.NextRetry.Sub(now)
+	}
+	t.health.ConsecutiveFailures++
+	// a flap is a transition from up to down
+	if t.health.State == StateUp {
+		t.health.flapTimes = append(t.health.flapTimes, now)
+	}
+	t.health.flapTimes = pruneBefore(t.health.flapTimes, now.Add(-t.flapWindow()))
+	t.health.Flaps = len(t.health.flapTimes)
+
+	var wait time.Duration
+	switch {
+	case t.health.State == StateFlapping && now.Before(t.health.HoldDownUntil):
+		wait = t.health.HoldDownUntil.Sub(now)
+	case t.health.Flaps >= t.flapThreshold():
+		t.setState(StateFlapping)
+		t.health.HoldDownUntil = now.Add(t.flapHoldDown())
+		t.health.flapTimes = t.health.flapTimes[:0]
+		wait = t.flapHoldDown()
+	default:
+		t.setState(StateDown)
+		wait = t.backoff(t.health.ConsecutiveFailures)
+	}
+	t.health.NextRetry = now.Add(wait)
+	return wait
+}
+
+// setState sets the connection state, it assumes the lock is acquired.
+func (t *Target) setState(s string) {
+	if t.health.State == s {
+		return
+	}
+	t.health.State = s
+	t.health.Since = time.Now()
+}
+
+// backoff returns the wait before the n-th redial attempt:
+// retry * 2^(n-1) capped at max-retry, of which a random half is jitter.
+func (t *Target) backoff(n int) time.Duration {
+	base := t.Config.RetryTimer
+	if base <= 0 {
+		base = defaultRetryTimer
+	}
+	max := t.Config.MaxRetryTimer
+	if max <= 0 {
+		max = defaultMaxRetryTimer
+	}
+	if max < base {
+		max = base
+	}
+	d := base
+	for i := 1; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func (t *Target) flapThreshold() int {
+	if t.Config.FlapThreshold > 0 {
+		return t.Config.FlapThreshold
+	}
+	return defaultFlapThreshold
+}
+
+func (t *Target) flapWindow() time.Duration {
+	if t.Config.FlapWindow > 0 {
+		return t.Config.FlapWindow
+	}
+	return defaultFlapWindow
+}
+
+func (t *Target) flapHoldDown() time.Duration {
+	if t.Config.FlapHoldDown > 0 {
+		return t.Config.FlapHoldDown
+	}
+	return defaultFlapHoldDown
+}
+
+// sleepCtx waits for d or until ctx is done,
+// it returns false if ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func pruneBefore(ts []time.Time, limit time.Time) []time.Time {
+	i := 0
+	for ; i < len(ts); i++ {
+		if ts[i].After(limit) {
+			break
+		}
+	}
+	return ts[i:]
+}
@@ -0,0 +1,135 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+// retryElapsed makes the scheduled retry of tg due.
+func retryElapsed(tg *Target) {
+	tg.m.Lock()
+	defer tg.m.Unlock()
+	tg.health.NextRetry = time.Now().Add(-time.Millisecond)
+}
+
+func TestConnectionFailedPerAttempt(t *testing.T) {
+	tg := NewTarget(&types.TargetConfig{
+		Name:          "t1",
+		RetryTimer:    time.Second,
+		MaxRetryTimer: time.Minute,
+	})
+	tg.SetUp()
+	errDown := errors.New("connection reset")
+	// 3 subscriptions fail on the same connection loss
+	first := tg.ConnectionFailed(errDown)
+	for i := 0; i < 2; i++ {
+		if wait := tg.ConnectionFailed(errDown); wait > first {
+			t.Errorf("subscription %d waits %s, more than the first one %s", i+2, wait, first)
+		}
+	}
+	h := tg.Health()
+	if h.ConsecutiveFailures != 1 || h.Flaps != 1 || h.State != StateDown {
+		t.Fatalf("unexpected health after the first attempt: %+v", h)
+	}
+	// the retry fails for all the subscriptions
+	retryElapsed(tg)
+	for i := 0; i < 3; i++ {
+		tg.ConnectionFailed(errDown)
+	}
+	h = tg.Health()
+	if h.ConsecutiveFailures != 2 || h.Flaps != 1 {
+		t.Fatalf("unexpected health after the second attempt: %+v", h)
+	}
+	if h.Reason != errDown.Error() {
+		t.Errorf("unexpected reason %q", h.Reason)
+	}
+	tg.SetUp()
+	h = tg.Health()
+	if h.ConsecutiveFailures != 0 || h.State != StateUp || h.Reason != "" || !h.NextRetry.IsZero() {
+		t.Errorf("unexpected health after the connection is up: %+v", h)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tg := NewTarget(&types.TargetConfig{
+		Name:          "t1",
+		RetryTimer:    time.Second,
+		MaxRetryTimer: 8 * time.Second,
+	})
+	for n, max := range []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		8 * time.Second,
+		8 * time.Second,
+	} {
+		for i := 0; i < 10; i++ {
+			d := tg.backoff(n + 1)
+			if d < max/2 || d > max {
+				t.Errorf("backoff(%d)=%s, expected within [%s, %s]", n+1, d, max/2, max)
+			}
+		}
+	}
+}
+
+func TestFlapping(t *testing.T) {
+	tg := NewTarget(&types.TargetConfig{
+		Name:          "t1",
+		RetryTimer:    time.Second,
+		FlapThreshold: 3,
+		FlapWindow:    time.Minute,
+		FlapHoldDown:  10 * time.Minute,
+	})
+	errDown := errors.New("connection reset")
+	var wait time.Duration
+	for i := 0; i < 3; i++ {
+		tg.SetConnecting()
+		tg.SetUp()
+		wait = tg.ConnectionFailed(errDown)
+		tg.ConnectionFailed(errDown)
+		retryElapsed(tg)
+	}
+	h := tg.Health()
+	if h.State != StateFlapping {
+		t.Fatalf("expected the target to be flapping: %+v", h)
+	}
+	if wait < 9*time.Minute || wait > 10*time.Minute {
+		t.Errorf("expected to wait for the hold down, got %s", wait)
+	}
+	// the hold down is not cut short by connection attempts or failures
+	tg.m.Lock()
+	tg.health.NextRetry = tg.health.HoldDownUntil
+	tg.m.Unlock()
+	tg.SetConnecting()
+	if wait := tg.ConnectionFailed(errDown); wait < 9*time.Minute {
+		t.Errorf("hold down cut short, wait=%s", wait)
+	}
+	if h := tg.Health(); h.State != StateFlapping {
+		t.Errorf("expected the target to stay flapping during the hold down: %+v", h)
+	}
+	// the target is redialed once the hold down is over
+	tg.m.Lock()
+	tg.health.HoldDownUntil = time.Now().Add(-time.Millisecond)
+	tg.m.Unlock()
+	retryElapsed(tg)
+	tg.SetConnecting()
+	if h := tg.Health(); h.State != StateConnecting {
+		t.Errorf("expected the target to be connecting after the hold down: %+v", h)
+	}
+	tg.SetUp()
+	if h := tg.Health(); h.State != StateUp {
+		t.Errorf("unexpected health after the hold down: %+v", h)
+	}
+}
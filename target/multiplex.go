@@ -0,0 +1,253 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"fmt"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+)
+
+// multiplexedSubscription is one of the subscriptions
+// sent over a multiplexed subscribe stream.
+type multiplexedSubscription struct {
+	config *types.SubscriptionConfig
+	paths  []*gnmi.Path
+}
+
+// SetMultiplexedSubscriptions registers the subscriptions sent over the subscribe stream named name.
+// The responses received on that stream are attributed to the subscriptions
+// with a path matching the notifications origin and paths.
+func (t *Target) SetMultiplexedSubscriptions(name string, subs []*types.SubscriptionConfig) error {
+	ms := make([]*multiplexedSubscription, 0, len(subs))
+	for _, sc := range subs {
+		m := &multiplexedSubscription{
+			config: sc,
+			paths:  make([]*gnmi.Path, 0, len(sc.Paths)),
+		}
+		prefix, err := utils.ParsePath(sc.Prefix)
+		if err != nil {
+			return err
+		}
+		for _, p := range sc.Paths {
+			gp, err := utils.ParsePath(p)
+			if err != nil {
+				return err
+			}
+			origin := prefix.GetOrigin()
+			if origin == "" {
+				origin = gp.GetOrigin()
+			}
+			m.paths = append(m.paths, &gnmi.Path{
+				Origin: origin,
+				Elem:   utils.PathElems(prefix, gp),
+			})
+		}
+		ms = append(ms, m)
+	}
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.multiplexed[name] = ms
+	return nil
+}
+
+// RouteResponse returns the responses of the subscriptions
+// carried by the subscribe stream name, built from the response rsp received on it.
+// See sendResponse.
+func (t *Target) RouteResponse(name string, rsp *gnmi.SubscribeResponse) ([]*SubscribeResponse, error) {
+	t.m.Lock()
+	sc := t.Subscriptions[name]
+	ms := t.multiplexed[name]
+	t.m.Unlock()
+	return routeResponse(name, sc, ms, rsp)
+}
+
+// sendResponse sends the response received on the subscribe stream name to the target responses channel.
+// If the stream is multiplexed, the notification updates and deletes are attributed
+// to the subscriptions matching their origin and path, sync responses are sent once per subscription.
+// The updates and deletes not matching any subscription are dropped and reported on the target errors channel.
+func (t *Target) sendResponse(name string, sc *types.SubscriptionConfig, ms []*multiplexedSubscription, rsp *gnmi.SubscribeResponse) {
+	rsps, err := routeResponse(name, sc, ms, rsp)
+	if err != nil {
+		t.errors <- &TargetError{
+			SubscriptionName: name,
+			Err:              err,
+		}
+	}
+	for _, r := range rsps {
+		t.subscribeResponses <- r
+	}
+}
+
+func routeResponse(name string, sc *types.SubscriptionConfig, ms []*multiplexedSubscription, rsp *gnmi.SubscribeResponse) ([]*SubscribeResponse, error) {
+	if len(ms) == 0 {
+		return []*SubscribeResponse{{
+			SubscriptionName:   name,
+			SubscriptionConfig: sc,
+			Response:           rsp,
+		}}, nil
+	}
+	if _, ok := rsp.GetResponse().(*gnmi.SubscribeResponse_SyncResponse); ok {
+		rsps := make([]*SubscribeResponse, 0, len(ms))
+		for _, m := range ms {
+			rsps = append(rsps, &SubscribeResponse{
+				SubscriptionName:   m.config.Name,
+				SubscriptionConfig: m.config,
+				Response:           &gnmi.SubscribeResponse{Response: rsp.GetResponse()},
+			})
+		}
+		return rsps, nil
+	}
+	n := rsp.GetUpdate()
+	if n == nil {
+		return nil, nil
+	}
+	// notifications per subscription, in the order the subscriptions are first matched
+	routed := make(map[*multiplexedSubscription]*gnmi.Notification)
+	order := make([]*multiplexedSubscription, 0, 1)
+	var dropped []string
+	notificationOf := func(m *multiplexedSubscription) *gnmi.Notification {
+		rn, ok := routed[m]
+		if !ok {
+			rn = &gnmi.Notification{
+				Timestamp: n.GetTimestamp(),
+				Prefix:    n.GetPrefix(),
+				Alias:     n.GetAlias(),
+				Atomic:    n.GetAtomic(),
+			}
+			routed[m] = rn
+			order = append(order, m)
+		}
+		return rn
+	}
+	// atomic notifications are not split,
+	// they are attributed to the subscription matching their first path
+	if n.GetAtomic() {
+		var p *gnmi.Path
+		switch {
+		case len(n.GetUpdate()) > 0:
+			p = n.GetUpdate()[0].GetPath()
+		case len(n.GetDelete()) > 0:
+			p = n.GetDelete()[0]
+		}
+		m := matchMultiplexed(ms, n.GetPrefix(), p)
+		if m == nil {
+			return nil, fmt.Errorf("atomic notification %s does not match any of the multiplexed subscriptions, dropped",
+				notificationPath(n.GetPrefix(), p))
+		}
+		return []*SubscribeResponse{{
+			SubscriptionName:   m.config.Name,
+			SubscriptionConfig: m.config,
+			Response:           rsp,
+		}}, nil
+	}
+	for _, upd := range n.GetUpdate() {
+		m := matchMultiplexed(ms, n.GetPrefix(), upd.GetPath())
+		if m == nil {
+			dropped = append(dropped, notificationPath(n.GetPrefix(), upd.GetPath()))
+			continue
+		}
+		rn := notificationOf(m)
+		rn.Update = append(rn.Update, upd)
+	}
+	for _, del := range n.GetDelete() {
+		m := matchMultiplexed(ms, n.GetPrefix(), del)
+		if m == nil {
+			dropped = append(dropped, notificationPath(n.GetPrefix(), del))
+			continue
+		}
+		rn := notificationOf(m)
+		rn.Delete = append(rn.Delete, del)
+	}
+	rsps := make([]*SubscribeResponse, 0, len(order))
+	for _, m := range order {
+		rsps = append(rsps, &SubscribeResponse{
+			SubscriptionName:   m.config.Name,
+			SubscriptionConfig: m.config,
+			Response: &gnmi.SubscribeResponse{
+				Response:  &gnmi.SubscribeResponse_Update{Update: routed[m]},
+				Extension: rsp.GetExtension(),
+			},
+		})
+	}
+	if len(dropped) > 0 {
+		return rsps, fmt.Errorf("paths %v do not match any of the multiplexed subscriptions, dropped", dropped)
+	}
+	return rsps, nil
+}
+
+// matchMultiplexed returns the subscription with a path matching
+// the origin and path of the notification path p under prefix,
+// or nil if none does.
+func matchMultiplexed(ms []*multiplexedSubscription, prefix, p *gnmi.Path) *multiplexedSubscription {
+	origin := prefix.GetOrigin()
+	if origin == "" {
+		origin = p.GetOrigin()
+	}
+	elems := utils.PathElems(prefix, p)
+	for _, m := range ms {
+		for _, sp := range m.paths {
+			if originsMatch(sp.GetOrigin(), origin) && pathElemsMatch(sp.GetElem(), elems) {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// originsMatch returns true if the notification origin no is the subscription origin so.
+// A notification without origin matches any subscription origin,
+// a subscription without origin matches the notifications of the default "openconfig" origin.
+func originsMatch(so, no string) bool {
+	switch {
+	case no == "", so == no:
+		return true
+	case so == "":
+		return no == "openconfig"
+	}
+	return false
+}
+
+// pathElemsMatch returns true if the subscription path sp matches
+// the beginning of the notification path np.
+// Wildcard names and keys in sp match any value,
+// the "..." name matches any number of elements.
+func pathElemsMatch(sp, np []*gnmi.PathElem) bool {
+	for i, pe := range sp {
+		if pe.GetName() == "..." {
+			for j := i; j <= len(np); j++ {
+				if pathElemsMatch(sp[i+1:], np[j:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if i >= len(np) {
+			return false
+		}
+		if pe.GetName() != "*" && pe.GetName() != np[i].GetName() {
+			return false
+		}
+		for k, v := range pe.GetKey() {
+			if v == "*" {
+				continue
+			}
+			if np[i].GetKey()[k] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func notificationPath(prefix, p *gnmi.Path) string {
+	return "/" + utils.GnmiPathToXPath(&gnmi.Path{Elem: utils.PathElems(prefix, p)}, false)
+}
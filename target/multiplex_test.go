@@ -0,0 +1,122 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/types"
+	"github.com/openconfig/gnmic/utils"
+)
+
+func mustPath(t *testing.T, p string) *gnmi.Path {
+	t.Helper()
+	gp, err := utils.ParsePath(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gp
+}
+
+func TestPathElemsMatch(t *testing.T) {
+	for _, tc := range []struct {
+		sub, notif string
+		want       bool
+	}{
+		{sub: "/interfaces", notif: "/interfaces/interface[name=1]/state/oper-status", want: true},
+		{sub: "/interfaces/interface[name=1]", notif: "/interfaces/interface[name=1]/state", want: true},
+		{sub: "/interfaces/interface[name=1]", notif: "/interfaces/interface[name=2]/state", want: false},
+		{sub: "/interfaces/interface[name=*]/state", notif: "/interfaces/interface[name=2]/state/counters", want: true},
+		{sub: "/interfaces/*/state", notif: "/interfaces/interface[name=2]/state", want: true},
+		{sub: "/interfaces/interface/state/counters", notif: "/interfaces/interface[name=2]/state", want: false},
+		{sub: "/system", notif: "/interfaces/interface[name=2]/state", want: false},
+		{sub: "/.../state/counters", notif: "/interfaces/interface[name=2]/state/counters/in-octets", want: true},
+		{sub: "/interfaces/.../in-octets", notif: "/interfaces/interface[name=2]/state/counters/in-octets", want: true},
+		{sub: "/interfaces/.../out-octets", notif: "/interfaces/interface[name=2]/state/counters/in-octets", want: false},
+		{sub: "/interfaces/...", notif: "/interfaces", want: true},
+		{sub: "/", notif: "/system", want: true},
+	} {
+		got := pathElemsMatch(mustPath(t, tc.sub).GetElem(), mustPath(t, tc.notif).GetElem())
+		if got != tc.want {
+			t.Errorf("pathElemsMatch(%q, %q) = %v, want %v", tc.sub, tc.notif, got, tc.want)
+		}
+	}
+}
+
+func TestRouteResponse(t *testing.T) {
+	ifaces := &types.SubscriptionConfig{Name: "ifaces", Paths: []string{"/interfaces/interface/state/counters"}}
+	native := &types.SubscriptionConfig{Name: "native", Prefix: "native:/", Paths: []string{"/interfaces"}}
+	sys := &types.SubscriptionConfig{Name: "sys", Paths: []string{"/system"}}
+	tg := NewTarget(&types.TargetConfig{Name: "t1"})
+	err := tg.SetMultiplexedSubscriptions("ifaces+native+sys", []*types.SubscriptionConfig{ifaces, native, sys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := func(p string) *gnmi.Update {
+		return &gnmi.Update{Path: mustPath(t, p), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_IntVal{IntVal: 1}}}
+	}
+	rsp := &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{
+		Timestamp: 42,
+		Update: []*gnmi.Update{
+			update("/interfaces/interface[name=1]/state/counters/in-octets"),
+			update("/system/state/hostname"),
+			update("native:/interfaces/interface[name=1]/mtu"),
+			update("/network-instances/network-instance[name=default]"),
+			update("/interfaces/interface[name=2]/state/counters/in-octets"),
+		},
+		Delete: []*gnmi.Path{mustPath(t, "/system/ntp")},
+	}}}
+	rsps, err := tg.RouteResponse("ifaces+native+sys", rsp)
+	if err == nil {
+		t.Error("expected an error reporting the unmatched path")
+	}
+	want := map[string]struct{ updates, deletes int }{
+		"ifaces": {updates: 2},
+		"sys":    {updates: 1, deletes: 1},
+		"native": {updates: 1},
+	}
+	if len(rsps) != len(want) {
+		t.Fatalf("expected %d responses, got %d", len(want), len(rsps))
+	}
+	for i, name := range []string{"ifaces", "sys", "native"} {
+		r := rsps[i]
+		n := r.Response.GetUpdate()
+		if r.SubscriptionName != name || r.SubscriptionConfig.Name != name {
+			t.Errorf("response %d: expected subscription %q, got %q", i, name, r.SubscriptionName)
+		}
+		if len(n.GetUpdate()) != want[name].updates || len(n.GetDelete()) != want[name].deletes || n.GetTimestamp() != 42 {
+			t.Errorf("subscription %q: unexpected notification %v", name, n)
+		}
+	}
+
+	// a notification of the openconfig origin does not match the native subscription
+	rsp = &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{
+		Prefix: &gnmi.Path{Origin: "openconfig"},
+		Update: []*gnmi.Update{update("/interfaces/interface[name=1]/mtu")},
+	}}}
+	rsps, err = tg.RouteResponse("ifaces+native+sys", rsp)
+	if err == nil || len(rsps) != 0 {
+		t.Errorf("expected the notification to be dropped, got %v, err=%v", rsps, err)
+	}
+
+	// sync responses are sent to each subscription
+	rsps, err = tg.RouteResponse("ifaces+native+sys", &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true},
+	})
+	if err != nil || len(rsps) != 3 {
+		t.Errorf("expected 3 sync responses, got %d, err=%v", len(rsps), err)
+	}
+
+	// responses of a stream that is not multiplexed are returned as is
+	rsps, err = tg.RouteResponse("other", rsp)
+	if err != nil || len(rsps) != 1 || rsps[0].Response != rsp || rsps[0].SubscriptionName != "other" {
+		t.Errorf("expected the response as is, got %v, err=%v", rsps, err)
+	}
+}
@@ -0,0 +1,77 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import "sort"
+
+// reasons a subscription is paused for
+const (
+	PausedByAPI      = "api"
+	PausedBySchedule = "schedule"
+)
+
+// PauseSubscription stops the subscribe stream of subscription name
+// and records it as paused for reason.
+// The subscription config is kept, it is resumed once all its pause reasons are cleared.
+func (t *Target) PauseSubscription(name, reason string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	if t.paused == nil {
+		t.paused = make(map[string]map[string]struct{})
+	}
+	if t.paused[name] == nil {
+		t.paused[name] = make(map[string]struct{})
+	}
+	t.paused[name][reason] = struct{}{}
+	if cfn, ok := t.subscribeCancelFn[name]; ok {
+		cfn()
+	}
+	delete(t.subscribeCancelFn, name)
+	delete(t.SubscribeClients, name)
+}
+
+// ResumeSubscription clears the pause reason of subscription name.
+// It returns true if the subscription was paused and no other reason keeps it paused,
+// the caller is responsible for sending its subscribe request.
+func (t *Target) ResumeSubscription(name, reason string) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+	reasons, ok := t.paused[name]
+	if !ok {
+		return false
+	}
+	if _, ok := reasons[reason]; !ok {
+		return false
+	}
+	delete(reasons, reason)
+	if len(reasons) > 0 {
+		return false
+	}
+	delete(t.paused, name)
+	return true
+}
+
+// SubscriptionPaused returns true if subscription name is paused.
+func (t *Target) SubscriptionPaused(name string) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return len(t.paused[name]) > 0
+}
+
+// SubscriptionPauseReasons returns the sorted reasons subscription name is paused for.
+func (t *Target) SubscriptionPauseReasons(name string) []string {
+	t.m.Lock()
+	defer t.m.Unlock()
+	reasons := make([]string, 0, len(t.paused[name]))
+	for r := range t.paused[name] {
+		reasons = append(reasons, r)
+	}
+	sort.Strings(reasons)
+	return reasons
+}
@@ -0,0 +1,138 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+type dialFn func(ctx context.Context, addr string) (net.Conn, error)
+
+// directDialer returns a dial function connecting to addr over TCP.
+func directDialer(timeout time.Duration) dialFn {
+	d := &net.Dialer{Timeout: timeout, KeepAlive: timeout}
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		return d.DialContext(ctx, "tcp", addr)
+	}
+}
+
+// proxyDialer returns a dial function connecting to the targets through the proxy
+// defined by proxyURL: socks5://[user:password@]host:port, http://[user:password@]host:port
+// or https://[user:password@]host:port.
+// The http and https proxies must support the CONNECT method.
+func proxyDialer(proxyURL string, timeout time.Duration) (dialFn, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %v", proxyURL, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing address", proxyURL)
+	}
+	netDialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: timeout,
+	}
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if u.User != nil {
+			auth = &proxy.Auth{User: u.User.Username()}
+			auth.Password, _ = u.User.Password()
+		}
+		dialer, err := proxy.SOCKS5("tcp", u.Host, auth, netDialer)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, addr string) (net.Conn, error) {
+			if cd, ok := dialer.(proxy.ContextDialer); ok {
+				return cd.DialContext(ctx, "tcp", addr)
+			}
+			return dialer.Dial("tcp", addr)
+		}, nil
+	case "http", "https":
+		return func(ctx context.Context, addr string) (net.Conn, error) {
+			return httpConnect(ctx, netDialer, u, addr)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy type %q, must be one of %q", u.Scheme, []string{"socks5", "http", "https"})
+	}
+}
+
+// httpConnect opens a tunnel to addr through the HTTP proxy u using the CONNECT method.
+func httpConnect(ctx context.Context, d *net.Dialer, u *url.URL, addr string) (net.Conn, error) {
+	conn, err := d.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		err = tlsConn.HandshakeContext(ctx)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		creds := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+creds)
+	}
+	err = req.Write(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	rsp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT to %s failed: %s", addr, rsp.Status)
+	}
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a net.Conn reading first the data
+// buffered while reading the proxy CONNECT response.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,33 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// NewRPCLimiter returns a token bucket allowing r RPCs per second with bursts of burst RPCs.
+// burst defaults to r rounded up.
+func NewRPCLimiter(r float64, burst int) *rate.Limiter {
+	if burst <= 0 {
+		burst = int(math.Ceil(r))
+	}
+	return rate.NewLimiter(rate.Limit(r), burst)
+}
+
+// WaitRPC blocks until the target rpc-rate allows sending an RPC or ctx is done.
+func (t *Target) WaitRPC(ctx context.Context) error {
+	if t.rpcLimiter == nil {
+		return nil
+	}
+	return t.rpcLimiter.Wait(ctx)
+}
@@ -0,0 +1,153 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/openconfig/gnmic/types"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Reachability is the result of the last reachability probe of a target.
+type Reachability struct {
+	Time      time.Time `json:"time,omitempty"`
+	Reachable bool      `json:"reachable"`
+	// time to connect to the target, or to get the gRPC health check response
+	Latency time.Duration `json:"latency,omitempty"`
+	// probed address, one of the target addresses
+	Address string `json:"address,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// StartReachabilityProbe probes the target reachability at the configured interval
+// until ctx is done or the target is stopped.
+// It does nothing if the target has no reachability probe.
+func (t *Target) StartReachabilityProbe(ctx context.Context) {
+	if t.Config.ReachabilityProbe == nil {
+		return
+	}
+	t.m.Lock()
+	if t.probeCancelFn != nil {
+		t.probeCancelFn()
+	}
+	ctx, t.probeCancelFn = context.WithCancel(ctx)
+	t.m.Unlock()
+	go func() {
+		ticker := time.NewTicker(t.Config.ReachabilityProbe.Interval)
+		defer ticker.Stop()
+		for {
+			r := t.ProbeReachability(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			t.setReachability(r)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ProbeReachability connects to the target, or sends it a gRPC health check,
+// using the same proxy, SSH tunnel and resolver as the subscriptions.
+// The addresses of a target with multiple addresses are probed in order until one is reachable.
+func (t *Target) ProbeReachability(ctx context.Context) *Reachability {
+	pc := t.Config.ReachabilityProbe
+	r := &Reachability{Time: time.Now()}
+	var errs []string
+	for _, addr := range strings.Split(t.Config.Address, ",") {
+		r.Address = addr
+		pctx, cancel := context.WithTimeout(ctx, pc.Timeout)
+		start := time.Now()
+		var err error
+		switch pc.Type {
+		case types.ReachabilityProbeGRPCHealth:
+			err = t.probeGRPCHealth(pctx, addr, pc.Service)
+		default:
+			err = t.probeTCP(pctx, addr)
+		}
+		cancel()
+		if err == nil {
+			r.Reachable = true
+			r.Latency = time.Since(start)
+			return r
+		}
+		errs = append(errs, addr+": "+err.Error())
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	r.Error = strings.Join(errs, ", ")
+	return r
+}
+
+func (t *Target) probeTCP(ctx context.Context, addr string) error {
+	var conn net.Conn
+	var err error
+	if strings.HasPrefix(addr, "unix://") {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "unix", strings.TrimPrefix(addr, "unix://"))
+	} else {
+		var dialer dialFn
+		dialer, err = t.contextDialer()
+		if err != nil {
+			return err
+		}
+		if dialer == nil {
+			dialer = directDialer(t.Config.ReachabilityProbe.Timeout)
+		}
+		conn, err = dialer(ctx, addr)
+	}
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (t *Target) probeGRPCHealth(ctx context.Context, addr, service string) error {
+	opts, err := t.Config.GrpcDialOptions()
+	if err != nil {
+		return err
+	}
+	dialer, err := t.contextDialer()
+	if err != nil {
+		return err
+	}
+	if dialer != nil {
+		opts = append(opts, grpc.WithContextDialer(dialer))
+	}
+	opts = append(opts, grpc.WithBlock())
+	conn, err := grpc.DialContext(ctx, addr, opts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	rsp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return err
+	}
+	if rsp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("health check status %s", rsp.GetStatus())
+	}
+	return nil
+}
+
+func (t *Target) setReachability(r *Reachability) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.health.Reachability = r
+}
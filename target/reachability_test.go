@@ -0,0 +1,121 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestProbeReachabilityTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	// a closed port
+	cl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := cl.Addr().String()
+	cl.Close()
+
+	pc := &types.ReachabilityProbeConfig{}
+	if err := pc.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	tg := NewTarget(&types.TargetConfig{
+		Name:              "router1",
+		Address:           closedAddr + "," + l.Addr().String(),
+		ReachabilityProbe: pc,
+	})
+	r := tg.ProbeReachability(context.Background())
+	if !r.Reachable || r.Address != l.Addr().String() {
+		t.Fatalf("expected the second address to be reachable: %+v", r)
+	}
+	tg.Config.Address = closedAddr
+	r = tg.ProbeReachability(context.Background())
+	if r.Reachable || r.Error == "" {
+		t.Fatalf("expected the target to be unreachable: %+v", r)
+	}
+}
+
+func TestProbeReachabilityGRPCHealth(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	insecure := true
+	pc := &types.ReachabilityProbeConfig{Type: types.ReachabilityProbeGRPCHealth, Service: "gnmi", Timeout: 2 * time.Second}
+	if err := pc.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	tg := NewTarget(&types.TargetConfig{
+		Name:              "router1",
+		Address:           l.Addr().String(),
+		Insecure:          &insecure,
+		Timeout:           2 * time.Second,
+		ReachabilityProbe: pc,
+	})
+	hs.SetServingStatus("gnmi", healthpb.HealthCheckResponse_SERVING)
+	r := tg.ProbeReachability(context.Background())
+	if !r.Reachable || r.Latency <= 0 {
+		t.Fatalf("expected the target to be reachable: %+v", r)
+	}
+	hs.SetServingStatus("gnmi", healthpb.HealthCheckResponse_NOT_SERVING)
+	r = tg.ProbeReachability(context.Background())
+	if r.Reachable || !strings.Contains(r.Error, "NOT_SERVING") {
+		t.Fatalf("expected a not serving error: %+v", r)
+	}
+}
+
+func TestStartReachabilityProbe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	tg := NewTarget(&types.TargetConfig{
+		Name:    "router1",
+		Address: l.Addr().String(),
+		ReachabilityProbe: &types.ReachabilityProbeConfig{
+			Type:     types.ReachabilityProbeTCP,
+			Interval: time.Hour,
+			Timeout:  time.Second,
+		},
+	})
+	tg.StartReachabilityProbe(context.Background())
+	defer tg.StopSubscriptions()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if r := tg.Health().Reachability; r != nil {
+			if !r.Reachable {
+				t.Fatalf("expected the target to be reachable: %+v", r)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("no reachability probe result")
+}
@@ -0,0 +1,150 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/openconfig/gnmic/types"
+)
+
+// resolver resolves the target address host name according to its resolver config.
+type resolver struct {
+	cfg       *types.ResolverConfig
+	resolvers []*net.Resolver
+
+	m *sync.Mutex
+	// host name to resolved addresses, used with resolve-once
+	cache map[string][]string
+}
+
+func newResolver(cfg *types.ResolverConfig) *resolver {
+	r := &resolver{
+		cfg:   cfg,
+		m:     new(sync.Mutex),
+		cache: make(map[string][]string),
+	}
+	for _, s := range cfg.Servers {
+		server := s
+		r.resolvers = append(r.resolvers, &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				d := net.Dialer{Timeout: cfg.Timeout}
+				return d.DialContext(ctx, network, server)
+			},
+		})
+	}
+	if len(r.resolvers) == 0 {
+		r.resolvers = []*net.Resolver{net.DefaultResolver}
+	}
+	return r
+}
+
+// dialer returns a dial function resolving the address host name
+// and dialing the resolved addresses in order with dial until one succeeds.
+func (r *resolver) dialer(dial dialFn) dialFn {
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := r.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var errs []string
+		for _, ip := range ips {
+			conn, err := dial(ctx, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err.Error())
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+}
+
+// resolve returns the addresses of host, ordered and filtered by IP family.
+func (r *resolver) resolve(ctx context.Context, host string) ([]string, error) {
+	if r.cfg.ResolveOnce {
+		r.m.Lock()
+		ips, ok := r.cache[host]
+		r.m.Unlock()
+		if ok {
+			return ips, nil
+		}
+	}
+	ips, err := r.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips = r.filter(ips)
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no %s address found for %q", r.cfg.Family, host)
+	}
+	if r.cfg.ResolveOnce {
+		r.m.Lock()
+		r.cache[host] = ips
+		r.m.Unlock()
+	}
+	return ips, nil
+}
+
+func (r *resolver) lookup(ctx context.Context, host string) ([]string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []string{host}, nil
+	}
+	if addrs, ok := r.cfg.Hosts[host]; ok {
+		return append([]string(nil), addrs...), nil
+	}
+	var err error
+	for _, res := range r.resolvers {
+		var ips []string
+		lctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+		ips, err = res.LookupHost(lctx, host)
+		cancel()
+		if err == nil {
+			return ips, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+func (r *resolver) filter(ips []string) []string {
+	result := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if r.cfg.Family == "" || ipFamily(ip) == r.cfg.Family {
+			result = append(result, ip)
+		}
+	}
+	if r.cfg.Prefer != "" {
+		sort.SliceStable(result, func(i, j int) bool {
+			return ipFamily(result[i]) == r.cfg.Prefer && ipFamily(result[j]) != r.cfg.Prefer
+		})
+	}
+	return result
+}
+
+func ipFamily(ip string) string {
+	if strings.Contains(ip, ":") {
+		return types.IPFamilyIPv6
+	}
+	return types.IPFamilyIPv4
+}
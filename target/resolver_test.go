@@ -0,0 +1,145 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openconfig/gnmic/types"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startDNSServer answers the A queries with ip,
+// it returns the server address and a counter of the answered queries.
+func startDNSServer(t *testing.T, ip [4]byte) (string, *int32) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	count := new(int32)
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, err := p.Question()
+			if err != nil {
+				continue
+			}
+			b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, Authoritative: true})
+			b.StartQuestions()
+			b.Question(q)
+			b.StartAnswers()
+			if q.Type == dnsmessage.TypeA {
+				atomic.AddInt32(count, 1)
+				b.AResource(dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}, dnsmessage.AResource{A: ip})
+			}
+			msg, err := b.Finish()
+			if err != nil {
+				continue
+			}
+			pc.WriteTo(msg, addr)
+		}
+	}()
+	return pc.LocalAddr().String(), count
+}
+
+func TestResolverHosts(t *testing.T) {
+	cfg := &types.ResolverConfig{
+		Hosts: map[string][]string{
+			"router1": {"10.0.0.1", "2001:db8::1", "10.0.0.2"},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	ips, err := newResolver(cfg).resolve(context.Background(), "router1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ips, []string{"10.0.0.1", "2001:db8::1", "10.0.0.2"}) {
+		t.Errorf("unexpected addresses %v", ips)
+	}
+	cfg.Prefer = types.IPFamilyIPv6
+	ips, _ = newResolver(cfg).resolve(context.Background(), "router1")
+	if !reflect.DeepEqual(ips, []string{"2001:db8::1", "10.0.0.1", "10.0.0.2"}) {
+		t.Errorf("expected the IPv6 address first, got %v", ips)
+	}
+	cfg.Prefer = ""
+	cfg.Family = types.IPFamilyIPv4
+	ips, _ = newResolver(cfg).resolve(context.Background(), "router1")
+	if !reflect.DeepEqual(ips, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Errorf("expected only IPv4 addresses, got %v", ips)
+	}
+}
+
+func TestResolverServers(t *testing.T) {
+	addr, count := startDNSServer(t, [4]byte{192, 0, 2, 10})
+	cfg := &types.ResolverConfig{
+		Servers:     []string{addr},
+		Family:      types.IPFamilyIPv4,
+		ResolveOnce: true,
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	r := newResolver(cfg)
+	var dialed []string
+	dial := r.dialer(func(_ context.Context, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		c1, c2 := net.Pipe()
+		c2.Close()
+		return c1, nil
+	})
+	for i := 0; i < 2; i++ {
+		conn, err := dial(context.Background(), "router1.example.com:57400")
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}
+	if !reflect.DeepEqual(dialed, []string{"192.0.2.10:57400", "192.0.2.10:57400"}) {
+		t.Errorf("unexpected dialed addresses %v", dialed)
+	}
+	if n := atomic.LoadInt32(count); n != 1 {
+		t.Errorf("expected the name to be resolved once, got %d queries", n)
+	}
+}
+
+func TestResolverConfigValidate(t *testing.T) {
+	for name, cfg := range map[string]*types.ResolverConfig{
+		"invalid_server": {Servers: []string{"dns.example.com"}},
+		"invalid_host":   {Hosts: map[string][]string{"router1": {"router1.example.com"}}},
+		"invalid_family": {Family: "ipx"},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	cfg := &types.ResolverConfig{Servers: []string{"10.0.0.53", "[2001:db8::53]:5353"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.Servers, []string{"10.0.0.53:53", "[2001:db8::53]:5353"}) {
+		t.Errorf("unexpected servers %v", cfg.Servers)
+	}
+}
@@ -0,0 +1,274 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/openconfig/gnmic/types"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultSSHPort              = "22"
+	defaultSSHKnownHosts        = "~/.ssh/known_hosts"
+	defaultSSHKeepaliveInterval = 30 * time.Second
+)
+
+// sshTunnel dials the target through an SSH jump host.
+// The SSH connection is established on the first dial and shared by the following ones,
+// it is re-established on the next dial if it goes down.
+type sshTunnel struct {
+	m       *sync.Mutex
+	cfg     *types.SSHTunnelConfig
+	timeout time.Duration
+	// dials the jump host, e.g: through a proxy
+	dial   dialFn
+	client *ssh.Client
+	done   chan struct{}
+}
+
+func newSSHTunnel(cfg *types.SSHTunnelConfig, timeout time.Duration, dial dialFn) *sshTunnel {
+	if dial == nil {
+		dial = directDialer(timeout)
+	}
+	return &sshTunnel{
+		m:       new(sync.Mutex),
+		cfg:     cfg,
+		timeout: timeout,
+		dial:    dial,
+	}
+}
+
+// DialContext opens a connection to addr through the SSH jump host.
+func (s *sshTunnel) DialContext(ctx context.Context, addr string) (net.Conn, error) {
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialThrough(ctx, client, addr)
+	if err == nil || ctx.Err() != nil {
+		return conn, err
+	}
+	// the SSH connection might be stale, re-establish it once
+	s.closeClient(client)
+	client, err = s.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return dialThrough(ctx, client, addr)
+}
+
+// dialThrough opens a connection to addr through the SSH connection client,
+// it gives up when ctx is done.
+func dialThrough(ctx context.Context, client *ssh.Client, addr string) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		conn, err := client.Dial("tcp", addr)
+		ch <- result{conn: conn, err: err}
+	}()
+	select {
+	case r := <-ch:
+		return r.conn, r.err
+	case <-ctx.Done():
+		// close the connection if it is opened after giving up
+		go func() {
+			if r := <-ch; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+func (s *sshTunnel) getClient(ctx context.Context) (*ssh.Client, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.client != nil {
+		return s.client, nil
+	}
+	sshConfig, closeAgent, err := s.clientConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// the agent is only used during the handshake
+	defer closeAgent()
+	addr := s.cfg.Address
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, defaultSSHPort)
+	}
+	conn, err := s.dial(ctx, addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh tunnel %s: %v", addr, err)
+	}
+	// abort the handshake when ctx is done
+	handshakeDone := make(chan struct{})
+	watchDone := make(chan struct{})
+	var aborted bool
+	go func() {
+		defer close(watchDone)
+		select {
+		case <-ctx.Done():
+			aborted = true
+			conn.Close()
+		case <-handshakeDone:
+		}
+	}()
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, sshConfig)
+	close(handshakeDone)
+	<-watchDone
+	if aborted {
+		if err == nil {
+			c.Close()
+		}
+		err = ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh tunnel %s: %v", addr, err)
+	}
+	s.client = ssh.NewClient(c, chans, reqs)
+	s.done = make(chan struct{})
+	go s.keepalive(s.client, s.done)
+	return s.client, nil
+}
+
+// keepalive sends keepalive requests over the SSH connection
+// and closes it if they fail, so that it is re-established by the next dial.
+func (s *sshTunnel) keepalive(client *ssh.Client, done chan struct{}) {
+	interval := s.cfg.KeepaliveInterval
+	if interval <= 0 {
+		interval = defaultSSHKeepaliveInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+			if err != nil {
+				s.closeClient(client)
+				return
+			}
+		}
+	}
+}
+
+// closeClient closes the SSH connection client if it is the current one.
+func (s *sshTunnel) closeClient(client *ssh.Client) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.client != client {
+		return
+	}
+	close(s.done)
+	s.client.Close()
+	s.client = nil
+}
+
+func (s *sshTunnel) Close() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.client == nil {
+		return nil
+	}
+	close(s.done)
+	err := s.client.Close()
+	s.client = nil
+	return err
+}
+
+// clientConfig returns the SSH client config and a function closing
+// the connection to the ssh agent, if any, once the handshake is done.
+func (s *sshTunnel) clientConfig(ctx context.Context) (_ *ssh.ClientConfig, closeAgent func(), err error) {
+	closeAgent = func() {}
+	auths := make([]ssh.AuthMethod, 0, 3)
+	if s.cfg.Agent {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return nil, nil, errors.New("ssh tunnel: agent enabled but SSH_AUTH_SOCK is not set")
+		}
+		var conn net.Conn
+		conn, err = new(net.Dialer).DialContext(ctx, "unix", sock)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ssh tunnel: failed to connect to the ssh agent: %v", err)
+		}
+		defer func() {
+			if err != nil {
+				conn.Close()
+			}
+		}()
+		closeAgent = func() { conn.Close() }
+		auths = append(auths, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+	if s.cfg.PrivateKey != "" {
+		keyFile, err := homedir.Expand(s.cfg.PrivateKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		b, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ssh tunnel: %v", err)
+		}
+		var signer ssh.Signer
+		if s.cfg.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(b, []byte(s.cfg.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(b)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("ssh tunnel: failed to parse private key %q: %v", keyFile, err)
+		}
+		auths = append(auths, ssh.PublicKeys(signer))
+	}
+	if s.cfg.Password != "" {
+		auths = append(auths, ssh.Password(s.cfg.Password))
+	}
+	if len(auths) == 0 {
+		return nil, nil, errors.New("ssh tunnel: no authentication method configured")
+	}
+	var hostKeyCallback ssh.HostKeyCallback
+	if s.cfg.InsecureIgnoreHostKey {
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	} else {
+		knownHostsFile := s.cfg.KnownHosts
+		if knownHostsFile == "" {
+			knownHostsFile = defaultSSHKnownHosts
+		}
+		knownHostsFile, err := homedir.Expand(knownHostsFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		hostKeyCallback, err = knownhosts.New(knownHostsFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ssh tunnel: failed to load known hosts: %v", err)
+		}
+	}
+	return &ssh.ClientConfig{
+		User:            s.cfg.Username,
+		Auth:            auths,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         s.timeout,
+	}, closeAgent, nil
+}
@@ -0,0 +1,310 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmic/types"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// sshJumpHost starts an SSH server accepting the password "secret"
+// and any public key, and forwarding direct-tcpip channels.
+// Channels to the host "blackhole" are left unanswered until the server is closed.
+func sshJumpHost(t *testing.T) string {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) != "secret" {
+				return nil, errors.New("wrong password")
+			}
+			return nil, nil
+		},
+		PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	cfg.AddHostKey(signer)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		close(done)
+		l.Close()
+	})
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(conn, cfg, done)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func serveSSH(conn net.Conn, cfg *ssh.ServerConfig, done chan struct{}) {
+	sc, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sc.Close()
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		if nc.ChannelType() != "direct-tcpip" {
+			nc.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		var req struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if err := ssh.Unmarshal(nc.ExtraData(), &req); err != nil {
+			nc.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		if req.Host == "blackhole" {
+			go func(nc ssh.NewChannel) {
+				<-done
+				nc.Reject(ssh.ConnectionFailed, "closed")
+			}(nc)
+			continue
+		}
+		go func(nc ssh.NewChannel) {
+			tc, err := net.Dial("tcp", net.JoinHostPort(req.Host, strconv.Itoa(int(req.Port))))
+			if err != nil {
+				nc.Reject(ssh.ConnectionFailed, err.Error())
+				return
+			}
+			ch, creqs, err := nc.Accept()
+			if err != nil {
+				tc.Close()
+				return
+			}
+			go ssh.DiscardRequests(creqs)
+			go func() {
+				io.Copy(ch, tc)
+				ch.Close()
+			}()
+			io.Copy(tc, ch)
+			tc.Close()
+		}(nc)
+	}
+}
+
+// echoServer returns the address of a TCP server echoing what it reads.
+func echoServer(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestSSHTunnelDial(t *testing.T) {
+	jump := sshJumpHost(t)
+	target := echoServer(t)
+	s := newSSHTunnel(&types.SSHTunnelConfig{
+		Address:               jump,
+		Username:              "admin",
+		Password:              "secret",
+		InsecureIgnoreHostKey: true,
+	}, time.Second, nil)
+	defer s.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		conn, err := s.DialContext(ctx, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = conn.Write([]byte("ping"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := make([]byte, 4)
+		_, err = io.ReadFull(conn, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "ping" {
+			t.Errorf("unexpected echo %q", b)
+		}
+		conn.Close()
+	}
+}
+
+func TestSSHTunnelWrongPassword(t *testing.T) {
+	jump := sshJumpHost(t)
+	s := newSSHTunnel(&types.SSHTunnelConfig{
+		Address:               jump,
+		Username:              "admin",
+		Password:              "wrong",
+		InsecureIgnoreHostKey: true,
+	}, time.Second, nil)
+	defer s.Close()
+	_, err := s.DialContext(context.Background(), "127.0.0.1:57400")
+	if err == nil {
+		t.Fatal("expected an authentication error")
+	}
+}
+
+func TestSSHTunnelDialCanceled(t *testing.T) {
+	jump := sshJumpHost(t)
+	s := newSSHTunnel(&types.SSHTunnelConfig{
+		Address:               jump,
+		Username:              "admin",
+		Password:              "secret",
+		InsecureIgnoreHostKey: true,
+	}, time.Second, nil)
+	defer s.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := s.DialContext(ctx, "blackhole:57400")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline exceeded error, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("dial did not honor the context deadline")
+	}
+	// the SSH connection is kept
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.client == nil {
+		t.Errorf("SSH connection closed after a canceled dial")
+	}
+}
+
+func TestSSHTunnelHandshakeCanceled(t *testing.T) {
+	// a jump host accepting connections without ever answering
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}()
+	s := newSSHTunnel(&types.SSHTunnelConfig{
+		Address:               l.Addr().String(),
+		Username:              "admin",
+		Password:              "secret",
+		InsecureIgnoreHostKey: true,
+	}, time.Second, nil)
+	defer s.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	_, err = s.DialContext(ctx, "127.0.0.1:57400")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("handshake not aborted by the context cancelation")
+	}
+}
+
+func TestSSHTunnelAgentClosed(t *testing.T) {
+	jump := sshJumpHost(t)
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := agent.NewKeyring()
+	err = keyring.Add(agent.AddedKey{PrivateKey: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := os.MkdirTemp("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	served := make(chan struct{})
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		agent.ServeAgent(keyring, conn)
+		close(served)
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+	s := newSSHTunnel(&types.SSHTunnelConfig{
+		Address:               jump,
+		Username:              "admin",
+		Agent:                 true,
+		InsecureIgnoreHostKey: true,
+	}, time.Second, nil)
+	defer s.Close()
+	_, err = s.getClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-served:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ssh agent connection not closed after the handshake")
+	}
+}
@@ -0,0 +1,65 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"sort"
+	"time"
+)
+
+// SubscriptionStats is a snapshot of a target subscription state.
+type SubscriptionStats struct {
+	Name    string `json:"name,omitempty"`
+	Running bool   `json:"running"`
+	// number of subscribe responses received since the target was created
+	Received     uint64    `json:"received"`
+	LastReceived time.Time `json:"last-received,omitempty"`
+}
+
+type subscriptionCounter struct {
+	received uint64
+	last     time.Time
+}
+
+// ResponseReceived records a subscribe response received for subscription name.
+func (t *Target) ResponseReceived(name string) {
+	now := time.Now()
+	t.m.Lock()
+	defer t.m.Unlock()
+	if t.received == nil {
+		t.received = make(map[string]*subscriptionCounter)
+	}
+	c, ok := t.received[name]
+	if !ok {
+		c = new(subscriptionCounter)
+		t.received[name] = c
+	}
+	c.received++
+	c.last = now
+}
+
+// SubscriptionStats returns the state of the target subscriptions sorted by name.
+func (t *Target) SubscriptionStats() []*SubscriptionStats {
+	t.m.Lock()
+	defer t.m.Unlock()
+	stats := make([]*SubscriptionStats, 0, len(t.Subscriptions))
+	for name := range t.Subscriptions {
+		s := &SubscriptionStats{Name: name}
+		_, s.Running = t.SubscribeClients[name]
+		if c, ok := t.received[name]; ok {
+			s.Received = c.received
+			s.LastReceived = c.last
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
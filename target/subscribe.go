@@ -14,11 +14,10 @@ import (
 	"fmt"
 	"io"
 	"strings"
-	"time"
 
 	"github.com/jhump/protoreflect/dynamic"
 	"github.com/openconfig/gnmi/proto/gnmi"
-	"google.golang.org/grpc/metadata"
+	"github.com/openconfig/gnmic/types"
 )
 
 // Subscribe sends a gnmi.SubscribeRequest to the target *t, responses and error are sent to the target channels
@@ -34,20 +33,18 @@ SUBSC:
 	default:
 		nctx, cancel = context.WithCancel(ctx)
 		defer cancel()
-		if t.Config.Username != nil {
-			nctx = metadata.AppendToOutgoingContext(nctx, "username", *t.Config.Username)
-		}
-		if t.Config.Password != nil {
-			nctx = metadata.AppendToOutgoingContext(nctx, "password", *t.Config.Password)
-		}
+		nctx = t.withCredentials(nctx)
 		subscribeClient, err = t.Client.Subscribe(nctx)
 		if err != nil {
+			wait := t.ConnectionFailed(err)
 			t.errors <- &TargetError{
 				SubscriptionName: subscriptionName,
-				Err:              fmt.Errorf("failed to create a subscribe client, target='%s', retry in %d. err=%v", t.Config.Name, t.Config.RetryTimer, err),
+				Err:              fmt.Errorf("failed to create a subscribe client, target='%s', retry in %s. err=%v", t.Config.Name, wait, err),
 			}
 			cancel()
-			time.Sleep(t.Config.RetryTimer)
+			if !sleepCtx(ctx, wait) {
+				return
+			}
 			goto SUBSC
 		}
 	}
@@ -58,43 +55,54 @@ SUBSC:
 	t.SubscribeClients[subscriptionName] = subscribeClient
 	t.subscribeCancelFn[subscriptionName] = cancel
 	subConfig := t.Subscriptions[subscriptionName]
+	multiplexed := t.multiplexed[subscriptionName]
 	t.m.Unlock()
 	err = subscribeClient.Send(req)
 	if err != nil {
+		wait := t.ConnectionFailed(err)
 		t.errors <- &TargetError{
 			SubscriptionName: subscriptionName,
-			Err:              fmt.Errorf("target '%s' send error, retry in %d. err=%v", t.Config.Name, t.Config.RetryTimer, err),
+			Err:              fmt.Errorf("target '%s' send error, retry in %s. err=%v", t.Config.Name, wait, err),
 		}
 		cancel()
-		time.Sleep(t.Config.RetryTimer)
+		if !sleepCtx(ctx, wait) {
+			return
+		}
 		goto SUBSC
 	}
 
 	switch req.GetSubscribe().Mode {
 	case gnmi.SubscriptionList_STREAM:
+		var up bool
 		for {
 			if nctx.Err() != nil {
 				return
 			}
 			response, err := subscribeClient.Recv()
 			if err != nil {
+				if nctx.Err() != nil {
+					return
+				}
 				t.errors <- &TargetError{
 					SubscriptionName: subscriptionName,
 					Err:              err,
 				}
+				wait := t.ConnectionFailed(err)
 				t.errors <- &TargetError{
 					SubscriptionName: subscriptionName,
-					Err:              fmt.Errorf("retrying in %s", t.Config.RetryTimer),
+					Err:              fmt.Errorf("retrying in %s", wait),
 				}
 				cancel()
-				time.Sleep(t.Config.RetryTimer)
+				if !sleepCtx(ctx, wait) {
+					return
+				}
 				goto SUBSC
 			}
-			t.subscribeResponses <- &SubscribeResponse{
-				SubscriptionName:   subscriptionName,
-				SubscriptionConfig: subConfig,
-				Response:           response,
+			if !up {
+				t.SetUp()
+				up = true
 			}
+			t.sendResponse(subscriptionName, subConfig, multiplexed, response)
 		}
 	case gnmi.SubscriptionList_ONCE:
 		for {
@@ -107,19 +115,18 @@ SUBSC:
 				if errors.Is(err, io.EOF) {
 					return
 				}
+				wait := t.ConnectionFailed(err)
 				t.errors <- &TargetError{
 					SubscriptionName: subscriptionName,
-					Err:              fmt.Errorf("retrying in %d", t.Config.RetryTimer),
+					Err:              fmt.Errorf("retrying in %s", wait),
 				}
 				cancel()
-				time.Sleep(t.Config.RetryTimer)
+				if !sleepCtx(ctx, wait) {
+					return
+				}
 				goto SUBSC
 			}
-			t.subscribeResponses <- &SubscribeResponse{
-				SubscriptionName:   subscriptionName,
-				SubscriptionConfig: subConfig,
-				Response:           response,
-			}
+			t.sendResponse(subscriptionName, subConfig, multiplexed, response)
 			switch response.Response.(type) {
 			case *gnmi.SubscribeResponse_SyncResponse:
 				return
@@ -149,11 +156,7 @@ SUBSC:
 					}
 					continue
 				}
-				t.subscribeResponses <- &SubscribeResponse{
-					SubscriptionName:   subscriptionName,
-					SubscriptionConfig: subConfig,
-					Response:           response,
-				}
+				t.sendResponse(subscriptionName, subConfig, multiplexed, response)
 			case <-nctx.Done():
 				return
 			}
@@ -168,7 +171,7 @@ func (t *Target) SubscribeOnceChan(ctx context.Context, req *gnmi.SubscribeReque
 		nctx, cancel := context.WithCancel(ctx)
 		defer cancel()
 
-		nctx = metadata.AppendToOutgoingContext(nctx, "username", *t.Config.Username, "password", *t.Config.Password)
+		nctx = t.withCredentials(nctx)
 		subscribeClient, err := t.Client.Subscribe(nctx)
 		if err != nil {
 			errCh <- err
@@ -192,6 +195,40 @@ func (t *Target) SubscribeOnceChan(ctx context.Context, req *gnmi.SubscribeReque
 	return responseCh, errCh
 }
 
+// SubscribeStreamChan sends a SubscribeRequest to the target *t and returns a channel of responses
+// and a channel of errors. The responses are delivered until the stream fails or ctx is done.
+func (t *Target) SubscribeStreamChan(ctx context.Context, req *gnmi.SubscribeRequest) (chan *gnmi.SubscribeResponse, chan error) {
+	responseCh := make(chan *gnmi.SubscribeResponse)
+	errCh := make(chan error, 1)
+	go func() {
+		ctx = t.withCredentials(ctx)
+		subscribeClient, err := t.Client.Subscribe(ctx)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		err = subscribeClient.Send(req)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		for {
+			response, err := subscribeClient.Recv()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			select {
+			case responseCh <- response:
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return responseCh, errCh
+}
+
 func (t *Target) SubscribeOnce(ctx context.Context, req *gnmi.SubscribeRequest) ([]*gnmi.SubscribeResponse, error) {
 	responses := make([]*gnmi.SubscribeResponse, 0)
 	rspChan, errChan := t.SubscribeOnceChan(ctx, req)
@@ -254,10 +291,20 @@ func (t *Target) DecodeProtoBytes(resp *gnmi.SubscribeResponse) error {
 	return nil
 }
 
+// SetSubscription adds or replaces the subscription config sc of the target.
+// It does not start nor restart the corresponding subscribe stream.
+func (t *Target) SetSubscription(sc *types.SubscriptionConfig) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.Subscriptions[sc.Name] = sc
+}
+
 func (t *Target) DeleteSubscription(name string) {
 	t.m.Lock()
 	defer t.m.Unlock()
-	t.subscribeCancelFn[name]()
+	if cfn, ok := t.subscribeCancelFn[name]; ok {
+		cfn()
+	}
 	delete(t.subscribeCancelFn, name)
 	delete(t.SubscribeClients, name)
 	delete(t.Subscriptions, name)
@@ -266,7 +313,16 @@ func (t *Target) DeleteSubscription(name string) {
 func (t *Target) StopSubscription(name string) {
 	t.m.Lock()
 	defer t.m.Unlock()
-	t.subscribeCancelFn[name]()
+	if cfn, ok := t.subscribeCancelFn[name]; ok {
+		cfn()
+	}
 	delete(t.subscribeCancelFn, name)
 	delete(t.SubscribeClients, name)
 }
+
+// SubscribeClient opens a Subscribe RPC stream to the target *t,
+// the target credentials are added to the stream metadata.
+func (t *Target) SubscribeClient(ctx context.Context) (gnmi.GNMI_SubscribeClient, error) {
+	ctx = t.withCredentials(ctx)
+	return t.Client.Subscribe(ctx)
+}
@@ -11,17 +11,16 @@ package target
 import (
 	"context"
 	"fmt"
-	"net"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/jhump/protoreflect/desc"
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmi/proto/gnmi_ext"
 	"github.com/openconfig/gnmic/types"
-	"golang.org/x/net/proxy"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/metadata"
 )
 
 type TargetError struct {
@@ -53,6 +52,28 @@ type Target struct {
 	StopChan           chan struct{}      `json:"-"`
 	Cfn                context.CancelFunc `json:"-"`
 	RootDesc           desc.Descriptor    `json:"-"`
+
+	// context the subscriptions are started in, canceled by Cfn
+	subscribeCtx      context.Context
+	lastError         string
+	capabilitiesProbe *CapabilitiesProbe
+	health            Health
+	// multiplexed subscribe stream name to the subscriptions it carries
+	multiplexed map[string][]*multiplexedSubscription
+	sshTunnel   *sshTunnel
+	// resolves the target address host name, if a resolver is configured
+	resolver *resolver
+	// subscription name to received responses counter
+	received map[string]*subscriptionCounter
+	// subscription name to the reasons it is paused for
+	paused map[string]map[string]struct{}
+	// limits the rate of the unary RPCs sent to the target
+	rpcLimiter *rate.Limiter
+	// stops the reachability probe
+	probeCancelFn context.CancelFunc
+	// credentials rotated by a credentials provider
+	credsMu sync.RWMutex
+	creds   *Credentials
 }
 
 // NewTarget //
@@ -67,20 +88,50 @@ func NewTarget(c *types.TargetConfig) *Target {
 		subscribeResponses: make(chan *SubscribeResponse, c.BufferSize),
 		errors:             make(chan *TargetError, c.BufferSize),
 		StopChan:           make(chan struct{}),
+		multiplexed:        make(map[string][]*multiplexedSubscription),
+		received:           make(map[string]*subscriptionCounter),
+	}
+	if c.RPCRate > 0 {
+		t.rpcLimiter = NewRPCLimiter(c.RPCRate, c.RPCBurst)
 	}
 	return t
 }
 
 // CreateGNMIClient //
 func (t *Target) CreateGNMIClient(ctx context.Context, opts ...grpc.DialOption) error {
-	tOpts, err := t.Config.GrpcDialOptions()
+	tOpts, err := t.dialConfig().GrpcDialOptions()
 	if err != nil {
 		return err
 	}
 	opts = append(opts, tOpts...)
 	opts = append(opts, grpc.WithBlock())
-	// create a gRPC connection
+	// unix socket targets are co-located, they are dialed directly
+	unixSocket := strings.HasPrefix(t.Config.Address, "unix://")
+	dialer, err := t.contextDialer()
+	if err != nil {
+		return err
+	}
 	addrs := strings.Split(t.Config.Address, ",")
+	dialTimeout := t.Config.Timeout
+	if t.Config.DialStrategy != "" && len(addrs) > 1 && !unixSocket {
+		if dialer == nil {
+			dialer = directDialer(t.Config.Timeout)
+		}
+		// a single gRPC connection dials all the addresses,
+		// its authority is the first address.
+		switch t.Config.DialStrategy {
+		case types.DialStrategyHappyEyeballs:
+			dialTimeout += time.Duration(len(addrs)-1) * t.Config.DialDelay
+		default:
+			dialTimeout *= time.Duration(len(addrs))
+		}
+		dialer = t.addressesDialer(addrs, dialer)
+		addrs = addrs[:1]
+	}
+	if dialer != nil {
+		opts = append(opts, grpc.WithContextDialer(dialer))
+	}
+	// create a gRPC connection
 	numAddrs := len(addrs)
 	errC := make(chan error, numAddrs)
 	connC := make(chan *grpc.ClientConn)
@@ -89,30 +140,8 @@ func (t *Target) CreateGNMIClient(ctx context.Context, opts ...grpc.DialOption)
 	defer cancel()
 	for _, addr := range addrs {
 		go func(addr string) {
-			timeoutCtx, cancel := context.WithTimeout(ctx, t.Config.Timeout)
+			timeoutCtx, cancel := context.WithTimeout(ctx, dialTimeout)
 			defer cancel()
-			if t.Config.Proxy != "" {
-				if idx := strings.Index(t.Config.Proxy, "://"); idx >= 0 {
-					proxyType := t.Config.Proxy[:idx]
-					proxyAddress := t.Config.Proxy[idx+3:]
-					if proxyType == "socks5" {
-						opts = append(opts, grpc.WithContextDialer(
-							func(ctx context.Context, addr string) (net.Conn, error) {
-								dialer, err := proxy.SOCKS5("tcp", proxyAddress, nil,
-									&net.Dialer{
-										Timeout:   t.Config.Timeout,
-										KeepAlive: t.Config.Timeout,
-									},
-								)
-								if err != nil {
-									errC <- fmt.Errorf("%s: %v", addr, err)
-									return nil, err
-								}
-								return dialer.Dial("tcp", addr)
-							}))
-					}
-				}
-			}
 			conn, err := grpc.DialContext(timeoutCtx, addr, opts...)
 			if err != nil {
 				errC <- fmt.Errorf("%s: %v", addr, err)
@@ -120,6 +149,9 @@ func (t *Target) CreateGNMIClient(ctx context.Context, opts ...grpc.DialOption)
 			}
 			select {
 			case connC <- conn:
+				if numAddrs > 1 && t.Config.DialStrategy == "" {
+					t.setConnectedAddress(addr)
+				}
 			case <-done:
 				if conn != nil {
 					conn.Close()
@@ -132,6 +164,9 @@ func (t *Target) CreateGNMIClient(ctx context.Context, opts ...grpc.DialOption)
 		select {
 		case conn := <-connC:
 			close(done)
+			if t.conn != nil {
+				t.conn.Close()
+			}
 			t.conn = conn
 			t.Client = gnmi.NewGNMIClient(conn)
 			return nil
@@ -144,37 +179,63 @@ func (t *Target) CreateGNMIClient(ctx context.Context, opts ...grpc.DialOption)
 	}
 }
 
-// Capabilities sends a gnmi.CapabilitiesRequest to the target *t and returns a gnmi.CapabilitiesResponse and an error
-func (t *Target) Capabilities(ctx context.Context, ext ...*gnmi_ext.Extension) (*gnmi.CapabilityResponse, error) {
-	if t.Config.Username != nil {
-		ctx = metadata.AppendToOutgoingContext(ctx, "username", *t.Config.Username)
+// contextDialer returns the function dialing the target addresses
+// through its proxy, its SSH tunnel and its resolver,
+// nil if the target is dialed directly by gRPC.
+func (t *Target) contextDialer() (dialFn, error) {
+	var dialer dialFn
+	var err error
+	// unix socket targets are co-located, they are dialed directly
+	if strings.HasPrefix(t.Config.Address, "unix://") {
+		return nil, nil
+	}
+	if t.Config.Proxy != "" {
+		dialer, err = proxyDialer(t.Config.Proxy, t.Config.Timeout)
+		if err != nil {
+			return nil, err
+		}
 	}
-	if t.Config.Password != nil {
-		ctx = metadata.AppendToOutgoingContext(ctx, "password", *t.Config.Password)
+	if t.Config.SSHTunnel != nil {
+		t.m.Lock()
+		if t.sshTunnel == nil {
+			// the jump host is reached through the proxy if one is set
+			t.sshTunnel = newSSHTunnel(t.Config.SSHTunnel, t.Config.Timeout, dialer)
+		}
+		dialer = t.sshTunnel.DialContext
+		t.m.Unlock()
+	}
+	if t.Config.Resolver != nil {
+		t.m.Lock()
+		if t.resolver == nil {
+			t.resolver = newResolver(t.Config.Resolver)
+		}
+		t.m.Unlock()
+		if dialer == nil {
+			dialer = directDialer(t.Config.Timeout)
+		}
+		// the target name is resolved locally, then dialed directly,
+		// through the proxy or through the SSH jump host
+		dialer = t.resolver.dialer(dialer)
 	}
+	return dialer, nil
+}
+
+// Capabilities sends a gnmi.CapabilitiesRequest to the target *t and returns a gnmi.CapabilitiesResponse and an error
+func (t *Target) Capabilities(ctx context.Context, ext ...*gnmi_ext.Extension) (*gnmi.CapabilityResponse, error) {
+	ctx = t.withCredentials(ctx)
 	return t.Client.Capabilities(ctx, &gnmi.CapabilityRequest{Extension: ext})
 }
 
 // Get sends a gnmi.GetRequest to the target *t and returns a gnmi.GetResponse and an error
 func (t *Target) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse, error) {
-	if t.Config.Username != nil {
-		ctx = metadata.AppendToOutgoingContext(ctx, "username", *t.Config.Username)
-	}
-	if t.Config.Password != nil {
-		ctx = metadata.AppendToOutgoingContext(ctx, "password", *t.Config.Password)
-	}
+	ctx = t.withCredentials(ctx)
 	return t.Client.Get(ctx, req)
 }
 
 // Set sends a gnmi.SetRequest to the target *t and returns a gnmi.SetResponse and an error
-func (t *Target) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetResponse, error) {
-	if t.Config.Username != nil {
-		ctx = metadata.AppendToOutgoingContext(ctx, "username", *t.Config.Username)
-	}
-	if t.Config.Password != nil {
-		ctx = metadata.AppendToOutgoingContext(ctx, "password", *t.Config.Password)
-	}
-	return t.Client.Set(ctx, req)
+func (t *Target) Set(ctx context.Context, req *gnmi.SetRequest, opts ...grpc.CallOption) (*gnmi.SetResponse, error) {
+	ctx = t.withCredentials(ctx)
+	return t.Client.Set(ctx, req, opts...)
 }
 
 func (t *Target) StopSubscriptions() {
@@ -186,20 +247,75 @@ func (t *Target) StopSubscriptions() {
 	if t.Cfn != nil {
 		t.Cfn()
 	}
+	if t.probeCancelFn != nil {
+		t.probeCancelFn()
+	}
 	if !t.stopped {
 		close(t.StopChan)
 	}
 	t.stopped = true
 }
 
+// SetSubscribeContext sets the context the target subscriptions are started in.
+func (t *Target) SetSubscribeContext(ctx context.Context) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.subscribeCtx = ctx
+}
+
+// SubscribeContext returns the context set by SetSubscribeContext,
+// nil if the target gNMI client is not created yet.
+func (t *Target) SubscribeContext() context.Context {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.subscribeCtx
+}
+
+// StopStreams stops the target subscribe streams and get polls,
+// unlike StopSubscriptions the gNMI client connection is kept open.
+func (t *Target) StopStreams() {
+	t.m.Lock()
+	defer t.m.Unlock()
+	for name, cfn := range t.subscribeCancelFn {
+		cfn()
+		delete(t.subscribeCancelFn, name)
+		delete(t.SubscribeClients, name)
+	}
+	for name := range t.multiplexed {
+		delete(t.multiplexed, name)
+	}
+}
+
 func (t *Target) Close() error {
 	t.StopSubscriptions()
+	t.m.Lock()
+	if t.sshTunnel != nil {
+		t.sshTunnel.Close()
+	}
+	t.m.Unlock()
 	if t.conn != nil {
 		return t.conn.Close()
 	}
 	return nil
 }
 
+// SetLastError records err as the last error seen on the target.
+func (t *Target) SetLastError(err error) {
+	if err == nil {
+		return
+	}
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.lastError = err.Error()
+}
+
+// LastError returns the last error seen on the target.
+func (t *Target) LastError() string {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.lastError
+}
+
 func (t *Target) ConnState() string {
 	if t.conn == nil {
 		return ""
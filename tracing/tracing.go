@@ -0,0 +1,189 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tracing provides optional OpenTelemetry tracing of the telemetry pipeline,
+// from the reception of a subscribe response to its write to the outputs.
+package tracing
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/openconfig/gnmic/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	tracerName = "github.com/openconfig/gnmic"
+
+	defaultServiceName = "gnmic"
+	defaultAddress     = "localhost:4317"
+	defaultTimeout     = 10 * time.Second
+	defaultSampleRatio = 1.0
+)
+
+// Config is the tracing configuration.
+type Config struct {
+	// OTLP gRPC collector address
+	Address string `mapstructure:"address,omitempty" json:"address,omitempty"`
+	// service.name resource attribute, defaults to gnmic
+	ServiceName string `mapstructure:"service-name,omitempty" json:"service-name,omitempty"`
+	// fraction of the pipeline traces sampled, between 0 and 1
+	SampleRatio float64 `mapstructure:"sample-ratio,omitempty" json:"sample-ratio,omitempty"`
+	// export timeout
+	Timeout time.Duration `mapstructure:"timeout,omitempty" json:"timeout,omitempty"`
+	// headers sent with each export request
+	Headers map[string]string `mapstructure:"headers,omitempty" json:"headers,omitempty"`
+	// TLS
+	Insecure   bool   `mapstructure:"insecure,omitempty" json:"insecure,omitempty"`
+	SkipVerify bool   `mapstructure:"skip-verify,omitempty" json:"skip-verify,omitempty"`
+	CaFile     string `mapstructure:"ca-file,omitempty" json:"ca-file,omitempty"`
+	CertFile   string `mapstructure:"cert-file,omitempty" json:"cert-file,omitempty"`
+	KeyFile    string `mapstructure:"key-file,omitempty" json:"key-file,omitempty"`
+}
+
+// SetDefaults sets the default values of unset fields.
+func (c *Config) SetDefaults() {
+	if c.Address == "" {
+		c.Address = defaultAddress
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = defaultServiceName
+	}
+	if c.SampleRatio <= 0 {
+		c.SampleRatio = defaultSampleRatio
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+}
+
+var enabled int32
+
+// Enabled returns true if a tracer provider was started with Start.
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) == 1
+}
+
+// Start configures the global tracer provider to export spans to the OTLP collector in cfg,
+// and the W3C trace context propagator.
+// The returned function flushes the pending spans and stops the provider.
+func Start(ctx context.Context, cfg *Config) (func(context.Context) error, error) {
+	if cfg == nil {
+		return nil, errors.New("missing tracing config")
+	}
+	cfg.SetDefaults()
+	if cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("invalid tracing sample-ratio %v, must be between 0 and 1", cfg.SampleRatio)
+	}
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(cfg.Address),
+		otlptracegrpc.WithTimeout(cfg.Timeout),
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	} else {
+		tlsConfig, err := utils.NewTLSConfig(cfg.CaFile, cfg.CertFile, cfg.KeyFile, cfg.SkipVerify, false)
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig == nil {
+			tlsConfig = new(tls.Config)
+		}
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	exp, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	setProvider(tp)
+	return func(ctx context.Context) error {
+		atomic.StoreInt32(&enabled, 0)
+		return tp.Shutdown(ctx)
+	}, nil
+}
+
+func setProvider(tp trace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	atomic.StoreInt32(&enabled, 1)
+}
+
+// StartSpan starts a span as a child of the span in ctx, if any.
+// It returns a no-op span if tracing is not enabled.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !Enabled() {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartChildSpan is like StartSpan but only starts a span if ctx already holds one.
+// It is used to continue the traces propagated by remote instances without starting new ones.
+func StartChildSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !HasSpan(ctx) {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	return StartSpan(ctx, name, attrs...)
+}
+
+// SetError records err on span and sets its status to error.
+func SetError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Inject writes the span context in ctx to the message headers carrier.
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	if !Enabled() {
+		return
+	}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// Extract returns a copy of ctx holding the span context found in the message headers carrier, if any.
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	if !Enabled() {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// HasSpan returns true if ctx holds a valid span context.
+func HasSpan(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
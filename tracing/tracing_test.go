@@ -0,0 +1,109 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func startRecorder(t *testing.T) *tracetest.SpanRecorder {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	setProvider(tp)
+	t.Cleanup(func() {
+		atomic.StoreInt32(&enabled, 0)
+		tp.Shutdown(context.Background())
+	})
+	return sr
+}
+
+func TestStartSpanDisabled(t *testing.T) {
+	ctx, span := StartSpan(context.Background(), "export")
+	span.End()
+	if span.SpanContext().IsValid() {
+		t.Errorf("expected a no-op span when tracing is disabled")
+	}
+	if HasSpan(ctx) {
+		t.Errorf("expected no span in the returned context")
+	}
+}
+
+func TestSpanHierarchy(t *testing.T) {
+	sr := startRecorder(t)
+
+	ctx, root := StartSpan(context.Background(), "subscribe.receive", attribute.String("target", "router1"))
+	_, child := StartSpan(ctx, "output.write")
+	child.End()
+	root.End()
+
+	spans := sr.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name() != "output.write" || spans[1].Name() != "subscribe.receive" {
+		t.Fatalf("unexpected spans: %q, %q", spans[0].Name(), spans[1].Name())
+	}
+	if spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Errorf("output.write is not a child of subscribe.receive")
+	}
+	if spans[0].SpanContext().TraceID() != spans[1].SpanContext().TraceID() {
+		t.Errorf("spans do not share the same trace ID")
+	}
+}
+
+func TestStartChildSpan(t *testing.T) {
+	sr := startRecorder(t)
+
+	_, span := StartChildSpan(context.Background(), "cache.consume")
+	span.End()
+	if len(sr.Ended()) != 0 {
+		t.Fatalf("expected no span without a parent, got %d", len(sr.Ended()))
+	}
+	ctx, parent := StartSpan(context.Background(), "cache.write")
+	_, span = StartChildSpan(ctx, "cache.publish")
+	span.End()
+	parent.End()
+	if len(sr.Ended()) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(sr.Ended()))
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	startRecorder(t)
+
+	ctx, span := StartSpan(context.Background(), "kafka.send")
+	defer span.End()
+	carrier := propagation.MapCarrier{}
+	Inject(ctx, carrier)
+	if carrier.Get("traceparent") == "" {
+		t.Fatalf("traceparent header not injected: %v", carrier)
+	}
+	rctx := Extract(context.Background(), carrier)
+	_, rspan := StartChildSpan(rctx, "cache.consume")
+	defer rspan.End()
+	if rspan.SpanContext().TraceID() != span.SpanContext().TraceID() {
+		t.Errorf("extracted trace ID %s, expected %s", rspan.SpanContext().TraceID(), span.SpanContext().TraceID())
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+	c := new(Config)
+	c.SetDefaults()
+	if c.Address != defaultAddress || c.ServiceName != defaultServiceName ||
+		c.SampleRatio != defaultSampleRatio || c.Timeout != defaultTimeout {
+		t.Errorf("unexpected defaults: %+v", c)
+	}
+}
@@ -0,0 +1,55 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import "sort"
+
+// TargetGroup is a named set of targets,
+// listed by name or selected by their variables.
+type TargetGroup struct {
+	Name string `mapstructure:"name,omitempty" json:"name,omitempty"`
+	// names of the group targets
+	Targets []string `mapstructure:"targets,omitempty" json:"targets,omitempty"`
+	// target variables, the targets having all of them
+	// with the same values belong to the group
+	Selector map[string]string `mapstructure:"selector,omitempty" json:"selector,omitempty"`
+}
+
+// Contains returns true if the target tc belongs to the group.
+func (g *TargetGroup) Contains(tc *TargetConfig) bool {
+	if tc == nil {
+		return false
+	}
+	for _, n := range g.Targets {
+		if n == tc.Name {
+			return true
+		}
+	}
+	if len(g.Selector) == 0 {
+		return false
+	}
+	for k, v := range g.Selector {
+		if tv, ok := tc.Vars[k]; !ok || tv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Members returns the sorted names of the targets in tcs belonging to the group.
+func (g *TargetGroup) Members(tcs map[string]*TargetConfig) []string {
+	names := make([]string, 0)
+	for n, tc := range tcs {
+		if g.Contains(tc) {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,47 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// SubscriptionProfile is a named set of subscriptions, event processors and output hints
+// that can be referenced by targets using their profiles field.
+type SubscriptionProfile struct {
+	Name          string                         `mapstructure:"name,omitempty" json:"name,omitempty"`
+	Description   string                         `mapstructure:"description,omitempty" json:"description,omitempty"`
+	Subscriptions map[string]*SubscriptionConfig `mapstructure:"subscriptions,omitempty" json:"subscriptions,omitempty"`
+	// outputs used by the targets referencing the profile,
+	// if they don't define their own.
+	Outputs []string `mapstructure:"outputs,omitempty" json:"outputs,omitempty"`
+	// event processors applied to the events of the profile subscriptions,
+	// before the outputs event processors.
+	Processors []string `mapstructure:"processors,omitempty" json:"processors,omitempty"`
+}
+
+// String //
+func (sp *SubscriptionProfile) String() string {
+	b, err := json.Marshal(sp)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// SubscriptionNames returns the sorted names of the profile subscriptions
+func (sp *SubscriptionProfile) SubscriptionNames() []string {
+	names := make([]string, 0, len(sp.Subscriptions))
+	for n := range sp.Subscriptions {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
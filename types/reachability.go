@@ -0,0 +1,55 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	ReachabilityProbeTCP        = "tcp"
+	ReachabilityProbeGRPCHealth = "grpc-health"
+
+	defaultReachabilityProbeInterval = 30 * time.Second
+	defaultReachabilityProbeTimeout  = 5 * time.Second
+)
+
+// ReachabilityProbeConfig configures the periodic probing of a target reachability,
+// independently of its subscriptions.
+type ReachabilityProbeConfig struct {
+	// tcp or grpc-health
+	Type string `mapstructure:"type,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	// interval between two probes
+	Interval time.Duration `mapstructure:"interval,omitempty" json:"interval,omitempty" yaml:"interval,omitempty"`
+	// time to wait for a probe to succeed
+	Timeout time.Duration `mapstructure:"timeout,omitempty" json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// service name sent in the gRPC health check request,
+	// empty for the overall target health
+	Service string `mapstructure:"service,omitempty" json:"service,omitempty" yaml:"service,omitempty"`
+}
+
+// Validate checks the reachability probe config and sets its defaults.
+func (p *ReachabilityProbeConfig) Validate() error {
+	switch p.Type {
+	case "":
+		p.Type = ReachabilityProbeTCP
+	case ReachabilityProbeTCP, ReachabilityProbeGRPCHealth:
+	default:
+		return fmt.Errorf("reachability-probe: unknown type %q, must be one of %q", p.Type,
+			[]string{ReachabilityProbeTCP, ReachabilityProbeGRPCHealth})
+	}
+	if p.Interval <= 0 {
+		p.Interval = defaultReachabilityProbeInterval
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = defaultReachabilityProbeTimeout
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	IPFamilyIPv4 = "ipv4"
+	IPFamilyIPv6 = "ipv6"
+
+	defaultResolverTimeout = 5 * time.Second
+)
+
+// ResolverConfig configures the resolution of the target address host name.
+// The name is resolved by gnmic, even when the target is reached through a proxy or an SSH jump host.
+type ResolverConfig struct {
+	// DNS servers, ip or ip:port, queried in order until one answers.
+	// The system resolver is used if empty
+	Servers []string `mapstructure:"servers,omitempty" json:"servers,omitempty" yaml:"servers,omitempty"`
+	// static host name to addresses overrides, /etc/hosts style
+	Hosts map[string][]string `mapstructure:"hosts,omitempty" json:"hosts,omitempty" yaml:"hosts,omitempty"`
+	// ipv4 or ipv6, the addresses of this family are dialed first
+	Prefer string `mapstructure:"prefer,omitempty" json:"prefer,omitempty" yaml:"prefer,omitempty"`
+	// ipv4 or ipv6, only the addresses of this family are dialed
+	Family string `mapstructure:"family,omitempty" json:"family,omitempty" yaml:"family,omitempty"`
+	// resolve the name once and reuse the addresses on the following dials,
+	// instead of resolving it on each dial
+	ResolveOnce bool `mapstructure:"resolve-once,omitempty" json:"resolve-once,omitempty" yaml:"resolve-once,omitempty"`
+	// DNS query timeout
+	Timeout time.Duration `mapstructure:"timeout,omitempty" json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// Validate checks the resolver config and sets its defaults.
+func (r *ResolverConfig) Validate() error {
+	for i, s := range r.Servers {
+		host, port, err := net.SplitHostPort(s)
+		if err != nil {
+			host, port = s, "53"
+		}
+		if net.ParseIP(host) == nil {
+			return fmt.Errorf("resolver: invalid DNS server %q", s)
+		}
+		r.Servers[i] = net.JoinHostPort(host, port)
+	}
+	for name, addrs := range r.Hosts {
+		for _, a := range addrs {
+			if net.ParseIP(a) == nil {
+				return fmt.Errorf("resolver: invalid address %q for host %q", a, name)
+			}
+		}
+	}
+	for _, f := range []string{r.Prefer, r.Family} {
+		switch f {
+		case "", IPFamilyIPv4, IPFamilyIPv6:
+		default:
+			return fmt.Errorf("resolver: unknown IP family %q, must be one of %q", f, []string{IPFamilyIPv4, IPFamilyIPv6})
+		}
+	}
+	if r.Timeout <= 0 {
+		r.Timeout = defaultResolverTimeout
+	}
+	return nil
+}
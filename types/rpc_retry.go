@@ -0,0 +1,104 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	defaultRPCRetryBackoff    = 500 * time.Millisecond
+	defaultRPCRetryMaxBackoff = 10 * time.Second
+)
+
+// RPCRetry configures the retries of the unary RPCs: Capabilities, Get and Set.
+type RPCRetry struct {
+	// maximum number of retries, 0 disables them
+	Count int `mapstructure:"count,omitempty" json:"count,omitempty" yaml:"count,omitempty"`
+	// delay before the first retry, doubled after each retry
+	Backoff time.Duration `mapstructure:"backoff,omitempty" json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	// maximum delay between two retries
+	MaxBackoff time.Duration `mapstructure:"max-backoff,omitempty" json:"max-backoff,omitempty" yaml:"max-backoff,omitempty"`
+	// gRPC status codes the RPC is retried on, e.g: unavailable, resource_exhausted.
+	// defaults to unavailable
+	RetryOn []string `mapstructure:"retry-on,omitempty" json:"retry-on,omitempty" yaml:"retry-on,omitempty"`
+}
+
+// Validate checks the retry-on codes names and sets the retry defaults.
+func (r *RPCRetry) Validate() error {
+	if r.Count < 0 {
+		return fmt.Errorf("invalid rpc-retry count %d", r.Count)
+	}
+	if r.Backoff <= 0 {
+		r.Backoff = defaultRPCRetryBackoff
+	}
+	if r.MaxBackoff <= 0 {
+		r.MaxBackoff = defaultRPCRetryMaxBackoff
+	}
+	if len(r.RetryOn) == 0 {
+		r.RetryOn = []string{GRPCCodeName(codes.Unavailable)}
+	}
+	for _, n := range r.RetryOn {
+		if _, ok := grpcCodes[strings.ToLower(n)]; !ok {
+			return fmt.Errorf("unknown rpc-retry retry-on code %q", n)
+		}
+	}
+	return nil
+}
+
+// RetriesOn returns true if the RPC is retried after failing with code c.
+func (r *RPCRetry) RetriesOn(c codes.Code) bool {
+	for _, n := range r.RetryOn {
+		if grpcCodes[strings.ToLower(n)] == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns the delay before the retry number attempt, starting at 1.
+func (r *RPCRetry) Delay(attempt int) time.Duration {
+	d := r.Backoff
+	for i := 1; i < attempt && d < r.MaxBackoff; i++ {
+		d *= 2
+	}
+	if r.MaxBackoff > 0 && d > r.MaxBackoff {
+		return r.MaxBackoff
+	}
+	return d
+}
+
+// grpcCodes maps the gRPC status codes names to their code.
+var grpcCodes = func() map[string]codes.Code {
+	m := make(map[string]codes.Code)
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		m[GRPCCodeName(c)] = c
+	}
+	return m
+}()
+
+// GRPCCodeName returns the snake case name of code c, e.g: permission_denied.
+func GRPCCodeName(c codes.Code) string {
+	sb := strings.Builder{}
+	for i, r := range c.String() {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,176 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SubscriptionSchedule restricts the time windows during which a subscription is active.
+type SubscriptionSchedule struct {
+	// IANA time zone of the windows, defaults to the local time zone
+	Timezone string `mapstructure:"timezone,omitempty" json:"timezone,omitempty"`
+	// windows during which the subscription is active,
+	// the subscription is always active if empty.
+	Active []*TimeWindow `mapstructure:"active,omitempty" json:"active,omitempty"`
+	// windows during which the subscription is paused,
+	// they take precedence over the active windows.
+	Inactive []*TimeWindow `mapstructure:"inactive,omitempty" json:"inactive,omitempty"`
+}
+
+// TimeWindow is a daily time window, limited to some week days.
+type TimeWindow struct {
+	// week days the window starts on, e.g: mon, sat or ranges like mon-fri,
+	// all days if empty.
+	Days []string `mapstructure:"days,omitempty" json:"days,omitempty"`
+	// window start time, HH:MM
+	Start string `mapstructure:"start,omitempty" json:"start,omitempty"`
+	// window end time, HH:MM. If it is not after the start time, the window ends the next day.
+	End string `mapstructure:"end,omitempty" json:"end,omitempty"`
+}
+
+var weekDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Validate checks the schedule time zone and windows.
+func (s *SubscriptionSchedule) Validate() error {
+	_, err := s.location()
+	if err != nil {
+		return err
+	}
+	for _, w := range append(append([]*TimeWindow{}, s.Active...), s.Inactive...) {
+		if w == nil {
+			continue
+		}
+		_, err = w.days()
+		if err != nil {
+			return err
+		}
+		_, err = parseDayMinute(w.Start)
+		if err != nil {
+			return err
+		}
+		_, err = parseDayMinute(w.End)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ActiveAt returns true if the subscription is active at time t.
+func (s *SubscriptionSchedule) ActiveAt(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	loc, err := s.location()
+	if err != nil {
+		return true
+	}
+	t = t.In(loc)
+	for _, w := range s.Inactive {
+		if w != nil && w.contains(t) {
+			return false
+		}
+	}
+	if len(s.Active) == 0 {
+		return true
+	}
+	for _, w := range s.Active {
+		if w != nil && w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SubscriptionSchedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule timezone %q: %v", s.Timezone, err)
+	}
+	return loc, nil
+}
+
+// contains returns true if t is within the window.
+func (w *TimeWindow) contains(t time.Time) bool {
+	days, err := w.days()
+	if err != nil {
+		return false
+	}
+	start, err := parseDayMinute(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseDayMinute(w.End)
+	if err != nil {
+		return false
+	}
+	m := t.Hour()*60 + t.Minute()
+	if start < end {
+		return days[t.Weekday()] && m >= start && m < end
+	}
+	// the window ends the next day
+	yesterday := (t.Weekday() + 6) % 7
+	return (days[t.Weekday()] && m >= start) || (days[yesterday] && m < end)
+}
+
+// days returns the week days the window starts on.
+func (w *TimeWindow) days() (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool, 7)
+	if len(w.Days) == 0 {
+		for _, d := range weekDays {
+			days[d] = true
+		}
+		return days, nil
+	}
+	for _, d := range w.Days {
+		first, last, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(d)), "-")
+		fd, ok := weekDays[first]
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule day %q", d)
+		}
+		if !isRange {
+			days[fd] = true
+			continue
+		}
+		ld, ok := weekDays[last]
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule day %q", d)
+		}
+		for wd := fd; ; wd = (wd + 1) % 7 {
+			days[wd] = true
+			if wd == ld {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseDayMinute returns the number of minutes since midnight of the HH:MM time s.
+func parseDayMinute(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedule time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -11,12 +11,17 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 )
 
 const (
 	notApplicable = "NA"
+
+	// SubscriptionModeGetPoll is the mode of the subscriptions
+	// polled with periodic Get RPCs instead of a Subscribe RPC.
+	SubscriptionModeGetPoll = "get-poll"
 )
 
 // SubscriptionConfig //
@@ -36,6 +41,112 @@ type SubscriptionConfig struct {
 	SuppressRedundant bool           `mapstructure:"suppress-redundant,omitempty" json:"suppress-redundant,omitempty"`
 	UpdatesOnly       bool           `mapstructure:"updates-only,omitempty" json:"updates-only,omitempty"`
 	History           *HistoryConfig `mapstructure:"history,omitempty" json:"history,omitempty"`
+	// depth extension level, 0 means no limit
+	Depth uint32 `mapstructure:"depth,omitempty" json:"depth,omitempty"`
+	// namespace the subscription belongs to,
+	// a subscription without namespace is available to all targets.
+	Namespace string `mapstructure:"namespace,omitempty" json:"namespace,omitempty"`
+	// stream parameters overrides for specific targets,
+	// applied in order, the later ones take precedence.
+	Overrides []*SubscriptionOverride `mapstructure:"overrides,omitempty" json:"overrides,omitempty"`
+	// time windows during which the subscription is active
+	Schedule *SubscriptionSchedule `mapstructure:"schedule,omitempty" json:"schedule,omitempty"`
+	// notifications timestamps handling
+	Timestamp *TimestampConfig `mapstructure:"timestamp,omitempty" json:"timestamp,omitempty"`
+}
+
+// SubscriptionOverride changes the stream parameters of a subscription
+// sent to the targets it matches.
+type SubscriptionOverride struct {
+	// regular expressions matched against the target name
+	Targets []string `mapstructure:"targets,omitempty" json:"targets,omitempty"`
+	// target variables, the target must have all of them with the same values
+	Vars              map[string]string `mapstructure:"vars,omitempty" json:"vars,omitempty"`
+	StreamMode        string            `mapstructure:"stream-mode,omitempty" json:"stream-mode,omitempty"`
+	SampleInterval    *time.Duration    `mapstructure:"sample-interval,omitempty" json:"sample-interval,omitempty"`
+	HeartbeatInterval *time.Duration    `mapstructure:"heartbeat-interval,omitempty" json:"heartbeat-interval,omitempty"`
+	SuppressRedundant *bool             `mapstructure:"suppress-redundant,omitempty" json:"suppress-redundant,omitempty"`
+}
+
+// Validate checks the override targets regular expressions.
+func (o *SubscriptionOverride) Validate() error {
+	if len(o.Targets) == 0 && len(o.Vars) == 0 {
+		return fmt.Errorf("override must set targets or vars")
+	}
+	for _, expr := range o.Targets {
+		_, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid override target %q: %v", expr, err)
+		}
+	}
+	return nil
+}
+
+// Matches returns true if the override applies to the target tc.
+func (o *SubscriptionOverride) Matches(tc *TargetConfig) bool {
+	if tc == nil {
+		return false
+	}
+	for k, v := range o.Vars {
+		if tv, ok := tc.Vars[k]; !ok || tv != v {
+			return false
+		}
+	}
+	if len(o.Targets) == 0 {
+		return true
+	}
+	for _, expr := range o.Targets {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			continue
+		}
+		if re.MatchString(tc.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForTarget returns the subscription config to send to target tc,
+// a copy with the matching overrides applied, or sc if none matches.
+func (sc *SubscriptionConfig) ForTarget(tc *TargetConfig) *SubscriptionConfig {
+	var nsc *SubscriptionConfig
+	for _, o := range sc.Overrides {
+		if o == nil || !o.Matches(tc) {
+			continue
+		}
+		if nsc == nil {
+			c := *sc
+			nsc = &c
+		}
+		if o.StreamMode != "" {
+			nsc.StreamMode = o.StreamMode
+		}
+		if o.SampleInterval != nil {
+			nsc.SampleInterval = o.SampleInterval
+		}
+		if o.HeartbeatInterval != nil {
+			nsc.HeartbeatInterval = o.HeartbeatInterval
+		}
+		if o.SuppressRedundant != nil {
+			nsc.SuppressRedundant = *o.SuppressRedundant
+		}
+	}
+	if nsc == nil {
+		return sc
+	}
+	return nsc
+}
+
+// IsGetPoll returns true if the subscription is polled with Get RPCs.
+func (sc *SubscriptionConfig) IsGetPoll() bool {
+	return strings.EqualFold(sc.Mode, SubscriptionModeGetPoll)
+}
+
+// InNamespace returns true if the subscription can be used
+// by the targets of namespace ns.
+func (sc *SubscriptionConfig) InNamespace(ns string) bool {
+	return sc.Namespace == "" || sc.Namespace == ns
 }
 
 type HistoryConfig struct {
@@ -72,7 +183,7 @@ func (sc *SubscriptionConfig) ModeString() string {
 }
 
 func (sc *SubscriptionConfig) SampleIntervalString() string {
-	if strings.ToLower(sc.Mode) == "stream" && strings.ToLower(sc.StreamMode) == "sample" {
+	if sc.IsGetPoll() || strings.ToLower(sc.Mode) == "stream" && strings.ToLower(sc.StreamMode) == "sample" {
 		return sc.SampleInterval.String()
 	}
 	return notApplicable
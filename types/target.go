@@ -16,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/openconfig/gnmic/spiffe"
 	"github.com/openconfig/gnmic/utils"
 	"golang.org/x/oauth2"
 	"google.golang.org/grpc"
@@ -23,6 +24,14 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/credentials/oauth"
 	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	DialStrategyOrdered       = "ordered"
+	DialStrategyHappyEyeballs = "happy-eyeballs"
+
+	DefaultDialDelay = 250 * time.Millisecond
 )
 
 // TargetConfig //
@@ -49,18 +58,137 @@ type TargetConfig struct {
 	ProtoDirs     []string          `mapstructure:"proto-dirs,omitempty" json:"proto-dirs,omitempty" yaml:"proto-dirs,omitempty"`
 	Tags          []string          `mapstructure:"tags,omitempty" json:"tags,omitempty" yaml:"tags,omitempty"`
 	EventTags     map[string]string `mapstructure:"event-tags,omitempty" json:"event-tags,omitempty" yaml:"event-tags,omitempty"`
+	Vars          map[string]string `mapstructure:"vars,omitempty" json:"vars,omitempty" yaml:"vars,omitempty"`
+	Namespace     string            `mapstructure:"namespace,omitempty" json:"namespace,omitempty" yaml:"namespace,omitempty"`
 	Gzip          *bool             `mapstructure:"gzip,omitempty" json:"gzip,omitempty" yaml:"gzip,omitempty"`
 	Token         *string           `mapstructure:"token,omitempty" json:"token,omitempty" yaml:"token,omitempty"`
 	Proxy         string            `mapstructure:"proxy,omitempty" json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	Profiles      []string          `mapstructure:"profiles,omitempty" json:"profiles,omitempty" yaml:"profiles,omitempty"`
+	// vendor of the target network OS, one of arista, cisco, juniper or nokia.
+	// selects the origin and encoding defaults of the target requests.
+	Vendor string `mapstructure:"vendor,omitempty" json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	// run a Capabilities RPC before subscribing and select the encoding of
+	// the subscriptions with encoding "auto" from the supported encodings
+	ProbeCapabilities *bool `mapstructure:"probe-capabilities,omitempty" json:"probe-capabilities,omitempty" yaml:"probe-capabilities,omitempty"`
+	// duration during which a successful Capabilities probe is reused
+	// instead of sending a new Capabilities RPC, zero disables the cache
+	CapabilitiesTTL time.Duration `mapstructure:"capabilities-ttl,omitempty" json:"capabilities-ttl,omitempty" yaml:"capabilities-ttl,omitempty"`
+	// encodings in order of preference, used to select the "auto" subscriptions encoding
+	EncodingPreference []string `mapstructure:"encoding-preference,omitempty" json:"encoding-preference,omitempty" yaml:"encoding-preference,omitempty"`
+	// maximum redial backoff, the backoff starts at the retry timer and doubles after each failure
+	MaxRetryTimer time.Duration `mapstructure:"max-retry,omitempty" json:"max-retry,omitempty" yaml:"max-retry,omitempty"`
+	// number of times the connection can go down within flap-window before the target is held down
+	FlapThreshold int           `mapstructure:"flap-threshold,omitempty" json:"flap-threshold,omitempty" yaml:"flap-threshold,omitempty"`
+	FlapWindow    time.Duration `mapstructure:"flap-window,omitempty" json:"flap-window,omitempty" yaml:"flap-window,omitempty"`
+	// how long a flapping target is held down before being redialed
+	FlapHoldDown time.Duration `mapstructure:"flap-hold-down,omitempty" json:"flap-hold-down,omitempty" yaml:"flap-hold-down,omitempty"`
+	// send the compatible subscriptions (same mode, encoding, prefix,...) over a single subscribe stream
+	MultiplexSubscriptions *bool `mapstructure:"multiplex-subscriptions,omitempty" json:"multiplex-subscriptions,omitempty" yaml:"multiplex-subscriptions,omitempty"`
+	// maximum number of concurrent subscribe streams to the target,
+	// the subscriptions are multiplexed if they exceed it
+	MaxStreams int `mapstructure:"max-streams,omitempty" json:"max-streams,omitempty" yaml:"max-streams,omitempty"`
+	// gRPC client keepalive parameters
+	GRPCKeepalive *ClientKeepalive `mapstructure:"grpc-keepalive,omitempty" json:"grpc-keepalive,omitempty" yaml:"grpc-keepalive,omitempty"`
+	// retries of the Capabilities, Get and Set RPCs
+	RPCRetry *RPCRetry `mapstructure:"rpc-retry,omitempty" json:"rpc-retry,omitempty" yaml:"rpc-retry,omitempty"`
+	// maximum number of Capabilities, Get and Set RPCs per second sent to the target, 0 means no limit
+	RPCRate float64 `mapstructure:"rpc-rate,omitempty" json:"rpc-rate,omitempty" yaml:"rpc-rate,omitempty"`
+	// number of RPCs sent at once before rpc-rate applies, defaults to rpc-rate rounded up
+	RPCBurst int `mapstructure:"rpc-burst,omitempty" json:"rpc-burst,omitempty" yaml:"rpc-burst,omitempty"`
+	// gRPC stream and connection initial window sizes, values lower than 64KB are ignored
+	InitialWindowSize     int32 `mapstructure:"initial-window-size,omitempty" json:"initial-window-size,omitempty" yaml:"initial-window-size,omitempty"`
+	InitialConnWindowSize int32 `mapstructure:"initial-conn-window-size,omitempty" json:"initial-conn-window-size,omitempty" yaml:"initial-conn-window-size,omitempty"`
+	// maximum size of a received gRPC message, overrides the global max-msg-size
+	MaxMsgSize int `mapstructure:"max-msg-size,omitempty" json:"max-msg-size,omitempty" yaml:"max-msg-size,omitempty"`
+	// gRPC user-agent, overrides the default gNMIc/<version>
+	UserAgent string `mapstructure:"user-agent,omitempty" json:"user-agent,omitempty" yaml:"user-agent,omitempty"`
+	// how the addresses of a target with multiple addresses are dialed:
+	// ordered or happy-eyeballs. When unset, they are dialed in parallel
+	DialStrategy string `mapstructure:"dial-strategy,omitempty" json:"dial-strategy,omitempty" yaml:"dial-strategy,omitempty"`
+	// delay between two connection attempts of the happy-eyeballs dial strategy
+	DialDelay time.Duration `mapstructure:"dial-delay,omitempty" json:"dial-delay,omitempty" yaml:"dial-delay,omitempty"`
+	// periodic probing of the target reachability, independent of the subscriptions
+	ReachabilityProbe *ReachabilityProbeConfig `mapstructure:"reachability-probe,omitempty" json:"reachability-probe,omitempty" yaml:"reachability-probe,omitempty"`
+	// resolution options of the target address host name
+	Resolver *ResolverConfig `mapstructure:"resolver,omitempty" json:"resolver,omitempty" yaml:"resolver,omitempty"`
+	// dial the target through an SSH jump host
+	SSHTunnel *SSHTunnelConfig `mapstructure:"ssh-tunnel,omitempty" json:"ssh-tunnel,omitempty" yaml:"ssh-tunnel,omitempty"`
+	// credentials read from a credentials provider,
+	// they override the username, password, token and TLS material set in the config
+	Credentials *CredentialsRef `mapstructure:"credentials,omitempty" json:"credentials,omitempty" yaml:"credentials,omitempty"`
+	// token sent in the gRPC metadata of each RPC, refreshed before it expires.
+	// It takes precedence over the static token field
+	TokenAuth *TokenAuth `mapstructure:"token-auth,omitempty" json:"token-auth,omitempty" yaml:"token-auth,omitempty"`
+	// name of the connection profile the unset fields are taken from
+	ConnectionProfile string `mapstructure:"connection-profile,omitempty" json:"connection-profile,omitempty" yaml:"connection-profile,omitempty"`
+	// TLS 1.0 to 1.2 cipher suites names, in order of preference, e.g: TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+	// The insecure suites are accepted to reach legacy devices
+	TLSCipherSuites []string `mapstructure:"tls-cipher-suites,omitempty" json:"tls-cipher-suites,omitempty" yaml:"tls-cipher-suites,omitempty"`
+	// key exchange curves names, in order of preference, e.g: X25519MLKEM768, X25519, P256
+	TLSCurvePreferences []string `mapstructure:"tls-curve-preferences,omitempty" json:"tls-curve-preferences,omitempty" yaml:"tls-curve-preferences,omitempty"`
+	// expected SPIFFE ID of the target, or its trust domain.
+	// When set, the TLS connection uses the gnmic X.509 SVID instead of the TLS files
+	SpiffeID string `mapstructure:"spiffe-id,omitempty" json:"spiffe-id,omitempty" yaml:"spiffe-id,omitempty"`
 	//
 	TunnelTargetType string `mapstructure:"-" json:"tunnel-target-type,omitempty" yaml:"tunnel-target-type,omitempty"`
 }
 
+// ClientKeepalive holds the gRPC client keepalive parameters.
+type ClientKeepalive struct {
+	// interval after which a ping is sent if no activity is seen on the connection
+	Time time.Duration `mapstructure:"time,omitempty" json:"time,omitempty" yaml:"time,omitempty"`
+	// time to wait for the ping ack before closing the connection
+	Timeout time.Duration `mapstructure:"timeout,omitempty" json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// send pings even without active streams
+	PermitWithoutStream bool `mapstructure:"permit-without-stream,omitempty" json:"permit-without-stream,omitempty" yaml:"permit-without-stream,omitempty"`
+}
+
+// SSHTunnelConfig is the configuration of the SSH jump host used to reach a target.
+type SSHTunnelConfig struct {
+	// jump host address, the port defaults to 22
+	Address  string `mapstructure:"address,omitempty" json:"address,omitempty" yaml:"address,omitempty"`
+	Username string `mapstructure:"username,omitempty" json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `mapstructure:"password,omitempty" json:"password,omitempty" yaml:"password,omitempty"`
+	// path to a private key file and its optional passphrase
+	PrivateKey string `mapstructure:"private-key,omitempty" json:"private-key,omitempty" yaml:"private-key,omitempty"`
+	Passphrase string `mapstructure:"passphrase,omitempty" json:"passphrase,omitempty" yaml:"passphrase,omitempty"`
+	// authenticate using the keys of the ssh agent listening on SSH_AUTH_SOCK
+	Agent bool `mapstructure:"agent,omitempty" json:"agent,omitempty" yaml:"agent,omitempty"`
+	// known hosts file used to verify the jump host key, defaults to ~/.ssh/known_hosts
+	KnownHosts            string `mapstructure:"known-hosts,omitempty" json:"known-hosts,omitempty" yaml:"known-hosts,omitempty"`
+	InsecureIgnoreHostKey bool   `mapstructure:"insecure-ignore-host-key,omitempty" json:"insecure-ignore-host-key,omitempty" yaml:"insecure-ignore-host-key,omitempty"`
+	// interval between SSH keepalive requests, defaults to 30s
+	KeepaliveInterval time.Duration `mapstructure:"keepalive-interval,omitempty" json:"keepalive-interval,omitempty" yaml:"keepalive-interval,omitempty"`
+}
+
+// CredentialsRef references a secret stored in a credentials provider.
+type CredentialsRef struct {
+	// name of the credentials provider
+	Provider string `mapstructure:"provider,omitempty" json:"provider,omitempty" yaml:"provider,omitempty"`
+	// provider specific secret reference: a Vault path,
+	// an AWS Secrets Manager secret name or a file path
+	Secret string `mapstructure:"secret,omitempty" json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
 func (tc TargetConfig) String() string {
 	if tc.Password != nil {
 		pwd := "****"
 		tc.Password = &pwd
 	}
+	if tc.Token != nil {
+		tok := "****"
+		tc.Token = &tok
+	}
+	if tc.TokenAuth != nil {
+		ta := *tc.TokenAuth
+		if ta.Token != "" {
+			ta.Token = "****"
+		}
+		if ta.ClientSecret != "" {
+			ta.ClientSecret = "****"
+		}
+		tc.TokenAuth = &ta
+	}
 
 	b, err := json.Marshal(tc)
 	if err != nil {
@@ -72,6 +200,22 @@ func (tc TargetConfig) String() string {
 
 // NewTLSConfig //
 func (tc *TargetConfig) NewTLSConfig() (*tls.Config, error) {
+	if tc.SpiffeID != "" {
+		src := spiffe.Default()
+		if src == nil {
+			return nil, fmt.Errorf("target %q: spiffe-id is set but the spiffe workload API is not configured", tc.Name)
+		}
+		tlsConfig := src.ClientTLSConfig([]string{tc.SpiffeID})
+		tlsConfig.MaxVersion = tc.getTLSMaxVersion()
+		if v := tc.getTLSMinVersion(); v != 0 {
+			tlsConfig.MinVersion = v
+		}
+		err := tc.applyTLSPolicy(tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return tlsConfig, nil
+	}
 	var ca, cert, key string
 	if tc.TLSCA != nil {
 		ca = *tc.TLSCA
@@ -87,7 +231,10 @@ func (tc *TargetConfig) NewTLSConfig() (*tls.Config, error) {
 		return nil, err
 	}
 	if tlsConfig == nil {
-		return nil, nil
+		if !tc.hasTLSPolicy() {
+			return nil, nil
+		}
+		tlsConfig = new(tls.Config)
 	}
 	if tc.LogTLSSecret != nil && *tc.LogTLSSecret {
 		logPath := tc.Name + ".tlssecret.log"
@@ -100,9 +247,32 @@ func (tc *TargetConfig) NewTLSConfig() (*tls.Config, error) {
 
 	tlsConfig.MaxVersion = tc.getTLSMaxVersion()
 	tlsConfig.MinVersion = tc.getTLSMinVersion()
+	err = tc.applyTLSPolicy(tlsConfig)
+	if err != nil {
+		return nil, err
+	}
 	return tlsConfig, nil
 }
 
+// hasTLSPolicy returns true if any of the TLS version,
+// cipher suites or curve preferences options is set.
+func (tc *TargetConfig) hasTLSPolicy() bool {
+	return tc.getTLSMinVersion() != 0 || tc.getTLSMaxVersion() != 0 ||
+		len(tc.TLSCipherSuites) > 0 || len(tc.TLSCurvePreferences) > 0
+}
+
+func (tc *TargetConfig) applyTLSPolicy(tlsConfig *tls.Config) error {
+	p := &utils.TLSPolicy{
+		CipherSuites:     tc.TLSCipherSuites,
+		CurvePreferences: tc.TLSCurvePreferences,
+	}
+	err := p.Apply(tlsConfig)
+	if err != nil {
+		return fmt.Errorf("target %q: %v", tc.Name, err)
+	}
+	return nil
+}
+
 // GrpcDialOptions creates the grpc.dialOption list from the target's configuration
 func (tc *TargetConfig) GrpcDialOptions() ([]grpc.DialOption, error) {
 	tOpts := make([]grpc.DialOption, 0, 1)
@@ -110,6 +280,40 @@ func (tc *TargetConfig) GrpcDialOptions() ([]grpc.DialOption, error) {
 	if tc.Gzip != nil && *tc.Gzip {
 		tOpts = append(tOpts, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
 	}
+	// keepalive
+	if tc.GRPCKeepalive != nil {
+		tOpts = append(tOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                tc.GRPCKeepalive.Time,
+			Timeout:             tc.GRPCKeepalive.Timeout,
+			PermitWithoutStream: tc.GRPCKeepalive.PermitWithoutStream,
+		}))
+	}
+	// window sizes
+	if tc.InitialWindowSize > 0 {
+		tOpts = append(tOpts, grpc.WithInitialWindowSize(tc.InitialWindowSize))
+	}
+	if tc.InitialConnWindowSize > 0 {
+		tOpts = append(tOpts, grpc.WithInitialConnWindowSize(tc.InitialConnWindowSize))
+	}
+	// max receive message size
+	if tc.MaxMsgSize > 0 {
+		tOpts = append(tOpts, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(tc.MaxMsgSize)))
+	}
+	// user-agent
+	if tc.UserAgent != "" {
+		tOpts = append(tOpts, grpc.WithUserAgent(tc.UserAgent))
+	}
+	// token auth
+	if tc.TokenAuth != nil {
+		if tc.Insecure != nil && *tc.Insecure && !tc.TokenAuth.AllowInsecure {
+			return nil, fmt.Errorf("target %q: token-auth over an insecure connection requires allow-insecure", tc.Name)
+		}
+		perRPC, err := tc.TokenAuth.PerRPCCredentials()
+		if err != nil {
+			return nil, err
+		}
+		tOpts = append(tOpts, grpc.WithPerRPCCredentials(perRPC))
+	}
 	// insecure
 	if tc.Insecure != nil && *tc.Insecure {
 		tOpts = append(tOpts,
@@ -126,7 +330,7 @@ func (tc *TargetConfig) GrpcDialOptions() ([]grpc.DialOption, error) {
 	}
 	tOpts = append(tOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
 	// token credentials
-	if tc.Token != nil && *tc.Token != "" {
+	if tc.TokenAuth == nil && tc.Token != nil && *tc.Token != "" {
 		tOpts = append(tOpts,
 			grpc.WithPerRPCCredentials(
 				oauth.NewOauthAccess(&oauth2.Token{
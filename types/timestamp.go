@@ -0,0 +1,68 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// TimestampSourceDevice keeps the notifications timestamps set by the device.
+	TimestampSourceDevice = "device"
+	// TimestampSourceArrival replaces the notifications timestamps with their arrival time.
+	TimestampSourceArrival = "arrival"
+)
+
+// TimestampConfig sets how the timestamps of the notifications
+// received by a subscription are handled.
+type TimestampConfig struct {
+	// device (default) or arrival
+	Source string `mapstructure:"source,omitempty" json:"source,omitempty"`
+	// added to the device timestamps, corrects a known device clock skew
+	Offset time.Duration `mapstructure:"offset,omitempty" json:"offset,omitempty"`
+	// device timestamps further than max-skew from the arrival time
+	// are replaced by the arrival time, disabled if 0.
+	MaxSkew time.Duration `mapstructure:"max-skew,omitempty" json:"max-skew,omitempty"`
+	// if true, the device and arrival timestamps are added to the events values
+	Record bool `mapstructure:"record,omitempty" json:"record,omitempty"`
+}
+
+// Validate checks the timestamp source and max-skew.
+func (tc *TimestampConfig) Validate() error {
+	switch strings.ToLower(tc.Source) {
+	case "", TimestampSourceDevice, TimestampSourceArrival:
+	default:
+		return fmt.Errorf("unknown timestamp source %q, must be %s or %s", tc.Source, TimestampSourceDevice, TimestampSourceArrival)
+	}
+	if tc.MaxSkew < 0 {
+		return fmt.Errorf("invalid timestamp max-skew %s", tc.MaxSkew)
+	}
+	return nil
+}
+
+// Adjust returns the timestamp, in nanoseconds, of a notification
+// with the device timestamp ts received at arrival.
+func (tc *TimestampConfig) Adjust(ts int64, arrival time.Time) int64 {
+	if tc == nil {
+		return ts
+	}
+	if strings.EqualFold(tc.Source, TimestampSourceArrival) {
+		return arrival.UnixNano()
+	}
+	ts += int64(tc.Offset)
+	if tc.MaxSkew > 0 {
+		skew := time.Duration(ts - arrival.UnixNano())
+		if skew > tc.MaxSkew || skew < -tc.MaxSkew {
+			return arrival.UnixNano()
+		}
+	}
+	return ts
+}
@@ -0,0 +1,188 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	TokenAuthStatic  = "static"
+	TokenAuthOAuth2  = "oauth2"
+	TokenAuthCommand = "command"
+
+	defaultTokenMetadataKey    = "authorization"
+	defaultTokenCommandTimeout = 10 * time.Second
+	defaultTokenRefresh        = 5 * time.Minute
+)
+
+// TokenAuth configures the token sent to the target in the gRPC metadata of each RPC.
+// The token is refreshed before it expires, without redialing the target.
+type TokenAuth struct {
+	// static, oauth2 or command
+	Type string `mapstructure:"type,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	// static token value
+	Token string `mapstructure:"token,omitempty" json:"token,omitempty" yaml:"token,omitempty"`
+	// OAuth2 client credentials grant
+	TokenURL     string            `mapstructure:"token-url,omitempty" json:"token-url,omitempty" yaml:"token-url,omitempty"`
+	ClientID     string            `mapstructure:"client-id,omitempty" json:"client-id,omitempty" yaml:"client-id,omitempty"`
+	ClientSecret string            `mapstructure:"client-secret,omitempty" json:"client-secret,omitempty" yaml:"client-secret,omitempty"`
+	Scopes       []string          `mapstructure:"scopes,omitempty" json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	Params       map[string]string `mapstructure:"params,omitempty" json:"params,omitempty" yaml:"params,omitempty"`
+	// command printing the token on its stdout, either the raw token
+	// or a JSON object with the access_token and expires_in fields
+	Command []string `mapstructure:"command,omitempty" json:"command,omitempty" yaml:"command,omitempty"`
+	// validity of a command token without expires_in
+	RefreshInterval time.Duration `mapstructure:"refresh-interval,omitempty" json:"refresh-interval,omitempty" yaml:"refresh-interval,omitempty"`
+	// metadata key the token is sent under, defaults to authorization.
+	// The authorization value is prefixed with the token type, e.g: Bearer <token>
+	MetadataKey string `mapstructure:"metadata-key,omitempty" json:"metadata-key,omitempty" yaml:"metadata-key,omitempty"`
+	// send the token over insecure connections
+	AllowInsecure bool `mapstructure:"allow-insecure,omitempty" json:"allow-insecure,omitempty" yaml:"allow-insecure,omitempty"`
+}
+
+// Validate checks the token auth config and sets its defaults.
+func (ta *TokenAuth) Validate() error {
+	switch ta.Type {
+	case TokenAuthStatic:
+		if ta.Token == "" {
+			return errors.New("token-auth: static type requires a token")
+		}
+	case TokenAuthOAuth2:
+		if ta.TokenURL == "" || ta.ClientID == "" {
+			return errors.New("token-auth: oauth2 type requires a token-url and a client-id")
+		}
+	case TokenAuthCommand:
+		if len(ta.Command) == 0 {
+			return errors.New("token-auth: command type requires a command")
+		}
+	default:
+		return fmt.Errorf("token-auth: unknown type %q", ta.Type)
+	}
+	if ta.MetadataKey == "" {
+		ta.MetadataKey = defaultTokenMetadataKey
+	}
+	ta.MetadataKey = strings.ToLower(ta.MetadataKey)
+	if ta.RefreshInterval <= 0 {
+		ta.RefreshInterval = defaultTokenRefresh
+	}
+	return nil
+}
+
+// PerRPCCredentials returns the gRPC credentials adding the token to the RPCs metadata.
+func (ta *TokenAuth) PerRPCCredentials() (credentials.PerRPCCredentials, error) {
+	err := ta.Validate()
+	if err != nil {
+		return nil, err
+	}
+	var src oauth2.TokenSource
+	switch ta.Type {
+	case TokenAuthStatic:
+		src = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: ta.Token})
+	case TokenAuthOAuth2:
+		cc := &clientcredentials.Config{
+			ClientID:     ta.ClientID,
+			ClientSecret: ta.ClientSecret,
+			TokenURL:     ta.TokenURL,
+			Scopes:       ta.Scopes,
+		}
+		if len(ta.Params) > 0 {
+			cc.EndpointParams = make(map[string][]string, len(ta.Params))
+			for k, v := range ta.Params {
+				cc.EndpointParams.Set(k, v)
+			}
+		}
+		src = cc.TokenSource(context.Background())
+	case TokenAuthCommand:
+		src = oauth2.ReuseTokenSource(nil, &commandTokenSource{
+			command: ta.Command,
+			refresh: ta.RefreshInterval,
+		})
+	}
+	return &tokenCredentials{
+		src:        src,
+		key:        ta.MetadataKey,
+		requireTLS: !ta.AllowInsecure,
+	}, nil
+}
+
+// tokenCredentials implements credentials.PerRPCCredentials
+type tokenCredentials struct {
+	src        oauth2.TokenSource
+	key        string
+	requireTLS bool
+}
+
+func (c *tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	t, err := c.src.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %v", err)
+	}
+	if c.key == defaultTokenMetadataKey {
+		return map[string]string{c.key: t.Type() + " " + t.AccessToken}, nil
+	}
+	return map[string]string{c.key: t.AccessToken}, nil
+}
+
+func (c *tokenCredentials) RequireTransportSecurity() bool { return c.requireTLS }
+
+// commandTokenSource gets a token from the output of a command.
+type commandTokenSource struct {
+	command []string
+	refresh time.Duration
+}
+
+func (s *commandTokenSource) Token() (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTokenCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, s.command[0], s.command[1:]...)
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("token command failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	out = bytes.TrimSpace(out)
+	if len(out) == 0 {
+		return nil, errors.New("token command returned an empty token")
+	}
+	t := &oauth2.Token{AccessToken: string(out)}
+	if out[0] == '{' {
+		rsp := struct {
+			AccessToken string `json:"access_token"`
+			TokenType   string `json:"token_type"`
+			ExpiresIn   int64  `json:"expires_in"`
+		}{}
+		err = json.Unmarshal(out, &rsp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the token command output: %v", err)
+		}
+		if rsp.AccessToken == "" {
+			return nil, errors.New("token command output has no access_token")
+		}
+		t = &oauth2.Token{AccessToken: rsp.AccessToken, TokenType: rsp.TokenType}
+		if rsp.ExpiresIn > 0 {
+			t.Expiry = time.Now().Add(time.Duration(rsp.ExpiresIn) * time.Second)
+			return t, nil
+		}
+	}
+	t.Expiry = time.Now().Add(s.refresh)
+	return t, nil
+}
@@ -0,0 +1,155 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenAuthJSON(t *testing.T) {
+	for name, ta := range map[string]*TokenAuth{
+		"static": {Type: TokenAuthStatic, Token: "s3cr3t-token"},
+		"oauth2": {Type: TokenAuthOAuth2, TokenURL: "https://idp/token", ClientID: "gnmic", ClientSecret: "s3cr3t"},
+	} {
+		// targets are JSON encoded when assigned to a cluster member
+		// and when added through the API.
+		b, err := json.Marshal(&TargetConfig{Name: "t1", TokenAuth: ta})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		tc := new(TargetConfig)
+		err = json.Unmarshal(b, tc)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(tc.TokenAuth, ta) {
+			t.Errorf("%s: token-auth not preserved: got %+v, want %+v", name, tc.TokenAuth, ta)
+			continue
+		}
+		if err := tc.TokenAuth.Validate(); err != nil {
+			t.Errorf("%s: decoded token-auth is invalid: %v", name, err)
+		}
+		// the secrets are masked when the target is printed
+		out := (&TargetConfig{Name: "t1", TokenAuth: ta}).String()
+		if strings.Contains(out, "s3cr3t") {
+			t.Errorf("%s: secret printed: %s", name, out)
+		}
+	}
+}
+
+func TestTokenAuthValidate(t *testing.T) {
+	for name, ta := range map[string]*TokenAuth{
+		"unknown_type":   {Type: "basic"},
+		"static_empty":   {Type: TokenAuthStatic},
+		"oauth2_no_url":  {Type: TokenAuthOAuth2, ClientID: "gnmic"},
+		"command_no_cmd": {Type: TokenAuthCommand},
+	} {
+		if err := ta.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestTokenAuthMetadata(t *testing.T) {
+	var issued int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "gnmic" || secret != "s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		issued++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"oauth-%d","token_type":"bearer","expires_in":3600}`, issued)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		ta   *TokenAuth
+		want map[string]string
+	}{
+		{
+			name: "static",
+			ta:   &TokenAuth{Type: TokenAuthStatic, Token: "abc"},
+			want: map[string]string{"authorization": "Bearer abc"},
+		},
+		{
+			name: "static_custom_key",
+			ta:   &TokenAuth{Type: TokenAuthStatic, Token: "abc", MetadataKey: "X-Auth-Token"},
+			want: map[string]string{"x-auth-token": "abc"},
+		},
+		{
+			name: "oauth2",
+			ta:   &TokenAuth{Type: TokenAuthOAuth2, TokenURL: srv.URL, ClientID: "gnmic", ClientSecret: "s3cr3t"},
+			want: map[string]string{"authorization": "Bearer oauth-1"},
+		},
+		{
+			name: "command_raw",
+			ta:   &TokenAuth{Type: TokenAuthCommand, Command: []string{"echo", "cmd-token"}},
+			want: map[string]string{"authorization": "Bearer cmd-token"},
+		},
+		{
+			name: "command_json",
+			ta:   &TokenAuth{Type: TokenAuthCommand, Command: []string{"echo", `{"access_token":"json-token","expires_in":60}`}},
+			want: map[string]string{"authorization": "Bearer json-token"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := tt.ta.PerRPCCredentials()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the second call reuses the token until it expires
+			for i := 0; i < 2; i++ {
+				md, err := c.GetRequestMetadata(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if fmt.Sprint(md) != fmt.Sprint(tt.want) {
+					t.Errorf("got %v, want %v", md, tt.want)
+				}
+			}
+			if !c.RequireTransportSecurity() {
+				t.Errorf("expected the token to require a secure connection")
+			}
+		})
+	}
+}
+
+func TestCommandTokenRefresh(t *testing.T) {
+	dir := t.TempDir()
+	ta := &TokenAuth{
+		Type:    TokenAuthCommand,
+		Command: []string{"sh", "-c", fmt.Sprintf("echo x >> %s/count; wc -l < %s/count", dir, dir)},
+		// refreshed on each RPC
+		RefreshInterval: time.Nanosecond,
+	}
+	c, err := ta.PerRPCCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 2; i++ {
+		md, err := c.GetRequestMetadata(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("Bearer %d", i); md["authorization"] != want {
+			t.Errorf("got %q, want %q", md["authorization"], want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VendorProfile holds the request conventions of a network OS vendor,
+// applied to the targets configured with the vendor name.
+type VendorProfile struct {
+	// origin set on the request paths that don't have one
+	Origin string `json:"origin,omitempty"`
+	// origins removed from the request paths,
+	// for targets rejecting them
+	RejectedOrigins []string `json:"rejected-origins,omitempty"`
+	// encodings in order of preference,
+	// used if the target does not define its own encoding-preference
+	EncodingPreference []string `json:"encoding-preference,omitempty"`
+}
+
+// VendorProfiles are the known vendor profiles, keyed by vendor name.
+var VendorProfiles = map[string]*VendorProfile{
+	// EOS expects the OpenConfig paths under origin openconfig,
+	// native paths use origins eos_native or cli
+	"arista": {
+		Origin:             "openconfig",
+		EncodingPreference: []string{"json", "ascii"},
+	},
+	// IOS-XR, IOS-XE and NX-OS expect the origin openconfig for OpenConfig paths
+	"cisco": {
+		Origin:             "openconfig",
+		EncodingPreference: []string{"json_ietf", "proto", "json"},
+	},
+	// Junos rejects the origin openconfig, OpenConfig paths are sent without origin
+	"juniper": {
+		RejectedOrigins:    []string{"openconfig"},
+		EncodingPreference: []string{"proto", "json"},
+	},
+	// SR OS and SR Linux serve OpenConfig paths without origin
+	"nokia": {
+		RejectedOrigins:    []string{"openconfig"},
+		EncodingPreference: []string{"json_ietf", "json", "proto"},
+	},
+}
+
+// GetVendorProfile returns the profile of vendor name, case insensitive.
+func GetVendorProfile(name string) (*VendorProfile, error) {
+	vp, ok := VendorProfiles[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown vendor %q, must be one of %q", name, VendorNames())
+	}
+	return vp, nil
+}
+
+// VendorNames returns the sorted names of the known vendor profiles.
+func VendorNames() []string {
+	names := make([]string, 0, len(VendorProfiles))
+	for n := range VendorProfiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RejectsOrigin returns true if the vendor targets reject the origin.
+func (vp *VendorProfile) RejectsOrigin(origin string) bool {
+	for _, o := range vp.RejectedOrigins {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,359 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	ACMEChallengeTLSALPN = "tls-alpn-01"
+	ACMEChallengeDNS     = "dns-01"
+
+	defaultACMERenewBefore = 30 * 24 * time.Hour
+	acmeRenewCheckInterval = 12 * time.Hour
+	acmeAccountKeyFile     = "account.key"
+)
+
+// ACMEConfig configures obtaining a server certificate from an ACME CA,
+// Let's Encrypt by default.
+type ACMEConfig struct {
+	// domain names of the certificate
+	Domains []string `mapstructure:"domains,omitempty" json:"domains,omitempty"`
+	// contact email of the ACME account
+	Email string `mapstructure:"email,omitempty" json:"email,omitempty"`
+	// ACME directory URL, defaults to Let's Encrypt production directory
+	DirectoryURL string `mapstructure:"directory-url,omitempty" json:"directory-url,omitempty"`
+	// directory the account key and certificates are stored in
+	CacheDir string `mapstructure:"cache-dir,omitempty" json:"cache-dir,omitempty"`
+	// challenge type, tls-alpn-01 or dns-01
+	Challenge string `mapstructure:"challenge,omitempty" json:"challenge,omitempty"`
+	// command creating and deleting the dns-01 challenge TXT records,
+	// it is called with the arguments: present|cleanup <fqdn> <value>
+	DNSHook string `mapstructure:"dns-hook,omitempty" json:"dns-hook,omitempty"`
+	// the certificate is renewed this long before it expires
+	RenewBefore time.Duration `mapstructure:"renew-before,omitempty" json:"renew-before,omitempty"`
+}
+
+// Validate checks the ACME config and sets its defaults.
+func (c *ACMEConfig) Validate() error {
+	if len(c.Domains) == 0 {
+		return errors.New("acme: missing domains")
+	}
+	switch c.Challenge {
+	case "":
+		c.Challenge = ACMEChallengeTLSALPN
+	case ACMEChallengeTLSALPN:
+	case ACMEChallengeDNS:
+		if c.DNSHook == "" {
+			return errors.New("acme: the dns-01 challenge requires a dns-hook")
+		}
+	default:
+		return fmt.Errorf("acme: unknown challenge %q", c.Challenge)
+	}
+	if c.DirectoryURL == "" {
+		c.DirectoryURL = acme.LetsEncryptURL
+	}
+	if c.CacheDir == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			dir = os.TempDir()
+		}
+		c.CacheDir = filepath.Join(dir, "gnmic", "acme")
+	}
+	if c.RenewBefore <= 0 {
+		c.RenewBefore = defaultACMERenewBefore
+	}
+	return nil
+}
+
+// configure sets the tlsConfig GetCertificate function
+// to serve the certificate obtained from the ACME CA.
+func (c *ACMEConfig) configure(ctx context.Context, tlsConfig *tls.Config, logger *log.Logger) error {
+	err := c.Validate()
+	if err != nil {
+		return err
+	}
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	if c.Challenge == ACMEChallengeTLSALPN {
+		m := &autocert.Manager{
+			Prompt:      autocert.AcceptTOS,
+			Cache:       autocert.DirCache(c.CacheDir),
+			HostPolicy:  autocert.HostWhitelist(c.Domains...),
+			RenewBefore: c.RenewBefore,
+			Email:       c.Email,
+			Client:      &acme.Client{DirectoryURL: c.DirectoryURL},
+		}
+		tlsConfig.GetCertificate = m.GetCertificate
+		tlsConfig.NextProtos = append(tlsConfig.NextProtos, acme.ALPNProto)
+		return nil
+	}
+	m := &acmeDNSManager{cfg: c, logger: logger}
+	err = m.start(ctx)
+	if err != nil {
+		return err
+	}
+	tlsConfig.GetCertificate = m.GetCertificate
+	return nil
+}
+
+// acmeDNSManager obtains and renews a certificate using the dns-01 challenge.
+type acmeDNSManager struct {
+	cfg    *ACMEConfig
+	logger *log.Logger
+	client *acme.Client
+
+	m    sync.RWMutex
+	cert *tls.Certificate
+}
+
+// start loads the cached certificate, obtaining a new one if it is missing or expiring,
+// then renews it in the background until ctx is done.
+func (m *acmeDNSManager) start(ctx context.Context) error {
+	err := os.MkdirAll(m.cfg.CacheDir, 0700)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(m.certPath(), m.certPath())
+	if err == nil {
+		m.cert = &cert
+	}
+	if m.needsRenewal() {
+		err = m.renew(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	go func() {
+		ticker := time.NewTicker(acmeRenewCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !m.needsRenewal() {
+					continue
+				}
+				err := m.renew(ctx)
+				if err != nil {
+					m.logger.Printf("acme: failed to renew certificate for %v: %v", m.cfg.Domains, err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *acmeDNSManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.m.RLock()
+	defer m.m.RUnlock()
+	return m.cert, nil
+}
+
+func (m *acmeDNSManager) certPath() string {
+	return filepath.Join(m.cfg.CacheDir, m.cfg.Domains[0]+".pem")
+}
+
+func (m *acmeDNSManager) needsRenewal() bool {
+	m.m.RLock()
+	defer m.m.RUnlock()
+	if m.cert == nil || len(m.cert.Certificate) == 0 {
+		return true
+	}
+	leaf, err := x509.ParseCertificate(m.cert.Certificate[0])
+	if err != nil {
+		return true
+	}
+	return time.Until(leaf.NotAfter) < m.cfg.RenewBefore
+}
+
+func (m *acmeDNSManager) renew(ctx context.Context) error {
+	m.logger.Printf("acme: requesting a certificate for %v", m.cfg.Domains)
+	pemBytes, err := m.obtain(ctx)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.X509KeyPair(pemBytes, pemBytes)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(m.certPath(), pemBytes, 0600)
+	if err != nil {
+		m.logger.Printf("acme: failed to cache certificate: %v", err)
+	}
+	m.m.Lock()
+	m.cert = &cert
+	m.m.Unlock()
+	m.logger.Printf("acme: obtained a certificate for %v", m.cfg.Domains)
+	return nil
+}
+
+// obtain runs an ACME order for the configured domains
+// and returns the certificate key and chain PEM encoded.
+func (m *acmeDNSManager) obtain(ctx context.Context) ([]byte, error) {
+	if m.client == nil {
+		key, err := m.accountKey()
+		if err != nil {
+			return nil, err
+		}
+		m.client = &acme.Client{Key: key, DirectoryURL: m.cfg.DirectoryURL}
+		acct := new(acme.Account)
+		if m.cfg.Email != "" {
+			acct.Contact = []string{"mailto:" + m.cfg.Email}
+		}
+		_, err = m.client.Register(ctx, acct, acme.AcceptTOS)
+		if err != nil && err != acme.ErrAccountAlreadyExists {
+			m.client = nil
+			return nil, err
+		}
+	}
+	order, err := m.client.AuthorizeOrder(ctx, acme.DomainIDs(m.cfg.Domains...))
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range order.AuthzURLs {
+		err = m.authorize(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+	}
+	order, err = m.client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: m.cfg.Domains[0]},
+		DNSNames: m.cfg.Domains,
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+	der, _, err := m.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, err
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	pem.Encode(buf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+	for _, b := range der {
+		pem.Encode(buf, &pem.Block{Type: "CERTIFICATE", Bytes: b})
+	}
+	return buf.Bytes(), nil
+}
+
+// authorize fulfills the dns-01 challenge of a pending authorization,
+// the challenge TXT record is deleted once the authorization is done.
+func (m *acmeDNSManager) authorize(ctx context.Context, url string) error {
+	z, err := m.client.GetAuthorization(ctx, url)
+	if err != nil {
+		return err
+	}
+	if z.Status == acme.StatusValid {
+		return nil
+	}
+	var chal *acme.Challenge
+	for _, c := range z.Challenges {
+		if c.Type == ACMEChallengeDNS {
+			chal = c
+			break
+		}
+	}
+	if chal == nil {
+		return fmt.Errorf("acme: no dns-01 challenge offered for %q", z.Identifier.Value)
+	}
+	value, err := m.client.DNS01ChallengeRecord(chal.Token)
+	if err != nil {
+		return err
+	}
+	fqdn := "_acme-challenge." + z.Identifier.Value
+	err = m.runHook(ctx, "present", fqdn, value)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := m.runHook(ctx, "cleanup", fqdn, value); err != nil {
+			m.logger.Printf("acme: %v", err)
+		}
+	}()
+	_, err = m.client.Accept(ctx, chal)
+	if err != nil {
+		return err
+	}
+	_, err = m.client.WaitAuthorization(ctx, z.URI)
+	return err
+}
+
+func (m *acmeDNSManager) runHook(ctx context.Context, action, fqdn, value string) error {
+	out, err := exec.CommandContext(ctx, m.cfg.DNSHook, action, fqdn, value).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("dns-hook %s %s failed: %v: %s", action, fqdn, err, out)
+	}
+	return nil
+}
+
+// accountKey loads the ACME account key from the cache directory,
+// generating it on first use.
+func (m *acmeDNSManager) accountKey() (crypto.Signer, error) {
+	path := filepath.Join(m.cfg.CacheDir, acmeAccountKeyFile)
+	b, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(b)
+		if block == nil {
+			return nil, fmt.Errorf("acme: invalid account key %q", path)
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0600)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
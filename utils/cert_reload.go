@@ -0,0 +1,141 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// certCheckInterval is the minimum interval between two checks
+// of the certificate files modification time.
+var certCheckInterval = 10 * time.Second
+
+// CertReloader serves a certificate loaded from a key pair of local files,
+// the files are reloaded when their modification time changes.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	logger   *log.Logger
+
+	m           sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	lastCheck   time.Time
+}
+
+// NewCertReloader loads the certificate key pair from certFile and keyFile.
+func NewCertReloader(certFile, keyFile string, logger *log.Logger) (*CertReloader, error) {
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+	}
+	err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CertReloader) load() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	certBytes, err := ReadFile(ctx, r.certFile)
+	if err != nil {
+		return err
+	}
+	keyBytes, err := ReadFile(ctx, r.keyFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.X509KeyPair(certBytes, keyBytes)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.certModTime = modTime(r.certFile)
+	r.keyModTime = modTime(r.keyFile)
+	return nil
+}
+
+// GetCertificate returns the current certificate,
+// it is meant to be used as the tls.Config GetCertificate function.
+// If the files changed since they were last loaded, they are reloaded first.
+// A failed reload is logged and the previous certificate is kept.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	now := time.Now()
+	if now.Sub(r.lastCheck) < certCheckInterval {
+		return r.cert, nil
+	}
+	r.lastCheck = now
+	if modTime(r.certFile).Equal(r.certModTime) && modTime(r.keyFile).Equal(r.keyModTime) {
+		return r.cert, nil
+	}
+	err := r.load()
+	if err != nil {
+		r.logger.Printf("failed to reload certificate %q: %v", r.certFile, err)
+		return r.cert, nil
+	}
+	r.logger.Printf("reloaded certificate %q", r.certFile)
+	return r.cert, nil
+}
+
+// modTime returns the modification time of a local file,
+// the zero time if the file is remote or cannot be read.
+func modTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+// NewServerTLSConfig generates a server *tls.Config like NewTLSConfig,
+// the certificate is reloaded when the cert and key files change.
+// If acme is not nil, the certificate is obtained and renewed from an ACME CA instead,
+// until ctx is done.
+func NewServerTLSConfig(ctx context.Context, ca, cert, key string, skipVerify bool, acme *ACMEConfig, logger *log.Logger) (*tls.Config, error) {
+	if acme != nil {
+		tlsConfig, err := NewTLSConfig(ca, "", "", skipVerify, false)
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig == nil {
+			tlsConfig = new(tls.Config)
+		}
+		err = acme.configure(ctx, tlsConfig, logger)
+		if err != nil {
+			return nil, err
+		}
+		return tlsConfig, nil
+	}
+	tlsConfig, err := NewTLSConfig(ca, cert, key, skipVerify, true)
+	if err != nil || tlsConfig == nil || cert == "" || key == "" {
+		return tlsConfig, err
+	}
+	r, err := NewCertReloader(cert, key, logger)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.Certificates = nil
+	tlsConfig.GetCertificate = r.GetCertificate
+	return tlsConfig, nil
+}
@@ -0,0 +1,166 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testKeyPair returns a PEM encoded self signed certificate and key for name.
+func testKeyPair(t *testing.T, name string, notAfter time.Time) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+}
+
+func certName(t *testing.T, c *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(c.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writePair := func(name string, mtime time.Time) {
+		c, k := testKeyPair(t, name, time.Now().Add(time.Hour))
+		for p, b := range map[string][]byte{certFile: c, keyFile: k} {
+			if err := os.WriteFile(p, b, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(p, mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	writePair("v1", time.Now().Add(-time.Minute))
+
+	tlsConfig, err := NewServerTLSConfig(context.Background(), "", certFile, keyFile, false, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(d time.Duration) { certCheckInterval = d }(certCheckInterval)
+	certCheckInterval = 0
+
+	c, err := tlsConfig.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := certName(t, c); n != "v1" {
+		t.Fatalf("expected certificate v1, got %s", n)
+	}
+	writePair("v2", time.Now())
+	c, _ = tlsConfig.GetCertificate(nil)
+	if n := certName(t, c); n != "v2" {
+		t.Fatalf("expected the reloaded certificate v2, got %s", n)
+	}
+	// an invalid key pair is not loaded
+	if err := os.WriteFile(keyFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(keyFile, future, future)
+	c, _ = tlsConfig.GetCertificate(nil)
+	if n := certName(t, c); n != "v2" {
+		t.Fatalf("expected the previous certificate v2 to be kept, got %s", n)
+	}
+}
+
+func TestACMEDNSCachedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	c, k := testKeyPair(t, "gnmic.example.com", time.Now().Add(90*24*time.Hour))
+	err := os.WriteFile(filepath.Join(dir, "gnmic.example.com.pem"), append(k, c...), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the cached certificate is not due for renewal, the CA is not contacted
+	tlsConfig, err := NewServerTLSConfig(ctx, "", "", "", false, &ACMEConfig{
+		Domains:      []string{"gnmic.example.com"},
+		Challenge:    ACMEChallengeDNS,
+		DNSHook:      "true",
+		DirectoryURL: "https://127.0.0.1:1/directory",
+		CacheDir:     dir,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tlsConfig.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(cert.Certificate[0], mustDecodePEM(t, c)) {
+		t.Errorf("expected the cached certificate")
+	}
+}
+
+func TestACMEConfigValidate(t *testing.T) {
+	for name, c := range map[string]*ACMEConfig{
+		"no_domains":     {},
+		"dns_no_hook":    {Domains: []string{"a.example.com"}, Challenge: ACMEChallengeDNS},
+		"unknown_method": {Domains: []string{"a.example.com"}, Challenge: "http-01"},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	c := &ACMEConfig{Domains: []string{"a.example.com"}}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Challenge != ACMEChallengeTLSALPN || c.CacheDir == "" || c.RenewBefore != defaultACMERenewBefore {
+		t.Errorf("unexpected defaults: %+v", c)
+	}
+}
+
+func mustDecodePEM(t *testing.T, b []byte) []byte {
+	t.Helper()
+	block, _ := pem.Decode(b)
+	if block == nil {
+		t.Fatal("invalid PEM")
+	}
+	return block.Bytes
+}
@@ -0,0 +1,97 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	unixSocketPrefix = "unix://"
+	// timeout of the connection to an existing socket,
+	// checking if it is still in use.
+	socketProbeTimeout = time.Second
+)
+
+// IsUnixSocketAddress returns true if addr is a unix domain socket
+// address in the format unix:///path/to/socket.
+func IsUnixSocketAddress(addr string) bool {
+	return strings.HasPrefix(addr, unixSocketPrefix)
+}
+
+// Listen creates a TCP listener, or a unix domain socket listener if
+// addr has the unix:// prefix.
+// A stale socket file left behind at the unix socket path is removed before listening,
+// a socket still accepting connections is left in place and an error is returned.
+// If perm is not empty, it is parsed as an octal file mode and applied
+// to the unix socket file, e.g: "0660".
+func Listen(addr, perm string) (net.Listener, error) {
+	if !IsUnixSocketAddress(addr) {
+		return net.Listen("tcp", addr)
+	}
+	path := strings.TrimPrefix(addr, unixSocketPrefix)
+	mode, err := parseSocketPermissions(perm)
+	if err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		err = removeStaleSocket(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if mode != 0 {
+		if err = os.Chmod(path, mode); err != nil {
+			l.Close()
+			return nil, fmt.Errorf("failed to set socket %q permissions: %v", path, err)
+		}
+	}
+	return l, nil
+}
+
+// removeStaleSocket removes the socket file at path if no process listens on it.
+func removeStaleSocket(path string) error {
+	conn, err := net.DialTimeout("unix", path, socketProbeTimeout)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %q: %w", path, syscall.EADDRINUSE)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("failed to check socket %q: %v", path, err)
+	}
+	if err = os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale socket %q: %v", path, err)
+	}
+	return nil
+}
+
+func parseSocketPermissions(perm string) (os.FileMode, error) {
+	if perm == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(perm, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid socket permissions %q: %v", perm, err)
+	}
+	if mode > 0777 {
+		return 0, fmt.Errorf("invalid socket permissions %q", perm)
+	}
+	return os.FileMode(mode), nil
+}
@@ -0,0 +1,72 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gnmic.sock")
+	l, err := Listen("unix://"+path, "0600")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat socket: %v", err)
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		t.Errorf("expected %q to be a socket", path)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions 0600, got %o", fi.Mode().Perm())
+	}
+	// the listener file is left behind, listening again must remove it
+	l.(interface{ SetUnlinkOnClose(bool) }).SetUnlinkOnClose(false)
+	l.Close()
+	l, err = Listen("unix://"+path, "")
+	if err != nil {
+		t.Fatalf("failed to listen on stale socket: %v", err)
+	}
+	l.Close()
+}
+
+func TestListenUnixSocketInUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gnmic.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	_, err = Listen("unix://"+path, "")
+	if !errors.Is(err, syscall.EADDRINUSE) {
+		t.Fatalf("expected an address in use error, got %v", err)
+	}
+	// the socket of the live listener is still reachable
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("the live socket was removed: %v", err)
+	}
+	conn.Close()
+}
+
+func TestListenInvalidSocketPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gnmic.sock")
+	for _, perm := range []string{"rw", "0999", "01777"} {
+		if _, err := Listen("unix://"+path, perm); err == nil {
+			t.Errorf("expected an error for permissions %q", perm)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+)
+
+const redactedValue = "****"
+
+const (
+	// secret keys: passwords, tokens and other credentials
+	secretKeys = `password|passphrase|passwd|secret|token|authorization|api[-_]?key|hmac[-_]?key|client[-_]?secret`
+	// optional HTTP authorization scheme preceding a secret value
+	authScheme = `(?:(?:bearer|basic)\s+)?`
+)
+
+// defaultRedactPatterns match the secrets found in logs, requests and API responses.
+// Only the text matched by the capturing groups is redacted.
+var defaultRedactPatterns = []string{
+	// key/value pairs as found in JSON, YAML, logfmt, URL queries and Go structs printed with %+v,
+	// the value is either a quoted string or a word.
+	`(?i)(?:` + secretKeys + `)"?\s*[:=]\s*(?:"` + authScheme + `((?:[^"\\]|\\.)*)"|` + authScheme + `([^\s"',;&{}\[\]]+))`,
+	// HTTP authorization credentials
+	`(?i)\b(?:bearer|basic)\s+([A-Za-z0-9\-._~+/]+=*)`,
+	// URL user info password
+	`://[^:/@\s"]+:([^@/\s"]+)@`,
+	// PEM encoded private keys, escaped or not
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----((?:.|\n)*?)-----END [A-Z ]*PRIVATE KEY-----`,
+}
+
+var defaultRedactor = mustRedactor()
+
+// Redactor masks the secrets (passwords, tokens, private keys...) found in a text.
+// A nil Redactor uses the default patterns.
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor using the default patterns
+// and the additional regular expressions patterns.
+// If a pattern has capturing groups, only the text they match is redacted,
+// otherwise the whole match is.
+func NewRedactor(patterns ...string) (*Redactor, error) {
+	r := &Redactor{
+		patterns: make([]*regexp.Regexp, 0, len(defaultRedactPatterns)+len(patterns)),
+	}
+	for _, p := range defaultRedactPatterns {
+		r.patterns = append(r.patterns, regexp.MustCompile(p))
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %v", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+func mustRedactor() *Redactor {
+	r, err := NewRedactor()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Redact returns s with the secrets it contains replaced with "****".
+func (r *Redactor) Redact(s string) string {
+	return string(r.RedactBytes([]byte(s)))
+}
+
+// RedactBytes returns b with the secrets it contains replaced with "****".
+// b is returned as is if it does not contain any secret.
+func (r *Redactor) RedactBytes(b []byte) []byte {
+	if r == nil {
+		r = defaultRedactor
+	}
+	for _, re := range r.patterns {
+		b = redactMatches(re, b)
+	}
+	return b
+}
+
+// Writer returns an io.Writer redacting the secrets written to w.
+// Each write is expected to hold complete lines, as done by a log.Logger.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return &redactWriter{r: r, w: w}
+}
+
+func redactMatches(re *regexp.Regexp, b []byte) []byte {
+	matches := re.FindAllSubmatchIndex(b, -1)
+	if len(matches) == 0 {
+		return b
+	}
+	out := make([]byte, 0, len(b))
+	last := 0
+	for _, m := range matches {
+		// spans to redact: the groups if any, the whole match otherwise
+		spans := m[2:]
+		if len(spans) == 0 {
+			spans = m[:2]
+		}
+		for i := 0; i < len(spans); i += 2 {
+			start, end := spans[i], spans[i+1]
+			// unmatched or empty group, or nested in a previous group
+			if start < 0 || start == end || start < last {
+				continue
+			}
+			out = append(out, b[last:start]...)
+			out = append(out, redactedValue...)
+			last = end
+		}
+	}
+	return append(out, b[last:]...)
+}
+
+type redactWriter struct {
+	r *Redactor
+	w io.Writer
+}
+
+func (rw *redactWriter) Write(p []byte) (int, error) {
+	_, err := rw.w.Write(rw.r.RedactBytes(p))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
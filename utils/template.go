@@ -10,15 +10,186 @@ package utils
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"text/template"
 
+	"github.com/Masterminds/sprig/v3"
 	"github.com/hairyhenderson/gomplate/v3"
 	"github.com/hairyhenderson/gomplate/v3/data"
 )
 
+// CreateTemplate parses text as a Go template named name.
+// The template can use the sprig and gomplate functions, gomplate's take precedence
+// when both define a function with the same name, as well as the gNMI specific helpers.
 func CreateTemplate(name, text string) (*template.Template, error) {
 	return template.New(name).
 		Option("missingkey=zero").
-		Funcs(gomplate.CreateFuncs(context.TODO(), new(data.Data))).
+		Funcs(TemplateFuncs()).
 		Parse(text)
 }
+
+// TemplateFuncs returns the functions available to the templates created with CreateTemplate.
+func TemplateFuncs() template.FuncMap {
+	funcs := sprig.TxtFuncMap()
+	for k, v := range gomplate.CreateFuncs(context.TODO(), new(data.Data)) {
+		funcs[k] = v
+	}
+	for k, v := range gnmiTemplateFuncs {
+		funcs[k] = v
+	}
+	return funcs
+}
+
+var gnmiTemplateFuncs = template.FuncMap{
+	"pathElem":       pathElem,
+	"keyValue":       keyValue,
+	"stripOrigin":    stripOrigin,
+	"toLineProtocol": toLineProtocol,
+}
+
+// pathElem returns the name of the path element at index i of the xpath p,
+// a negative index counts from the last element.
+// It returns an empty string if the index is out of range.
+func pathElem(i int, p string) (string, error) {
+	gp, err := ParsePath(p)
+	if err != nil {
+		return "", err
+	}
+	elems := gp.GetElem()
+	if i < 0 {
+		i += len(elems)
+	}
+	if i < 0 || i >= len(elems) {
+		return "", nil
+	}
+	return elems[i].GetName(), nil
+}
+
+// keyValue returns the value of the key named key in the xpath p,
+// if multiple path elements have that key, the last one is returned.
+func keyValue(key, p string) (string, error) {
+	gp, err := ParsePath(p)
+	if err != nil {
+		return "", err
+	}
+	elems := gp.GetElem()
+	for i := len(elems) - 1; i >= 0; i-- {
+		if v, ok := elems[i].GetKey()[key]; ok {
+			return v, nil
+		}
+	}
+	return "", nil
+}
+
+// stripOrigin removes the origin, if any, from the xpath p.
+func stripOrigin(p string) string {
+	idx := strings.Index(p, ":")
+	if idx >= 0 && len(p) > 0 && p[0] != '/' && !strings.Contains(p[:idx], "/") &&
+		(idx+1 == len(p) || p[idx+1] == '/') {
+		return p[idx+1:]
+	}
+	return p
+}
+
+// toLineProtocol formats an event message, or a list of event messages,
+// as InfluxDB line protocol, one line per event.
+// Events without values are skipped.
+func toLineProtocol(v interface{}) (string, error) {
+	var events []interface{}
+	switch v := v.(type) {
+	case []interface{}:
+		events = v
+	case map[string]interface{}:
+		events = []interface{}{v}
+	default:
+		return "", fmt.Errorf("toLineProtocol: unexpected input type %T", v)
+	}
+	lines := make([]string, 0, len(events))
+	for _, ev := range events {
+		e, ok := ev.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("toLineProtocol: unexpected event type %T", ev)
+		}
+		line, err := eventToLineProtocol(e)
+		if err != nil {
+			return "", err
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+var (
+	lpMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	lpKeyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	lpStringEscaper      = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+func eventToLineProtocol(e map[string]interface{}) (string, error) {
+	values, _ := e["values"].(map[string]interface{})
+	if len(values) == 0 {
+		return "", nil
+	}
+	sb := new(strings.Builder)
+	name, _ := e["name"].(string)
+	sb.WriteString(lpMeasurementEscaper.Replace(name))
+
+	tags, _ := e["tags"].(map[string]interface{})
+	for _, k := range sortedKeys(tags) {
+		sb.WriteString(",")
+		sb.WriteString(lpKeyEscaper.Replace(k))
+		sb.WriteString("=")
+		sb.WriteString(lpKeyEscaper.Replace(fmt.Sprint(tags[k])))
+	}
+	for i, k := range sortedKeys(values) {
+		if i == 0 {
+			sb.WriteString(" ")
+		} else {
+			sb.WriteString(",")
+		}
+		sb.WriteString(lpKeyEscaper.Replace(k))
+		sb.WriteString("=")
+		switch v := values[k].(type) {
+		case string:
+			sb.WriteString(`"` + lpStringEscaper.Replace(v) + `"`)
+		case float64:
+			sb.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		case json.Number:
+			sb.WriteString(v.String())
+		case bool:
+			sb.WriteString(strconv.FormatBool(v))
+		case int, int32, int64, uint, uint32, uint64:
+			fmt.Fprintf(sb, "%di", v)
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", fmt.Errorf("toLineProtocol: value %q: %v", k, err)
+			}
+			sb.WriteString(`"` + lpStringEscaper.Replace(string(b)) + `"`)
+		}
+	}
+	switch ts := e["timestamp"].(type) {
+	case float64:
+		fmt.Fprintf(sb, " %d", int64(ts))
+	case json.Number:
+		sb.WriteString(" " + ts.String())
+	case int64:
+		fmt.Fprintf(sb, " %d", ts)
+	}
+	return sb.String(), nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
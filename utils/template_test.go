@@ -0,0 +1,87 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestCreateTemplate(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		input string
+		want  string
+	}{
+		{
+			name:  "sprig",
+			text:  `{{ .name | upper | trunc 5 }}-{{ "InOctets" | snakecase }}`,
+			input: `{"name": "interfaces"}`,
+			want:  "INTER-in_octets",
+		},
+		{
+			name:  "gomplate",
+			text:  `{{ strings.ToUpper .name }}`,
+			input: `{"name": "interfaces"}`,
+			want:  "INTERFACES",
+		},
+		{
+			name:  "pathElem",
+			text:  `{{ pathElem 0 .path }}/{{ .path | pathElem -1 }}/{{ .path | pathElem 10 }}`,
+			input: `{"path": "srl:/interface[name=ethernet-1/1]/statistics/in-octets"}`,
+			want:  "interface/in-octets/",
+		},
+		{
+			name:  "keyValue",
+			text:  `{{ .path | keyValue "name" }}|{{ .path | keyValue "index" }}|{{ .path | keyValue "vlan" }}`,
+			input: `{"path": "/interface[name=ethernet-1/1]/subinterface[index=0]/state"}`,
+			want:  "ethernet-1/1|0|",
+		},
+		{
+			name:  "stripOrigin",
+			text:  `{{ .p1 | stripOrigin }} {{ .p2 | stripOrigin }}`,
+			input: `{"p1": "openconfig:/interfaces/interface", "p2": "/interface[name=a:b]"}`,
+			want:  "/interfaces/interface /interface[name=a:b]",
+		},
+		{
+			name: "toLineProtocol",
+			text: `{{ toLineProtocol . }}`,
+			input: `[
+				{"name": "sub 1", "timestamp": 1000, "tags": {"source": "r1", "interface_name": "ethernet-1/1"}, "values": {"in-octets": 42, "oper-state": "up", "enabled": true}},
+				{"name": "sub1", "timestamp": 2000, "tags": {"source": "r1"}},
+				{"name": "sub1", "tags": {"source": "r2"}, "values": {"description": "a \"b\""}}
+			]`,
+			want: `sub\ 1,interface_name=ethernet-1/1,source=r1 enabled=true,in-octets=42,oper-state="up" 1000` + "\n" +
+				`sub1,source=r2 description="a \"b\""`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := CreateTemplate(tt.name, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var input interface{}
+			err = json.Unmarshal([]byte(tt.input), &input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := new(bytes.Buffer)
+			err = tpl.Execute(b, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("got %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// curveIDs maps the supported curve preferences names to their IDs.
+// The post-quantum hybrid key exchanges are listed by their IANA code point,
+// they are only negotiated if the Go version gnmic is built with implements them.
+var curveIDs = map[string]tls.CurveID{
+	"X25519":             tls.X25519,
+	"P256":               tls.CurveP256,
+	"P-256":              tls.CurveP256,
+	"P384":               tls.CurveP384,
+	"P-384":              tls.CurveP384,
+	"P521":               tls.CurveP521,
+	"P-521":              tls.CurveP521,
+	"X25519MLKEM768":     tls.CurveID(0x11ec),
+	"SecP256r1MLKEM768":  tls.CurveID(0x11eb),
+	"SecP384r1MLKEM1024": tls.CurveID(0x11ed),
+}
+
+// ParseTLSVersion converts a TLS version string, one of 1.0, 1.1, 1.2 or 1.3,
+// to its tls package value. An empty string returns 0.
+func ParseTLSVersion(v string) (uint16, error) {
+	switch v {
+	case "":
+		return 0, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.0", "1":
+		return tls.VersionTLS10, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version %q", v)
+}
+
+// ParseCipherSuites converts cipher suite names, e.g: TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+// to their IDs. The insecure cipher suites are accepted to reach legacy devices.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, cs := range tls.CipherSuites() {
+		known[cs.Name] = cs.ID
+	}
+	for _, cs := range tls.InsecureCipherSuites() {
+		known[cs.Name] = cs.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, n := range names {
+		id, ok := known[strings.ToUpper(n)]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite %q", n)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ParseCurvePreferences converts curve names, e.g: X25519, P256 or X25519MLKEM768,
+// to their IDs.
+func ParseCurvePreferences(names []string) ([]tls.CurveID, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	ids := make([]tls.CurveID, 0, len(names))
+	for _, n := range names {
+		id, ok := curveIDs[n]
+		if !ok {
+			return nil, fmt.Errorf("unknown curve %q", n)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// TLSPolicy restricts the TLS versions, cipher suites and key exchange curves
+// negotiated on a connection.
+type TLSPolicy struct {
+	// minimum and maximum TLS versions, one of 1.0, 1.1, 1.2 or 1.3
+	MinVersion string `mapstructure:"min-version,omitempty" json:"min-version,omitempty"`
+	MaxVersion string `mapstructure:"max-version,omitempty" json:"max-version,omitempty"`
+	// TLS 1.0 to 1.2 cipher suites names, in order of preference
+	CipherSuites []string `mapstructure:"cipher-suites,omitempty" json:"cipher-suites,omitempty"`
+	// key exchange curves names, in order of preference
+	CurvePreferences []string `mapstructure:"curve-preferences,omitempty" json:"curve-preferences,omitempty"`
+}
+
+// IsSet returns true if any of the policy fields is set.
+func (p *TLSPolicy) IsSet() bool {
+	return p != nil && (p.MinVersion != "" || p.MaxVersion != "" || len(p.CipherSuites) > 0 || len(p.CurvePreferences) > 0)
+}
+
+// Validate checks the policy values.
+func (p *TLSPolicy) Validate() error {
+	return p.Apply(new(tls.Config))
+}
+
+// Apply sets the policy on tlsConfig, the unset fields keep the tlsConfig values.
+// The cipher suites only apply to TLS 1.0 to 1.2, the TLS 1.3 suites are not configurable.
+func (p *TLSPolicy) Apply(tlsConfig *tls.Config) error {
+	if p == nil || tlsConfig == nil {
+		return nil
+	}
+	minVersion, err := ParseTLSVersion(p.MinVersion)
+	if err != nil {
+		return err
+	}
+	maxVersion, err := ParseTLSVersion(p.MaxVersion)
+	if err != nil {
+		return err
+	}
+	if minVersion > 0 && maxVersion > 0 && minVersion > maxVersion {
+		return fmt.Errorf("TLS min version %q is higher than max version %q", p.MinVersion, p.MaxVersion)
+	}
+	ciphers, err := ParseCipherSuites(p.CipherSuites)
+	if err != nil {
+		return err
+	}
+	curves, err := ParseCurvePreferences(p.CurvePreferences)
+	if err != nil {
+		return err
+	}
+	if minVersion > 0 {
+		tlsConfig.MinVersion = minVersion
+	}
+	if maxVersion > 0 {
+		tlsConfig.MaxVersion = maxVersion
+	}
+	if len(ciphers) > 0 {
+		tlsConfig.CipherSuites = ciphers
+	}
+	if len(curves) > 0 {
+		tlsConfig.CurvePreferences = curves
+	}
+	return nil
+}
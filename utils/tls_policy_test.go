@@ -0,0 +1,68 @@
+// © 2022 Nokia.
+//
+// This code is a Contribution to the gNMIc project (“Work”) made under the Google Software Grant and Corporate Contributor License Agreement (“CLA”) and governed by the Apache License 2.0.
+// No other rights or licenses in or to any of Nokia’s intellectual property are granted for any other purpose.
+// This code is provided on an “as is” basis without any warranties of any kind.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"crypto/tls"
+	"reflect"
+	"testing"
+)
+
+func TestTLSPolicyApply(t *testing.T) {
+	p := &TLSPolicy{
+		MinVersion:       "1.0",
+		MaxVersion:       "1.2",
+		CipherSuites:     []string{"TLS_RSA_WITH_AES_128_CBC_SHA", "tls_ecdhe_rsa_with_aes_128_gcm_sha256"},
+		CurvePreferences: []string{"X25519MLKEM768", "X25519", "P-256"},
+	}
+	c := &tls.Config{MinVersion: tls.VersionTLS12}
+	err := p.Apply(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.MinVersion != tls.VersionTLS10 || c.MaxVersion != tls.VersionTLS12 {
+		t.Errorf("unexpected versions: min=%x, max=%x", c.MinVersion, c.MaxVersion)
+	}
+	wantCiphers := []uint16{tls.TLS_RSA_WITH_AES_128_CBC_SHA, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	if !reflect.DeepEqual(c.CipherSuites, wantCiphers) {
+		t.Errorf("unexpected cipher suites: %v", c.CipherSuites)
+	}
+	wantCurves := []tls.CurveID{tls.CurveID(0x11ec), tls.X25519, tls.CurveP256}
+	if !reflect.DeepEqual(c.CurvePreferences, wantCurves) {
+		t.Errorf("unexpected curves: %v", c.CurvePreferences)
+	}
+
+	// unset fields keep the config values
+	c = &tls.Config{MinVersion: tls.VersionTLS12}
+	err = (&TLSPolicy{MaxVersion: "1.3"}).Apply(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.MinVersion != tls.VersionTLS12 || c.CipherSuites != nil {
+		t.Errorf("unexpected config: %+v", c)
+	}
+	// nil policy
+	var np *TLSPolicy
+	if err := np.Apply(c); err != nil || np.IsSet() {
+		t.Errorf("expected a nil policy to be a noop")
+	}
+}
+
+func TestTLSPolicyValidate(t *testing.T) {
+	for name, p := range map[string]*TLSPolicy{
+		"unknown_version": {MinVersion: "1.4"},
+		"min_above_max":   {MinVersion: "1.3", MaxVersion: "1.2"},
+		"unknown_cipher":  {CipherSuites: []string{"TLS_NULL"}},
+		"unknown_curve":   {CurvePreferences: []string{"P128"}},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}